@@ -3,11 +3,14 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/spf13/cobra"
 
+	"github.com/jamesainslie/dot/internal/cli/diff"
 	"github.com/jamesainslie/dot/internal/domain"
 	"github.com/jamesainslie/dot/internal/scanner"
 	"github.com/jamesainslie/dot/pkg/dot"
@@ -27,7 +30,28 @@ Package name can be auto-derived from the file name:
 
 Or explicitly specified:
   dot adopt dot-ssh .ssh      # Use package "dot-ssh"
-  dot adopt vim .vimrc .vim   # Adopt multiple files to "vim"`,
+  dot adopt vim .vimrc .vim   # Adopt multiple files to "vim"
+
+Files may be glob patterns relative to the target directory, where "~/"
+also stands for the target directory and "**" matches any number of
+directories. Quote patterns so the shell does not expand them. Matched
+files keep their path below the pattern's leading directories inside
+the package and are linked individually:
+  dot adopt nvim '~/.config/nvim/**'   # Every file below ~/.config/nvim
+  dot adopt git '.git*'                # .gitconfig, .gitignore, ...
+
+With --recursive, directories are adopted file by file in the same way
+instead of being moved and linked as a whole:
+  dot adopt --recursive nvim ~/.config/nvim
+
+When patterns or --recursive are used, the files to be moved are listed
+and confirmation is requested. Use --yes to skip the prompt.
+
+Files with other hard links, such as messages in a maildir, are reported
+with a warning because moving them can break the link with their other
+names. With --copy-hardlinks such files are copied into the package
+instead, and the original stays in place under its other names:
+  dot adopt --copy-hardlinks mail '~/Mail/**'`,
 		Args: argsWithUsage(cobra.MinimumNArgs(1)),
 		RunE: runAdopt,
 		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
@@ -35,13 +59,18 @@ Or explicitly specified:
 			// For explicit mode, first arg is package, rest are files
 			if len(args) == 0 {
 				// Could be package name or file - suggest both packages and files
-				return getAvailablePackages(), cobra.ShellCompDirectiveDefault
+				return packageCompletions(false, nil), cobra.ShellCompDirectiveDefault
 			}
 			// Subsequent arguments: complete with files
 			return nil, cobra.ShellCompDirectiveDefault
 		},
 	}
 
+	cmd.Flags().BoolP("recursive", "r", false, "Adopt the files below directories individually, keeping their structure")
+	cmd.Flags().BoolP("yes", "y", false, "Skip confirmation prompt")
+	cmd.Flags().Bool("copy-hardlinks", false, "Copy files with other hard links into the package instead of moving them")
+	addPlanFormatFlag(cmd)
+
 	return cmd
 }
 
@@ -52,6 +81,11 @@ func runAdopt(cmd *cobra.Command, args []string) error {
 		return formatError(err)
 	}
 
+	format, err := requestedPlanFormat(cmd, cfg.DryRun)
+	if err != nil {
+		return err
+	}
+
 	client, err := dot.NewClient(cfg)
 	if err != nil {
 		return formatError(err)
@@ -62,6 +96,12 @@ func runAdopt(cmd *cobra.Command, args []string) error {
 		ctx = context.Background()
 	}
 
+	recursive, _ := cmd.Flags().GetBool("recursive")
+	skipConfirm, _ := cmd.Flags().GetBool("yes")
+	copyHardlinks, _ := cmd.Flags().GetBool("copy-hardlinks")
+	extCfg, _ := loadConfigWithRepoPriority(getConfigFilePath())
+	opts := dot.AdoptOptions{CopyHardlinks: copyHardlinks, SkipPatterns: scanSkipPatterns(extCfg)}
+
 	var pkg string
 	var files []string
 
@@ -69,7 +109,7 @@ func runAdopt(cmd *cobra.Command, args []string) error {
 	if len(args) == 1 {
 		// Auto-naming mode: derive package from single file name
 		files = []string{args[0]}
-		pkg = derivePackageName(args[0])
+		pkg = derivePackageName(adoptPatternBase(args[0]))
 		if pkg == "" {
 			return fmt.Errorf("cannot derive package name from: %s", args[0])
 		}
@@ -79,15 +119,19 @@ func runAdopt(cmd *cobra.Command, args []string) error {
 	} else {
 		// Multiple args: could be explicit package name OR glob expansion
 		// Check if first arg looks like an existing file/directory
-		firstArgIsFile := fileExists(ctx, cfg.FS, args[0])
+		firstArgIsFile := fileExists(ctx, cfg.FS, args[0]) || isAdoptPattern(args[0])
 
 		if firstArgIsFile {
 			// Glob expansion mode: all args are files, derive package from common prefix
 			files = args
-			pkg = deriveCommonPackageName(args)
+			bases := make([]string, len(args))
+			for i, arg := range args {
+				bases[i] = adoptPatternBase(arg)
+			}
+			pkg = deriveCommonPackageName(bases)
 			if pkg == "" {
 				// Fall back to first file's name if no common prefix
-				pkg = derivePackageName(args[0])
+				pkg = derivePackageName(bases[0])
 			}
 			// Apply dotfile translation
 			pkg = scanner.UntranslateDotfile(pkg)
@@ -98,17 +142,168 @@ func runAdopt(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	if err := client.Adopt(ctx, files, pkg); err != nil {
+	if recursive {
+		for i, file := range files {
+			if !isAdoptPattern(file) {
+				files[i] = strings.TrimSuffix(file, "/") + "/**"
+			}
+		}
+	}
+
+	if format != "" {
+		plan, err := client.PlanAdoptWithOptions(ctx, opts, files, pkg)
+		if err != nil {
+			return formatError(err)
+		}
+		return renderPlan(cmd, format, plan)
+	}
+
+	plan, err := client.PlanAdoptWithOptions(ctx, opts, files, pkg)
+	if err != nil {
 		return formatError(err)
 	}
+	printPlanWarnings(cmd.ErrOrStderr(), plan)
+	if cfg.DryRun {
+		displayReplacements(cmd.OutOrStdout(), plan)
+	}
+
+	adoptedCount := len(files)
+	if expandsPatterns(files) {
+		moves := adoptMoves(plan)
+		adoptedCount = len(moves)
+
+		if cfg.DryRun || !skipConfirm {
+			displayAdoptSummary(cmd.OutOrStdout(), moves, pkg, cfg.TargetDir)
+		}
+		if !skipConfirm && !cfg.DryRun {
+			if !isTerminal(cmd) {
+				return fmt.Errorf("stdin is not a terminal; use --yes to confirm")
+			}
+			if !confirmAction(cmd, fmt.Sprintf("Adopt %d file(s) into %s?", len(moves), pkg)) {
+				fmt.Println("Operation cancelled")
+				return nil
+			}
+		}
+	}
 
 	if !cfg.DryRun {
-		fmt.Printf("Successfully adopted %d file(s) into %s\n", len(files), pkg)
+		if err := recoverInterrupted(cmd, client, ctx); err != nil {
+			return err
+		}
+	}
+
+	if err := client.AdoptWithOptions(ctx, opts, files, pkg); err != nil {
+		return formatError(err)
+	}
+
+	if !cfg.DryRun {
+		fmt.Printf("Successfully adopted %d file(s) into %s\n", adoptedCount, pkg)
 	}
 
 	return nil
 }
 
+// isAdoptPattern reports whether an adopt argument is a glob pattern.
+func isAdoptPattern(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// expandsPatterns reports whether any adopt argument is a glob pattern.
+func expandsPatterns(files []string) bool {
+	for _, file := range files {
+		if isAdoptPattern(file) {
+			return true
+		}
+	}
+	return false
+}
+
+// adoptPatternBase returns the directories of a glob pattern before its
+// first wildcard, which name the package when none is given.
+// "~/.config/nvim/**" → ".config/nvim"
+func adoptPatternBase(path string) string {
+	if !isAdoptPattern(path) {
+		return path
+	}
+	path = strings.TrimPrefix(path, "~/")
+	var literal []string
+	for _, part := range strings.Split(filepath.ToSlash(path), "/") {
+		if isAdoptPattern(part) {
+			break
+		}
+		literal = append(literal, part)
+	}
+	return filepath.Join(literal...)
+}
+
+// adoptMoves returns the paths of the files an adopt plan moves or, for
+// hard-linked files with --copy-hardlinks, copies into the package.
+func adoptMoves(plan dot.Plan) []string {
+	var moves []string
+	for _, op := range plan.Operations {
+		switch op := op.(type) {
+		case dot.FileMove:
+			moves = append(moves, op.Source.String())
+		case dot.FileBackup:
+			moves = append(moves, op.Source.String())
+		}
+	}
+	return moves
+}
+
+// printPlanWarnings writes the warnings recorded while planning, such as
+// hard-linked or special files.
+func printPlanWarnings(w io.Writer, plan dot.Plan) {
+	for _, planWarning := range plan.Metadata.Warnings {
+		fmt.Fprintf(w, "%s %s\n", warning("Warning:"), planWarning.Message)
+	}
+}
+
+// displayReplacements shows how each package file an adopt replaces would
+// change, so a dry run shows what the package would lose.
+func displayReplacements(w io.Writer, plan dot.Plan) {
+	for _, replacement := range plan.Metadata.Replacements {
+		fmt.Fprintf(w, "%s %s would be replaced by %s\n", warning("Replaces:"), replacement.Path, replacement.Replacement)
+		old, err := os.ReadFile(replacement.Path)
+		if err != nil {
+			continue
+		}
+		new, err := os.ReadFile(replacement.Replacement)
+		if err != nil {
+			continue
+		}
+		unified := diff.Unified(replacement.Path, replacement.Replacement, old, new)
+		if unified == "" {
+			continue
+		}
+		for _, line := range strings.Split(strings.TrimSuffix(unified, "\n"), "\n") {
+			switch {
+			case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+				line = dim(line)
+			case strings.HasPrefix(line, "@@"):
+				line = info(line)
+			case strings.HasPrefix(line, "-"):
+				line = errorText(line)
+			case strings.HasPrefix(line, "+"):
+				line = success(line)
+			}
+			fmt.Fprintf(w, "  %s\n", line)
+		}
+	}
+}
+
+// displayAdoptSummary lists the files an adopt will move into the package.
+func displayAdoptSummary(w io.Writer, moves []string, pkg, targetDir string) {
+	fmt.Fprintf(w, "This will move %s into %s:\n", accent(fmt.Sprintf("%d file(s)", len(moves))), bold(pkg))
+	for _, source := range moves {
+		if rel, err := filepath.Rel(targetDir, source); err == nil {
+			source = rel
+		}
+		fmt.Fprintf(w, "  %s %s\n", dim("•"), source)
+	}
+	fmt.Fprintln(w)
+}
+
 // fileExists checks if a path exists in the filesystem.
 func fileExists(ctx context.Context, fs domain.FS, path string) bool {
 	return fs.Exists(ctx, path)
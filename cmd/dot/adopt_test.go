@@ -165,3 +165,48 @@ func TestFileExists(t *testing.T) {
 		assert.False(t, fileExists(ctx, fs, ""))
 	})
 }
+
+func TestAdoptPatternBase(t *testing.T) {
+	assert.Equal(t, ".config/nvim", adoptPatternBase("~/.config/nvim/**"))
+	assert.Equal(t, ".config", adoptPatternBase(".config/*.toml"))
+	assert.Equal(t, "", adoptPatternBase(".git*"))
+	assert.Equal(t, ".vimrc", adoptPatternBase(".vimrc"))
+}
+
+func TestAdoptCommand_Recursive(t *testing.T) {
+	tmpDir := t.TempDir()
+	packageDir := filepath.Join(tmpDir, "packages")
+	targetDir := filepath.Join(tmpDir, "target")
+	nvimDir := filepath.Join(targetDir, ".config", "nvim")
+
+	require.NoError(t, os.MkdirAll(packageDir, 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(nvimDir, "lua"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(nvimDir, "init.lua"), []byte("init"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(nvimDir, "lua", "opts.lua"), []byte("opts"), 0644))
+
+	globalCfg = globalConfig{
+		packageDir: packageDir,
+		targetDir:  targetDir,
+	}
+
+	// Without --yes the listed files need confirmation from a terminal
+	cmd := newAdoptCommand()
+	cmd.SetContext(context.Background())
+	cmd.SetArgs([]string{"--recursive", "nvim", ".config/nvim"})
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "use --yes to confirm")
+	assert.FileExists(t, filepath.Join(nvimDir, "init.lua"))
+
+	cmd = newAdoptCommand()
+	cmd.SetContext(context.Background())
+	cmd.SetArgs([]string{"--recursive", "--yes", "nvim", ".config/nvim"})
+	require.NoError(t, cmd.Execute())
+
+	link, err := os.Readlink(filepath.Join(nvimDir, "lua", "opts.lua"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(packageDir, "nvim", "lua", "opts.lua"), link)
+	info, err := os.Lstat(nvimDir)
+	require.NoError(t, err)
+	assert.True(t, info.IsDir(), "directory is kept, files are linked individually")
+}
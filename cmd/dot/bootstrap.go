@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// newBootstrapCommand creates the bootstrap command group.
+func newBootstrapCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bootstrap",
+		Short: "Check bootstrap configuration files",
+		Long: `Check the .dotbootstrap.yaml of a dotfiles repository before it is
+published, so mistakes are caught by its author rather than by users
+during clone.
+
+To generate a bootstrap configuration from the current installation, use
+'dot clone bootstrap'.`,
+		Example: `  # Check the bootstrap configuration of the package directory
+  dot bootstrap validate
+
+  # Write the JSON Schema for editor validation
+  dot bootstrap schema > .dotbootstrap.schema.json`,
+		Args: argsWithUsage(cobra.NoArgs),
+	}
+
+	cmd.AddCommand(
+		newBootstrapValidateCommand(),
+		newBootstrapSchemaCommand(),
+	)
+
+	return cmd
+}
+
+// newBootstrapValidateCommand creates the validate subcommand.
+func newBootstrapValidateCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate [FILE]",
+		Short: "Check a bootstrap configuration file for errors",
+		Long: `Check a bootstrap configuration file, by default the .dotbootstrap.yaml
+of the package directory.
+
+Every problem is reported with its line: unsupported versions, fields
+that are not part of the configuration and would be ignored, package
+names that are empty or duplicated, invalid platforms and conflict
+policies, and profiles that reference undefined packages or do not
+exist. The command fails if any problem is found, so it can run in CI.`,
+		Example: `  # Check the package directory's configuration
+  dot bootstrap validate
+
+  # Check a file before committing it
+  dot bootstrap validate ~/dotfiles/.dotbootstrap.yaml`,
+		Args: argsWithUsage(cobra.MaximumNArgs(1)),
+		RunE: runBootstrapValidate,
+	}
+}
+
+// runBootstrapValidate handles the bootstrap validate command execution.
+func runBootstrapValidate(cmd *cobra.Command, args []string) error {
+	cfg, err := buildConfigWithCmd(cmd)
+	if err != nil {
+		return formatError(err)
+	}
+
+	client, err := dot.NewClient(cfg)
+	if err != nil {
+		return formatError(err)
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var path string
+	if len(args) > 0 {
+		if path, err = filepath.Abs(args[0]); err != nil {
+			return fmt.Errorf("resolve %s: %w", args[0], err)
+		}
+	}
+
+	result, err := client.ValidateBootstrap(ctx, path)
+	if err != nil {
+		return formatError(err)
+	}
+
+	if !globalCfg.quiet || len(result.Problems) > 0 {
+		renderBootstrapValidation(cmd.OutOrStdout(), result)
+	}
+	if len(result.Problems) > 0 {
+		return fmt.Errorf("bootstrap configuration has %d problem(s)", len(result.Problems))
+	}
+	return nil
+}
+
+// renderBootstrapValidation prints the problems found in a bootstrap
+// configuration file.
+func renderBootstrapValidation(w io.Writer, result dot.BootstrapValidation) {
+	if len(result.Problems) == 0 {
+		fmt.Fprintf(w, "%s %s is valid\n", success("✓"), result.Path)
+		return
+	}
+
+	fmt.Fprintf(w, "%s\n", bold(result.Path))
+	for _, problem := range result.Problems {
+		location := ""
+		if problem.Line > 0 {
+			location = dim(fmt.Sprintf("line %d: ", problem.Line))
+		}
+		if problem.Field != "" {
+			location += accent(problem.Field) + ": "
+		}
+		fmt.Fprintf(w, "  %s %s%s\n", errorText("✗"), location, problem.Message)
+	}
+}
+
+// newBootstrapSchemaCommand creates the schema subcommand.
+func newBootstrapSchemaCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON Schema of bootstrap configuration files",
+		Long: `Print the JSON Schema of .dotbootstrap.yaml files.
+
+Editors with a YAML language server check the configuration while it is
+written when the file names the schema in its first line:
+
+  # yaml-language-server: $schema=./.dotbootstrap.schema.json
+
+The schema covers the fields and their values. Checks that need the whole
+file, such as profiles referencing defined packages, are made by
+'dot bootstrap validate'.`,
+		Example: `  # Save the schema next to the configuration
+  dot bootstrap schema > ~/dotfiles/.dotbootstrap.schema.json`,
+		Args: argsWithUsage(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, err := cmd.OutOrStdout().Write(dot.BootstrapSchema())
+			return err
+		},
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// runBootstrapCommand runs dot with args against a package directory whose
+// .dotbootstrap.yaml holds config and returns the output.
+func runBootstrapCommand(t *testing.T, config string, args ...string) (string, error) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	packageDir := filepath.Join(tmpDir, "packages")
+	targetDir := filepath.Join(tmpDir, "target")
+	require.NoError(t, os.MkdirAll(packageDir, 0755))
+	require.NoError(t, os.MkdirAll(targetDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(packageDir, ".dotbootstrap.yaml"), []byte(config), 0644))
+
+	rootCmd := NewRootCommand("test", "none", "unknown")
+	rootCmd.SetContext(context.Background())
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs(append([]string{"--dir", packageDir, "--target", targetDir}, args...))
+
+	err := rootCmd.Execute()
+	return buf.String(), err
+}
+
+func TestBootstrapValidateCommand(t *testing.T) {
+	out, err := runBootstrapCommand(t, "version: \"1.0\"\npackages:\n  - name: dot-vim\n", "bootstrap", "validate")
+	require.NoError(t, err)
+	assert.Contains(t, out, "is valid")
+
+	out, err = runBootstrapCommand(t, "version: \"1.0\"\npackages:\n  - name: dot-vim\n    platform: [macos]\n", "bootstrap", "validate")
+	assert.ErrorContains(t, err, "1 problem(s)")
+	assert.Contains(t, out, "line 4")
+	assert.Contains(t, out, `invalid platform "macos"`)
+
+	_, err = runBootstrapCommand(t, "", "bootstrap", "validate", filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestBootstrapSchemaCommand(t *testing.T) {
+	out, err := runBootstrapCommand(t, "", "bootstrap", "schema")
+	require.NoError(t, err)
+	assert.True(t, json.Valid([]byte(out)))
+}
+
+func TestRenderBootstrapValidation(t *testing.T) {
+	var buf bytes.Buffer
+	renderBootstrapValidation(&buf, dot.BootstrapValidation{
+		Path: "/dotfiles/.dotbootstrap.yaml",
+		Problems: []dot.BootstrapProblem{
+			{Line: 3, Field: "packages[0].name", Message: "package name cannot be empty"},
+			{Message: "at least one package is required"},
+		},
+	})
+	out := buf.String()
+	assert.Contains(t, out, "/dotfiles/.dotbootstrap.yaml")
+	assert.Contains(t, out, "line 3: ")
+	assert.Contains(t, out, "packages[0].name: package name cannot be empty")
+	assert.Contains(t, out, "at least one package is required")
+}
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"bytes"
 	"os"
+	"os/user"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -127,3 +130,168 @@ func TestBuildConfig_BackupDirFlag(t *testing.T) {
 
 	assert.Contains(t, cfg.BackupDir, "backups")
 }
+
+func TestBuildConfig_OfflineFlag(t *testing.T) {
+	previous := globalCfg
+	t.Cleanup(func() {
+		globalCfg = previous
+	})
+
+	globalCfg = globalConfig{
+		packageDir: ".",
+		targetDir:  t.TempDir(),
+		offline:    true,
+	}
+
+	cfg, err := buildConfig()
+	require.NoError(t, err)
+
+	assert.True(t, cfg.Offline)
+}
+
+func TestBuildConfig_GitNetworkSettings(t *testing.T) {
+	previous := globalCfg
+	t.Cleanup(func() {
+		globalCfg = previous
+	})
+
+	t.Setenv("DOT_GIT_TIMEOUT", "45s")
+	t.Setenv("DOT_GIT_PROXY", "http://proxy.example.com:3128")
+	t.Setenv("DOT_GIT_INSECURE_SKIP_TLS", "true")
+
+	globalCfg = globalConfig{
+		packageDir: ".",
+		targetDir:  t.TempDir(),
+	}
+
+	cfg, err := buildConfig()
+	require.NoError(t, err)
+
+	assert.Equal(t, 45*time.Second, cfg.GitTimeout)
+	assert.Equal(t, "http://proxy.example.com:3128", cfg.GitProxy)
+	assert.True(t, cfg.GitInsecureSkipTLS)
+}
+
+func TestBuildConfig_SecretsSettings(t *testing.T) {
+	previous := globalCfg
+	t.Cleanup(func() {
+		globalCfg = previous
+	})
+
+	t.Setenv("DOT_SECRETS_PROVIDER", "op")
+	t.Setenv("DOT_SECRETS_VAULT", "Personal")
+
+	globalCfg = globalConfig{
+		packageDir: ".",
+		targetDir:  t.TempDir(),
+	}
+
+	cfg, err := buildConfig()
+	require.NoError(t, err)
+
+	assert.Equal(t, "op", cfg.SecretsProvider)
+	assert.Equal(t, "Personal", cfg.SecretsVault)
+}
+
+func TestBuildConfig_ScanCache(t *testing.T) {
+	previous := globalCfg
+	t.Cleanup(func() {
+		globalCfg = previous
+	})
+
+	cacheHome := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheHome)
+
+	globalCfg = globalConfig{
+		packageDir: ".",
+		targetDir:  t.TempDir(),
+	}
+
+	cfg, err := buildConfig()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(cacheHome, "dot", "scan"), cfg.ScanCacheDir)
+	assert.Equal(t, filepath.Join(cacheHome, "dot", "snapshots"), cfg.SnapshotDir)
+
+	globalCfg.noCache = true
+	cfg, err = buildConfig()
+	require.NoError(t, err)
+	assert.Empty(t, cfg.ScanCacheDir)
+	assert.Equal(t, filepath.Join(cacheHome, "dot", "snapshots"), cfg.SnapshotDir)
+}
+
+func TestBuildConfig_TargetUser(t *testing.T) {
+	previous, previousEUID := globalCfg, geteuid
+	t.Cleanup(func() {
+		globalCfg, geteuid = previous, previousEUID
+	})
+
+	current, err := user.Current()
+	require.NoError(t, err)
+	homeDir, _ := os.UserHomeDir()
+	globalCfg = globalConfig{
+		packageDir: ".",
+		targetDir:  homeDir,
+		targetUser: current.Username,
+	}
+
+	geteuid = func() int { return 1000 }
+	_, err = buildConfig()
+	assert.ErrorContains(t, err, "--target-user requires running as root")
+
+	geteuid = func() int { return 0 }
+	cfg, err := buildConfig()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Clean(current.HomeDir), cfg.TargetDir)
+	assert.Equal(t, filepath.Join(current.HomeDir, ".local", "state", "dot"), cfg.ManifestDir)
+
+	// An explicit target directory is kept
+	target := t.TempDir()
+	globalCfg.targetDir = target
+	cfg, err = buildConfig()
+	require.NoError(t, err)
+	assert.Equal(t, target, cfg.TargetDir)
+
+	globalCfg.targetUser = "no-such-user-dot-test"
+	_, err = buildConfig()
+	assert.ErrorContains(t, err, "--target-user")
+}
+
+func TestLoadConfigWithRepoPriority_ProjectConfig(t *testing.T) {
+	previous := globalCfg
+	t.Cleanup(func() {
+		globalCfg = previous
+	})
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	repo := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(repo, ".dotrc.yaml"), []byte("dotfile:\n  prefix: _\n"), 0644))
+	globalCfg = globalConfig{packageDir: repo}
+
+	cfg, err := loadConfigWithRepoPriority(getConfigFilePath())
+	require.NoError(t, err)
+	assert.Equal(t, "_", cfg.Dotfile.Prefix)
+}
+
+func TestLoadConfigWithRepoPriority_ConvertedRepoConfig(t *testing.T) {
+	previous := globalCfg
+	t.Cleanup(func() {
+		globalCfg = previous
+	})
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	repo := t.TempDir()
+	repoConfigPath := filepath.Join(repo, ".config", "dot", "config.yaml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(repoConfigPath), 0755))
+	require.NoError(t, os.WriteFile(repoConfigPath, []byte("dotfile:\n  prefix: _\n"), 0644))
+	globalCfg = globalConfig{packageDir: repo}
+
+	cmd := newConfigCommand()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetArgs([]string{"convert", repoConfigPath, "--to", "json"})
+	require.NoError(t, cmd.Execute())
+	require.FileExists(t, filepath.Join(filepath.Dir(repoConfigPath), "config.json"))
+
+	cfg, err := loadConfigWithRepoPriority(getConfigFilePath())
+	require.NoError(t, err)
+	assert.Equal(t, "_", cfg.Dotfile.Prefix)
+}
@@ -1,9 +1,14 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/jamesainslie/dot/pkg/dot"
 	"github.com/spf13/cobra"
@@ -16,6 +21,16 @@ func newCloneCommand() *cobra.Command {
 		cloneInteractive bool
 		cloneForce       bool
 		cloneBranch      string
+		cloneFullHistory bool
+		machineBranch    string
+		cloneMirrors     []string
+		attemptTimeout   time.Duration
+		clonePackages    []string
+		cloneLazy        bool
+		cloneResume      bool
+		cloneCleanup     bool
+		cloneBundle      string
+		output           cloneOutput
 	)
 
 	cmd := &cobra.Command{
@@ -29,6 +44,7 @@ The clone command performs the following steps:
   3. Detects and uses repository configuration (.config/dot/config.yaml)
   4. Loads optional .dotbootstrap.yaml for package selection
   5. Selects packages to install:
+     - As listed (--packages)
      - Via named profile (--profile)
      - Interactively (--interactive or automatic terminal detection)
      - All packages (non-interactive mode)
@@ -36,6 +52,49 @@ The clone command performs the following steps:
   7. Installs selected packages
   8. Updates manifest with repository tracking
 
+History:
+  Only the latest commit is cloned by default. Use --full-history to clone
+  every commit, or run 'dot repo unshallow' later to fetch the rest.
+
+Machine Branches:
+  --machine-branch checks out a branch for this machine's own commits.
+  An existing remote branch of that name is tracked; otherwise the branch
+  starts at the cloned branch. 'dot sync' then rebases the machine branch
+  onto the cloned branch. Full history is always cloned with a machine
+  branch.
+
+Sparse Checkout:
+  --packages installs only the listed packages and checks out only their
+  directories, .config/dot and the files at the repository root. This
+  keeps large monorepos small on disk. Running 'dot manage' on a package
+  that is not checked out adds its directory to the checkout.
+
+  --lazy checks out no package directories at all. Packages are selected
+  from the git index as usual, and each package is checked out when it is
+  first managed, starting with the packages the clone installs.
+
+Interrupted Clones:
+  If a clone is interrupted after the repository was cloned but before its
+  packages were installed, running the same clone again finds the partial
+  clone. On a terminal it offers to resume or to clean up; otherwise pass
+  --resume to select and install packages from the existing clone, or
+  --cleanup to remove it and clone again. A partial clone with uncommitted
+  changes or local commits is never removed.
+
+Offline Installs:
+  --from-bundle installs from a bundle written by 'dot export' instead of
+  cloning, so no network access is needed. The bundle is extracted into
+  the package directory and packages are selected as above, except that
+  without --packages, --profile or --interactive the packages that were
+  installed on the exporting machine are installed.
+
+Summary:
+  After cloning, a summary shows the repository, branch and commit, the
+  packages installed and those skipped with the reason (platform, profile,
+  not requested or deselected), any conflicts, and suggested next steps.
+  --format json prints the summary as JSON instead, and --summary-file
+  also writes it as JSON to a file. Both include failed clones.
+
 Repository Configuration:
   If the repository contains .config/dot/config.yaml, it will be used
   automatically for all subsequent dot commands. This allows repositories
@@ -76,10 +135,62 @@ Examples:
   dot clone https://github.com/user/dotfiles --force
 
   # Clone via SSH
-  dot clone git@github.com:user/dotfiles.git`,
-		Args: argsWithUsage(cobra.ExactArgs(1)),
+  dot clone git@github.com:user/dotfiles.git
+
+  # Keep this machine's changes on a branch named after the host
+  dot clone https://github.com/user/dotfiles --machine-branch "$(hostname -s)"
+
+  # Use an explicit machine branch based on develop
+  dot clone https://github.com/user/dotfiles --branch develop --machine-branch work-laptop
+
+  # Check out and install only two packages of a large repository
+  dot clone https://github.com/user/dotfiles --packages vim,zsh
+
+  # Check out packages only once they are managed
+  dot clone https://github.com/user/dotfiles --lazy --profile minimal
+
+  # Clone with full history for bisecting
+  dot clone https://github.com/user/dotfiles --full-history
+
+  # Finish a clone that was interrupted before installing packages
+  dot clone https://github.com/user/dotfiles --resume
+
+  # Fall back to a mirror if the primary host is unreachable
+  dot clone https://github.com/user/dotfiles --mirror https://gitlab.com/user/dotfiles --attempt-timeout 1m
+
+  # Install on an air-gapped machine from a bundle written by 'dot export'
+  dot clone --from-bundle dotfiles.tar.gz
+
+  # Keep a machine-readable summary for provisioning logs
+  dot clone https://github.com/user/dotfiles --summary-file /var/log/dot-clone.json`,
+		Args: argsWithUsage(func(cmd *cobra.Command, args []string) error {
+			if cloneBundle != "" {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		}),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runClone(cmd, args, cloneProfile, cloneInteractive, cloneForce, cloneBranch)
+			if output.format != "text" && output.format != "json" {
+				return fmt.Errorf("invalid format %q: use text or json", output.format)
+			}
+			opts := dot.CloneOptions{
+				Profile:        cloneProfile,
+				Interactive:    cloneInteractive,
+				Force:          cloneForce,
+				Branch:         cloneBranch,
+				FullHistory:    cloneFullHistory,
+				MachineBranch:  machineBranch,
+				Mirrors:        cloneMirrors,
+				AttemptTimeout: attemptTimeout,
+				Packages:       clonePackages,
+				Lazy:           cloneLazy,
+				Resume:         cloneResume,
+				Cleanup:        cloneCleanup,
+			}
+			if cloneBundle != "" {
+				return runCloneBundle(cmd, cloneBundle, opts, output)
+			}
+			return runClone(cmd, args, opts, output)
 		},
 		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			return nil, cobra.ShellCompDirectiveNoFileComp
@@ -90,6 +201,18 @@ Examples:
 	cmd.Flags().BoolVar(&cloneInteractive, "interactive", false, "interactively select packages")
 	cmd.Flags().BoolVar(&cloneForce, "force", false, "overwrite package directory if exists")
 	cmd.Flags().StringVar(&cloneBranch, "branch", "", "branch to clone (defaults to repository default)")
+	cmd.Flags().BoolVar(&cloneFullHistory, "full-history", false, "clone all commits instead of only the latest")
+	cmd.Flags().StringVar(&machineBranch, "machine-branch", "", "check out a per-machine branch that sync rebases onto the cloned branch")
+	cmd.Flags().StringArrayVar(&cloneMirrors, "mirror", nil, "fallback repository URL, tried in order after git.mirrors (repeatable)")
+	cmd.Flags().DurationVar(&attemptTimeout, "attempt-timeout", 0, "time limit for each clone attempt (0 = no limit)")
+	cmd.Flags().StringSliceVar(&clonePackages, "packages", nil, "install only these packages and check out only their directories")
+	cmd.Flags().BoolVar(&cloneLazy, "lazy", false, "check out package directories only when they are first managed")
+	cmd.Flags().BoolVar(&cloneResume, "resume", false, "install packages from the partial clone of an interrupted clone")
+	cmd.Flags().BoolVar(&cloneCleanup, "cleanup", false, "remove the partial clone of an interrupted clone and clone again")
+	cmd.Flags().StringVar(&cloneBundle, "from-bundle", "", "install from a bundle written by 'dot export' instead of cloning")
+	cmd.Flags().StringVar(&output.format, "format", "text", "summary format (text, json)")
+	cmd.Flags().StringVar(&output.summaryFile, "summary-file", "", "also write the summary as JSON to this file")
+	cmd.MarkFlagsMutuallyExclusive("resume", "cleanup")
 
 	// Add bootstrap subcommand
 	cmd.AddCommand(newCloneBootstrapCommand())
@@ -97,8 +220,14 @@ Examples:
 	return cmd
 }
 
+// cloneOutput selects how the post-clone summary is reported.
+type cloneOutput struct {
+	format      string
+	summaryFile string
+}
+
 // runClone handles the clone command execution.
-func runClone(cmd *cobra.Command, args []string, profile string, interactive bool, force bool, branch string) error {
+func runClone(cmd *cobra.Command, args []string, opts dot.CloneOptions, output cloneOutput) error {
 	repoURL := args[0]
 
 	// Build config
@@ -119,29 +248,162 @@ func runClone(cmd *cobra.Command, args []string, profile string, interactive boo
 		ctx = context.Background()
 	}
 
-	// Build clone options
-	opts := dot.CloneOptions{
-		Profile:     profile,
-		Interactive: interactive,
-		Force:       force,
-		Branch:      branch,
+	// Configured mirrors are tried before those given on the command line
+	if extCfg, _ := loadConfigWithRepoPriority(getConfigFilePath()); extCfg != nil {
+		opts.Mirrors = append(append([]string{}, extCfg.Git.Mirrors...), opts.Mirrors...)
+	}
+	if len(opts.Mirrors) > 0 {
+		client.Events().Subscribe(cloneAttemptReporter(cmd.ErrOrStderr(), args[0]), dot.TopicProgress, dot.TopicWarning)
 	}
 
-	// Execute clone
-	if err := client.Clone(ctx, repoURL, opts); err != nil {
-		return formatCloneError(err)
+	// Execute clone, offering to recover from an interrupted one
+	report, err := client.Clone(ctx, repoURL, opts)
+	var partial dot.ErrPartialClone
+	if errors.As(err, &partial) && partial.Reason == "" && isTerminal(cmd) {
+		switch promptPartialClone(cmd.ErrOrStderr(), cmd.InOrStdin(), partial) {
+		case partialCloneResume:
+			opts.Resume = true
+		case partialCloneCleanup:
+			opts.Cleanup = true
+		default:
+			return formatCloneError(err)
+		}
+		report, err = client.Clone(ctx, repoURL, opts)
+	}
+
+	return reportClone(cmd, report, err, output)
+}
+
+// reportClone reports the summary of a clone that ended with err and
+// returns err formatted for display. The text summary is shown after
+// successful clones and clones that failed on conflicts, while JSON
+// summaries are written for every clone.
+func reportClone(cmd *cobra.Command, report dot.CloneReport, err error, output cloneOutput) error {
+	summary := newCloneSummary(report, err)
+	if output.summaryFile != "" {
+		if writeErr := writeCloneSummaryFile(output.summaryFile, summary); writeErr != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "%s %v\n", warning("Warning:"), writeErr)
+		}
+	}
+
+	switch {
+	case output.format == "json":
+		if writeErr := writeCloneSummary(cmd.OutOrStdout(), summary, output.format); writeErr != nil {
+			return writeErr
+		}
+	case err == nil && !globalCfg.quiet, len(report.Conflicts) > 0:
+		renderCloneSummary(cmd.OutOrStdout(), summary)
 	}
 
+	if err != nil {
+		return formatCloneError(err)
+	}
 	return nil
 }
 
+// gitCloneFlags lists the clone flags that have no meaning for bundles.
+var gitCloneFlags = []string{"branch", "full-history", "machine-branch", "mirror", "attempt-timeout", "lazy", "resume", "cleanup"}
+
+// runCloneBundle handles the clone command execution with --from-bundle.
+func runCloneBundle(cmd *cobra.Command, bundle string, opts dot.CloneOptions, output cloneOutput) error {
+	for _, name := range gitCloneFlags {
+		if cmd.Flags().Changed(name) {
+			return fmt.Errorf("--%s cannot be used with --from-bundle", name)
+		}
+	}
+
+	bundlePath, err := filepath.Abs(bundle)
+	if err != nil {
+		return fmt.Errorf("resolve %s: %w", bundle, err)
+	}
+
+	cfg, err := buildConfigWithCmd(cmd)
+	if err != nil {
+		return formatError(err)
+	}
+
+	client, err := dot.NewClient(cfg)
+	if err != nil {
+		return formatError(err)
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	report, err := client.CloneBundle(ctx, bundlePath, opts)
+	return reportClone(cmd, report, err, output)
+}
+
+// partialCloneChoice is how the user chose to handle an interrupted clone.
+type partialCloneChoice int
+
+const (
+	partialCloneAbort partialCloneChoice = iota
+	partialCloneResume
+	partialCloneCleanup
+)
+
+// promptPartialClone asks how to handle an interrupted clone.
+// Anything other than resume or clean up aborts.
+func promptPartialClone(w io.Writer, in io.Reader, partial dot.ErrPartialClone) partialCloneChoice {
+	fmt.Fprintf(w, "%s %s holds a clone that was interrupted before its packages were installed\n", warning("Warning:"), partial.Path)
+	fmt.Fprint(w, "Resume it, clean it up and clone again, or abort? [r/c/A]: ")
+	response, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && response == "" {
+		return partialCloneAbort
+	}
+
+	switch strings.ToLower(strings.TrimSpace(response)) {
+	case "r", "resume":
+		return partialCloneResume
+	case "c", "cleanup", "clean up":
+		return partialCloneCleanup
+	default:
+		return partialCloneAbort
+	}
+}
+
+// cloneAttemptReporter reports failed clone attempts and, when a mirror
+// was used, which one succeeded.
+func cloneAttemptReporter(w io.Writer, primary string) dot.EventHandler {
+	return func(ctx context.Context, event dot.Event) {
+		url, _ := event.Fields["url"].(string)
+		switch {
+		case event.Name == "clone_attempt_failed":
+			fmt.Fprintf(w, "%s %s\n", warning("Clone failed from"), url)
+		case event.Name == "clone_attempt_succeeded" && url != primary:
+			fmt.Fprintf(w, "%s %s\n", success("Cloned from mirror"), url)
+		}
+	}
+}
+
 // formatCloneError formats clone-specific errors with helpful messages.
 func formatCloneError(err error) error {
+	var offline dot.ErrOffline
+	if errors.As(err, &offline) {
+		return fmt.Errorf("%w\n\nRun without --offline once network access is available", offline)
+	}
+
+	var partial dot.ErrPartialClone
+	if errors.As(err, &partial) && partial.Reason != "" {
+		return fmt.Errorf("%w\n\nCommit or remove the changes, or use --resume to keep the clone", partial)
+	}
+	if errors.As(err, &partial) {
+		return fmt.Errorf("%w\n\nUse --resume to install packages from it, or --cleanup to remove it and clone again", partial)
+	}
+
 	var packageDirNotEmpty dot.ErrPackageDirNotEmpty
 	if errors.As(err, &packageDirNotEmpty) {
 		return fmt.Errorf("%w\n\nUse --force to overwrite the existing directory", packageDirNotEmpty)
 	}
 
+	var invalidBundle dot.ErrInvalidBundle
+	if errors.As(err, &invalidBundle) {
+		return fmt.Errorf("%w\n\nCreate bundles with 'dot export --output FILE'", invalidBundle)
+	}
+
 	var bootstrapNotFound dot.ErrBootstrapNotFound
 	if errors.As(err, &bootstrapNotFound) {
 		return fmt.Errorf("%w\n\nThe repository may not have been properly cloned", bootstrapNotFound)
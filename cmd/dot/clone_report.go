@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// cloneSummary is the post-clone summary: the clone report, the error that
+// ended the clone if any, and suggested next steps.
+type cloneSummary struct {
+	dot.CloneReport
+	Error     string   `json:"error,omitempty"`
+	NextSteps []string `json:"next_steps"`
+}
+
+// newCloneSummary builds the summary of a clone that ended with err.
+func newCloneSummary(report dot.CloneReport, err error) cloneSummary {
+	summary := cloneSummary{CloneReport: report, NextSteps: cloneNextSteps(report, err)}
+	if err != nil {
+		summary.Error = err.Error()
+	}
+	return summary
+}
+
+// cloneNextSteps suggests what to do after a clone.
+func cloneNextSteps(report dot.CloneReport, err error) []string {
+	steps := []string{}
+	if len(report.Conflicts) > 0 {
+		steps = append(steps,
+			"Move or back up the conflicting files, then run 'dot manage "+strings.Join(report.Selected, " ")+"'",
+			"Or run 'dot adopt' to move existing files into a package")
+		return steps
+	}
+	if err != nil {
+		return steps
+	}
+
+	if report.DryRun {
+		steps = append(steps, "Run again without --dry-run to install the packages")
+		return steps
+	}
+	if len(report.Installed) > 0 {
+		steps = append(steps,
+			"Run 'dot status' to review the installed packages",
+			"Run 'dot doctor' to check the links")
+	}
+
+	var installable []string
+	for _, skip := range report.Skipped {
+		if skip.Reason != dot.SkipPlatform {
+			installable = append(installable, skip.Name)
+		}
+	}
+	switch {
+	case len(installable) == 1:
+		steps = append(steps, "Run 'dot manage "+installable[0]+"' to install the skipped package")
+	case len(installable) > 1:
+		steps = append(steps, "Run 'dot manage <package>' to install a skipped package ("+strings.Join(installable, ", ")+")")
+	case len(report.Installed) == 0:
+		steps = append(steps, "Run 'dot manage <package>' to install packages")
+	}
+
+	if !report.Bundle {
+		steps = append(steps, "Run 'dot sync' later to pull changes to the repository")
+	}
+	return steps
+}
+
+// writeCloneSummary writes the summary in format, text or json.
+func writeCloneSummary(w io.Writer, summary cloneSummary, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(summary)
+	case "text":
+		renderCloneSummary(w, summary)
+		return nil
+	default:
+		return fmt.Errorf("invalid format %q: use text or json", format)
+	}
+}
+
+// writeCloneSummaryFile writes the summary as JSON to path.
+func writeCloneSummaryFile(path string, summary cloneSummary) error {
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("write clone summary: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("write clone summary: %w", err)
+	}
+	return nil
+}
+
+// renderCloneSummary renders the summary for a terminal.
+func renderCloneSummary(w io.Writer, summary cloneSummary) {
+	report := summary.CloneReport
+
+	verb := "Cloned"
+	switch {
+	case report.Bundle:
+		verb = "Extracted"
+	case report.Resumed:
+		verb = "Resumed clone of"
+	}
+	fmt.Fprintf(w, "%s %s\n", bold(verb), report.Repository)
+	if report.Branch != "" {
+		branch := report.Branch
+		if report.BaseBranch != "" {
+			branch += " (based on " + report.BaseBranch + ")"
+		}
+		if report.Commit != "" {
+			branch += " at " + shortSHA(report.Commit)
+		}
+		fmt.Fprintf(w, "  %-10s %s\n", dim("branch:"), branch)
+	}
+	fmt.Fprintf(w, "  %-10s %s\n", dim("packages:"), report.PackageDir)
+	fmt.Fprintf(w, "  %-10s %s\n", dim("target:"), report.TargetDir)
+	if report.Profile != "" {
+		fmt.Fprintf(w, "  %-10s %s\n", dim("profile:"), report.Profile)
+	}
+
+	fmt.Fprintln(w)
+	switch {
+	case len(report.Installed) > 0:
+		fmt.Fprintf(w, "%s %d package(s): %s\n", success("Installed"), len(report.Installed), accent(strings.Join(report.Installed, ", ")))
+	case report.DryRun && len(report.Selected) > 0:
+		fmt.Fprintf(w, "%s %d package(s): %s\n", info("Would install"), len(report.Selected), accent(strings.Join(report.Selected, ", ")))
+	case len(report.Selected) > 0:
+		fmt.Fprintf(w, "%s %s\n", errorText("Not installed:"), strings.Join(report.Selected, ", "))
+	case summary.Error == "":
+		fmt.Fprintf(w, "%s\n", warning("No packages installed"))
+	}
+
+	if len(report.Skipped) > 0 {
+		fmt.Fprintf(w, "%s %d package(s):\n", warning("Skipped"), len(report.Skipped))
+		width := 0
+		for _, skip := range report.Skipped {
+			width = max(width, len(skip.Name))
+		}
+		for _, skip := range report.Skipped {
+			reason := string(skip.Reason)
+			if skip.Detail != "" {
+				reason += ": " + skip.Detail
+			}
+			fmt.Fprintf(w, "  %-*s  %s\n", width, skip.Name, dim(reason))
+		}
+	}
+
+	if len(report.Conflicts) > 0 {
+		fmt.Fprintf(w, "%s %d:\n", errorText("Conflicts"), len(report.Conflicts))
+		for _, conflict := range report.Conflicts {
+			fmt.Fprintf(w, "  %s  %s\n", conflict.Path, dim(conflict.Reason))
+		}
+	}
+
+	if len(summary.NextSteps) > 0 {
+		fmt.Fprintf(w, "\n%s\n", bold("Next steps:"))
+		for _, step := range summary.NextSteps {
+			fmt.Fprintf(w, "  - %s\n", step)
+		}
+	}
+}
+
+// shortSHA abbreviates a commit SHA.
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
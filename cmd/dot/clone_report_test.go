@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+func sampleCloneReport() dot.CloneReport {
+	return dot.CloneReport{
+		Repository: "https://github.com/user/dotfiles",
+		Branch:     "laptop",
+		BaseBranch: "main",
+		Commit:     "0123456789abcdef",
+		PackageDir: "/home/user/.dotfiles",
+		TargetDir:  "/home/user",
+		Profile:    "minimal",
+		Selected:   []string{"vim", "zsh"},
+		Installed:  []string{"vim", "zsh"},
+		Skipped: []dot.SkippedPackage{
+			{Name: "karabiner", Reason: dot.SkipPlatform, Detail: "requires darwin"},
+			{Name: "tmux", Reason: dot.SkipProfile, Detail: "not in profile minimal"},
+		},
+		Conflicts: []dot.CloneConflict{},
+	}
+}
+
+func TestCloneNextSteps(t *testing.T) {
+	report := sampleCloneReport()
+	steps := cloneNextSteps(report, nil)
+	assert.Contains(t, steps, "Run 'dot status' to review the installed packages")
+	assert.Contains(t, steps, "Run 'dot manage tmux' to install the skipped package")
+	assert.Contains(t, steps, "Run 'dot sync' later to pull changes to the repository")
+
+	report.Bundle = true
+	assert.NotContains(t, cloneNextSteps(report, nil), "Run 'dot sync' later to pull changes to the repository")
+
+	report.DryRun = true
+	report.Installed = []string{}
+	assert.Equal(t, []string{"Run again without --dry-run to install the packages"}, cloneNextSteps(report, nil))
+
+	report.DryRun = false
+	report.Conflicts = []dot.CloneConflict{{Path: "/home/user/.vimrc", Reason: "file exists"}}
+	steps = cloneNextSteps(report, assert.AnError)
+	assert.Contains(t, steps[0], "'dot manage vim zsh'")
+
+	report.Conflicts = nil
+	assert.Empty(t, cloneNextSteps(report, assert.AnError))
+}
+
+func TestRenderCloneSummary(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, writeCloneSummary(&buf, newCloneSummary(sampleCloneReport(), nil), "text"))
+	out := buf.String()
+
+	assert.Contains(t, out, "Cloned https://github.com/user/dotfiles")
+	assert.Contains(t, out, "laptop (based on main) at 0123456")
+	assert.Contains(t, out, "minimal")
+	assert.Contains(t, out, "Installed 2 package(s): vim, zsh")
+	assert.Contains(t, out, "Skipped 2 package(s)")
+	assert.Contains(t, out, "karabiner  platform: requires darwin")
+	assert.Contains(t, out, "tmux       profile: not in profile minimal")
+	assert.Contains(t, out, "Next steps:")
+	assert.NotContains(t, out, "Conflicts")
+
+	report := sampleCloneReport()
+	report.Installed = []string{}
+	report.Conflicts = []dot.CloneConflict{{Path: "/home/user/.vimrc", Reason: "file exists"}}
+	buf.Reset()
+	renderCloneSummary(&buf, newCloneSummary(report, assert.AnError))
+	assert.Contains(t, buf.String(), "Not installed: vim, zsh")
+	assert.Contains(t, buf.String(), "Conflicts 1:")
+	assert.Contains(t, buf.String(), "/home/user/.vimrc")
+
+	assert.ErrorContains(t, writeCloneSummary(&buf, cloneSummary{}, "yaml"), "invalid format")
+}
+
+func TestWriteCloneSummary_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, writeCloneSummary(&buf, newCloneSummary(sampleCloneReport(), assert.AnError), "json"))
+
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, "https://github.com/user/dotfiles", decoded["repository"])
+	assert.Equal(t, "0123456789abcdef", decoded["commit"])
+	assert.Equal(t, []any{"vim", "zsh"}, decoded["installed"])
+	assert.Equal(t, "platform", decoded["skipped"].([]any)[0].(map[string]any)["reason"])
+	assert.Equal(t, assert.AnError.Error(), decoded["error"])
+	assert.Contains(t, decoded, "next_steps")
+	assert.Contains(t, decoded, "conflicts")
+}
+
+func TestCloneCommand_Summary(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	packageDir := filepath.Join(tmpDir, "packages")
+	targetDir := filepath.Join(tmpDir, "target")
+	require.NoError(t, os.MkdirAll(filepath.Join(packageDir, "vim"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(packageDir, "zsh"), 0755))
+	require.NoError(t, os.MkdirAll(targetDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(packageDir, "vim", "dot-vimrc"), []byte("set nocompatible"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(packageDir, "zsh", "dot-zshrc"), []byte("bindkey -v"), 0644))
+	_, err := runDot(t, "--dir", packageDir, "--target", targetDir, "manage", "vim")
+	require.NoError(t, err)
+	bundle := filepath.Join(tmpDir, "bundle.tar.gz")
+	_, err = runDot(t, "--dir", packageDir, "--target", targetDir, "export", "--output", bundle)
+	require.NoError(t, err)
+
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	newTargetDir := filepath.Join(tmpDir, "new-target")
+	require.NoError(t, os.MkdirAll(newTargetDir, 0755))
+	summaryFile := filepath.Join(tmpDir, "logs", "clone.json")
+	out, err := runDot(t, "--dir", filepath.Join(tmpDir, "new-packages"), "--target", newTargetDir,
+		"clone", "--from-bundle", bundle, "--format", "json", "--summary-file", summaryFile)
+	require.NoError(t, err)
+
+	var printed cloneSummary
+	require.NoError(t, json.Unmarshal([]byte(out), &printed))
+	assert.True(t, printed.Bundle)
+	assert.Equal(t, []string{"vim"}, printed.Installed)
+	require.Len(t, printed.Skipped, 1)
+	assert.Equal(t, "zsh", printed.Skipped[0].Name)
+	assert.Contains(t, printed.NextSteps, "Run 'dot manage zsh' to install the skipped package")
+
+	data, err := os.ReadFile(summaryFile)
+	require.NoError(t, err)
+	var written cloneSummary
+	require.NoError(t, json.Unmarshal(data, &written))
+	assert.Equal(t, printed, written)
+}
+
+func TestCloneCommand_InvalidFormat(t *testing.T) {
+	tmpDir := t.TempDir()
+	_, err := runDot(t, "--dir", filepath.Join(tmpDir, "packages"), "--target", tmpDir,
+		"clone", "https://github.com/user/dotfiles", "--format", "yaml")
+	assert.ErrorContains(t, err, `invalid format "yaml"`)
+}
@@ -1,11 +1,15 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"strings"
 	"testing"
 
 	"github.com/jamesainslie/dot/pkg/dot"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCloneCommand_Flags(t *testing.T) {
@@ -34,6 +38,23 @@ func TestCloneCommand_Flags(t *testing.T) {
 		assert.NotNil(t, flag)
 		assert.Equal(t, "string", flag.Value.Type())
 	})
+
+	t.Run("has full-history flag", func(t *testing.T) {
+		flag := cmd.Flags().Lookup("full-history")
+		assert.NotNil(t, flag)
+		assert.Equal(t, "bool", flag.Value.Type())
+	})
+
+	t.Run("has machine-branch flag", func(t *testing.T) {
+		flag := cmd.Flags().Lookup("machine-branch")
+		assert.NotNil(t, flag)
+		assert.Equal(t, "string", flag.Value.Type())
+	})
+
+	t.Run("has resume and cleanup flags", func(t *testing.T) {
+		assert.NotNil(t, cmd.Flags().Lookup("resume"))
+		assert.NotNil(t, cmd.Flags().Lookup("cleanup"))
+	})
 }
 
 func TestCloneCommand_Args(t *testing.T) {
@@ -52,6 +73,12 @@ func TestCloneCommand_Args(t *testing.T) {
 		err = cmd.Args(cmd, []string{"https://github.com/user/repo"})
 		assert.NoError(t, err)
 	})
+
+	t.Run("takes no argument with a bundle", func(t *testing.T) {
+		require.NoError(t, cmd.Flags().Set("from-bundle", "bundle.tar.gz"))
+		assert.NoError(t, cmd.Args(cmd, []string{}))
+		assert.Error(t, cmd.Args(cmd, []string{"https://github.com/user/repo"}))
+	})
 }
 
 func TestCloneCommand_Help(t *testing.T) {
@@ -83,6 +110,42 @@ func TestFormatCloneError_PackageDirNotEmpty(t *testing.T) {
 	assert.Contains(t, errMsg, "--force")
 }
 
+func TestFormatCloneError_PartialClone(t *testing.T) {
+	err := formatCloneError(dot.ErrPartialClone{Path: "/path/to/packages", URL: "https://example.com/dotfiles"})
+	assert.Contains(t, err.Error(), "interrupted clone")
+	assert.Contains(t, err.Error(), "--resume")
+	assert.Contains(t, err.Error(), "--cleanup")
+
+	err = formatCloneError(dot.ErrPartialClone{Path: "/path/to/packages", Reason: "it has uncommitted changes or local commits"})
+	assert.Contains(t, err.Error(), "not removed because")
+	assert.Contains(t, err.Error(), "Commit or remove the changes")
+}
+
+func TestPromptPartialClone(t *testing.T) {
+	tests := []struct {
+		input string
+		want  partialCloneChoice
+	}{
+		{"r\n", partialCloneResume},
+		{"Resume\n", partialCloneResume},
+		{"c\n", partialCloneCleanup},
+		{"cleanup\n", partialCloneCleanup},
+		{"\n", partialCloneAbort},
+		{"", partialCloneAbort},
+		{"x\n", partialCloneAbort},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.TrimSpace(tt.input), func(t *testing.T) {
+			var out bytes.Buffer
+			got := promptPartialClone(&out, strings.NewReader(tt.input), dot.ErrPartialClone{Path: "/dotfiles"})
+			assert.Equal(t, tt.want, got)
+			assert.Contains(t, out.String(), "/dotfiles")
+			assert.Contains(t, out.String(), "[r/c/A]")
+		})
+	}
+}
+
 func TestFormatCloneError_BootstrapNotFound(t *testing.T) {
 	err := dot.ErrBootstrapNotFound{Path: "/path/.dotbootstrap.yaml"}
 	formatted := formatCloneError(err)
@@ -143,3 +206,32 @@ func TestCloneCommand_Integration(t *testing.T) {
 	// Skipped in unit tests
 	t.Skip("requires integration test setup with test repository")
 }
+
+func TestCloneAttemptReporter(t *testing.T) {
+	var buf bytes.Buffer
+	report := cloneAttemptReporter(&buf, "https://github.com/user/dotfiles")
+
+	ctx := context.Background()
+	report(ctx, dot.Event{Topic: dot.TopicWarning, Name: "clone_attempt_failed", Fields: map[string]any{"url": "https://github.com/user/dotfiles"}, Err: errors.New("timeout")})
+	report(ctx, dot.Event{Topic: dot.TopicProgress, Name: "clone_attempt_succeeded", Fields: map[string]any{"url": "https://mirror.example.com/dotfiles"}})
+
+	out := buf.String()
+	assert.Contains(t, out, "Clone failed from https://github.com/user/dotfiles")
+	assert.Contains(t, out, "Cloned from mirror https://mirror.example.com/dotfiles")
+}
+
+func TestCloneAttemptReporter_PrimarySucceeds(t *testing.T) {
+	var buf bytes.Buffer
+	report := cloneAttemptReporter(&buf, "https://github.com/user/dotfiles")
+
+	report(context.Background(), dot.Event{Topic: dot.TopicProgress, Name: "clone_attempt_succeeded", Fields: map[string]any{"url": "https://github.com/user/dotfiles"}})
+	report(context.Background(), dot.Event{Topic: dot.TopicProgress, Name: "cloning_repository", Fields: map[string]any{"url": "https://github.com/user/dotfiles"}})
+
+	assert.Empty(t, buf.String())
+}
+
+func TestCloneCommand_MirrorFlags(t *testing.T) {
+	cmd := newCloneCommand()
+	assert.NotNil(t, cmd.Flags().Lookup("mirror"))
+	assert.NotNil(t, cmd.Flags().Lookup("attempt-timeout"))
+}
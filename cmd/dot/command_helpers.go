@@ -5,9 +5,14 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 
 	"github.com/spf13/cobra"
 
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/internal/cli/renderer"
+	"github.com/jamesainslie/dot/internal/config"
+	"github.com/jamesainslie/dot/internal/scanner"
 	"github.com/jamesainslie/dot/pkg/dot"
 )
 
@@ -36,6 +41,13 @@ func executePackageCommand(cmd *cobra.Command, args []string, fn packageCommandF
 
 	packages := args
 
+	if !cfg.DryRun {
+		if err := recoverInterrupted(cmd, client, ctx); err != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Error: %v\n", err)
+			return err
+		}
+	}
+
 	if err := fn(client, ctx, packages); err != nil {
 		fmt.Fprintf(cmd.ErrOrStderr(), "Error: %v\n", err)
 		return err
@@ -48,12 +60,57 @@ func executePackageCommand(cmd *cobra.Command, args []string, fn packageCommandF
 	return nil
 }
 
-// getAvailablePackages returns list of available packages from the package directory.
-func getAvailablePackages() []string {
-	packageDir := globalCfg.packageDir
-	if packageDir == "" {
-		packageDir = "."
+// addPlanFormatFlag registers the --format flag used to render dry-run plans.
+func addPlanFormatFlag(cmd *cobra.Command) {
+	cmd.Flags().StringP("format", "f", "text", "Plan output format with --dry-run (text, json, yaml, table)")
+}
+
+// requestedPlanFormat returns the --format value if it was set explicitly.
+// Only dry-run plans are rendered, so --format without --dry-run is an error.
+func requestedPlanFormat(cmd *cobra.Command, dryRun bool) (string, error) {
+	flag := cmd.Flags().Lookup("format")
+	if flag == nil || !flag.Changed {
+		return "", nil
+	}
+	if !dryRun {
+		return "", fmt.Errorf("--format requires --dry-run")
 	}
+	return flag.Value.String(), nil
+}
+
+// renderPlan writes plan to the command output in the given format,
+// honouring table_style from the configuration.
+func renderPlan(cmd *cobra.Command, format string, plan dot.Plan) error {
+	tableStyle := ""
+	if extCfg, _ := loadConfigWithRepoPriority(getConfigFilePath()); extCfg != nil {
+		tableStyle = extCfg.Output.TableStyle
+	}
+
+	rend, err := renderer.NewRenderer(format, true, tableStyle)
+	if err != nil {
+		return fmt.Errorf("invalid format: %w", err)
+	}
+	return rend.RenderPlan(cmd.OutOrStdout(), plan)
+}
+
+// scanSkipPatterns returns the directories orphan scans and adoption globs
+// skip: the defaults unless ignore.scan_defaults is off, followed by
+// ignore.scan_patterns. A nil extCfg means the defaults.
+func scanSkipPatterns(extCfg *config.ExtendedConfig) []string {
+	if extCfg == nil {
+		return dot.DefaultScanSkipPatterns()
+	}
+	var patterns []string
+	if extCfg.Ignore.ScanDefaults {
+		patterns = dot.DefaultScanSkipPatterns()
+	}
+	return append(patterns, extCfg.Ignore.ScanPatterns...)
+}
+
+// getAvailablePackages returns list of available packages from the package
+// directory, including packages a sparse checkout has not checked out yet.
+func getAvailablePackages() []string {
+	packageDir := completionPackageDir()
 
 	absDir, err := filepath.Abs(packageDir)
 	if err != nil {
@@ -72,11 +129,56 @@ func getAvailablePackages() []string {
 		}
 	}
 
+	for _, name := range sparsePackages(absDir) {
+		if !slices.Contains(packages, name) && !isHiddenOrIgnored(name) {
+			packages = append(packages, name)
+		}
+	}
+
 	return packages
 }
 
+// sparsePackages returns the directories tracked in the sparse checkout at
+// packageDir, or nil if it is not a sparse checkout.
+func sparsePackages(packageDir string) []string {
+	ctx := context.Background()
+	git := adapters.NewGoGitCloner()
+	if dirs, err := git.SparseDirectories(ctx, packageDir); err != nil || dirs == nil {
+		return nil
+	}
+	tracked, err := git.RepositoryDirectories(ctx, packageDir)
+	if err != nil {
+		return nil
+	}
+	return tracked
+}
+
+// completionPackageDir returns the package directory used for completion:
+// the --dir flag if given, otherwise the configured package directory.
+func completionPackageDir() string {
+	if globalCfg.packageDir != "" && globalCfg.packageDir != "." {
+		return globalCfg.packageDir
+	}
+	if cfg, err := buildConfigWithCmd(nil); err == nil && cfg.PackageDir != "" {
+		return cfg.PackageDir
+	}
+	return "."
+}
+
 // getInstalledPackages returns list of installed packages from the manifest.
 func getInstalledPackages() []string {
+	pkgList := listInstalledPackages()
+	packages := make([]string, 0, len(pkgList))
+	for _, pkg := range pkgList {
+		packages = append(packages, pkg.Name)
+	}
+
+	return packages
+}
+
+// listInstalledPackages returns the packages recorded in the manifest, or
+// nil if it cannot be read.
+func listInstalledPackages() []dot.PackageInfo {
 	cfg, err := buildConfigWithCmd(nil)
 	if err != nil {
 		return nil
@@ -87,18 +189,11 @@ func getInstalledPackages() []string {
 		return nil
 	}
 
-	ctx := context.Background()
-	pkgList, err := client.List(ctx)
+	pkgList, err := client.List(context.Background())
 	if err != nil {
 		return nil
 	}
-
-	packages := make([]string, 0, len(pkgList))
-	for _, pkg := range pkgList {
-		packages = append(packages, pkg.Name)
-	}
-
-	return packages
+	return pkgList
 }
 
 // isHiddenOrIgnored checks if a directory name should be ignored for completion.
@@ -124,14 +219,45 @@ func isHiddenOrIgnored(name string) bool {
 // If installed is true, completes with installed packages, otherwise available packages.
 func packageCompletion(installed bool) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
 	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-		var packages []string
-		if installed {
-			packages = getInstalledPackages()
-		} else {
-			packages = getAvailablePackages()
+		return packageCompletions(installed, args), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// packageCompletions returns the package names to complete, leaving out
+// those already given in args. Names carry a description where the shell
+// shows one: the link count of installed packages, and the target name of
+// packages whose "dot-" prefix maps to a dotfile.
+func packageCompletions(installed bool, args []string) []string {
+	given := make(map[string]bool, len(args))
+	for _, arg := range args {
+		given[arg] = true
+	}
+
+	completions := make([]string, 0)
+	if installed {
+		for _, pkg := range listInstalledPackages() {
+			if given[pkg.Name] {
+				continue
+			}
+			unit := "links"
+			if pkg.LinkCount == 1 {
+				unit = "link"
+			}
+			completions = append(completions, fmt.Sprintf("%s\t%d %s", pkg.Name, pkg.LinkCount, unit))
+		}
+		return completions
+	}
+
+	for _, name := range getAvailablePackages() {
+		if given[name] {
+			continue
+		}
+		if target := scanner.TranslatePackageName(name); target != name {
+			name += "\ttargets " + target
 		}
-		return packages, cobra.ShellCompDirectiveNoFileComp
+		completions = append(completions, name)
 	}
+	return completions
 }
 
 // derivePackageName derives a package name from a file or directory path.
@@ -2,13 +2,19 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"io"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
-	"github.com/jamesainslie/dot/internal/config"
 	"github.com/spf13/cobra"
+
+	"github.com/jamesainslie/dot/internal/config"
+	"github.com/jamesainslie/dot/pkg/dot"
 )
 
 // newConfigCommand creates the config command.
@@ -38,8 +44,28 @@ files, and managing configuration across sources.`,
   # Set configuration value
   dot config set directories.package ~/dotfiles
 
+  # Reset a value, or change a list
+  dot config unset logging.level
+  dot config add ignore.patterns "*.log"
+
+  # Edit the configuration file, checking it before it is saved
+  dot config edit
+
+  # List the available keys, or describe one
+  dot config keys
+  dot config explain symlinks.on_conflict
+
   # Show configuration file path
-  dot config path`,
+  dot config path
+
+  # Replace deprecated keys in the configuration file
+  dot config migrate
+
+  # Rewrite the configuration file in TOML
+  dot config convert --to toml
+
+  # Check the configured directories are usable
+  dot config check-env`,
 		RunE: runConfigList,
 	}
 
@@ -47,8 +73,17 @@ files, and managing configuration across sources.`,
 		newConfigInitCommand(),
 		newConfigGetCommand(),
 		newConfigSetCommand(),
+		newConfigUnsetCommand(),
+		newConfigAddCommand(),
+		newConfigRemoveCommand(),
+		newConfigEditCommand(),
 		newConfigListCommand(),
 		newConfigPathCommand(),
+		newConfigMigrateCommand(),
+		newConfigConvertCommand(),
+		newConfigCheckEnvCommand(),
+		newConfigKeysCommand(),
+		newConfigExplainCommand(),
 	)
 
 	return cmd
@@ -66,9 +101,8 @@ func getConfigFilePath() string {
 		return path
 	}
 
-	// Use XDG config directory with default filename
-	configDir := config.GetConfigPath("dot")
-	return filepath.Join(configDir, "config.yaml")
+	// Use the file in the XDG config directory, in any format
+	return config.FindConfigFile(config.GetConfigPath("dot"))
 }
 
 // newConfigInitCommand creates the init subcommand.
@@ -195,10 +229,13 @@ func runConfigGet(key string) error {
 
 	loader := config.NewLoader("dot", configPath)
 	cfg, err := loader.LoadWithEnv()
+	warnDeprecations(os.Stderr, loader.Deprecations())
 	if err != nil {
 		return fmt.Errorf("load config: %w", err)
 	}
 
+	key = canonicalConfigKey(key)
+
 	value, err := getConfigValue(cfg, key)
 	if err != nil {
 		return err
@@ -208,56 +245,49 @@ func runConfigGet(key string) error {
 	return nil
 }
 
+// canonicalConfigKey returns the key replacing a deprecated key, warning
+// about the rename.
+func canonicalConfigKey(key string) string {
+	canonical, deprecated := config.CanonicalKey(key)
+	if deprecated && !globalCfg.quiet {
+		fmt.Fprintf(os.Stderr, "%s config key %s is deprecated; use %s\n", warning("Warning:"), key, canonical)
+	}
+	return canonical
+}
+
 // getValidConfigKeys returns all valid configuration keys for completion.
 func getValidConfigKeys() []string {
-	return []string{
-		"directories.package",
-		"directories.target",
-		"directories.manifest",
-		"logging.level",
-		"logging.format",
-		"logging.destination",
-		"symlinks.mode",
-		"symlinks.backup_suffix",
-		"symlinks.backup_dir",
-		"dotfile.prefix",
-		"output.format",
-		"output.color",
-		"packages.sort_by",
+	schema := config.Schema()
+	keys := make([]string, 0, len(schema))
+	for _, spec := range schema {
+		keys = append(keys, spec.Key)
 	}
+	return keys
 }
 
 // getConfigValue retrieves a value from config by key path.
 func getConfigValue(cfg *config.ExtendedConfig, key string) (string, error) {
-	switch key {
-	case "directories.package":
-		return cfg.Directories.Package, nil
-	case "directories.target":
-		return cfg.Directories.Target, nil
-	case "directories.manifest":
-		return cfg.Directories.Manifest, nil
-	case "logging.level":
-		return cfg.Logging.Level, nil
-	case "logging.format":
-		return cfg.Logging.Format, nil
-	case "logging.destination":
-		return cfg.Logging.Destination, nil
-	case "symlinks.mode":
-		return cfg.Symlinks.Mode, nil
-	case "symlinks.backup_suffix":
-		return cfg.Symlinks.BackupSuffix, nil
-	case "symlinks.backup_dir":
-		return cfg.Symlinks.BackupDir, nil
-	case "dotfile.prefix":
-		return cfg.Dotfile.Prefix, nil
-	case "output.format":
-		return cfg.Output.Format, nil
-	case "output.color":
-		return cfg.Output.Color, nil
-	case "packages.sort_by":
-		return cfg.Packages.SortBy, nil
+	value, err := cfg.Value(key)
+	if err != nil {
+		return "", err
+	}
+	return formatConfigValue(value), nil
+}
+
+// formatConfigValue formats a configuration value for output, with one
+// line per entry of lists and maps.
+func formatConfigValue(value any) string {
+	switch v := value.(type) {
+	case []string:
+		return strings.Join(v, "\n")
+	case map[string]string:
+		lines := make([]string, 0, len(v))
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			lines = append(lines, key+": "+v[key])
+		}
+		return strings.Join(lines, "\n")
 	default:
-		return "", fmt.Errorf("unknown config key: %s", key)
+		return fmt.Sprint(v)
 	}
 }
 
@@ -298,6 +328,7 @@ Values are automatically type-converted based on the field.`,
 // runConfigSet handles the set subcommand.
 func runConfigSet(key, value string) error {
 	configPath := getConfigFilePath()
+	key = canonicalConfigKey(key)
 
 	writer := config.NewWriter(configPath)
 	if err := writer.Update(key, value); err != nil {
@@ -310,6 +341,125 @@ func runConfigSet(key, value string) error {
 	return nil
 }
 
+// newConfigUnsetCommand creates the unset subcommand.
+func newConfigUnsetCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "unset <key>",
+		Short: "Reset configuration value to its default",
+		Long: `Reset a configuration value in the configuration file to its default.
+
+Unsetting an entry of a map key, such as targets.vim, removes the entry.`,
+		Example: `  # Reset the logging level
+  dot config unset logging.level
+
+  # Remove the target directory of a package
+  dot config unset targets.vim`,
+		Args: argsWithUsage(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfigUnset(args[0])
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 0 {
+				return getValidConfigKeys(), cobra.ShellCompDirectiveNoFileComp
+			}
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+
+	return cmd
+}
+
+// runConfigUnset handles the unset subcommand.
+func runConfigUnset(key string) error {
+	configPath := getConfigFilePath()
+	key = canonicalConfigKey(key)
+
+	writer := config.NewWriter(configPath)
+	if err := writer.Unset(key); err != nil {
+		return fmt.Errorf("update config: %w", err)
+	}
+
+	fmt.Printf("Updated configuration: %s\n", configPath)
+	fmt.Printf("  %s: reset to default\n", key)
+
+	return nil
+}
+
+// newConfigAddCommand creates the add subcommand.
+func newConfigAddCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "add <key> <value>",
+		Short: "Add a value to a list setting",
+		Long: `Append a value to a list setting, such as ignore.patterns, in the
+configuration file. A value already in the list is not added again.`,
+		Example: `  # Ignore log files
+  dot config add ignore.patterns "*.log"
+
+  # Add a fallback mirror
+  dot config add git.mirrors https://mirror.example.com/dotfiles.git`,
+		Args: argsWithUsage(cobra.ExactArgs(2)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfigModifyList(args[0], args[1], true)
+		},
+		ValidArgsFunction: completeConfigListKeys,
+	}
+
+	return cmd
+}
+
+// newConfigRemoveCommand creates the remove subcommand.
+func newConfigRemoveCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "remove <key> <value>",
+		Short: "Remove a value from a list setting",
+		Long: `Remove a value from a list setting, such as ignore.patterns, in the
+configuration file.`,
+		Example: `  # Stop ignoring log files
+  dot config remove ignore.patterns "*.log"`,
+		Args: argsWithUsage(cobra.ExactArgs(2)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfigModifyList(args[0], args[1], false)
+		},
+		ValidArgsFunction: completeConfigListKeys,
+	}
+
+	return cmd
+}
+
+// runConfigModifyList handles the add and remove subcommands.
+func runConfigModifyList(key, value string, add bool) error {
+	configPath := getConfigFilePath()
+	key = canonicalConfigKey(key)
+
+	writer := config.NewWriter(configPath)
+	change, verb := writer.Remove, "removed"
+	if add {
+		change, verb = writer.Add, "added"
+	}
+	if err := change(key, value); err != nil {
+		return fmt.Errorf("update config: %w", err)
+	}
+
+	fmt.Printf("Updated configuration: %s\n", configPath)
+	fmt.Printf("  %s: %s %s\n", key, verb, value)
+
+	return nil
+}
+
+// completeConfigListKeys completes the list keys as the first argument.
+func completeConfigListKeys(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var keys []string
+	for _, spec := range config.Schema() {
+		if spec.Type == config.KeyTypeList {
+			keys = append(keys, spec.Key)
+		}
+	}
+	return keys, cobra.ShellCompDirectiveNoFileComp
+}
+
 // newConfigListCommand creates the list subcommand.
 func newConfigListCommand() *cobra.Command {
 	cmd := &cobra.Command{
@@ -335,13 +485,18 @@ func runConfigListCmd(cmd *cobra.Command, args []string) error {
 
 	loader := config.NewLoader("dot", configPath)
 	cfg, err := loader.LoadWithEnv()
+	warnDeprecations(os.Stderr, loader.Deprecations())
 	if err != nil {
 		return fmt.Errorf("load config: %w", err)
 	}
 
 	// Build the configuration table output
 	var buf bytes.Buffer
-	fmt.Fprintf(&buf, "%s\n\n", dim("Configuration from: "+configPath))
+	fmt.Fprintf(&buf, "%s\n", dim("Configuration from: "+configPath))
+	for _, include := range loader.Includes() {
+		fmt.Fprintf(&buf, "%s\n", dim("  including: "+include))
+	}
+	buf.WriteString("\n")
 
 	// Create table for each section
 	sections := []struct {
@@ -349,6 +504,7 @@ func runConfigListCmd(cmd *cobra.Command, args []string) error {
 		render func(*bytes.Buffer, *config.ExtendedConfig)
 	}{
 		{"Directories", renderDirectoriesSection},
+		{"Targets", renderTargetsSection},
 		{"Logging", renderLoggingSection},
 		{"Symlinks", renderSymlinksSection},
 		{"Ignore", renderIgnoreSection},
@@ -357,6 +513,9 @@ func runConfigListCmd(cmd *cobra.Command, args []string) error {
 		{"Operations", renderOperationsSection},
 		{"Packages", renderPackagesSection},
 		{"Doctor", renderDoctorSection},
+		{"Git", renderGitSection},
+		{"Secrets", renderSecretsSection},
+		{"Observability", renderObservabilitySection},
 		{"Experimental", renderExperimentalSection},
 	}
 
@@ -380,6 +539,18 @@ func renderDirectoriesSection(buf *bytes.Buffer, cfg *config.ExtendedConfig) {
 	fmt.Fprintf(buf, "  %-20s %s\n", dim("manifest:"), cfg.Directories.Manifest)
 }
 
+// renderTargetsSection renders the package target directories.
+func renderTargetsSection(buf *bytes.Buffer, cfg *config.ExtendedConfig) {
+	fmt.Fprintf(buf, "%s\n", bold("Targets"))
+	if len(cfg.Targets) == 0 {
+		fmt.Fprintf(buf, "  %s\n", dim("(none)"))
+		return
+	}
+	for _, pkg := range slices.Sorted(maps.Keys(cfg.Targets)) {
+		fmt.Fprintf(buf, "  %-20s %s\n", dim(pkg+":"), cfg.Targets[pkg])
+	}
+}
+
 // renderLoggingSection renders the logging configuration.
 func renderLoggingSection(buf *bytes.Buffer, cfg *config.ExtendedConfig) {
 	fmt.Fprintf(buf, "%s\n", bold("Logging"))
@@ -402,6 +573,15 @@ func renderSymlinksSection(buf *bytes.Buffer, cfg *config.ExtendedConfig) {
 	if cfg.Symlinks.BackupDir != "" {
 		fmt.Fprintf(buf, "  %-20s %s\n", dim("backup_dir:"), cfg.Symlinks.BackupDir)
 	}
+	if cfg.Symlinks.OnConflict != "" {
+		fmt.Fprintf(buf, "  %-20s %s\n", dim("on_conflict:"), cfg.Symlinks.OnConflict)
+	}
+	if len(cfg.Symlinks.Policies) > 0 {
+		fmt.Fprintf(buf, "  %s\n", dim("policies:"))
+		for _, pattern := range slices.Sorted(maps.Keys(cfg.Symlinks.Policies)) {
+			fmt.Fprintf(buf, "    %-18s %s\n", dim(pattern+":"), cfg.Symlinks.Policies[pattern])
+		}
+	}
 }
 
 // renderIgnoreSection renders the ignore configuration section.
@@ -410,6 +590,9 @@ func renderIgnoreSection(buf *bytes.Buffer, cfg *config.ExtendedConfig) {
 	fmt.Fprintf(buf, "  %-20s %s\n", dim("use_defaults:"), formatBool(cfg.Ignore.UseDefaults))
 	fmt.Fprintf(buf, "  %-20s %s\n", dim("patterns:"), formatSlice(cfg.Ignore.Patterns))
 	fmt.Fprintf(buf, "  %-20s %s\n", dim("overrides:"), formatSlice(cfg.Ignore.Overrides))
+	fmt.Fprintf(buf, "  %-20s %s\n", dim("special_files:"), cfg.Ignore.SpecialFiles)
+	fmt.Fprintf(buf, "  %-20s %s\n", dim("scan_defaults:"), formatBool(cfg.Ignore.ScanDefaults))
+	fmt.Fprintf(buf, "  %-20s %s\n", dim("scan_patterns:"), formatSlice(cfg.Ignore.ScanPatterns))
 }
 
 // renderDotfileSection renders the dotfile configuration section.
@@ -454,13 +637,66 @@ func renderDoctorSection(buf *bytes.Buffer, cfg *config.ExtendedConfig) {
 	fmt.Fprintf(buf, "  %-20s %s\n", dim("check_broken_links:"), formatBool(cfg.Doctor.CheckBrokenLinks))
 	fmt.Fprintf(buf, "  %-20s %s\n", dim("check_orphaned:"), formatBool(cfg.Doctor.CheckOrphaned))
 	fmt.Fprintf(buf, "  %-20s %s\n", dim("check_permissions:"), formatBool(cfg.Doctor.CheckPermissions))
+	fmt.Fprintf(buf, "  %-20s %d\n", dim("orphaned_threshold:"), cfg.Doctor.OrphanedThreshold)
+	fmt.Fprintf(buf, "  %-20s %d\n", dim("broken_threshold:"), cfg.Doctor.BrokenThreshold)
+}
+
+// renderGitSection renders the git configuration section.
+func renderGitSection(buf *bytes.Buffer, cfg *config.ExtendedConfig) {
+	fmt.Fprintf(buf, "%s\n", bold("Git"))
+	if cfg.Git.Timeout != "" {
+		fmt.Fprintf(buf, "  %-20s %s\n", dim("timeout:"), cfg.Git.Timeout)
+	}
+	if cfg.Git.Proxy != "" {
+		fmt.Fprintf(buf, "  %-20s %s\n", dim("proxy:"), cfg.Git.Proxy)
+	}
+	fmt.Fprintf(buf, "  %-20s %s\n", dim("insecure_skip_tls:"), formatBool(cfg.Git.InsecureSkipTLS))
+	fmt.Fprintf(buf, "  %-20s %s\n", dim("mirrors:"), formatSlice(cfg.Git.Mirrors))
+}
+
+// renderSecretsSection renders the secrets configuration section.
+func renderSecretsSection(buf *bytes.Buffer, cfg *config.ExtendedConfig) {
+	fmt.Fprintf(buf, "%s\n", bold("Secrets"))
+	fmt.Fprintf(buf, "  %-20s %s\n", dim("provider:"), cfg.Secrets.Provider)
+	fmt.Fprintf(buf, "  %-20s %s\n", dim("env_prefix:"), cfg.Secrets.EnvPrefix)
+	if cfg.Secrets.Vault != "" {
+		fmt.Fprintf(buf, "  %-20s %s\n", dim("vault:"), cfg.Secrets.Vault)
+	}
+}
+
+// renderObservabilitySection renders the observability configuration
+// section. Header values are hidden as they usually hold credentials.
+func renderObservabilitySection(buf *bytes.Buffer, cfg *config.ExtendedConfig) {
+	o := cfg.Observability
+	fmt.Fprintf(buf, "%s\n", bold("Observability"))
+	if !o.Enabled() {
+		fmt.Fprintf(buf, "  %-20s %s\n", dim("exporters:"), "none")
+		return
+	}
+	if o.PrometheusTextfile != "" {
+		fmt.Fprintf(buf, "  %-20s %s\n", dim("prometheus_textfile:"), o.PrometheusTextfile)
+	}
+	if o.PrometheusPushgateway != "" {
+		fmt.Fprintf(buf, "  %-20s %s\n", dim("prometheus_pushgateway:"), o.PrometheusPushgateway)
+		fmt.Fprintf(buf, "  %-20s %s\n", dim("prometheus_job:"), o.PrometheusJob)
+	}
+	if o.OTLPEndpoint != "" {
+		fmt.Fprintf(buf, "  %-20s %s\n", dim("otlp_endpoint:"), o.OTLPEndpoint)
+		names := make([]string, 0, len(o.OTLPHeaders))
+		for _, header := range o.OTLPHeaders {
+			name, _, _ := strings.Cut(header, "=")
+			names = append(names, name+"=***")
+		}
+		fmt.Fprintf(buf, "  %-20s %s\n", dim("otlp_headers:"), formatSlice(names))
+		fmt.Fprintf(buf, "  %-20s %s\n", dim("service_name:"), o.ServiceName)
+	}
+	fmt.Fprintf(buf, "  %-20s %s\n", dim("timeout:"), o.Timeout)
 }
 
 // renderExperimentalSection renders the experimental configuration section.
 func renderExperimentalSection(buf *bytes.Buffer, cfg *config.ExtendedConfig) {
 	fmt.Fprintf(buf, "%s\n", bold("Experimental"))
-	fmt.Fprintf(buf, "  %-20s %s\n", dim("parallel:"), formatBool(cfg.Experimental.Parallel))
-	fmt.Fprintf(buf, "  %-20s %s\n", dim("profiling:"), formatBool(cfg.Experimental.Profiling))
+	fmt.Fprintf(buf, "  %-20s %s\n", dim("flags:"), formatSlice(cfg.Experimental.Flags))
 }
 
 // formatBool formats a boolean value for display.
@@ -523,3 +759,195 @@ func runConfigPath() error {
 
 	return nil
 }
+
+// newConfigMigrateCommand creates the migrate subcommand.
+func newConfigMigrateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate [FILE]",
+		Short: "Replace deprecated keys in a configuration file",
+		Long: `Rewrite a configuration file with deprecated keys replaced by their
+current names, for example directories.stow by directories.package.
+
+FILE defaults to the user configuration file. The original is kept next
+to it with a .bak suffix. Files without deprecated keys are not changed.
+Comments are preserved in YAML files.`,
+		Example: `  # Migrate the user configuration file
+  dot config migrate
+
+  # Migrate the configuration kept in a dotfiles repository
+  dot config migrate ~/.dotfiles/.config/dot/config.yaml`,
+		Args: argsWithUsage(cobra.MaximumNArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configPath := getConfigFilePath()
+			if len(args) == 1 {
+				configPath = args[0]
+			}
+			return runConfigMigrate(cmd, configPath)
+		},
+	}
+
+	return cmd
+}
+
+// runConfigMigrate handles the migrate subcommand.
+func runConfigMigrate(cmd *cobra.Command, configPath string) error {
+	deprecations, err := config.NewWriter(configPath).Migrate()
+	if err != nil {
+		return fmt.Errorf("migrate config: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	if len(deprecations) == 0 {
+		fmt.Fprintf(out, "No deprecated keys in %s\n", configPath)
+		return nil
+	}
+
+	fmt.Fprintf(out, "%s %s\n", success("Migrated"), configPath)
+	for _, d := range deprecations {
+		fmt.Fprintf(out, "  %s → %s\n", d.Key, d.Replacement)
+	}
+	fmt.Fprintln(out, dim("Original saved as "+configPath+".bak"))
+	return nil
+}
+
+// newConfigConvertCommand creates the convert subcommand.
+func newConfigConvertCommand() *cobra.Command {
+	var to string
+
+	cmd := &cobra.Command{
+		Use:   "convert [FILE]",
+		Short: "Rewrite a configuration file in another format",
+		Long: `Rewrite a configuration file in another format: yaml, json or toml.
+
+The converted file has the name of the original with the extension of
+the format, such as config.toml, and the original is kept next to it
+with a .bak suffix. Comments of keys are carried over between YAML and
+TOML; JSON has none.
+
+FILE defaults to the user configuration file. The user configuration
+file is found in the configuration directory in any format, so no
+setting is needed after converting it. When DOT_CONFIG names the
+original, point it at the converted file.`,
+		Example: `  # Convert the user configuration file to TOML
+  dot config convert --to toml
+
+  # Convert a repository configuration file to JSON
+  dot config convert ~/.dotfiles/.config/dot/config.yaml --to json`,
+		Args: argsWithUsage(cobra.MaximumNArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configPath := getConfigFilePath()
+			if len(args) == 1 {
+				configPath = args[0]
+			}
+			return runConfigConvert(cmd, configPath, to)
+		},
+	}
+
+	cmd.Flags().StringVar(&to, "to", "", "Format to convert to (yaml, json, toml)")
+	_ = cmd.MarkFlagRequired("to")
+	_ = cmd.RegisterFlagCompletionFunc("to", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"yaml", "json", "toml"}, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	return cmd
+}
+
+// runConfigConvert handles the convert subcommand.
+func runConfigConvert(cmd *cobra.Command, configPath, format string) error {
+	convertedPath, err := config.NewWriter(configPath).Convert(format)
+	if err != nil {
+		return fmt.Errorf("convert config: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "%s %s → %s\n", success("Converted"), configPath, convertedPath)
+	fmt.Fprintln(out, dim("Original saved as "+configPath+".bak"))
+	if env := os.Getenv("DOT_CONFIG"); env != "" && filepath.Clean(env) == filepath.Clean(configPath) {
+		fmt.Fprintf(cmd.ErrOrStderr(), "%s DOT_CONFIG names the original; set DOT_CONFIG=%s\n", warning("Warning:"), convertedPath)
+	}
+	return nil
+}
+
+// newConfigCheckEnvCommand creates the config check-env subcommand.
+func newConfigCheckEnvCommand() *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "check-env",
+		Short: "Check the environment can hold the configured directories",
+		Long: `Probe the environment of the effective configuration:
+
+  package_dir_readable    the package directory can be listed
+  target_writable         files can be created in the target directory
+  symlinks_supported      symbolic links can be created in the target directory
+  manifest_dir_creatable  the manifest directory exists or can be created
+
+Probes create and remove a temporary file and link. A directory that does
+not exist yet is probed at its nearest existing parent. The command fails
+if any check fails.`,
+		Example: `  # Check the configured directories
+  dot config check-env
+
+  # Check another target directory
+  dot --target /srv/home config check-env
+
+  # Machine-readable report
+  dot config check-env --format json`,
+		Args: argsWithUsage(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfigCheckEnv(cmd, format)
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "f", "text", "Output format (text, json, yaml)")
+
+	return cmd
+}
+
+// runConfigCheckEnv handles the check-env subcommand.
+func runConfigCheckEnv(cmd *cobra.Command, format string) error {
+	if err := checkConfigFormat(format); err != nil {
+		return err
+	}
+
+	cfg, err := buildConfigWithCmd(cmd)
+	if err != nil {
+		return formatError(err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return formatError(err)
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	report := cfg.CheckEnvironment(ctx)
+
+	out := cmd.OutOrStdout()
+	if format == "text" {
+		renderCapabilityReport(out, report)
+	} else if err := encodeConfigOutput(out, format, report); err != nil {
+		return err
+	}
+
+	if failed := report.Failed(); len(failed) > 0 {
+		return fmt.Errorf("%d of %d environment check(s) failed", len(failed), len(report.Checks))
+	}
+	return nil
+}
+
+// renderCapabilityReport writes one line per check of report.
+func renderCapabilityReport(w io.Writer, report dot.CapabilityReport) {
+	for _, check := range report.Checks {
+		symbol := success("✓")
+		if !check.OK {
+			symbol = errorText("✗")
+		}
+		line := fmt.Sprintf("%s %-24s %s", symbol, check.Name, check.Path)
+		if check.Message != "" {
+			line += dim(" (" + check.Message + ")")
+		}
+		fmt.Fprintln(w, line)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jamesainslie/dot/internal/config"
+)
+
+// newConfigEditCommand creates the edit subcommand.
+func newConfigEditCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "edit",
+		Short: "Edit the configuration file",
+		Long: `Open the configuration file in $VISUAL or $EDITOR, and check it when
+the editor exits before replacing the file.
+
+The edited copy is loaded as dot would load it. Syntax errors and invalid
+values are reported with their line in the file, and the editor can be
+re-opened on the copy to fix them. The configuration file is only
+replaced once the copy is valid; declining to re-open keeps the file
+unchanged and the copy beside it.
+
+When no configuration file exists, one with the default values is
+created first.`,
+		Example: `  # Edit the configuration file
+  dot config edit
+
+  # Edit with a specific editor
+  EDITOR="code --wait" dot config edit`,
+		Args: argsWithUsage(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfigEdit(cmd, getConfigFilePath())
+		},
+	}
+}
+
+// runConfigEdit handles the edit subcommand.
+func runConfigEdit(cmd *cobra.Command, configPath string) error {
+	if _, err := os.Stat(configPath); errors.Is(err, os.ErrNotExist) {
+		format := strings.TrimPrefix(filepath.Ext(configPath), ".")
+		if format == "yml" || format == "" {
+			format = "yaml"
+		}
+		if err := config.NewWriter(configPath).WriteDefault(config.WriteOptions{
+			Format:          format,
+			IncludeComments: format == "yaml",
+		}); err != nil {
+			return fmt.Errorf("write config file: %w", err)
+		}
+	}
+
+	original, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+	info, err := os.Stat(configPath)
+	if err != nil {
+		return fmt.Errorf("stat config file: %w", err)
+	}
+
+	// The copy is kept beside the file, so that includes resolve as they
+	// do from the file, and with its extension, which sets its format.
+	ext := filepath.Ext(configPath)
+	pattern := strings.TrimSuffix(filepath.Base(configPath), ext) + ".edit-*" + ext
+	tmp, err := os.CreateTemp(filepath.Dir(configPath), pattern)
+	if err != nil {
+		return fmt.Errorf("create edit copy: %w", err)
+	}
+	editPath := tmp.Name()
+	_, err = tmp.Write(original)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(editPath)
+		return fmt.Errorf("write edit copy: %w", err)
+	}
+
+	editor := editorCommand()
+	for {
+		if err := runEditor(cmd, editor, editPath); err != nil {
+			os.Remove(editPath)
+			return fmt.Errorf("run editor %s: %w", editor[0], err)
+		}
+
+		checkErr := config.CheckFile(editPath)
+		if checkErr == nil {
+			break
+		}
+
+		var fileErr *config.FileError
+		if errors.As(checkErr, &fileErr) {
+			checkErr = &config.FileError{Path: configPath, Line: fileErr.Line, Err: fileErr.Err}
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "%s %v\n", errorText("Invalid configuration:"), checkErr)
+		if !confirmAction(cmd, "Re-open the editor?") {
+			return fmt.Errorf("configuration not updated; edited copy kept at %s", editPath)
+		}
+	}
+
+	edited, err := os.ReadFile(editPath)
+	if err != nil {
+		os.Remove(editPath)
+		return fmt.Errorf("read edit copy: %w", err)
+	}
+	if bytes.Equal(edited, original) {
+		os.Remove(editPath)
+		fmt.Fprintln(cmd.OutOrStdout(), "No changes")
+		return nil
+	}
+
+	if err := os.Chmod(editPath, info.Mode().Perm()); err != nil {
+		os.Remove(editPath)
+		return fmt.Errorf("set config file permissions: %w", err)
+	}
+	if err := os.Rename(editPath, configPath); err != nil {
+		os.Remove(editPath)
+		return fmt.Errorf("replace config file: %w", err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Updated configuration: %s\n", configPath)
+	return nil
+}
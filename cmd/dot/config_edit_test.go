@@ -0,0 +1,127 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// editConfigWith replaces the editor with one writing each of contents to
+// the file in turn, and returns the number of times it ran.
+func editConfigWith(t *testing.T, contents ...string) *int {
+	t.Helper()
+	runs := 0
+	previous := runEditor
+	runEditor = func(cmd *cobra.Command, editor []string, path string) error {
+		require.Less(t, runs, len(contents), "editor opened too often")
+		runs++
+		return os.WriteFile(path, []byte(contents[runs-1]), 0600)
+	}
+	t.Cleanup(func() { runEditor = previous })
+	return &runs
+}
+
+// editCopies returns the edit copies left beside configPath.
+func editCopies(t *testing.T, configPath string) []string {
+	t.Helper()
+	copies, err := filepath.Glob(filepath.Join(filepath.Dir(configPath), "config.edit-*"))
+	require.NoError(t, err)
+	return copies
+}
+
+func TestConfigEdit_Valid(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("logging:\n  level: INFO\n"), 0640))
+	runs := editConfigWith(t, "logging:\n  level: DEBUG\n")
+
+	cmd := newConfigEditCommand()
+	var out strings.Builder
+	cmd.SetOut(&out)
+	require.NoError(t, runConfigEdit(cmd, configPath))
+
+	assert.Equal(t, 1, *runs)
+	assert.Contains(t, out.String(), "Updated configuration: "+configPath)
+	data, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "logging:\n  level: DEBUG\n", string(data))
+	info, err := os.Stat(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+	assert.Empty(t, editCopies(t, configPath))
+}
+
+func TestConfigEdit_Unchanged(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("logging:\n  level: INFO\n"), 0600))
+	editConfigWith(t, "logging:\n  level: INFO\n")
+
+	cmd := newConfigEditCommand()
+	var out strings.Builder
+	cmd.SetOut(&out)
+	require.NoError(t, runConfigEdit(cmd, configPath))
+
+	assert.Contains(t, out.String(), "No changes")
+	assert.Empty(t, editCopies(t, configPath))
+}
+
+func TestConfigEdit_ReopensOnInvalid(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("logging:\n  level: INFO\n"), 0600))
+	runs := editConfigWith(t, "logging:\n  format: text\n  level: LOUD\n", "logging:\n  level: WARN\n")
+
+	cmd := newConfigEditCommand()
+	var stderr strings.Builder
+	cmd.SetErr(&stderr)
+	cmd.SetIn(strings.NewReader("y\n"))
+	require.NoError(t, runConfigEdit(cmd, configPath))
+
+	assert.Equal(t, 2, *runs)
+	assert.Contains(t, stderr.String(), configPath+":3: logging.level: invalid log level")
+	data, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "logging:\n  level: WARN\n", string(data))
+}
+
+func TestConfigEdit_Abandon(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("logging:\n  level: INFO\n"), 0600))
+	editConfigWith(t, "logging: [\n")
+
+	cmd := newConfigEditCommand()
+	cmd.SetErr(&strings.Builder{})
+	cmd.SetIn(strings.NewReader("n\n"))
+	err := runConfigEdit(cmd, configPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "configuration not updated")
+
+	data, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "logging:\n  level: INFO\n", string(data))
+	copies := editCopies(t, configPath)
+	require.Len(t, copies, 1)
+	assert.Equal(t, ".yaml", filepath.Ext(copies[0]))
+}
+
+func TestConfigEdit_CreatesMissingFile(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "dot", "config.yaml")
+	var opened string
+	previous := runEditor
+	runEditor = func(cmd *cobra.Command, editor []string, path string) error {
+		data, err := os.ReadFile(path)
+		opened = string(data)
+		return err
+	}
+	t.Cleanup(func() { runEditor = previous })
+
+	cmd := newConfigEditCommand()
+	cmd.SetOut(&strings.Builder{})
+	require.NoError(t, runConfigEdit(cmd, configPath))
+
+	assert.Contains(t, opened, "directories:")
+	assert.FileExists(t, configPath)
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/jamesainslie/dot/internal/config"
+)
+
+// newConfigKeysCommand creates the keys subcommand.
+func newConfigKeysCommand() *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "keys",
+		Short: "List the configuration keys",
+		Long: `List every configuration key with its type and default value.
+
+Map keys such as targets hold entries addressed as <key>.<name>, for
+example targets.vim.`,
+		Example: `  # List the keys
+  dot config keys
+
+  # List the keys with their descriptions, for tools
+  dot config keys --format json`,
+		Args: argsWithUsage(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return writeConfigKeys(cmd.OutOrStdout(), format, config.Schema())
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "f", "text", "Output format (text, json, yaml)")
+
+	return cmd
+}
+
+// newConfigExplainCommand creates the explain subcommand.
+func newConfigExplainCommand() *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "explain <key>",
+		Short: "Describe a configuration key",
+		Long: `Describe a configuration key: what it controls, its type, its default
+and its value in the effective configuration.`,
+		Example: `  # Describe the conflict resolution strategy
+  dot config explain symlinks.on_conflict
+
+  # Describe the target directory of a package
+  dot config explain targets.vim --format json`,
+		Args: argsWithUsage(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfigExplain(cmd.OutOrStdout(), args[0], format)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 0 {
+				return getValidConfigKeys(), cobra.ShellCompDirectiveNoFileComp
+			}
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "f", "text", "Output format (text, json, yaml)")
+
+	return cmd
+}
+
+// configKeyExplanation is the output of config explain.
+type configKeyExplanation struct {
+	config.KeySpec `yaml:",inline"`
+
+	// Value is the value of the key in the effective configuration.
+	Value any `json:"value" yaml:"value"`
+}
+
+// runConfigExplain handles the explain subcommand.
+func runConfigExplain(w io.Writer, key, format string) error {
+	if err := checkConfigFormat(format); err != nil {
+		return err
+	}
+	key = canonicalConfigKey(key)
+	spec, ok := config.LookupKey(key)
+	if !ok {
+		return fmt.Errorf("unknown config key: %s\n\nRun 'dot config keys' for the available keys", key)
+	}
+
+	loader := config.NewLoader("dot", getConfigFilePath())
+	cfg, err := loader.LoadWithEnv()
+	warnDeprecations(os.Stderr, loader.Deprecations())
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	value, err := cfg.Value(key)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "json", "yaml":
+		return encodeConfigOutput(w, format, configKeyExplanation{KeySpec: spec, Value: value})
+	}
+	fmt.Fprintf(w, "%s (%s)\n", bold(spec.Key), spec.Type)
+	fmt.Fprintf(w, "  %s\n\n", spec.Description)
+	fmt.Fprintf(w, "  %-10s %s\n", dim("default:"), indentConfigValue(formatConfigValue(spec.Default)))
+	fmt.Fprintf(w, "  %-10s %s\n", dim("value:"), indentConfigValue(formatConfigValue(value)))
+	return nil
+}
+
+// writeConfigKeys writes the keys of schema in format.
+func writeConfigKeys(w io.Writer, format string, schema []config.KeySpec) error {
+	if err := checkConfigFormat(format); err != nil {
+		return err
+	}
+	if format != "text" {
+		return encodeConfigOutput(w, format, schema)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tTYPE\tDEFAULT")
+	for _, spec := range schema {
+		def := strings.ReplaceAll(formatConfigValue(spec.Default), "\n", ", ")
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", spec.Key, spec.Type, def)
+	}
+	return tw.Flush()
+}
+
+// checkConfigFormat checks format is an output format of the config
+// subcommands.
+func checkConfigFormat(format string) error {
+	if format != "text" && format != "json" && format != "yaml" {
+		return fmt.Errorf("invalid format %q: use text, json or yaml", format)
+	}
+	return nil
+}
+
+// encodeConfigOutput writes v as JSON or YAML.
+func encodeConfigOutput(w io.Writer, format string, v any) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// indentConfigValue indents the lines after the first of a formatted
+// value to line up under the first in explain output.
+func indentConfigValue(value string) string {
+	if value == "" {
+		return dim("(none)")
+	}
+	return strings.ReplaceAll(value, "\n", "\n"+strings.Repeat(" ", 13))
+}
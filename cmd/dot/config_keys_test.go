@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/jamesainslie/dot/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigKeysCommand(t *testing.T) {
+	out, err := runDot(t, "config", "keys")
+	require.NoError(t, err)
+	assert.Contains(t, out, "KEY")
+	assert.Regexp(t, `symlinks\.folding\s+bool\s+true`, out)
+
+	out, err = runDot(t, "config", "keys", "--format", "json")
+	require.NoError(t, err)
+	var keys []config.KeySpec
+	require.NoError(t, json.Unmarshal([]byte(out), &keys))
+	assert.Len(t, keys, len(config.Schema()))
+
+	_, err = runDot(t, "config", "keys", "--format", "xml")
+	assert.ErrorContains(t, err, "invalid format")
+}
+
+func TestConfigExplainCommand(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	t.Setenv("DOT_CONFIG", configPath)
+	cfg := config.DefaultExtended()
+	cfg.Symlinks.OnConflict = "backup"
+	cfg.Targets = map[string]string{"vim": "/srv/vim"}
+	require.NoError(t, config.NewWriter(configPath).Write(cfg, config.WriteOptions{Format: "yaml"}))
+
+	var out bytes.Buffer
+	require.NoError(t, runConfigExplain(&out, "symlinks.on_conflict", "text"))
+	assert.Contains(t, out.String(), "symlinks.on_conflict (string)")
+	assert.Contains(t, out.String(), "Conflict resolution strategy")
+	assert.Regexp(t, `value:\S*\s+backup`, out.String())
+
+	out.Reset()
+	require.NoError(t, runConfigExplain(&out, "targets.vim", "json"))
+	var explained map[string]any
+	require.NoError(t, json.Unmarshal(out.Bytes(), &explained))
+	assert.Equal(t, "targets.vim", explained["key"])
+	assert.Equal(t, "string", explained["type"])
+	assert.Equal(t, "/srv/vim", explained["value"])
+
+	err := runConfigExplain(&out, "unknown.key", "text")
+	assert.ErrorContains(t, err, "unknown config key: unknown.key")
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
 	"testing"
@@ -52,6 +53,7 @@ func TestGetConfigValue(t *testing.T) {
 			Mode:         "relative",
 			BackupSuffix: ".bak",
 			BackupDir:    "/test/backup",
+			OnConflict:   "adopt",
 		},
 		Dotfile: config.DotfileConfig{
 			Prefix: "dot-",
@@ -79,6 +81,7 @@ func TestGetConfigValue(t *testing.T) {
 		{"symlinks.mode", "relative", false},
 		{"symlinks.backup_suffix", ".bak", false},
 		{"symlinks.backup_dir", "/test/backup", false},
+		{"symlinks.on_conflict", "adopt", false},
 		{"dotfile.prefix", "dot-", false},
 		{"output.format", "text", false},
 		{"output.color", "auto", false},
@@ -177,6 +180,88 @@ func TestConfigCommand_Set(t *testing.T) {
 	assert.Equal(t, "/new/dotfiles", cfg.Directories.Package)
 }
 
+func TestConfigCommand_UnsetAddRemove(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	t.Setenv("DOT_CONFIG", configPath)
+
+	require.NoError(t, runConfigSet("logging.level", "DEBUG"))
+	require.NoError(t, runConfigUnset("logging.level"))
+	require.NoError(t, runConfigModifyList("ignore.patterns", "*.log", true))
+	require.NoError(t, runConfigModifyList("ignore.patterns", "*.tmp", true))
+	require.NoError(t, runConfigModifyList("ignore.patterns", "*.log", false))
+
+	cfg, err := config.LoadExtendedFromFile(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "INFO", cfg.Logging.Level)
+	assert.Equal(t, []string{"*.tmp"}, cfg.Ignore.Patterns)
+
+	assert.Error(t, runConfigModifyList("ignore.patterns", "*.log", false))
+}
+
+func TestConfigCommand_Migrate(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("directories:\n  stow: /old/dotfiles\n"), 0600))
+
+	t.Setenv("DOT_CONFIG", configPath)
+
+	cmd := newConfigCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"migrate"})
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, out.String(), "directories.stow → directories.package")
+	assert.FileExists(t, configPath+".bak")
+
+	cfg, err := config.LoadExtendedFromFile(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "/old/dotfiles", cfg.Directories.Package)
+
+	// Deprecated keys are accepted by get and set
+	require.NoError(t, runConfigSet("directories.stow", "/new/dotfiles"))
+	cfg, err = config.LoadExtendedFromFile(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "/new/dotfiles", cfg.Directories.Package)
+	assert.NoError(t, runConfigGet("directories.stow"))
+}
+
+func TestConfigCommand_Convert(t *testing.T) {
+	configDir := filepath.Join(t.TempDir(), "dot")
+	t.Setenv("XDG_CONFIG_HOME", filepath.Dir(configDir))
+	t.Setenv("DOT_CONFIG", "")
+	require.NoError(t, runConfigSet("logging.level", "DEBUG"))
+
+	cmd := newConfigCommand()
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"convert", "--to", "toml"})
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, out.String(), filepath.Join(configDir, "config.toml"))
+
+	// The converted file is found without DOT_CONFIG
+	assert.Equal(t, filepath.Join(configDir, "config.toml"), getConfigFilePath())
+	cfg, err := config.NewLoader("dot", getConfigFilePath()).Load()
+	require.NoError(t, err)
+	assert.Equal(t, "DEBUG", cfg.Logging.Level)
+}
+
+func TestWarnDeprecations(t *testing.T) {
+	var out bytes.Buffer
+	deprecations := []config.Deprecation{
+		{Key: "directories.stow", Replacement: "directories.package", File: "/repo/.config/dot/config.yaml"},
+		{Key: "DOT_STOW_DIR", Replacement: "DOT_DIRECTORIES_PACKAGE"},
+	}
+
+	warnDeprecations(&out, deprecations)
+	assert.Contains(t, out.String(), "config key directories.stow in /repo/.config/dot/config.yaml is deprecated; use directories.package")
+	assert.Contains(t, out.String(), "environment variable DOT_STOW_DIR is deprecated; use DOT_DIRECTORIES_PACKAGE")
+	assert.Contains(t, out.String(), "dot config migrate /repo/.config/dot/config.yaml")
+
+	// Each warning is printed once per process
+	out.Reset()
+	warnDeprecations(&out, deprecations)
+	assert.Empty(t, out.String())
+}
+
 func TestConfigCommand_Path(t *testing.T) {
 	tmpDir := t.TempDir()
 	configPath := filepath.Join(tmpDir, "config.yaml")
@@ -234,7 +319,7 @@ func TestConfigCommand_List_DisplaysAllSections(t *testing.T) {
 	cfg.Operations.DryRun = true
 	cfg.Packages.SortBy = "date"
 	cfg.Doctor.AutoFix = true
-	cfg.Experimental.Parallel = true
+	cfg.Experimental.Flags = []string{"parallel"}
 
 	writer := config.NewWriter(configPath)
 	err := writer.Write(cfg, config.WriteOptions{Format: "yaml"})
@@ -255,7 +340,7 @@ func TestConfigCommand_List_DisplaysAllSections(t *testing.T) {
 	assert.True(t, loadedCfg.Operations.DryRun)
 	assert.Equal(t, "date", loadedCfg.Packages.SortBy)
 	assert.True(t, loadedCfg.Doctor.AutoFix)
-	assert.True(t, loadedCfg.Experimental.Parallel)
+	assert.True(t, loadedCfg.Experimental.IsEnabled("parallel"))
 }
 
 func TestConfigCommand_List_DisplaysDefaults(t *testing.T) {
@@ -285,7 +370,7 @@ func TestConfigCommand_List_DisplaysDefaults(t *testing.T) {
 	assert.False(t, cfg.Operations.DryRun)
 	assert.Equal(t, "name", cfg.Packages.SortBy)
 	assert.False(t, cfg.Doctor.AutoFix)
-	assert.False(t, cfg.Experimental.Parallel)
+	assert.Empty(t, cfg.Experimental.Flags)
 }
 
 func TestGetValidConfigKeys(t *testing.T) {
@@ -305,6 +390,7 @@ func TestGetValidConfigKeys(t *testing.T) {
 		"symlinks.mode",
 		"symlinks.backup_suffix",
 		"symlinks.backup_dir",
+		"symlinks.on_conflict",
 		"dotfile.prefix",
 		"output.format",
 		"output.color",
@@ -359,3 +445,27 @@ func TestConfigSetCommand_Completion(t *testing.T) {
 	assert.Empty(t, completions)
 	assert.Equal(t, 4, int(directive)) // NoFileComp
 }
+
+func TestConfigCheckEnvCommand(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	packageDir := filepath.Join(tmpDir, "packages")
+	targetDir := filepath.Join(tmpDir, "target")
+	require.NoError(t, os.MkdirAll(packageDir, 0755))
+	require.NoError(t, os.MkdirAll(targetDir, 0755))
+
+	out, err := runDot(t, "--dir", packageDir, "--target", targetDir, "config", "check-env")
+	require.NoError(t, err, out)
+	assert.Contains(t, out, "package_dir_readable")
+	assert.Contains(t, out, "symlinks_supported")
+
+	entries, err := os.ReadDir(targetDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "probes are removed")
+
+	out, err = runDot(t, "--dir", filepath.Join(tmpDir, "missing"), "--target", targetDir, "config", "check-env", "--format", "json")
+	assert.ErrorContains(t, err, "environment check(s) failed")
+	assert.Contains(t, out, `"name": "package_dir_readable"`)
+	assert.Contains(t, out, `"ok": false`)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// newConflictPrompter returns a prompter asking on w which strategy
+// resolves each conflict and reading the answer from in. An empty answer
+// or the end of input fails the conflict. Questions are asked one at a
+// time when packages are planned concurrently.
+func newConflictPrompter(w io.Writer, in io.Reader) dot.ConflictPrompter {
+	var mu sync.Mutex
+	reader := bufio.NewReader(in)
+	return func(c dot.Conflict, choices []string) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		fmt.Fprintf(w, "%s %s: %s\n", warning("Conflict:"), c.Path.String(), c.Details)
+		for {
+			fmt.Fprintf(w, "Resolve with %s? [fail]: ", strings.Join(choices, ", "))
+			response, err := reader.ReadString('\n')
+			choice := strings.ToLower(strings.TrimSpace(response))
+			switch {
+			case choice == "":
+				if err != nil {
+					fmt.Fprintln(w)
+				}
+				return "fail", nil
+			case slices.Contains(choices, choice):
+				return choice, nil
+			case err != nil:
+				fmt.Fprintln(w)
+				return "fail", nil
+			}
+			fmt.Fprintf(w, "%s unknown strategy %q\n", errorText("Error:"), choice)
+		}
+	}
+}
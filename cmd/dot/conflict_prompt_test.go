@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+func TestConflictPrompter(t *testing.T) {
+	conflict := dot.Conflict{
+		Path:    dot.NewFilePath("/home/user/.vimrc").Unwrap(),
+		Details: "File exists at target (size=12)",
+	}
+	choices := []string{"adopt", "backup", "fail", "overwrite", "skip"}
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"chosen strategy", "backup\n", "backup"},
+		{"case and space insensitive", "  Adopt \n", "adopt"},
+		{"empty answer fails", "\n", "fail"},
+		{"end of input fails", "", "fail"},
+		{"asks again after unknown strategy", "merge\nskip\n", "skip"},
+		{"unknown strategy at end of input fails", "merge", "fail"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			ask := newConflictPrompter(&out, strings.NewReader(tt.input))
+
+			choice, err := ask(conflict, choices)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, choice)
+			assert.Contains(t, out.String(), "/home/user/.vimrc: File exists at target (size=12)")
+			assert.Contains(t, out.String(), "Resolve with adopt, backup, fail, overwrite, skip? [fail]: ")
+		})
+	}
+}
+
+func TestManageCommand_OnConflict(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	packageDir := filepath.Join(tmpDir, "packages")
+	targetDir := filepath.Join(tmpDir, "target")
+	require.NoError(t, os.MkdirAll(filepath.Join(packageDir, "vim"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(targetDir, "vim"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(packageDir, "vim", "dot-vimrc"), []byte("set nocompatible"), 0644))
+	existing := filepath.Join(targetDir, "vim", ".vimrc")
+	require.NoError(t, os.WriteFile(existing, []byte("my local vimrc"), 0644))
+
+	_, err := runDot(t, "--dir", packageDir, "--target", targetDir, "manage", "vim")
+	require.Error(t, err)
+
+	_, err = runDot(t, "--dir", packageDir, "--target", targetDir, "--on-conflict", "merge", "manage", "vim")
+	assert.ErrorContains(t, err, `unknown conflict strategy "merge"`)
+
+	_, err = runDot(t, "--dir", packageDir, "--target", targetDir, "--on-conflict", "adopt", "manage", "vim")
+	require.NoError(t, err)
+
+	info, err := os.Lstat(existing)
+	require.NoError(t, err)
+	assert.NotZero(t, info.Mode()&os.ModeSymlink)
+	data, err := os.ReadFile(filepath.Join(packageDir, "vim", "dot-vimrc"))
+	require.NoError(t, err)
+	assert.Equal(t, "my local vimrc", string(data))
+}
+
+func TestManageCommand_DryRunShowsReplacedContent(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("NO_COLOR", "1")
+	tmpDir := t.TempDir()
+	packageDir := filepath.Join(tmpDir, "packages")
+	targetDir := filepath.Join(tmpDir, "target")
+	require.NoError(t, os.MkdirAll(filepath.Join(packageDir, "vim"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(targetDir, "vim"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(packageDir, "vim", "dot-vimrc"), []byte("set nocompatible\n"), 0644))
+	existing := filepath.Join(targetDir, "vim", ".vimrc")
+	require.NoError(t, os.WriteFile(existing, []byte("my local vimrc\n"), 0644))
+
+	out, err := runDot(t, "--dir", packageDir, "--target", targetDir, "--on-conflict", "overwrite", "--dry-run", "manage", "vim")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Replaced files:")
+	assert.Contains(t, out, "-my local vimrc")
+	assert.Contains(t, out, "+set nocompatible")
+
+	data, err := os.ReadFile(existing)
+	require.NoError(t, err)
+	assert.Equal(t, "my local vimrc\n", string(data), "dry run changes nothing")
+}
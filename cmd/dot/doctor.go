@@ -2,11 +2,19 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"os"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 
 	"github.com/jamesainslie/dot/internal/cli/pretty"
 	"github.com/jamesainslie/dot/internal/cli/renderer"
@@ -33,6 +41,10 @@ func newDoctorCommand() *cobra.Command {
 		color, _ := cmd.Flags().GetString("color")
 		scanMode, _ := cmd.Flags().GetString("scan-mode")
 		maxDepth, _ := cmd.Flags().GetInt("max-depth")
+		stream, _ := cmd.Flags().GetBool("stream")
+		if stream && format != "text" && format != "json" && format != "yaml" {
+			return fmt.Errorf("--stream supports text, json and yaml output, not %s", format)
+		}
 
 		// Create client
 		client, err := dot.NewClient(cfg)
@@ -58,8 +70,50 @@ func newDoctorCommand() *cobra.Command {
 			return fmt.Errorf("invalid scan-mode: %s (must be off, scoped, or deep)", scanMode)
 		}
 
-		// Run diagnostics
-		report, err := client.DoctorWithScan(cmd.Context(), scanCfg)
+		if scanCfg.Mode != dot.ScanOff {
+			scanCfg.SkipPatterns = scanSkipPatterns(extCfg)
+		}
+		scanCfg.Packages = doctorPackages(cmd, args)
+		scanCfg.MaxEntries, _ = cmd.Flags().GetInt("scan-max-entries")
+		scanCfg.MaxDuration, _ = cmd.Flags().GetDuration("scan-timeout")
+
+		if extCfg != nil {
+			scanCfg.Thresholds = dot.HealthThresholds{
+				OrphanedLinks: extCfg.Doctor.OrphanedThreshold,
+				BrokenLinks:   extCfg.Doctor.BrokenThreshold,
+			}
+			scanCfg.CheckPermissions = extCfg.Doctor.CheckPermissions
+		}
+		if cmd.Flags().Changed("check-permissions") {
+			scanCfg.CheckPermissions, _ = cmd.Flags().GetBool("check-permissions")
+		}
+
+		// Write issues as they are found when streaming
+		var issueStream *doctorStream
+		if stream {
+			issueStream = newDoctorStream(cmd.OutOrStdout(), format)
+			scanCfg.OnIssue = issueStream.issue
+		}
+
+		// Show link check progress on an interactive terminal
+		if format == "text" && !stream && extCfg != nil && extCfg.Output.Progress && !globalCfg.quiet && isTerminalWriter(os.Stderr) {
+			bar := newLinkCheckProgress(os.Stderr, extCfg.Output.Width)
+			client.Events().Subscribe(bar.OnEvent, dot.TopicProgress)
+			defer bar.Stop()
+		}
+
+		// Run diagnostics, within --timeout when set
+		ctx := cmd.Context()
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		report, err := client.DoctorWithScan(ctx, scanCfg)
+		if errors.Is(err, context.DeadlineExceeded) && timeout > 0 {
+			return fmt.Errorf("doctor did not finish within %s: %w (raise --timeout, or name packages to check fewer links)", timeout, err)
+		}
 		if err != nil {
 			return formatError(err)
 		}
@@ -72,16 +126,31 @@ func newDoctorCommand() *cobra.Command {
 		if extCfg != nil {
 			tableStyle = extCfg.Output.TableStyle
 		}
-		r, err := renderer.NewRenderer(format, colorize, tableStyle)
-		if err != nil {
-			return fmt.Errorf("invalid format: %w", err)
+		var r renderer.Renderer
+		if format != "sarif" {
+			r, err = renderer.NewRenderer(format, colorize, tableStyle)
+			if err != nil {
+				return fmt.Errorf("invalid format: %w", err)
+			}
 		}
 
 		// Render diagnostics - use succinct output for text format with pagination
-		if format == "text" {
+		switch {
+		case stream:
+			if err := issueStream.finish(report, len(scanCfg.Packages) > 0); err != nil {
+				return fmt.Errorf("render failed: %w", err)
+			}
+		case format == "sarif":
+			if err := renderer.RenderDiagnosticsSARIF(cmd.OutOrStdout(), report, version); err != nil {
+				return fmt.Errorf("render failed: %w", err)
+			}
+		case format == "text":
 			// Render to buffer first to enable pagination
 			var buf bytes.Buffer
 			renderSuccinctDiagnostics(&buf, report)
+			if len(scanCfg.Packages) > 0 {
+				renderPackageHealth(&buf, report)
+			}
 
 			// Use pager for output (auto-detects terminal size)
 			pager := pretty.NewPager(pretty.PagerConfig{
@@ -91,13 +160,51 @@ func newDoctorCommand() *cobra.Command {
 			if err := pager.PageLines(strings.Split(buf.String(), "\n")); err != nil {
 				return fmt.Errorf("failed to display output: %w", err)
 			}
-		} else {
+		default:
 			// For non-text formats, render directly without pagination
 			if err := r.RenderDiagnostics(cmd.OutOrStdout(), report); err != nil {
 				return fmt.Errorf("render failed: %w", err)
 			}
 		}
 
+		w := cmd.OutOrStdout()
+		if format != "text" {
+			// Keep structured output parseable
+			w = cmd.ErrOrStderr()
+		}
+
+		// Record orphaned links into packages in the manifest
+		if adoptOrphans, _ := cmd.Flags().GetBool("adopt-orphans"); adoptOrphans {
+			adopted, err := client.AdoptOrphans(cmd.Context(), report)
+			if err != nil {
+				return formatError(err)
+			}
+			renderAdoptedOrphans(w, adopted, cfg.DryRun)
+			if !cfg.DryRun {
+				report.Issues = slices.DeleteFunc(report.Issues, func(issue dot.Issue) bool {
+					return slices.Contains(adopted, issue)
+				})
+				report.OverallHealth = worstHealth(report.Issues)
+			}
+		}
+
+		// Repair issues when asked to, or when doctor.auto_fix is set
+		fix, _ := cmd.Flags().GetBool("fix")
+		if !cmd.Flags().Changed("fix") && extCfg != nil {
+			fix = extCfg.Doctor.AutoFix
+		}
+		if fix && len(report.Issues) > 0 {
+			fixPlan, err := client.Fix(cmd.Context(), report)
+			if err != nil {
+				return formatError(err)
+			}
+			renderFixPlan(w, fixPlan, cfg.DryRun)
+			if !cfg.DryRun {
+				report.Issues = fixPlan.Skipped
+				report.OverallHealth = worstHealth(fixPlan.Skipped)
+			}
+		}
+
 		// Return error to set exit code based on health status
 		// The main function will handle converting this to an exit code
 		if report.OverallHealth == dot.HealthErrors {
@@ -112,8 +219,171 @@ func newDoctorCommand() *cobra.Command {
 	return cmd
 }
 
+// doctorPackages returns the packages named as arguments and with
+// --package, without repeats.
+func doctorPackages(cmd *cobra.Command, args []string) []string {
+	flagged, _ := cmd.Flags().GetStringArray("package")
+	var packages []string
+	for _, name := range slices.Concat(args, flagged) {
+		if !slices.Contains(packages, name) {
+			packages = append(packages, name)
+		}
+	}
+	return packages
+}
+
+// renderPackageHealth outputs the health and link counts of each package
+// of the report, for runs scoped to named packages.
+func renderPackageHealth(w io.Writer, report dot.DiagnosticReport) {
+	names := slices.Sorted(maps.Keys(report.Statistics.Packages))
+	if len(names) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n%s\n", dim("Packages:"))
+	for _, name := range names {
+		pkg := report.Statistics.Packages[name]
+		icon, text, color := getHealthDisplay(pkg.Health)
+		fmt.Fprintf(w, "  %s %s %s\n", icon, name, dim(fmt.Sprintf("%s, %d links, %d broken, %d issues", color(text), pkg.Links, pkg.BrokenLinks, pkg.Issues)))
+	}
+}
+
+// doctorStream writes the issues of a doctor run as they are found, then
+// the complete report: as lines of text followed by the summary, as one
+// JSON object per line ending with {"report": ...}, or as a YAML document
+// per issue ending with a report document.
+type doctorStream struct {
+	w      io.Writer
+	format string
+	json   *json.Encoder
+	yaml   *yaml.Encoder
+	err    error
+}
+
+// newDoctorStream creates a stream writing to w in format.
+func newDoctorStream(w io.Writer, format string) *doctorStream {
+	s := &doctorStream{w: w, format: format}
+	switch format {
+	case "json":
+		s.json = json.NewEncoder(w)
+	case "yaml":
+		s.yaml = yaml.NewEncoder(w)
+		s.yaml.SetIndent(2)
+	}
+	return s
+}
+
+// issue writes one issue; it is the ScanConfig.OnIssue of the run. The
+// first write error is kept for finish.
+func (s *doctorStream) issue(issue dot.Issue) {
+	if s.err != nil {
+		return
+	}
+	switch s.format {
+	case "json":
+		s.err = s.json.Encode(map[string]dot.Issue{"issue": issue})
+	case "yaml":
+		s.err = s.yaml.Encode(map[string]dot.Issue{"issue": issue})
+	default:
+		icon, color := severityDisplay(issue.Severity)
+		line := fmt.Sprintf("%s %s", icon, color(issue.Path))
+		if issue.Message != "" {
+			line += fmt.Sprintf(" %s %s", dim("—"), dim(issue.Message))
+		}
+		_, s.err = fmt.Fprintln(s.w, line)
+	}
+}
+
+// finish writes the complete report: its summary in text, with the
+// health of each package when byPackage is set.
+func (s *doctorStream) finish(report dot.DiagnosticReport, byPackage bool) error {
+	if s.err != nil {
+		return s.err
+	}
+	switch s.format {
+	case "json":
+		return s.json.Encode(map[string]dot.DiagnosticReport{"report": report})
+	case "yaml":
+		if err := s.yaml.Encode(map[string]dot.DiagnosticReport{"report": report}); err != nil {
+			return err
+		}
+		return s.yaml.Close()
+	default:
+		if len(report.Issues) > 0 {
+			fmt.Fprintln(s.w)
+		}
+		renderDiagnosticSummary(s.w, report)
+		if len(report.Issues) == 0 {
+			fmt.Fprintf(s.w, "  %s\n", success("No issues found"))
+		} else {
+			fmt.Fprintf(s.w, "  %s %s\n", dim("•"), dim(issueCounts(report.Issues)))
+		}
+		if byPackage {
+			renderPackageHealth(s.w, report)
+		}
+		return nil
+	}
+}
+
+// severityDisplay returns the icon and color of issues of a severity.
+func severityDisplay(severity dot.IssueSeverity) (string, func(string) string) {
+	switch severity {
+	case dot.SeverityError:
+		return errorText("✗"), errorText
+	case dot.SeverityWarning:
+		return warning("⚠"), warning
+	default:
+		return info("ℹ"), bold
+	}
+}
+
+// issueCounts describes the number of issues of each severity, such as
+// "2 errors, 1 warnings".
+func issueCounts(issues []dot.Issue) string {
+	var counts []string
+	for _, sev := range []struct {
+		severity dot.IssueSeverity
+		name     string
+	}{{dot.SeverityError, "errors"}, {dot.SeverityWarning, "warnings"}, {dot.SeverityInfo, "info"}} {
+		if n := len(filterIssuesBySeverity(issues, sev.severity)); n > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", n, sev.name))
+		}
+	}
+	return strings.Join(counts, ", ")
+}
+
 // renderSuccinctDiagnostics outputs diagnostics in a succinct, colorized format.
 func renderSuccinctDiagnostics(w io.Writer, report dot.DiagnosticReport) {
+	renderDiagnosticSummary(w, report)
+
+	// Issues grouped by severity
+	errors := filterIssuesBySeverity(report.Issues, dot.SeverityError)
+	warnings := filterIssuesBySeverity(report.Issues, dot.SeverityWarning)
+	infos := filterIssuesBySeverity(report.Issues, dot.SeverityInfo)
+
+	if len(errors) > 0 {
+		fmt.Fprintf(w, "\n%s %s\n", errorText("✗"), errorText(fmt.Sprintf("%d errors:", len(errors))))
+		renderIssueList(w, errors, errorText)
+	}
+
+	if len(warnings) > 0 {
+		fmt.Fprintf(w, "\n%s %s\n", warning("⚠"), warning(fmt.Sprintf("%d warnings:", len(warnings))))
+		renderIssueList(w, warnings, warning)
+	}
+
+	if len(infos) > 0 {
+		fmt.Fprintf(w, "\n%s %s\n", info("ℹ"), info(fmt.Sprintf("%d info:", len(infos))))
+		renderIssueList(w, infos, dim)
+	}
+
+	// Clean summary if no issues
+	if len(report.Issues) == 0 {
+		fmt.Fprintf(w, "  %s\n", success("No issues found"))
+	}
+}
+
+// renderDiagnosticSummary outputs the overall health and statistics of a
+// report.
+func renderDiagnosticSummary(w io.Writer, report dot.DiagnosticReport) {
 	// Health status header
 	healthIcon, healthText, healthColor := getHealthDisplay(report.OverallHealth)
 	fmt.Fprintf(w, "%s %s\n",
@@ -132,31 +402,84 @@ func renderSuccinctDiagnostics(w io.Writer, report dot.DiagnosticReport) {
 				report.Statistics.OrphanedLinks)),
 		)
 	}
+	if report.Statistics.ForeignLinks > 0 {
+		fmt.Fprintf(w, "  %s %s\n",
+			dim("•"),
+			dim(fmt.Sprintf("%d links outside the package directory left alone", report.Statistics.ForeignLinks)),
+		)
+	}
+	if report.Statistics.UnknownLinks > 0 {
+		fmt.Fprintf(w, "  %s %s\n",
+			dim("•"),
+			dim(fmt.Sprintf("%d links with unknown sources (package directory unavailable)", report.Statistics.UnknownLinks)),
+		)
+	}
+	if report.Statistics.FilesScanned > 0 || report.Statistics.ScanDuration > 0 {
+		fmt.Fprintf(w, "  %s %s\n",
+			dim("•"),
+			dim(fmt.Sprintf("%d files scanned in %s",
+				report.Statistics.FilesScanned,
+				report.Statistics.ScanDuration.Round(time.Millisecond))),
+		)
+	}
+}
 
-	// Issues grouped by severity
-	errors := filterIssuesBySeverity(report.Issues, dot.SeverityError)
-	warnings := filterIssuesBySeverity(report.Issues, dot.SeverityWarning)
-	infos := filterIssuesBySeverity(report.Issues, dot.SeverityInfo)
-
-	if len(errors) > 0 {
-		fmt.Fprintf(w, "\n%s %s\n", errorText("✗"), errorText(fmt.Sprintf("%d errors:", len(errors))))
-		renderIssueList(w, errors, errorText)
+// renderFixPlan reports the repairs doctor made, or would make in dry-run mode.
+func renderFixPlan(w io.Writer, fix dot.FixPlan, dryRun bool) {
+	fmt.Fprintln(w)
+	switch {
+	case len(fix.Fixed) == 0:
+		fmt.Fprintf(w, "%s %s\n", dim("•"), dim("No issues can be fixed automatically"))
+	case dryRun:
+		fmt.Fprintf(w, "%s %s\n", info("ℹ"), info(fmt.Sprintf("Would fix %d issue(s):", len(fix.Fixed))))
+		for _, op := range fix.Plan.Operations {
+			fmt.Fprintf(w, "  %s %s\n", dim("•"), op.String())
+		}
+	default:
+		fmt.Fprintf(w, "%s %s\n", success("✓"), success(fmt.Sprintf("Fixed %d issue(s)", len(fix.Fixed))))
 	}
 
-	if len(warnings) > 0 {
-		fmt.Fprintf(w, "\n%s %s\n", warning("⚠"), warning(fmt.Sprintf("%d warnings:", len(warnings))))
-		renderIssueList(w, warnings, warning)
+	if len(fix.Skipped) > 0 {
+		fmt.Fprintf(w, "%s %s\n", warning("⚠"), warning(fmt.Sprintf("%d issue(s) need manual action:", len(fix.Skipped))))
+		for _, issue := range fix.Skipped {
+			fmt.Fprintf(w, "  %s %s", dim("•"), bold(issue.Path))
+			if issue.Suggestion != "" {
+				fmt.Fprintf(w, " %s %s", dim("—"), dim(issue.Suggestion))
+			}
+			fmt.Fprintln(w)
+		}
 	}
+}
 
-	if len(infos) > 0 {
-		fmt.Fprintf(w, "\n%s %s\n", info("ℹ"), info(fmt.Sprintf("%d info:", len(infos))))
-		renderIssueList(w, infos, dim)
+// renderAdoptedOrphans reports orphaned links added to the manifest.
+func renderAdoptedOrphans(w io.Writer, adopted []dot.Issue, dryRun bool) {
+	fmt.Fprintln(w)
+	switch {
+	case len(adopted) == 0:
+		fmt.Fprintf(w, "%s %s\n", dim("•"), dim("No orphaned links point into the package directory"))
+		return
+	case dryRun:
+		fmt.Fprintf(w, "%s %s\n", info("ℹ"), info(fmt.Sprintf("Would adopt %d orphaned link(s):", len(adopted))))
+	default:
+		fmt.Fprintf(w, "%s %s\n", success("✓"), success(fmt.Sprintf("Adopted %d orphaned link(s):", len(adopted))))
 	}
+	for _, issue := range adopted {
+		fmt.Fprintf(w, "  %s %s %s\n", dim("•"), bold(issue.Path), dim("→ "+issue.Package))
+	}
+}
 
-	// Clean summary if no issues
-	if len(report.Issues) == 0 {
-		fmt.Fprintf(w, "  %s\n", success("No issues found"))
+// worstHealth returns the health status implied by the most severe issue.
+func worstHealth(issues []dot.Issue) dot.HealthStatus {
+	health := dot.HealthOK
+	for _, issue := range issues {
+		switch issue.Severity {
+		case dot.SeverityError:
+			return dot.HealthErrors
+		case dot.SeverityWarning:
+			health = dot.HealthWarnings
+		}
 	}
+	return health
 }
 
 // getHealthDisplay returns icon, text, and color for health status
@@ -207,14 +530,23 @@ func NewDoctorCommand(cfg *dot.Config) *cobra.Command {
 	var color string
 
 	cmd := &cobra.Command{
-		Use:   "doctor",
+		Use:   "doctor [PACKAGE...]",
 		Short: "Perform health checks on the installation",
 		Long: `Run comprehensive health checks on the dot installation.
 
+With package names, given as arguments or with --package, only the links
+of those packages are checked, and orphan detection is limited to the
+directories holding them. This is a fast way to verify a single package
+after editing it. The text output then ends with the health of each
+package, and JSON and YAML reports break the statistics down by package
+as always.
+
 Checks for:
   - Broken symlinks in managed packages (links pointing to non-existent targets)
-  - Orphaned symlinks not in manifest (unmanaged links in target directory)
+  - Orphaned symlinks not in manifest (unmanaged links into the package directory)
   - Broken unmanaged symlinks (orphaned links with non-existent targets)
+  - Foreign symlinks pointing outside the package directory, such as those
+    of nix, homebrew or asdf, reported as info and never touched
   - Permission issues
   - Manifest inconsistencies
 
@@ -226,34 +558,100 @@ Orphan Detection:
   Use --scan-mode=off to disable orphan detection for faster checks.
   Use --scan-mode=deep for thorough scanning of entire target directory.
 
+  Deep scans of large home directories can take minutes. Bound them with
+  --scan-max-entries and --scan-timeout: a scan that reaches either limit
+  reports what it found so far with a scan_truncated warning.
+
+  Managed links are checked in parallel, up to operations.max_parallel at
+  a time, with a progress bar on an interactive terminal for large
+  manifests. --timeout bounds the whole check: doctor
+  fails without a report when the checks take longer. Repairs made by
+  --fix are not bounded.
+
+Streaming:
+  With --stream, each issue is written as soon as it is found instead of
+  after the checks, so problems of a long run show up early and the run
+  can be interrupted once they are seen. Text output then lists issues
+  in the order they were found, followed by the summary. JSON output is
+  one object per line: {"issue": ...} for each issue, then {"report": ...}
+  with the complete report. YAML output is a document per issue, then the
+  report document.
+
 Exit codes:
   0 - Healthy (no issues found)
   1 - Warnings detected (e.g., orphaned links)
-  2 - Errors detected (e.g., broken links)`,
+  2 - Errors detected (e.g., broken links)
+
+Fixing Issues:
+  With --fix, doctor repairs what it can through the same executor as
+  manage: missing and broken links of managed packages are recreated, a
+  file in place of a managed link is moved to the backup directory and
+  replaced by the link, links a package no longer provides are removed
+  from the target and the manifest, and unmanaged links into the package
+  directory with a missing target are removed. Orphaned links with a
+  valid target, broken foreign links and permission problems are left for
+  manual action. Combine with --dry-run to preview
+  the repairs. Setting doctor.auto_fix in the configuration enables --fix
+  by default.
+
+Adopting Orphans:
+  Orphaned links that point into the package directory, for example
+  after the manifest was lost, can be recorded under the package they
+  point into with --adopt-orphans. Only the manifest changes.`,
 		Example: `  # Run health check with default scoped scanning
   dot doctor
 
+  # Check only the vim and zsh packages
+  dot doctor vim zsh
+  dot doctor --package vim --package zsh
+
   # Run health check without orphan detection (faster)
   dot doctor --scan-mode=off
 
   # Run thorough scan of entire home directory
   dot doctor --scan-mode=deep
 
+  # Deep scan for at most 30 seconds
+  dot doctor --scan-mode=deep --scan-timeout=30s
+
   # Run health check with JSON output
   dot doctor --format=json
 
+  # Show issues of a deep scan while it runs
+  dot doctor --scan-mode=deep --stream
+
+  # Write a SARIF report for CI annotations
+  dot doctor --format=sarif > dot.sarif
+
   # Run health check without colors
-  dot doctor --color=never`,
+  dot doctor --color=never
+
+  # Preview, then apply, automatic repairs
+  dot doctor --fix --dry-run
+  dot doctor --fix
+
+  # Record links into the package directory missing from the manifest
+  dot doctor --adopt-orphans`,
+		ValidArgsFunction: packageCompletion(true), // Complete with installed packages
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Placeholder - will be overridden by newDoctorCommand
 			return nil
 		},
 	}
 
-	cmd.Flags().StringVarP(&format, "format", "f", "text", "Output format (text, json, yaml, table)")
+	cmd.Flags().StringVarP(&format, "format", "f", "text", "Output format (text, json, yaml, table, sarif)")
 	cmd.Flags().StringVar(&color, "color", "auto", "Colorize output (auto, always, never)")
 	cmd.Flags().String("scan-mode", "scoped", "Orphan detection mode (off, scoped, deep)")
 	cmd.Flags().Int("max-depth", 10, "Maximum recursion depth for deep scan")
+	cmd.Flags().Int("scan-max-entries", 0, "Stop the orphan scan after visiting this many entries (0 = unlimited)")
+	cmd.Flags().Duration("scan-timeout", 0, "Stop the orphan scan after this long, e.g. 30s (0 = unlimited)")
+	cmd.Flags().Duration("timeout", 0, "Fail if the checks take longer than this, e.g. 2m (0 = unlimited)")
+	cmd.Flags().Bool("adopt-orphans", false, "Add orphaned links into packages to the manifest")
+	cmd.Flags().Bool("fix", false, "Repair fixable issues (default from doctor.auto_fix)")
+	cmd.Flags().StringArray("package", nil, "Check only this installed package (repeatable)")
+	_ = cmd.RegisterFlagCompletionFunc("package", packageCompletion(true))
+	cmd.Flags().Bool("stream", false, "Write issues as they are found (text, json, yaml)")
+	cmd.Flags().Bool("check-permissions", false, "Check modes and owners of package files (default from doctor.check_permissions)")
 
 	return cmd
 }
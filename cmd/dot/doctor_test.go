@@ -2,7 +2,10 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -34,6 +37,18 @@ func TestDoctorCommand_Flags(t *testing.T) {
 	colorFlag := cmd.Flags().Lookup("color")
 	require.NotNil(t, colorFlag)
 	assert.Equal(t, "auto", colorFlag.DefValue)
+
+	// Scan budgets are unlimited by default
+	maxEntries := cmd.Flags().Lookup("scan-max-entries")
+	require.NotNil(t, maxEntries)
+	assert.Equal(t, "0", maxEntries.DefValue)
+	timeout := cmd.Flags().Lookup("scan-timeout")
+	require.NotNil(t, timeout)
+	assert.Equal(t, "0s", timeout.DefValue)
+	runTimeout := cmd.Flags().Lookup("timeout")
+	require.NotNil(t, runTimeout)
+	assert.Equal(t, "0s", runTimeout.DefValue)
+	assert.NotNil(t, cmd.Flags().Lookup("package"))
 }
 
 func TestDoctorCommand_Help(t *testing.T) {
@@ -196,3 +211,124 @@ func TestRenderIssueList(t *testing.T) {
 	assert.Contains(t, output, "test message")
 	assert.Contains(t, output, "/another/path")
 }
+
+func TestRenderFixPlan(t *testing.T) {
+	fix := dot.FixPlan{
+		Plan: dot.Plan{Operations: []dot.Operation{
+			dot.NewLinkDelete("doctor-fix-unlink-home/.old", dot.NewTargetPath("/home/.old").Unwrap()),
+		}},
+		Fixed:   []dot.Issue{{Type: dot.IssueBrokenLink, Path: ".old"}},
+		Skipped: []dot.Issue{{Severity: dot.SeverityWarning, Type: dot.IssueOrphanedLink, Path: ".other", Suggestion: "Use 'dot adopt'"}},
+	}
+
+	var buf bytes.Buffer
+	renderFixPlan(&buf, fix, true)
+	assert.Contains(t, buf.String(), "Would fix 1 issue(s)")
+	assert.Contains(t, buf.String(), "delete link /home/.old")
+	assert.Contains(t, buf.String(), "1 issue(s) need manual action")
+	assert.Contains(t, buf.String(), "Use 'dot adopt'")
+
+	buf.Reset()
+	renderFixPlan(&buf, fix, false)
+	assert.Contains(t, buf.String(), "Fixed 1 issue(s)")
+
+	assert.Equal(t, dot.HealthWarnings, worstHealth(fix.Skipped))
+	assert.Equal(t, dot.HealthOK, worstHealth(nil))
+}
+
+func TestDoctorCommand_SARIF(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	packageDir := filepath.Join(tmpDir, "packages")
+	targetDir := filepath.Join(tmpDir, "target")
+	require.NoError(t, os.MkdirAll(filepath.Join(packageDir, "vim"), 0755))
+	require.NoError(t, os.MkdirAll(targetDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(packageDir, "vim", "dot-vimrc"), []byte("set nocompatible"), 0644))
+	_, err := runDot(t, "--dir", packageDir, "--target", targetDir, "manage", "vim")
+	require.NoError(t, err)
+	require.NoError(t, os.Remove(filepath.Join(packageDir, "vim", "dot-vimrc")))
+
+	out, err := runDot(t, "--dir", packageDir, "--target", targetDir, "doctor", "--format=sarif", "--scan-mode=off")
+	require.EqualError(t, err, "health check detected errors", "exit codes are those of other formats")
+
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Results []struct {
+				RuleID string `json:"ruleId"`
+				Level  string `json:"level"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	require.NoError(t, json.NewDecoder(strings.NewReader(out)).Decode(&log))
+	assert.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+	require.NotEmpty(t, log.Runs[0].Results)
+	assert.Equal(t, "broken_link", log.Runs[0].Results[0].RuleID)
+	assert.Equal(t, "error", log.Runs[0].Results[0].Level)
+}
+
+func TestDoctorCommand_Package(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	packageDir := filepath.Join(tmpDir, "packages")
+	targetDir := filepath.Join(tmpDir, "target")
+	for _, pkg := range []string{"vim", "zsh"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(packageDir, pkg), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(packageDir, pkg, "dot-"+pkg+"rc"), []byte(pkg), 0644))
+	}
+	require.NoError(t, os.MkdirAll(targetDir, 0755))
+	_, err := runDot(t, "--dir", packageDir, "--target", targetDir, "manage", "vim", "zsh")
+	require.NoError(t, err)
+	require.NoError(t, os.Remove(filepath.Join(packageDir, "zsh", "dot-zshrc")))
+
+	out, err := runDot(t, "--dir", packageDir, "--target", targetDir, "doctor", "--package", "vim", "--scan-mode=off")
+	require.NoError(t, err, "the broken zsh link is out of scope")
+	assert.Contains(t, out, "Packages:")
+	assert.Contains(t, out, "vim")
+	assert.NotContains(t, out, "zsh")
+
+	out, err = runDot(t, "--dir", packageDir, "--target", targetDir, "doctor", "vim", "--package", "zsh", "--package", "vim", "--scan-mode=off")
+	require.EqualError(t, err, "health check detected errors")
+	assert.Contains(t, out, ".zshrc")
+}
+
+func TestDoctorCommand_Stream(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	packageDir := filepath.Join(tmpDir, "packages")
+	targetDir := filepath.Join(tmpDir, "target")
+	require.NoError(t, os.MkdirAll(filepath.Join(packageDir, "vim"), 0755))
+	require.NoError(t, os.MkdirAll(targetDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(packageDir, "vim", "dot-vimrc"), []byte("set nocompatible"), 0644))
+	_, err := runDot(t, "--dir", packageDir, "--target", targetDir, "manage", "vim")
+	require.NoError(t, err)
+	require.NoError(t, os.Remove(filepath.Join(packageDir, "vim", "dot-vimrc")))
+
+	out, err := runDot(t, "--dir", packageDir, "--target", targetDir, "doctor", "--stream", "--scan-mode=off")
+	require.EqualError(t, err, "health check detected errors")
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	assert.Contains(t, lines[0], ".vimrc", "issues come before the summary")
+	assert.Contains(t, out, "Errors detected")
+	assert.Contains(t, out, "1 errors")
+
+	// JSON is an object per issue, then the report
+	out, err = runDot(t, "--dir", packageDir, "--target", targetDir, "doctor", "--stream", "--format=json", "--scan-mode=off")
+	require.EqualError(t, err, "health check detected errors")
+	dec := json.NewDecoder(strings.NewReader(out))
+	var issue struct {
+		Issue map[string]any `json:"issue"`
+	}
+	require.NoError(t, dec.Decode(&issue))
+	assert.Equal(t, "broken_link", issue.Issue["type"])
+	var report struct {
+		Report map[string]any `json:"report"`
+	}
+	require.NoError(t, dec.Decode(&report))
+	assert.Equal(t, "errors", report.Report["overall_health"])
+	assert.Equal(t, []any{issue.Issue}, report.Report["issues"])
+	assert.False(t, dec.More())
+
+	_, err = runDot(t, "--dir", packageDir, "--target", targetDir, "doctor", "--stream", "--format=table")
+	assert.ErrorContains(t, err, "--stream supports text, json and yaml")
+}
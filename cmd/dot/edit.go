@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// runEditor opens path in an editor, attached to the terminal. Tests
+// replace it to avoid starting a real editor.
+var runEditor = func(cmd *cobra.Command, editor []string, path string) error {
+	editCmd := exec.CommandContext(cmd.Context(), editor[0], append(editor[1:], path)...)
+	editCmd.Stdin = os.Stdin
+	editCmd.Stdout = cmd.OutOrStdout()
+	editCmd.Stderr = cmd.ErrOrStderr()
+	return editCmd.Run()
+}
+
+// newEditCommand creates the edit command.
+func newEditCommand() *cobra.Command {
+	var remanage bool
+
+	cmd := &cobra.Command{
+		Use:   "edit PATH",
+		Short: "Open the package file behind a path in an editor",
+		Long: `Open the package file behind a managed path in $VISUAL or $EDITOR.
+
+PATH is either a path in the target directory, such as ~/.zshrc, or a
+path relative to the package directory that starts with the package
+name, such as dot-zsh/dot-zshrc. A target path is resolved through the
+manifest, so files inside folded directories and links to rendered
+templates lead to the package file that provides them.
+
+When the file is a template of an installed package, the package is
+remanaged after the editor exits so the rendered output is updated.
+Pass --remanage=false to skip this.
+
+The editor command is taken from $VISUAL, then $EDITOR, falling back to
+vi. It may include arguments, such as "code --wait".
+
+Examples:
+  # Edit the source of a linked file
+  dot edit ~/.zshrc
+
+  # Edit a file by its path in the package directory
+  dot edit dot-vim/vimrc
+
+  # Edit a template without re-rendering it
+  dot edit ~/.gitconfig --remanage=false`,
+		Args: argsWithUsage(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runEdit(cmd, args[0], remanage)
+		},
+	}
+
+	cmd.Flags().BoolVar(&remanage, "remanage", true, "remanage the package after editing one of its templates")
+
+	return cmd
+}
+
+// runEdit handles the edit command execution.
+func runEdit(cmd *cobra.Command, path string, remanage bool) error {
+	cfg, err := buildConfigWithCmd(cmd)
+	if err != nil {
+		return formatError(err)
+	}
+
+	client, err := dot.NewClient(cfg)
+	if err != nil {
+		return formatError(err)
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+		cmd.SetContext(ctx)
+	}
+
+	source, err := resolveEditPath(ctx, client, path)
+	if err != nil {
+		return formatError(err)
+	}
+
+	editor := editorCommand()
+	if err := runEditor(cmd, editor, source.Path); err != nil {
+		return fmt.Errorf("run editor %s: %w", editor[0], err)
+	}
+
+	if !remanage || !source.Template || !source.Managed {
+		return nil
+	}
+	if err := client.Remanage(ctx, source.Package); err != nil {
+		return formatError(err)
+	}
+	if !globalCfg.quiet {
+		fmt.Fprintf(cmd.OutOrStdout(), "Remanaged %s\n", source.Package)
+	}
+	return nil
+}
+
+// resolveEditPath resolves the argument of edit to its package file. A
+// leading ~ is expanded to the home directory. A relative path whose first
+// element is not a package is taken relative to the working directory.
+func resolveEditPath(ctx context.Context, client *dot.Client, path string) (dot.SourceFile, error) {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return dot.SourceFile{}, fmt.Errorf("expand %s: %w", path, err)
+		}
+		path = filepath.Join(home, strings.TrimPrefix(path, "~"))
+	}
+
+	source, err := client.ResolveSource(ctx, path)
+	var notFound dot.ErrPackageNotFound
+	if filepath.IsAbs(path) || !errors.As(err, &notFound) {
+		return source, err
+	}
+
+	abs, absErr := filepath.Abs(path)
+	if absErr != nil {
+		return dot.SourceFile{}, err
+	}
+	return client.ResolveSource(ctx, abs)
+}
+
+// editorCommand returns the editor command and its arguments.
+func editorCommand() []string {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(name)); len(fields) > 0 {
+			return fields
+		}
+	}
+	return []string{"vi"}
+}
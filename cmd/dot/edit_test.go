@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditCommand_Flags(t *testing.T) {
+	cmd := newEditCommand()
+
+	flag := cmd.Flags().Lookup("remanage")
+	require.NotNil(t, flag)
+	assert.Equal(t, "true", flag.DefValue)
+	assert.Error(t, cmd.Args(cmd, []string{}))
+	assert.Error(t, cmd.Args(cmd, []string{"a", "b"}))
+}
+
+func TestEditCommand_OpensPackageFile(t *testing.T) {
+	setupGlobalCfg(t)
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "nano -w")
+	require.NoError(t, os.MkdirAll(filepath.Join(globalCfg.packageDir, "dot-vim"), 0755))
+
+	var editor []string
+	var opened string
+	previous := runEditor
+	runEditor = func(cmd *cobra.Command, command []string, path string) error {
+		editor, opened = command, path
+		return nil
+	}
+	t.Cleanup(func() { runEditor = previous })
+
+	cmd := newEditCommand()
+	cmd.SetArgs([]string{"dot-vim/vimrc"})
+	require.NoError(t, cmd.Execute())
+
+	assert.Equal(t, []string{"nano", "-w"}, editor)
+	assert.Equal(t, filepath.Join(globalCfg.packageDir, "dot-vim", "vimrc"), opened)
+}
+
+func TestEditorCommand(t *testing.T) {
+	t.Setenv("VISUAL", "code --wait")
+	t.Setenv("EDITOR", "nano")
+	assert.Equal(t, []string{"code", "--wait"}, editorCommand())
+
+	t.Setenv("VISUAL", "")
+	assert.Equal(t, []string{"nano"}, editorCommand())
+
+	t.Setenv("EDITOR", "")
+	assert.Equal(t, []string{"vi"}, editorCommand())
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// newExportCommand creates the export command.
+func newExportCommand() *cobra.Command {
+	var output string
+	var packages []string
+	var reproducible bool
+
+	cmd := &cobra.Command{
+		Use:   "export --output FILE",
+		Short: "Write packages to a bundle for offline installs",
+		Long: `Write packages to a gzip-compressed tar bundle that 'dot clone
+--from-bundle' installs on a machine without network access.
+
+The bundle holds the selected packages, by default every package of the
+package directory, together with:
+  - the .dotbootstrap.yaml, restricted to the bundled packages
+  - the repository configuration in .config/dot
+  - a snapshot of the manifest, recording which bundled packages are
+    installed on this machine
+
+Git metadata is not bundled. File permissions and links inside packages
+are kept.
+
+With --reproducible, exporting the same inputs twice writes byte-identical
+bundles: entries are stamped with SOURCE_DATE_EPOCH, or the Unix epoch when
+it is unset, and permissions are normalized to 0755 or 0644. The index
+records the commit of the package directory, a hash of the configuration
+and a hash of each package, so bundles can be traced to their sources.
+
+Examples:
+  # Bundle every package
+  dot export --output dotfiles.tar.gz
+
+  # Bundle two packages
+  dot export --output dotfiles.tar.gz --packages vim,zsh
+
+  # Bundle reproducibly, stamped with the time of the last commit
+  SOURCE_DATE_EPOCH=$(git log -1 --format=%ct) dot export --output dotfiles.tar.gz --reproducible
+
+  # Show what would be bundled
+  dot --dry-run export --output dotfiles.tar.gz`,
+		Args: argsWithUsage(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runExport(cmd, output, packages, reproducible)
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "", "path of the bundle to write")
+	cmd.Flags().StringSliceVar(&packages, "packages", nil, "packages to bundle (default all)")
+	cmd.Flags().BoolVar(&reproducible, "reproducible", false, "write a byte-identical bundle for the same inputs")
+	_ = cmd.MarkFlagRequired("output")
+	_ = cmd.MarkFlagFilename("output", "tar.gz", "tgz")
+
+	return cmd
+}
+
+// newBakeCommand creates the bake command, which writes the bundle of
+// export reproducibly.
+func newBakeCommand() *cobra.Command {
+	var output string
+	var packages []string
+	var reproducible bool
+
+	cmd := &cobra.Command{
+		Use:   "bake --output FILE",
+		Short: "Build a reproducible bundle of packages",
+		Long: `Build the bundle of 'dot export' for build pipelines, reproducibly
+by default: exporting the same inputs twice writes byte-identical bundles.
+
+Entries are stamped with SOURCE_DATE_EPOCH, or the Unix epoch when it is
+unset, written in name order without owner or group, and permissions are
+normalized to 0755 or 0644. The index, dot-bundle.json, records the
+commit of the package directory, a hash of the configuration and a hash
+of each package.
+
+bake is export with --reproducible set; --reproducible=false writes the
+bundle as export does.
+
+Examples:
+  # Bake every package, stamped with the time of the last commit
+  SOURCE_DATE_EPOCH=$(git log -1 --format=%ct) dot bake --output dotfiles.tar.gz --reproducible
+
+  # Bake two packages
+  dot bake --output dotfiles.tar.gz --packages vim,zsh`,
+		Args: argsWithUsage(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runExport(cmd, output, packages, reproducible)
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "", "path of the bundle to write")
+	cmd.Flags().StringSliceVar(&packages, "packages", nil, "packages to bundle (default all)")
+	cmd.Flags().BoolVar(&reproducible, "reproducible", true, "write a byte-identical bundle for the same inputs")
+	_ = cmd.MarkFlagRequired("output")
+	_ = cmd.MarkFlagFilename("output", "tar.gz", "tgz")
+
+	return cmd
+}
+
+// runExport handles the export command execution.
+func runExport(cmd *cobra.Command, output string, packages []string, reproducible bool) error {
+	cfg, err := buildConfigWithCmd(cmd)
+	if err != nil {
+		return formatError(err)
+	}
+
+	client, err := dot.NewClient(cfg)
+	if err != nil {
+		return formatError(err)
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	outputPath, err := filepath.Abs(output)
+	if err != nil {
+		return fmt.Errorf("resolve %s: %w", output, err)
+	}
+
+	opts := dot.ExportOptions{Output: outputPath, Packages: packages, Reproducible: reproducible}
+	if reproducible {
+		if opts.ModTime, err = sourceDateEpoch(); err != nil {
+			return err
+		}
+	}
+
+	result, err := client.Export(ctx, opts)
+	if err != nil {
+		return formatError(err)
+	}
+
+	if !globalCfg.quiet {
+		renderExportResult(cmd.OutOrStdout(), result, cfg.DryRun)
+		if reproducible {
+			renderExportInputs(cmd.OutOrStdout(), result.Inputs)
+		}
+	}
+	return nil
+}
+
+// sourceDateEpoch returns the time set by the SOURCE_DATE_EPOCH variable
+// of reproducible builds, or the zero time when it is unset.
+func sourceDateEpoch() (time.Time, error) {
+	value := os.Getenv("SOURCE_DATE_EPOCH")
+	if value == "" {
+		return time.Time{}, nil
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH %q: must be seconds since the Unix epoch", value)
+	}
+	return time.Unix(seconds, 0).UTC(), nil
+}
+
+// renderExportInputs prints the input hashes recorded in a bundle.
+func renderExportInputs(w io.Writer, inputs dot.BundleInputs) {
+	if inputs.Commit != "" {
+		fmt.Fprintf(w, "  %s %s\n", dim("commit:"), inputs.Commit)
+	}
+	if inputs.ConfigHash != "" {
+		fmt.Fprintf(w, "  %s sha256:%s\n", dim("config:"), inputs.ConfigHash)
+	}
+}
+
+// renderExportResult prints the contents of a written bundle.
+func renderExportResult(w io.Writer, result dot.ExportResult, dryRun bool) {
+	verb := "Exported"
+	if dryRun {
+		verb = "Would export"
+	}
+	fmt.Fprintf(w, "%s %d packages (%d files) to %s\n", verb, len(result.Packages), result.Files, result.Path)
+	if len(result.Packages) > 0 {
+		fmt.Fprintf(w, "  %s %s\n", dim("packages:"), accent(strings.Join(result.Packages, ", ")))
+	}
+	var extras []string
+	if result.Bootstrap {
+		extras = append(extras, "bootstrap configuration")
+	}
+	if result.Manifest {
+		extras = append(extras, "manifest snapshot")
+	}
+	if len(extras) > 0 {
+		fmt.Fprintf(w, "  %s %s\n", dim("includes:"), strings.Join(extras, ", "))
+	}
+}
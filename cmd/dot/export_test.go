@@ -0,0 +1,162 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// runDot runs dot with args and returns the output.
+func runDot(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	rootCmd := NewRootCommand("test", "none", "unknown")
+	rootCmd.SetContext(context.Background())
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs(args)
+
+	err := rootCmd.Execute()
+	return buf.String(), err
+}
+
+func TestExportCommand_CloneFromBundle(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	packageDir := filepath.Join(tmpDir, "packages")
+	targetDir := filepath.Join(tmpDir, "target")
+	require.NoError(t, os.MkdirAll(filepath.Join(packageDir, "vim"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(packageDir, "zsh"), 0755))
+	require.NoError(t, os.MkdirAll(targetDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(packageDir, "vim", "dot-vimrc"), []byte("set nocompatible"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(packageDir, "zsh", "dot-zshrc"), []byte("bindkey -v"), 0644))
+	_, err := runDot(t, "--dir", packageDir, "--target", targetDir, "manage", "vim")
+	require.NoError(t, err)
+
+	bundle := filepath.Join(tmpDir, "bundle.tar.gz")
+	out, err := runDot(t, "--dir", packageDir, "--target", targetDir, "export", "--output", bundle)
+	require.NoError(t, err)
+	assert.Contains(t, out, "Exported 2 packages (2 files)")
+	assert.Contains(t, out, "manifest snapshot")
+	assert.FileExists(t, bundle)
+
+	// Install on another machine without network access
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	newPackageDir := filepath.Join(tmpDir, "new-packages")
+	newTargetDir := filepath.Join(tmpDir, "new-target")
+	require.NoError(t, os.MkdirAll(newTargetDir, 0755))
+	_, err = runDot(t, "--dir", newPackageDir, "--target", newTargetDir, "--offline", "clone", "--from-bundle", bundle)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(filepath.Join(newTargetDir, "vim", ".vimrc"))
+	require.NoError(t, err)
+	assert.Equal(t, "set nocompatible", string(data))
+	assert.FileExists(t, filepath.Join(newPackageDir, "zsh", "dot-zshrc"))
+	assert.NoDirExists(t, filepath.Join(newTargetDir, "zsh"))
+}
+
+func TestExportCommand_Reproducible(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	packageDir := filepath.Join(tmpDir, "packages")
+	require.NoError(t, os.MkdirAll(filepath.Join(packageDir, "vim"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(packageDir, "vim", "dot-vimrc"), []byte("set nocompatible"), 0600))
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+
+	var bundles [][]byte
+	for _, name := range []string{"a.tar.gz", "b.tar.gz"} {
+		output := filepath.Join(tmpDir, name)
+		_, err := runDot(t, "--dir", packageDir, "--target", tmpDir, "export", "--output", output, "--reproducible")
+		require.NoError(t, err)
+		data, err := os.ReadFile(output)
+		require.NoError(t, err)
+		bundles = append(bundles, data)
+
+		// Touching the package between exports changes nothing
+		now := time.Now()
+		require.NoError(t, os.Chtimes(filepath.Join(packageDir, "vim", "dot-vimrc"), now, now))
+	}
+	assert.Equal(t, bundles[0], bundles[1])
+
+	t.Setenv("SOURCE_DATE_EPOCH", "yesterday")
+	_, err := runDot(t, "--dir", packageDir, "--target", tmpDir, "export", "--output", filepath.Join(tmpDir, "c.tar.gz"), "--reproducible")
+	assert.ErrorContains(t, err, "invalid SOURCE_DATE_EPOCH")
+}
+
+func TestBakeCommand(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	packageDir := filepath.Join(tmpDir, "packages")
+	require.NoError(t, os.MkdirAll(filepath.Join(packageDir, "vim"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(packageDir, "vim", "dot-vimrc"), []byte("set nocompatible"), 0600))
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+
+	// bake is reproducible without the flag, and matches export --reproducible
+	baked := filepath.Join(tmpDir, "baked.tar.gz")
+	_, err := runDot(t, "--dir", packageDir, "--target", tmpDir, "bake", "--output", baked)
+	require.NoError(t, err)
+	exported := filepath.Join(tmpDir, "exported.tar.gz")
+	_, err = runDot(t, "--dir", packageDir, "--target", tmpDir, "export", "--output", exported, "--reproducible")
+	require.NoError(t, err)
+
+	bakedData, err := os.ReadFile(baked)
+	require.NoError(t, err)
+	exportedData, err := os.ReadFile(exported)
+	require.NoError(t, err)
+	assert.Equal(t, exportedData, bakedData)
+
+	_, err = runDot(t, "--dir", packageDir, "--target", tmpDir, "bake", "--output", filepath.Join(tmpDir, "again.tar.gz"), "--reproducible")
+	require.NoError(t, err)
+	again, err := os.ReadFile(filepath.Join(tmpDir, "again.tar.gz"))
+	require.NoError(t, err)
+	assert.Equal(t, bakedData, again)
+
+	// Files private to their owner stay private in the bundle
+	gz, err := gzip.NewReader(bytes.NewReader(bakedData))
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+	modes := make(map[string]int64)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		modes[hdr.Name] = hdr.Mode
+	}
+	assert.Equal(t, int64(0600), modes["packages/vim/dot-vimrc"])
+	assert.Equal(t, int64(0755), modes["packages/vim/"])
+}
+
+func TestCloneCommand_FromBundleRejectsGitFlags(t *testing.T) {
+	tmpDir := t.TempDir()
+	_, err := runDot(t, "--dir", filepath.Join(tmpDir, "packages"), "--target", tmpDir,
+		"clone", "--from-bundle", filepath.Join(tmpDir, "bundle.tar.gz"), "--branch", "main")
+	assert.ErrorContains(t, err, "--branch cannot be used with --from-bundle")
+}
+
+func TestRenderExportResult(t *testing.T) {
+	result := dot.ExportResult{Path: "/tmp/b.tar.gz", Packages: []string{"vim", "zsh"}, Files: 3, Bootstrap: true}
+
+	var buf bytes.Buffer
+	renderExportResult(&buf, result, false)
+	assert.Contains(t, buf.String(), "Exported 2 packages (3 files) to /tmp/b.tar.gz")
+	assert.Contains(t, buf.String(), "vim, zsh")
+	assert.Contains(t, buf.String(), "bootstrap configuration")
+	assert.NotContains(t, buf.String(), "manifest snapshot")
+
+	buf.Reset()
+	renderExportResult(&buf, result, true)
+	assert.Contains(t, buf.String(), "Would export")
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jamesainslie/dot/internal/config"
+)
+
+// newFeaturesCommand creates the features command group.
+func newFeaturesCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "features",
+		Short: "Manage experimental features",
+		Long: `List, enable, and disable experimental features.
+
+Experimental features are enabled by name in the experimental.flags
+configuration list. Commands print a warning for each enabled feature.`,
+		Args: argsWithUsage(cobra.NoArgs),
+	}
+
+	cmd.AddCommand(
+		newFeaturesListCommand(),
+		newFeaturesToggleCommand("enable", "Enable", true),
+		newFeaturesToggleCommand("disable", "Disable", false),
+	)
+
+	return cmd
+}
+
+// newFeaturesListCommand creates the features list subcommand.
+func newFeaturesListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List experimental features",
+		Args:  argsWithUsage(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfigWithRepoPriority(getConfigFilePath())
+			if err != nil {
+				return fmt.Errorf("load configuration: %w", err)
+			}
+			renderFeatureList(cmd.OutOrStdout(), cfg.Experimental)
+			return nil
+		},
+	}
+}
+
+// newFeaturesToggleCommand creates the features enable or disable
+// subcommand.
+func newFeaturesToggleCommand(verb, title string, enable bool) *cobra.Command {
+	return &cobra.Command{
+		Use:   verb + " FEATURE...",
+		Short: fmt.Sprintf("%s experimental features", title),
+		Long: fmt.Sprintf(`%s experimental features in the configuration file.
+
+Run 'dot features list' for the available features.`, title),
+		Args: argsWithUsage(cobra.MinimumNArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configPath := getConfigFilePath()
+			writer := config.NewWriter(configPath)
+			for _, name := range args {
+				if err := writer.SetFeature(name, enable); err != nil {
+					return fmt.Errorf("update config: %w", err)
+				}
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Updated configuration: %s\n", configPath)
+			for _, name := range args {
+				fmt.Fprintf(cmd.OutOrStdout(), "  %s: %sd\n", name, verb)
+			}
+			return nil
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			var names []string
+			for _, feature := range config.Features() {
+				names = append(names, feature.Name+"\t"+feature.Description)
+			}
+			return names, cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+}
+
+// renderFeatureList writes the feature registry with the enabled state of
+// each feature in cfg.
+func renderFeatureList(w io.Writer, cfg config.ExperimentalConfig) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FEATURE\tSTATUS\tENABLED\tDESCRIPTION")
+	for _, feature := range config.Features() {
+		enabled := "no"
+		if cfg.IsEnabled(feature.Name) {
+			enabled = "yes"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", feature.Name, feature.Status, enabled, feature.Description)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/config"
+)
+
+func TestFeaturesCommand_EnableListDisable(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	t.Setenv("DOT_CONFIG", configPath)
+	previous := globalCfg
+	t.Cleanup(func() { globalCfg = previous })
+	globalCfg.packageDir = t.TempDir()
+
+	run := func(args ...string) string {
+		cmd := newFeaturesCommand()
+		var stdout bytes.Buffer
+		cmd.SetOut(&stdout)
+		cmd.SetArgs(args)
+		require.NoError(t, cmd.Execute())
+		return stdout.String()
+	}
+
+	run("enable", "parallel")
+	cfg, err := config.LoadExtendedFromFile(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"parallel"}, cfg.Experimental.Flags)
+
+	out := run("list")
+	assert.Regexp(t, `parallel\s+beta\s+yes`, out)
+	assert.Regexp(t, `profiling\s+alpha\s+no`, out)
+
+	run("disable", "parallel")
+	cfg, err = config.LoadExtendedFromFile(configPath)
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Experimental.Flags)
+}
+
+func TestFeaturesCommand_UnknownFeature(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	t.Setenv("DOT_CONFIG", configPath)
+
+	cmd := newFeaturesCommand()
+	cmd.SetArgs([]string{"enable", "teleport"})
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown experimental feature "teleport"`)
+	_, statErr := os.Stat(configPath)
+	assert.True(t, os.IsNotExist(statErr), "a failed update writes nothing")
+}
+
+func TestWarnExperimentalFeatures_WarnsOnce(t *testing.T) {
+	previous := warnedFeatures
+	warnedFeatures = make(map[string]bool)
+	t.Cleanup(func() { warnedFeatures = previous })
+
+	var buf bytes.Buffer
+	cfg := config.ExperimentalConfig{Flags: []string{"profiling"}}
+	warnExperimentalFeatures(&buf, cfg)
+	warnExperimentalFeatures(&buf, cfg)
+	assert.Equal(t, 1, bytes.Count(buf.Bytes(), []byte("experimental feature profiling (alpha) is enabled")))
+}
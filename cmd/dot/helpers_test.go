@@ -168,6 +168,27 @@ func TestPackageCompletion_Available(t *testing.T) {
 	assert.Equal(t, cobra.ShellCompDirectiveNoFileComp, directive)
 }
 
+func TestPackageCompletion_MapsDotfilesAndSkipsGiven(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	previous := globalCfg
+	t.Cleanup(func() {
+		globalCfg = previous
+	})
+
+	globalCfg = globalConfig{
+		packageDir: tmpDir,
+	}
+
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "vim"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "dot-ssh"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "tmux"), 0755))
+
+	completions, _ := packageCompletion(false)(&cobra.Command{}, []string{"tmux"}, "")
+
+	assert.ElementsMatch(t, []string{"vim", "dot-ssh\ttargets .ssh"}, completions)
+}
+
 func TestGetInstalledPackages(t *testing.T) {
 	// Test that getInstalledPackages doesn't crash
 	packages := getInstalledPackages()
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// historyOptions holds the flags of the history command.
+type historyOptions struct {
+	pkg    string
+	since  string
+	until  string
+	limit  int
+	format string
+}
+
+// newHistoryCommand creates the history command.
+func newHistoryCommand() *cobra.Command {
+	var opts historyOptions
+
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Show the operations dot has executed",
+		Long: `Show the operations recorded in the audit log, oldest first.
+
+Every operation executed by manage, unmanage, remanage, adopt, rollback,
+and the other commands that change the target directory is appended to
+$XDG_STATE_HOME/dot/audit.jsonl with its time, user, plan ID, checkpoint
+ID, and result. The log is never rewritten.
+
+--since and --until take a date (2006-01-02), a time (RFC 3339), or a
+duration before now such as 36h or 7d. A date given to --until includes
+that whole day.
+
+Examples:
+  # Show everything dot has done
+  dot history
+
+  # Show the changes made to the vim package this week
+  dot history --package vim --since 7d
+
+  # Show the last 20 operations as JSON
+  dot history --limit 20 --format json`,
+		Args: argsWithUsage(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runHistory(cmd, opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.pkg, "package", "", "show only the operations of a package")
+	cmd.Flags().StringVar(&opts.since, "since", "", "show operations at or after a date, time, or duration ago")
+	cmd.Flags().StringVar(&opts.until, "until", "", "show operations before a date, time, or duration ago")
+	cmd.Flags().IntVar(&opts.limit, "limit", 0, "show only the most recent operations")
+	cmd.Flags().StringVar(&opts.format, "format", "text", "output format (text, json)")
+
+	return cmd
+}
+
+// runHistory handles the history command execution.
+func runHistory(cmd *cobra.Command, opts historyOptions) error {
+	if opts.format != "text" && opts.format != "json" {
+		return fmt.Errorf("invalid format %q: use text or json", opts.format)
+	}
+	if opts.limit < 0 {
+		return fmt.Errorf("invalid limit %d: must not be negative", opts.limit)
+	}
+
+	now := time.Now()
+	filter := dot.HistoryFilter{Package: opts.pkg, Limit: opts.limit}
+	var err error
+	if opts.since != "" {
+		if filter.Since, err = parseHistoryTime(opts.since, now, false); err != nil {
+			return fmt.Errorf("invalid --since: %w", err)
+		}
+	}
+	if opts.until != "" {
+		if filter.Until, err = parseHistoryTime(opts.until, now, true); err != nil {
+			return fmt.Errorf("invalid --until: %w", err)
+		}
+	}
+
+	cfg, err := buildConfigWithCmd(cmd)
+	if err != nil {
+		return formatError(err)
+	}
+
+	client, err := dot.NewClient(cfg)
+	if err != nil {
+		return formatError(err)
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	records, err := client.History(ctx, filter)
+	if err != nil {
+		return formatError(err)
+	}
+
+	out := cmd.OutOrStdout()
+	if opts.format == "json" {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	}
+	renderHistory(out, records)
+	return nil
+}
+
+// parseHistoryTime parses a --since or --until value relative to now. A
+// date is the start of that day, or the end of it when endOfDay is set.
+func parseHistoryTime(value string, now time.Time, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		if endOfDay {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not a date (2006-01-02), RFC 3339 time, or duration such as 24h or 7d", value)
+}
+
+// renderHistory prints one line per audit record.
+func renderHistory(w io.Writer, records []dot.AuditRecord) {
+	if len(records) == 0 {
+		fmt.Fprintln(w, "No operations recorded")
+		return
+	}
+
+	width := 0
+	for _, r := range records {
+		width = max(width, len(r.Package))
+	}
+	for _, r := range records {
+		pkg := r.Package
+		if pkg == "" {
+			pkg = "-"
+		}
+		fmt.Fprintf(w, "%s  %s  %s  %-*s  %s\n",
+			r.Time.Local().Format("2006-01-02 15:04:05"),
+			accent(shortCheckpointID(r.PlanID)),
+			historyResultText(r.Result),
+			max(width, 1), pkg,
+			r.Operation)
+		if r.Error != "" {
+			fmt.Fprintf(w, "  %s\n", dim(r.Error))
+		}
+	}
+}
+
+// historyResultText pads and colors an audit result for display.
+func historyResultText(result dot.AuditResult) string {
+	padded := fmt.Sprintf("%-15s", result)
+	switch result {
+	case dot.AuditSucceeded:
+		return success(padded)
+	case dot.AuditFailed, dot.AuditRollbackFailed:
+		return errorText(padded)
+	default:
+		return warning(padded)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+func TestHistoryCommand(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	packageDir := filepath.Join(tmpDir, "packages")
+	targetDir := filepath.Join(tmpDir, "target")
+	require.NoError(t, os.MkdirAll(filepath.Join(packageDir, "vim"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(packageDir, "zsh"), 0755))
+	require.NoError(t, os.MkdirAll(targetDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(packageDir, "vim", "dot-vimrc"), []byte("set nocompatible"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(packageDir, "zsh", "dot-zshrc"), []byte("bindkey -v"), 0644))
+
+	out, err := runDot(t, "--dir", packageDir, "--target", targetDir, "history")
+	require.NoError(t, err)
+	assert.Contains(t, out, "No operations recorded")
+
+	_, err = runDot(t, "--dir", packageDir, "--target", targetDir, "manage", "vim", "zsh")
+	require.NoError(t, err)
+
+	out, err = runDot(t, "--dir", packageDir, "--target", targetDir, "history", "--package", "vim", "--format", "json")
+	require.NoError(t, err)
+	var records []dot.AuditRecord
+	require.NoError(t, json.Unmarshal([]byte(out), &records))
+	require.NotEmpty(t, records)
+	for _, r := range records {
+		assert.Equal(t, "vim", r.Package)
+		assert.Equal(t, dot.AuditSucceeded, r.Result)
+	}
+
+	out, err = runDot(t, "--dir", packageDir, "--target", targetDir, "history", "--since", "1h")
+	require.NoError(t, err)
+	assert.Contains(t, out, ".vimrc")
+	assert.Contains(t, out, ".zshrc")
+
+	out, err = runDot(t, "--dir", packageDir, "--target", targetDir, "history", "--until", "2000-01-01")
+	require.NoError(t, err)
+	assert.Contains(t, out, "No operations recorded")
+}
+
+func TestHistoryCommand_InvalidFlags(t *testing.T) {
+	_, err := runDot(t, "history", "--since", "yesterday")
+	assert.ErrorContains(t, err, "invalid --since")
+
+	_, err = runDot(t, "history", "--format", "xml")
+	assert.ErrorContains(t, err, "invalid format")
+}
+
+func TestParseHistoryTime(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	got, err := parseHistoryTime("2024-03-01T08:00:00Z", now, false)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC), got)
+
+	got, err = parseHistoryTime("2024-03-01", now, false)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local), got)
+
+	got, err = parseHistoryTime("2024-03-01", now, true)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 2, 0, 0, 0, 0, time.Local), got)
+
+	got, err = parseHistoryTime("7d", now, false)
+	require.NoError(t, err)
+	assert.Equal(t, now.AddDate(0, 0, -7), got)
+
+	got, err = parseHistoryTime("36h", now, false)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(-36*time.Hour), got)
+
+	_, err = parseHistoryTime("-1h", now, false)
+	assert.Error(t, err)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// newImportCommand creates the import command.
+func newImportCommand() *cobra.Command {
+	var fromStow, fromChezmoi, fromYadm string
+
+	cmd := &cobra.Command{
+		Use:   "import (--from-stow | --from-chezmoi | --from-yadm) DIR",
+		Short: "Convert dotfiles of another manager into packages",
+		Long: `Convert the dotfiles of GNU Stow, chezmoi or yadm into packages of the
+package directory and take over their files in the target directory.
+
+Files are copied into dot's layout: a leading dot of a file name is
+written as the dot- prefix and, with package name mapping, each top-level
+directory of the target directory becomes a package of its own, so
+~/dotfiles/vim/.vim/colors becomes dot-vim/colors. Files at the root of
+the target directory, such as .vimrc, cannot be placed with package name
+mapping; they are reported and left out.
+
+--from-stow imports a Stow directory. Links in the target directory that
+point into it are replaced with links to the new packages.
+
+--from-chezmoi imports a chezmoi source directory, usually
+~/.local/share/chezmoi. Attributes in file names are applied: dot_
+becomes a leading dot and private_, readonly_ and executable_ set the
+file's permissions. Scripts, templates, encrypted files and files listed
+in .chezmoiignore are reported and left out. Files chezmoi wrote to the
+target directory are replaced with links if they match the source.
+
+--from-yadm imports the files tracked by a yadm repository, usually
+~/.local/share/yadm/repo.git. Alternate files and yadm's own
+configuration are reported and left out.
+
+Without package name mapping, chezmoi and yadm files are grouped into a
+package per top-level entry of the target directory, such as vimrc and
+config. The new packages are managed and recorded in the manifest. The
+directory or repository imported from is not changed.
+
+Examples:
+  # Import the packages of ~/dotfiles
+  dot import --from-stow ~/dotfiles
+
+  # Import a chezmoi source directory
+  dot import --from-chezmoi ~/.local/share/chezmoi
+
+  # Preview the packages and files without changing anything
+  dot --dry-run import --from-yadm ~/.local/share/yadm/repo.git`,
+		Args: argsWithUsage(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch {
+			case fromChezmoi != "":
+				return runImport(cmd, fromChezmoi, (*dot.Client).ImportChezmoi)
+			case fromYadm != "":
+				return runImport(cmd, fromYadm, (*dot.Client).ImportYadm)
+			default:
+				return runImport(cmd, fromStow, (*dot.Client).ImportStow)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&fromStow, "from-stow", "", "GNU Stow directory to import")
+	cmd.Flags().StringVar(&fromChezmoi, "from-chezmoi", "", "chezmoi source directory to import")
+	cmd.Flags().StringVar(&fromYadm, "from-yadm", "", "yadm repository to import")
+	cmd.MarkFlagsOneRequired("from-stow", "from-chezmoi", "from-yadm")
+	cmd.MarkFlagsMutuallyExclusive("from-stow", "from-chezmoi", "from-yadm")
+	for _, name := range []string{"from-stow", "from-chezmoi", "from-yadm"} {
+		_ = cmd.MarkFlagDirname(name)
+	}
+
+	return cmd
+}
+
+// runImport handles the import command execution, importing dir with
+// importer.
+func runImport(cmd *cobra.Command, dir string, importer func(*dot.Client, context.Context, string) (dot.ImportReport, error)) error {
+	cfg, err := buildConfigWithCmd(cmd)
+	if err != nil {
+		return formatError(err)
+	}
+
+	client, err := dot.NewClient(cfg)
+	if err != nil {
+		return formatError(err)
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("resolve %s: %w", dir, err)
+	}
+
+	report, err := importer(client, ctx, absDir)
+	if err != nil {
+		return formatError(err)
+	}
+
+	if !globalCfg.quiet {
+		renderImportReport(cmd.OutOrStdout(), report, cfg.DryRun)
+	}
+	return nil
+}
+
+// renderImportReport prints the packages created by an import, the links
+// and files it replaced and the files it left out.
+func renderImportReport(w io.Writer, report dot.ImportReport, dryRun bool) {
+	if len(report.Packages) == 0 {
+		fmt.Fprintln(w, "Nothing to import")
+	}
+	verb := "Created"
+	if dryRun {
+		verb = "Would create"
+	}
+	for _, pkg := range report.Packages {
+		fmt.Fprintf(w, "%s %s from %s: %d files\n", verb, accent(pkg.Name), strings.Join(pkg.From, ", "), len(pkg.Files))
+	}
+	if len(report.Replaced) > 0 {
+		verb = "Replaced"
+		if dryRun {
+			verb = "Would replace"
+		}
+		what := report.From + " files"
+		if report.From == "stow" {
+			what = "stow links"
+		}
+		fmt.Fprintf(w, "%s %d %s\n", verb, len(report.Replaced), what)
+	}
+	for _, skip := range report.Skipped {
+		fmt.Fprintf(w, "  %s %s: %s\n", warning("-"), skip.Path, dim(skip.Reason))
+	}
+}
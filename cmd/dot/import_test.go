@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+func TestImportCommand_Flags(t *testing.T) {
+	cmd := newImportCommand()
+
+	for _, name := range []string{"from-stow", "from-chezmoi", "from-yadm"} {
+		require.NotNil(t, cmd.Flags().Lookup(name))
+	}
+	assert.Error(t, cmd.Args(cmd, []string{"extra"}))
+	assert.NoError(t, cmd.Args(cmd, []string{}))
+
+	// One source is required, and only one
+	assert.Error(t, cmd.ValidateFlagGroups())
+	require.NoError(t, cmd.Flags().Set("from-chezmoi", "/chezmoi"))
+	assert.NoError(t, cmd.ValidateFlagGroups())
+	require.NoError(t, cmd.Flags().Set("from-yadm", "/yadm"))
+	assert.Error(t, cmd.ValidateFlagGroups())
+}
+
+func TestRenderImportReport(t *testing.T) {
+	report := dot.ImportReport{
+		From: "stow",
+		Packages: []dot.ImportedPackage{
+			{Name: "dot-config", From: []string{"nvim", "kitty"}, Files: []string{"nvim/init.lua", "kitty/kitty.conf"}},
+		},
+		Replaced: []string{".config/nvim", ".config/kitty"},
+		Skipped:  []dot.ImportSkip{{Path: "zsh/.zshrc", Reason: "files at the root of the target directory need package_name_mapping disabled"}},
+	}
+
+	var buf bytes.Buffer
+	renderImportReport(&buf, report, false)
+	out := buf.String()
+	assert.Contains(t, out, "from nvim, kitty: 2 files")
+	assert.Contains(t, out, "Replaced 2 stow links")
+	assert.Contains(t, out, "zsh/.zshrc")
+
+	buf.Reset()
+	renderImportReport(&buf, report, true)
+	assert.Contains(t, buf.String(), "Would create")
+	assert.Contains(t, buf.String(), "Would replace 2 stow links")
+
+	buf.Reset()
+	report.From = "chezmoi"
+	renderImportReport(&buf, report, false)
+	assert.Contains(t, buf.String(), "Replaced 2 chezmoi files")
+
+	buf.Reset()
+	renderImportReport(&buf, dot.ImportReport{}, false)
+	assert.Contains(t, buf.String(), "Nothing to import")
+}
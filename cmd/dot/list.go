@@ -29,6 +29,13 @@ func newListCommand() *cobra.Command {
 		format, _ := cmd.Flags().GetString("format")
 		color, _ := cmd.Flags().GetString("color")
 		sortBy, _ := cmd.Flags().GetString("sort")
+		if !cmd.Flags().Changed("sort") && extCfg != nil && extCfg.Packages.SortBy != "" {
+			sortBy = extCfg.Packages.SortBy
+		}
+		opts := dot.ListOptions{}
+		opts.Installed, _ = cmd.Flags().GetBool("installed")
+		opts.Available, _ = cmd.Flags().GetBool("available")
+		opts.Pattern, _ = cmd.Flags().GetString("pattern")
 
 		// Create client
 		client, err := dot.NewClient(cfg)
@@ -37,7 +44,7 @@ func newListCommand() *cobra.Command {
 		}
 
 		// Get list of packages
-		packages, err := client.List(cmd.Context())
+		packages, err := client.ListWithOptions(cmd.Context(), opts)
 		if err != nil {
 			return formatError(err)
 		}
@@ -45,11 +52,6 @@ func newListCommand() *cobra.Command {
 		// Sort packages
 		sortPackages(packages, sortBy)
 
-		// Create status from packages
-		status := dot.Status{
-			Packages: packages,
-		}
-
 		// Determine colorization
 		colorize := shouldColorize(color)
 
@@ -76,7 +78,7 @@ func newListCommand() *cobra.Command {
 		}
 
 		// Render list
-		if err := r.RenderStatus(cmd.OutOrStdout(), status); err != nil {
+		if err := r.RenderPackageList(cmd.OutOrStdout(), packages); err != nil {
 			return fmt.Errorf("render failed: %w", err)
 		}
 
@@ -96,18 +98,30 @@ func NewListCommand(cfg *dot.Config) *cobra.Command {
 	var format string
 	var color string
 	var sortBy string
+	var installed, available bool
+	var pattern string
 
 	cmd := &cobra.Command{
 		Use:   "list",
-		Short: "List all installed packages",
-		Long: `Display information about all installed packages.
+		Short: "List installed and available packages",
+		Long: `Display information about installed and available packages.
 
-Shows package name, link count, and installation timestamp for all
-packages currently managed by dot. The list can be sorted by various
-fields and displayed in multiple output formats.`,
+Shows every package in the package directory, whether it is installed,
+and for installed packages the source (managed or adopted), link count,
+and installation time. Use --installed or --available to show only one
+group, and --pattern to filter package names with a glob.
+
+Packages are sorted by the packages.sort_by configuration value unless
+--sort is given.`,
 		Example: `  # List all packages
   dot list
 
+  # List packages that are not installed yet
+  dot list --available
+
+  # List installed packages whose name starts with "vim"
+  dot list --installed --pattern 'vim*'
+
   # List packages sorted by link count
   dot list --sort=links
 
@@ -124,7 +138,10 @@ fields and displayed in multiple output formats.`,
 
 	cmd.Flags().StringVarP(&format, "format", "f", "table", "Output format (text, json, yaml, table)")
 	cmd.Flags().StringVar(&color, "color", "auto", "Colorize output (auto, always, never)")
-	cmd.Flags().StringVar(&sortBy, "sort", "name", "Sort by field (name, links, date)")
+	cmd.Flags().StringVar(&sortBy, "sort", "name", "Sort by field (name, links, date); defaults to packages.sort_by")
+	cmd.Flags().BoolVar(&installed, "installed", false, "Show only installed packages")
+	cmd.Flags().BoolVar(&available, "available", false, "Show only packages that are not installed")
+	cmd.Flags().StringVar(&pattern, "pattern", "", "Show only packages whose name matches the glob")
 
 	return cmd
 }
@@ -133,20 +150,20 @@ fields and displayed in multiple output formats.`,
 func sortPackages(packages []dot.PackageInfo, sortBy string) {
 	switch sortBy {
 	case "name":
-		sort.Slice(packages, func(i, j int) bool {
+		sort.SliceStable(packages, func(i, j int) bool {
 			return packages[i].Name < packages[j].Name
 		})
 	case "links":
-		sort.Slice(packages, func(i, j int) bool {
+		sort.SliceStable(packages, func(i, j int) bool {
 			return packages[i].LinkCount > packages[j].LinkCount // Descending
 		})
 	case "date":
-		sort.Slice(packages, func(i, j int) bool {
+		sort.SliceStable(packages, func(i, j int) bool {
 			return packages[i].InstalledAt.After(packages[j].InstalledAt) // Most recent first
 		})
 	default:
 		// Default to name sorting
-		sort.Slice(packages, func(i, j int) bool {
+		sort.SliceStable(packages, func(i, j int) bool {
 			return packages[i].Name < packages[j].Name
 		})
 	}
@@ -37,6 +37,10 @@ func TestListCommand_Flags(t *testing.T) {
 	sortFlag := cmd.Flags().Lookup("sort")
 	require.NotNil(t, sortFlag)
 	assert.Equal(t, "name", sortFlag.DefValue)
+
+	for _, name := range []string{"installed", "available", "pattern"} {
+		assert.NotNil(t, cmd.Flags().Lookup(name), name)
+	}
 }
 
 func TestListCommand_Help(t *testing.T) {
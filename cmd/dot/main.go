@@ -20,6 +20,7 @@ func main() {
 
 	// Execute command
 	executedCmd, err := executeCommand(rootCmd)
+	flushTelemetry(os.Stderr, executedCmd, err)
 	if err != nil {
 		// Show usage for argument validation errors
 		// (Flag errors are handled by SetFlagErrorFunc in root.go)
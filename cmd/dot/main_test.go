@@ -1,11 +1,36 @@
 package main
 
 import (
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 )
 
+// TestMain keeps execution checkpoints and scanned package trees written by
+// command tests out of the real state and cache directories.
+func TestMain(m *testing.M) {
+	stateDir, err := os.MkdirTemp("", "dot-state-*")
+	if err != nil {
+		panic(err)
+	}
+	if err := os.Setenv("XDG_STATE_HOME", stateDir); err != nil {
+		panic(err)
+	}
+	cacheDir, err := os.MkdirTemp("", "dot-cache-*")
+	if err != nil {
+		panic(err)
+	}
+	if err := os.Setenv("XDG_CACHE_HOME", cacheDir); err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+	_ = os.RemoveAll(stateDir)
+	_ = os.RemoveAll(cacheDir)
+	os.Exit(code)
+}
+
 func TestMain_Exists(t *testing.T) {
 	// This test verifies that main function exists and can be referenced.
 	// Actual CLI testing happens through command tests.
@@ -3,11 +3,9 @@ package main
 import (
 	"context"
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 
-	"github.com/jamesainslie/dot/internal/cli/renderer"
 	"github.com/jamesainslie/dot/pkg/dot"
 )
 
@@ -23,6 +21,8 @@ directory to the target directory.`,
 		ValidArgsFunction: packageCompletion(false), // Complete with available packages
 	}
 
+	addPlanFormatFlag(cmd)
+
 	return cmd
 }
 
@@ -34,9 +34,11 @@ func runManage(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	// Load extended config for table_style
-	configPath := getConfigFilePath()
-	extCfg, _ := loadConfigWithRepoPriority(configPath)
+	format, err := requestedPlanFormat(cmd, cfg.DryRun)
+	if err != nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Error: %v\n", err)
+		return err
+	}
 
 	client, err := dot.NewClient(cfg)
 	if err != nil {
@@ -59,18 +61,10 @@ func runManage(cmd *cobra.Command, args []string) error {
 			return err
 		}
 
-		// Create renderer and render the plan with table_style from config
-		tableStyle := ""
-		if extCfg != nil {
-			tableStyle = extCfg.Output.TableStyle
+		if format == "" {
+			format = "text"
 		}
-		rend, err := renderer.NewRenderer("text", true, tableStyle)
-		if err != nil {
-			fmt.Fprintf(cmd.ErrOrStderr(), "Error: %v\n", err)
-			return err
-		}
-
-		if err := rend.RenderPlan(os.Stdout, plan); err != nil {
+		if err := renderPlan(cmd, format, plan); err != nil {
 			fmt.Fprintf(cmd.ErrOrStderr(), "Error: %v\n", err)
 			return err
 		}
@@ -79,6 +73,10 @@ func runManage(cmd *cobra.Command, args []string) error {
 	}
 
 	// Normal execution
+	if err := recoverInterrupted(cmd, client, ctx); err != nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Error: %v\n", err)
+		return err
+	}
 	if err := client.Manage(ctx, packages...); err != nil {
 		fmt.Fprintf(cmd.ErrOrStderr(), "Error: %v\n", err)
 		return err
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// newManifestCommand creates the manifest command group.
+func newManifestCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "manifest",
+		Short: "Export and import the manifest",
+		Long: `Convert the manifest of the target directory to and from a portable
+YAML or JSON document.
+
+The portable document records the installed packages, their links and
+directories, rendered templates, content hashes and the repository they
+were cloned from. Every path in it is relative to the target directory,
+the package directory or the template cache, so the document can be
+inspected with standard tools, kept as a backup, or imported on another
+machine or into another manifest directory.`,
+		Example: `  # Inspect the manifest
+  dot manifest export
+
+  # Back it up as JSON and list the packages with jq
+  dot manifest export --format json --output manifest.json
+  jq -r '.packages[].name' manifest.json
+
+  # Restore it
+  dot manifest import --force manifest.json`,
+		Args: argsWithUsage(cobra.NoArgs),
+	}
+
+	cmd.AddCommand(
+		newManifestExportCommand(),
+		newManifestImportCommand(),
+	)
+
+	return cmd
+}
+
+// newManifestExportCommand creates the export subcommand.
+func newManifestExportCommand() *cobra.Command {
+	var format, output string
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Write the manifest in the portable format",
+		Long: `Write the manifest of the target directory as a portable YAML or JSON
+document, to standard output or to the file named by --output.
+
+Packages are sorted by name. File sizes and modification times are not
+exported: they describe this machine only, and files are hashed again
+after an import.`,
+		Args: argsWithUsage(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runManifestExport(cmd, format, output)
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "yaml", "output format (yaml, json)")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "file to write instead of standard output")
+	_ = cmd.MarkFlagFilename("output", "yaml", "yml", "json")
+
+	return cmd
+}
+
+// runManifestExport handles the manifest export command execution.
+func runManifestExport(cmd *cobra.Command, format, output string) error {
+	if format != "yaml" && format != "json" {
+		return fmt.Errorf("invalid format %q: use yaml or json", format)
+	}
+
+	cfg, err := buildConfigWithCmd(cmd)
+	if err != nil {
+		return formatError(err)
+	}
+	client, err := dot.NewClient(cfg)
+	if err != nil {
+		return formatError(err)
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	portable, err := client.ExportManifest(ctx)
+	if err != nil {
+		return formatError(err)
+	}
+
+	var buf bytes.Buffer
+	if err := encodePortableManifest(&buf, portable, format); err != nil {
+		return err
+	}
+	if output == "" {
+		_, err = cmd.OutOrStdout().Write(buf.Bytes())
+		return err
+	}
+	if err := os.WriteFile(output, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	if !globalCfg.quiet {
+		fmt.Fprintf(cmd.OutOrStdout(), "Exported %d packages to %s\n", len(portable.Packages), output)
+	}
+	return nil
+}
+
+// encodePortableManifest writes p to w in format, yaml or json.
+func encodePortableManifest(w io.Writer, p dot.PortableManifest, format string) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(p)
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(p); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// newManifestImportCommand creates the import subcommand.
+func newManifestImportCommand() *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "import FILE",
+		Short: "Replace the manifest with a portable one",
+		Long: `Replace the manifest of the target directory with a portable document
+written by 'dot manifest export', read from FILE or from standard input
+when FILE is -. JSON and YAML are both accepted.
+
+Paths are resolved against the package directory and template cache of
+this machine. Only the manifest is written: the links it records are
+expected to exist already, which 'dot doctor' checks.
+
+A manifest that already records packages is replaced only with --force.`,
+		Example: `  # Import a backup into an empty target directory
+  dot manifest import manifest.yaml
+
+  # Carry the manifest over to a home directory restored elsewhere
+  dot manifest export | dot --target /mnt/home/alex manifest import -
+
+  # Show what would be imported
+  dot --dry-run manifest import --force manifest.json`,
+		Args: argsWithUsage(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runManifestImport(cmd, args[0], force)
+		},
+	}
+
+	cmd.Flags().BoolVar(&force, "force", false, "replace a manifest that already records packages")
+
+	return cmd
+}
+
+// runManifestImport handles the manifest import command execution.
+func runManifestImport(cmd *cobra.Command, file string, force bool) error {
+	portable, err := readPortableManifest(cmd, file)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := buildConfigWithCmd(cmd)
+	if err != nil {
+		return formatError(err)
+	}
+	client, err := dot.NewClient(cfg)
+	if err != nil {
+		return formatError(err)
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	result, err := client.ImportManifest(ctx, portable, dot.ManifestImportOptions{Force: force})
+	if err != nil {
+		return formatError(err)
+	}
+
+	if !globalCfg.quiet {
+		renderManifestImportResult(cmd.OutOrStdout(), result)
+	}
+	return nil
+}
+
+// readPortableManifest parses the JSON or YAML portable manifest in file,
+// or in standard input when file is -.
+func readPortableManifest(cmd *cobra.Command, file string) (dot.PortableManifest, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if file == "-" {
+		data, err = io.ReadAll(cmd.InOrStdin())
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return dot.PortableManifest{}, fmt.Errorf("read manifest: %w", err)
+	}
+
+	var portable dot.PortableManifest
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		err = json.Unmarshal(data, &portable)
+	} else {
+		err = yaml.Unmarshal(data, &portable)
+	}
+	if err != nil {
+		return dot.PortableManifest{}, fmt.Errorf("parse manifest: %w", err)
+	}
+	return portable, nil
+}
+
+// renderManifestImportResult prints the packages of an imported manifest.
+func renderManifestImportResult(w io.Writer, result dot.ManifestImportResult) {
+	verb := "Imported"
+	if result.DryRun {
+		verb = "Would import"
+	}
+	fmt.Fprintf(w, "%s %d packages\n", verb, len(result.Packages))
+	if len(result.Packages) > 0 {
+		fmt.Fprintf(w, "  %s %s\n", dim("packages:"), accent(strings.Join(result.Packages, ", ")))
+	}
+	if len(result.Replaced) > 0 {
+		fmt.Fprintf(w, "  %s %s\n", dim("replaced:"), strings.Join(result.Replaced, ", "))
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManifestCommand_ExportImport(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	packageDir := filepath.Join(tmpDir, "packages")
+	targetDir := filepath.Join(tmpDir, "target")
+	require.NoError(t, os.MkdirAll(filepath.Join(packageDir, "vim"), 0755))
+	require.NoError(t, os.MkdirAll(targetDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(packageDir, "vim", "dot-vimrc"), []byte("set nocompatible"), 0644))
+	_, err := runDot(t, "--dir", packageDir, "--target", targetDir, "manage", "vim")
+	require.NoError(t, err)
+
+	out, err := runDot(t, "--dir", packageDir, "--target", targetDir, "manifest", "export")
+	require.NoError(t, err)
+	assert.Contains(t, out, "format: dot-manifest")
+	assert.Contains(t, out, "- name: vim")
+	assert.NotContains(t, out, tmpDir)
+
+	file := filepath.Join(tmpDir, "manifest.json")
+	out, err = runDot(t, "--dir", packageDir, "--target", targetDir, "manifest", "export", "--format", "json", "--output", file)
+	require.NoError(t, err)
+	assert.Contains(t, out, "Exported 1 packages to "+file)
+
+	_, err = runDot(t, "--dir", packageDir, "--target", targetDir, "manifest", "import", file)
+	assert.ErrorContains(t, err, "already records 1 package(s)")
+
+	// Move the manifest to a fresh manifest directory
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	out, err = runDot(t, "--dir", packageDir, "--target", targetDir, "--dry-run", "manifest", "import", file)
+	require.NoError(t, err)
+	assert.Contains(t, out, "Would import 1 packages")
+
+	out, err = runDot(t, "--dir", packageDir, "--target", targetDir, "manifest", "import", file)
+	require.NoError(t, err)
+	assert.Contains(t, out, "Imported 1 packages")
+
+	out, err = runDot(t, "--dir", packageDir, "--target", targetDir, "list")
+	require.NoError(t, err)
+	assert.Contains(t, out, "vim")
+
+	_, err = runDot(t, "--dir", packageDir, "--target", targetDir, "manifest", "export", "--format", "toml")
+	assert.ErrorContains(t, err, `invalid format "toml"`)
+}
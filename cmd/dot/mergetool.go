@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// newMergetoolCommand creates the mergetool command group.
+func newMergetoolCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mergetool",
+		Short: "Resolve merge conflicts in dot state files",
+		Long: `Resolve git merge conflicts in state files kept in a repository.
+
+dot state files are JSON documents, so a line-based merge of two versions
+often conflicts even when the changes are independent. These commands
+merge the documents entry by entry instead.`,
+		Args: argsWithUsage(cobra.NoArgs),
+	}
+
+	cmd.AddCommand(newMergetoolManifestCommand())
+
+	return cmd
+}
+
+// newMergetoolManifestCommand creates the manifest subcommand.
+func newMergetoolManifestCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "manifest FILE | BASE LOCAL REMOTE [MERGED]",
+		Short: "Merge diverged versions of the manifest",
+		Long: `Merge two versions of .dot-manifest.json package by package.
+
+With one argument, FILE is a manifest left with conflict markers by git
+and is rewritten in place. Set merge.conflictStyle to diff3 so that the
+common ancestor is included; without it, packages removed on one side
+are kept.
+
+With three or four arguments, BASE is the common ancestor and LOCAL and
+REMOTE the two versions. The result is written to MERGED, or to LOCAL if
+MERGED is omitted, as git expects of a merge driver.
+
+Packages and hashes changed on one side take that side's version. A
+package changed on both sides keeps the links of both, and its hash is
+dropped so the next remanage recomputes it. The command fails if the
+repository information changed on both sides; the local version is
+written in that case.
+
+Examples:
+  # Resolve a conflicted manifest after git merge
+  dot mergetool manifest .dot-manifest.json
+
+  # Use as a git merge driver (add to .gitattributes:
+  #   .dot-manifest.json merge=dot-manifest)
+  git config merge.dot-manifest.driver 'dot mergetool manifest %O %A %B'
+
+  # Use as a git mergetool
+  git config mergetool.dot-manifest.cmd 'dot mergetool manifest "$BASE" "$LOCAL" "$REMOTE" "$MERGED"'`,
+		Args: argsWithUsage(func(cmd *cobra.Command, args []string) error {
+			if n := len(args); n != 1 && n != 3 && n != 4 {
+				return fmt.Errorf("accepts 1, 3, or 4 arg(s), received %d", n)
+			}
+			return nil
+		}),
+		RunE: runMergetoolManifest,
+	}
+}
+
+// runMergetoolManifest handles the mergetool manifest command execution.
+func runMergetoolManifest(cmd *cobra.Command, args []string) error {
+	var (
+		result dot.ManifestMergeResult
+		output string
+		err    error
+	)
+
+	if len(args) == 1 {
+		output = args[0]
+		data, readErr := os.ReadFile(output)
+		if readErr != nil {
+			return fmt.Errorf("read manifest: %w", readErr)
+		}
+		result, err = dot.MergeConflictedManifest(data)
+	} else {
+		var sides [3][]byte
+		for i, path := range args[:3] {
+			// A side without the file, for example when both sides add it,
+			// is passed by git as an empty file
+			sides[i], err = os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("read manifest: %w", err)
+			}
+		}
+		output = args[1]
+		if len(args) == 4 {
+			output = args[3]
+		}
+		result, err = dot.MergeManifests(sides[0], sides[1], sides[2])
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(output, result.Merged, 0644); err != nil {
+		return fmt.Errorf("write merged manifest: %w", err)
+	}
+
+	renderManifestMergeResult(cmd.ErrOrStderr(), result)
+	if len(result.Conflicts) > 0 {
+		return fmt.Errorf("manifest has %d unresolved conflict(s); edit %s to finish the merge", len(result.Conflicts), output)
+	}
+	return nil
+}
+
+// renderManifestMergeResult reports merge decisions worth reviewing.
+func renderManifestMergeResult(w io.Writer, result dot.ManifestMergeResult) {
+	if result.TwoWay {
+		fmt.Fprintln(w, warning("No common ancestor in conflict markers; packages removed on one side were kept"))
+		fmt.Fprintln(w, dim("Set 'git config merge.conflictStyle diff3' for three-way merges"))
+	}
+	for _, note := range result.Resolved {
+		fmt.Fprintf(w, "  %s\n", note)
+	}
+	for _, conflict := range result.Conflicts {
+		fmt.Fprintf(w, "  %s %s\n", warning("conflict:"), conflict)
+	}
+	if len(result.Conflicts) == 0 {
+		fmt.Fprintln(w, success("Merged manifest"))
+	}
+}
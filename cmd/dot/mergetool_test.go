@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const mergetoolBase = `{"schema_version": 1, "version": "1.0", "packages": {"vim": {"name": "vim", "links": [".vimrc"], "link_count": 1}}, "hashes": {"vim": "a"}}`
+
+func TestMergetoolManifestCommand_Args(t *testing.T) {
+	cmd := newMergetoolManifestCommand()
+	assert.NoError(t, cmd.Args(cmd, []string{"a"}))
+	assert.NoError(t, cmd.Args(cmd, []string{"a", "b", "c"}))
+	assert.NoError(t, cmd.Args(cmd, []string{"a", "b", "c", "d"}))
+	assert.Error(t, cmd.Args(cmd, []string{"a", "b"}))
+	assert.Error(t, cmd.Args(cmd, nil))
+}
+
+func TestMergetoolManifest_MergeDriver(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+	base := write("base", mergetoolBase)
+	local := write("local", `{"schema_version": 1, "version": "1.0", "packages": {"vim": {"name": "vim", "links": [".vimrc"], "link_count": 1}, "zsh": {"name": "zsh", "links": [".zshrc"], "link_count": 1}}, "hashes": {"vim": "a", "zsh": "z"}}`)
+	remote := write("remote", `{"schema_version": 1, "version": "1.0", "packages": {"vim": {"name": "vim", "links": [".vimrc"], "link_count": 1}}, "hashes": {"vim": "b"}}`)
+
+	cmd := newMergetoolCommand()
+	var stderr bytes.Buffer
+	cmd.SetErr(&stderr)
+	cmd.SetArgs([]string{"manifest", base, local, remote})
+	require.NoError(t, cmd.Execute())
+
+	merged, err := os.ReadFile(local)
+	require.NoError(t, err)
+	assert.Contains(t, string(merged), `"zsh"`)
+	assert.Contains(t, string(merged), `"vim": "b"`)
+	assert.Contains(t, stderr.String(), "Merged manifest")
+}
+
+func TestMergetoolManifest_ConflictMarkers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".dot-manifest.json")
+	conflicted := "<<<<<<< HEAD\n" +
+		`{"schema_version": 1, "version": "1.0", "packages": {"vim": {"name": "vim", "links": [".vimrc"], "link_count": 1}}, "hashes": {"vim": "a"}, "repository": {"url": "u", "branch": "main", "cloned_at": "2026-01-01T00:00:00Z", "commit_sha": "b"}}` + "\n" +
+		"||||||| base\n" +
+		`{"schema_version": 1, "version": "1.0", "packages": {}, "hashes": {}, "repository": {"url": "u", "branch": "main", "cloned_at": "2026-01-01T00:00:00Z", "commit_sha": "a"}}` + "\n" +
+		"=======\n" +
+		`{"schema_version": 1, "version": "1.0", "packages": {"zsh": {"name": "zsh", "links": [".zshrc"], "link_count": 1}}, "hashes": {"zsh": "z"}, "repository": {"url": "u", "branch": "main", "cloned_at": "2026-01-01T00:00:00Z", "commit_sha": "c"}}` + "\n" +
+		">>>>>>> theirs\n"
+	require.NoError(t, os.WriteFile(path, []byte(conflicted), 0644))
+
+	cmd := newMergetoolCommand()
+	var stderr bytes.Buffer
+	cmd.SetErr(&stderr)
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	cmd.SetArgs([]string{"manifest", path})
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 unresolved conflict")
+	assert.Contains(t, stderr.String(), "repository: changed on both sides")
+
+	merged, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(merged), "<<<<<<<")
+	assert.Contains(t, string(merged), `"vim"`)
+	assert.Contains(t, string(merged), `"zsh"`)
+}
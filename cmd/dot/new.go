@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// newNewCommand creates the new command.
+func newNewCommand() *cobra.Command {
+	var opts dot.NewPackageOptions
+
+	cmd := &cobra.Command{
+		Use:   "new PACKAGE",
+		Short: "Create a new package",
+		Long: `Create a new package in the package directory.
+
+The package is named for the directory it links into: a leading dot is
+written as the dot- prefix, and names without one are given the prefix
+too, so 'vim' and '.vim' both create dot-vim, linked into ~/.vim. Pass
+--no-prefix for packages linked into a directory without a leading dot,
+such as bin.
+
+The package starts with a .dotignore listing files of the package that
+are not linked, such as notes or scripts. Files given with --file are
+created empty, named as in the target directory; --template creates them
+as templates. If the repository has a .dotbootstrap.yaml, the package is
+added to its package list.
+
+Examples:
+  # Create dot-zsh with an empty ~/.zsh/.zshrc
+  dot new zsh --file .zshrc
+
+  # Create a package whose gitconfig is rendered from a template
+  dot new git --file .gitconfig --template
+
+  # Keep notes in the package without linking them
+  dot new nvim --ignore '*.md'
+
+  # Create a package linked into ~/bin
+  dot new bin --no-prefix`,
+		Args: argsWithUsage(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runNew(cmd, args[0], opts)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+
+	cmd.Flags().StringArrayVar(&opts.Files, "file", nil, "create an empty file, named as in the target directory (repeatable)")
+	cmd.Flags().BoolVar(&opts.Template, "template", false, "create the files as templates")
+	cmd.Flags().StringArrayVar(&opts.Ignore, "ignore", nil, "pattern to add to the package's .dotignore (repeatable)")
+	cmd.Flags().BoolVar(&opts.NoPrefix, "no-prefix", false, "do not add the dot- prefix to the package name")
+
+	return cmd
+}
+
+// runNew handles the new command execution.
+func runNew(cmd *cobra.Command, name string, opts dot.NewPackageOptions) error {
+	cfg, err := buildConfigWithCmd(cmd)
+	if err != nil {
+		return formatError(err)
+	}
+
+	client, err := dot.NewClient(cfg)
+	if err != nil {
+		return formatError(err)
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	result, err := client.NewPackage(ctx, name, opts)
+	if err != nil {
+		return formatError(err)
+	}
+
+	if !globalCfg.quiet {
+		renderNewPackage(cmd.OutOrStdout(), result, cfg.DryRun)
+	}
+	return nil
+}
+
+// renderNewPackage prints the created package and its files.
+func renderNewPackage(w io.Writer, result dot.NewPackageResult, dryRun bool) {
+	verb := "Created"
+	if dryRun {
+		verb = "Would create"
+	}
+	fmt.Fprintf(w, "%s package %s at %s\n", verb, accent(result.Package), result.Path)
+	for _, file := range result.Created {
+		fmt.Fprintf(w, "  %s\n", file)
+	}
+	if result.Registered && dryRun {
+		fmt.Fprintln(w, "Would add it to .dotbootstrap.yaml")
+	} else if result.Registered {
+		fmt.Fprintln(w, "Added it to .dotbootstrap.yaml")
+	}
+}
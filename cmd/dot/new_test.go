@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+func TestNewCommand_Flags(t *testing.T) {
+	cmd := newNewCommand()
+
+	for _, name := range []string{"file", "template", "ignore", "no-prefix"} {
+		require.NotNil(t, cmd.Flags().Lookup(name), name)
+	}
+	assert.Error(t, cmd.Args(cmd, []string{}))
+	assert.NoError(t, cmd.Args(cmd, []string{"zsh"}))
+}
+
+func TestRenderNewPackage(t *testing.T) {
+	result := dot.NewPackageResult{
+		Package:    "dot-zsh",
+		Path:       "/dotfiles/dot-zsh",
+		Created:    []string{".dotignore", "dot-zshrc"},
+		Registered: true,
+	}
+
+	var buf bytes.Buffer
+	renderNewPackage(&buf, result, false)
+	out := buf.String()
+	assert.Contains(t, out, "Created package")
+	assert.Contains(t, out, "/dotfiles/dot-zsh")
+	assert.Contains(t, out, "  dot-zshrc\n")
+	assert.Contains(t, out, "Added it to .dotbootstrap.yaml")
+
+	buf.Reset()
+	renderNewPackage(&buf, result, true)
+	assert.Contains(t, buf.String(), "Would create package")
+	assert.Contains(t, buf.String(), "Would add it to .dotbootstrap.yaml")
+}
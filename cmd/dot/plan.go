@@ -0,0 +1,419 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/jamesainslie/dot/internal/cli/renderer"
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// planMatrixOptions holds the flags of the plan command.
+type planMatrixOptions struct {
+	allProfiles  bool
+	allPlatforms bool
+	format       string
+	graph        string
+}
+
+// newPlanCommand creates the plan command group.
+func newPlanCommand() *cobra.Command {
+	var opts planMatrixOptions
+
+	cmd := &cobra.Command{
+		Use:   "plan",
+		Short: "Work with plans written by --dry-run --format",
+		Long: `Work with machine-readable plans.
+
+Commands that change the target directory print the plan they would
+apply with --dry-run --format json or --format yaml. These commands
+operate on such a plan file.
+
+With --all-profiles or --all-platforms, plan computes what manage would
+do for each profile and platform of the repository's .dotbootstrap.yaml
+and prints the results as a matrix. Each plan runs against an empty
+target directory, in memory, so it shows what a fresh machine would get
+and nothing is changed. --all-profiles covers every profile instead of
+the default one, the global --profile names one profile, and
+--all-platforms covers every platform packages are restricted to instead
+of the running one. Templates are rendered for the platform of each plan. The command fails if any plan fails.
+
+With --graph dot or --graph mermaid, plan prints the dependency graph of
+the plan in FILE as Graphviz DOT or a Mermaid flowchart. Each operation
+is a node colored by the batch it runs in, and each edge points from an
+operation to one that waits for it. Operations in the same batch may run
+in parallel. Use - as FILE to read the plan from standard input.
+
+Examples:
+  # See the blast radius of a change before pushing it
+  dot plan --all-profiles --all-platforms
+
+  # Compare in CI
+  dot plan --all-profiles --all-platforms --format json > matrix.json
+
+  # Check one profile on every platform
+  dot --profile work plan --all-platforms
+
+  # See why operations are ordered the way they are
+  dot --dry-run manage vim --format json | dot plan --graph dot - | dot -Tsvg > plan.svg`,
+		Args: argsWithUsage(cobra.MaximumNArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.graph != "" {
+				if len(args) != 1 {
+					return fmt.Errorf("--graph requires a plan FILE")
+				}
+				return runPlanGraph(cmd, opts.graph, args[0])
+			}
+			if len(args) > 0 {
+				return fmt.Errorf("unknown command %q for \"dot plan\"", args[0])
+			}
+			profile := profileFlag(cmd)
+			if profile != "" && opts.allProfiles {
+				return fmt.Errorf("--profile cannot be combined with --all-profiles")
+			}
+			if !opts.allProfiles && !opts.allPlatforms && profile == "" {
+				return cmd.Help()
+			}
+			return runPlanMatrix(cmd, opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.allProfiles, "all-profiles", false, "plan every bootstrap profile")
+	cmd.Flags().BoolVar(&opts.allPlatforms, "all-platforms", false, "plan every platform of the bootstrap configuration")
+	cmd.Flags().StringVar(&opts.format, "format", "text", "output format for the matrix (text, json, yaml)")
+	cmd.Flags().StringVar(&opts.graph, "graph", "", "print the dependency graph of a plan FILE (dot, mermaid)")
+
+	cmd.AddCommand(newPlanValidateCommand(), newPlanTraceCommand())
+
+	return cmd
+}
+
+// runPlanGraph handles the plan command with --graph.
+func runPlanGraph(cmd *cobra.Command, format, file string) error {
+	if format != "dot" && format != "mermaid" {
+		return fmt.Errorf("invalid graph format %q: use dot or mermaid", format)
+	}
+
+	plan, err := readPlanFile(cmd, file)
+	if err != nil {
+		return err
+	}
+
+	graph := plan.ToDOT()
+	if format == "mermaid" {
+		graph = plan.ToMermaid()
+	}
+	_, err = io.WriteString(cmd.OutOrStdout(), graph)
+	return err
+}
+
+// runPlanMatrix handles the plan command with --all-profiles or
+// --all-platforms.
+func runPlanMatrix(cmd *cobra.Command, opts planMatrixOptions) error {
+	if opts.format != "text" && opts.format != "json" && opts.format != "yaml" {
+		return fmt.Errorf("invalid format %q: use text, json or yaml", opts.format)
+	}
+
+	cfg, err := buildConfigWithCmd(cmd)
+	if err != nil {
+		return formatError(err)
+	}
+	client, err := dot.NewClient(cfg)
+	if err != nil {
+		return formatError(err)
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	matrix, err := client.PlanMatrix(ctx, dot.PlanMatrixOptions{
+		AllProfiles:  opts.allProfiles,
+		AllPlatforms: opts.allPlatforms,
+		Profile:      profileFlag(cmd),
+	})
+	if err != nil {
+		return formatError(err)
+	}
+
+	out := cmd.OutOrStdout()
+	switch opts.format {
+	case "json":
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(matrix); err != nil {
+			return err
+		}
+	case "yaml":
+		enc := yaml.NewEncoder(out)
+		enc.SetIndent(2)
+		if err := enc.Encode(matrix); err != nil {
+			return err
+		}
+		if err := enc.Close(); err != nil {
+			return err
+		}
+	default:
+		renderPlanMatrix(out, matrix)
+	}
+
+	if failed := matrix.Failed(); len(failed) > 0 {
+		return fmt.Errorf("%d of %d plan(s) failed", len(failed), len(matrix.Entries))
+	}
+	return nil
+}
+
+// renderPlanMatrix prints the operation counts of a plan matrix as a
+// table of profiles by platforms, followed by the packages of each plan.
+func renderPlanMatrix(w io.Writer, matrix dot.PlanMatrix) {
+	cells := make(map[string]string, len(matrix.Entries))
+	for _, entry := range matrix.Entries {
+		cell := fmt.Sprintf("%d ops", entry.Operations)
+		if entry.Error != "" {
+			cell = "failed"
+		}
+		cells[entry.Profile+"\x00"+entry.Platform] = cell
+	}
+
+	width := len("PROFILE")
+	for _, profile := range matrix.Profiles {
+		width = max(width, len(matrixProfileName(profile)))
+	}
+	columns := make([]int, len(matrix.Platforms))
+	for i, platform := range matrix.Platforms {
+		columns[i] = len(platform)
+		for _, profile := range matrix.Profiles {
+			columns[i] = max(columns[i], len(cells[profile+"\x00"+platform]))
+		}
+	}
+
+	fmt.Fprintf(w, "%s\n\n", bold(fmt.Sprintf("Plan matrix: %d profile(s) × %d platform(s), against an empty target",
+		len(matrix.Profiles), len(matrix.Platforms))))
+	fmt.Fprintf(w, "  %-*s", width, "PROFILE")
+	for i, platform := range matrix.Platforms {
+		fmt.Fprintf(w, "  %-*s", columns[i], platform)
+	}
+	fmt.Fprintln(w)
+	for _, profile := range matrix.Profiles {
+		fmt.Fprintf(w, "  %-*s", width, matrixProfileName(profile))
+		for i, platform := range matrix.Platforms {
+			cell := cells[profile+"\x00"+platform]
+			padded := fmt.Sprintf("%-*s", columns[i], cell)
+			if cell == "failed" {
+				padded = errorText(padded)
+			}
+			fmt.Fprintf(w, "  %s", padded)
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintln(w)
+	for _, entry := range matrix.Entries {
+		name := matrixProfileName(entry.Profile) + " on " + entry.Platform
+		switch {
+		case entry.Error != "":
+			fmt.Fprintf(w, "%s %s: %s\n", errorText("✗"), bold(name), entry.Error)
+		case len(entry.Packages) == 0:
+			fmt.Fprintf(w, "%s %s: %s\n", dim("•"), bold(name), dim("no packages"))
+		default:
+			fmt.Fprintf(w, "%s %s: %s %s\n", success("✓"), bold(name), strings.Join(entry.Packages, ", "),
+				dim(fmt.Sprintf("(%d links, %d directories)", entry.Links, entry.Directories)))
+		}
+		for _, conflict := range entry.Conflicts {
+			fmt.Fprintf(w, "    %s %s: %s\n", warning("conflict:"), conflict.Path, conflict.Details)
+		}
+	}
+}
+
+// matrixProfileName names a plan matrix profile, where an empty profile
+// stands for every package.
+func matrixProfileName(profile string) string {
+	if profile == "" {
+		return "(all packages)"
+	}
+	return profile
+}
+
+// newPlanValidateCommand creates the plan validate subcommand.
+func newPlanValidateCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate FILE",
+		Short: "Check a plan for inconsistencies",
+		Long: `Check a JSON or YAML plan for inconsistencies before it is applied.
+
+The same checks run automatically before dot executes any plan:
+  - every operation is valid and has a unique ID
+  - declared dependencies refer to operations of the plan and form no cycle
+  - no two operations create the same path
+  - no parallel batch both creates and deletes a path
+  - every operation listed for a package belongs to the plan
+
+Use - as FILE to read the plan from standard input.
+
+Examples:
+  # Validate a plan before handing it to another tool
+  dot --dry-run manage vim --format json > plan.json
+  dot plan validate plan.json
+
+  # Validate without a temporary file
+  dot --dry-run remanage --format json | dot plan validate -`,
+		Args: argsWithUsage(cobra.ExactArgs(1)),
+		RunE: runPlanValidate,
+	}
+}
+
+// runPlanValidate handles the plan validate command execution.
+func runPlanValidate(cmd *cobra.Command, args []string) error {
+	plan, err := readPlanFile(cmd, args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := plan.Validate(); err != nil {
+		problems := []error{err}
+		var multiple dot.ErrMultiple
+		if errors.As(err, &multiple) {
+			problems = multiple.Errors
+		}
+		for _, problem := range problems {
+			fmt.Fprintf(cmd.ErrOrStderr(), "  %s %v\n", warning("problem:"), problem)
+		}
+		return fmt.Errorf("plan has %d problem(s)", len(problems))
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), success(fmt.Sprintf("Plan is valid: %d operation(s)", len(plan.Operations))))
+	return nil
+}
+
+// newPlanTraceCommand creates the plan trace subcommand.
+func newPlanTraceCommand() *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "trace FILE",
+		Short: "Show the filesystem changes a plan would make",
+		Long: `Run a JSON or YAML plan through the executor without changing anything
+and print every filesystem change each operation would make.
+
+--dry-run shows what the planner intends; trace shows what executing the
+plan would actually do, down to each symlink, rename, write and removal,
+including changes made outside the target directory such as backups.
+Operations run one at a time in plan order against a filesystem that
+records changes instead of making them, so each operation sees the
+filesystem as it is now. A plan the executor would reject, or an
+operation that would fail, makes trace fail.
+
+Use - as FILE to read the plan from standard input.
+
+Examples:
+  # See exactly what managing vim would change
+  dot --dry-run manage vim --format json | dot plan trace -
+
+  # Keep the trace for review
+  dot --dry-run remanage --format json > plan.json
+  dot plan trace plan.json --format json > trace.json`,
+		Args: argsWithUsage(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPlanTrace(cmd, args[0], format)
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "text", "output format (text, json, yaml)")
+
+	return cmd
+}
+
+// runPlanTrace handles the plan trace command execution.
+func runPlanTrace(cmd *cobra.Command, file, format string) error {
+	if format != "text" && format != "json" && format != "yaml" {
+		return fmt.Errorf("invalid format %q: use text, json or yaml", format)
+	}
+
+	plan, err := readPlanFile(cmd, file)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := buildConfigWithCmd(cmd)
+	if err != nil {
+		return formatError(err)
+	}
+	client, err := dot.NewClient(cfg)
+	if err != nil {
+		return formatError(err)
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	trace, traceErr := client.TracePlan(ctx, plan)
+
+	out := cmd.OutOrStdout()
+	switch format {
+	case "json":
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(trace); err != nil {
+			return err
+		}
+	case "yaml":
+		enc := yaml.NewEncoder(out)
+		enc.SetIndent(2)
+		if err := enc.Encode(trace); err != nil {
+			return err
+		}
+		if err := enc.Close(); err != nil {
+			return err
+		}
+	default:
+		renderPlanTrace(out, trace)
+	}
+
+	if traceErr != nil {
+		return formatError(traceErr)
+	}
+	return nil
+}
+
+// renderPlanTrace prints each operation of a trace followed by the
+// filesystem changes it would make.
+func renderPlanTrace(w io.Writer, trace dot.PlanTrace) {
+	for _, op := range trace.Operations {
+		line := op.Description
+		if op.Package != "" {
+			line += " " + dim("("+op.Package+")")
+		}
+		fmt.Fprintln(w, line)
+		for _, call := range op.Calls {
+			fmt.Fprintf(w, "  %s\n", call)
+		}
+	}
+	fmt.Fprintf(w, "%d operation(s), %d filesystem change(s)\n", len(trace.Operations), len(trace.Calls()))
+}
+
+// readPlanFile parses the JSON or YAML plan in file, or in standard input
+// when file is -.
+func readPlanFile(cmd *cobra.Command, file string) (dot.Plan, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if file == "-" {
+		data, err = io.ReadAll(cmd.InOrStdin())
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return dot.Plan{}, fmt.Errorf("read plan: %w", err)
+	}
+	return renderer.ParsePlan(data)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// setupPlanFormatTest creates a vim package and sets global flags.
+func setupPlanFormatTest(t *testing.T, dryRun bool) string {
+	t.Helper()
+	tmpDir := t.TempDir()
+	packageDir := filepath.Join(tmpDir, "packages")
+	targetDir := filepath.Join(tmpDir, "target")
+
+	require.NoError(t, os.MkdirAll(filepath.Join(packageDir, "vim"), 0755))
+	require.NoError(t, os.MkdirAll(targetDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(packageDir, "vim", "dot-vimrc"), []byte("set nocompatible"), 0644))
+
+	previous := globalCfg
+	t.Cleanup(func() {
+		globalCfg = previous
+	})
+	globalCfg = globalConfig{
+		packageDir: packageDir,
+		targetDir:  targetDir,
+		dryRun:     dryRun,
+		quiet:      true,
+	}
+	return targetDir
+}
+
+func TestManageCommand_DryRunJSONPlan(t *testing.T) {
+	targetDir := setupPlanFormatTest(t, true)
+
+	var out bytes.Buffer
+	cmd := newManageCommand()
+	cmd.SetContext(context.Background())
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"--format", "json", "vim"})
+	require.NoError(t, cmd.Execute())
+
+	var doc struct {
+		Operations []struct {
+			Kind   string `json:"kind"`
+			Source string `json:"source"`
+			Target string `json:"target"`
+		} `json:"operations"`
+		Packages  map[string][]string `json:"packages"`
+		Conflicts []any               `json:"conflicts"`
+	}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &doc))
+
+	var linkTargets []string
+	for _, op := range doc.Operations {
+		if op.Kind == "LinkCreate" {
+			linkTargets = append(linkTargets, op.Target)
+		}
+	}
+	assert.Contains(t, linkTargets, filepath.Join(targetDir, "vim", ".vimrc"))
+	assert.Contains(t, doc.Packages, "vim")
+	assert.Empty(t, doc.Conflicts)
+	assert.NoFileExists(t, filepath.Join(targetDir, "vim", ".vimrc"))
+}
+
+func TestUnmanageCommand_DryRunYAMLPlan(t *testing.T) {
+	targetDir := setupPlanFormatTest(t, false)
+
+	manage := newManageCommand()
+	manage.SetContext(context.Background())
+	manage.SetArgs([]string{"vim"})
+	require.NoError(t, manage.Execute())
+
+	globalCfg.dryRun = true
+	var out bytes.Buffer
+	cmd := newUnmanageCommand()
+	cmd.SetContext(context.Background())
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{"--format", "yaml", "vim"})
+	require.NoError(t, cmd.Execute())
+
+	var doc struct {
+		Operations []struct {
+			Kind string `yaml:"kind"`
+			Path string `yaml:"path"`
+		} `yaml:"operations"`
+	}
+	require.NoError(t, yaml.Unmarshal(out.Bytes(), &doc))
+	require.NotEmpty(t, doc.Operations)
+	assert.Equal(t, "LinkDelete", doc.Operations[0].Kind)
+
+	// Dry-run leaves the link in place
+	_, err := os.Lstat(filepath.Join(targetDir, "vim", ".vimrc"))
+	assert.NoError(t, err)
+}
+
+func TestFormatFlag_RequiresDryRun(t *testing.T) {
+	setupPlanFormatTest(t, false)
+
+	cmd := newRemanageCommand()
+	cmd.SetContext(context.Background())
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"--format", "json", "vim"})
+
+	err := cmd.Execute()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--format requires --dry-run")
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const validPlanJSON = `{
+  "operations": [
+    {"id": "dir", "kind": "DirCreate", "path": "/home/.vim"},
+    {"id": "link", "kind": "LinkCreate", "source": "/pkg/vim/vimrc", "target": "/home/.vim/vimrc", "depends_on": ["dir"]}
+  ],
+  "packages": {"vim": ["dir", "link"]}
+}`
+
+func TestPlanValidate_ValidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.json")
+	require.NoError(t, os.WriteFile(path, []byte(validPlanJSON), 0644))
+
+	cmd := newPlanCommand()
+	var stdout bytes.Buffer
+	cmd.SetOut(&stdout)
+	cmd.SetArgs([]string{"validate", path})
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, stdout.String(), "Plan is valid: 2 operation(s)")
+}
+
+func TestPlanValidate_ReportsProblemsFromStdin(t *testing.T) {
+	invalid := strings.Replace(validPlanJSON, `"packages": {"vim": ["dir", "link"]}`, `"packages": {"vim": ["gone"]}`, 1)
+	invalid = strings.Replace(invalid, `"depends_on": ["dir"]`, `"depends_on": ["missing"]`, 1)
+
+	cmd := newPlanCommand()
+	var stderr bytes.Buffer
+	cmd.SetIn(strings.NewReader(invalid))
+	cmd.SetErr(&stderr)
+	cmd.SetArgs([]string{"validate", "-"})
+	err := cmd.Execute()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "plan has 2 problem(s)")
+	assert.Contains(t, stderr.String(), "depends on unknown operation missing")
+	assert.Contains(t, stderr.String(), "lists unknown operation gone")
+}
+
+func TestPlanCommand_Matrix(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	packageDir := filepath.Join(tmpDir, "packages")
+	targetDir := filepath.Join(tmpDir, "target")
+	require.NoError(t, os.MkdirAll(filepath.Join(packageDir, "vim"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(packageDir, "mac"), 0755))
+	require.NoError(t, os.MkdirAll(targetDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(packageDir, "vim", "dot-vimrc"), []byte("set nocompatible"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(packageDir, "mac", "dot-hammerspoon"), []byte("x"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(packageDir, ".dotbootstrap.yaml"), []byte(`version: "1.0"
+packages:
+  - name: vim
+  - name: mac
+    platform: [darwin]
+  - name: missing
+    platform: [windows]
+profiles:
+  editor:
+    description: Editor
+    packages: [vim]
+`), 0644))
+
+	out, err := runDot(t, "--dir", packageDir, "--target", targetDir, "plan", "--all-profiles", "--all-platforms")
+	require.NoError(t, err)
+	assert.Contains(t, out, "PROFILE")
+	assert.Contains(t, out, "darwin")
+	assert.Contains(t, out, "editor on windows: vim")
+
+	// Without a default profile, every package of the platform is planned
+	out, err = runDot(t, "--dir", packageDir, "--target", targetDir, "plan", "--all-platforms", "--format", "json")
+	require.Error(t, err, "the windows plan names a missing package")
+	assert.Contains(t, err.Error(), "plan(s) failed")
+	assert.Contains(t, out, `"platforms"`)
+	assert.Contains(t, out, `"missing"`)
+
+	entries, err := os.ReadDir(targetDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "nothing is changed")
+
+	out, err = runDot(t, "--dir", packageDir, "--target", targetDir, "plan")
+	require.NoError(t, err)
+	assert.Contains(t, out, "validate")
+
+	out, err = runDot(t, "--dir", packageDir, "--target", targetDir, "--profile", "bootstrap:editor", "plan", "--all-platforms")
+	require.NoError(t, err)
+	assert.Contains(t, out, "editor on darwin: vim")
+
+	_, err = runDot(t, "--dir", packageDir, "--target", targetDir, "--profile", "work", "plan")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "available: bootstrap:editor")
+
+	_, err = runDot(t, "--dir", packageDir, "--target", targetDir, "--profile", "editor", "plan", "--all-profiles")
+	assert.ErrorContains(t, err, "cannot be combined")
+}
+
+func TestPlanCommand_Graph(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.json")
+	require.NoError(t, os.WriteFile(path, []byte(validPlanJSON), 0644))
+
+	cmd := newPlanCommand()
+	var stdout bytes.Buffer
+	cmd.SetOut(&stdout)
+	cmd.SetArgs([]string{"--graph", "dot", path})
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, stdout.String(), "digraph plan {")
+	assert.Contains(t, stdout.String(), `"dir" -> "link";`)
+
+	cmd = newPlanCommand()
+	stdout.Reset()
+	cmd.SetOut(&stdout)
+	cmd.SetIn(strings.NewReader(validPlanJSON))
+	cmd.SetArgs([]string{"--graph", "mermaid", "-"})
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, stdout.String(), "flowchart LR")
+	assert.Contains(t, stdout.String(), "op1 --> op2")
+}
+
+func TestPlanCommand_GraphInvalid(t *testing.T) {
+	cmd := newPlanCommand()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"--graph", "svg", "-"})
+	assert.ErrorContains(t, cmd.Execute(), `invalid graph format "svg"`)
+
+	cmd = newPlanCommand()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"--graph", "dot"})
+	assert.ErrorContains(t, cmd.Execute(), "--graph requires a plan FILE")
+}
+
+func TestPlanTrace_ShowsFilesystemChanges(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	packageDir := filepath.Join(tmpDir, "packages")
+	targetDir := filepath.Join(tmpDir, "target")
+	require.NoError(t, os.MkdirAll(filepath.Join(packageDir, "vim"), 0755))
+	require.NoError(t, os.MkdirAll(targetDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(packageDir, "vim", "dot-vimrc"), []byte("set nocompatible"), 0644))
+
+	planJSON, err := runDot(t, "--dir", packageDir, "--target", targetDir, "--dry-run", "manage", "vim", "--format", "json")
+	require.NoError(t, err)
+	planFile := filepath.Join(tmpDir, "plan.json")
+	require.NoError(t, os.WriteFile(planFile, []byte(planJSON), 0644))
+
+	out, err := runDot(t, "--dir", packageDir, "--target", targetDir, "plan", "trace", planFile)
+	require.NoError(t, err)
+	assert.Contains(t, out, "symlink "+targetDir)
+	assert.Contains(t, out, "-> "+filepath.Join(packageDir, "vim", "dot-vimrc"))
+	assert.Contains(t, out, "2 operation(s), 2 filesystem change(s)")
+	entries, err := os.ReadDir(targetDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	out, err = runDot(t, "--dir", packageDir, "--target", targetDir, "plan", "trace", planFile, "--format", "json")
+	require.NoError(t, err)
+	assert.Contains(t, out, `"method": "Symlink"`)
+}
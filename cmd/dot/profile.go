@@ -0,0 +1,12 @@
+package main
+
+import "github.com/spf13/cobra"
+
+// profileFlag returns the profile named by --profile. Commands selecting
+// packages by profile, such as clone, define the flag themselves, which
+// takes the global one's place, so the profile is read the same way
+// wherever --profile was written on the command line.
+func profileFlag(cmd *cobra.Command) string {
+	profile, _ := cmd.Flags().GetString("profile")
+	return profile
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"io"
+
+	"github.com/jamesainslie/dot/internal/cli/progress"
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// minProgressOperations is the smallest plan that gets a progress bar;
+// smaller plans finish before a bar would be readable.
+const minProgressOperations = 20
+
+// progressSink draws a progress bar for executed plans of at least
+// minProgressOperations operations.
+type progressSink struct {
+	bar    progress.Indicator
+	active bool
+}
+
+// newProgressSink creates a sink drawing its bar on w.
+func newProgressSink(w io.Writer, width int) *progressSink {
+	return &progressSink{
+		bar: progress.NewBar(progress.Config{
+			Enabled:     true,
+			Interactive: true,
+			Width:       width,
+			Output:      w,
+		}),
+	}
+}
+
+// OnEvent advances the bar as operations finish.
+func (s *progressSink) OnEvent(ctx context.Context, event dot.ExecutionEvent) {
+	switch event.Kind {
+	case dot.EventStarted:
+		if !s.active && event.Completed == 0 && event.Total >= minProgressOperations {
+			s.active = true
+			s.bar.Start("Applying operations")
+		}
+	case dot.EventSucceeded:
+		if !s.active {
+			return
+		}
+		s.bar.Update(event.Completed, event.Total, "")
+		if event.Completed == event.Total {
+			s.bar.Stop("Applied operations")
+			s.active = false
+		}
+	case dot.EventFailed:
+		if s.active {
+			s.bar.Update(event.Completed, event.Total, "")
+			s.bar.Fail("Failed, rolling back")
+			s.active = false
+		}
+	}
+}
+
+// linkCheckProgress draws a progress bar for doctor runs checking at
+// least minProgressOperations links.
+type linkCheckProgress struct {
+	bar    progress.Indicator
+	active bool
+}
+
+// newLinkCheckProgress creates a reporter drawing its bar on w.
+func newLinkCheckProgress(w io.Writer, width int) *linkCheckProgress {
+	return &linkCheckProgress{
+		bar: progress.NewBar(progress.Config{
+			Enabled:     true,
+			Interactive: true,
+			Width:       width,
+			Output:      w,
+		}),
+	}
+}
+
+// OnEvent advances the bar as links are checked. Events arrive one at a
+// time, in order.
+func (p *linkCheckProgress) OnEvent(ctx context.Context, event dot.Event) {
+	if event.Name != "doctor_links_checked" {
+		return
+	}
+	checked, _ := event.Fields["checked"].(int)
+	total, _ := event.Fields["total"].(int)
+	if !p.active {
+		if total < minProgressOperations || checked == total {
+			return
+		}
+		p.active = true
+		p.bar.Start("Checking links")
+	}
+	p.bar.Update(checked, total, "")
+	if checked == total {
+		p.bar.Stop("Checked links")
+		p.active = false
+	}
+}
+
+// Stop ends a bar left unfinished by a run that stopped early.
+func (p *linkCheckProgress) Stop() {
+	if p.active {
+		p.bar.Fail("Stopped checking links")
+		p.active = false
+	}
+}
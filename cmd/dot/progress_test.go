@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+func TestProgressSink(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("small plans draw nothing", func(t *testing.T) {
+		var buf bytes.Buffer
+		sink := newProgressSink(&buf, 0)
+		sink.OnEvent(ctx, dot.ExecutionEvent{Kind: dot.EventStarted, Total: 3})
+		sink.OnEvent(ctx, dot.ExecutionEvent{Kind: dot.EventSucceeded, Completed: 1, Total: 3})
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("large plans draw a bar", func(t *testing.T) {
+		var buf bytes.Buffer
+		sink := newProgressSink(&buf, 0)
+		total := minProgressOperations
+		for i := 1; i <= total; i++ {
+			sink.OnEvent(ctx, dot.ExecutionEvent{Kind: dot.EventStarted, Completed: i - 1, Total: total})
+			sink.OnEvent(ctx, dot.ExecutionEvent{Kind: dot.EventSucceeded, Completed: i, Total: total})
+		}
+		assert.Contains(t, buf.String(), "Applied operations")
+		assert.Contains(t, buf.String(), "100%")
+		assert.False(t, sink.active)
+	})
+
+	t.Run("failure ends the bar", func(t *testing.T) {
+		var buf bytes.Buffer
+		sink := newProgressSink(&buf, 0)
+		total := minProgressOperations
+		sink.OnEvent(ctx, dot.ExecutionEvent{Kind: dot.EventStarted, Total: total})
+		sink.OnEvent(ctx, dot.ExecutionEvent{Kind: dot.EventFailed, Completed: 1, Total: total, Err: errors.New("boom")})
+		assert.Contains(t, buf.String(), "rolling back")
+		assert.False(t, sink.active)
+	})
+}
+
+func TestLinkCheckProgress(t *testing.T) {
+	ctx := context.Background()
+	checked := func(n, total int) dot.Event {
+		return dot.Event{Topic: dot.TopicProgress, Name: "doctor_links_checked", Fields: map[string]any{"checked": n, "total": total}}
+	}
+
+	t.Run("small manifests draw nothing", func(t *testing.T) {
+		var buf bytes.Buffer
+		bar := newLinkCheckProgress(&buf, 0)
+		bar.OnEvent(ctx, checked(1, 3))
+		bar.OnEvent(ctx, checked(3, 3))
+		bar.Stop()
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("large manifests draw a bar", func(t *testing.T) {
+		var buf bytes.Buffer
+		bar := newLinkCheckProgress(&buf, 0)
+		total := minProgressOperations
+		for i := 1; i <= total; i++ {
+			bar.OnEvent(ctx, checked(i, total))
+		}
+		assert.Contains(t, buf.String(), "Checked links")
+		assert.Contains(t, buf.String(), "100%")
+		assert.False(t, bar.active)
+	})
+
+	t.Run("stopping early ends the bar", func(t *testing.T) {
+		var buf bytes.Buffer
+		bar := newLinkCheckProgress(&buf, 0)
+		bar.OnEvent(ctx, checked(1, minProgressOperations))
+		bar.Stop()
+		assert.Contains(t, buf.String(), "Stopped checking links")
+		assert.False(t, bar.active)
+	})
+}
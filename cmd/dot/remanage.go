@@ -14,18 +14,42 @@ func newRemanageCommand() *cobra.Command {
 		Use:   "remanage PACKAGE [PACKAGE...]",
 		Short: "Reinstall packages with incremental updates",
 		Long: `Reinstall one or more packages by removing old symlinks and 
-creating new ones.`,
+creating new ones.
+
+Packages whose files are unchanged since they were last installed, and
+whose links are all in place, are skipped. Files are compared by the
+content hashes recorded in the manifest; a file is only read again when
+its size or modification time changed. Use --force to reinstall
+regardless.`,
 		Args:              argsWithUsage(cobra.MinimumNArgs(1)),
 		RunE:              runRemanage,
 		ValidArgsFunction: packageCompletion(true), // Complete with installed packages
 	}
 
+	addPlanFormatFlag(cmd)
+	cmd.Flags().Bool("force", false, "Reinstall packages even if unchanged")
+
 	return cmd
 }
 
 // runRemanage handles the remanage command execution.
 func runRemanage(cmd *cobra.Command, args []string) error {
+	format, err := requestedPlanFormat(cmd, globalCfg.dryRun)
+	if err != nil {
+		return err
+	}
+
+	force, _ := cmd.Flags().GetBool("force")
+	opts := dot.RemanageOptions{Force: force}
+
 	return executePackageCommand(cmd, args, func(client *dot.Client, ctx context.Context, packages []string) error {
-		return client.Remanage(ctx, packages...)
+		if format != "" {
+			plan, err := client.PlanRemanageWithOptions(ctx, opts, packages...)
+			if err != nil {
+				return err
+			}
+			return renderPlan(cmd, format, plan)
+		}
+		return client.RemanageWithOptions(ctx, opts, packages...)
 	}, "remanaged")
 }
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// remoteDialTimeout bounds connecting to the machine of a remote target.
+const remoteDialTimeout = 30 * time.Second
+
+// remoteTarget is a target directory on another machine: a host reached
+// over SSH, named by a ssh://[user@]host[:port]/path URL, or a running
+// container, named by a docker://NAME[/path] or podman://NAME[/path] URL.
+type remoteTarget struct {
+	scheme    string
+	ssh       adapters.SSHTarget
+	container string
+	dir       string
+}
+
+// remoteSchemes are the URL schemes of remote targets.
+var remoteSchemes = []string{"ssh", "docker", "podman"}
+
+// isRemoteTarget reports whether a target directory names another machine.
+func isRemoteTarget(target string) bool {
+	for _, scheme := range remoteSchemes {
+		if strings.HasPrefix(target, scheme+"://") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRemoteTarget parses a remote target URL.
+func parseRemoteTarget(target string) (remoteTarget, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return remoteTarget{}, fmt.Errorf("invalid remote target: %w", err)
+	}
+	if u.Hostname() == "" {
+		return remoteTarget{}, fmt.Errorf("invalid remote target %q: missing host", target)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return remoteTarget{}, fmt.Errorf("invalid remote target %q: unexpected query or fragment", target)
+	}
+	r := remoteTarget{scheme: u.Scheme}
+	if u.Path != "" {
+		r.dir = path.Clean(u.Path)
+	}
+
+	if u.Scheme != "ssh" {
+		// Containers are named by the host: docker://NAME/path
+		if u.User != nil || u.Port() != "" {
+			return remoteTarget{}, fmt.Errorf("invalid remote target %q: expected %s://NAME[/path]", target, u.Scheme)
+		}
+		r.container = u.Host
+		return r, nil
+	}
+
+	if _, ok := u.User.Password(); ok {
+		return remoteTarget{}, fmt.Errorf("invalid remote target %q: passwords are not supported, use SSH keys", target)
+	}
+	if r.dir == "" {
+		return remoteTarget{}, fmt.Errorf("invalid remote target %q: missing directory, as in ssh://host/home/user", target)
+	}
+	r.ssh = adapters.SSHTarget{User: u.User.Username(), Host: u.Hostname()}
+	if port := u.Port(); port != "" {
+		r.ssh.Port, err = strconv.Atoi(port)
+		if err != nil || r.ssh.Port < 1 || r.ssh.Port > 65535 {
+			return remoteTarget{}, fmt.Errorf("invalid remote target %q: invalid port %q", target, port)
+		}
+	}
+	return r, nil
+}
+
+// machine returns r without its directory, naming the machine alone.
+func (r remoteTarget) machine() remoteTarget {
+	r.dir = ""
+	return r
+}
+
+// remoteDir returns a directory on the remote machine named by a flag, or
+// fallback when the flag is not set. Those paths are not resolved against
+// the local working directory, so they must be absolute.
+func remoteDir(flag, value, fallback string) (string, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	if !path.IsAbs(value) {
+		return "", fmt.Errorf("%s must be an absolute path on the remote machine, not %q", flag, value)
+	}
+	return path.Clean(value), nil
+}
+
+// dialRemote opens the filesystem of a remote machine. Tests replace it to
+// exercise remote targets without one.
+var dialRemote = func(ctx context.Context, r remoteTarget) (dot.FS, error) {
+	if r.scheme == "ssh" {
+		return adapters.DialSFTP(ctx, r.ssh)
+	}
+	fs := adapters.NewContainerFS(r.scheme, r.container)
+	// Report a container that is not running now rather than on first use
+	if _, err := fs.Stat(ctx, "/"); err != nil {
+		return nil, fmt.Errorf("%s exec %s: %w", r.scheme, r.container, errors.Unwrap(err))
+	}
+	return fs, nil
+}
+
+// remoteFilesystems keeps the filesystems opened by this process, since
+// some commands build their configuration more than once. Connections are
+// closed when the process exits.
+var remoteFilesystems = make(map[remoteTarget]dot.FS)
+
+// openRemote returns the filesystem of the machine of r, connecting to it
+// the first time.
+func openRemote(ctx context.Context, r remoteTarget) (dot.FS, error) {
+	if fs, ok := remoteFilesystems[r.machine()]; ok {
+		return fs, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, remoteDialTimeout)
+	defer cancel()
+	fs, err := dialRemote(ctx, r)
+	if err != nil {
+		return nil, fmt.Errorf("connect to remote target: %w", err)
+	}
+	remoteFilesystems[r.machine()] = fs
+	return fs, nil
+}
+
+// remoteHome returns the home directory of the user on a remote machine,
+// the target directory when the URL names none.
+func remoteHome(ctx context.Context, fs dot.FS) (string, error) {
+	homer, ok := fs.(interface {
+		Home(context.Context) (string, error)
+	})
+	if !ok {
+		return "", fmt.Errorf("remote target: missing directory")
+	}
+	home, err := homer.Home(ctx)
+	if err != nil {
+		return "", fmt.Errorf("remote target: find home directory: %w", err)
+	}
+	return home, nil
+}
@@ -0,0 +1,357 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// newRepoCommand creates the repo command group.
+func newRepoCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "repo",
+		Short: "Manage the package repository",
+		Long: `Manage the git repository checked out in the package directory.
+
+These commands operate on the package directory directly, so there is no
+need to change into it to run git.`,
+		Example: `  # Show branch and uncommitted changes
+  dot repo status
+
+  # Pull without applying changes, then push local commits
+  dot repo pull
+  dot repo push
+
+  # Point origin at a new URL
+  dot repo remote set-url git@github.com:user/dotfiles.git
+
+  # Fetch full history for a shallow clone
+  dot repo unshallow`,
+		Args: argsWithUsage(cobra.NoArgs),
+	}
+
+	cmd.AddCommand(
+		newRepoStatusCommand(),
+		newRepoPullCommand(),
+		newRepoPushCommand(),
+		newRepoRemoteCommand(),
+		newRepoUnshallowCommand(),
+	)
+
+	return cmd
+}
+
+// newRepoStatusCommand creates the status subcommand.
+func newRepoStatusCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show the state of the package repository",
+		Long: `Show the branch, remote, and uncommitted changes of the package repository.
+
+Changes are grouped by the package they belong to. Commits pulled but
+not yet applied to the target directory are reported, as are commits
+not yet pushed. Divergence is measured against the last fetched state
+of the remote; no network access is needed.`,
+		Example: `  # Show repository state
+  dot repo status`,
+		Args: argsWithUsage(cobra.NoArgs),
+		RunE: runRepoStatus,
+	}
+}
+
+// runRepoStatus handles the repo status command execution.
+func runRepoStatus(cmd *cobra.Command, args []string) error {
+	client, ctx, err := newRepoClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	status, err := client.RepoStatus(ctx)
+	if err != nil {
+		return formatRepoError(err)
+	}
+
+	renderRepoStatus(cmd.OutOrStdout(), status)
+	return nil
+}
+
+// renderRepoStatus writes a human-readable summary of the repository state.
+func renderRepoStatus(w io.Writer, status dot.RepoStatus) {
+	branch := status.Branch
+	if branch == "" {
+		branch = "(detached HEAD)"
+	}
+	fmt.Fprintf(w, "%s %s at %s\n", bold("Repository:"), status.Path, shortCommit(status.Head))
+	fmt.Fprintf(w, "%s %s\n", bold("Branch:"), branch)
+	if status.RemoteURL != "" {
+		fmt.Fprintf(w, "%s %s\n", bold("Remote:"), status.RemoteURL)
+	}
+
+	switch {
+	case status.Upstream == "":
+		fmt.Fprintln(w, dim("No upstream branch"))
+	case status.Ahead == 0 && status.Behind == 0:
+		fmt.Fprintf(w, "Up to date with %s\n", status.Upstream)
+	default:
+		fmt.Fprintf(w, "%d ahead, %d behind %s\n", status.Ahead, status.Behind, status.Upstream)
+	}
+
+	if status.Unsynced() {
+		fmt.Fprintf(w, "%s commits since %s are not applied; run 'dot sync'\n",
+			warning("Pending:"), shortCommit(status.SyncedCommit))
+	}
+
+	if len(status.Changes) == 0 {
+		fmt.Fprintln(w, "No uncommitted changes")
+		return
+	}
+
+	fmt.Fprintf(w, "\n%s\n", bold("Uncommitted changes:"))
+	for _, change := range status.Changes {
+		fmt.Fprintf(w, "  %-10s %s\n", change.Status, change.Path)
+	}
+	if len(status.Packages) > 0 {
+		fmt.Fprintf(w, "\nPackages with changes: %s\n", strings.Join(status.Packages, ", "))
+	}
+}
+
+// newRepoPullCommand creates the pull subcommand.
+func newRepoPullCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "pull",
+		Short: "Pull remote changes without applying them",
+		Long: `Fast-forward the package repository from its remote.
+
+Unlike sync, pull leaves the target directory untouched. Run
+'dot sync' afterwards to remanage the packages the pull changed.`,
+		Example: `  # Pull, review, then apply
+  dot repo pull
+  dot --dry-run sync
+  dot sync`,
+		Args: argsWithUsage(cobra.NoArgs),
+		RunE: runRepoPull,
+	}
+}
+
+// runRepoPull handles the repo pull command execution.
+func runRepoPull(cmd *cobra.Command, args []string) error {
+	client, ctx, err := newRepoClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	result, err := client.RepoPull(ctx)
+	if err != nil {
+		return formatRepoError(err)
+	}
+
+	out := cmd.OutOrStdout()
+	switch {
+	case client.Config().DryRun:
+		fmt.Fprintf(out, "Would pull into %s at %s\n", client.Config().PackageDir, shortCommit(result.FromCommit))
+	case result.UpToDate():
+		fmt.Fprintf(out, "Already up to date at %s\n", shortCommit(result.ToCommit))
+	default:
+		fmt.Fprintf(out, "%s %s..%s (%d file(s) changed)\n",
+			success("Pulled"), shortCommit(result.FromCommit), shortCommit(result.ToCommit), len(result.Changed))
+		if result.Rebased > 0 {
+			renderRebasedNote(out, result.Rebased)
+		}
+		fmt.Fprintln(out, dim("Run 'dot sync' to apply the changes"))
+	}
+
+	return nil
+}
+
+// newRepoPushCommand creates the push subcommand.
+func newRepoPushCommand() *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "push",
+		Short: "Push local commits to the remote",
+		Long: `Push the checked-out branch of the package repository to origin.
+
+Credentials are resolved as for clone: GITHUB_TOKEN or GIT_TOKEN for
+HTTPS remotes, or SSH keys in ~/.ssh for SSH remotes.
+
+A per-machine branch rewritten by sync no longer fast-forwards its
+remote copy; push it with --force.`,
+		Example: `  # Push committed changes
+  dot repo push
+
+  # Update a machine branch after sync rebased it
+  dot repo push --force`,
+		Args: argsWithUsage(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRepoPush(cmd, force)
+		},
+	}
+
+	cmd.Flags().BoolVar(&force, "force", false, "overwrite the remote branch even if the push is not a fast-forward")
+	return cmd
+}
+
+// runRepoPush handles the repo push command execution.
+func runRepoPush(cmd *cobra.Command, force bool) error {
+	client, ctx, err := newRepoClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	if err := client.RepoPush(ctx, dot.RepoPushOptions{Force: force}); err != nil {
+		return formatRepoError(err)
+	}
+
+	out := cmd.OutOrStdout()
+	if client.Config().DryRun {
+		fmt.Fprintln(out, "Would push the current branch to origin")
+		return nil
+	}
+	fmt.Fprintf(out, "%s to origin\n", success("Pushed"))
+	return nil
+}
+
+// newRepoRemoteCommand creates the remote command group.
+func newRepoRemoteCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "remote",
+		Short: "Manage repository remotes",
+		Args:  argsWithUsage(cobra.NoArgs),
+	}
+
+	cmd.AddCommand(newRepoRemoteSetURLCommand())
+	return cmd
+}
+
+// newRepoRemoteSetURLCommand creates the remote set-url subcommand.
+func newRepoRemoteSetURLCommand() *cobra.Command {
+	var remote string
+
+	cmd := &cobra.Command{
+		Use:   "set-url URL",
+		Short: "Change the URL of a remote",
+		Long: `Change the URL of a remote of the package repository.
+
+Changing origin also updates the repository URL recorded in the
+manifest, which selects the credentials used by sync, pull, and push.`,
+		Example: `  # Switch origin from HTTPS to SSH
+  dot repo remote set-url git@github.com:user/dotfiles.git
+
+  # Change another remote
+  dot repo remote set-url --remote upstream https://github.com/other/dotfiles`,
+		Args: argsWithUsage(cobra.ExactArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRepoRemoteSetURL(cmd, remote, args[0])
+		},
+	}
+
+	cmd.Flags().StringVar(&remote, "remote", "origin", "name of the remote to change")
+	return cmd
+}
+
+// runRepoRemoteSetURL handles the repo remote set-url command execution.
+func runRepoRemoteSetURL(cmd *cobra.Command, remote, url string) error {
+	client, ctx, err := newRepoClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	if err := client.SetRemoteURL(ctx, remote, url); err != nil {
+		return formatRepoError(err)
+	}
+
+	out := cmd.OutOrStdout()
+	if client.Config().DryRun {
+		fmt.Fprintf(out, "Would set %s to %s\n", remote, url)
+		return nil
+	}
+	fmt.Fprintf(out, "%s %s to %s\n", success("Set"), remote, url)
+	return nil
+}
+
+// newRepoUnshallowCommand creates the unshallow subcommand.
+func newRepoUnshallowCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "unshallow",
+		Short: "Fetch full history for a shallow clone",
+		Long: `Fetch the commit history missing from a shallow clone.
+
+dot clone fetches only the latest commit unless --full-history is given.
+Full history is needed to bisect a bad configuration change, and lets
+sync diff against commits recorded before the clone. A repository that
+already has full history is left unchanged.`,
+		Example: `  # Fetch full history
+  dot repo unshallow
+
+  # Check whether the repository is shallow
+  dot --dry-run repo unshallow`,
+		Args: argsWithUsage(cobra.NoArgs),
+		RunE: runRepoUnshallow,
+	}
+}
+
+// runRepoUnshallow handles the repo unshallow command execution.
+func runRepoUnshallow(cmd *cobra.Command, args []string) error {
+	client, ctx, err := newRepoClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	wasShallow, err := client.Unshallow(ctx)
+	if err != nil {
+		return formatRepoError(err)
+	}
+
+	out := cmd.OutOrStdout()
+	switch {
+	case !wasShallow:
+		fmt.Fprintln(out, "Repository already has full history")
+	case client.Config().DryRun:
+		fmt.Fprintln(out, "Would fetch full history for shallow clone")
+	default:
+		fmt.Fprintf(out, "%s full history\n", success("Fetched"))
+	}
+
+	return nil
+}
+
+// newRepoClient builds the client and context shared by repo subcommands.
+func newRepoClient(cmd *cobra.Command) (*dot.Client, context.Context, error) {
+	cfg, err := buildConfigWithCmd(cmd)
+	if err != nil {
+		return nil, nil, formatError(err)
+	}
+
+	client, err := dot.NewClient(cfg)
+	if err != nil {
+		return nil, nil, formatError(err)
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return client, ctx, nil
+}
+
+// formatRepoError formats repository errors with helpful messages.
+func formatRepoError(err error) error {
+	var pullFailed dot.ErrPullFailed
+	if errors.As(err, &pullFailed) {
+		return fmt.Errorf("%w\n\nEnsure:\n  - The package directory is a git repository with a remote\n  - Network connection is available", pullFailed)
+	}
+
+	var pushFailed dot.ErrPushFailed
+	if errors.As(err, &pushFailed) {
+		return fmt.Errorf("%w\n\nEnsure:\n  - Credentials with write access are available\n  - The remote has no commits missing locally (run 'dot sync' first)\n  - A rebased machine branch is pushed with --force", pushFailed)
+	}
+
+	return formatSyncError(err)
+}
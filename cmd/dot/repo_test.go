@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/jamesainslie/dot/pkg/dot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepoCommand_Subcommands(t *testing.T) {
+	cmd := newRepoCommand()
+
+	for _, name := range []string{"status", "pull", "push"} {
+		sub, _, err := cmd.Find([]string{name})
+		require.NoError(t, err)
+		assert.Equal(t, name, sub.Name())
+		assert.Error(t, sub.Args(sub, []string{"extra"}))
+	}
+
+	setURL, _, err := cmd.Find([]string{"remote", "set-url"})
+	require.NoError(t, err)
+	assert.Equal(t, "set-url", setURL.Name())
+	assert.Error(t, setURL.Args(setURL, nil))
+	assert.NoError(t, setURL.Args(setURL, []string{"git@example.com:user/dotfiles.git"}))
+	assert.Equal(t, "origin", setURL.Flag("remote").DefValue)
+
+	push, _, err := cmd.Find([]string{"push"})
+	require.NoError(t, err)
+	assert.NotNil(t, push.Flag("force"))
+
+	unshallow, _, err := cmd.Find([]string{"unshallow"})
+	require.NoError(t, err)
+	assert.Equal(t, "unshallow", unshallow.Name())
+	assert.Error(t, unshallow.Args(unshallow, []string{"extra"}))
+}
+
+func TestRenderRepoStatus(t *testing.T) {
+	t.Run("clean", func(t *testing.T) {
+		var buf bytes.Buffer
+		renderRepoStatus(&buf, dot.RepoStatus{
+			Path:         "/dotfiles",
+			Branch:       "main",
+			Head:         "abc123456789",
+			SyncedCommit: "abc123456789",
+			Upstream:     "origin/main",
+		})
+		out := buf.String()
+		assert.Contains(t, out, "/dotfiles at abc1234")
+		assert.Contains(t, out, "Up to date with origin/main")
+		assert.Contains(t, out, "No uncommitted changes")
+		assert.NotContains(t, out, "dot sync")
+	})
+
+	t.Run("diverged with changes", func(t *testing.T) {
+		var buf bytes.Buffer
+		renderRepoStatus(&buf, dot.RepoStatus{
+			Path:         "/dotfiles",
+			Head:         "def456789012",
+			SyncedCommit: "abc123456789",
+			Upstream:     "origin/main",
+			Ahead:        2,
+			Behind:       1,
+			Changes:      []dot.RepoChange{{Path: "vim/dot-vimrc", Status: "modified"}},
+			Packages:     []string{"vim"},
+		})
+		out := buf.String()
+		assert.Contains(t, out, "(detached HEAD)")
+		assert.Contains(t, out, "2 ahead, 1 behind origin/main")
+		assert.Contains(t, out, "since abc1234 are not applied; run 'dot sync'")
+		assert.Contains(t, out, "modified   vim/dot-vimrc")
+		assert.Contains(t, out, "Packages with changes: vim")
+	})
+}
+
+func TestFormatRepoError(t *testing.T) {
+	t.Run("push failure", func(t *testing.T) {
+		err := formatRepoError(dot.ErrPushFailed{Path: "/dotfiles", Cause: errors.New("non-fast-forward update")})
+		assert.Contains(t, err.Error(), "/dotfiles")
+		assert.Contains(t, err.Error(), "write access")
+	})
+
+	t.Run("fetch failure", func(t *testing.T) {
+		err := formatRepoError(dot.ErrPullFailed{Path: "/dotfiles", Cause: errors.New("connection refused")})
+		assert.Contains(t, err.Error(), "/dotfiles")
+		assert.Contains(t, err.Error(), "git repository with a remote")
+	})
+
+	t.Run("offline", func(t *testing.T) {
+		err := formatRepoError(dot.ErrOffline{Operation: "repo unshallow"})
+		assert.Contains(t, err.Error(), "without --offline")
+	})
+}
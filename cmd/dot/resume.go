@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// newResumeCommand creates the resume command.
+func newResumeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "resume [CHECKPOINT-ID]",
+		Short: "Finish a plan interrupted by a crash",
+		Long: `Finish executing a plan that was interrupted, for example by a crash
+or power loss during manage.
+
+Before executing a plan, dot journals it to a checkpoint under
+$XDG_STATE_HOME/dot/checkpoints and records each operation as it completes.
+Resume runs the operations that never completed and updates the manifest
+as the interrupted command would have. To undo the operations that did run
+instead, use 'dot rollback'.
+
+Without an argument, the most recent interrupted plan is resumed. Pass a
+checkpoint ID, or a unique prefix of one, to select another.
+
+Examples:
+  # Finish the interrupted plan
+  dot resume
+
+  # Show what would be run
+  dot --dry-run resume
+
+  # List checkpoints; interrupted plans are pending
+  dot rollback --list`,
+		Args: argsWithUsage(cobra.MaximumNArgs(1)),
+		RunE: runResume,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+
+	return cmd
+}
+
+// runResume handles the resume command execution.
+func runResume(cmd *cobra.Command, args []string) error {
+	cfg, err := buildConfigWithCmd(cmd)
+	if err != nil {
+		return formatError(err)
+	}
+
+	client, err := dot.NewClient(cfg)
+	if err != nil {
+		return formatError(err)
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var id string
+	if len(args) > 0 {
+		id = args[0]
+	}
+
+	result, err := client.Resume(ctx, id)
+	if err != nil {
+		return formatResumeError(err)
+	}
+
+	renderResumeResult(cmd.OutOrStdout(), result)
+	return nil
+}
+
+// renderResumeResult summarises a resume and the operations it ran.
+func renderResumeResult(w io.Writer, result dot.ResumeResult) {
+	id := shortCheckpointID(result.Checkpoint.ID)
+
+	if result.DryRun {
+		fmt.Fprintf(w, "Would resume checkpoint %s (%d remaining operations):\n", accent(id), len(result.Remaining))
+		for _, op := range result.Remaining {
+			fmt.Fprintf(w, "  %s %s\n", dim("run"), op)
+		}
+		return
+	}
+
+	fmt.Fprintf(w, "%s checkpoint %s (%d operations run)\n", success("Resumed"), accent(id), result.Executed)
+}
+
+// formatResumeError formats resume-specific errors with helpful messages.
+func formatResumeError(err error) error {
+	var notFound dot.ErrCheckpointNotFound
+	if errors.As(err, &notFound) && notFound.ID == "" {
+		return fmt.Errorf("no interrupted plan to resume")
+	}
+
+	return formatRollbackError(err)
+}
+
+// recoveryChoice is how the user chose to handle an interrupted plan.
+type recoveryChoice int
+
+const (
+	recoveryContinue recoveryChoice = iota
+	recoveryResume
+	recoveryRollback
+)
+
+// recoverInterrupted checks for a plan interrupted by a crash before a
+// command applies changes. On a terminal the user can resume it, roll it
+// back, or continue and leave it for later; otherwise a warning explains
+// how to recover. Detection is best-effort and never blocks the command.
+func recoverInterrupted(cmd *cobra.Command, client *dot.Client, ctx context.Context) error {
+	interrupted, err := client.Interrupted(ctx)
+	if err != nil || len(interrupted) == 0 {
+		return nil
+	}
+
+	checkpoint := interrupted[0]
+	errOut := cmd.ErrOrStderr()
+	renderInterruptedWarning(errOut, checkpoint)
+
+	if !isTerminal(cmd) {
+		fmt.Fprintln(errOut, "Run 'dot resume' to finish it or 'dot rollback' to undo it")
+		return nil
+	}
+
+	switch promptRecovery(errOut, cmd.InOrStdin()) {
+	case recoveryResume:
+		result, err := client.Resume(ctx, checkpoint.ID)
+		if err != nil {
+			return formatResumeError(err)
+		}
+		renderResumeResult(errOut, result)
+	case recoveryRollback:
+		result, err := client.Rollback(ctx, checkpoint.ID)
+		if err != nil {
+			return formatRollbackError(err)
+		}
+		renderRollbackResult(errOut, result)
+	}
+	return nil
+}
+
+// renderInterruptedWarning reports an interrupted plan and its progress.
+func renderInterruptedWarning(w io.Writer, checkpoint dot.CheckpointInfo) {
+	fmt.Fprintf(w, "%s a previous plan was interrupted (checkpoint %s, %d of %d operations applied)\n",
+		warning("Warning:"), accent(shortCheckpointID(checkpoint.ID)),
+		len(checkpoint.Operations), len(checkpoint.Planned))
+}
+
+// promptRecovery asks how to handle an interrupted plan.
+// Anything other than resume or rollback continues without recovering.
+func promptRecovery(w io.Writer, in io.Reader) recoveryChoice {
+	fmt.Fprint(w, "Resume it, roll it back, or continue? [r/b/C]: ")
+	response, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && response == "" {
+		return recoveryContinue
+	}
+
+	switch strings.ToLower(strings.TrimSpace(response)) {
+	case "r", "resume":
+		return recoveryResume
+	case "b", "rollback":
+		return recoveryRollback
+	default:
+		return recoveryContinue
+	}
+}
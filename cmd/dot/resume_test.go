@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/jamesainslie/dot/pkg/dot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResumeCommand_Args(t *testing.T) {
+	cmd := newResumeCommand()
+	assert.NoError(t, cmd.Args(cmd, []string{}))
+	assert.NoError(t, cmd.Args(cmd, []string{"3f2a9c41"}))
+	assert.Error(t, cmd.Args(cmd, []string{"a", "b"}))
+}
+
+func TestRenderResumeResult(t *testing.T) {
+	checkpoint := dot.CheckpointInfo{ID: "3f2a9c41-8b7d-4e2f-9a1c-0d5e6f7a8b9c"}
+
+	t.Run("dry run", func(t *testing.T) {
+		var buf bytes.Buffer
+		renderResumeResult(&buf, dot.ResumeResult{
+			Checkpoint: checkpoint,
+			Remaining:  []string{"create link a", "create link b"},
+			DryRun:     true,
+		})
+
+		out := buf.String()
+		assert.Contains(t, out, "Would resume checkpoint")
+		assert.Contains(t, out, "(2 remaining operations)")
+		assert.Less(t, strings.Index(out, "create link a"), strings.Index(out, "create link b"), "run in plan order")
+	})
+
+	t.Run("resumed", func(t *testing.T) {
+		var buf bytes.Buffer
+		renderResumeResult(&buf, dot.ResumeResult{Checkpoint: checkpoint, Executed: 1})
+		assert.Contains(t, buf.String(), "Resumed")
+		assert.Contains(t, buf.String(), "1 operations run")
+	})
+}
+
+func TestRenderInterruptedWarning(t *testing.T) {
+	var buf bytes.Buffer
+	renderInterruptedWarning(&buf, dot.CheckpointInfo{
+		ID:         "3f2a9c41-8b7d-4e2f-9a1c-0d5e6f7a8b9c",
+		Operations: []string{"a"},
+		Planned:    []string{"a", "b", "c"},
+	})
+
+	assert.Contains(t, buf.String(), "3f2a9c41")
+	assert.Contains(t, buf.String(), "1 of 3 operations applied")
+}
+
+func TestPromptRecovery(t *testing.T) {
+	tests := []struct {
+		input string
+		want  recoveryChoice
+	}{
+		{"r\n", recoveryResume},
+		{"resume\n", recoveryResume},
+		{"B\n", recoveryRollback},
+		{"rollback\n", recoveryRollback},
+		{"\n", recoveryContinue},
+		{"c\n", recoveryContinue},
+		{"", recoveryContinue},
+		{"r", recoveryResume},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.TrimSpace(tt.input), func(t *testing.T) {
+			var out bytes.Buffer
+			got := promptRecovery(&out, strings.NewReader(tt.input))
+			assert.Equal(t, tt.want, got)
+			assert.Contains(t, out.String(), "[r/b/C]")
+		})
+	}
+}
+
+func TestFormatResumeError(t *testing.T) {
+	err := formatResumeError(dot.ErrCheckpointNotFound{})
+	require.Error(t, err)
+	assert.Equal(t, "no interrupted plan to resume", err.Error())
+
+	err = formatResumeError(dot.ErrCheckpointNotFound{ID: "deadbeef"})
+	assert.Contains(t, err.Error(), "dot rollback --list")
+}
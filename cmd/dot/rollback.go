@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// newRollbackCommand creates the rollback command.
+func newRollbackCommand() *cobra.Command {
+	var list bool
+
+	cmd := &cobra.Command{
+		Use:   "rollback [CHECKPOINT-ID]",
+		Short: "Undo the last executed plan",
+		Long: `Undo the operations of an executed plan using its checkpoint.
+
+Every plan executed by manage, unmanage, remanage, and adopt records a
+checkpoint under $XDG_STATE_HOME/dot/checkpoints as it runs. Without an
+argument, rollback undoes the most recent plan that has not already been
+rolled back, including one interrupted by a crash. Pass a checkpoint ID,
+or a unique prefix of one, to select a plan; an older plan can only be
+rolled back once every plan after it has been.
+
+Links created by the plan are removed from the manifest, and links it
+deleted are added back to their packages. The most recent
+10 finished checkpoints are kept.
+
+Examples:
+  # Undo the last executed plan
+  dot rollback
+
+  # List stored checkpoints
+  dot rollback --list
+
+  # Undo a specific checkpoint by ID prefix
+  dot rollback 3f2a9c
+
+  # Show what would be undone
+  dot --dry-run rollback`,
+		Args: argsWithUsage(cobra.MaximumNArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRollback(cmd, args, list)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+
+	cmd.Flags().BoolVar(&list, "list", false, "list stored checkpoints instead of rolling back")
+
+	return cmd
+}
+
+// runRollback handles the rollback command execution.
+func runRollback(cmd *cobra.Command, args []string, list bool) error {
+	cfg, err := buildConfigWithCmd(cmd)
+	if err != nil {
+		return formatError(err)
+	}
+
+	client, err := dot.NewClient(cfg)
+	if err != nil {
+		return formatError(err)
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	out := cmd.OutOrStdout()
+
+	if list {
+		checkpoints, err := client.Checkpoints(ctx)
+		if err != nil {
+			return formatError(err)
+		}
+		renderCheckpointList(out, checkpoints)
+		return nil
+	}
+
+	var id string
+	if len(args) > 0 {
+		id = args[0]
+	}
+
+	result, err := client.Rollback(ctx, id)
+	if err != nil {
+		return formatRollbackError(err)
+	}
+
+	renderRollbackResult(out, result)
+	return nil
+}
+
+// renderCheckpointList prints one line per checkpoint, newest first.
+func renderCheckpointList(w io.Writer, checkpoints []dot.CheckpointInfo) {
+	if len(checkpoints) == 0 {
+		fmt.Fprintln(w, "No checkpoints found")
+		return
+	}
+
+	for _, checkpoint := range checkpoints {
+		count := fmt.Sprintf("%d operations", len(checkpoint.Operations))
+		if len(checkpoint.Planned) > len(checkpoint.Operations) {
+			count = fmt.Sprintf("%d of %d operations", len(checkpoint.Operations), len(checkpoint.Planned))
+		}
+		fmt.Fprintf(w, "%s  %s  %s  %s\n",
+			accent(shortCheckpointID(checkpoint.ID)),
+			checkpoint.CreatedAt.Format("2006-01-02 15:04:05"),
+			checkpointStatusText(checkpoint.Status),
+			count)
+	}
+}
+
+// renderRollbackResult summarises a rollback and the operations it undid.
+func renderRollbackResult(w io.Writer, result dot.RollbackResult) {
+	ops := result.Checkpoint.Operations
+	id := shortCheckpointID(result.Checkpoint.ID)
+
+	if result.DryRun {
+		fmt.Fprintf(w, "Would roll back checkpoint %s (%d operations):\n", accent(id), len(ops))
+	} else {
+		fmt.Fprintf(w, "%s checkpoint %s (%d of %d operations undone)\n",
+			success("Rolled back"), accent(id), result.RolledBack, len(ops))
+	}
+
+	// Operations are undone in reverse execution order
+	for i := len(ops) - 1; i >= 0; i-- {
+		fmt.Fprintf(w, "  %s %s\n", dim("undo"), ops[i])
+	}
+}
+
+// checkpointStatusText pads and colors a checkpoint status for display.
+func checkpointStatusText(status string) string {
+	padded := fmt.Sprintf("%-11s", status)
+	switch status {
+	case "completed":
+		return success(padded)
+	case "pending":
+		return warning(padded)
+	default:
+		return dim(padded)
+	}
+}
+
+// shortCheckpointID abbreviates a checkpoint ID for display.
+func shortCheckpointID(id string) string {
+	const shortLen = 8
+	if len(id) > shortLen {
+		return id[:shortLen]
+	}
+	return id
+}
+
+// formatRollbackError formats rollback-specific errors with helpful messages.
+func formatRollbackError(err error) error {
+	var notFound dot.ErrCheckpointNotFound
+	if errors.As(err, &notFound) {
+		if notFound.ID == "" {
+			return fmt.Errorf("no checkpoint to roll back\n\nCheckpoints are recorded when manage, unmanage, remanage, or adopt apply changes")
+		}
+		return fmt.Errorf("%w\n\nRun 'dot rollback --list' to see stored checkpoints", notFound)
+	}
+
+	return formatError(err)
+}
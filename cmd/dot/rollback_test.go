@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jamesainslie/dot/pkg/dot"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRollbackCommand_Flags(t *testing.T) {
+	cmd := newRollbackCommand()
+
+	flag := cmd.Flags().Lookup("list")
+	require.NotNil(t, flag)
+	assert.Equal(t, "bool", flag.Value.Type())
+	assert.Error(t, cmd.Args(cmd, []string{"a", "b"}))
+}
+
+func TestRenderCheckpointList(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		var buf bytes.Buffer
+		renderCheckpointList(&buf, nil)
+		assert.Equal(t, "No checkpoints found\n", buf.String())
+	})
+
+	t.Run("checkpoints", func(t *testing.T) {
+		var buf bytes.Buffer
+		renderCheckpointList(&buf, []dot.CheckpointInfo{
+			{
+				ID:         "3f2a9c41-8b7d-4e2f-9a1c-0d5e6f7a8b9c",
+				CreatedAt:  time.Date(2026, 5, 1, 10, 30, 0, 0, time.UTC),
+				Status:     "completed",
+				Operations: []string{"create symlink a -> b", "create directory c"},
+			},
+		})
+
+		out := buf.String()
+		assert.Contains(t, out, "3f2a9c41")
+		assert.NotContains(t, out, "3f2a9c41-")
+		assert.Contains(t, out, "2026-05-01 10:30:00")
+		assert.Contains(t, out, "completed")
+		assert.Contains(t, out, "2 operations")
+	})
+
+	t.Run("interrupted checkpoint", func(t *testing.T) {
+		var buf bytes.Buffer
+		renderCheckpointList(&buf, []dot.CheckpointInfo{
+			{
+				ID:         "3f2a9c41-8b7d-4e2f-9a1c-0d5e6f7a8b9c",
+				Status:     "pending",
+				Operations: []string{"create directory c"},
+				Planned:    []string{"create directory c", "create symlink a -> b"},
+			},
+		})
+		assert.Contains(t, buf.String(), "1 of 2 operations")
+	})
+}
+
+func TestRenderRollbackResult(t *testing.T) {
+	checkpoint := dot.CheckpointInfo{
+		ID:         "3f2a9c41-8b7d-4e2f-9a1c-0d5e6f7a8b9c",
+		Operations: []string{"first op", "second op"},
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		var buf bytes.Buffer
+		renderRollbackResult(&buf, dot.RollbackResult{Checkpoint: checkpoint, DryRun: true})
+
+		out := buf.String()
+		assert.Contains(t, out, "Would roll back checkpoint")
+		assert.Contains(t, out, "(2 operations)")
+		assert.Less(t, strings.Index(out, "second op"), strings.Index(out, "first op"), "undone in reverse order")
+	})
+
+	t.Run("rolled back", func(t *testing.T) {
+		var buf bytes.Buffer
+		renderRollbackResult(&buf, dot.RollbackResult{Checkpoint: checkpoint, RolledBack: 2})
+
+		out := buf.String()
+		assert.Contains(t, out, "Rolled back")
+		assert.Contains(t, out, "2 of 2 operations undone")
+	})
+}
+
+func TestFormatRollbackError(t *testing.T) {
+	t.Run("nothing to roll back", func(t *testing.T) {
+		err := formatRollbackError(dot.ErrCheckpointNotFound{})
+		assert.Contains(t, err.Error(), "no checkpoint to roll back")
+	})
+
+	t.Run("unknown checkpoint", func(t *testing.T) {
+		err := formatRollbackError(dot.ErrCheckpointNotFound{ID: "deadbeef"})
+		assert.Contains(t, err.Error(), "deadbeef")
+		assert.Contains(t, err.Error(), "dot rollback --list")
+	})
+}
@@ -1,10 +1,15 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
+	"time"
 
 	"golang.org/x/term"
 
@@ -20,10 +25,16 @@ type globalConfig struct {
 	packageDir string
 	targetDir  string
 	backupDir  string
+	onConflict string
 	dryRun     bool
+	offline    bool
+	noCache    bool
+	targetUser string
+	profile    string
 	verbose    int
 	quiet      bool
 	logJSON    bool
+	simulate   bool
 }
 
 var globalCfg globalConfig
@@ -43,7 +54,9 @@ comprehensive conflict detection, and incremental updates.`,
 		SilenceErrors: true,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			// Perform startup version check (non-blocking)
-			performStartupVersionCheck(version)
+			if !globalCfg.offline {
+				performStartupVersionCheck(version)
+			}
 			return nil
 		},
 	}
@@ -70,17 +83,29 @@ comprehensive conflict detection, and incremental updates.`,
 	}
 
 	rootCmd.PersistentFlags().StringVarP(&globalCfg.targetDir, "target", "t", defaultTarget,
-		"Target directory for symlinks")
+		"Target directory for symlinks, or a ssh://, docker:// or podman:// URL")
 	rootCmd.PersistentFlags().StringVar(&globalCfg.backupDir, "backup-dir", "",
 		"Directory for backup files (default: <target>/.dot-backup)")
+	rootCmd.PersistentFlags().StringVar(&globalCfg.onConflict, "on-conflict", "",
+		"Conflict resolution strategy: fail, skip, backup, overwrite, adopt, prompt")
 	rootCmd.PersistentFlags().BoolVarP(&globalCfg.dryRun, "dry-run", "n", false,
 		"Show what would be done without applying changes")
+	rootCmd.PersistentFlags().BoolVar(&globalCfg.offline, "offline", false,
+		"Disable network access; commands that need it fail immediately")
+	rootCmd.PersistentFlags().BoolVar(&globalCfg.noCache, "no-cache", false,
+		"Scan every package instead of reusing cached package trees")
+	rootCmd.PersistentFlags().StringVar(&globalCfg.targetUser, "target-user", "",
+		"Manage the dotfiles of another user (root only)")
+	rootCmd.PersistentFlags().StringVar(&globalCfg.profile, "profile", "",
+		"Profile to use, optionally qualified as bootstrap:NAME")
 	rootCmd.PersistentFlags().CountVarP(&globalCfg.verbose, "verbose", "v",
 		"Increase verbosity (repeatable: -v, -vv, -vvv)")
 	rootCmd.PersistentFlags().BoolVarP(&globalCfg.quiet, "quiet", "q", false,
 		"Suppress all non-error output")
 	rootCmd.PersistentFlags().BoolVar(&globalCfg.logJSON, "log-json", false,
 		"Output logs in JSON format")
+	rootCmd.PersistentFlags().BoolVar(&globalCfg.simulate, "simulate", false,
+		"Run against an in-memory copy of the packages and an empty home, changing nothing on disk")
 
 	// Add subcommands
 	rootCmd.AddCommand(
@@ -88,11 +113,33 @@ comprehensive conflict detection, and incremental updates.`,
 		newUnmanageCommand(),
 		newRemanageCommand(),
 		newAdoptCommand(),
+		newTakeoverCommand(),
+		newRollbackCommand(),
+		newResumeCommand(),
+		newHistoryCommand(),
+		newUndoCommand(),
 		newStatusCommand(),
 		newListCommand(),
+		newEditCommand(),
+		newNewCommand(),
+		newImportCommand(),
+		newExportCommand(),
+		newBakeCommand(),
 		newDoctorCommand(),
 		newConfigCommand(),
+		newFeaturesCommand(),
 		newCloneCommand(),
+		newBootstrapCommand(),
+		newSyncCommand(),
+		newRepoCommand(),
+		newMergetoolCommand(),
+		newPlanCommand(),
+		newSkelCommand(),
+		newManifestCommand(),
+		newStatsCommand(),
+		newSchemaCommand(),
+		newVerifyCommand(),
+		newVersionCommand(version, commit, date),
 		newUpgradeCommand(version),
 	)
 
@@ -119,13 +166,37 @@ func buildConfigWithCmd(cmd *cobra.Command) (dot.Config, error) {
 	}
 
 	// Start with config file values
-	var packageDir, targetDir, backupDir, manifestDir string
+	var packageDir, targetDir, backupDir, manifestDir, onConflict string
+	var backup bool
+	var maxParallel int
+	var gitCfg config.GitConfig
+	var secretsCfg config.SecretsConfig
+	var specialFiles dot.SpecialFilePolicy
+	var conflictPolicies map[string]string
 
 	if extCfg != nil {
 		packageDir = extCfg.Directories.Package
 		targetDir = extCfg.Directories.Target
 		backupDir = extCfg.Symlinks.BackupDir
 		manifestDir = extCfg.Directories.Manifest
+		backup = extCfg.Symlinks.Backup
+		onConflict = extCfg.Symlinks.OnConflict
+		conflictPolicies = extCfg.Symlinks.Policies
+		maxParallel = extCfg.Operations.MaxParallel
+		gitCfg = extCfg.Git
+		secretsCfg = extCfg.Secrets
+		if extCfg.Ignore.SpecialFiles == "error" {
+			specialFiles = dot.SpecialFilesError
+		}
+		warnExperimentalFeatures(os.Stderr, extCfg.Experimental)
+	}
+
+	var gitTimeout time.Duration
+	if gitCfg.Timeout != "" {
+		gitTimeout, err = time.ParseDuration(gitCfg.Timeout)
+		if err != nil {
+			return dot.Config{}, fmt.Errorf("invalid git timeout: %w", err)
+		}
 	}
 
 	// Override with globalCfg if set (covers both flag and test scenarios)
@@ -143,6 +214,72 @@ func buildConfigWithCmd(cmd *cobra.Command) (dot.Config, error) {
 		backupDir = globalCfg.backupDir
 	}
 
+	if globalCfg.onConflict != "" {
+		onConflict = globalCfg.onConflict
+	}
+
+	// Deploy to another machine, over SSH or into a container: the
+	// packages, the links and the manifest are all there. Directories of
+	// the configuration file are local, so only flags name other
+	// directories on the machine
+	var remoteFS dot.FS
+	if isRemoteTarget(targetDir) {
+		if globalCfg.targetUser != "" {
+			return dot.Config{}, fmt.Errorf("--target-user cannot be combined with a remote target")
+		}
+		if globalCfg.simulate {
+			return dot.Config{}, fmt.Errorf("--simulate cannot be combined with a remote target")
+		}
+		r, err := parseRemoteTarget(targetDir)
+		if err != nil {
+			return dot.Config{}, err
+		}
+		ctx := context.Background()
+		if cmd != nil && cmd.Context() != nil {
+			ctx = cmd.Context()
+		}
+		remoteFS, err = openRemote(ctx, r)
+		if err != nil {
+			return dot.Config{}, err
+		}
+		if r.dir == "" {
+			r.dir, err = remoteHome(ctx, remoteFS)
+			if err != nil {
+				return dot.Config{}, err
+			}
+		}
+		targetDir = r.dir
+
+		var dirFlag string
+		if globalCfg.packageDir != "." {
+			dirFlag = globalCfg.packageDir
+		}
+		packageDir, err = remoteDir("--dir", dirFlag, path.Join(r.dir, ".dotfiles"))
+		if err != nil {
+			return dot.Config{}, err
+		}
+		backupDir, err = remoteDir("--backup-dir", globalCfg.backupDir, "")
+		if err != nil {
+			return dot.Config{}, err
+		}
+		manifestDir = ""
+	}
+
+	// Manage another user's home, giving them what is created there and
+	// keeping the manifest in their state directory
+	var owner *targetUser
+	if globalCfg.targetUser != "" {
+		u, err := lookupTargetUser(globalCfg.targetUser)
+		if err != nil {
+			return dot.Config{}, err
+		}
+		owner = &u
+		if globalCfg.targetDir == "" || globalCfg.targetDir == homeDir {
+			targetDir = owner.home
+		}
+		manifestDir = owner.stateDir()
+	}
+
 	// Apply final defaults if still empty
 	if packageDir == "" {
 		packageDir = "."
@@ -164,19 +301,99 @@ func buildConfigWithCmd(cmd *cobra.Command) (dot.Config, error) {
 	if err != nil {
 		return dot.Config{}, fmt.Errorf("invalid target directory: %w", err)
 	}
+	if owner != nil {
+		fs = fs.WithOwner(owner.uid, owner.gid, owner.home, targetDir)
+	}
+
+	var targets map[string]string
+	if extCfg != nil && len(extCfg.Targets) > 0 && remoteFS == nil {
+		targets = make(map[string]string, len(extCfg.Targets))
+		for pkg, dir := range extCfg.Targets {
+			targets[pkg], err = filepath.Abs(dir)
+			if err != nil {
+				return dot.Config{}, fmt.Errorf("invalid target directory of package %s: %w", pkg, err)
+			}
+		}
+	}
 
 	cfg := dot.Config{
 		PackageDir:         packageDir,
 		TargetDir:          targetDir,
+		Targets:            targets,
 		BackupDir:          backupDir,
+		Backup:             backup,
+		OnConflict:         onConflict,
+		ConflictPolicies:   conflictPolicies,
+		CheckpointDir:      filepath.Join(config.GetStatePath("dot"), "checkpoints"),
+		AuditLog:           filepath.Join(config.GetStatePath("dot"), "audit.jsonl"),
 		ManifestDir:        manifestDir,
+		Concurrency:        maxParallel,
 		DryRun:             globalCfg.dryRun,
+		Offline:            globalCfg.offline,
+		GitTimeout:         gitTimeout,
+		GitProxy:           gitCfg.Proxy,
+		GitInsecureSkipTLS: gitCfg.InsecureSkipTLS,
+		SecretsProvider:    secretsCfg.Provider,
+		SecretsEnvPrefix:   secretsCfg.EnvPrefix,
+		SecretsVault:       secretsCfg.Vault,
 		Verbosity:          globalCfg.verbose,
 		PackageNameMapping: true, // Default: true (pre-1.0 breaking change)
+		SpecialFiles:       specialFiles,
 		FS:                 fs,
 		Logger:             logger,
 	}
 
+	if !globalCfg.noCache {
+		cfg.ScanCacheDir = filepath.Join(config.GetCachePath("dot"), "scan")
+	}
+	// Snapshots are a last resort for recovering packages, so --no-cache
+	// does not disable them
+	cfg.SnapshotDir = filepath.Join(config.GetCachePath("dot"), "snapshots")
+
+	// Checkpoints, the audit log, the scan cache and snapshots are local
+	// state, which is not kept for a remote target
+	if remoteFS != nil {
+		cfg.FS = remoteFS
+		cfg.CheckpointDir, cfg.AuditLog, cfg.ScanCacheDir, cfg.SnapshotDir = "", "", "", ""
+	}
+	if globalCfg.simulate {
+		ctx, command := context.Background(), ""
+		if cmd != nil {
+			command = cmd.Name()
+			if cmd.Context() != nil {
+				ctx = cmd.Context()
+			}
+		}
+		if err := simulate(ctx, &cfg, command); err != nil {
+			return dot.Config{}, err
+		}
+	}
+
+	if extCfg != nil {
+		t, err := setupTelemetry(fs, extCfg.Observability)
+		if err != nil {
+			return dot.Config{}, err
+		}
+		if t != nil {
+			cfg.Metrics = t.metrics
+			cfg.Tracer = t.tracer
+		}
+	}
+
+	if extCfg != nil && extCfg.Experimental.IsEnabled("boundary") {
+		cfg.Boundary = true
+	}
+
+	// Ask how to resolve each conflict on an interactive terminal
+	if cfg.OnConflict == "prompt" && term.IsTerminal(int(os.Stdin.Fd())) {
+		cfg.ConflictPrompt = newConflictPrompter(os.Stderr, os.Stdin)
+	}
+
+	// Show a progress bar for large plans on an interactive terminal
+	if extCfg != nil && extCfg.Output.Progress && !globalCfg.quiet && !globalCfg.dryRun && isTerminalWriter(os.Stderr) {
+		cfg.EventSink = newProgressSink(os.Stderr, extCfg.Output.Width)
+	}
+
 	return cfg.WithDefaults(), nil
 }
 
@@ -188,13 +405,17 @@ func buildConfigWithCmd(cmd *cobra.Command) (dot.Config, error) {
 //  3. Fall back to XDG location (provided configPath)
 //  4. Use defaults
 //
+// The .dotrc.yaml of the package directory, the --dir flag or else the
+// directory of the configuration, is merged over the file found.
+//
 // This allows repositories to define their own configuration without circular dependency.
 func loadConfigWithRepoPriority(xdgConfigPath string) (*config.ExtendedConfig, error) {
-	var packageDir string
+	var packageDir, projectDir string
 
 	// Check if packageDir was explicitly set via flag
 	if globalCfg.packageDir != "" && globalCfg.packageDir != "." {
 		packageDir = globalCfg.packageDir
+		projectDir = packageDir
 	} else {
 		// Use default packageDir location
 		homeDir, err := os.UserHomeDir()
@@ -205,11 +426,12 @@ func loadConfigWithRepoPriority(xdgConfigPath string) (*config.ExtendedConfig, e
 
 	// Try to load from repository first
 	if packageDir != "" {
-		repoConfigPath := filepath.Join(packageDir, ".config", "dot", "config.yaml")
+		repoConfigPath := config.FindConfigFile(filepath.Join(packageDir, ".config", "dot"))
 		if _, err := os.Stat(repoConfigPath); err == nil {
 			// Repository config exists - use it
-			loader := config.NewLoader("dot", repoConfigPath)
+			loader := config.NewLoader("dot", repoConfigPath).WithProjectDir(projectDir)
 			cfg, err := loader.LoadWithEnv()
+			warnDeprecations(os.Stderr, loader.Deprecations())
 			if err == nil {
 				return cfg, nil
 			}
@@ -219,8 +441,62 @@ func loadConfigWithRepoPriority(xdgConfigPath string) (*config.ExtendedConfig, e
 	}
 
 	// Fall back to XDG location
-	loader := config.NewLoader("dot", xdgConfigPath)
-	return loader.LoadWithEnv()
+	loader := config.NewLoader("dot", xdgConfigPath).WithProjectDir(projectDir)
+	cfg, err := loader.LoadWithEnv()
+	warnDeprecations(os.Stderr, loader.Deprecations())
+	return cfg, err
+}
+
+// warnedDeprecations records deprecation warnings already printed, since
+// some commands load the configuration more than once.
+var warnedDeprecations = make(map[string]bool)
+
+// warnDeprecations prints a warning for each deprecated configuration key
+// or environment variable in use.
+func warnDeprecations(w io.Writer, deprecations []config.Deprecation) {
+	if globalCfg.quiet {
+		return
+	}
+
+	var files []string
+	for _, d := range deprecations {
+		message := d.String()
+		if warnedDeprecations[message] {
+			continue
+		}
+		warnedDeprecations[message] = true
+		fmt.Fprintf(w, "%s %s\n", warning("Warning:"), message)
+		if d.File != "" && !slices.Contains(files, d.File) {
+			files = append(files, d.File)
+		}
+	}
+
+	for _, file := range files {
+		command := "dot config migrate"
+		if file != getConfigFilePath() {
+			command += " " + file
+		}
+		fmt.Fprintln(w, dim(fmt.Sprintf("Run '%s' to update the configuration file", command)))
+	}
+}
+
+// warnedFeatures records the experimental features already warned about.
+var warnedFeatures = make(map[string]bool)
+
+// warnExperimentalFeatures prints a warning for each enabled experimental
+// feature, once per run.
+func warnExperimentalFeatures(w io.Writer, cfg config.ExperimentalConfig) {
+	if globalCfg.quiet {
+		return
+	}
+	for _, name := range cfg.Enabled() {
+		if warnedFeatures[name] {
+			continue
+		}
+		warnedFeatures[name] = true
+		feature, _ := config.LookupFeature(name)
+		fmt.Fprintf(w, "%s experimental feature %s (%s) is enabled\n", warning("Warning:"), name, feature.Status)
+	}
 }
 
 // createLogger creates appropriate logger based on flags.
@@ -232,9 +508,7 @@ func createLogger() dot.Logger {
 	level := verbosityToLevel(globalCfg.verbose)
 
 	if globalCfg.logJSON {
-		return adapters.NewSlogLogger(slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{
-			Level: level,
-		})))
+		return adapters.NewJSONLogger(os.Stderr, level)
 	}
 
 	return adapters.NewSlogLogger(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
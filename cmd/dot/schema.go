@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// fileSchema is the JSON Schema of a kind of file dot reads.
+type fileSchema struct {
+	name   string
+	file   string
+	schema func() []byte
+}
+
+// fileSchemas lists the schemas printed by the schema command and checked
+// by the verify command.
+var fileSchemas = []fileSchema{
+	{name: "bootstrap", file: ".dotbootstrap.yaml", schema: dot.BootstrapSchema},
+	{name: "manifest", file: "dot manifest export documents", schema: dot.PortableManifestSchema},
+}
+
+// newSchemaCommand creates the schema command.
+func newSchemaCommand() *cobra.Command {
+	names := make([]string, 0, len(fileSchemas))
+	for _, s := range fileSchemas {
+		names = append(names, s.name)
+	}
+
+	return &cobra.Command{
+		Use:   "schema [NAME]",
+		Short: "Print the JSON Schema of a dot file",
+		Long: `Print the JSON Schema of a kind of file dot reads, so that editors
+complete and check it while it is written. Without NAME, the available
+schemas are listed.
+
+Editors with a YAML language server apply a schema named in the first
+line of the file:
+
+  # yaml-language-server: $schema=./.dotbootstrap.schema.json
+
+A schema covers fields and their values. Checks that need the whole file
+or the repository, such as profiles naming defined packages, are made by
+'dot bootstrap validate' and 'dot manifest import'; 'dot verify' checks
+files against the schemas.`,
+		Example: `  # List the schemas
+  dot schema
+
+  # Save the bootstrap schema next to the configuration
+  dot schema bootstrap > ~/dotfiles/.dotbootstrap.schema.json`,
+		Args:      argsWithUsage(cobra.MaximumNArgs(1)),
+		ValidArgs: names,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				renderSchemaList(cmd.OutOrStdout())
+				return nil
+			}
+			s, err := lookupSchema(args[0])
+			if err != nil {
+				return err
+			}
+			_, err = cmd.OutOrStdout().Write(s.schema())
+			return err
+		},
+	}
+}
+
+// lookupSchema returns the schema named name.
+func lookupSchema(name string) (fileSchema, error) {
+	for _, s := range fileSchemas {
+		if s.name == name {
+			return s, nil
+		}
+	}
+	return fileSchema{}, fmt.Errorf("unknown schema %q: run 'dot schema' to list them", name)
+}
+
+// renderSchemaList prints the name of each schema and the files it covers.
+func renderSchemaList(w io.Writer) {
+	for _, s := range fileSchemas {
+		fmt.Fprintf(w, "  %-10s %s\n", accent(s.name), dim(s.file))
+	}
+}
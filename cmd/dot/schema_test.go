@@ -0,0 +1,27 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaCommand(t *testing.T) {
+	out, err := runDot(t, "schema")
+	require.NoError(t, err)
+	assert.Contains(t, out, "bootstrap")
+	assert.Contains(t, out, "manifest")
+
+	for _, name := range []string{"bootstrap", "manifest"} {
+		out, err := runDot(t, "schema", name)
+		require.NoError(t, err)
+		var doc map[string]any
+		require.NoError(t, json.Unmarshal([]byte(out), &doc), name)
+		assert.Equal(t, "http://json-schema.org/draft-07/schema#", doc["$schema"])
+	}
+
+	_, err = runDot(t, "schema", "dotmeta")
+	assert.ErrorContains(t, err, `unknown schema "dotmeta"`)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// simulate makes cfg run against an in-memory filesystem holding a copy of
+// the package directory and empty target directories, so that a command
+// shows what it would do to a pristine home without changing the disk.
+// Local state is not kept, and only clone may use the network: pulling
+// or pushing would change the repository on disk.
+func simulate(ctx context.Context, cfg *dot.Config, command string) error {
+	memFS := adapters.NewMemFS()
+	if err := copyToMemFS(ctx, memFS, cfg.PackageDir); err != nil {
+		return fmt.Errorf("simulate: copy package directory: %w", err)
+	}
+	targets := []string{cfg.TargetDir}
+	for _, dir := range cfg.Targets {
+		targets = append(targets, dir)
+	}
+	for _, dir := range targets {
+		if err := memFS.MkdirAll(ctx, dir, 0755); err != nil {
+			return fmt.Errorf("simulate: create target directory: %w", err)
+		}
+	}
+
+	cfg.FS = memFS
+	cfg.CheckpointDir, cfg.AuditLog, cfg.ScanCacheDir, cfg.SnapshotDir = "", "", "", ""
+	if command != "clone" {
+		cfg.Offline = true
+	}
+	return nil
+}
+
+// copyToMemFS copies the directory tree at dir on disk, except its .git
+// directory, to the same path of memFS. A missing dir is not copied.
+func copyToMemFS(ctx context.Context, memFS *adapters.MemFS, dir string) error {
+	if _, err := os.Lstat(dir); os.IsNotExist(err) {
+		return nil
+	}
+	return filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		switch {
+		case entry.IsDir() && entry.Name() == ".git":
+			return filepath.SkipDir
+		case entry.IsDir():
+			return memFS.MkdirAll(ctx, path, 0755)
+		case entry.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return memFS.Symlink(ctx, target, path)
+		case entry.Type().IsRegular():
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			return memFS.WriteFile(ctx, path, data, info.Mode().Perm())
+		default:
+			// Sockets, devices and pipes cannot be packaged
+			return nil
+		}
+	})
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimulate_ChangesNothingOnDisk(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	packageDir := filepath.Join(tmpDir, "packages")
+	targetDir := filepath.Join(tmpDir, "target")
+	require.NoError(t, os.MkdirAll(filepath.Join(packageDir, "vim"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(packageDir, "vim", "dot-vimrc"), []byte("syntax on"), 0644))
+
+	_, err := runDot(t, "--simulate", "--dir", packageDir, "--target", targetDir, "manage", "vim")
+	require.NoError(t, err)
+
+	assert.NoDirExists(t, targetDir)
+	entries, err := os.ReadDir(packageDir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	// Every command starts from a pristine home
+	out, err := runDot(t, "--simulate", "--dir", packageDir, "--target", targetDir, "status")
+	require.NoError(t, err)
+	assert.NotContains(t, out, "vim")
+}
+
+func TestSimulate_SeedsPackagesAndGoesOffline(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	packageDir := filepath.Join(tmpDir, "packages")
+	targetDir := filepath.Join(tmpDir, "target")
+	require.NoError(t, os.MkdirAll(filepath.Join(packageDir, "vim"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(packageDir, ".git"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(packageDir, "vim", "dot-vimrc"), []byte("syntax on"), 0644))
+	require.NoError(t, os.Symlink("dot-vimrc", filepath.Join(packageDir, "vim", "dot-exrc")))
+
+	globalCfg = globalConfig{packageDir: packageDir, targetDir: targetDir, simulate: true}
+	t.Cleanup(func() { globalCfg = globalConfig{} })
+
+	cfg, err := buildConfigWithCmd(nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+	data, err := cfg.FS.ReadFile(ctx, filepath.Join(packageDir, "vim", "dot-vimrc"))
+	require.NoError(t, err)
+	assert.Equal(t, "syntax on", string(data))
+	link, err := cfg.FS.ReadLink(ctx, filepath.Join(packageDir, "vim", "dot-exrc"))
+	require.NoError(t, err)
+	assert.Equal(t, "dot-vimrc", link)
+	assert.False(t, cfg.FS.Exists(ctx, filepath.Join(packageDir, ".git")))
+	assert.True(t, cfg.FS.Exists(ctx, targetDir))
+	assert.True(t, cfg.Offline)
+	assert.Empty(t, cfg.CheckpointDir)
+	assert.Empty(t, cfg.AuditLog)
+
+	globalCfg.targetDir = "ssh://host/home/user"
+	_, err = buildConfigWithCmd(nil)
+	assert.ErrorContains(t, err, "--simulate cannot be combined with a remote target")
+}
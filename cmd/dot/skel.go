@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// newSkelCommand creates the skel command group.
+func newSkelCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "skel",
+		Short: "Work with system skeleton directories",
+		Long: `Work with skeleton directories such as /etc/skel, whose files
+useradd copies into the home directory of every new account.`,
+		Args: argsWithUsage(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(newSkelExportCommand())
+
+	return cmd
+}
+
+// newSkelExportCommand creates the skel export subcommand.
+func newSkelExportCommand() *cobra.Command {
+	var output, profile string
+	var allowSecrets bool
+
+	cmd := &cobra.Command{
+		Use:   "export [PACKAGE...]",
+		Short: "Copy packages into a skeleton directory",
+		Long: `Copy the files of packages into a skeleton directory, so new Unix
+accounts start with them without running dot.
+
+Files are copied, not linked, to where manage would link them in an empty
+home directory, with templates rendered for the running platform. No
+manifest is written: accounts created from the skeleton own plain files.
+Files already in the skeleton directory, such as the defaults of the
+distribution, are replaced. Templates that read secrets are left out,
+since every new account would get a copy, unless --allow-secrets is given.
+
+Without PACKAGE arguments, the packages of the default profile of
+.dotbootstrap.yaml available on the running platform are copied, or those
+of the profile named by --profile.
+
+Examples:
+  # Give new accounts the organization's base profile
+  sudo dot --dir /srv/dotfiles skel export
+
+  # Copy two packages of the base profile into a staging directory
+  dot skel export shell git --output ./skel
+
+  # Show what would be written
+  dot --dry-run skel export --profile base`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSkelExport(cmd, dot.SkelOptions{Output: output, Packages: args, Profile: profile, AllowSecrets: allowSecrets})
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "/etc/skel", "skeleton directory to write")
+	cmd.Flags().StringVar(&profile, "profile", "", "bootstrap profile to copy (default from .dotbootstrap.yaml)")
+	cmd.Flags().BoolVar(&allowSecrets, "allow-secrets", false, "copy rendered templates that hold secrets, readable only by their owner")
+	_ = cmd.MarkFlagDirname("output")
+
+	return cmd
+}
+
+// runSkelExport handles the skel export command execution.
+func runSkelExport(cmd *cobra.Command, opts dot.SkelOptions) error {
+	if len(opts.Packages) > 0 && opts.Profile != "" {
+		return fmt.Errorf("--profile cannot be combined with PACKAGE arguments")
+	}
+
+	cfg, err := buildConfigWithCmd(cmd)
+	if err != nil {
+		return formatError(err)
+	}
+	client, err := dot.NewClient(cfg)
+	if err != nil {
+		return formatError(err)
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	opts.Output, err = filepath.Abs(opts.Output)
+	if err != nil {
+		return fmt.Errorf("resolve %s: %w", opts.Output, err)
+	}
+
+	result, err := client.ExportSkel(ctx, opts)
+	if err != nil {
+		return formatError(err)
+	}
+
+	if !globalCfg.quiet {
+		renderSkelResult(cmd.OutOrStdout(), result, cfg.DryRun)
+	}
+	return nil
+}
+
+// renderSkelResult prints the files written to a skeleton directory.
+func renderSkelResult(w io.Writer, result dot.SkelResult, dryRun bool) {
+	verb := "Copied"
+	if dryRun {
+		verb = "Would copy"
+	}
+	fmt.Fprintf(w, "%s %d files from %d packages to %s\n", verb, len(result.Files), len(result.Packages), result.Path)
+	if len(result.Packages) > 0 {
+		fmt.Fprintf(w, "  %s %s\n", dim("packages:"), accent(strings.Join(result.Packages, ", ")))
+	}
+
+	replaced := make(map[string]bool, len(result.Replaced))
+	for _, path := range result.Replaced {
+		replaced[path] = true
+	}
+	for _, path := range result.Files {
+		if replaced[path] {
+			fmt.Fprintf(w, "  %s %s\n", warning("replace"), path)
+		} else {
+			fmt.Fprintf(w, "  %s %s\n", success("create "), path)
+		}
+	}
+	for _, path := range result.Skipped {
+		fmt.Fprintf(w, "  %s %s %s\n", dim("skip   "), path, dim("(outside the target directory)"))
+	}
+	for _, path := range result.SkippedSecrets {
+		fmt.Fprintf(w, "  %s %s %s\n", dim("skip   "), path, dim("(holds secrets; use --allow-secrets)"))
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSkelExportCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	packageDir := filepath.Join(tmpDir, "packages")
+	targetDir := filepath.Join(tmpDir, "target")
+	skelDir := filepath.Join(tmpDir, "skel")
+	require.NoError(t, os.MkdirAll(filepath.Join(packageDir, "dot-vim"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(packageDir, "dot-emacs.d"), 0755))
+	require.NoError(t, os.MkdirAll(targetDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(packageDir, "dot-vim", "vimrc"), []byte("set nocompatible"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(packageDir, "dot-emacs.d", "init.el"), []byte("(setq x 1)"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(packageDir, ".dotbootstrap.yaml"), []byte(`version: "1.0"
+packages:
+  - name: dot-vim
+  - name: dot-emacs.d
+profiles:
+  base:
+    description: Base
+    packages: [dot-vim]
+defaults:
+  profile: base
+`), 0644))
+
+	out, err := runDot(t, "--dir", packageDir, "--target", targetDir, "--dry-run", "skel", "export", "--output", skelDir)
+	require.NoError(t, err)
+	assert.Contains(t, out, "Would copy 1 files from 1 packages to "+skelDir)
+	assert.NoDirExists(t, skelDir)
+
+	out, err = runDot(t, "--dir", packageDir, "--target", targetDir, "skel", "export", "--output", skelDir, "dot-emacs.d")
+	require.NoError(t, err)
+	assert.Contains(t, out, "create  .emacs.d/init.el")
+	data, err := os.ReadFile(filepath.Join(skelDir, ".emacs.d", "init.el"))
+	require.NoError(t, err)
+	assert.Equal(t, "(setq x 1)", string(data))
+	assert.NoFileExists(t, filepath.Join(targetDir, ".dot-manifest.json"))
+
+	_, err = runDot(t, "--dir", packageDir, "skel", "export", "--profile", "base", "dot-vim")
+	assert.ErrorContains(t, err, "--profile cannot be combined")
+
+	// The global position of --profile selects the same profile
+	_, err = runDot(t, "--dir", packageDir, "--profile", "bootstrap:base", "skel", "export", "--output", skelDir, "dot-vim")
+	assert.ErrorContains(t, err, "--profile cannot be combined")
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// newStatsCommand creates the stats command group.
+func newStatsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show statistics about managed files",
+		Long: `Show statistics about what dot manages in the target directory, to
+understand and tidy up large configurations.`,
+		Example: `  # See where links pile up and which files are linked twice
+  dot stats links`,
+		Args: argsWithUsage(cobra.NoArgs),
+	}
+
+	cmd.AddCommand(newStatsLinksCommand())
+
+	return cmd
+}
+
+// newStatsLinksCommand creates the links subcommand.
+func newStatsLinksCommand() *cobra.Command {
+	var format string
+	var top int
+
+	cmd := &cobra.Command{
+		Use:   "links",
+		Short: "Show how managed links are laid out",
+		Long: `Report how the links recorded in the manifest are laid out:
+  - the directories holding the most links
+  - the links nested deepest below the target directory
+  - links that resolve through more than one symbolic link, longest first
+  - files that several links resolve to
+
+Each list shows the first --top entries; --top -1 shows all. Links are
+read from the target directory but nothing is changed.`,
+		Example: `  # Summarize the symlink farm
+  dot stats links
+
+  # Every directory, as JSON
+  dot stats links --top -1 --format json`,
+		Args: argsWithUsage(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runStatsLinks(cmd, format, top)
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", "text", "output format (text, json, yaml)")
+	cmd.Flags().IntVar(&top, "top", 10, "entries shown in each list (-1 for all)")
+
+	return cmd
+}
+
+// runStatsLinks handles the stats links command execution.
+func runStatsLinks(cmd *cobra.Command, format string, top int) error {
+	if format != "text" && format != "json" && format != "yaml" {
+		return fmt.Errorf("invalid format %q: use text, json or yaml", format)
+	}
+	if top == 0 || top < -1 {
+		return fmt.Errorf("invalid --top %d: use a positive number or -1", top)
+	}
+
+	cfg, err := buildConfigWithCmd(cmd)
+	if err != nil {
+		return formatError(err)
+	}
+	client, err := dot.NewClient(cfg)
+	if err != nil {
+		return formatError(err)
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	stats, err := client.LinkStats(ctx, dot.LinkStatsOptions{Top: top})
+	if err != nil {
+		return formatError(err)
+	}
+
+	out := cmd.OutOrStdout()
+	switch format {
+	case "json":
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(stats)
+	case "yaml":
+		enc := yaml.NewEncoder(out)
+		enc.SetIndent(2)
+		if err := enc.Encode(stats); err != nil {
+			return err
+		}
+		return enc.Close()
+	default:
+		renderLinkStats(out, stats)
+		return nil
+	}
+}
+
+// renderLinkStats prints link statistics as sections of counted entries.
+func renderLinkStats(w io.Writer, stats dot.LinkStats) {
+	fmt.Fprintf(w, "Links: %d in %d directories\n", stats.Links, stats.DirectoryCount)
+
+	if len(stats.Directories) > 0 {
+		fmt.Fprintf(w, "\n%s\n", accent("Links per directory:"))
+		for _, dir := range stats.Directories {
+			fmt.Fprintf(w, "  %4d  %s\n", dir.Links, dir.Dir)
+		}
+	}
+
+	if len(stats.Deepest) > 0 {
+		fmt.Fprintf(w, "\n%s\n", accent("Deepest links:"))
+		for _, link := range stats.Deepest {
+			fmt.Fprintf(w, "  %4d  %s %s\n", link.Depth, link.Path, dim("("+link.Package+")"))
+		}
+	}
+
+	if len(stats.LongestChains) > 0 {
+		fmt.Fprintf(w, "\n%s\n", accent("Longest chains:"))
+		for _, chain := range stats.LongestChains {
+			fmt.Fprintf(w, "  %4d  %s -> %s\n", chain.Hops(), chain.Path, strings.Join(chain.Targets, " -> "))
+		}
+	}
+
+	if len(stats.DuplicateSources) > 0 {
+		fmt.Fprintf(w, "\n%s\n", warning("Duplicate sources:"))
+		for _, dup := range stats.DuplicateSources {
+			fmt.Fprintf(w, "  %s\n", dup.Source)
+			for _, link := range dup.Links {
+				fmt.Fprintf(w, "        %s\n", link)
+			}
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsLinksCommand(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	packageDir := filepath.Join(tmpDir, "packages")
+	targetDir := filepath.Join(tmpDir, "target")
+	require.NoError(t, os.MkdirAll(filepath.Join(packageDir, "vim"), 0755))
+	require.NoError(t, os.MkdirAll(targetDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(packageDir, "vim", "dot-vimrc"), []byte("set nocompatible"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(packageDir, "vim", "dot-gvimrc"), []byte("set guifont"), 0644))
+	_, err := runDot(t, "--dir", packageDir, "--target", targetDir, "manage", "vim")
+	require.NoError(t, err)
+
+	out, err := runDot(t, "--dir", packageDir, "--target", targetDir, "stats", "links")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Links: 2 in 1 directories")
+	assert.Contains(t, out, "Links per directory:")
+	assert.Contains(t, out, "Deepest links:")
+	assert.NotContains(t, out, "Duplicate sources:")
+
+	out, err = runDot(t, "--dir", packageDir, "--target", targetDir, "stats", "links", "--format", "json")
+	require.NoError(t, err)
+	assert.Contains(t, out, `"duplicate_sources": []`)
+
+	_, err = runDot(t, "--dir", packageDir, "--target", targetDir, "stats", "links", "--top", "0")
+	assert.ErrorContains(t, err, "invalid --top 0")
+}
@@ -27,6 +27,11 @@ func newStatusCommand() *cobra.Command {
 		// Get format and color from local flags
 		format, _ := cmd.Flags().GetString("format")
 		color, _ := cmd.Flags().GetString("color")
+		watch, _ := cmd.Flags().GetBool("watch")
+		health, _ := cmd.Flags().GetBool("health")
+		if watch && format != "text" && format != "table" {
+			return fmt.Errorf("--watch supports text and table formats only")
+		}
 
 		// Create client
 		client, err := dot.NewClient(cfg)
@@ -34,8 +39,12 @@ func newStatusCommand() *cobra.Command {
 			return formatError(err)
 		}
 
+		if watch {
+			return runStatusWatch(cmd, cfg, client, args, shouldColorize(color))
+		}
+
 		// Get status
-		status, err := client.Status(cmd.Context(), args...)
+		status, err := client.StatusWithOptions(cmd.Context(), dot.StatusOptions{Packages: args, Health: health})
 		if err != nil {
 			return formatError(err)
 		}
@@ -73,6 +82,8 @@ func newStatusCommand() *cobra.Command {
 func NewStatusCommand(cfg *dot.Config) *cobra.Command {
 	var format string
 	var color string
+	var watch bool
+	var health bool
 
 	cmd := &cobra.Command{
 		Use:   "status [PACKAGE...]",
@@ -80,7 +91,14 @@ func NewStatusCommand(cfg *dot.Config) *cobra.Command {
 		Long: `Display the current installation state for specified packages.
 
 If no packages are specified, shows status for all installed packages.
-The status includes installation timestamp, number of links, and link paths.`,
+The status includes installation timestamp, number of links, and link paths.
+
+With --health, the links of each package are also inspected and counted
+as ok, broken or drifted, shown in a Health column of the table. Without
+it, status reads the manifest only.
+
+With --watch, a live table of link health (ok, broken, drifted) per package
+is refreshed whenever the target or package directories change.`,
 		Example: `  # Show status for all packages
   dot status
 
@@ -90,22 +108,36 @@ The status includes installation timestamp, number of links, and link paths.`,
   # Show status in JSON format
   dot status --format=json
 
+  # Count broken and drifted links per package
+  dot status --health --format=table
+
   # Show status with colors disabled
-  dot status --color=never`,
+  dot status --color=never
+
+  # Watch link health while editing dotfiles
+  dot status --watch`,
 		ValidArgsFunction: packageCompletion(true), // Complete with installed packages
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Load extended config for table_style
 			configPath := getConfigFilePath()
 			extCfg, _ := loadConfigWithRepoPriority(configPath)
 
+			if watch && format != "text" && format != "table" {
+				return fmt.Errorf("--watch supports text and table formats only")
+			}
+
 			// Create client
 			client, err := dot.NewClient(*cfg)
 			if err != nil {
 				return formatError(err)
 			}
 
+			if watch {
+				return runStatusWatch(cmd, *cfg, client, args, shouldColorize(color))
+			}
+
 			// Get status
-			status, err := client.Status(cmd.Context(), args...)
+			status, err := client.StatusWithOptions(cmd.Context(), dot.StatusOptions{Packages: args, Health: health})
 			if err != nil {
 				return formatError(err)
 			}
@@ -139,6 +171,8 @@ The status includes installation timestamp, number of links, and link paths.`,
 
 	cmd.Flags().StringVarP(&format, "format", "f", "text", "Output format (text, json, yaml, table)")
 	cmd.Flags().StringVar(&color, "color", "auto", "Colorize output (auto, always, never)")
+	cmd.Flags().BoolVarP(&watch, "watch", "w", false, "Refresh link health as files change")
+	cmd.Flags().BoolVar(&health, "health", false, "Inspect links and report broken and drifted counts")
 
 	return cmd
 }
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -33,6 +35,10 @@ func TestStatusCommand_Flags(t *testing.T) {
 	colorFlag := cmd.Flags().Lookup("color")
 	require.NotNil(t, colorFlag)
 	assert.Equal(t, "auto", colorFlag.DefValue)
+
+	healthFlag := cmd.Flags().Lookup("health")
+	require.NotNil(t, healthFlag)
+	assert.Equal(t, "false", healthFlag.DefValue)
 }
 
 func TestStatusCommand_OutputFormat(t *testing.T) {
@@ -96,3 +102,25 @@ func TestStatusCommand_Help(t *testing.T) {
 	assert.NotEmpty(t, cmd.Long)
 	assert.NotEmpty(t, cmd.Example)
 }
+
+func TestStatusCommand_Health(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	packageDir := filepath.Join(tmpDir, "packages")
+	targetDir := filepath.Join(tmpDir, "target")
+	require.NoError(t, os.MkdirAll(filepath.Join(packageDir, "vim"), 0755))
+	require.NoError(t, os.MkdirAll(targetDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(packageDir, "vim", "dot-vimrc"), []byte("set nocompatible"), 0644))
+	_, err := runDot(t, "--dir", packageDir, "--target", targetDir, "manage", "vim")
+	require.NoError(t, err)
+
+	out, err := runDot(t, "--dir", packageDir, "--target", targetDir, "status", "--format", "table")
+	require.NoError(t, err)
+	assert.NotContains(t, out, "HEALTH")
+
+	require.NoError(t, os.Remove(filepath.Join(packageDir, "vim", "dot-vimrc")))
+	out, err = runDot(t, "--dir", packageDir, "--target", targetDir, "status", "--health", "--format", "table")
+	require.NoError(t, err)
+	assert.Contains(t, out, "HEALTH")
+	assert.Contains(t, out, "1 broken")
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/jamesainslie/dot/internal/cli/pretty"
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// watchDebounce is how long the watcher waits for filesystem events to
+// settle before refreshing the table.
+const watchDebounce = 200 * time.Millisecond
+
+// clearScreen moves the cursor home and clears the terminal.
+const clearScreen = "\033[H\033[2J"
+
+// statusWatcher re-renders link health whenever watched paths change.
+type statusWatcher struct {
+	client   *dot.Client
+	packages []string
+	out      io.Writer
+	colorize bool
+	clear    bool
+	debounce time.Duration
+
+	// onRefresh is called with the latest health after each render so
+	// newly created directories can be watched.
+	onRefresh func([]dot.PackageHealth)
+}
+
+// runStatusWatch shows a live link health table until interrupted.
+func runStatusWatch(cmd *cobra.Command, cfg dot.Config, client *dot.Client, packages []string, colorize bool) error {
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("start watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	out := cmd.OutOrStdout()
+	sw := &statusWatcher{
+		client:   client,
+		packages: packages,
+		out:      out,
+		colorize: colorize,
+		clear:    isTerminalWriter(out),
+		debounce: watchDebounce,
+		onRefresh: func(health []dot.PackageHealth) {
+			addStatusWatches(watcher, cfg, health)
+		},
+	}
+
+	return sw.run(ctx, watcher.Events, watcher.Errors)
+}
+
+// run renders the table once, then again after each burst of events.
+// It returns nil when ctx is cancelled.
+func (w *statusWatcher) run(ctx context.Context, events <-chan fsnotify.Event, errs <-chan error) error {
+	if err := w.refresh(ctx); err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(w.debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case _, ok := <-events:
+			if !ok {
+				return nil
+			}
+			timer.Reset(w.debounce)
+		case err, ok := <-errs:
+			if !ok {
+				return nil
+			}
+			return fmt.Errorf("watch failed: %w", err)
+		case <-timer.C:
+			if err := w.refresh(ctx); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// refresh evaluates link health and redraws the table.
+func (w *statusWatcher) refresh(ctx context.Context) error {
+	health, err := w.client.Health(ctx, w.packages...)
+	if err != nil {
+		return formatError(err)
+	}
+
+	if w.clear {
+		fmt.Fprint(w.out, clearScreen)
+	}
+	renderHealthTable(w.out, health, w.colorize)
+	fmt.Fprintf(w.out, "\n%s\n", dim(fmt.Sprintf("Updated %s. Watching for changes, press Ctrl+C to stop.",
+		time.Now().Format("15:04:05"))))
+
+	if w.onRefresh != nil {
+		w.onRefresh(health)
+	}
+	return nil
+}
+
+// renderHealthTable writes one row per package with link state counts.
+func renderHealthTable(w io.Writer, health []dot.PackageHealth, colorize bool) {
+	if len(health) == 0 {
+		fmt.Fprintln(w, "No packages installed")
+		return
+	}
+
+	table := pretty.NewTableWriter(pretty.StyleLight, pretty.TableConfig{
+		ColorEnabled: colorize,
+		AutoWrap:     true,
+	})
+	table.SetHeader("Package", "OK", "Broken", "Drifted")
+	for _, pkg := range health {
+		table.AppendRow(
+			pkg.Name,
+			fmt.Sprintf("%d", pkg.Count(dot.LinkStateOK)),
+			fmt.Sprintf("%d", pkg.Count(dot.LinkStateBroken)),
+			fmt.Sprintf("%d", pkg.Count(dot.LinkStateDrifted)),
+		)
+	}
+	table.Render(w)
+}
+
+// addStatusWatches watches the directories that hold each package's links
+// and every directory inside each package. Adding an existing watch is a
+// no-op, and paths that cannot be watched are skipped.
+func addStatusWatches(watcher *fsnotify.Watcher, cfg dot.Config, health []dot.PackageHealth) {
+	for _, pkg := range health {
+		for _, link := range pkg.Links {
+			_ = watcher.Add(filepath.Dir(filepath.Join(cfg.TargetDir, link.Path)))
+		}
+
+		_ = filepath.WalkDir(filepath.Join(cfg.PackageDir, pkg.Name), func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				_ = watcher.Add(path)
+			}
+			return nil
+		})
+	}
+}
+
+// isTerminalWriter reports whether w is an interactive terminal.
+func isTerminalWriter(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
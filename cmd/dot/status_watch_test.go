@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+func TestRenderHealthTable(t *testing.T) {
+	health := []dot.PackageHealth{
+		{
+			Name: "vim",
+			Links: []dot.LinkStatus{
+				{Path: ".vimrc", State: dot.LinkStateOK},
+				{Path: ".gvimrc", State: dot.LinkStateBroken},
+				{Path: ".vim", State: dot.LinkStateDrifted},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	renderHealthTable(&buf, health, false)
+	out := buf.String()
+
+	assert.Contains(t, out, "PACKAGE")
+	assert.Contains(t, out, "DRIFTED")
+	assert.Contains(t, out, "vim")
+}
+
+func TestRenderHealthTable_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	renderHealthTable(&buf, nil, false)
+
+	assert.Contains(t, buf.String(), "No packages installed")
+}
+
+func TestStatusWatcher_RefreshesOnEvents(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/vim", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/vim/dot-vimrc", []byte("set nocompatible"), 0644))
+
+	client, err := dot.NewClient(dot.Config{
+		PackageDir: "/test/packages",
+		TargetDir:  "/test/target",
+		FS:         fs,
+		Logger:     adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+	require.NoError(t, client.Manage(ctx, "vim"))
+
+	var buf bytes.Buffer
+	refreshed := make(chan struct{}, 4)
+	w := &statusWatcher{
+		client:   client,
+		out:      &buf,
+		debounce: time.Millisecond,
+		onRefresh: func([]dot.PackageHealth) {
+			refreshed <- struct{}{}
+		},
+	}
+
+	events := make(chan fsnotify.Event)
+	errs := make(chan error)
+	done := make(chan error, 1)
+	go func() { done <- w.run(ctx, events, errs) }()
+
+	<-refreshed
+	events <- fsnotify.Event{Name: "/test/target/.vimrc", Op: fsnotify.Remove}
+	events <- fsnotify.Event{Name: "/test/target/.vimrc", Op: fsnotify.Create}
+	<-refreshed
+
+	cancel()
+	require.NoError(t, <-done)
+	assert.Equal(t, 2, strings.Count(buf.String(), "Watching for changes"))
+	assert.NotContains(t, buf.String(), clearScreen)
+}
+
+func TestStatusWatcher_StopsOnWatchError(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target", 0755))
+
+	client, err := dot.NewClient(dot.Config{
+		PackageDir: "/test/packages",
+		TargetDir:  "/test/target",
+		FS:         fs,
+		Logger:     adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+
+	errs := make(chan error, 1)
+	errs <- assert.AnError
+	w := &statusWatcher{client: client, out: &bytes.Buffer{}, debounce: time.Millisecond}
+
+	err = w.run(ctx, make(chan fsnotify.Event), errs)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "watch failed")
+}
+
+func TestStatusCommand_WatchRejectsJSON(t *testing.T) {
+	cmd := NewStatusCommand(&dot.Config{})
+	cmd.SetArgs([]string{"--watch", "--format", "json"})
+	cmd.SetOut(&bytes.Buffer{})
+
+	err := cmd.Execute()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--watch")
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jamesainslie/dot/internal/cli/renderer"
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// newSyncCommand creates the sync command.
+func newSyncCommand() *cobra.Command {
+	var syncBranch string
+
+	cmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Pull repository changes and remanage changed packages",
+		Long: `Pull the package repository and remanage only the installed packages
+whose files changed since the commit recorded in the manifest.
+
+The sync command performs the following steps:
+  1. Pulls the package directory from its remote (fast-forward only), or
+     rebases a per-machine branch onto its base branch
+  2. Compares the recorded commit with the new HEAD
+  3. Remanages installed packages with changed files
+  4. Unmanages installed packages removed from the repository
+  5. Records the new commit in the manifest
+
+A per-machine branch, set up by 'dot clone --machine-branch', keeps
+machine-specific commits on top of the shared branch. If both changed the
+same file differently, sync stops before changing anything and reports
+the conflicting packages.
+
+With --dry-run the repository is not pulled. The plan shows changes that
+are already checked out but have not been applied yet.
+
+Examples:
+  # Pull and apply changes
+  dot sync
+
+  # Pull a specific branch
+  dot sync --branch work
+
+  # Preview changes pulled manually with git
+  git -C ~/dotfiles pull && dot sync --dry-run`,
+		Args: argsWithUsage(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSync(cmd, syncBranch)
+		},
+	}
+
+	cmd.Flags().StringVar(&syncBranch, "branch", "", "branch to pull, or to rebase a machine branch onto (defaults to recorded branch)")
+
+	return cmd
+}
+
+// runSync handles the sync command execution.
+func runSync(cmd *cobra.Command, branch string) error {
+	cfg, err := buildConfigWithCmd(cmd)
+	if err != nil {
+		return formatError(err)
+	}
+
+	client, err := dot.NewClient(cfg)
+	if err != nil {
+		return formatError(err)
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	result, err := client.Sync(ctx, dot.SyncOptions{Branch: branch})
+	if err != nil {
+		return formatSyncError(err)
+	}
+
+	out := cmd.OutOrStdout()
+
+	if result.ShallowHistory {
+		renderShallowHistoryWarning(cmd.ErrOrStderr(), result.FromCommit)
+	}
+
+	if cfg.DryRun {
+		if len(result.Removed) > 0 {
+			fmt.Fprintf(out, "Would unmanage removed package(s): %s\n", strings.Join(result.Removed, ", "))
+		}
+		if len(result.Packages) == 0 {
+			fmt.Fprintln(out, "No package changes to apply")
+			return nil
+		}
+
+		extCfg, _ := loadConfigWithRepoPriority(getConfigFilePath())
+		tableStyle := ""
+		if extCfg != nil {
+			tableStyle = extCfg.Output.TableStyle
+		}
+		rend, err := renderer.NewRenderer("text", true, tableStyle)
+		if err != nil {
+			return err
+		}
+		return rend.RenderPlan(out, result.Plan)
+	}
+
+	if result.Rebased > 0 {
+		renderRebasedNote(out, result.Rebased)
+	}
+
+	if result.UpToDate() {
+		fmt.Fprintf(out, "Already up to date at %s\n", shortCommit(result.ToCommit))
+		return nil
+	}
+
+	fmt.Fprintf(out, "Synced to %s\n", shortCommit(result.ToCommit))
+	if len(result.Packages) > 0 {
+		fmt.Fprintf(out, "Remanaged %d package(s): %s\n", len(result.Packages), strings.Join(result.Packages, ", "))
+	}
+	if len(result.Removed) > 0 {
+		fmt.Fprintf(out, "Unmanaged %d removed package(s): %s\n", len(result.Removed), strings.Join(result.Removed, ", "))
+	}
+
+	return nil
+}
+
+// renderShallowHistoryWarning explains why every package was remanaged when
+// the recorded commit is missing from a shallow clone.
+func renderShallowHistoryWarning(w io.Writer, from string) {
+	fmt.Fprintf(w, "%s commit %s is not in the shallow clone's history, so all installed packages were checked\n",
+		warning("Warning:"), shortCommit(from))
+	fmt.Fprintln(w, "Run 'dot repo unshallow' to fetch full history")
+}
+
+// renderRebasedNote reports rebased per-machine commits, which must be
+// force pushed if the machine branch was pushed before.
+func renderRebasedNote(w io.Writer, count int) {
+	fmt.Fprintf(w, "Rebased %d machine commit(s) onto the base branch\n", count)
+	fmt.Fprintln(w, dim("Run 'dot repo push --force' to update the remote machine branch"))
+}
+
+// shortCommit abbreviates a commit hash for display.
+func shortCommit(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+// formatSyncError formats sync-specific errors with helpful messages.
+func formatSyncError(err error) error {
+	var offline dot.ErrOffline
+	if errors.As(err, &offline) {
+		return fmt.Errorf("%w\n\nRun without --offline once network access is available", offline)
+	}
+
+	var pullFailed dot.ErrPullFailed
+	if errors.As(err, &pullFailed) {
+		return fmt.Errorf("%w\n\nEnsure:\n  - The package directory is a git repository with a remote\n  - Local changes do not prevent a fast-forward\n  - Network connection is available", pullFailed)
+	}
+
+	var conflict dot.ErrRebaseConflict
+	if errors.As(err, &conflict) {
+		return fmt.Errorf("%w\n\nBoth %s and %s changed:\n  - %s\n\nNothing was changed. Resolve the conflict in the package directory with\ngit rebase origin/%s, then run 'dot sync' again",
+			conflict, conflict.Branch, conflict.Onto, strings.Join(conflict.Files, "\n  - "), conflict.Onto)
+	}
+
+	var authFailed dot.ErrAuthFailed
+	if errors.As(err, &authFailed) {
+		return fmt.Errorf("%w\n\nTry:\n  - Setting GITHUB_TOKEN environment variable\n  - Setting GIT_TOKEN environment variable\n  - Configuring SSH keys in ~/.ssh/", authFailed)
+	}
+
+	return err
+}
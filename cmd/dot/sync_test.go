@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+func TestSyncCommand_Flags(t *testing.T) {
+	cmd := newSyncCommand()
+
+	flag := cmd.Flags().Lookup("branch")
+	assert.NotNil(t, flag)
+	assert.Equal(t, "string", flag.Value.Type())
+
+	assert.Error(t, cmd.Args(cmd, []string{"extra"}))
+	assert.NoError(t, cmd.Args(cmd, []string{}))
+}
+
+func TestShortCommit(t *testing.T) {
+	assert.Equal(t, "1234567", shortCommit("1234567890abcdef"))
+	assert.Equal(t, "abc", shortCommit("abc"))
+}
+
+func TestRenderShallowHistoryWarning(t *testing.T) {
+	var buf bytes.Buffer
+	renderShallowHistoryWarning(&buf, "1234567890abcdef")
+
+	out := buf.String()
+	assert.Contains(t, out, "1234567")
+	assert.Contains(t, out, "shallow clone")
+	assert.Contains(t, out, "dot repo unshallow")
+}
+
+func TestRenderRebasedNote(t *testing.T) {
+	var buf bytes.Buffer
+	renderRebasedNote(&buf, 3)
+
+	out := buf.String()
+	assert.Contains(t, out, "Rebased 3 machine commit(s)")
+	assert.Contains(t, out, "dot repo push --force")
+}
+
+func TestFormatSyncError_RebaseConflict(t *testing.T) {
+	err := formatSyncError(dot.ErrRebaseConflict{
+		Branch:   "laptop",
+		Onto:     "main",
+		Packages: []string{"vim"},
+		Files:    []string{"vim/dot-vimrc"},
+	})
+
+	msg := err.Error()
+	assert.Contains(t, msg, "conflicting changes in package(s) vim")
+	assert.Contains(t, msg, "  - vim/dot-vimrc")
+	assert.Contains(t, msg, "Nothing was changed")
+	assert.Contains(t, msg, "git rebase origin/main")
+}
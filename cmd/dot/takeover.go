@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// newTakeoverCommand creates the takeover command.
+func newTakeoverCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "takeover PACKAGE [PACKAGE...]",
+		Short: "Register existing links in the manifest without changing files",
+		Long: `Rebuild manifest entries for packages whose links already exist in the
+target directory, for example links created by GNU Stow or by an earlier
+dot install whose manifest was lost.
+
+The target directory is never modified. For each package the command
+reports links found, links still missing, and conflicting paths. Packages
+with at least one existing link are registered; run 'dot manage' afterwards
+to create any missing links.
+
+Examples:
+  # Take over packages previously installed with GNU Stow
+  dot takeover vim zsh git
+
+  # Preview the report without writing the manifest
+  dot --dry-run takeover vim`,
+		Args:              argsWithUsage(cobra.MinimumNArgs(1)),
+		RunE:              runTakeover,
+		ValidArgsFunction: packageCompletion(false), // Complete with available packages
+	}
+
+	return cmd
+}
+
+// runTakeover handles the takeover command execution.
+func runTakeover(cmd *cobra.Command, args []string) error {
+	cfg, err := buildConfigWithCmd(cmd)
+	if err != nil {
+		return formatError(err)
+	}
+
+	client, err := dot.NewClient(cfg)
+	if err != nil {
+		return formatError(err)
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	report, err := client.Takeover(ctx, args...)
+	if err != nil {
+		return formatError(err)
+	}
+
+	renderTakeoverReport(cmd.OutOrStdout(), report, cfg.DryRun)
+	return nil
+}
+
+// renderTakeoverReport prints a per-package summary of a takeover.
+func renderTakeoverReport(w io.Writer, report dot.TakeoverReport, dryRun bool) {
+	for _, pkg := range report.Packages {
+		fmt.Fprintf(w, "%s: %d linked, %d missing, %d conflicts\n",
+			pkg.Name, len(pkg.Linked), len(pkg.Missing), len(pkg.Conflicts))
+
+		for _, link := range pkg.Linked {
+			fmt.Fprintf(w, "  %s %s\n", success("✓"), link)
+		}
+		for _, link := range pkg.Missing {
+			fmt.Fprintf(w, "  %s %s %s\n", warning("-"), link, dim("(missing)"))
+		}
+		for _, conflict := range pkg.Conflicts {
+			fmt.Fprintf(w, "  %s %s: %s\n", errorText("✗"), conflict.Path, conflict.Details)
+		}
+
+		switch {
+		case pkg.Registered:
+			fmt.Fprintf(w, "  Registered %s in manifest\n", pkg.Name)
+		case dryRun && len(pkg.Linked) > 0:
+			fmt.Fprintf(w, "  Would register %s in manifest\n", pkg.Name)
+		default:
+			fmt.Fprintf(w, "  Not registered: no existing links found\n")
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+func TestTakeoverCommand_Args(t *testing.T) {
+	cmd := newTakeoverCommand()
+
+	assert.Error(t, cmd.Args(cmd, []string{}))
+	assert.NoError(t, cmd.Args(cmd, []string{"vim", "zsh"}))
+}
+
+func TestRenderTakeoverReport(t *testing.T) {
+	report := dot.TakeoverReport{
+		Packages: []dot.PackageTakeover{
+			{
+				Name:       "vim",
+				Linked:     []string{".vimrc"},
+				Missing:    []string{".gvimrc"},
+				Conflicts:  []dot.ConflictInfo{{Path: ".vim", Details: "File exists at target (size=3)"}},
+				Registered: true,
+			},
+			{Name: "zsh"},
+		},
+	}
+
+	var buf bytes.Buffer
+	renderTakeoverReport(&buf, report, false)
+	out := buf.String()
+
+	assert.Contains(t, out, "vim: 1 linked, 1 missing, 1 conflicts")
+	assert.Contains(t, out, ".vimrc")
+	assert.Contains(t, out, ".gvimrc (missing)")
+	assert.Contains(t, out, ".vim: File exists at target (size=3)")
+	assert.Contains(t, out, "Registered vim in manifest")
+	assert.Contains(t, out, "zsh: 0 linked, 0 missing, 0 conflicts")
+	assert.Contains(t, out, "Not registered")
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+)
+
+// geteuid returns the effective user ID of the process. Tests replace it
+// to exercise --target-user without running as root.
+var geteuid = os.Geteuid
+
+// targetUser is the account whose dotfiles --target-user manages.
+type targetUser struct {
+	name     string
+	home     string
+	uid, gid int
+}
+
+// stateDir returns the directory dot keeps the state of u in, the default
+// XDG state directory under the home of u.
+func (u targetUser) stateDir() string {
+	return filepath.Join(u.home, ".local", "state", "dot")
+}
+
+// lookupTargetUser resolves the account named by --target-user. Only root
+// can create files owned by another user, so other users are refused.
+func lookupTargetUser(name string) (targetUser, error) {
+	if geteuid() != 0 {
+		return targetUser{}, fmt.Errorf("--target-user requires running as root")
+	}
+
+	u, err := user.Lookup(name)
+	if err != nil {
+		return targetUser{}, fmt.Errorf("--target-user: %w", err)
+	}
+	uid, err := strconv.Atoi(u.Uid)
+	if err != nil {
+		return targetUser{}, fmt.Errorf("--target-user: user %s has non-numeric uid %q", name, u.Uid)
+	}
+	gid, err := strconv.Atoi(u.Gid)
+	if err != nil {
+		return targetUser{}, fmt.Errorf("--target-user: user %s has non-numeric gid %q", name, u.Gid)
+	}
+	if u.HomeDir == "" {
+		return targetUser{}, fmt.Errorf("--target-user: user %s has no home directory", name)
+	}
+
+	return targetUser{name: name, home: filepath.Clean(u.HomeDir), uid: uid, gid: gid}, nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/internal/config"
+	"github.com/jamesainslie/dot/internal/domain"
+)
+
+// telemetry collects the metrics and spans of the running command and
+// exports them when it finishes.
+type telemetry struct {
+	metrics   *adapters.MetricsRegistry
+	tracer    *adapters.RecordingTracer
+	exporters []adapters.TelemetryExporter
+	timeout   time.Duration
+}
+
+// activeTelemetry is the telemetry of the running command, created by the
+// first buildConfig call that finds an exporter configured.
+var activeTelemetry *telemetry
+
+// setupTelemetry returns the telemetry of the running command, creating it
+// from cfg if needed. It returns nil if no exporter is configured.
+func setupTelemetry(fs domain.FS, cfg config.ObservabilityConfig) (*telemetry, error) {
+	if activeTelemetry != nil {
+		return activeTelemetry, nil
+	}
+	if !cfg.Enabled() {
+		return nil, nil
+	}
+	t, err := newTelemetry(fs, cfg)
+	if err != nil {
+		return nil, err
+	}
+	activeTelemetry = t
+	return t, nil
+}
+
+// newTelemetry creates the exporters of cfg.
+func newTelemetry(fs domain.FS, cfg config.ObservabilityConfig) (*telemetry, error) {
+	t := &telemetry{
+		metrics: adapters.NewMetricsRegistry(),
+		tracer:  adapters.NewRecordingTracer(),
+		timeout: 5 * time.Second,
+	}
+	if cfg.Timeout != "" {
+		timeout, err := time.ParseDuration(cfg.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid observability timeout: %w", err)
+		}
+		t.timeout = timeout
+	}
+
+	if cfg.PrometheusTextfile != "" {
+		t.exporters = append(t.exporters, adapters.NewPrometheusTextfileExporter(fs, cfg.PrometheusTextfile))
+	}
+	if cfg.PrometheusPushgateway != "" {
+		grouping := map[string]string{}
+		if host, err := os.Hostname(); err == nil {
+			grouping["instance"] = host
+		}
+		t.exporters = append(t.exporters, adapters.NewPrometheusPushExporter(nil, cfg.PrometheusPushgateway, cfg.PrometheusJob, grouping))
+	}
+	if cfg.OTLPEndpoint != "" {
+		headers := make(map[string]string, len(cfg.OTLPHeaders))
+		for _, header := range cfg.OTLPHeaders {
+			name, value, ok := strings.Cut(header, "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid OTLP header %q: must be name=value", header)
+			}
+			headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		}
+		t.exporters = append(t.exporters, adapters.NewOTLPExporter(nil, cfg.OTLPEndpoint, headers, cfg.ServiceName, t.metrics.Start()))
+	}
+	return t, nil
+}
+
+// flush records the outcome of the command and sends everything collected
+// to every exporter, returning the errors of those that failed.
+func (t *telemetry) flush(ctx context.Context, command string, cmdErr error) error {
+	result := "success"
+	if cmdErr != nil {
+		result = "failure"
+	}
+	t.metrics.Counter("cli.commands", "command", "result").Inc(command, result)
+	t.metrics.Gauge("cli.last_run.timestamp.seconds", "command").Set(float64(time.Now().Unix()), command)
+
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	metrics := t.metrics.Snapshot()
+	spans := t.tracer.Spans()
+	var errs []error
+	for _, exporter := range t.exporters {
+		if err := exporter.Export(ctx, metrics, spans); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// flushTelemetry exports the telemetry of the finished command, if any,
+// warning on w when exporting fails. Failing to export never changes the
+// outcome of the command.
+func flushTelemetry(w io.Writer, cmd *cobra.Command, cmdErr error) {
+	t := activeTelemetry
+	if t == nil {
+		return
+	}
+	activeTelemetry = nil
+
+	if err := t.flush(context.Background(), commandName(cmd), cmdErr); err != nil {
+		fmt.Fprintf(w, "Warning: export telemetry: %v\n", err)
+	}
+}
+
+// commandName returns the path of cmd below the root command, such as
+// "manage" or "config set", or the name of the root command itself.
+func commandName(cmd *cobra.Command) string {
+	if cmd == nil {
+		return "dot"
+	}
+	if !cmd.HasParent() {
+		return cmd.Name()
+	}
+	return strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/internal/config"
+)
+
+func TestTelemetry_Textfile(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Cleanup(func() { activeTelemetry = nil })
+
+	tmpDir := t.TempDir()
+	packageDir := filepath.Join(tmpDir, "packages")
+	targetDir := filepath.Join(tmpDir, "target")
+	textfile := filepath.Join(tmpDir, "metrics", "dot.prom")
+	require.NoError(t, os.MkdirAll(filepath.Join(packageDir, "vim"), 0755))
+	require.NoError(t, os.MkdirAll(targetDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(packageDir, "vim", "dot-vimrc"), []byte("set nocompatible"), 0644))
+	t.Setenv("DOT_OBSERVABILITY_PROMETHEUS_TEXTFILE", textfile)
+
+	rootCmd := NewRootCommand("test", "none", "unknown")
+	rootCmd.SetContext(context.Background())
+	rootCmd.SetOut(new(bytes.Buffer))
+	rootCmd.SetErr(new(bytes.Buffer))
+	rootCmd.SetArgs([]string{"--dir", packageDir, "--target", targetDir, "manage", "vim"})
+	executedCmd, err := executeCommand(rootCmd)
+	require.NoError(t, err)
+
+	var stderr bytes.Buffer
+	flushTelemetry(&stderr, executedCmd, err)
+	assert.Empty(t, stderr.String())
+	assert.Nil(t, activeTelemetry)
+
+	data, err := os.ReadFile(textfile)
+	require.NoError(t, err)
+	text := string(data)
+	assert.Contains(t, text, `dot_cli_commands_total{command="manage",result="success"} 1`)
+	assert.Contains(t, text, "dot_executor_executions_total 1")
+	assert.Contains(t, text, `dot_executor_operations_executed_total{kind="LinkCreate"} 1`)
+	assert.Contains(t, text, "dot_executor_duration_seconds_count 1")
+	assert.Contains(t, text, `dot_cli_last_run_timestamp_seconds{command="manage"}`)
+}
+
+func TestTelemetry_Disabled(t *testing.T) {
+	t.Cleanup(func() { activeTelemetry = nil })
+
+	tel, err := setupTelemetry(adapters.NewMemFS(), config.DefaultExtended().Observability)
+	require.NoError(t, err)
+	assert.Nil(t, tel)
+
+	// Flushing without telemetry does nothing
+	var stderr bytes.Buffer
+	flushTelemetry(&stderr, nil, nil)
+	assert.Empty(t, stderr.String())
+}
+
+func TestTelemetry_ExportFailureWarns(t *testing.T) {
+	t.Cleanup(func() { activeTelemetry = nil })
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	cfg := config.DefaultExtended().Observability
+	cfg.PrometheusPushgateway = server.URL
+	cfg.OTLPEndpoint = server.URL
+	tel, err := setupTelemetry(adapters.NewMemFS(), cfg)
+	require.NoError(t, err)
+	require.NotNil(t, tel)
+	assert.Len(t, tel.exporters, 2)
+
+	// The telemetry of the command is reused by later configurations
+	again, err := setupTelemetry(adapters.NewMemFS(), config.DefaultExtended().Observability)
+	require.NoError(t, err)
+	assert.Same(t, tel, again)
+
+	var stderr bytes.Buffer
+	flushTelemetry(&stderr, &cobra.Command{Use: "status"}, assert.AnError)
+	assert.Contains(t, stderr.String(), "Warning: export telemetry")
+	assert.Contains(t, stderr.String(), "push metrics")
+	assert.Contains(t, stderr.String(), "export metrics")
+}
+
+func TestCommandName(t *testing.T) {
+	root := &cobra.Command{Use: "dot"}
+	cfg := &cobra.Command{Use: "config"}
+	set := &cobra.Command{Use: "set"}
+	root.AddCommand(cfg)
+	cfg.AddCommand(set)
+
+	assert.Equal(t, "dot", commandName(nil))
+	assert.Equal(t, "dot", commandName(root))
+	assert.Equal(t, "config", commandName(cfg))
+	assert.Equal(t, "config set", commandName(set))
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// newUndoCommand creates the undo command.
+func newUndoCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "undo [PLAN-ID]",
+		Short: "Revert the last executed plan from the audit log",
+		Long: `Revert a plan recorded in the audit log by executing its inverse.
+
+Without an argument, undo reverts the most recent plan that has not been
+undone, such as the last manage, unmanage, or adopt. Pass a plan ID from
+'dot history', or a unique prefix of one, to select an older plan.
+
+Links the plan created are deleted, links it deleted are recreated, and
+files it adopted are moved back out of the package. Each step is checked
+against the current state first: if a link was changed or a path is now
+occupied, nothing is changed and the conflicts are reported. Rendered
+templates are left in place.
+
+Unlike rollback, which replays a checkpoint, undo only needs the audit log
+and refuses to overwrite changes made since the plan ran.
+
+Examples:
+  # Revert the last executed plan
+  dot undo
+
+  # Revert a specific plan by ID prefix
+  dot undo 3f2a9c
+
+  # Show what would be reverted
+  dot --dry-run undo`,
+		Args: argsWithUsage(cobra.MaximumNArgs(1)),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runUndo(cmd, args)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+
+	return cmd
+}
+
+// runUndo handles the undo command execution.
+func runUndo(cmd *cobra.Command, args []string) error {
+	cfg, err := buildConfigWithCmd(cmd)
+	if err != nil {
+		return formatError(err)
+	}
+
+	client, err := dot.NewClient(cfg)
+	if err != nil {
+		return formatError(err)
+	}
+
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var id string
+	if len(args) > 0 {
+		id = args[0]
+	}
+
+	result, err := client.Undo(ctx, id)
+	if err != nil {
+		return formatUndoError(err)
+	}
+
+	renderUndoResult(cmd.OutOrStdout(), result)
+	return nil
+}
+
+// renderUndoResult summarises an undo and the operations it executed.
+func renderUndoResult(w io.Writer, result dot.UndoResult) {
+	id := shortCheckpointID(result.PlanID)
+
+	switch {
+	case result.DryRun:
+		fmt.Fprintf(w, "Would undo plan %s (%d operations):\n", accent(id), len(result.Operations))
+	case len(result.Operations) == 0:
+		fmt.Fprintf(w, "Nothing to undo for plan %s\n", accent(id))
+	default:
+		fmt.Fprintf(w, "%s plan %s (%d operations)\n", success("Undid"), accent(id), len(result.Operations))
+	}
+
+	for _, op := range result.Operations {
+		fmt.Fprintf(w, "  %s\n", op)
+	}
+	for _, skipped := range result.Skipped {
+		fmt.Fprintf(w, "  %s %s\n", dim("skip"), skipped)
+	}
+}
+
+// formatUndoError formats undo-specific errors with helpful messages.
+func formatUndoError(err error) error {
+	var notFound dot.ErrPlanNotFound
+	if errors.As(err, &notFound) {
+		if notFound.ID == "" {
+			return fmt.Errorf("no plan to undo\n\nPlans are recorded in the audit log when manage, unmanage, remanage, or adopt apply changes")
+		}
+		return fmt.Errorf("%w\n\nRun 'dot history' to see recorded plans", notFound)
+	}
+
+	return formatError(err)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUndoCommand(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	tmpDir := t.TempDir()
+	packageDir := filepath.Join(tmpDir, "packages")
+	targetDir := filepath.Join(tmpDir, "target")
+	require.NoError(t, os.MkdirAll(filepath.Join(packageDir, "vim"), 0755))
+	require.NoError(t, os.MkdirAll(targetDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(packageDir, "vim", "dot-vimrc"), []byte("set nocompatible"), 0644))
+
+	_, err := runDot(t, "--dir", packageDir, "--target", targetDir, "undo")
+	assert.ErrorContains(t, err, "no plan to undo")
+
+	_, err = runDot(t, "--dir", packageDir, "--target", targetDir, "manage", "vim")
+	require.NoError(t, err)
+
+	out, err := runDot(t, "--dir", packageDir, "--target", targetDir, "--dry-run", "undo")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Would undo plan")
+	assert.Contains(t, out, "vim")
+	_, err = os.Lstat(filepath.Join(targetDir, "vim"))
+	require.NoError(t, err)
+
+	out, err = runDot(t, "--dir", packageDir, "--target", targetDir, "undo")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Undid plan")
+	_, err = os.Lstat(filepath.Join(targetDir, "vim"))
+	assert.True(t, os.IsNotExist(err))
+
+	_, err = runDot(t, "--dir", packageDir, "--target", targetDir, "undo", "nonexistent")
+	assert.ErrorContains(t, err, "dot history")
+}
@@ -21,6 +21,7 @@ func newUnmanageCommand() *cobra.Command {
 	var cleanup bool
 	var all bool
 	var yes bool
+	var pruneDirs bool
 
 	cmd := &cobra.Command{
 		Use:   "unmanage PACKAGE [PACKAGE...]",
@@ -34,6 +35,10 @@ their original locations. Managed packages only have their symlinks removed.
 Cleanup mode removes orphaned packages from the manifest without modifying 
 the filesystem - useful when packages no longer exist.
 
+Use --prune-dirs to also delete directories dot created for the links
+that are left empty, restoring the target tree to its shape before
+'dot manage'. Directories that existed before are never removed.
+
 Use --all to remove all managed packages at once. This requires confirmation
 unless --yes or --force is specified.`,
 		Example: `  # Remove package and restore adopted files
@@ -45,6 +50,9 @@ unless --yes or --force is specified.`,
   # Remove package without restoring (leave in package dir)
   dot unmanage ssh --no-restore
 
+  # Remove package and the directories left empty
+  dot unmanage nvim --prune-dirs
+
   # Clean up orphaned manifest entry (no filesystem changes)
   dot unmanage old-package --cleanup
 
@@ -67,7 +75,7 @@ unless --yes or --force is specified.`,
 			return nil
 		}),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runUnmanage(cmd, args, purge, noRestore, cleanup, all, yes)
+			return runUnmanage(cmd, args, purge, noRestore, cleanup, all, yes, pruneDirs)
 		},
 		ValidArgsFunction: packageCompletion(true), // Complete with installed packages
 	}
@@ -75,20 +83,27 @@ unless --yes or --force is specified.`,
 	cmd.Flags().BoolVar(&purge, "purge", false, "Delete package directory instead of restoring files")
 	cmd.Flags().BoolVar(&noRestore, "no-restore", false, "Don't restore adopted files (leave in package directory)")
 	cmd.Flags().BoolVar(&cleanup, "cleanup", false, "Remove orphaned manifest entries (packages with missing links/directories)")
+	cmd.Flags().BoolVar(&pruneDirs, "prune-dirs", false, "Delete directories created by dot that are left empty")
 	cmd.Flags().BoolVar(&all, "all", false, "Remove all managed packages")
 	cmd.Flags().BoolVar(&yes, "yes", false, "Skip confirmation prompt (can also use --force)")
 	cmd.Flags().BoolVar(&yes, "force", false, "Skip confirmation prompt (alias for --yes)")
+	addPlanFormatFlag(cmd)
 
 	return cmd
 }
 
 // runUnmanage handles the unmanage command execution.
-func runUnmanage(cmd *cobra.Command, args []string, purge, noRestore, cleanup, all, yes bool) error {
+func runUnmanage(cmd *cobra.Command, args []string, purge, noRestore, cleanup, all, yes, pruneDirs bool) error {
 	cfg, err := buildConfigWithCmd(cmd)
 	if err != nil {
 		return err
 	}
 
+	format, err := requestedPlanFormat(cmd, cfg.DryRun)
+	if err != nil {
+		return err
+	}
+
 	client, err := dot.NewClient(cfg)
 	if err != nil {
 		return err
@@ -101,9 +116,36 @@ func runUnmanage(cmd *cobra.Command, args []string, purge, noRestore, cleanup, a
 
 	// Build options
 	opts := dot.UnmanageOptions{
-		Purge:   purge,
-		Restore: !noRestore && !purge, // Default is true unless --no-restore or --purge
-		Cleanup: cleanup,
+		Purge:     purge,
+		Restore:   !noRestore && !purge, // Default is true unless --no-restore or --purge
+		Cleanup:   cleanup,
+		PruneDirs: pruneDirs,
+	}
+
+	packages := args
+
+	if format != "" {
+		if all {
+			status, err := client.Status(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to get status: %w", err)
+			}
+			packages = make([]string, 0, len(status.Packages))
+			for _, pkg := range status.Packages {
+				packages = append(packages, pkg.Name)
+			}
+		}
+		plan, err := client.PlanUnmanageWithOptions(ctx, opts, packages...)
+		if err != nil {
+			return err
+		}
+		return renderPlan(cmd, format, plan)
+	}
+
+	if !cfg.DryRun {
+		if err := recoverInterrupted(cmd, client, ctx); err != nil {
+			return err
+		}
 	}
 
 	// Handle --all flag
@@ -111,8 +153,6 @@ func runUnmanage(cmd *cobra.Command, args []string, purge, noRestore, cleanup, a
 		return runUnmanageAll(cmd, cfg, client, ctx, opts, yes)
 	}
 
-	packages := args
-
 	// Execute unmanage with options
 	if err := client.UnmanageWithOptions(ctx, opts, packages...); err != nil {
 		return err
@@ -8,6 +8,7 @@ import (
 
 	"github.com/jamesainslie/dot/internal/config"
 	"github.com/jamesainslie/dot/internal/updater"
+	"github.com/jamesainslie/dot/pkg/dot"
 	"github.com/spf13/cobra"
 )
 
@@ -51,6 +52,10 @@ Configuration (in ~/.config/dot/config.yaml):
 
 // runUpgrade handles the upgrade command execution.
 func runUpgrade(currentVersion string, yes, checkOnly bool) error {
+	if globalCfg.offline {
+		return fmt.Errorf("%w\n\nRun without --offline once network access is available", dot.ErrOffline{Operation: "upgrade"})
+	}
+
 	// Load configuration
 	cfg, err := loadConfig()
 	if err != nil {
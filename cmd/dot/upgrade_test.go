@@ -115,3 +115,16 @@ func TestUpgradeCommand_HelpOutput(t *testing.T) {
 		assert.Contains(t, output, "upgrade")
 	}
 }
+
+func TestRunUpgrade_Offline(t *testing.T) {
+	previous := globalCfg
+	t.Cleanup(func() {
+		globalCfg = previous
+	})
+	globalCfg.offline = true
+
+	err := runUpgrade("1.0.0", true, true)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "offline mode is enabled")
+}
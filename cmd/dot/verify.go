@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/jamesainslie/dot/internal/jsonschema"
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// newVerifyCommand creates the verify command.
+func newVerifyCommand() *cobra.Command {
+	var schemaName string
+
+	cmd := &cobra.Command{
+		Use:   "verify [FILE...]",
+		Short: "Check dot files against their JSON Schema",
+		Long: `Check files against the JSON Schemas printed by 'dot schema', by default
+the .dotbootstrap.yaml of the package directory.
+
+The schema of a file is chosen from its name for bootstrap
+configurations, and from its format field for portable manifests; use
+--schema for files it cannot be told from. Every field the schema
+rejects is reported with its line, and the command fails if any file
+has a problem, so it can run in CI.
+
+The schema covers fields and their values. 'dot bootstrap validate' and
+'dot manifest import' also check what needs the whole file, such as
+profiles naming defined packages.`,
+		Example: `  # Check the package directory's bootstrap configuration
+  dot verify
+
+  # Check an exported manifest and a bootstrap configuration
+  dot verify dot-manifest.json ~/dotfiles/.dotbootstrap.yaml
+
+  # Check a file with a given schema
+  dot verify --schema bootstrap bootstrap.example.yaml`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runVerify(cmd, args, schemaName)
+		},
+	}
+
+	cmd.Flags().StringVar(&schemaName, "schema", "", "Schema to check every file against (see 'dot schema')")
+	return cmd
+}
+
+// runVerify handles the verify command execution.
+func runVerify(cmd *cobra.Command, files []string, schemaName string) error {
+	var forced *fileSchema
+	if schemaName != "" {
+		s, err := lookupSchema(schemaName)
+		if err != nil {
+			return err
+		}
+		forced = &s
+	}
+
+	if len(files) == 0 {
+		cfg, err := buildConfigWithCmd(cmd)
+		if err != nil {
+			return formatError(err)
+		}
+		files = []string{filepath.Join(cfg.PackageDir, ".dotbootstrap.yaml")}
+	}
+
+	invalid := 0
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("read %s: %w", file, err)
+		}
+
+		s := forced
+		if s == nil {
+			if s = detectSchema(file, data); s == nil {
+				return fmt.Errorf("cannot tell the schema of %s: use --schema", file)
+			}
+		}
+		compiled, err := jsonschema.Compile(s.schema())
+		if err != nil {
+			return fmt.Errorf("%s schema: %w", s.name, err)
+		}
+
+		problems := compiled.Validate(data)
+		if len(problems) > 0 {
+			invalid++
+		}
+		if !globalCfg.quiet || len(problems) > 0 {
+			renderSchemaProblems(cmd.OutOrStdout(), file, s.name, problems)
+		}
+	}
+
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d file(s) do not match their schema", invalid, len(files))
+	}
+	return nil
+}
+
+// detectSchema returns the schema of the file at path holding data: the
+// bootstrap schema for .dotbootstrap files and the manifest schema for
+// documents of the portable manifest format, or nil.
+func detectSchema(path string, data []byte) *fileSchema {
+	name := "bootstrap"
+	if !strings.HasPrefix(filepath.Base(path), ".dotbootstrap.") {
+		var doc struct {
+			Format string `yaml:"format"`
+		}
+		if yaml.Unmarshal(data, &doc) != nil || doc.Format != dot.PortableManifestFormat {
+			return nil
+		}
+		name = "manifest"
+	}
+	s, _ := lookupSchema(name)
+	return &s
+}
+
+// renderSchemaProblems prints the problems the schema found in a file.
+func renderSchemaProblems(w io.Writer, path, schemaName string, problems []jsonschema.Problem) {
+	if len(problems) == 0 {
+		fmt.Fprintf(w, "%s %s matches the %s schema\n", success("✓"), path, schemaName)
+		return
+	}
+
+	fmt.Fprintf(w, "%s %s\n", bold(path), dim("("+schemaName+" schema)"))
+	for _, problem := range problems {
+		location := ""
+		if problem.Line > 0 {
+			location = dim(fmt.Sprintf("line %d: ", problem.Line))
+		}
+		if problem.Field != "" {
+			location += accent(problem.Field) + ": "
+		}
+		fmt.Fprintf(w, "  %s %s%s\n", errorText("✗"), location, problem.Message)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/jsonschema"
+)
+
+func TestVerifyCommand(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	packageDir := t.TempDir()
+	bootstrapPath := filepath.Join(packageDir, ".dotbootstrap.yaml")
+	require.NoError(t, os.WriteFile(bootstrapPath, []byte("version: \"1.0\"\npackages:\n  - name: vim\n"), 0644))
+
+	out, err := runDot(t, "--dir", packageDir, "verify")
+	require.NoError(t, err)
+	assert.Contains(t, out, bootstrapPath+" matches the bootstrap schema")
+
+	manifestPath := filepath.Join(t.TempDir(), "dot-manifest.json")
+	require.NoError(t, os.WriteFile(manifestPath, []byte(`{
+  "format": "dot-manifest",
+  "version": 1,
+  "packages": [
+    {
+      "name": "vim",
+      "installed_at": "2024-05-01T10:00:00Z",
+      "links": ["../.vimrc"]
+    }
+  ]
+}
+`), 0644))
+
+	out, err = runDot(t, "verify", bootstrapPath, manifestPath)
+	assert.EqualError(t, err, "1 of 2 file(s) do not match their schema")
+	assert.Contains(t, out, "matches the bootstrap schema")
+	assert.Contains(t, out, "(manifest schema)")
+	assert.Contains(t, out, `line 8: packages[0].links[0]: "../.vimrc" is not allowed`)
+}
+
+func TestVerifyCommand_Schema(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "bootstrap.example.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("version: \"1.0\"\npackages:\n  - name: vim\n    platfrom: [linux]\n"), 0644))
+
+	_, err := runDot(t, "verify", path)
+	assert.ErrorContains(t, err, "cannot tell the schema of "+path)
+
+	out, err := runDot(t, "verify", "--schema", "bootstrap", path)
+	assert.Error(t, err)
+	assert.Contains(t, out, `line 4: packages[0]: unknown field "platfrom"`)
+
+	_, err = runDot(t, "verify", "--schema", "dotmeta", path)
+	assert.ErrorContains(t, err, `unknown schema "dotmeta"`)
+}
+
+func TestFileSchemas_Compile(t *testing.T) {
+	for _, s := range fileSchemas {
+		_, err := jsonschema.Compile(s.schema())
+		assert.NoError(t, err, s.name)
+	}
+}
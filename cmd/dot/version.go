@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// versionInfo describes the running dot binary.
+type versionInfo struct {
+	Version              string   `json:"version"`
+	Commit               string   `json:"commit"`
+	Date                 string   `json:"date"`
+	GoVersion            string   `json:"go_version"`
+	Platform             string   `json:"platform"`
+	ExperimentalFeatures []string `json:"experimental_features"`
+	ManifestSchema       int      `json:"manifest_schema"`
+	ManifestSchemas      []int    `json:"supported_manifest_schemas"`
+}
+
+// newVersionCommand creates the version command.
+func newVersionCommand(version, commit, date string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "version",
+		Short: "Show version information",
+		Long: `Show the version of dot and how it was built.
+
+With --format json the output also lists the experimental features
+enabled in the configuration and the manifest schema versions this
+binary reads, for inventory tooling.
+
+Examples:
+  # Full version info
+  dot version
+
+  # Version number only
+  dot version --short
+
+  # Machine-readable build metadata
+  dot version --format json`,
+		Args: argsWithUsage(cobra.NoArgs),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			short, _ := cmd.Flags().GetBool("short")
+			format, _ := cmd.Flags().GetString("format")
+
+			info := newVersionInfo(version, commit, date)
+			if short {
+				fmt.Fprintln(cmd.OutOrStdout(), info.Version)
+				return nil
+			}
+			return renderVersion(cmd.OutOrStdout(), info, format)
+		},
+	}
+
+	cmd.Flags().Bool("short", false, "Show version number only")
+	cmd.Flags().StringP("format", "f", "text", "Output format (text, json)")
+
+	return cmd
+}
+
+// newVersionInfo collects the build metadata of the running binary.
+// Experimental features are read from the configuration; a configuration
+// that fails to load lists none.
+func newVersionInfo(version, commit, date string) versionInfo {
+	features := []string{}
+	if cfg, err := loadConfig(); err == nil {
+		features = cfg.Experimental.Enabled()
+	}
+	return versionInfo{
+		Version:              version,
+		Commit:               commit,
+		Date:                 date,
+		GoVersion:            runtime.Version(),
+		Platform:             runtime.GOOS + "/" + runtime.GOARCH,
+		ExperimentalFeatures: features,
+		ManifestSchema:       dot.ManifestSchemaVersion,
+		ManifestSchemas:      dot.SupportedManifestSchemaVersions(),
+	}
+}
+
+// renderVersion writes info in the requested format.
+func renderVersion(w io.Writer, info versionInfo, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(info)
+	case "text":
+		fmt.Fprintf(w, "dot version %s\n", info.Version)
+		fmt.Fprintf(w, "Built with %s\n", info.GoVersion)
+		fmt.Fprintf(w, "Commit: %s\n", info.Commit)
+		fmt.Fprintf(w, "Build date: %s\n", info.Date)
+		fmt.Fprintf(w, "Platform: %s\n", info.Platform)
+		return nil
+	default:
+		return fmt.Errorf("invalid format %q: use text or json", format)
+	}
+}
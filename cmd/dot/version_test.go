@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionCommand_JSON(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("experimental:\n  flags: [parallel]\n"), 0644))
+	t.Setenv("DOT_CONFIG", configPath)
+
+	cmd := newVersionCommand("v1.2.3", "abc1234", "2026-01-02")
+	var stdout bytes.Buffer
+	cmd.SetOut(&stdout)
+	cmd.SetArgs([]string{"--format", "json"})
+	require.NoError(t, cmd.Execute())
+
+	var info map[string]any
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &info))
+	assert.Equal(t, "v1.2.3", info["version"])
+	assert.Equal(t, "abc1234", info["commit"])
+	assert.Equal(t, "2026-01-02", info["date"])
+	assert.Equal(t, runtime.Version(), info["go_version"])
+	assert.Equal(t, runtime.GOOS+"/"+runtime.GOARCH, info["platform"])
+	assert.Equal(t, []any{"parallel"}, info["experimental_features"])
+	assert.Equal(t, float64(1), info["manifest_schema"])
+	assert.Equal(t, []any{float64(0), float64(1)}, info["supported_manifest_schemas"])
+}
+
+func TestVersionCommand_TextAndShort(t *testing.T) {
+	cmd := newVersionCommand("v1.2.3", "abc1234", "2026-01-02")
+	var stdout bytes.Buffer
+	cmd.SetOut(&stdout)
+	cmd.SetArgs([]string{})
+	require.NoError(t, cmd.Execute())
+	assert.Contains(t, stdout.String(), "dot version v1.2.3\n")
+	assert.Contains(t, stdout.String(), "Commit: abc1234\n")
+
+	cmd = newVersionCommand("v1.2.3", "abc1234", "2026-01-02")
+	stdout.Reset()
+	cmd.SetOut(&stdout)
+	cmd.SetArgs([]string{"--short"})
+	require.NoError(t, cmd.Execute())
+	assert.Equal(t, "v1.2.3\n", stdout.String())
+
+	cmd = newVersionCommand("v1.2.3", "abc1234", "2026-01-02")
+	cmd.SetArgs([]string{"--format", "xml"})
+	assert.Error(t, cmd.Execute())
+}
@@ -0,0 +1,203 @@
+package adapters
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/jamesainslie/dot/internal/domain"
+)
+
+// BoundaryFS is a filesystem that refuses changes outside a set of root
+// directories and passes everything else to another filesystem. It guards
+// against bugs translating paths: a path computed wrongly fails with
+// domain.ErrOutsideBoundary instead of creating, overwriting or removing a
+// file elsewhere.
+//
+// Paths are compared lexically, after cleaning; symbolic links in them are
+// not resolved. Reads are not confined, and neither is the target of a
+// symbolic or hard link, which is not changed.
+type BoundaryFS struct {
+	fs    domain.FS
+	roots []string
+}
+
+// NewBoundaryFS creates a filesystem confining the changes made through
+// it to the given roots and the paths below them. Roots that are empty or
+// not absolute are ignored.
+func NewBoundaryFS(fs domain.FS, roots ...string) *BoundaryFS {
+	confined := make([]string, 0, len(roots))
+	for _, root := range roots {
+		if filepath.IsAbs(root) {
+			confined = append(confined, filepath.Clean(root))
+		}
+	}
+	slices.Sort(confined)
+	return &BoundaryFS{fs: fs, roots: slices.Compact(confined)}
+}
+
+// Roots returns the directories changes are confined to, sorted.
+func (b *BoundaryFS) Roots() []string {
+	return slices.Clone(b.roots)
+}
+
+// Contains reports whether path is one of the roots or below one.
+func (b *BoundaryFS) Contains(path string) bool {
+	if !filepath.IsAbs(path) {
+		return false
+	}
+	path = filepath.Clean(path)
+	for _, root := range b.roots {
+		if rel, err := filepath.Rel(root, path); err == nil && filepath.IsLocal(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *BoundaryFS) check(operation string, paths ...string) error {
+	for _, path := range paths {
+		if !b.Contains(path) {
+			return domain.ErrOutsideBoundary{Path: path, Operation: operation}
+		}
+	}
+	return nil
+}
+
+func (b *BoundaryFS) Stat(ctx context.Context, path string) (domain.FileInfo, error) {
+	return b.fs.Stat(ctx, path)
+}
+
+func (b *BoundaryFS) ReadDir(ctx context.Context, path string) ([]domain.DirEntry, error) {
+	return b.fs.ReadDir(ctx, path)
+}
+
+func (b *BoundaryFS) ReadLink(ctx context.Context, path string) (string, error) {
+	return b.fs.ReadLink(ctx, path)
+}
+
+func (b *BoundaryFS) ReadFile(ctx context.Context, path string) ([]byte, error) {
+	return b.fs.ReadFile(ctx, path)
+}
+
+func (b *BoundaryFS) Exists(ctx context.Context, path string) bool {
+	return b.fs.Exists(ctx, path)
+}
+
+func (b *BoundaryFS) IsDir(ctx context.Context, path string) (bool, error) {
+	return b.fs.IsDir(ctx, path)
+}
+
+func (b *BoundaryFS) IsSymlink(ctx context.Context, path string) (bool, error) {
+	return b.fs.IsSymlink(ctx, path)
+}
+
+func (b *BoundaryFS) WriteFile(ctx context.Context, path string, data []byte, perm os.FileMode) error {
+	if err := b.check("write", path); err != nil {
+		return err
+	}
+	return b.fs.WriteFile(ctx, path, data, perm)
+}
+
+func (b *BoundaryFS) Mkdir(ctx context.Context, path string, perm os.FileMode) error {
+	if err := b.check("create directory", path); err != nil {
+		return err
+	}
+	return b.fs.Mkdir(ctx, path, perm)
+}
+
+// MkdirAll creates path and its missing parents. Only path itself needs
+// to be inside a root: parents of a root may be created for it.
+func (b *BoundaryFS) MkdirAll(ctx context.Context, path string, perm os.FileMode) error {
+	if err := b.check("create directory", path); err != nil {
+		return err
+	}
+	return b.fs.MkdirAll(ctx, path, perm)
+}
+
+func (b *BoundaryFS) Remove(ctx context.Context, path string) error {
+	if err := b.check("remove", path); err != nil {
+		return err
+	}
+	return b.fs.Remove(ctx, path)
+}
+
+func (b *BoundaryFS) RemoveAll(ctx context.Context, path string) error {
+	if err := b.check("remove", path); err != nil {
+		return err
+	}
+	return b.fs.RemoveAll(ctx, path)
+}
+
+func (b *BoundaryFS) Symlink(ctx context.Context, oldname, newname string) error {
+	if err := b.check("create symlink", newname); err != nil {
+		return err
+	}
+	return b.fs.Symlink(ctx, oldname, newname)
+}
+
+func (b *BoundaryFS) Rename(ctx context.Context, oldpath, newpath string) error {
+	if err := b.check("rename", oldpath, newpath); err != nil {
+		return err
+	}
+	return b.fs.Rename(ctx, oldpath, newpath)
+}
+
+// Chmod changes the mode of path. It implements domain.MetadataFS.
+func (b *BoundaryFS) Chmod(ctx context.Context, path string, mode os.FileMode) error {
+	mfs, ok := b.fs.(domain.MetadataFS)
+	if !ok {
+		return domain.ErrNotImplemented{Feature: "Chmod"}
+	}
+	if err := b.check("change mode of", path); err != nil {
+		return err
+	}
+	return mfs.Chmod(ctx, path, mode)
+}
+
+// Chtimes changes the times of path. It implements domain.MetadataFS.
+func (b *BoundaryFS) Chtimes(ctx context.Context, path string, atime, mtime time.Time) error {
+	mfs, ok := b.fs.(domain.MetadataFS)
+	if !ok {
+		return domain.ErrNotImplemented{Feature: "Chtimes"}
+	}
+	if err := b.check("change times of", path); err != nil {
+		return err
+	}
+	return mfs.Chtimes(ctx, path, atime, mtime)
+}
+
+// Link creates a hard link. It implements domain.HardlinkFS.
+func (b *BoundaryFS) Link(ctx context.Context, oldname, newname string) error {
+	hl, ok := b.fs.(domain.HardlinkFS)
+	if !ok {
+		return domain.ErrNotImplemented{Feature: "Link"}
+	}
+	if err := b.check("create hard link", newname); err != nil {
+		return err
+	}
+	return hl.Link(ctx, oldname, newname)
+}
+
+// LinkCount returns the link count of path, or 0 when the wrapped
+// filesystem does not report link counts. It implements domain.HardlinkFS.
+func (b *BoundaryFS) LinkCount(ctx context.Context, path string) (uint64, error) {
+	if hl, ok := b.fs.(domain.HardlinkFS); ok {
+		return hl.LinkCount(ctx, path)
+	}
+	return 0, nil
+}
+
+// AppendFile appends to the file at path. It implements domain.AppendFS.
+func (b *BoundaryFS) AppendFile(ctx context.Context, path string, data []byte, perm os.FileMode) error {
+	afs, ok := b.fs.(domain.AppendFS)
+	if !ok {
+		return domain.ErrNotImplemented{Feature: "AppendFile"}
+	}
+	if err := b.check("append to", path); err != nil {
+		return err
+	}
+	return afs.AppendFile(ctx, path, data, perm)
+}
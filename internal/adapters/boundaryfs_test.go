@@ -0,0 +1,58 @@
+package adapters
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/domain"
+)
+
+func TestBoundaryFS_RefusesChangesOutsideRoots(t *testing.T) {
+	ctx := context.Background()
+	mem := NewMemFS()
+	require.NoError(t, mem.MkdirAll(ctx, "/home/me", 0755))
+	require.NoError(t, mem.MkdirAll(ctx, "/dotfiles/vim", 0755))
+	require.NoError(t, mem.MkdirAll(ctx, "/etc", 0755))
+	require.NoError(t, mem.WriteFile(ctx, "/etc/hosts", []byte("127.0.0.1"), 0644))
+
+	var fs domain.FS = NewBoundaryFS(mem, "/home/me", "/dotfiles/", "", "relative")
+	assert.Equal(t, []string{"/dotfiles", "/home/me"}, fs.(*BoundaryFS).Roots())
+
+	// Changes inside the roots go through, links may point anywhere
+	require.NoError(t, fs.WriteFile(ctx, "/dotfiles/vim/vimrc", []byte("set nocompatible"), 0644))
+	require.NoError(t, fs.Symlink(ctx, "/etc/hosts", "/home/me/hosts"))
+	require.NoError(t, fs.MkdirAll(ctx, "/home/me", 0755))
+	require.NoError(t, fs.Rename(ctx, "/home/me/hosts", "/dotfiles/vim/hosts"))
+	assert.True(t, mem.Exists(ctx, "/dotfiles/vim/hosts"))
+
+	// Reads are not confined
+	data, err := fs.ReadFile(ctx, "/etc/hosts")
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1", string(data))
+
+	refused := map[string]error{
+		"escaping":  fs.RemoveAll(ctx, "/home/me/../../etc"),
+		"sibling":   fs.WriteFile(ctx, "/home/meow", nil, 0644),
+		"parent":    fs.Remove(ctx, "/home"),
+		"relative":  fs.Mkdir(ctx, "home/me/x", 0755),
+		"rename":    fs.Rename(ctx, "/etc/hosts", "/home/me/hosts"),
+		"symlink":   fs.Symlink(ctx, "/home/me/x", "/etc/x"),
+		"hard link": fs.(domain.HardlinkFS).Link(ctx, "/home/me/x", "/etc/x"),
+		"chmod":     fs.(domain.MetadataFS).Chmod(ctx, "/etc/hosts", 0600),
+		"append":    fs.(domain.AppendFS).AppendFile(ctx, "/etc/hosts", []byte("x"), 0644),
+	}
+	for name, err := range refused {
+		var outside domain.ErrOutsideBoundary
+		assert.ErrorAs(t, err, &outside, name)
+	}
+
+	var outside domain.ErrOutsideBoundary
+	require.ErrorAs(t, refused["escaping"], &outside)
+	assert.Equal(t, "remove", outside.Operation)
+	assert.Equal(t, "/home/me/../../etc", outside.Path)
+	assert.True(t, mem.Exists(ctx, "/etc/hosts"))
+	assert.True(t, mem.Exists(ctx, "/home/me"))
+}
@@ -0,0 +1,385 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jamesainslie/dot/internal/domain"
+)
+
+// Exit codes of the scripts run by ExecFS, besides 0 and 1, which reports
+// an error written to stderr.
+const (
+	execNotExist = 2
+	execExist    = 3
+)
+
+// Scripts run by ExecFS, given their arguments as $1, $2 and $3. They
+// need only a POSIX shell and the stat, find and touch of GNU coreutils or
+// BusyBox, found in nearly every image.
+const (
+	execStatScript    = `[ -e "$1" ] || exit 2; exec stat -L -c '%f %s %Y' -- "$1"`
+	execReadDirScript = `[ -e "$1" ] || exit 2
+[ -d "$1" ] || { echo "not a directory" >&2; exit 1; }
+cd -- "$1" && exec find . -mindepth 1 -maxdepth 1 -exec sh -c '
+for f; do s=$(stat -c "%f %s %Y" -- "$f") || exit 1; printf "%s\0%s\0" "$s" "$f"; done' sh {} +`
+	execReadLinkScript = `[ -L "$1" ] || { [ -e "$1" ] || exit 2; echo "not a symbolic link" >&2; exit 1; }
+exec readlink -- "$1"`
+	execReadFileScript = `[ -e "$1" ] || exit 2; exec cat -- "$1"`
+	// A new file gets the permissions asked; an existing one keeps its own
+	execWriteFileScript = `[ -d "$(dirname -- "$1")" ] || exit 2
+if [ -e "$1" ]; then exec cat > "$1"; fi
+cat > "$1" && exec chmod "$2" -- "$1"`
+	execAppendFileScript = `[ -d "$(dirname -- "$1")" ] || exit 2
+if [ -e "$1" ]; then exec cat >> "$1"; fi
+cat > "$1" && exec chmod "$2" -- "$1"`
+	execMkdirScript = `[ -e "$1" ] || [ -L "$1" ] && exit 3
+[ -d "$(dirname -- "$1")" ] || exit 2
+exec mkdir -m "$2" -- "$1"`
+	execMkdirAllScript = `exec mkdir -p -m "$2" -- "$1"`
+	execRemoveScript   = `[ -e "$1" ] || [ -L "$1" ] || exit 2
+if [ -d "$1" ] && [ ! -L "$1" ]; then exec rmdir -- "$1"; fi
+exec rm -f -- "$1"`
+	execRemoveAllScript = `exec rm -rf -- "$1"`
+	execSymlinkScript   = `[ -e "$2" ] || [ -L "$2" ] && exit 3
+[ -d "$(dirname -- "$2")" ] || exit 2
+exec ln -s -- "$1" "$2"`
+	// mv moves into a directory instead of replacing it, which rename(2)
+	// does when it is empty
+	execRenameScript = `[ -e "$1" ] || [ -L "$1" ] || exit 2
+if [ -d "$2" ] && [ ! -L "$2" ]; then
+  [ -d "$1" ] && [ ! -L "$1" ] || { echo "is a directory" >&2; exit 1; }
+  rmdir -- "$2" || exit 1
+fi
+exec mv -f -- "$1" "$2"`
+	execIsSymlinkScript = `[ -L "$1" ] && { echo yes; exit; }; [ -e "$1" ] || exit 2; echo no`
+	execChmodScript     = `[ -e "$1" ] || exit 2; exec chmod "$2" -- "$1"`
+	execChtimesScript   = `[ -e "$1" ] || exit 2
+export TZ=UTC0
+touch -c -a -t "$2" -- "$1" && exec touch -c -m -t "$3" -- "$1"`
+	execHomeScript = `printf '%s' "$HOME"`
+)
+
+// ExecFS is a filesystem reached by running shell commands, such as the
+// filesystem of a running container reached with docker exec. Each
+// operation runs a short POSIX shell script; file contents are streamed
+// through its standard input and output, and directory listings are
+// NUL-separated, so that any file name can be read back.
+//
+// Streaming a tar archive would not save anything here: the executor
+// calls WriteFile and ReadFile one file at a time, checking each result
+// before the next operation, so an archive would hold a single file. It
+// would also need tar in the image, and tar replaces existing files and
+// their permissions where WriteFile keeps them.
+type ExecFS struct {
+	command []string
+}
+
+// NewExecFS creates a filesystem running its scripts with sh, prefixed
+// with command, as in docker exec -i NAME. Without a command, sh runs
+// on the local machine.
+func NewExecFS(command ...string) *ExecFS {
+	return &ExecFS{command: slices.Clone(command)}
+}
+
+// NewContainerFS creates the filesystem of a running container, reached
+// with the exec command of engine, docker or podman.
+func NewContainerFS(engine, container string) *ExecFS {
+	return NewExecFS(engine, "exec", "-i", container)
+}
+
+// run runs a script with args, feeding it stdin, and returns its
+// standard output. Errors are about name.
+func (e *ExecFS) run(ctx context.Context, op, name string, stdin []byte, script string, args ...string) ([]byte, error) {
+	command := slices.Concat(e.command, []string{"sh", "-c", script, "sh"}, args)
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	// Without input, the script reads an empty one
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	err := cmd.Run()
+	if err == nil {
+		return stdout.Bytes(), nil
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	switch exitErr.ExitCode() {
+	case execNotExist:
+		err = fs.ErrNotExist
+	case execExist:
+		err = fs.ErrExist
+	default:
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = errors.New(msg)
+		}
+	}
+	return nil, &fs.PathError{Op: op, Path: name, Err: err}
+}
+
+// Home returns the home directory of the user running the scripts.
+func (e *ExecFS) Home(ctx context.Context) (string, error) {
+	out, err := e.run(ctx, "home", "$HOME", nil, execHomeScript)
+	if err != nil {
+		return "", err
+	}
+	if home := string(out); path.IsAbs(home) {
+		return path.Clean(home), nil
+	}
+	return "", errors.New("no home directory")
+}
+
+func (e *ExecFS) Stat(ctx context.Context, name string) (domain.FileInfo, error) {
+	return e.stat(ctx, "stat", name, execStatScript)
+}
+
+func (e *ExecFS) stat(ctx context.Context, op, name, script string) (execFileInfo, error) {
+	out, err := e.run(ctx, op, name, nil, script, name)
+	if err != nil {
+		return execFileInfo{}, err
+	}
+	info, err := parseExecStat(path.Base(name), strings.TrimSuffix(string(out), "\n"))
+	if err != nil {
+		return execFileInfo{}, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	return info, nil
+}
+
+// parseExecStat parses the "%f %s %Y" output of stat: the mode in hex,
+// the size and the modification time.
+func parseExecStat(name, fields string) (execFileInfo, error) {
+	parts := strings.Fields(fields)
+	if len(parts) != 3 {
+		return execFileInfo{}, fmt.Errorf("unexpected stat output %q", fields)
+	}
+	mode, err := strconv.ParseUint(parts[0], 16, 32)
+	if err != nil {
+		return execFileInfo{}, fmt.Errorf("unexpected stat mode %q", parts[0])
+	}
+	size, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return execFileInfo{}, fmt.Errorf("unexpected stat size %q", parts[1])
+	}
+	mtime, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return execFileInfo{}, fmt.Errorf("unexpected stat time %q", parts[2])
+	}
+	return execFileInfo{name: name, mode: posixFileMode(uint32(mode)), size: size, modTime: time.Unix(mtime, 0)}, nil
+}
+
+// ReadDir lists the entries of a directory sorted by name, describing the
+// entries themselves rather than what they link to.
+func (e *ExecFS) ReadDir(ctx context.Context, name string) ([]domain.DirEntry, error) {
+	out, err := e.run(ctx, "readdir", name, nil, execReadDirScript, name)
+	if err != nil {
+		return nil, err
+	}
+	// Records are the stat fields and the name of each entry, each ended
+	// by a NUL, which no file name holds
+	records := strings.Split(string(out), "\x00")
+	if len(records)%2 != 1 || records[len(records)-1] != "" {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fmt.Errorf("unexpected find output %q", out)}
+	}
+	var entries []domain.DirEntry
+	for i := 0; i+1 < len(records); i += 2 {
+		info, err := parseExecStat(strings.TrimPrefix(records[i+1], "./"), records[i])
+		if err != nil {
+			return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+		}
+		entries = append(entries, execDirEntry{info})
+	}
+	slices.SortFunc(entries, func(a, b domain.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+	return entries, nil
+}
+
+func (e *ExecFS) ReadLink(ctx context.Context, name string) (string, error) {
+	out, err := e.run(ctx, "readlink", name, nil, execReadLinkScript, name)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func (e *ExecFS) ReadFile(ctx context.Context, name string) ([]byte, error) {
+	out, err := e.run(ctx, "open", name, nil, execReadFileScript, name)
+	if err != nil {
+		return nil, err
+	}
+	if out == nil {
+		out = []byte{}
+	}
+	return out, nil
+}
+
+// WriteFile writes data to a file, creating it with perm if needed, as
+// os.WriteFile does.
+func (e *ExecFS) WriteFile(ctx context.Context, name string, data []byte, perm fs.FileMode) error {
+	_, err := e.run(ctx, "open", name, data, execWriteFileScript, name, octal(perm))
+	return err
+}
+
+// AppendFile appends data to a file, creating it with perm if needed. It
+// implements domain.AppendFS.
+func (e *ExecFS) AppendFile(ctx context.Context, name string, data []byte, perm fs.FileMode) error {
+	_, err := e.run(ctx, "open", name, data, execAppendFileScript, name, octal(perm))
+	return err
+}
+
+func (e *ExecFS) Mkdir(ctx context.Context, name string, perm fs.FileMode) error {
+	_, err := e.run(ctx, "mkdir", name, nil, execMkdirScript, name, octal(perm))
+	return err
+}
+
+func (e *ExecFS) MkdirAll(ctx context.Context, name string, perm fs.FileMode) error {
+	_, err := e.run(ctx, "mkdir", name, nil, execMkdirAllScript, name, octal(perm))
+	return err
+}
+
+// Remove removes a file, a symbolic link or an empty directory.
+func (e *ExecFS) Remove(ctx context.Context, name string) error {
+	_, err := e.run(ctx, "remove", name, nil, execRemoveScript, name)
+	return err
+}
+
+// RemoveAll removes a path and everything it contains. A missing path is
+// not an error.
+func (e *ExecFS) RemoveAll(ctx context.Context, name string) error {
+	_, err := e.run(ctx, "remove", name, nil, execRemoveAllScript, name)
+	return err
+}
+
+// Symlink creates newname as a symbolic link to oldname.
+func (e *ExecFS) Symlink(ctx context.Context, oldname, newname string) error {
+	_, err := e.run(ctx, "symlink", newname, nil, execSymlinkScript, oldname, newname)
+	return err
+}
+
+// Rename renames a file, replacing newpath as rename(2) does.
+func (e *ExecFS) Rename(ctx context.Context, oldpath, newpath string) error {
+	_, err := e.run(ctx, "rename", oldpath, nil, execRenameScript, oldpath, newpath)
+	return err
+}
+
+func (e *ExecFS) Exists(ctx context.Context, name string) bool {
+	_, err := e.Stat(ctx, name)
+	return err == nil
+}
+
+func (e *ExecFS) IsDir(ctx context.Context, name string) (bool, error) {
+	info, err := e.Stat(ctx, name)
+	if err != nil {
+		return false, err
+	}
+	return info.IsDir(), nil
+}
+
+func (e *ExecFS) IsSymlink(ctx context.Context, name string) (bool, error) {
+	out, err := e.run(ctx, "lstat", name, nil, execIsSymlinkScript, name)
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(string(out)) == "yes", nil
+}
+
+// Chmod changes the permissions of a file. It implements
+// domain.MetadataFS.
+func (e *ExecFS) Chmod(ctx context.Context, name string, mode fs.FileMode) error {
+	_, err := e.run(ctx, "chmod", name, nil, execChmodScript, name, octal(mode))
+	return err
+}
+
+// Chtimes changes the access and modification times of a file, to the
+// second. It implements domain.MetadataFS.
+func (e *ExecFS) Chtimes(ctx context.Context, name string, atime, mtime time.Time) error {
+	const touchTime = "200601021504.05"
+	_, err := e.run(ctx, "chtimes", name, nil, execChtimesScript, name,
+		atime.UTC().Format(touchTime), mtime.UTC().Format(touchTime))
+	return err
+}
+
+// octal formats the permissions of mode for chmod and mkdir -m.
+func octal(mode fs.FileMode) string {
+	return strconv.FormatUint(uint64(mode.Perm()), 8)
+}
+
+// POSIX file type bits of st_mode, as stat -c %f prints it in hex.
+const (
+	sIFMT   = 0o170000
+	sIFDIR  = 0o040000
+	sIFLNK  = 0o120000
+	sIFIFO  = 0o010000
+	sIFSOCK = 0o140000
+	sIFCHR  = 0o020000
+	sIFBLK  = 0o060000
+)
+
+// posixFileMode converts POSIX permission and file type bits, as in
+// st_mode, into a FileMode.
+func posixFileMode(bits uint32) fs.FileMode {
+	mode := fs.FileMode(bits & 0o777)
+	switch bits & sIFMT {
+	case sIFDIR:
+		mode |= fs.ModeDir
+	case sIFLNK:
+		mode |= fs.ModeSymlink
+	case sIFIFO:
+		mode |= fs.ModeNamedPipe
+	case sIFSOCK:
+		mode |= fs.ModeSocket
+	case sIFCHR:
+		mode |= fs.ModeDevice | fs.ModeCharDevice
+	case sIFBLK:
+		mode |= fs.ModeDevice
+	}
+	if bits&0o4000 != 0 {
+		mode |= fs.ModeSetuid
+	}
+	if bits&0o2000 != 0 {
+		mode |= fs.ModeSetgid
+	}
+	if bits&0o1000 != 0 {
+		mode |= fs.ModeSticky
+	}
+	return mode
+}
+
+// execFileInfo is the domain.FileInfo of a file of an ExecFS.
+type execFileInfo struct {
+	name    string
+	mode    fs.FileMode
+	size    int64
+	modTime time.Time
+}
+
+func (i execFileInfo) Name() string      { return i.name }
+func (i execFileInfo) Size() int64       { return i.size }
+func (i execFileInfo) Mode() fs.FileMode { return i.mode }
+func (i execFileInfo) ModTime() any      { return i.modTime }
+func (i execFileInfo) IsDir() bool       { return i.mode.IsDir() }
+func (i execFileInfo) Sys() any          { return nil }
+
+// execDirEntry is the domain.DirEntry of an entry of an ExecFS directory.
+type execDirEntry struct {
+	info execFileInfo
+}
+
+func (e execDirEntry) Name() string                   { return e.info.Name() }
+func (e execDirEntry) IsDir() bool                    { return e.info.IsDir() }
+func (e execDirEntry) Type() fs.FileMode              { return e.info.Mode().Type() }
+func (e execDirEntry) Info() (domain.FileInfo, error) { return e.info, nil }
@@ -0,0 +1,177 @@
+package adapters_test
+
+import (
+	"bytes"
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+)
+
+// The scripts of ExecFS run with the local sh, as they would in a
+// container.
+
+func TestExecFS_Files(t *testing.T) {
+	ctx := context.Background()
+	fsys := adapters.NewExecFS()
+	dir := t.TempDir()
+	name := filepath.Join(dir, "dot vimrc")
+
+	data := append(bytes.Repeat([]byte("set nu\n"), 10000), 0, 0xff)
+	require.NoError(t, fsys.WriteFile(ctx, name, data, 0600))
+	got, err := fsys.ReadFile(ctx, name)
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+
+	info, err := fsys.Stat(ctx, name)
+	require.NoError(t, err)
+	assert.Equal(t, "dot vimrc", info.Name())
+	assert.Equal(t, int64(len(data)), info.Size())
+	assert.Equal(t, fs.FileMode(0600), info.Mode())
+
+	// An existing file keeps its permissions, as with os.WriteFile
+	require.NoError(t, fsys.WriteFile(ctx, name, []byte("short"), 0644))
+	require.NoError(t, fsys.AppendFile(ctx, name, []byte(" line"), 0644))
+	got, err = os.ReadFile(name)
+	require.NoError(t, err)
+	assert.Equal(t, "short line", string(got))
+	osInfo, err := os.Stat(name)
+	require.NoError(t, err)
+	assert.Equal(t, fs.FileMode(0600), osInfo.Mode())
+
+	require.NoError(t, fsys.WriteFile(ctx, filepath.Join(dir, "empty"), nil, 0644))
+	got, err = fsys.ReadFile(ctx, filepath.Join(dir, "empty"))
+	require.NoError(t, err)
+	assert.Empty(t, got)
+
+	require.NoError(t, fsys.Chmod(ctx, name, 0644))
+	mtime := time.Date(2024, 5, 1, 12, 30, 15, 0, time.UTC)
+	require.NoError(t, fsys.Chtimes(ctx, name, mtime, mtime))
+	osInfo, err = os.Stat(name)
+	require.NoError(t, err)
+	assert.Equal(t, fs.FileMode(0644), osInfo.Mode())
+	assert.True(t, mtime.Equal(osInfo.ModTime()))
+
+	// Missing files are reported as os.IsNotExist expects
+	_, err = fsys.ReadFile(ctx, filepath.Join(dir, "missing"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = fsys.Stat(ctx, filepath.Join(dir, "missing"))
+	assert.True(t, os.IsNotExist(err))
+	assert.False(t, fsys.Exists(ctx, filepath.Join(dir, "missing")))
+	err = fsys.WriteFile(ctx, filepath.Join(dir, "missing", "file"), nil, 0644)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestExecFS_DirectoriesAndLinks(t *testing.T) {
+	ctx := context.Background()
+	fsys := adapters.NewExecFS()
+	home := t.TempDir()
+	pkg := filepath.Join(home, ".dotfiles", "vim")
+
+	require.NoError(t, fsys.MkdirAll(ctx, pkg, 0755))
+	require.NoError(t, fsys.MkdirAll(ctx, pkg, 0755))
+	isDir, err := fsys.IsDir(ctx, pkg)
+	require.NoError(t, err)
+	assert.True(t, isDir)
+	assert.ErrorIs(t, fsys.Mkdir(ctx, pkg, 0755), fs.ErrExist)
+
+	require.NoError(t, fsys.WriteFile(ctx, filepath.Join(pkg, "dot-vimrc"), []byte("set nu"), 0644))
+	require.NoError(t, fsys.Symlink(ctx, ".dotfiles/vim/dot-vimrc", filepath.Join(home, ".vimrc")))
+	assert.ErrorIs(t, fsys.Symlink(ctx, "x", filepath.Join(home, ".vimrc")), fs.ErrExist)
+
+	target, err := fsys.ReadLink(ctx, filepath.Join(home, ".vimrc"))
+	require.NoError(t, err)
+	assert.Equal(t, ".dotfiles/vim/dot-vimrc", target)
+	isLink, err := fsys.IsSymlink(ctx, filepath.Join(home, ".vimrc"))
+	require.NoError(t, err)
+	assert.True(t, isLink)
+	isLink, err = fsys.IsSymlink(ctx, pkg)
+	require.NoError(t, err)
+	assert.False(t, isLink)
+	_, err = fsys.IsSymlink(ctx, filepath.Join(home, "missing"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = fsys.ReadLink(ctx, pkg)
+	assert.ErrorContains(t, err, "not a symbolic link")
+
+	// Links are followed by Stat, not by ReadDir
+	info, err := fsys.Stat(ctx, filepath.Join(home, ".vimrc"))
+	require.NoError(t, err)
+	assert.True(t, info.Mode().IsRegular())
+	entries, err := fsys.ReadDir(ctx, home)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, ".dotfiles", entries[0].Name())
+	assert.True(t, entries[0].IsDir())
+	assert.Equal(t, ".vimrc", entries[1].Name())
+	assert.Equal(t, fs.ModeSymlink, entries[1].Type())
+
+	entries, err = fsys.ReadDir(ctx, filepath.Join(home, ".empty"))
+	assert.True(t, os.IsNotExist(err))
+	assert.Empty(t, entries)
+
+	// Rename replaces files and empty directories
+	require.NoError(t, fsys.WriteFile(ctx, filepath.Join(home, "new"), []byte("new"), 0644))
+	require.NoError(t, fsys.WriteFile(ctx, filepath.Join(home, "old"), []byte("old"), 0644))
+	require.NoError(t, fsys.Rename(ctx, filepath.Join(home, "new"), filepath.Join(home, "old")))
+	got, err := os.ReadFile(filepath.Join(home, "old"))
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(got))
+	require.NoError(t, os.Mkdir(filepath.Join(home, "empty"), 0755))
+	require.NoError(t, fsys.Rename(ctx, filepath.Join(home, ".dotfiles"), filepath.Join(home, "empty")))
+	assert.FileExists(t, filepath.Join(home, "empty", "vim", "dot-vimrc"))
+	require.NoError(t, fsys.Rename(ctx, filepath.Join(home, "empty"), filepath.Join(home, ".dotfiles")))
+
+	// Remove takes files, links and empty directories
+	require.NoError(t, fsys.Remove(ctx, filepath.Join(home, ".vimrc")))
+	assert.NoFileExists(t, filepath.Join(home, ".vimrc"))
+	assert.Error(t, fsys.Remove(ctx, filepath.Join(home, ".dotfiles")))
+	require.NoError(t, fsys.RemoveAll(ctx, filepath.Join(home, ".dotfiles")))
+	assert.NoDirExists(t, filepath.Join(home, ".dotfiles"))
+	require.NoError(t, fsys.RemoveAll(ctx, filepath.Join(home, ".dotfiles")))
+	assert.True(t, os.IsNotExist(fsys.Remove(ctx, filepath.Join(home, ".dotfiles"))))
+}
+
+func TestExecFS_ReadDirNames(t *testing.T) {
+	ctx := context.Background()
+	fsys := adapters.NewExecFS()
+	dir := t.TempDir()
+	names := []string{" lead", "line\nbreak", "tab\tand space", "trail\n"}
+	for _, name := range names {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(name), 0644))
+	}
+
+	entries, err := fsys.ReadDir(ctx, dir)
+	require.NoError(t, err)
+	require.Len(t, entries, len(names))
+	for i, entry := range entries {
+		assert.Equal(t, names[i], entry.Name())
+		info, err := entry.Info()
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(names[i])), info.Size())
+	}
+}
+
+func TestExecFS_Home(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	got, err := adapters.NewExecFS().Home(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, home, got)
+}
+
+func TestExecFS_CommandFails(t *testing.T) {
+	ctx := context.Background()
+	fsys := adapters.NewExecFS("sh", "-c", `echo "container not running" >&2; exit 1`, "sh")
+
+	_, err := fsys.ReadFile(ctx, "/home/me/.vimrc")
+	assert.ErrorContains(t, err, "container not running")
+	assert.False(t, os.IsNotExist(err))
+}
@@ -0,0 +1,240 @@
+package adapters
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+
+	"github.com/jamesainslie/dot/internal/domain"
+)
+
+// FSCloner implements GitCloner for package directories on a filesystem
+// other than the local disk, such as an in-memory one. The repository is
+// cloned into memory and the files of its checked-out commit are written
+// to the filesystem, together with a .git directory recording the branch
+// and commit. The result is a snapshot of the repository rather than a
+// working copy: it cannot be pulled or pushed, and is checked out in full
+// since a sparse checkout could not be extended later.
+type FSCloner struct {
+	fs        domain.FS
+	transport TransportOptions
+}
+
+// NewFSCloner creates a cloner writing the repositories it clones to fs.
+func NewFSCloner(fs domain.FS, opts TransportOptions) *FSCloner {
+	return &FSCloner{fs: fs, transport: opts}
+}
+
+// Clone clones the repository at url and writes its files to path.
+func (c *FSCloner) Clone(ctx context.Context, url string, path string, opts CloneOptions) error {
+	if err := validateFSTargetPath(ctx, c.fs, path); err != nil {
+		return err
+	}
+
+	auth, err := convertAuthMethod(opts.Auth)
+	if err != nil {
+		return fmt.Errorf("configure authentication: %w", err)
+	}
+	cloneOpts := &git.CloneOptions{
+		URL:             url,
+		Progress:        opts.Progress,
+		Auth:            auth,
+		Depth:           opts.Depth,
+		InsecureSkipTLS: c.transport.InsecureSkipTLS,
+		ProxyOptions:    c.transport.proxyOptions(),
+	}
+	if opts.Branch != "" {
+		cloneOpts.ReferenceName = plumbing.NewBranchReferenceName(opts.Branch)
+	}
+
+	ctx, cancel := c.transport.withTimeout(ctx)
+	defer cancel()
+
+	repo, err := git.CloneContext(ctx, memory.NewStorage(), nil, cloneOpts)
+	if err != nil {
+		return fmt.Errorf("clone repository: %w", err)
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return fmt.Errorf("resolve HEAD: %w", err)
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return fmt.Errorf("read HEAD commit: %w", err)
+	}
+
+	files, err := commit.Files()
+	if err != nil {
+		return fmt.Errorf("read HEAD tree: %w", err)
+	}
+	checkout := newFSCheckout(c.fs, path)
+	err = files.ForEach(func(file *object.File) error {
+		contents, err := file.Contents()
+		if err != nil {
+			return err
+		}
+		return checkout.write(ctx, file.Name, file.Mode, contents)
+	})
+	if err != nil {
+		return fmt.Errorf("check out files: %w", err)
+	}
+
+	branch := ""
+	if head.Name().IsBranch() {
+		branch = head.Name().Short()
+	}
+	return checkout.finish(ctx, branch, head.Hash().String())
+}
+
+// GitFixture is a repository served by a FixtureCloner.
+type GitFixture struct {
+	// Branch is the default branch of the repository. If empty, "main".
+	Branch string
+
+	// Commit is the hash of the commit checked out. If empty, a hash
+	// derived from the files is used.
+	Commit string
+
+	// Files maps paths within the repository, using forward slashes, to
+	// their contents.
+	Files map[string]string
+}
+
+// FixtureCloner implements GitCloner by writing fixture repositories to a
+// filesystem, as FSCloner writes the repositories it clones, so that
+// clones can be exercised without a network, a git server or the disk.
+type FixtureCloner struct {
+	fs    domain.FS
+	repos map[string]GitFixture
+}
+
+// NewFixtureCloner creates a cloner serving repos, keyed by URL, into fs.
+func NewFixtureCloner(fs domain.FS, repos map[string]GitFixture) *FixtureCloner {
+	return &FixtureCloner{fs: fs, repos: repos}
+}
+
+// Clone writes the fixture repository of url to path.
+func (c *FixtureCloner) Clone(ctx context.Context, url string, path string, opts CloneOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	repo, ok := c.repos[url]
+	if !ok {
+		return fmt.Errorf("clone repository: repository not found: %s", url)
+	}
+	branch := repo.Branch
+	if branch == "" {
+		branch = "main"
+	}
+	if opts.Branch != "" && opts.Branch != branch {
+		return fmt.Errorf("clone repository: couldn't find remote ref %q", plumbing.NewBranchReferenceName(opts.Branch))
+	}
+	if err := validateFSTargetPath(ctx, c.fs, path); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(repo.Files))
+	for name := range repo.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	commit := repo.Commit
+	hash := sha1.New()
+	checkout := newFSCheckout(c.fs, path)
+	for _, name := range names {
+		hash.Write([]byte(name + "\x00" + repo.Files[name] + "\x00"))
+		if err := checkout.write(ctx, name, filemode.Regular, repo.Files[name]); err != nil {
+			return fmt.Errorf("check out files: %w", err)
+		}
+	}
+	if commit == "" {
+		commit = hex.EncodeToString(hash.Sum(nil))
+	}
+	return checkout.finish(ctx, branch, commit)
+}
+
+// fsCheckout writes the files of a commit to a directory of a filesystem.
+type fsCheckout struct {
+	fs  domain.FS
+	dir string
+}
+
+func newFSCheckout(fs domain.FS, dir string) *fsCheckout {
+	return &fsCheckout{fs: fs, dir: dir}
+}
+
+// write writes the file name of the repository with mode and contents.
+func (c *fsCheckout) write(ctx context.Context, name string, mode filemode.FileMode, contents string) error {
+	dest := filepath.Join(c.dir, filepath.FromSlash(path.Clean(name)))
+	if err := c.fs.MkdirAll(ctx, filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	switch mode {
+	case filemode.Symlink:
+		return c.fs.Symlink(ctx, contents, dest)
+	case filemode.Executable:
+		return c.fs.WriteFile(ctx, dest, []byte(contents), 0755)
+	default:
+		return c.fs.WriteFile(ctx, dest, []byte(contents), 0644)
+	}
+}
+
+// finish records branch and commit in the .git directory, as git does, so
+// that the clone can be told apart from other directories and its branch
+// and commit read. An empty branch records a detached HEAD.
+func (c *fsCheckout) finish(ctx context.Context, branch, commit string) error {
+	gitDir := filepath.Join(c.dir, ".git")
+	head := commit + "\n"
+	if branch != "" {
+		ref := plumbing.NewBranchReferenceName(branch).String()
+		refPath := filepath.Join(gitDir, filepath.FromSlash(ref))
+		if err := c.fs.MkdirAll(ctx, filepath.Dir(refPath), 0755); err != nil {
+			return fmt.Errorf("record commit: %w", err)
+		}
+		if err := c.fs.WriteFile(ctx, refPath, []byte(commit+"\n"), 0644); err != nil {
+			return fmt.Errorf("record commit: %w", err)
+		}
+		head = "ref: " + ref + "\n"
+	}
+	if err := c.fs.MkdirAll(ctx, gitDir, 0755); err != nil {
+		return fmt.Errorf("record commit: %w", err)
+	}
+	if err := c.fs.WriteFile(ctx, filepath.Join(gitDir, "HEAD"), []byte(head), 0644); err != nil {
+		return fmt.Errorf("record commit: %w", err)
+	}
+	return nil
+}
+
+// validateFSTargetPath checks that path is missing or an empty directory
+// of fs, as validateTargetPath does on the local disk.
+func validateFSTargetPath(ctx context.Context, fs domain.FS, path string) error {
+	if !fs.Exists(ctx, path) {
+		return nil
+	}
+	isDir, err := fs.IsDir(ctx, path)
+	if err != nil {
+		return fmt.Errorf("check target path: %w", err)
+	}
+	if !isDir {
+		return fmt.Errorf("target path exists and is not a directory: %s", path)
+	}
+	entries, err := fs.ReadDir(ctx, path)
+	if err != nil {
+		return fmt.Errorf("read target directory: %w", err)
+	}
+	if len(entries) > 0 {
+		return fmt.Errorf("target directory already exists and is not empty: %s", path)
+	}
+	return nil
+}
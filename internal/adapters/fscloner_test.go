@@ -0,0 +1,71 @@
+package adapters
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFSCloner_Clone(t *testing.T) {
+	ctx := context.Background()
+	originPath := filepath.Join(t.TempDir(), "origin")
+	origin, err := git.PlainInit(originPath, false)
+	require.NoError(t, err)
+	commitFile(t, origin, originPath, "vim/dot-vimrc", "set nocompatible")
+	head := commitFile(t, origin, originPath, "zsh/dot-zshrc", "export EDITOR=vim")
+
+	fs := NewMemFS()
+	require.NoError(t, NewFSCloner(fs, TransportOptions{}).Clone(ctx, originPath, "/dotfiles", CloneOptions{
+		SparseDirectories: []string{"vim"},
+	}))
+
+	data, err := fs.ReadFile(ctx, "/dotfiles/vim/dot-vimrc")
+	require.NoError(t, err)
+	assert.Equal(t, "set nocompatible", string(data))
+	assert.True(t, fs.Exists(ctx, "/dotfiles/zsh/dot-zshrc"), "snapshots are checked out in full")
+
+	data, err = fs.ReadFile(ctx, "/dotfiles/.git/HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, "ref: refs/heads/master\n", string(data))
+	data, err = fs.ReadFile(ctx, "/dotfiles/.git/refs/heads/master")
+	require.NoError(t, err)
+	assert.Equal(t, head+"\n", string(data))
+
+	err = NewFSCloner(fs, TransportOptions{}).Clone(ctx, originPath, "/dotfiles", CloneOptions{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not empty")
+}
+
+func TestFixtureCloner_Clone(t *testing.T) {
+	ctx := context.Background()
+	fs := NewMemFS()
+	cloner := NewFixtureCloner(fs, map[string]GitFixture{
+		"https://example.com/dotfiles.git": {
+			Branch: "trunk",
+			Files:  map[string]string{"vim/dot-vimrc": "syntax on", "README.md": "dotfiles"},
+		},
+	})
+
+	require.NoError(t, cloner.Clone(ctx, "https://example.com/dotfiles.git", "/dotfiles", CloneOptions{}))
+	data, err := fs.ReadFile(ctx, "/dotfiles/vim/dot-vimrc")
+	require.NoError(t, err)
+	assert.Equal(t, "syntax on", string(data))
+	data, err = fs.ReadFile(ctx, "/dotfiles/.git/HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, "ref: refs/heads/trunk\n", string(data))
+	data, err = fs.ReadFile(ctx, "/dotfiles/.git/refs/heads/trunk")
+	require.NoError(t, err)
+	assert.Len(t, string(data), 41, "a commit hash is derived from the files")
+
+	err = cloner.Clone(ctx, "https://example.com/dotfiles.git", "/other", CloneOptions{Branch: "main"})
+	require.Error(t, err)
+	assert.False(t, fs.Exists(ctx, "/other"))
+
+	err = cloner.Clone(ctx, "https://example.com/missing.git", "/other", CloneOptions{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "repository not found")
+}
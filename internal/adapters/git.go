@@ -4,6 +4,7 @@ package adapters
 import (
 	"context"
 	"io"
+	"time"
 )
 
 // GitCloner defines the interface for cloning git repositories.
@@ -37,6 +38,193 @@ type CloneOptions struct {
 	// Progress is an optional writer for clone progress output.
 	// If nil, no progress is reported.
 	Progress io.Writer
+
+	// SparseDirectories, if set, checks out only these directories and the
+	// files at the root of the repository.
+	SparseDirectories []string
+}
+
+// GitSparseCheckout defines the interface for repositories that check out
+// only some of their directories.
+type GitSparseCheckout interface {
+	// SparseDirectories returns the directories checked out in the
+	// repository at path, or nil if every directory is checked out.
+	SparseDirectories(ctx context.Context, path string) ([]string, error)
+
+	// AddSparseDirectories checks out dirs in addition to the directories
+	// already checked out in the sparse repository at path.
+	AddSparseDirectories(ctx context.Context, path string, dirs []string) error
+
+	// RepositoryDirectories returns the top-level directories tracked in
+	// the repository at path, whether they are checked out or not.
+	RepositoryDirectories(ctx context.Context, path string) ([]string, error)
+}
+
+// GitCheckout defines the interface for switching the branch of an existing
+// git checkout.
+type GitCheckout interface {
+	// CheckoutBranch checks out branch in the repository at path. If the
+	// remote has a branch of that name it is checked out tracking the remote
+	// branch; otherwise the branch is created at HEAD.
+	CheckoutBranch(ctx context.Context, path string, branch string) error
+}
+
+// GitPuller defines the interface for updating an existing git checkout.
+type GitPuller interface {
+	// Pull fetches and merges changes from the remote into the repository at path.
+	// An already up-to-date repository is not an error.
+	Pull(ctx context.Context, path string, opts PullOptions) error
+
+	// Head returns the commit hash checked out in the repository at path.
+	Head(ctx context.Context, path string) (string, error)
+
+	// ChangedFiles returns the repository-relative paths of files that differ
+	// between the commits from and to, using forward slashes.
+	ChangedFiles(ctx context.Context, path string, from string, to string) ([]string, error)
+}
+
+// GitHistory defines the interface for inspecting and completing the commit
+// history of an existing git checkout.
+type GitHistory interface {
+	// IsShallow reports whether the repository at path was cloned with
+	// truncated history.
+	IsShallow(ctx context.Context, path string) (bool, error)
+
+	// Unshallow fetches the complete history of the repository at path.
+	// A repository that already has full history is not an error.
+	Unshallow(ctx context.Context, path string, opts PullOptions) error
+}
+
+// GitRebaser defines the interface for keeping a local branch on top of a
+// shared remote branch.
+type GitRebaser interface {
+	// Rebase fetches origin and replays the commits of the branch checked
+	// out in the repository at path that are not on the remote branch onto.
+	//
+	// Commits are replayed file by file: a file changed both locally and
+	// on onto is a conflict unless both sides made the same change. On
+	// conflict nothing is changed and the conflicting paths are returned in
+	// RebaseResult.Conflicts.
+	//
+	// Returns an error if the worktree has uncommitted changes to tracked
+	// files or the branches share no locally available history.
+	Rebase(ctx context.Context, path string, onto string, opts PullOptions) (RebaseResult, error)
+}
+
+// RebaseResult describes the outcome of a rebase.
+type RebaseResult struct {
+	// Replayed is the number of local commits rewritten onto the new base.
+	Replayed int
+
+	// Conflicts lists the repository-relative paths changed differently on
+	// both branches. Empty if the rebase succeeded.
+	Conflicts []string
+}
+
+// GitRepository defines the interface for routine maintenance of an
+// existing git checkout.
+type GitRepository interface {
+	// Status reports the branch, upstream divergence, and uncommitted
+	// changes of the repository at path.
+	Status(ctx context.Context, path string) (RepoStatus, error)
+
+	// Push pushes the checked-out branch of the repository at path to the
+	// origin remote. An already up-to-date remote is not an error.
+	Push(ctx context.Context, path string, opts PushOptions) error
+
+	// SetRemoteURL replaces the URL of the named remote of the repository
+	// at path.
+	//
+	// Returns an error if the remote does not exist.
+	SetRemoteURL(ctx context.Context, path string, remote string, url string) error
+}
+
+// GitTree defines the interface for reading the files committed to a
+// repository, which may be bare.
+type GitTree interface {
+	// TrackedFiles returns the repository-relative paths of the files
+	// committed at HEAD in the repository at path, sorted and using forward
+	// slashes. A repository without commits has no files.
+	TrackedFiles(ctx context.Context, path string) ([]string, error)
+}
+
+// RepoStatus describes the state of a git checkout.
+type RepoStatus struct {
+	// Branch is the checked-out branch, or empty if HEAD is detached.
+	Branch string
+
+	// Head is the commit hash checked out, or empty if there are no commits.
+	Head string
+
+	// RemoteURL is the URL of the origin remote, or empty if there is none.
+	RemoteURL string
+
+	// Upstream names the remote-tracking branch compared against, such as
+	// "origin/main". Empty if the branch has no remote-tracking branch.
+	Upstream string
+
+	// Ahead is the number of local commits not on Upstream.
+	Ahead int
+
+	// Behind is the number of Upstream commits not checked out.
+	Behind int
+
+	// Changes lists uncommitted changes, sorted by path.
+	Changes []FileChange
+}
+
+// FileChange describes an uncommitted change to a file.
+type FileChange struct {
+	// Path is the repository-relative path using forward slashes.
+	Path string
+
+	// Status is one of "modified", "added", "deleted", "renamed",
+	// or "untracked".
+	Status string
+}
+
+// PushOptions configures repository push behavior.
+type PushOptions struct {
+	// Auth specifies the authentication method.
+	// If nil, no authentication is used.
+	Auth AuthMethod
+
+	// Progress is an optional writer for push progress output.
+	// If nil, no progress is reported.
+	Progress io.Writer
+
+	// Force overwrites the remote branch even if the push is not a
+	// fast-forward, as needed after rebasing a branch that was pushed.
+	Force bool
+}
+
+// PullOptions configures repository pull behavior.
+type PullOptions struct {
+	// Auth specifies the authentication method.
+	// If nil, no authentication is used (public repos only).
+	Auth AuthMethod
+
+	// Branch specifies which remote branch to pull.
+	// If empty, the branch tracked by HEAD is pulled.
+	Branch string
+
+	// Progress is an optional writer for pull progress output.
+	// If nil, no progress is reported.
+	Progress io.Writer
+}
+
+// TransportOptions configures how git operations reach the network.
+// The zero value connects directly with TLS verification and no time limit.
+type TransportOptions struct {
+	// Timeout bounds each network operation. If 0, there is no limit.
+	Timeout time.Duration
+
+	// ProxyURL routes traffic through the given proxy, e.g.
+	// "http://proxy.example.com:3128". If empty, no proxy is used.
+	ProxyURL string
+
+	// InsecureSkipTLS disables certificate verification for HTTPS remotes.
+	InsecureSkipTLS bool
 }
 
 // AuthMethod represents a git authentication method.
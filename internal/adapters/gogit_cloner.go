@@ -6,6 +6,7 @@ import (
 	"os"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
@@ -13,13 +14,21 @@ import (
 )
 
 // GoGitCloner implements GitCloner using go-git library.
-type GoGitCloner struct{}
+type GoGitCloner struct {
+	transport TransportOptions
+}
 
 // NewGoGitCloner creates a new go-git based cloner.
 func NewGoGitCloner() *GoGitCloner {
 	return &GoGitCloner{}
 }
 
+// NewGoGitClonerWithTransport creates a go-git based cloner that applies
+// the given timeout, proxy, and TLS settings to every clone.
+func NewGoGitClonerWithTransport(opts TransportOptions) *GoGitCloner {
+	return &GoGitCloner{transport: opts}
+}
+
 // Clone clones a git repository using go-git.
 func (g *GoGitCloner) Clone(ctx context.Context, url string, path string, opts CloneOptions) error {
 	// Check if target path already exists and is not empty
@@ -35,9 +44,11 @@ func (g *GoGitCloner) Clone(ctx context.Context, url string, path string, opts C
 
 	// Build clone options
 	cloneOpts := &git.CloneOptions{
-		URL:      url,
-		Progress: opts.Progress,
-		Auth:     auth,
+		URL:             url,
+		Progress:        opts.Progress,
+		Auth:            auth,
+		InsecureSkipTLS: g.transport.InsecureSkipTLS,
+		ProxyOptions:    g.transport.proxyOptions(),
 	}
 
 	// Set branch reference if specified
@@ -50,15 +61,84 @@ func (g *GoGitCloner) Clone(ctx context.Context, url string, path string, opts C
 		cloneOpts.Depth = opts.Depth
 	}
 
+	// Sparse clones are checked out once the sparse set is known
+	sparse := len(opts.SparseDirectories) > 0
+	cloneOpts.NoCheckout = sparse
+
+	ctx, cancel := g.transport.withTimeout(ctx)
+	defer cancel()
+
 	// Perform clone with context
-	_, err = git.PlainCloneContext(ctx, path, false, cloneOpts)
+	repo, err := git.PlainCloneContext(ctx, path, false, cloneOpts)
 	if err != nil {
 		return fmt.Errorf("clone repository: %w", err)
 	}
 
+	if sparse {
+		return checkoutSparse(repo, path, opts.SparseDirectories)
+	}
 	return nil
 }
 
+// CheckoutBranch checks out branch in a cloned repository, tracking the
+// remote branch of the same name if there is one.
+func (g *GoGitCloner) CheckoutBranch(ctx context.Context, path string, branch string) error {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return fmt.Errorf("open repository: %w", err)
+	}
+	if err := checkoutLocalBranch(repo, branch); err != nil {
+		return fmt.Errorf("check out branch %s: %w", branch, err)
+	}
+	return nil
+}
+
+// checkoutLocalBranch checks out branch, tracking the remote branch of the
+// same name if there is one and otherwise starting it at HEAD.
+func checkoutLocalBranch(repo *git.Repository, branch string) error {
+	head, err := repo.Head()
+	if err != nil {
+		return fmt.Errorf("resolve HEAD: %w", err)
+	}
+	branchRef := plumbing.NewBranchReferenceName(branch)
+	if head.Name() == branchRef {
+		return nil
+	}
+
+	start := head.Hash()
+	remoteRef, err := repo.Reference(plumbing.NewRemoteReferenceName(git.DefaultRemoteName, branch), true)
+	if err == nil {
+		start = remoteRef.Hash()
+		err = repo.CreateBranch(&config.Branch{
+			Name:   branch,
+			Remote: git.DefaultRemoteName,
+			Merge:  branchRef,
+		})
+		if err != nil {
+			return fmt.Errorf("configure tracking: %w", err)
+		}
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("open worktree: %w", err)
+	}
+	return worktree.Checkout(&git.CheckoutOptions{Branch: branchRef, Hash: start, Create: true})
+}
+
+// proxyOptions converts the configured proxy to go-git proxy options.
+func (o TransportOptions) proxyOptions() transport.ProxyOptions {
+	return transport.ProxyOptions{URL: o.ProxyURL}
+}
+
+// withTimeout bounds ctx by the configured timeout, if any.
+func (o TransportOptions) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if o.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, o.Timeout)
+}
+
 // validateTargetPath checks if the target path is suitable for cloning.
 func validateTargetPath(path string) error {
 	info, err := os.Stat(path)
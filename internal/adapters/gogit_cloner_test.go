@@ -209,3 +209,42 @@ func TestGoGitCloner_Clone_ContextCancellation(t *testing.T) {
 	err := cloner.Clone(ctx, url, tempDir, opts)
 	assert.Error(t, err)
 }
+
+func TestGoGitCloner_Clone_WithTransport(t *testing.T) {
+	cloner := NewGoGitClonerWithTransport(TransportOptions{
+		Timeout:         time.Minute,
+		InsecureSkipTLS: true,
+	})
+	targetPath := filepath.Join(t.TempDir(), "repo")
+
+	err := cloner.Clone(context.Background(), getTestRepoURL(t), targetPath, CloneOptions{Depth: 1})
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(targetPath, "README.md"))
+}
+
+func TestGoGitCloner_Clone_Timeout(t *testing.T) {
+	cloner := NewGoGitClonerWithTransport(TransportOptions{Timeout: time.Nanosecond})
+	targetPath := filepath.Join(t.TempDir(), "repo")
+
+	err := cloner.Clone(context.Background(), getTestRepoURL(t), targetPath, CloneOptions{})
+	assert.Error(t, err)
+}
+
+func TestTransportOptions_WithTimeout(t *testing.T) {
+	ctx, cancel := TransportOptions{}.withTimeout(context.Background())
+	defer cancel()
+	_, hasDeadline := ctx.Deadline()
+	assert.False(t, hasDeadline)
+
+	ctx, cancel = TransportOptions{Timeout: time.Minute}.withTimeout(context.Background())
+	defer cancel()
+	deadline, hasDeadline := ctx.Deadline()
+	assert.True(t, hasDeadline)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, 5*time.Second)
+}
+
+func TestTransportOptions_ProxyOptions(t *testing.T) {
+	assert.Empty(t, TransportOptions{}.proxyOptions().URL)
+	assert.Equal(t, "http://proxy.example.com:3128",
+		TransportOptions{ProxyURL: "http://proxy.example.com:3128"}.proxyOptions().URL)
+}
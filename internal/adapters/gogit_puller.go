@@ -0,0 +1,237 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+// GoGitPuller implements GitPuller using go-git library.
+type GoGitPuller struct {
+	transport TransportOptions
+}
+
+// NewGoGitPuller creates a new go-git based puller.
+func NewGoGitPuller() *GoGitPuller {
+	return &GoGitPuller{}
+}
+
+// NewGoGitPullerWithTransport creates a go-git based puller that applies
+// the given timeout, proxy, and TLS settings to every pull.
+func NewGoGitPullerWithTransport(opts TransportOptions) *GoGitPuller {
+	return &GoGitPuller{transport: opts}
+}
+
+// Pull fetches and merges remote changes using go-git.
+// Only fast-forward merges are supported.
+func (g *GoGitPuller) Pull(ctx context.Context, path string, opts PullOptions) error {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return fmt.Errorf("open repository: %w", err)
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("open worktree: %w", err)
+	}
+
+	auth, err := convertAuthMethod(opts.Auth)
+	if err != nil {
+		return fmt.Errorf("configure authentication: %w", err)
+	}
+
+	pullOpts := &git.PullOptions{
+		RemoteName:      git.DefaultRemoteName,
+		Auth:            auth,
+		Progress:        opts.Progress,
+		InsecureSkipTLS: g.transport.InsecureSkipTLS,
+		ProxyOptions:    g.transport.proxyOptions(),
+	}
+	if opts.Branch != "" {
+		pullOpts.ReferenceName = plumbing.NewBranchReferenceName(opts.Branch)
+	}
+
+	ctx, cancel := g.transport.withTimeout(ctx)
+	defer cancel()
+
+	err = worktree.PullContext(ctx, pullOpts)
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("pull repository: %w", err)
+	}
+
+	return nil
+}
+
+// infiniteDepth is the depth git itself requests for --unshallow.
+const infiniteDepth = 2147483647
+
+// shallowFile lists the shallow commit boundary inside the .git directory.
+const shallowFile = "shallow"
+
+// IsShallow reports whether the repository has a shallow commit boundary.
+func (g *GoGitPuller) IsShallow(ctx context.Context, path string) (bool, error) {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return false, fmt.Errorf("open repository: %w", err)
+	}
+
+	shallows, err := repo.Storer.Shallow()
+	if err != nil {
+		return false, fmt.Errorf("read shallow commits: %w", err)
+	}
+
+	return len(shallows) > 0, nil
+}
+
+// Unshallow fetches the history missing from a shallow clone.
+func (g *GoGitPuller) Unshallow(ctx context.Context, path string, opts PullOptions) error {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return fmt.Errorf("open repository: %w", err)
+	}
+
+	auth, err := convertAuthMethod(opts.Auth)
+	if err != nil {
+		return fmt.Errorf("configure authentication: %w", err)
+	}
+
+	fetchOpts := &git.FetchOptions{
+		RemoteName:      git.DefaultRemoteName,
+		Auth:            auth,
+		Progress:        opts.Progress,
+		Depth:           infiniteDepth,
+		InsecureSkipTLS: g.transport.InsecureSkipTLS,
+		ProxyOptions:    g.transport.proxyOptions(),
+	}
+
+	ctx, cancel := g.transport.withTimeout(ctx)
+	defer cancel()
+
+	err = repo.FetchContext(ctx, fetchOpts)
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("fetch history: %w", err)
+	}
+
+	return pruneShallow(repo)
+}
+
+// pruneShallow drops commits whose parents are now present from the shallow
+// list. go-git only ever adds to the list when fetching, so without this the
+// repository would still be reported as shallow after a full fetch.
+func pruneShallow(repo *git.Repository) error {
+	shallows, err := repo.Storer.Shallow()
+	if err != nil {
+		return fmt.Errorf("read shallow commits: %w", err)
+	}
+
+	kept := make([]plumbing.Hash, 0, len(shallows))
+	for _, hash := range shallows {
+		if !hasParents(repo, hash) {
+			kept = append(kept, hash)
+		}
+	}
+
+	if len(kept) == len(shallows) {
+		return nil
+	}
+
+	// git treats an empty shallow file as shallow, so remove it like
+	// git fetch --unshallow does
+	if storage, ok := repo.Storer.(*filesystem.Storage); ok && len(kept) == 0 {
+		err := storage.Filesystem().Remove(shallowFile)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove shallow file: %w", err)
+		}
+		return nil
+	}
+
+	if err := repo.Storer.SetShallow(kept); err != nil {
+		return fmt.Errorf("update shallow commits: %w", err)
+	}
+	return nil
+}
+
+// hasParents reports whether every parent of the commit exists locally.
+func hasParents(repo *git.Repository, hash plumbing.Hash) bool {
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return false
+	}
+	for _, parent := range commit.ParentHashes {
+		if _, err := repo.Storer.EncodedObject(plumbing.CommitObject, parent); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Head returns the commit hash of HEAD.
+func (g *GoGitPuller) Head(ctx context.Context, path string) (string, error) {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return "", fmt.Errorf("open repository: %w", err)
+	}
+
+	ref, err := repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("resolve HEAD: %w", err)
+	}
+
+	return ref.Hash().String(), nil
+}
+
+// ChangedFiles returns the files that differ between two commits.
+func (g *GoGitPuller) ChangedFiles(ctx context.Context, path string, from string, to string) ([]string, error) {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return nil, fmt.Errorf("open repository: %w", err)
+	}
+
+	fromTree, err := commitTree(repo, from)
+	if err != nil {
+		return nil, err
+	}
+	toTree, err := commitTree(repo, to)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := object.DiffTreeWithOptions(ctx, fromTree, toTree, object.DefaultDiffTreeOptions)
+	if err != nil {
+		return nil, fmt.Errorf("diff commits: %w", err)
+	}
+
+	files := make([]string, 0, len(changes))
+	for _, change := range changes {
+		// Renames report both names so that both packages are refreshed
+		if change.From.Name != "" {
+			files = append(files, change.From.Name)
+		}
+		if change.To.Name != "" && change.To.Name != change.From.Name {
+			files = append(files, change.To.Name)
+		}
+	}
+
+	return files, nil
+}
+
+// commitTree resolves the tree of the commit with the given hash.
+func commitTree(repo *git.Repository, hash string) (*object.Tree, error) {
+	commit, err := repo.CommitObject(plumbing.NewHash(hash))
+	if err != nil {
+		return nil, fmt.Errorf("resolve commit %s: %w", hash, err)
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("read tree of commit %s: %w", hash, err)
+	}
+
+	return tree, nil
+}
@@ -0,0 +1,154 @@
+package adapters
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// commitFile writes a file into the repository worktree and commits it.
+func commitFile(t *testing.T, repo *git.Repository, repoPath, name, content string) string {
+	t.Helper()
+
+	fullPath := filepath.Join(repoPath, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(fullPath), 0755))
+	require.NoError(t, os.WriteFile(fullPath, []byte(content), 0644))
+
+	worktree, err := repo.Worktree()
+	require.NoError(t, err)
+	_, err = worktree.Add(name)
+	require.NoError(t, err)
+
+	hash, err := worktree.Commit("update "+name, &git.CommitOptions{
+		Author: &object.Signature{
+			Name:  "Test User",
+			Email: "test@example.com",
+			When:  time.Now(),
+		},
+	})
+	require.NoError(t, err)
+	return hash.String()
+}
+
+func TestNewGoGitPuller(t *testing.T) {
+	puller := NewGoGitPuller()
+	assert.NotNil(t, puller)
+}
+
+func TestGoGitPuller_PullAndChangedFiles(t *testing.T) {
+	ctx := context.Background()
+	originPath := filepath.Join(t.TempDir(), "origin")
+	clonePath := filepath.Join(t.TempDir(), "clone")
+
+	origin, err := git.PlainInit(originPath, false)
+	require.NoError(t, err)
+	first := commitFile(t, origin, originPath, "vim/dot-vimrc", "set nocompatible")
+	commitFile(t, origin, originPath, "zsh/dot-zshrc", "export EDITOR=vim")
+
+	require.NoError(t, NewGoGitCloner().Clone(ctx, originPath, clonePath, CloneOptions{}))
+
+	puller := NewGoGitPuller()
+	head, err := puller.Head(ctx, clonePath)
+	require.NoError(t, err)
+
+	// Nothing to pull yet
+	require.NoError(t, puller.Pull(ctx, clonePath, PullOptions{}))
+
+	last := commitFile(t, origin, originPath, "git/dot-gitconfig", "[user]")
+	require.NoError(t, puller.Pull(ctx, clonePath, PullOptions{}))
+
+	newHead, err := puller.Head(ctx, clonePath)
+	require.NoError(t, err)
+	assert.Equal(t, last, newHead)
+
+	changed, err := puller.ChangedFiles(ctx, clonePath, head, newHead)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"git/dot-gitconfig"}, changed)
+
+	changed, err = puller.ChangedFiles(ctx, clonePath, first, newHead)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"git/dot-gitconfig", "zsh/dot-zshrc"}, changed)
+}
+
+func TestGoGitPuller_NotARepository(t *testing.T) {
+	ctx := context.Background()
+	puller := NewGoGitPuller()
+	dir := t.TempDir()
+
+	assert.Error(t, puller.Pull(ctx, dir, PullOptions{}))
+
+	_, err := puller.Head(ctx, dir)
+	assert.Error(t, err)
+
+	_, err = puller.ChangedFiles(ctx, dir, "a", "b")
+	assert.Error(t, err)
+}
+
+func TestGoGitPuller_UnknownCommit(t *testing.T) {
+	ctx := context.Background()
+	repoPath := t.TempDir()
+	repo, err := git.PlainInit(repoPath, false)
+	require.NoError(t, err)
+	head := commitFile(t, repo, repoPath, "vim/dot-vimrc", "set nocompatible")
+
+	_, err = NewGoGitPuller().ChangedFiles(ctx, repoPath, "0123456789012345678901234567890123456789", head)
+	assert.Error(t, err)
+}
+
+func TestGoGitPuller_Unshallow(t *testing.T) {
+	ctx := context.Background()
+	originPath := filepath.Join(t.TempDir(), "origin")
+	clonePath := filepath.Join(t.TempDir(), "clone")
+
+	origin, err := git.PlainInit(originPath, false)
+	require.NoError(t, err)
+	first := commitFile(t, origin, originPath, "vim/dot-vimrc", "set nocompatible")
+	last := commitFile(t, origin, originPath, "zsh/dot-zshrc", "export EDITOR=vim")
+
+	require.NoError(t, NewGoGitCloner().Clone(ctx, "file://"+originPath, clonePath, CloneOptions{Depth: 1}))
+
+	puller := NewGoGitPuller()
+	shallow, err := puller.IsShallow(ctx, clonePath)
+	require.NoError(t, err)
+	assert.True(t, shallow)
+
+	// History before the shallow boundary is missing
+	_, err = puller.ChangedFiles(ctx, clonePath, first, last)
+	require.Error(t, err)
+
+	require.NoError(t, puller.Unshallow(ctx, clonePath, PullOptions{}))
+
+	shallow, err = puller.IsShallow(ctx, clonePath)
+	require.NoError(t, err)
+	assert.False(t, shallow)
+	assert.NoFileExists(t, filepath.Join(clonePath, ".git", "shallow"), "git treats an empty shallow file as shallow")
+
+	changed, err := puller.ChangedFiles(ctx, clonePath, first, last)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"zsh/dot-zshrc"}, changed)
+
+	// Unshallowing a complete repository is a no-op
+	require.NoError(t, puller.Unshallow(ctx, clonePath, PullOptions{}))
+}
+
+func TestGoGitPuller_IsShallow_FullClone(t *testing.T) {
+	ctx := context.Background()
+	repoPath := t.TempDir()
+	repo, err := git.PlainInit(repoPath, false)
+	require.NoError(t, err)
+	commitFile(t, repo, repoPath, "vim/dot-vimrc", "set nocompatible")
+
+	shallow, err := NewGoGitPuller().IsShallow(ctx, repoPath)
+	require.NoError(t, err)
+	assert.False(t, shallow)
+
+	_, err = NewGoGitPuller().IsShallow(ctx, t.TempDir())
+	assert.Error(t, err)
+}
@@ -0,0 +1,326 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// treeFile is a non-directory tree entry.
+type treeFile struct {
+	hash plumbing.Hash
+	mode filemode.FileMode
+}
+
+// Rebase fetches origin and replays local commits onto the remote branch
+// onto. go-git has no rebase, so commits are replayed at file granularity:
+// each commit's changed files are applied to the new base provided the
+// new base still has the content the commit started from.
+func (g *GoGitPuller) Rebase(ctx context.Context, path string, onto string, opts PullOptions) (RebaseResult, error) {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return RebaseResult{}, fmt.Errorf("open repository: %w", err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return RebaseResult{}, fmt.Errorf("resolve HEAD: %w", err)
+	}
+	if !head.Name().IsBranch() {
+		return RebaseResult{}, fmt.Errorf("cannot rebase detached HEAD")
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return RebaseResult{}, fmt.Errorf("open worktree: %w", err)
+	}
+	if err := requireCleanWorktree(worktree); err != nil {
+		return RebaseResult{}, err
+	}
+
+	if err := g.fetch(ctx, repo, opts); err != nil {
+		return RebaseResult{}, err
+	}
+
+	ontoRef, err := repo.Reference(plumbing.NewRemoteReferenceName(git.DefaultRemoteName, onto), true)
+	if err != nil {
+		return RebaseResult{}, fmt.Errorf("resolve %s/%s: %w", git.DefaultRemoteName, onto, err)
+	}
+
+	newHead, result, err := replayCommits(repo, head.Hash(), ontoRef.Hash())
+	if err != nil || len(result.Conflicts) > 0 || newHead == head.Hash() {
+		return result, err
+	}
+
+	err = worktree.Reset(&git.ResetOptions{Commit: newHead, Mode: git.HardReset})
+	if err != nil {
+		return RebaseResult{}, fmt.Errorf("check out rebased branch: %w", err)
+	}
+	return result, nil
+}
+
+// fetch updates the remote-tracking branches of origin.
+func (g *GoGitPuller) fetch(ctx context.Context, repo *git.Repository, opts PullOptions) error {
+	auth, err := convertAuthMethod(opts.Auth)
+	if err != nil {
+		return fmt.Errorf("configure authentication: %w", err)
+	}
+
+	ctx, cancel := g.transport.withTimeout(ctx)
+	defer cancel()
+
+	err = repo.FetchContext(ctx, &git.FetchOptions{
+		RemoteName:      git.DefaultRemoteName,
+		Auth:            auth,
+		Progress:        opts.Progress,
+		InsecureSkipTLS: g.transport.InsecureSkipTLS,
+		ProxyOptions:    g.transport.proxyOptions(),
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("fetch repository: %w", err)
+	}
+	return nil
+}
+
+// requireCleanWorktree fails if tracked files have uncommitted changes,
+// which a hard reset to the rebased commit would discard.
+func requireCleanWorktree(worktree *git.Worktree) error {
+	status, err := worktree.Status()
+	if err != nil {
+		return fmt.Errorf("read worktree status: %w", err)
+	}
+	for file, s := range status {
+		if s.Worktree != git.Untracked && (s.Worktree != git.Unmodified || s.Staging != git.Unmodified) {
+			return fmt.Errorf("uncommitted changes to %s: commit or discard them before rebasing", file)
+		}
+	}
+	return nil
+}
+
+// replayCommits rewrites the first-parent commits of local not reachable
+// from base on top of base. It returns the new branch head, which is local
+// itself if it already contains base.
+func replayCommits(repo *git.Repository, local, base plumbing.Hash) (plumbing.Hash, RebaseResult, error) {
+	baseCommits, err := ancestors(repo, base)
+	if err != nil {
+		return plumbing.ZeroHash, RebaseResult{}, err
+	}
+	if baseCommits[local] {
+		// Nothing local to replay: fast-forward
+		return base, RebaseResult{}, nil
+	}
+	localCommits, err := ancestors(repo, local)
+	if err != nil {
+		return plumbing.ZeroHash, RebaseResult{}, err
+	}
+	if localCommits[base] {
+		// Already based on the new base
+		return local, RebaseResult{}, nil
+	}
+
+	var pending []*object.Commit
+	for hash := local; !baseCommits[hash]; {
+		commit, err := repo.CommitObject(hash)
+		if err != nil {
+			return plumbing.ZeroHash, RebaseResult{}, fmt.Errorf("no common history with the new base: %w", err)
+		}
+		if len(commit.ParentHashes) == 0 {
+			return plumbing.ZeroHash, RebaseResult{}, fmt.Errorf("no common history with the new base")
+		}
+		pending = append(pending, commit)
+		hash = commit.ParentHashes[0]
+	}
+
+	baseCommit, err := repo.CommitObject(base)
+	if err != nil {
+		return plumbing.ZeroHash, RebaseResult{}, fmt.Errorf("read commit %s: %w", base, err)
+	}
+	current, err := commitFiles(baseCommit)
+	if err != nil {
+		return plumbing.ZeroHash, RebaseResult{}, err
+	}
+
+	var result RebaseResult
+	parent, parentTree := base, baseCommit.TreeHash
+	for i := len(pending) - 1; i >= 0; i-- {
+		commit := pending[i]
+		conflicts, err := applyCommit(repo, commit, current)
+		if err != nil {
+			return plumbing.ZeroHash, RebaseResult{}, err
+		}
+		if len(conflicts) > 0 {
+			return local, RebaseResult{Conflicts: conflicts}, nil
+		}
+
+		tree, err := writeTree(repo.Storer, current)
+		if err != nil {
+			return plumbing.ZeroHash, RebaseResult{}, err
+		}
+		if tree == parentTree {
+			// The change is already on the new base
+			continue
+		}
+
+		rewritten, err := writeCommit(repo.Storer, commit, tree, parent)
+		if err != nil {
+			return plumbing.ZeroHash, RebaseResult{}, err
+		}
+		parent, parentTree = rewritten, tree
+		result.Replayed++
+	}
+
+	return parent, result, nil
+}
+
+// applyCommit applies the changes commit made relative to its first parent
+// to files, returning the paths that had changed differently in files.
+func applyCommit(repo *git.Repository, commit *object.Commit, files map[string]treeFile) ([]string, error) {
+	parent, err := repo.CommitObject(commit.ParentHashes[0])
+	if err != nil {
+		return nil, fmt.Errorf("read commit %s: %w", commit.ParentHashes[0], err)
+	}
+	before, err := commitFiles(parent)
+	if err != nil {
+		return nil, err
+	}
+	after, err := commitFiles(commit)
+	if err != nil {
+		return nil, err
+	}
+
+	var conflicts []string
+	apply := func(path string, from, to treeFile, fromOK, toOK bool) {
+		cur, curOK := files[path]
+		switch {
+		case curOK == toOK && cur == to:
+			// Already changed the same way
+		case curOK == fromOK && cur == from:
+			if toOK {
+				files[path] = to
+			} else {
+				delete(files, path)
+			}
+		default:
+			conflicts = append(conflicts, path)
+		}
+	}
+	for path, to := range after {
+		if from, ok := before[path]; !ok || from != to {
+			apply(path, from, to, ok, true)
+		}
+	}
+	for path, from := range before {
+		if _, ok := after[path]; !ok {
+			apply(path, from, treeFile{}, true, false)
+		}
+	}
+
+	sort.Strings(conflicts)
+	return conflicts, nil
+}
+
+// commitFiles flattens the tree of a commit to its non-directory entries.
+func commitFiles(commit *object.Commit) (map[string]treeFile, error) {
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("read tree of commit %s: %w", commit.Hash, err)
+	}
+
+	files := make(map[string]treeFile)
+	walker := object.NewTreeWalker(tree, true, nil)
+	defer walker.Close()
+	for {
+		name, entry, err := walker.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("walk tree of commit %s: %w", commit.Hash, err)
+		}
+		if entry.Mode != filemode.Dir {
+			files[name] = treeFile{hash: entry.Hash, mode: entry.Mode}
+		}
+	}
+	return files, nil
+}
+
+// writeTree stores the nested tree objects for files and returns the hash
+// of the root tree.
+func writeTree(s storer.EncodedObjectStorer, files map[string]treeFile) (plumbing.Hash, error) {
+	tree := &object.Tree{}
+	subdirs := make(map[string]map[string]treeFile)
+	for path, file := range files {
+		dir, rest, nested := strings.Cut(path, "/")
+		if !nested {
+			tree.Entries = append(tree.Entries, object.TreeEntry{Name: path, Mode: file.mode, Hash: file.hash})
+			continue
+		}
+		if subdirs[dir] == nil {
+			subdirs[dir] = make(map[string]treeFile)
+		}
+		subdirs[dir][rest] = file
+	}
+	for dir, subfiles := range subdirs {
+		hash, err := writeTree(s, subfiles)
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		tree.Entries = append(tree.Entries, object.TreeEntry{Name: dir, Mode: filemode.Dir, Hash: hash})
+	}
+
+	// git orders entries as if directory names ended in a slash
+	sortKey := func(entry object.TreeEntry) string {
+		if entry.Mode == filemode.Dir {
+			return entry.Name + "/"
+		}
+		return entry.Name
+	}
+	sort.Slice(tree.Entries, func(i, j int) bool {
+		return sortKey(tree.Entries[i]) < sortKey(tree.Entries[j])
+	})
+
+	obj := s.NewEncodedObject()
+	if err := tree.Encode(obj); err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("encode tree: %w", err)
+	}
+	hash, err := s.SetEncodedObject(obj)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("store tree: %w", err)
+	}
+	return hash, nil
+}
+
+// writeCommit stores a copy of commit with the given tree and parent. The
+// author is kept; the commit time is updated as git rebase does.
+func writeCommit(s storer.EncodedObjectStorer, commit *object.Commit, tree, parent plumbing.Hash) (plumbing.Hash, error) {
+	committer := commit.Committer
+	committer.When = time.Now()
+
+	rewritten := &object.Commit{
+		Author:       commit.Author,
+		Committer:    committer,
+		Message:      commit.Message,
+		TreeHash:     tree,
+		ParentHashes: []plumbing.Hash{parent},
+	}
+
+	obj := s.NewEncodedObject()
+	if err := rewritten.Encode(obj); err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("encode commit: %w", err)
+	}
+	hash, err := s.SetEncodedObject(obj)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("store commit: %w", err)
+	}
+	return hash, nil
+}
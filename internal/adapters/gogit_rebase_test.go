@@ -0,0 +1,158 @@
+package adapters
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cloneMachineBranch clones a fresh origin onto the local branch "laptop".
+func cloneMachineBranch(t *testing.T) (*git.Repository, string, *git.Repository, string) {
+	t.Helper()
+	ctx := context.Background()
+	originPath := filepath.Join(t.TempDir(), "origin")
+	clonePath := filepath.Join(t.TempDir(), "clone")
+
+	origin, err := git.PlainInit(originPath, false)
+	require.NoError(t, err)
+	commitFile(t, origin, originPath, "vim/dot-vimrc", "set nocompatible")
+
+	cloner := NewGoGitCloner()
+	require.NoError(t, cloner.Clone(ctx, originPath, clonePath, CloneOptions{}))
+	require.NoError(t, cloner.CheckoutBranch(ctx, clonePath, "laptop"))
+	clone, err := git.PlainOpen(clonePath)
+	require.NoError(t, err)
+	return origin, originPath, clone, clonePath
+}
+
+func TestGoGitCloner_CheckoutBranch_New(t *testing.T) {
+	_, _, clone, _ := cloneMachineBranch(t)
+
+	head, err := clone.Head()
+	require.NoError(t, err)
+	assert.Equal(t, "refs/heads/laptop", head.Name().String())
+}
+
+func TestGoGitCloner_CheckoutBranch_TracksRemote(t *testing.T) {
+	ctx := context.Background()
+	origin, originPath, clone, clonePath := cloneMachineBranch(t)
+	machineCommit := commitFile(t, clone, clonePath, "zsh/dot-zshrc", "export EDITOR=vim")
+	require.NoError(t, clone.PushContext(ctx, &git.PushOptions{}))
+	_, err := origin.Reference("refs/heads/laptop", true)
+	require.NoError(t, err)
+
+	secondPath := filepath.Join(t.TempDir(), "second")
+	cloner := NewGoGitCloner()
+	require.NoError(t, cloner.Clone(ctx, originPath, secondPath, CloneOptions{}))
+	require.NoError(t, cloner.CheckoutBranch(ctx, secondPath, "laptop"))
+
+	second, err := git.PlainOpen(secondPath)
+	require.NoError(t, err)
+	head, err := second.Head()
+	require.NoError(t, err)
+	assert.Equal(t, machineCommit, head.Hash().String())
+
+	cfg, err := second.Config()
+	require.NoError(t, err)
+	require.Contains(t, cfg.Branches, "laptop")
+	assert.Equal(t, "origin", cfg.Branches["laptop"].Remote)
+}
+
+func TestGoGitPuller_Rebase(t *testing.T) {
+	ctx := context.Background()
+	origin, originPath, clone, clonePath := cloneMachineBranch(t)
+	commitFile(t, clone, clonePath, "zsh/dot-zshrc", "export EDITOR=vim")
+	shared := commitFile(t, origin, originPath, "git/dot-gitconfig", "[user]")
+
+	puller := NewGoGitPuller()
+	result, err := puller.Rebase(ctx, clonePath, "master", PullOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Replayed)
+	assert.Empty(t, result.Conflicts)
+
+	head, err := clone.Head()
+	require.NoError(t, err)
+	assert.Equal(t, "refs/heads/laptop", head.Name().String())
+	commit, err := clone.CommitObject(head.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, "update zsh/dot-zshrc", commit.Message)
+	require.Len(t, commit.ParentHashes, 1)
+	assert.Equal(t, shared, commit.ParentHashes[0].String())
+
+	// Both sides' files are checked out
+	assert.FileExists(t, filepath.Join(clonePath, "zsh", "dot-zshrc"))
+	assert.FileExists(t, filepath.Join(clonePath, "git", "dot-gitconfig"))
+
+	// Rebasing again finds nothing to do
+	result, err = puller.Rebase(ctx, clonePath, "master", PullOptions{})
+	require.NoError(t, err)
+	assert.Zero(t, result.Replayed)
+	again, err := clone.Head()
+	require.NoError(t, err)
+	assert.Equal(t, head.Hash(), again.Hash())
+}
+
+func TestGoGitPuller_Rebase_FastForward(t *testing.T) {
+	ctx := context.Background()
+	origin, originPath, clone, clonePath := cloneMachineBranch(t)
+	shared := commitFile(t, origin, originPath, "git/dot-gitconfig", "[user]")
+
+	result, err := NewGoGitPuller().Rebase(ctx, clonePath, "master", PullOptions{})
+	require.NoError(t, err)
+	assert.Zero(t, result.Replayed)
+
+	head, err := clone.Head()
+	require.NoError(t, err)
+	assert.Equal(t, shared, head.Hash().String())
+	assert.Equal(t, "refs/heads/laptop", head.Name().String())
+}
+
+func TestGoGitPuller_Rebase_Conflict(t *testing.T) {
+	ctx := context.Background()
+	origin, originPath, clone, clonePath := cloneMachineBranch(t)
+	local := commitFile(t, clone, clonePath, "vim/dot-vimrc", "set number")
+	commitFile(t, origin, originPath, "vim/dot-vimrc", "set relativenumber")
+
+	result, err := NewGoGitPuller().Rebase(ctx, clonePath, "master", PullOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"vim/dot-vimrc"}, result.Conflicts)
+	assert.Zero(t, result.Replayed)
+
+	// The branch is left untouched
+	head, err := clone.Head()
+	require.NoError(t, err)
+	assert.Equal(t, local, head.Hash().String())
+	content, err := os.ReadFile(filepath.Join(clonePath, "vim", "dot-vimrc"))
+	require.NoError(t, err)
+	assert.Equal(t, "set number", string(content))
+}
+
+func TestGoGitPuller_Rebase_SameChangeIsNotConflict(t *testing.T) {
+	ctx := context.Background()
+	origin, originPath, clone, clonePath := cloneMachineBranch(t)
+	commitFile(t, clone, clonePath, "vim/dot-vimrc", "set number")
+	shared := commitFile(t, origin, originPath, "vim/dot-vimrc", "set number")
+
+	result, err := NewGoGitPuller().Rebase(ctx, clonePath, "master", PullOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, result.Conflicts)
+	assert.Zero(t, result.Replayed)
+
+	head, err := clone.Head()
+	require.NoError(t, err)
+	assert.Equal(t, shared, head.Hash().String())
+}
+
+func TestGoGitPuller_Rebase_DirtyWorktree(t *testing.T) {
+	ctx := context.Background()
+	_, _, _, clonePath := cloneMachineBranch(t)
+	require.NoError(t, os.WriteFile(filepath.Join(clonePath, "vim", "dot-vimrc"), []byte("set number"), 0644))
+
+	_, err := NewGoGitPuller().Rebase(ctx, clonePath, "master", PullOptions{})
+	assert.ErrorContains(t, err, "uncommitted changes")
+}
@@ -0,0 +1,274 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// GoGitRepository implements GitRepository using go-git library.
+type GoGitRepository struct {
+	transport TransportOptions
+}
+
+// NewGoGitRepository creates a new go-git based repository adapter.
+func NewGoGitRepository() *GoGitRepository {
+	return &GoGitRepository{}
+}
+
+// NewGoGitRepositoryWithTransport creates a go-git based repository adapter
+// that applies the given timeout, proxy, and TLS settings to every push.
+func NewGoGitRepositoryWithTransport(opts TransportOptions) *GoGitRepository {
+	return &GoGitRepository{transport: opts}
+}
+
+// Status reports the state of the checkout using go-git.
+func (g *GoGitRepository) Status(ctx context.Context, path string) (RepoStatus, error) {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return RepoStatus{}, fmt.Errorf("open repository: %w", err)
+	}
+
+	var status RepoStatus
+	if remote, err := repo.Remote(git.DefaultRemoteName); err == nil && len(remote.Config().URLs) > 0 {
+		status.RemoteURL = remote.Config().URLs[0]
+	}
+
+	head, err := repo.Head()
+	switch {
+	case errors.Is(err, plumbing.ErrReferenceNotFound):
+		// No commits yet
+	case err != nil:
+		return RepoStatus{}, fmt.Errorf("resolve HEAD: %w", err)
+	default:
+		status.Head = head.Hash().String()
+		if head.Name().IsBranch() {
+			status.Branch = head.Name().Short()
+		}
+	}
+
+	if status.Branch != "" {
+		if err := upstreamDivergence(repo, status.Branch, head.Hash(), &status); err != nil {
+			return RepoStatus{}, err
+		}
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return RepoStatus{}, fmt.Errorf("open worktree: %w", err)
+	}
+	fileStatus, err := worktree.Status()
+	if err != nil {
+		return RepoStatus{}, fmt.Errorf("read worktree status: %w", err)
+	}
+	for file, s := range fileStatus {
+		if change := fileChangeStatus(s); change != "" {
+			status.Changes = append(status.Changes, FileChange{Path: file, Status: change})
+		}
+	}
+	sort.Slice(status.Changes, func(i, j int) bool {
+		return status.Changes[i].Path < status.Changes[j].Path
+	})
+
+	return status, nil
+}
+
+// upstreamDivergence fills in the upstream of branch and how far the local
+// commit has diverged from it. The upstream is the branch's configured merge
+// target, falling back to the same-named branch on origin.
+func upstreamDivergence(repo *git.Repository, branch string, local plumbing.Hash, status *RepoStatus) error {
+	remoteName, remoteBranch := git.DefaultRemoteName, branch
+	if cfg, err := repo.Config(); err == nil {
+		if tracking, ok := cfg.Branches[branch]; ok && tracking.Remote != "" && tracking.Merge.IsBranch() {
+			remoteName, remoteBranch = tracking.Remote, tracking.Merge.Short()
+		}
+	}
+
+	ref, err := repo.Reference(plumbing.NewRemoteReferenceName(remoteName, remoteBranch), true)
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("resolve upstream: %w", err)
+	}
+	status.Upstream = remoteName + "/" + remoteBranch
+
+	if ref.Hash() == local {
+		return nil
+	}
+	localCommits, err := ancestors(repo, local)
+	if err != nil {
+		return err
+	}
+	upstreamCommits, err := ancestors(repo, ref.Hash())
+	if err != nil {
+		return err
+	}
+	for hash := range localCommits {
+		if !upstreamCommits[hash] {
+			status.Ahead++
+		}
+	}
+	for hash := range upstreamCommits {
+		if !localCommits[hash] {
+			status.Behind++
+		}
+	}
+	return nil
+}
+
+// ancestors returns the commit and every ancestor present locally.
+// Missing parents, such as those beyond a shallow boundary, are skipped.
+func ancestors(repo *git.Repository, start plumbing.Hash) (map[plumbing.Hash]bool, error) {
+	seen := map[plumbing.Hash]bool{start: true}
+	queue := []plumbing.Hash{start}
+	for len(queue) > 0 {
+		hash := queue[0]
+		queue = queue[1:]
+
+		commit, err := repo.CommitObject(hash)
+		if errors.Is(err, plumbing.ErrObjectNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read commit %s: %w", hash, err)
+		}
+		for _, parent := range commit.ParentHashes {
+			if !seen[parent] {
+				seen[parent] = true
+				queue = append(queue, parent)
+			}
+		}
+	}
+	return seen, nil
+}
+
+// fileChangeStatus describes a go-git file status, preferring the worktree
+// state over the staged state. Unmodified files yield an empty string.
+func fileChangeStatus(s *git.FileStatus) string {
+	code := s.Worktree
+	if code == git.Unmodified {
+		code = s.Staging
+	}
+	switch code {
+	case git.Untracked:
+		return "untracked"
+	case git.Added, git.Copied:
+		return "added"
+	case git.Deleted:
+		return "deleted"
+	case git.Renamed:
+		return "renamed"
+	case git.Modified, git.UpdatedButUnmerged:
+		return "modified"
+	default:
+		return ""
+	}
+}
+
+// Push pushes the checked-out branch to origin using go-git.
+func (g *GoGitRepository) Push(ctx context.Context, path string, opts PushOptions) error {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return fmt.Errorf("open repository: %w", err)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return fmt.Errorf("resolve HEAD: %w", err)
+	}
+	if !head.Name().IsBranch() {
+		return fmt.Errorf("cannot push detached HEAD")
+	}
+
+	auth, err := convertAuthMethod(opts.Auth)
+	if err != nil {
+		return fmt.Errorf("configure authentication: %w", err)
+	}
+
+	pushOpts := &git.PushOptions{
+		RemoteName:      git.DefaultRemoteName,
+		RefSpecs:        []config.RefSpec{config.RefSpec(head.Name() + ":" + head.Name())},
+		Auth:            auth,
+		Progress:        opts.Progress,
+		Force:           opts.Force,
+		InsecureSkipTLS: g.transport.InsecureSkipTLS,
+		ProxyOptions:    g.transport.proxyOptions(),
+	}
+
+	ctx, cancel := g.transport.withTimeout(ctx)
+	defer cancel()
+
+	err = repo.PushContext(ctx, pushOpts)
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return fmt.Errorf("push repository: %w", err)
+	}
+
+	return nil
+}
+
+// SetRemoteURL replaces the URLs of the remote in the repository config.
+func (g *GoGitRepository) SetRemoteURL(ctx context.Context, path string, remote string, url string) error {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return fmt.Errorf("open repository: %w", err)
+	}
+
+	cfg, err := repo.Config()
+	if err != nil {
+		return fmt.Errorf("read repository config: %w", err)
+	}
+
+	remoteCfg, ok := cfg.Remotes[remote]
+	if !ok {
+		return fmt.Errorf("remote %q not found", remote)
+	}
+	remoteCfg.URLs = []string{url}
+
+	if err := repo.Storer.SetConfig(cfg); err != nil {
+		return fmt.Errorf("write repository config: %w", err)
+	}
+
+	return nil
+}
+
+// TrackedFiles lists the files of the tree committed at HEAD.
+func (g *GoGitRepository) TrackedFiles(ctx context.Context, path string) ([]string, error) {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return nil, fmt.Errorf("open repository: %w", err)
+	}
+
+	head, err := repo.Head()
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("resolve HEAD: %w", err)
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("read commit: %w", err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("read tree: %w", err)
+	}
+
+	files := []string{}
+	err = tree.Files().ForEach(func(f *object.File) error {
+		files = append(files, f.Name)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list files: %w", err)
+	}
+	sort.Strings(files)
+	return files, nil
+}
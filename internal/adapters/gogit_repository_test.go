@@ -0,0 +1,160 @@
+package adapters
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cloneTestRepository creates an origin repository with one commit and
+// clones it, returning the origin, its path, and the clone path.
+func cloneTestRepository(t *testing.T) (*git.Repository, string, string) {
+	t.Helper()
+	ctx := context.Background()
+	originPath := filepath.Join(t.TempDir(), "origin")
+	clonePath := filepath.Join(t.TempDir(), "clone")
+
+	origin, err := git.PlainInit(originPath, false)
+	require.NoError(t, err)
+	commitFile(t, origin, originPath, "vim/dot-vimrc", "set nocompatible")
+
+	require.NoError(t, NewGoGitCloner().Clone(ctx, originPath, clonePath, CloneOptions{}))
+	return origin, originPath, clonePath
+}
+
+func TestGoGitRepository_Status_Clean(t *testing.T) {
+	ctx := context.Background()
+	_, originPath, clonePath := cloneTestRepository(t)
+
+	status, err := NewGoGitRepository().Status(ctx, clonePath)
+	require.NoError(t, err)
+
+	assert.Equal(t, "master", status.Branch)
+	assert.NotEmpty(t, status.Head)
+	assert.Equal(t, originPath, status.RemoteURL)
+	assert.Equal(t, "origin/master", status.Upstream)
+	assert.Zero(t, status.Ahead)
+	assert.Zero(t, status.Behind)
+	assert.Empty(t, status.Changes)
+}
+
+func TestGoGitRepository_Status_Changes(t *testing.T) {
+	ctx := context.Background()
+	_, _, clonePath := cloneTestRepository(t)
+
+	require.NoError(t, os.WriteFile(filepath.Join(clonePath, "vim", "dot-vimrc"), []byte("set number"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(clonePath, "zsh"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(clonePath, "zsh", "dot-zshrc"), []byte("export EDITOR=vim"), 0644))
+
+	status, err := NewGoGitRepository().Status(ctx, clonePath)
+	require.NoError(t, err)
+
+	assert.Equal(t, []FileChange{
+		{Path: "vim/dot-vimrc", Status: "modified"},
+		{Path: "zsh/dot-zshrc", Status: "untracked"},
+	}, status.Changes)
+}
+
+func TestGoGitRepository_Status_AheadBehind(t *testing.T) {
+	ctx := context.Background()
+	origin, originPath, clonePath := cloneTestRepository(t)
+
+	clone, err := git.PlainOpen(clonePath)
+	require.NoError(t, err)
+	commitFile(t, clone, clonePath, "zsh/dot-zshrc", "export EDITOR=vim")
+
+	commitFile(t, origin, originPath, "git/dot-gitconfig", "[user]")
+	commitFile(t, origin, originPath, "tmux/dot-tmux.conf", "set -g mouse on")
+	require.NoError(t, clone.FetchContext(ctx, &git.FetchOptions{}))
+
+	status, err := NewGoGitRepository().Status(ctx, clonePath)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, status.Ahead)
+	assert.Equal(t, 2, status.Behind)
+}
+
+func TestGoGitRepository_Push(t *testing.T) {
+	ctx := context.Background()
+	_, originPath, _ := cloneTestRepository(t)
+
+	// Pushing to a checked-out branch is refused, so push to a bare remote
+	barePath := filepath.Join(t.TempDir(), "bare")
+	bare, err := git.PlainClone(barePath, true, &git.CloneOptions{URL: originPath})
+	require.NoError(t, err)
+	clonePath := filepath.Join(t.TempDir(), "clone")
+	require.NoError(t, NewGoGitCloner().Clone(ctx, barePath, clonePath, CloneOptions{}))
+
+	clone, err := git.PlainOpen(clonePath)
+	require.NoError(t, err)
+	pushed := commitFile(t, clone, clonePath, "zsh/dot-zshrc", "export EDITOR=vim")
+
+	repository := NewGoGitRepository()
+	require.NoError(t, repository.Push(ctx, clonePath, PushOptions{}))
+
+	ref, err := bare.Reference("refs/heads/master", true)
+	require.NoError(t, err)
+	assert.Equal(t, pushed, ref.Hash().String())
+
+	// Pushing again is a no-op
+	require.NoError(t, repository.Push(ctx, clonePath, PushOptions{}))
+
+	status, err := repository.Status(ctx, clonePath)
+	require.NoError(t, err)
+	assert.Zero(t, status.Ahead)
+}
+
+func TestGoGitRepository_SetRemoteURL(t *testing.T) {
+	ctx := context.Background()
+	_, _, clonePath := cloneTestRepository(t)
+	repository := NewGoGitRepository()
+
+	require.NoError(t, repository.SetRemoteURL(ctx, clonePath, "origin", "https://example.com/dotfiles.git"))
+
+	status, err := repository.Status(ctx, clonePath)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/dotfiles.git", status.RemoteURL)
+
+	err = repository.SetRemoteURL(ctx, clonePath, "upstream", "https://example.com/dotfiles.git")
+	assert.ErrorContains(t, err, `remote "upstream" not found`)
+}
+
+func TestGoGitRepository_TrackedFiles(t *testing.T) {
+	ctx := context.Background()
+	origin, originPath, _ := cloneTestRepository(t)
+	commitFile(t, origin, originPath, "zsh/dot-zshrc", "export EDITOR=vim")
+
+	// yadm keeps its repository bare, without a worktree
+	barePath := filepath.Join(t.TempDir(), "repo.git")
+	_, err := git.PlainClone(barePath, true, &git.CloneOptions{URL: originPath})
+	require.NoError(t, err)
+
+	files, err := NewGoGitRepository().TrackedFiles(ctx, barePath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"vim/dot-vimrc", "zsh/dot-zshrc"}, files)
+
+	emptyPath := filepath.Join(t.TempDir(), "empty.git")
+	_, err = git.PlainInit(emptyPath, true)
+	require.NoError(t, err)
+	files, err = NewGoGitRepository().TrackedFiles(ctx, emptyPath)
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestGoGitRepository_NotARepository(t *testing.T) {
+	ctx := context.Background()
+	repository := NewGoGitRepository()
+	dir := t.TempDir()
+
+	_, err := repository.Status(ctx, dir)
+	assert.Error(t, err)
+	assert.Error(t, repository.Push(ctx, dir, PushOptions{}))
+	assert.Error(t, repository.SetRemoteURL(ctx, dir, "origin", "https://example.com"))
+	_, err = repository.TrackedFiles(ctx, dir)
+	assert.Error(t, err)
+}
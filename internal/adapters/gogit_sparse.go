@@ -0,0 +1,205 @@
+package adapters
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+)
+
+// sparseCheckoutFile holds the patterns of a sparse checkout, relative to
+// the .git directory. It is written in git's cone format so that the git
+// command line honors the same checkout.
+const sparseCheckoutFile = "info/sparse-checkout"
+
+// checkoutSparse checks out HEAD of a clone made without checkout,
+// limited to dirs and the files at the root of the repository, and
+// records the sparse checkout.
+func checkoutSparse(repo *git.Repository, path string, dirs []string) error {
+	head, err := repo.Head()
+	if err != nil {
+		return fmt.Errorf("resolve HEAD: %w", err)
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return fmt.Errorf("read HEAD commit: %w", err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return fmt.Errorf("read HEAD tree: %w", err)
+	}
+
+	patterns := make([]string, 0, len(dirs)+len(tree.Entries))
+	for _, dir := range dirs {
+		patterns = append(patterns, sparsePrefix(dir))
+	}
+	for _, entry := range tree.Entries {
+		if entry.Mode != filemode.Dir {
+			patterns = append(patterns, entry.Name)
+		}
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("open worktree: %w", err)
+	}
+	if err := worktree.Checkout(&git.CheckoutOptions{Branch: head.Name(), SparseCheckoutDirectories: patterns}); err != nil {
+		return fmt.Errorf("sparse checkout: %w", err)
+	}
+	return writeSparseCheckout(repo, path, dirs)
+}
+
+// SparseDirectories returns the directories checked out in the sparse
+// repository at path, or nil if the repository is not a sparse checkout.
+func (g *GoGitCloner) SparseDirectories(ctx context.Context, path string) ([]string, error) {
+	f, err := os.Open(filepath.Join(path, git.GitDirName, sparseCheckoutFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read sparse checkout: %w", err)
+	}
+	defer f.Close()
+
+	dirs := []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line == "/*" || line == "!/*/" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		dirs = append(dirs, strings.Trim(line, "/"))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read sparse checkout: %w", err)
+	}
+	return dirs, nil
+}
+
+// AddSparseDirectories checks out dirs in the sparse repository at path.
+// Files already checked out, including local changes to them, are left
+// as they are.
+func (g *GoGitCloner) AddSparseDirectories(ctx context.Context, path string, dirs []string) error {
+	current, err := g.SparseDirectories(ctx, path)
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		return fmt.Errorf("repository at %s is not a sparse checkout", path)
+	}
+
+	var added []string
+	for _, dir := range dirs {
+		dir = strings.Trim(filepath.ToSlash(dir), "/")
+		if dir != "" && !slices.Contains(current, dir) && !slices.Contains(added, dir) {
+			added = append(added, dir)
+		}
+	}
+	if len(added) == 0 {
+		return nil
+	}
+
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return fmt.Errorf("open repository: %w", err)
+	}
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return fmt.Errorf("read index: %w", err)
+	}
+	var files []string
+	for _, entry := range idx.Entries {
+		if !entry.SkipWorktree {
+			continue
+		}
+		for _, dir := range added {
+			if strings.HasPrefix(entry.Name, sparsePrefix(dir)) {
+				entry.SkipWorktree = false
+				files = append(files, entry.Name)
+				break
+			}
+		}
+	}
+	if err := repo.Storer.SetIndex(idx); err != nil {
+		return fmt.Errorf("write index: %w", err)
+	}
+
+	if len(files) > 0 {
+		worktree, err := repo.Worktree()
+		if err != nil {
+			return fmt.Errorf("open worktree: %w", err)
+		}
+		if err := worktree.Reset(&git.ResetOptions{Mode: git.HardReset, Files: files}); err != nil {
+			return fmt.Errorf("check out %s: %w", strings.Join(added, ", "), err)
+		}
+	}
+	return writeSparseCheckout(repo, path, append(current, added...))
+}
+
+// RepositoryDirectories returns the top-level directories tracked in the
+// index of the repository at path, sorted. Directories left out of a
+// sparse checkout are included.
+func (g *GoGitCloner) RepositoryDirectories(ctx context.Context, path string) ([]string, error) {
+	repo, err := git.PlainOpen(path)
+	if err != nil {
+		return nil, fmt.Errorf("open repository: %w", err)
+	}
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, fmt.Errorf("read index: %w", err)
+	}
+
+	dirs := []string{}
+	for _, entry := range idx.Entries {
+		dir, _, found := strings.Cut(entry.Name, "/")
+		if found && !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	slices.Sort(dirs)
+	return dirs, nil
+}
+
+// writeSparseCheckout records dirs as the sparse checkout of the
+// repository at path and enables sparse checkout in its configuration.
+func writeSparseCheckout(repo *git.Repository, path string, dirs []string) error {
+	dirs = slices.Clone(dirs)
+	slices.Sort(dirs)
+
+	var b strings.Builder
+	b.WriteString("/*\n!/*/\n")
+	for _, dir := range dirs {
+		b.WriteString("/" + sparsePrefix(dir) + "\n")
+	}
+	file := filepath.Join(path, git.GitDirName, sparseCheckoutFile)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return fmt.Errorf("write sparse checkout: %w", err)
+	}
+	if err := os.WriteFile(file, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("write sparse checkout: %w", err)
+	}
+
+	cfg, err := repo.Config()
+	if err != nil {
+		return fmt.Errorf("read repository config: %w", err)
+	}
+	core := cfg.Raw.Section("core")
+	core.SetOption("sparseCheckout", "true")
+	core.SetOption("sparseCheckoutCone", "true")
+	if err := repo.SetConfig(cfg); err != nil {
+		return fmt.Errorf("write repository config: %w", err)
+	}
+	return nil
+}
+
+// sparsePrefix returns the prefix of the repository paths inside dir.
+func sparsePrefix(dir string) string {
+	return strings.Trim(filepath.ToSlash(dir), "/") + "/"
+}
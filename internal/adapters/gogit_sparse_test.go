@@ -0,0 +1,99 @@
+package adapters
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoGitCloner_SparseClone(t *testing.T) {
+	ctx := context.Background()
+	cloner := NewGoGitCloner()
+	path := filepath.Join(t.TempDir(), "repo")
+
+	err := cloner.Clone(ctx, getTestRepoURL(t), path, CloneOptions{
+		Auth:              NoAuth{},
+		Depth:             1,
+		SparseDirectories: []string{"dot-vim"},
+	})
+	require.NoError(t, err)
+
+	assert.FileExists(t, filepath.Join(path, ".dotbootstrap.yaml"), "root files are checked out")
+	assert.DirExists(t, filepath.Join(path, "dot-vim"))
+	assert.NoDirExists(t, filepath.Join(path, "dot-zsh"))
+
+	dirs, err := cloner.SparseDirectories(ctx, path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dot-vim"}, dirs)
+
+	data, err := os.ReadFile(filepath.Join(path, ".git", "info", "sparse-checkout"))
+	require.NoError(t, err)
+	assert.Equal(t, "/*\n!/*/\n/dot-vim/\n", string(data))
+}
+
+func TestGoGitCloner_AddSparseDirectories(t *testing.T) {
+	ctx := context.Background()
+	cloner := NewGoGitCloner()
+	path := filepath.Join(t.TempDir(), "repo")
+	require.NoError(t, cloner.Clone(ctx, getTestRepoURL(t), path, CloneOptions{
+		Auth:              NoAuth{},
+		Depth:             1,
+		SparseDirectories: []string{"dot-vim"},
+	}))
+
+	// A local change in a checked-out directory survives the extension
+	entries, err := os.ReadDir(filepath.Join(path, "dot-vim"))
+	require.NoError(t, err)
+	require.NotEmpty(t, entries)
+	edited := filepath.Join(path, "dot-vim", entries[0].Name())
+	require.NoError(t, os.WriteFile(edited, []byte("local edit"), 0644))
+
+	require.NoError(t, cloner.AddSparseDirectories(ctx, path, []string{"dot-zsh", "dot-vim"}))
+
+	assert.FileExists(t, filepath.Join(path, "dot-zsh", "zshrc"))
+	assert.NoDirExists(t, filepath.Join(path, "dot-git"))
+	data, err := os.ReadFile(edited)
+	require.NoError(t, err)
+	assert.Equal(t, "local edit", string(data))
+
+	dirs, err := cloner.SparseDirectories(ctx, path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dot-vim", "dot-zsh"}, dirs)
+}
+
+func TestGoGitCloner_SparseDirectories_FullClone(t *testing.T) {
+	ctx := context.Background()
+	cloner := NewGoGitCloner()
+	path := filepath.Join(t.TempDir(), "repo")
+	require.NoError(t, cloner.Clone(ctx, getTestRepoURL(t), path, CloneOptions{Auth: NoAuth{}, Depth: 1}))
+
+	dirs, err := cloner.SparseDirectories(ctx, path)
+	require.NoError(t, err)
+	assert.Nil(t, dirs)
+
+	err = cloner.AddSparseDirectories(ctx, path, []string{"dot-vim"})
+	assert.ErrorContains(t, err, "not a sparse checkout")
+}
+
+func TestGoGitCloner_RepositoryDirectories(t *testing.T) {
+	ctx := context.Background()
+	cloner := NewGoGitCloner()
+	path := filepath.Join(t.TempDir(), "repo")
+	require.NoError(t, cloner.Clone(ctx, getTestRepoURL(t), path, CloneOptions{
+		Auth:              NoAuth{},
+		Depth:             1,
+		SparseDirectories: []string{".config/dot"},
+	}))
+	assert.NoDirExists(t, filepath.Join(path, "dot-vim"))
+
+	dirs, err := cloner.RepositoryDirectories(ctx, path)
+	require.NoError(t, err)
+	assert.Contains(t, dirs, "dot-vim")
+	assert.Contains(t, dirs, "dot-zsh")
+	assert.True(t, slices.IsSorted(dirs))
+}
@@ -0,0 +1,73 @@
+package adapters
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"github.com/jamesainslie/dot/internal/domain"
+)
+
+// contextHandler adds the log fields carried by the context of a record,
+// as set with domain.WithLogFields, to the record. Fields the record or
+// the logger already has are left alone.
+type contextHandler struct {
+	next slog.Handler
+
+	// bound are the keys of the attributes bound with WithAttrs.
+	bound []string
+}
+
+// NewContextHandler wraps next so that records are given the plan ID,
+// package and operation ID of their context.
+func NewContextHandler(next slog.Handler) slog.Handler {
+	if h, ok := next.(*contextHandler); ok {
+		return h
+	}
+	return &contextHandler{next: next}
+}
+
+// Enabled reports whether next handles records at level.
+func (h *contextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle adds the log fields of ctx to r and passes it to next.
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	fields := domain.LogFieldsFromContext(ctx)
+	if fields == (domain.LogFields{}) {
+		return h.next.Handle(ctx, r)
+	}
+
+	present := slices.Clone(h.bound)
+	r.Attrs(func(a slog.Attr) bool {
+		present = append(present, a.Key)
+		return true
+	})
+	r = r.Clone()
+	for _, field := range []slog.Attr{
+		slog.String(LogFieldPlanID, fields.PlanID),
+		slog.String(LogFieldPackage, fields.Package),
+		slog.String(LogFieldOpID, fields.OperationID),
+	} {
+		if field.Value.String() != "" && !slices.Contains(present, field.Key) {
+			r.AddAttrs(field)
+		}
+	}
+	return h.next.Handle(ctx, r)
+}
+
+// WithAttrs returns a handler binding attrs, whose keys are no longer
+// taken from the context.
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	bound := slices.Clone(h.bound)
+	for _, a := range attrs {
+		bound = append(bound, a.Key)
+	}
+	return &contextHandler{next: h.next.WithAttrs(attrs), bound: bound}
+}
+
+// WithGroup returns a handler nesting the attributes that follow in name.
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{next: h.next.WithGroup(name), bound: h.bound}
+}
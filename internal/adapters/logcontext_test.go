@@ -0,0 +1,68 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/domain"
+)
+
+// logRecords returns the JSON records written to buf.
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		require.NoError(t, ValidateLogRecord(line))
+		var record map[string]any
+		require.NoError(t, json.Unmarshal(line, &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestContextHandler_AddsLogFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewJSONLogger(&buf, slog.LevelDebug)
+
+	ctx := domain.WithLogFields(context.Background(), domain.LogFields{PlanID: "plan-1", Package: "vim"})
+	opCtx := domain.WithLogFields(ctx, domain.LogFields{OperationID: "link-1"})
+	logger.Info(opCtx, "operation_succeeded")
+	logger.Info(ctx, "package_planned")
+	logger.Info(context.Background(), "loading_config")
+
+	records := logRecords(t, &buf)
+	require.Len(t, records, 3)
+	assert.Equal(t, "plan-1", records[0][LogFieldPlanID])
+	assert.Equal(t, "vim", records[0][LogFieldPackage])
+	assert.Equal(t, "link-1", records[0][LogFieldOpID])
+	assert.Equal(t, "vim", records[1][LogFieldPackage])
+	assert.NotContains(t, records[1], LogFieldOpID)
+	assert.NotContains(t, records[2], LogFieldPlanID)
+	assert.NotContains(t, records[2], LogFieldPackage)
+}
+
+func TestContextHandler_KeepsExplicitFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewJSONLogger(&buf, slog.LevelDebug).With(LogFieldPlanID, "checkpoint-1")
+
+	ctx := domain.WithLogFields(context.Background(), domain.LogFields{PlanID: "plan-1", Package: "vim", OperationID: "link-1"})
+	logger.Info(ctx, "operation_failed", LogFieldOpID, "link-2")
+
+	// Fields are not repeated, and those given to the logger win
+	line := bytes.TrimSpace(buf.Bytes())
+	assert.Equal(t, 1, bytes.Count(line, []byte(`"plan_id"`)))
+	assert.Equal(t, 1, bytes.Count(line, []byte(`"op_id"`)))
+	records := logRecords(t, &buf)
+	assert.Equal(t, "checkpoint-1", records[0][LogFieldPlanID])
+	assert.Equal(t, "link-2", records[0][LogFieldOpID])
+	assert.Equal(t, "vim", records[0][LogFieldPackage])
+
+	h := NewContextHandler(slog.NewTextHandler(&buf, nil))
+	assert.Same(t, h, NewContextHandler(h))
+}
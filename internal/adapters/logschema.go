@@ -0,0 +1,87 @@
+package adapters
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+)
+
+// LogSchemaVersion is the version of the JSON log record layout written by
+// NewJSONLogger. It changes only when a field is removed or its meaning or
+// type changes; new fields may be added within a version.
+const LogSchemaVersion = 1
+
+// Fields of JSON log records. Time, level, event and schema version are
+// present in every record; component, plan ID, package and operation ID
+// are present when the record concerns them.
+const (
+	LogFieldTime      = "time"
+	LogFieldLevel     = "level"
+	LogFieldEvent     = "event"
+	LogFieldSchema    = "schema_version"
+	LogFieldComponent = "component"
+	LogFieldPlanID    = "plan_id"
+	LogFieldPackage   = "package"
+	LogFieldOpID      = "op_id"
+)
+
+var (
+	// eventPattern matches event types, which are snake_case identifiers.
+	eventPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+	// levelPattern matches slog level names, which carry an offset for
+	// levels between the named ones, such as DEBUG-2.
+	levelPattern = regexp.MustCompile(`^(DEBUG|INFO|WARN|ERROR)([+-][0-9]+)?$`)
+)
+
+// NewJSONLogger creates a logger writing one JSON object per line to w,
+// following the log schema of LogSchemaVersion.
+func NewJSONLogger(w io.Writer, level slog.Leveler) *SlogLogger {
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.MessageKey {
+				a.Key = LogFieldEvent
+			}
+			return a
+		},
+	})
+	return NewSlogLogger(slog.New(handler).With(LogFieldSchema, LogSchemaVersion))
+}
+
+// ValidateLogRecord checks that line is a JSON log record of the current
+// schema.
+func ValidateLogRecord(line []byte) error {
+	var record map[string]any
+	if err := json.Unmarshal(line, &record); err != nil {
+		return fmt.Errorf("log record is not a JSON object: %w", err)
+	}
+
+	for _, field := range []string{LogFieldTime, LogFieldLevel, LogFieldEvent} {
+		if _, ok := record[field].(string); !ok {
+			return fmt.Errorf("log record field %s must be a string", field)
+		}
+	}
+	if level := record[LogFieldLevel].(string); !levelPattern.MatchString(level) {
+		return fmt.Errorf("log record level %q is not a known level", level)
+	}
+	if event := record[LogFieldEvent].(string); !eventPattern.MatchString(event) {
+		return fmt.Errorf("log record event %q is not snake_case", event)
+	}
+	if version, ok := record[LogFieldSchema].(float64); !ok || version != LogSchemaVersion {
+		return fmt.Errorf("log record schema_version must be %d, got %v", LogSchemaVersion, record[LogFieldSchema])
+	}
+
+	for _, field := range []string{LogFieldComponent, LogFieldPlanID, LogFieldPackage, LogFieldOpID} {
+		value, present := record[field]
+		if !present {
+			continue
+		}
+		if s, ok := value.(string); !ok || s == "" {
+			return fmt.Errorf("log record field %s must be a non-empty string", field)
+		}
+	}
+	return nil
+}
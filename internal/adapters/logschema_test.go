@@ -0,0 +1,106 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewJSONLogger_Schema(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewJSONLogger(&buf, slog.LevelDebug).With("component", "executor", "plan_id", "plan-1")
+
+	logger.Info(context.Background(), "operation_succeeded", "op_id", "link-1", "duration_ms", 3)
+
+	line := bytes.TrimSpace(buf.Bytes())
+	require.NoError(t, ValidateLogRecord(line))
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(line, &record))
+	assert.Equal(t, "operation_succeeded", record[LogFieldEvent])
+	assert.NotContains(t, record, slog.MessageKey)
+	assert.Equal(t, "INFO", record[LogFieldLevel])
+	assert.InDelta(t, LogSchemaVersion, record[LogFieldSchema], 0)
+	assert.Equal(t, "executor", record[LogFieldComponent])
+	assert.Equal(t, "plan-1", record[LogFieldPlanID])
+	assert.Equal(t, "link-1", record[LogFieldOpID])
+	assert.InDelta(t, 3, record["duration_ms"], 0)
+}
+
+func TestNewJSONLogger_VerboseLevels(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewJSONLogger(&buf, slog.LevelDebug-2)
+
+	logger.logger.Log(context.Background(), slog.LevelDebug-1, "trace_event")
+
+	assert.NoError(t, ValidateLogRecord(bytes.TrimSpace(buf.Bytes())))
+}
+
+func TestValidateLogRecord(t *testing.T) {
+	tests := []struct {
+		name    string
+		record  string
+		wantErr string
+	}{
+		{
+			name:   "minimal record",
+			record: `{"time":"2026-01-02T03:04:05Z","level":"WARN","event":"manifest_read_failed","schema_version":1}`,
+		},
+		{
+			name:    "not JSON",
+			record:  `level=INFO msg=hello`,
+			wantErr: "not a JSON object",
+		},
+		{
+			name:    "message instead of event",
+			record:  `{"time":"2026-01-02T03:04:05Z","level":"INFO","msg":"hello","schema_version":1}`,
+			wantErr: "field event",
+		},
+		{
+			name:    "event not snake_case",
+			record:  `{"time":"2026-01-02T03:04:05Z","level":"INFO","event":"failed to read","schema_version":1}`,
+			wantErr: "not snake_case",
+		},
+		{
+			name:    "unknown level",
+			record:  `{"time":"2026-01-02T03:04:05Z","level":"TRACE","event":"hello","schema_version":1}`,
+			wantErr: "not a known level",
+		},
+		{
+			name:    "missing schema version",
+			record:  `{"time":"2026-01-02T03:04:05Z","level":"INFO","event":"hello"}`,
+			wantErr: "schema_version",
+		},
+		{
+			name:    "newer schema version",
+			record:  `{"time":"2026-01-02T03:04:05Z","level":"INFO","event":"hello","schema_version":2}`,
+			wantErr: "schema_version",
+		},
+		{
+			name:    "empty plan ID",
+			record:  `{"time":"2026-01-02T03:04:05Z","level":"INFO","event":"hello","schema_version":1,"plan_id":""}`,
+			wantErr: "plan_id",
+		},
+		{
+			name:    "numeric component",
+			record:  `{"time":"2026-01-02T03:04:05Z","level":"INFO","event":"hello","schema_version":1,"component":3}`,
+			wantErr: "component",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateLogRecord([]byte(tt.record))
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
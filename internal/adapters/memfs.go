@@ -144,6 +144,36 @@ func (f *MemFS) WriteFile(ctx context.Context, name string, data []byte, perm fs
 	return nil
 }
 
+// AppendFile appends data to a file, creating it if needed. Hard links
+// to the file see the appended data.
+func (f *MemFS) AppendFile(ctx context.Context, name string, data []byte, perm fs.FileMode) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, exists := f.files[name]
+	if !exists {
+		parent := filepath.Dir(name)
+		if parent != "." && parent != "/" {
+			if _, exists := f.files[parent]; !exists {
+				return fs.ErrNotExist
+			}
+		}
+		f.files[name] = &memFile{
+			data:    append([]byte(nil), data...),
+			mode:    perm,
+			modTime: time.Now(),
+		}
+		return nil
+	}
+	if file.isDir {
+		return fs.ErrInvalid
+	}
+
+	file.data = append(file.data[:len(file.data):len(file.data)], data...)
+	file.modTime = time.Now()
+	return nil
+}
+
 func (f *MemFS) Mkdir(ctx context.Context, name string, perm fs.FileMode) error {
 	f.mu.Lock()
 	defer f.mu.Unlock()
@@ -259,6 +289,79 @@ func (f *MemFS) Symlink(ctx context.Context, oldname, newname string) error {
 	return nil
 }
 
+// Link creates newname as a hard link to oldname. Both names share the
+// same file until one of them is written with WriteFile, which replaces
+// the file rather than changing it in place.
+func (f *MemFS) Link(ctx context.Context, oldname, newname string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, exists := f.files[oldname]
+	if !exists {
+		return fs.ErrNotExist
+	}
+	if file.isDir {
+		return fs.ErrInvalid
+	}
+	if _, exists := f.files[newname]; exists {
+		return fs.ErrExist
+	}
+	parent := filepath.Dir(newname)
+	if parent != "." && parent != "/" {
+		if _, exists := f.files[parent]; !exists {
+			return fs.ErrNotExist
+		}
+	}
+
+	f.files[newname] = file
+	return nil
+}
+
+// LinkCount returns the number of names sharing the file at name.
+func (f *MemFS) LinkCount(ctx context.Context, name string) (uint64, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	file, exists := f.files[name]
+	if !exists {
+		return 0, fs.ErrNotExist
+	}
+	var count uint64
+	for _, other := range f.files {
+		if other == file {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Chmod changes the permission bits of a file, keeping its type.
+func (f *MemFS) Chmod(ctx context.Context, name string, mode fs.FileMode) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, exists := f.files[name]
+	if !exists {
+		return fs.ErrNotExist
+	}
+	file.mode = file.mode&^fs.ModePerm | mode.Perm()
+	return nil
+}
+
+// Chtimes changes the modification time of a file. MemFS keeps no access
+// time, so atime is ignored.
+func (f *MemFS) Chtimes(ctx context.Context, name string, atime, mtime time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, exists := f.files[name]
+	if !exists {
+		return fs.ErrNotExist
+	}
+	file.modTime = mtime
+	return nil
+}
+
 func (f *MemFS) Rename(ctx context.Context, oldname, newname string) error {
 	f.mu.Lock()
 	defer f.mu.Unlock()
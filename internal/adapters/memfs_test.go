@@ -42,6 +42,23 @@ func TestMemFS_WriteFile_ParentNotExist(t *testing.T) {
 	require.ErrorIs(t, err, fs.ErrNotExist)
 }
 
+func TestMemFS_AppendFile(t *testing.T) {
+	ctx := context.Background()
+	mfs := NewMemFS()
+	require.NoError(t, mfs.MkdirAll(ctx, "/home", 0755))
+
+	require.NoError(t, mfs.AppendFile(ctx, "/home/log", []byte("one\n"), 0600))
+	require.NoError(t, mfs.Link(ctx, "/home/log", "/home/link"))
+	require.NoError(t, mfs.AppendFile(ctx, "/home/log", []byte("two\n"), 0600))
+
+	data, err := mfs.ReadFile(ctx, "/home/link")
+	require.NoError(t, err)
+	require.Equal(t, "one\ntwo\n", string(data))
+
+	err = mfs.AppendFile(ctx, "/missing/log", []byte("x"), 0600)
+	require.ErrorIs(t, err, fs.ErrNotExist)
+}
+
 func TestMemFS_ReadFile_NotExist(t *testing.T) {
 	ctx := context.Background()
 	mfs := NewMemFS()
@@ -459,3 +476,28 @@ func TestMemFS_ConcurrentAccess(t *testing.T) {
 
 	// No test assertion - just verify no panics
 }
+
+func TestMemFS_Link(t *testing.T) {
+	ctx := context.Background()
+	mfs := NewMemFS()
+
+	require.NoError(t, mfs.MkdirAll(ctx, "/mail/cur", 0755))
+	require.NoError(t, mfs.WriteFile(ctx, "/mail/cur/msg", []byte("hello"), 0644))
+	require.NoError(t, mfs.Link(ctx, "/mail/cur/msg", "/mail/all"))
+
+	count, err := mfs.LinkCount(ctx, "/mail/all")
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), count)
+
+	data, err := mfs.ReadFile(ctx, "/mail/all")
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(data))
+
+	require.NoError(t, mfs.Remove(ctx, "/mail/cur/msg"))
+	count, err = mfs.LinkCount(ctx, "/mail/all")
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), count)
+
+	require.Error(t, mfs.Link(ctx, "/missing", "/mail/other"))
+	require.Error(t, mfs.Link(ctx, "/mail/cur", "/mail/dir"), "directories cannot be hard linked")
+}
@@ -0,0 +1,203 @@
+package adapters
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jamesainslie/dot/internal/domain"
+)
+
+// MetricKind is the type of a metric family.
+type MetricKind int
+
+const (
+	// MetricCounter is a monotonically increasing value.
+	MetricCounter MetricKind = iota
+	// MetricGauge is a value that can go up or down.
+	MetricGauge
+	// MetricHistogram is a distribution of observed values.
+	MetricHistogram
+)
+
+// DefaultBuckets are the upper bounds of histogram buckets. They cover
+// durations in seconds as well as counts such as plan sizes.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 25, 50, 100, 250, 500, 1000}
+
+// MetricFamily is a snapshot of the series of one metric.
+type MetricFamily struct {
+	// Name is the name the metric was registered with, such as
+	// "executor.duration.seconds".
+	Name string
+
+	// Kind is the type of the metric.
+	Kind MetricKind
+
+	// Labels are the label names of the metric.
+	Labels []string
+
+	// Series holds a value for each combination of label values, sorted
+	// by label values.
+	Series []MetricSeries
+}
+
+// MetricSeries is the value of a metric for one combination of label
+// values.
+type MetricSeries struct {
+	// LabelValues holds a value for each label of the family.
+	LabelValues []string
+
+	// Value is the value of a counter or gauge.
+	Value float64
+
+	// Count, Sum and Buckets describe a histogram. Buckets holds the
+	// number of observations in each bucket of Bounds, not cumulative,
+	// followed by those above the last bound.
+	Count   uint64
+	Sum     float64
+	Bounds  []float64
+	Buckets []uint64
+}
+
+// MetricsRegistry is a metrics collector that keeps metrics in memory, so
+// exporters can send them when a command finishes.
+//
+// Metrics are created with label names, and values are recorded with label
+// values in the same order. Missing label values are recorded as empty and
+// extra ones are ignored. A metric keeps the kind and labels it was first
+// created with. The registry is safe for concurrent use.
+type MetricsRegistry struct {
+	mu       sync.Mutex
+	families map[string]*metricFamily
+	start    time.Time
+}
+
+// metricFamily holds the series of one metric, keyed by joined label
+// values.
+type metricFamily struct {
+	kind   MetricKind
+	labels []string
+	series map[string]*MetricSeries
+}
+
+// NewMetricsRegistry creates an empty metrics registry.
+func NewMetricsRegistry() *MetricsRegistry {
+	return &MetricsRegistry{
+		families: make(map[string]*metricFamily),
+		start:    time.Now(),
+	}
+}
+
+// Start returns when the registry was created, which is when its counters
+// started counting.
+func (r *MetricsRegistry) Start() time.Time {
+	return r.start
+}
+
+func (r *MetricsRegistry) Counter(name string, labels ...string) domain.Counter {
+	return &registryMetric{registry: r, family: r.family(name, MetricCounter, labels)}
+}
+
+func (r *MetricsRegistry) Histogram(name string, labels ...string) domain.Histogram {
+	return &registryMetric{registry: r, family: r.family(name, MetricHistogram, labels)}
+}
+
+func (r *MetricsRegistry) Gauge(name string, labels ...string) domain.Gauge {
+	return &registryMetric{registry: r, family: r.family(name, MetricGauge, labels)}
+}
+
+// family returns the family of name, creating it if needed.
+func (r *MetricsRegistry) family(name string, kind MetricKind, labels []string) *metricFamily {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if f, ok := r.families[name]; ok {
+		return f
+	}
+	f := &metricFamily{kind: kind, labels: slices.Clone(labels), series: make(map[string]*MetricSeries)}
+	r.families[name] = f
+	return f
+}
+
+// Snapshot returns a copy of every metric, sorted by name.
+func (r *MetricsRegistry) Snapshot() []MetricFamily {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	families := make([]MetricFamily, 0, len(r.families))
+	for name, f := range r.families {
+		family := MetricFamily{Name: name, Kind: f.kind, Labels: slices.Clone(f.labels)}
+		for _, s := range f.series {
+			series := *s
+			series.LabelValues = slices.Clone(s.LabelValues)
+			series.Buckets = slices.Clone(s.Buckets)
+			family.Series = append(family.Series, series)
+		}
+		sort.Slice(family.Series, func(i, j int) bool {
+			return slices.Compare(family.Series[i].LabelValues, family.Series[j].LabelValues) < 0
+		})
+		families = append(families, family)
+	}
+	sort.Slice(families, func(i, j int) bool { return families[i].Name < families[j].Name })
+	return families
+}
+
+// registryMetric records values of one family.
+type registryMetric struct {
+	registry *MetricsRegistry
+	family   *metricFamily
+}
+
+// update applies fn to the series of the label values under the registry
+// lock.
+func (m *registryMetric) update(values []string, fn func(s *MetricSeries)) {
+	normalized := make([]string, len(m.family.labels))
+	copy(normalized, values)
+	key := strings.Join(normalized, "\xff")
+
+	m.registry.mu.Lock()
+	defer m.registry.mu.Unlock()
+	s, ok := m.family.series[key]
+	if !ok {
+		s = &MetricSeries{LabelValues: normalized}
+		if m.family.kind == MetricHistogram {
+			s.Bounds = DefaultBuckets
+			s.Buckets = make([]uint64, len(DefaultBuckets)+1)
+		}
+		m.family.series[key] = s
+	}
+	fn(s)
+}
+
+func (m *registryMetric) Inc(labels ...string) {
+	m.update(labels, func(s *MetricSeries) { s.Value++ })
+}
+
+func (m *registryMetric) Add(delta float64, labels ...string) {
+	m.update(labels, func(s *MetricSeries) { s.Value += delta })
+}
+
+func (m *registryMetric) Set(value float64, labels ...string) {
+	m.update(labels, func(s *MetricSeries) { s.Value = value })
+}
+
+func (m *registryMetric) Dec(labels ...string) {
+	m.update(labels, func(s *MetricSeries) { s.Value-- })
+}
+
+func (m *registryMetric) Observe(value float64, labels ...string) {
+	m.update(labels, func(s *MetricSeries) {
+		s.Count++
+		s.Sum += value
+		i := sort.SearchFloat64s(s.Bounds, value)
+		s.Buckets[i]++
+	})
+}
+
+// TelemetryExporter sends collected metrics and spans to a backend.
+// Exporters that do not support traces ignore the spans.
+type TelemetryExporter interface {
+	Export(ctx context.Context, metrics []MetricFamily, spans []SpanData) error
+}
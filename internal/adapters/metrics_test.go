@@ -0,0 +1,109 @@
+package adapters_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/internal/domain"
+)
+
+func TestMetricsRegistry(t *testing.T) {
+	registry := adapters.NewMetricsRegistry()
+
+	ops := registry.Counter("executor.operations.executed", "kind")
+	ops.Inc("link_create")
+	ops.Add(2, "link_create")
+	ops.Inc("dir_create")
+	registry.Gauge("executor.operations.queued").Set(7)
+	registry.Gauge("executor.operations.queued").Dec()
+
+	duration := registry.Histogram("executor.duration.seconds")
+	duration.Observe(0.003)
+	duration.Observe(0.2)
+	duration.Observe(5000)
+
+	families := registry.Snapshot()
+	require.Len(t, families, 3)
+
+	assert.Equal(t, "executor.duration.seconds", families[0].Name)
+	assert.Equal(t, adapters.MetricHistogram, families[0].Kind)
+	hist := families[0].Series[0]
+	assert.Equal(t, uint64(3), hist.Count)
+	assert.InDelta(t, 5000.203, hist.Sum, 1e-9)
+	assert.Equal(t, uint64(1), hist.Buckets[0], "0.003 is in the first bucket")
+	assert.Equal(t, uint64(1), hist.Buckets[len(hist.Buckets)-1], "5000 is above the last bound")
+
+	assert.Equal(t, "executor.operations.executed", families[1].Name)
+	assert.Equal(t, []string{"kind"}, families[1].Labels)
+	require.Len(t, families[1].Series, 2)
+	assert.Equal(t, []string{"dir_create"}, families[1].Series[0].LabelValues)
+	assert.Equal(t, 1.0, families[1].Series[0].Value)
+	assert.Equal(t, 3.0, families[1].Series[1].Value)
+
+	assert.Equal(t, adapters.MetricGauge, families[2].Kind)
+	assert.Equal(t, 6.0, families[2].Series[0].Value)
+}
+
+func TestMetricsRegistry_LabelValues(t *testing.T) {
+	registry := adapters.NewMetricsRegistry()
+	counter := registry.Counter("rollbacks", "trigger", "result")
+
+	// Missing values are empty, extra ones are ignored
+	counter.Inc("failure")
+	counter.Inc("failure", "", "extra")
+
+	series := registry.Snapshot()[0].Series
+	require.Len(t, series, 1)
+	assert.Equal(t, []string{"failure", ""}, series[0].LabelValues)
+	assert.Equal(t, 2.0, series[0].Value)
+}
+
+func TestMetricsRegistry_Concurrent(t *testing.T) {
+	registry := adapters.NewMetricsRegistry()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				registry.Counter("ops").Inc()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1000.0, registry.Snapshot()[0].Series[0].Value)
+}
+
+func TestRecordingTracer(t *testing.T) {
+	tracer := adapters.NewRecordingTracer()
+	ctx := context.Background()
+
+	ctx, root := tracer.Start(ctx, "executor.execute", func(c *domain.SpanConfig) {
+		c.Attributes = append(c.Attributes, domain.Attribute{Key: "operations", Value: 3})
+	})
+	_, child := tracer.Start(ctx, "executor.operation")
+	child.RecordError(errors.New("permission denied"))
+	child.RecordError(errors.New("ignored"))
+	child.End()
+	root.SetAttributes(domain.Attribute{Key: "failed", Value: 1})
+	root.End()
+	root.End()
+
+	spans := tracer.Spans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "executor.operation", spans[0].Name)
+	assert.Equal(t, "permission denied", spans[0].Err)
+	assert.Equal(t, spans[1].TraceID, spans[0].TraceID)
+	assert.Equal(t, spans[1].SpanID, spans[0].ParentSpanID)
+	assert.Len(t, spans[1].TraceID, 32)
+	assert.Len(t, spans[1].SpanID, 16)
+	assert.Empty(t, spans[1].ParentSpanID)
+	assert.Len(t, spans[1].Attributes, 2)
+	assert.False(t, spans[1].End.Before(spans[1].Start))
+}
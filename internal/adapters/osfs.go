@@ -3,20 +3,152 @@ package adapters
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io/fs"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/jamesainslie/dot/internal/domain"
 )
 
 // OSFilesystem implements the FS interface using the os package.
-type OSFilesystem struct{}
+type OSFilesystem struct {
+	owner *fileOwner
+}
+
+// fileOwner is the user and group given the files created under dirs.
+type fileOwner struct {
+	uid, gid int
+	dirs     []string
+}
 
 // NewOSFilesystem creates a new OS filesystem adapter.
 func NewOSFilesystem() *OSFilesystem {
 	return &OSFilesystem{}
 }
 
+// WithOwner returns a filesystem like f that gives the files, directories
+// and links it creates under dirs to user uid and group gid, as when root
+// manages the home directory of another user. Existing files keep their
+// owner. Changing the owner of a file needs privileges the process must
+// already have.
+//
+// The user controls what is under dirs, so changes there are made as
+// them: on Linux the thread making a change takes their filesystem user
+// and group, and the kernel checks the change as it would for them.
+// Paths under dirs going through a symbolic link are refused, as are
+// writes to a file that is a link, so that a link the user plants cannot
+// send a write elsewhere.
+func (f *OSFilesystem) WithOwner(uid, gid int, dirs ...string) *OSFilesystem {
+	owner := &fileOwner{uid: uid, gid: gid}
+	for _, dir := range dirs {
+		owner.dirs = append(owner.dirs, filepath.Clean(dir))
+	}
+	return &OSFilesystem{owner: owner}
+}
+
+// owns reports whether files created at name are given to the owner.
+func (f *OSFilesystem) owns(name string) bool {
+	return f.ownedDir(name) != ""
+}
+
+// ownedDir returns the outermost owned directory holding name, or "".
+func (f *OSFilesystem) ownedDir(name string) string {
+	if f.owner == nil {
+		return ""
+	}
+	name = filepath.Clean(name)
+	owned := ""
+	for _, dir := range f.owner.dirs {
+		if name == dir || strings.HasPrefix(name, dir+string(filepath.Separator)) {
+			if owned == "" || len(dir) < len(owned) {
+				owned = dir
+			}
+		}
+	}
+	return owned
+}
+
+// change runs fn, which changes the files at names, as the owner when one
+// of names is under the owned directories, after checking that none of
+// them goes through a symbolic link there. With leaf set, the names
+// themselves must not be links either, as for changes that follow them.
+func (f *OSFilesystem) change(op string, leaf bool, fn func() error, names ...string) error {
+	owned := false
+	for _, name := range names {
+		if !f.owns(name) {
+			continue
+		}
+		owned = true
+		if err := f.checkLinks(op, name, leaf); err != nil {
+			return err
+		}
+	}
+	if !owned {
+		return fn()
+	}
+	return runAs(f.owner.uid, f.owner.gid, fn)
+}
+
+// checkLinks refuses name when a directory between the owned directory
+// holding it and name is a symbolic link, or when name is one and leaf is
+// set. Directories that do not exist yet are created by the change.
+func (f *OSFilesystem) checkLinks(op, name string, leaf bool) error {
+	name = filepath.Clean(name)
+	dir := f.ownedDir(name)
+	rel, err := filepath.Rel(dir, name)
+	if err != nil || rel == "." {
+		return err
+	}
+
+	parts := strings.Split(rel, string(filepath.Separator))
+	if !leaf {
+		parts = parts[:len(parts)-1]
+	}
+	path := dir
+	for _, part := range parts {
+		path = filepath.Join(path, part)
+		info, err := os.Lstat(path)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.Mode()&fs.ModeSymlink != 0 {
+			return &fs.PathError{Op: op, Path: name, Err: fmt.Errorf("%w: %s", errOwnedSymlink, path)}
+		}
+	}
+	return nil
+}
+
+// errOwnedSymlink is the error of changes refused by checkLinks.
+var errOwnedSymlink = errors.New("refusing to follow a symbolic link in a directory of the target user")
+
+// chown gives the file just created at name to the owner.
+func (f *OSFilesystem) chown(name string) error {
+	if !f.owns(name) {
+		return nil
+	}
+	if err := os.Lchown(name, f.owner.uid, f.owner.gid); err != nil {
+		return fmt.Errorf("set owner of %s: %w", name, err)
+	}
+	return nil
+}
+
+// created reports whether nothing exists at name yet, so that writing it
+// creates a file to give to the owner.
+func (f *OSFilesystem) created(name string) bool {
+	if !f.owns(name) {
+		return false
+	}
+	_, err := os.Lstat(name)
+	return os.IsNotExist(err)
+}
+
 // Stat returns file information.
 func (f *OSFilesystem) Stat(ctx context.Context, name string) (domain.FileInfo, error) {
 	if err := ctx.Err(); err != nil {
@@ -74,7 +206,54 @@ func (f *OSFilesystem) WriteFile(ctx context.Context, name string, data []byte,
 		return err
 	}
 
-	return os.WriteFile(name, data, perm)
+	if !f.owns(name) {
+		return os.WriteFile(name, data, perm)
+	}
+	return f.change("open", true, func() error {
+		created := f.created(name)
+		if err := writeFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC|openNoFollow, data, perm); err != nil {
+			return err
+		}
+		if created {
+			return f.chown(name)
+		}
+		return nil
+	}, name)
+}
+
+// writeFile writes data to the file name opened with flag.
+func writeFile(name string, flag int, data []byte, perm fs.FileMode) error {
+	file, err := os.OpenFile(name, flag, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// AppendFile appends data to a file, creating it if needed.
+func (f *OSFilesystem) AppendFile(ctx context.Context, name string, data []byte, perm fs.FileMode) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	flag := os.O_WRONLY | os.O_APPEND | os.O_CREATE
+	if !f.owns(name) {
+		return writeFile(name, flag, data, perm)
+	}
+	return f.change("open", true, func() error {
+		created := f.created(name)
+		if err := writeFile(name, flag|openNoFollow, data, perm); err != nil {
+			return err
+		}
+		if created {
+			return f.chown(name)
+		}
+		return nil
+	}, name)
 }
 
 // Mkdir creates a directory.
@@ -83,7 +262,12 @@ func (f *OSFilesystem) Mkdir(ctx context.Context, name string, perm fs.FileMode)
 		return err
 	}
 
-	return os.Mkdir(name, perm)
+	return f.change("mkdir", false, func() error {
+		if err := os.Mkdir(name, perm); err != nil {
+			return err
+		}
+		return f.chown(name)
+	}, name)
 }
 
 // MkdirAll creates a directory tree.
@@ -92,7 +276,23 @@ func (f *OSFilesystem) MkdirAll(ctx context.Context, name string, perm fs.FileMo
 		return err
 	}
 
-	return os.MkdirAll(name, perm)
+	return f.change("mkdir", false, func() error {
+		// Find the directories MkdirAll creates, outermost first
+		var missing []string
+		for dir := filepath.Clean(name); f.created(dir); dir = filepath.Dir(dir) {
+			missing = append([]string{dir}, missing...)
+		}
+
+		if err := os.MkdirAll(name, perm); err != nil {
+			return err
+		}
+		for _, dir := range missing {
+			if err := f.chown(dir); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, name)
 }
 
 // Remove removes a file or empty directory.
@@ -101,7 +301,7 @@ func (f *OSFilesystem) Remove(ctx context.Context, name string) error {
 		return err
 	}
 
-	return os.Remove(name)
+	return f.change("remove", false, func() error { return os.Remove(name) }, name)
 }
 
 // RemoveAll removes a directory tree.
@@ -110,7 +310,7 @@ func (f *OSFilesystem) RemoveAll(ctx context.Context, name string) error {
 		return err
 	}
 
-	return os.RemoveAll(name)
+	return f.change("remove", false, func() error { return os.RemoveAll(name) }, name)
 }
 
 // Symlink creates a symbolic link.
@@ -119,7 +319,53 @@ func (f *OSFilesystem) Symlink(ctx context.Context, oldname, newname string) err
 		return err
 	}
 
-	return os.Symlink(oldname, newname)
+	return f.change("symlink", false, func() error {
+		if err := os.Symlink(oldname, newname); err != nil {
+			return err
+		}
+		return f.chown(newname)
+	}, newname)
+}
+
+// Chmod changes the mode of a file.
+func (f *OSFilesystem) Chmod(ctx context.Context, name string, mode fs.FileMode) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return f.change("chmod", true, func() error { return os.Chmod(name, mode) }, name)
+}
+
+// Chtimes changes the access and modification times of a file.
+func (f *OSFilesystem) Chtimes(ctx context.Context, name string, atime, mtime time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return f.change("chtimes", true, func() error { return os.Chtimes(name, atime, mtime) }, name)
+}
+
+// Link creates newname as a hard link to oldname.
+func (f *OSFilesystem) Link(ctx context.Context, oldname, newname string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return f.change("link", false, func() error { return os.Link(oldname, newname) }, newname)
+}
+
+// LinkCount returns the number of hard links to a file, or 0 on platforms
+// that do not report it.
+func (f *OSFilesystem) LinkCount(ctx context.Context, name string) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	info, err := os.Stat(name)
+	if err != nil {
+		return 0, err
+	}
+	return linkCount(info), nil
 }
 
 // Rename moves or renames a file.
@@ -128,7 +374,7 @@ func (f *OSFilesystem) Rename(ctx context.Context, oldname, newname string) erro
 		return err
 	}
 
-	return os.Rename(oldname, newname)
+	return f.change("rename", false, func() error { return os.Rename(oldname, newname) }, oldname, newname)
 }
 
 // Exists checks if a path exists.
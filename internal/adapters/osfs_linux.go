@@ -0,0 +1,62 @@
+//go:build linux
+
+package adapters
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+
+	"golang.org/x/sys/unix"
+)
+
+// runAs runs fn on a thread whose filesystem user and group are uid and
+// gid, with gid as its only supplementary group, so that the kernel checks
+// what fn opens as that user and gives them what it creates. Only the
+// thread is changed; it is restored before other goroutines may use it,
+// and dropped when it cannot be.
+func runAs(uid, gid int, fn func() error) error {
+	if uid == os.Geteuid() && gid == os.Getegid() {
+		return fn()
+	}
+	runtime.LockOSThread()
+
+	groups, err := unix.Getgroups()
+	if err != nil {
+		runtime.UnlockOSThread()
+		return fmt.Errorf("read supplementary groups: %w", err)
+	}
+	prevUID, _ := unix.SetfsuidRetUid(-1)
+	prevGID, _ := unix.SetfsgidRetGid(-1)
+	if err := switchFSUser(uid, gid, []int{gid}); err != nil {
+		// Leaving the thread locked makes the runtime discard it when
+		// the goroutine exits
+		return err
+	}
+
+	runErr := fn()
+
+	if err := switchFSUser(prevUID, prevGID, groups); err != nil {
+		return err
+	}
+	runtime.UnlockOSThread()
+	return runErr
+}
+
+// switchFSUser sets the filesystem user, group and supplementary groups of
+// the current thread. setfsuid and setfsgid report no error, so the ids
+// are read back to check them.
+func switchFSUser(uid, gid int, groups []int) error {
+	if err := unix.Setgroups(groups); err != nil {
+		return fmt.Errorf("set supplementary groups: %w", err)
+	}
+	_, _ = unix.SetfsgidRetGid(gid)
+	_, _ = unix.SetfsuidRetUid(uid)
+	if got, _ := unix.SetfsgidRetGid(-1); got != gid {
+		return fmt.Errorf("set filesystem group %d: got %d", gid, got)
+	}
+	if got, _ := unix.SetfsuidRetUid(-1); got != uid {
+		return fmt.Errorf("set filesystem user %d: got %d", uid, got)
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package adapters
+
+import "os"
+
+// openNoFollow is not available on this platform; the symbolic link
+// checks of owned paths are made before opening instead.
+const openNoFollow = 0
+
+// linkCount reports 0 because this platform's stat data carries no hard
+// link count.
+func linkCount(info os.FileInfo) uint64 {
+	return 0
+}
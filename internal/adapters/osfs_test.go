@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/jamesainslie/dot/internal/adapters"
 	"github.com/stretchr/testify/assert"
@@ -92,6 +93,36 @@ func TestOSFilesystem_WriteFile(t *testing.T) {
 	assert.Equal(t, content, data)
 }
 
+func TestOSFilesystem_AppendFile(t *testing.T) {
+	ctx := context.Background()
+	fsys := adapters.NewOSFilesystem()
+
+	tmpFile := filepath.Join(t.TempDir(), "log")
+	require.NoError(t, fsys.AppendFile(ctx, tmpFile, []byte("one\n"), 0600))
+	require.NoError(t, fsys.AppendFile(ctx, tmpFile, []byte("two\n"), 0600))
+
+	data, err := os.ReadFile(tmpFile)
+	require.NoError(t, err)
+	assert.Equal(t, "one\ntwo\n", string(data))
+}
+
+func TestOSFilesystem_ChmodChtimes(t *testing.T) {
+	ctx := context.Background()
+	fsys := adapters.NewOSFilesystem()
+
+	tmpFile := filepath.Join(t.TempDir(), "test.txt")
+	require.NoError(t, os.WriteFile(tmpFile, []byte("x"), 0644))
+
+	mtime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, fsys.Chmod(ctx, tmpFile, 0600))
+	require.NoError(t, fsys.Chtimes(ctx, tmpFile, mtime, mtime))
+
+	info, err := os.Stat(tmpFile)
+	require.NoError(t, err)
+	assert.Equal(t, fs.FileMode(0600), info.Mode().Perm())
+	assert.True(t, mtime.Equal(info.ModTime()))
+}
+
 func TestOSFilesystem_Mkdir(t *testing.T) {
 	ctx := context.Background()
 	fsys := adapters.NewOSFilesystem()
@@ -305,3 +336,24 @@ func TestOSDirEntryWrapper(t *testing.T) {
 	assert.Equal(t, "test.txt", info.Name())
 	assert.False(t, info.IsDir())
 }
+
+func TestOSFilesystem_LinkCount(t *testing.T) {
+	ctx := context.Background()
+	fsys := adapters.NewOSFilesystem()
+
+	tmpDir := t.TempDir()
+	original := filepath.Join(tmpDir, "original")
+	require.NoError(t, os.WriteFile(original, []byte("test"), 0644))
+
+	count, err := fsys.LinkCount(ctx, original)
+	require.NoError(t, err)
+	if count == 0 {
+		t.Skip("platform does not report hard link counts")
+	}
+	assert.Equal(t, uint64(1), count)
+
+	require.NoError(t, fsys.Link(ctx, original, filepath.Join(tmpDir, "link")))
+	count, err = fsys.LinkCount(ctx, original)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), count)
+}
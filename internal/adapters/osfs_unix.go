@@ -0,0 +1,20 @@
+//go:build unix
+
+package adapters
+
+import (
+	"os"
+	"syscall"
+)
+
+// openNoFollow makes opening a symbolic link fail instead of opening what
+// it points to.
+const openNoFollow = syscall.O_NOFOLLOW
+
+// linkCount reads the hard link count from the stat data of info.
+func linkCount(info os.FileInfo) uint64 {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(st.Nlink)
+	}
+	return 0
+}
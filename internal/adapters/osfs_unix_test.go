@@ -0,0 +1,107 @@
+//go:build unix
+
+package adapters_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ownerOf returns the user and group owning path, without following links.
+func ownerOf(t *testing.T, path string) (int, int) {
+	t.Helper()
+	info, err := os.Lstat(path)
+	require.NoError(t, err)
+	st := info.Sys().(*syscall.Stat_t)
+	return int(st.Uid), int(st.Gid)
+}
+
+func TestOSFilesystem_WithOwner(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("changing file owners requires root")
+	}
+	ctx := context.Background()
+	home := t.TempDir()
+	outside := t.TempDir()
+	const uid, gid = 65534, 65534
+	fsys := adapters.NewOSFilesystem().WithOwner(uid, gid, home)
+
+	// Changes in the home are made as the user, who owns it
+	require.NoError(t, os.Chmod(filepath.Dir(home), 0755))
+	require.NoError(t, os.Chown(home, uid, gid))
+	existing := filepath.Join(home, "existing")
+	require.NoError(t, os.WriteFile(existing, []byte("root's"), 0666))
+	require.NoError(t, os.Chmod(existing, 0666))
+
+	require.NoError(t, fsys.MkdirAll(ctx, filepath.Join(home, ".config", "app"), 0755))
+	require.NoError(t, fsys.WriteFile(ctx, filepath.Join(home, ".config", "app", "rc"), []byte("rc"), 0644))
+	require.NoError(t, fsys.Symlink(ctx, "/pkg/vim/dot-vimrc", filepath.Join(home, ".vimrc")))
+	require.NoError(t, fsys.WriteFile(ctx, existing, []byte("updated"), 0644))
+	require.NoError(t, fsys.WriteFile(ctx, filepath.Join(outside, "state"), []byte("x"), 0644))
+
+	for _, path := range []string{".config", ".config/app", ".config/app/rc", ".vimrc"} {
+		gotUID, gotGID := ownerOf(t, filepath.Join(home, path))
+		assert.Equal(t, uid, gotUID, path)
+		assert.Equal(t, gid, gotGID, path)
+	}
+
+	gotUID, _ := ownerOf(t, existing)
+	assert.Equal(t, 0, gotUID, "existing files keep their owner")
+	gotUID, _ = ownerOf(t, filepath.Join(outside, "state"))
+	assert.Equal(t, 0, gotUID, "files outside the owned directories keep the process owner")
+
+	// Files the user could not change are not changed for them
+	private := filepath.Join(home, "private")
+	require.NoError(t, os.WriteFile(private, []byte("root's"), 0600))
+	assert.ErrorIs(t, fsys.WriteFile(ctx, private, []byte("x"), 0644), os.ErrPermission)
+	require.NoError(t, fsys.WriteFile(ctx, filepath.Join(outside, "private"), []byte("x"), 0600))
+}
+
+func TestOSFilesystem_WithOwnerRefusesSymlinks(t *testing.T) {
+	ctx := context.Background()
+	home := t.TempDir()
+	outside := t.TempDir()
+	fsys := adapters.NewOSFilesystem().WithOwner(os.Getuid(), os.Getgid(), home)
+
+	victim := filepath.Join(outside, "passwd")
+	require.NoError(t, os.WriteFile(victim, []byte("root:x:0:0"), 0644))
+	state := filepath.Join(home, ".local", "state", "dot")
+	require.NoError(t, os.MkdirAll(state, 0755))
+	require.NoError(t, os.Symlink(victim, filepath.Join(state, ".dot-manifest.json.tmp")))
+	require.NoError(t, os.Symlink(outside, filepath.Join(home, ".config")))
+
+	// A file planted as a link is not written through
+	err := fsys.WriteFile(ctx, filepath.Join(state, ".dot-manifest.json.tmp"), []byte("{}"), 0644)
+	assert.ErrorContains(t, err, "refusing to follow a symbolic link")
+	assert.Error(t, fsys.AppendFile(ctx, filepath.Join(state, ".dot-manifest.json.tmp"), []byte("{}"), 0644))
+	assert.Error(t, fsys.Chmod(ctx, filepath.Join(state, ".dot-manifest.json.tmp"), 0777))
+
+	// Nor are directories planted as links
+	for _, change := range []func() error{
+		func() error { return fsys.MkdirAll(ctx, filepath.Join(home, ".config", "nvim"), 0755) },
+		func() error { return fsys.Symlink(ctx, "/pkg/nvim", filepath.Join(home, ".config", "nvim")) },
+		func() error { return fsys.WriteFile(ctx, filepath.Join(home, ".config", "passwd"), []byte("x"), 0644) },
+		func() error { return fsys.Remove(ctx, filepath.Join(home, ".config", "passwd")) },
+		func() error {
+			return fsys.Rename(ctx, filepath.Join(state, ".dot-manifest.json.tmp"), filepath.Join(home, ".config", "passwd"))
+		},
+	} {
+		assert.ErrorContains(t, change(), "refusing to follow a symbolic link")
+	}
+
+	got, err := os.ReadFile(victim)
+	require.NoError(t, err)
+	assert.Equal(t, "root:x:0:0", string(got))
+	assert.NoDirExists(t, filepath.Join(outside, "nvim"))
+
+	// The links themselves can be replaced
+	require.NoError(t, fsys.Remove(ctx, filepath.Join(home, ".config")))
+	require.NoError(t, fsys.MkdirAll(ctx, filepath.Join(home, ".config", "nvim"), 0755))
+}
@@ -0,0 +1,329 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jamesainslie/dot/internal/domain"
+)
+
+// otlpScopeName is the instrumentation scope reported to OTLP backends.
+const otlpScopeName = "github.com/jamesainslie/dot"
+
+// OTLPExporter sends metrics and spans to an OpenTelemetry collector using
+// OTLP over HTTP with JSON encoding.
+type OTLPExporter struct {
+	client      *http.Client
+	endpoint    string
+	headers     map[string]string
+	serviceName string
+	start       time.Time
+}
+
+// NewOTLPExporter creates an exporter sending to the collector at endpoint,
+// such as "http://localhost:4318". Metrics are posted to /v1/metrics and
+// spans to /v1/traces, with headers added to every request. start is when
+// counters started counting. A nil client uses http.DefaultClient.
+func NewOTLPExporter(client *http.Client, endpoint string, headers map[string]string, serviceName string, start time.Time) *OTLPExporter {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &OTLPExporter{
+		client:      client,
+		endpoint:    strings.TrimRight(endpoint, "/"),
+		headers:     headers,
+		serviceName: serviceName,
+		start:       start,
+	}
+}
+
+// Export sends metrics, then spans if there are any.
+func (e *OTLPExporter) Export(ctx context.Context, metrics []MetricFamily, spans []SpanData) error {
+	if len(metrics) > 0 {
+		if err := e.post(ctx, "/v1/metrics", e.metricsRequest(metrics, time.Now()), "export metrics"); err != nil {
+			return err
+		}
+	}
+	if len(spans) > 0 {
+		if err := e.post(ctx, "/v1/traces", e.tracesRequest(spans), "export traces"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// post sends body as JSON to path under the endpoint.
+func (e *OTLPExporter) post(ctx context.Context, path string, body any, what string) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("%s: %w", what, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint+path, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("%s: %w", what, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range e.headers {
+		req.Header.Set(name, value)
+	}
+	return sendTelemetry(e.client, req, what)
+}
+
+// The types below are the subset of the OTLP JSON encoding the exporter
+// produces. 64-bit integers are encoded as strings, as OTLP requires.
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpMetricsRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpMetric struct {
+	Name      string         `json:"name"`
+	Sum       *otlpSum       `json:"sum,omitempty"`
+	Gauge     *otlpGauge     `json:"gauge,omitempty"`
+	Histogram *otlpHistogram `json:"histogram,omitempty"`
+}
+
+type otlpSum struct {
+	DataPoints             []otlpNumberDataPoint `json:"dataPoints"`
+	AggregationTemporality int                   `json:"aggregationTemporality"`
+	IsMonotonic            bool                  `json:"isMonotonic"`
+}
+
+type otlpGauge struct {
+	DataPoints []otlpNumberDataPoint `json:"dataPoints"`
+}
+
+type otlpHistogram struct {
+	DataPoints             []otlpHistogramDataPoint `json:"dataPoints"`
+	AggregationTemporality int                      `json:"aggregationTemporality"`
+}
+
+type otlpNumberDataPoint struct {
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      string         `json:"timeUnixNano"`
+	AsDouble          float64        `json:"asDouble"`
+}
+
+type otlpHistogramDataPoint struct {
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	TimeUnixNano      string         `json:"timeUnixNano"`
+	Count             string         `json:"count"`
+	Sum               float64        `json:"sum"`
+	BucketCounts      []string       `json:"bucketCounts"`
+	ExplicitBounds    []float64      `json:"explicitBounds"`
+}
+
+type otlpTracesRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            *otlpStatus    `json:"status,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+const (
+	// otlpTemporalityCumulative reports values accumulated since start.
+	otlpTemporalityCumulative = 2
+	// otlpSpanKindInternal marks spans of in-process operations.
+	otlpSpanKindInternal = 1
+	// otlpStatusError marks spans that recorded an error.
+	otlpStatusError = 2
+)
+
+// resource describes the service the telemetry comes from.
+func (e *OTLPExporter) resource() otlpResource {
+	return otlpResource{Attributes: []otlpKeyValue{otlpString("service.name", e.serviceName)}}
+}
+
+// metricsRequest converts metrics to an OTLP export request.
+func (e *OTLPExporter) metricsRequest(metrics []MetricFamily, now time.Time) otlpMetricsRequest {
+	start := otlpTime(e.start)
+	timestamp := otlpTime(now)
+
+	out := make([]otlpMetric, 0, len(metrics))
+	for _, family := range metrics {
+		metric := otlpMetric{Name: family.Name}
+		switch family.Kind {
+		case MetricHistogram:
+			metric.Histogram = &otlpHistogram{AggregationTemporality: otlpTemporalityCumulative}
+			for _, series := range family.Series {
+				buckets := make([]string, len(series.Buckets))
+				for i, n := range series.Buckets {
+					buckets[i] = strconv.FormatUint(n, 10)
+				}
+				metric.Histogram.DataPoints = append(metric.Histogram.DataPoints, otlpHistogramDataPoint{
+					Attributes:        otlpLabels(family.Labels, series.LabelValues),
+					StartTimeUnixNano: start,
+					TimeUnixNano:      timestamp,
+					Count:             strconv.FormatUint(series.Count, 10),
+					Sum:               series.Sum,
+					BucketCounts:      buckets,
+					ExplicitBounds:    series.Bounds,
+				})
+			}
+		case MetricGauge:
+			metric.Gauge = &otlpGauge{}
+			for _, series := range family.Series {
+				metric.Gauge.DataPoints = append(metric.Gauge.DataPoints, otlpNumberDataPoint{
+					Attributes:   otlpLabels(family.Labels, series.LabelValues),
+					TimeUnixNano: timestamp,
+					AsDouble:     series.Value,
+				})
+			}
+		default:
+			metric.Sum = &otlpSum{AggregationTemporality: otlpTemporalityCumulative, IsMonotonic: true}
+			for _, series := range family.Series {
+				metric.Sum.DataPoints = append(metric.Sum.DataPoints, otlpNumberDataPoint{
+					Attributes:        otlpLabels(family.Labels, series.LabelValues),
+					StartTimeUnixNano: start,
+					TimeUnixNano:      timestamp,
+					AsDouble:          series.Value,
+				})
+			}
+		}
+		out = append(out, metric)
+	}
+
+	return otlpMetricsRequest{ResourceMetrics: []otlpResourceMetrics{{
+		Resource:     e.resource(),
+		ScopeMetrics: []otlpScopeMetrics{{Scope: otlpScope{Name: otlpScopeName}, Metrics: out}},
+	}}}
+}
+
+// tracesRequest converts spans to an OTLP export request.
+func (e *OTLPExporter) tracesRequest(spans []SpanData) otlpTracesRequest {
+	out := make([]otlpSpan, 0, len(spans))
+	for _, span := range spans {
+		s := otlpSpan{
+			TraceID:           span.TraceID,
+			SpanID:            span.SpanID,
+			ParentSpanID:      span.ParentSpanID,
+			Name:              span.Name,
+			Kind:              otlpSpanKindInternal,
+			StartTimeUnixNano: otlpTime(span.Start),
+			EndTimeUnixNano:   otlpTime(span.End),
+			Attributes:        otlpAttributes(span.Attributes),
+		}
+		if span.Err != "" {
+			s.Status = &otlpStatus{Code: otlpStatusError, Message: span.Err}
+		}
+		out = append(out, s)
+	}
+
+	return otlpTracesRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   e.resource(),
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: otlpScopeName}, Spans: out}},
+	}}}
+}
+
+// otlpTime encodes t as nanoseconds since the epoch.
+func otlpTime(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// otlpString returns a string attribute.
+func otlpString(key, value string) otlpKeyValue {
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{StringValue: &value}}
+}
+
+// otlpLabels converts metric labels to attributes.
+func otlpLabels(names, values []string) []otlpKeyValue {
+	attrs := make([]otlpKeyValue, 0, len(names))
+	for i, name := range names {
+		value := ""
+		if i < len(values) {
+			value = values[i]
+		}
+		attrs = append(attrs, otlpString(name, value))
+	}
+	return attrs
+}
+
+// otlpAttributes converts span attributes, keeping the type of booleans
+// and numbers and formatting other values as strings.
+func otlpAttributes(attrs []domain.Attribute) []otlpKeyValue {
+	out := make([]otlpKeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		kv := otlpKeyValue{Key: attr.Key}
+		switch v := attr.Value.(type) {
+		case string:
+			kv.Value.StringValue = &v
+		case bool:
+			kv.Value.BoolValue = &v
+		case int:
+			s := strconv.Itoa(v)
+			kv.Value.IntValue = &s
+		case int64:
+			s := strconv.FormatInt(v, 10)
+			kv.Value.IntValue = &s
+		case float64:
+			kv.Value.DoubleValue = &v
+		default:
+			s := fmt.Sprint(v)
+			kv.Value.StringValue = &s
+		}
+		out = append(out, kv)
+	}
+	return out
+}
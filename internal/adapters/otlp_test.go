@@ -0,0 +1,143 @@
+package adapters_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/internal/domain"
+)
+
+// otlpCollector records the requests of an OTLP exporter by path.
+type otlpCollector struct {
+	mu       sync.Mutex
+	requests map[string]map[string]any
+	headers  http.Header
+}
+
+func newOTLPCollector(t *testing.T) (*otlpCollector, *httptest.Server) {
+	t.Helper()
+	c := &otlpCollector{requests: map[string]map[string]any{}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.requests[r.URL.Path] = body
+		c.headers = r.Header.Clone()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{}"))
+	}))
+	t.Cleanup(server.Close)
+	return c, server
+}
+
+// jsonPath returns the value at keys in v, indexing arrays with ints.
+func jsonPath(t *testing.T, v any, keys ...any) any {
+	t.Helper()
+	for _, key := range keys {
+		switch k := key.(type) {
+		case string:
+			m, ok := v.(map[string]any)
+			require.True(t, ok, "expected object at %v", k)
+			v = m[k]
+		case int:
+			a, ok := v.([]any)
+			require.True(t, ok, "expected array at %d", k)
+			require.Greater(t, len(a), k)
+			v = a[k]
+		}
+	}
+	return v
+}
+
+func TestOTLPExporter(t *testing.T) {
+	collector, server := newOTLPCollector(t)
+
+	tracer := adapters.NewRecordingTracer()
+	ctx, root := tracer.Start(context.Background(), "executor.execute")
+	_, child := tracer.Start(ctx, "executor.operation", func(c *domain.SpanConfig) {
+		c.Attributes = []domain.Attribute{{Key: "kind", Value: "link_create"}, {Key: "retry", Value: true}, {Key: "size", Value: 3}}
+	})
+	child.RecordError(errors.New("permission denied"))
+	child.End()
+	root.End()
+
+	exporter := adapters.NewOTLPExporter(server.Client(), server.URL, map[string]string{"Authorization": "Bearer token"}, "dot", time.Unix(100, 0))
+	require.NoError(t, exporter.Export(context.Background(), sampleRegistry().Snapshot(), tracer.Spans()))
+
+	assert.Equal(t, "Bearer token", collector.headers.Get("Authorization"))
+	assert.Equal(t, "application/json", collector.headers.Get("Content-Type"))
+
+	metrics := collector.requests["/v1/metrics"]
+	require.NotNil(t, metrics)
+	resource := jsonPath(t, metrics, "resourceMetrics", 0, "resource", "attributes", 0)
+	assert.Equal(t, "service.name", jsonPath(t, resource, "key"))
+	assert.Equal(t, "dot", jsonPath(t, resource, "value", "stringValue"))
+
+	scope := jsonPath(t, metrics, "resourceMetrics", 0, "scopeMetrics", 0)
+	assert.Equal(t, "github.com/jamesainslie/dot", jsonPath(t, scope, "scope", "name"))
+	counter := jsonPath(t, scope, "metrics", 0)
+	assert.Equal(t, "executor.operations.executed", jsonPath(t, counter, "name"))
+	assert.Equal(t, true, jsonPath(t, counter, "sum", "isMonotonic"))
+	assert.Equal(t, 2.0, jsonPath(t, counter, "sum", "aggregationTemporality"))
+	assert.Equal(t, "100000000000", jsonPath(t, counter, "sum", "dataPoints", 0, "startTimeUnixNano"))
+	assert.Equal(t, "kind", jsonPath(t, counter, "sum", "dataPoints", 0, "attributes", 0, "key"))
+	assert.Equal(t, 3.0, jsonPath(t, counter, "sum", "dataPoints", 0, "asDouble"))
+
+	gauge := jsonPath(t, scope, "metrics", 1)
+	assert.Equal(t, 4.0, jsonPath(t, gauge, "gauge", "dataPoints", 0, "asDouble"))
+
+	histogram := jsonPath(t, scope, "metrics", 2, "histogram", "dataPoints", 0)
+	assert.Equal(t, "1", jsonPath(t, histogram, "count"))
+	assert.Equal(t, 4.0, jsonPath(t, histogram, "sum"))
+	assert.Len(t, jsonPath(t, histogram, "bucketCounts"), len(adapters.DefaultBuckets)+1)
+	assert.Len(t, jsonPath(t, histogram, "explicitBounds"), len(adapters.DefaultBuckets))
+
+	traces := collector.requests["/v1/traces"]
+	require.NotNil(t, traces)
+	spans := jsonPath(t, traces, "resourceSpans", 0, "scopeSpans", 0, "spans").([]any)
+	require.Len(t, spans, 2)
+	assert.Equal(t, "executor.operation", jsonPath(t, spans[0], "name"))
+	assert.Equal(t, jsonPath(t, spans[1], "spanId"), jsonPath(t, spans[0], "parentSpanId"))
+	assert.Equal(t, 2.0, jsonPath(t, spans[0], "status", "code"))
+	assert.Equal(t, "permission denied", jsonPath(t, spans[0], "status", "message"))
+	assert.Equal(t, "link_create", jsonPath(t, spans[0], "attributes", 0, "value", "stringValue"))
+	assert.Equal(t, true, jsonPath(t, spans[0], "attributes", 1, "value", "boolValue"))
+	assert.Equal(t, "3", jsonPath(t, spans[0], "attributes", 2, "value", "intValue"))
+	assert.Nil(t, jsonPath(t, spans[1], "status"))
+}
+
+func TestOTLPExporter_NoSpans(t *testing.T) {
+	collector, server := newOTLPCollector(t)
+	exporter := adapters.NewOTLPExporter(server.Client(), server.URL, nil, "dot", time.Now())
+	require.NoError(t, exporter.Export(context.Background(), sampleRegistry().Snapshot(), nil))
+
+	assert.Contains(t, collector.requests, "/v1/metrics")
+	assert.NotContains(t, collector.requests, "/v1/traces")
+}
+
+func TestOTLPExporter_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	exporter := adapters.NewOTLPExporter(server.Client(), server.URL, nil, "dot", time.Now())
+	err := exporter.Export(context.Background(), sampleRegistry().Snapshot(), nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "export metrics")
+	assert.Contains(t, err.Error(), "401")
+}
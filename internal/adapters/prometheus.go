@@ -0,0 +1,207 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jamesainslie/dot/internal/domain"
+)
+
+// prometheusContentType is the media type of the Prometheus text format.
+const prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// PrometheusName converts a metric name such as "executor.duration.seconds"
+// to a Prometheus metric name, "dot_executor_duration_seconds". Counters
+// get the _total suffix if they lack it.
+func PrometheusName(name string, kind MetricKind) string {
+	var b strings.Builder
+	b.WriteString("dot_")
+	for _, r := range name {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	out := b.String()
+	if kind == MetricCounter && !strings.HasSuffix(out, "_total") {
+		out += "_total"
+	}
+	return out
+}
+
+// WritePrometheusText writes metrics in the Prometheus text exposition
+// format.
+func WritePrometheusText(w io.Writer, metrics []MetricFamily) error {
+	var b bytes.Buffer
+	for _, family := range metrics {
+		name := PrometheusName(family.Name, family.Kind)
+		kind := "counter"
+		switch family.Kind {
+		case MetricGauge:
+			kind = "gauge"
+		case MetricHistogram:
+			kind = "histogram"
+		}
+		fmt.Fprintf(&b, "# TYPE %s %s\n", name, kind)
+
+		for _, series := range family.Series {
+			labels := prometheusLabels(family.Labels, series.LabelValues)
+			if family.Kind != MetricHistogram {
+				fmt.Fprintf(&b, "%s%s %s\n", name, labels.String(""), formatPrometheusFloat(series.Value))
+				continue
+			}
+			var cumulative uint64
+			for i, bound := range series.Bounds {
+				cumulative += series.Buckets[i]
+				fmt.Fprintf(&b, "%s_bucket%s %d\n", name, labels.String(formatPrometheusFloat(bound)), cumulative)
+			}
+			fmt.Fprintf(&b, "%s_bucket%s %d\n", name, labels.String("+Inf"), series.Count)
+			fmt.Fprintf(&b, "%s_sum%s %s\n", name, labels.String(""), formatPrometheusFloat(series.Sum))
+			fmt.Fprintf(&b, "%s_count%s %d\n", name, labels.String(""), series.Count)
+		}
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// prometheusLabelSet is a list of formatted name="value" pairs.
+type prometheusLabelSet []string
+
+// prometheusLabels formats the labels of a series.
+func prometheusLabels(names, values []string) prometheusLabelSet {
+	set := make(prometheusLabelSet, 0, len(names))
+	for i, name := range names {
+		value := ""
+		if i < len(values) {
+			value = values[i]
+		}
+		set = append(set, name+`="`+prometheusLabelEscaper.Replace(value)+`"`)
+	}
+	return set
+}
+
+// String formats the label set, adding the le label of a histogram bucket
+// if le is not empty.
+func (s prometheusLabelSet) String(le string) string {
+	labels := s
+	if le != "" {
+		labels = append(labels[:len(labels):len(labels)], `le="`+le+`"`)
+	}
+	if len(labels) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(labels, ",") + "}"
+}
+
+// prometheusLabelEscaper escapes label values as the text format requires.
+var prometheusLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// formatPrometheusFloat formats a sample value.
+func formatPrometheusFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// PrometheusTextfileExporter writes metrics to a file in the Prometheus
+// text format, for the textfile collector of the node exporter. The file
+// is replaced atomically so a scrape never reads a partial file.
+type PrometheusTextfileExporter struct {
+	fs   domain.FS
+	path string
+}
+
+// NewPrometheusTextfileExporter creates an exporter writing to path.
+func NewPrometheusTextfileExporter(fs domain.FS, path string) *PrometheusTextfileExporter {
+	return &PrometheusTextfileExporter{fs: fs, path: path}
+}
+
+// Export writes metrics to the file, replacing its contents.
+func (e *PrometheusTextfileExporter) Export(ctx context.Context, metrics []MetricFamily, spans []SpanData) error {
+	var b bytes.Buffer
+	if err := WritePrometheusText(&b, metrics); err != nil {
+		return err
+	}
+	if err := e.fs.MkdirAll(ctx, filepath.Dir(e.path), 0755); err != nil {
+		return fmt.Errorf("create %s: %w", filepath.Dir(e.path), err)
+	}
+	tmp := e.path + ".tmp"
+	if err := e.fs.WriteFile(ctx, tmp, b.Bytes(), 0644); err != nil {
+		return fmt.Errorf("write %s: %w", tmp, err)
+	}
+	if err := e.fs.Rename(ctx, tmp, e.path); err != nil {
+		_ = e.fs.Remove(ctx, tmp)
+		return fmt.Errorf("write %s: %w", e.path, err)
+	}
+	return nil
+}
+
+// PrometheusPushExporter pushes metrics to a Prometheus Pushgateway,
+// replacing the metrics of its grouping key.
+type PrometheusPushExporter struct {
+	client *http.Client
+	url    string
+}
+
+// NewPrometheusPushExporter creates an exporter pushing to the Pushgateway
+// at gatewayURL under job and the grouping labels. A nil client uses
+// http.DefaultClient.
+func NewPrometheusPushExporter(client *http.Client, gatewayURL, job string, grouping map[string]string) *PrometheusPushExporter {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	path := strings.TrimRight(gatewayURL, "/") + "/metrics/job/" + url.PathEscape(job)
+	names := make([]string, 0, len(grouping))
+	for name := range grouping {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		path += "/" + url.PathEscape(name) + "/" + url.PathEscape(grouping[name])
+	}
+	return &PrometheusPushExporter{client: client, url: path}
+}
+
+// Export pushes metrics.
+func (e *PrometheusPushExporter) Export(ctx context.Context, metrics []MetricFamily, spans []SpanData) error {
+	var b bytes.Buffer
+	if err := WritePrometheusText(&b, metrics); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, e.url, &b)
+	if err != nil {
+		return fmt.Errorf("push metrics: %w", err)
+	}
+	req.Header.Set("Content-Type", prometheusContentType)
+	return sendTelemetry(e.client, req, "push metrics")
+}
+
+// sendTelemetry sends req and fails unless the response is successful.
+func sendTelemetry(client *http.Client, req *http.Request, what string) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", what, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s to %s: %s: %s", what, req.URL.Redacted(), resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package adapters_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+)
+
+func TestPrometheusName(t *testing.T) {
+	assert.Equal(t, "dot_executor_duration_seconds", adapters.PrometheusName("executor.duration.seconds", adapters.MetricHistogram))
+	assert.Equal(t, "dot_executor_rollbacks_total", adapters.PrometheusName("executor.rollbacks.total", adapters.MetricCounter))
+	assert.Equal(t, "dot_cli_commands_total", adapters.PrometheusName("cli.commands", adapters.MetricCounter))
+	assert.Equal(t, "dot_plan_size", adapters.PrometheusName("plan-size", adapters.MetricGauge))
+}
+
+func sampleRegistry() *adapters.MetricsRegistry {
+	registry := adapters.NewMetricsRegistry()
+	registry.Counter("executor.operations.executed", "kind").Add(3, "link_create")
+	registry.Counter("executor.operations.executed", "kind").Inc(`say "hi"\`)
+	registry.Gauge("executor.operations.queued").Set(4)
+	registry.Histogram("executor.plan.operations").Observe(4)
+	return registry
+}
+
+func TestWritePrometheusText(t *testing.T) {
+	var b bytes.Buffer
+	require.NoError(t, adapters.WritePrometheusText(&b, sampleRegistry().Snapshot()))
+	text := b.String()
+
+	assert.Contains(t, text, "# TYPE dot_executor_operations_executed_total counter\n")
+	assert.Contains(t, text, `dot_executor_operations_executed_total{kind="link_create"} 3`+"\n")
+	assert.Contains(t, text, `dot_executor_operations_executed_total{kind="say \"hi\"\\"} 1`+"\n")
+	assert.Contains(t, text, "# TYPE dot_executor_operations_queued gauge\ndot_executor_operations_queued 4\n")
+	assert.Contains(t, text, "# TYPE dot_executor_plan_operations histogram\n")
+	assert.Contains(t, text, `dot_executor_plan_operations_bucket{le="2.5"} 0`+"\n")
+	assert.Contains(t, text, `dot_executor_plan_operations_bucket{le="5"} 1`+"\n")
+	assert.Contains(t, text, `dot_executor_plan_operations_bucket{le="1000"} 1`+"\n")
+	assert.Contains(t, text, `dot_executor_plan_operations_bucket{le="+Inf"} 1`+"\n")
+	assert.Contains(t, text, "dot_executor_plan_operations_sum 4\ndot_executor_plan_operations_count 1\n")
+}
+
+func TestPrometheusTextfileExporter(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	exporter := adapters.NewPrometheusTextfileExporter(fs, "/var/lib/node_exporter/dot.prom")
+
+	require.NoError(t, exporter.Export(ctx, sampleRegistry().Snapshot(), nil))
+	data, err := fs.ReadFile(ctx, "/var/lib/node_exporter/dot.prom")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "dot_executor_operations_queued 4")
+	assert.False(t, fs.Exists(ctx, "/var/lib/node_exporter/dot.prom.tmp"))
+
+	// A later export replaces the file
+	require.NoError(t, exporter.Export(ctx, nil, nil))
+	data, err = fs.ReadFile(ctx, "/var/lib/node_exporter/dot.prom")
+	require.NoError(t, err)
+	assert.Empty(t, data)
+}
+
+func TestPrometheusPushExporter(t *testing.T) {
+	var method, path, contentType, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path, contentType = r.Method, r.URL.EscapedPath(), r.Header.Get("Content-Type")
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+	}))
+	defer server.Close()
+
+	exporter := adapters.NewPrometheusPushExporter(server.Client(), server.URL+"/", "dot", map[string]string{
+		"instance": "laptop",
+		"env":      "ci/1",
+	})
+	require.NoError(t, exporter.Export(context.Background(), sampleRegistry().Snapshot(), nil))
+
+	assert.Equal(t, http.MethodPut, method)
+	assert.Equal(t, "/metrics/job/dot/env/ci%2F1/instance/laptop", path)
+	assert.True(t, strings.HasPrefix(contentType, "text/plain; version=0.0.4"))
+	assert.Contains(t, body, "dot_executor_operations_queued 4")
+}
+
+func TestPrometheusPushExporter_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "pushed metrics are invalid", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	exporter := adapters.NewPrometheusPushExporter(server.Client(), server.URL, "dot", nil)
+	err := exporter.Export(context.Background(), sampleRegistry().Snapshot(), nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "400 Bad Request")
+	assert.Contains(t, err.Error(), "pushed metrics are invalid")
+}
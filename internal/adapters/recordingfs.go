@@ -0,0 +1,194 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jamesainslie/dot/internal/domain"
+)
+
+// FSCall is a mutating call recorded by a RecordingFS.
+type FSCall struct {
+	// Method is the name of the FS method called, such as "Symlink".
+	Method string `json:"method" yaml:"method"`
+
+	// Path is the path created, changed or removed.
+	Path string `json:"path" yaml:"path"`
+
+	// Source is the other path of Symlink, Link and Rename: the target of
+	// the link, or the path renamed.
+	Source string `json:"source,omitempty" yaml:"source,omitempty"`
+
+	// Mode is the permission requested by WriteFile, Mkdir, MkdirAll,
+	// AppendFile and Chmod.
+	Mode os.FileMode `json:"mode,omitempty" yaml:"mode,omitempty"`
+
+	// Size is the number of bytes WriteFile and AppendFile would write.
+	Size int `json:"size,omitempty" yaml:"size,omitempty"`
+}
+
+// String returns the call as it would change the filesystem, such as
+// "symlink /home/me/.vimrc -> /dotfiles/vim/dot-vimrc".
+func (c FSCall) String() string {
+	switch c.Method {
+	case "Symlink":
+		return fmt.Sprintf("symlink %s -> %s", c.Path, c.Source)
+	case "Link":
+		return fmt.Sprintf("link %s = %s", c.Path, c.Source)
+	case "Rename":
+		return fmt.Sprintf("rename %s -> %s", c.Source, c.Path)
+	case "WriteFile", "AppendFile":
+		return fmt.Sprintf("%s %s (%d bytes, %04o)", methodVerb(c.Method), c.Path, c.Size, c.Mode.Perm())
+	case "Mkdir", "MkdirAll", "Chmod":
+		return fmt.Sprintf("%s %s (%04o)", methodVerb(c.Method), c.Path, c.Mode.Perm())
+	default:
+		return fmt.Sprintf("%s %s", methodVerb(c.Method), c.Path)
+	}
+}
+
+// methodVerb returns the lower-case verb of an FS method.
+func methodVerb(method string) string {
+	switch method {
+	case "WriteFile":
+		return "write"
+	case "AppendFile":
+		return "append"
+	case "MkdirAll":
+		return "mkdir -p"
+	case "RemoveAll":
+		return "remove -r"
+	default:
+		return strings.ToLower(method)
+	}
+}
+
+// RecordingFS is a filesystem that reads through to another and records
+// every mutating call instead of making it, so that a plan can be run by
+// the executor without changing anything. Reads see the wrapped
+// filesystem as it is: nothing recorded is visible to them. It is safe for
+// concurrent use.
+type RecordingFS struct {
+	fs domain.FS
+
+	mu    sync.Mutex
+	calls []FSCall
+}
+
+// NewRecordingFS creates a filesystem reading from fs and recording writes.
+func NewRecordingFS(fs domain.FS) *RecordingFS {
+	return &RecordingFS{fs: fs}
+}
+
+// Calls returns the mutating calls in the order they were made.
+func (r *RecordingFS) Calls() []FSCall {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.calls)
+}
+
+// Reset forgets the recorded calls.
+func (r *RecordingFS) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+}
+
+func (r *RecordingFS) record(ctx context.Context, call FSCall) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, call)
+	return nil
+}
+
+func (r *RecordingFS) Stat(ctx context.Context, path string) (domain.FileInfo, error) {
+	return r.fs.Stat(ctx, path)
+}
+
+func (r *RecordingFS) ReadDir(ctx context.Context, path string) ([]domain.DirEntry, error) {
+	return r.fs.ReadDir(ctx, path)
+}
+
+func (r *RecordingFS) ReadLink(ctx context.Context, path string) (string, error) {
+	return r.fs.ReadLink(ctx, path)
+}
+
+func (r *RecordingFS) ReadFile(ctx context.Context, path string) ([]byte, error) {
+	return r.fs.ReadFile(ctx, path)
+}
+
+func (r *RecordingFS) Exists(ctx context.Context, path string) bool {
+	return r.fs.Exists(ctx, path)
+}
+
+func (r *RecordingFS) IsDir(ctx context.Context, path string) (bool, error) {
+	return r.fs.IsDir(ctx, path)
+}
+
+func (r *RecordingFS) IsSymlink(ctx context.Context, path string) (bool, error) {
+	return r.fs.IsSymlink(ctx, path)
+}
+
+func (r *RecordingFS) WriteFile(ctx context.Context, path string, data []byte, perm os.FileMode) error {
+	return r.record(ctx, FSCall{Method: "WriteFile", Path: path, Mode: perm, Size: len(data)})
+}
+
+func (r *RecordingFS) Mkdir(ctx context.Context, path string, perm os.FileMode) error {
+	return r.record(ctx, FSCall{Method: "Mkdir", Path: path, Mode: perm})
+}
+
+func (r *RecordingFS) MkdirAll(ctx context.Context, path string, perm os.FileMode) error {
+	return r.record(ctx, FSCall{Method: "MkdirAll", Path: path, Mode: perm})
+}
+
+func (r *RecordingFS) Remove(ctx context.Context, path string) error {
+	return r.record(ctx, FSCall{Method: "Remove", Path: path})
+}
+
+func (r *RecordingFS) RemoveAll(ctx context.Context, path string) error {
+	return r.record(ctx, FSCall{Method: "RemoveAll", Path: path})
+}
+
+func (r *RecordingFS) Symlink(ctx context.Context, oldname, newname string) error {
+	return r.record(ctx, FSCall{Method: "Symlink", Path: newname, Source: oldname})
+}
+
+func (r *RecordingFS) Rename(ctx context.Context, oldpath, newpath string) error {
+	return r.record(ctx, FSCall{Method: "Rename", Path: newpath, Source: oldpath})
+}
+
+// Chmod records a change of mode. It implements domain.MetadataFS.
+func (r *RecordingFS) Chmod(ctx context.Context, path string, mode os.FileMode) error {
+	return r.record(ctx, FSCall{Method: "Chmod", Path: path, Mode: mode})
+}
+
+// Chtimes records a change of times. It implements domain.MetadataFS.
+func (r *RecordingFS) Chtimes(ctx context.Context, path string, atime, mtime time.Time) error {
+	return r.record(ctx, FSCall{Method: "Chtimes", Path: path})
+}
+
+// Link records a hard link. It implements domain.HardlinkFS.
+func (r *RecordingFS) Link(ctx context.Context, oldname, newname string) error {
+	return r.record(ctx, FSCall{Method: "Link", Path: newname, Source: oldname})
+}
+
+// LinkCount returns the link count of path in the wrapped filesystem, or
+// 0 when it does not report link counts. It implements domain.HardlinkFS.
+func (r *RecordingFS) LinkCount(ctx context.Context, path string) (uint64, error) {
+	if hl, ok := r.fs.(domain.HardlinkFS); ok {
+		return hl.LinkCount(ctx, path)
+	}
+	return 0, nil
+}
+
+// AppendFile records an append. It implements domain.AppendFS.
+func (r *RecordingFS) AppendFile(ctx context.Context, path string, data []byte, perm os.FileMode) error {
+	return r.record(ctx, FSCall{Method: "AppendFile", Path: path, Mode: perm, Size: len(data)})
+}
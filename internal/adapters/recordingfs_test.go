@@ -0,0 +1,70 @@
+package adapters
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/domain"
+)
+
+func TestRecordingFS_RecordsWithoutWriting(t *testing.T) {
+	ctx := context.Background()
+	mem := NewMemFS()
+	require.NoError(t, mem.MkdirAll(ctx, "/pkg", 0755))
+	require.NoError(t, mem.WriteFile(ctx, "/pkg/vimrc", []byte("set nocompatible"), 0644))
+
+	var fs domain.FS = NewRecordingFS(mem)
+	rec := fs.(*RecordingFS)
+
+	// Reads go through
+	data, err := fs.ReadFile(ctx, "/pkg/vimrc")
+	require.NoError(t, err)
+	assert.Equal(t, "set nocompatible", string(data))
+
+	require.NoError(t, fs.MkdirAll(ctx, "/home", 0755))
+	require.NoError(t, fs.Symlink(ctx, "/pkg/vimrc", "/home/.vimrc"))
+	require.NoError(t, fs.WriteFile(ctx, "/home/.gvimrc", []byte("abc"), 0600))
+	require.NoError(t, fs.Rename(ctx, "/pkg/vimrc", "/pkg/old"))
+	require.NoError(t, fs.RemoveAll(ctx, "/pkg"))
+	require.NoError(t, fs.(domain.AppendFS).AppendFile(ctx, "/log", []byte("x\n"), 0644))
+
+	// Nothing changed
+	assert.False(t, mem.Exists(ctx, "/home"))
+	assert.True(t, mem.Exists(ctx, "/pkg/vimrc"))
+	assert.False(t, mem.Exists(ctx, "/log"))
+
+	calls := rec.Calls()
+	require.Len(t, calls, 6)
+	assert.Equal(t, FSCall{Method: "Symlink", Path: "/home/.vimrc", Source: "/pkg/vimrc"}, calls[1])
+	assert.Equal(t, []string{
+		"mkdir -p /home (0755)",
+		"symlink /home/.vimrc -> /pkg/vimrc",
+		"write /home/.gvimrc (3 bytes, 0600)",
+		"rename /pkg/vimrc -> /pkg/old",
+		"remove -r /pkg",
+		"append /log (2 bytes, 0644)",
+	}, callStrings(calls))
+
+	rec.Reset()
+	assert.Empty(t, rec.Calls())
+}
+
+func TestRecordingFS_CanceledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rec := NewRecordingFS(NewMemFS())
+
+	assert.ErrorIs(t, rec.Remove(ctx, "/x"), context.Canceled)
+	assert.Empty(t, rec.Calls())
+}
+
+func callStrings(calls []FSCall) []string {
+	out := make([]string, len(calls))
+	for i, c := range calls {
+		out[i] = c.String()
+	}
+	return out
+}
@@ -0,0 +1,284 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/sftp"
+
+	"github.com/jamesainslie/dot/internal/domain"
+)
+
+// sftpPosixRename is the OpenSSH extension renaming over an existing
+// file, as rename(2) does.
+const sftpPosixRename = "posix-rename@openssh.com"
+
+// SFTPFS is a filesystem on a remote host, reached through an SFTP client,
+// usually over SSH. Nothing needs to be installed on the host beyond its
+// SFTP server.
+//
+// A call checks ctx before its requests but, apart from ReadDir, cannot
+// interrupt one under way.
+type SFTPFS struct {
+	client *sftp.Client
+	closer io.Closer
+}
+
+// NewSFTPFS creates a filesystem on the SFTP session of client. Close
+// closes client and then closer, which may be nil.
+func NewSFTPFS(client *sftp.Client, closer io.Closer) *SFTPFS {
+	return &SFTPFS{client: client, closer: closer}
+}
+
+// Close ends the session.
+func (s *SFTPFS) Close() error {
+	err := s.client.Close()
+	if s.closer != nil {
+		err = errors.Join(err, s.closer.Close())
+	}
+	return err
+}
+
+// sftpPathError returns err as an error about name that os.IsNotExist and
+// os.IsPermission recognize. The client reports some errors without the
+// path.
+func sftpPathError(op, name string, err error) error {
+	var pathErr *fs.PathError
+	if err == nil || errors.As(err, &pathErr) {
+		return err
+	}
+	return &fs.PathError{Op: op, Path: name, Err: err}
+}
+
+func (s *SFTPFS) Stat(ctx context.Context, name string) (domain.FileInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	info, err := s.client.Stat(name)
+	if err != nil {
+		return nil, sftpPathError("stat", name, err)
+	}
+	return WrapFileInfo(info), nil
+}
+
+func (s *SFTPFS) lstat(ctx context.Context, name string) (fs.FileInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	info, err := s.client.Lstat(name)
+	return info, sftpPathError("lstat", name, err)
+}
+
+// ReadDir lists the entries of a directory sorted by name, without "."
+// and "..".
+func (s *SFTPFS) ReadDir(ctx context.Context, name string) ([]domain.DirEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	infos, err := s.client.ReadDirContext(ctx, name)
+	if err != nil {
+		return nil, sftpPathError("readdir", name, err)
+	}
+	if err := ctx.Err(); err != nil {
+		// The listing stops short when ctx is done
+		return nil, err
+	}
+
+	entries := make([]domain.DirEntry, 0, len(infos))
+	for _, info := range infos {
+		if info.Name() != "." && info.Name() != ".." {
+			entries = append(entries, WrapDirEntry(fs.FileInfoToDirEntry(info)))
+		}
+	}
+	slices.SortFunc(entries, func(a, b domain.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+	return entries, nil
+}
+
+func (s *SFTPFS) ReadLink(ctx context.Context, name string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	target, err := s.client.ReadLink(name)
+	return target, sftpPathError("readlink", name, err)
+}
+
+func (s *SFTPFS) ReadFile(ctx context.Context, name string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	f, err := s.client.Open(name)
+	if err != nil {
+		return nil, sftpPathError("open", name, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, sftpPathError("read", name, err)
+	}
+	return data, nil
+}
+
+// WriteFile writes data to a file, creating it with perm if needed, as
+// os.WriteFile does. A new file gets perm before data is written to it.
+func (s *SFTPFS) WriteFile(ctx context.Context, name string, data []byte, perm fs.FileMode) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	_, statErr := s.client.Lstat(name)
+	created := errors.Is(statErr, fs.ErrNotExist)
+
+	f, err := s.client.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return sftpPathError("open", name, err)
+	}
+	if created {
+		if err := f.Chmod(perm.Perm()); err != nil {
+			_ = f.Close()
+			return sftpPathError("chmod", name, err)
+		}
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return sftpPathError("write", name, err)
+	}
+	return sftpPathError("close", name, f.Close())
+}
+
+// Mkdir creates a directory with perm. The protocol creates it with the
+// default mode of the server, so perm is applied afterwards.
+func (s *SFTPFS) Mkdir(ctx context.Context, name string, perm fs.FileMode) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := s.client.Mkdir(name); err != nil {
+		return sftpPathError("mkdir", name, err)
+	}
+	return sftpPathError("chmod", name, s.client.Chmod(name, perm.Perm()))
+}
+
+// MkdirAll creates a directory and its missing parents, as os.MkdirAll
+// does.
+func (s *SFTPFS) MkdirAll(ctx context.Context, name string, perm fs.FileMode) error {
+	if info, err := s.Stat(ctx, name); err == nil {
+		if info.IsDir() {
+			return nil
+		}
+		return &fs.PathError{Op: "mkdir", Path: name, Err: errors.New("not a directory")}
+	}
+	if parent := path.Dir(name); parent != name {
+		if err := s.MkdirAll(ctx, parent, perm); err != nil {
+			return err
+		}
+	}
+	if err := s.Mkdir(ctx, name, perm); err != nil {
+		// Created meanwhile; servers do not tell that it exists
+		if isDir, statErr := s.IsDir(ctx, name); statErr == nil && isDir {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// Remove removes a file, a symbolic link or an empty directory.
+func (s *SFTPFS) Remove(ctx context.Context, name string) error {
+	info, err := s.lstat(ctx, name)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return sftpPathError("remove", name, s.client.RemoveDirectory(name))
+	}
+	return sftpPathError("remove", name, s.client.Remove(name))
+}
+
+// RemoveAll removes a path and everything it contains, without following
+// symbolic links. A missing path is not an error.
+func (s *SFTPFS) RemoveAll(ctx context.Context, name string) error {
+	info, err := s.lstat(ctx, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		entries, err := s.ReadDir(ctx, name)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := s.RemoveAll(ctx, path.Join(name, entry.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return s.Remove(ctx, name)
+}
+
+// Symlink creates newname as a symbolic link to oldname.
+func (s *SFTPFS) Symlink(ctx context.Context, oldname, newname string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return sftpPathError("symlink", newname, s.client.Symlink(oldname, newname))
+}
+
+// Rename renames a file, replacing newpath when the server supports the
+// posix-rename@openssh.com extension.
+func (s *SFTPFS) Rename(ctx context.Context, oldpath, newpath string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if _, ok := s.client.HasExtension(sftpPosixRename); ok {
+		return sftpPathError("rename", oldpath, s.client.PosixRename(oldpath, newpath))
+	}
+	return sftpPathError("rename", oldpath, s.client.Rename(oldpath, newpath))
+}
+
+func (s *SFTPFS) Exists(ctx context.Context, name string) bool {
+	_, err := s.Stat(ctx, name)
+	return err == nil
+}
+
+func (s *SFTPFS) IsDir(ctx context.Context, name string) (bool, error) {
+	info, err := s.Stat(ctx, name)
+	if err != nil {
+		return false, err
+	}
+	return info.IsDir(), nil
+}
+
+func (s *SFTPFS) IsSymlink(ctx context.Context, name string) (bool, error) {
+	info, err := s.lstat(ctx, name)
+	if err != nil {
+		return false, err
+	}
+	return info.Mode()&fs.ModeSymlink != 0, nil
+}
+
+// Chmod changes the permissions of a file. It implements
+// domain.MetadataFS.
+func (s *SFTPFS) Chmod(ctx context.Context, name string, mode fs.FileMode) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return sftpPathError("chmod", name, s.client.Chmod(name, mode.Perm()))
+}
+
+// Chtimes changes the access and modification times of a file, to the
+// second. It implements domain.MetadataFS.
+func (s *SFTPFS) Chtimes(ctx context.Context, name string, atime, mtime time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return sftpPathError("chtimes", name, s.client.Chtimes(name, atime, mtime))
+}
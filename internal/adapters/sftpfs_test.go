@@ -0,0 +1,178 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sftpPipe is one end of the connection between a test client and server.
+type sftpPipe struct {
+	io.Reader
+	io.WriteCloser
+}
+
+// newTestSFTPFS connects an SFTPFS to an SFTP server on this machine, as
+// OpenSSH would serve it, and returns a directory to work in.
+func newTestSFTPFS(t *testing.T) (*SFTPFS, string) {
+	t.Helper()
+	reqR, reqW := io.Pipe()
+	respR, respW := io.Pipe()
+
+	server, err := sftp.NewServer(sftpPipe{Reader: reqR, WriteCloser: respW})
+	require.NoError(t, err)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = server.Serve()
+	}()
+
+	client, err := sftp.NewClientPipe(respR, reqW)
+	require.NoError(t, err)
+	sftpFS := NewSFTPFS(client, nil)
+	t.Cleanup(func() {
+		// The client waits for the server to hang up before it closes
+		_ = server.Close()
+		_ = sftpFS.Close()
+		<-done
+	})
+	return sftpFS, t.TempDir()
+}
+
+func TestSFTPFS_Files(t *testing.T) {
+	ctx := context.Background()
+	sftpFS, home := newTestSFTPFS(t)
+	vimrc := filepath.Join(home, ".vimrc")
+
+	// Contents larger than a packet are written and read in pieces
+	data := bytes.Repeat([]byte("0123456789abcdef"), 5000)
+	require.NoError(t, sftpFS.WriteFile(ctx, vimrc, data, 0600))
+	got, err := sftpFS.ReadFile(ctx, vimrc)
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+
+	info, err := sftpFS.Stat(ctx, vimrc)
+	require.NoError(t, err)
+	assert.Equal(t, ".vimrc", info.Name())
+	assert.Equal(t, int64(len(data)), info.Size())
+	assert.Equal(t, fs.FileMode(0600), info.Mode())
+	assert.True(t, sftpFS.Exists(ctx, vimrc))
+
+	// Rewriting a file keeps its mode, as os.WriteFile does
+	require.NoError(t, sftpFS.WriteFile(ctx, vimrc, []byte("set nu"), 0644))
+	info, err = sftpFS.Stat(ctx, vimrc)
+	require.NoError(t, err)
+	assert.Equal(t, fs.FileMode(0600), info.Mode())
+
+	empty := filepath.Join(home, "empty")
+	require.NoError(t, sftpFS.WriteFile(ctx, empty, nil, 0644))
+	got, err = sftpFS.ReadFile(ctx, empty)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+
+	require.NoError(t, sftpFS.Chmod(ctx, vimrc, 0644))
+	mtime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, sftpFS.Chtimes(ctx, vimrc, mtime, mtime))
+	info, err = sftpFS.Stat(ctx, vimrc)
+	require.NoError(t, err)
+	assert.Equal(t, fs.FileMode(0644), info.Mode())
+	assert.True(t, mtime.Equal(info.ModTime().(time.Time)))
+
+	// Missing files are reported as os.IsNotExist expects
+	missing := filepath.Join(home, "missing")
+	_, err = sftpFS.ReadFile(ctx, missing)
+	assert.True(t, os.IsNotExist(err))
+	_, err = sftpFS.Stat(ctx, missing)
+	assert.True(t, os.IsNotExist(err))
+	assert.ErrorContains(t, err, missing)
+	assert.False(t, sftpFS.Exists(ctx, missing))
+}
+
+func TestSFTPFS_DirectoriesAndLinks(t *testing.T) {
+	ctx := context.Background()
+	sftpFS, home := newTestSFTPFS(t)
+	vim := filepath.Join(home, ".dotfiles", "vim")
+
+	require.NoError(t, sftpFS.MkdirAll(ctx, vim, 0700))
+	require.NoError(t, sftpFS.MkdirAll(ctx, vim, 0700))
+	info, err := sftpFS.Stat(ctx, vim)
+	require.NoError(t, err)
+	assert.True(t, info.IsDir())
+	assert.Equal(t, fs.FileMode(0700), info.Mode().Perm(), "the mode is not left to the server")
+
+	require.NoError(t, sftpFS.WriteFile(ctx, filepath.Join(vim, "dot-vimrc"), []byte("set nu"), 0644))
+	require.NoError(t, sftpFS.WriteFile(ctx, filepath.Join(vim, "b"), nil, 0644))
+	vimrc := filepath.Join(home, ".vimrc")
+	require.NoError(t, sftpFS.Symlink(ctx, ".dotfiles/vim/dot-vimrc", vimrc))
+
+	target, err := sftpFS.ReadLink(ctx, vimrc)
+	require.NoError(t, err)
+	assert.Equal(t, ".dotfiles/vim/dot-vimrc", target)
+	isLink, err := sftpFS.IsSymlink(ctx, vimrc)
+	require.NoError(t, err)
+	assert.True(t, isLink)
+
+	// Entries are sorted and describe themselves, not what they link to
+	entries, err := sftpFS.ReadDir(ctx, home)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, ".dotfiles", entries[0].Name())
+	assert.True(t, entries[0].IsDir())
+	assert.Equal(t, ".vimrc", entries[1].Name())
+	assert.Equal(t, fs.ModeSymlink, entries[1].Type())
+
+	entries, err = sftpFS.ReadDir(ctx, vim)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "b", entries[0].Name())
+	assert.Equal(t, "dot-vimrc", entries[1].Name())
+
+	// Remove takes files, links and empty directories
+	require.NoError(t, sftpFS.Remove(ctx, vimrc))
+	_, err = os.Lstat(vimrc)
+	assert.True(t, os.IsNotExist(err))
+	assert.Error(t, sftpFS.Remove(ctx, filepath.Join(home, ".dotfiles")))
+
+	require.NoError(t, sftpFS.RemoveAll(ctx, filepath.Join(home, ".dotfiles")))
+	_, err = os.Lstat(filepath.Join(home, ".dotfiles"))
+	assert.True(t, os.IsNotExist(err))
+	require.NoError(t, sftpFS.RemoveAll(ctx, filepath.Join(home, ".dotfiles")))
+}
+
+func TestSFTPFS_Rename(t *testing.T) {
+	ctx := context.Background()
+	sftpFS, home := newTestSFTPFS(t)
+	a, b := filepath.Join(home, "a"), filepath.Join(home, "b")
+	require.NoError(t, os.WriteFile(a, []byte("new"), 0644))
+	require.NoError(t, os.WriteFile(b, []byte("old"), 0644))
+
+	// The server supports posix-rename, which replaces b
+	require.NoError(t, sftpFS.Rename(ctx, a, b))
+	data, err := os.ReadFile(b)
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(data))
+	_, err = os.Stat(a)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestSFTPFS_CanceledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	sftpFS, home := newTestSFTPFS(t)
+
+	_, err := sftpFS.ReadFile(ctx, filepath.Join(home, "a"))
+	assert.ErrorIs(t, err, context.Canceled)
+	err = sftpFS.WriteFile(ctx, filepath.Join(home, "a"), nil, 0644)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = os.Stat(filepath.Join(home, "a"))
+	assert.True(t, os.IsNotExist(err), "nothing is sent once ctx is done")
+}
@@ -16,10 +16,11 @@ type SlogLogger struct {
 	logger *slog.Logger
 }
 
-// NewSlogLogger creates a new slog logger adapter.
+// NewSlogLogger creates a new slog logger adapter. Records are given the
+// log fields of their context, as NewContextHandler does.
 func NewSlogLogger(logger *slog.Logger) *SlogLogger {
 	return &SlogLogger{
-		logger: logger,
+		logger: slog.New(NewContextHandler(logger.Handler())),
 	}
 }
 
@@ -31,9 +32,7 @@ func NewConsoleLogger(w io.Writer, level string) *SlogLogger {
 		Level: logLevel,
 	})
 
-	return &SlogLogger{
-		logger: slog.New(handler),
-	}
+	return NewSlogLogger(slog.New(handler))
 }
 
 // Debug logs a debug-level message.
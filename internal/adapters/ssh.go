@@ -0,0 +1,129 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// SSHTarget names an account on a host reached over SSH.
+type SSHTarget struct {
+	User string
+	Host string
+	Port int
+}
+
+// Addr returns the host and port to dial, the port defaulting to 22.
+func (t SSHTarget) Addr() string {
+	port := t.Port
+	if port == 0 {
+		port = 22
+	}
+	return net.JoinHostPort(t.Host, strconv.Itoa(port))
+}
+
+// sshKeyFiles are the private keys tried, in ~/.ssh, after those of the
+// SSH agent, as ssh(1) tries them.
+var sshKeyFiles = []string{"id_ed25519", "id_ecdsa", "id_rsa"}
+
+// DialSFTP connects to target over SSH and opens an SFTP session on it.
+// It authenticates with the keys of the SSH agent named by SSH_AUTH_SOCK
+// and with the unencrypted keys in ~/.ssh, and accepts only a host key
+// recorded in ~/.ssh/known_hosts. Closing the filesystem closes the
+// connection.
+func DialSFTP(ctx context.Context, target SSHTarget) (*SFTPFS, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("ssh: %w", err)
+	}
+
+	knownHosts := filepath.Join(home, ".ssh", "known_hosts")
+	hostKeys, err := knownhosts.New(knownHosts)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("ssh: no %s to check the key of %s: connect once with ssh to record it", knownHosts, target.Host)
+		}
+		return nil, fmt.Errorf("ssh: read known hosts: %w", err)
+	}
+
+	auth, closeAgent := sshAuthMethods(filepath.Join(home, ".ssh"))
+	defer closeAgent()
+
+	user := target.User
+	if user == "" {
+		user = os.Getenv("USER")
+	}
+	config := &ssh.ClientConfig{
+		User:            user,
+		Auth:            auth,
+		HostKeyCallback: hostKeys,
+	}
+
+	addr := target.Addr()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("ssh: %w", err)
+	}
+	// The handshake has no context of its own: closing the connection
+	// interrupts it
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if !stop() {
+		err = errors.Join(err, ctx.Err())
+	}
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("ssh: connect to %s: %w", addr, err)
+	}
+	client := ssh.NewClient(sshConn, chans, reqs)
+
+	sftpClient, err := sftp.NewClient(client)
+	if err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("ssh: start sftp on %s: %w", target.Host, err)
+	}
+	return NewSFTPFS(sftpClient, client), nil
+}
+
+// sshAuthMethods returns the ways of authenticating: the keys of the SSH
+// agent, then those of sshDir that need no passphrase. The returned
+// function closes the connection to the agent, once authenticated.
+func sshAuthMethods(sshDir string) ([]ssh.AuthMethod, func()) {
+	var methods []ssh.AuthMethod
+	closeAgent := func() {}
+
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		if conn, err := net.Dial("unix", sock); err == nil {
+			methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+			closeAgent = func() { _ = conn.Close() }
+		}
+	}
+
+	var signers []ssh.Signer
+	for _, name := range sshKeyFiles {
+		data, err := os.ReadFile(filepath.Join(sshDir, name))
+		if err != nil {
+			continue
+		}
+		// Keys protected by a passphrase are left to the agent
+		signer, err := ssh.ParsePrivateKey(data)
+		if err != nil {
+			continue
+		}
+		signers = append(signers, signer)
+	}
+	if len(signers) > 0 {
+		methods = append(methods, ssh.PublicKeys(signers...))
+	}
+	return methods, closeAgent
+}
@@ -0,0 +1,126 @@
+package adapters
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/jamesainslie/dot/internal/domain"
+)
+
+// SpanData is a finished span recorded by a RecordingTracer.
+type SpanData struct {
+	// TraceID and SpanID are hex-encoded identifiers of 16 and 8 bytes.
+	// ParentSpanID is empty for root spans.
+	TraceID      string
+	SpanID       string
+	ParentSpanID string
+
+	Name       string
+	Start      time.Time
+	End        time.Time
+	Attributes []domain.Attribute
+
+	// Err is the message of the first error recorded on the span, or
+	// empty if none was.
+	Err string
+}
+
+// RecordingTracer is a tracer that keeps finished spans in memory, so
+// exporters can send them when a command finishes. Spans started from a
+// context holding a span of this tracer are its children. It is safe for
+// concurrent use.
+type RecordingTracer struct {
+	mu    sync.Mutex
+	spans []SpanData
+}
+
+// NewRecordingTracer creates a tracer with no recorded spans.
+func NewRecordingTracer() *RecordingTracer {
+	return &RecordingTracer{}
+}
+
+// spanContextKey is the context key of the current recording span.
+type spanContextKey struct{}
+
+func (t *RecordingTracer) Start(ctx context.Context, name string, opts ...domain.SpanOption) (context.Context, domain.Span) {
+	var cfg domain.SpanConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	span := &recordingSpan{
+		tracer: t,
+		data: SpanData{
+			SpanID:     randomID(8),
+			Name:       name,
+			Start:      time.Now(),
+			Attributes: slices.Clone(cfg.Attributes),
+		},
+	}
+	if parent, ok := ctx.Value(spanContextKey{}).(*recordingSpan); ok && parent.tracer == t {
+		span.data.TraceID = parent.data.TraceID
+		span.data.ParentSpanID = parent.data.SpanID
+	} else {
+		span.data.TraceID = randomID(16)
+	}
+	return context.WithValue(ctx, spanContextKey{}, span), span
+}
+
+// Spans returns the finished spans in the order they ended.
+func (t *RecordingTracer) Spans() []SpanData {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return slices.Clone(t.spans)
+}
+
+// recordingSpan is a span of a RecordingTracer.
+type recordingSpan struct {
+	tracer *RecordingTracer
+	mu     sync.Mutex
+	data   SpanData
+	ended  bool
+}
+
+func (s *recordingSpan) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mu.Unlock()
+
+	s.tracer.mu.Lock()
+	s.tracer.spans = append(s.tracer.spans, data)
+	s.tracer.mu.Unlock()
+}
+
+func (s *recordingSpan) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data.Err == "" {
+		s.data.Err = err.Error()
+	}
+}
+
+func (s *recordingSpan) SetAttributes(attrs ...domain.Attribute) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Attributes = append(s.data.Attributes, attrs...)
+}
+
+// randomID returns n random bytes, hex-encoded.
+func randomID(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,253 @@
+// Package audit records the operations dot executes in an append-only
+// log, one JSON record per line, and reads them back.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/jamesainslie/dot/internal/domain"
+)
+
+// Result is the outcome of an audited operation.
+type Result string
+
+const (
+	// ResultSucceeded marks operations that executed.
+	ResultSucceeded Result = "succeeded"
+	// ResultFailed marks operations that failed to execute.
+	ResultFailed Result = "failed"
+	// ResultRolledBack marks executed operations that were undone.
+	ResultRolledBack Result = "rolled_back"
+	// ResultRollbackFailed marks executed operations that could not be
+	// undone.
+	ResultRollbackFailed Result = "rollback_failed"
+)
+
+// Record is one entry of the audit log.
+type Record struct {
+	Time time.Time `json:"time"`
+	User string    `json:"user,omitempty"`
+
+	PlanID       string `json:"plan_id,omitempty"`
+	CheckpointID string `json:"checkpoint_id,omitempty"`
+
+	OperationID string `json:"operation_id"`
+	Kind        string `json:"kind"`
+	// Operation describes the operation, such as "create link ~/.vimrc -> ...".
+	Operation string `json:"operation"`
+	Package   string `json:"package,omitempty"`
+
+	// Source, Target and Path are the paths the operation acted on, as in
+	// domain.OperationRecord, so that it can be reconstructed. Rendered
+	// template content is never recorded.
+	Source string `json:"source,omitempty"`
+	Target string `json:"target,omitempty"`
+	Path   string `json:"path,omitempty"`
+
+	Result Result `json:"result"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ToOperation reconstructs the recorded operation from its paths. File
+// renders come back without their content.
+func (r Record) ToOperation() (domain.Operation, error) {
+	return domain.OperationRecord{
+		ID:     domain.OperationID(r.OperationID),
+		Kind:   r.Kind,
+		Source: r.Source,
+		Target: r.Target,
+		Path:   r.Path,
+	}.Operation()
+}
+
+// Filter selects audit records. Zero fields select everything.
+type Filter struct {
+	// Package selects the records of one package.
+	Package string
+
+	// Since and Until bound the time of records, Since inclusive and
+	// Until exclusive.
+	Since time.Time
+	Until time.Time
+
+	// Limit keeps only the most recent records.
+	Limit int
+}
+
+// Matches reports whether r is selected by f, ignoring Limit.
+func (f Filter) Matches(r Record) bool {
+	if f.Package != "" && r.Package != f.Package {
+		return false
+	}
+	if !f.Since.IsZero() && r.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !r.Time.Before(f.Until) {
+		return false
+	}
+	return true
+}
+
+// Log is an append-only audit log stored at a path. It is safe for
+// concurrent use and implements domain.EventSink, recording each finished
+// or rolled back operation.
+type Log struct {
+	fs   domain.FS
+	path string
+	log  domain.Logger
+	user string
+
+	mu sync.Mutex
+}
+
+// NewLog creates an audit log at path recording operations as the current
+// user. Failures to record events are logged to logger.
+func NewLog(fs domain.FS, path string, logger domain.Logger) *Log {
+	return &Log{fs: fs, path: path, log: logger, user: currentUser()}
+}
+
+// Path returns the path of the log file.
+func (l *Log) Path() string {
+	return l.path
+}
+
+// OnEvent records the outcome of the operation of event. Started events
+// are not recorded. A failure to record never interrupts execution.
+func (l *Log) OnEvent(ctx context.Context, event domain.ExecutionEvent) {
+	if event.Kind == domain.EventStarted || event.Operation == nil {
+		return
+	}
+	if err := l.Append(ctx, l.record(event)); err != nil && l.log != nil {
+		l.log.Warn(ctx, "audit_write_failed", "path", l.path, "error", err)
+	}
+}
+
+// record converts event to an audit record.
+func (l *Log) record(event domain.ExecutionEvent) Record {
+	op := event.Operation
+	r := Record{
+		Time:         time.Now().UTC(),
+		User:         l.user,
+		PlanID:       event.PlanID,
+		CheckpointID: event.CheckpointID,
+		OperationID:  string(op.ID()),
+		Kind:         op.Kind().String(),
+		Operation:    op.String(),
+		Package:      event.Package,
+	}
+	if rec, err := domain.NewOperationRecord(op); err == nil {
+		r.Source, r.Target, r.Path = rec.Source, rec.Target, rec.Path
+	}
+	switch {
+	case event.Kind == domain.EventRolledBack && event.Err != nil:
+		r.Result = ResultRollbackFailed
+	case event.Kind == domain.EventRolledBack:
+		r.Result = ResultRolledBack
+	case event.Kind == domain.EventFailed:
+		r.Result = ResultFailed
+	default:
+		r.Result = ResultSucceeded
+	}
+	if event.Err != nil {
+		r.Error = event.Err.Error()
+	}
+	return r
+}
+
+// Append adds records to the end of the log, creating it if needed.
+func (l *Log) Append(ctx context.Context, records ...Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	for _, r := range records {
+		data, err := json.Marshal(r)
+		if err != nil {
+			return fmt.Errorf("encode audit record: %w", err)
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.fs.MkdirAll(ctx, filepath.Dir(l.path), 0700); err != nil {
+		return fmt.Errorf("create audit log directory: %w", err)
+	}
+	if appender, ok := l.fs.(domain.AppendFS); ok {
+		if err := appender.AppendFile(ctx, l.path, buf.Bytes(), 0600); err != nil {
+			return fmt.Errorf("append audit log: %w", err)
+		}
+		return nil
+	}
+
+	// Filesystems that cannot append get the log rewritten
+	existing, err := l.fs.ReadFile(ctx, l.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("read audit log: %w", err)
+	}
+	if err := l.fs.WriteFile(ctx, l.path, append(existing, buf.Bytes()...), 0600); err != nil {
+		return fmt.Errorf("write audit log: %w", err)
+	}
+	return nil
+}
+
+// Read returns the records selected by filter, oldest first. A missing
+// log has no records. Lines that cannot be decoded, such as one cut short
+// by a crash, are skipped.
+func (l *Log) Read(ctx context.Context, filter Filter) ([]Record, error) {
+	data, err := l.fs.ReadFile(ctx, l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Record{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read audit log: %w", err)
+	}
+
+	records := []Record{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var r Record
+		if err := json.Unmarshal(line, &r); err != nil {
+			continue
+		}
+		if filter.Matches(r) {
+			records = append(records, r)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read audit log: %w", err)
+	}
+
+	if filter.Limit > 0 && len(records) > filter.Limit {
+		records = records[len(records)-filter.Limit:]
+	}
+	return records, nil
+}
+
+// currentUser returns the name of the user running dot.
+func currentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
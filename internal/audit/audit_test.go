@@ -0,0 +1,127 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/internal/domain"
+)
+
+// plainFS hides the optional capabilities of the filesystem it wraps.
+type plainFS struct {
+	domain.FS
+}
+
+func testLink(id string) domain.Operation {
+	return domain.NewLinkCreate(domain.OperationID(id), domain.MustParsePath("/packages/vim/dot-vimrc"), domain.MustParseTargetPath("/home/.vimrc"))
+}
+
+func TestLog_OnEvent(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	log := NewLog(fs, "/state/dot/audit.jsonl", adapters.NewNoopLogger())
+	log.user = "alice"
+
+	base := domain.ExecutionEvent{Operation: testLink("link1"), PlanID: "plan-1", CheckpointID: "cp-1", Package: "vim"}
+	for _, event := range []struct {
+		kind domain.ExecutionEventKind
+		err  error
+	}{
+		{domain.EventStarted, nil},
+		{domain.EventSucceeded, nil},
+		{domain.EventFailed, errors.New("permission denied")},
+		{domain.EventRolledBack, nil},
+		{domain.EventRolledBack, errors.New("busy")},
+	} {
+		e := base
+		e.Kind, e.Err = event.kind, event.err
+		log.OnEvent(ctx, e)
+	}
+
+	records, err := log.Read(ctx, Filter{})
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+
+	first := records[0]
+	assert.Equal(t, "alice", first.User)
+	assert.Equal(t, "plan-1", first.PlanID)
+	assert.Equal(t, "cp-1", first.CheckpointID)
+	assert.Equal(t, "link1", first.OperationID)
+	assert.Equal(t, "LinkCreate", first.Kind)
+	assert.Equal(t, "vim", first.Package)
+	assert.Contains(t, first.Operation, "/home/.vimrc")
+	assert.False(t, first.Time.IsZero())
+
+	var results []Result
+	for _, r := range records {
+		results = append(results, r.Result)
+	}
+	assert.Equal(t, []Result{ResultSucceeded, ResultFailed, ResultRolledBack, ResultRollbackFailed}, results)
+	assert.Equal(t, "permission denied", records[1].Error)
+	assert.Equal(t, "busy", records[3].Error)
+}
+
+func TestLog_AppendWithoutAppendFS(t *testing.T) {
+	ctx := context.Background()
+	fs := plainFS{adapters.NewMemFS()}
+	log := NewLog(fs, "/state/audit.jsonl", nil)
+
+	require.NoError(t, log.Append(ctx, Record{OperationID: "a"}))
+	require.NoError(t, log.Append(ctx, Record{OperationID: "b"}, Record{OperationID: "c"}))
+
+	records, err := log.Read(ctx, Filter{})
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, "c", records[2].OperationID)
+}
+
+func TestLog_ReadFilter(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	log := NewLog(fs, "/audit.jsonl", nil)
+
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, log.Append(ctx,
+		Record{OperationID: "1", Package: "vim", Time: day},
+		Record{OperationID: "2", Package: "zsh", Time: day.Add(24 * time.Hour)},
+		Record{OperationID: "3", Package: "vim", Time: day.Add(48 * time.Hour)},
+		Record{OperationID: "4", Package: "vim", Time: day.Add(72 * time.Hour)},
+	))
+
+	ids := func(filter Filter) []string {
+		records, err := log.Read(ctx, filter)
+		require.NoError(t, err)
+		out := []string{}
+		for _, r := range records {
+			out = append(out, r.OperationID)
+		}
+		return out
+	}
+
+	assert.Equal(t, []string{"1", "3", "4"}, ids(Filter{Package: "vim"}))
+	assert.Equal(t, []string{"2", "3"}, ids(Filter{Since: day.Add(24 * time.Hour), Until: day.Add(72 * time.Hour)}))
+	assert.Equal(t, []string{"3", "4"}, ids(Filter{Package: "vim", Limit: 2}))
+}
+
+func TestLog_ReadSkipsTornLines(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	require.NoError(t, fs.WriteFile(ctx, "/audit.jsonl", []byte("{\"operation_id\":\"a\"}\n\n{\"operation_id\":\"b\""), 0600))
+
+	records, err := NewLog(fs, "/audit.jsonl", nil).Read(ctx, Filter{})
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "a", records[0].OperationID)
+}
+
+func TestLog_ReadMissing(t *testing.T) {
+	records, err := NewLog(adapters.NewMemFS(), "/none.jsonl", nil).Read(context.Background(), Filter{})
+	require.NoError(t, err)
+	assert.Empty(t, records)
+}
@@ -0,0 +1,99 @@
+package audit
+
+import (
+	"strings"
+	"time"
+)
+
+// undoPrefix starts the IDs of plans that undo another plan.
+const undoPrefix = "undo-"
+
+// UndoPlanID returns the ID of the plan undoing the plan with id.
+func UndoPlanID(id string) string {
+	return undoPrefix + id
+}
+
+// Plan is an executed plan as recorded in the audit log.
+type Plan struct {
+	ID           string
+	CheckpointID string
+	User         string
+
+	// Time is when the last operation of the plan was recorded.
+	Time time.Time
+
+	// Applied are the records of the operations that succeeded and were
+	// not rolled back afterwards, in execution order.
+	Applied []Record
+
+	// Undoes is the ID of the plan this plan undoes, if it is an undo.
+	Undoes string
+
+	// UndoneBy is the ID of the plan that undid this one, if any.
+	UndoneBy string
+}
+
+// Packages returns the packages of the applied operations, in order of
+// first appearance.
+func (p Plan) Packages() []string {
+	seen := map[string]bool{}
+	var packages []string
+	for _, r := range p.Applied {
+		if r.Package != "" && !seen[r.Package] {
+			seen[r.Package] = true
+			packages = append(packages, r.Package)
+		}
+	}
+	return packages
+}
+
+// Plans groups records by plan, oldest first. Records without a plan ID
+// are left out.
+func Plans(records []Record) []Plan {
+	index := map[string]int{}
+	var plans []Plan
+	for _, r := range records {
+		if r.PlanID == "" {
+			continue
+		}
+		i, ok := index[r.PlanID]
+		if !ok {
+			i = len(plans)
+			index[r.PlanID] = i
+			plan := Plan{ID: r.PlanID, CheckpointID: r.CheckpointID, User: r.User}
+			if undone, isUndo := strings.CutPrefix(r.PlanID, undoPrefix); isUndo {
+				plan.Undoes = undone
+			}
+			plans = append(plans, plan)
+		}
+
+		plan := &plans[i]
+		plan.Time = r.Time
+		switch r.Result {
+		case ResultSucceeded:
+			plan.Applied = append(plan.Applied, r)
+		case ResultRolledBack:
+			plan.Applied = withoutOperation(plan.Applied, r.OperationID)
+		}
+	}
+
+	for _, plan := range plans {
+		if plan.Undoes == "" || len(plan.Applied) == 0 {
+			continue
+		}
+		if i, ok := index[plan.Undoes]; ok {
+			plans[i].UndoneBy = plan.ID
+		}
+	}
+	return plans
+}
+
+// withoutOperation removes the last record of the operation id.
+func withoutOperation(records []Record, id string) []Record {
+	for i := len(records) - 1; i >= 0; i-- {
+		if records[i].OperationID == id {
+			return append(records[:i:i], records[i+1:]...)
+		}
+	}
+	return records
+}
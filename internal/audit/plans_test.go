@@ -0,0 +1,43 @@
+package audit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlans(t *testing.T) {
+	records := []Record{
+		{PlanID: "p1", CheckpointID: "c1", OperationID: "a", Package: "vim", Result: ResultSucceeded},
+		{PlanID: "p1", OperationID: "b", Package: "zsh", Result: ResultSucceeded},
+		{OperationID: "orphan", Result: ResultSucceeded},
+		{PlanID: "p2", OperationID: "c", Result: ResultSucceeded},
+		{PlanID: "p2", OperationID: "d", Result: ResultFailed},
+		{PlanID: "p2", OperationID: "c", Result: ResultRolledBack},
+		{PlanID: UndoPlanID("p1"), OperationID: "undo-b", Result: ResultSucceeded},
+	}
+
+	plans := Plans(records)
+	require.Len(t, plans, 3)
+
+	assert.Equal(t, "p1", plans[0].ID)
+	assert.Equal(t, "c1", plans[0].CheckpointID)
+	assert.Len(t, plans[0].Applied, 2)
+	assert.Equal(t, []string{"vim", "zsh"}, plans[0].Packages())
+	assert.Equal(t, "undo-p1", plans[0].UndoneBy)
+
+	assert.Empty(t, plans[1].Applied, "rolled back operations are not applied")
+
+	assert.Equal(t, "p1", plans[2].Undoes)
+}
+
+func TestPlans_RolledBackUndo(t *testing.T) {
+	plans := Plans([]Record{
+		{PlanID: "p1", OperationID: "a", Result: ResultSucceeded},
+		{PlanID: UndoPlanID("p1"), OperationID: "undo-a", Result: ResultSucceeded},
+		{PlanID: UndoPlanID("p1"), OperationID: "undo-a", Result: ResultRolledBack},
+	})
+	require.Len(t, plans, 2)
+	assert.Empty(t, plans[0].UndoneBy, "an undo that was rolled back undid nothing")
+}
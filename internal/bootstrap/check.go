@@ -0,0 +1,132 @@
+package bootstrap
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Problem describes an error in a bootstrap configuration.
+type Problem struct {
+	// Field is the path of the offending field, such as
+	// "packages[1].platform[0]", or empty for the file as a whole.
+	Field string
+
+	// Line is the line of the field in the file, or zero if unknown.
+	Line int
+
+	// Message describes the problem.
+	Message string
+}
+
+// String formats the problem as "line 3: packages[1].name: message".
+func (p Problem) String() string {
+	var b strings.Builder
+	if p.Line > 0 {
+		fmt.Fprintf(&b, "line %d: ", p.Line)
+	}
+	if p.Field != "" {
+		b.WriteString(p.Field + ": ")
+	}
+	b.WriteString(p.Message)
+	return b.String()
+}
+
+// unknownFieldPattern matches the errors yaml reports for fields missing
+// from the target type.
+var unknownFieldPattern = regexp.MustCompile(`^line (\d+): field (\S+) not found in type \S+$`)
+
+// fieldPathPattern splits a Problem field path into keys and indices.
+var fieldPathPattern = regexp.MustCompile(`[^.\[\]]+|\[\d+\]`)
+
+// Check parses the bootstrap configuration in data and returns every
+// problem found, with the line it is on. Unlike Load, fields the schema
+// does not define are reported, so a repository author notices misspelled
+// or unsupported settings before they are silently ignored during clone.
+// A configuration without problems returns an empty slice.
+func Check(data []byte) []Problem {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return []Problem{{Message: fmt.Sprintf("parse YAML: %s", strings.TrimPrefix(err.Error(), "yaml: "))}}
+	}
+
+	problems := []Problem{}
+	var cfg Config
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&cfg); err != nil {
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			// An empty file has no document to decode
+			if len(root.Content) > 0 {
+				return []Problem{{Message: fmt.Sprintf("parse YAML: %s", err)}}
+			}
+		} else {
+			for _, msg := range typeErr.Errors {
+				problems = append(problems, typeProblem(msg))
+			}
+		}
+	}
+
+	for _, p := range cfg.Problems() {
+		if node := lookup(&root, p.Field); node != nil {
+			p.Line = node.Line
+		}
+		problems = append(problems, p)
+	}
+	return problems
+}
+
+// typeProblem converts an error reported by the yaml decoder.
+func typeProblem(msg string) Problem {
+	if m := unknownFieldPattern.FindStringSubmatch(msg); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		return Problem{Line: line, Message: fmt.Sprintf("unknown field %q", m[2])}
+	}
+	var line int
+	if _, err := fmt.Sscanf(msg, "line %d:", &line); err == nil {
+		_, msg, _ = strings.Cut(msg, ": ")
+	}
+	return Problem{Line: line, Message: msg}
+}
+
+// lookup returns the node of the field path in the document root, or the
+// node of its nearest existing parent. It returns nil for an empty path.
+func lookup(root *yaml.Node, field string) *yaml.Node {
+	if field == "" || len(root.Content) == 0 {
+		return nil
+	}
+	node := root.Content[0]
+	for _, part := range fieldPathPattern.FindAllString(field, -1) {
+		next := child(node, part)
+		if next == nil {
+			return node
+		}
+		node = next
+	}
+	return node
+}
+
+// child returns the value of the key of a mapping node, or the element
+// "[i]" of a sequence node.
+func child(node *yaml.Node, part string) *yaml.Node {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == part {
+				return node.Content[i+1]
+			}
+		}
+	case yaml.SequenceNode:
+		i, err := strconv.Atoi(strings.Trim(part, "[]"))
+		if err == nil && i < len(node.Content) {
+			return node.Content[i]
+		}
+	}
+	return nil
+}
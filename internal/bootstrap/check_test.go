@@ -0,0 +1,75 @@
+package bootstrap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck_Valid(t *testing.T) {
+	data := []byte(`version: "1.0"
+packages:
+  - name: dot-vim
+    description: Vim editor
+    platform: [linux, darwin]
+  - name: dot-zsh
+    required: true
+profiles:
+  minimal:
+    description: Shell only
+    packages: [dot-zsh]
+defaults:
+  on_conflict: backup
+  profile: minimal
+`)
+	assert.Empty(t, Check(data))
+}
+
+func TestCheck_Problems(t *testing.T) {
+	data := []byte(`version: "2.0"
+packages:
+  - name: dot-vim
+    platfrom: [linux]
+  - name: dot-zsh
+    platform: [macos]
+    on_conflict: merge
+  - name: dot-vim
+profiles:
+  work:
+    packages: [dot-zsh, dot-git]
+defaults:
+  profile: full
+`)
+	problems := Check(data)
+
+	assert.Equal(t, []Problem{
+		{Line: 4, Message: `unknown field "platfrom"`},
+		{Field: "version", Line: 1, Message: `version "2.0" is not supported; this version of dot reads version 1.0`},
+		{Field: "packages[1].platform[0]", Line: 6, Message: `invalid platform "macos" for package dot-zsh; valid platforms are linux, darwin, windows, freebsd`},
+		{Field: "packages[1].on_conflict", Line: 7, Message: `invalid conflict policy "merge" for package dot-zsh`},
+		{Field: "packages[2].name", Line: 8, Message: "duplicate package name: dot-vim"},
+		{Field: "profiles.work.packages[1]", Line: 11, Message: `profile "work" references unknown package: dot-git`},
+		{Field: "defaults.profile", Line: 13, Message: `default profile "full" does not exist`},
+	}, problems)
+	assert.Equal(t, `line 8: packages[2].name: duplicate package name: dot-vim`, problems[4].String())
+}
+
+func TestCheck_Syntax(t *testing.T) {
+	problems := Check([]byte("version: \"1.0\"\npackages: [\n"))
+	require.Len(t, problems, 1)
+	assert.Contains(t, problems[0].Message, "parse YAML")
+
+	problems = Check([]byte("version: \"1.0\"\npackages:\n  - name: dot-vim\n    required: maybe\n"))
+	require.Len(t, problems, 1)
+	assert.Equal(t, 4, problems[0].Line)
+	assert.Contains(t, problems[0].Message, "cannot unmarshal")
+}
+
+func TestCheck_Empty(t *testing.T) {
+	problems := Check(nil)
+	require.Len(t, problems, 2)
+	assert.Equal(t, "version is required", problems[0].Message)
+	assert.Zero(t, problems[0].Line)
+	assert.Equal(t, "at least one package is required", problems[1].Message)
+}
@@ -6,7 +6,11 @@
 package bootstrap
 
 import (
+	"errors"
 	"fmt"
+	"slices"
+	"sort"
+	"strings"
 )
 
 // Config represents the bootstrap configuration for a dotfiles repository.
@@ -29,6 +33,9 @@ type PackageSpec struct {
 	// Name is the package directory name.
 	Name string `yaml:"name"`
 
+	// Description is shown when packages are selected interactively.
+	Description string `yaml:"description,omitempty"`
+
 	// Required indicates if this package must be installed.
 	Required bool `yaml:"required"`
 
@@ -60,10 +67,14 @@ type Defaults struct {
 	Profile string `yaml:"profile"`
 }
 
-// Validate checks the configuration for errors.
+// SupportedVersions lists the bootstrap config schema versions this
+// version of dot reads.
+var SupportedVersions = []string{"1.0"}
+
+// Validate checks the configuration for errors and returns the first one.
 //
 // Returns an error if:
-//   - Version is missing or empty
+//   - Version is missing, empty or not supported
 //   - No packages are defined
 //   - Package names are empty or duplicated
 //   - Invalid platform names are used
@@ -71,112 +82,102 @@ type Defaults struct {
 //   - Profiles reference non-existent packages
 //   - Default profile does not exist
 func (c Config) Validate() error {
-	// Check version
-	if c.Version == "" {
-		return fmt.Errorf("version is required")
-	}
-
-	// Check packages exist
-	if len(c.Packages) == 0 {
-		return fmt.Errorf("at least one package is required")
+	if problems := c.Problems(); len(problems) > 0 {
+		return errors.New(problems[0].Message)
 	}
+	return nil
+}
 
-	// Validate packages and build name set
-	packageNames, err := c.validatePackages()
-	if err != nil {
-		return err
+// Problems checks the configuration for the errors reported by Validate
+// and returns all of them, in the order of the fields in the file. The
+// Line of each problem is zero.
+func (c Config) Problems() []Problem {
+	var problems []Problem
+	add := func(field, format string, args ...any) {
+		problems = append(problems, Problem{Field: field, Message: fmt.Sprintf(format, args...)})
 	}
 
-	// Validate defaults
-	if err := c.validateDefaults(); err != nil {
-		return err
+	// Check version
+	switch {
+	case c.Version == "":
+		add("version", "version is required")
+	case !slices.Contains(SupportedVersions, c.Version):
+		add("version", "version %q is not supported; this version of dot reads version %s",
+			c.Version, strings.Join(SupportedVersions, ", "))
 	}
 
-	// Validate profiles reference valid packages
-	if err := c.validateProfiles(packageNames); err != nil {
-		return err
+	// Check packages exist
+	if len(c.Packages) == 0 {
+		add("packages", "at least one package is required")
 	}
 
-	return nil
-}
-
-// validatePackages validates all packages and returns their names.
-func (c Config) validatePackages() (map[string]bool, error) {
+	// Validate packages and build name set
 	packageNames := make(map[string]bool)
+	for i, pkg := range c.Packages {
+		field := fmt.Sprintf("packages[%d]", i)
 
-	for _, pkg := range c.Packages {
 		// Check package name
 		if pkg.Name == "" {
-			return nil, fmt.Errorf("package name cannot be empty")
-		}
-
-		// Check for duplicates
-		if packageNames[pkg.Name] {
-			return nil, fmt.Errorf("duplicate package name: %s", pkg.Name)
+			add(field+".name", "package name cannot be empty")
+		} else if packageNames[pkg.Name] {
+			add(field+".name", "duplicate package name: %s", pkg.Name)
 		}
 		packageNames[pkg.Name] = true
 
 		// Validate platforms
-		for _, platform := range pkg.Platform {
+		for j, platform := range pkg.Platform {
 			if !isValidPlatform(platform) {
-				return nil, fmt.Errorf("invalid platform %q for package %s", platform, pkg.Name)
+				add(fmt.Sprintf("%s.platform[%d]", field, j), "invalid platform %q for package %s; valid platforms are %s",
+					platform, pkg.Name, strings.Join(validPlatforms, ", "))
 			}
 		}
 
 		// Validate conflict policy
 		if pkg.ConflictPolicy != "" && !isValidConflictPolicy(pkg.ConflictPolicy) {
-			return nil, fmt.Errorf("invalid conflict policy %q for package %s", pkg.ConflictPolicy, pkg.Name)
+			add(field+".on_conflict", "invalid conflict policy %q for package %s", pkg.ConflictPolicy, pkg.Name)
 		}
 	}
 
-	return packageNames, nil
-}
+	// Validate profiles reference valid packages
+	profileNames := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		profileNames = append(profileNames, name)
+	}
+	sort.Strings(profileNames)
+	for _, profileName := range profileNames {
+		for i, pkgName := range c.Profiles[profileName].Packages {
+			if !packageNames[pkgName] {
+				add(fmt.Sprintf("profiles.%s.packages[%d]", profileName, i),
+					"profile %q references unknown package: %s", profileName, pkgName)
+			}
+		}
+	}
 
-// validateDefaults validates default configuration.
-func (c Config) validateDefaults() error {
-	// Validate default conflict policy
+	// Validate defaults
 	if c.Defaults.ConflictPolicy != "" && !isValidConflictPolicy(c.Defaults.ConflictPolicy) {
-		return fmt.Errorf("invalid conflict policy in defaults: %s", c.Defaults.ConflictPolicy)
+		add("defaults.on_conflict", "invalid conflict policy in defaults: %s", c.Defaults.ConflictPolicy)
 	}
-
-	// Validate default profile exists
 	if c.Defaults.Profile != "" {
 		if _, exists := c.Profiles[c.Defaults.Profile]; !exists {
-			return fmt.Errorf("default profile %q does not exist", c.Defaults.Profile)
+			add("defaults.profile", "default profile %q does not exist", c.Defaults.Profile)
 		}
 	}
 
-	return nil
+	return problems
 }
 
-// validateProfiles validates that profiles reference valid packages.
-func (c Config) validateProfiles(packageNames map[string]bool) error {
-	for profileName, profile := range c.Profiles {
-		for _, pkgName := range profile.Packages {
-			if !packageNames[pkgName] {
-				return fmt.Errorf("profile %q references unknown package: %s", profileName, pkgName)
-			}
-		}
-	}
-	return nil
-}
+// validPlatforms lists the supported platform names.
+var validPlatforms = []string{"linux", "darwin", "windows", "freebsd"}
+
+// validConflictPolicies lists the supported conflict policies.
+var validConflictPolicies = []string{"fail", "backup", "overwrite", "skip"}
 
 // isValidPlatform checks if a platform name is supported.
 func isValidPlatform(platform string) bool {
-	switch platform {
-	case "linux", "darwin", "windows", "freebsd":
-		return true
-	default:
-		return false
-	}
+	return slices.Contains(validPlatforms, platform)
 }
 
 // isValidConflictPolicy checks if a conflict policy is supported.
 func isValidConflictPolicy(policy string) bool {
-	switch policy {
-	case "fail", "backup", "overwrite", "skip":
-		return true
-	default:
-		return false
-	}
+	return slices.Contains(validConflictPolicies, policy)
 }
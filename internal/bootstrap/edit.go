@@ -0,0 +1,61 @@
+package bootstrap
+
+import (
+	"bytes"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// AddPackage appends spec to the packages of the bootstrap configuration
+// in data, keeping its comments and layout. It reports whether the
+// package was added; a package already listed is left unchanged.
+func AddPackage(data []byte, spec PackageSpec) ([]byte, bool, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, false, fmt.Errorf("parse bootstrap config: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, false, fmt.Errorf("bootstrap config is not a mapping")
+	}
+	root := doc.Content[0]
+
+	var packages *yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "packages" {
+			packages = root.Content[i+1]
+		}
+	}
+	if packages == nil {
+		packages = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "packages"}, packages)
+	}
+	if packages.Kind != yaml.SequenceNode {
+		return nil, false, fmt.Errorf("bootstrap config packages is not a list")
+	}
+
+	for _, entry := range packages.Content {
+		var existing PackageSpec
+		if err := entry.Decode(&existing); err == nil && existing.Name == spec.Name {
+			return data, false, nil
+		}
+	}
+
+	var entry yaml.Node
+	if err := entry.Encode(spec); err != nil {
+		return nil, false, fmt.Errorf("encode package %s: %w", spec.Name, err)
+	}
+	packages.Content = append(packages.Content, &entry)
+	packages.Style = 0
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, false, fmt.Errorf("write bootstrap config: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, false, fmt.Errorf("write bootstrap config: %w", err)
+	}
+	return buf.Bytes(), true, nil
+}
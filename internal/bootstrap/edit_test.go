@@ -0,0 +1,66 @@
+package bootstrap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+const editTestConfig = `# Shared dotfiles
+version: "1.0"
+packages:
+  # Editor
+  - name: dot-vim
+    required: true
+profiles:
+  minimal:
+    description: Editor only
+    packages:
+      - dot-vim
+`
+
+func TestAddPackage(t *testing.T) {
+	data, added, err := AddPackage([]byte(editTestConfig), PackageSpec{Name: "dot-zsh"})
+	require.NoError(t, err)
+	assert.True(t, added)
+
+	out := string(data)
+	assert.Contains(t, out, "# Shared dotfiles")
+	assert.Contains(t, out, "# Editor")
+
+	var cfg Config
+	require.NoError(t, yaml.Unmarshal(data, &cfg))
+	require.Len(t, cfg.Packages, 2)
+	assert.Equal(t, "dot-vim", cfg.Packages[0].Name)
+	assert.Equal(t, "dot-zsh", cfg.Packages[1].Name)
+	assert.Equal(t, []string{"dot-vim"}, cfg.Profiles["minimal"].Packages)
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestAddPackage_AlreadyListed(t *testing.T) {
+	data, added, err := AddPackage([]byte(editTestConfig), PackageSpec{Name: "dot-vim"})
+	require.NoError(t, err)
+	assert.False(t, added)
+	assert.Equal(t, editTestConfig, string(data))
+}
+
+func TestAddPackage_NoPackages(t *testing.T) {
+	data, added, err := AddPackage([]byte("version: \"1.0\"\n"), PackageSpec{Name: "dot-zsh"})
+	require.NoError(t, err)
+	assert.True(t, added)
+
+	var cfg Config
+	require.NoError(t, yaml.Unmarshal(data, &cfg))
+	require.Len(t, cfg.Packages, 1)
+	assert.Equal(t, "dot-zsh", cfg.Packages[0].Name)
+}
+
+func TestAddPackage_Invalid(t *testing.T) {
+	_, _, err := AddPackage([]byte("- a\n- b\n"), PackageSpec{Name: "dot-zsh"})
+	assert.ErrorContains(t, err, "not a mapping")
+
+	_, _, err = AddPackage([]byte("packages: dot-vim\n"), PackageSpec{Name: "dot-zsh"})
+	assert.ErrorContains(t, err, "not a list")
+}
@@ -86,3 +86,45 @@ func GetProfile(cfg Config, profileName string) ([]string, error) {
 	}
 	return profile.Packages, nil
 }
+
+// Subset returns the configuration restricted to the named packages.
+//
+// Profiles lose their references to other packages, and profiles left
+// empty are dropped. The default profile is cleared if it was dropped.
+func Subset(cfg Config, names []string) Config {
+	keep := make(map[string]bool, len(names))
+	for _, name := range names {
+		keep[name] = true
+	}
+
+	out := cfg
+	out.Packages = make([]PackageSpec, 0, len(cfg.Packages))
+	for _, pkg := range cfg.Packages {
+		if keep[pkg.Name] {
+			out.Packages = append(out.Packages, pkg)
+		}
+	}
+
+	out.Profiles = nil
+	for name, profile := range cfg.Profiles {
+		packages := make([]string, 0, len(profile.Packages))
+		for _, pkg := range profile.Packages {
+			if keep[pkg] {
+				packages = append(packages, pkg)
+			}
+		}
+		if len(packages) == 0 {
+			continue
+		}
+		if out.Profiles == nil {
+			out.Profiles = make(map[string]Profile, len(cfg.Profiles))
+		}
+		profile.Packages = packages
+		out.Profiles[name] = profile
+	}
+
+	if _, ok := out.Profiles[out.Defaults.Profile]; !ok {
+		out.Defaults.Profile = ""
+	}
+	return out
+}
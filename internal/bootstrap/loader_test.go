@@ -313,3 +313,31 @@ func TestGetProfile(t *testing.T) {
 		assert.Nil(t, packages)
 	})
 }
+
+func TestSubset(t *testing.T) {
+	config := Config{
+		Version: "1.0",
+		Packages: []PackageSpec{
+			{Name: "dot-vim"},
+			{Name: "dot-zsh"},
+			{Name: "dot-tmux"},
+		},
+		Profiles: map[string]Profile{
+			"minimal": {Description: "Minimal setup", Packages: []string{"dot-tmux"}},
+			"full":    {Description: "Full setup", Packages: []string{"dot-vim", "dot-zsh", "dot-tmux"}},
+		},
+		Defaults: Defaults{ConflictPolicy: "backup", Profile: "minimal"},
+	}
+
+	subset := Subset(config, []string{"dot-vim", "dot-zsh"})
+	assert.Equal(t, []string{"dot-vim", "dot-zsh"}, GetPackageNames(subset))
+	assert.Equal(t, map[string]Profile{
+		"full": {Description: "Full setup", Packages: []string{"dot-vim", "dot-zsh"}},
+	}, subset.Profiles)
+	assert.Equal(t, Defaults{ConflictPolicy: "backup"}, subset.Defaults)
+	assert.NoError(t, subset.Validate())
+
+	// The original is unchanged
+	assert.Len(t, config.Profiles["full"].Packages, 3)
+	assert.Equal(t, "minimal", config.Defaults.Profile)
+}
@@ -0,0 +1,17 @@
+package bootstrap
+
+import (
+	"bytes"
+	_ "embed"
+)
+
+//go:embed schema.json
+var schema []byte
+
+// Schema returns the JSON Schema of the bootstrap configuration file, for
+// editors that check YAML against a schema while it is written. Checks
+// that need the whole file, such as profiles referencing defined
+// packages, are only made by Check.
+func Schema() []byte {
+	return bytes.Clone(schema)
+}
@@ -0,0 +1,85 @@
+package bootstrap
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// schemaObject is the part of a JSON Schema object definition the tests
+// compare with the configuration types.
+type schemaObject struct {
+	Properties map[string]json.RawMessage `json:"properties"`
+}
+
+// yamlFields returns the yaml names of the fields of the struct type of v.
+func yamlFields(v any) []string {
+	typ := reflect.TypeOf(v)
+	names := make([]string, 0, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		name, _, _ := strings.Cut(typ.Field(i).Tag.Get("yaml"), ",")
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// keys returns the sorted property names of obj.
+func keys(obj schemaObject) []string {
+	names := make([]string, 0, len(obj.Properties))
+	for name := range obj.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestSchema_MatchesConfig(t *testing.T) {
+	var doc struct {
+		schemaObject
+		Definitions map[string]schemaObject `json:"definitions"`
+	}
+	require.NoError(t, json.Unmarshal(Schema(), &doc))
+
+	assert.Equal(t, yamlFields(Config{}), keys(doc.schemaObject))
+	assert.Equal(t, yamlFields(PackageSpec{}), keys(doc.Definitions["package"]))
+	assert.Equal(t, yamlFields(Profile{}), keys(doc.Definitions["profile"]))
+
+	var defaults schemaObject
+	require.NoError(t, json.Unmarshal(doc.Properties["defaults"], &defaults))
+	assert.Equal(t, yamlFields(Defaults{}), keys(defaults))
+}
+
+func TestSchema_Enums(t *testing.T) {
+	var doc struct {
+		Properties struct {
+			Version struct {
+				Enum []string `json:"enum"`
+			} `json:"version"`
+		} `json:"properties"`
+		Definitions struct {
+			Package struct {
+				Properties struct {
+					Platform struct {
+						Items struct {
+							Enum []string `json:"enum"`
+						} `json:"items"`
+					} `json:"platform"`
+				} `json:"properties"`
+			} `json:"package"`
+			ConflictPolicy struct {
+				Enum []string `json:"enum"`
+			} `json:"conflictPolicy"`
+		} `json:"definitions"`
+	}
+	require.NoError(t, json.Unmarshal(Schema(), &doc))
+
+	assert.Equal(t, SupportedVersions, doc.Properties.Version.Enum)
+	assert.Equal(t, validPlatforms, doc.Definitions.Package.Properties.Platform.Items.Enum)
+	assert.Equal(t, validConflictPolicies, doc.Definitions.ConflictPolicy.Enum)
+}
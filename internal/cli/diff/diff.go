@@ -0,0 +1,199 @@
+// Package diff renders line differences between file contents as unified
+// diffs for dry-run output.
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines shown around each change.
+const contextLines = 3
+
+// maxCells bounds the size of the table used to compare the changed middle
+// of two files, so very large files are reported as differing rather than
+// compared line by line.
+const maxCells = 1 << 22
+
+// binarySniffLen is how much of a file is checked for NUL bytes, as git does.
+const binarySniffLen = 8000
+
+// IsBinary reports whether data looks like binary content.
+func IsBinary(data []byte) bool {
+	return bytes.IndexByte(data[:min(len(data), binarySniffLen)], 0) >= 0
+}
+
+// Unified returns a unified diff turning old, named oldName, into new,
+// named newName. It returns "" when the contents are equal and a single
+// "Binary files ... differ" line when either is binary.
+func Unified(oldName, newName string, old, new []byte) string {
+	if bytes.Equal(old, new) {
+		return ""
+	}
+	if IsBinary(old) || IsBinary(new) {
+		return fmt.Sprintf("Binary files %s and %s differ\n", oldName, newName)
+	}
+
+	edits, ok := diffLines(splitLines(old), splitLines(new))
+	if !ok {
+		return fmt.Sprintf("Files %s and %s differ (too large to compare)\n", oldName, newName)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+	writeHunks(&sb, edits)
+	return sb.String()
+}
+
+// edit is one line of an edit script: kept (' '), removed ('-') or
+// added ('+').
+type edit struct {
+	op   byte
+	line string
+}
+
+// splitLines splits data into lines, each keeping its newline. The last
+// line has none if data does not end with one.
+func splitLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns the edit script turning a into b. Lines common to the
+// start and end of both are kept without comparison; ok is false when the
+// rest is too large to compare.
+func diffLines(a, b []string) (edits []edit, ok bool) {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if (len(midA)+1)*(len(midB)+1) > maxCells {
+		return nil, false
+	}
+
+	edits = make([]edit, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		edits = append(edits, edit{op: ' ', line: line})
+	}
+	edits = append(edits, lcsEdits(midA, midB)...)
+	for _, line := range a[len(a)-suffix:] {
+		edits = append(edits, edit{op: ' ', line: line})
+	}
+	return edits, true
+}
+
+// lcsEdits returns the edit script turning a into b that keeps their
+// longest common subsequence of lines.
+func lcsEdits(a, b []string) []edit {
+	n, m := len(a), len(b)
+	// lengths[i][j] is the length of the longest common subsequence of
+	// a[i:] and b[j:]
+	lengths := make([][]int, n+1)
+	for i := range lengths {
+		lengths[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	edits := make([]edit, 0, n+m)
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			edits = append(edits, edit{op: ' ', line: a[i]})
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			edits = append(edits, edit{op: '-', line: a[i]})
+			i++
+		default:
+			edits = append(edits, edit{op: '+', line: b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		edits = append(edits, edit{op: '-', line: a[i]})
+	}
+	for ; j < m; j++ {
+		edits = append(edits, edit{op: '+', line: b[j]})
+	}
+	return edits
+}
+
+// writeHunks writes the changes of edits as hunks with contextLines of
+// unchanged lines around them. Changes closer than twice that share a hunk.
+func writeHunks(sb *strings.Builder, edits []edit) {
+	// oldLine[i] and newLine[i] count the lines of each side before edits[i]
+	oldLine := make([]int, len(edits)+1)
+	newLine := make([]int, len(edits)+1)
+	for i, e := range edits {
+		oldLine[i+1], newLine[i+1] = oldLine[i], newLine[i]
+		if e.op != '+' {
+			oldLine[i+1]++
+		}
+		if e.op != '-' {
+			newLine[i+1]++
+		}
+	}
+
+	for start := 0; start < len(edits); {
+		first := start
+		for first < len(edits) && edits[first].op == ' ' {
+			first++
+		}
+		if first == len(edits) {
+			return
+		}
+
+		end := first + 1
+		for i := first + 1; i < len(edits); i++ {
+			if edits[i].op != ' ' {
+				end = i + 1
+			} else if i-end+1 > 2*contextLines {
+				break
+			}
+		}
+		begin := max(first-contextLines, start)
+		stop := min(end+contextLines, len(edits))
+
+		fmt.Fprintf(sb, "@@ -%s +%s @@\n",
+			hunkRange(oldLine[begin], oldLine[stop]-oldLine[begin]),
+			hunkRange(newLine[begin], newLine[stop]-newLine[begin]))
+		for _, e := range edits[begin:stop] {
+			sb.WriteByte(e.op)
+			sb.WriteString(e.line)
+			if !strings.HasSuffix(e.line, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		start = stop
+	}
+}
+
+// hunkRange formats the range of a hunk header for count lines following
+// the first before lines. An empty range names the line before it.
+func hunkRange(before, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", before)
+	}
+	return fmt.Sprintf("%d,%d", before+1, count)
+}
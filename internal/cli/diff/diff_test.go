@@ -0,0 +1,55 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnified_Equal(t *testing.T) {
+	assert.Empty(t, Unified("a", "b", []byte("same\n"), []byte("same\n")))
+}
+
+func TestUnified_Binary(t *testing.T) {
+	got := Unified("a", "b", []byte("text\n"), []byte{0x7f, 'E', 'L', 'F', 0})
+	assert.Equal(t, "Binary files a and b differ\n", got)
+}
+
+func TestUnified_Changes(t *testing.T) {
+	old := "set number\nset ruler\nsyntax on\n"
+	new := "set number\nsyntax on\nset hlsearch\n"
+
+	want := strings.Join([]string{
+		"--- a",
+		"+++ b",
+		"@@ -1,3 +1,3 @@",
+		" set number",
+		"-set ruler",
+		" syntax on",
+		"+set hlsearch",
+		"",
+	}, "\n")
+	assert.Equal(t, want, Unified("a", "b", []byte(old), []byte(new)))
+}
+
+func TestUnified_SeparateHunks(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 20; i++ {
+		lines = append(lines, string(rune('a'+i-1)))
+	}
+	old := strings.Join(lines, "\n") + "\n"
+	lines[1] = "B"
+	lines[18] = "S"
+	new := strings.Join(lines, "\n") + "\n"
+
+	got := Unified("a", "b", []byte(old), []byte(new))
+	assert.Contains(t, got, "@@ -1,5 +1,5 @@\n a\n-b\n+B\n c\n d\n e\n")
+	assert.Contains(t, got, "@@ -16,5 +16,5 @@\n p\n q\n r\n-s\n+S\n t\n")
+	assert.Equal(t, 2, strings.Count(got, "@@ -"))
+}
+
+func TestUnified_NewFileAndMissingNewline(t *testing.T) {
+	got := Unified("a", "b", nil, []byte("only"))
+	assert.Equal(t, "--- a\n+++ b\n@@ -0,0 +1,1 @@\n+only\n\\ No newline at end of file\n", got)
+}
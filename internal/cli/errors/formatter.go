@@ -143,6 +143,16 @@ func (f *Formatter) getTemplate(err error, ctx ErrorContext) *Template {
 		}
 	}
 
+	var outside domain.ErrOutsideBoundary
+	if errors.As(err, &outside) {
+		return &Template{
+			Title:       "Outside Managed Directories",
+			Description: fmt.Sprintf("Refused to %s %q", outside.Operation, outside.Path),
+			Details:     []string{"The path is outside the package, target, backup, cache and manifest directories"},
+			Suggestions: []string{"Check the directories in the configuration", "Report the command that failed if the directories are correct"},
+		}
+	}
+
 	var fsOp domain.ErrFilesystemOperation
 	if errors.As(err, &fsOp) {
 		return &Template{
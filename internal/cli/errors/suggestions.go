@@ -107,7 +107,7 @@ func (e *SuggestionEngine) suggestForConflict(err domain.ErrConflict) []string {
 		suggestions = append(suggestions,
 			"Use 'dot adopt' to move the existing file into the package",
 			"Remove the conflicting file manually if it's not needed",
-			"Use --backup to preserve the existing file")
+			"Use --on-conflict backup to preserve the existing file")
 	}
 
 	suggestions = append(suggestions,
@@ -2,6 +2,8 @@ package progress
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"strings"
 	"time"
 )
@@ -14,6 +16,7 @@ type Bar struct {
 	message   string
 	startTime time.Time
 	started   bool
+	out       io.Writer
 }
 
 // Start begins progress display.
@@ -47,7 +50,7 @@ func (b *Bar) Stop(message string) {
 	}
 	b.current = b.total
 	b.render()
-	fmt.Println() // Move to next line
+	fmt.Fprintln(b.writer()) // Move to next line
 	b.started = false
 }
 
@@ -57,7 +60,7 @@ func (b *Bar) Fail(message string) {
 		b.message = message
 	}
 	b.render()
-	fmt.Println() // Move to next line
+	fmt.Fprintln(b.writer()) // Move to next line
 	b.started = false
 }
 
@@ -104,5 +107,13 @@ func (b *Bar) Render() string {
 func (b *Bar) render() {
 	output := b.Render()
 	// Clear line and print progress
-	fmt.Printf("\r\033[K%s", output)
+	fmt.Fprintf(b.writer(), "\r\033[K%s", output)
+}
+
+// writer returns where the bar is drawn, stdout unless configured.
+func (b *Bar) writer() io.Writer {
+	if b.out == nil {
+		return os.Stdout
+	}
+	return b.out
 }
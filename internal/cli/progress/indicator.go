@@ -1,6 +1,7 @@
 package progress
 
 import (
+	"io"
 	"os"
 
 	"golang.org/x/term"
@@ -19,6 +20,7 @@ type Config struct {
 	Enabled     bool
 	Interactive bool // Terminal supports cursor control
 	Width       int
+	Output      io.Writer // Where bars are drawn; stdout if nil
 }
 
 // New creates appropriate indicator for terminal.
@@ -42,6 +44,7 @@ func NewBar(cfg Config) Indicator {
 	}
 	return &Bar{
 		width: cfg.Width,
+		out:   cfg.Output,
 	}
 }
 
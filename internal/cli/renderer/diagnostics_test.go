@@ -2,6 +2,7 @@ package renderer
 
 import (
 	"bytes"
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -90,3 +91,87 @@ func TestTableRenderer_RenderDiagnostics(t *testing.T) {
 	assert.Contains(t, output, "Total Links: 5")
 	assert.Contains(t, output, "Broken Links: 1")
 }
+
+func TestRenderDiagnosticsSARIF(t *testing.T) {
+	report := dot.DiagnosticReport{
+		SchemaVersion: dot.DiagnosticSchemaVersion,
+		OverallHealth: dot.HealthErrors,
+		Issues: []dot.Issue{
+			{Severity: dot.SeverityError, Type: dot.IssueBrokenLink, Path: "/home/me/.vimrc", Package: "vim", Message: "Broken link", Suggestion: "Run 'dot doctor --fix'"},
+			{Severity: dot.SeverityWarning, Type: dot.IssueOrphanedLink, Path: "/home/me/.old", Message: "Orphaned link"},
+			{Severity: dot.SeverityInfo, Type: dot.IssueManifestInconsistency, Message: "No manifest found"},
+			{Severity: dot.SeverityWarning, Type: dot.IssueBrokenLink, Path: "/home/me/.gvimrc", Message: "Broken link"},
+		},
+		Statistics: dot.DiagnosticStats{TotalLinks: 3, BrokenLinks: 2},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, RenderDiagnosticsSARIF(&buf, report, "1.2.3"))
+
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name    string `json:"name"`
+					Version string `json:"version"`
+					Rules   []struct {
+						ID string `json:"id"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				RuleIndex int    `json:"ruleIndex"`
+				Level     string `json:"level"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+				Properties map[string]string `json:"properties"`
+			} `json:"results"`
+			Properties struct {
+				OverallHealth string `json:"overall_health"`
+			} `json:"properties"`
+		} `json:"runs"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+
+	assert.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+	run := log.Runs[0]
+	assert.Equal(t, "dot", run.Tool.Driver.Name)
+	assert.Equal(t, "1.2.3", run.Tool.Driver.Version)
+	assert.Equal(t, "errors", run.Properties.OverallHealth)
+
+	ids := []string{}
+	for _, rule := range run.Tool.Driver.Rules {
+		ids = append(ids, rule.ID)
+	}
+	assert.Equal(t, []string{"broken_link", "manifest_inconsistency", "orphaned_link"}, ids)
+
+	require.Len(t, run.Results, 4)
+	assert.Equal(t, "broken_link", run.Results[0].RuleID)
+	assert.Equal(t, 0, run.Results[0].RuleIndex)
+	assert.Equal(t, "error", run.Results[0].Level)
+	assert.Equal(t, "file:///home/me/.vimrc", run.Results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Equal(t, map[string]string{"package": "vim", "suggestion": "Run 'dot doctor --fix'"}, run.Results[0].Properties)
+	assert.Equal(t, 2, run.Results[1].RuleIndex)
+	assert.Equal(t, "warning", run.Results[1].Level)
+	assert.Equal(t, "note", run.Results[2].Level)
+	assert.Empty(t, run.Results[2].Locations)
+	assert.Equal(t, "warning", run.Results[3].Level)
+}
+
+func TestRenderDiagnosticsSARIF_NoIssues(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, RenderDiagnosticsSARIF(&buf, dot.DiagnosticReport{OverallHealth: dot.HealthOK}, ""))
+
+	output := buf.String()
+	assert.Contains(t, output, `"results": []`)
+	assert.Contains(t, output, `"rules": []`)
+	assert.NotContains(t, output, `"version": ""`)
+}
@@ -27,6 +27,11 @@ func (r *JSONRenderer) RenderStatus(w io.Writer, status dot.Status) error {
 	return r.newEncoder(w).Encode(status)
 }
 
+// RenderPackageList renders a package list as JSON.
+func (r *JSONRenderer) RenderPackageList(w io.Writer, packages []dot.PackageInfo) error {
+	return r.newEncoder(w).Encode(dot.Status{Packages: packages})
+}
+
 // RenderDiagnostics renders diagnostic report as JSON.
 func (r *JSONRenderer) RenderDiagnostics(w io.Writer, report dot.DiagnosticReport) error {
 	return r.newEncoder(w).Encode(report)
@@ -34,5 +39,5 @@ func (r *JSONRenderer) RenderDiagnostics(w io.Writer, report dot.DiagnosticRepor
 
 // RenderPlan renders an execution plan as JSON.
 func (r *JSONRenderer) RenderPlan(w io.Writer, plan domain.Plan) error {
-	return r.newEncoder(w).Encode(plan)
+	return r.newEncoder(w).Encode(newPlanDocument(plan))
 }
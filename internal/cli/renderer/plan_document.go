@@ -0,0 +1,322 @@
+package renderer
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jamesainslie/dot/internal/domain"
+)
+
+// planDocument is the machine-readable form of a plan used by the JSON and
+// YAML renderers. Operations are flattened to their kind and paths so the
+// output is stable regardless of how operations are represented internally.
+type planDocument struct {
+	Operations []operationDocument  `json:"operations" yaml:"operations"`
+	Batches    [][]string           `json:"batches,omitempty" yaml:"batches,omitempty"`
+	Packages   map[string][]string  `json:"packages,omitempty" yaml:"packages,omitempty"`
+	Satisfied  []operationDocument  `json:"satisfied,omitempty" yaml:"satisfied,omitempty"`
+	Conflicts  []conflictDocument   `json:"conflicts" yaml:"conflicts"`
+	Warnings   []warningDocument    `json:"warnings" yaml:"warnings"`
+	Metadata   planMetadataDocument `json:"metadata" yaml:"metadata"`
+
+	Replacements []replacementDocument `json:"replacements,omitempty" yaml:"replacements,omitempty"`
+}
+
+// operationDocument describes a single planned operation.
+type operationDocument struct {
+	ID          string   `json:"id" yaml:"id"`
+	Kind        string   `json:"kind" yaml:"kind"`
+	Description string   `json:"description" yaml:"description"`
+	Source      string   `json:"source,omitempty" yaml:"source,omitempty"`
+	Target      string   `json:"target,omitempty" yaml:"target,omitempty"`
+	Path        string   `json:"path,omitempty" yaml:"path,omitempty"`
+	DependsOn   []string `json:"depends_on,omitempty" yaml:"depends_on,omitempty"`
+}
+
+// conflictDocument describes a conflict that prevents the plan from applying.
+type conflictDocument struct {
+	Type    string            `json:"type" yaml:"type"`
+	Path    string            `json:"path" yaml:"path"`
+	Details string            `json:"details" yaml:"details"`
+	Context map[string]string `json:"context,omitempty" yaml:"context,omitempty"`
+}
+
+// warningDocument describes a non-fatal issue found while planning.
+type warningDocument struct {
+	Message  string            `json:"message" yaml:"message"`
+	Severity string            `json:"severity" yaml:"severity"`
+	Context  map[string]string `json:"context,omitempty" yaml:"context,omitempty"`
+}
+
+// replacementDocument describes an existing file the plan replaces.
+type replacementDocument struct {
+	Path        string `json:"path" yaml:"path"`
+	Replacement string `json:"replacement" yaml:"replacement"`
+	Policy      string `json:"policy" yaml:"policy"`
+}
+
+// planMetadataDocument summarises plan statistics.
+type planMetadataDocument struct {
+	PackageCount   int `json:"package_count" yaml:"package_count"`
+	OperationCount int `json:"operation_count" yaml:"operation_count"`
+	LinkCount      int `json:"link_count" yaml:"link_count"`
+	DirCount       int `json:"dir_count" yaml:"dir_count"`
+}
+
+// newPlanDocument converts a plan into its machine-readable form.
+func newPlanDocument(plan domain.Plan) planDocument {
+	doc := planDocument{
+		Operations: operationDocuments(plan.Operations, plan.Dependencies),
+		Satisfied:  operationDocuments(plan.Satisfied, nil),
+		Conflicts:  make([]conflictDocument, 0, len(plan.Metadata.Conflicts)),
+		Warnings:   make([]warningDocument, 0, len(plan.Metadata.Warnings)),
+		Metadata: planMetadataDocument{
+			PackageCount:   plan.Metadata.PackageCount,
+			OperationCount: plan.Metadata.OperationCount,
+			LinkCount:      plan.Metadata.LinkCount,
+			DirCount:       plan.Metadata.DirCount,
+		},
+	}
+
+	for _, batch := range plan.Batches {
+		ids := make([]string, 0, len(batch))
+		for _, op := range batch {
+			ids = append(ids, string(op.ID()))
+		}
+		doc.Batches = append(doc.Batches, ids)
+	}
+
+	if len(plan.PackageOperations) > 0 {
+		doc.Packages = make(map[string][]string, len(plan.PackageOperations))
+		for pkg, opIDs := range plan.PackageOperations {
+			ids := make([]string, 0, len(opIDs))
+			for _, id := range opIDs {
+				ids = append(ids, string(id))
+			}
+			sort.Strings(ids)
+			doc.Packages[pkg] = ids
+		}
+	}
+
+	for _, c := range plan.Metadata.Conflicts {
+		doc.Conflicts = append(doc.Conflicts, conflictDocument(c))
+	}
+	for _, w := range plan.Metadata.Warnings {
+		doc.Warnings = append(doc.Warnings, warningDocument(w))
+	}
+	for _, r := range plan.Metadata.Replacements {
+		doc.Replacements = append(doc.Replacements, replacementDocument(r))
+	}
+
+	return doc
+}
+
+// operationDocuments converts operations preserving their order, listing
+// the dependencies of each from deps.
+func operationDocuments(ops []domain.Operation, deps map[domain.OperationID][]domain.OperationID) []operationDocument {
+	if ops == nil {
+		return nil
+	}
+	docs := make([]operationDocument, 0, len(ops))
+	for _, op := range ops {
+		doc := newOperationDocument(op)
+		for _, dep := range deps[op.ID()] {
+			doc.DependsOn = append(doc.DependsOn, string(dep))
+		}
+		docs = append(docs, doc)
+	}
+	return docs
+}
+
+// newOperationDocument extracts the paths an operation acts on.
+// Source and Target are set for operations between two paths; Path is set
+// for operations on a single path.
+func newOperationDocument(op domain.Operation) operationDocument {
+	doc := operationDocument{
+		ID:          string(op.ID()),
+		Kind:        op.Kind().String(),
+		Description: op.String(),
+	}
+
+	switch typed := normalizeOperation(op).(type) {
+	case domain.LinkCreate:
+		doc.Source = typed.Source.String()
+		doc.Target = typed.Target.String()
+	case domain.LinkDelete:
+		doc.Path = typed.Target.String()
+	case domain.DirCreate:
+		doc.Path = typed.Path.String()
+	case domain.DirDelete:
+		doc.Path = typed.Path.String()
+	case domain.DirRemoveAll:
+		doc.Path = typed.Path.String()
+	case domain.FileMove:
+		doc.Source = typed.Source.String()
+		doc.Target = typed.Dest.String()
+	case domain.FileBackup:
+		doc.Source = typed.Source.String()
+		doc.Target = typed.Backup.String()
+	case domain.FileStash:
+		doc.Source = typed.Source.String()
+		doc.Target = domain.BackupObjectPath(typed.BackupDir.String(), typed.Hash)
+	case domain.FileRestore:
+		doc.Source = domain.BackupObjectPath(typed.BackupDir.String(), typed.Hash)
+		doc.Target = typed.Path.String()
+	case domain.DirCopy:
+		doc.Source = typed.Source.String()
+		doc.Target = typed.Dest.String()
+	case domain.FileRender:
+		doc.Source = typed.Template.String()
+		doc.Target = typed.Dest.String()
+	}
+
+	return doc
+}
+
+// ParsePlan reads a plan written by the JSON or YAML renderer back into a
+// domain.Plan for validation. The document only records the IDs, kinds and
+// paths of operations, so template content is not restored and the plan
+// is not meant to be executed.
+func ParsePlan(data []byte) (domain.Plan, error) {
+	// YAML is a superset of JSON, so one decoder reads both formats
+	var doc planDocument
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return domain.Plan{}, fmt.Errorf("parse plan: %w", err)
+	}
+
+	plan := domain.Plan{
+		Metadata: domain.PlanMetadata{
+			PackageCount:   doc.Metadata.PackageCount,
+			OperationCount: doc.Metadata.OperationCount,
+			LinkCount:      doc.Metadata.LinkCount,
+			DirCount:       doc.Metadata.DirCount,
+		},
+	}
+	byID := make(map[string]domain.Operation, len(doc.Operations))
+	for _, opDoc := range doc.Operations {
+		op, err := opDoc.operation()
+		if err != nil {
+			return domain.Plan{}, err
+		}
+		plan.Operations = append(plan.Operations, op)
+		byID[opDoc.ID] = op
+		for _, dep := range opDoc.DependsOn {
+			if plan.Dependencies == nil {
+				plan.Dependencies = make(map[domain.OperationID][]domain.OperationID)
+			}
+			plan.Dependencies[op.ID()] = append(plan.Dependencies[op.ID()], domain.OperationID(dep))
+		}
+	}
+	for _, opDoc := range doc.Satisfied {
+		op, err := opDoc.operation()
+		if err != nil {
+			return domain.Plan{}, err
+		}
+		plan.Satisfied = append(plan.Satisfied, op)
+	}
+
+	for i, ids := range doc.Batches {
+		batch := make([]domain.Operation, 0, len(ids))
+		for _, id := range ids {
+			op, ok := byID[id]
+			if !ok {
+				return domain.Plan{}, fmt.Errorf("batch %d lists unknown operation %s", i, id)
+			}
+			batch = append(batch, op)
+		}
+		plan.Batches = append(plan.Batches, batch)
+	}
+
+	if len(doc.Packages) > 0 {
+		plan.PackageOperations = make(map[string][]domain.OperationID, len(doc.Packages))
+		for pkg, ids := range doc.Packages {
+			for _, id := range ids {
+				plan.PackageOperations[pkg] = append(plan.PackageOperations[pkg], domain.OperationID(id))
+			}
+		}
+	}
+	return plan, nil
+}
+
+// operation reconstructs the operation the document describes.
+func (d operationDocument) operation() (domain.Operation, error) {
+	id := domain.OperationID(d.ID)
+	var paths docPaths
+
+	switch d.Kind {
+	case domain.OpKindLinkCreate.String():
+		source, target := paths.file(d.Source), paths.target(d.Target)
+		return paths.op(d, domain.NewLinkCreate(id, source, target))
+	case domain.OpKindLinkDelete.String():
+		return paths.op(d, domain.NewLinkDelete(id, paths.target(d.Path)))
+	case domain.OpKindDirCreate.String():
+		return paths.op(d, domain.NewDirCreate(id, paths.file(d.Path)))
+	case domain.OpKindDirDelete.String():
+		return paths.op(d, domain.NewDirDelete(id, paths.file(d.Path)))
+	case domain.OpKindDirRemoveAll.String():
+		return paths.op(d, domain.NewDirRemoveAll(id, paths.file(d.Path)))
+	case domain.OpKindFileMove.String():
+		source, dest := paths.target(d.Source), paths.file(d.Target)
+		return paths.op(d, domain.NewFileMove(id, source, dest))
+	case domain.OpKindFileBackup.String():
+		source, backup := paths.file(d.Source), paths.file(d.Target)
+		return paths.op(d, domain.NewFileBackup(id, source, backup))
+	case domain.OpKindDirCopy.String():
+		source, dest := paths.file(d.Source), paths.file(d.Target)
+		return paths.op(d, domain.NewDirCopy(id, source, dest))
+	case domain.OpKindFileRender.String():
+		source, dest := paths.file(d.Source), paths.file(d.Target)
+		return paths.op(d, domain.NewFileRender(id, source, dest, ""))
+	case domain.OpKindFileStash.String():
+		// The target is the backup object path <dir>/objects/<xx>/<hash>
+		source := paths.file(d.Source)
+		backupDir := paths.file(filepath.Dir(filepath.Dir(filepath.Dir(d.Target))))
+		return paths.op(d, domain.NewFileStash(id, source, backupDir, filepath.Base(d.Target)))
+	case domain.OpKindFileRestore.String():
+		// The source is the backup object path; the plan the backup was
+		// taken by is not documented, so any backup of the file is released
+		path := paths.file(d.Target)
+		backupDir := paths.file(filepath.Dir(filepath.Dir(filepath.Dir(d.Source))))
+		return paths.op(d, domain.NewFileRestore(id, path, backupDir, filepath.Base(d.Source), ""))
+	default:
+		return nil, fmt.Errorf("operation %s has unknown kind %q", d.ID, d.Kind)
+	}
+}
+
+// docPaths parses the paths of an operation document, keeping the first
+// invalid one so that it can be reported once the operation is built.
+type docPaths struct {
+	err error
+}
+
+func (p *docPaths) file(path string) domain.FilePath {
+	result := domain.NewFilePath(path)
+	if !result.IsOk() {
+		if p.err == nil {
+			p.err = result.UnwrapErr()
+		}
+		return domain.FilePath{}
+	}
+	return result.Unwrap()
+}
+
+func (p *docPaths) target(path string) domain.TargetPath {
+	result := domain.NewTargetPath(path)
+	if !result.IsOk() {
+		if p.err == nil {
+			p.err = result.UnwrapErr()
+		}
+		return domain.TargetPath{}
+	}
+	return result.Unwrap()
+}
+
+func (p *docPaths) op(d operationDocument, op domain.Operation) (domain.Operation, error) {
+	if p.err != nil {
+		return nil, fmt.Errorf("operation %s: %w", d.ID, p.err)
+	}
+	return op, nil
+}
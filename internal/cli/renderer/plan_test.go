@@ -2,6 +2,7 @@ package renderer
 
 import (
 	"bytes"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -28,8 +29,11 @@ func TestJSONRenderer_RenderPlan(t *testing.T) {
 	require.NoError(t, err)
 
 	output := buf.String()
-	assert.Contains(t, output, "Operations")
-	assert.Contains(t, output, "Metadata")
+	assert.Contains(t, output, `"operations"`)
+	assert.Contains(t, output, `"metadata"`)
+	assert.Contains(t, output, `"kind": "LinkCreate"`)
+	assert.Contains(t, output, `"source": "/src/file"`)
+	assert.Contains(t, output, `"target": "/dst/file"`)
 }
 
 func TestYAMLRenderer_RenderPlan(t *testing.T) {
@@ -50,7 +54,9 @@ func TestYAMLRenderer_RenderPlan(t *testing.T) {
 	require.NoError(t, err)
 
 	output := buf.String()
-	assert.Contains(t, output, "packagecount")
+	assert.Contains(t, output, "package_count: 1")
+	assert.Contains(t, output, "kind: LinkCreate")
+	assert.Contains(t, output, "target: /dst")
 }
 
 func TestTextRenderer_RenderPlan(t *testing.T) {
@@ -82,6 +88,44 @@ func TestTextRenderer_RenderPlan(t *testing.T) {
 	assert.Contains(t, output, "Summary:")
 }
 
+func TestTextRenderer_RenderPlan_Replacements(t *testing.T) {
+	files := map[string]string{
+		"/target/.vimrc":       "set number\nset ruler\n",
+		"/packages/vim/vimrc":  "set number\n",
+		"/target/.bin":         "\x00\x01",
+		"/packages/bin/binary": "\x00\x02",
+	}
+	r := &TextRenderer{
+		readFile: func(path string) ([]byte, error) {
+			data, ok := files[path]
+			if !ok {
+				return nil, errors.New("not found")
+			}
+			return []byte(data), nil
+		},
+	}
+
+	plan := dot.Plan{
+		Metadata: dot.PlanMetadata{
+			Replacements: []dot.ReplacementInfo{
+				{Path: "/target/.vimrc", Replacement: "/packages/vim/vimrc", Policy: "overwrite"},
+				{Path: "/target/.bin", Replacement: "/packages/bin/binary", Policy: "overwrite"},
+				{Path: "/target/.gone", Replacement: "/packages/vim/vimrc", Policy: "adopt"},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, r.RenderPlan(&buf, plan))
+
+	output := buf.String()
+	assert.Contains(t, output, "Replaced files:")
+	assert.Contains(t, output, "~ /target/.vimrc (overwrite, replaced by /packages/vim/vimrc)")
+	assert.Contains(t, output, "      -set ruler\n")
+	assert.Contains(t, output, "Binary files /target/.bin and /packages/bin/binary differ")
+	assert.Contains(t, output, "cannot read /target/.gone")
+}
+
 func TestTableRenderer_RenderPlan(t *testing.T) {
 	r := &TableRenderer{}
 
@@ -101,3 +145,79 @@ func TestTableRenderer_RenderPlan(t *testing.T) {
 	output := buf.String()
 	assert.NotEmpty(t, output)
 }
+
+func TestNewPlanDocument(t *testing.T) {
+	link := dot.NewLinkCreate("link1", dot.MustParsePath("/pkg/vim/dot-vimrc"), dot.MustParseTargetPath("/home/.vimrc"))
+	dir := dot.NewDirCreate("dir1", dot.MustParsePath("/home/.vim"))
+	plan := dot.Plan{
+		Operations:        []dot.Operation{dir, link},
+		Batches:           [][]dot.Operation{{dir}, {link}},
+		PackageOperations: map[string][]dot.OperationID{"vim": {"link1", "dir1"}},
+		Dependencies:      map[dot.OperationID][]dot.OperationID{"link1": {"dir1"}},
+		Metadata: dot.PlanMetadata{
+			PackageCount:   1,
+			OperationCount: 2,
+			Conflicts:      []dot.ConflictInfo{{Type: "file_exists", Path: "/home/.gvimrc", Details: "File exists"}},
+		},
+	}
+
+	doc := newPlanDocument(plan)
+
+	require.Len(t, doc.Operations, 2)
+	assert.Equal(t, operationDocument{
+		ID:          "dir1",
+		Kind:        "DirCreate",
+		Description: dir.String(),
+		Path:        "/home/.vim",
+	}, doc.Operations[0])
+	assert.Equal(t, "/pkg/vim/dot-vimrc", doc.Operations[1].Source)
+	assert.Equal(t, "/home/.vimrc", doc.Operations[1].Target)
+	assert.Equal(t, []string{"dir1"}, doc.Operations[1].DependsOn)
+	assert.Equal(t, [][]string{{"dir1"}, {"link1"}}, doc.Batches)
+	assert.Equal(t, []string{"dir1", "link1"}, doc.Packages["vim"])
+	require.Len(t, doc.Conflicts, 1)
+	assert.Equal(t, "/home/.gvimrc", doc.Conflicts[0].Path)
+	assert.NotNil(t, doc.Warnings)
+	assert.Equal(t, 2, doc.Metadata.OperationCount)
+}
+
+func TestParsePlan_RoundTrip(t *testing.T) {
+	dir := dot.NewDirCreate("dir1", dot.MustParsePath("/home/.vim"))
+	link := dot.NewLinkCreate("link1", dot.MustParsePath("/pkg/vim/dot-vimrc"), dot.MustParseTargetPath("/home/.vim/vimrc"))
+	stash := dot.NewFileStash("stash1", dot.MustParsePath("/home/.vimrc"), dot.MustParsePath("/home/.dot-backup"), "abcdef")
+	unlink := dot.NewLinkDelete("unlink1", dot.MustParseTargetPath("/home/.gvimrc"))
+	plan := dot.Plan{
+		Operations:        []dot.Operation{dir, stash, unlink, link},
+		Batches:           [][]dot.Operation{{dir, stash, unlink}, {link}},
+		PackageOperations: map[string][]dot.OperationID{"vim": {"dir1", "link1"}},
+		Dependencies:      map[dot.OperationID][]dot.OperationID{"link1": {"dir1"}},
+	}
+
+	for _, format := range []string{"json", "yaml"} {
+		t.Run(format, func(t *testing.T) {
+			r, err := NewRenderer(format, false, "")
+			require.NoError(t, err)
+			var buf bytes.Buffer
+			require.NoError(t, r.RenderPlan(&buf, plan))
+
+			parsed, err := ParsePlan(buf.Bytes())
+			require.NoError(t, err)
+			assert.Equal(t, plan.Operations, parsed.Operations)
+			assert.Equal(t, plan.Batches, parsed.Batches)
+			assert.Equal(t, plan.Dependencies, parsed.Dependencies)
+			assert.ElementsMatch(t, plan.PackageOperations["vim"], parsed.PackageOperations["vim"])
+			assert.NoError(t, parsed.Validate())
+		})
+	}
+}
+
+func TestParsePlan_Errors(t *testing.T) {
+	_, err := ParsePlan([]byte(`{"operations": [{"id": "x", "kind": "Teleport"}]}`))
+	assert.ErrorContains(t, err, "Teleport")
+
+	_, err = ParsePlan([]byte(`{"operations": [{"id": "x", "kind": "DirCreate", "path": ""}]}`))
+	assert.ErrorContains(t, err, "operation x")
+
+	_, err = ParsePlan([]byte(`{"operations": [], "batches": [["y"]]}`))
+	assert.ErrorContains(t, err, "unknown operation y")
+}
@@ -17,6 +17,7 @@ import (
 // Renderer defines the interface for output formatting.
 type Renderer interface {
 	RenderStatus(w io.Writer, status dot.Status) error
+	RenderPackageList(w io.Writer, packages []dot.PackageInfo) error
 	RenderDiagnostics(w io.Writer, report dot.DiagnosticReport) error
 	RenderPlan(w io.Writer, plan dot.Plan) error
 }
@@ -69,6 +70,7 @@ func NewRenderer(format string, colorize bool, tableStyle string) (Renderer, err
 			scheme:       scheme,
 			width:        width,
 			displayLimit: 5, // Default: show first 5 items
+			readFile:     os.ReadFile,
 		}, nil
 	case "json":
 		return &JSONRenderer{
@@ -122,6 +124,20 @@ func formatBytes(bytes int64) string {
 }
 
 // formatDuration converts a time to a human-readable relative duration.
+// packageListRow returns the status, source, link count and install time
+// columns of a package list entry. Available packages show dashes for the
+// columns that only apply to installed ones.
+func packageListRow(pkg dot.PackageInfo) (status, source, links, installed string) {
+	if !pkg.Installed {
+		return "available", "-", "-", "-"
+	}
+	source = pkg.Source
+	if source == "" {
+		source = "managed"
+	}
+	return "installed", source, fmt.Sprintf("%d", pkg.LinkCount), formatDuration(pkg.InstalledAt)
+}
+
 func formatDuration(t time.Time) string {
 	duration := time.Since(t)
 
@@ -267,6 +283,10 @@ func normalizeOperation(op domain.Operation) domain.Operation {
 		return *typed
 	case *domain.FileBackup:
 		return *typed
+	case *domain.FileStash:
+		return *typed
+	case *domain.FileRestore:
+		return *typed
 	case *domain.DirDelete:
 		return *typed
 	case *domain.LinkDelete:
@@ -0,0 +1,177 @@
+package renderer
+
+import (
+	"cmp"
+	"encoding/json"
+	"io"
+	"net/url"
+	"path/filepath"
+	"slices"
+
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// SARIF 2.1.0 identifiers written by RenderDiagnosticsSARIF.
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifToolURI = "https://github.com/jamesainslie/dot"
+)
+
+// sarifLog is the root of a SARIF document, limited to the properties
+// diagnostic reports fill in.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool          `json:"tool"`
+	Results    []sarifResult      `json:"results"`
+	Properties sarifRunProperties `json:"properties"`
+}
+
+// sarifRunProperties carries what SARIF has no place for: the overall
+// health and statistics of the report.
+type sarifRunProperties struct {
+	SchemaVersion int                 `json:"schema_version"`
+	OverallHealth dot.HealthStatus    `json:"overall_health"`
+	Statistics    dot.DiagnosticStats `json:"statistics"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID     string                `json:"ruleId"`
+	RuleIndex  int                   `json:"ruleIndex"`
+	Level      string                `json:"level"`
+	Message    sarifMessage          `json:"message"`
+	Locations  []sarifLocation       `json:"locations,omitempty"`
+	Properties sarifResultProperties `json:"properties,omitempty"`
+}
+
+type sarifResultProperties struct {
+	Package    string `json:"package,omitempty"`
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// RenderDiagnosticsSARIF renders report as a SARIF 2.1.0 log, for code
+// scanning tools and CI annotations. Each issue is a result whose rule is
+// its issue type, at level error, warning or note by severity, located at
+// its path when it has one. toolVersion is the version of dot reported as
+// the tool; empty omits it.
+func RenderDiagnosticsSARIF(w io.Writer, report dot.DiagnosticReport, toolVersion string) error {
+	// Rules are the issue types reported, in a stable order
+	var types []dot.IssueType
+	for _, issue := range report.Issues {
+		if !slices.Contains(types, issue.Type) {
+			types = append(types, issue.Type)
+		}
+	}
+	slices.SortFunc(types, func(a, b dot.IssueType) int {
+		return cmp.Compare(a.String(), b.String())
+	})
+
+	rules := make([]sarifRule, 0, len(types))
+	for _, t := range types {
+		rules = append(rules, sarifRule{
+			ID:               t.String(),
+			ShortDescription: sarifMessage{Text: t.Description()},
+		})
+	}
+
+	results := make([]sarifResult, 0, len(report.Issues))
+	for _, issue := range report.Issues {
+		result := sarifResult{
+			RuleID:    issue.Type.String(),
+			RuleIndex: slices.Index(types, issue.Type),
+			Level:     sarifLevel(issue.Severity),
+			Message:   sarifMessage{Text: issue.Message},
+			Properties: sarifResultProperties{
+				Package:    issue.Package,
+				Suggestion: issue.Suggestion,
+			},
+		}
+		if issue.Path != "" {
+			result.Locations = []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: sarifURI(issue.Path)},
+			}}}
+		}
+		results = append(results, result)
+	}
+
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "dot",
+				Version:        toolVersion,
+				InformationURI: sarifToolURI,
+				Rules:          rules,
+			}},
+			Results: results,
+			Properties: sarifRunProperties{
+				SchemaVersion: report.SchemaVersion,
+				OverallHealth: report.OverallHealth,
+				Statistics:    report.Statistics,
+			},
+		}},
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(log)
+}
+
+// sarifLevel returns the SARIF level of issues of severity.
+func sarifLevel(severity dot.IssueSeverity) string {
+	switch severity {
+	case dot.SeverityError:
+		return "error"
+	case dot.SeverityWarning:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// sarifURI returns path as a file URI, or as a relative reference when it
+// is not absolute.
+func sarifURI(path string) string {
+	if !filepath.IsAbs(path) {
+		return (&url.URL{Path: filepath.ToSlash(path)}).String()
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
@@ -3,7 +3,9 @@ package renderer
 import (
 	"fmt"
 	"io"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/jamesainslie/dot/internal/cli/pretty"
 	"github.com/jamesainslie/dot/internal/domain"
@@ -27,7 +29,11 @@ func (r *TableRenderer) RenderStatus(w io.Writer, status dot.Status) error {
 
 	// Use legacy simple rendering if configured
 	if r.tableStyle == "simple" {
-		return r.renderStatusSimple(w, status)
+		if err := r.renderStatusSimple(w, status); err != nil {
+			return err
+		}
+		renderSourceUnknownNote(w, status)
+		return nil
 	}
 
 	// Create table with Light style for clean, professional look
@@ -38,37 +44,105 @@ func (r *TableRenderer) RenderStatus(w io.Writer, status dot.Status) error {
 	})
 
 	// Set header
-	table.SetHeader("Package", "Links", "Installed")
+	headers, rows := statusRows(status)
+	table.SetHeader(tableCells(headers)...)
 
 	// Add rows
-	for _, pkg := range status.Packages {
-		table.AppendRow(
-			pkg.Name,
-			fmt.Sprintf("%d", pkg.LinkCount),
-			formatDuration(pkg.InstalledAt),
-		)
+	for _, row := range rows {
+		table.AppendRow(tableCells(row)...)
 	}
 
 	// Render
 	table.Render(w)
+	renderSourceUnknownNote(w, status)
 	return nil
 }
 
+// renderSourceUnknownNote notes below a status table that the package
+// directory is unavailable, so sources could not be checked.
+func renderSourceUnknownNote(w io.Writer, status dot.Status) {
+	for _, pkg := range status.Packages {
+		if pkg.SourceUnknown {
+			fmt.Fprintln(w, "Sources unknown: package directory unavailable, status read from the manifest")
+			return
+		}
+	}
+}
+
 // renderStatusSimple renders status using legacy plain text format.
 func (r *TableRenderer) renderStatusSimple(w io.Writer, status dot.Status) error {
+	headers, rows := statusRows(status)
+	return r.renderTableSimple(w, headers, rows)
+}
+
+// tableCells converts a row of strings to table cells.
+func tableCells(row []string) []interface{} {
+	cells := make([]interface{}, len(row))
+	for i, cell := range row {
+		cells[i] = cell
+	}
+	return cells
+}
+
+// statusRows returns the columns and rows of a status table. The Health
+// column is added when the health of any package was inspected.
+func statusRows(status dot.Status) ([]string, [][]string) {
+	withHealth := slices.ContainsFunc(status.Packages, func(pkg dot.PackageInfo) bool {
+		return pkg.Health != nil
+	})
+
 	headers := []string{"Package", "Links", "Installed"}
+	if withHealth {
+		headers = append(headers, "Health")
+	}
 	rows := make([][]string, 0, len(status.Packages))
-
 	for _, pkg := range status.Packages {
 		row := []string{
 			pkg.Name,
 			fmt.Sprintf("%d", pkg.LinkCount),
 			formatDuration(pkg.InstalledAt),
 		}
+		if withHealth {
+			health := "-"
+			if pkg.Health != nil {
+				health = pkg.Health.String()
+			}
+			row = append(row, health)
+		}
 		rows = append(rows, row)
 	}
+	return headers, rows
+}
 
-	return r.renderTableSimple(w, headers, rows)
+// RenderPackageList renders installed and available packages as a table.
+func (r *TableRenderer) RenderPackageList(w io.Writer, packages []dot.PackageInfo) error {
+	if len(packages) == 0 {
+		fmt.Fprintln(w, "No packages found")
+		return nil
+	}
+
+	headers := []string{"Package", "Status", "Source", "Links", "Installed"}
+	rows := make([][]string, 0, len(packages))
+	for _, pkg := range packages {
+		status, source, links, installed := packageListRow(pkg)
+		rows = append(rows, []string{pkg.Name, status, source, links, installed})
+	}
+
+	if r.tableStyle == "simple" {
+		return r.renderTableSimple(w, headers, rows)
+	}
+
+	table := pretty.NewTableWriter(pretty.StyleLight, pretty.TableConfig{
+		ColorEnabled: r.colorize,
+		AutoWrap:     true,
+		MaxWidth:     0, // Auto-detect terminal width
+	})
+	table.SetHeader(headers[0], headers[1], headers[2], headers[3], headers[4])
+	for _, row := range rows {
+		table.AppendRow(row[0], row[1], row[2], row[3], row[4])
+	}
+	table.Render(w)
+	return nil
 }
 
 func (r *TableRenderer) resetColor() string {
@@ -145,7 +219,13 @@ func (r *TableRenderer) RenderDiagnostics(w io.Writer, report dot.DiagnosticRepo
 	fmt.Fprintf(w, "  Total Links: %d\n", report.Statistics.TotalLinks)
 	fmt.Fprintf(w, "  Managed Links: %d\n", report.Statistics.ManagedLinks)
 	fmt.Fprintf(w, "  Broken Links: %d\n", report.Statistics.BrokenLinks)
-	fmt.Fprintf(w, "  Orphaned Links: %d\n\n", report.Statistics.OrphanedLinks)
+	fmt.Fprintf(w, "  Orphaned Links: %d\n", report.Statistics.OrphanedLinks)
+	fmt.Fprintf(w, "  Foreign Links: %d\n", report.Statistics.ForeignLinks)
+	if report.Statistics.UnknownLinks > 0 {
+		fmt.Fprintf(w, "  Unknown Sources: %d\n", report.Statistics.UnknownLinks)
+	}
+	fmt.Fprintf(w, "  Files Scanned: %d\n", report.Statistics.FilesScanned)
+	fmt.Fprintf(w, "  Scan Duration: %s\n\n", report.Statistics.ScanDuration.Round(time.Millisecond))
 
 	// Show issues in a table
 	if len(report.Issues) == 0 {
@@ -247,6 +327,16 @@ func formatOperationForTable(op domain.Operation) operationDisplay {
 		display.Type = "File"
 		display.Details = fmt.Sprintf("%s -> %s", typed.Source.String(), typed.Backup.String())
 
+	case domain.FileStash:
+		display.Action = "Backup"
+		display.Type = "File"
+		display.Details = fmt.Sprintf("%s -> %s", typed.Source.String(), domain.BackupObjectPath(typed.BackupDir.String(), typed.Hash))
+
+	case domain.FileRestore:
+		display.Action = "Restore"
+		display.Type = "File"
+		display.Details = fmt.Sprintf("%s -> %s", domain.BackupObjectPath(typed.BackupDir.String(), typed.Hash), typed.Path.String())
+
 	case domain.DirDelete:
 		display.Action = "Delete"
 		display.Type = "Directory"
@@ -328,7 +418,7 @@ func (r *TableRenderer) RenderPlan(w io.Writer, plan domain.Plan) error {
 	if count := counts[domain.OpKindFileMove]; count > 0 {
 		fmt.Fprintf(w, "  Files moved: %d\n", count)
 	}
-	if count := counts[domain.OpKindFileBackup]; count > 0 {
+	if count := counts[domain.OpKindFileBackup] + counts[domain.OpKindFileStash]; count > 0 {
 		fmt.Fprintf(w, "  Backups created: %d\n", count)
 	}
 	if count := counts[domain.OpKindDirDelete]; count > 0 {
@@ -156,3 +156,38 @@ func TestNewRenderer_WithTableStyle(t *testing.T) {
 		})
 	}
 }
+
+func TestTableRenderer_RenderPackageList(t *testing.T) {
+	packages := []dot.PackageInfo{
+		{Name: "vim", Source: "adopted", LinkCount: 3, Installed: true},
+		{Name: "zsh"},
+	}
+	r := &TableRenderer{width: 80, tableStyle: "simple"}
+
+	var buf bytes.Buffer
+	require.NoError(t, r.RenderPackageList(&buf, packages))
+
+	lines := strings.Split(buf.String(), "\n")
+	require.GreaterOrEqual(t, len(lines), 4)
+	assert.Contains(t, lines[0], "Source")
+	assert.Regexp(t, `vim\s+installed\s+adopted\s+3`, lines[2])
+	assert.Regexp(t, `zsh\s+available\s+-\s+-`, lines[3])
+}
+
+func TestTableRenderer_StatusHealthColumn(t *testing.T) {
+	r := &TableRenderer{tableStyle: "simple"}
+
+	var buf bytes.Buffer
+	require.NoError(t, r.RenderStatus(&buf, dot.Status{Packages: []dot.PackageInfo{{Name: "vim", LinkCount: 2}}}))
+	assert.NotContains(t, buf.String(), "Health")
+
+	buf.Reset()
+	require.NoError(t, r.RenderStatus(&buf, dot.Status{Packages: []dot.PackageInfo{
+		{Name: "vim", LinkCount: 2, Health: &dot.LinkHealthSummary{OK: 2}},
+		{Name: "zsh", LinkCount: 3, Health: &dot.LinkHealthSummary{OK: 1, Broken: 2}},
+	}}))
+	output := buf.String()
+	assert.Contains(t, output, "Health")
+	assert.Contains(t, output, "ok")
+	assert.Contains(t, output, "2 broken")
+}
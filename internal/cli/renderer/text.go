@@ -3,7 +3,12 @@ package renderer
 import (
 	"fmt"
 	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/jamesainslie/dot/internal/cli/diff"
 	"github.com/jamesainslie/dot/internal/domain"
 	"github.com/jamesainslie/dot/pkg/dot"
 )
@@ -14,6 +19,10 @@ type TextRenderer struct {
 	scheme       ColorScheme
 	width        int
 	displayLimit int // Maximum number of items to display before truncation
+
+	// readFile reads the files a plan replaces to show how they change.
+	// When nil, replaced files are listed without their differences.
+	readFile func(path string) ([]byte, error)
 }
 
 // RenderStatus renders installation status as plain text.
@@ -27,6 +36,16 @@ func (r *TextRenderer) RenderStatus(w io.Writer, status dot.Status) error {
 		fmt.Fprintf(w, "%s%s%s\n", r.colorText(r.scheme.Info), pkg.Name, r.resetColor())
 		fmt.Fprintf(w, "  Links: %d\n", pkg.LinkCount)
 		fmt.Fprintf(w, "  Installed: %s\n", formatDuration(pkg.InstalledAt))
+		if pkg.Health != nil {
+			color := r.scheme.Success
+			if !pkg.Health.Healthy() {
+				color = r.scheme.Error
+			}
+			fmt.Fprintf(w, "  Health: %s%s%s\n", r.colorText(color), pkg.Health, r.resetColor())
+		}
+		if pkg.SourceUnknown {
+			fmt.Fprintf(w, "  %sSource: unknown (package directory unavailable)%s\n", r.colorText(r.scheme.Warning), r.resetColor())
+		}
 
 		if len(pkg.Links) > 0 {
 			fmt.Fprintf(w, "  Files:\n")
@@ -46,6 +65,26 @@ func (r *TextRenderer) RenderStatus(w io.Writer, status dot.Status) error {
 	return nil
 }
 
+// RenderPackageList renders installed and available packages as plain text.
+func (r *TextRenderer) RenderPackageList(w io.Writer, packages []dot.PackageInfo) error {
+	if len(packages) == 0 {
+		fmt.Fprintln(w, "No packages found")
+		return nil
+	}
+
+	for _, pkg := range packages {
+		status, source, links, installed := packageListRow(pkg)
+		fmt.Fprintf(w, "%s%s%s (%s)\n", r.colorText(r.scheme.Info), pkg.Name, r.resetColor(), status)
+		if pkg.Installed {
+			fmt.Fprintf(w, "  Source: %s\n", source)
+			fmt.Fprintf(w, "  Links: %s\n", links)
+			fmt.Fprintf(w, "  Installed: %s\n", installed)
+		}
+	}
+
+	return nil
+}
+
 func (r *TextRenderer) colorText(color string) string {
 	if r.colorize && color != "" {
 		return color
@@ -85,6 +124,25 @@ func (r *TextRenderer) RenderDiagnostics(w io.Writer, report dot.DiagnosticRepor
 	if report.Statistics.OrphanedLinks > 0 {
 		fmt.Fprintf(w, "  %sOrphaned Links: %d%s\n", r.colorText(r.scheme.Warning), report.Statistics.OrphanedLinks, r.resetColor())
 	}
+	if report.Statistics.ForeignLinks > 0 {
+		fmt.Fprintf(w, "  Foreign Links: %d\n", report.Statistics.ForeignLinks)
+	}
+	if report.Statistics.UnknownLinks > 0 {
+		fmt.Fprintf(w, "  Unknown Sources: %d\n", report.Statistics.UnknownLinks)
+	}
+	fmt.Fprintf(w, "  Files Scanned: %d\n", report.Statistics.FilesScanned)
+	fmt.Fprintf(w, "  Scan Duration: %s\n", report.Statistics.ScanDuration.Round(time.Millisecond))
+	if len(report.Statistics.Packages) > 0 {
+		fmt.Fprintf(w, "  Packages:\n")
+		for _, name := range slices.Sorted(maps.Keys(report.Statistics.Packages)) {
+			pkg := report.Statistics.Packages[name]
+			if pkg.UnknownLinks > 0 {
+				fmt.Fprintf(w, "    %s: %d links, %d broken, %d unknown\n", name, pkg.Links, pkg.BrokenLinks, pkg.UnknownLinks)
+				continue
+			}
+			fmt.Fprintf(w, "    %s: %d links, %d broken\n", name, pkg.Links, pkg.BrokenLinks)
+		}
+	}
 	fmt.Fprintln(w)
 
 	// Show issues
@@ -137,6 +195,14 @@ func (r *TextRenderer) RenderPlan(w io.Writer, plan domain.Plan) error {
 	}
 	fmt.Fprintln(w)
 
+	if len(plan.Metadata.Replacements) > 0 {
+		fmt.Fprintln(w, "Replaced files:")
+		for _, replacement := range plan.Metadata.Replacements {
+			r.renderReplacement(w, replacement)
+		}
+		fmt.Fprintln(w)
+	}
+
 	// Summary counts
 	fmt.Fprintln(w, "Summary:")
 	counts := r.countOperations(plan)
@@ -188,6 +254,12 @@ func (r *TextRenderer) renderOperation(w io.Writer, op domain.Operation) {
 	case domain.FileBackup:
 		fmt.Fprintf(w, "  %s Backup file: %s -> %s\n", symbol, typed.Source.String(), typed.Backup.String())
 
+	case domain.FileStash:
+		fmt.Fprintf(w, "  %s Backup file: %s -> %s\n", symbol, typed.Source.String(), domain.BackupObjectPath(typed.BackupDir.String(), typed.Hash))
+
+	case domain.FileRestore:
+		fmt.Fprintf(w, "  %s Restore file: %s -> %s\n", symbol, domain.BackupObjectPath(typed.BackupDir.String(), typed.Hash), typed.Path.String())
+
 	case domain.DirDelete:
 		deleteSymbol := r.colorText(r.scheme.Error) + "-" + r.resetColor()
 		fmt.Fprintf(w, "  %s Delete directory: %s\n", deleteSymbol, typed.Path.String())
@@ -202,6 +274,47 @@ func (r *TextRenderer) renderOperation(w io.Writer, op domain.Operation) {
 	}
 }
 
+// renderReplacement renders a file the plan replaces and, when both files
+// can be read, the difference between its content and what replaces it.
+func (r *TextRenderer) renderReplacement(w io.Writer, replacement domain.ReplacementInfo) {
+	symbol := r.colorText(r.scheme.Warning) + "~" + r.resetColor()
+	fmt.Fprintf(w, "  %s %s (%s, replaced by %s)\n", symbol, replacement.Path, replacement.Policy, replacement.Replacement)
+	if r.readFile == nil {
+		return
+	}
+
+	old, err := r.readFile(replacement.Path)
+	if err != nil {
+		fmt.Fprintf(w, "      %scannot read %s: %v%s\n", r.colorText(r.scheme.Muted), replacement.Path, err, r.resetColor())
+		return
+	}
+	new, err := r.readFile(replacement.Replacement)
+	if err != nil {
+		fmt.Fprintf(w, "      %scannot read %s: %v%s\n", r.colorText(r.scheme.Muted), replacement.Replacement, err, r.resetColor())
+		return
+	}
+
+	unified := diff.Unified(replacement.Path, replacement.Replacement, old, new)
+	if unified == "" {
+		fmt.Fprintf(w, "      %scontents are identical%s\n", r.colorText(r.scheme.Muted), r.resetColor())
+		return
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(unified, "\n"), "\n") {
+		color := ""
+		switch {
+		case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+			color = r.scheme.Muted
+		case strings.HasPrefix(line, "@@"):
+			color = r.scheme.Info
+		case strings.HasPrefix(line, "-"):
+			color = r.scheme.Error
+		case strings.HasPrefix(line, "+"):
+			color = r.scheme.Success
+		}
+		fmt.Fprintf(w, "      %s%s%s\n", r.colorText(color), line, r.resetColor())
+	}
+}
+
 // operationCounts holds counts of different operation types.
 type operationCounts struct {
 	DirCreate  int
@@ -228,7 +341,7 @@ func (r *TextRenderer) countOperations(plan domain.Plan) operationCounts {
 			counts.LinkDelete++
 		case domain.OpKindFileMove:
 			counts.FileMove++
-		case domain.OpKindFileBackup:
+		case domain.OpKindFileBackup, domain.OpKindFileStash:
 			counts.FileBackup++
 		}
 	}
@@ -2,6 +2,7 @@ package renderer
 
 import (
 	"bytes"
+	"strings"
 	"testing"
 	"time"
 
@@ -118,3 +119,23 @@ func TestTextRenderer_RenderStatus_WithPackages(t *testing.T) {
 	assert.Contains(t, output, "Links: 5")
 	assert.Contains(t, output, ".vimrc")
 }
+
+func TestTextRenderer_RenderStatus_SourceUnknown(t *testing.T) {
+	r := &TextRenderer{
+		colorize: false,
+		scheme:   ColorScheme{},
+		width:    80,
+	}
+
+	status := dot.Status{
+		Packages: []dot.PackageInfo{
+			{Name: "vim", InstalledAt: time.Now(), LinkCount: 1, Links: []string{".vimrc"}, SourceUnknown: true},
+			{Name: "zsh", InstalledAt: time.Now(), LinkCount: 1, Links: []string{".zshrc"}},
+		},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, r.RenderStatus(&buf, status))
+
+	assert.Equal(t, 1, strings.Count(buf.String(), "Source: unknown (package directory unavailable)"))
+}
@@ -28,6 +28,13 @@ func (r *YAMLRenderer) RenderStatus(w io.Writer, status dot.Status) error {
 	return encoder.Encode(status)
 }
 
+// RenderPackageList renders a package list as YAML.
+func (r *YAMLRenderer) RenderPackageList(w io.Writer, packages []dot.PackageInfo) error {
+	encoder := r.newEncoder(w)
+	defer encoder.Close()
+	return encoder.Encode(dot.Status{Packages: packages})
+}
+
 // RenderDiagnostics renders diagnostic report as YAML.
 func (r *YAMLRenderer) RenderDiagnostics(w io.Writer, report dot.DiagnosticReport) error {
 	encoder := r.newEncoder(w)
@@ -39,5 +46,5 @@ func (r *YAMLRenderer) RenderDiagnostics(w io.Writer, report dot.DiagnosticRepor
 func (r *YAMLRenderer) RenderPlan(w io.Writer, plan domain.Plan) error {
 	encoder := r.newEncoder(w)
 	defer encoder.Close()
-	return encoder.Encode(plan)
+	return encoder.Encode(newPlanDocument(plan))
 }
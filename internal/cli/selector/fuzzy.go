@@ -0,0 +1,327 @@
+package selector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"golang.org/x/term"
+
+	"github.com/jamesainslie/dot/internal/cli/pretty"
+)
+
+// ErrCancelled is returned when the user leaves a selection without
+// confirming it.
+var ErrCancelled = errors.New("package selection cancelled")
+
+// Option describes a package offered for selection.
+type Option struct {
+	// Name is the package name.
+	Name string
+
+	// Description is shown next to the name and searched with it.
+	Description string
+
+	// Platform lists the platforms the package is restricted to.
+	Platform []string
+}
+
+// OptionSelector is implemented by selectors that can show the details of
+// the packages they offer.
+type OptionSelector interface {
+	PackageSelector
+
+	// SelectOptions prompts the user to select among options and returns
+	// the names of the selected packages.
+	SelectOptions(ctx context.Context, options []Option) ([]string, error)
+}
+
+// FuzzySelector implements OptionSelector with a full-screen list that is
+// filtered by typing and allows several packages to be selected. When
+// input or output is not a terminal it falls back to the numbered prompt
+// of InteractiveSelector.
+type FuzzySelector struct {
+	input    io.Reader
+	output   io.Writer
+	fallback *InteractiveSelector
+}
+
+// NewFuzzySelector creates a new fuzzy selector.
+func NewFuzzySelector(input io.Reader, output io.Writer) *FuzzySelector {
+	return &FuzzySelector{
+		input:    input,
+		output:   output,
+		fallback: NewInteractiveSelector(input, output),
+	}
+}
+
+// Select prompts the user to select packages from the provided list.
+func (s *FuzzySelector) Select(ctx context.Context, packages []string) ([]string, error) {
+	options := make([]Option, len(packages))
+	for i, pkg := range packages {
+		options[i] = Option{Name: pkg}
+	}
+	return s.SelectOptions(ctx, options)
+}
+
+// SelectOptions prompts the user to select among options. Selected
+// packages are returned in the order of options.
+func (s *FuzzySelector) SelectOptions(ctx context.Context, options []Option) ([]string, error) {
+	if len(options) == 0 {
+		return []string{}, nil
+	}
+	if !isTerminal(s.input) || !isTerminal(s.output) {
+		names := make([]string, len(options))
+		for i, opt := range options {
+			names[i] = opt.Name
+		}
+		return s.fallback.Select(ctx, names)
+	}
+
+	program := tea.NewProgram(newSelectModel(options),
+		tea.WithInput(s.input),
+		tea.WithOutput(s.output),
+		tea.WithContext(ctx),
+		tea.WithAltScreen(),
+	)
+	final, err := program.Run()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("run package selector: %w", err)
+	}
+	m := final.(selectModel)
+	if m.cancelled {
+		return nil, ErrCancelled
+	}
+	return m.selection(), nil
+}
+
+// isTerminal reports whether v is a file attached to a terminal.
+func isTerminal(v any) bool {
+	f, ok := v.(interface{ Fd() uintptr })
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+// selectModel is the bubbletea model of the fuzzy selector.
+type selectModel struct {
+	options []Option
+	query   []rune
+	// matches holds the indices of the options matching query, best
+	// match first.
+	matches   []int
+	cursor    int
+	offset    int
+	selected  map[int]bool
+	height    int
+	cancelled bool
+}
+
+// newSelectModel creates a model offering options, none selected.
+func newSelectModel(options []Option) selectModel {
+	m := selectModel{
+		options:  options,
+		selected: make(map[int]bool),
+		height:   10,
+	}
+	m.filter()
+	return m
+}
+
+// Init implements tea.Model.
+func (m selectModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements tea.Model.
+func (m selectModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		// Leave room for the header, the query and the help line
+		m.height = max(msg.Height-4, 1)
+		m.scroll()
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyCtrlC, tea.KeyEsc:
+			m.cancelled = true
+			return m, tea.Quit
+		case tea.KeyEnter:
+			return m, tea.Quit
+		case tea.KeyUp, tea.KeyCtrlP:
+			m.move(-1)
+		case tea.KeyDown, tea.KeyCtrlN:
+			m.move(1)
+		case tea.KeyPgUp:
+			m.move(-m.height)
+		case tea.KeyPgDown:
+			m.move(m.height)
+		case tea.KeySpace, tea.KeyTab:
+			if len(m.matches) > 0 {
+				idx := m.matches[m.cursor]
+				m.selected[idx] = !m.selected[idx]
+				if msg.Type == tea.KeyTab {
+					m.move(1)
+				}
+			}
+		case tea.KeyCtrlA:
+			m.toggleAll()
+		case tea.KeyCtrlU:
+			m.query = nil
+			m.filter()
+		case tea.KeyBackspace:
+			if len(m.query) > 0 {
+				m.query = m.query[:len(m.query)-1]
+				m.filter()
+			}
+		case tea.KeyRunes:
+			m.query = append(m.query, msg.Runes...)
+			m.filter()
+		}
+	}
+	return m, nil
+}
+
+// View implements tea.Model.
+func (m selectModel) View() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n", pretty.Bold("Select packages to install"),
+		pretty.Dim(fmt.Sprintf("(%d of %d selected)", len(m.selection()), len(m.options))))
+	fmt.Fprintf(&b, "%s %s\n", pretty.Accent(">"), string(m.query))
+
+	width := 0
+	for _, opt := range m.options {
+		width = max(width, len(opt.Name))
+	}
+	end := min(m.offset+m.height, len(m.matches))
+	for i := m.offset; i < end; i++ {
+		opt := m.options[m.matches[i]]
+		pointer, box := " ", "[ ]"
+		if i == m.cursor {
+			pointer = pretty.Accent("›")
+		}
+		if m.selected[m.matches[i]] {
+			box = pretty.Success("[x]")
+		}
+		line := fmt.Sprintf("%s %s %-*s", pointer, box, width, opt.Name)
+		if opt.Description != "" {
+			line += "  " + opt.Description
+		}
+		if len(opt.Platform) > 0 {
+			line += "  " + pretty.Dim(strings.Join(opt.Platform, ", "))
+		}
+		b.WriteString(line + "\n")
+	}
+	if len(m.matches) == 0 {
+		b.WriteString(pretty.Dim("  no matching packages") + "\n")
+	}
+
+	b.WriteString(pretty.Dim("↑/↓ move · space select · ctrl+a all/none · enter confirm · esc cancel"))
+	return b.String()
+}
+
+// selection returns the names of the selected packages in option order.
+func (m selectModel) selection() []string {
+	names := make([]string, 0, len(m.selected))
+	for i, opt := range m.options {
+		if m.selected[i] {
+			names = append(names, opt.Name)
+		}
+	}
+	return names
+}
+
+// move moves the cursor by delta rows within the matches.
+func (m *selectModel) move(delta int) {
+	if len(m.matches) == 0 {
+		return
+	}
+	m.cursor = min(max(m.cursor+delta, 0), len(m.matches)-1)
+	m.scroll()
+}
+
+// scroll keeps the cursor inside the visible rows.
+func (m *selectModel) scroll() {
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+m.height {
+		m.offset = m.cursor - m.height + 1
+	}
+}
+
+// toggleAll selects every matching option, or clears them when all are
+// already selected.
+func (m *selectModel) toggleAll() {
+	all := true
+	for _, idx := range m.matches {
+		all = all && m.selected[idx]
+	}
+	for _, idx := range m.matches {
+		m.selected[idx] = !all
+	}
+}
+
+// filter recomputes the matches for the current query.
+func (m *selectModel) filter() {
+	type match struct {
+		index int
+		score int
+	}
+	query := string(m.query)
+	found := make([]match, 0, len(m.options))
+	for i, opt := range m.options {
+		score, ok := fuzzyScore(query, opt.Name)
+		if descScore, descOK := fuzzyScore(query, opt.Description); !ok && descOK {
+			// Matches in the description rank below matches in the name
+			score, ok = descScore-len(opt.Name)-100, true
+		}
+		if ok {
+			found = append(found, match{index: i, score: score})
+		}
+	}
+	sort.SliceStable(found, func(a, b int) bool { return found[a].score > found[b].score })
+
+	m.matches = make([]int, 0, len(found))
+	for _, f := range found {
+		m.matches = append(m.matches, f.index)
+	}
+	m.cursor, m.offset = 0, 0
+}
+
+// fuzzyScore reports whether the runes of query appear in text in order,
+// ignoring case, and scores the match. Runs of consecutive runes, runes
+// at the start of text or of a word, and shorter texts score higher.
+func fuzzyScore(query, text string) (int, bool) {
+	if query == "" {
+		return 0, true
+	}
+	q := []rune(strings.ToLower(query))
+	t := []rune(strings.ToLower(text))
+
+	score, qi, prev := 0, 0, -2
+	for ti := 0; ti < len(t) && qi < len(q); ti++ {
+		if t[ti] != q[qi] {
+			continue
+		}
+		switch {
+		case ti == prev+1:
+			score += 5
+		case ti == 0 || !unicode.IsLetter(t[ti-1]) && !unicode.IsDigit(t[ti-1]):
+			score += 3
+		default:
+			score++
+		}
+		prev = ti
+		qi++
+	}
+	if qi < len(q) {
+		return 0, false
+	}
+	return score*10 - len(t), true
+}
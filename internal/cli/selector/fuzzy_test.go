@@ -0,0 +1,137 @@
+package selector
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func press(m selectModel, msgs ...tea.Msg) selectModel {
+	for _, msg := range msgs {
+		next, _ := m.Update(msg)
+		m = next.(selectModel)
+	}
+	return m
+}
+
+func typed(s string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+var testOptions = []Option{
+	{Name: "dot-vim", Description: "Vim editor"},
+	{Name: "dot-zsh", Description: "Shell", Platform: []string{"linux", "darwin"}},
+	{Name: "dot-tmux", Description: "Terminal multiplexer"},
+	{Name: "dot-config"},
+}
+
+func TestFuzzySelector_FallsBackWithoutTerminal(t *testing.T) {
+	output := &bytes.Buffer{}
+	sel := NewFuzzySelector(strings.NewReader("2\n"), output)
+
+	selected, err := sel.SelectOptions(context.Background(), testOptions)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dot-zsh"}, selected)
+	assert.Contains(t, output.String(), "Available packages:")
+}
+
+func TestFuzzySelector_Empty(t *testing.T) {
+	sel := NewFuzzySelector(strings.NewReader(""), &bytes.Buffer{})
+
+	selected, err := sel.Select(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Empty(t, selected)
+}
+
+func TestSelectModel_SelectAndNavigate(t *testing.T) {
+	m := press(newSelectModel(testOptions),
+		tea.KeyMsg{Type: tea.KeySpace},
+		tea.KeyMsg{Type: tea.KeyDown},
+		tea.KeyMsg{Type: tea.KeyDown},
+		tea.KeyMsg{Type: tea.KeyTab},
+		tea.KeyMsg{Type: tea.KeyUp},
+		tea.KeyMsg{Type: tea.KeyUp},
+		tea.KeyMsg{Type: tea.KeyUp},
+	)
+
+	assert.Equal(t, []string{"dot-vim", "dot-tmux"}, m.selection())
+	assert.Equal(t, 0, m.cursor)
+
+	// Toggling again clears a selection
+	m = press(m, tea.KeyMsg{Type: tea.KeySpace})
+	assert.Equal(t, []string{"dot-tmux"}, m.selection())
+}
+
+func TestSelectModel_FilterAndSelectAll(t *testing.T) {
+	m := press(newSelectModel(testOptions), typed("mux"))
+	require.Len(t, m.matches, 1)
+	assert.Equal(t, "dot-tmux", m.options[m.matches[0]].Name)
+
+	// Descriptions are searched too
+	m = press(m, tea.KeyMsg{Type: tea.KeyCtrlU}, typed("shell"))
+	require.Len(t, m.matches, 1)
+	assert.Equal(t, "dot-zsh", m.options[m.matches[0]].Name)
+
+	// Select all applies to the shown matches only
+	m = press(m, tea.KeyMsg{Type: tea.KeyCtrlA})
+	assert.Equal(t, []string{"dot-zsh"}, m.selection())
+
+	m = press(m, tea.KeyMsg{Type: tea.KeyCtrlU}, tea.KeyMsg{Type: tea.KeyCtrlA})
+	assert.Len(t, m.selection(), len(testOptions))
+	m = press(m, tea.KeyMsg{Type: tea.KeyCtrlA})
+	assert.Empty(t, m.selection())
+
+	m = press(m, typed("zq"), tea.KeyMsg{Type: tea.KeyBackspace})
+	assert.Equal(t, "z", string(m.query))
+	assert.Contains(t, m.View(), "dot-zsh")
+}
+
+func TestSelectModel_ConfirmAndCancel(t *testing.T) {
+	m := newSelectModel(testOptions)
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	require.NotNil(t, cmd)
+	assert.IsType(t, tea.QuitMsg{}, cmd())
+
+	m = press(m, tea.KeyMsg{Type: tea.KeyEsc})
+	assert.True(t, m.cancelled)
+}
+
+func TestSelectModel_View(t *testing.T) {
+	m := press(newSelectModel(testOptions), tea.KeyMsg{Type: tea.KeySpace})
+	view := m.View()
+
+	assert.Contains(t, view, "1 of 4 selected")
+	assert.Contains(t, view, "[x]")
+	assert.Contains(t, view, "Vim editor")
+	assert.Contains(t, view, "linux, darwin")
+
+	m = press(m, typed("nothing"))
+	assert.Contains(t, m.View(), "no matching packages")
+}
+
+func TestSelectModel_Scroll(t *testing.T) {
+	m := press(newSelectModel(testOptions), tea.WindowSizeMsg{Height: 6})
+	assert.Equal(t, 2, m.height)
+
+	m = press(m, tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeyDown})
+	assert.Equal(t, 3, m.cursor)
+	assert.Equal(t, 2, m.offset)
+	assert.NotContains(t, m.View(), "dot-vim")
+}
+
+func TestFuzzyScore(t *testing.T) {
+	_, ok := fuzzyScore("vm", "dot-vim")
+	assert.True(t, ok)
+	_, ok = fuzzyScore("mv", "dot-vim")
+	assert.False(t, ok)
+
+	// Consecutive and word-start matches rank higher
+	prefix, _ := fuzzyScore("vim", "dot-vim")
+	scattered, _ := fuzzyScore("vim", "dot-viewim")
+	assert.Greater(t, prefix, scattered)
+}
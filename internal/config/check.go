@@ -0,0 +1,166 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// FileError is a problem found in a configuration file, at a line of the
+// file when it is known.
+type FileError struct {
+	// Path is the configuration file.
+	Path string
+
+	// Line is the line of the problem, counted from 1, or 0 if unknown.
+	Line int
+
+	// Err is the problem.
+	Err error
+}
+
+// Error returns the problem prefixed with the file and line.
+func (e *FileError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %v", e.Path, e.Line, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+// Unwrap returns the problem.
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	// syntaxLine matches the line of YAML syntax errors.
+	syntaxLine = regexp.MustCompile(`\bline (\d+)\b`)
+
+	// problemKey matches the key a validation error starts with, such as
+	// "ignore.patterns[2]: ..." or `symlinks.policies["*.env"]: ...`.
+	problemKey = regexp.MustCompile(`^([a-z_]+(?:\.[^.\[:\s]+)*)(?:\[(\d+|"(?:[^"\\]|\\.)*")\])?: `)
+
+	// decodeKey matches the key named by errors decoding values of the
+	// wrong type, such as "'symlinks.folding' cannot parse value".
+	decodeKey = regexp.MustCompile(`'([a-z_]+(?:\.[a-z_]+)+)'`)
+)
+
+// CheckFile loads the configuration file at path, with the files it
+// includes, and returns the first problem the loader would reject it for
+// as a *FileError, or nil. The line of syntax errors is reported, as is
+// the line of the key holding an invalid value in YAML and JSON files.
+func CheckFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return &FileError{Path: path, Err: err}
+	}
+
+	if _, _, err := readConfigFile(path); err != nil {
+		return &FileError{Path: path, Line: syntaxErrorLine(data, err), Err: err}
+	}
+
+	cfg, _, err := decodeExtendedFile(path, true)
+	if err != nil {
+		line := 0
+		if m := decodeKey.FindStringSubmatch(err.Error()); m != nil {
+			line = keyLine(path, data, strings.Split(m[1], "."), "")
+		}
+		return &FileError{Path: path, Line: line, Err: err}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		line := 0
+		if m := problemKey.FindStringSubmatch(err.Error()); m != nil {
+			line = keyLine(path, data, strings.Split(m[1], "."), m[2])
+		}
+		return &FileError{Path: path, Line: line, Err: err}
+	}
+	return nil
+}
+
+// syntaxErrorLine returns the line of the syntax error err of data, or 0.
+func syntaxErrorLine(data []byte, err error) int {
+	var tomlErr *toml.DecodeError
+	if errors.As(err, &tomlErr) {
+		row, _ := tomlErr.Position()
+		return row
+	}
+	var jsonErr *json.SyntaxError
+	if errors.As(err, &jsonErr) && jsonErr.Offset <= int64(len(data)) {
+		return bytes.Count(data[:jsonErr.Offset], []byte("\n")) + 1
+	}
+	if m := syntaxLine.FindStringSubmatch(err.Error()); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		return line
+	}
+	return 0
+}
+
+// keyLine returns the line of the key path in data, of the element index
+// of its value if set, or of the closest enclosing key set in the file
+// when the key is not. TOML files are not located, and give 0.
+func keyLine(path string, data []byte, keys []string, index string) int {
+	if filepath.Ext(path) == ".toml" {
+		return 0
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 {
+		return 0
+	}
+
+	line := 0
+	node := doc.Content[0]
+	for _, key := range keys {
+		value := mappingValue(node, key)
+		if value == nil {
+			return line
+		}
+		line, node = value.line, value.node
+	}
+	if index == "" {
+		return line
+	}
+
+	if i, err := strconv.Atoi(index); err == nil {
+		if node.Kind == yaml.SequenceNode && i < len(node.Content) {
+			return node.Content[i].Line
+		}
+		return line
+	}
+	if name, err := strconv.Unquote(index); err == nil {
+		if value := mappingValue(node, name); value != nil {
+			return value.line
+		}
+	}
+	return line
+}
+
+// mappingEntry is the value of a key in a YAML mapping, with the line of
+// the key.
+type mappingEntry struct {
+	line int
+	node *yaml.Node
+}
+
+// mappingValue returns the entry of key in the mapping node, compared
+// without case as the loader compares keys, or nil.
+func mappingValue(node *yaml.Node, key string) *mappingEntry {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if strings.EqualFold(node.Content[i].Value, key) {
+			return &mappingEntry{line: node.Content[i].Line, node: node.Content[i+1]}
+		}
+	}
+	return nil
+}
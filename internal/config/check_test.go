@@ -0,0 +1,113 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		contents string
+		line     int
+		message  string
+	}{
+		{
+			name:     "valid",
+			file:     "config.yaml",
+			contents: "logging:\n  level: DEBUG\n",
+		},
+		{
+			name:     "yaml syntax",
+			file:     "config.yaml",
+			contents: "logging:\n  level: DEBUG\nsymlinks:\n  folding: yes: no\n",
+			line:     4,
+			message:  "mapping values are not allowed",
+		},
+		{
+			name:     "json syntax",
+			file:     "config.json",
+			contents: "{\n  \"logging\": {\n    \"level\": \"DEBUG\",\n  }\n}\n",
+			line:     4,
+			message:  "invalid character",
+		},
+		{
+			name:     "toml syntax",
+			file:     "config.toml",
+			contents: "[logging]\nlevel = \"DEBUG\"\nformat =\n",
+			line:     3,
+			message:  "toml:",
+		},
+		{
+			name:     "invalid value",
+			file:     "config.yaml",
+			contents: "directories:\n  package: /tmp\nlogging:\n  format: text\n  level: LOUD\n",
+			line:     5,
+			message:  "logging.level: invalid log level",
+		},
+		{
+			name:     "invalid list element",
+			file:     "config.yaml",
+			contents: "ignore:\n  patterns:\n    - \"*.swp\"\n    - \"[a\"\n",
+			line:     4,
+			message:  "ignore.patterns[1]",
+		},
+		{
+			name:     "invalid value in json",
+			file:     "config.json",
+			contents: "{\n  \"output\": {\n    \"verbosity\": 7\n  }\n}\n",
+			line:     3,
+			message:  "output.verbosity",
+		},
+		{
+			name:     "wrong type",
+			file:     "config.yaml",
+			contents: "symlinks:\n  folding: sometimes\n",
+			line:     2,
+			message:  "'symlinks.folding'",
+		},
+		{
+			name:     "invalid value in toml",
+			file:     "config.toml",
+			contents: "[logging]\nlevel = \"LOUD\"\n",
+			message:  "logging.level",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			require.NoError(t, os.WriteFile(path, []byte(tt.contents), 0600))
+
+			err := CheckFile(path)
+			if tt.message == "" {
+				require.NoError(t, err)
+				return
+			}
+			var fileErr *FileError
+			require.True(t, errors.As(err, &fileErr), "got %v", err)
+			assert.Equal(t, path, fileErr.Path)
+			assert.Equal(t, tt.line, fileErr.Line)
+			assert.ErrorContains(t, err, tt.message)
+		})
+	}
+}
+
+func TestCheckFile_Missing(t *testing.T) {
+	err := CheckFile(filepath.Join(t.TempDir(), "config.yaml"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestFileError_Error(t *testing.T) {
+	err := &FileError{Path: "config.yaml", Line: 3, Err: errors.New("bad")}
+	assert.Equal(t, "config.yaml:3: bad", err.Error())
+
+	err.Line = 0
+	assert.Equal(t, "config.yaml: bad", err.Error())
+}
@@ -128,6 +128,48 @@ func GetConfigPath(appName string) string {
 	return filepath.Join(".", appName)
 }
 
+// configFileNames are the names the configuration file is looked for
+// under in the configuration directory, in order.
+var configFileNames = []string{"config.yaml", "config.yml", "config.json", "config.toml"}
+
+// FindConfigFile returns the configuration file in dir: the first of
+// config.yaml, config.yml, config.json and config.toml that exists, or
+// config.yaml when none does.
+func FindConfigFile(dir string) string {
+	for _, name := range configFileNames {
+		path := filepath.Join(dir, name)
+		if fileExists(path) {
+			return path
+		}
+	}
+	return filepath.Join(dir, configFileNames[0])
+}
+
+// GetCachePath returns XDG-compliant cache directory path.
+// Uses XDG_CACHE_HOME if set, otherwise falls back to the user cache
+// directory of the platform, such as ~/.cache on Unix systems.
+func GetCachePath(appName string) string {
+	if cacheHome := os.Getenv("XDG_CACHE_HOME"); cacheHome != "" {
+		return filepath.Join(cacheHome, appName)
+	}
+
+	if cacheDir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(cacheDir, appName)
+	}
+
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(homeDir, ".cache", appName)
+	}
+
+	return filepath.Join(".", appName)
+}
+
+// GetStatePath returns XDG-compliant state directory path.
+// Uses XDG_STATE_HOME if set, otherwise falls back to ~/.local/state.
+func GetStatePath(appName string) string {
+	return getXDGStatePath(appName)
+}
+
 // contains checks if a string slice contains a value.
 func contains(slice []string, value string) bool {
 	for _, item := range slice {
@@ -186,6 +186,17 @@ func TestGetConfigPath(t *testing.T) {
 	}
 }
 
+func TestFindConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	assert.Equal(t, filepath.Join(dir, "config.yaml"), config.FindConfigFile(dir))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.toml"), nil, 0600))
+	assert.Equal(t, filepath.Join(dir, "config.toml"), config.FindConfigFile(dir))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yaml"), nil, 0600))
+	assert.Equal(t, filepath.Join(dir, "config.yaml"), config.FindConfigFile(dir))
+}
+
 func TestContainsFunction(t *testing.T) {
 	// Test the validation logic indirectly through Validate
 	cfg := &config.Config{
@@ -0,0 +1,302 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jamesainslie/dot/internal/domain"
+)
+
+// Convert rewrites the configuration file in format, as a file of the same
+// name with the extension of the format, and renames the original with a
+// ".bak" suffix. Comments of keys are kept when both formats have them,
+// which JSON does not. It returns the path of the new file.
+func (w *Writer) Convert(format string) (string, error) {
+	strategy, err := GetStrategy(format)
+	if err != nil {
+		return "", err
+	}
+	to, from := strategy.Name(), w.DetectFormat()
+	if !fileExists(w.path) {
+		return "", fmt.Errorf("config file not found: %s", w.path)
+	}
+	if to == from {
+		return "", fmt.Errorf("config file is already %s: %s", to, w.path)
+	}
+
+	convertedPath := strings.TrimSuffix(w.path, filepath.Ext(w.path)) + "." + to
+	if fileExists(convertedPath) {
+		return "", fmt.Errorf("config file already exists: %s", convertedPath)
+	}
+
+	cfg, _, err := loadExtendedFromFile(w.path, false)
+	if err != nil {
+		return "", fmt.Errorf("load existing config: %w", err)
+	}
+	original, err := os.ReadFile(w.path)
+	if err != nil {
+		return "", fmt.Errorf("read config file: %w", err)
+	}
+
+	data, err := w.marshal(cfg, WriteOptions{Format: to})
+	if err != nil {
+		return "", fmt.Errorf("marshal config: %w", err)
+	}
+	if data, err = writeComments(data, to, readComments(original, from)); err != nil {
+		return "", fmt.Errorf("write comments: %w", err)
+	}
+
+	if err := os.WriteFile(convertedPath, data, domain.PermUserRW); err != nil {
+		return "", fmt.Errorf("write config file: %w", err)
+	}
+	if err := os.Rename(w.path, w.path+".bak"); err != nil {
+		return "", fmt.Errorf("back up config file: %w", err)
+	}
+	return convertedPath, nil
+}
+
+// keyComment is the comment of a key: the lines above it and the comment
+// at the end of its line, without their "#".
+type keyComment struct {
+	head []string
+	line string
+}
+
+// keyComments are the comments of a configuration file by dotted key. The
+// comment at the top of the file has the empty key.
+type keyComments map[string]keyComment
+
+// readComments returns the comments of the keys of data in format.
+func readComments(data []byte, format string) keyComments {
+	switch format {
+	case "yaml":
+		return readYAMLComments(data)
+	case "toml":
+		return readTOMLComments(data)
+	default:
+		return nil
+	}
+}
+
+// writeComments adds comments to data in format.
+func writeComments(data []byte, format string, comments keyComments) ([]byte, error) {
+	if len(comments) == 0 {
+		return data, nil
+	}
+	switch format {
+	case "yaml":
+		return writeYAMLComments(data, comments)
+	case "toml":
+		return writeTOMLComments(data, comments), nil
+	default:
+		return data, nil
+	}
+}
+
+// readYAMLComments returns the comments of the mapping keys of a YAML
+// document.
+func readYAMLComments(data []byte) keyComments {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 {
+		return nil
+	}
+	comments := keyComments{}
+	if head := commentLines(doc.HeadComment); len(head) > 0 {
+		comments[""] = keyComment{head: head}
+	}
+	walkYAMLKeys(doc.Content[0], "", func(path string, key, value *yaml.Node) {
+		comment := keyComment{head: commentLines(key.HeadComment)}
+		if line := commentLines(key.LineComment + "\n" + value.LineComment); len(line) > 0 {
+			comment.line = line[0]
+		}
+		if len(comment.head) > 0 || comment.line != "" {
+			comments[canonicalCommentKey(path)] = comment
+		}
+	})
+	return comments
+}
+
+// writeYAMLComments sets the comments of the keys of a YAML document.
+func writeYAMLComments(data []byte, comments keyComments) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return data, nil
+	}
+	doc.HeadComment = formatCommentLines(comments[""].head)
+	walkYAMLKeys(doc.Content[0], "", func(path string, key, value *yaml.Node) {
+		if comment, ok := comments[path]; ok {
+			key.HeadComment = formatCommentLines(comment.head)
+			if comment.line != "" {
+				key.LineComment = "# " + comment.line
+			}
+		}
+	})
+
+	return encodeYAMLNode(&doc, 4)
+}
+
+// walkYAMLKeys calls visit with the dotted path, key and value of each
+// key of the mapping node and the mappings nested in it.
+func walkYAMLKeys(node *yaml.Node, prefix string, visit func(path string, key, value *yaml.Node)) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		path := prefix + key.Value
+		visit(path, key, value)
+		walkYAMLKeys(value, path+".", visit)
+	}
+}
+
+// readTOMLComments returns the comments of the tables and keys of a TOML
+// document.
+func readTOMLComments(data []byte) keyComments {
+	comments := keyComments{}
+	var table string
+	var pending []string
+	for i, raw := range strings.Split(string(data), "\n") {
+		line := strings.TrimSpace(raw)
+		switch {
+		case strings.HasPrefix(line, "#"):
+			pending = append(pending, commentLines(line)...)
+			continue
+		case line == "":
+			if i > 0 && table == "" && len(comments) == 0 && len(pending) > 0 {
+				comments[""] = keyComment{head: pending}
+			}
+			pending = nil
+			continue
+		}
+
+		path, rest, ok := tomlLineKey(line, table)
+		if !ok {
+			pending = nil
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			table = path
+		}
+		comment := keyComment{head: pending, line: tomlLineComment(rest)}
+		if len(comment.head) > 0 || comment.line != "" {
+			comments[canonicalCommentKey(path)] = comment
+		}
+		pending = nil
+	}
+	return comments
+}
+
+// writeTOMLComments adds comments above the tables and keys of a TOML
+// document written by the TOML strategy.
+func writeTOMLComments(data []byte, comments keyComments) []byte {
+	var buf bytes.Buffer
+	if head := comments[""].head; len(head) > 0 {
+		buf.WriteString(formatCommentLines(head) + "\n\n")
+	}
+	var table string
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	for _, raw := range lines {
+		line := strings.TrimSpace(raw)
+		path, _, ok := tomlLineKey(line, table)
+		if !ok {
+			buf.WriteString(raw + "\n")
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			table = path
+		}
+		comment := comments[path]
+		indent := raw[:len(raw)-len(strings.TrimLeft(raw, " \t"))]
+		for _, head := range comment.head {
+			buf.WriteString(indent + strings.TrimRight("# "+head, " ") + "\n")
+		}
+		buf.WriteString(raw)
+		if comment.line != "" {
+			buf.WriteString(" # " + comment.line)
+		}
+		buf.WriteString("\n")
+	}
+	return buf.Bytes()
+}
+
+// tomlLineKey returns the dotted path of the table header or key of a
+// TOML line in table, and the rest of the line.
+func tomlLineKey(line, table string) (string, string, bool) {
+	if strings.HasPrefix(line, "[") && !strings.HasPrefix(line, "[[") {
+		name, rest, ok := strings.Cut(line[1:], "]")
+		return tomlKeyPath(name), rest, ok
+	}
+	key, rest, ok := strings.Cut(line, "=")
+	if !ok {
+		return "", "", false
+	}
+	path := tomlKeyPath(key)
+	if table != "" {
+		path = table + "." + path
+	}
+	return path, rest, true
+}
+
+// tomlLineComment returns the comment at the end of the rest of a TOML
+// line, skipping the "#" in strings.
+func tomlLineComment(rest string) string {
+	var quote byte
+	for i := 0; i < len(rest); i++ {
+		switch c := rest[i]; {
+		case quote == 0 && c == '#':
+			return strings.TrimSpace(rest[i+1:])
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == '"' && c == '\\':
+			i++
+		case c == quote:
+			quote = 0
+		}
+	}
+	return ""
+}
+
+// tomlKeyPath returns the dotted path of a TOML key, without its quotes.
+func tomlKeyPath(key string) string {
+	parts := strings.Split(strings.TrimSpace(key), ".")
+	for i, part := range parts {
+		parts[i] = strings.Trim(strings.TrimSpace(part), `"'`)
+	}
+	return strings.Join(parts, ".")
+}
+
+// canonicalCommentKey returns the key replacing a deprecated key, so that
+// the comment follows the value to its new key.
+func canonicalCommentKey(path string) string {
+	key, _ := CanonicalKey(path)
+	return key
+}
+
+// commentLines returns the lines of a comment without their "#".
+func commentLines(comment string) []string {
+	var lines []string
+	for _, line := range strings.Split(comment, "\n") {
+		line = strings.TrimSpace(line)
+		if text, ok := strings.CutPrefix(line, "#"); ok {
+			lines = append(lines, strings.TrimPrefix(text, " "))
+		}
+	}
+	return lines
+}
+
+// formatCommentLines returns lines as a comment.
+func formatCommentLines(lines []string) string {
+	formatted := make([]string, len(lines))
+	for i, line := range lines {
+		formatted[i] = strings.TrimRight("# "+line, " ")
+	}
+	return strings.Join(formatted, "\n")
+}
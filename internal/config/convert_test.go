@@ -0,0 +1,91 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/config"
+)
+
+const commentedConfig = `# Configuration of my machines
+
+# Where the dotfiles live
+directories:
+  # Cloned from the dotfiles repository
+  package: /srv/dotfiles # not ~/dotfiles
+logging:
+  level: DEBUG
+ignore:
+  # Editor files
+  patterns:
+    - "*.swp"
+`
+
+func TestWriter_Convert(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(commentedConfig), 0600))
+
+	tomlPath, err := config.NewWriter(configPath).Convert("toml")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(filepath.Dir(configPath), "config.toml"), tomlPath)
+	assert.NoFileExists(t, configPath)
+	assert.FileExists(t, configPath+".bak")
+
+	data, err := os.ReadFile(tomlPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "# Configuration of my machines\n\n")
+	assert.Contains(t, string(data), "# Where the dotfiles live\n[directories]\n")
+	assert.Contains(t, string(data), "# Cloned from the dotfiles repository\npackage = '/srv/dotfiles' # not ~/dotfiles\n")
+	assert.Contains(t, string(data), "# Editor files\npatterns = ")
+
+	cfg, err := config.LoadExtendedFromFile(tomlPath)
+	require.NoError(t, err)
+	assert.Equal(t, "/srv/dotfiles", cfg.Directories.Package)
+	assert.Equal(t, "DEBUG", cfg.Logging.Level)
+	assert.Equal(t, []string{"*.swp"}, cfg.Ignore.Patterns)
+
+	// Back to YAML, with the comments kept through TOML
+	require.NoError(t, os.Remove(configPath+".bak"))
+	yamlPath, err := config.NewWriter(tomlPath).Convert("yml")
+	require.NoError(t, err)
+	assert.Equal(t, configPath, yamlPath)
+	data, err = os.ReadFile(yamlPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "# Where the dotfiles live\ndirectories:\n")
+	assert.Contains(t, string(data), "    # Cloned from the dotfiles repository\n    package: /srv/dotfiles # not ~/dotfiles\n")
+}
+
+func TestWriter_ConvertJSON(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(commentedConfig), 0600))
+
+	jsonPath, err := config.NewWriter(configPath).Convert("json")
+	require.NoError(t, err)
+
+	cfg, err := config.LoadExtendedFromFile(jsonPath)
+	require.NoError(t, err)
+	assert.Equal(t, "/srv/dotfiles", cfg.Directories.Package)
+}
+
+func TestWriter_ConvertErrors(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+
+	_, err := config.NewWriter(configPath).Convert("toml")
+	assert.ErrorContains(t, err, "config file not found")
+
+	require.NoError(t, os.WriteFile(configPath, []byte(commentedConfig), 0600))
+	_, err = config.NewWriter(configPath).Convert("xml")
+	assert.ErrorContains(t, err, "unsupported format")
+	_, err = config.NewWriter(configPath).Convert("yaml")
+	assert.ErrorContains(t, err, "already yaml")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.toml"), nil, 0600))
+	_, err = config.NewWriter(configPath).Convert("toml")
+	assert.ErrorContains(t, err, "already exists")
+	assert.FileExists(t, configPath)
+}
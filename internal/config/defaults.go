@@ -39,16 +39,19 @@ const (
 	DefaultPackagesValidateNames = true   // Validate package naming conventions
 
 	// Doctor defaults
-	DefaultDoctorAutoFix          = false // Do not auto-fix issues (require explicit action)
-	DefaultDoctorCheckManifest    = true  // Check manifest integrity
-	DefaultDoctorCheckBrokenLinks = true  // Check for broken symlinks
-	DefaultDoctorCheckOrphaned    = false // Do not check for orphaned links (opt-in)
-	DefaultDoctorOrphanScanMode   = "off" // Orphan scan mode (off, scoped, deep)
-	DefaultDoctorOrphanScanDepth  = 0     // Orphan scan depth (0 = unlimited)
-	DefaultDoctorCheckPermissions = false // Do not check file permissions
+	DefaultDoctorAutoFix           = false // Do not auto-fix issues (require explicit action)
+	DefaultDoctorCheckManifest     = true  // Check manifest integrity
+	DefaultDoctorCheckBrokenLinks  = true  // Check for broken symlinks
+	DefaultDoctorCheckOrphaned     = false // Do not check for orphaned links (opt-in)
+	DefaultDoctorOrphanScanMode    = "off" // Orphan scan mode (off, scoped, deep)
+	DefaultDoctorOrphanScanDepth   = 0     // Orphan scan depth (0 = unlimited)
+	DefaultDoctorCheckPermissions  = false // Do not check file permissions
+	DefaultDoctorOrphanedThreshold = 0     // Any orphaned link makes health a warning
+	DefaultDoctorBrokenThreshold   = 0     // Any broken link makes health an error
 
 	// Ignore defaults
-	DefaultIgnoreUseDefaults = true // Use default ignore patterns
+	DefaultIgnoreUseDefaults  = true   // Use default ignore patterns
+	DefaultIgnoreSpecialFiles = "skip" // Skip sockets, named pipes, and devices
 
 	// Experimental defaults
 	DefaultExperimentalParallel  = false // Experimental parallel operations disabled
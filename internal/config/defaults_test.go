@@ -54,6 +54,8 @@ func TestDefaultValueConstants(t *testing.T) {
 		{name: "DefaultDoctorOrphanScanMode", constant: DefaultDoctorOrphanScanMode, expected: "off", desc: "default orphan scan mode"},
 		{name: "DefaultDoctorOrphanScanDepth", constant: DefaultDoctorOrphanScanDepth, expected: 0, desc: "default orphan scan depth"},
 		{name: "DefaultDoctorCheckPermissions", constant: DefaultDoctorCheckPermissions, expected: false, desc: "default check permissions"},
+		{name: "DefaultDoctorOrphanedThreshold", constant: DefaultDoctorOrphanedThreshold, expected: 0, desc: "default orphaned threshold"},
+		{name: "DefaultDoctorBrokenThreshold", constant: DefaultDoctorBrokenThreshold, expected: 0, desc: "default broken threshold"},
 
 		// Ignore defaults
 		{name: "DefaultIgnoreUseDefaults", constant: DefaultIgnoreUseDefaults, expected: true, desc: "default use ignore defaults"},
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// KeyAlias maps a deprecated configuration key to the key replacing it.
+type KeyAlias struct {
+	Old string
+	New string
+}
+
+// EnvAlias maps a deprecated environment variable, without the application
+// prefix, to the configuration key it sets.
+type EnvAlias struct {
+	Name string
+	Key  string
+}
+
+// deprecatedKeys lists renamed configuration file keys. Each old key is
+// also accepted as an environment variable.
+var deprecatedKeys = []KeyAlias{
+	{Old: "directories.stow", New: KeyDirPackage},
+}
+
+// deprecatedEnv lists environment variables from earlier releases that do
+// not follow the section_field naming of the configuration keys.
+var deprecatedEnv = []EnvAlias{
+	{Name: "STOW_DIR", Key: KeyDirPackage},
+	{Name: "PACKAGE_DIR", Key: KeyDirPackage},
+	{Name: "TARGET_DIR", Key: KeyDirTarget},
+}
+
+// Deprecation records a deprecated key found while loading configuration.
+type Deprecation struct {
+	// Key is the deprecated file key or environment variable.
+	Key string
+
+	// Replacement is the key or environment variable to use instead.
+	Replacement string
+
+	// File is the configuration file containing Key, or empty when Key is
+	// an environment variable.
+	File string
+}
+
+// String describes the deprecation and its replacement.
+func (d Deprecation) String() string {
+	if d.File == "" {
+		return fmt.Sprintf("environment variable %s is deprecated; use %s", d.Key, d.Replacement)
+	}
+	return fmt.Sprintf("config key %s in %s is deprecated; use %s", d.Key, d.File, d.Replacement)
+}
+
+// CanonicalKey returns the key replacing a deprecated configuration key,
+// and whether key was deprecated. Other keys are returned unchanged.
+func CanonicalKey(key string) (string, bool) {
+	for _, alias := range deprecatedKeys {
+		if key == alias.Old {
+			return alias.New, true
+		}
+	}
+	return key, false
+}
+
+// applyKeyAliases copies values of deprecated keys in a configuration file
+// to their replacements. A replacement set in the file takes precedence.
+func applyKeyAliases(v *viper.Viper, path string) []Deprecation {
+	var found []Deprecation
+	for _, alias := range deprecatedKeys {
+		if !v.IsSet(alias.Old) {
+			continue
+		}
+		if !v.IsSet(alias.New) {
+			v.Set(alias.New, v.Get(alias.Old))
+		}
+		found = append(found, Deprecation{Key: alias.Old, Replacement: alias.New, File: path})
+	}
+	return found
+}
+
+// applyEnvAliases sets configuration keys from deprecated environment
+// variables. A variable named after the replacement key takes precedence.
+func applyEnvAliases(v *viper.Viper, prefix string) []Deprecation {
+	aliases := append([]EnvAlias{}, deprecatedEnv...)
+	for _, alias := range deprecatedKeys {
+		aliases = append(aliases, EnvAlias{Name: envSuffix(alias.Old), Key: alias.New})
+	}
+
+	var found []Deprecation
+	for _, alias := range aliases {
+		name := prefix + "_" + alias.Name
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if !v.IsSet(alias.Key) {
+			v.Set(alias.Key, value)
+		}
+		found = append(found, Deprecation{Key: name, Replacement: prefix + "_" + envSuffix(alias.Key)})
+	}
+	return found
+}
+
+// envSuffix converts a configuration key to its environment variable name
+// without the application prefix.
+func envSuffix(key string) string {
+	return strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
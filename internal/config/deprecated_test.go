@@ -0,0 +1,94 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jamesainslie/dot/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalKey(t *testing.T) {
+	key, deprecated := config.CanonicalKey("directories.stow")
+	assert.True(t, deprecated)
+	assert.Equal(t, config.KeyDirPackage, key)
+
+	key, deprecated = config.CanonicalKey(config.KeyDirTarget)
+	assert.False(t, deprecated)
+	assert.Equal(t, config.KeyDirTarget, key)
+}
+
+func TestLoader_DeprecatedFileKey(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("directories:\n  stow: /old/dotfiles\n"), 0600))
+
+	loader := config.NewLoader("dot", configPath)
+	cfg, err := loader.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "/old/dotfiles", cfg.Directories.Package)
+	require.Len(t, loader.Deprecations(), 1)
+	assert.Equal(t, config.Deprecation{Key: "directories.stow", Replacement: config.KeyDirPackage, File: configPath}, loader.Deprecations()[0])
+
+	// The current key wins when both are present
+	require.NoError(t, os.WriteFile(configPath, []byte("directories:\n  stow: /old/dotfiles\n  package: /new/dotfiles\n"), 0600))
+	cfg, err = config.NewLoader("dot", configPath).Load()
+	require.NoError(t, err)
+	assert.Equal(t, "/new/dotfiles", cfg.Directories.Package)
+}
+
+func TestLoader_DeprecatedEnv(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "missing.yaml")
+
+	t.Setenv("DOT_STOW_DIR", "/env/dotfiles")
+	t.Setenv("DOT_TARGET_DIR", "/env/home")
+	loader := config.NewLoader("dot", configPath)
+	cfg, err := loader.LoadWithEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "/env/dotfiles", cfg.Directories.Package)
+	assert.Equal(t, "/env/home", cfg.Directories.Target)
+	assert.Contains(t, loader.Deprecations(), config.Deprecation{Key: "DOT_STOW_DIR", Replacement: "DOT_DIRECTORIES_PACKAGE"})
+
+	t.Setenv("DOT_DIRECTORIES_PACKAGE", "/env/current")
+	cfg, err = config.NewLoader("dot", configPath).LoadWithEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "/env/current", cfg.Directories.Package)
+}
+
+func TestWriter_Migrate(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	original := "directories:\n  stow: /old/dotfiles\nlogging:\n  level: DEBUG\n"
+	require.NoError(t, os.WriteFile(configPath, []byte(original), 0600))
+
+	writer := config.NewWriter(configPath)
+	deprecations, err := writer.Migrate()
+	require.NoError(t, err)
+	require.Len(t, deprecations, 1)
+
+	backup, err := os.ReadFile(configPath + ".bak")
+	require.NoError(t, err)
+	assert.Equal(t, original, string(backup))
+
+	loader := config.NewLoader("dot", configPath)
+	cfg, err := loader.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "/old/dotfiles", cfg.Directories.Package)
+	assert.Equal(t, "DEBUG", cfg.Logging.Level)
+	assert.Empty(t, loader.Deprecations())
+
+	// Nothing left to migrate
+	deprecations, err = writer.Migrate()
+	require.NoError(t, err)
+	assert.Empty(t, deprecations)
+}
+
+func TestWriter_UpdateDeprecatedKey(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+
+	require.NoError(t, config.NewWriter(configPath).Update("directories.stow", "/new/dotfiles"))
+
+	cfg, err := config.LoadExtendedFromFile(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "/new/dotfiles", cfg.Directories.Package)
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// pathVariable matches the variables expanded in paths: $HOME and the XDG
+// base directories, written as $NAME or ${NAME}.
+var pathVariable = regexp.MustCompile(`\$\{(HOME|XDG_[A-Z_]+)\}|\$(HOME|XDG_[A-Z_]+)\b`)
+
+// xdgDefaults are the directories of the home directory that XDG base
+// directory variables stand for when they are not set.
+var xdgDefaults = map[string]string{
+	"XDG_CONFIG_HOME": ".config",
+	"XDG_DATA_HOME":   filepath.Join(".local", "share"),
+	"XDG_STATE_HOME":  filepath.Join(".local", "state"),
+	"XDG_CACHE_HOME":  ".cache",
+}
+
+// ExpandPath expands a leading "~", $HOME and the XDG base directory
+// variables in path, so that a configuration file can name directories
+// the same way on every machine. An unset XDG variable stands for its
+// default directory under the home directory; other unset variables are
+// left as written.
+func ExpandPath(path string) string {
+	homeDir, _ := os.UserHomeDir()
+	if homeDir != "" {
+		if path == "~" {
+			path = homeDir
+		} else if rest, ok := strings.CutPrefix(path, "~/"); ok {
+			path = filepath.Join(homeDir, rest)
+		}
+	}
+
+	return pathVariable.ReplaceAllStringFunc(path, func(variable string) string {
+		name := strings.Trim(variable, "${}")
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+		if name == "HOME" && homeDir != "" {
+			return homeDir
+		}
+		if dir, ok := xdgDefaults[name]; ok && homeDir != "" {
+			return filepath.Join(homeDir, dir)
+		}
+		return variable
+	})
+}
+
+// expandPaths expands the paths of the configuration with ExpandPath.
+func (c *ExtendedConfig) expandPaths() {
+	for _, path := range []*string{
+		&c.Directories.Package,
+		&c.Directories.Target,
+		&c.Directories.Manifest,
+		&c.Logging.File,
+		&c.Symlinks.BackupDir,
+	} {
+		*path = ExpandPath(*path)
+	}
+	if len(c.Targets) > 0 {
+		targets := make(map[string]string, len(c.Targets))
+		for pkg, dir := range c.Targets {
+			targets[pkg] = ExpandPath(dir)
+		}
+		c.Targets = targets
+	}
+}
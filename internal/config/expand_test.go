@@ -0,0 +1,92 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jamesainslie/dot/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "/xdg/config")
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("DOT_TEST_DIR", "/unused")
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "~", want: home},
+		{path: "~/dotfiles", want: filepath.Join(home, "dotfiles")},
+		{path: "$HOME/dotfiles", want: filepath.Join(home, "dotfiles")},
+		{path: "${HOME}/dotfiles", want: filepath.Join(home, "dotfiles")},
+		{path: "${XDG_CONFIG_HOME}/dot", want: "/xdg/config/dot"},
+		{path: "$XDG_CONFIG_HOME/dot", want: "/xdg/config/dot"},
+		{path: "${XDG_DATA_HOME}/dot/manifest", want: filepath.Join(home, ".local", "share", "dot", "manifest")},
+		{path: "$XDG_UNKNOWN_DIR/dot", want: "$XDG_UNKNOWN_DIR/dot"},
+		{path: "$DOT_TEST_DIR/dot", want: "$DOT_TEST_DIR/dot"},
+		{path: "$HOMEDIR/dot", want: "$HOMEDIR/dot"},
+		{path: "~user/dot", want: "~user/dot"},
+		{path: "/srv/dotfiles", want: "/srv/dotfiles"},
+		{path: "", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.want, config.ExpandPath(tt.path))
+		})
+	}
+}
+
+func TestLoader_ExpandsPaths(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_STATE_HOME", "/xdg/state")
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfigFile(t, configPath, `directories:
+  package: ~/dotfiles
+  target: $HOME
+  manifest: ${XDG_STATE_HOME}/dot
+logging:
+  file: ${XDG_STATE_HOME}/dot/dot.log
+symlinks:
+  backup_dir: ~/.dot-backup
+targets:
+  nvim: ${XDG_CONFIG_HOME}/nvim
+`)
+
+	cfg, err := config.NewLoader("dot", configPath).Load()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, "dotfiles"), cfg.Directories.Package)
+	assert.Equal(t, home, cfg.Directories.Target)
+	assert.Equal(t, "/xdg/state/dot", cfg.Directories.Manifest)
+	assert.Equal(t, "/xdg/state/dot/dot.log", cfg.Logging.File)
+	assert.Equal(t, filepath.Join(home, ".dot-backup"), cfg.Symlinks.BackupDir)
+	assert.Equal(t, filepath.Join(home, ".config", "nvim"), cfg.Targets["nvim"])
+
+	// Environment variables and flags are expanded too
+	t.Setenv("DOT_DIRECTORIES_PACKAGE", "~/env-dotfiles")
+	cfg, err = config.NewLoader("dot", configPath).LoadWithFlags(map[string]interface{}{"target": "~/flag-target"})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, "env-dotfiles"), cfg.Directories.Package)
+	assert.Equal(t, filepath.Join(home, "flag-target"), cfg.Directories.Target)
+
+	cfg, err = config.LoadExtendedFromFile(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(home, "dotfiles"), cfg.Directories.Package)
+}
+
+func TestWriter_KeepsPathsAsWritten(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfigFile(t, configPath, "directories:\n  package: ~/dotfiles\n")
+
+	require.NoError(t, config.NewWriter(configPath).Update("logging.level", "DEBUG"))
+
+	data, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "package: ~/dotfiles")
+}
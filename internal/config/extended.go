@@ -1,27 +1,45 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
+	"maps"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/pelletier/go-toml/v2"
 	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
 )
 
 // ExtendedConfig contains all application configuration with comprehensive settings.
 type ExtendedConfig struct {
-	Directories  DirectoriesConfig  `mapstructure:"directories" json:"directories" yaml:"directories" toml:"directories"`
-	Logging      LoggingConfig      `mapstructure:"logging" json:"logging" yaml:"logging" toml:"logging"`
-	Symlinks     SymlinksConfig     `mapstructure:"symlinks" json:"symlinks" yaml:"symlinks" toml:"symlinks"`
-	Ignore       IgnoreConfig       `mapstructure:"ignore" json:"ignore" yaml:"ignore" toml:"ignore"`
-	Dotfile      DotfileConfig      `mapstructure:"dotfile" json:"dotfile" yaml:"dotfile" toml:"dotfile"`
-	Output       OutputConfig       `mapstructure:"output" json:"output" yaml:"output" toml:"output"`
-	Operations   OperationsConfig   `mapstructure:"operations" json:"operations" yaml:"operations" toml:"operations"`
-	Packages     PackagesConfig     `mapstructure:"packages" json:"packages" yaml:"packages" toml:"packages"`
-	Doctor       DoctorConfig       `mapstructure:"doctor" json:"doctor" yaml:"doctor" toml:"doctor"`
-	Update       UpdateConfig       `mapstructure:"update" json:"update" yaml:"update" toml:"update"`
-	Experimental ExperimentalConfig `mapstructure:"experimental" json:"experimental" yaml:"experimental" toml:"experimental"`
+	// Include lists further configuration files merged over this one, in
+	// order. Relative paths are resolved from the including file.
+	Include []string `mapstructure:"include" json:"include,omitempty" yaml:"include,omitempty" toml:"include,omitempty"`
+
+	// Targets maps package names to the directory their links are created
+	// in, for packages that do not belong in directories.target.
+	Targets map[string]string `mapstructure:"targets" json:"targets,omitempty" yaml:"targets,omitempty" toml:"targets,omitempty"`
+
+	Directories   DirectoriesConfig   `mapstructure:"directories" json:"directories" yaml:"directories" toml:"directories"`
+	Logging       LoggingConfig       `mapstructure:"logging" json:"logging" yaml:"logging" toml:"logging"`
+	Symlinks      SymlinksConfig      `mapstructure:"symlinks" json:"symlinks" yaml:"symlinks" toml:"symlinks"`
+	Ignore        IgnoreConfig        `mapstructure:"ignore" json:"ignore" yaml:"ignore" toml:"ignore"`
+	Dotfile       DotfileConfig       `mapstructure:"dotfile" json:"dotfile" yaml:"dotfile" toml:"dotfile"`
+	Output        OutputConfig        `mapstructure:"output" json:"output" yaml:"output" toml:"output"`
+	Operations    OperationsConfig    `mapstructure:"operations" json:"operations" yaml:"operations" toml:"operations"`
+	Packages      PackagesConfig      `mapstructure:"packages" json:"packages" yaml:"packages" toml:"packages"`
+	Doctor        DoctorConfig        `mapstructure:"doctor" json:"doctor" yaml:"doctor" toml:"doctor"`
+	Update        UpdateConfig        `mapstructure:"update" json:"update" yaml:"update" toml:"update"`
+	Git           GitConfig           `mapstructure:"git" json:"git" yaml:"git" toml:"git"`
+	Secrets       SecretsConfig       `mapstructure:"secrets" json:"secrets" yaml:"secrets" toml:"secrets"`
+	Observability ObservabilityConfig `mapstructure:"observability" json:"observability" yaml:"observability" toml:"observability"`
+	Experimental  ExperimentalConfig  `mapstructure:"experimental" json:"experimental" yaml:"experimental" toml:"experimental"`
 }
 
 // DirectoriesConfig contains directory path configuration.
@@ -70,6 +88,14 @@ type SymlinksConfig struct {
 
 	// Directory for backup files (default: <target>/.dot-backup)
 	BackupDir string `mapstructure:"backup_dir" json:"backup_dir" yaml:"backup_dir" toml:"backup_dir"`
+
+	// Conflict resolution strategy: fail, skip, backup, overwrite, adopt, prompt
+	// (default: fail, or backup when backup is enabled)
+	OnConflict string `mapstructure:"on_conflict" json:"on_conflict" yaml:"on_conflict" toml:"on_conflict"`
+
+	// Conflict resolution strategy per target path glob, overriding
+	// on_conflict for existing files and wrong links at matching paths
+	Policies map[string]string `mapstructure:"policies" json:"policies,omitempty" yaml:"policies,omitempty" toml:"policies,omitempty"`
 }
 
 // IgnoreConfig contains ignore pattern configuration.
@@ -82,6 +108,16 @@ type IgnoreConfig struct {
 
 	// Patterns to override (force include even if ignored)
 	Overrides []string `mapstructure:"overrides" json:"overrides" yaml:"overrides" toml:"overrides"`
+
+	// Handling of sockets, named pipes, and devices: skip, error
+	SpecialFiles string `mapstructure:"special_files" json:"special_files" yaml:"special_files" toml:"special_files"`
+
+	// Skip the default cloud-synced and volatile directories in orphan
+	// scans and adoption globs
+	ScanDefaults bool `mapstructure:"scan_defaults" json:"scan_defaults" yaml:"scan_defaults" toml:"scan_defaults"`
+
+	// Additional directories to skip in orphan scans and adoption globs
+	ScanPatterns []string `mapstructure:"scan_patterns" json:"scan_patterns" yaml:"scan_patterns" toml:"scan_patterns"`
 }
 
 // DotfileConfig contains dotfile translation configuration.
@@ -159,6 +195,12 @@ type DoctorConfig struct {
 
 	// Check file permissions
 	CheckPermissions bool `mapstructure:"check_permissions" json:"check_permissions" yaml:"check_permissions" toml:"check_permissions"`
+
+	// Orphaned links tolerated before they make overall health a warning
+	OrphanedThreshold int `mapstructure:"orphaned_threshold" json:"orphaned_threshold" yaml:"orphaned_threshold" toml:"orphaned_threshold"`
+
+	// Broken links tolerated before they make overall health an error
+	BrokenThreshold int `mapstructure:"broken_threshold" json:"broken_threshold" yaml:"broken_threshold" toml:"broken_threshold"`
 }
 
 // UpdateConfig contains update and upgrade configuration.
@@ -179,13 +221,67 @@ type UpdateConfig struct {
 	IncludePrerelease bool `mapstructure:"include_prerelease" json:"include_prerelease" yaml:"include_prerelease" toml:"include_prerelease"`
 }
 
+// GitConfig contains network settings for git operations such as clone and sync.
+type GitConfig struct {
+	// Maximum duration of a single git network operation, e.g. "2m" (empty = no limit)
+	Timeout string `mapstructure:"timeout" json:"timeout" yaml:"timeout" toml:"timeout"`
+
+	// Proxy URL for git network traffic, e.g. "http://proxy.corp:3128" (empty = none)
+	Proxy string `mapstructure:"proxy" json:"proxy" yaml:"proxy" toml:"proxy"`
+
+	// Skip TLS certificate verification for HTTPS remotes
+	InsecureSkipTLS bool `mapstructure:"insecure_skip_tls" json:"insecure_skip_tls" yaml:"insecure_skip_tls" toml:"insecure_skip_tls"`
+
+	// Fallback repository URLs tried in order when cloning the primary URL fails
+	Mirrors []string `mapstructure:"mirrors" json:"mirrors" yaml:"mirrors" toml:"mirrors"`
+}
+
+// SecretsConfig selects where template secrets are read from.
+type SecretsConfig struct {
+	// Secret source for {{ secret "name" }} in templates: env, pass, op
+	Provider string `mapstructure:"provider" json:"provider" yaml:"provider" toml:"provider"`
+
+	// Prefix of the environment variables read by the env provider
+	EnvPrefix string `mapstructure:"env_prefix" json:"env_prefix" yaml:"env_prefix" toml:"env_prefix"`
+
+	// 1Password vault holding items referenced by name (op provider)
+	Vault string `mapstructure:"vault" json:"vault" yaml:"vault" toml:"vault"`
+}
+
+// ObservabilityConfig selects where metrics and traces of each command are
+// exported. Every destination is optional; with none, nothing is collected.
+type ObservabilityConfig struct {
+	// File rewritten with Prometheus metrics for the node exporter textfile collector
+	PrometheusTextfile string `mapstructure:"prometheus_textfile" json:"prometheus_textfile" yaml:"prometheus_textfile" toml:"prometheus_textfile"`
+
+	// Prometheus Pushgateway URL metrics are pushed to, e.g. "http://pushgateway:9091"
+	PrometheusPushgateway string `mapstructure:"prometheus_pushgateway" json:"prometheus_pushgateway" yaml:"prometheus_pushgateway" toml:"prometheus_pushgateway"`
+
+	// Job name metrics are pushed under
+	PrometheusJob string `mapstructure:"prometheus_job" json:"prometheus_job" yaml:"prometheus_job" toml:"prometheus_job"`
+
+	// OTLP/HTTP collector URL metrics and traces are sent to, e.g. "http://localhost:4318"
+	OTLPEndpoint string `mapstructure:"otlp_endpoint" json:"otlp_endpoint" yaml:"otlp_endpoint" toml:"otlp_endpoint"`
+
+	// Headers added to OTLP requests, as "name=value"
+	OTLPHeaders []string `mapstructure:"otlp_headers" json:"otlp_headers" yaml:"otlp_headers" toml:"otlp_headers"`
+
+	// Service name reported to the OTLP collector
+	ServiceName string `mapstructure:"service_name" json:"service_name" yaml:"service_name" toml:"service_name"`
+
+	// Maximum duration of exporting when a command finishes, e.g. "5s"
+	Timeout string `mapstructure:"timeout" json:"timeout" yaml:"timeout" toml:"timeout"`
+}
+
+// Enabled reports whether any exporter is configured.
+func (c ObservabilityConfig) Enabled() bool {
+	return c.PrometheusTextfile != "" || c.PrometheusPushgateway != "" || c.OTLPEndpoint != ""
+}
+
 // ExperimentalConfig contains experimental feature flags.
 type ExperimentalConfig struct {
-	// Enable parallel operations
-	Parallel bool `mapstructure:"parallel" json:"parallel" yaml:"parallel" toml:"parallel"`
-
-	// Enable performance profiling
-	Profiling bool `mapstructure:"profiling" json:"profiling" yaml:"profiling" toml:"profiling"`
+	// Names of the enabled experimental features; see Features
+	Flags []string `mapstructure:"flags" json:"flags" yaml:"flags" toml:"flags"`
 }
 
 // DefaultExtended returns extended configuration with sensible defaults.
@@ -215,9 +311,12 @@ func DefaultExtended() *ExtendedConfig {
 			BackupSuffix: ".bak",
 		},
 		Ignore: IgnoreConfig{
-			UseDefaults: true,
-			Patterns:    []string{},
-			Overrides:   []string{},
+			UseDefaults:  true,
+			Patterns:     []string{},
+			Overrides:    []string{},
+			SpecialFiles: DefaultIgnoreSpecialFiles,
+			ScanDefaults: true,
+			ScanPatterns: []string{},
 		},
 		Dotfile: DotfileConfig{
 			Translate:          true,
@@ -256,32 +355,236 @@ func DefaultExtended() *ExtendedConfig {
 			Repository:        "jamesainslie/dot",
 			IncludePrerelease: false,
 		},
+		Git: GitConfig{
+			Timeout:         "",
+			Proxy:           "",
+			InsecureSkipTLS: false,
+			Mirrors:         []string{},
+		},
+		Secrets: SecretsConfig{
+			Provider:  "env",
+			EnvPrefix: "DOT_SECRET_",
+			Vault:     "",
+		},
+		Observability: ObservabilityConfig{
+			PrometheusTextfile:    "",
+			PrometheusPushgateway: "",
+			PrometheusJob:         "dot",
+			OTLPEndpoint:          "",
+			OTLPHeaders:           []string{},
+			ServiceName:           "dot",
+			Timeout:               "5s",
+		},
 		Experimental: ExperimentalConfig{
-			Parallel:  false,
-			Profiling: false,
+			Flags: []string{},
 		},
 	}
 }
 
-// LoadExtendedFromFile loads extended configuration from specified file.
+// LoadExtendedFromFile loads extended configuration from specified file,
+// merging the files it includes and expanding its paths.
 func LoadExtendedFromFile(path string) (*ExtendedConfig, error) {
+	cfg, _, err := loadExtendedFromFile(path, true)
+	if err != nil {
+		return nil, err
+	}
+	cfg.expandPaths()
+	return cfg, nil
+}
+
+// fileLoad describes the files read by loadExtendedFromFile.
+type fileLoad struct {
+	// deprecations are the deprecated keys in the file and its includes.
+	deprecations []Deprecation
+
+	// includes are the included files merged, in merge order.
+	includes []string
+}
+
+// loadExtendedFromFile loads configuration from a file. With
+// followIncludes, the files listed under include are merged over it.
+func loadExtendedFromFile(path string, followIncludes bool) (*ExtendedConfig, fileLoad, error) {
+	cfg, load, err := decodeExtendedFile(path, followIncludes)
+	if err != nil {
+		return nil, load, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, load, fmt.Errorf("validate config: %w", err)
+	}
+	return cfg, load, nil
+}
+
+// decodeExtendedFile decodes configuration from a file, as
+// loadExtendedFromFile does, without validating it.
+func decodeExtendedFile(path string, followIncludes bool) (*ExtendedConfig, fileLoad, error) {
+	var load fileLoad
+
+	settings, deprecations, err := readConfigFile(path)
+	if err != nil {
+		return nil, load, err
+	}
+	load.deprecations = deprecations
+
+	merged := viper.New()
+	if err := merged.MergeConfigMap(settings); err != nil {
+		return nil, load, fmt.Errorf("merge config: %w", err)
+	}
+	if followIncludes {
+		seen := map[string]bool{filepath.Clean(path): true}
+		if err := mergeIncludes(merged, path, settings, seen, &load); err != nil {
+			return nil, load, err
+		}
+	}
+
+	cfg := DefaultExtended()
+	if err := merged.Unmarshal(cfg); err != nil {
+		return nil, load, fmt.Errorf("unmarshal config: %w", err)
+	}
+
+	// Policy patterns are read apart from viper, which would split them
+	// on dots and lowercase them
+	for _, file := range append([]string{path}, load.includes...) {
+		policies, err := readPathPolicies(file)
+		if err != nil {
+			return nil, load, err
+		}
+		if len(policies) > 0 && cfg.Symlinks.Policies == nil {
+			cfg.Symlinks.Policies = make(map[string]string, len(policies))
+		}
+		maps.Copy(cfg.Symlinks.Policies, policies)
+	}
+
+	return cfg, load, nil
+}
+
+// readConfigFile reads the settings of a single configuration file, with
+// deprecated keys copied to their replacements.
+func readConfigFile(path string) (map[string]any, []Deprecation, error) {
 	v := viper.New()
 	v.SetConfigFile(path)
 
 	if err := v.ReadInConfig(); err != nil {
+		return nil, nil, fmt.Errorf("read config file: %w", err)
+	}
+	deprecations := applyKeyAliases(v, path)
+	settings := v.AllSettings()
+	deprecations = append(deprecations, applyFeatureAliases(settings, path)...)
+	if symlinks, ok := settings["symlinks"].(map[string]any); ok {
+		delete(symlinks, "policies")
+	}
+
+	return settings, deprecations, nil
+}
+
+// readPathPolicies reads symlinks.policies from the configuration file at
+// path, keeping its patterns as written.
+func readPathPolicies(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
 		return nil, fmt.Errorf("read config file: %w", err)
 	}
 
-	cfg := DefaultExtended()
-	if err := v.Unmarshal(cfg); err != nil {
-		return nil, fmt.Errorf("unmarshal config: %w", err)
+	var file struct {
+		Symlinks struct {
+			Policies map[string]string `json:"policies" yaml:"policies" toml:"policies"`
+		} `json:"symlinks" yaml:"symlinks" toml:"symlinks"`
+	}
+	switch filepath.Ext(path) {
+	case ".json":
+		err = json.Unmarshal(data, &file)
+	case ".toml":
+		err = toml.Unmarshal(data, &file)
+	default:
+		err = yaml.Unmarshal(data, &file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("symlinks.policies: %w", err)
 	}
+	return file.Symlinks.Policies, nil
+}
 
-	if err := cfg.Validate(); err != nil {
-		return nil, fmt.Errorf("validate config: %w", err)
+// mergeIncludes merges the files included by the file at path into
+// merged, depth first, so that each included file overrides the files
+// before it. Missing files are skipped, so includes can name files that
+// only exist on some machines. seen holds the files being included, to
+// detect cycles.
+func mergeIncludes(merged *viper.Viper, path string, settings map[string]any, seen map[string]bool, load *fileLoad) error {
+	includes, err := includeList(settings["include"])
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	for _, include := range includes {
+		includePath := resolveIncludePath(path, include)
+		if seen[includePath] {
+			return fmt.Errorf("%s: include cycle through %s", path, includePath)
+		}
+		if !fileExists(includePath) {
+			continue
+		}
+
+		included, deprecations, err := readConfigFile(includePath)
+		if err != nil {
+			return fmt.Errorf("include %s: %w", include, err)
+		}
+		load.deprecations = append(load.deprecations, deprecations...)
+		load.includes = append(load.includes, includePath)
+
+		// The include list of the top-level file is the one kept
+		overrides := make(map[string]any, len(included))
+		for key, value := range included {
+			if key != "include" {
+				overrides[key] = value
+			}
+		}
+		if err := merged.MergeConfigMap(overrides); err != nil {
+			return fmt.Errorf("include %s: %w", include, err)
+		}
+
+		seen[includePath] = true
+		if err := mergeIncludes(merged, includePath, included, seen, load); err != nil {
+			return err
+		}
+		delete(seen, includePath)
 	}
 
-	return cfg, nil
+	return nil
+}
+
+// includeList converts the include setting to a list of paths.
+func includeList(value any) ([]string, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case []any:
+		includes := make([]string, 0, len(v))
+		for _, item := range v {
+			include, ok := item.(string)
+			if !ok || include == "" {
+				return nil, fmt.Errorf("include: entries must be file paths")
+			}
+			includes = append(includes, include)
+		}
+		return includes, nil
+	default:
+		return nil, fmt.Errorf("include: must be a list of file paths")
+	}
+}
+
+// resolveIncludePath resolves an include entry against the directory of
+// the including file, expanding a leading "~/".
+func resolveIncludePath(from, include string) string {
+	if rest, ok := strings.CutPrefix(include, "~/"); ok {
+		if homeDir, err := os.UserHomeDir(); err == nil {
+			include = filepath.Join(homeDir, rest)
+		}
+	}
+	if !filepath.IsAbs(include) {
+		include = filepath.Join(filepath.Dir(from), include)
+	}
+	return filepath.Clean(include)
 }
 
 // Validate checks configuration for errors.
@@ -289,6 +592,9 @@ func (c *ExtendedConfig) Validate() error {
 	if err := c.validateDirectories(); err != nil {
 		return err
 	}
+	if err := c.validateTargets(); err != nil {
+		return err
+	}
 	if err := c.validateLogging(); err != nil {
 		return err
 	}
@@ -310,9 +616,24 @@ func (c *ExtendedConfig) Validate() error {
 	if err := c.validatePackages(); err != nil {
 		return err
 	}
+	if err := c.validateDoctor(); err != nil {
+		return err
+	}
 	if err := c.validateUpdate(); err != nil {
 		return err
 	}
+	if err := c.validateGit(); err != nil {
+		return err
+	}
+	if err := c.validateSecrets(); err != nil {
+		return err
+	}
+	if err := c.validateObservability(); err != nil {
+		return err
+	}
+	if err := c.Experimental.validate(); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -329,6 +650,18 @@ func (c *ExtendedConfig) validateDirectories() error {
 	return nil
 }
 
+func (c *ExtendedConfig) validateTargets() error {
+	for pkg, dir := range c.Targets {
+		if pkg == "" {
+			return fmt.Errorf("targets: package name cannot be empty")
+		}
+		if dir == "" {
+			return fmt.Errorf("targets.%s: target directory cannot be empty", pkg)
+		}
+	}
+	return nil
+}
+
 func (c *ExtendedConfig) validateLogging() error {
 	validLevels := []string{"DEBUG", "INFO", "WARN", "ERROR"}
 	if !contains(validLevels, c.Logging.Level) {
@@ -355,6 +688,9 @@ func (c *ExtendedConfig) validateLogging() error {
 	return nil
 }
 
+// validConflictStrategies lists the built-in conflict resolution strategies.
+var validConflictStrategies = []string{"fail", "skip", "backup", "overwrite", "adopt", "prompt"}
+
 func (c *ExtendedConfig) validateSymlinks() error {
 	validModes := []string{"relative", "absolute"}
 	if !contains(validModes, c.Symlinks.Mode) {
@@ -366,6 +702,25 @@ func (c *ExtendedConfig) validateSymlinks() error {
 		return fmt.Errorf("symlinks.backup_suffix: backup suffix cannot be empty when backup is enabled")
 	}
 
+	if c.Symlinks.OnConflict != "" && !contains(validConflictStrategies, c.Symlinks.OnConflict) {
+		return fmt.Errorf("symlinks.on_conflict: invalid conflict strategy %q (must be one of: %s)",
+			c.Symlinks.OnConflict, strings.Join(validConflictStrategies, ", "))
+	}
+
+	for _, pattern := range slices.Sorted(maps.Keys(c.Symlinks.Policies)) {
+		if pattern == "" {
+			return fmt.Errorf("symlinks.policies: pattern cannot be empty")
+		}
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("symlinks.policies[%q]: invalid glob pattern: %w", pattern, err)
+		}
+		strategy := c.Symlinks.Policies[pattern]
+		if !contains(validConflictStrategies, strategy) {
+			return fmt.Errorf("symlinks.policies[%q]: invalid conflict strategy %q (must be one of: %s)",
+				pattern, strategy, strings.Join(validConflictStrategies, ", "))
+		}
+	}
+
 	return nil
 }
 
@@ -384,6 +739,18 @@ func (c *ExtendedConfig) validateIgnore() error {
 		}
 	}
 
+	for i, pattern := range c.Ignore.ScanPatterns {
+		if _, err := filepath.Match(pattern, "test"); err != nil {
+			return fmt.Errorf("ignore.scan_patterns[%d]: invalid glob pattern %q: %w", i, pattern, err)
+		}
+	}
+
+	validPolicies := []string{"skip", "error"}
+	if c.Ignore.SpecialFiles != "" && !contains(validPolicies, c.Ignore.SpecialFiles) {
+		return fmt.Errorf("ignore.special_files: invalid policy %q (must be one of: %s)",
+			c.Ignore.SpecialFiles, strings.Join(validPolicies, ", "))
+	}
+
 	return nil
 }
 
@@ -428,6 +795,17 @@ func (c *ExtendedConfig) validateOperations() error {
 	return nil
 }
 
+func (c *ExtendedConfig) validateDoctor() error {
+	if c.Doctor.OrphanedThreshold < 0 {
+		return fmt.Errorf("doctor.orphaned_threshold: threshold cannot be negative, got %d", c.Doctor.OrphanedThreshold)
+	}
+	if c.Doctor.BrokenThreshold < 0 {
+		return fmt.Errorf("doctor.broken_threshold: threshold cannot be negative, got %d", c.Doctor.BrokenThreshold)
+	}
+
+	return nil
+}
+
 func (c *ExtendedConfig) validatePackages() error {
 	validSortBy := []string{"name", "links", "date"}
 	if !contains(validSortBy, c.Packages.SortBy) {
@@ -464,6 +842,87 @@ func (c *ExtendedConfig) validateUpdate() error {
 	return nil
 }
 
+func (c *ExtendedConfig) validateGit() error {
+	if c.Git.Timeout != "" {
+		timeout, err := time.ParseDuration(c.Git.Timeout)
+		if err != nil {
+			return fmt.Errorf("git.timeout: invalid duration %q: %w", c.Git.Timeout, err)
+		}
+		if timeout < 0 {
+			return fmt.Errorf("git.timeout: timeout cannot be negative, got %q", c.Git.Timeout)
+		}
+	}
+
+	if c.Git.Proxy != "" {
+		u, err := url.Parse(c.Git.Proxy)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("git.proxy: invalid proxy URL %q (must be scheme://host[:port])", c.Git.Proxy)
+		}
+	}
+
+	for i, mirror := range c.Git.Mirrors {
+		if strings.TrimSpace(mirror) == "" {
+			return fmt.Errorf("git.mirrors[%d]: mirror URL cannot be empty", i)
+		}
+	}
+
+	return nil
+}
+
+func (c *ExtendedConfig) validateSecrets() error {
+	validProviders := []string{"env", "pass", "op"}
+	if !contains(validProviders, c.Secrets.Provider) {
+		return fmt.Errorf("secrets.provider: invalid provider %q (must be one of: %s)",
+			c.Secrets.Provider, strings.Join(validProviders, ", "))
+	}
+
+	if c.Secrets.Provider == "env" && c.Secrets.EnvPrefix == "" {
+		return fmt.Errorf("secrets.env_prefix: prefix cannot be empty")
+	}
+
+	return nil
+}
+
+func (c *ExtendedConfig) validateObservability() error {
+	o := c.Observability
+	endpoints := []struct{ key, value string }{
+		{"observability.prometheus_pushgateway", o.PrometheusPushgateway},
+		{"observability.otlp_endpoint", o.OTLPEndpoint},
+	}
+	for _, endpoint := range endpoints {
+		if endpoint.value == "" {
+			continue
+		}
+		u, err := url.Parse(endpoint.value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%s: invalid URL %q (must be http[s]://host[:port][/path])", endpoint.key, endpoint.value)
+		}
+	}
+
+	if o.PrometheusPushgateway != "" && o.PrometheusJob == "" {
+		return fmt.Errorf("observability.prometheus_job: job cannot be empty")
+	}
+
+	for i, header := range o.OTLPHeaders {
+		name, _, ok := strings.Cut(header, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return fmt.Errorf("observability.otlp_headers[%d]: invalid header %q (must be name=value)", i, header)
+		}
+	}
+
+	if o.Timeout != "" {
+		timeout, err := time.ParseDuration(o.Timeout)
+		if err != nil {
+			return fmt.Errorf("observability.timeout: invalid duration %q: %w", o.Timeout, err)
+		}
+		if timeout <= 0 {
+			return fmt.Errorf("observability.timeout: timeout must be positive, got %q", o.Timeout)
+		}
+	}
+
+	return nil
+}
+
 // getXDGDataPath returns XDG data directory path.
 func getXDGDataPath(suffix string) string {
 	if dataHome := os.Getenv("XDG_DATA_HOME"); dataHome != "" {
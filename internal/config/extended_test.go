@@ -74,8 +74,7 @@ func TestExtendedConfig_Default(t *testing.T) {
 	assert.False(t, cfg.Update.IncludePrerelease)
 
 	// Experimental
-	assert.False(t, cfg.Experimental.Parallel)
-	assert.False(t, cfg.Experimental.Profiling)
+	assert.Empty(t, cfg.Experimental.Flags)
 }
 
 func TestExtendedConfig_LoadFromYAML(t *testing.T) {
@@ -164,7 +163,8 @@ experimental:
 	assert.Equal(t, 4, cfg.Operations.MaxParallel)
 	assert.Equal(t, "links", cfg.Packages.SortBy)
 	assert.True(t, cfg.Doctor.AutoFix)
-	assert.True(t, cfg.Experimental.Parallel)
+	// Legacy boolean feature keys are read into experimental.flags
+	assert.Equal(t, []string{"parallel", "profiling"}, cfg.Experimental.Enabled())
 }
 
 func TestExtendedConfig_ValidateDirectories(t *testing.T) {
@@ -283,6 +283,18 @@ func TestExtendedConfig_ValidateSymlinks(t *testing.T) {
 	}
 }
 
+func TestExtendedConfig_ValidateOnConflict(t *testing.T) {
+	for _, strategy := range []string{"", "fail", "skip", "backup", "overwrite", "adopt", "prompt"} {
+		cfg := config.DefaultExtended()
+		cfg.Symlinks.OnConflict = strategy
+		assert.NoError(t, cfg.Validate(), strategy)
+	}
+
+	cfg := config.DefaultExtended()
+	cfg.Symlinks.OnConflict = "merge"
+	assert.ErrorContains(t, cfg.Validate(), `symlinks.on_conflict: invalid conflict strategy "merge"`)
+}
+
 func TestExtendedConfig_ValidateOutput(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -374,6 +386,58 @@ func TestExtendedConfig_ValidateIgnorePatterns(t *testing.T) {
 	}
 }
 
+func TestExtendedConfig_ValidateSpecialFiles(t *testing.T) {
+	cfg := config.DefaultExtended()
+	assert.Equal(t, "skip", cfg.Ignore.SpecialFiles)
+
+	cfg.Ignore.SpecialFiles = "error"
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Ignore.SpecialFiles = "follow"
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ignore.special_files")
+}
+
+func TestExtendedConfig_ValidateScanPatterns(t *testing.T) {
+	cfg := config.DefaultExtended()
+	assert.True(t, cfg.Ignore.ScanDefaults)
+	assert.Empty(t, cfg.Ignore.ScanPatterns)
+
+	cfg.Ignore.ScanPatterns = []string{"VirtualBox VMs", "Dropbox*"}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Ignore.ScanPatterns = []string{"[unclosed"}
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ignore.scan_patterns[0]")
+}
+
+func TestExtendedConfig_ValidateSymlinkPolicies(t *testing.T) {
+	cfg := config.DefaultExtended()
+	cfg.Symlinks.Policies = map[string]string{"~/.config/**": "backup", "~/.ssh/**": "fail"}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Symlinks.Policies = map[string]string{"~/.ssh/**": "shred"}
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `symlinks.policies["~/.ssh/**"]`)
+
+	cfg.Symlinks.Policies = map[string]string{"[unclosed": "skip"}
+	assert.ErrorContains(t, cfg.Validate(), "invalid glob pattern")
+}
+
+func TestExtendedConfig_ValidateTargets(t *testing.T) {
+	cfg := config.DefaultExtended()
+	cfg.Targets = map[string]string{"dot-bin": "/home/user/.local/bin"}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Targets["etc"] = ""
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "targets.etc")
+}
+
 func TestExtendedConfig_ValidateOperations(t *testing.T) {
 	cfg := config.DefaultExtended()
 
@@ -433,6 +497,40 @@ func TestExtendedConfig_ValidateUpdate(t *testing.T) {
 	}
 }
 
+func TestExtendedConfig_ValidateGit(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout string
+		proxy   string
+		wantErr bool
+	}{
+		{"valid defaults", "", "", false},
+		{"valid timeout", "90s", "", false},
+		{"valid zero timeout", "0s", "", false},
+		{"invalid timeout", "soon", "", true},
+		{"negative timeout", "-1m", "", true},
+		{"valid http proxy", "", "http://proxy.example.com:3128", false},
+		{"valid socks proxy", "", "socks5://127.0.0.1:1080", false},
+		{"proxy without scheme", "", "proxy.example.com:3128", true},
+		{"proxy without host", "", "http://", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.DefaultExtended()
+			cfg.Git.Timeout = tt.timeout
+			cfg.Git.Proxy = tt.proxy
+
+			err := cfg.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
 func TestExtendedConfig_MarshalYAML(t *testing.T) {
 	cfg := config.DefaultExtended()
 	cfg.Directories.Package = "/test/dotfiles"
@@ -451,3 +549,71 @@ func TestExtendedConfig_MarshalJSON(t *testing.T) {
 	// Verify config is valid
 	assert.NoError(t, cfg.Validate())
 }
+
+func TestExtendedConfig_ValidateGitMirrors(t *testing.T) {
+	cfg := config.DefaultExtended()
+	cfg.Git.Mirrors = []string{"https://mirror.example.com/dotfiles"}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Git.Mirrors = append(cfg.Git.Mirrors, " ")
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "git.mirrors[1]")
+}
+
+func TestExtendedConfig_ValidateSecrets(t *testing.T) {
+	cfg := config.DefaultExtended()
+	assert.Equal(t, "env", cfg.Secrets.Provider)
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Secrets.Provider = "pass"
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Secrets.Provider = "vault"
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "secrets.provider")
+
+	cfg.Secrets.Provider = "env"
+	cfg.Secrets.EnvPrefix = ""
+	err = cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "secrets.env_prefix")
+}
+
+func TestExtendedConfig_ValidateObservability(t *testing.T) {
+	cfg := config.DefaultExtended()
+	assert.False(t, cfg.Observability.Enabled())
+	assert.Equal(t, "dot", cfg.Observability.PrometheusJob)
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Observability.PrometheusTextfile = "/var/lib/node_exporter/dot.prom"
+	cfg.Observability.PrometheusPushgateway = "http://pushgateway:9091"
+	cfg.Observability.OTLPEndpoint = "https://otel.example.com:4318"
+	cfg.Observability.OTLPHeaders = []string{"Authorization=Bearer token"}
+	assert.True(t, cfg.Observability.Enabled())
+	assert.NoError(t, cfg.Validate())
+
+	tests := []struct {
+		name   string
+		modify func(o *config.ObservabilityConfig)
+		key    string
+	}{
+		{"pushgateway without scheme", func(o *config.ObservabilityConfig) { o.PrometheusPushgateway = "pushgateway:9091" }, "observability.prometheus_pushgateway"},
+		{"empty job", func(o *config.ObservabilityConfig) { o.PrometheusJob = "" }, "observability.prometheus_job"},
+		{"otlp not http", func(o *config.ObservabilityConfig) { o.OTLPEndpoint = "grpc://otel:4317" }, "observability.otlp_endpoint"},
+		{"header without value", func(o *config.ObservabilityConfig) { o.OTLPHeaders = []string{"Authorization"} }, "observability.otlp_headers[0]"},
+		{"invalid timeout", func(o *config.ObservabilityConfig) { o.Timeout = "soon" }, "observability.timeout"},
+		{"zero timeout", func(o *config.ObservabilityConfig) { o.Timeout = "0s" }, "observability.timeout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invalid := *cfg
+			invalid.Observability.OTLPHeaders = nil
+			tt.modify(&invalid.Observability)
+			err := invalid.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.key)
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+)
+
+// FeatureStatus describes how far an experimental feature is from being
+// stable.
+type FeatureStatus string
+
+const (
+	// FeatureAlpha marks a feature that may change or be removed without
+	// notice.
+	FeatureAlpha FeatureStatus = "alpha"
+
+	// FeatureBeta marks a feature whose behavior is settled but which has
+	// seen limited use.
+	FeatureBeta FeatureStatus = "beta"
+)
+
+// Feature describes an experimental feature that can be enabled through
+// experimental.flags.
+type Feature struct {
+	Name        string
+	Description string
+	Status      FeatureStatus
+}
+
+// features is the registry of experimental features, sorted by name.
+var features = []Feature{
+	{
+		Name:        "boundary",
+		Description: "Refuse changes outside the managed directories while executing plans",
+		Status:      FeatureAlpha,
+	},
+	{
+		Name:        "parallel",
+		Description: "Run independent operations of a plan concurrently",
+		Status:      FeatureBeta,
+	},
+	{
+		Name:        "profiling",
+		Description: "Record performance profiles of command execution",
+		Status:      FeatureAlpha,
+	},
+}
+
+// Features returns the registered experimental features, sorted by name.
+func Features() []Feature {
+	return slices.Clone(features)
+}
+
+// LookupFeature returns the registered feature called name.
+func LookupFeature(name string) (Feature, bool) {
+	for _, f := range features {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Feature{}, false
+}
+
+// ErrUnknownFeature indicates a feature name missing from the registry.
+type ErrUnknownFeature struct {
+	Name string
+}
+
+func (e ErrUnknownFeature) Error() string {
+	return fmt.Sprintf("unknown experimental feature %q", e.Name)
+}
+
+// Enabled returns the names of the enabled experimental features, sorted
+// and without duplicates.
+func (c ExperimentalConfig) Enabled() []string {
+	enabled := []string{}
+	for _, f := range features {
+		if slices.Contains(c.Flags, f.Name) {
+			enabled = append(enabled, f.Name)
+		}
+	}
+	return enabled
+}
+
+// IsEnabled reports whether the feature called name is enabled.
+func (c ExperimentalConfig) IsEnabled(name string) bool {
+	return slices.Contains(c.Flags, name)
+}
+
+// Enable adds the feature called name to the enabled features.
+func (c *ExperimentalConfig) Enable(name string) error {
+	if _, ok := LookupFeature(name); !ok {
+		return ErrUnknownFeature{Name: name}
+	}
+	if !c.IsEnabled(name) {
+		c.Flags = append(c.Flags, name)
+		slices.Sort(c.Flags)
+	}
+	return nil
+}
+
+// Disable removes the feature called name from the enabled features.
+func (c *ExperimentalConfig) Disable(name string) error {
+	if _, ok := LookupFeature(name); !ok {
+		return ErrUnknownFeature{Name: name}
+	}
+	c.Flags = slices.DeleteFunc(c.Flags, func(flag string) bool { return flag == name })
+	return nil
+}
+
+// validate rejects flags naming unregistered features, which are most
+// likely typos.
+func (c ExperimentalConfig) validate() error {
+	for _, flag := range c.Flags {
+		if _, ok := LookupFeature(flag); !ok {
+			return fmt.Errorf("experimental.flags: %w", ErrUnknownFeature{Name: flag})
+		}
+	}
+	return nil
+}
+
+// legacyFeatureKeys lists the boolean keys that enabled a feature before
+// experimental.flags existed.
+var legacyFeatureKeys = []string{"parallel", "profiling"}
+
+// applyFeatureAliases moves the legacy boolean feature keys of a
+// configuration file's settings into experimental.flags.
+func applyFeatureAliases(settings map[string]any, path string) []Deprecation {
+	section, ok := settings["experimental"].(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	var found []Deprecation
+	for _, name := range legacyFeatureKeys {
+		value, exists := section[name]
+		if !exists {
+			continue
+		}
+		delete(section, name)
+		found = append(found, Deprecation{Key: "experimental." + name, Replacement: "experimental.flags", File: path})
+		if enabled, _ := value.(bool); !enabled {
+			continue
+		}
+		flags, _ := section["flags"].([]any)
+		if !slices.Contains(flags, any(name)) {
+			section["flags"] = append(flags, name)
+		}
+	}
+	return found
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExperimentalConfig_EnableDisable(t *testing.T) {
+	var cfg ExperimentalConfig
+	require.NoError(t, cfg.Enable("profiling"))
+	require.NoError(t, cfg.Enable("parallel"))
+	require.NoError(t, cfg.Enable("parallel"))
+	assert.Equal(t, []string{"parallel", "profiling"}, cfg.Flags)
+	assert.True(t, cfg.IsEnabled("parallel"))
+
+	require.NoError(t, cfg.Disable("parallel"))
+	assert.Equal(t, []string{"profiling"}, cfg.Enabled())
+
+	var unknown ErrUnknownFeature
+	assert.ErrorAs(t, cfg.Enable("teleport"), &unknown)
+	assert.Equal(t, "teleport", unknown.Name)
+}
+
+func TestValidate_RejectsUnknownFeature(t *testing.T) {
+	cfg := DefaultExtended()
+	cfg.Experimental.Flags = []string{"teleport"}
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "experimental.flags")
+}
+
+func TestLoader_LegacyFeatureKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("experimental:\n  parallel: true\n  profiling: false\n"), 0600))
+	t.Setenv("DOT_EXPERIMENTAL_PROFILING", "true")
+
+	loader := NewLoader("dot", path)
+	cfg, err := loader.LoadWithEnv()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"parallel", "profiling"}, cfg.Experimental.Enabled())
+
+	var keys []string
+	for _, d := range loader.Deprecations() {
+		keys = append(keys, d.Key)
+	}
+	assert.ElementsMatch(t, []string{"experimental.parallel", "experimental.profiling", "DOT_EXPERIMENTAL_PROFILING"}, keys)
+}
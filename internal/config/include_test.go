@@ -0,0 +1,114 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jamesainslie/dot/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfigFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+}
+
+func TestLoader_Include(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	writeConfigFile(t, configPath, `include:
+  - work.yaml
+  - private/secrets.yaml
+  - missing.yaml
+directories:
+  package: /main/dotfiles
+logging:
+  level: WARN
+  format: json
+`)
+	writeConfigFile(t, filepath.Join(dir, "work.yaml"), `logging:
+  level: DEBUG
+git:
+  proxy: http://proxy.work.example.com:3128
+`)
+	writeConfigFile(t, filepath.Join(dir, "private", "secrets.yaml"), `include:
+  - nested.json
+logging:
+  level: INFO
+`)
+	writeConfigFile(t, filepath.Join(dir, "private", "nested.json"), `{"secrets": {"provider": "pass"}}`)
+
+	loader := config.NewLoader("dot", configPath)
+	cfg, err := loader.Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, "/main/dotfiles", cfg.Directories.Package)
+	assert.Equal(t, "INFO", cfg.Logging.Level, "later includes override earlier ones")
+	assert.Equal(t, "json", cfg.Logging.Format, "keys not set by includes are kept")
+	assert.Equal(t, "http://proxy.work.example.com:3128", cfg.Git.Proxy)
+	assert.Equal(t, "pass", cfg.Secrets.Provider, "nested includes are merged")
+	assert.Equal(t, []string{"work.yaml", "private/secrets.yaml", "missing.yaml"}, cfg.Include)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "work.yaml"),
+		filepath.Join(dir, "private", "secrets.yaml"),
+		filepath.Join(dir, "private", "nested.json"),
+	}, loader.Includes())
+}
+
+func TestLoader_SymlinkPolicies(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	writeConfigFile(t, configPath, `include: [work.toml]
+symlinks:
+  on_conflict: fail
+  policies:
+    "~/.config/**": backup
+    "~/.ssh/**": fail
+`)
+	writeConfigFile(t, filepath.Join(dir, "work.toml"), `[symlinks.policies]
+"~/.ssh/**" = "skip"
+"~/Work/*.CONF" = "overwrite"
+`)
+
+	cfg, err := config.NewLoader("dot", configPath).Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, "fail", cfg.Symlinks.OnConflict)
+	assert.Equal(t, map[string]string{
+		"~/.config/**":  "backup",
+		"~/.ssh/**":     "skip",
+		"~/Work/*.CONF": "overwrite",
+	}, cfg.Symlinks.Policies, "patterns keep their dots and case, and includes override")
+}
+
+func TestLoader_IncludeCycle(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	writeConfigFile(t, configPath, "include: [a.yaml]\n")
+	writeConfigFile(t, filepath.Join(dir, "a.yaml"), "include: [config.yaml]\n")
+
+	_, err := config.NewLoader("dot", configPath).Load()
+	assert.ErrorContains(t, err, "include cycle")
+}
+
+func TestWriter_UpdateKeepsIncludesSeparate(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	writeConfigFile(t, configPath, "include: [private.yaml]\n")
+	writeConfigFile(t, filepath.Join(dir, "private.yaml"), "git:\n  proxy: http://secret-proxy:3128\n")
+
+	require.NoError(t, config.NewWriter(configPath).Update("logging.level", "DEBUG"))
+
+	data, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "secret-proxy")
+	assert.Contains(t, string(data), "private.yaml")
+
+	cfg, err := config.LoadExtendedFromFile(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "DEBUG", cfg.Logging.Level)
+	assert.Equal(t, "http://secret-proxy:3128", cfg.Git.Proxy)
+}
@@ -23,11 +23,16 @@ const (
 	KeySymlinkBackup       = "symlinks.backup"
 	KeySymlinkBackupSuffix = "symlinks.backup_suffix"
 	KeySymlinkBackupDir    = "symlinks.backup_dir"
+	KeySymlinkOnConflict   = "symlinks.on_conflict"
+	KeySymlinkPolicies     = "symlinks.policies"
 
 	// Ignore pattern configuration keys
-	KeyIgnoreUseDefaults = "ignore.use_defaults"
-	KeyIgnorePatterns    = "ignore.patterns"
-	KeyIgnoreOverrides   = "ignore.overrides"
+	KeyIgnoreUseDefaults  = "ignore.use_defaults"
+	KeyIgnorePatterns     = "ignore.patterns"
+	KeyIgnoreOverrides    = "ignore.overrides"
+	KeyIgnoreSpecialFiles = "ignore.special_files"
+	KeyIgnoreScanDefaults = "ignore.scan_defaults"
+	KeyIgnoreScanPatterns = "ignore.scan_patterns"
 
 	// Dotfile translation configuration keys
 	KeyDotfileTranslate = "dotfile.translate"
@@ -56,7 +61,35 @@ const (
 	KeyDoctorCheckBrokenLinks   = "doctor.check_broken_links"
 	KeyDoctorCheckOrphaned      = "doctor.check_orphaned"
 	KeyDoctorCheckPermissions   = "doctor.check_permissions"
+	KeyDoctorOrphanedThreshold  = "doctor.orphaned_threshold"
+	KeyDoctorBrokenThreshold    = "doctor.broken_threshold"
 	KeyDoctorOrphanScanMode     = "doctor.orphan_scan_mode"
 	KeyDoctorOrphanScanDepth    = "doctor.orphan_scan_depth"
 	KeyDoctorOrphanSkipPatterns = "doctor.orphan_skip_patterns"
 )
+
+// Git configuration keys
+const (
+	KeyGitTimeout         = "git.timeout"
+	KeyGitProxy           = "git.proxy"
+	KeyGitInsecureSkipTLS = "git.insecure_skip_tls"
+	KeyGitMirrors         = "git.mirrors"
+)
+
+// Secrets configuration keys
+const (
+	KeySecretsProvider  = "secrets.provider"
+	KeySecretsEnvPrefix = "secrets.env_prefix"
+	KeySecretsVault     = "secrets.vault"
+)
+
+// Observability configuration keys
+const (
+	KeyObservabilityPrometheusTextfile    = "observability.prometheus_textfile"
+	KeyObservabilityPrometheusPushgateway = "observability.prometheus_pushgateway"
+	KeyObservabilityPrometheusJob         = "observability.prometheus_job"
+	KeyObservabilityOTLPEndpoint          = "observability.otlp_endpoint"
+	KeyObservabilityOTLPHeaders           = "observability.otlp_headers"
+	KeyObservabilityServiceName           = "observability.service_name"
+	KeyObservabilityTimeout               = "observability.timeout"
+)
@@ -32,11 +32,15 @@ func TestConfigurationKeyConstants(t *testing.T) {
 		{name: "KeySymlinkBackup", key: KeySymlinkBackup, expected: "symlinks.backup", category: "symlinks"},
 		{name: "KeySymlinkBackupSuffix", key: KeySymlinkBackupSuffix, expected: "symlinks.backup_suffix", category: "symlinks"},
 		{name: "KeySymlinkBackupDir", key: KeySymlinkBackupDir, expected: "symlinks.backup_dir", category: "symlinks"},
+		{name: "KeySymlinkOnConflict", key: KeySymlinkOnConflict, expected: "symlinks.on_conflict", category: "symlinks"},
+		{name: "KeySymlinkPolicies", key: KeySymlinkPolicies, expected: "symlinks.policies", category: "symlinks"},
 
 		// Ignore keys
 		{name: "KeyIgnoreUseDefaults", key: KeyIgnoreUseDefaults, expected: "ignore.use_defaults", category: "ignore"},
 		{name: "KeyIgnorePatterns", key: KeyIgnorePatterns, expected: "ignore.patterns", category: "ignore"},
 		{name: "KeyIgnoreOverrides", key: KeyIgnoreOverrides, expected: "ignore.overrides", category: "ignore"},
+		{name: "KeyIgnoreScanDefaults", key: KeyIgnoreScanDefaults, expected: "ignore.scan_defaults", category: "ignore"},
+		{name: "KeyIgnoreScanPatterns", key: KeyIgnoreScanPatterns, expected: "ignore.scan_patterns", category: "ignore"},
 
 		// Dotfile keys
 		{name: "KeyDotfileTranslate", key: KeyDotfileTranslate, expected: "dotfile.translate", category: "dotfile"},
@@ -84,6 +88,7 @@ func TestKeyFormatConsistency(t *testing.T) {
 		KeySymlinkMode, KeySymlinkFolding, KeySymlinkOverwrite, KeySymlinkBackup,
 		KeySymlinkBackupSuffix, KeySymlinkBackupDir,
 		KeyIgnoreUseDefaults, KeyIgnorePatterns, KeyIgnoreOverrides,
+		KeyIgnoreScanDefaults, KeyIgnoreScanPatterns,
 		KeyDotfileTranslate, KeyDotfilePrefix,
 		KeyOutputFormat, KeyOutputColor, KeyOutputProgress, KeyOutputVerbosity, KeyOutputWidth,
 		KeyOperationsDryRun, KeyOperationsAtomic, KeyOperationsMaxParallel,
@@ -119,7 +124,7 @@ func TestKeyCategoryGrouping(t *testing.T) {
 		"directories": {KeyDirPackage, KeyDirTarget, KeyDirManifest},
 		"logging":     {KeyLogLevel, KeyLogFormat, KeyLogDestination, KeyLogFile},
 		"symlinks":    {KeySymlinkMode, KeySymlinkFolding, KeySymlinkOverwrite, KeySymlinkBackup, KeySymlinkBackupSuffix, KeySymlinkBackupDir},
-		"ignore":      {KeyIgnoreUseDefaults, KeyIgnorePatterns, KeyIgnoreOverrides},
+		"ignore":      {KeyIgnoreUseDefaults, KeyIgnorePatterns, KeyIgnoreOverrides, KeyIgnoreScanDefaults, KeyIgnoreScanPatterns},
 		"dotfile":     {KeyDotfileTranslate, KeyDotfilePrefix},
 		"output":      {KeyOutputFormat, KeyOutputColor, KeyOutputProgress, KeyOutputVerbosity, KeyOutputWidth},
 		"operations":  {KeyOperationsDryRun, KeyOperationsAtomic, KeyOperationsMaxParallel},
@@ -144,6 +149,7 @@ func TestKeyUniqueness(t *testing.T) {
 		KeySymlinkMode, KeySymlinkFolding, KeySymlinkOverwrite, KeySymlinkBackup,
 		KeySymlinkBackupSuffix, KeySymlinkBackupDir,
 		KeyIgnoreUseDefaults, KeyIgnorePatterns, KeyIgnoreOverrides,
+		KeyIgnoreScanDefaults, KeyIgnoreScanPatterns,
 		KeyDotfileTranslate, KeyDotfilePrefix,
 		KeyOutputFormat, KeyOutputColor, KeyOutputProgress, KeyOutputVerbosity, KeyOutputWidth,
 		KeyOperationsDryRun, KeyOperationsAtomic, KeyOperationsMaxParallel,
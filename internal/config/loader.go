@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"maps"
 	"os"
 	"strings"
 
@@ -10,8 +11,13 @@ import (
 
 // Loader handles loading configuration from multiple sources.
 type Loader struct {
-	appName    string
-	configPath string
+	appName      string
+	configPath   string
+	deprecations []Deprecation
+	includes     []string
+	projectDir   string
+	findProject  bool
+	project      string
 }
 
 // NewLoader creates a configuration loader.
@@ -23,20 +29,27 @@ func NewLoader(appName string, configPath string) *Loader {
 }
 
 // Load loads configuration from file with proper precedence.
-// Precedence: file > defaults
+// Precedence: project file > file > defaults
 func (l *Loader) Load() (*ExtendedConfig, error) {
-	// Load from config file if it exists
+	// Load from config file if it exists, using file config directly to
+	// preserve explicit false values
+	cfg := DefaultExtended()
 	if fileExists(l.configPath) {
-		fileCfg, err := LoadExtendedFromFile(l.configPath)
+		fileCfg, load, err := loadExtendedFromFile(l.configPath, true)
 		if err != nil {
 			return nil, fmt.Errorf("load config file: %w", err)
 		}
-		// Use file config directly to preserve explicit false values
-		return fileCfg, nil
+		l.deprecations = append(l.deprecations, load.deprecations...)
+		l.includes = load.includes
+		cfg = fileCfg
 	}
 
-	// No file - return defaults
-	cfg := DefaultExtended()
+	// Paths are expanded first, as the project is found in the package
+	// directory
+	cfg.expandPaths()
+	if err := l.applyProject(cfg); err != nil {
+		return nil, err
+	}
 
 	// Validate configuration
 	if err := cfg.Validate(); err != nil {
@@ -47,7 +60,7 @@ func (l *Loader) Load() (*ExtendedConfig, error) {
 }
 
 // LoadWithEnv loads configuration from file and applies environment variable overrides.
-// Precedence: env > file > defaults
+// Precedence: env > project file > file > defaults
 func (l *Loader) LoadWithEnv() (*ExtendedConfig, error) {
 	// Start with file load
 	cfg, err := l.Load()
@@ -59,6 +72,7 @@ func (l *Loader) LoadWithEnv() (*ExtendedConfig, error) {
 	envCfg := l.loadFromEnv()
 	// Use simple merge for env (only strings, no booleans unless tracked)
 	cfg = mergeConfigs(cfg, envCfg)
+	cfg.expandPaths()
 
 	// Validate merged configuration
 	if err := cfg.Validate(); err != nil {
@@ -69,7 +83,7 @@ func (l *Loader) LoadWithEnv() (*ExtendedConfig, error) {
 }
 
 // LoadWithFlags loads configuration and applies flag overrides.
-// Precedence: flags > env > file > defaults
+// Precedence: flags > env > project file > file > defaults
 func (l *Loader) LoadWithFlags(flags map[string]interface{}) (*ExtendedConfig, error) {
 	// Load with env
 	cfg, err := l.LoadWithEnv()
@@ -80,6 +94,7 @@ func (l *Loader) LoadWithFlags(flags map[string]interface{}) (*ExtendedConfig, e
 	// Apply flag overrides
 	flagCfg, verbositySet := l.configFromFlags(flags)
 	cfg = mergeConfigsWithVerbosity(cfg, flagCfg, verbositySet)
+	cfg.expandPaths()
 
 	// Validate again after flag overrides
 	if err := cfg.Validate(); err != nil {
@@ -89,6 +104,18 @@ func (l *Loader) LoadWithFlags(flags map[string]interface{}) (*ExtendedConfig, e
 	return cfg, nil
 }
 
+// Deprecations returns the deprecated keys and environment variables seen
+// by previous loads.
+func (l *Loader) Deprecations() []Deprecation {
+	return l.deprecations
+}
+
+// Includes returns the included configuration files merged by the last
+// load, in merge order.
+func (l *Loader) Includes() []string {
+	return l.includes
+}
+
 // loadFromEnv loads configuration from environment variables.
 // Returns a sparse config with only explicitly set environment values.
 func (l *Loader) loadFromEnv() *ExtendedConfig {
@@ -101,6 +128,7 @@ func (l *Loader) loadFromEnv() *ExtendedConfig {
 
 	// Bind all configuration keys
 	l.bindEnvKeys(v)
+	l.deprecations = append(l.deprecations, applyEnvAliases(v, strings.ToUpper(l.appName))...)
 
 	// Create sparse config
 	cfg := createSparseConfig()
@@ -115,7 +143,10 @@ func (l *Loader) loadFromEnv() *ExtendedConfig {
 	loadOperationsFromEnv(v, &cfg.Operations)
 	loadPackagesFromEnv(v, &cfg.Packages)
 	loadDoctorFromEnv(v, &cfg.Doctor)
-	loadExperimentalFromEnv(v, &cfg.Experimental)
+	loadGitFromEnv(v, &cfg.Git)
+	loadSecretsFromEnv(v, &cfg.Secrets)
+	loadObservabilityFromEnv(v, &cfg.Observability)
+	l.deprecations = append(l.deprecations, loadExperimentalFromEnv(v, strings.ToUpper(l.appName), &cfg.Experimental)...)
 
 	return cfg
 }
@@ -163,6 +194,9 @@ func loadSymlinksFromEnv(v *viper.Viper, cfg *SymlinksConfig) {
 	if v.IsSet("symlinks.backup_suffix") {
 		cfg.BackupSuffix = v.GetString("symlinks.backup_suffix")
 	}
+	if v.IsSet("symlinks.on_conflict") {
+		cfg.OnConflict = v.GetString("symlinks.on_conflict")
+	}
 }
 
 func loadIgnoreFromEnv(v *viper.Viper, cfg *IgnoreConfig) {
@@ -175,6 +209,15 @@ func loadIgnoreFromEnv(v *viper.Viper, cfg *IgnoreConfig) {
 	if v.IsSet("ignore.overrides") {
 		cfg.Overrides = v.GetStringSlice("ignore.overrides")
 	}
+	if v.IsSet("ignore.special_files") {
+		cfg.SpecialFiles = v.GetString("ignore.special_files")
+	}
+	if v.IsSet("ignore.scan_defaults") {
+		cfg.ScanDefaults = v.GetBool("ignore.scan_defaults")
+	}
+	if v.IsSet("ignore.scan_patterns") {
+		cfg.ScanPatterns = v.GetStringSlice("ignore.scan_patterns")
+	}
 }
 
 func loadDotfileFromEnv(v *viper.Viper, cfg *DotfileConfig) {
@@ -244,15 +287,84 @@ func loadDoctorFromEnv(v *viper.Viper, cfg *DoctorConfig) {
 	if v.IsSet("doctor.check_permissions") {
 		cfg.CheckPermissions = v.GetBool("doctor.check_permissions")
 	}
+	if v.IsSet("doctor.orphaned_threshold") {
+		cfg.OrphanedThreshold = v.GetInt("doctor.orphaned_threshold")
+	}
+	if v.IsSet("doctor.broken_threshold") {
+		cfg.BrokenThreshold = v.GetInt("doctor.broken_threshold")
+	}
+}
+
+func loadGitFromEnv(v *viper.Viper, cfg *GitConfig) {
+	if v.IsSet("git.timeout") {
+		cfg.Timeout = v.GetString("git.timeout")
+	}
+	if v.IsSet("git.proxy") {
+		cfg.Proxy = v.GetString("git.proxy")
+	}
+	if v.IsSet("git.insecure_skip_tls") {
+		cfg.InsecureSkipTLS = v.GetBool("git.insecure_skip_tls")
+	}
+	if v.IsSet("git.mirrors") {
+		cfg.Mirrors = v.GetStringSlice("git.mirrors")
+	}
+}
+
+func loadSecretsFromEnv(v *viper.Viper, cfg *SecretsConfig) {
+	if v.IsSet("secrets.provider") {
+		cfg.Provider = v.GetString("secrets.provider")
+	}
+	if v.IsSet("secrets.env_prefix") {
+		cfg.EnvPrefix = v.GetString("secrets.env_prefix")
+	}
+	if v.IsSet("secrets.vault") {
+		cfg.Vault = v.GetString("secrets.vault")
+	}
+}
+
+func loadObservabilityFromEnv(v *viper.Viper, cfg *ObservabilityConfig) {
+	if v.IsSet("observability.prometheus_textfile") {
+		cfg.PrometheusTextfile = v.GetString("observability.prometheus_textfile")
+	}
+	if v.IsSet("observability.prometheus_pushgateway") {
+		cfg.PrometheusPushgateway = v.GetString("observability.prometheus_pushgateway")
+	}
+	if v.IsSet("observability.prometheus_job") {
+		cfg.PrometheusJob = v.GetString("observability.prometheus_job")
+	}
+	if v.IsSet("observability.otlp_endpoint") {
+		cfg.OTLPEndpoint = v.GetString("observability.otlp_endpoint")
+	}
+	if v.IsSet("observability.otlp_headers") {
+		cfg.OTLPHeaders = v.GetStringSlice("observability.otlp_headers")
+	}
+	if v.IsSet("observability.service_name") {
+		cfg.ServiceName = v.GetString("observability.service_name")
+	}
+	if v.IsSet("observability.timeout") {
+		cfg.Timeout = v.GetString("observability.timeout")
+	}
 }
 
-func loadExperimentalFromEnv(v *viper.Viper, cfg *ExperimentalConfig) {
-	if v.IsSet("experimental.parallel") {
-		cfg.Parallel = v.GetBool("experimental.parallel")
+// loadExperimentalFromEnv reads experimental.flags, and the boolean
+// variables of the features registered before it, which are deprecated.
+func loadExperimentalFromEnv(v *viper.Viper, prefix string, cfg *ExperimentalConfig) []Deprecation {
+	if v.IsSet("experimental.flags") {
+		cfg.Flags = v.GetStringSlice("experimental.flags")
 	}
-	if v.IsSet("experimental.profiling") {
-		cfg.Profiling = v.GetBool("experimental.profiling")
+
+	var found []Deprecation
+	for _, name := range legacyFeatureKeys {
+		key := "experimental." + name
+		if !v.IsSet(key) {
+			continue
+		}
+		found = append(found, Deprecation{Key: prefix + "_" + envSuffix(key), Replacement: prefix + "_EXPERIMENTAL_FLAGS"})
+		if v.GetBool(key) && !cfg.IsEnabled(name) {
+			cfg.Flags = append(cfg.Flags, name)
+		}
 	}
+	return found
 }
 
 // getEnvWithPrefix gets an environment variable with the given prefix.
@@ -276,10 +388,14 @@ func (l *Loader) bindEnvKeys(v *viper.Viper) {
 	v.BindEnv("symlinks.overwrite")
 	v.BindEnv("symlinks.backup")
 	v.BindEnv("symlinks.backup_suffix")
+	v.BindEnv("symlinks.on_conflict")
 
 	v.BindEnv("ignore.use_defaults")
 	v.BindEnv("ignore.patterns")
 	v.BindEnv("ignore.overrides")
+	v.BindEnv("ignore.special_files")
+	v.BindEnv("ignore.scan_defaults")
+	v.BindEnv("ignore.scan_patterns")
 
 	v.BindEnv("dotfile.translate")
 	v.BindEnv("dotfile.prefix")
@@ -303,9 +419,30 @@ func (l *Loader) bindEnvKeys(v *viper.Viper) {
 	v.BindEnv("doctor.check_broken_links")
 	v.BindEnv("doctor.check_orphaned")
 	v.BindEnv("doctor.check_permissions")
+	v.BindEnv("doctor.orphaned_threshold")
+	v.BindEnv("doctor.broken_threshold")
+
+	v.BindEnv("git.timeout")
+	v.BindEnv("git.proxy")
+	v.BindEnv("git.insecure_skip_tls")
+	v.BindEnv("git.mirrors")
+
+	v.BindEnv("secrets.provider")
+	v.BindEnv("secrets.env_prefix")
+	v.BindEnv("secrets.vault")
 
-	v.BindEnv("experimental.parallel")
-	v.BindEnv("experimental.profiling")
+	v.BindEnv("observability.prometheus_textfile")
+	v.BindEnv("observability.prometheus_pushgateway")
+	v.BindEnv("observability.prometheus_job")
+	v.BindEnv("observability.otlp_endpoint")
+	v.BindEnv("observability.otlp_headers")
+	v.BindEnv("observability.service_name")
+	v.BindEnv("observability.timeout")
+
+	v.BindEnv("experimental.flags")
+	for _, name := range legacyFeatureKeys {
+		v.BindEnv("experimental." + name)
+	}
 }
 
 // configFromFlags creates partial config from flag map.
@@ -320,16 +457,19 @@ func (l *Loader) configFromFlags(flags map[string]interface{}) (*ExtendedConfig,
 // createSparseConfig creates an empty config for flag/env merging.
 func createSparseConfig() *ExtendedConfig {
 	return &ExtendedConfig{
-		Directories:  DirectoriesConfig{},
-		Logging:      LoggingConfig{},
-		Symlinks:     SymlinksConfig{},
-		Ignore:       IgnoreConfig{},
-		Dotfile:      DotfileConfig{},
-		Output:       OutputConfig{Verbosity: -1}, // Use -1 as sentinel for "not set"
-		Operations:   OperationsConfig{},
-		Packages:     PackagesConfig{},
-		Doctor:       DoctorConfig{},
-		Experimental: ExperimentalConfig{},
+		Directories:   DirectoriesConfig{},
+		Logging:       LoggingConfig{},
+		Symlinks:      SymlinksConfig{},
+		Ignore:        IgnoreConfig{},
+		Dotfile:       DotfileConfig{},
+		Output:        OutputConfig{Verbosity: -1}, // Use -1 as sentinel for "not set"
+		Operations:    OperationsConfig{},
+		Packages:      PackagesConfig{},
+		Doctor:        DoctorConfig{},
+		Git:           GitConfig{},
+		Secrets:       SecretsConfig{},
+		Observability: ObservabilityConfig{},
+		Experimental:  ExperimentalConfig{},
 	}
 }
 
@@ -402,6 +542,7 @@ func mergeConfigsWithVerbosity(base, override *ExtendedConfig, verbosityExplicit
 	merged := *base
 
 	mergeDirectories(&merged, override)
+	mergeTargets(&merged, override)
 	mergeLogging(&merged, override)
 	mergeSymlinks(&merged, override)
 	mergeIgnore(&merged, override)
@@ -410,6 +551,9 @@ func mergeConfigsWithVerbosity(base, override *ExtendedConfig, verbosityExplicit
 	mergeOperations(&merged, override)
 	mergePackages(&merged, override)
 	mergeDoctor(&merged, override)
+	mergeGit(&merged, override)
+	mergeSecrets(&merged, override)
+	mergeObservability(&merged, override)
 	mergeExperimental(&merged, override)
 
 	return &merged
@@ -428,6 +572,18 @@ func mergeDirectories(merged *ExtendedConfig, override *ExtendedConfig) {
 	}
 }
 
+// mergeTargets merges package target directories; override entries
+// replace those for the same package.
+func mergeTargets(merged *ExtendedConfig, override *ExtendedConfig) {
+	if len(override.Targets) == 0 {
+		return
+	}
+	targets := make(map[string]string, len(merged.Targets)+len(override.Targets))
+	maps.Copy(targets, merged.Targets)
+	maps.Copy(targets, override.Targets)
+	merged.Targets = targets
+}
+
 // mergeLogging merges logging configuration.
 func mergeLogging(merged *ExtendedConfig, override *ExtendedConfig) {
 	if override.Logging.Level != "" {
@@ -458,6 +614,15 @@ func mergeSymlinks(merged *ExtendedConfig, override *ExtendedConfig) {
 	if override.Symlinks.Backup {
 		merged.Symlinks.Backup = true
 	}
+	if override.Symlinks.OnConflict != "" {
+		merged.Symlinks.OnConflict = override.Symlinks.OnConflict
+	}
+	if len(override.Symlinks.Policies) > 0 {
+		policies := make(map[string]string, len(merged.Symlinks.Policies)+len(override.Symlinks.Policies))
+		maps.Copy(policies, merged.Symlinks.Policies)
+		maps.Copy(policies, override.Symlinks.Policies)
+		merged.Symlinks.Policies = policies
+	}
 }
 
 // mergeIgnore merges ignore pattern configuration.
@@ -468,6 +633,12 @@ func mergeIgnore(merged *ExtendedConfig, override *ExtendedConfig) {
 	if len(override.Ignore.Overrides) > 0 {
 		merged.Ignore.Overrides = override.Ignore.Overrides
 	}
+	if override.Ignore.SpecialFiles != "" {
+		merged.Ignore.SpecialFiles = override.Ignore.SpecialFiles
+	}
+	if len(override.Ignore.ScanPatterns) > 0 {
+		merged.Ignore.ScanPatterns = override.Ignore.ScanPatterns
+	}
 }
 
 // mergeDotfile merges dotfile translation configuration.
@@ -515,14 +686,73 @@ func mergeDoctor(merged *ExtendedConfig, override *ExtendedConfig) {
 	if override.Doctor.AutoFix {
 		merged.Doctor.AutoFix = true
 	}
+	if override.Doctor.OrphanedThreshold > 0 {
+		merged.Doctor.OrphanedThreshold = override.Doctor.OrphanedThreshold
+	}
+	if override.Doctor.BrokenThreshold > 0 {
+		merged.Doctor.BrokenThreshold = override.Doctor.BrokenThreshold
+	}
+}
+
+// mergeGit merges git network configuration.
+func mergeGit(merged *ExtendedConfig, override *ExtendedConfig) {
+	if override.Git.Timeout != "" {
+		merged.Git.Timeout = override.Git.Timeout
+	}
+	if override.Git.Proxy != "" {
+		merged.Git.Proxy = override.Git.Proxy
+	}
+	if override.Git.InsecureSkipTLS {
+		merged.Git.InsecureSkipTLS = true
+	}
+	if len(override.Git.Mirrors) > 0 {
+		merged.Git.Mirrors = override.Git.Mirrors
+	}
+}
+
+// mergeSecrets merges template secrets configuration.
+func mergeSecrets(merged *ExtendedConfig, override *ExtendedConfig) {
+	if override.Secrets.Provider != "" {
+		merged.Secrets.Provider = override.Secrets.Provider
+	}
+	if override.Secrets.EnvPrefix != "" {
+		merged.Secrets.EnvPrefix = override.Secrets.EnvPrefix
+	}
+	if override.Secrets.Vault != "" {
+		merged.Secrets.Vault = override.Secrets.Vault
+	}
+}
+
+// mergeObservability merges metrics and traces export configuration.
+func mergeObservability(merged *ExtendedConfig, override *ExtendedConfig) {
+	if override.Observability.PrometheusTextfile != "" {
+		merged.Observability.PrometheusTextfile = override.Observability.PrometheusTextfile
+	}
+	if override.Observability.PrometheusPushgateway != "" {
+		merged.Observability.PrometheusPushgateway = override.Observability.PrometheusPushgateway
+	}
+	if override.Observability.PrometheusJob != "" {
+		merged.Observability.PrometheusJob = override.Observability.PrometheusJob
+	}
+	if override.Observability.OTLPEndpoint != "" {
+		merged.Observability.OTLPEndpoint = override.Observability.OTLPEndpoint
+	}
+	if len(override.Observability.OTLPHeaders) > 0 {
+		merged.Observability.OTLPHeaders = override.Observability.OTLPHeaders
+	}
+	if override.Observability.ServiceName != "" {
+		merged.Observability.ServiceName = override.Observability.ServiceName
+	}
+	if override.Observability.Timeout != "" {
+		merged.Observability.Timeout = override.Observability.Timeout
+	}
 }
 
 // mergeExperimental merges experimental feature configuration.
 func mergeExperimental(merged *ExtendedConfig, override *ExtendedConfig) {
-	if override.Experimental.Parallel {
-		merged.Experimental.Parallel = true
-	}
-	if override.Experimental.Profiling {
-		merged.Experimental.Profiling = true
+	for _, flag := range override.Experimental.Flags {
+		if !merged.Experimental.IsEnabled(flag) {
+			merged.Experimental.Flags = append(merged.Experimental.Flags, flag)
+		}
 	}
 }
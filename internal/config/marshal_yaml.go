@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"reflect"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -53,138 +55,248 @@ func (s *YAMLStrategy) Unmarshal(data []byte) (*ExtendedConfig, error) {
 	return &cfg, nil
 }
 
-// marshalWithComments creates YAML with helpful comments.
+// yamlFileComment is the comment at the top of configuration files
+// written with comments.
+const yamlFileComment = "# Dot Configuration File\n# Documentation: https://github.com/jamesainslie/dot/docs/configuration.md"
+
+// yamlKeyComments are the comments written above the sections and keys of
+// configuration files written with comments.
+var yamlKeyComments = map[string]string{
+	"include": "Files merged over this one, in order",
+
+	"directories":          "Core Directories",
+	"directories.package":  "Package directory containing packages",
+	"directories.target":   "Target directory for symlinks",
+	"directories.manifest": "Manifest directory for tracking",
+
+	"targets": "Target directories of packages linked outside directories.target",
+
+	"logging":             "Logging Configuration",
+	"logging.level":       "Log level: DEBUG, INFO, WARN, ERROR",
+	"logging.format":      "Log format: text, json",
+	"logging.destination": "Log destination: stderr, stdout, file",
+	"logging.file":        "Log file path (only used if destination is file)",
+
+	"symlinks":               "Symlink Behavior",
+	"symlinks.mode":          "Link mode: relative, absolute",
+	"symlinks.folding":       "Enable directory folding optimization",
+	"symlinks.overwrite":     "Overwrite existing files when conflicts occur",
+	"symlinks.backup":        "Create backup of overwritten files",
+	"symlinks.backup_suffix": "Backup suffix when backups enabled",
+	"symlinks.backup_dir":    "Directory for backup files",
+	"symlinks.on_conflict":   "Conflict resolution strategy: fail, skip, backup, overwrite, adopt, prompt",
+	"symlinks.policies":      "Conflict resolution strategy per target path glob",
+
+	"ignore":               "Ignore Patterns",
+	"ignore.use_defaults":  "Use default ignore patterns",
+	"ignore.patterns":      "Additional patterns to ignore (glob format)",
+	"ignore.overrides":     "Patterns to override (force include even if ignored)",
+	"ignore.special_files": "Handling of sockets, named pipes, and devices: skip, error",
+	"ignore.scan_defaults": "Skip cloud-synced and volatile directories in orphan scans and adoption",
+	"ignore.scan_patterns": "Additional directories to skip in orphan scans and adoption",
+
+	"dotfile":                      "Dotfile Translation",
+	"dotfile.translate":            "Enable dot- to . translation",
+	"dotfile.prefix":               "Prefix for dotfile translation",
+	"dotfile.package_name_mapping": "Link the files of a package named dot-NAME into ~/.NAME",
+
+	"output":             "Output Configuration",
+	"output.format":      "Default output format: text, json, yaml, table",
+	"output.color":       "Enable colored output: auto, always, never",
+	"output.table_style": "Table style: default, simple",
+	"output.progress":    "Show progress indicators",
+	"output.verbosity":   "Verbosity level: 0 (quiet), 1 (normal), 2 (verbose), 3 (debug)",
+	"output.width":       "Terminal width for text wrapping (0 = auto-detect)",
+
+	"operations":              "Operation Defaults",
+	"operations.dry_run":      "Enable dry-run mode by default",
+	"operations.atomic":       "Enable atomic operations with rollback",
+	"operations.max_parallel": "Maximum number of parallel operations (0 = auto)",
+
+	"packages":                "Package Management",
+	"packages.sort_by":        "Default sort order: name, links, date",
+	"packages.auto_discover":  "Automatically scan for new packages",
+	"packages.validate_names": "Package naming convention validation",
+
+	"doctor":                    "Doctor Configuration",
+	"doctor.auto_fix":           "Auto-fix issues when possible",
+	"doctor.check_manifest":     "Check manifest integrity",
+	"doctor.check_broken_links": "Check for broken symlinks",
+	"doctor.check_orphaned":     "Check for orphaned links",
+	"doctor.check_permissions":  "Check file permissions",
+	"doctor.orphaned_threshold": "Orphaned links tolerated before health becomes a warning",
+	"doctor.broken_threshold":   "Broken links tolerated before health becomes an error",
+
+	"update":                    "Version Checks",
+	"update.check_on_startup":   "Check for new versions at startup",
+	"update.check_frequency":    "Hours between version checks (0 = always, -1 = never)",
+	"update.package_manager":    "Package manager used by upgrade: auto, brew, apt, yum, pacman, manual",
+	"update.repository":         "GitHub repository releases are read from",
+	"update.include_prerelease": "Offer pre-release versions",
+
+	"git":                   "Git Network Settings",
+	"git.timeout":           "Maximum duration of a clone or pull, e.g. 2m (empty = no limit)",
+	"git.proxy":             "Proxy URL for git traffic, e.g. http://proxy.example.com:3128",
+	"git.insecure_skip_tls": "Skip TLS certificate verification (use only with trusted networks)",
+	"git.mirrors":           "Fallback repository URLs tried in order when clone fails",
+
+	"secrets":            "Template Secrets",
+	"secrets.provider":   `Source of {{ secret "name" }} values: env, pass, op`,
+	"secrets.env_prefix": "Environment variable prefix for the env provider",
+	"secrets.vault":      "1Password vault for items referenced by name",
+
+	"observability":                        "Metrics and Traces Export",
+	"observability.prometheus_textfile":    "File rewritten for the node exporter textfile collector (empty = disabled)",
+	"observability.prometheus_pushgateway": "Prometheus Pushgateway URL, e.g. http://pushgateway:9091 (empty = disabled)",
+	"observability.prometheus_job":         "Job name metrics are pushed under",
+	"observability.otlp_endpoint":          "OTLP/HTTP collector URL, e.g. http://localhost:4318 (empty = disabled)",
+	"observability.otlp_headers":           "Headers added to OTLP requests, as name=value",
+	"observability.service_name":           "Service name reported to the OTLP collector",
+	"observability.timeout":                "Maximum duration of exporting when a command finishes",
+
+	"experimental":       "Experimental Features",
+	"experimental.flags": "Enabled experimental features; see 'dot features list'",
+}
+
+// marshalWithComments creates YAML with helpful comments above each
+// section and key.
 func (s *YAMLStrategy) marshalWithComments(cfg *ExtendedConfig) ([]byte, error) {
+	var root yaml.Node
+	if err := root.Encode(cfg); err != nil {
+		return nil, fmt.Errorf("marshal yaml: %w", err)
+	}
+	walkYAMLKeys(&root, "", func(path string, key, value *yaml.Node) {
+		if comment, ok := yamlKeyComments[path]; ok {
+			key.HeadComment = "# " + comment
+		}
+	})
+	doc := yaml.Node{Kind: yaml.DocumentNode, HeadComment: yamlFileComment, Content: []*yaml.Node{&root}}
+	data, err := encodeYAMLNode(&doc, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	// Separate the sections by a blank line above their comments
+	lines := strings.SplitAfter(string(data), "\n")
 	var buf bytes.Buffer
+	for i, line := range lines {
+		if i > 0 && strings.HasPrefix(line, "#") && !strings.HasPrefix(lines[i-1], "#") && lines[i-1] != "\n" {
+			buf.WriteString("\n")
+		}
+		buf.WriteString(line)
+	}
+	return buf.Bytes(), nil
+}
 
-	buf.WriteString("# Dot Configuration File\n")
-	buf.WriteString("# Documentation: https://github.com/jamesainslie/dot/docs/configuration.md\n\n")
-
-	buf.WriteString("# Core Directories\n")
-	buf.WriteString("directories:\n")
-	buf.WriteString("  # Package directory containing packages\n")
-	buf.WriteString(fmt.Sprintf("  package: %s\n", cfg.Directories.Package))
-	buf.WriteString("  # Target directory for symlinks\n")
-	buf.WriteString(fmt.Sprintf("  target: %s\n", cfg.Directories.Target))
-	buf.WriteString("  # Manifest directory for tracking\n")
-	buf.WriteString(fmt.Sprintf("  manifest: %s\n\n", cfg.Directories.Manifest))
-
-	buf.WriteString("# Logging Configuration\n")
-	buf.WriteString("logging:\n")
-	buf.WriteString("  # Log level: DEBUG, INFO, WARN, ERROR\n")
-	buf.WriteString(fmt.Sprintf("  level: %s\n", cfg.Logging.Level))
-	buf.WriteString("  # Log format: text, json\n")
-	buf.WriteString(fmt.Sprintf("  format: %s\n", cfg.Logging.Format))
-	buf.WriteString("  # Log destination: stderr, stdout, file\n")
-	buf.WriteString(fmt.Sprintf("  destination: %s\n", cfg.Logging.Destination))
-	buf.WriteString("  # Log file path (only used if destination is file)\n")
-	buf.WriteString(fmt.Sprintf("  file: %s\n\n", cfg.Logging.File))
-
-	buf.WriteString("# Symlink Behavior\n")
-	buf.WriteString("symlinks:\n")
-	buf.WriteString("  # Link mode: relative, absolute\n")
-	buf.WriteString(fmt.Sprintf("  mode: %s\n", cfg.Symlinks.Mode))
-	buf.WriteString("  # Enable directory folding optimization\n")
-	buf.WriteString(fmt.Sprintf("  folding: %t\n", cfg.Symlinks.Folding))
-	buf.WriteString("  # Overwrite existing files when conflicts occur\n")
-	buf.WriteString(fmt.Sprintf("  overwrite: %t\n", cfg.Symlinks.Overwrite))
-	buf.WriteString("  # Create backup of overwritten files\n")
-	buf.WriteString(fmt.Sprintf("  backup: %t\n", cfg.Symlinks.Backup))
-	buf.WriteString("  # Backup suffix when backups enabled\n")
-	buf.WriteString(fmt.Sprintf("  backup_suffix: %s\n", cfg.Symlinks.BackupSuffix))
-	buf.WriteString("  # Directory for backup files\n")
-	if cfg.Symlinks.BackupDir == "" {
-		buf.WriteString("  backup_dir:\n\n")
+// updateYAML returns the YAML document original with the values of cfg,
+// keeping its comments, the order of its keys and the style of the values
+// cfg leaves unchanged. Keys cfg does not have are removed, and keys new
+// to the document are added at the end of their section. Deprecated keys
+// are renamed to the keys replacing them.
+func updateYAML(original []byte, cfg *ExtendedConfig) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(original, &doc); err != nil {
+		return nil, fmt.Errorf("parse yaml: %w", err)
+	}
+	var updated yaml.Node
+	if err := updated.Encode(cfg); err != nil {
+		return nil, fmt.Errorf("marshal yaml: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		doc = yaml.Node{Kind: yaml.DocumentNode, HeadComment: doc.HeadComment, Content: []*yaml.Node{&updated}}
 	} else {
-		buf.WriteString(fmt.Sprintf("  backup_dir: %s\n\n", cfg.Symlinks.BackupDir))
-	}
-
-	buf.WriteString("# Ignore Patterns\n")
-	buf.WriteString("ignore:\n")
-	buf.WriteString("  # Use default ignore patterns\n")
-	buf.WriteString(fmt.Sprintf("  use_defaults: %t\n", cfg.Ignore.UseDefaults))
-	buf.WriteString("  # Additional patterns to ignore (glob format)\n")
-	s.writeYAMLList(&buf, "patterns", cfg.Ignore.Patterns, 2)
-	buf.WriteString("  # Patterns to override (force include even if ignored)\n")
-	s.writeYAMLList(&buf, "overrides", cfg.Ignore.Overrides, 2)
-	buf.WriteString("\n")
-
-	buf.WriteString("# Dotfile Translation\n")
-	buf.WriteString("dotfile:\n")
-	buf.WriteString("  # Enable dot- to . translation\n")
-	buf.WriteString(fmt.Sprintf("  translate: %t\n", cfg.Dotfile.Translate))
-	buf.WriteString("  # Prefix for dotfile translation\n")
-	buf.WriteString(fmt.Sprintf("  prefix: %s\n\n", cfg.Dotfile.Prefix))
-
-	buf.WriteString("# Output Configuration\n")
-	buf.WriteString("output:\n")
-	buf.WriteString("  # Default output format: text, json, yaml, table\n")
-	buf.WriteString(fmt.Sprintf("  format: %s\n", cfg.Output.Format))
-	buf.WriteString("  # Enable colored output: auto, always, never\n")
-	buf.WriteString(fmt.Sprintf("  color: %s\n", cfg.Output.Color))
-	buf.WriteString("  # Show progress indicators\n")
-	buf.WriteString(fmt.Sprintf("  progress: %t\n", cfg.Output.Progress))
-	buf.WriteString("  # Verbosity level: 0 (quiet), 1 (normal), 2 (verbose), 3 (debug)\n")
-	buf.WriteString(fmt.Sprintf("  verbosity: %d\n", cfg.Output.Verbosity))
-	buf.WriteString("  # Terminal width for text wrapping (0 = auto-detect)\n")
-	buf.WriteString(fmt.Sprintf("  width: %d\n\n", cfg.Output.Width))
-
-	buf.WriteString("# Operation Defaults\n")
-	buf.WriteString("operations:\n")
-	buf.WriteString("  # Enable dry-run mode by default\n")
-	buf.WriteString(fmt.Sprintf("  dry_run: %t\n", cfg.Operations.DryRun))
-	buf.WriteString("  # Enable atomic operations with rollback\n")
-	buf.WriteString(fmt.Sprintf("  atomic: %t\n", cfg.Operations.Atomic))
-	buf.WriteString("  # Maximum number of parallel operations (0 = auto)\n")
-	buf.WriteString(fmt.Sprintf("  max_parallel: %d\n\n", cfg.Operations.MaxParallel))
-
-	buf.WriteString("# Package Management\n")
-	buf.WriteString("packages:\n")
-	buf.WriteString("  # Default sort order: name, links, date\n")
-	buf.WriteString(fmt.Sprintf("  sort_by: %s\n", cfg.Packages.SortBy))
-	buf.WriteString("  # Automatically scan for new packages\n")
-	buf.WriteString(fmt.Sprintf("  auto_discover: %t\n", cfg.Packages.AutoDiscover))
-	buf.WriteString("  # Package naming convention validation\n")
-	buf.WriteString(fmt.Sprintf("  validate_names: %t\n\n", cfg.Packages.ValidateNames))
-
-	buf.WriteString("# Doctor Configuration\n")
-	buf.WriteString("doctor:\n")
-	buf.WriteString("  # Auto-fix issues when possible\n")
-	buf.WriteString(fmt.Sprintf("  auto_fix: %t\n", cfg.Doctor.AutoFix))
-	buf.WriteString("  # Check manifest integrity\n")
-	buf.WriteString(fmt.Sprintf("  check_manifest: %t\n", cfg.Doctor.CheckManifest))
-	buf.WriteString("  # Check for broken symlinks\n")
-	buf.WriteString(fmt.Sprintf("  check_broken_links: %t\n", cfg.Doctor.CheckBrokenLinks))
-	buf.WriteString("  # Check for orphaned links\n")
-	buf.WriteString(fmt.Sprintf("  check_orphaned: %t\n", cfg.Doctor.CheckOrphaned))
-	buf.WriteString("  # Check file permissions\n")
-	buf.WriteString(fmt.Sprintf("  check_permissions: %t\n\n", cfg.Doctor.CheckPermissions))
-
-	buf.WriteString("# Experimental Features\n")
-	buf.WriteString("experimental:\n")
-	buf.WriteString("  # Enable parallel operations\n")
-	buf.WriteString(fmt.Sprintf("  parallel: %t\n", cfg.Experimental.Parallel))
-	buf.WriteString("  # Enable performance profiling\n")
-	buf.WriteString(fmt.Sprintf("  profiling: %t\n", cfg.Experimental.Profiling))
-
-	return buf.Bytes(), nil
+		renameDeprecatedYAMLKeys(doc.Content[0], "")
+		mergeYAMLMapping(doc.Content[0], &updated)
+	}
+	return encodeYAMLNode(&doc, yamlIndent(original))
 }
 
-// writeYAMLList writes a YAML list with proper indentation.
-func (s *YAMLStrategy) writeYAMLList(buf *bytes.Buffer, key string, items []string, indent int) {
-	spaces := make([]byte, indent)
-	for i := range spaces {
-		spaces[i] = ' '
+// mergeYAMLMapping updates the mapping node dst to hold the keys and values
+// of src, as described by updateYAML.
+func mergeYAMLMapping(dst, src *yaml.Node) {
+	values := make(map[string]*yaml.Node, len(src.Content)/2)
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		values[src.Content[i].Value] = src.Content[i+1]
+	}
+
+	content := make([]*yaml.Node, 0, len(src.Content))
+	kept := make(map[string]bool, len(values))
+	for i := 0; i+1 < len(dst.Content); i += 2 {
+		key, value := dst.Content[i], dst.Content[i+1]
+		next, ok := values[key.Value]
+		if !ok || kept[key.Value] {
+			continue
+		}
+		kept[key.Value] = true
+		switch {
+		case value.Kind == yaml.MappingNode && next.Kind == yaml.MappingNode:
+			mergeYAMLMapping(value, next)
+		case !equalYAMLValues(value, next):
+			value.Kind, value.Tag, value.Value = next.Kind, next.Tag, next.Value
+			value.Style, value.Content, value.Anchor, value.Alias = next.Style, next.Content, "", nil
+		}
+		content = append(content, key, value)
 	}
-	prefix := string(spaces)
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		if !kept[src.Content[i].Value] {
+			content = append(content, src.Content[i], src.Content[i+1])
+		}
+	}
+	dst.Content = content
+}
 
-	if len(items) == 0 {
-		buf.WriteString(fmt.Sprintf("%s%s: []\n", prefix, key))
+// renameDeprecatedYAMLKeys renames the deprecated keys of the mapping node
+// to the keys replacing them, with prefix the path of the mapping, unless
+// the replacement is set as well.
+func renameDeprecatedYAMLKeys(node *yaml.Node, prefix string) {
+	if node.Kind != yaml.MappingNode {
 		return
 	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		path := prefix + key.Value
+		if replacement, ok := CanonicalKey(path); ok {
+			name, found := strings.CutPrefix(replacement, prefix)
+			if found && !strings.Contains(name, ".") && mappingValue(node, name) == nil {
+				key.Value = name
+			}
+		}
+		renameDeprecatedYAMLKeys(node.Content[i+1], path+".")
+	}
+}
+
+// equalYAMLValues reports whether the nodes a and b hold the same value.
+func equalYAMLValues(a, b *yaml.Node) bool {
+	var va, vb any
+	if a.Decode(&va) != nil || b.Decode(&vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// yamlIndent returns the indentation of the first indented line of the
+// YAML document data, or 2.
+func yamlIndent(data []byte) int {
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || trimmed == line || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "- ") {
+			continue
+		}
+		return len(line) - len(trimmed)
+	}
+	return 2
+}
 
-	buf.WriteString(fmt.Sprintf("%s%s:\n", prefix, key))
-	for _, item := range items {
-		buf.WriteString(fmt.Sprintf("%s  - %s\n", prefix, item))
+// encodeYAMLNode encodes node with indent spaces of indentation.
+func encodeYAMLNode(node *yaml.Node, indent int) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(indent)
+	if err := enc.Encode(node); err != nil {
+		return nil, fmt.Errorf("marshal yaml: %w", err)
 	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("marshal yaml: %w", err)
+	}
+	return buf.Bytes(), nil
 }
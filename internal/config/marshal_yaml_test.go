@@ -183,6 +183,7 @@ func TestYAMLStrategy_RoundTrip(t *testing.T) {
 	t.Run("round trip with comments preserves data", func(t *testing.T) {
 		original := DefaultExtended()
 		original.Logging.Level = "DEBUG"
+		original.Symlinks.Policies = map[string]string{"~/.config/**": "backup"}
 
 		strategy := NewYAMLStrategy()
 		opts := MarshalOptions{
@@ -199,6 +200,7 @@ func TestYAMLStrategy_RoundTrip(t *testing.T) {
 		require.NoError(t, err)
 
 		assert.Equal(t, original.Logging.Level, restored.Logging.Level)
+		assert.Equal(t, original.Symlinks.Policies, restored.Symlinks.Policies)
 	})
 }
 
@@ -30,6 +30,12 @@ func TestGetConfigPath_EmptyApp(t *testing.T) {
 	assert.NotEmpty(t, path)
 }
 
+func TestGetCachePath_WithXDGSet(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", "/tmp/test-cache")
+
+	assert.Equal(t, "/tmp/test-cache/dot", config.GetCachePath("dot"))
+}
+
 func TestDefaultExtended_AllFieldsSet(t *testing.T) {
 	cfg := config.DefaultExtended()
 
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// ProjectConfigFile is the name of the project configuration file kept in
+// the root of a package repository.
+const ProjectConfigFile = ".dotrc.yaml"
+
+// projectKeys are the keys a project configuration file may set: those
+// describing how the packages of the repository are laid out, rather than
+// the machine they are installed on.
+var projectKeys = []string{
+	KeySymlinkMode,
+	KeySymlinkFolding,
+	KeyIgnoreUseDefaults,
+	KeyIgnorePatterns,
+	KeyIgnoreOverrides,
+	KeyIgnoreSpecialFiles,
+	KeyIgnoreScanDefaults,
+	KeyIgnoreScanPatterns,
+	KeyDotfileTranslate,
+	KeyDotfilePrefix,
+}
+
+// WithProjectDir makes the loader merge the project configuration file of
+// the package repository at dir, if it has one, over the configuration
+// file. An empty dir selects the package directory of the configuration.
+func (l *Loader) WithProjectDir(dir string) *Loader {
+	l.projectDir = dir
+	l.findProject = true
+	return l
+}
+
+// ProjectConfig returns the project configuration file merged by the last
+// load, or an empty string if there was none.
+func (l *Loader) ProjectConfig() string {
+	return l.project
+}
+
+// applyProject merges the project configuration file into cfg.
+func (l *Loader) applyProject(cfg *ExtendedConfig) error {
+	l.project = ""
+	if !l.findProject {
+		return nil
+	}
+	dir := l.projectDir
+	if dir == "" {
+		dir = cfg.Directories.Package
+	}
+	path := filepath.Join(dir, ProjectConfigFile)
+	if !fileExists(path) {
+		return nil
+	}
+
+	settings, deprecations, err := readConfigFile(path)
+	if err != nil {
+		return fmt.Errorf("load project config: %w", err)
+	}
+	l.deprecations = append(l.deprecations, deprecations...)
+
+	project := viper.New()
+	if err := project.MergeConfigMap(settings); err != nil {
+		return fmt.Errorf("load project config: %w", err)
+	}
+	policies, err := readPathPolicies(path)
+	if err != nil {
+		return fmt.Errorf("load project config: %w", err)
+	}
+	keys := project.AllKeys()
+	if len(policies) > 0 {
+		keys = append(keys, KeySymlinkPolicies)
+	}
+	for _, key := range keys {
+		if !slices.Contains(projectKeys, key) {
+			return fmt.Errorf("load project config: %s: %s cannot be set in %s, only %s", path, key, ProjectConfigFile, strings.Join(projectKeys, ", "))
+		}
+	}
+
+	// Only the keys set in the file are decoded, so the others keep the
+	// values of the configuration file
+	if err := project.Unmarshal(cfg); err != nil {
+		return fmt.Errorf("load project config: unmarshal config: %w", err)
+	}
+	l.project = path
+	return nil
+}
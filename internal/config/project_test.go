@@ -0,0 +1,95 @@
+package config_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/jamesainslie/dot/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoader_ProjectConfig(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	repo := filepath.Join(dir, "dotfiles")
+	writeConfigFile(t, configPath, `symlinks:
+  mode: absolute
+  backup_suffix: .orig
+ignore:
+  use_defaults: true
+  patterns: ["*.bak"]
+dotfile:
+  prefix: dot-
+`)
+	writeConfigFile(t, filepath.Join(repo, config.ProjectConfigFile), `symlinks:
+  mode: relative
+ignore:
+  use_defaults: false
+  patterns: ["*.swp", "notes/"]
+dotfile:
+  prefix: _
+`)
+
+	loader := config.NewLoader("dot", configPath).WithProjectDir(repo)
+	cfg, err := loader.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "relative", cfg.Symlinks.Mode)
+	assert.Equal(t, ".orig", cfg.Symlinks.BackupSuffix, "keys not set by the project are kept")
+	assert.False(t, cfg.Ignore.UseDefaults, "explicit false values override")
+	assert.Equal(t, []string{"*.swp", "notes/"}, cfg.Ignore.Patterns)
+	assert.Equal(t, "_", cfg.Dotfile.Prefix)
+	assert.Equal(t, filepath.Join(repo, config.ProjectConfigFile), loader.ProjectConfig())
+
+	// Environment variables override the project
+	t.Setenv("DOT_SYMLINKS_MODE", "absolute")
+	cfg, err = loader.LoadWithEnv()
+	require.NoError(t, err)
+	assert.Equal(t, "absolute", cfg.Symlinks.Mode)
+	assert.Equal(t, "_", cfg.Dotfile.Prefix)
+}
+
+func TestLoader_ProjectConfigWithoutFile(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFile(t, filepath.Join(dir, config.ProjectConfigFile), "dotfile:\n  prefix: _\n")
+
+	// The project applies over the defaults when there is no config file
+	loader := config.NewLoader("dot", filepath.Join(dir, "missing.yaml")).WithProjectDir(dir)
+	cfg, err := loader.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "_", cfg.Dotfile.Prefix)
+	assert.Equal(t, config.DefaultExtended().Symlinks.Mode, cfg.Symlinks.Mode)
+
+	// A repository without one leaves the configuration alone
+	loader = config.NewLoader("dot", filepath.Join(dir, "missing.yaml")).WithProjectDir(t.TempDir())
+	cfg, err = loader.Load()
+	require.NoError(t, err)
+	assert.Equal(t, config.DefaultExtended().Dotfile.Prefix, cfg.Dotfile.Prefix)
+	assert.Empty(t, loader.ProjectConfig())
+}
+
+func TestLoader_ProjectConfigRejectsMachineKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		key     string
+	}{
+		{name: "directories", content: "directories:\n  target: /srv\n", key: "directories.target"},
+		{name: "backup dir", content: "symlinks:\n  backup_dir: /tmp\n", key: "symlinks.backup_dir"},
+		{name: "policies", content: "symlinks:\n  policies:\n    \"*.conf\": backup\n", key: "symlinks.policies"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeConfigFile(t, filepath.Join(dir, config.ProjectConfigFile), tt.content)
+
+			_, err := config.NewLoader("dot", filepath.Join(dir, "missing.yaml")).WithProjectDir(dir).Load()
+			assert.ErrorContains(t, err, tt.key+" cannot be set in .dotrc.yaml")
+		})
+	}
+
+	dir := t.TempDir()
+	writeConfigFile(t, filepath.Join(dir, config.ProjectConfigFile), "symlinks:\n  mode: sideways\n")
+	_, err := config.NewLoader("dot", filepath.Join(dir, "missing.yaml")).WithProjectDir(dir).Load()
+	assert.ErrorContains(t, err, "invalid configuration")
+}
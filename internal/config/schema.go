@@ -0,0 +1,220 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// KeyType is the type of the value of a configuration key.
+type KeyType string
+
+const (
+	KeyTypeString KeyType = "string"
+	KeyTypeBool   KeyType = "bool"
+	KeyTypeInt    KeyType = "int"
+	KeyTypeList   KeyType = "list"
+	KeyTypeMap    KeyType = "map"
+)
+
+// KeySpec describes a configuration key.
+type KeySpec struct {
+	// Key is the dotted path of the key, such as "logging.level".
+	Key string `json:"key" yaml:"key"`
+
+	// Type is the type of the value. Lists hold strings, and maps map
+	// strings to strings, with entries addressed as "<key>.<name>".
+	Type KeyType `json:"type" yaml:"type"`
+
+	// Default is the value used when no source sets the key.
+	Default any `json:"default" yaml:"default"`
+
+	// Description says what the key controls.
+	Description string `json:"description" yaml:"description"`
+}
+
+// keyDescriptions describe the keys of ExtendedConfig. Every key has one;
+// the schema tests check that they agree.
+var keyDescriptions = map[string]string{
+	"include": "Further configuration files merged over this one, in order",
+	"targets": "Directory the links of a package are created in, by package name",
+
+	KeyDirPackage:  "Directory containing the packages",
+	KeyDirTarget:   "Directory the links are created in",
+	KeyDirManifest: "Directory the manifest is kept in",
+
+	KeyLogLevel:       "Log level: DEBUG, INFO, WARN, ERROR",
+	KeyLogFormat:      "Log format: text, json",
+	KeyLogDestination: "Log destination: stderr, stdout, file",
+	KeyLogFile:        "Log file, when the destination is file",
+
+	KeySymlinkMode:         "Link mode: relative, absolute",
+	KeySymlinkFolding:      "Link whole directories when a package owns them",
+	KeySymlinkOverwrite:    "Overwrite existing files when conflicts occur",
+	KeySymlinkBackup:       "Back up files replaced by links",
+	KeySymlinkBackupSuffix: "Suffix of backup files",
+	KeySymlinkBackupDir:    "Directory for backup files (default: <target>/.dot-backup)",
+	KeySymlinkOnConflict:   "Conflict resolution strategy: fail, skip, backup, overwrite, adopt, prompt",
+	KeySymlinkPolicies:     "Conflict resolution strategy by target path glob, overriding on_conflict",
+
+	KeyIgnoreUseDefaults:  "Ignore the default patterns, such as .git and .DS_Store",
+	KeyIgnorePatterns:     "Additional glob patterns of package files to ignore",
+	KeyIgnoreOverrides:    "Glob patterns of package files to include even if ignored",
+	KeyIgnoreSpecialFiles: "Handling of sockets, named pipes and devices: skip, error",
+	KeyIgnoreScanDefaults: "Skip cloud-synced and volatile directories in orphan scans and adoption globs",
+	KeyIgnoreScanPatterns: "Additional directories to skip in orphan scans and adoption globs",
+
+	KeyDotfileTranslate:            "Translate the dot- prefix of package files to a leading dot",
+	KeyDotfilePrefix:               "Prefix translated to a leading dot",
+	"dotfile.package_name_mapping": "Link the files of a package named dot-NAME into ~/.NAME",
+
+	KeyOutputFormat:      "Default output format: text, json, yaml, table",
+	"output.color":       "Colored output: auto, always, never",
+	"output.table_style": "Table style: default, simple",
+	KeyOutputProgress:    "Show progress indicators",
+	KeyOutputVerbosity:   "Verbosity level: 0 (quiet), 1 (normal), 2 (verbose), 3 (debug)",
+	KeyOutputWidth:       "Terminal width for text wrapping (0 = auto-detect)",
+
+	KeyOperationsDryRun:      "Preview commands without applying changes by default",
+	KeyOperationsAtomic:      "Roll back every operation of a command when one fails",
+	KeyOperationsMaxParallel: "Maximum number of parallel operations (0 = number of CPUs)",
+
+	KeyPackagesSortBy:        "Default sort order of list: name, links, date",
+	KeyPackagesAutoDiscover:  "Scan the package directory for new packages",
+	KeyPackagesValidateNames: "Validate package names",
+
+	KeyDoctorAutoFix:           "Fix issues found by doctor when possible",
+	KeyDoctorCheckManifest:     "Check the integrity of the manifest",
+	KeyDoctorCheckBrokenLinks:  "Check for broken links",
+	KeyDoctorCheckOrphaned:     "Check for orphaned links",
+	KeyDoctorCheckPermissions:  "Check file permissions",
+	KeyDoctorOrphanedThreshold: "Orphaned links tolerated before health is a warning",
+	KeyDoctorBrokenThreshold:   "Broken links tolerated before health is an error",
+
+	"update.check_on_startup":   "Check for new versions at startup",
+	"update.check_frequency":    "Hours between version checks (0 = always, -1 = never)",
+	"update.package_manager":    "Package manager used by upgrade: auto, brew, apt, yum, pacman, manual",
+	"update.repository":         "GitHub repository releases are read from",
+	"update.include_prerelease": "Offer pre-release versions",
+
+	KeyGitTimeout:         "Maximum duration of a git network operation, e.g. 2m (empty = no limit)",
+	KeyGitProxy:           "Proxy URL for git network traffic",
+	KeyGitInsecureSkipTLS: "Skip TLS certificate verification for HTTPS remotes",
+	KeyGitMirrors:         "Fallback repository URLs tried in order when cloning fails",
+
+	KeySecretsProvider:  "Source of template secrets: env, pass, op",
+	KeySecretsEnvPrefix: "Prefix of the environment variables read by the env provider",
+	KeySecretsVault:     "1Password vault of the op provider",
+
+	KeyObservabilityPrometheusTextfile:    "File rewritten with Prometheus metrics for the textfile collector",
+	KeyObservabilityPrometheusPushgateway: "Prometheus Pushgateway URL metrics are pushed to",
+	KeyObservabilityPrometheusJob:         "Job name metrics are pushed under",
+	KeyObservabilityOTLPEndpoint:          "OTLP/HTTP collector URL metrics and traces are sent to",
+	KeyObservabilityOTLPHeaders:           "Headers added to OTLP requests, as name=value",
+	KeyObservabilityServiceName:           "Service name reported to the OTLP collector",
+	KeyObservabilityTimeout:               "Maximum duration of exporting when a command finishes",
+
+	"experimental.flags": "Names of the enabled experimental features",
+}
+
+// Schema returns the configuration keys, sorted by key. Their types and
+// defaults are read from ExtendedConfig and DefaultExtended.
+func Schema() []KeySpec {
+	var keys []KeySpec
+	walkConfig(reflect.ValueOf(DefaultExtended()).Elem(), "", func(key string, value reflect.Value) {
+		keys = append(keys, KeySpec{
+			Key:         key,
+			Type:        keyType(value.Type()),
+			Default:     value.Interface(),
+			Description: keyDescriptions[key],
+		})
+	})
+	slices.SortFunc(keys, func(a, b KeySpec) int { return strings.Compare(a.Key, b.Key) })
+	return keys
+}
+
+// LookupKey returns the description of key, which may be an entry of a map
+// key such as "targets.vim": entries are strings, unset by default.
+func LookupKey(key string) (KeySpec, bool) {
+	schema := Schema()
+	for _, spec := range schema {
+		if spec.Key == key {
+			return spec, true
+		}
+	}
+	for _, spec := range schema {
+		if name, ok := strings.CutPrefix(key, spec.Key+"."); ok && spec.Type == KeyTypeMap && name != "" {
+			return KeySpec{Key: key, Type: KeyTypeString, Default: "", Description: spec.Description}, true
+		}
+	}
+	return KeySpec{}, false
+}
+
+// Value returns the value of key in cfg. An entry of a map key such as
+// "targets.vim" has the value of the entry, or an empty string if unset.
+func (c *ExtendedConfig) Value(key string) (any, error) {
+	if _, ok := LookupKey(key); !ok {
+		return nil, fmt.Errorf("unknown config key: %s", key)
+	}
+
+	var value any
+	walkConfig(reflect.ValueOf(c).Elem(), "", func(path string, field reflect.Value) {
+		if path == key {
+			value = field.Interface()
+		} else if name, ok := strings.CutPrefix(key, path+"."); ok && field.Kind() == reflect.Map {
+			entries, _ := field.Interface().(map[string]string)
+			value = entries[name]
+		}
+	})
+	return value, nil
+}
+
+// field returns the settable field of c holding key. For an entry of a
+// map key such as "targets.vim", it returns the map and the entry name.
+func (c *ExtendedConfig) field(key string) (reflect.Value, string, bool) {
+	var found reflect.Value
+	var entry string
+	walkConfig(reflect.ValueOf(c).Elem(), "", func(path string, field reflect.Value) {
+		if path == key {
+			found = field
+		} else if name, ok := strings.CutPrefix(key, path+"."); ok && name != "" && field.Kind() == reflect.Map {
+			found, entry = field, name
+		}
+	})
+	return found, entry, found.IsValid()
+}
+
+// walkConfig calls visit with the dotted key and value of each field of the
+// configuration struct v that is not itself a section.
+func walkConfig(v reflect.Value, prefix string, visit func(key string, value reflect.Value)) {
+	t := v.Type()
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("mapstructure"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		key := prefix + name
+		if field := v.Field(i); field.Kind() == reflect.Struct {
+			walkConfig(field, key+".", visit)
+		} else {
+			visit(key, field)
+		}
+	}
+}
+
+// keyType returns the KeyType of values of type t.
+func keyType(t reflect.Type) KeyType {
+	switch t.Kind() {
+	case reflect.Bool:
+		return KeyTypeBool
+	case reflect.Int, reflect.Int64:
+		return KeyTypeInt
+	case reflect.Slice:
+		return KeyTypeList
+	case reflect.Map:
+		return KeyTypeMap
+	default:
+		return KeyTypeString
+	}
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchema_DescribesEveryKey(t *testing.T) {
+	schema := Schema()
+	require.NotEmpty(t, schema)
+
+	keys := make(map[string]bool, len(schema))
+	for _, spec := range schema {
+		keys[spec.Key] = true
+		assert.NotEmpty(t, spec.Description, "key %s has no description", spec.Key)
+	}
+	for key := range keyDescriptions {
+		assert.True(t, keys[key], "description of unknown key %s", key)
+	}
+}
+
+func TestSchema_TypesAndDefaults(t *testing.T) {
+	specs := make(map[string]KeySpec)
+	for _, spec := range Schema() {
+		specs[spec.Key] = spec
+	}
+
+	assert.Equal(t, KeySpec{Key: KeyLogLevel, Type: KeyTypeString, Default: "INFO", Description: keyDescriptions[KeyLogLevel]}, specs[KeyLogLevel])
+	assert.Equal(t, KeyTypeBool, specs[KeySymlinkFolding].Type)
+	assert.Equal(t, true, specs[KeySymlinkFolding].Default)
+	assert.Equal(t, KeyTypeInt, specs[KeyOutputVerbosity].Type)
+	assert.Equal(t, 1, specs[KeyOutputVerbosity].Default)
+	assert.Equal(t, KeyTypeList, specs[KeyGitMirrors].Type)
+	assert.Equal(t, KeyTypeMap, specs["targets"].Type)
+	assert.Equal(t, KeyTypeMap, specs[KeySymlinkPolicies].Type)
+	assert.NotContains(t, specs, "symlinks", "sections are not keys")
+}
+
+func TestLookupKey(t *testing.T) {
+	spec, ok := LookupKey("targets.vim")
+	require.True(t, ok)
+	assert.Equal(t, KeySpec{Key: "targets.vim", Type: KeyTypeString, Default: "", Description: keyDescriptions["targets"]}, spec)
+
+	_, ok = LookupKey("targets.")
+	assert.False(t, ok)
+	_, ok = LookupKey("logging.level.extra")
+	assert.False(t, ok)
+	_, ok = LookupKey("unknown.key")
+	assert.False(t, ok)
+}
+
+func TestExtendedConfig_Value(t *testing.T) {
+	cfg := DefaultExtended()
+	cfg.Logging.Level = "DEBUG"
+	cfg.Targets = map[string]string{"vim": "/home/user/.config"}
+
+	value, err := cfg.Value(KeyLogLevel)
+	require.NoError(t, err)
+	assert.Equal(t, "DEBUG", value)
+
+	value, err = cfg.Value(KeyOutputWidth)
+	require.NoError(t, err)
+	assert.Equal(t, 0, value)
+
+	value, err = cfg.Value("targets.vim")
+	require.NoError(t, err)
+	assert.Equal(t, "/home/user/.config", value)
+
+	value, err = cfg.Value("targets.zsh")
+	require.NoError(t, err)
+	assert.Equal(t, "", value)
+
+	_, err = cfg.Value("unknown.key")
+	assert.ErrorContains(t, err, "unknown config key: unknown.key")
+}
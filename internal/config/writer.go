@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
 
 	"github.com/jamesainslie/dot/internal/domain"
@@ -51,13 +53,104 @@ func (w *Writer) WriteDefault(opts WriteOptions) error {
 }
 
 // Update updates specific value in configuration file.
+// Deprecated keys update the key replacing them.
 func (w *Writer) Update(key string, value interface{}) error {
+	key, _ = CanonicalKey(key)
+	return w.modify(func(cfg *ExtendedConfig) error {
+		if err := w.setValue(cfg, key, value); err != nil {
+			return fmt.Errorf("set value: %w", err)
+		}
+		return nil
+	})
+}
+
+// Unset resets key in the configuration file to its default value. An
+// entry of a map key, such as "targets.vim", is removed.
+func (w *Writer) Unset(key string) error {
+	key, _ = CanonicalKey(key)
+	spec, ok := LookupKey(key)
+	if !ok {
+		return fmt.Errorf("unknown config key: %s", key)
+	}
+	return w.modify(func(cfg *ExtendedConfig) error {
+		field, entry, _ := cfg.field(key)
+		if entry != "" {
+			if !field.IsNil() {
+				field.SetMapIndex(reflect.ValueOf(entry), reflect.Value{})
+			}
+			return nil
+		}
+		field.Set(reflect.ValueOf(spec.Default))
+		return nil
+	})
+}
+
+// Add appends value to the list key in the configuration file. A value
+// already in the list is not added again.
+func (w *Writer) Add(key, value string) error {
+	key, _ = CanonicalKey(key)
+	return w.modifyList(key, func(list []string) ([]string, error) {
+		if slices.Contains(list, value) {
+			return list, nil
+		}
+		return append(list, value), nil
+	})
+}
+
+// Remove removes every occurrence of value from the list key in the
+// configuration file.
+func (w *Writer) Remove(key, value string) error {
+	key, _ = CanonicalKey(key)
+	return w.modifyList(key, func(list []string) ([]string, error) {
+		if !slices.Contains(list, value) {
+			return nil, fmt.Errorf("%s does not contain %q", key, value)
+		}
+		return slices.DeleteFunc(list, func(v string) bool { return v == value }), nil
+	})
+}
+
+// modifyList applies change to the value of the list key.
+func (w *Writer) modifyList(key string, change func([]string) ([]string, error)) error {
+	spec, ok := LookupKey(key)
+	if !ok {
+		return fmt.Errorf("unknown config key: %s", key)
+	}
+	if spec.Type != KeyTypeList {
+		return fmt.Errorf("%s is not a list (type %s)", key, spec.Type)
+	}
+	return w.modify(func(cfg *ExtendedConfig) error {
+		field, _, _ := cfg.field(key)
+		list, err := change(slices.Clone(field.Interface().([]string)))
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(list))
+		return nil
+	})
+}
+
+// SetFeature enables or disables the experimental feature called name in
+// the configuration file.
+func (w *Writer) SetFeature(name string, enabled bool) error {
+	return w.modify(func(cfg *ExtendedConfig) error {
+		if enabled {
+			return cfg.Experimental.Enable(name)
+		}
+		return cfg.Experimental.Disable(name)
+	})
+}
+
+// modify applies change to the configuration file, creating it with
+// defaults when missing, and writes the result back after validation.
+func (w *Writer) modify(change func(cfg *ExtendedConfig) error) error {
 	// Load existing config
 	var cfg *ExtendedConfig
 	var err error
 
 	if fileExists(w.path) {
-		cfg, err = LoadExtendedFromFile(w.path)
+		// Included files are left alone, so their values are not copied
+		// into this file
+		cfg, _, err = loadExtendedFromFile(w.path, false)
 		if err != nil {
 			return fmt.Errorf("load existing config: %w", err)
 		}
@@ -66,9 +159,8 @@ func (w *Writer) Update(key string, value interface{}) error {
 		cfg = DefaultExtended()
 	}
 
-	// Update value
-	if err := w.setValue(cfg, key, value); err != nil {
-		return fmt.Errorf("set value: %w", err)
+	if err := change(cfg); err != nil {
+		return err
 	}
 
 	// Validate
@@ -76,12 +168,61 @@ func (w *Writer) Update(key string, value interface{}) error {
 		return err
 	}
 
-	// Write back
-	opts := WriteOptions{
-		Format:          w.DetectFormat(),
-		IncludeComments: false,
+	return w.rewrite(cfg)
+}
+
+// rewrite writes cfg to the configuration file. An existing YAML file is
+// updated in place, keeping its comments and the order of its keys; other
+// files are replaced.
+func (w *Writer) rewrite(cfg *ExtendedConfig) error {
+	format := w.DetectFormat()
+	if format != "yaml" || !fileExists(w.path) {
+		return w.Write(cfg, WriteOptions{Format: format})
+	}
+
+	original, err := os.ReadFile(w.path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
 	}
-	return w.Write(cfg, opts)
+	data, err := updateYAML(original, cfg)
+	if err != nil {
+		return fmt.Errorf("marshal config: %w", err)
+	}
+	if err := os.WriteFile(w.path, data, domain.PermUserRW); err != nil {
+		return fmt.Errorf("write config file: %w", err)
+	}
+	return nil
+}
+
+// Migrate rewrites the configuration file with deprecated keys replaced,
+// keeping the original as a ".bak" file next to it. It returns the
+// deprecated keys found; the file is left untouched when there are none.
+func (w *Writer) Migrate() ([]Deprecation, error) {
+	if !fileExists(w.path) {
+		return nil, fmt.Errorf("config file not found: %s", w.path)
+	}
+
+	cfg, load, err := loadExtendedFromFile(w.path, false)
+	if err != nil {
+		return nil, fmt.Errorf("load existing config: %w", err)
+	}
+	deprecations := load.deprecations
+	if len(deprecations) == 0 {
+		return nil, nil
+	}
+
+	original, err := os.ReadFile(w.path)
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+	if err := os.WriteFile(w.path+".bak", original, domain.PermUserRW); err != nil {
+		return nil, fmt.Errorf("back up config file: %w", err)
+	}
+
+	if err := w.rewrite(cfg); err != nil {
+		return nil, err
+	}
+	return deprecations, nil
 }
 
 // WriteOptions controls configuration file output.
@@ -139,6 +280,8 @@ func (w *Writer) setValue(cfg *ExtendedConfig, key string, value interface{}) er
 	switch section {
 	case "directories":
 		return setDirectoriesValue(&cfg.Directories, field, value)
+	case "targets":
+		return setTargetsValue(cfg, field, value)
 	case "logging":
 		return setLoggingValue(&cfg.Logging, field, value)
 	case "symlinks":
@@ -155,6 +298,12 @@ func (w *Writer) setValue(cfg *ExtendedConfig, key string, value interface{}) er
 		return setPackagesValue(&cfg.Packages, field, value)
 	case "doctor":
 		return setDoctorValue(&cfg.Doctor, field, value)
+	case "git":
+		return setGitValue(&cfg.Git, field, value)
+	case "secrets":
+		return setSecretsValue(&cfg.Secrets, field, value)
+	case "observability":
+		return setObservabilityValue(&cfg.Observability, field, value)
 	case "experimental":
 		return setExperimentalValue(&cfg.Experimental, field, value)
 	default:
@@ -182,6 +331,24 @@ func setDirectoriesValue(cfg *DirectoriesConfig, field string, value interface{}
 	return nil
 }
 
+// setTargetsValue sets the target directory of package pkg. An empty
+// value removes the entry.
+func setTargetsValue(cfg *ExtendedConfig, pkg string, value interface{}) error {
+	dir, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("targets.%s: value must be string", pkg)
+	}
+	if dir == "" {
+		delete(cfg.Targets, pkg)
+		return nil
+	}
+	if cfg.Targets == nil {
+		cfg.Targets = make(map[string]string)
+	}
+	cfg.Targets[pkg] = dir
+	return nil
+}
+
 func setLoggingValue(cfg *LoggingConfig, field string, value interface{}) error {
 	switch field {
 	case "level", "format", "destination", "file":
@@ -209,7 +376,7 @@ func setLoggingValue(cfg *LoggingConfig, field string, value interface{}) error
 
 func setSymlinksValue(cfg *SymlinksConfig, field string, value interface{}) error {
 	switch field {
-	case "mode", "backup_suffix":
+	case "mode", "backup_suffix", "on_conflict":
 		str, ok := value.(string)
 		if !ok {
 			return fmt.Errorf("symlinks.%s: value must be string", field)
@@ -220,6 +387,8 @@ func setSymlinksValue(cfg *SymlinksConfig, field string, value interface{}) erro
 			cfg.Mode = str
 		case "backup_suffix":
 			cfg.BackupSuffix = str
+		case "on_conflict":
+			cfg.OnConflict = str
 		}
 
 	case "folding", "overwrite", "backup":
@@ -246,14 +415,18 @@ func setSymlinksValue(cfg *SymlinksConfig, field string, value interface{}) erro
 
 func setIgnoreValue(cfg *IgnoreConfig, field string, value interface{}) error {
 	switch field {
-	case "use_defaults":
+	case "use_defaults", "scan_defaults":
 		b, ok := value.(bool)
 		if !ok {
 			return fmt.Errorf("ignore.%s: value must be bool", field)
 		}
-		cfg.UseDefaults = b
+		if field == "use_defaults" {
+			cfg.UseDefaults = b
+		} else {
+			cfg.ScanDefaults = b
+		}
 
-	case "patterns", "overrides":
+	case "patterns", "overrides", "scan_patterns":
 		// Accept both []string and string
 		var arr []string
 		switch v := value.(type) {
@@ -274,8 +447,17 @@ func setIgnoreValue(cfg *IgnoreConfig, field string, value interface{}) error {
 			cfg.Patterns = arr
 		case "overrides":
 			cfg.Overrides = arr
+		case "scan_patterns":
+			cfg.ScanPatterns = arr
 		}
 
+	case "special_files":
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("ignore.%s: value must be string", field)
+		}
+		cfg.SpecialFiles = s
+
 	default:
 		return fmt.Errorf("unknown field: ignore.%s", field)
 	}
@@ -417,6 +599,25 @@ func setPackagesValue(cfg *PackagesConfig, field string, value interface{}) erro
 }
 
 func setDoctorValue(cfg *DoctorConfig, field string, value interface{}) error {
+	switch field {
+	case "orphaned_threshold", "broken_threshold":
+		var i int
+		switch v := value.(type) {
+		case int:
+			i = v
+		case float64:
+			i = int(v)
+		default:
+			return fmt.Errorf("doctor.%s: value must be int", field)
+		}
+		if field == "orphaned_threshold" {
+			cfg.OrphanedThreshold = i
+		} else {
+			cfg.BrokenThreshold = i
+		}
+		return nil
+	}
+
 	b, ok := value.(bool)
 	if !ok {
 		return fmt.Errorf("doctor.%s: value must be bool", field)
@@ -440,17 +641,145 @@ func setDoctorValue(cfg *DoctorConfig, field string, value interface{}) error {
 	return nil
 }
 
-func setExperimentalValue(cfg *ExperimentalConfig, field string, value interface{}) error {
-	b, ok := value.(bool)
+func setGitValue(cfg *GitConfig, field string, value interface{}) error {
+	switch field {
+	case "timeout", "proxy":
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("git.%s: value must be string", field)
+		}
+
+		switch field {
+		case "timeout":
+			cfg.Timeout = str
+		case "proxy":
+			cfg.Proxy = str
+		}
+
+	case "insecure_skip_tls":
+		b, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("git.%s: value must be bool", field)
+		}
+		cfg.InsecureSkipTLS = b
+
+	case "mirrors":
+		switch v := value.(type) {
+		case []string:
+			cfg.Mirrors = v
+		case string:
+			// Split comma-separated string
+			cfg.Mirrors = strings.Split(v, ",")
+			for i := range cfg.Mirrors {
+				cfg.Mirrors[i] = strings.TrimSpace(cfg.Mirrors[i])
+			}
+		default:
+			return fmt.Errorf("git.%s: value must be []string or string", field)
+		}
+
+	default:
+		return fmt.Errorf("unknown field: git.%s", field)
+	}
+
+	return nil
+}
+
+func setSecretsValue(cfg *SecretsConfig, field string, value interface{}) error {
+	str, ok := value.(string)
 	if !ok {
-		return fmt.Errorf("experimental.%s: value must be bool", field)
+		return fmt.Errorf("secrets.%s: value must be string", field)
 	}
 
 	switch field {
-	case "parallel":
-		cfg.Parallel = b
-	case "profiling":
-		cfg.Profiling = b
+	case "provider":
+		cfg.Provider = str
+	case "env_prefix":
+		cfg.EnvPrefix = str
+	case "vault":
+		cfg.Vault = str
+	default:
+		return fmt.Errorf("unknown field: secrets.%s", field)
+	}
+
+	return nil
+}
+
+func setObservabilityValue(cfg *ObservabilityConfig, field string, value interface{}) error {
+	if field == "otlp_headers" {
+		switch v := value.(type) {
+		case []string:
+			cfg.OTLPHeaders = v
+		case string:
+			// Split comma-separated string
+			cfg.OTLPHeaders = strings.Split(v, ",")
+			for i := range cfg.OTLPHeaders {
+				cfg.OTLPHeaders[i] = strings.TrimSpace(cfg.OTLPHeaders[i])
+			}
+		default:
+			return fmt.Errorf("observability.%s: value must be []string or string", field)
+		}
+		return nil
+	}
+
+	str, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("observability.%s: value must be string", field)
+	}
+
+	switch field {
+	case "prometheus_textfile":
+		cfg.PrometheusTextfile = str
+	case "prometheus_pushgateway":
+		cfg.PrometheusPushgateway = str
+	case "prometheus_job":
+		cfg.PrometheusJob = str
+	case "otlp_endpoint":
+		cfg.OTLPEndpoint = str
+	case "service_name":
+		cfg.ServiceName = str
+	case "timeout":
+		cfg.Timeout = str
+	default:
+		return fmt.Errorf("unknown field: observability.%s", field)
+	}
+
+	return nil
+}
+
+func setExperimentalValue(cfg *ExperimentalConfig, field string, value interface{}) error {
+	switch field {
+	case "flags":
+		var flags []string
+		switch v := value.(type) {
+		case []string:
+			flags = v
+		case string:
+			for _, flag := range strings.Split(v, ",") {
+				if flag = strings.TrimSpace(flag); flag != "" {
+					flags = append(flags, flag)
+				}
+			}
+		default:
+			return fmt.Errorf("experimental.flags: value must be []string or string")
+		}
+		cfg.Flags = nil
+		for _, flag := range flags {
+			if err := cfg.Enable(flag); err != nil {
+				return fmt.Errorf("experimental.flags: %w", err)
+			}
+		}
+
+	case "parallel", "profiling":
+		// Boolean keys of the features registered before experimental.flags
+		b, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("experimental.%s: value must be bool", field)
+		}
+		if b {
+			return cfg.Enable(field)
+		}
+		return cfg.Disable(field)
+
 	default:
 		return fmt.Errorf("unknown field: experimental.%s", field)
 	}
@@ -3,6 +3,7 @@ package config_test
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/jamesainslie/dot/internal/config"
@@ -123,6 +124,102 @@ func TestWriter_Update(t *testing.T) {
 	assert.Equal(t, "/new/dotfiles", loaded.Directories.Package)
 }
 
+func TestWriter_UpdateTargets(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	writer := config.NewWriter(configPath)
+	require.NoError(t, writer.WriteDefault(config.WriteOptions{Format: "yaml", IncludeComments: true}))
+
+	require.NoError(t, writer.Update("targets.dot-bin", "/home/user/.local/bin"))
+	require.NoError(t, writer.Update("targets.etc", "/etc"))
+	loaded, err := config.LoadExtendedFromFile(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"dot-bin": "/home/user/.local/bin", "etc": "/etc"}, loaded.Targets)
+
+	// An empty value removes the entry
+	require.NoError(t, writer.Update("targets.etc", ""))
+	loaded, err = config.LoadExtendedFromFile(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"dot-bin": "/home/user/.local/bin"}, loaded.Targets)
+}
+
+func TestWriter_Unset(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	writer := config.NewWriter(configPath)
+	require.NoError(t, writer.Update("logging.level", "DEBUG"))
+	require.NoError(t, writer.Update("targets.etc", "/etc"))
+
+	require.NoError(t, writer.Unset("logging.level"))
+	require.NoError(t, writer.Unset("targets.etc"))
+	require.NoError(t, writer.Unset("targets.missing"))
+	loaded, err := config.LoadExtendedFromFile(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, config.DefaultExtended().Logging.Level, loaded.Logging.Level)
+	assert.Empty(t, loaded.Targets)
+
+	assert.ErrorContains(t, writer.Unset("logging.volume"), "unknown config key")
+}
+
+func TestWriter_AddRemove(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	writer := config.NewWriter(configPath)
+
+	require.NoError(t, writer.Add("ignore.patterns", "*.log"))
+	require.NoError(t, writer.Add("ignore.patterns", "*.tmp"))
+	require.NoError(t, writer.Add("ignore.patterns", "*.log"))
+	loaded, err := config.LoadExtendedFromFile(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"*.log", "*.tmp"}, loaded.Ignore.Patterns)
+
+	require.NoError(t, writer.Remove("ignore.patterns", "*.log"))
+	loaded, err = config.LoadExtendedFromFile(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"*.tmp"}, loaded.Ignore.Patterns)
+
+	assert.ErrorContains(t, writer.Remove("ignore.patterns", "*.log"), `does not contain "*.log"`)
+	assert.ErrorContains(t, writer.Add("logging.level", "DEBUG"), "not a list")
+	assert.ErrorContains(t, writer.Add("ignore.patterns", "[a"), "invalid glob pattern")
+}
+
+func TestWriter_UpdateKeepsComments(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	original := `# Machine configuration
+
+logging:
+    # Raised while debugging
+    level: INFO # was WARN
+ignore:
+    patterns:
+        # Editor files
+        - '*.swp'
+directories:
+    stow: ~/dotfiles
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(original), 0600))
+
+	writer := config.NewWriter(configPath)
+	require.NoError(t, writer.Update("logging.level", "DEBUG"))
+
+	content, err := os.ReadFile(configPath)
+	require.NoError(t, err)
+	updated := string(content)
+	assert.True(t, strings.HasPrefix(updated, "# Machine configuration\n\nlogging:\n    # Raised while debugging\n    level: DEBUG # was WARN\n"), updated)
+	assert.Contains(t, updated, "    patterns:\n        # Editor files\n        - '*.swp'\n")
+	assert.Less(t, strings.Index(updated, "ignore:"), strings.Index(updated, "directories:"), "keys keep their order")
+	assert.Contains(t, updated, "    package: ~/dotfiles\n", "deprecated keys are renamed")
+	assert.NotContains(t, updated, "stow")
+
+	loaded, err := config.LoadExtendedFromFile(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, "DEBUG", loaded.Logging.Level)
+
+	// Removed entries are removed from the file
+	require.NoError(t, writer.Update("targets.etc", "/etc"))
+	require.NoError(t, writer.Unset("targets.etc"))
+	content, err = os.ReadFile(configPath)
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "etc")
+}
+
 func TestWriter_UpdateNonExistentFile(t *testing.T) {
 	tmpDir := t.TempDir()
 	configPath := filepath.Join(tmpDir, "config.yaml")
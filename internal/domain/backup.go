@@ -0,0 +1,276 @@
+package domain
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Backup store layout. Backups are stored content-addressed so that
+// backing up identical content repeatedly keeps a single copy.
+const (
+	// BackupObjectsDir holds backup contents named by their SHA-256 hash.
+	BackupObjectsDir = "objects"
+
+	// BackupIndexFile records which paths were backed up and object reference counts.
+	BackupIndexFile = "index.json"
+)
+
+// BackupIndex tracks backed up paths and reference counts for stored objects.
+type BackupIndex struct {
+	Objects map[string]BackupObject `json:"objects"`
+	Entries []BackupEntry           `json:"entries"`
+}
+
+// BackupObject describes a stored backup object.
+type BackupObject struct {
+	Refs int   `json:"refs"`
+	Size int64 `json:"size"`
+}
+
+// BackupEntry records a single backup of a file. Path is the original
+// path of the file and PlanID the plan that displaced it, empty for
+// backups taken outside a plan or before plans were recorded. Mode is the
+// permission bits of the file, restored with its content; it is zero for
+// backups recorded before modes were kept.
+type BackupEntry struct {
+	Path       string      `json:"path"`
+	Hash       string      `json:"hash"`
+	PlanID     string      `json:"plan_id,omitempty"`
+	Mode       os.FileMode `json:"mode,omitempty"`
+	BackedUpAt time.Time   `json:"backed_up_at"`
+}
+
+// Entry returns the most recent backup of path taken by the plan with
+// planID.
+func (i BackupIndex) Entry(planID, path string) (BackupEntry, bool) {
+	for j := len(i.Entries) - 1; j >= 0; j-- {
+		if entry := i.Entries[j]; entry.PlanID == planID && entry.Path == path {
+			return entry, true
+		}
+	}
+	return BackupEntry{}, false
+}
+
+// match returns the index of the most recent entry for path with the
+// given hash taken by the plan with planID, or by any plan when there is
+// none, or -1.
+func (i BackupIndex) match(path, hash, planID string) int {
+	match := -1
+	for j := len(i.Entries) - 1; j >= 0; j-- {
+		entry := i.Entries[j]
+		if entry.Path != path || entry.Hash != hash {
+			continue
+		}
+		if entry.PlanID == planID {
+			return j
+		}
+		if match < 0 {
+			match = j
+		}
+	}
+	return match
+}
+
+// planIDKey is the context key of the ID of the plan being executed.
+type planIDKey struct{}
+
+// WithPlanID returns a context carrying the ID of the plan whose
+// operations run under it, so that backups they take are tagged with it.
+func WithPlanID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, planIDKey{}, id)
+}
+
+// PlanIDFromContext returns the plan ID set by WithPlanID, or "".
+func PlanIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(planIDKey{}).(string)
+	return id
+}
+
+// backupIndexMu serialises index updates from operations executed in parallel.
+var backupIndexMu sync.Mutex
+
+// HashContent returns the hex-encoded SHA-256 hash of data.
+func HashContent(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// BackupObjectPath returns the path of the object with the given hash in the backup store.
+func BackupObjectPath(backupDir, hash string) string {
+	if len(hash) < 2 {
+		return filepath.Join(backupDir, BackupObjectsDir, hash)
+	}
+	return filepath.Join(backupDir, BackupObjectsDir, hash[:2], hash)
+}
+
+// LoadBackupIndex reads the backup index from backupDir.
+// Returns an empty index if none exists yet.
+func LoadBackupIndex(ctx context.Context, fs FS, backupDir string) (BackupIndex, error) {
+	index := BackupIndex{
+		Objects: make(map[string]BackupObject),
+		Entries: []BackupEntry{},
+	}
+
+	path := filepath.Join(backupDir, BackupIndexFile)
+	if !fs.Exists(ctx, path) {
+		return index, nil
+	}
+
+	data, err := fs.ReadFile(ctx, path)
+	if err != nil {
+		return BackupIndex{}, fmt.Errorf("read backup index: %w", err)
+	}
+	if err := json.Unmarshal(data, &index); err != nil {
+		return BackupIndex{}, fmt.Errorf("parse backup index: %w", err)
+	}
+	if index.Objects == nil {
+		index.Objects = make(map[string]BackupObject)
+	}
+
+	return index, nil
+}
+
+// SaveBackupIndex writes the backup index to backupDir.
+func SaveBackupIndex(ctx context.Context, fs FS, backupDir string, index BackupIndex) error {
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal backup index: %w", err)
+	}
+	if err := fs.MkdirAll(ctx, backupDir, DefaultDirPerms); err != nil {
+		return fmt.Errorf("create backup directory: %w", err)
+	}
+	return fs.WriteFile(ctx, filepath.Join(backupDir, BackupIndexFile), data, DefaultFilePerms)
+}
+
+// storeBackup writes data into the backup store and records an entry for path,
+// tagged with planID and the permission bits mode of the file. The object is
+// only written if no object with the same content exists.
+func storeBackup(ctx context.Context, fs FS, backupDir, path, planID string, data []byte, mode os.FileMode) (string, error) {
+	backupIndexMu.Lock()
+	defer backupIndexMu.Unlock()
+
+	index, err := LoadBackupIndex(ctx, fs, backupDir)
+	if err != nil {
+		return "", err
+	}
+
+	hash := HashContent(data)
+	objectPath := BackupObjectPath(backupDir, hash)
+	if !fs.Exists(ctx, objectPath) {
+		// Objects keep the content of files that may have been private, and
+		// the mode they had is only restored from the entry
+		objectDir := filepath.Dir(objectPath)
+		if err := fs.MkdirAll(ctx, objectDir, SecureDirPerms); err != nil {
+			return "", fmt.Errorf("create backup object directory: %w", err)
+		}
+		if err := secureObjectDirs(ctx, fs, backupDir, objectDir); err != nil {
+			return "", fmt.Errorf("secure backup object directory: %w", err)
+		}
+		if err := fs.WriteFile(ctx, objectPath, data, SecureFilePerms); err != nil {
+			return "", fmt.Errorf("write backup object: %w", err)
+		}
+	}
+
+	object := index.Objects[hash]
+	object.Refs++
+	object.Size = int64(len(data))
+	index.Objects[hash] = object
+	index.Entries = append(index.Entries, BackupEntry{
+		Path:       path,
+		Hash:       hash,
+		PlanID:     planID,
+		Mode:       mode.Perm(),
+		BackedUpAt: time.Now(),
+	})
+
+	if err := SaveBackupIndex(ctx, fs, backupDir, index); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// secureObjectDirs makes the objects directory of backupDir and the
+// directory objectDir below it accessible only by their owner, as stores
+// written by earlier versions left them readable by everyone. MkdirAll only
+// sets the mode of directories it creates, subject to the umask.
+func secureObjectDirs(ctx context.Context, fs FS, backupDir, objectDir string) error {
+	meta, ok := fs.(MetadataFS)
+	if !ok {
+		return nil
+	}
+	for _, dir := range []string{filepath.Join(backupDir, BackupObjectsDir), objectDir} {
+		if err := meta.Chmod(ctx, dir, SecureDirPerms); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseBackup removes the most recent entry for path with the given hash
+// taken by the plan with planID, or by any plan when there is none, and
+// deletes the object once no entries reference it.
+func releaseBackup(ctx context.Context, fs FS, backupDir, path, hash, planID string) error {
+	backupIndexMu.Lock()
+	defer backupIndexMu.Unlock()
+
+	index, err := LoadBackupIndex(ctx, fs, backupDir)
+	if err != nil {
+		return err
+	}
+
+	if match := index.match(path, hash, planID); match >= 0 {
+		index.Entries = append(index.Entries[:match], index.Entries[match+1:]...)
+	}
+
+	object, exists := index.Objects[hash]
+	if exists {
+		object.Refs--
+		if object.Refs <= 0 {
+			delete(index.Objects, hash)
+			if err := fs.Remove(ctx, BackupObjectPath(backupDir, hash)); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("remove backup object: %w", err)
+			}
+		} else {
+			index.Objects[hash] = object
+		}
+	}
+
+	return SaveBackupIndex(ctx, fs, backupDir, index)
+}
+
+// backupMode returns the permission bits recorded for the backup of path
+// that releaseBackup would release, or DefaultFilePerms when the backup
+// has no mode recorded.
+func backupMode(ctx context.Context, fs FS, backupDir, path, hash, planID string) (os.FileMode, error) {
+	backupIndexMu.Lock()
+	defer backupIndexMu.Unlock()
+
+	index, err := LoadBackupIndex(ctx, fs, backupDir)
+	if err != nil {
+		return 0, err
+	}
+	if match := index.match(path, hash, planID); match >= 0 && index.Entries[match].Mode != 0 {
+		return index.Entries[match].Mode, nil
+	}
+	return DefaultFilePerms, nil
+}
+
+// fileMode returns the permission bits of the file at path, or
+// DefaultFilePerms when fs reports none.
+func fileMode(ctx context.Context, fs FS, path string) (os.FileMode, error) {
+	info, err := fs.Stat(ctx, path)
+	if err != nil {
+		return 0, err
+	}
+	if mode := info.Mode().Perm(); mode != 0 {
+		return mode, nil
+	}
+	return DefaultFilePerms, nil
+}
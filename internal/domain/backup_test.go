@@ -0,0 +1,297 @@
+package domain_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupObjectPath(t *testing.T) {
+	hash := domain.HashContent([]byte("content"))
+
+	path := domain.BackupObjectPath("/backup", hash)
+
+	assert.Equal(t, "/backup/objects/"+hash[:2]+"/"+hash, path)
+}
+
+func TestLoadBackupIndex_Missing(t *testing.T) {
+	fs := adapters.NewMemFS()
+
+	index, err := domain.LoadBackupIndex(context.Background(), fs, "/backup")
+
+	require.NoError(t, err)
+	assert.Empty(t, index.Objects)
+	assert.Empty(t, index.Entries)
+}
+
+func TestFileStash_Execute(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+	data := []byte("set number\n")
+	require.NoError(t, fs.MkdirAll(ctx, "/home/user", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/home/user/.vimrc", data, 0644))
+	// As left readable by everyone by earlier versions
+	require.NoError(t, fs.MkdirAll(ctx, "/backup/"+domain.BackupObjectsDir, 0755))
+
+	hash := domain.HashContent(data)
+	op := domain.NewFileStash("stash1",
+		domain.MustParsePath("/home/user/.vimrc"),
+		domain.MustParsePath("/backup"),
+		hash)
+
+	require.NoError(t, op.Validate())
+	require.NoError(t, op.Execute(ctx, fs))
+
+	assert.False(t, fs.Exists(ctx, "/home/user/.vimrc"))
+	stored, err := fs.ReadFile(ctx, domain.BackupObjectPath("/backup", hash))
+	require.NoError(t, err)
+	assert.Equal(t, data, stored)
+
+	// The content of the file is kept private whatever its mode was
+	for path, want := range map[string]os.FileMode{
+		domain.BackupObjectPath("/backup", hash):               0600,
+		filepath.Dir(domain.BackupObjectPath("/backup", hash)): 0700,
+		"/backup/" + domain.BackupObjectsDir:                   0700,
+	} {
+		info, err := fs.Stat(ctx, path)
+		require.NoError(t, err)
+		assert.Equal(t, want, info.Mode().Perm(), path)
+	}
+
+	index, err := domain.LoadBackupIndex(ctx, fs, "/backup")
+	require.NoError(t, err)
+	assert.Equal(t, 1, index.Objects[hash].Refs)
+	require.Len(t, index.Entries, 1)
+	assert.Equal(t, "/home/user/.vimrc", index.Entries[0].Path)
+}
+
+func TestFileStash_DeduplicatesIdenticalContent(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+	data := []byte("shared\n")
+	hash := domain.HashContent(data)
+	require.NoError(t, fs.MkdirAll(ctx, "/home/user", 0755))
+
+	for _, name := range []string{"/home/user/.a", "/home/user/.b"} {
+		require.NoError(t, fs.WriteFile(ctx, name, data, 0644))
+		op := domain.NewFileStash(domain.OperationID("stash-"+name),
+			domain.MustParsePath(name), domain.MustParsePath("/backup"), hash)
+		require.NoError(t, op.Execute(ctx, fs))
+	}
+
+	index, err := domain.LoadBackupIndex(ctx, fs, "/backup")
+	require.NoError(t, err)
+	assert.Len(t, index.Objects, 1)
+	assert.Equal(t, 2, index.Objects[hash].Refs)
+	assert.Len(t, index.Entries, 2)
+
+	entries, err := fs.ReadDir(ctx, "/backup/objects/"+hash[:2])
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestFileStash_ExecuteRejectsChangedFile(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+	require.NoError(t, fs.MkdirAll(ctx, "/home/user", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/home/user/.vimrc", []byte("new"), 0644))
+
+	op := domain.NewFileStash("stash1",
+		domain.MustParsePath("/home/user/.vimrc"),
+		domain.MustParsePath("/backup"),
+		domain.HashContent([]byte("old")))
+
+	err := op.Execute(ctx, fs)
+
+	require.Error(t, err)
+	assert.True(t, fs.Exists(ctx, "/home/user/.vimrc"))
+}
+
+func TestFileStash_Rollback(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+	data := []byte("export EDITOR=vim\n")
+	hash := domain.HashContent(data)
+	require.NoError(t, fs.MkdirAll(ctx, "/home/user", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/home/user/.bashrc", data, 0644))
+
+	op := domain.NewFileStash("stash1",
+		domain.MustParsePath("/home/user/.bashrc"),
+		domain.MustParsePath("/backup"),
+		hash)
+	require.NoError(t, op.Execute(ctx, fs))
+
+	require.NoError(t, op.Rollback(ctx, fs))
+
+	restored, err := fs.ReadFile(ctx, "/home/user/.bashrc")
+	require.NoError(t, err)
+	assert.Equal(t, data, restored)
+	assert.False(t, fs.Exists(ctx, domain.BackupObjectPath("/backup", hash)))
+
+	index, err := domain.LoadBackupIndex(ctx, fs, "/backup")
+	require.NoError(t, err)
+	assert.Empty(t, index.Objects)
+	assert.Empty(t, index.Entries)
+}
+
+func TestFileStash_RollbackRestoresMode(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+	data := []byte("#!/bin/sh\necho hi\n")
+	hash := domain.HashContent(data)
+	require.NoError(t, fs.MkdirAll(ctx, "/home/user/bin", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/home/user/bin/hi", data, 0700))
+
+	op := domain.NewFileStash("stash1",
+		domain.MustParsePath("/home/user/bin/hi"), domain.MustParsePath("/backup"), hash)
+	require.NoError(t, op.Execute(ctx, fs))
+
+	index, err := domain.LoadBackupIndex(ctx, fs, "/backup")
+	require.NoError(t, err)
+	require.Len(t, index.Entries, 1)
+	assert.Equal(t, os.FileMode(0700), index.Entries[0].Mode)
+
+	require.NoError(t, op.Rollback(ctx, fs))
+
+	info, err := fs.Stat(ctx, "/home/user/bin/hi")
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+}
+
+func TestFileRestore_ExecuteRestoresMode(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx := domain.WithPlanID(context.Background(), "plan-1")
+	data := []byte("token\n")
+	hash := domain.HashContent(data)
+	require.NoError(t, fs.MkdirAll(ctx, "/home/user", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/home/user/.netrc", data, 0600))
+	stash := domain.NewFileStash("stash1",
+		domain.MustParsePath("/home/user/.netrc"), domain.MustParsePath("/backup"), hash)
+	require.NoError(t, stash.Execute(ctx, fs))
+
+	restore := domain.NewFileRestore("restore1",
+		domain.MustParsePath("/home/user/.netrc"), domain.MustParsePath("/backup"), hash, "plan-1")
+	require.NoError(t, restore.Execute(ctx, fs))
+
+	info, err := fs.Stat(ctx, "/home/user/.netrc")
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestFileStash_RollbackWithoutRecordedMode(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+	data := []byte("legacy\n")
+	hash := domain.HashContent(data)
+	require.NoError(t, fs.MkdirAll(ctx, filepath.Dir(domain.BackupObjectPath("/backup", hash)), 0755))
+	require.NoError(t, fs.WriteFile(ctx, domain.BackupObjectPath("/backup", hash), data, 0644))
+	require.NoError(t, domain.SaveBackupIndex(ctx, fs, "/backup", domain.BackupIndex{
+		Objects: map[string]domain.BackupObject{hash: {Refs: 1, Size: int64(len(data))}},
+		Entries: []domain.BackupEntry{{Path: "/home/user/.legacy", Hash: hash}},
+	}))
+	require.NoError(t, fs.MkdirAll(ctx, "/home/user", 0755))
+
+	op := domain.NewFileStash("stash1",
+		domain.MustParsePath("/home/user/.legacy"), domain.MustParsePath("/backup"), hash)
+	require.NoError(t, op.Rollback(ctx, fs))
+
+	info, err := fs.Stat(ctx, "/home/user/.legacy")
+	require.NoError(t, err)
+	assert.Equal(t, domain.DefaultFilePerms, info.Mode().Perm())
+}
+
+func TestFileStash_RollbackKeepsSharedObject(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+	data := []byte("shared\n")
+	hash := domain.HashContent(data)
+	require.NoError(t, fs.MkdirAll(ctx, "/home/user", 0755))
+
+	var ops []domain.FileStash
+	for _, name := range []string{"/home/user/.a", "/home/user/.b"} {
+		require.NoError(t, fs.WriteFile(ctx, name, data, 0644))
+		op := domain.NewFileStash(domain.OperationID("stash-"+name),
+			domain.MustParsePath(name), domain.MustParsePath("/backup"), hash)
+		require.NoError(t, op.Execute(ctx, fs))
+		ops = append(ops, op)
+	}
+
+	require.NoError(t, ops[1].Rollback(ctx, fs))
+
+	assert.True(t, fs.Exists(ctx, domain.BackupObjectPath("/backup", hash)))
+	index, err := domain.LoadBackupIndex(ctx, fs, "/backup")
+	require.NoError(t, err)
+	assert.Equal(t, 1, index.Objects[hash].Refs)
+	require.Len(t, index.Entries, 1)
+	assert.Equal(t, "/home/user/.a", index.Entries[0].Path)
+}
+
+func TestFileStash_TagsBackupWithPlanID(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+	data := []byte("shared\n")
+	hash := domain.HashContent(data)
+	require.NoError(t, fs.MkdirAll(ctx, "/home/user", 0755))
+
+	for _, planID := range []string{"plan-1", "plan-2"} {
+		require.NoError(t, fs.WriteFile(ctx, "/home/user/.vimrc", data, 0644))
+		op := domain.NewFileStash("stash1",
+			domain.MustParsePath("/home/user/.vimrc"), domain.MustParsePath("/backup"), hash)
+		require.NoError(t, op.Execute(domain.WithPlanID(ctx, planID), fs))
+	}
+
+	index, err := domain.LoadBackupIndex(ctx, fs, "/backup")
+	require.NoError(t, err)
+	entry, ok := index.Entry("plan-1", "/home/user/.vimrc")
+	require.True(t, ok)
+	assert.Equal(t, hash, entry.Hash)
+	assert.Equal(t, "plan-1", entry.PlanID)
+	_, ok = index.Entry("plan-3", "/home/user/.vimrc")
+	assert.False(t, ok)
+
+	// Restoring the backup of one plan keeps the backup of the other
+	restore := domain.NewFileRestore("restore1",
+		domain.MustParsePath("/home/user/.vimrc"), domain.MustParsePath("/backup"), hash, "plan-1")
+	require.NoError(t, restore.Validate())
+	require.NoError(t, restore.Execute(ctx, fs))
+
+	restored, err := fs.ReadFile(ctx, "/home/user/.vimrc")
+	require.NoError(t, err)
+	assert.Equal(t, data, restored)
+	index, err = domain.LoadBackupIndex(ctx, fs, "/backup")
+	require.NoError(t, err)
+	require.Len(t, index.Entries, 1)
+	assert.Equal(t, "plan-2", index.Entries[0].PlanID)
+	assert.Equal(t, 1, index.Objects[hash].Refs)
+}
+
+func TestFileRestore_Rollback(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx := domain.WithPlanID(context.Background(), "plan-1")
+	data := []byte("export EDITOR=vim\n")
+	hash := domain.HashContent(data)
+	require.NoError(t, fs.MkdirAll(ctx, "/home/user", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/home/user/.bashrc", data, 0644))
+	stash := domain.NewFileStash("stash1",
+		domain.MustParsePath("/home/user/.bashrc"), domain.MustParsePath("/backup"), hash)
+	require.NoError(t, stash.Execute(ctx, fs))
+
+	restore := domain.NewFileRestore("restore1",
+		domain.MustParsePath("/home/user/.bashrc"), domain.MustParsePath("/backup"), hash, "plan-1")
+	require.NoError(t, restore.Execute(ctx, fs))
+	assert.False(t, fs.Exists(ctx, domain.BackupObjectPath("/backup", hash)))
+
+	require.NoError(t, restore.Rollback(ctx, fs))
+
+	assert.False(t, fs.Exists(ctx, "/home/user/.bashrc"))
+	index, err := domain.LoadBackupIndex(ctx, fs, "/backup")
+	require.NoError(t, err)
+	_, ok := index.Entry("plan-1", "/home/user/.bashrc")
+	assert.True(t, ok, "the backup is tagged with its plan again")
+}
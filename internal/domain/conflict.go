@@ -16,3 +16,12 @@ type WarningInfo struct {
 	Severity string            `json:"severity"`
 	Context  map[string]string `json:"context,omitempty"`
 }
+
+// ReplacementInfo records an existing file a plan replaces. Path is the
+// file whose content is lost and Replacement the file whose content takes
+// its place, so dry runs can show the difference.
+type ReplacementInfo struct {
+	Path        string `json:"path"`
+	Replacement string `json:"replacement"`
+	Policy      string `json:"policy"`
+}
@@ -2,6 +2,7 @@ package domain_test
 
 import (
 	"context"
+	"os"
 	"testing"
 
 	"github.com/jamesainslie/dot/internal/adapters"
@@ -157,3 +158,19 @@ func TestDirCopy_Dependencies(t *testing.T) {
 	deps := op.Dependencies()
 	assert.Empty(t, deps, "DirCopy should have no dependencies")
 }
+
+func TestDirCopy_RejectsSpecialFiles(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+
+	require.NoError(t, fs.MkdirAll(ctx, "/source/mydir", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/source/mydir/fifo", nil, os.ModeNamedPipe|0644))
+
+	op := domain.NewDirCopy("copy1", domain.MustParsePath("/source/mydir"), domain.MustParsePath("/dest/mydir"))
+
+	err := op.Execute(ctx, fs)
+	var special domain.ErrSpecialFile
+	require.ErrorAs(t, err, &special)
+	assert.Equal(t, "/source/mydir/fifo", special.Path)
+	assert.Equal(t, "named pipe", special.Kind)
+}
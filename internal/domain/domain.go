@@ -1,5 +1,7 @@
 package domain
 
+import "os"
+
 // Package represents a collection of configuration files to be managed.
 type Package struct {
 	Name string
@@ -19,6 +21,10 @@ const (
 
 	// NodeSymlink represents a symbolic link.
 	NodeSymlink
+
+	// NodeSpecial represents a socket, named pipe, device node or other
+	// file that is neither regular, a directory nor a symbolic link.
+	NodeSpecial
 )
 
 // String returns the string representation of a NodeType.
@@ -30,6 +36,8 @@ func (t NodeType) String() string {
 		return "Dir"
 	case NodeSymlink:
 		return "Symlink"
+	case NodeSpecial:
+		return "Special"
 	default:
 		return "Unknown"
 	}
@@ -57,8 +65,48 @@ func (n Node) IsSymlink() bool {
 	return n.Type == NodeSymlink
 }
 
+// IsSpecial returns true if the node is a special file.
+func (n Node) IsSpecial() bool {
+	return n.Type == NodeSpecial
+}
+
+// SpecialFilePolicy selects how special files found in packages and
+// adopted directories are handled.
+type SpecialFilePolicy int
+
+const (
+	// SpecialFilesSkip leaves special files out and reports a warning.
+	SpecialFilesSkip SpecialFilePolicy = iota
+
+	// SpecialFilesError fails the operation with ErrSpecialFile.
+	SpecialFilesError
+)
+
+// SpecialFileKind describes the kind of special file mode stands for, or
+// returns "" for regular files, directories and symbolic links.
+func SpecialFileKind(mode os.FileMode) string {
+	switch {
+	case mode.IsRegular(), mode.IsDir(), mode&os.ModeSymlink != 0:
+		return ""
+	case mode&os.ModeSocket != 0:
+		return "socket"
+	case mode&os.ModeNamedPipe != 0:
+		return "named pipe"
+	case mode&os.ModeCharDevice != 0:
+		return "character device"
+	case mode&os.ModeDevice != 0:
+		return "device"
+	default:
+		return "irregular file"
+	}
+}
+
 // Plan represents a set of operations to execute.
 type Plan struct {
+	// ID identifies the plan in audit records. The executor assigns one
+	// when executing a plan without it.
+	ID string `json:"id,omitempty"`
+
 	Operations []Operation
 	Metadata   PlanMetadata
 	Batches    [][]Operation // Parallel execution batches (if computed)
@@ -68,17 +116,23 @@ type Plan struct {
 	// allowing accurate manifest updates and selective operations.
 	// Optional field for backward compatibility.
 	PackageOperations map[string][]OperationID `json:"package_operations,omitempty"`
+
+	// Satisfied holds operations whose desired state already exists in the target,
+	// such as links created by GNU Stow or an earlier install without a manifest.
+	// They are not executed but are still attributed to packages for manifest updates.
+	Satisfied []Operation `json:"satisfied,omitempty"`
+
+	// Dependencies maps operation IDs to the IDs of the operations they
+	// must run after, as determined by the planner. Serialized plans keep
+	// it so that the dependency graph survives without re-planning.
+	// Optional; operations without an entry have no dependencies.
+	Dependencies map[OperationID][]OperationID `json:"dependencies,omitempty"`
 }
 
-// Validate checks if the plan is valid.
-func (p Plan) Validate() error {
-	// Validate each operation
-	for _, op := range p.Operations {
-		if err := op.Validate(); err != nil {
-			return err
-		}
-	}
-	return nil
+// DependenciesOf returns the IDs of the operations that the operation with
+// the given ID depends on.
+func (p Plan) DependenciesOf(id OperationID) []OperationID {
+	return p.Dependencies[id]
 }
 
 // CanParallelize returns true if the plan has computed parallel batches.
@@ -92,8 +146,8 @@ func (p Plan) ParallelBatches() [][]Operation {
 	return p.Batches
 }
 
-// OperationsForPackage returns all operations that belong to the specified package.
-// Returns an empty slice if the package is not in the plan or if PackageOperations is not set.
+// OperationsForPackage returns all operations that belong to the specified package,
+// including satisfied operations. Returns an empty slice if the package is not in the plan or if PackageOperations is not set.
 func (p Plan) OperationsForPackage(pkg string) []Operation {
 	if p.PackageOperations == nil {
 		return []Operation{}
@@ -104,8 +158,12 @@ func (p Plan) OperationsForPackage(pkg string) []Operation {
 		return []Operation{}
 	}
 
+	candidates := make([]Operation, 0, len(p.Operations)+len(p.Satisfied))
+	candidates = append(candidates, p.Operations...)
+	candidates = append(candidates, p.Satisfied...)
+
 	result := make([]Operation, 0, len(ids))
-	for _, op := range p.Operations {
+	for _, op := range candidates {
 		for _, id := range ids {
 			if op.ID() == id {
 				result = append(result, op)
@@ -160,4 +218,7 @@ type PlanMetadata struct {
 	DirCount       int            `json:"dir_count"`
 	Conflicts      []ConflictInfo `json:"conflicts,omitempty"`
 	Warnings       []WarningInfo  `json:"warnings,omitempty"`
+
+	// Replacements lists the existing files the plan replaces.
+	Replacements []ReplacementInfo `json:"replacements,omitempty"`
 }
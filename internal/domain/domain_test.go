@@ -1,10 +1,12 @@
 package domain_test
 
 import (
+	"os"
 	"testing"
 
 	"github.com/jamesainslie/dot/internal/domain"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNodeType_String(t *testing.T) {
@@ -28,6 +30,11 @@ func TestNodeType_String(t *testing.T) {
 			nodeType: domain.NodeSymlink,
 			expected: "Symlink",
 		},
+		{
+			name:     "Special",
+			nodeType: domain.NodeSpecial,
+			expected: "Special",
+		},
 		{
 			name:     "Unknown",
 			nodeType: domain.NodeType(99),
@@ -199,6 +206,77 @@ func TestPlan_Validate(t *testing.T) {
 		err := plan.Validate()
 		assert.NoError(t, err)
 	})
+
+	t.Run("Dependency on unknown operation", func(t *testing.T) {
+		dir := domain.NewDirCreate("dir", domain.MustParsePath("/home/.vim"))
+		plan := domain.Plan{
+			Operations:   []domain.Operation{dir},
+			Dependencies: map[domain.OperationID][]domain.OperationID{"dir": {"missing"}},
+		}
+		err := plan.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "missing")
+		assert.Empty(t, plan.DependenciesOf("other"))
+	})
+
+	source := domain.MustParsePath("/packages/vim/dot-vimrc")
+	target := domain.MustParseTargetPath("/home/.vimrc")
+	link := domain.NewLinkCreate("link", source, target)
+	unlink := domain.NewLinkDelete("unlink", target)
+	dir := domain.NewDirCreate("dir", domain.MustParsePath("/home/.vim"))
+
+	t.Run("Dependency cycle", func(t *testing.T) {
+		plan := domain.Plan{
+			Operations: []domain.Operation{link, dir},
+			Dependencies: map[domain.OperationID][]domain.OperationID{
+				"link": {"dir"},
+				"dir":  {"link"},
+			},
+		}
+		var cycle domain.ErrCyclicDependency
+		require.ErrorAs(t, plan.Validate(), &cycle)
+		assert.Equal(t, []string{"dir", "link", "dir"}, cycle.Cycle)
+	})
+
+	t.Run("Two operations create the same path", func(t *testing.T) {
+		other := domain.NewLinkCreate("other", domain.MustParsePath("/packages/gvim/dot-vimrc"), target)
+		plan := domain.Plan{Operations: []domain.Operation{link, other, dir, domain.NewDirCreate("dir-again", domain.MustParsePath("/home/.vim"))}}
+		var invalid domain.ErrInvalidPlan
+		require.ErrorAs(t, plan.Validate(), &invalid)
+		assert.Contains(t, invalid.Reason, "both create /home/.vimrc")
+	})
+
+	t.Run("Create and delete in one batch", func(t *testing.T) {
+		plan := domain.Plan{
+			Operations: []domain.Operation{unlink, link},
+			Batches:    [][]domain.Operation{{unlink, link}},
+		}
+		require.ErrorAs(t, plan.Validate(), new(domain.ErrInvalidPlan))
+
+		plan.Batches = [][]domain.Operation{{unlink}, {link}}
+		assert.NoError(t, plan.Validate(), "separate batches are ordered")
+	})
+
+	t.Run("Package lists unknown operation", func(t *testing.T) {
+		plan := domain.Plan{
+			Operations:        []domain.Operation{link},
+			Satisfied:         []domain.Operation{dir},
+			PackageOperations: map[string][]domain.OperationID{"vim": {"link", "dir", "gone"}},
+		}
+		err := plan.Validate()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "package vim lists unknown operation gone")
+	})
+
+	t.Run("Problems are aggregated", func(t *testing.T) {
+		plan := domain.Plan{
+			Operations:        []domain.Operation{link, link},
+			PackageOperations: map[string][]domain.OperationID{"vim": {"gone"}},
+		}
+		var multiple domain.ErrMultiple
+		require.ErrorAs(t, plan.Validate(), &multiple)
+		assert.Len(t, multiple.Errors, 3)
+	})
 }
 
 func TestPlan_CanParallelize(t *testing.T) {
@@ -366,3 +444,23 @@ func TestPlan_OperationCountForPackage(t *testing.T) {
 		})
 	}
 }
+
+func TestSpecialFileKind(t *testing.T) {
+	tests := []struct {
+		mode     os.FileMode
+		expected string
+	}{
+		{0644, ""},
+		{os.ModeDir | 0755, ""},
+		{os.ModeSymlink | 0777, ""},
+		{os.ModeSocket | 0755, "socket"},
+		{os.ModeNamedPipe | 0644, "named pipe"},
+		{os.ModeDevice | os.ModeCharDevice | 0620, "character device"},
+		{os.ModeDevice | 0660, "device"},
+		{os.ModeIrregular, "irregular file"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, domain.SpecialFileKind(tt.mode), tt.mode.String())
+	}
+}
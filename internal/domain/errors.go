@@ -45,6 +45,27 @@ func (e ErrCyclicDependency) Error() string {
 	return fmt.Sprintf("cyclic dependency detected: %s", strings.Join(e.Cycle, " -> "))
 }
 
+// ErrInvalidPlan indicates a plan that is inconsistent as a whole, such as
+// one whose operations would race on the same path.
+type ErrInvalidPlan struct {
+	Reason string
+}
+
+func (e ErrInvalidPlan) Error() string {
+	return fmt.Sprintf("invalid plan: %s", e.Reason)
+}
+
+// ErrSpecialFile indicates a socket, named pipe, device node or other
+// special file where dot expects a regular file.
+type ErrSpecialFile struct {
+	Path string
+	Kind string
+}
+
+func (e ErrSpecialFile) Error() string {
+	return fmt.Sprintf("special file %q (%s) cannot be managed", e.Path, e.Kind)
+}
+
 // Infrastructure Errors
 
 // ErrFilesystemOperation indicates a filesystem operation failed.
@@ -72,6 +93,17 @@ func (e ErrPermissionDenied) Error() string {
 	return fmt.Sprintf("permission denied: cannot %s %q", e.Operation, e.Path)
 }
 
+// ErrOutsideBoundary indicates a change to the filesystem outside the
+// directories a boundary confines changes to.
+type ErrOutsideBoundary struct {
+	Path      string
+	Operation string
+}
+
+func (e ErrOutsideBoundary) Error() string {
+	return fmt.Sprintf("refusing to %s %q: outside the directories dot manages", e.Operation, e.Path)
+}
+
 // Executor Errors
 
 // ErrEmptyPlan indicates an attempt to execute a plan with no operations.
@@ -198,6 +230,9 @@ func UserFacingError(err error) string {
 	case ErrPermissionDenied:
 		return fmt.Sprintf("Permission denied: cannot %s %q\nCheck file permissions and try again.", e.Operation, e.Path)
 
+	case ErrOutsideBoundary:
+		return fmt.Sprintf("Refused to %s %q: it is outside the directories dot manages.", e.Operation, e.Path)
+
 	case ErrEmptyPlan:
 		return "Cannot execute empty plan. Ensure the plan contains operations."
 
@@ -237,6 +237,11 @@ func TestUserFacingErrorComprehensive(t *testing.T) {
 			err:      domain.ErrPermissionDenied{Path: "/restricted", Operation: "read"},
 			contains: []string{"Permission denied", "/restricted"},
 		},
+		{
+			name:     "ErrOutsideBoundary",
+			err:      domain.ErrOutsideBoundary{Path: "/etc/passwd", Operation: "remove"},
+			contains: []string{"Refused to remove", "/etc/passwd", "outside"},
+		},
 		{
 			name:     "ErrMultiple",
 			err:      domain.ErrMultiple{Errors: []error{errors.New("err1"), errors.New("err2")}},
@@ -0,0 +1,118 @@
+package domain
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// EventTopic groups the events published on an EventBus.
+type EventTopic string
+
+const (
+	// TopicOperation carries the execution of plan operations. Events of
+	// this topic set Execution.
+	TopicOperation EventTopic = "operation"
+	// TopicProgress carries the steps of long-running commands, such as
+	// cloning a repository.
+	TopicProgress EventTopic = "progress"
+	// TopicWarning carries problems a command worked around.
+	TopicWarning EventTopic = "warning"
+)
+
+// Event is a message published by a service.
+type Event struct {
+	Topic EventTopic
+
+	// Source is the service publishing the event, such as "clone".
+	Source string
+
+	// Name identifies what happened, such as "repository_cloned", and is
+	// stable across releases. Message describes it for people.
+	Name    string
+	Message string
+
+	// Fields holds the details of the event, keyed by name.
+	Fields map[string]any
+
+	// Err is the error a warning or failed step reports.
+	Err error
+
+	// Execution is set for TopicOperation events.
+	Execution *ExecutionEvent
+}
+
+// EventHandler consumes events delivered by an EventBus.
+type EventHandler func(ctx context.Context, event Event)
+
+// EventBus delivers published events to the handlers subscribed to their
+// topic. Handlers run synchronously on the publishing goroutine, in the
+// order they subscribed, so events of one publisher arrive in order.
+//
+// A nil EventBus discards events, so services may publish without
+// checking whether anyone listens. EventBus is safe for concurrent use.
+type EventBus struct {
+	mu            sync.RWMutex
+	subscriptions []*subscription
+}
+
+// subscription is a handler and the topics it receives; no topics means
+// every topic.
+type subscription struct {
+	handler EventHandler
+	topics  []EventTopic
+}
+
+// NewEventBus returns an event bus with no subscribers.
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// Subscribe registers handler for events of the given topics, or of every
+// topic when none are given. The returned function removes the
+// subscription.
+func (b *EventBus) Subscribe(handler EventHandler, topics ...EventTopic) (unsubscribe func()) {
+	sub := &subscription{handler: handler, topics: topics}
+
+	b.mu.Lock()
+	b.subscriptions = append(b.subscriptions, sub)
+	b.mu.Unlock()
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.subscriptions = slices.DeleteFunc(b.subscriptions, func(s *subscription) bool { return s == sub })
+	}
+}
+
+// SubscribeSink registers sink for the operation events of the bus.
+func (b *EventBus) SubscribeSink(sink EventSink) (unsubscribe func()) {
+	return b.Subscribe(func(ctx context.Context, event Event) {
+		if event.Execution != nil {
+			sink.OnEvent(ctx, *event.Execution)
+		}
+	}, TopicOperation)
+}
+
+// Publish delivers event to every handler subscribed to its topic.
+func (b *EventBus) Publish(ctx context.Context, event Event) {
+	if b == nil {
+		return
+	}
+
+	b.mu.RLock()
+	subscriptions := slices.Clone(b.subscriptions)
+	b.mu.RUnlock()
+
+	for _, sub := range subscriptions {
+		if len(sub.topics) == 0 || slices.Contains(sub.topics, event.Topic) {
+			sub.handler(ctx, event)
+		}
+	}
+}
+
+// OnEvent publishes an execution event under TopicOperation, so the bus
+// can serve as the EventSink of an executor.
+func (b *EventBus) OnEvent(ctx context.Context, event ExecutionEvent) {
+	b.Publish(ctx, Event{Topic: TopicOperation, Source: "executor", Name: string(event.Kind), Execution: &event})
+}
@@ -0,0 +1,62 @@
+package domain_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/domain"
+)
+
+func TestEventBus_Topics(t *testing.T) {
+	ctx := context.Background()
+	bus := domain.NewEventBus()
+
+	var all, warnings []string
+	bus.Subscribe(func(ctx context.Context, event domain.Event) {
+		all = append(all, event.Name)
+	})
+	unsubscribe := bus.Subscribe(func(ctx context.Context, event domain.Event) {
+		warnings = append(warnings, event.Name)
+	}, domain.TopicWarning)
+
+	bus.Publish(ctx, domain.Event{Topic: domain.TopicProgress, Name: "started"})
+	bus.Publish(ctx, domain.Event{Topic: domain.TopicWarning, Name: "fallback"})
+	unsubscribe()
+	bus.Publish(ctx, domain.Event{Topic: domain.TopicWarning, Name: "ignored"})
+
+	assert.Equal(t, []string{"started", "fallback", "ignored"}, all)
+	assert.Equal(t, []string{"fallback"}, warnings)
+}
+
+func TestEventBus_Sink(t *testing.T) {
+	ctx := context.Background()
+	bus := domain.NewEventBus()
+
+	var kinds []domain.ExecutionEventKind
+	bus.SubscribeSink(domain.EventSinkFunc(func(ctx context.Context, event domain.ExecutionEvent) {
+		kinds = append(kinds, event.Kind)
+	}))
+
+	var published []domain.Event
+	bus.Subscribe(func(ctx context.Context, event domain.Event) {
+		published = append(published, event)
+	}, domain.TopicOperation)
+
+	bus.OnEvent(ctx, domain.ExecutionEvent{Kind: domain.EventStarted, Total: 1})
+	bus.Publish(ctx, domain.Event{Topic: domain.TopicProgress, Name: "other"})
+
+	assert.Equal(t, []domain.ExecutionEventKind{domain.EventStarted}, kinds)
+	require.Len(t, published, 1)
+	assert.Equal(t, "started", published[0].Name)
+	assert.Equal(t, 1, published[0].Execution.Total)
+}
+
+func TestEventBus_Nil(t *testing.T) {
+	var bus *domain.EventBus
+	assert.NotPanics(t, func() {
+		bus.Publish(context.Background(), domain.Event{Topic: domain.TopicProgress})
+	})
+}
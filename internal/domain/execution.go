@@ -1,5 +1,7 @@
 package domain
 
+import "time"
+
 // ExecutionResult contains the outcome of plan execution.
 type ExecutionResult struct {
 	Executed   []OperationID
@@ -17,3 +19,45 @@ func (r ExecutionResult) Success() bool {
 func (r ExecutionResult) PartialFailure() bool {
 	return len(r.Executed) > 0 && len(r.Failed) > 0
 }
+
+// ExecutionEventKind identifies what happened to an operation during execution.
+type ExecutionEventKind string
+
+const (
+	// EventStarted is sent before an operation executes.
+	EventStarted ExecutionEventKind = "started"
+	// EventSucceeded is sent after an operation executed successfully.
+	EventSucceeded ExecutionEventKind = "succeeded"
+	// EventFailed is sent after an operation failed to execute.
+	EventFailed ExecutionEventKind = "failed"
+	// EventRolledBack is sent after an executed operation was undone, or
+	// could not be undone if Err is set.
+	EventRolledBack ExecutionEventKind = "rolled_back"
+)
+
+// ExecutionEvent reports the progress of a single operation.
+type ExecutionEvent struct {
+	Kind      ExecutionEventKind
+	Operation Operation
+
+	// PlanID and CheckpointID identify the executed plan and the
+	// checkpoint journaling it. Package is the package the operation
+	// belongs to, if known.
+	PlanID       string
+	CheckpointID string
+	Package      string
+
+	// Completed counts the operations finished so far, including this one
+	// once it has finished, out of Total. During rollback both count the
+	// operations being undone.
+	Completed int
+	Total     int
+
+	// Duration is how long the operation took; zero for EventStarted.
+	Duration time.Duration
+	// Elapsed is the time since execution, or rollback, began.
+	Elapsed time.Duration
+
+	// Err is the error of a failed operation or rollback.
+	Err error
+}
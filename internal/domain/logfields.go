@@ -0,0 +1,40 @@
+package domain
+
+import "context"
+
+// LogFields correlate log records with the plan, package and operation
+// they were made for, so that records of operations run in parallel can
+// be grouped. Empty fields are unknown.
+type LogFields struct {
+	// PlanID is the ID of the checkpoint journaling the executed plan.
+	PlanID string
+	// Package is the package the operation belongs to.
+	Package string
+	// OperationID is the ID of the operation.
+	OperationID string
+}
+
+// logFieldsKey is the context key of the log fields.
+type logFieldsKey struct{}
+
+// WithLogFields returns a context carrying fields for the log records made
+// under it. Empty fields keep the values already carried by ctx.
+func WithLogFields(ctx context.Context, fields LogFields) context.Context {
+	merged := LogFieldsFromContext(ctx)
+	if fields.PlanID != "" {
+		merged.PlanID = fields.PlanID
+	}
+	if fields.Package != "" {
+		merged.Package = fields.Package
+	}
+	if fields.OperationID != "" {
+		merged.OperationID = fields.OperationID
+	}
+	return context.WithValue(ctx, logFieldsKey{}, merged)
+}
+
+// LogFieldsFromContext returns the log fields set by WithLogFields.
+func LogFieldsFromContext(ctx context.Context) LogFields {
+	fields, _ := ctx.Value(logFieldsKey{}).(LogFields)
+	return fields
+}
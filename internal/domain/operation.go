@@ -7,6 +7,7 @@ import (
 	"os"
 	"strings"
 	"syscall"
+	"time"
 )
 
 // OperationKind identifies the type of operation.
@@ -36,6 +37,15 @@ const (
 
 	// OpKindDirCopy recursively copies a directory.
 	OpKindDirCopy
+
+	// OpKindFileRender writes rendered template output to a file.
+	OpKindFileRender
+
+	// OpKindFileStash moves a file into the content-addressed backup store.
+	OpKindFileStash
+
+	// OpKindFileRestore moves a file out of the backup store to its path.
+	OpKindFileRestore
 )
 
 // String returns the string representation of an OperationKind.
@@ -57,6 +67,12 @@ func (k OperationKind) String() string {
 		return "FileBackup"
 	case OpKindDirCopy:
 		return "DirCopy"
+	case OpKindFileRender:
+		return "FileRender"
+	case OpKindFileStash:
+		return "FileStash"
+	case OpKindFileRestore:
+		return "FileRestore"
 	default:
 		return "Unknown"
 	}
@@ -93,6 +109,14 @@ type Operation interface {
 	Equals(other Operation) bool
 }
 
+// StateCapturer is implemented by operations whose rollback needs state
+// that only exists before they execute. The executor calls CaptureState
+// just before Execute and records the returned operation, so rolling it
+// back restores what was there.
+type StateCapturer interface {
+	CaptureState(ctx context.Context, fs FS) Operation
+}
+
 // LinkCreate creates a symbolic link from source to target.
 type LinkCreate struct {
 	OpID   OperationID
@@ -155,6 +179,10 @@ func (op LinkCreate) Equals(other Operation) bool {
 type LinkDelete struct {
 	OpID   OperationID
 	Target TargetPath
+
+	// Previous is what the link pointed at before deletion, captured by
+	// CaptureState. Rollback recreates the link from it.
+	Previous string
 }
 
 // NewLinkDelete creates a new link deletion operation.
@@ -194,10 +222,27 @@ func (op LinkDelete) Execute(ctx context.Context, fs FS) error {
 	return err
 }
 
+// Rollback recreates the deleted link. Without a captured Previous target
+// there is nothing to restore.
 func (op LinkDelete) Rollback(ctx context.Context, fs FS) error {
-	// Cannot restore deleted link without knowing original target
-	// This would require storing the original target in the operation
-	return nil
+	if op.Previous == "" {
+		return nil
+	}
+	if current, err := fs.ReadLink(ctx, op.Target.String()); err == nil && current == op.Previous {
+		return nil
+	}
+	return fs.Symlink(ctx, op.Previous, op.Target.String())
+}
+
+// CaptureState records the current target of the link in Previous. The
+// operation is returned unchanged if there is no link to read.
+func (op LinkDelete) CaptureState(ctx context.Context, fs FS) Operation {
+	target, err := fs.ReadLink(ctx, op.Target.String())
+	if err != nil {
+		return op
+	}
+	op.Previous = target
+	return op
 }
 
 func (op LinkDelete) String() string {
@@ -275,6 +320,10 @@ func (op DirCreate) Equals(other Operation) bool {
 type DirDelete struct {
 	OpID OperationID
 	Path FilePath
+
+	// Mode holds the permissions of the directory, captured before it is
+	// removed so that rollback recreates it as it was.
+	Mode os.FileMode
 }
 
 // NewDirDelete creates a new directory deletion operation.
@@ -304,12 +353,35 @@ func (op DirDelete) Dependencies() []Operation {
 	return nil
 }
 
+// Execute removes the directory. A directory that gained entries since
+// the plan was made, for example a file the user just saved there, is
+// reported as an ErrConflict and left untouched.
 func (op DirDelete) Execute(ctx context.Context, fs FS) error {
+	if entries, err := fs.ReadDir(ctx, op.Path.String()); err == nil && len(entries) > 0 {
+		return ErrConflict{Path: op.Path.String(), Reason: fmt.Sprintf("directory is not empty (%d entries)", len(entries))}
+	}
 	return fs.Remove(ctx, op.Path.String())
 }
 
+// Rollback recreates the directory with its captured mode, or with
+// DefaultDirPerms if none was captured.
 func (op DirDelete) Rollback(ctx context.Context, fs FS) error {
-	return fs.Mkdir(ctx, op.Path.String(), DefaultDirPerms)
+	mode := op.Mode
+	if mode == 0 {
+		mode = DefaultDirPerms
+	}
+	return fs.Mkdir(ctx, op.Path.String(), mode)
+}
+
+// CaptureState records the permissions of the directory in Mode. The
+// operation is returned unchanged if the directory cannot be read.
+func (op DirDelete) CaptureState(ctx context.Context, fs FS) Operation {
+	info, err := fs.Stat(ctx, op.Path.String())
+	if err != nil || !info.IsDir() {
+		return op
+	}
+	op.Mode = info.Mode().Perm()
+	return op
 }
 
 func (op DirDelete) String() string {
@@ -450,6 +522,10 @@ func (op FileMove) copyAndDelete(ctx context.Context, fs FS) error {
 			return fmt.Errorf("copy directory for cross-device move: %w", err)
 		}
 	} else {
+		if kind := SpecialFileKind(info.Mode()); kind != "" {
+			return ErrSpecialFile{Path: op.Source.String(), Kind: kind}
+		}
+
 		// Handle file move
 		data, err := fs.ReadFile(ctx, op.Source.String())
 		if err != nil {
@@ -529,7 +605,9 @@ func (op FileMove) Equals(other Operation) bool {
 	return op.Source.Equals(o.Source) && op.Dest.Equals(o.Dest)
 }
 
-// FileBackup creates a backup copy of a file.
+// FileBackup creates a backup copy of a file. The copy keeps the mode and
+// modification time of the original where the filesystem supports it, and
+// a symlink is backed up as a link to the same target.
 type FileBackup struct {
 	OpID   OperationID
 	Source FilePath
@@ -565,15 +643,59 @@ func (op FileBackup) Dependencies() []Operation {
 }
 
 func (op FileBackup) Execute(ctx context.Context, fs FS) error {
-	data, err := fs.ReadFile(ctx, op.Source.String())
+	source, backup := op.Source.String(), op.Backup.String()
+
+	if isLink, err := fs.IsSymlink(ctx, source); err == nil && isLink {
+		target, err := fs.ReadLink(ctx, source)
+		if err != nil {
+			return err
+		}
+		return fs.Symlink(ctx, target, backup)
+	}
+
+	info, err := fs.Stat(ctx, source)
+	if err != nil {
+		return err
+	}
+	data, err := fs.ReadFile(ctx, source)
 	if err != nil {
 		return err
 	}
-	return fs.WriteFile(ctx, op.Backup.String(), data, DefaultFilePerms)
+	mode := info.Mode().Perm()
+	if mode == 0 {
+		mode = DefaultFilePerms
+	}
+	if err := fs.WriteFile(ctx, backup, data, mode); err != nil {
+		return err
+	}
+	return copyMetadata(ctx, fs, backup, mode, info.ModTime())
 }
 
+// Rollback removes the backup copy. A backup that is already gone is not
+// an error.
 func (op FileBackup) Rollback(ctx context.Context, fs FS) error {
-	return fs.Remove(ctx, op.Backup.String())
+	err := fs.Remove(ctx, op.Backup.String())
+	if err != nil && os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// copyMetadata applies mode and modTime to path when fs can change file
+// metadata. WriteFile only sets the mode of new files, subject to the
+// umask, so the mode is applied again explicitly.
+func copyMetadata(ctx context.Context, fs FS, path string, mode os.FileMode, modTime any) error {
+	meta, ok := fs.(MetadataFS)
+	if !ok {
+		return nil
+	}
+	if err := meta.Chmod(ctx, path, mode); err != nil {
+		return err
+	}
+	if mtime, ok := modTime.(time.Time); ok && !mtime.IsZero() {
+		return meta.Chtimes(ctx, path, mtime, mtime)
+	}
+	return nil
 }
 
 func (op FileBackup) String() string {
@@ -650,6 +772,310 @@ func (op DirCopy) Equals(other Operation) bool {
 	return op.Source.Equals(o.Source) && op.Dest.Equals(o.Dest)
 }
 
+// FileStash moves a file into the content-addressed backup store under BackupDir.
+// Identical content backed up more than once is stored as a single object.
+// The backup is recorded with the original path of the file and the ID of
+// the plan executing the operation, taken from the context.
+type FileStash struct {
+	OpID      OperationID
+	Source    FilePath
+	BackupDir FilePath
+	Hash      string // SHA-256 of Source content at planning time
+}
+
+// NewFileStash creates a new file stash operation.
+func NewFileStash(id OperationID, source, backupDir FilePath, hash string) FileStash {
+	return FileStash{
+		OpID:      id,
+		Source:    source,
+		BackupDir: backupDir,
+		Hash:      hash,
+	}
+}
+
+func (op FileStash) ID() OperationID {
+	return op.OpID
+}
+
+func (op FileStash) Kind() OperationKind {
+	return OpKindFileStash
+}
+
+func (op FileStash) Validate() error {
+	if op.OpID == "" {
+		return ErrInvalidPath{Path: "", Reason: "operation ID cannot be empty"}
+	}
+	if op.Hash == "" {
+		return ErrInvalidPath{Path: op.Source.String(), Reason: "content hash cannot be empty"}
+	}
+	return nil
+}
+
+func (op FileStash) Dependencies() []Operation {
+	return nil
+}
+
+func (op FileStash) Execute(ctx context.Context, fs FS) error {
+	data, err := fs.ReadFile(ctx, op.Source.String())
+	if err != nil {
+		return err
+	}
+	if hash := HashContent(data); hash != op.Hash {
+		return ErrInvalidPath{Path: op.Source.String(), Reason: "file changed since the plan was computed"}
+	}
+	mode, err := fileMode(ctx, fs, op.Source.String())
+	if err != nil {
+		return err
+	}
+	if _, err := storeBackup(ctx, fs, op.BackupDir.String(), op.Source.String(), PlanIDFromContext(ctx), data, mode); err != nil {
+		return err
+	}
+	return fs.Remove(ctx, op.Source.String())
+}
+
+// Rollback moves the file back out of the backup store, with the mode it
+// had when it was stashed.
+func (op FileStash) Rollback(ctx context.Context, fs FS) error {
+	data, err := fs.ReadFile(ctx, BackupObjectPath(op.BackupDir.String(), op.Hash))
+	if err != nil {
+		return err
+	}
+	mode, err := backupMode(ctx, fs, op.BackupDir.String(), op.Source.String(), op.Hash, PlanIDFromContext(ctx))
+	if err != nil {
+		return err
+	}
+	if err := fs.WriteFile(ctx, op.Source.String(), data, mode); err != nil {
+		return err
+	}
+	if err := copyMetadata(ctx, fs, op.Source.String(), mode, nil); err != nil {
+		return err
+	}
+	return releaseBackup(ctx, fs, op.BackupDir.String(), op.Source.String(), op.Hash, PlanIDFromContext(ctx))
+}
+
+func (op FileStash) String() string {
+	return fmt.Sprintf("stash file %s -> %s", op.Source.String(), BackupObjectPath(op.BackupDir.String(), op.Hash))
+}
+
+func (op FileStash) Equals(other Operation) bool {
+	if other.Kind() != OpKindFileStash {
+		return false
+	}
+	o, ok := other.(FileStash)
+	if !ok {
+		return false
+	}
+	return op.Source.Equals(o.Source) && op.BackupDir.Equals(o.BackupDir) && op.Hash == o.Hash
+}
+
+// FileRestore moves the backup of a file taken by the plan with PlanID out
+// of the backup store under BackupDir, back to Path. The object is deleted
+// once no other backup refers to it.
+type FileRestore struct {
+	OpID      OperationID
+	Path      FilePath
+	BackupDir FilePath
+	Hash      string // SHA-256 of the backed up content
+	PlanID    string
+}
+
+// NewFileRestore creates a new file restore operation.
+func NewFileRestore(id OperationID, path, backupDir FilePath, hash, planID string) FileRestore {
+	return FileRestore{
+		OpID:      id,
+		Path:      path,
+		BackupDir: backupDir,
+		Hash:      hash,
+		PlanID:    planID,
+	}
+}
+
+func (op FileRestore) ID() OperationID {
+	return op.OpID
+}
+
+func (op FileRestore) Kind() OperationKind {
+	return OpKindFileRestore
+}
+
+func (op FileRestore) Validate() error {
+	if op.OpID == "" {
+		return ErrInvalidPath{Path: "", Reason: "operation ID cannot be empty"}
+	}
+	if op.Hash == "" {
+		return ErrInvalidPath{Path: op.Path.String(), Reason: "content hash cannot be empty"}
+	}
+	return nil
+}
+
+func (op FileRestore) Dependencies() []Operation {
+	return nil
+}
+
+func (op FileRestore) Execute(ctx context.Context, fs FS) error {
+	data, err := fs.ReadFile(ctx, BackupObjectPath(op.BackupDir.String(), op.Hash))
+	if err != nil {
+		return err
+	}
+	if parent := op.Path.Parent(); parent.IsOk() {
+		if err := fs.MkdirAll(ctx, parent.Unwrap().String(), DefaultDirPerms); err != nil {
+			return err
+		}
+	}
+	mode, err := backupMode(ctx, fs, op.BackupDir.String(), op.Path.String(), op.Hash, op.PlanID)
+	if err != nil {
+		return err
+	}
+	if err := fs.WriteFile(ctx, op.Path.String(), data, mode); err != nil {
+		return err
+	}
+	if err := copyMetadata(ctx, fs, op.Path.String(), mode, nil); err != nil {
+		return err
+	}
+	return releaseBackup(ctx, fs, op.BackupDir.String(), op.Path.String(), op.Hash, op.PlanID)
+}
+
+func (op FileRestore) Rollback(ctx context.Context, fs FS) error {
+	data, err := fs.ReadFile(ctx, op.Path.String())
+	if err != nil {
+		return err
+	}
+	mode, err := fileMode(ctx, fs, op.Path.String())
+	if err != nil {
+		return err
+	}
+	if _, err := storeBackup(ctx, fs, op.BackupDir.String(), op.Path.String(), op.PlanID, data, mode); err != nil {
+		return err
+	}
+	return fs.Remove(ctx, op.Path.String())
+}
+
+func (op FileRestore) String() string {
+	return fmt.Sprintf("restore file %s <- %s", op.Path.String(), BackupObjectPath(op.BackupDir.String(), op.Hash))
+}
+
+func (op FileRestore) Equals(other Operation) bool {
+	if other.Kind() != OpKindFileRestore {
+		return false
+	}
+	o, ok := other.(FileRestore)
+	if !ok {
+		return false
+	}
+	return op.Path.Equals(o.Path) && op.BackupDir.Equals(o.BackupDir) && op.Hash == o.Hash && op.PlanID == o.PlanID
+}
+
+// FileRender writes the rendered output of a template to a cache file.
+// Rendering happens during planning; the operation only carries the result
+// so that plans remain pure data and comparable.
+//
+// Output containing secrets is written readable by the owner only.
+type FileRender struct {
+	OpID     OperationID
+	Template FilePath
+	Dest     FilePath
+	Content  string
+	Secret   bool
+
+	// Previous and PreviousMode are the content and permissions of the
+	// cache file before rendering, captured by CaptureState. Rollback
+	// writes them back; a zero PreviousMode means there was no file.
+	Previous     string
+	PreviousMode os.FileMode
+}
+
+// NewFileRender creates a new template render operation.
+func NewFileRender(id OperationID, template, dest FilePath, content string) FileRender {
+	return FileRender{
+		OpID:     id,
+		Template: template,
+		Dest:     dest,
+		Content:  content,
+	}
+}
+
+func (op FileRender) ID() OperationID {
+	return op.OpID
+}
+
+func (op FileRender) Kind() OperationKind {
+	return OpKindFileRender
+}
+
+func (op FileRender) Validate() error {
+	if op.OpID == "" {
+		return ErrInvalidPath{Path: "", Reason: "operation ID cannot be empty"}
+	}
+	return nil
+}
+
+func (op FileRender) Dependencies() []Operation {
+	return nil
+}
+
+func (op FileRender) Execute(ctx context.Context, fs FS) error {
+	parent := op.Dest.Parent()
+	if parent.IsOk() {
+		if err := fs.MkdirAll(ctx, parent.Unwrap().String(), DefaultDirPerms); err != nil {
+			return err
+		}
+	}
+	if !op.Secret {
+		return fs.WriteFile(ctx, op.Dest.String(), []byte(op.Content), DefaultFilePerms)
+	}
+	// WriteFile keeps the mode of an existing file, so replace it
+	if fs.Exists(ctx, op.Dest.String()) {
+		if err := fs.Remove(ctx, op.Dest.String()); err != nil {
+			return err
+		}
+	}
+	return fs.WriteFile(ctx, op.Dest.String(), []byte(op.Content), SecureFilePerms)
+}
+
+// Rollback restores the cache file captured before rendering, or removes
+// the rendered output if there was none.
+func (op FileRender) Rollback(ctx context.Context, fs FS) error {
+	if op.PreviousMode == 0 {
+		return fs.Remove(ctx, op.Dest.String())
+	}
+	// WriteFile keeps the mode of an existing file, so replace it
+	if err := fs.Remove(ctx, op.Dest.String()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return fs.WriteFile(ctx, op.Dest.String(), []byte(op.Previous), op.PreviousMode)
+}
+
+// CaptureState records the content and permissions of the cache file in
+// Previous and PreviousMode. The operation is returned unchanged if there
+// is no regular file to read.
+func (op FileRender) CaptureState(ctx context.Context, fs FS) Operation {
+	info, err := fs.Stat(ctx, op.Dest.String())
+	if err != nil || !info.Mode().IsRegular() {
+		return op
+	}
+	data, err := fs.ReadFile(ctx, op.Dest.String())
+	if err != nil {
+		return op
+	}
+	op.Previous, op.PreviousMode = string(data), info.Mode().Perm()
+	return op
+}
+
+func (op FileRender) String() string {
+	return fmt.Sprintf("render template %s -> %s", op.Template.String(), op.Dest.String())
+}
+
+func (op FileRender) Equals(other Operation) bool {
+	if other.Kind() != OpKindFileRender {
+		return false
+	}
+	o, ok := other.(FileRender)
+	if !ok {
+		return false
+	}
+	return op.Template.Equals(o.Template) && op.Dest.Equals(o.Dest) && op.Content == o.Content && op.Secret == o.Secret
+}
+
 // copyDirRecursiveHelper recursively copies a directory and all its contents.
 // This is a package-level helper used by both FileMove and DirCopy operations.
 func copyDirRecursiveHelper(ctx context.Context, fs FS, src, dst string) error {
@@ -679,6 +1105,11 @@ func copyDirRecursiveHelper(ctx context.Context, fs FS, src, dst string) error {
 				return err
 			}
 		} else {
+			// Reading a named pipe or device would block or never end
+			if kind := SpecialFileKind(entry.Type()); kind != "" {
+				return ErrSpecialFile{Path: srcPath, Kind: kind}
+			}
+
 			// Copy file
 			data, err := fs.ReadFile(ctx, srcPath)
 			if err != nil {
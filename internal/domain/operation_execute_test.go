@@ -2,7 +2,9 @@ package domain_test
 
 import (
 	"context"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/jamesainslie/dot/internal/adapters"
 	"github.com/jamesainslie/dot/internal/domain"
@@ -90,13 +92,33 @@ func TestLinkDelete_Rollback(t *testing.T) {
 	require.True(t, targetResult.IsOk())
 	target := targetResult.Unwrap()
 
-	// LinkDelete rollback needs the original source to recreate the link
-	// Since we don't store that, rollback returns ErrNotImplemented
+	// Without a captured target there is nothing to restore
 	op := domain.NewLinkDelete("del1", target)
+	require.NoError(t, op.Rollback(ctx, fs))
+	assert.False(t, fs.Exists(ctx, "/target/link"))
 
-	err := op.Rollback(ctx, fs)
-	// LinkDelete rollback returns nil (cannot restore without knowing source)
-	assert.NoError(t, err)
+	// Capturing the target before deletion lets rollback recreate the link
+	require.NoError(t, fs.Symlink(ctx, "/source/file", "/target/link"))
+	captured := op.CaptureState(ctx, fs).(domain.LinkDelete)
+	assert.Equal(t, "/source/file", captured.Previous)
+
+	require.NoError(t, captured.Execute(ctx, fs))
+	require.NoError(t, captured.Rollback(ctx, fs))
+	link, err := fs.ReadLink(ctx, "/target/link")
+	require.NoError(t, err)
+	assert.Equal(t, "/source/file", link)
+
+	// Rolling back again is a no-op
+	require.NoError(t, captured.Rollback(ctx, fs))
+}
+
+func TestLinkDelete_CaptureStateWithoutLink(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+
+	op := domain.NewLinkDelete("del1", domain.MustParseTargetPath("/target/missing"))
+	captured := op.CaptureState(ctx, fs).(domain.LinkDelete)
+	assert.Empty(t, captured.Previous)
 }
 
 func TestDirCreate_Execute(t *testing.T) {
@@ -165,6 +187,42 @@ func TestDirDelete_Rollback(t *testing.T) {
 	assert.True(t, exists)
 }
 
+func TestDirDelete_RollbackRestoresMode(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+
+	require.NoError(t, fs.MkdirAll(ctx, "/parent", 0755))
+	require.NoError(t, fs.Mkdir(ctx, "/parent/private", 0700))
+
+	op := domain.NewDirDelete("del1", domain.MustParsePath("/parent/private"))
+	captured := op.CaptureState(ctx, fs).(domain.DirDelete)
+	assert.Equal(t, os.FileMode(0700), captured.Mode)
+
+	require.NoError(t, captured.Execute(ctx, fs))
+	require.NoError(t, captured.Rollback(ctx, fs))
+
+	info, err := fs.Stat(ctx, "/parent/private")
+	require.NoError(t, err)
+	assert.True(t, info.IsDir())
+	assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+}
+
+func TestDirDelete_ExecuteNonEmptyIsConflict(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+
+	require.NoError(t, fs.MkdirAll(ctx, "/parent/dir", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/parent/dir/notes.txt", []byte("new"), 0644))
+
+	op := domain.NewDirDelete("del1", domain.MustParsePath("/parent/dir"))
+	err := op.Execute(ctx, fs)
+
+	var conflict domain.ErrConflict
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, "/parent/dir", conflict.Path)
+	assert.True(t, fs.Exists(ctx, "/parent/dir/notes.txt"))
+}
+
 func TestDirRemoveAll_Execute(t *testing.T) {
 	fs := adapters.NewMemFS()
 	ctx := context.Background()
@@ -224,6 +282,43 @@ func TestFileBackup_Execute(t *testing.T) {
 	assert.Equal(t, []byte("original"), data)
 }
 
+func TestFileBackup_ExecutePreservesMetadata(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+
+	require.NoError(t, fs.MkdirAll(ctx, "/test", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/script", []byte("#!/bin/sh"), 0700))
+	mtime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, fs.Chtimes(ctx, "/test/script", mtime, mtime))
+
+	op := domain.NewFileBackup("bak1", domain.MustParsePath("/test/script"), domain.MustParsePath("/test/script.bak"))
+	require.NoError(t, op.Execute(ctx, fs))
+
+	info, err := fs.Stat(ctx, "/test/script.bak")
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+	assert.True(t, mtime.Equal(info.ModTime().(time.Time)))
+}
+
+func TestFileBackup_ExecuteSymlink(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+
+	require.NoError(t, fs.MkdirAll(ctx, "/test", 0755))
+	require.NoError(t, fs.Symlink(ctx, "/nowhere", "/test/link"))
+
+	op := domain.NewFileBackup("bak1", domain.MustParsePath("/test/link"), domain.MustParsePath("/test/link.bak"))
+	require.NoError(t, op.Execute(ctx, fs))
+
+	target, err := fs.ReadLink(ctx, "/test/link.bak")
+	require.NoError(t, err)
+	assert.Equal(t, "/nowhere", target)
+
+	require.NoError(t, op.Rollback(ctx, fs))
+	assert.False(t, fs.Exists(ctx, "/test/link.bak"))
+	require.NoError(t, op.Rollback(ctx, fs), "rollback without a backup is a no-op")
+}
+
 func TestFileBackup_Rollback(t *testing.T) {
 	fs := adapters.NewMemFS()
 	ctx := context.Background()
@@ -242,3 +337,50 @@ func TestFileBackup_Rollback(t *testing.T) {
 	// Verify backup was deleted
 	assert.False(t, fs.Exists(ctx, "/test/file.bak"))
 }
+
+func TestFileRender_Execute_Secret(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+
+	// Output rendered before the template used a secret
+	require.NoError(t, fs.MkdirAll(ctx, "/cache/gh", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/cache/gh/hosts.yml", []byte("old"), 0644))
+
+	op := domain.NewFileRender("render1", domain.MustParsePath("/packages/gh/hosts.yml.tmpl"), domain.MustParsePath("/cache/gh/hosts.yml"), "oauth_token: ghp_123")
+	op.Secret = true
+
+	require.NoError(t, op.Execute(ctx, fs))
+
+	data, err := fs.ReadFile(ctx, "/cache/gh/hosts.yml")
+	require.NoError(t, err)
+	assert.Equal(t, "oauth_token: ghp_123", string(data))
+	info, err := fs.Stat(ctx, "/cache/gh/hosts.yml")
+	require.NoError(t, err)
+	assert.Equal(t, domain.SecureFilePerms, info.Mode().Perm())
+}
+
+func TestFileRender_RollbackRestoresPrevious(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+	require.NoError(t, fs.MkdirAll(ctx, "/cache/vim", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/cache/vim/vimrc", []byte("set number"), 0640))
+
+	op := domain.NewFileRender("render1", domain.MustParsePath("/packages/vim/vimrc.tmpl"), domain.MustParsePath("/cache/vim/vimrc"), "set nonumber")
+	captured := op.CaptureState(ctx, fs)
+	require.NoError(t, captured.Execute(ctx, fs))
+	require.NoError(t, captured.Rollback(ctx, fs))
+
+	data, err := fs.ReadFile(ctx, "/cache/vim/vimrc")
+	require.NoError(t, err)
+	assert.Equal(t, "set number", string(data), "the output of the earlier render is kept")
+	info, err := fs.Stat(ctx, "/cache/vim/vimrc")
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+
+	// Output that did not exist before is removed
+	fresh := domain.NewFileRender("render2", domain.MustParsePath("/packages/vim/gvimrc.tmpl"), domain.MustParsePath("/cache/vim/gvimrc"), "set guifont=Mono")
+	captured = fresh.CaptureState(ctx, fs)
+	require.NoError(t, captured.Execute(ctx, fs))
+	require.NoError(t, captured.Rollback(ctx, fs))
+	assert.False(t, fs.Exists(ctx, "/cache/vim/gvimrc"))
+}
@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"fmt"
+	"os"
+)
+
+// OperationRecord is the serializable form of an Operation.
+// It allows operations to be persisted, for example in checkpoints, and
+// restored later with their original kind and paths.
+type OperationRecord struct {
+	ID      OperationID `json:"id"`
+	Kind    string      `json:"kind"`
+	Source  string      `json:"source,omitempty"`
+	Target  string      `json:"target,omitempty"`
+	Path    string      `json:"path,omitempty"`
+	Hash    string      `json:"hash,omitempty"`
+	Content string      `json:"content,omitempty"`
+	Secret  bool        `json:"secret,omitempty"`
+	Mode    os.FileMode `json:"mode,omitempty"`
+
+	// Previous is the captured content of a file a render replaced.
+	Previous string `json:"previous,omitempty"`
+
+	// PlanID is the plan whose backup a restored file comes from.
+	PlanID string `json:"plan_id,omitempty"`
+
+	// DependsOn lists the IDs of the operations this one runs after, taken
+	// from Plan.Dependencies when a plan is recorded.
+	DependsOn []OperationID `json:"depends_on,omitempty"`
+}
+
+// NewOperationRecord converts an operation into its serializable form.
+// Source and Target hold the two paths of operations that act between
+// paths; Path holds the path of operations that act on a single path, and
+// Source the captured previous target of a deleted link. Mode holds the
+// captured permissions of a deleted directory or of a file a render
+// replaced.
+func NewOperationRecord(op Operation) (OperationRecord, error) {
+	rec := OperationRecord{ID: op.ID(), Kind: op.Kind().String()}
+
+	switch typed := op.(type) {
+	case LinkCreate:
+		rec.Source, rec.Target = typed.Source.String(), typed.Target.String()
+	case LinkDelete:
+		rec.Path, rec.Source = typed.Target.String(), typed.Previous
+	case DirCreate:
+		rec.Path = typed.Path.String()
+	case DirDelete:
+		rec.Path, rec.Mode = typed.Path.String(), typed.Mode
+	case DirRemoveAll:
+		rec.Path = typed.Path.String()
+	case FileMove:
+		rec.Source, rec.Target = typed.Source.String(), typed.Dest.String()
+	case FileBackup:
+		rec.Source, rec.Target = typed.Source.String(), typed.Backup.String()
+	case DirCopy:
+		rec.Source, rec.Target = typed.Source.String(), typed.Dest.String()
+	case FileStash:
+		rec.Source, rec.Path, rec.Hash = typed.Source.String(), typed.BackupDir.String(), typed.Hash
+	case FileRestore:
+		rec.Target, rec.Path, rec.Hash, rec.PlanID = typed.Path.String(), typed.BackupDir.String(), typed.Hash, typed.PlanID
+	case FileRender:
+		rec.Source, rec.Target, rec.Content, rec.Secret = typed.Template.String(), typed.Dest.String(), typed.Content, typed.Secret
+		rec.Previous, rec.Mode = typed.Previous, typed.PreviousMode
+	default:
+		return OperationRecord{}, fmt.Errorf("cannot record operation %s of type %T", op.ID(), op)
+	}
+
+	return rec, nil
+}
+
+// Operation reconstructs the operation described by the record.
+func (r OperationRecord) Operation() (Operation, error) {
+	switch r.Kind {
+	case OpKindLinkCreate.String():
+		return NewLinkCreate(r.ID, FilePath{path: r.Source}, TargetPath{path: r.Target}), nil
+	case OpKindLinkDelete.String():
+		del := NewLinkDelete(r.ID, TargetPath{path: r.Path})
+		del.Previous = r.Source
+		return del, nil
+	case OpKindDirCreate.String():
+		return NewDirCreate(r.ID, FilePath{path: r.Path}), nil
+	case OpKindDirDelete.String():
+		del := NewDirDelete(r.ID, FilePath{path: r.Path})
+		del.Mode = r.Mode
+		return del, nil
+	case OpKindDirRemoveAll.String():
+		return NewDirRemoveAll(r.ID, FilePath{path: r.Path}), nil
+	case OpKindFileMove.String():
+		return NewFileMove(r.ID, TargetPath{path: r.Source}, FilePath{path: r.Target}), nil
+	case OpKindFileBackup.String():
+		return NewFileBackup(r.ID, FilePath{path: r.Source}, FilePath{path: r.Target}), nil
+	case OpKindDirCopy.String():
+		return NewDirCopy(r.ID, FilePath{path: r.Source}, FilePath{path: r.Target}), nil
+	case OpKindFileStash.String():
+		return NewFileStash(r.ID, FilePath{path: r.Source}, FilePath{path: r.Path}, r.Hash), nil
+	case OpKindFileRestore.String():
+		return NewFileRestore(r.ID, FilePath{path: r.Target}, FilePath{path: r.Path}, r.Hash, r.PlanID), nil
+	case OpKindFileRender.String():
+		render := NewFileRender(r.ID, FilePath{path: r.Source}, FilePath{path: r.Target}, r.Content)
+		render.Secret = r.Secret
+		render.Previous, render.PreviousMode = r.Previous, r.Mode
+		return render, nil
+	default:
+		return nil, fmt.Errorf("unknown operation kind %q for operation %s", r.Kind, r.ID)
+	}
+}
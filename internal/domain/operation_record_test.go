@@ -0,0 +1,66 @@
+package domain_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/domain"
+)
+
+func TestOperationRecord_RoundTrip(t *testing.T) {
+	source := domain.MustParsePath("/packages/vim/vimrc")
+	target := domain.MustParseTargetPath("/home/.vimrc")
+	dir := domain.MustParsePath("/home/.config")
+	secretRender := domain.NewFileRender("render-secret", source, domain.MustParsePath("/cache/netrc"), "password")
+	secretRender.Secret = true
+	capturedRender := domain.NewFileRender("render-captured", source, domain.MustParsePath("/cache/vimrc"), "rendered")
+	capturedRender.Previous, capturedRender.PreviousMode = "old", 0600
+	capturedUnlink := domain.NewLinkDelete("unlink-captured", target)
+	capturedUnlink.Previous = source.String()
+	capturedRmdir := domain.NewDirDelete("rmdir-captured", dir)
+	capturedRmdir.Mode = 0700
+
+	ops := []domain.Operation{
+		domain.NewLinkCreate("link", source, target),
+		domain.NewLinkDelete("unlink", target),
+		capturedUnlink,
+		domain.NewDirCreate("mkdir", dir),
+		domain.NewDirDelete("rmdir", dir),
+		capturedRmdir,
+		domain.NewDirRemoveAll("rmall", dir),
+		domain.NewFileMove("move", target, source),
+		domain.NewFileBackup("backup", source, domain.MustParsePath("/home/.vimrc.bak")),
+		domain.NewDirCopy("copy", dir, domain.MustParsePath("/packages/config")),
+		domain.NewFileStash("stash", source, domain.MustParsePath("/home/.dot-backup"), "abc123"),
+		domain.NewFileRender("render", source, domain.MustParsePath("/cache/vimrc"), "rendered"),
+		secretRender,
+		capturedRender,
+	}
+
+	for _, op := range ops {
+		t.Run(op.Kind().String(), func(t *testing.T) {
+			rec, err := domain.NewOperationRecord(op)
+			require.NoError(t, err)
+			assert.Equal(t, op.Kind().String(), rec.Kind)
+
+			// Records survive JSON encoding
+			data, err := json.Marshal(rec)
+			require.NoError(t, err)
+			var decoded domain.OperationRecord
+			require.NoError(t, json.Unmarshal(data, &decoded))
+
+			restored, err := decoded.Operation()
+			require.NoError(t, err)
+			assert.Equal(t, op, restored)
+		})
+	}
+}
+
+func TestOperationRecord_UnknownKind(t *testing.T) {
+	_, err := domain.OperationRecord{ID: "op", Kind: "Teleport"}.Operation()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Teleport")
+}
@@ -257,6 +257,7 @@ func TestOperationKindString(t *testing.T) {
 		{domain.OpKindFileMove, "FileMove"},
 		{domain.OpKindFileBackup, "FileBackup"},
 		{domain.OpKindDirCopy, "DirCopy"},
+		{domain.OpKindFileRender, "FileRender"},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,162 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// batchColors are the fill colors of successive batches in plan graphs.
+// Colors repeat for plans with more batches than colors.
+var batchColors = []string{
+	"#a6cee3", "#b2df8a", "#fdbf6f", "#cab2d6", "#fb9a99", "#ffff99", "#8dd3c7", "#d9d9d9",
+}
+
+// ToDOT returns the dependency graph of the plan in the DOT language of
+// Graphviz. Each operation is a node, filled with the color of the batch
+// it runs in and grouped in a cluster per batch, and each edge points from
+// an operation to one that must run after it.
+//
+// Render it with, for example, dot -Tsvg.
+func (p Plan) ToDOT() string {
+	var sb strings.Builder
+	sb.WriteString("digraph plan {\n")
+	sb.WriteString("  rankdir=LR;\n")
+	sb.WriteString("  node [shape=box, style=\"rounded,filled\", fontname=\"Helvetica\"];\n")
+
+	for i, batch := range p.graphBatches() {
+		fmt.Fprintf(&sb, "  subgraph cluster_batch_%d {\n", i+1)
+		fmt.Fprintf(&sb, "    label=%s;\n", dotQuote(fmt.Sprintf("batch %d", i+1)))
+		sb.WriteString("    style=dashed;\n")
+		for _, op := range batch {
+			fmt.Fprintf(&sb, "    %s [label=%s, fillcolor=%s];\n",
+				dotQuote(string(op.ID())), dotQuote(op.String()), dotQuote(batchColor(i)))
+		}
+		sb.WriteString("  }\n")
+	}
+
+	for _, edge := range p.graphEdges() {
+		fmt.Fprintf(&sb, "  %s -> %s;\n", dotQuote(string(edge[0])), dotQuote(string(edge[1])))
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// ToMermaid returns the dependency graph of the plan as a Mermaid
+// flowchart, laid out like ToDOT with a subgraph and a color per batch.
+func (p Plan) ToMermaid() string {
+	var sb strings.Builder
+	sb.WriteString("flowchart LR\n")
+
+	// Operation IDs may hold characters Mermaid does not allow in node
+	// IDs, so nodes are numbered
+	nodes := make(map[OperationID]string, len(p.Operations))
+	batches := p.graphBatches()
+	for i, batch := range batches {
+		fmt.Fprintf(&sb, "  subgraph batch%d[%s]\n", i+1, mermaidQuote(fmt.Sprintf("batch %d", i+1)))
+		for _, op := range batch {
+			node := fmt.Sprintf("op%d", len(nodes)+1)
+			nodes[op.ID()] = node
+			fmt.Fprintf(&sb, "    %s[%s]\n", node, mermaidQuote(op.String()))
+		}
+		sb.WriteString("  end\n")
+	}
+
+	for _, edge := range p.graphEdges() {
+		fmt.Fprintf(&sb, "  %s --> %s\n", nodes[edge[0]], nodes[edge[1]])
+	}
+
+	for i, batch := range batches {
+		names := make([]string, 0, len(batch))
+		for _, op := range batch {
+			names = append(names, nodes[op.ID()])
+		}
+		fmt.Fprintf(&sb, "  classDef batch%d fill:%s,stroke:#555\n", i+1, batchColor(i))
+		fmt.Fprintf(&sb, "  class %s batch%d\n", strings.Join(names, ","), i+1)
+	}
+	return sb.String()
+}
+
+// graphBatches returns the operations of the plan grouped into the
+// batches they run in. Computed parallel batches are used when present;
+// otherwise an operation runs in the batch after the last of its
+// dependencies, as the planner batches them. Operations keep plan order
+// within a batch.
+func (p Plan) graphBatches() [][]Operation {
+	if len(p.Batches) > 0 {
+		return p.Batches
+	}
+
+	inPlan := make(map[OperationID]bool, len(p.Operations))
+	for _, op := range p.Operations {
+		inPlan[op.ID()] = true
+	}
+
+	levels := make(map[OperationID]int, len(p.Operations))
+	visiting := make(map[OperationID]bool)
+	var level func(id OperationID) int
+	level = func(id OperationID) int {
+		if l, ok := levels[id]; ok {
+			return l
+		}
+		if visiting[id] {
+			// A cycle, which Validate reports; break it here
+			return 0
+		}
+		visiting[id] = true
+		l := 0
+		for _, dep := range p.Dependencies[id] {
+			if inPlan[dep] {
+				l = max(l, level(dep)+1)
+			}
+		}
+		visiting[id] = false
+		levels[id] = l
+		return l
+	}
+
+	var batches [][]Operation
+	for _, op := range p.Operations {
+		l := level(op.ID())
+		for len(batches) <= l {
+			batches = append(batches, nil)
+		}
+		batches[l] = append(batches[l], op)
+	}
+	return batches
+}
+
+// graphEdges returns the dependency edges between operations of the plan
+// as pairs of the operation that runs first and the one that waits for
+// it, in plan order.
+func (p Plan) graphEdges() [][2]OperationID {
+	inPlan := make(map[OperationID]bool, len(p.Operations))
+	for _, op := range p.Operations {
+		inPlan[op.ID()] = true
+	}
+
+	var edges [][2]OperationID
+	for _, op := range p.Operations {
+		for _, dep := range p.Dependencies[op.ID()] {
+			if inPlan[dep] {
+				edges = append(edges, [2]OperationID{dep, op.ID()})
+			}
+		}
+	}
+	return edges
+}
+
+// batchColor returns the fill color of the batch with index i.
+func batchColor(i int) string {
+	return batchColors[i%len(batchColors)]
+}
+
+// dotQuote returns s as a quoted DOT string.
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// mermaidQuote returns s as a quoted Mermaid label, with the characters
+// Mermaid would read as markup written as entities.
+func mermaidQuote(s string) string {
+	return `"` + strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;", "\n", " ").Replace(s) + `"`
+}
@@ -0,0 +1,71 @@
+package domain_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jamesainslie/dot/internal/domain"
+)
+
+// graphPlan returns a plan creating a directory and two links in it.
+func graphPlan() domain.Plan {
+	dir := domain.NewDirCreate("dir", domain.MustParsePath("/home/.vim"))
+	vimrc := domain.NewLinkCreate("vimrc", domain.MustParsePath("/pkg/vim/vimrc"), domain.MustParseTargetPath("/home/.vim/vimrc"))
+	colors := domain.NewLinkCreate("colors", domain.MustParsePath("/pkg/vim/colors"), domain.MustParseTargetPath("/home/.vim/colors"))
+	return domain.Plan{
+		Operations: []domain.Operation{dir, vimrc, colors},
+		Dependencies: map[domain.OperationID][]domain.OperationID{
+			"vimrc":  {"dir"},
+			"colors": {"dir"},
+		},
+	}
+}
+
+func TestPlan_ToDOT(t *testing.T) {
+	graph := graphPlan().ToDOT()
+
+	assert.True(t, strings.HasPrefix(graph, "digraph plan {\n"))
+	assert.Contains(t, graph, "subgraph cluster_batch_1 {")
+	assert.Contains(t, graph, "subgraph cluster_batch_2 {")
+	assert.NotContains(t, graph, "cluster_batch_3")
+	assert.Contains(t, graph, `"dir" [label="create directory /home/.vim", fillcolor="#a6cee3"];`)
+	assert.Contains(t, graph, `"vimrc" [label="create link /home/.vim/vimrc -> /pkg/vim/vimrc", fillcolor="#b2df8a"];`)
+	assert.Contains(t, graph, `"dir" -> "vimrc";`)
+	assert.Contains(t, graph, `"dir" -> "colors";`)
+}
+
+func TestPlan_ToDOT_UsesComputedBatches(t *testing.T) {
+	plan := graphPlan()
+	plan.Batches = [][]domain.Operation{plan.Operations[:1], plan.Operations[1:2], plan.Operations[2:]}
+
+	assert.Contains(t, plan.ToDOT(), "subgraph cluster_batch_3 {")
+}
+
+func TestPlan_ToDOT_Escapes(t *testing.T) {
+	op := domain.NewDirCreate(`say "hi"`, domain.MustParsePath(`/home/"quoted"`))
+	graph := domain.Plan{Operations: []domain.Operation{op}}.ToDOT()
+
+	assert.Contains(t, graph, `"say \"hi\"" [label="create directory /home/\"quoted\""`)
+}
+
+func TestPlan_ToMermaid(t *testing.T) {
+	graph := graphPlan().ToMermaid()
+
+	assert.True(t, strings.HasPrefix(graph, "flowchart LR\n"))
+	assert.Contains(t, graph, `subgraph batch1["batch 1"]`)
+	assert.Contains(t, graph, `op1["create directory /home/.vim"]`)
+	assert.Contains(t, graph, `op2["create link /home/.vim/vimrc -#gt; /pkg/vim/vimrc"]`)
+	assert.Contains(t, graph, "op1 --> op2")
+	assert.Contains(t, graph, "op1 --> op3")
+	assert.Contains(t, graph, "classDef batch2 fill:#b2df8a")
+	assert.Contains(t, graph, "class op2,op3 batch2")
+}
+
+func TestPlan_ToDOT_CycleTerminates(t *testing.T) {
+	plan := graphPlan()
+	plan.Dependencies["dir"] = []domain.OperationID{"vimrc"}
+
+	assert.Contains(t, plan.ToDOT(), `"vimrc" -> "dir";`)
+}
@@ -0,0 +1,199 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Validate checks if the plan is valid.
+//
+// Beyond validating each operation, it checks the plan as a whole:
+// operation IDs are unique, the declared dependencies refer to operations
+// of the plan and form no cycle, no two operations produce the same path,
+// no parallel batch both creates and deletes a path, and every ID in
+// PackageOperations belongs to an operation of the plan. All problems
+// found are reported, aggregated in an ErrMultiple when there are several.
+func (p Plan) Validate() error {
+	var errs []error
+	ids := make(map[OperationID]bool, len(p.Operations))
+	for _, op := range p.Operations {
+		if err := op.Validate(); err != nil {
+			return err
+		}
+		if ids[op.ID()] {
+			errs = append(errs, ErrInvalidPlan{Reason: fmt.Sprintf("duplicate operation ID %s", op.ID())})
+		}
+		ids[op.ID()] = true
+	}
+
+	errs = append(errs, p.validateDependencies(ids)...)
+	errs = append(errs, p.validatePaths()...)
+
+	known := make(map[OperationID]bool, len(ids)+len(p.Satisfied))
+	for id := range ids {
+		known[id] = true
+	}
+	for _, op := range p.Satisfied {
+		known[op.ID()] = true
+	}
+	for _, pkg := range sortedKeys(p.PackageOperations) {
+		for _, id := range p.PackageOperations[pkg] {
+			if !known[id] {
+				errs = append(errs, ErrInvalidPlan{Reason: fmt.Sprintf("package %s lists unknown operation %s", pkg, id)})
+			}
+		}
+	}
+
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	default:
+		return ErrMultiple{Errors: errs}
+	}
+}
+
+// validateDependencies checks that declared dependencies refer to
+// operations in ids and contain no cycle.
+func (p Plan) validateDependencies(ids map[OperationID]bool) []error {
+	var errs []error
+	froms := sortedKeys(p.Dependencies)
+	for _, id := range froms {
+		if !ids[id] {
+			errs = append(errs, ErrInvalidPlan{Reason: fmt.Sprintf("dependencies declared for unknown operation %s", id)})
+		}
+		for _, dep := range p.Dependencies[id] {
+			if !ids[dep] {
+				errs = append(errs, ErrInvalidPlan{Reason: fmt.Sprintf("operation %s depends on unknown operation %s", id, dep)})
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[OperationID]int, len(p.Dependencies))
+	var path []OperationID
+	var visit func(id OperationID) []string
+	visit = func(id OperationID) []string {
+		switch state[id] {
+		case visiting:
+			for i, onPath := range path {
+				if onPath == id {
+					cycle := make([]string, 0, len(path)-i+1)
+					for _, c := range path[i:] {
+						cycle = append(cycle, string(c))
+					}
+					return append(cycle, string(id))
+				}
+			}
+		case done:
+			return nil
+		}
+		state[id] = visiting
+		path = append(path, id)
+		for _, dep := range p.Dependencies[id] {
+			if cycle := visit(dep); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		state[id] = done
+		return nil
+	}
+	for _, id := range froms {
+		if state[id] == unvisited {
+			if cycle := visit(id); cycle != nil {
+				errs = append(errs, ErrCyclicDependency{Cycle: cycle})
+				break
+			}
+		}
+	}
+	return errs
+}
+
+// validatePaths checks that no two operations produce the same path and
+// that no parallel batch both creates and deletes a path. Directories may
+// be created more than once, as plans merged from several packages share
+// parent directories; sequential plans order everything else by position.
+func (p Plan) validatePaths() []error {
+	var errs []error
+	producers := make(map[string]OperationID)
+	for _, op := range p.Operations {
+		created, _ := opPaths(op)
+		if created == "" {
+			continue
+		}
+		if _, isDir := op.(DirCreate); isDir {
+			continue
+		}
+		if other, exists := producers[created]; exists {
+			errs = append(errs, ErrInvalidPlan{Reason: fmt.Sprintf("operations %s and %s both create %s", other, op.ID(), created)})
+			continue
+		}
+		producers[created] = op.ID()
+	}
+
+	for i, batch := range p.Batches {
+		creates := make(map[string]OperationID)
+		deletes := make(map[string]OperationID)
+		for _, op := range batch {
+			created, deleted := opPaths(op)
+			if created != "" {
+				creates[created] = op.ID()
+			}
+			if deleted != "" {
+				deletes[deleted] = op.ID()
+			}
+		}
+		for _, path := range sortedKeys(creates) {
+			if deleter, exists := deletes[path]; exists {
+				errs = append(errs, ErrInvalidPlan{Reason: fmt.Sprintf("batch %d creates %s with %s and deletes it with %s without ordering", i, path, creates[path], deleter)})
+			}
+		}
+	}
+	return errs
+}
+
+// opPaths returns the path an operation creates and the path it removes,
+// either of which may be empty.
+func opPaths(op Operation) (created, deleted string) {
+	switch typed := op.(type) {
+	case LinkCreate:
+		return typed.Target.String(), ""
+	case LinkDelete:
+		return "", typed.Target.String()
+	case DirCreate:
+		return typed.Path.String(), ""
+	case DirDelete:
+		return "", typed.Path.String()
+	case DirRemoveAll:
+		return "", typed.Path.String()
+	case FileMove:
+		return typed.Dest.String(), typed.Source.String()
+	case FileBackup:
+		return typed.Backup.String(), ""
+	case DirCopy:
+		return typed.Dest.String(), ""
+	case FileStash:
+		return "", typed.Source.String()
+	case FileRestore:
+		return typed.Path.String(), ""
+	case FileRender:
+		return typed.Dest.String(), ""
+	}
+	return "", ""
+}
+
+// sortedKeys returns the keys of m in sorted order for stable reports.
+func sortedKeys[K ~string, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
@@ -3,6 +3,7 @@ package domain
 import (
 	"context"
 	"os"
+	"time"
 )
 
 // FS defines the filesystem abstraction interface.
@@ -28,6 +29,34 @@ type FS interface {
 	IsSymlink(ctx context.Context, path string) (bool, error)
 }
 
+// MetadataFS is implemented by filesystems that can change the metadata
+// of existing files. Operations that copy files use it, when available,
+// to give the copy the mode and times of the original.
+type MetadataFS interface {
+	Chmod(ctx context.Context, path string, mode os.FileMode) error
+	Chtimes(ctx context.Context, path string, atime, mtime time.Time) error
+}
+
+// HardlinkFS is implemented by filesystems that can create hard links and
+// count the names of a file. Adoption uses it, when available, to notice
+// files whose other hard links moving them would break.
+type HardlinkFS interface {
+	Link(ctx context.Context, oldname, newname string) error
+
+	// LinkCount returns the number of hard links to the file at path, or
+	// 0 when the platform does not report it.
+	LinkCount(ctx context.Context, path string) (uint64, error)
+}
+
+// AppendFS is implemented by filesystems that can append to a file in a
+// single write. The audit log uses it, when available, so that records
+// are never rewritten.
+type AppendFS interface {
+	// AppendFile appends data to the file at path, creating it with perm
+	// if it does not exist.
+	AppendFile(ctx context.Context, path string, data []byte, perm os.FileMode) error
+}
+
 // FileInfo provides information about a file.
 type FileInfo interface {
 	Name() string
@@ -88,6 +117,21 @@ type Metrics interface {
 	Gauge(name string, labels ...string) Gauge
 }
 
+// EventSink receives the progress of plan execution, one event per
+// operation step. Events of one execution are sent from a single goroutine
+// in the order they happen.
+type EventSink interface {
+	OnEvent(ctx context.Context, event ExecutionEvent)
+}
+
+// EventSinkFunc adapts a function to the EventSink interface.
+type EventSinkFunc func(ctx context.Context, event ExecutionEvent)
+
+// OnEvent calls f(ctx, event).
+func (f EventSinkFunc) OnEvent(ctx context.Context, event ExecutionEvent) {
+	f(ctx, event)
+}
+
 // Counter represents a monotonically increasing metric.
 type Counter interface {
 	Inc(labels ...string)
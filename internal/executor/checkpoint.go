@@ -12,22 +12,116 @@ import (
 // CheckpointID uniquely identifies a checkpoint.
 type CheckpointID string
 
+// CheckpointStatus describes how the execution recorded by a checkpoint ended.
+type CheckpointStatus string
+
+const (
+	// CheckpointPending marks an execution that is in progress or was
+	// interrupted before it finished.
+	CheckpointPending CheckpointStatus = "pending"
+
+	// CheckpointCompleted marks an execution whose operations all succeeded.
+	CheckpointCompleted CheckpointStatus = "completed"
+
+	// CheckpointRolledBack marks an execution whose operations were undone.
+	CheckpointRolledBack CheckpointStatus = "rolled_back"
+)
+
 // Checkpoint records executed operations for rollback.
+//
+// A checkpoint also journals the plan being executed, so that an execution
+// interrupted part way can later be resumed from the operations that never
+// ran.
 type Checkpoint struct {
 	ID         CheckpointID
 	CreatedAt  time.Time
+	Status     CheckpointStatus
 	operations map[domain.OperationID]domain.Operation
+	order      []domain.OperationID
+	plan       *domain.Plan
+	packages   map[domain.OperationID]string
 	mu         sync.RWMutex
+
+	// onChange, if set, is called after the plan is journaled and after each
+	// recorded operation so that persistent stores can save progress as
+	// execution proceeds.
+	onChange func(*Checkpoint)
+}
+
+// SetPlan journals the plan the checkpoint's execution intends to apply.
+// The executor calls it before executing any operation.
+func (c *Checkpoint) SetPlan(plan domain.Plan) {
+	c.mu.Lock()
+	c.plan = &plan
+	c.packages = nil
+	onChange := c.onChange
+	c.mu.Unlock()
+
+	if onChange != nil {
+		onChange(c)
+	}
+}
+
+// Plan returns the journaled plan, or false if no plan was journaled.
+func (c *Checkpoint) Plan() (domain.Plan, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.plan == nil {
+		return domain.Plan{}, false
+	}
+	return *c.plan, true
+}
+
+// Package returns the package that the operation id of the journaled plan
+// belongs to, or "" if it is not known.
+func (c *Checkpoint) Package(id domain.OperationID) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.packages == nil && c.plan != nil {
+		c.packages = make(map[domain.OperationID]string)
+		for pkg, ids := range c.plan.PackageOperations {
+			for _, opID := range ids {
+				c.packages[opID] = pkg
+			}
+		}
+	}
+	return c.packages[id]
+}
+
+// Remaining returns the journaled operations that have not been recorded
+// as executed, in plan order.
+func (c *Checkpoint) Remaining() []domain.Operation {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.plan == nil {
+		return nil
+	}
+
+	remaining := make([]domain.Operation, 0, len(c.plan.Operations))
+	for _, op := range c.plan.Operations {
+		if _, executed := c.operations[op.ID()]; !executed {
+			remaining = append(remaining, op)
+		}
+	}
+	return remaining
 }
 
 // Record stores an executed operation in the checkpoint.
 func (c *Checkpoint) Record(id domain.OperationID, op domain.Operation) {
 	c.mu.Lock()
-	defer c.mu.Unlock()
 	if c.operations == nil {
 		c.operations = make(map[domain.OperationID]domain.Operation)
 	}
+	if _, exists := c.operations[id]; !exists {
+		c.order = append(c.order, id)
+	}
 	c.operations[id] = op
+	onChange := c.onChange
+	c.mu.Unlock()
+
+	if onChange != nil {
+		onChange(c)
+	}
 }
 
 // Lookup retrieves an operation from the checkpoint.
@@ -47,19 +141,27 @@ func (c *Checkpoint) GetOperation(id domain.OperationID) (domain.Operation, bool
 	return op, exists
 }
 
-// ListOperations returns a snapshot of all operations in the checkpoint.
+// ListOperations returns a snapshot of all operations in the checkpoint
+// in the order they were recorded.
 // The returned slice is a copy and safe to use concurrently.
 func (c *Checkpoint) ListOperations() []domain.Operation {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	ops := make([]domain.Operation, 0, len(c.operations))
-	for _, op := range c.operations {
-		ops = append(ops, op)
+	ops := make([]domain.Operation, 0, len(c.order))
+	for _, id := range c.order {
+		ops = append(ops, c.operations[id])
 	}
 	return ops
 }
 
+// ExecutedIDs returns the IDs of recorded operations in execution order.
+func (c *Checkpoint) ExecutedIDs() []domain.OperationID {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]domain.OperationID(nil), c.order...)
+}
+
 // Len returns the number of operations in the checkpoint.
 func (c *Checkpoint) Len() int {
 	c.mu.RLock()
@@ -74,6 +176,13 @@ type CheckpointStore interface {
 	Restore(ctx context.Context, id CheckpointID) (*Checkpoint, error)
 }
 
+// CheckpointFinisher is implemented by stores that keep checkpoints after
+// execution ends so that completed plans can be rolled back later.
+// The executor calls Finish instead of Delete when the store supports it.
+type CheckpointFinisher interface {
+	Finish(ctx context.Context, id CheckpointID, status CheckpointStatus) error
+}
+
 // MemoryCheckpointStore keeps checkpoints in memory.
 // Suitable for testing and simple cases where persistence is not required.
 type MemoryCheckpointStore struct {
@@ -96,6 +205,7 @@ func (s *MemoryCheckpointStore) Create(ctx context.Context) *Checkpoint {
 	checkpoint := &Checkpoint{
 		ID:        id,
 		CreatedAt: time.Now(),
+		Status:    CheckpointPending,
 		// operations map lazily initialized in Record()
 	}
 	s.checkpoints[id] = checkpoint
@@ -0,0 +1,86 @@
+package executor
+
+import (
+	"context"
+	"time"
+
+	"github.com/jamesainslie/dot/internal/domain"
+)
+
+// progressReporter reports the operations of one execution or rollback to
+// the event sink. It is only used from the goroutine driving the execution;
+// a nil reporter reports nothing.
+type progressReporter struct {
+	sink         domain.EventSink
+	planID       string
+	checkpointID string
+	packages     map[domain.OperationID]string
+	total        int
+	completed    int
+	start        time.Time
+}
+
+// newProgress starts reporting on total operations of the plan journaled
+// by checkpoint. Without an event sink nothing is reported.
+func (e *Executor) newProgress(checkpoint *Checkpoint, total int) *progressReporter {
+	p := &progressReporter{sink: e.events, total: total, start: time.Now()}
+	if e.events == nil {
+		return p
+	}
+	p.checkpointID = string(checkpoint.ID)
+	if plan, ok := checkpoint.Plan(); ok {
+		p.planID = plan.ID
+		p.packages = make(map[domain.OperationID]string)
+		for pkg, ids := range plan.PackageOperations {
+			for _, id := range ids {
+				p.packages[id] = pkg
+			}
+		}
+	}
+	return p
+}
+
+// started reports that op is about to run.
+func (p *progressReporter) started(ctx context.Context, op domain.Operation) {
+	p.send(ctx, domain.EventStarted, op, 0, nil)
+}
+
+// finished reports that op ran for duration, failing with err if set.
+func (p *progressReporter) finished(ctx context.Context, op domain.Operation, duration time.Duration, err error) {
+	if p == nil {
+		return
+	}
+	p.completed++
+	kind := domain.EventSucceeded
+	if err != nil {
+		kind = domain.EventFailed
+	}
+	p.send(ctx, kind, op, duration, err)
+}
+
+// rolledBack reports that op was undone, or could not be if err is set.
+func (p *progressReporter) rolledBack(ctx context.Context, op domain.Operation, duration time.Duration, err error) {
+	if p == nil {
+		return
+	}
+	p.completed++
+	p.send(ctx, domain.EventRolledBack, op, duration, err)
+}
+
+func (p *progressReporter) send(ctx context.Context, kind domain.ExecutionEventKind, op domain.Operation, duration time.Duration, err error) {
+	if p == nil || p.sink == nil {
+		return
+	}
+	p.sink.OnEvent(ctx, domain.ExecutionEvent{
+		Kind:         kind,
+		Operation:    op,
+		PlanID:       p.planID,
+		CheckpointID: p.checkpointID,
+		Package:      p.packages[op.ID()],
+		Completed:    p.completed,
+		Total:        p.total,
+		Duration:     duration,
+		Elapsed:      time.Since(p.start),
+		Err:          err,
+	})
+}
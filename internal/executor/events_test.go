@@ -0,0 +1,186 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/internal/domain"
+)
+
+// recordingSink collects the events it receives.
+type recordingSink struct {
+	events []domain.ExecutionEvent
+}
+
+func (s *recordingSink) OnEvent(ctx context.Context, event domain.ExecutionEvent) {
+	s.events = append(s.events, event)
+}
+
+func (s *recordingSink) kinds() []domain.ExecutionEventKind {
+	kinds := make([]domain.ExecutionEventKind, len(s.events))
+	for i, event := range s.events {
+		kinds[i] = event.Kind
+	}
+	return kinds
+}
+
+func newEventsTest(t *testing.T) (*adapters.MemFS, *Executor, *recordingSink) {
+	t.Helper()
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	require.NoError(t, fs.MkdirAll(ctx, "/packages/pkg", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/home", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/packages/pkg/file1", []byte("content1"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/packages/pkg/file2", []byte("content2"), 0644))
+
+	sink := &recordingSink{}
+	exec := New(Opts{
+		FS:     fs,
+		Logger: adapters.NewNoopLogger(),
+		Tracer: adapters.NewNoopTracer(),
+		Events: sink,
+	})
+	return fs, exec, sink
+}
+
+func TestExecute_ReportsEvents(t *testing.T) {
+	ctx := context.Background()
+	_, exec, sink := newEventsTest(t)
+
+	plan := domain.Plan{Operations: []domain.Operation{
+		domain.NewLinkCreate("link1", domain.MustParsePath("/packages/pkg/file1"), domain.MustParseTargetPath("/home/file1")),
+		domain.NewLinkCreate("link2", domain.MustParsePath("/packages/pkg/file2"), domain.MustParseTargetPath("/home/file2")),
+	}}
+	require.True(t, exec.Execute(ctx, plan).IsOk())
+
+	assert.Equal(t, []domain.ExecutionEventKind{
+		domain.EventStarted, domain.EventSucceeded,
+		domain.EventStarted, domain.EventSucceeded,
+	}, sink.kinds())
+
+	last := sink.events[3]
+	assert.Equal(t, domain.OperationID("link2"), last.Operation.ID())
+	assert.Equal(t, 2, last.Completed)
+	assert.Equal(t, 2, last.Total)
+	assert.GreaterOrEqual(t, last.Elapsed, last.Duration)
+	assert.Equal(t, 0, sink.events[0].Completed)
+}
+
+func TestExecute_ReportsFailureAndRollback(t *testing.T) {
+	ctx := context.Background()
+	fs, exec, sink := newEventsTest(t)
+
+	plan := domain.Plan{Operations: []domain.Operation{
+		domain.NewLinkCreate("link1", domain.MustParsePath("/packages/pkg/file1"), domain.MustParseTargetPath("/home/file1")),
+		domain.NewDirDelete("rmdir", domain.MustParsePath("/home/missing")),
+	}}
+	require.True(t, exec.Execute(ctx, plan).IsErr())
+	assert.False(t, fs.Exists(ctx, "/home/file1"))
+
+	assert.Equal(t, []domain.ExecutionEventKind{
+		domain.EventStarted, domain.EventSucceeded,
+		domain.EventStarted, domain.EventFailed,
+		domain.EventRolledBack,
+	}, sink.kinds())
+
+	failed := sink.events[3]
+	assert.Equal(t, domain.OperationID("rmdir"), failed.Operation.ID())
+	assert.Error(t, failed.Err)
+
+	rolledBack := sink.events[4]
+	assert.Equal(t, domain.OperationID("link1"), rolledBack.Operation.ID())
+	assert.Equal(t, 1, rolledBack.Completed)
+	assert.Equal(t, 1, rolledBack.Total)
+	assert.NoError(t, rolledBack.Err)
+}
+
+func TestExecute_ParallelReportsEveryOperation(t *testing.T) {
+	ctx := context.Background()
+	_, exec, sink := newEventsTest(t)
+
+	link1 := domain.NewLinkCreate("link1", domain.MustParsePath("/packages/pkg/file1"), domain.MustParseTargetPath("/home/file1"))
+	link2 := domain.NewLinkCreate("link2", domain.MustParsePath("/packages/pkg/file2"), domain.MustParseTargetPath("/home/file2"))
+	plan := domain.Plan{
+		Operations: []domain.Operation{link1, link2},
+		Batches:    [][]domain.Operation{{link1, link2}},
+	}
+	require.True(t, exec.Execute(ctx, plan).IsOk())
+
+	require.Len(t, sink.events, 4)
+	assert.ElementsMatch(t, []domain.ExecutionEventKind{
+		domain.EventStarted, domain.EventStarted,
+		domain.EventSucceeded, domain.EventSucceeded,
+	}, sink.kinds())
+	assert.Equal(t, 2, sink.events[3].Completed)
+}
+
+func TestExecute_EventsIdentifyPlan(t *testing.T) {
+	ctx := context.Background()
+	_, exec, sink := newEventsTest(t)
+
+	plan := domain.Plan{
+		Operations: []domain.Operation{
+			domain.NewLinkCreate("link1", domain.MustParsePath("/packages/pkg/file1"), domain.MustParseTargetPath("/home/file1")),
+		},
+		PackageOperations: map[string][]domain.OperationID{"pkg": {"link1"}},
+	}
+	require.True(t, exec.Execute(ctx, plan).IsOk())
+
+	require.Len(t, sink.events, 2)
+	for _, event := range sink.events {
+		assert.NotEmpty(t, event.PlanID)
+		assert.NotEmpty(t, event.CheckpointID)
+		assert.NotEqual(t, event.PlanID, event.CheckpointID)
+		assert.Equal(t, "pkg", event.Package)
+	}
+
+	plan.ID = "plan-1"
+	require.NoError(t, exec.fs.Remove(ctx, "/home/file1"))
+	require.True(t, exec.Execute(ctx, plan).IsOk())
+	assert.Equal(t, "plan-1", sink.events[3].PlanID)
+}
+
+func TestExecute_LogRecordsCarryPackage(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	require.NoError(t, fs.MkdirAll(ctx, "/packages/vim", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/packages/zsh", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/home", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/packages/vim/vimrc", []byte("set nu"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/packages/zsh/zshrc", []byte("bindkey -v"), 0644))
+
+	var buf bytes.Buffer
+	exec := New(Opts{
+		FS:     fs,
+		Logger: adapters.NewJSONLogger(&buf, slog.LevelDebug),
+		Tracer: adapters.NewNoopTracer(),
+	})
+	plan := domain.Plan{
+		Operations: []domain.Operation{
+			domain.NewLinkCreate("vim-link", domain.MustParsePath("/packages/vim/vimrc"), domain.MustParseTargetPath("/home/.vimrc")),
+			domain.NewLinkCreate("zsh-link", domain.MustParsePath("/packages/zsh/zshrc"), domain.MustParseTargetPath("/home/.zshrc")),
+		},
+		PackageOperations: map[string][]domain.OperationID{"vim": {"vim-link"}, "zsh": {"zsh-link"}},
+	}
+	require.True(t, exec.Execute(ctx, plan).IsOk())
+
+	// Records of operations, which may run in parallel, can be grouped by
+	// package
+	packages := make(map[string]string)
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		var record map[string]any
+		require.NoError(t, json.Unmarshal(line, &record))
+		if event := record[adapters.LogFieldEvent]; event == "executing_operation" || event == "executing_operation_parallel" {
+			packages[record[adapters.LogFieldOpID].(string)] = record[adapters.LogFieldPackage].(string)
+			assert.NotEmpty(t, record[adapters.LogFieldPlanID])
+		}
+	}
+	assert.Equal(t, map[string]string{"vim-link": "vim", "zsh-link": "zsh"}, packages)
+}
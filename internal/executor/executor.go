@@ -5,16 +5,23 @@ package executor
 import (
 	"context"
 	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/google/uuid"
 
 	"github.com/jamesainslie/dot/internal/domain"
 )
 
 // Executor executes validated plans with transaction safety.
 type Executor struct {
-	fs         domain.FS
-	log        domain.Logger
-	tracer     domain.Tracer
-	checkpoint CheckpointStore
+	fs          domain.FS
+	log         domain.Logger
+	tracer      domain.Tracer
+	checkpoint  CheckpointStore
+	events      domain.EventSink
+	metrics     domain.Metrics
+	maxParallel int
 }
 
 // Opts configures executor creation.
@@ -24,26 +31,52 @@ type Opts struct {
 	Tracer     domain.Tracer
 	Metrics    domain.Metrics
 	Checkpoint CheckpointStore
+
+	// Events receives an event as each operation starts, succeeds, fails
+	// or is rolled back. Optional.
+	Events domain.EventSink
+
+	// MaxParallel bounds the operations of a parallel batch that run at
+	// once. If zero, defaults to runtime.NumCPU().
+	MaxParallel int
 }
 
 // New creates a new Executor with the given options.
 // If no checkpoint store is provided, a memory-based store is used.
-// For metrics collection, wrap the returned executor with NewInstrumented().
+// Metrics receives the size, duration and outcome of each execution and
+// rollback, and parallel batch measurements.
 func New(opts Opts) *Executor {
 	if opts.Checkpoint == nil {
 		opts.Checkpoint = NewMemoryCheckpointStore()
 	}
+	if opts.Metrics == nil {
+		opts.Metrics = domain.NewNoopMetrics()
+	}
+	if opts.MaxParallel <= 0 {
+		opts.MaxParallel = runtime.NumCPU()
+	}
 
 	return &Executor{
-		fs:         opts.FS,
-		log:        opts.Logger,
-		tracer:     opts.Tracer,
-		checkpoint: opts.Checkpoint,
+		fs:          opts.FS,
+		log:         opts.Logger,
+		tracer:      opts.Tracer,
+		checkpoint:  opts.Checkpoint,
+		events:      opts.Events,
+		metrics:     opts.Metrics,
+		maxParallel: opts.MaxParallel,
 	}
 }
 
 // Execute executes a plan with two-phase commit and automatic rollback on failure.
 func (e *Executor) Execute(ctx context.Context, plan domain.Plan) domain.Result[ExecutionResult] {
+	start := time.Now()
+	result := e.execute(ctx, plan)
+	recordExecution(e.metrics, plan, result, time.Since(start))
+	return result
+}
+
+// execute implements Execute.
+func (e *Executor) execute(ctx context.Context, plan domain.Plan) domain.Result[ExecutionResult] {
 	ctx, span := e.tracer.Start(ctx, "executor.Execute")
 	defer span.End()
 
@@ -65,8 +98,16 @@ func (e *Executor) Execute(ctx context.Context, plan domain.Plan) domain.Result[
 		return domain.Err[ExecutionResult](err)
 	}
 
-	// Create checkpoint before execution
+	if plan.ID == "" {
+		plan.ID = uuid.NewString()
+	}
+	ctx = domain.WithPlanID(ctx, plan.ID)
+
+	// Create checkpoint and journal the plan before execution
 	checkpoint := e.checkpoint.Create(ctx)
+	checkpoint.SetPlan(plan)
+	e = e.forPlan(checkpoint.ID)
+	ctx = domain.WithLogFields(ctx, domain.LogFields{PlanID: string(checkpoint.ID)})
 	e.log.Info(ctx, "checkpoint_created", "checkpoint_id", checkpoint.ID)
 
 	// Phase 2: Commit - execute operations
@@ -82,6 +123,7 @@ func (e *Executor) Execute(ctx context.Context, plan domain.Plan) domain.Result[
 		e.log.Warn(ctx, "execution_failed_rolling_back", "failed_count", len(result.Failed))
 		rolledBack := e.rollback(ctx, result.Executed, checkpoint)
 		result.RolledBack = rolledBack
+		e.finishCheckpoint(ctx, checkpoint.ID, CheckpointRolledBack)
 
 		err := domain.ErrExecutionFailed{
 			Executed:   len(result.Executed),
@@ -92,8 +134,12 @@ func (e *Executor) Execute(ctx context.Context, plan domain.Plan) domain.Result[
 		return domain.Err[ExecutionResult](err)
 	}
 
-	// Success - delete checkpoint
-	if err := e.checkpoint.Delete(ctx, checkpoint.ID); err != nil {
+	// Success - keep checkpoint if the store supports later rollback, otherwise delete it
+	if finisher, ok := e.checkpoint.(CheckpointFinisher); ok {
+		if err := finisher.Finish(ctx, checkpoint.ID, CheckpointCompleted); err != nil {
+			e.log.Warn(ctx, "checkpoint_finish_failed", "checkpoint_id", checkpoint.ID, "error", err)
+		}
+	} else if err := e.checkpoint.Delete(ctx, checkpoint.ID); err != nil {
 		e.log.Error(ctx, "checkpoint_delete_failed", "checkpoint_id", checkpoint.ID, "error", err)
 		return domain.Err[ExecutionResult](fmt.Errorf("checkpoint cleanup failed: %w", err))
 	}
@@ -103,6 +149,162 @@ func (e *Executor) Execute(ctx context.Context, plan domain.Plan) domain.Result[
 	return domain.Ok(result)
 }
 
+// Rollback undoes the operations recorded in a stored checkpoint, in reverse
+// execution order. It can recover from an execution that was interrupted or
+// undo a plan that completed. Checkpoints already rolled back are rejected.
+func (e *Executor) Rollback(ctx context.Context, id CheckpointID) domain.Result[ExecutionResult] {
+	ctx, span := e.tracer.Start(ctx, "executor.RollbackCheckpoint")
+	defer span.End()
+	e = e.forPlan(id)
+	ctx = domain.WithLogFields(ctx, domain.LogFields{PlanID: string(id)})
+
+	checkpoint, err := e.checkpoint.Restore(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+		return domain.Err[ExecutionResult](err)
+	}
+	if plan, ok := checkpoint.Plan(); ok {
+		ctx = domain.WithPlanID(ctx, plan.ID)
+	}
+	if checkpoint.Status == CheckpointRolledBack {
+		err := fmt.Errorf("checkpoint %s has already been rolled back", id)
+		span.RecordError(err)
+		return domain.Err[ExecutionResult](err)
+	}
+
+	executed := checkpoint.ExecutedIDs()
+	rolledBack, errs := e.rollbackOperations(ctx, executed, checkpoint)
+
+	result := ExecutionResult{
+		Executed:   executed,
+		Failed:     []domain.OperationID{},
+		RolledBack: rolledBack,
+		Errors:     []error{},
+	}
+	for _, opID := range executed {
+		if err, failed := errs[opID]; failed {
+			result.Failed = append(result.Failed, opID)
+			result.Errors = append(result.Errors, err)
+		}
+	}
+
+	if len(result.Failed) > 0 {
+		// Leave the checkpoint as it was so the rollback can be retried
+		return domain.Err[ExecutionResult](domain.ErrExecutionFailed{
+			Executed:   len(result.Executed),
+			Failed:     len(result.Failed),
+			RolledBack: len(result.RolledBack),
+			Errors:     result.Errors,
+		})
+	}
+
+	e.finishCheckpoint(ctx, id, CheckpointRolledBack)
+	e.metrics.Counter("executor.rollbacks.total", "trigger").Inc("checkpoint")
+	return domain.Ok(result)
+}
+
+// Resume completes an interrupted execution from its journaled plan.
+//
+// Operations recorded in the checkpoint are skipped and the remaining ones
+// run in plan order. A link found already pointing at its source, as when
+// execution stopped after creating it but before recording it, is recorded
+// without being recreated. If an operation fails, every operation of the
+// plan executed so far is rolled back, as a failed Execute would.
+func (e *Executor) Resume(ctx context.Context, id CheckpointID) domain.Result[ExecutionResult] {
+	ctx, span := e.tracer.Start(ctx, "executor.Resume")
+	defer span.End()
+	e = e.forPlan(id)
+	ctx = domain.WithLogFields(ctx, domain.LogFields{PlanID: string(id)})
+
+	checkpoint, err := e.checkpoint.Restore(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+		return domain.Err[ExecutionResult](err)
+	}
+	if checkpoint.Status != CheckpointPending {
+		err := fmt.Errorf("checkpoint %s is %s, only interrupted executions can be resumed", id, checkpoint.Status)
+		span.RecordError(err)
+		return domain.Err[ExecutionResult](err)
+	}
+	plan, ok := checkpoint.Plan()
+	if !ok {
+		err := fmt.Errorf("checkpoint %s has no journaled plan to resume", id)
+		span.RecordError(err)
+		return domain.Err[ExecutionResult](err)
+	}
+	ctx = domain.WithPlanID(ctx, plan.ID)
+
+	remaining := make([]domain.Operation, 0)
+	for _, op := range checkpoint.Remaining() {
+		if e.alreadyApplied(ctx, op) {
+			e.log.Debug(ctx, "operation_already_applied", "op_id", op.ID())
+			checkpoint.Record(op.ID(), op)
+			continue
+		}
+		remaining = append(remaining, op)
+	}
+	e.log.Info(ctx, "resuming_execution", "checkpoint_id", id, "remaining", len(remaining))
+
+	result := e.executeSequential(ctx, domain.Plan{Operations: remaining}, checkpoint)
+	if len(result.Failed) > 0 {
+		e.log.Warn(ctx, "resume_failed_rolling_back", "failed_count", len(result.Failed))
+		result.RolledBack = e.rollback(ctx, checkpoint.ExecutedIDs(), checkpoint)
+		e.finishCheckpoint(ctx, id, CheckpointRolledBack)
+
+		err := domain.ErrExecutionFailed{
+			Executed:   len(result.Executed),
+			Failed:     len(result.Failed),
+			RolledBack: len(result.RolledBack),
+			Errors:     result.Errors,
+		}
+		span.RecordError(err)
+		return domain.Err[ExecutionResult](err)
+	}
+
+	e.finishCheckpoint(ctx, id, CheckpointCompleted)
+	return domain.Ok(result)
+}
+
+// forPlan returns a copy of the executor whose log records carry the ID of
+// the checkpoint journaling the plan as plan_id, for loggers that do not
+// take it from the context.
+func (e *Executor) forPlan(id CheckpointID) *Executor {
+	scoped := *e
+	scoped.log = e.log.With("plan_id", string(id))
+	return &scoped
+}
+
+// operationContext returns the context operation id runs under, whose log
+// records carry its ID and package.
+func operationContext(ctx context.Context, checkpoint *Checkpoint, id domain.OperationID) context.Context {
+	return domain.WithLogFields(ctx, domain.LogFields{Package: checkpoint.Package(id), OperationID: string(id)})
+}
+
+// alreadyApplied reports whether a link operation's result already exists.
+// Other operations are either idempotent or cannot be told apart from a
+// conflicting change, so they are always executed.
+func (e *Executor) alreadyApplied(ctx context.Context, op domain.Operation) bool {
+	link, ok := op.(domain.LinkCreate)
+	if !ok {
+		return false
+	}
+	dest, err := e.fs.ReadLink(ctx, link.Target.String())
+	return err == nil && dest == link.Source.String()
+}
+
+// finishCheckpoint records the final status of a checkpoint when the store
+// keeps finished checkpoints. Failures are logged; they do not affect the
+// outcome of execution.
+func (e *Executor) finishCheckpoint(ctx context.Context, id CheckpointID, status CheckpointStatus) {
+	finisher, ok := e.checkpoint.(CheckpointFinisher)
+	if !ok {
+		return
+	}
+	if err := finisher.Finish(ctx, id, status); err != nil {
+		e.log.Warn(ctx, "checkpoint_finish_failed", "checkpoint_id", id, "error", err)
+	}
+}
+
 // prepare validates all operations and checks preconditions.
 func (e *Executor) prepare(ctx context.Context, plan domain.Plan) error {
 	ctx, span := e.tracer.Start(ctx, "executor.Prepare")
@@ -132,6 +334,20 @@ func (e *Executor) prepare(ctx context.Context, plan domain.Plan) error {
 		if moveOp, ok := op.(domain.FileMove); ok {
 			pendingFiles[moveOp.Dest.String()] = true
 		}
+
+		// Track copies into packages for subsequent link operations
+		if backupOp, ok := op.(domain.FileBackup); ok {
+			pendingFiles[backupOp.Backup.String()] = true
+		}
+
+		// Track rendered templates for subsequent link operations
+		if renderOp, ok := op.(domain.FileRender); ok {
+			pendingFiles[renderOp.Dest.String()] = true
+		}
+	}
+
+	if err := plan.Validate(); err != nil {
+		return fmt.Errorf("plan validation failed: %w", err)
 	}
 
 	e.log.Debug(ctx, "prepare_complete")
@@ -296,16 +512,22 @@ func (e *Executor) executeSequential(ctx context.Context, plan domain.Plan, chec
 		RolledBack: []domain.OperationID{},
 		Errors:     []error{},
 	}
+	progress := e.newProgress(checkpoint, len(plan.Operations))
 
 	for _, op := range plan.Operations {
 		opID := op.ID()
 
-		ctx, span := e.tracer.Start(ctx, "operation.Execute")
+		ctx, span := e.tracer.Start(operationContext(ctx, checkpoint, opID), "operation.Execute")
 		e.log.Debug(ctx, "executing_operation",
 			"op_id", opID,
 			"op_kind", op.Kind())
 
-		if err := op.Execute(ctx, e.fs); err != nil {
+		op = e.captureState(ctx, op)
+		progress.started(ctx, op)
+		start := time.Now()
+		err := op.Execute(ctx, e.fs)
+		progress.finished(ctx, op, time.Since(start), err)
+		if err != nil {
 			e.log.Error(ctx, "operation_failed", "op_id", opID, "error", err)
 			result.Failed = append(result.Failed, opID)
 			result.Errors = append(result.Errors, err)
@@ -322,6 +544,15 @@ func (e *Executor) executeSequential(ctx context.Context, plan domain.Plan, chec
 	return result
 }
 
+// captureState returns op with the state its rollback restores, for
+// operations that need it captured before they execute.
+func (e *Executor) captureState(ctx context.Context, op domain.Operation) domain.Operation {
+	if capturer, ok := op.(domain.StateCapturer); ok {
+		return capturer.CaptureState(ctx, e.fs)
+	}
+	return op
+}
+
 // rollback reverses executed operations in reverse order.
 func (e *Executor) rollback(ctx context.Context, executed []domain.OperationID, checkpoint *Checkpoint) []domain.OperationID {
 	ctx, span := e.tracer.Start(ctx, "executor.Rollback")
@@ -329,12 +560,24 @@ func (e *Executor) rollback(ctx context.Context, executed []domain.OperationID,
 
 	e.log.Warn(ctx, "starting_rollback", "operations", len(executed))
 
+	rolledBack, _ := e.rollbackOperations(ctx, executed, checkpoint)
+	return rolledBack
+}
+
+// rollbackOperations reverses executed operations in reverse order and
+// returns the operations undone together with the errors of those that
+// could not be undone.
+func (e *Executor) rollbackOperations(ctx context.Context, executed []domain.OperationID, checkpoint *Checkpoint) ([]domain.OperationID, map[domain.OperationID]error) {
 	var rolledBack []domain.OperationID
+	errs := make(map[domain.OperationID]error)
+	progress := e.newProgress(checkpoint, len(executed))
+	planCtx := ctx
 
 	// Rollback in reverse order
 	for i := len(executed) - 1; i >= 0; i-- {
 		opID := executed[i]
 		op := checkpoint.Lookup(opID)
+		ctx := operationContext(planCtx, checkpoint, opID)
 
 		if op == nil {
 			e.log.Error(ctx, "operation_not_in_checkpoint", "op_id", opID)
@@ -343,8 +586,12 @@ func (e *Executor) rollback(ctx context.Context, executed []domain.OperationID,
 
 		e.log.Debug(ctx, "rolling_back_operation", "op_id", opID, "op_kind", op.Kind())
 
-		if err := op.Rollback(ctx, e.fs); err != nil {
+		start := time.Now()
+		err := op.Rollback(ctx, e.fs)
+		progress.rolledBack(ctx, op, time.Since(start), err)
+		if err != nil {
 			e.log.Error(ctx, "rollback_failed", "op_id", opID, "error", err)
+			errs[opID] = err
 			// Continue rolling back other operations
 		} else {
 			rolledBack = append(rolledBack, opID)
@@ -355,7 +602,7 @@ func (e *Executor) rollback(ctx context.Context, executed []domain.OperationID,
 		"attempted", len(executed),
 		"succeeded", len(rolledBack))
 
-	return rolledBack
+	return rolledBack, errs
 }
 
 // executeParallel executes operations in parallel batches based on dependencies.
@@ -372,11 +619,15 @@ func (e *Executor) executeParallel(ctx context.Context, plan domain.Plan, checkp
 		RolledBack: []domain.OperationID{},
 		Errors:     []error{},
 	}
+	progress := e.newProgress(checkpoint, len(plan.Operations))
 
 	for i, batch := range batches {
 		e.log.Debug(ctx, "executing_batch", "batch", i, "size", len(batch))
 
-		batchResult := e.executeBatch(ctx, batch, checkpoint)
+		start := time.Now()
+		batchResult := e.executeBatch(ctx, batch, checkpoint, progress)
+		e.metrics.Histogram("executor.batch.duration.seconds").Observe(time.Since(start).Seconds())
+		e.metrics.Histogram("executor.batch.size").Observe(float64(len(batch)))
 
 		result.Executed = append(result.Executed, batchResult.Executed...)
 		result.Failed = append(result.Failed, batchResult.Failed...)
@@ -392,8 +643,9 @@ func (e *Executor) executeParallel(ctx context.Context, plan domain.Plan, checkp
 	return result
 }
 
-// executeBatch executes a batch of operations concurrently.
-func (e *Executor) executeBatch(ctx context.Context, batch []domain.Operation, checkpoint *Checkpoint) ExecutionResult {
+// executeBatch executes a batch of operations concurrently. Events are
+// reported from the calling goroutine.
+func (e *Executor) executeBatch(ctx context.Context, batch []domain.Operation, checkpoint *Checkpoint, progress *progressReporter) ExecutionResult {
 	result := ExecutionResult{
 		Executed:   []domain.OperationID{},
 		Failed:     []domain.OperationID{},
@@ -409,10 +661,16 @@ func (e *Executor) executeBatch(ctx context.Context, batch []domain.Operation, c
 	if len(batch) == 1 {
 		op := batch[0]
 		opID := op.ID()
+		ctx := operationContext(ctx, checkpoint, opID)
 
 		e.log.Debug(ctx, "executing_operation", "op_id", opID, "op_kind", op.Kind())
 
-		if err := op.Execute(ctx, e.fs); err != nil {
+		op = e.captureState(ctx, op)
+		progress.started(ctx, op)
+		start := time.Now()
+		err := op.Execute(ctx, e.fs)
+		progress.finished(ctx, op, time.Since(start), err)
+		if err != nil {
 			e.log.Error(ctx, "operation_failed", "op_id", opID, "error", err)
 			result.Failed = append(result.Failed, opID)
 			result.Errors = append(result.Errors, err)
@@ -424,45 +682,77 @@ func (e *Executor) executeBatch(ctx context.Context, batch []domain.Operation, c
 		return result
 	}
 
-	// Execute multiple operations concurrently
+	// Execute multiple operations on a bounded pool of workers. The
+	// dispatch loop only hands out an operation when a worker is free, and
+	// stops handing them out once the context is cancelled.
 	type opResult struct {
-		id  domain.OperationID
-		err error
-	}
-
-	resultCh := make(chan opResult, len(batch))
-
-	for _, op := range batch {
-		go func(operation domain.Operation) {
-			opID := operation.ID()
-
-			e.log.Debug(ctx, "executing_operation_parallel",
-				"op_id", opID,
-				"op_kind", operation.Kind())
-
-			err := operation.Execute(ctx, e.fs)
-			resultCh <- opResult{id: opID, err: err}
-		}(op)
+		op       domain.Operation
+		err      error
+		duration time.Duration
+	}
+
+	jobs := make(chan domain.Operation)
+	results := make(chan opResult, len(batch))
+	workers := min(e.maxParallel, len(batch))
+	for range workers {
+		go func() {
+			for operation := range jobs {
+				ctx := operationContext(ctx, checkpoint, operation.ID())
+				e.log.Debug(ctx, "executing_operation_parallel",
+					"op_id", operation.ID(),
+					"op_kind", operation.Kind())
+
+				operation = e.captureState(ctx, operation)
+				start := time.Now()
+				err := operation.Execute(ctx, e.fs)
+				results <- opResult{op: operation, err: err, duration: time.Since(start)}
+			}
+		}()
 	}
 
-	// Collect results
-	opMap := make(map[domain.OperationID]domain.Operation)
-	for _, op := range batch {
-		opMap[op.ID()] = op
+	record := func(res opResult) {
+		progress.finished(ctx, res.op, res.duration, res.err)
+		if res.err != nil {
+			e.log.Error(operationContext(ctx, checkpoint, res.op.ID()), "operation_failed", "op_id", res.op.ID(), "error", res.err)
+			result.Failed = append(result.Failed, res.op.ID())
+			result.Errors = append(result.Errors, res.err)
+			return
+		}
+		result.Executed = append(result.Executed, res.op.ID())
+		checkpoint.Record(res.op.ID(), res.op)
 	}
 
-	for i := 0; i < len(batch); i++ {
-		res := <-resultCh
+	next, running := 0, 0
+	for next < len(batch) || running > 0 {
+		if next < len(batch) && ctx.Err() != nil {
+			// Operations not yet started fail with the cancellation
+			for _, op := range batch[next:] {
+				result.Failed = append(result.Failed, op.ID())
+				result.Errors = append(result.Errors, ctx.Err())
+			}
+			next = len(batch)
+			continue
+		}
 
-		if res.err != nil {
-			e.log.Error(ctx, "operation_failed", "op_id", res.id, "error", res.err)
-			result.Failed = append(result.Failed, res.id)
-			result.Errors = append(result.Errors, res.err)
-		} else {
-			result.Executed = append(result.Executed, res.id)
-			checkpoint.Record(res.id, opMap[res.id])
+		// Only offer the next operation while one is left
+		var dispatch chan domain.Operation
+		var done <-chan struct{}
+		var op domain.Operation
+		if next < len(batch) {
+			dispatch, done, op = jobs, ctx.Done(), batch[next]
+		}
+		select {
+		case dispatch <- op:
+			progress.started(ctx, batch[next])
+			next++
+			running++
+		case res := <-results:
+			running--
+			record(res)
+		case <-done:
 		}
 	}
+	close(jobs)
 
 	return result
 }
@@ -0,0 +1,339 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jamesainslie/dot/internal/domain"
+)
+
+// DefaultCheckpointRetention is how many finished checkpoints FSCheckpointStore
+// keeps. Pending checkpoints are never pruned.
+const DefaultCheckpointRetention = 10
+
+const checkpointFileExt = ".json"
+
+// FSCheckpointStore persists checkpoints as JSON files in a directory so
+// that executed plans can be rolled back by a later process, including after
+// a crash mid-execution.
+//
+// The files double as a write-ahead journal: the plan is written before its
+// first operation runs and each recorded operation is written through to
+// disk, so an interrupted execution can also be resumed. Persisting is
+// best-effort: a write failure never interrupts plan execution.
+type FSCheckpointStore struct {
+	fs     domain.FS
+	dir    string
+	retain int
+	mu     sync.Mutex
+}
+
+// checkpointFile is the on-disk form of a checkpoint.
+type checkpointFile struct {
+	ID         CheckpointID             `json:"id"`
+	CreatedAt  time.Time                `json:"created_at"`
+	Status     CheckpointStatus         `json:"status"`
+	Operations []domain.OperationRecord `json:"operations"`
+	Plan       *planFile                `json:"plan,omitempty"`
+}
+
+// planFile is the on-disk form of a journaled plan.
+type planFile struct {
+	ID         string                          `json:"id,omitempty"`
+	Operations []domain.OperationRecord        `json:"operations"`
+	Satisfied  []domain.OperationRecord        `json:"satisfied,omitempty"`
+	Packages   map[string][]domain.OperationID `json:"packages,omitempty"`
+}
+
+// NewFSCheckpointStore creates a checkpoint store rooted at dir.
+func NewFSCheckpointStore(fs domain.FS, dir string) *FSCheckpointStore {
+	return &FSCheckpointStore{
+		fs:     fs,
+		dir:    dir,
+		retain: DefaultCheckpointRetention,
+	}
+}
+
+// Dir returns the directory holding checkpoint files.
+func (s *FSCheckpointStore) Dir() string {
+	return s.dir
+}
+
+func (s *FSCheckpointStore) Create(ctx context.Context) *Checkpoint {
+	// Progress must be saved even if the caller's context is cancelled
+	// mid-execution, since that is when a checkpoint matters most.
+	writeCtx := context.WithoutCancel(ctx)
+
+	checkpoint := &Checkpoint{
+		ID:        CheckpointID(uuid.New().String()),
+		CreatedAt: time.Now(),
+		Status:    CheckpointPending,
+	}
+	checkpoint.onChange = func(c *Checkpoint) {
+		_ = s.save(writeCtx, c)
+	}
+
+	_ = s.save(writeCtx, checkpoint)
+	return checkpoint
+}
+
+func (s *FSCheckpointStore) Delete(ctx context.Context, id CheckpointID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.fs.Remove(ctx, s.path(id))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("delete checkpoint %s: %w", id, err)
+	}
+	return nil
+}
+
+func (s *FSCheckpointStore) Restore(ctx context.Context, id CheckpointID) (*Checkpoint, error) {
+	data, err := s.fs.ReadFile(ctx, s.path(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, domain.ErrCheckpointNotFound{ID: string(id)}
+		}
+		return nil, fmt.Errorf("read checkpoint %s: %w", id, err)
+	}
+
+	var file checkpointFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse checkpoint %s: %w", id, err)
+	}
+
+	checkpoint := &Checkpoint{
+		ID:        file.ID,
+		CreatedAt: file.CreatedAt,
+		Status:    file.Status,
+	}
+	for _, rec := range file.Operations {
+		op, err := rec.Operation()
+		if err != nil {
+			return nil, fmt.Errorf("restore checkpoint %s: %w", id, err)
+		}
+		checkpoint.Record(op.ID(), op)
+	}
+	if file.Plan != nil {
+		plan, err := file.Plan.plan()
+		if err != nil {
+			return nil, fmt.Errorf("restore checkpoint %s: %w", id, err)
+		}
+		checkpoint.plan = &plan
+	}
+	checkpoint.onChange = func(c *Checkpoint) {
+		_ = s.save(context.WithoutCancel(ctx), c)
+	}
+
+	return checkpoint, nil
+}
+
+// Finish records how execution ended and prunes old finished checkpoints.
+func (s *FSCheckpointStore) Finish(ctx context.Context, id CheckpointID, status CheckpointStatus) error {
+	checkpoint, err := s.Restore(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	checkpoint.Status = status
+	if err := s.save(ctx, checkpoint); err != nil {
+		return err
+	}
+
+	return s.prune(ctx)
+}
+
+// List returns all stored checkpoints, newest first.
+func (s *FSCheckpointStore) List(ctx context.Context) ([]*Checkpoint, error) {
+	if !s.fs.Exists(ctx, s.dir) {
+		return nil, nil
+	}
+
+	entries, err := s.fs.ReadDir(ctx, s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("read checkpoint directory: %w", err)
+	}
+
+	checkpoints := make([]*Checkpoint, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, checkpointFileExt) {
+			continue
+		}
+		checkpoint, err := s.Restore(ctx, CheckpointID(strings.TrimSuffix(name, checkpointFileExt)))
+		if err != nil {
+			return nil, err
+		}
+		checkpoints = append(checkpoints, checkpoint)
+	}
+
+	sort.SliceStable(checkpoints, func(i, j int) bool {
+		return checkpoints[i].CreatedAt.After(checkpoints[j].CreatedAt)
+	})
+	return checkpoints, nil
+}
+
+// prune deletes finished checkpoints beyond the retention limit.
+func (s *FSCheckpointStore) prune(ctx context.Context) error {
+	checkpoints, err := s.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	kept := 0
+	for _, checkpoint := range checkpoints {
+		if checkpoint.Status == CheckpointPending {
+			continue
+		}
+		kept++
+		if kept > s.retain {
+			if err := s.Delete(ctx, checkpoint.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// save atomically writes the checkpoint to its file.
+func (s *FSCheckpointStore) save(ctx context.Context, checkpoint *Checkpoint) error {
+	checkpoint.mu.RLock()
+	file := checkpointFile{
+		ID:         checkpoint.ID,
+		CreatedAt:  checkpoint.CreatedAt,
+		Status:     checkpoint.Status,
+		Operations: make([]domain.OperationRecord, 0, len(checkpoint.order)),
+	}
+	for _, id := range checkpoint.order {
+		rec, err := domain.NewOperationRecord(checkpoint.operations[id])
+		if err != nil {
+			checkpoint.mu.RUnlock()
+			return err
+		}
+		file.Operations = append(file.Operations, rec)
+	}
+	if checkpoint.plan != nil {
+		plan, err := newPlanFile(*checkpoint.plan)
+		if err != nil {
+			checkpoint.mu.RUnlock()
+			return err
+		}
+		file.Plan = &plan
+	}
+	checkpoint.mu.RUnlock()
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal checkpoint: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.fs.MkdirAll(ctx, s.dir, domain.DefaultDirPerms); err != nil {
+		return fmt.Errorf("create checkpoint directory: %w", err)
+	}
+
+	path := s.path(checkpoint.ID)
+	tempPath := path + ".tmp"
+	// Journaled plans carry rendered template output, which may include secrets
+	if err := s.fs.WriteFile(ctx, tempPath, data, domain.SecureFilePerms); err != nil {
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	if err := s.fs.Rename(ctx, tempPath, path); err != nil {
+		// Ignore cleanup error: best-effort during error recovery.
+		_ = s.fs.Remove(ctx, tempPath)
+		return fmt.Errorf("rename checkpoint: %w", err)
+	}
+	return nil
+}
+
+// newPlanFile converts a plan to its on-disk form. Parallel batches are not
+// kept; a resumed plan runs its remaining operations sequentially.
+func newPlanFile(plan domain.Plan) (planFile, error) {
+	operations, err := operationRecords(plan.Operations, plan.Dependencies)
+	if err != nil {
+		return planFile{}, err
+	}
+	satisfied, err := operationRecords(plan.Satisfied, nil)
+	if err != nil {
+		return planFile{}, err
+	}
+	return planFile{
+		ID:         plan.ID,
+		Operations: operations,
+		Satisfied:  satisfied,
+		Packages:   plan.PackageOperations,
+	}, nil
+}
+
+// plan reconstructs the journaled plan.
+func (f planFile) plan() (domain.Plan, error) {
+	operations, deps, err := recordOperations(f.Operations)
+	if err != nil {
+		return domain.Plan{}, err
+	}
+	satisfied, _, err := recordOperations(f.Satisfied)
+	if err != nil {
+		return domain.Plan{}, err
+	}
+	return domain.Plan{
+		ID:                f.ID,
+		Operations:        operations,
+		Satisfied:         satisfied,
+		PackageOperations: f.Packages,
+		Dependencies:      deps,
+	}, nil
+}
+
+// operationRecords converts ops to records carrying their dependencies.
+func operationRecords(ops []domain.Operation, deps map[domain.OperationID][]domain.OperationID) ([]domain.OperationRecord, error) {
+	if len(ops) == 0 {
+		return nil, nil
+	}
+	records := make([]domain.OperationRecord, 0, len(ops))
+	for _, op := range ops {
+		rec, err := domain.NewOperationRecord(op)
+		if err != nil {
+			return nil, err
+		}
+		rec.DependsOn = deps[op.ID()]
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+// recordOperations reconstructs operations and their dependencies.
+func recordOperations(records []domain.OperationRecord) ([]domain.Operation, map[domain.OperationID][]domain.OperationID, error) {
+	if len(records) == 0 {
+		return nil, nil, nil
+	}
+	ops := make([]domain.Operation, 0, len(records))
+	var deps map[domain.OperationID][]domain.OperationID
+	for _, rec := range records {
+		op, err := rec.Operation()
+		if err != nil {
+			return nil, nil, err
+		}
+		ops = append(ops, op)
+		if len(rec.DependsOn) > 0 {
+			if deps == nil {
+				deps = make(map[domain.OperationID][]domain.OperationID)
+			}
+			deps[rec.ID] = rec.DependsOn
+		}
+	}
+	return ops, deps, nil
+}
+
+func (s *FSCheckpointStore) path(id CheckpointID) string {
+	return filepath.Join(s.dir, string(id)+checkpointFileExt)
+}
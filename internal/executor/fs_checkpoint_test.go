@@ -0,0 +1,267 @@
+package executor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/internal/domain"
+)
+
+const testCheckpointDir = "/state/dot/checkpoints"
+
+func newCheckpointTestExecutor(t *testing.T) (*adapters.MemFS, *FSCheckpointStore, *Executor) {
+	t.Helper()
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	require.NoError(t, fs.MkdirAll(ctx, "/packages/vim", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/home", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/packages/vim/vimrc", []byte("set nocompatible"), 0644))
+
+	store := NewFSCheckpointStore(fs, testCheckpointDir)
+	exec := New(Opts{
+		FS:         fs,
+		Logger:     adapters.NewNoopLogger(),
+		Tracer:     adapters.NewNoopTracer(),
+		Checkpoint: store,
+	})
+	return fs, store, exec
+}
+
+func TestFSCheckpointStore_PersistsRecordedOperations(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	store := NewFSCheckpointStore(fs, testCheckpointDir)
+
+	checkpoint := store.Create(ctx)
+	assert.True(t, fs.Exists(ctx, testCheckpointDir+"/"+string(checkpoint.ID)+".json"))
+
+	dir := domain.NewDirCreate("dir", domain.MustParsePath("/home/.config"))
+	link := domain.NewLinkCreate("link", domain.MustParsePath("/packages/vim/vimrc"), domain.MustParseTargetPath("/home/.vimrc"))
+	checkpoint.Record(dir.ID(), dir)
+	checkpoint.Record(link.ID(), link)
+
+	// A separate store, as in a new process, sees the recorded progress
+	restored, err := NewFSCheckpointStore(fs, testCheckpointDir).Restore(ctx, checkpoint.ID)
+	require.NoError(t, err)
+	assert.Equal(t, CheckpointPending, restored.Status)
+	assert.Equal(t, []domain.OperationID{"dir", "link"}, restored.ExecutedIDs())
+	assert.Equal(t, []domain.Operation{dir, link}, restored.ListOperations())
+}
+
+func TestFSCheckpointStore_RestoreNotFound(t *testing.T) {
+	store := NewFSCheckpointStore(adapters.NewMemFS(), testCheckpointDir)
+
+	_, err := store.Restore(context.Background(), "missing")
+
+	var notFound domain.ErrCheckpointNotFound
+	require.ErrorAs(t, err, &notFound)
+	assert.Equal(t, "missing", notFound.ID)
+}
+
+func TestFSCheckpointStore_ListNewestFirst(t *testing.T) {
+	ctx := context.Background()
+	store := NewFSCheckpointStore(adapters.NewMemFS(), testCheckpointDir)
+
+	empty, err := store.List(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+
+	first := store.Create(ctx)
+	time.Sleep(time.Millisecond)
+	second := store.Create(ctx)
+
+	checkpoints, err := store.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, checkpoints, 2)
+	assert.Equal(t, second.ID, checkpoints[0].ID)
+	assert.Equal(t, first.ID, checkpoints[1].ID)
+}
+
+func TestFSCheckpointStore_FinishPrunesOldCheckpoints(t *testing.T) {
+	ctx := context.Background()
+	store := NewFSCheckpointStore(adapters.NewMemFS(), testCheckpointDir)
+	store.retain = 2
+
+	pending := store.Create(ctx)
+	var finished []CheckpointID
+	for i := 0; i < 3; i++ {
+		time.Sleep(time.Millisecond)
+		checkpoint := store.Create(ctx)
+		require.NoError(t, store.Finish(ctx, checkpoint.ID, CheckpointCompleted))
+		finished = append(finished, checkpoint.ID)
+	}
+
+	checkpoints, err := store.List(ctx)
+	require.NoError(t, err)
+	ids := make([]CheckpointID, 0, len(checkpoints))
+	for _, checkpoint := range checkpoints {
+		ids = append(ids, checkpoint.ID)
+	}
+
+	// The oldest finished checkpoint is pruned; pending ones are kept
+	assert.ElementsMatch(t, []CheckpointID{pending.ID, finished[1], finished[2]}, ids)
+}
+
+func TestExecutor_KeepsCompletedCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	fs, store, exec := newCheckpointTestExecutor(t)
+
+	link := domain.NewLinkCreate("link", domain.MustParsePath("/packages/vim/vimrc"), domain.MustParseTargetPath("/home/.vimrc"))
+	result := exec.Execute(ctx, domain.Plan{Operations: []domain.Operation{link}})
+	require.True(t, result.IsOk())
+
+	checkpoints, err := store.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, checkpoints, 1)
+	assert.Equal(t, CheckpointCompleted, checkpoints[0].Status)
+
+	// Rolling back the completed plan removes the link
+	rollback := exec.Rollback(ctx, checkpoints[0].ID)
+	require.True(t, rollback.IsOk())
+	assert.Equal(t, []domain.OperationID{"link"}, rollback.Unwrap().RolledBack)
+	assert.False(t, fs.Exists(ctx, "/home/.vimrc"))
+
+	restored, err := store.Restore(ctx, checkpoints[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, CheckpointRolledBack, restored.Status)
+
+	// A checkpoint cannot be rolled back twice
+	again := exec.Rollback(ctx, checkpoints[0].ID)
+	require.False(t, again.IsOk())
+	assert.Contains(t, again.UnwrapErr().Error(), "already been rolled back")
+}
+
+func TestExecutor_FailedExecutionMarksCheckpointRolledBack(t *testing.T) {
+	ctx := context.Background()
+	fs, store, exec := newCheckpointTestExecutor(t)
+
+	link := domain.NewLinkCreate("link", domain.MustParsePath("/packages/vim/vimrc"), domain.MustParseTargetPath("/home/.vimrc"))
+	// Deleting a missing directory passes preconditions but fails at execution
+	missing := domain.NewDirDelete("missing", domain.MustParsePath("/home/.missing"))
+	result := exec.Execute(ctx, domain.Plan{Operations: []domain.Operation{link, missing}})
+	require.False(t, result.IsOk())
+	assert.False(t, fs.Exists(ctx, "/home/.vimrc"))
+
+	checkpoints, err := store.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, checkpoints, 1)
+	assert.Equal(t, CheckpointRolledBack, checkpoints[0].Status)
+}
+
+func TestExecutor_RollbackInterruptedExecution(t *testing.T) {
+	ctx := context.Background()
+	fs, store, exec := newCheckpointTestExecutor(t)
+
+	// Simulate a crash: operations ran and were recorded, but execution never finished
+	dir := domain.NewDirCreate("dir", domain.MustParsePath("/home/.vim"))
+	link := domain.NewLinkCreate("link", domain.MustParsePath("/packages/vim/vimrc"), domain.MustParseTargetPath("/home/.vim/vimrc"))
+	checkpoint := store.Create(ctx)
+	for _, op := range []domain.Operation{dir, link} {
+		require.NoError(t, op.Execute(ctx, fs))
+		checkpoint.Record(op.ID(), op)
+	}
+
+	result := exec.Rollback(ctx, checkpoint.ID)
+	require.True(t, result.IsOk())
+	assert.Equal(t, []domain.OperationID{"link", "dir"}, result.Unwrap().RolledBack)
+	assert.False(t, fs.Exists(ctx, "/home/.vim"))
+}
+
+func TestExecutor_JournalsPlanBeforeExecution(t *testing.T) {
+	ctx := context.Background()
+	_, store, exec := newCheckpointTestExecutor(t)
+
+	dir := domain.NewDirCreate("dir", domain.MustParsePath("/home/.vim"))
+	link := domain.NewLinkCreate("link", domain.MustParsePath("/packages/vim/vimrc"), domain.MustParseTargetPath("/home/.vim/vimrc"))
+	plan := domain.Plan{
+		Operations:        []domain.Operation{dir, link},
+		PackageOperations: map[string][]domain.OperationID{"vim": {"dir", "link"}},
+		Dependencies:      map[domain.OperationID][]domain.OperationID{"link": {"dir"}},
+	}
+	require.True(t, exec.Execute(ctx, plan).IsOk())
+
+	checkpoints, err := store.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, checkpoints, 1)
+
+	journaled, ok := checkpoints[0].Plan()
+	require.True(t, ok)
+	assert.Equal(t, plan.Operations, journaled.Operations)
+	assert.Equal(t, plan.PackageOperations, journaled.PackageOperations)
+	assert.Equal(t, plan.Dependencies, journaled.Dependencies)
+	assert.Empty(t, checkpoints[0].Remaining())
+}
+
+// interruptedCheckpoint simulates power loss part way through a plan: the
+// directory was created and recorded, the first link was created but not
+// recorded, and the second link never ran.
+func interruptedCheckpoint(t *testing.T, fs *adapters.MemFS, store *FSCheckpointStore, second domain.Operation) CheckpointID {
+	t.Helper()
+	ctx := context.Background()
+
+	dir := domain.NewDirCreate("dir", domain.MustParsePath("/home/.vim"))
+	first := domain.NewLinkCreate("first", domain.MustParsePath("/packages/vim/vimrc"), domain.MustParseTargetPath("/home/.vim/vimrc"))
+
+	checkpoint := store.Create(ctx)
+	checkpoint.SetPlan(domain.Plan{Operations: []domain.Operation{dir, first, second}})
+	require.NoError(t, dir.Execute(ctx, fs))
+	checkpoint.Record(dir.ID(), dir)
+	require.NoError(t, first.Execute(ctx, fs))
+
+	return checkpoint.ID
+}
+
+func TestExecutor_ResumeInterruptedExecution(t *testing.T) {
+	ctx := context.Background()
+	fs, store, exec := newCheckpointTestExecutor(t)
+
+	second := domain.NewLinkCreate("second", domain.MustParsePath("/packages/vim/vimrc"), domain.MustParseTargetPath("/home/.vimrc"))
+	id := interruptedCheckpoint(t, fs, NewFSCheckpointStore(fs, testCheckpointDir), second)
+
+	result := exec.Resume(ctx, id)
+	require.True(t, result.IsOk(), "resume failed: %v", result)
+	assert.Equal(t, []domain.OperationID{"second"}, result.Unwrap().Executed)
+	assert.True(t, fs.Exists(ctx, "/home/.vimrc"))
+
+	restored, err := store.Restore(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, CheckpointCompleted, restored.Status)
+	assert.Equal(t, []domain.OperationID{"dir", "first", "second"}, restored.ExecutedIDs())
+
+	// Only interrupted executions can be resumed
+	again := exec.Resume(ctx, id)
+	require.False(t, again.IsOk())
+	assert.Contains(t, again.UnwrapErr().Error(), "only interrupted executions can be resumed")
+}
+
+func TestExecutor_ResumeFailureRollsBackPlan(t *testing.T) {
+	ctx := context.Background()
+	fs, store, exec := newCheckpointTestExecutor(t)
+
+	missing := domain.NewDirDelete("missing", domain.MustParsePath("/home/.missing"))
+	id := interruptedCheckpoint(t, fs, store, missing)
+
+	result := exec.Resume(ctx, id)
+	require.False(t, result.IsOk())
+	assert.False(t, fs.Exists(ctx, "/home/.vim"), "operations from before the interruption are rolled back")
+
+	restored, err := store.Restore(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, CheckpointRolledBack, restored.Status)
+}
+
+func TestExecutor_ResumeWithoutJournal(t *testing.T) {
+	ctx := context.Background()
+	_, store, exec := newCheckpointTestExecutor(t)
+
+	checkpoint := store.Create(ctx)
+
+	result := exec.Resume(ctx, checkpoint.ID)
+	require.False(t, result.IsOk())
+	assert.Contains(t, result.UnwrapErr().Error(), "no journaled plan")
+}
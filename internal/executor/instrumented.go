@@ -7,7 +7,8 @@ import (
 	"github.com/jamesainslie/dot/internal/domain"
 )
 
-// InstrumentedExecutor wraps Executor with metrics collection.
+// InstrumentedExecutor wraps Executor with metrics collection, recording
+// executions into a collector other than the executor's own Metrics.
 type InstrumentedExecutor struct {
 	inner   *Executor
 	metrics domain.Metrics
@@ -24,34 +25,49 @@ func NewInstrumented(inner *Executor, metrics domain.Metrics) *InstrumentedExecu
 // Execute executes a plan with metrics collection.
 func (e *InstrumentedExecutor) Execute(ctx context.Context, plan domain.Plan) domain.Result[ExecutionResult] {
 	start := time.Now()
-
-	e.metrics.Counter("executor.executions.total").Inc()
-	e.metrics.Gauge("executor.operations.queued").Set(float64(len(plan.Operations)))
-
 	result := e.inner.Execute(ctx, plan)
+	recordExecution(e.metrics, plan, result, time.Since(start))
+	return result
+}
 
-	duration := time.Since(start)
-	e.metrics.Histogram("executor.duration.seconds").Observe(duration.Seconds())
-
-	if result.IsOk() {
-		execResult := result.Unwrap()
-		e.metrics.Counter("executor.executions.success").Inc()
-		e.metrics.Counter("executor.operations.executed").Add(float64(len(execResult.Executed)))
+// recordExecution records the size, duration and outcome of executing plan.
+// Executed operations are counted by kind.
+func recordExecution(metrics domain.Metrics, plan domain.Plan, result domain.Result[ExecutionResult], duration time.Duration) {
+	metrics.Counter("executor.executions.total").Inc()
+	metrics.Gauge("executor.operations.queued").Set(float64(len(plan.Operations)))
+	metrics.Histogram("executor.plan.operations").Observe(float64(len(plan.Operations)))
+	metrics.Histogram("executor.duration.seconds").Observe(duration.Seconds())
 
-		if len(plan.Batches) > 0 {
-			e.metrics.Histogram("executor.parallel.batches").Observe(float64(len(plan.Batches)))
+	execResult := result.UnwrapOr(ExecutionResult{})
+	if len(execResult.Executed) > 0 {
+		kinds := make(map[domain.OperationID]string, len(plan.Operations))
+		for _, op := range plan.Operations {
+			kinds[op.ID()] = op.Kind().String()
 		}
-	} else {
-		e.metrics.Counter("executor.executions.failed").Inc()
-
-		execResult := result.UnwrapOr(ExecutionResult{})
-		if len(execResult.Failed) > 0 {
-			e.metrics.Counter("executor.operations.failed").Add(float64(len(execResult.Failed)))
+		counts := make(map[string]int)
+		for _, id := range execResult.Executed {
+			counts[kinds[id]]++
 		}
-		if len(execResult.RolledBack) > 0 {
-			e.metrics.Counter("executor.operations.rolled_back").Add(float64(len(execResult.RolledBack)))
+		executed := metrics.Counter("executor.operations.executed", "kind")
+		for kind, n := range counts {
+			executed.Add(float64(n), kind)
 		}
 	}
 
-	return result
+	if result.IsOk() {
+		metrics.Counter("executor.executions.success").Inc()
+		if len(plan.Batches) > 0 {
+			metrics.Histogram("executor.parallel.batches").Observe(float64(len(plan.Batches)))
+		}
+		return
+	}
+
+	metrics.Counter("executor.executions.failed").Inc()
+	if len(execResult.Failed) > 0 {
+		metrics.Counter("executor.operations.failed").Add(float64(len(execResult.Failed)))
+	}
+	if len(execResult.RolledBack) > 0 {
+		metrics.Counter("executor.operations.rolled_back").Add(float64(len(execResult.RolledBack)))
+		metrics.Counter("executor.rollbacks.total", "trigger").Inc("failure")
+	}
 }
@@ -2,7 +2,10 @@ package executor
 
 import (
 	"context"
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/jamesainslie/dot/internal/adapters"
 	"github.com/jamesainslie/dot/internal/domain"
@@ -40,7 +43,7 @@ func TestExecuteBatch_Concurrent(t *testing.T) {
 	}
 
 	checkpoint := exec.checkpoint.Create(ctx)
-	result := exec.executeBatch(ctx, ops, checkpoint)
+	result := exec.executeBatch(ctx, ops, checkpoint, nil)
 
 	require.Len(t, result.Executed, 3)
 	require.Empty(t, result.Failed)
@@ -80,7 +83,7 @@ func TestExecuteBatch_PartialFailure(t *testing.T) {
 	}
 
 	checkpoint := exec.checkpoint.Create(ctx)
-	result := exec.executeBatch(ctx, ops, checkpoint)
+	result := exec.executeBatch(ctx, ops, checkpoint, nil)
 
 	require.Len(t, result.Executed, 2, "two operations should succeed")
 	require.Len(t, result.Failed, 1, "one operation should fail")
@@ -181,3 +184,83 @@ func TestExecuteParallel_Internal_MultipleBatches(t *testing.T) {
 	require.True(t, fs.Exists(ctx, target2.String()))
 	require.True(t, fs.Exists(ctx, target3.String()))
 }
+
+// concurrencyFS records the most symlinks created at the same time.
+type concurrencyFS struct {
+	domain.FS
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+}
+
+func (f *concurrencyFS) Symlink(ctx context.Context, oldname, newname string) error {
+	f.mu.Lock()
+	f.inFlight++
+	f.peak = max(f.peak, f.inFlight)
+	f.mu.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	f.mu.Lock()
+	f.inFlight--
+	f.mu.Unlock()
+	return f.FS.Symlink(ctx, oldname, newname)
+}
+
+func newLinkBatch(t *testing.T, fs domain.FS, n int) []domain.Operation {
+	t.Helper()
+	ctx := context.Background()
+	require.NoError(t, fs.MkdirAll(ctx, "/packages/pkg", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/home", 0755))
+
+	ops := make([]domain.Operation, n)
+	for i := range ops {
+		source := domain.MustParsePath(fmt.Sprintf("/packages/pkg/file%d", i))
+		require.NoError(t, fs.WriteFile(ctx, source.String(), []byte("content"), 0644))
+		target := domain.MustParseTargetPath(fmt.Sprintf("/home/file%d", i))
+		ops[i] = domain.NewLinkCreate(domain.OperationID(fmt.Sprintf("link%d", i)), source, target)
+	}
+	return ops
+}
+
+func TestExecuteBatch_HonorsMaxParallel(t *testing.T) {
+	ctx := context.Background()
+	fs := &concurrencyFS{FS: adapters.NewMemFS()}
+	metrics := newMockMetrics()
+	exec := New(Opts{
+		FS:          fs,
+		Logger:      adapters.NewNoopLogger(),
+		Tracer:      adapters.NewNoopTracer(),
+		Metrics:     metrics,
+		MaxParallel: 2,
+	})
+
+	ops := newLinkBatch(t, fs, 8)
+	result := exec.Execute(ctx, domain.Plan{Operations: ops, Batches: [][]domain.Operation{ops}})
+	require.True(t, result.IsOk())
+	require.Len(t, result.Unwrap().Executed, 8)
+
+	require.LessOrEqual(t, fs.peak, 2, "no more than MaxParallel operations run at once")
+	require.Len(t, metrics.histograms["executor.batch.duration.seconds"], 1)
+	require.Equal(t, []float64{8}, metrics.histograms["executor.batch.size"])
+}
+
+func TestExecuteBatch_StopsOnCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	fs := adapters.NewMemFS()
+	exec := New(Opts{
+		FS:     fs,
+		Logger: adapters.NewNoopLogger(),
+		Tracer: adapters.NewNoopTracer(),
+	})
+
+	ops := newLinkBatch(t, fs, 4)
+	result := exec.executeBatch(ctx, ops, exec.checkpoint.Create(ctx), nil)
+
+	require.Empty(t, result.Executed)
+	require.Len(t, result.Failed, 4)
+	require.ErrorIs(t, result.Errors[0], context.Canceled)
+	require.False(t, fs.Exists(context.Background(), "/home/file0"))
+}
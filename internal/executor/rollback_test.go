@@ -161,3 +161,29 @@ func TestExecute_AutomaticRollback(t *testing.T) {
 	exists := fs.Exists(ctx, target1.String())
 	require.False(t, exists, "rolled back operation should be undone")
 }
+
+func TestExecute_RollbackRestoresDeletedLinks(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	exec := New(Opts{
+		FS:     fs,
+		Logger: adapters.NewNoopLogger(),
+		Tracer: adapters.NewNoopTracer(),
+	})
+
+	require.NoError(t, fs.MkdirAll(ctx, "/packages/pkg", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/home", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/packages/pkg/file", []byte("content"), 0644))
+	require.NoError(t, fs.Symlink(ctx, "../packages/pkg/file", "/home/file"))
+
+	// The link is deleted, then a later operation fails
+	plan := domain.Plan{Operations: []domain.Operation{
+		domain.NewLinkDelete("unlink", domain.MustParseTargetPath("/home/file")),
+		domain.NewDirDelete("rmdir", domain.MustParsePath("/home/missing")),
+	}}
+	require.True(t, exec.Execute(ctx, plan).IsErr())
+
+	target, err := fs.ReadLink(ctx, "/home/file")
+	require.NoError(t, err, "deleted link should be recreated")
+	require.Equal(t, "../packages/pkg/file", target)
+}
@@ -0,0 +1,417 @@
+// Package jsonschema checks YAML and JSON documents against the JSON
+// Schemas dot ships for its files.
+//
+// Only the keywords those schemas use are supported: type, enum, const,
+// properties, required, additionalProperties, items, minItems, minLength,
+// pattern, not, the date-time format and $ref to the schema's own
+// definitions. Other keywords are ignored.
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Problem describes a part of a document the schema rejects.
+type Problem struct {
+	// Field is the path of the offending field, such as
+	// "packages[1].links[0]", or empty for the document as a whole.
+	Field string
+
+	// Line is the line of the field in the document, or zero if unknown.
+	Line int
+
+	// Message describes the problem.
+	Message string
+}
+
+// String formats the problem as "line 3: packages[1].name: message".
+func (p Problem) String() string {
+	var b strings.Builder
+	if p.Line > 0 {
+		fmt.Fprintf(&b, "line %d: ", p.Line)
+	}
+	if p.Field != "" {
+		b.WriteString(p.Field + ": ")
+	}
+	b.WriteString(p.Message)
+	return b.String()
+}
+
+// Schema is a compiled JSON Schema.
+type Schema struct {
+	root *schema
+}
+
+// schema is a JSON Schema or one of its subschemas.
+type schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 typeList           `json:"type"`
+	Enum                 []any              `json:"enum"`
+	Const                json.RawMessage    `json:"const"`
+	Properties           map[string]*schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *additional        `json:"additionalProperties"`
+	Items                *schema            `json:"items"`
+	MinItems             *int               `json:"minItems"`
+	MinLength            *int               `json:"minLength"`
+	Pattern              string             `json:"pattern"`
+	Not                  *schema            `json:"not"`
+	Format               string             `json:"format"`
+	Definitions          map[string]*schema `json:"definitions"`
+
+	pattern *regexp.Regexp
+}
+
+// typeList is the type keyword, a type name or a list of them.
+type typeList []string
+
+// UnmarshalJSON accepts a single type name or a list.
+func (t *typeList) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*t = typeList{name}
+		return nil
+	}
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return fmt.Errorf("type must be a string or a list of strings")
+	}
+	*t = names
+	return nil
+}
+
+// additional is the additionalProperties keyword: false, or the schema of
+// the properties not listed in properties.
+type additional struct {
+	forbidden bool
+	schema    *schema
+}
+
+// UnmarshalJSON accepts a boolean or a schema.
+func (a *additional) UnmarshalJSON(data []byte) error {
+	var allowed bool
+	if err := json.Unmarshal(data, &allowed); err == nil {
+		a.forbidden = !allowed
+		return nil
+	}
+	a.schema = &schema{}
+	return json.Unmarshal(data, a.schema)
+}
+
+// Compile parses the JSON Schema in data.
+func Compile(data []byte) (*Schema, error) {
+	root := &schema{}
+	if err := json.Unmarshal(data, root); err != nil {
+		return nil, fmt.Errorf("parse schema: %w", err)
+	}
+	if err := root.compile(root); err != nil {
+		return nil, err
+	}
+	return &Schema{root: root}, nil
+}
+
+// compile compiles the patterns of s and its subschemas, and checks that
+// references name a definition of root.
+func (s *schema) compile(root *schema) error {
+	if s == nil {
+		return nil
+	}
+	if s.Ref != "" {
+		if _, err := root.resolve(s.Ref); err != nil {
+			return err
+		}
+	}
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("compile pattern %q: %w", s.Pattern, err)
+		}
+		s.pattern = re
+	}
+
+	subschemas := []*schema{s.Items, s.Not}
+	if s.AdditionalProperties != nil {
+		subschemas = append(subschemas, s.AdditionalProperties.schema)
+	}
+	for _, sub := range s.Properties {
+		subschemas = append(subschemas, sub)
+	}
+	for _, sub := range s.Definitions {
+		subschemas = append(subschemas, sub)
+	}
+	for _, sub := range subschemas {
+		if err := sub.compile(root); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolve returns the definition named by ref, "#/definitions/NAME".
+func (s *schema) resolve(ref string) (*schema, error) {
+	name, ok := strings.CutPrefix(ref, "#/definitions/")
+	if def := s.Definitions[name]; ok && def != nil {
+		return def, nil
+	}
+	return nil, fmt.Errorf("unsupported schema reference %q", ref)
+}
+
+// Validate parses the YAML or JSON document in data and returns every
+// problem the schema finds in it, in the order of the document, with the
+// line it is on. A document the schema accepts returns an empty slice.
+func (s *Schema) Validate(data []byte) []Problem {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return []Problem{{Message: fmt.Sprintf("parse document: %s", strings.TrimPrefix(err.Error(), "yaml: "))}}
+	}
+	if len(doc.Content) == 0 {
+		return []Problem{{Message: "document is empty"}}
+	}
+
+	v := &validator{root: s.root, problems: []Problem{}}
+	v.check(s.root, doc.Content[0], "")
+	return v.problems
+}
+
+// validator collects the problems of a document.
+type validator struct {
+	root     *schema
+	problems []Problem
+}
+
+// report records a problem of the field at node.
+func (v *validator) report(node *yaml.Node, field, format string, args ...any) {
+	v.problems = append(v.problems, Problem{Field: field, Line: node.Line, Message: fmt.Sprintf(format, args...)})
+}
+
+// check validates node, at the path field, against s.
+func (v *validator) check(s *schema, node *yaml.Node, field string) {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if s.Ref != "" {
+		// References were resolved when the schema was compiled
+		ref, _ := v.root.resolve(s.Ref)
+		v.check(ref, node, field)
+	}
+
+	kind := nodeType(node)
+	if len(s.Type) > 0 && !matchesType(kind, s.Type) {
+		v.report(node, field, "must be %s, not %s", describeTypes(s.Type), article(kind))
+		return
+	}
+	if len(s.Const) > 0 {
+		var want any
+		if err := json.Unmarshal(s.Const, &want); err == nil && !equal(node, want) {
+			v.report(node, field, "must be %s", formatValue(want))
+		}
+	}
+	if len(s.Enum) > 0 && !anyEqual(node, s.Enum) {
+		v.report(node, field, "must be one of %s", formatValues(s.Enum))
+	}
+	if s.Not != nil && v.accepts(s.Not, node) {
+		v.report(node, field, "%s is not allowed", formatNode(node))
+	}
+
+	switch kind {
+	case "string":
+		v.checkString(s, node, field)
+	case "array":
+		v.checkArray(s, node, field)
+	case "object":
+		v.checkObject(s, node, field)
+	}
+}
+
+// accepts reports whether s finds no problem in node.
+func (v *validator) accepts(s *schema, node *yaml.Node) bool {
+	sub := &validator{root: v.root}
+	sub.check(s, node, "")
+	return len(sub.problems) == 0
+}
+
+// checkString validates the string node against the string keywords of s.
+func (v *validator) checkString(s *schema, node *yaml.Node, field string) {
+	if s.MinLength != nil && len([]rune(node.Value)) < *s.MinLength {
+		if *s.MinLength == 1 {
+			v.report(node, field, "must not be empty")
+		} else {
+			v.report(node, field, "must be at least %d characters", *s.MinLength)
+		}
+	}
+	if s.pattern != nil && !s.pattern.MatchString(node.Value) {
+		v.report(node, field, "%q does not match %s", node.Value, s.Pattern)
+	}
+	if s.Format == "date-time" {
+		if _, err := time.Parse(time.RFC3339, node.Value); err != nil {
+			v.report(node, field, "%q is not an RFC 3339 date and time", node.Value)
+		}
+	}
+}
+
+// checkArray validates the sequence node against the array keywords of s.
+func (v *validator) checkArray(s *schema, node *yaml.Node, field string) {
+	if s.MinItems != nil && len(node.Content) < *s.MinItems {
+		v.report(node, field, "must have at least %d item(s)", *s.MinItems)
+	}
+	if s.Items == nil {
+		return
+	}
+	for i, item := range node.Content {
+		v.check(s.Items, item, fmt.Sprintf("%s[%d]", field, i))
+	}
+}
+
+// checkObject validates the mapping node against the object keywords of s.
+func (v *validator) checkObject(s *schema, node *yaml.Node, field string) {
+	present := make(map[string]bool, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		present[key.Value] = true
+		path := key.Value
+		if field != "" {
+			path = field + "." + key.Value
+		}
+
+		if sub, ok := s.Properties[key.Value]; ok {
+			v.check(sub, value, path)
+			continue
+		}
+		switch a := s.AdditionalProperties; {
+		case a == nil:
+		case a.forbidden:
+			v.report(key, field, "unknown field %q", key.Value)
+		case a.schema != nil:
+			v.check(a.schema, value, path)
+		}
+	}
+
+	for _, name := range s.Required {
+		if !present[name] {
+			v.report(node, field, "missing required field %q", name)
+		}
+	}
+}
+
+// nodeType returns the JSON type of a YAML node.
+func nodeType(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "object"
+	case yaml.SequenceNode:
+		return "array"
+	}
+	switch node.ShortTag() {
+	case "!!null":
+		return "null"
+	case "!!bool":
+		return "boolean"
+	case "!!int":
+		return "integer"
+	case "!!float":
+		if f, err := strconv.ParseFloat(node.Value, 64); err == nil && f == math.Trunc(f) {
+			return "integer"
+		}
+		return "number"
+	default:
+		return "string"
+	}
+}
+
+// matchesType reports whether a value of type kind has one of types.
+func matchesType(kind string, types []string) bool {
+	for _, t := range types {
+		if t == kind || (t == "number" && kind == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// describeTypes describes types as "a string or null".
+func describeTypes(types []string) string {
+	described := make([]string, len(types))
+	for i, t := range types {
+		described[i] = article(t)
+	}
+	return strings.Join(described, " or ")
+}
+
+// article prefixes a type name with its article, as "an integer".
+func article(kind string) string {
+	switch kind {
+	case "null":
+		return "null"
+	case "object", "array", "integer":
+		return "an " + kind
+	default:
+		return "a " + kind
+	}
+}
+
+// value returns the JSON value of a scalar node, or nil for collections.
+func value(node *yaml.Node) any {
+	switch nodeType(node) {
+	case "string":
+		return node.Value
+	case "boolean":
+		return node.Value == "true"
+	case "integer", "number":
+		f, _ := strconv.ParseFloat(node.Value, 64)
+		return f
+	default:
+		return nil
+	}
+}
+
+// equal reports whether node holds the scalar JSON value want.
+func equal(node *yaml.Node, want any) bool {
+	if node.Kind != yaml.ScalarNode {
+		return false
+	}
+	return value(node) == want
+}
+
+// anyEqual reports whether node holds one of values.
+func anyEqual(node *yaml.Node, values []any) bool {
+	for _, want := range values {
+		if equal(node, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatValue formats a JSON value as it is written in JSON.
+func formatValue(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// formatValues formats a list of JSON values as "\"a\", \"b\"".
+func formatValues(values []any) string {
+	formatted := make([]string, len(values))
+	for i, v := range values {
+		formatted[i] = formatValue(v)
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// formatNode formats the value of node for a problem message.
+func formatNode(node *yaml.Node) string {
+	if node.Kind == yaml.ScalarNode {
+		return formatValue(value(node))
+	}
+	return article(nodeType(node))
+}
@@ -0,0 +1,136 @@
+package jsonschema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSchema = `{
+  "type": "object",
+  "required": ["format", "version", "items"],
+  "additionalProperties": false,
+  "properties": {
+    "format": { "const": "test" },
+    "version": { "type": "integer", "enum": [1] },
+    "items": {
+      "type": "array",
+      "minItems": 1,
+      "items": { "$ref": "#/definitions/item" }
+    },
+    "tags": {
+      "type": "object",
+      "additionalProperties": { "type": "string" }
+    }
+  },
+  "definitions": {
+    "item": {
+      "type": "object",
+      "required": ["path"],
+      "additionalProperties": false,
+      "properties": {
+        "path": {
+          "type": "string",
+          "minLength": 1,
+          "not": { "pattern": "^/" }
+        },
+        "kind": { "type": "string", "enum": ["file", "dir"] },
+        "at": { "type": "string", "format": "date-time" },
+        "size": { "type": ["integer", "null"] }
+      }
+    }
+  }
+}`
+
+func TestSchema_Validate(t *testing.T) {
+	s, err := Compile([]byte(testSchema))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		document string
+		want     []Problem
+	}{
+		{
+			name:     "valid yaml",
+			document: "format: test\nversion: 1\nitems:\n  - path: a\n    kind: dir\n    at: 2024-05-01T10:00:00Z\n    size: null\ntags:\n  a: b\n",
+			want:     []Problem{},
+		},
+		{
+			name:     "valid json",
+			document: `{"format": "test", "version": 1, "items": [{"path": "a", "size": 3}]}`,
+			want:     []Problem{},
+		},
+		{
+			name:     "invalid values",
+			document: "format: other\nversion: 2\nitems:\n  - path: /abs\n    kind: link\n  - path: \"\"\n    at: yesterday\n    size: big\ntags:\n  a: [b]\n",
+			want: []Problem{
+				{Field: "format", Line: 1, Message: `must be "test"`},
+				{Field: "version", Line: 2, Message: "must be one of 1"},
+				{Field: "items[0].path", Line: 4, Message: `"/abs" is not allowed`},
+				{Field: "items[0].kind", Line: 5, Message: `must be one of "file", "dir"`},
+				{Field: "items[1].path", Line: 6, Message: "must not be empty"},
+				{Field: "items[1].at", Line: 7, Message: `"yesterday" is not an RFC 3339 date and time`},
+				{Field: "items[1].size", Line: 8, Message: "must be an integer or null, not a string"},
+				{Field: "tags.a", Line: 10, Message: "must be a string, not an array"},
+			},
+		},
+		{
+			name:     "structure",
+			document: "version: \"1\"\nitems: []\nextra: true\n",
+			want: []Problem{
+				{Field: "version", Line: 1, Message: "must be an integer, not a string"},
+				{Field: "items", Line: 2, Message: "must have at least 1 item(s)"},
+				{Line: 3, Message: `unknown field "extra"`},
+				{Line: 1, Message: `missing required field "format"`},
+			},
+		},
+		{
+			name:     "nested unknown and missing fields",
+			document: "format: test\nversion: 1\nitems:\n  - kind: file\n    mode: 644\n",
+			want: []Problem{
+				{Field: "items[0]", Line: 5, Message: `unknown field "mode"`},
+				{Field: "items[0]", Line: 4, Message: `missing required field "path"`},
+			},
+		},
+		{
+			name:     "wrong root type",
+			document: "- a\n",
+			want:     []Problem{{Line: 1, Message: "must be an object, not an array"}},
+		},
+		{
+			name:     "syntax error",
+			document: "format: [test\n",
+			want:     []Problem{{Message: "parse document: line 1: did not find expected ',' or ']'"}},
+		},
+		{
+			name:     "empty",
+			document: "",
+			want:     []Problem{{Message: "document is empty"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, s.Validate([]byte(tt.document)))
+		})
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	_, err := Compile([]byte(`{"type": 1}`))
+	assert.ErrorContains(t, err, "type must be a string")
+
+	_, err = Compile([]byte(`{"items": {"$ref": "#/definitions/missing"}}`))
+	assert.ErrorContains(t, err, `unsupported schema reference "#/definitions/missing"`)
+
+	_, err = Compile([]byte(`{"pattern": "["}`))
+	assert.ErrorContains(t, err, `compile pattern "["`)
+}
+
+func TestProblem_String(t *testing.T) {
+	assert.Equal(t, "line 3: items[0].path: must not be empty",
+		Problem{Field: "items[0].path", Line: 3, Message: "must not be empty"}.String())
+	assert.Equal(t, "document is empty", Problem{Message: "document is empty"}.String())
+}
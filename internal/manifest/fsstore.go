@@ -2,7 +2,6 @@ package manifest
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -55,9 +54,11 @@ func (s *FSManifestStore) Load(ctx context.Context, targetDir domain.TargetPath)
 		return domain.Err[Manifest](fmt.Errorf("failed to read manifest: %w", err))
 	}
 
-	var m Manifest
-	if err := json.Unmarshal(data, &m); err != nil {
-		return domain.Err[Manifest](fmt.Errorf("failed to parse manifest: %w", err))
+	// A migrated manifest is not written back here, so that read-only
+	// commands leave the file alone; the next Save stores the new schema.
+	m, _, err := Decode(data)
+	if err != nil {
+		return domain.Err[Manifest](err)
 	}
 
 	return domain.Ok(m)
@@ -78,17 +79,20 @@ func (s *FSManifestStore) Save(ctx context.Context, targetDir domain.TargetPath,
 		return ctx.Err()
 	}
 
-	// Update timestamp
+	// Update timestamp and stamp the schema this version writes
 	manifest.UpdatedAt = time.Now()
+	manifest.SchemaVersion = CurrentSchemaVersion
+
+	return s.write(ctx, s.getManifestPath(targetDir), manifest)
+}
 
-	// Marshal to JSON with indentation
-	data, err := json.MarshalIndent(manifest, "", "  ")
+// write atomically writes manifest to manifestPath.
+func (s *FSManifestStore) write(ctx context.Context, manifestPath string, manifest Manifest) error {
+	data, err := Encode(manifest)
 	if err != nil {
-		return fmt.Errorf("failed to marshal manifest: %w", err)
+		return err
 	}
 
-	manifestPath := s.getManifestPath(targetDir)
-
 	// Ensure manifest directory exists
 	manifestDir := filepath.Dir(manifestPath)
 	if !s.fs.Exists(ctx, manifestDir) {
@@ -97,8 +101,11 @@ func (s *FSManifestStore) Save(ctx context.Context, targetDir domain.TargetPath,
 		}
 	}
 
-	// Atomic write via temp file and rename
+	// Atomic write via temp file and rename. A temp file left over is
+	// removed first, so that the write creates a new file rather than
+	// writing through whatever is there.
 	tempPath := manifestPath + ".tmp"
+	_ = s.fs.Remove(ctx, tempPath)
 
 	// Write to temp file
 	if err := s.fs.WriteFile(ctx, tempPath, data, 0644); err != nil {
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"sort"
+	"time"
 
 	"github.com/jamesainslie/dot/internal/domain"
 )
@@ -71,6 +72,65 @@ func (h *ContentHasher) HashPackage(ctx context.Context, pkgPath domain.PackageP
 	return hex.EncodeToString(hasher.Sum(nil)), nil
 }
 
+// HashFiles computes the content hash of every regular file in the
+// package. A file whose size and modification time match its entry in
+// previous keeps that entry without being read; pass nil to hash every
+// file.
+func (h *ContentHasher) HashFiles(ctx context.Context, pkgPath domain.PackagePath, previous map[string]FileHash) (map[string]FileHash, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	var files []string
+	if err := h.walkPackage(ctx, pkgPath.String(), pkgPath.String(), &files); err != nil {
+		return nil, fmt.Errorf("failed to walk package: %w", err)
+	}
+
+	hashes := make(map[string]FileHash, len(files))
+	for _, relPath := range files {
+		fullPath := filepath.Join(pkgPath.String(), relPath)
+		key := filepath.ToSlash(relPath)
+
+		info, err := h.fs.Stat(ctx, fullPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat file %s: %w", fullPath, err)
+		}
+		modTime, _ := info.ModTime().(time.Time)
+
+		if prev, ok := previous[key]; ok && prev.Size == info.Size() && !modTime.IsZero() && prev.ModTime.Equal(modTime) {
+			hashes[key] = prev
+			continue
+		}
+
+		data, err := h.fs.ReadFile(ctx, fullPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file %s: %w", fullPath, err)
+		}
+		sum := sha256.Sum256(data)
+		hashes[key] = FileHash{
+			Hash:    hex.EncodeToString(sum[:]),
+			Size:    info.Size(),
+			ModTime: modTime,
+		}
+	}
+	return hashes, nil
+}
+
+// SameFiles reports whether two file hash trees hold the same paths with
+// the same content, regardless of recorded sizes and times.
+func SameFiles(a, b map[string]FileHash) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, hash := range a {
+		other, ok := b[path]
+		if !ok || other.Hash != hash.Hash {
+			return false
+		}
+	}
+	return true
+}
+
 // walkPackage collects regular files recursively
 func (h *ContentHasher) walkPackage(ctx context.Context, root, current string, files *[]string) error {
 	entries, err := h.fs.ReadDir(ctx, current)
@@ -188,3 +188,51 @@ func TestContentHasher_HashPackage_NoCollisions(t *testing.T) {
 	// Hashes must be different due to delimiter preventing concatenation ambiguity
 	assert.NotEqual(t, hash1, hash2, "delimiter should prevent hash collision")
 }
+
+func TestContentHasher_HashFiles(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	pkgPath := mustPackagePath(t, "/packages/vim")
+	require.NoError(t, fs.MkdirAll(ctx, filepath.Join(pkgPath.String(), "colors"), 0755))
+	require.NoError(t, fs.WriteFile(ctx, filepath.Join(pkgPath.String(), "dot-vimrc"), []byte("set number\n"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, filepath.Join(pkgPath.String(), "colors", "dark.vim"), []byte("hi Normal\n"), 0644))
+
+	hasher := NewContentHasher(fs)
+	files, err := hasher.HashFiles(ctx, pkgPath, nil)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	assert.Contains(t, files, "colors/dark.vim")
+	assert.Len(t, files["dot-vimrc"].Hash, 64)
+	assert.Equal(t, int64(len("set number\n")), files["dot-vimrc"].Size)
+
+	again, err := hasher.HashFiles(ctx, pkgPath, nil)
+	require.NoError(t, err)
+	assert.True(t, SameFiles(files, again))
+
+	t.Run("unchanged files are not read", func(t *testing.T) {
+		previous := map[string]FileHash{}
+		for path, hash := range files {
+			hash.Hash = "recorded"
+			previous[path] = hash
+		}
+		reused, err := hasher.HashFiles(ctx, pkgPath, previous)
+		require.NoError(t, err)
+		assert.Equal(t, "recorded", reused["dot-vimrc"].Hash)
+	})
+
+	t.Run("changed files are rehashed", func(t *testing.T) {
+		require.NoError(t, fs.WriteFile(ctx, filepath.Join(pkgPath.String(), "dot-vimrc"), []byte("set nonumber\n"), 0644))
+		changed, err := hasher.HashFiles(ctx, pkgPath, files)
+		require.NoError(t, err)
+		assert.False(t, SameFiles(files, changed))
+		assert.Equal(t, files["colors/dark.vim"], changed["colors/dark.vim"])
+	})
+}
+
+func TestSameFiles(t *testing.T) {
+	a := map[string]FileHash{"a": {Hash: "1", Size: 1}}
+	assert.True(t, SameFiles(a, map[string]FileHash{"a": {Hash: "1", Size: 2}}), "only content counts")
+	assert.False(t, SameFiles(a, map[string]FileHash{"a": {Hash: "2"}}))
+	assert.False(t, SameFiles(a, map[string]FileHash{"b": {Hash: "1"}}))
+	assert.False(t, SameFiles(a, nil))
+}
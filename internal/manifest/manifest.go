@@ -4,11 +4,13 @@ import "time"
 
 // Manifest tracks installed package state
 type Manifest struct {
-	Version    string                 `json:"version"`
-	UpdatedAt  time.Time              `json:"updated_at"`
-	Packages   map[string]PackageInfo `json:"packages"`
-	Hashes     map[string]string      `json:"hashes"`
-	Repository *RepositoryInfo        `json:"repository,omitempty"`
+	// SchemaVersion identifies the manifest structure; see CurrentSchemaVersion.
+	SchemaVersion int                    `json:"schema_version"`
+	Version       string                 `json:"version"`
+	UpdatedAt     time.Time              `json:"updated_at"`
+	Packages      map[string]PackageInfo `json:"packages"`
+	Hashes        map[string]string      `json:"hashes"`
+	Repository    *RepositoryInfo        `json:"repository,omitempty"`
 }
 
 // PackageSource indicates how a package was installed
@@ -27,7 +29,37 @@ type PackageInfo struct {
 	InstalledAt time.Time     `json:"installed_at"`
 	LinkCount   int           `json:"link_count"`
 	Links       []string      `json:"links"`
-	Source      PackageSource `json:"source,omitempty"` // How package was installed (adopted vs managed)
+	Source      PackageSource `json:"source,omitempty"`    // How package was installed (adopted vs managed)
+	Templates   []RenderInfo  `json:"templates,omitempty"` // Links served from rendered templates
+
+	// Dirs lists the directories dot created in the target directory to
+	// hold the package's links, relative to the target directory.
+	Dirs []string `json:"dirs,omitempty"`
+
+	// Files holds the content hash of each package file, keyed by
+	// slash-separated path relative to the package directory.
+	Files map[string]FileHash `json:"files,omitempty"`
+}
+
+// FileHash records the content hash of a package file together with the
+// size and modification time it had when hashed, so that an unchanged
+// file is recognized without reading it again.
+type FileHash struct {
+	Hash    string    `json:"hash"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// RenderInfo records that a link points at output rendered from a package template.
+type RenderInfo struct {
+	// Link is the link path relative to the target directory.
+	Link string `json:"link"`
+
+	// Template is the absolute path of the template file in the package.
+	Template string `json:"template"`
+
+	// Rendered is the absolute path of the rendered output in the cache directory.
+	Rendered string `json:"rendered"`
 }
 
 // RepositoryInfo contains metadata about the cloned repository.
@@ -38,6 +70,10 @@ type RepositoryInfo struct {
 	// Branch is the cloned branch name.
 	Branch string `json:"branch"`
 
+	// BaseBranch is the shared branch that Branch, a per-machine branch, is
+	// rebased onto when syncing. Empty if Branch is pulled directly.
+	BaseBranch string `json:"base_branch,omitempty"`
+
 	// ClonedAt is the timestamp when the repository was cloned.
 	ClonedAt time.Time `json:"cloned_at"`
 
@@ -48,10 +84,11 @@ type RepositoryInfo struct {
 // New creates a new empty manifest
 func New() Manifest {
 	return Manifest{
-		Version:   "1.0",
-		UpdatedAt: time.Now(),
-		Packages:  make(map[string]PackageInfo),
-		Hashes:    make(map[string]string),
+		SchemaVersion: CurrentSchemaVersion,
+		Version:       "1.0",
+		UpdatedAt:     time.Now(),
+		Packages:      make(map[string]PackageInfo),
+		Hashes:        make(map[string]string),
 	}
 }
 
@@ -0,0 +1,275 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// MergeResult is the outcome of a three-way manifest merge.
+type MergeResult struct {
+	// Manifest is the merged manifest. When Conflicts is not empty it holds
+	// the local side of each conflicting entry.
+	Manifest Manifest
+
+	// Resolved describes entries changed on both sides that were combined
+	// automatically and may deserve review.
+	Resolved []string
+
+	// Conflicts describes entries that could not be merged.
+	Conflicts []string
+}
+
+// Merge combines two manifests that diverged from base, entry by entry.
+//
+// A package or hash changed on one side only takes that side's value. A
+// package changed on both sides keeps the union of its links and
+// templates. A hash changed differently on both sides is dropped, so the
+// next remanage recomputes it. A package removed on one side and changed
+// on the other is kept. Repository information changed differently on
+// both sides is a conflict.
+func Merge(base, ours, theirs Manifest) MergeResult {
+	merged := New()
+	merged.Version = ours.Version
+	merged.UpdatedAt = ours.UpdatedAt
+	if theirs.UpdatedAt.After(merged.UpdatedAt) {
+		merged.UpdatedAt = theirs.UpdatedAt
+	}
+
+	var result MergeResult
+	for _, name := range unionKeys(base.Packages, ours.Packages, theirs.Packages) {
+		b, inBase := base.Packages[name]
+		o, inOurs := ours.Packages[name]
+		t, inTheirs := theirs.Packages[name]
+
+		if inOurs && inTheirs && samePackage(o, t) {
+			// Both sides installed the same links; keep the later install
+			if t.InstalledAt.After(o.InstalledAt) {
+				o = t
+			}
+			merged.Packages[name] = o
+			continue
+		}
+
+		pkg, keep, combined := mergeEntry(b, o, t, inBase, inOurs, inTheirs, func(x, y PackageInfo) bool { return reflect.DeepEqual(x, y) })
+		if combined {
+			switch {
+			case inOurs && inTheirs:
+				pkg, keep = unionPackages(o, t), true
+				result.Resolved = append(result.Resolved, fmt.Sprintf("package %s: combined links from both sides", name))
+			case inOurs:
+				pkg, keep = o, true
+				result.Resolved = append(result.Resolved, fmt.Sprintf("package %s: kept local changes although removed remotely", name))
+			default:
+				pkg, keep = t, true
+				result.Resolved = append(result.Resolved, fmt.Sprintf("package %s: kept remote changes although removed locally", name))
+			}
+		}
+		if keep {
+			merged.Packages[name] = pkg
+		}
+	}
+
+	for _, name := range unionKeys(base.Hashes, ours.Hashes, theirs.Hashes) {
+		b, inBase := base.Hashes[name]
+		o, inOurs := ours.Hashes[name]
+		t, inTheirs := theirs.Hashes[name]
+
+		hash, keep, combined := mergeEntry(b, o, t, inBase, inOurs, inTheirs, func(x, y string) bool { return x == y })
+		if combined {
+			result.Resolved = append(result.Resolved, fmt.Sprintf("hash %s: dropped, recomputed on next remanage", name))
+			continue
+		}
+		if keep {
+			merged.Hashes[name] = hash
+		}
+	}
+	// A hash without its package is never read
+	for name := range merged.Hashes {
+		if _, ok := merged.Packages[name]; !ok {
+			delete(merged.Hashes, name)
+		}
+	}
+
+	repo, keep, combined := mergeEntry(deref(base.Repository), deref(ours.Repository), deref(theirs.Repository),
+		base.Repository != nil, ours.Repository != nil, theirs.Repository != nil,
+		func(x, y RepositoryInfo) bool { return x == y })
+	if combined {
+		result.Conflicts = append(result.Conflicts, "repository: changed on both sides")
+		repo, keep = deref(ours.Repository), ours.Repository != nil
+	}
+	if keep {
+		merged.Repository = &repo
+	}
+
+	result.Manifest = merged
+	return result
+}
+
+// mergeEntry merges one entry present in any of the three versions. It
+// returns the merged value and whether the entry exists after the merge,
+// or combined=true if both sides changed it differently.
+func mergeEntry[T any](b, o, t T, inBase, inOurs, inTheirs bool, equal func(T, T) bool) (value T, keep bool, combined bool) {
+	same := func(x T, inX bool, y T, inY bool) bool {
+		return inX == inY && (!inX || equal(x, y))
+	}
+
+	switch {
+	case same(o, inOurs, t, inTheirs):
+		return o, inOurs, false
+	case same(b, inBase, o, inOurs):
+		return t, inTheirs, false
+	case same(b, inBase, t, inTheirs):
+		return o, inOurs, false
+	default:
+		return value, false, true
+	}
+}
+
+// samePackage compares package entries ignoring link order and the
+// install time, which changes whenever a package is remanaged.
+func samePackage(a, b PackageInfo) bool {
+	a.InstalledAt = b.InstalledAt
+	a.Links, b.Links = sortedCopy(a.Links), sortedCopy(b.Links)
+	return reflect.DeepEqual(a, b)
+}
+
+// unionPackages combines two versions of a package entry.
+func unionPackages(o, t PackageInfo) PackageInfo {
+	pkg := o
+	if t.InstalledAt.After(pkg.InstalledAt) {
+		pkg.InstalledAt = t.InstalledAt
+	}
+
+	seen := make(map[string]bool)
+	pkg.Links = nil
+	for _, link := range append(append([]string{}, o.Links...), t.Links...) {
+		if !seen[link] {
+			seen[link] = true
+			pkg.Links = append(pkg.Links, link)
+		}
+	}
+	sort.Strings(pkg.Links)
+	pkg.LinkCount = len(pkg.Links)
+
+	rendered := make(map[string]bool)
+	pkg.Templates = nil
+	for _, tmpl := range append(append([]RenderInfo{}, o.Templates...), t.Templates...) {
+		if !rendered[tmpl.Link] {
+			rendered[tmpl.Link] = true
+			pkg.Templates = append(pkg.Templates, tmpl)
+		}
+	}
+
+	// The file hashes of either side describe neither; without them the
+	// next remanage rehashes the package
+	pkg.Files = nil
+	return pkg
+}
+
+func unionKeys[V any](maps ...map[string]V) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, m := range maps {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedCopy(s []string) []string {
+	c := append([]string{}, s...)
+	sort.Strings(c)
+	return c
+}
+
+func deref(info *RepositoryInfo) RepositoryInfo {
+	if info == nil {
+		return RepositoryInfo{}
+	}
+	return *info
+}
+
+// Decode parses a manifest document, migrating it to the current schema.
+// The boolean reports whether a migration ran.
+func Decode(data []byte) (Manifest, bool, error) {
+	data, migrated, err := Migrate(data)
+	if err != nil {
+		return Manifest{}, false, fmt.Errorf("failed to migrate manifest: %w", err)
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return Manifest{}, false, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	if m.Packages == nil {
+		m.Packages = make(map[string]PackageInfo)
+	}
+	if m.Hashes == nil {
+		m.Hashes = make(map[string]string)
+	}
+	return m, migrated, nil
+}
+
+// Encode formats a manifest as written to disk.
+func Encode(m Manifest) ([]byte, error) {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+	return data, nil
+}
+
+// SplitConflict separates a file containing git conflict markers into the
+// local, base and remote versions. Text outside conflict regions is shared
+// by all three. The base is only present for files written with the diff3
+// conflict style; hasBase reports whether any region had one.
+func SplitConflict(data []byte) (ours, base, theirs []byte, hasBase bool, err error) {
+	const (
+		shared = iota
+		inOurs
+		inBase
+		inTheirs
+	)
+
+	var o, b, t bytes.Buffer
+	state := shared
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		marker := func(prefix string) bool { return strings.HasPrefix(line, prefix) }
+		switch {
+		case marker("<<<<<<<") && state == shared:
+			state = inOurs
+		case marker("|||||||") && state == inOurs:
+			state, hasBase = inBase, true
+		case marker("=======") && (state == inOurs || state == inBase):
+			state = inTheirs
+		case marker(">>>>>>>") && state == inTheirs:
+			state = shared
+		default:
+			switch state {
+			case shared:
+				o.WriteString(line)
+				b.WriteString(line)
+				t.WriteString(line)
+			case inOurs:
+				o.WriteString(line)
+			case inBase:
+				b.WriteString(line)
+			case inTheirs:
+				t.WriteString(line)
+			}
+		}
+	}
+	if state != shared {
+		return nil, nil, nil, false, fmt.Errorf("unterminated conflict region")
+	}
+	return o.Bytes(), b.Bytes(), t.Bytes(), hasBase, nil
+}
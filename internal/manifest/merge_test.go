@@ -0,0 +1,126 @@
+package manifest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mergeTestManifest(packages ...PackageInfo) Manifest {
+	m := New()
+	for _, pkg := range packages {
+		pkg.LinkCount = len(pkg.Links)
+		m.Packages[pkg.Name] = pkg
+		m.Hashes[pkg.Name] = "hash-" + pkg.Name
+	}
+	return m
+}
+
+func TestMerge_OneSidedChanges(t *testing.T) {
+	day := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	vim := PackageInfo{Name: "vim", InstalledAt: day, Links: []string{".vimrc"}}
+	zsh := PackageInfo{Name: "zsh", InstalledAt: day, Links: []string{".zshrc"}}
+	git := PackageInfo{Name: "git", InstalledAt: day, Links: []string{".gitconfig"}}
+
+	base := mergeTestManifest(vim, zsh)
+	ours := mergeTestManifest(vim, zsh, git) // installed git
+	theirs := mergeTestManifest(vim)         // removed zsh
+	theirs.Hashes["vim"] = "hash-vim-2"
+
+	result := Merge(base, ours, theirs)
+
+	assert.Empty(t, result.Conflicts)
+	assert.Empty(t, result.Resolved)
+	assert.ElementsMatch(t, []string{"vim", "git"}, keys(result.Manifest.Packages))
+	assert.Equal(t, map[string]string{"vim": "hash-vim-2", "git": "hash-git"}, result.Manifest.Hashes)
+}
+
+func TestMerge_BothChangedPackage(t *testing.T) {
+	day := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	base := mergeTestManifest(PackageInfo{Name: "vim", InstalledAt: day, Links: []string{".vimrc"}})
+	ours := mergeTestManifest(PackageInfo{Name: "vim", InstalledAt: day.Add(time.Hour), Links: []string{".vimrc", ".vim/colors"}})
+	theirs := mergeTestManifest(PackageInfo{Name: "vim", InstalledAt: day.Add(2 * time.Hour), Links: []string{".vim/ftplugin", ".vimrc"}})
+	ours.Hashes["vim"] = "ours"
+	theirs.Hashes["vim"] = "theirs"
+
+	result := Merge(base, ours, theirs)
+
+	assert.Empty(t, result.Conflicts)
+	vim := result.Manifest.Packages["vim"]
+	assert.Equal(t, []string{".vim/colors", ".vim/ftplugin", ".vimrc"}, vim.Links)
+	assert.Equal(t, 3, vim.LinkCount)
+	assert.Equal(t, day.Add(2*time.Hour), vim.InstalledAt)
+	_, hasHash := result.Manifest.Hashes["vim"]
+	assert.False(t, hasHash, "diverged hash is dropped")
+	assert.Len(t, result.Resolved, 2)
+}
+
+func TestMerge_SameChangeOnBothSides(t *testing.T) {
+	day := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	base := mergeTestManifest()
+	ours := mergeTestManifest(PackageInfo{Name: "vim", InstalledAt: day, Links: []string{".vimrc", ".vim"}})
+	theirs := mergeTestManifest(PackageInfo{Name: "vim", InstalledAt: day.Add(time.Hour), Links: []string{".vim", ".vimrc"}})
+
+	result := Merge(base, ours, theirs)
+
+	assert.Empty(t, result.Resolved)
+	assert.Equal(t, day.Add(time.Hour), result.Manifest.Packages["vim"].InstalledAt)
+}
+
+func TestMerge_RemovedAndChanged(t *testing.T) {
+	day := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	base := mergeTestManifest(PackageInfo{Name: "vim", InstalledAt: day, Links: []string{".vimrc"}})
+	ours := mergeTestManifest()
+	theirs := mergeTestManifest(PackageInfo{Name: "vim", InstalledAt: day, Links: []string{".vimrc", ".vim"}})
+
+	result := Merge(base, ours, theirs)
+
+	require.Contains(t, result.Manifest.Packages, "vim")
+	assert.Equal(t, []string{"package vim: kept remote changes although removed locally"}, result.Resolved)
+}
+
+func TestMerge_RepositoryConflict(t *testing.T) {
+	base := New()
+	base.SetRepository(RepositoryInfo{URL: "https://example.com/dotfiles", CommitSHA: "aaa"})
+	ours := New()
+	ours.SetRepository(RepositoryInfo{URL: "https://example.com/dotfiles", CommitSHA: "bbb"})
+	theirs := New()
+	theirs.SetRepository(RepositoryInfo{URL: "https://example.com/dotfiles", CommitSHA: "ccc"})
+
+	result := Merge(base, ours, theirs)
+	assert.Equal(t, []string{"repository: changed on both sides"}, result.Conflicts)
+	assert.Equal(t, "bbb", result.Manifest.Repository.CommitSHA)
+
+	// A one-sided change merges cleanly
+	result = Merge(base, base, theirs)
+	assert.Empty(t, result.Conflicts)
+	assert.Equal(t, "ccc", result.Manifest.Repository.CommitSHA)
+}
+
+func TestSplitConflict(t *testing.T) {
+	data := "{\n<<<<<<< HEAD\n  \"a\": 1\n||||||| base\n  \"a\": 0\n=======\n  \"a\": 2\n>>>>>>> theirs\n}\n"
+
+	ours, base, theirs, hasBase, err := SplitConflict([]byte(data))
+	require.NoError(t, err)
+	assert.True(t, hasBase)
+	assert.Equal(t, "{\n  \"a\": 1\n}\n", string(ours))
+	assert.Equal(t, "{\n  \"a\": 0\n}\n", string(base))
+	assert.Equal(t, "{\n  \"a\": 2\n}\n", string(theirs))
+
+	_, _, _, hasBase, err = SplitConflict([]byte("<<<<<<< HEAD\n1\n=======\n2\n>>>>>>> theirs\n"))
+	require.NoError(t, err)
+	assert.False(t, hasBase)
+
+	_, _, _, _, err = SplitConflict([]byte("<<<<<<< HEAD\n1\n"))
+	assert.Error(t, err)
+}
+
+func keys[V any](m map[string]V) []string {
+	var out []string
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
@@ -0,0 +1,147 @@
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// CurrentSchemaVersion is the manifest schema written by this version of dot.
+// Increment it together with a new entry in migrations whenever the
+// manifest structure changes.
+const CurrentSchemaVersion = 1
+
+// Migration upgrades a decoded manifest document by one schema version.
+//
+// Migrations operate on the raw JSON document rather than on Manifest so
+// they can rename or restructure fields that no longer exist in the
+// current type.
+type Migration struct {
+	// From is the schema version the migration upgrades. The result is From+1.
+	From int
+
+	// Description briefly states what the migration changes.
+	Description string
+
+	// Apply rewrites doc in place.
+	Apply func(doc map[string]any) error
+}
+
+// migrations is the ordered migration registry. Manifests written before
+// schema versioning was introduced have no schema_version and are treated
+// as version 0.
+var migrations = []Migration{
+	{
+		From:        0,
+		Description: "add schema version and normalize package entries",
+		Apply:       migrateV0,
+	},
+}
+
+// ErrUnsupportedSchema indicates a manifest written by a newer version of dot.
+type ErrUnsupportedSchema struct {
+	Version int
+}
+
+func (e ErrUnsupportedSchema) Error() string {
+	return fmt.Sprintf("manifest schema version %d is newer than supported version %d; upgrade dot",
+		e.Version, CurrentSchemaVersion)
+}
+
+// SupportedSchemaVersions returns the manifest schema versions this version
+// of dot reads, oldest first. Older versions are migrated on load.
+func SupportedSchemaVersions() []int {
+	versions := make([]int, 0, CurrentSchemaVersion+1)
+	for version := 0; version <= CurrentSchemaVersion; version++ {
+		versions = append(versions, version)
+	}
+	return versions
+}
+
+// Migrate upgrades a raw manifest to CurrentSchemaVersion.
+//
+// Returns the upgraded document and whether any migration ran. Manifests
+// already at the current version are returned unchanged.
+func Migrate(data []byte) ([]byte, bool, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, false, err
+	}
+
+	version, err := schemaVersion(doc)
+	if err != nil {
+		return nil, false, err
+	}
+	if version > CurrentSchemaVersion {
+		return nil, false, ErrUnsupportedSchema{Version: version}
+	}
+	if version == CurrentSchemaVersion {
+		return data, false, nil
+	}
+
+	for _, m := range migrations {
+		if m.From < version {
+			continue
+		}
+		if m.From != version {
+			return nil, false, fmt.Errorf("no manifest migration from schema version %d", version)
+		}
+		if err := m.Apply(doc); err != nil {
+			return nil, false, fmt.Errorf("migrate manifest from schema version %d: %w", m.From, err)
+		}
+		version = m.From + 1
+		doc["schema_version"] = version
+	}
+	if version != CurrentSchemaVersion {
+		return nil, false, fmt.Errorf("no manifest migration from schema version %d", version)
+	}
+
+	migrated, err := json.Marshal(doc)
+	if err != nil {
+		return nil, false, err
+	}
+	return migrated, true, nil
+}
+
+// schemaVersion reads schema_version from doc, treating a missing field as 0.
+func schemaVersion(doc map[string]any) (int, error) {
+	raw, exists := doc["schema_version"]
+	if !exists || raw == nil {
+		return 0, nil
+	}
+	number, ok := raw.(float64)
+	if !ok || number < 0 || number != float64(int(number)) {
+		return 0, fmt.Errorf("invalid manifest schema_version: %v", raw)
+	}
+	return int(number), nil
+}
+
+// migrateV0 normalizes manifests written before schema versioning.
+// Package entries gain their name and source when missing, and link_count
+// is recomputed from the recorded links.
+func migrateV0(doc map[string]any) error {
+	if _, ok := doc["hashes"].(map[string]any); !ok {
+		doc["hashes"] = map[string]any{}
+	}
+
+	packages, ok := doc["packages"].(map[string]any)
+	if !ok {
+		doc["packages"] = map[string]any{}
+		return nil
+	}
+
+	for name, raw := range packages {
+		pkg, ok := raw.(map[string]any)
+		if !ok {
+			return fmt.Errorf("package %q: expected object", name)
+		}
+		if pkgName, _ := pkg["name"].(string); pkgName == "" {
+			pkg["name"] = name
+		}
+		if source, _ := pkg["source"].(string); source == "" {
+			pkg["source"] = string(SourceManaged)
+		}
+		links, _ := pkg["links"].([]any)
+		pkg["link_count"] = len(links)
+	}
+	return nil
+}
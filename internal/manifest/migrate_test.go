@@ -0,0 +1,136 @@
+package manifest
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const unversionedManifest = `{
+  "version": "1.0",
+  "updated_at": "2025-01-01T00:00:00Z",
+  "packages": {
+    "vim": {
+      "installed_at": "2025-01-01T00:00:00Z",
+      "link_count": 5,
+      "links": [".vimrc", ".gvimrc"]
+    }
+  }
+}`
+
+func TestMigrations_ContiguousRegistry(t *testing.T) {
+	for i, m := range migrations {
+		assert.Equal(t, i, m.From, "migration %d must upgrade from version %d", i, i)
+		assert.NotEmpty(t, m.Description)
+		assert.NotNil(t, m.Apply)
+	}
+	assert.Len(t, migrations, CurrentSchemaVersion)
+}
+
+func TestMigrate_Unversioned(t *testing.T) {
+	data, migrated, err := Migrate([]byte(unversionedManifest))
+	require.NoError(t, err)
+	assert.True(t, migrated)
+
+	var m Manifest
+	require.NoError(t, json.Unmarshal(data, &m))
+	assert.Equal(t, CurrentSchemaVersion, m.SchemaVersion)
+	require.Contains(t, m.Packages, "vim")
+	pkg := m.Packages["vim"]
+	assert.Equal(t, "vim", pkg.Name)
+	assert.Equal(t, SourceManaged, pkg.Source)
+	assert.Equal(t, 2, pkg.LinkCount)
+	assert.NotNil(t, m.Hashes)
+}
+
+func TestMigrate_CurrentVersionUnchanged(t *testing.T) {
+	input := []byte(`{"schema_version": 1, "version": "1.0", "packages": {}, "hashes": {}}`)
+
+	data, migrated, err := Migrate(input)
+
+	require.NoError(t, err)
+	assert.False(t, migrated)
+	assert.Equal(t, input, data)
+}
+
+func TestMigrate_NewerVersion(t *testing.T) {
+	_, _, err := Migrate([]byte(`{"schema_version": 99}`))
+
+	var unsupported ErrUnsupportedSchema
+	require.ErrorAs(t, err, &unsupported)
+	assert.Equal(t, 99, unsupported.Version)
+	assert.Contains(t, err.Error(), "upgrade dot")
+}
+
+func TestMigrate_InvalidVersion(t *testing.T) {
+	_, _, err := Migrate([]byte(`{"schema_version": "one"}`))
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid manifest schema_version")
+}
+
+func TestFSManifestStore_Load_MigratesWithoutWriting(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+	require.NoError(t, fs.MkdirAll(ctx, "/home/user", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/home/user/.dot-manifest.json", []byte(unversionedManifest), 0644))
+
+	store := NewFSManifestStore(fs)
+	result := store.Load(ctx, mustTargetPath(t, "/home/user"))
+
+	require.True(t, result.IsOk())
+	m := result.Unwrap()
+	assert.Equal(t, CurrentSchemaVersion, m.SchemaVersion)
+	assert.Equal(t, 2, m.Packages["vim"].LinkCount)
+
+	// Loading leaves the file as it was
+	data, err := fs.ReadFile(ctx, "/home/user/.dot-manifest.json")
+	require.NoError(t, err)
+	assert.Equal(t, unversionedManifest, string(data))
+
+	// The next save stores the new schema
+	require.NoError(t, store.Save(ctx, mustTargetPath(t, "/home/user"), m))
+	data, err = fs.ReadFile(ctx, "/home/user/.dot-manifest.json")
+	require.NoError(t, err)
+	var onDisk Manifest
+	require.NoError(t, json.Unmarshal(data, &onDisk))
+	assert.Equal(t, CurrentSchemaVersion, onDisk.SchemaVersion)
+	assert.Equal(t, "vim", onDisk.Packages["vim"].Name)
+}
+
+func TestFSManifestStore_Load_RejectsNewerSchema(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+	require.NoError(t, fs.MkdirAll(ctx, "/home/user", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/home/user/.dot-manifest.json", []byte(`{"schema_version": 99}`), 0644))
+
+	store := NewFSManifestStore(fs)
+	result := store.Load(ctx, mustTargetPath(t, "/home/user"))
+
+	require.False(t, result.IsOk())
+	assert.Contains(t, result.UnwrapErr().Error(), "newer than supported")
+}
+
+func TestFSManifestStore_Save_StampsSchemaVersion(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+	require.NoError(t, fs.MkdirAll(ctx, "/home/user", 0755))
+
+	store := NewFSManifestStore(fs)
+	require.NoError(t, store.Save(ctx, mustTargetPath(t, "/home/user"), Manifest{Packages: map[string]PackageInfo{}}))
+
+	data, err := fs.ReadFile(ctx, "/home/user/.dot-manifest.json")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"schema_version": 1`)
+}
+
+func TestSupportedSchemaVersions(t *testing.T) {
+	versions := SupportedSchemaVersions()
+	require.NotEmpty(t, versions)
+	assert.Equal(t, 0, versions[0])
+	assert.Equal(t, CurrentSchemaVersion, versions[len(versions)-1])
+}
@@ -42,6 +42,24 @@ func convertWarnings(warnings []planner.Warning) []domain.WarningInfo {
 	return infos
 }
 
+// convertReplacements converts planner.Replacement to domain.ReplacementInfo
+// for plan metadata.
+func convertReplacements(replacements []planner.Replacement) []domain.ReplacementInfo {
+	if len(replacements) == 0 {
+		return nil
+	}
+
+	infos := make([]domain.ReplacementInfo, 0, len(replacements))
+	for _, r := range replacements {
+		infos = append(infos, domain.ReplacementInfo{
+			Path:        r.Path,
+			Replacement: r.With,
+			Policy:      string(r.Policy),
+		})
+	}
+	return infos
+}
+
 // copyContext creates a shallow copy of a context map.
 // Returns nil if the input is nil, otherwise returns a new map with copied entries.
 // This prevents shared mutation between planner structures and public API metadata.
@@ -2,11 +2,15 @@ package pipeline
 
 import (
 	"context"
+	"fmt"
 	"path/filepath"
+	"strings"
 
 	"github.com/jamesainslie/dot/internal/domain"
 	"github.com/jamesainslie/dot/internal/ignore"
 	"github.com/jamesainslie/dot/internal/planner"
+	"github.com/jamesainslie/dot/internal/scanner"
+	"github.com/jamesainslie/dot/internal/templating"
 )
 
 // ManagePipelineOpts contains options for the Manage pipeline
@@ -16,6 +20,15 @@ type ManagePipelineOpts struct {
 	Policies           planner.ResolutionPolicies
 	BackupDir          string
 	PackageNameMapping bool
+	Renderer           *templating.Renderer // Optional: renders *.tmpl package files
+	SpecialFiles       domain.SpecialFilePolicy
+
+	// ScanConcurrency bounds how many packages are scanned at once.
+	ScanConcurrency int
+
+	// ScanCache keeps the trees of unchanged packages between runs.
+	// Optional: without it every package is scanned.
+	ScanCache *scanner.TreeCache
 }
 
 // ManageInput contains the input for manage operations
@@ -39,7 +52,7 @@ func NewManagePipeline(opts ManagePipelineOpts) *ManagePipeline {
 }
 
 // Execute runs the complete manage pipeline.
-// It performs: scan packages -> compute desired state -> resolve conflicts -> sort operations
+// It performs: scan packages -> compute desired state -> render templates -> resolve conflicts -> sort operations
 func (p *ManagePipeline) Execute(ctx context.Context, input ManageInput) domain.Result[domain.Plan] {
 	// Stage 1: Scan packages
 	scanInput := ScanInput{
@@ -48,6 +61,9 @@ func (p *ManagePipeline) Execute(ctx context.Context, input ManageInput) domain.
 		Packages:   input.Packages,
 		IgnoreSet:  p.opts.IgnoreSet,
 		FS:         p.opts.FS,
+
+		Concurrency: p.opts.ScanConcurrency,
+		Cache:       p.opts.ScanCache,
 	}
 
 	scanResult := ScanStage()(ctx, scanInput)
@@ -56,6 +72,11 @@ func (p *ManagePipeline) Execute(ctx context.Context, input ManageInput) domain.
 	}
 	packages := scanResult.Unwrap()
 
+	specialWarnings, err := checkSpecialFiles(ctx, p.opts.FS, packages, p.opts.SpecialFiles)
+	if err != nil {
+		return domain.Err[domain.Plan](err)
+	}
+
 	// Stage 2: Compute desired state
 	planInput := PlanInput{
 		Packages:           packages,
@@ -69,12 +90,23 @@ func (p *ManagePipeline) Execute(ctx context.Context, input ManageInput) domain.
 	}
 	desired := planResult.Unwrap()
 
+	// Stage 2b: Render templates into the cache directory
+	renderResult := RenderStage()(ctx, RenderInput{
+		Desired:  desired,
+		Renderer: p.opts.Renderer,
+	})
+	if renderResult.IsErr() {
+		return domain.Err[domain.Plan](renderResult.UnwrapErr())
+	}
+	desired = renderResult.Unwrap()
+
 	// Stage 3: Resolve conflicts and generate operations
 	resolveInput := ResolveInput{
-		Desired:   desired,
-		FS:        p.opts.FS,
-		Policies:  p.opts.Policies,
-		BackupDir: p.opts.BackupDir,
+		Desired:    desired,
+		FS:         p.opts.FS,
+		Policies:   p.opts.Policies,
+		BackupDir:  p.opts.BackupDir,
+		PackageDir: input.PackageDir.String(),
 	}
 
 	resolveResult := ResolveStage()(ctx, resolveInput)
@@ -95,8 +127,11 @@ func (p *ManagePipeline) Execute(ctx context.Context, input ManageInput) domain.
 				LinkCount:      countOperationsByKind(resolved.Operations, domain.OpKindLinkCreate),
 				DirCount:       countOperationsByKind(resolved.Operations, domain.OpKindDirCreate),
 				Conflicts:      convertConflicts(resolved.Conflicts),
-				Warnings:       convertWarnings(resolved.Warnings),
+				Warnings:       append(specialWarnings, convertWarnings(resolved.Warnings)...),
+				Replacements:   convertReplacements(resolved.Replacements),
 			},
+			PackageOperations: buildPackageOperationMapping(packages, concatOperations(resolved.Operations, resolved.Satisfied)),
+			Satisfied:         resolved.Satisfied,
 		})
 	}
 
@@ -111,8 +146,9 @@ func (p *ManagePipeline) Execute(ctx context.Context, input ManageInput) domain.
 	}
 	sorted := sortResult.Unwrap()
 
-	// Build package-operation mapping by matching operations to package source paths
-	packageOps := buildPackageOperationMapping(packages, sorted)
+	// Build package-operation mapping by matching operations to package source paths.
+	// Satisfied operations are included so that existing links are attributed too.
+	packageOps := buildPackageOperationMapping(packages, concatOperations(sorted, resolved.Satisfied))
 
 	// Build final plan with metadata including any warnings
 	plan := domain.Plan{
@@ -123,14 +159,44 @@ func (p *ManagePipeline) Execute(ctx context.Context, input ManageInput) domain.
 			LinkCount:      countOperationsByKind(sorted, domain.OpKindLinkCreate),
 			DirCount:       countOperationsByKind(sorted, domain.OpKindDirCreate),
 			Conflicts:      nil, // No conflicts in success path
-			Warnings:       convertWarnings(resolved.Warnings),
+			Warnings:       append(specialWarnings, convertWarnings(resolved.Warnings)...),
+			Replacements:   convertReplacements(resolved.Replacements),
 		},
 		PackageOperations: packageOps,
+		Satisfied:         resolved.Satisfied,
+		Dependencies:      planner.BuildGraph(sorted).DependencyIDs(),
 	}
 
 	return domain.Ok(plan)
 }
 
+// checkSpecialFiles applies policy to the special files found in packages.
+// Skipped files are reported as warnings; with SpecialFilesError the first
+// one fails the plan.
+func checkSpecialFiles(ctx context.Context, fs domain.FS, packages []domain.Package, policy domain.SpecialFilePolicy) ([]domain.WarningInfo, error) {
+	var warnings []domain.WarningInfo
+	for _, pkg := range packages {
+		if pkg.Tree == nil {
+			continue
+		}
+		for _, path := range scanner.CollectSpecial(*pkg.Tree) {
+			kind := "special file"
+			if info, err := fs.Stat(ctx, path.String()); err == nil && domain.SpecialFileKind(info.Mode()) != "" {
+				kind = domain.SpecialFileKind(info.Mode())
+			}
+			if policy == domain.SpecialFilesError {
+				return nil, domain.ErrSpecialFile{Path: path.String(), Kind: kind}
+			}
+			warnings = append(warnings, domain.WarningInfo{
+				Message:  fmt.Sprintf("skipped %s %s", kind, path.String()),
+				Severity: planner.WarnCaution.String(),
+				Context:  map[string]string{"package": pkg.Name, "path": path.String(), "kind": kind},
+			})
+		}
+	}
+	return warnings, nil
+}
+
 // countOperationsByKind counts operations of a specific kind
 func countOperationsByKind(ops []domain.Operation, kind domain.OperationKind) int {
 	count := 0
@@ -142,6 +208,13 @@ func countOperationsByKind(ops []domain.Operation, kind domain.OperationKind) in
 	return count
 }
 
+// concatOperations returns a new slice holding the operations of a followed by b.
+func concatOperations(a, b []domain.Operation) []domain.Operation {
+	result := make([]domain.Operation, 0, len(a)+len(b))
+	result = append(result, a...)
+	return append(result, b...)
+}
+
 // buildPackageOperationMapping creates a mapping from package names to operation IDs
 // by matching operation source paths to package paths.
 func buildPackageOperationMapping(packages []domain.Package, operations []domain.Operation) map[string][]domain.OperationID {
@@ -152,9 +225,18 @@ func buildPackageOperationMapping(packages []domain.Package, operations []domain
 		pkgPath := pkg.Path.String()
 		ops := make([]domain.OperationID, 0)
 
+		// Rendered templates live outside the package, so links to them are
+		// attributed through the render operation that produced them
+		rendered := make(map[string]bool)
+		for _, op := range operations {
+			if renderOp, ok := op.(domain.FileRender); ok && isUnderPath(renderOp.Template.String(), pkgPath) {
+				rendered[renderOp.Dest.String()] = true
+			}
+		}
+
 		for _, op := range operations {
 			// Check if this operation's source is from this package
-			if operationBelongsToPackage(op, pkgPath) {
+			if operationBelongsToPackage(op, pkgPath, rendered) {
 				ops = append(ops, op.ID())
 			}
 		}
@@ -168,11 +250,15 @@ func buildPackageOperationMapping(packages []domain.Package, operations []domain
 }
 
 // operationBelongsToPackage checks if an operation's source is from the given package path.
-func operationBelongsToPackage(op domain.Operation, pkgPath string) bool {
+// rendered holds output paths of templates rendered from the package.
+func operationBelongsToPackage(op domain.Operation, pkgPath string, rendered map[string]bool) bool {
 	switch o := op.(type) {
 	case domain.LinkCreate:
-		// LinkCreate source is the file in the package
-		return isUnderPath(o.Source.String(), pkgPath)
+		// LinkCreate source is the file in the package or its rendered copy
+		return isUnderPath(o.Source.String(), pkgPath) || rendered[o.Source.String()]
+	case domain.FileRender:
+		// FileRender template is the file in the package
+		return isUnderPath(o.Template.String(), pkgPath)
 	case domain.FileMove:
 		// FileMove destination is the file in the package
 		return isUnderPath(o.Dest.String(), pkgPath)
@@ -194,6 +280,7 @@ func isUnderPath(path, basePath string) bool {
 		return false
 	}
 
-	// If relative path doesn't go up (..), it's under basePath
-	return rel != "." && !filepath.IsAbs(rel) && len(rel) > 0 && rel[0] != '.'
+	// If relative path doesn't go up (..), it's under basePath. Entries
+	// such as .config are under it too.
+	return rel != "." && rel != ".." && !filepath.IsAbs(rel) && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
 }
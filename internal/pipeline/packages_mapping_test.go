@@ -112,7 +112,7 @@ func TestOperationBelongsToPackage(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := operationBelongsToPackage(tt.op, tt.pkgPath)
+			got := operationBelongsToPackage(tt.op, tt.pkgPath, nil)
 			assert.Equal(t, tt.want, got)
 		})
 	}
@@ -2,12 +2,16 @@ package pipeline
 
 import (
 	"context"
+	"fmt"
 	"path/filepath"
+	"strings"
+	"sync"
 
 	"github.com/jamesainslie/dot/internal/domain"
 	"github.com/jamesainslie/dot/internal/ignore"
 	"github.com/jamesainslie/dot/internal/planner"
 	"github.com/jamesainslie/dot/internal/scanner"
+	"github.com/jamesainslie/dot/internal/templating"
 )
 
 // ScanInput contains the input for scanning packages
@@ -17,10 +21,20 @@ type ScanInput struct {
 	Packages   []string
 	IgnoreSet  *ignore.IgnoreSet
 	FS         domain.FS
+
+	// Concurrency bounds how many packages are scanned at once. Values
+	// below 2 scan packages one at a time.
+	Concurrency int
+
+	// Cache keeps the trees of unchanged packages between scans. Nil
+	// scans every package.
+	Cache *scanner.TreeCache
 }
 
 // ScanStage creates a pipeline stage that scans packages.
-// Returns a slice of scanned packages with their file trees.
+// Returns a slice of scanned packages with their file trees, in the order
+// of input.Packages. Packages are scanned by a bounded pool of workers;
+// when several fail, the error of the first failing package is returned.
 func ScanStage() Pipeline[ScanInput, []domain.Package] {
 	return func(ctx context.Context, input ScanInput) domain.Result[[]domain.Package] {
 		// Early cancellation check
@@ -30,32 +44,57 @@ func ScanStage() Pipeline[ScanInput, []domain.Package] {
 		default:
 		}
 
-		packages := make([]domain.Package, 0, len(input.Packages))
-
-		for _, pkgName := range input.Packages {
+		results := make([]domain.Result[domain.Package], len(input.Packages))
+		scan := func(i int) {
 			// Check for cancellation before processing each package
-			select {
-			case <-ctx.Done():
-				return domain.Err[[]domain.Package](ctx.Err())
-			default:
+			if err := ctx.Err(); err != nil {
+				results[i] = domain.Err[domain.Package](err)
+				return
 			}
 
 			// Create package path by joining package dir with package name
+			pkgName := input.Packages[i]
 			pkgPathStr := filepath.Join(input.PackageDir.String(), pkgName)
 			pkgPathResult := domain.NewPackagePath(pkgPathStr)
 			if pkgPathResult.IsErr() {
-				return domain.Err[[]domain.Package](pkgPathResult.UnwrapErr())
+				results[i] = domain.Err[domain.Package](pkgPathResult.UnwrapErr())
+				return
 			}
-			pkgPath := pkgPathResult.Unwrap()
 
-			// scanner.ScanPackage already accepts context and should handle cancellation
-			pkgResult := scanner.ScanPackage(ctx, input.FS, pkgPath, pkgName, input.IgnoreSet)
+			// scanner.ScanPackageCached already accepts context and should handle cancellation
+			results[i] = scanner.ScanPackageCached(ctx, input.FS, pkgPathResult.Unwrap(), pkgName, input.IgnoreSet, input.Cache)
+		}
 
-			if pkgResult.IsErr() {
-				return domain.Err[[]domain.Package](pkgResult.UnwrapErr())
+		workers := min(input.Concurrency, len(input.Packages))
+		if workers <= 1 {
+			for i := range input.Packages {
+				scan(i)
+			}
+		} else {
+			next := make(chan int)
+			var wg sync.WaitGroup
+			for w := 0; w < workers; w++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := range next {
+						scan(i)
+					}
+				}()
 			}
+			for i := range input.Packages {
+				next <- i
+			}
+			close(next)
+			wg.Wait()
+		}
 
-			packages = append(packages, pkgResult.Unwrap())
+		packages := make([]domain.Package, 0, len(input.Packages))
+		for _, result := range results {
+			if result.IsErr() {
+				return domain.Err[[]domain.Package](result.UnwrapErr())
+			}
+			packages = append(packages, result.Unwrap())
 		}
 
 		return domain.Ok(packages)
@@ -84,12 +123,125 @@ func PlanStage() Pipeline[PlanInput, planner.DesiredState] {
 	}
 }
 
+// RenderInput contains the input for template rendering
+type RenderInput struct {
+	Desired  planner.DesiredState
+	Renderer *templating.Renderer
+}
+
+// RenderStage creates a pipeline stage that renders template files.
+// Links whose source is a template are rewritten to point at the rendered
+// copy in the cache directory, with the template suffix removed from the
+// target. Output is recorded in DesiredState.Renders for the resolver.
+// A template whose target is also linked from another file of the packages,
+// such as foo next to foo.tmpl, is reported as an ErrConflict. Values files
+// are read once for all templates. A nil renderer leaves the desired state
+// unchanged.
+func RenderStage() Pipeline[RenderInput, planner.DesiredState] {
+	return func(ctx context.Context, input RenderInput) domain.Result[planner.DesiredState] {
+		if input.Renderer == nil {
+			return domain.Ok(input.Desired)
+		}
+
+		desired := planner.DesiredState{
+			Links:   make(map[string]planner.LinkSpec, len(input.Desired.Links)),
+			Dirs:    input.Desired.Dirs,
+			Renders: make(map[string]planner.RenderSpec),
+		}
+		for k, v := range input.Desired.Renders {
+			desired.Renders[k] = v
+		}
+		var data *templating.Data
+
+		for key, link := range input.Desired.Links {
+			// Check for cancellation between potentially slow renders
+			select {
+			case <-ctx.Done():
+				return domain.Err[planner.DesiredState](ctx.Err())
+			default:
+			}
+
+			if !templating.IsTemplate(link.Source.String()) {
+				desired.Links[key] = link
+				continue
+			}
+
+			outPath, err := input.Renderer.OutputPath(link.Source.String())
+			if err != nil {
+				return domain.Err[planner.DesiredState](err)
+			}
+			destResult := domain.NewFilePath(outPath)
+			if destResult.IsErr() {
+				return domain.Err[planner.DesiredState](destResult.UnwrapErr())
+			}
+			targetResult := domain.NewTargetPath(templating.StripSuffix(link.Target.String()))
+			if targetResult.IsErr() {
+				return domain.Err[planner.DesiredState](targetResult.UnwrapErr())
+			}
+
+			dest := destResult.Unwrap()
+			target := targetResult.Unwrap()
+			if err := renderConflict(input.Desired, desired, link, dest, target); err != nil {
+				return domain.Err[planner.DesiredState](err)
+			}
+
+			if data == nil {
+				loaded, err := input.Renderer.Data(ctx)
+				if err != nil {
+					return domain.Err[planner.DesiredState](err)
+				}
+				data = &loaded
+			}
+			output, err := input.Renderer.RenderOutputWith(ctx, link.Source.String(), *data)
+			if err != nil {
+				return domain.Err[planner.DesiredState](err)
+			}
+			desired.Renders[dest.String()] = planner.RenderSpec{
+				Template: link.Source,
+				Dest:     dest,
+				Content:  output.Content,
+				Secret:   output.Secret,
+			}
+			desired.Links[target.String()] = planner.LinkSpec{
+				Source: dest,
+				Target: target,
+			}
+		}
+
+		return domain.Ok(desired)
+	}
+}
+
+// renderConflict returns an ErrConflict if the target of the rendered
+// template link is also linked from another file: a plain file of the
+// planned state, or another template rendered to a different copy.
+func renderConflict(planned, rendered planner.DesiredState, link planner.LinkSpec, dest domain.FilePath, target domain.TargetPath) error {
+	other, ok := planned.Links[target.String()]
+	if !ok || templating.IsTemplate(other.Source.String()) {
+		other, ok = rendered.Links[target.String()]
+		if !ok || other.Source.Equals(dest) {
+			return nil
+		}
+		if render, isRender := rendered.Renders[other.Source.String()]; isRender {
+			other.Source = render.Template
+		}
+	}
+	return domain.ErrConflict{
+		Path:   target.String(),
+		Reason: fmt.Sprintf("both %s and the template %s would be linked here", other.Source.String(), link.Source.String()),
+	}
+}
+
 // ResolveInput contains the input for conflict resolution
 type ResolveInput struct {
 	Desired   planner.DesiredState
 	FS        domain.FS
 	Policies  planner.ResolutionPolicies
 	BackupDir string
+
+	// PackageDir lets directory symlinks into packages be recognised as
+	// folded and unfolded when needed (optional)
+	PackageDir string
 }
 
 // ResolveStage creates a pipeline stage that resolves conflicts.
@@ -113,13 +265,9 @@ func ResolveStage() Pipeline[ResolveInput, planner.ResolveResult] {
 		default:
 		}
 
-		// For now, use empty current state (will scan target in future phases)
-		// This will be enhanced when we add current state scanning
-		current := planner.CurrentState{
-			Files: make(map[string]planner.FileInfo),
-			Links: make(map[string]planner.LinkTarget),
-			Dirs:  make(map[string]bool),
-		}
+		// Inspect the target paths the plan touches so that links already
+		// created by GNU Stow or an earlier install are recognised
+		current := scanCurrentState(ctx, input.FS, operations, input.PackageDir)
 
 		// Check for cancellation before potentially long-running conflict resolution
 		select {
@@ -134,6 +282,111 @@ func ResolveStage() Pipeline[ResolveInput, planner.ResolveResult] {
 	}
 }
 
+// scanCurrentState builds the current filesystem state for the paths that
+// the given operations would create. Only those paths are inspected, so the
+// cost is proportional to the plan rather than the size of the target.
+// Directory symlinks into packageDir found at planned directory paths are
+// recorded as folded, together with the contents of the directory they
+// point at.
+func scanCurrentState(ctx context.Context, fs domain.FS, operations []domain.Operation, packageDir string) planner.CurrentState {
+	current := planner.CurrentState{
+		Files:  make(map[string]planner.FileInfo),
+		Links:  make(map[string]planner.LinkTarget),
+		Dirs:   make(map[string]bool),
+		Folded: make(map[string]planner.FoldedDir),
+	}
+	if fs == nil {
+		return current
+	}
+
+	for _, op := range operations {
+		switch o := op.(type) {
+		case domain.LinkCreate:
+			recordPathState(ctx, fs, o.Target.String(), current)
+		case domain.DirCreate:
+			path := o.Path.String()
+			recordPathState(ctx, fs, path, current)
+			if link, ok := current.Links[path]; ok && packageDir != "" {
+				recordFoldedDir(ctx, fs, path, link.Target, packageDir, current)
+			}
+		}
+	}
+
+	return current
+}
+
+// recordFoldedDir records the link at path as folded if it points at a
+// directory inside packageDir.
+func recordFoldedDir(ctx context.Context, fs domain.FS, path, target, packageDir string, current planner.CurrentState) {
+	rel, err := filepath.Rel(packageDir, target)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return
+	}
+	if isDir, err := fs.IsDir(ctx, target); err != nil || !isDir {
+		return
+	}
+
+	fold := planner.FoldedDir{Target: target}
+	var walk func(dir string) error
+	walk = func(dir string) error {
+		entries, err := fs.ReadDir(ctx, filepath.Join(target, dir))
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			entryRel := filepath.Join(dir, entry.Name())
+			if entry.IsDir() {
+				fold.Dirs = append(fold.Dirs, entryRel)
+				if err := walk(entryRel); err != nil {
+					return err
+				}
+				continue
+			}
+			fold.Files = append(fold.Files, entryRel)
+		}
+		return nil
+	}
+	if err := walk(""); err != nil {
+		return
+	}
+	current.Folded[path] = fold
+}
+
+// recordPathState records what currently exists at path in the given state.
+// Symlink targets are resolved to absolute paths so that relative links,
+// as created by GNU Stow, compare equal to the planned link source.
+func recordPathState(ctx context.Context, fs domain.FS, path string, current planner.CurrentState) {
+	if isLink, err := fs.IsSymlink(ctx, path); err == nil && isLink {
+		target, err := fs.ReadLink(ctx, path)
+		if err != nil {
+			return
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(path), target)
+		}
+		current.Links[path] = planner.LinkTarget{Target: filepath.Clean(target)}
+		return
+	}
+
+	info, err := fs.Stat(ctx, path)
+	if err != nil {
+		return
+	}
+	if info.IsDir() {
+		current.Dirs[path] = true
+		return
+	}
+	fileInfo := planner.FileInfo{
+		Size: info.Size(),
+		Mode: uint32(info.Mode()),
+	}
+	// The content hash lets the backup policy address the file in the backup store
+	if data, err := fs.ReadFile(ctx, path); err == nil {
+		fileInfo.Hash = domain.HashContent(data)
+	}
+	current.Files[path] = fileInfo
+}
+
 // SortInput contains the input for topological sorting
 type SortInput struct {
 	Operations []domain.Operation
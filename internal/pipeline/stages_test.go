@@ -8,6 +8,7 @@ import (
 	"github.com/jamesainslie/dot/internal/domain"
 	"github.com/jamesainslie/dot/internal/ignore"
 	"github.com/jamesainslie/dot/internal/planner"
+	"github.com/jamesainslie/dot/internal/templating"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -53,6 +54,40 @@ func TestScanStage_ContextCancellation(t *testing.T) {
 	})
 }
 
+func TestScanStage_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	var names []string
+	for _, name := range []string{"zsh", "vim", "git", "tmux", "bash", "nvim"} {
+		require.NoError(t, fs.MkdirAll(ctx, "/packages/"+name, 0755))
+		require.NoError(t, fs.WriteFile(ctx, "/packages/"+name+"/dot-"+name+"rc", []byte(name), 0644))
+		names = append(names, name)
+	}
+
+	input := ScanInput{
+		PackageDir:  domain.NewPackagePath("/packages").Unwrap(),
+		TargetDir:   domain.NewTargetPath("/target").Unwrap(),
+		Packages:    names,
+		IgnoreSet:   ignore.NewIgnoreSet(),
+		FS:          fs,
+		Concurrency: 4,
+	}
+
+	result := ScanStage()(ctx, input)
+	require.True(t, result.IsOk())
+	packages := result.Unwrap()
+	require.Len(t, packages, len(names))
+	for i, pkg := range packages {
+		assert.Equal(t, names[i], pkg.Name, "packages should keep input order")
+	}
+
+	// The first missing package in input order is reported
+	input.Packages = []string{"vim", "missing-a", "git", "missing-b"}
+	result = ScanStage()(ctx, input)
+	require.True(t, result.IsErr())
+	assert.Equal(t, domain.ErrPackageNotFound{Package: "missing-a"}, result.UnwrapErr())
+}
+
 func TestPlanStage_ContextCancellation(t *testing.T) {
 	t.Run("cancelled before planning", func(t *testing.T) {
 		ctx, cancel := context.WithCancel(context.Background())
@@ -172,3 +207,102 @@ func TestStages_ValidContextPropagation(t *testing.T) {
 		require.True(t, sortResult.IsOk())
 	})
 }
+
+func TestResolveStage_DetectsExistingLinks(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	require.NoError(t, fs.MkdirAll(ctx, "/packages/vim", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/home", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/packages/vim/dot-vimrc", []byte("set nocompatible"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/packages/vim/dot-gvimrc", []byte("set guifont"), 0644))
+
+	// Relative link as created by GNU Stow, and a link pointing elsewhere
+	require.NoError(t, fs.Symlink(ctx, "../packages/vim/dot-vimrc", "/home/.vimrc"))
+	require.NoError(t, fs.Symlink(ctx, "/elsewhere/gvimrc", "/home/.gvimrc"))
+
+	vimrc := domain.NewFilePath("/packages/vim/dot-vimrc").Unwrap()
+	gvimrc := domain.NewFilePath("/packages/vim/dot-gvimrc").Unwrap()
+	result := ResolveStage()(ctx, ResolveInput{
+		Desired: planner.DesiredState{
+			Links: map[string]planner.LinkSpec{
+				"/home/.vimrc":  {Source: vimrc, Target: domain.NewTargetPath("/home/.vimrc").Unwrap()},
+				"/home/.gvimrc": {Source: gvimrc, Target: domain.NewTargetPath("/home/.gvimrc").Unwrap()},
+			},
+			Dirs: make(map[string]planner.DirSpec),
+		},
+		FS:       fs,
+		Policies: planner.DefaultPolicies(),
+	})
+	require.True(t, result.IsOk())
+	resolved := result.Unwrap()
+
+	assert.Empty(t, resolved.Operations)
+	require.Len(t, resolved.Satisfied, 1)
+	link, ok := resolved.Satisfied[0].(domain.LinkCreate)
+	require.True(t, ok)
+	assert.Equal(t, "/home/.vimrc", link.Target.String())
+
+	require.Len(t, resolved.Conflicts, 1)
+	assert.Equal(t, planner.ConflictWrongLink, resolved.Conflicts[0].Type)
+	assert.Equal(t, "/home/.gvimrc", resolved.Conflicts[0].Path.String())
+}
+
+// readCountingFS counts the reads of each file.
+type readCountingFS struct {
+	domain.FS
+	reads map[string]int
+}
+
+func (f *readCountingFS) ReadFile(ctx context.Context, path string) ([]byte, error) {
+	f.reads[path]++
+	return f.FS.ReadFile(ctx, path)
+}
+
+func setupRenderStage(t *testing.T, files map[string]string) (*readCountingFS, RenderInput) {
+	t.Helper()
+	ctx := context.Background()
+	memFS := adapters.NewMemFS()
+	require.NoError(t, memFS.MkdirAll(ctx, "/packages/vim", 0755))
+	require.NoError(t, memFS.MkdirAll(ctx, "/packages/.dot-values", 0755))
+	require.NoError(t, memFS.WriteFile(ctx, "/packages/.dot-values/default.yaml", []byte("number: true\n"), 0644))
+
+	desired := planner.DesiredState{Links: make(map[string]planner.LinkSpec), Dirs: make(map[string]planner.DirSpec)}
+	for name, content := range files {
+		source := domain.NewFilePath("/packages/vim/" + name).Unwrap()
+		require.NoError(t, memFS.WriteFile(ctx, source.String(), []byte(content), 0644))
+		target := domain.NewTargetPath("/home/." + name).Unwrap()
+		desired.Links[target.String()] = planner.LinkSpec{Source: source, Target: target}
+	}
+
+	fs := &readCountingFS{FS: memFS, reads: make(map[string]int)}
+	renderer := templating.NewRenderer(fs, templating.Opts{CacheDir: "/cache", PackageDir: "/packages"})
+	return fs, RenderInput{Desired: desired, Renderer: renderer}
+}
+
+func TestRenderStage_LoadsValuesOnce(t *testing.T) {
+	fs, input := setupRenderStage(t, map[string]string{
+		"vimrc.tmpl":  "number={{ .Values.number }}",
+		"gvimrc.tmpl": "number={{ .Values.number }}",
+	})
+
+	result := RenderStage()(context.Background(), input)
+	require.True(t, result.IsOk(), "%v", result)
+	desired := result.Unwrap()
+	assert.Equal(t, "number=true", desired.Renders["/cache/vim/vimrc"].Content)
+	assert.Equal(t, "number=true", desired.Renders["/cache/vim/gvimrc"].Content)
+	assert.Equal(t, 1, fs.reads["/packages/.dot-values/default.yaml"])
+}
+
+func TestRenderStage_PlainFileAndTemplateConflict(t *testing.T) {
+	_, input := setupRenderStage(t, map[string]string{
+		"vimrc":      "set number",
+		"vimrc.tmpl": "number={{ .Values.number }}",
+	})
+
+	result := RenderStage()(context.Background(), input)
+	require.True(t, result.IsErr())
+	var conflict domain.ErrConflict
+	require.ErrorAs(t, result.UnwrapErr(), &conflict)
+	assert.Equal(t, "/home/.vimrc", conflict.Path)
+	assert.Contains(t, conflict.Reason, "/packages/vim/vimrc and the template /packages/vim/vimrc.tmpl")
+}
@@ -20,10 +20,20 @@ type DirSpec struct {
 	Path domain.FilePath
 }
 
+// RenderSpec specifies a template whose rendered output must be written
+// before the link pointing at it is created.
+type RenderSpec struct {
+	Template domain.FilePath // Template file in package
+	Dest     domain.FilePath // Rendered output in cache directory
+	Content  string          // Rendered content
+	Secret   bool            // Content includes secrets
+}
+
 // DesiredState represents the desired filesystem state.
 type DesiredState struct {
-	Links map[string]LinkSpec // Key: target path
-	Dirs  map[string]DirSpec  // Key: directory path
+	Links   map[string]LinkSpec   // Key: target path
+	Dirs    map[string]DirSpec    // Key: directory path
+	Renders map[string]RenderSpec // Key: rendered output path (optional)
 }
 
 // PlanResult contains planning results with optional conflict resolution
@@ -181,8 +191,8 @@ func translatePath(path string) string {
 
 // ComputeOperationsFromDesiredState converts desired state into operations
 func ComputeOperationsFromDesiredState(desired DesiredState) []domain.Operation {
-	// Preallocate slice for directories and links
-	ops := make([]domain.Operation, 0, len(desired.Dirs)+len(desired.Links))
+	// Preallocate slice for directories, renders and links
+	ops := make([]domain.Operation, 0, len(desired.Dirs)+len(desired.Renders)+len(desired.Links))
 
 	// Create directory operations with content-based IDs for determinism
 	for _, dirSpec := range desired.Dirs {
@@ -190,6 +200,15 @@ func ComputeOperationsFromDesiredState(desired DesiredState) []domain.Operation
 		ops = append(ops, domain.NewDirCreate(id, dirSpec.Path))
 	}
 
+	// Create render operations for templates; links to rendered output
+	// depend on these implicitly (see BuildGraph)
+	for _, renderSpec := range desired.Renders {
+		id := domain.OperationID(fmt.Sprintf("render-%s->%s", renderSpec.Template.String(), renderSpec.Dest.String()))
+		render := domain.NewFileRender(id, renderSpec.Template, renderSpec.Dest, renderSpec.Content)
+		render.Secret = renderSpec.Secret
+		ops = append(ops, render)
+	}
+
 	// Create link operations with content-based IDs for determinism
 	for _, linkSpec := range desired.Links {
 		id := domain.OperationID(fmt.Sprintf("link-%s->%s", linkSpec.Source.String(), linkSpec.Target.String()))
@@ -19,7 +19,9 @@ type DependencyGraph struct {
 // BuildGraph constructs a dependency graph from a list of operations.
 // It analyzes the Dependencies() of each operation to build the graph edges.
 // Additionally, it computes implicit dependencies for DirCreate operations,
-// ensuring parent directories are created before child directories.
+// ensuring parent directories are created before child directories and
+// links are removed before directories replace them, and for LinkCreate
+// operations whose source is produced by a FileRender operation.
 //
 // Time complexity: O(n + e) where n is the number of operations and e is
 // the total number of dependencies across all operations.
@@ -33,6 +35,19 @@ func BuildGraph(ops []domain.Operation) *DependencyGraph {
 	// Track DirCreate operations by path for dependency resolution
 	dirOps := make(map[string]domain.Operation)
 
+	// Track FileRender operations by output path for link dependencies
+	renderOps := make(map[string]domain.Operation)
+
+	// Track FileStash operations by stashed path; links replace those files
+	stashOps := make(map[string]domain.Operation)
+
+	// Track LinkDelete operations by path; unfolded directories and
+	// overwriting links replace those links
+	unlinkOps := make(map[string]domain.Operation)
+
+	// Track FileMove operations by moved path; links replace adopted files
+	moveOps := make(map[string]domain.Operation)
+
 	// Add all operations as nodes
 	for i, op := range ops {
 		graph.nodes[op] = i
@@ -43,6 +58,26 @@ func BuildGraph(ops []domain.Operation) *DependencyGraph {
 			dirOps[dirOp.Path.String()] = op
 		}
 
+		// Track template render operations
+		if renderOp, ok := op.(domain.FileRender); ok {
+			renderOps[renderOp.Dest.String()] = op
+		}
+
+		// Track backup operations
+		if stashOp, ok := op.(domain.FileStash); ok {
+			stashOps[stashOp.Source.String()] = op
+		}
+
+		// Track link removals
+		if unlinkOp, ok := op.(domain.LinkDelete); ok {
+			unlinkOps[unlinkOp.Target.String()] = op
+		}
+
+		// Track file moves
+		if moveOp, ok := op.(domain.FileMove); ok {
+			moveOps[moveOp.Source.String()] = op
+		}
+
 		// Build edges from explicit dependencies
 		deps := op.Dependencies()
 		if len(deps) > 0 {
@@ -53,6 +88,27 @@ func BuildGraph(ops []domain.Operation) *DependencyGraph {
 		}
 	}
 
+	// Add implicit dependencies for links to rendered templates and
+	// for links replacing files that are backed up, deleted or moved first
+	for _, op := range graph.ops {
+		linkOp, ok := op.(domain.LinkCreate)
+		if !ok {
+			continue
+		}
+		if renderOp, exists := renderOps[linkOp.Source.String()]; exists {
+			graph.edges[op] = append(graph.edges[op], renderOp)
+		}
+		if stashOp, exists := stashOps[linkOp.Target.String()]; exists {
+			graph.edges[op] = append(graph.edges[op], stashOp)
+		}
+		if unlinkOp, exists := unlinkOps[linkOp.Target.String()]; exists {
+			graph.edges[op] = append(graph.edges[op], unlinkOp)
+		}
+		if moveOp, exists := moveOps[linkOp.Target.String()]; exists {
+			graph.edges[op] = append(graph.edges[op], moveOp)
+		}
+	}
+
 	// Add implicit dependencies for directory operations
 	for _, op := range graph.ops {
 		dirOp, ok := op.(domain.DirCreate)
@@ -60,6 +116,11 @@ func BuildGraph(ops []domain.Operation) *DependencyGraph {
 			continue
 		}
 
+		// A directory replacing a link is created once the link is gone
+		if unlinkOp, exists := unlinkOps[dirOp.Path.String()]; exists {
+			graph.edges[op] = append(graph.edges[op], unlinkOp)
+		}
+
 		// Check if parent directory is also being created
 		parentResult := dirOp.Path.Parent()
 		if !parentResult.IsOk() {
@@ -109,6 +170,28 @@ func (g *DependencyGraph) Dependencies(op domain.Operation) []domain.Operation {
 	return nil
 }
 
+// DependencyIDs returns the edges of the graph by operation ID, in the
+// form kept by domain.Plan.Dependencies. Operations without dependencies
+// have no entry; the result is nil for a graph without edges.
+func (g *DependencyGraph) DependencyIDs() map[domain.OperationID][]domain.OperationID {
+	var ids map[domain.OperationID][]domain.OperationID
+	for _, op := range g.ops {
+		deps := g.edges[op]
+		if len(deps) == 0 {
+			continue
+		}
+		if ids == nil {
+			ids = make(map[domain.OperationID][]domain.OperationID)
+		}
+		depIDs := make([]domain.OperationID, 0, len(deps))
+		for _, dep := range deps {
+			depIDs = append(depIDs, dep.ID())
+		}
+		ids[op.ID()] = depIDs
+	}
+	return ids
+}
+
 // Operations returns all operations in the graph.
 // The returned slice is a copy to prevent external modification.
 func (g *DependencyGraph) Operations() []domain.Operation {
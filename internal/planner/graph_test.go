@@ -120,6 +120,30 @@ func TestBuildGraph_DiamondPattern(t *testing.T) {
 	assert.Len(t, graph.Dependencies(opDWithDep), 2)
 }
 
+func TestBuildGraph_DirectoryReplacingLink(t *testing.T) {
+	unlink := domain.NewLinkDelete("unlink", mustParseTargetPath("/home/user/.config"))
+	dir := domain.NewDirCreate("dir", mustParsePath("/home/user/.config"))
+
+	graph := BuildGraph([]domain.Operation{dir, unlink})
+
+	assert.Equal(t, []domain.Operation{unlink}, graph.Dependencies(dir))
+	assert.Empty(t, graph.Dependencies(unlink))
+}
+
+func TestBuildGraph_LinkReplacingFile(t *testing.T) {
+	target := mustParseTargetPath("/home/user/.vimrc")
+	source := mustParsePath("/packages/vim/dot-vimrc")
+	link := domain.NewLinkCreate("link", source, target)
+
+	unlink := domain.NewLinkDelete("unlink", target)
+	graph := BuildGraph([]domain.Operation{link, unlink})
+	assert.Equal(t, []domain.Operation{unlink}, graph.Dependencies(link))
+
+	move := domain.NewFileMove("move", target, source)
+	graph = BuildGraph([]domain.Operation{link, move})
+	assert.Equal(t, []domain.Operation{move}, graph.Dependencies(link))
+}
+
 func TestGraph_Size(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -226,3 +250,14 @@ func mustParseTargetPath(s string) domain.TargetPath {
 	}
 	return result.Unwrap()
 }
+
+func TestGraph_DependencyIDs(t *testing.T) {
+	parent := domain.NewDirCreate("parent", mustParsePath("/home/user/.config"))
+	child := domain.NewDirCreate("child", mustParsePath("/home/user/.config/nvim"))
+	other := domain.NewDirCreate("other", mustParsePath("/home/user/.local"))
+
+	ids := BuildGraph([]domain.Operation{child, parent, other}).DependencyIDs()
+	assert.Equal(t, map[domain.OperationID][]domain.OperationID{"child": {"parent"}}, ids)
+
+	assert.Nil(t, BuildGraph([]domain.Operation{other}).DependencyIDs())
+}
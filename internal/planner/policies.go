@@ -1,37 +1,38 @@
 package planner
 
 import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
 	"github.com/jamesainslie/dot/internal/domain"
 )
 
-// ResolutionPolicy defines how to handle conflicts
-type ResolutionPolicy int
+// ResolutionPolicy names the strategy that resolves a kind of conflict.
+// The zero value is PolicyFail.
+type ResolutionPolicy string
 
 const (
 	// PolicyFail stops and reports conflict (default, safest)
-	PolicyFail ResolutionPolicy = iota
+	PolicyFail ResolutionPolicy = "fail"
 	// PolicyBackup backs up conflicting file before linking
-	PolicyBackup
+	PolicyBackup ResolutionPolicy = "backup"
 	// PolicyOverwrite replaces conflicting file with link
-	PolicyOverwrite
+	PolicyOverwrite ResolutionPolicy = "overwrite"
 	// PolicySkip skips conflicting operation
-	PolicySkip
+	PolicySkip ResolutionPolicy = "skip"
+	// PolicyAdopt moves conflicting file into the package before linking
+	PolicyAdopt ResolutionPolicy = "adopt"
+	// PolicyPrompt asks which strategy to apply to each conflict
+	PolicyPrompt ResolutionPolicy = "prompt"
 )
 
-// String returns the string representation of ResolutionPolicy
+// String returns the name of the strategy
 func (rp ResolutionPolicy) String() string {
-	switch rp {
-	case PolicyFail:
-		return "fail"
-	case PolicyBackup:
-		return "backup"
-	case PolicyOverwrite:
-		return "overwrite"
-	case PolicySkip:
-		return "skip"
-	default:
-		return "unknown"
+	if rp == "" {
+		return string(PolicyFail)
 	}
+	return string(rp)
 }
 
 // ResolutionPolicies configures conflict resolution behavior per conflict type
@@ -41,6 +42,99 @@ type ResolutionPolicies struct {
 	OnPermissionErr ResolutionPolicy
 	OnCircular      ResolutionPolicy
 	OnTypeMismatch  ResolutionPolicy
+
+	// Paths override OnFileExists and OnWrongLink for conflicts at
+	// matching target paths. The first matching entry applies.
+	Paths []PathPolicy
+
+	// Strategies holds the strategies the policies name. If nil, only the
+	// built-in strategies are available.
+	Strategies *StrategyRegistry
+}
+
+// PathPolicy sets the policies for conflicts at target paths matching a
+// pattern.
+type PathPolicy struct {
+	// Pattern is a glob over absolute paths. Each component is matched
+	// with filepath.Match, and a "**" component matches any number of
+	// components.
+	Pattern string
+
+	// OnFileExists and OnWrongLink replace the policies of the same name
+	// at matching paths. Empty fields keep them.
+	OnFileExists ResolutionPolicy
+	OnWrongLink  ResolutionPolicy
+}
+
+// Matches reports whether path matches the pattern of p.
+func (p PathPolicy) Matches(path string) bool {
+	return matchComponents(splitComponents(p.Pattern), splitComponents(path))
+}
+
+// ValidatePathPattern checks that pattern is a valid PathPolicy pattern.
+func ValidatePathPattern(pattern string) error {
+	if pattern == "" {
+		return fmt.Errorf("pattern cannot be empty")
+	}
+	for _, part := range splitComponents(pattern) {
+		if part == "**" {
+			continue
+		}
+		if _, err := filepath.Match(part, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// forPath returns the policies applying to conflicts at path.
+func (p ResolutionPolicies) forPath(path string) ResolutionPolicies {
+	for _, override := range p.Paths {
+		if !override.Matches(path) {
+			continue
+		}
+		if override.OnFileExists != "" {
+			p.OnFileExists = override.OnFileExists
+		}
+		if override.OnWrongLink != "" {
+			p.OnWrongLink = override.OnWrongLink
+		}
+		break
+	}
+	return p
+}
+
+// splitComponents splits a slash-separated path into its components.
+func splitComponents(path string) []string {
+	var parts []string
+	for _, part := range strings.Split(filepath.ToSlash(path), "/") {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// matchComponents matches path components against pattern components.
+func matchComponents(pattern, path []string) bool {
+	if len(pattern) == 0 {
+		return len(path) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(path); i++ {
+			if matchComponents(pattern[1:], path[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(path) == 0 {
+		return false
+	}
+	if ok, _ := filepath.Match(pattern[0], path[0]); !ok {
+		return false
+	}
+	return matchComponents(pattern[1:], path[1:])
 }
 
 // DefaultPolicies returns safe default policies (all fail)
@@ -74,3 +168,29 @@ func applySkipPolicy(op domain.LinkCreate, c Conflict) ResolutionOutcome {
 		Warning: &warning,
 	}
 }
+
+// applyBackupPolicy moves the conflicting file into the backup store before linking.
+// The backup store is content-addressed, so repeated backups of identical
+// content share a single stored copy.
+func applyBackupPolicy(op domain.LinkCreate, c Conflict, info FileInfo, backupDir string) ResolutionOutcome {
+	dirResult := domain.NewFilePath(backupDir)
+	if !dirResult.IsOk() {
+		return applyFailPolicy(c)
+	}
+	id := domain.OperationID(fmt.Sprintf("stash-%s", op.Target.String()))
+	stash := domain.NewFileStash(id, c.Path, dirResult.Unwrap(), info.Hash)
+
+	warning := Warning{
+		Message:  "Backing up existing file: " + op.Target.String(),
+		Severity: WarnCaution,
+		Context: map[string]string{
+			"backup": domain.BackupObjectPath(backupDir, info.Hash),
+		},
+	}
+
+	return ResolutionOutcome{
+		Status:     ResolveWarning,
+		Operations: []domain.Operation{stash, op},
+		Warning:    &warning,
+	}
+}
@@ -18,6 +18,9 @@ func TestResolutionPolicyTypes(t *testing.T) {
 		{"backup", PolicyBackup, "backup"},
 		{"overwrite", PolicyOverwrite, "overwrite"},
 		{"skip", PolicySkip, "skip"},
+		{"adopt", PolicyAdopt, "adopt"},
+		{"prompt", PolicyPrompt, "prompt"},
+		{"zero value", ResolutionPolicy(""), "fail"},
 	}
 
 	for _, tt := range tests {
@@ -95,9 +98,9 @@ func TestPolicySkip(t *testing.T) {
 
 // Additional coverage tests
 func TestResolutionPolicyStringEdgeCases(t *testing.T) {
-	// Test unknown policy
-	unknownPolicy := ResolutionPolicy(999)
-	assert.Equal(t, "unknown", unknownPolicy.String())
+	// Custom strategies are named as registered
+	customPolicy := ResolutionPolicy("merge")
+	assert.Equal(t, "merge", customPolicy.String())
 }
 
 func TestConflictTypeStringEdgeCases(t *testing.T) {
@@ -117,3 +120,89 @@ func TestWarningSeverityStringEdgeCases(t *testing.T) {
 	unknownSeverity := WarningSeverity(999)
 	assert.Equal(t, "unknown", unknownSeverity.String())
 }
+
+func TestPolicyBackup(t *testing.T) {
+	sourcePath := domain.NewFilePath("/packages/bash/dot-bashrc").Unwrap()
+	targetPath := domain.NewTargetPath("/home/user/.bashrc").Unwrap()
+	op := domain.NewLinkCreate("link-auto", sourcePath, targetPath)
+
+	targetFilePath := domain.NewFilePath(targetPath.String()).Unwrap()
+	conflict := NewConflict(ConflictFileExists, targetFilePath, "File exists")
+	info := FileInfo{Hash: domain.HashContent([]byte("content"))}
+
+	outcome := applyBackupPolicy(op, conflict, info, "/home/user/.dot-backup")
+
+	assert.Equal(t, ResolveWarning, outcome.Status)
+	if assert.Len(t, outcome.Operations, 2) {
+		stash, ok := outcome.Operations[0].(domain.FileStash)
+		assert.True(t, ok)
+		assert.Equal(t, targetFilePath, stash.Source)
+		assert.Equal(t, info.Hash, stash.Hash)
+		assert.Equal(t, op, outcome.Operations[1])
+	}
+	assert.NotNil(t, outcome.Warning)
+	assert.Equal(t, domain.BackupObjectPath("/home/user/.dot-backup", info.Hash), outcome.Warning.Context["backup"])
+}
+
+func TestPolicyBackup_InvalidBackupDir(t *testing.T) {
+	sourcePath := domain.NewFilePath("/packages/bash/dot-bashrc").Unwrap()
+	targetPath := domain.NewTargetPath("/home/user/.bashrc").Unwrap()
+	op := domain.NewLinkCreate("link-auto", sourcePath, targetPath)
+
+	targetFilePath := domain.NewFilePath(targetPath.String()).Unwrap()
+	conflict := NewConflict(ConflictFileExists, targetFilePath, "File exists")
+
+	outcome := applyBackupPolicy(op, conflict, FileInfo{Hash: "abc"}, "relative/backup")
+
+	assert.Equal(t, ResolveConflict, outcome.Status)
+	assert.Empty(t, outcome.Operations)
+}
+
+func TestPathPolicyMatches(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"/home/user/.config/**", "/home/user/.config/nvim/init.lua", true},
+		{"/home/user/.config/**", "/home/user/.config", true},
+		{"/home/user/.config/*", "/home/user/.config/nvim/init.lua", false},
+		{"/home/user/.ssh/*", "/home/user/.ssh/config", true},
+		{"/home/user/**/*.conf", "/home/user/a/b/x.conf", true},
+		{"/home/user/**/*.conf", "/home/user/.bashrc", false},
+		{"/home/user/.bashrc", "/home/user/.bashrc", true},
+	}
+	for _, tt := range tests {
+		got := PathPolicy{Pattern: tt.pattern}.Matches(tt.path)
+		assert.Equal(t, tt.want, got, "%s ~ %s", tt.pattern, tt.path)
+	}
+}
+
+func TestValidatePathPattern(t *testing.T) {
+	assert.NoError(t, ValidatePathPattern("/home/user/.config/**"))
+	assert.Error(t, ValidatePathPattern(""))
+	assert.Error(t, ValidatePathPattern("/home/[user"))
+}
+
+func TestResolveLinkCreateWithPathPolicies(t *testing.T) {
+	policies := DefaultPolicies()
+	policies.Paths = []PathPolicy{
+		{Pattern: "/home/user/.config/**", OnFileExists: PolicySkip},
+	}
+
+	resolve := func(target string) ResolutionOutcome {
+		source := domain.NewFilePath("/packages/app/file").Unwrap()
+		targetPath := domain.NewTargetPath(target).Unwrap()
+		op := domain.NewLinkCreate("link-auto", source, targetPath)
+		current := CurrentState{
+			Files: map[string]FileInfo{target: {Size: 1}},
+			Links: make(map[string]LinkTarget),
+			Dirs:  make(map[string]bool),
+		}
+		return resolveLinkCreate(op, current, policies, "", false)
+	}
+
+	assert.Equal(t, ResolveSkip, resolve("/home/user/.config/app/file").Status)
+	assert.Equal(t, ResolveConflict, resolve("/home/user/.apprc").Status,
+		"paths outside the pattern keep the global policy")
+}
@@ -2,6 +2,7 @@ package planner
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/jamesainslie/dot/internal/domain"
 )
@@ -152,6 +153,18 @@ type ResolutionOutcome struct {
 	Operations []domain.Operation // Modified operations after resolution
 	Conflict   *Conflict          // If status is ResolveConflict
 	Warning    *Warning           // If status is ResolveWarning
+
+	// Replacement is set when the resolved operations replace an
+	// existing file.
+	Replacement *Replacement
+}
+
+// Replacement records an existing file whose content a resolution
+// replaces, so that a dry run can show what would be lost.
+type Replacement struct {
+	Path   string           // File whose content is lost
+	With   string           // File whose content takes its place
+	Policy ResolutionPolicy // Policy that replaces it
 }
 
 // ResolveResult contains all resolved operations, conflicts, and warnings
@@ -159,6 +172,10 @@ type ResolveResult struct {
 	Operations []domain.Operation
 	Conflicts  []Conflict
 	Warnings   []Warning
+	Satisfied  []domain.Operation // Operations skipped because their result already exists
+
+	// Replacements lists the existing files the operations replace.
+	Replacements []Replacement
 }
 
 // NewResolveResult creates a new ResolveResult with the given operations
@@ -204,6 +221,7 @@ func (r ResolveResult) WarningCount() int {
 type FileInfo struct {
 	Size int64
 	Mode uint32
+	Hash string // SHA-256 of the content, if known
 }
 
 // LinkTarget represents a symlink target
@@ -216,6 +234,11 @@ type CurrentState struct {
 	Files map[string]FileInfo   // Regular files at target paths
 	Links map[string]LinkTarget // Existing symlinks
 	Dirs  map[string]bool       // Existing directories
+
+	// Folded holds the directory symlinks into the package directory at
+	// planned directory paths, which are unfolded when another package
+	// needs to place entries inside them (optional)
+	Folded map[string]FoldedDir
 }
 
 // detectLinkCreateConflicts checks for conflicts when creating a symlink
@@ -225,9 +248,10 @@ func detectLinkCreateConflicts(op domain.LinkCreate, current CurrentState) Resol
 	// Check if symlink already exists and points to the correct location
 	if link, exists := current.Links[targetKey]; exists {
 		if link.Target == op.Source.String() {
-			// Link already correct, skip
+			// Link already correct, skip but report it as satisfied
 			return ResolutionOutcome{
-				Status: ResolveSkip,
+				Status:     ResolveSkip,
+				Operations: []domain.Operation{op},
 			}
 		}
 		// Symlink exists but points elsewhere
@@ -236,7 +260,7 @@ func detectLinkCreateConflicts(op domain.LinkCreate, current CurrentState) Resol
 			ConflictWrongLink,
 			targetFilePath,
 			fmt.Sprintf("Symlink points to %s, expected %s", link.Target, op.Source.String()),
-		)
+		).WithContext("source", op.Source.String())
 		return ResolutionOutcome{
 			Status:   ResolveConflict,
 			Conflict: &conflict,
@@ -250,7 +274,7 @@ func detectLinkCreateConflicts(op domain.LinkCreate, current CurrentState) Resol
 			ConflictFileExists,
 			targetFilePath,
 			fmt.Sprintf("File exists at target (size=%d)", fileInfo.Size),
-		)
+		).WithContext("source", op.Source.String())
 		return ResolutionOutcome{
 			Status:   ResolveConflict,
 			Conflict: &conflict,
@@ -270,9 +294,10 @@ func detectDirCreateConflicts(op domain.DirCreate, current CurrentState) Resolut
 
 	// Check if directory already exists
 	if current.Dirs[pathKey] {
-		// Directory already exists, skip
+		// Directory already exists, skip but report it as satisfied
 		return ResolutionOutcome{
-			Status: ResolveSkip,
+			Status:     ResolveSkip,
+			Operations: []domain.Operation{op},
 		}
 	}
 
@@ -301,10 +326,23 @@ func resolveOperation(
 	op domain.Operation,
 	current CurrentState,
 	policies ResolutionPolicies,
+	backupDir string,
+) ResolutionOutcome {
+	return resolvePlanned(op, current, policies, backupDir, nil)
+}
+
+// resolvePlanned resolves a single operation of a plan whose rendered
+// templates are written to the paths in rendered.
+func resolvePlanned(
+	op domain.Operation,
+	current CurrentState,
+	policies ResolutionPolicies,
+	backupDir string,
+	rendered map[string]bool,
 ) ResolutionOutcome {
 	switch op := op.(type) {
 	case domain.LinkCreate:
-		return resolveLinkCreate(op, current, policies)
+		return resolveLinkCreate(op, current, policies, backupDir, rendered[op.Source.String()])
 	case domain.DirCreate:
 		return resolveDirCreate(op, current, policies)
 	case domain.LinkDelete:
@@ -328,11 +366,14 @@ func resolveOperation(
 	}
 }
 
-// resolveLinkCreate detects and resolves conflicts for LinkCreate operations
+// resolveLinkCreate detects and resolves conflicts for LinkCreate operations.
+// rendered is set when the link points at a rendered template.
 func resolveLinkCreate(
 	op domain.LinkCreate,
 	current CurrentState,
 	policies ResolutionPolicies,
+	backupDir string,
+	rendered bool,
 ) ResolutionOutcome {
 	// Detect conflicts
 	outcome := detectLinkCreateConflicts(op, current)
@@ -340,8 +381,9 @@ func resolveLinkCreate(
 		return outcome
 	}
 
-	// Apply policy based on conflict type
+	// Apply policy based on conflict type and path
 	conflict := *outcome.Conflict
+	policies = policies.forPath(op.Target.String())
 	var policy ResolutionPolicy
 
 	switch conflict.Type {
@@ -355,7 +397,14 @@ func resolveLinkCreate(
 		policy = PolicyFail
 	}
 
-	return applyPolicyToLinkCreate(op, conflict, policy)
+	return applyStrategy(ResolutionRequest{
+		Operation:  op,
+		Conflict:   conflict,
+		File:       current.Files[op.Target.String()],
+		Rendered:   rendered,
+		BackupDir:  backupDir,
+		Strategies: policies.Strategies,
+	}, policy)
 }
 
 // resolveDirCreate detects and resolves conflicts for DirCreate operations
@@ -371,53 +420,12 @@ func resolveDirCreate(
 	}
 
 	// Apply policy
-	conflict := *outcome.Conflict
-	policy := policies.OnTypeMismatch
-
-	return applyPolicyToDirCreate(op, conflict, policy)
-}
-
-// applyPolicyToLinkCreate applies a policy to a link creation conflict
-func applyPolicyToLinkCreate(
-	op domain.LinkCreate,
-	conflict Conflict,
-	policy ResolutionPolicy,
-) ResolutionOutcome {
-	switch policy {
-	case PolicyFail:
-		return applyFailPolicy(conflict)
-	case PolicySkip:
-		return applySkipPolicy(op, conflict)
-	case PolicyBackup, PolicyOverwrite:
-		// These require additional operation types (FileDelete)
-		// For now, fall back to fail
-		return applyFailPolicy(conflict)
-	default:
-		return applyFailPolicy(conflict)
-	}
-}
-
-// applyPolicyToDirCreate applies a policy to a directory creation conflict
-func applyPolicyToDirCreate(
-	op domain.DirCreate,
-	conflict Conflict,
-	policy ResolutionPolicy,
-) ResolutionOutcome {
-	switch policy {
-	case PolicyFail:
-		return applyFailPolicy(conflict)
-	case PolicySkip:
-		warning := Warning{
-			Message:  "Skipping directory creation due to conflict: " + op.Path.String(),
-			Severity: WarnInfo,
-		}
-		return ResolutionOutcome{
-			Status:  ResolveSkip,
-			Warning: &warning,
-		}
-	default:
-		return applyFailPolicy(conflict)
-	}
+	return applyStrategy(ResolutionRequest{
+		Operation:  op,
+		Conflict:   *outcome.Conflict,
+		File:       current.Files[op.Path.String()],
+		Strategies: policies.Strategies,
+	}, policies.OnTypeMismatch)
 }
 
 // Resolve applies conflict resolution to a list of operations
@@ -429,31 +437,56 @@ func Resolve(
 ) ResolveResult {
 	result := NewResolveResult(nil)
 
+	rendered := make(map[string]bool)
 	for _, op := range operations {
-		outcome := resolveOperation(op, current, policies)
+		if render, ok := op.(domain.FileRender); ok {
+			rendered[render.Dest.String()] = true
+		}
+	}
 
-		switch outcome.Status {
-		case ResolveOK:
-			result.Operations = append(result.Operations, outcome.Operations...)
+	operations, folded := splitFolded(operations, current)
+	links := make([]string, 0, len(folded))
+	for link := range folded {
+		links = append(links, link)
+	}
+	sort.Strings(links)
+	for _, link := range links {
+		result = resolveFolded(result, link, current.Folded[link], folded[link], policies, backupDir, rendered)
+	}
 
-		case ResolveWarning:
-			result.Operations = append(result.Operations, outcome.Operations...)
-			if outcome.Warning != nil {
-				result = result.WithWarning(*outcome.Warning)
-			}
+	for _, op := range operations {
+		result = result.withOutcome(resolvePlanned(op, current, policies, backupDir, rendered))
+	}
 
-		case ResolveConflict:
-			if outcome.Conflict != nil {
-				enriched := enrichConflictWithSuggestions(*outcome.Conflict)
-				result = result.WithConflict(enriched)
-			}
+	return result
+}
 
-		case ResolveSkip:
-			if outcome.Warning != nil {
-				result = result.WithWarning(*outcome.Warning)
-			}
+// withOutcome adds the result of resolving a single operation.
+func (r ResolveResult) withOutcome(outcome ResolutionOutcome) ResolveResult {
+	switch outcome.Status {
+	case ResolveOK:
+		r.Operations = append(r.Operations, outcome.Operations...)
+
+	case ResolveWarning:
+		r.Operations = append(r.Operations, outcome.Operations...)
+		if outcome.Warning != nil {
+			r = r.WithWarning(*outcome.Warning)
+		}
+		if outcome.Replacement != nil {
+			r.Replacements = append(r.Replacements, *outcome.Replacement)
 		}
-	}
 
-	return result
+	case ResolveConflict:
+		if outcome.Conflict != nil {
+			enriched := enrichConflictWithSuggestions(*outcome.Conflict)
+			r = r.WithConflict(enriched)
+		}
+
+	case ResolveSkip:
+		r.Satisfied = append(r.Satisfied, outcome.Operations...)
+		if outcome.Warning != nil {
+			r = r.WithWarning(*outcome.Warning)
+		}
+	}
+	return r
 }
@@ -229,6 +229,7 @@ func TestDetectFileExistsConflict(t *testing.T) {
 	assert.NotNil(t, outcome.Conflict)
 	assert.Equal(t, ConflictFileExists, outcome.Conflict.Type)
 	assert.Contains(t, outcome.Conflict.Details, "File exists")
+	assert.Equal(t, sourcePath.String(), outcome.Conflict.Context["source"])
 }
 
 func TestDetectWrongLinkConflict(t *testing.T) {
@@ -250,6 +251,7 @@ func TestDetectWrongLinkConflict(t *testing.T) {
 	assert.Equal(t, ResolveConflict, outcome.Status)
 	assert.NotNil(t, outcome.Conflict)
 	assert.Equal(t, ConflictWrongLink, outcome.Conflict.Type)
+	assert.Equal(t, sourcePath.String(), outcome.Conflict.Context["source"])
 }
 
 func TestDetectNoConflict(t *testing.T) {
@@ -519,7 +521,7 @@ func TestResolveOperationWithAllTypes(t *testing.T) {
 		linkPath := domain.NewTargetPath("/home/user/.bashrc").Unwrap()
 		op := domain.NewLinkDelete("link-del-auto", linkPath)
 
-		outcome := resolveOperation(op, current, policies)
+		outcome := resolveOperation(op, current, policies, "")
 
 		assert.Equal(t, ResolveOK, outcome.Status)
 		assert.Len(t, outcome.Operations, 1)
@@ -529,7 +531,7 @@ func TestResolveOperationWithAllTypes(t *testing.T) {
 		dirPath := domain.NewFilePath("/home/user/.config").Unwrap()
 		op := domain.NewDirDelete("dir-del-auto", dirPath)
 
-		outcome := resolveOperation(op, current, policies)
+		outcome := resolveOperation(op, current, policies, "")
 
 		assert.Equal(t, ResolveOK, outcome.Status)
 		assert.Len(t, outcome.Operations, 1)
@@ -540,7 +542,7 @@ func TestResolveOperationWithAllTypes(t *testing.T) {
 		dest := domain.NewFilePath("/packages/bash/dot-bashrc").Unwrap()
 		op := domain.NewFileMove("move-auto", source, dest)
 
-		outcome := resolveOperation(op, current, policies)
+		outcome := resolveOperation(op, current, policies, "")
 
 		assert.Equal(t, ResolveOK, outcome.Status)
 		assert.Len(t, outcome.Operations, 1)
@@ -551,59 +553,69 @@ func TestResolveOperationWithAllTypes(t *testing.T) {
 		backup := domain.NewFilePath("/backup/.bashrc").Unwrap()
 		op := domain.NewFileBackup("backup-auto", source, backup)
 
-		outcome := resolveOperation(op, current, policies)
+		outcome := resolveOperation(op, current, policies, "")
 
 		assert.Equal(t, ResolveOK, outcome.Status)
 		assert.Len(t, outcome.Operations, 1)
 	})
 }
 
-func TestApplyPolicyToDirCreate(t *testing.T) {
+func TestApplyStrategyToDirCreate(t *testing.T) {
 	dirPath := domain.NewFilePath("/home/user/.config").Unwrap()
 	op := domain.NewDirCreate("dir-auto", dirPath)
 	conflict := NewConflict(ConflictFileExpected, dirPath, "File exists")
+	req := ResolutionRequest{Operation: op, Conflict: conflict}
 
 	t.Run("fail policy", func(t *testing.T) {
-		outcome := applyPolicyToDirCreate(op, conflict, PolicyFail)
+		outcome := applyStrategy(req, PolicyFail)
 		assert.Equal(t, ResolveConflict, outcome.Status)
 		assert.NotNil(t, outcome.Conflict)
 	})
 
 	t.Run("skip policy", func(t *testing.T) {
-		outcome := applyPolicyToDirCreate(op, conflict, PolicySkip)
+		outcome := applyStrategy(req, PolicySkip)
 		assert.Equal(t, ResolveSkip, outcome.Status)
 		assert.NotNil(t, outcome.Warning)
 		assert.Contains(t, outcome.Warning.Message, "Skipping")
 	})
 
+	t.Run("link strategies fail", func(t *testing.T) {
+		for _, policy := range []ResolutionPolicy{PolicyBackup, PolicyOverwrite, PolicyAdopt} {
+			outcome := applyStrategy(req, policy)
+			assert.Equal(t, ResolveConflict, outcome.Status, policy)
+		}
+	})
+
 	t.Run("unknown policy defaults to fail", func(t *testing.T) {
-		outcome := applyPolicyToDirCreate(op, conflict, ResolutionPolicy(999))
+		outcome := applyStrategy(req, ResolutionPolicy("bogus"))
 		assert.Equal(t, ResolveConflict, outcome.Status)
 	})
 }
 
-func TestApplyPolicyToLinkCreateEdgeCases(t *testing.T) {
+func TestApplyStrategyToLinkCreateEdgeCases(t *testing.T) {
 	sourcePath := domain.NewFilePath("/packages/bash/dot-bashrc").Unwrap()
 	targetPath := domain.NewTargetPath("/home/user/.bashrc").Unwrap()
 	targetFilePath := domain.NewFilePath(targetPath.String()).Unwrap()
 	op := domain.NewLinkCreate("link-auto", sourcePath, targetPath)
 	conflict := NewConflict(ConflictFileExists, targetFilePath, "File exists")
+	req := ResolutionRequest{Operation: op, Conflict: conflict, BackupDir: "/backup"}
 
-	t.Run("backup policy falls back to fail", func(t *testing.T) {
-		outcome := applyPolicyToLinkCreate(op, conflict, PolicyBackup)
-		// Falls back to fail since backup needs FileDelete operation
+	t.Run("backup policy without hash falls back to fail", func(t *testing.T) {
+		outcome := applyStrategy(req, PolicyBackup)
 		assert.Equal(t, ResolveConflict, outcome.Status)
 	})
 
-	t.Run("overwrite policy falls back to fail", func(t *testing.T) {
-		outcome := applyPolicyToLinkCreate(op, conflict, PolicyOverwrite)
-		// Falls back to fail since overwrite needs FileDelete operation
+	t.Run("adopt policy fails for rendered templates", func(t *testing.T) {
+		rendered := req
+		rendered.Rendered = true
+		outcome := applyStrategy(rendered, PolicyAdopt)
 		assert.Equal(t, ResolveConflict, outcome.Status)
 	})
 
 	t.Run("unknown policy defaults to fail", func(t *testing.T) {
-		outcome := applyPolicyToLinkCreate(op, conflict, ResolutionPolicy(999))
+		outcome := applyStrategy(req, ResolutionPolicy("bogus"))
 		assert.Equal(t, ResolveConflict, outcome.Status)
+		assert.Contains(t, outcome.Conflict.Context["strategy"], "bogus")
 	})
 }
 
@@ -626,7 +638,7 @@ func TestResolveLinkCreateWithDifferentConflicts(t *testing.T) {
 			Dirs: make(map[string]bool),
 		}
 
-		outcome := resolveLinkCreate(op, current, policies)
+		outcome := resolveLinkCreate(op, current, policies, "", false)
 		assert.Equal(t, ResolveSkip, outcome.Status)
 	})
 }
@@ -0,0 +1,267 @@
+package planner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jamesainslie/dot/internal/domain"
+)
+
+// ResolutionRequest describes a conflict for a strategy to resolve.
+type ResolutionRequest struct {
+	// Operation is the conflicting operation, a LinkCreate or DirCreate.
+	Operation domain.Operation
+	Conflict  Conflict
+
+	// File describes the file at the conflicting path, if it is a
+	// regular file.
+	File FileInfo
+
+	// Rendered is set when the operation links to a rendered template
+	// rather than a package file.
+	Rendered bool
+
+	// BackupDir is where conflicting files are backed up, if anywhere.
+	BackupDir string
+
+	// Strategies is the registry the strategy was looked up in, for
+	// strategies that delegate to others.
+	Strategies *StrategyRegistry
+}
+
+// ResolutionStrategy resolves conflicts. Strategies are registered in a
+// StrategyRegistry under their name, which policies refer to.
+//
+// A strategy that cannot resolve a kind of conflict returns the outcome
+// of the fail strategy.
+type ResolutionStrategy interface {
+	Name() string
+	Resolve(req ResolutionRequest) ResolutionOutcome
+}
+
+// Prompter asks how to resolve a conflict and returns the name of the
+// strategy to apply, one of choices.
+type Prompter func(c Conflict, choices []string) (string, error)
+
+// StrategyRegistry holds resolution strategies by name. It is safe for
+// concurrent use.
+type StrategyRegistry struct {
+	mu         sync.RWMutex
+	strategies map[string]ResolutionStrategy
+}
+
+// NewStrategyRegistry creates a registry holding the built-in strategies:
+// fail, skip, backup, overwrite, adopt and prompt. The prompt strategy
+// asks prompter, and fails every conflict if prompter is nil.
+func NewStrategyRegistry(prompter Prompter) *StrategyRegistry {
+	r := &StrategyRegistry{strategies: make(map[string]ResolutionStrategy)}
+	for _, s := range []ResolutionStrategy{
+		failStrategy{},
+		skipStrategy{},
+		backupStrategy{},
+		overwriteStrategy{},
+		adoptStrategy{},
+		promptStrategy{ask: prompter},
+	} {
+		r.strategies[s.Name()] = s
+	}
+	return r
+}
+
+// defaultStrategies holds the built-in strategies for policies without a
+// registry.
+var defaultStrategies = NewStrategyRegistry(nil)
+
+// BuiltinStrategies returns the names of the built-in strategies.
+func BuiltinStrategies() []string {
+	return defaultStrategies.Names()
+}
+
+// Register adds s to the registry. It fails if the name is empty or
+// already taken.
+func (r *StrategyRegistry) Register(s ResolutionStrategy) error {
+	name := s.Name()
+	if name == "" {
+		return fmt.Errorf("register conflict strategy: name cannot be empty")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.strategies[name]; exists {
+		return fmt.Errorf("register conflict strategy: %q is already registered", name)
+	}
+	r.strategies[name] = s
+	return nil
+}
+
+// Lookup returns the strategy registered under name.
+func (r *StrategyRegistry) Lookup(name string) (ResolutionStrategy, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s, ok := r.strategies[name]
+	return s, ok
+}
+
+// Names returns the names of the registered strategies, sorted.
+func (r *StrategyRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.strategies))
+	for name := range r.strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyStrategy resolves req with the strategy policy names. Unknown
+// strategies fail the conflict.
+func applyStrategy(req ResolutionRequest, policy ResolutionPolicy) ResolutionOutcome {
+	if req.Strategies == nil {
+		req.Strategies = defaultStrategies
+	}
+	strategy, ok := req.Strategies.Lookup(policy.String())
+	if !ok {
+		return applyFailPolicy(req.Conflict.WithContext("strategy", fmt.Sprintf("unknown conflict strategy %q", policy)))
+	}
+	return strategy.Resolve(req)
+}
+
+// failStrategy reports every conflict.
+type failStrategy struct{}
+
+func (failStrategy) Name() string { return string(PolicyFail) }
+
+func (failStrategy) Resolve(req ResolutionRequest) ResolutionOutcome {
+	return applyFailPolicy(req.Conflict)
+}
+
+// skipStrategy leaves the conflicting path alone and drops the operation.
+type skipStrategy struct{}
+
+func (skipStrategy) Name() string { return string(PolicySkip) }
+
+func (skipStrategy) Resolve(req ResolutionRequest) ResolutionOutcome {
+	switch op := req.Operation.(type) {
+	case domain.LinkCreate:
+		return applySkipPolicy(op, req.Conflict)
+	case domain.DirCreate:
+		warning := Warning{
+			Message:  "Skipping directory creation due to conflict: " + op.Path.String(),
+			Severity: WarnInfo,
+		}
+		return ResolutionOutcome{
+			Status:  ResolveSkip,
+			Warning: &warning,
+		}
+	default:
+		return applyFailPolicy(req.Conflict)
+	}
+}
+
+// backupStrategy moves an existing file into the backup store before
+// linking. Other conflicts fail.
+type backupStrategy struct{}
+
+func (backupStrategy) Name() string { return string(PolicyBackup) }
+
+func (backupStrategy) Resolve(req ResolutionRequest) ResolutionOutcome {
+	op, ok := req.Operation.(domain.LinkCreate)
+	// Backing up an existing file needs its content hash and a backup directory
+	if !ok || req.Conflict.Type != ConflictFileExists || req.BackupDir == "" || req.File.Hash == "" {
+		return applyFailPolicy(req.Conflict)
+	}
+	return applyBackupPolicy(op, req.Conflict, req.File, req.BackupDir)
+}
+
+// overwriteStrategy deletes an existing file or wrong link before linking.
+// The deleted file is not recoverable.
+type overwriteStrategy struct{}
+
+func (overwriteStrategy) Name() string { return string(PolicyOverwrite) }
+
+func (overwriteStrategy) Resolve(req ResolutionRequest) ResolutionOutcome {
+	op, ok := req.Operation.(domain.LinkCreate)
+	if !ok || (req.Conflict.Type != ConflictFileExists && req.Conflict.Type != ConflictWrongLink) {
+		return applyFailPolicy(req.Conflict)
+	}
+
+	remove := domain.NewLinkDelete(domain.OperationID("overwrite-"+op.Target.String()), op.Target)
+	warning := Warning{
+		Message:  "Overwriting existing " + conflictSubject(req.Conflict) + ": " + op.Target.String(),
+		Severity: WarnDanger,
+	}
+	outcome := ResolutionOutcome{
+		Status:     ResolveWarning,
+		Operations: []domain.Operation{remove, op},
+		Warning:    &warning,
+	}
+	if req.Conflict.Type == ConflictFileExists {
+		outcome.Replacement = &Replacement{Path: op.Target.String(), With: op.Source.String(), Policy: PolicyOverwrite}
+	}
+	return outcome
+}
+
+// adoptStrategy moves an existing file into the package in place of the
+// package file, as GNU Stow's --adopt does, then links it. Links to
+// rendered templates cannot be adopted and fail.
+type adoptStrategy struct{}
+
+func (adoptStrategy) Name() string { return string(PolicyAdopt) }
+
+func (adoptStrategy) Resolve(req ResolutionRequest) ResolutionOutcome {
+	op, ok := req.Operation.(domain.LinkCreate)
+	if !ok || req.Conflict.Type != ConflictFileExists || req.Rendered {
+		return applyFailPolicy(req.Conflict)
+	}
+
+	move := domain.NewFileMove(domain.OperationID("adopt-"+op.Target.String()), op.Target, op.Source)
+	warning := Warning{
+		Message:  "Adopting existing file into package: " + op.Target.String(),
+		Severity: WarnCaution,
+		Context: map[string]string{
+			"source": op.Source.String(),
+		},
+	}
+	return ResolutionOutcome{
+		Status:      ResolveWarning,
+		Operations:  []domain.Operation{move, op},
+		Warning:     &warning,
+		Replacement: &Replacement{Path: op.Source.String(), With: op.Target.String(), Policy: PolicyAdopt},
+	}
+}
+
+// promptStrategy asks which strategy to apply to each conflict.
+type promptStrategy struct {
+	ask Prompter
+}
+
+func (promptStrategy) Name() string { return string(PolicyPrompt) }
+
+func (s promptStrategy) Resolve(req ResolutionRequest) ResolutionOutcome {
+	if s.ask == nil {
+		return applyFailPolicy(req.Conflict.WithContext("strategy", "no terminal to prompt on"))
+	}
+
+	choices := make([]string, 0, len(req.Strategies.Names()))
+	for _, name := range req.Strategies.Names() {
+		if name != string(PolicyPrompt) {
+			choices = append(choices, name)
+		}
+	}
+	choice, err := s.ask(req.Conflict, choices)
+	if err != nil {
+		return applyFailPolicy(req.Conflict.WithContext("strategy", err.Error()))
+	}
+	if choice == string(PolicyPrompt) {
+		return applyFailPolicy(req.Conflict)
+	}
+	return applyStrategy(req, ResolutionPolicy(choice))
+}
+
+// conflictSubject names what is in the way of a link.
+func conflictSubject(c Conflict) string {
+	return strings.ReplaceAll(strings.TrimSuffix(c.Type.String(), "_exists"), "_", " ")
+}
@@ -0,0 +1,163 @@
+package planner
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/domain"
+)
+
+// keepStrategy is a custom strategy that keeps existing files and reports
+// them as satisfied.
+type keepStrategy struct{}
+
+func (keepStrategy) Name() string { return "keep" }
+
+func (keepStrategy) Resolve(req ResolutionRequest) ResolutionOutcome {
+	return ResolutionOutcome{Status: ResolveSkip, Operations: []domain.Operation{req.Operation}}
+}
+
+func TestStrategyRegistry(t *testing.T) {
+	registry := NewStrategyRegistry(nil)
+	assert.Equal(t, []string{"adopt", "backup", "fail", "overwrite", "prompt", "skip"}, registry.Names())
+	assert.Equal(t, registry.Names(), BuiltinStrategies())
+
+	require.NoError(t, registry.Register(keepStrategy{}))
+	s, ok := registry.Lookup("keep")
+	assert.True(t, ok)
+	assert.Equal(t, "keep", s.Name())
+
+	assert.ErrorContains(t, registry.Register(keepStrategy{}), `"keep" is already registered`)
+	assert.ErrorContains(t, registry.Register(failStrategy{}), `"fail" is already registered`)
+
+	_, ok = NewStrategyRegistry(nil).Lookup("keep")
+	assert.False(t, ok, "registries are independent")
+}
+
+func TestResolve_CustomStrategy(t *testing.T) {
+	registry := NewStrategyRegistry(nil)
+	require.NoError(t, registry.Register(keepStrategy{}))
+
+	source := domain.NewFilePath("/packages/bash/dot-bashrc").Unwrap()
+	target := domain.NewTargetPath("/home/user/.bashrc").Unwrap()
+	ops := []domain.Operation{domain.NewLinkCreate("link-auto", source, target)}
+	current := CurrentState{Files: map[string]FileInfo{target.String(): {Size: 100}}}
+
+	policies := DefaultPolicies()
+	policies.OnFileExists = "keep"
+	policies.Strategies = registry
+
+	result := Resolve(ops, current, policies, "")
+	assert.False(t, result.HasConflicts())
+	assert.Empty(t, result.Operations)
+	assert.Equal(t, ops, result.Satisfied)
+
+	// Without the registry the name is unknown
+	policies.Strategies = nil
+	result = Resolve(ops, current, policies, "")
+	assert.True(t, result.HasConflicts())
+}
+
+func TestOverwriteStrategy(t *testing.T) {
+	source := domain.NewFilePath("/packages/bash/dot-bashrc").Unwrap()
+	target := domain.NewTargetPath("/home/user/.bashrc").Unwrap()
+	op := domain.NewLinkCreate("link-auto", source, target)
+
+	policies := DefaultPolicies()
+	policies.OnFileExists = PolicyOverwrite
+	policies.OnWrongLink = PolicyOverwrite
+
+	for name, current := range map[string]CurrentState{
+		"file":       {Files: map[string]FileInfo{target.String(): {Size: 100}}},
+		"wrong link": {Links: map[string]LinkTarget{target.String(): {Target: "/elsewhere"}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			result := Resolve([]domain.Operation{op}, current, policies, "")
+			assert.False(t, result.HasConflicts())
+			require.Len(t, result.Operations, 2)
+			remove, ok := result.Operations[0].(domain.LinkDelete)
+			require.True(t, ok)
+			assert.Equal(t, target, remove.Target)
+			assert.Equal(t, op, result.Operations[1])
+			require.Len(t, result.Warnings, 1)
+			assert.Equal(t, WarnDanger, result.Warnings[0].Severity)
+			assert.Contains(t, result.Warnings[0].Message, "Overwriting existing "+name)
+		})
+	}
+
+	t.Run("replacement", func(t *testing.T) {
+		current := CurrentState{Files: map[string]FileInfo{target.String(): {Size: 100}}}
+		result := Resolve([]domain.Operation{op}, current, policies, "")
+		assert.Equal(t, []Replacement{{Path: target.String(), With: source.String(), Policy: PolicyOverwrite}}, result.Replacements)
+
+		current = CurrentState{Links: map[string]LinkTarget{target.String(): {Target: "/elsewhere"}}}
+		result = Resolve([]domain.Operation{op}, current, policies, "")
+		assert.Empty(t, result.Replacements, "replacing a link loses no content")
+	})
+}
+
+func TestAdoptStrategy(t *testing.T) {
+	source := domain.NewFilePath("/packages/bash/dot-bashrc").Unwrap()
+	target := domain.NewTargetPath("/home/user/.bashrc").Unwrap()
+	op := domain.NewLinkCreate("link-auto", source, target)
+	current := CurrentState{Files: map[string]FileInfo{target.String(): {Size: 100}}}
+
+	policies := DefaultPolicies()
+	policies.OnFileExists = PolicyAdopt
+
+	result := Resolve([]domain.Operation{op}, current, policies, "")
+	assert.False(t, result.HasConflicts())
+	require.Len(t, result.Operations, 2)
+	assert.Equal(t, domain.NewFileMove("adopt-/home/user/.bashrc", target, source), result.Operations[0])
+	assert.Equal(t, op, result.Operations[1])
+	assert.Equal(t, []Replacement{{Path: source.String(), With: target.String(), Policy: PolicyAdopt}}, result.Replacements)
+
+	t.Run("rendered template", func(t *testing.T) {
+		render := domain.NewFileRender("render", domain.NewFilePath("/packages/bash/dot-bashrc.tmpl").Unwrap(), source, "")
+		result := Resolve([]domain.Operation{render, op}, current, policies, "")
+		assert.True(t, result.HasConflicts())
+	})
+}
+
+func TestPromptStrategy(t *testing.T) {
+	source := domain.NewFilePath("/packages/bash/dot-bashrc").Unwrap()
+	target := domain.NewTargetPath("/home/user/.bashrc").Unwrap()
+	ops := []domain.Operation{domain.NewLinkCreate("link-auto", source, target)}
+	current := CurrentState{Files: map[string]FileInfo{target.String(): {Size: 100}}}
+
+	policies := DefaultPolicies()
+	policies.OnFileExists = PolicyPrompt
+
+	t.Run("without prompter", func(t *testing.T) {
+		result := Resolve(ops, current, policies, "")
+		require.Len(t, result.Conflicts, 1)
+		assert.Equal(t, "no terminal to prompt on", result.Conflicts[0].Context["strategy"])
+	})
+
+	t.Run("applies the chosen strategy", func(t *testing.T) {
+		var asked []string
+		policies.Strategies = NewStrategyRegistry(func(c Conflict, choices []string) (string, error) {
+			asked = choices
+			assert.Equal(t, target.String(), c.Path.String())
+			return "skip", nil
+		})
+
+		result := Resolve(ops, current, policies, "")
+		assert.False(t, result.HasConflicts())
+		assert.Empty(t, result.Operations)
+		assert.Equal(t, []string{"adopt", "backup", "fail", "overwrite", "skip"}, asked)
+	})
+
+	t.Run("prompt error fails the conflict", func(t *testing.T) {
+		policies.Strategies = NewStrategyRegistry(func(Conflict, []string) (string, error) {
+			return "", errors.New("cancelled")
+		})
+
+		result := Resolve(ops, current, policies, "")
+		require.Len(t, result.Conflicts, 1)
+		assert.Equal(t, "cancelled", result.Conflicts[0].Context["strategy"])
+	})
+}
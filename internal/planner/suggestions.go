@@ -24,9 +24,9 @@ func generateSuggestions(c Conflict) []Suggestion {
 func generateFileExistsSuggestions(c Conflict) []Suggestion {
 	return []Suggestion{
 		{
-			Action:      "Use --backup flag to preserve existing file",
+			Action:      "Use --on-conflict backup to preserve existing file",
 			Explanation: "Moves conflicting file to backup location before linking",
-			Example:     "dot manage --backup <package>",
+			Example:     "dot --on-conflict backup manage <package>",
 		},
 		{
 			Action:      "Use dot adopt to move file into package",
@@ -50,9 +50,9 @@ func generateWrongLinkSuggestions(c Conflict) []Suggestion {
 			Example:     "dot unmanage <other-package>",
 		},
 		{
-			Action:      "Use --overwrite to replace the link",
+			Action:      "Use --on-conflict overwrite to replace the link",
 			Explanation: "Forces link to point to new package",
-			Example:     "dot manage --overwrite <package>",
+			Example:     "dot --on-conflict overwrite manage <package>",
 		},
 		{
 			Action:      "Check which package owns the link",
@@ -0,0 +1,182 @@
+package planner
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jamesainslie/dot/internal/domain"
+)
+
+// FoldedDir describes a directory symlink pointing into the package
+// directory, as GNU Stow creates when a whole tree belongs to one package
+// and as adopting a directory leaves behind.
+type FoldedDir struct {
+	Target string   // Directory the link points at
+	Dirs   []string // Directories below Target, relative, parents first
+	Files  []string // Files and symlinks below Target, relative
+}
+
+// splitFolded separates the operations that place entries at or below a
+// folded directory link from the rest. Folded operations are grouped by
+// the deepest link containing them.
+func splitFolded(operations []domain.Operation, current CurrentState) ([]domain.Operation, map[string][]domain.Operation) {
+	if len(current.Folded) == 0 {
+		return operations, nil
+	}
+
+	remaining := make([]domain.Operation, 0, len(operations))
+	folded := make(map[string][]domain.Operation)
+	for _, op := range operations {
+		if link := foldedLinkFor(operationPath(op), current.Folded); link != "" {
+			folded[link] = append(folded[link], op)
+			continue
+		}
+		remaining = append(remaining, op)
+	}
+	return remaining, folded
+}
+
+// foldedLinkFor returns the deepest folded link that is path or one of its
+// ancestors, or "" if there is none.
+func foldedLinkFor(path string, folded map[string]FoldedDir) string {
+	if path == "" {
+		return ""
+	}
+	best := ""
+	for link := range folded {
+		if (path == link || strings.HasPrefix(path, link+string(filepath.Separator))) && len(link) > len(best) {
+			best = link
+		}
+	}
+	return best
+}
+
+// operationPath returns the target path a DirCreate or LinkCreate places
+// an entry at, or "" for other operations.
+func operationPath(op domain.Operation) string {
+	switch o := op.(type) {
+	case domain.DirCreate:
+		return o.Path.String()
+	case domain.LinkCreate:
+		return o.Target.String()
+	}
+	return ""
+}
+
+// resolveFolded resolves the operations placing entries at or below the
+// folded directory link at link.
+//
+// When the folded directory already provides everything the operations
+// ask for, as after adopting a directory, they are satisfied. Otherwise
+// the link is unfolded the way GNU Stow does: it is replaced by a real
+// directory holding a link to every file of the directory it pointed at,
+// and the operations are resolved against those links rather than
+// reported as conflicts with the folded link.
+func resolveFolded(
+	result ResolveResult,
+	link string,
+	fold FoldedDir,
+	operations []domain.Operation,
+	policies ResolutionPolicies,
+	backupDir string,
+	rendered map[string]bool,
+) ResolveResult {
+	if foldProvides(link, fold, operations) {
+		result.Satisfied = append(result.Satisfied, operations...)
+		return result
+	}
+
+	unfold, unfolded := unfoldOperations(link, fold)
+	covered := make(map[domain.OperationID]bool, len(unfold))
+	for _, op := range unfold {
+		covered[op.ID()] = true
+	}
+
+	result.Operations = append(result.Operations, unfold...)
+	result = result.WithWarning(Warning{
+		Message:  fmt.Sprintf("Unfolding directory link %s into links to the files in %s", link, fold.Target),
+		Severity: WarnInfo,
+		Context: map[string]string{
+			"path":   link,
+			"target": fold.Target,
+		},
+	})
+
+	for _, op := range operations {
+		if covered[op.ID()] {
+			continue
+		}
+		result = result.withOutcome(resolvePlanned(op, unfolded, policies, backupDir, rendered))
+	}
+	return result
+}
+
+// foldProvides reports whether every operation is already carried out by
+// the folded link: its directories exist below the link target and its
+// links point at the files the link target holds.
+func foldProvides(link string, fold FoldedDir, operations []domain.Operation) bool {
+	dirs := make(map[string]bool, len(fold.Dirs))
+	for _, dir := range fold.Dirs {
+		dirs[dir] = true
+	}
+	files := make(map[string]bool, len(fold.Files))
+	for _, file := range fold.Files {
+		files[file] = true
+	}
+
+	for _, op := range operations {
+		rel, err := filepath.Rel(link, operationPath(op))
+		if err != nil {
+			return false
+		}
+		switch o := op.(type) {
+		case domain.DirCreate:
+			if rel != "." && !dirs[rel] {
+				return false
+			}
+		case domain.LinkCreate:
+			if !files[rel] || o.Source.String() != filepath.Join(fold.Target, rel) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// unfoldOperations returns the operations replacing the folded link with a
+// directory of links, and the state of the target once they have run.
+func unfoldOperations(link string, fold FoldedDir) ([]domain.Operation, CurrentState) {
+	state := CurrentState{
+		Files: make(map[string]FileInfo),
+		Links: make(map[string]LinkTarget),
+		Dirs:  map[string]bool{link: true},
+	}
+
+	linkPath := domain.NewTargetPath(link).Unwrap()
+	ops := make([]domain.Operation, 0, len(fold.Dirs)+len(fold.Files)+2)
+	ops = append(ops, domain.NewLinkDelete(domain.OperationID(fmt.Sprintf("unfold-%s", link)), linkPath))
+
+	dirs := append([]string{"."}, fold.Dirs...)
+	for _, dir := range dirs {
+		path := filepath.Join(link, dir)
+		state.Dirs[path] = true
+		dirPath := domain.NewFilePath(path).Unwrap()
+		ops = append(ops, domain.NewDirCreate(domain.OperationID(fmt.Sprintf("dir-%s", path)), dirPath))
+	}
+
+	files := append([]string(nil), fold.Files...)
+	sort.Strings(files)
+	for _, file := range files {
+		source := domain.NewFilePath(filepath.Join(fold.Target, file)).Unwrap()
+		target := linkPath.Join(file)
+		state.Links[target.String()] = LinkTarget{Target: source.String()}
+		id := domain.OperationID(fmt.Sprintf("link-%s->%s", source.String(), target.String()))
+		ops = append(ops, domain.NewLinkCreate(id, source, target))
+	}
+
+	return ops, state
+}
@@ -0,0 +1,96 @@
+package planner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/domain"
+)
+
+// foldedConfigState describes /home/user/.config as a link to the .config
+// directory of package a, which holds git/config.
+func foldedConfigState() CurrentState {
+	return CurrentState{
+		Files: make(map[string]FileInfo),
+		Links: map[string]LinkTarget{"/home/user/.config": {Target: "/packages/a/.config"}},
+		Dirs:  make(map[string]bool),
+		Folded: map[string]FoldedDir{
+			"/home/user/.config": {
+				Target: "/packages/a/.config",
+				Dirs:   []string{"git"},
+				Files:  []string{"git/config"},
+			},
+		},
+	}
+}
+
+func dirOp(path string) domain.Operation {
+	return domain.NewDirCreate(domain.OperationID("dir-"+path), domain.NewFilePath(path).Unwrap())
+}
+
+func linkOp(source, target string) domain.Operation {
+	return domain.NewLinkCreate(
+		domain.OperationID("link-"+source+"->"+target),
+		domain.NewFilePath(source).Unwrap(),
+		domain.NewTargetPath(target).Unwrap(),
+	)
+}
+
+func TestResolve_FoldedDirectoryProvidesOperations(t *testing.T) {
+	ops := []domain.Operation{
+		dirOp("/home/user/.config"),
+		dirOp("/home/user/.config/git"),
+		linkOp("/packages/a/.config/git/config", "/home/user/.config/git/config"),
+	}
+
+	result := Resolve(ops, foldedConfigState(), DefaultPolicies(), "/backup")
+
+	assert.False(t, result.HasConflicts())
+	assert.Empty(t, result.Operations)
+	assert.Len(t, result.Satisfied, 3)
+	assert.Empty(t, result.Warnings)
+}
+
+func TestResolve_UnfoldsFoldedDirectory(t *testing.T) {
+	ops := []domain.Operation{
+		dirOp("/home/user/.config"),
+		dirOp("/home/user/.config/nvim"),
+		linkOp("/packages/b/.config/nvim/init.lua", "/home/user/.config/nvim/init.lua"),
+	}
+
+	result := Resolve(ops, foldedConfigState(), DefaultPolicies(), "/backup")
+
+	require.False(t, result.HasConflicts())
+	ids := make([]domain.OperationID, 0, len(result.Operations))
+	for _, op := range result.Operations {
+		ids = append(ids, op.ID())
+	}
+	assert.Equal(t, []domain.OperationID{
+		"unfold-/home/user/.config",
+		"dir-/home/user/.config",
+		"dir-/home/user/.config/git",
+		"link-/packages/a/.config/git/config->/home/user/.config/git/config",
+		"dir-/home/user/.config/nvim",
+		"link-/packages/b/.config/nvim/init.lua->/home/user/.config/nvim/init.lua",
+	}, ids)
+
+	require.Len(t, result.Warnings, 1)
+	assert.Equal(t, WarnInfo, result.Warnings[0].Severity)
+	assert.Equal(t, "/home/user/.config", result.Warnings[0].Context["path"])
+}
+
+func TestResolve_UnfoldReportsConflictsWithFoldedFiles(t *testing.T) {
+	ops := []domain.Operation{
+		dirOp("/home/user/.config"),
+		dirOp("/home/user/.config/git"),
+		linkOp("/packages/b/.config/git/config", "/home/user/.config/git/config"),
+	}
+
+	result := Resolve(ops, foldedConfigState(), DefaultPolicies(), "/backup")
+
+	require.Len(t, result.Conflicts, 1)
+	assert.Equal(t, ConflictWrongLink, result.Conflicts[0].Type)
+	assert.Equal(t, "/home/user/.config/git/config", result.Conflicts[0].Path.String())
+}
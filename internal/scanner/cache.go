@@ -0,0 +1,180 @@
+package scanner
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/jamesainslie/dot/internal/domain"
+)
+
+// cacheVersion is bumped whenever the layout of cached trees changes, so
+// entries written by older releases are rescanned rather than misread.
+const cacheVersion = 1
+
+// racyWindow is how recently a directory may have changed for its tree not
+// to be cached. A change in the same modification time tick as the scan
+// would otherwise go unnoticed, as with the racy index entries of git.
+const racyWindow = 2 * time.Second
+
+// TreeCache keeps the scanned trees of packages in a directory, so package
+// trees whose directories have not changed since they were last scanned
+// are not walked again.
+//
+// A cached tree is valid while the modification time of every directory in
+// it is unchanged. Creating, removing or renaming an entry updates the
+// modification time of its directory, which is all a tree records; file
+// contents are not part of it. A nil TreeCache caches nothing.
+type TreeCache struct {
+	fs  domain.FS
+	dir string
+	now func() time.Time
+}
+
+// NewTreeCache returns a cache storing trees in dir of fs.
+func NewTreeCache(fs domain.FS, dir string) *TreeCache {
+	return &TreeCache{fs: fs, dir: dir, now: time.Now}
+}
+
+// cacheEntry is the cached tree of one root directory.
+type cacheEntry struct {
+	Version int    `json:"version"`
+	Root    string `json:"root"`
+
+	// Dirs maps every directory of the tree, relative to Root, to its
+	// modification time in Unix nanoseconds when scanned.
+	Dirs map[string]int64 `json:"dirs"`
+
+	Tree cachedNode `json:"tree"`
+}
+
+// cachedNode is a Node with its path reduced to a base name.
+type cachedNode struct {
+	Name     string          `json:"name"`
+	Type     domain.NodeType `json:"type"`
+	Children []cachedNode    `json:"children,omitempty"`
+}
+
+// ScanTreeCached scans the tree at path like ScanTree, reusing the tree
+// stored in cache when no directory in it changed and storing the tree
+// scanned otherwise. Failing to read or write the cache only costs a scan.
+func ScanTreeCached(ctx context.Context, fs domain.FS, path domain.FilePath, cache *TreeCache) domain.Result[domain.Node] {
+	if cache == nil {
+		return ScanTree(ctx, fs, path)
+	}
+	if tree, ok := cache.load(ctx, fs, path); ok {
+		return domain.Ok(tree)
+	}
+
+	result := ScanTree(ctx, fs, path)
+	if result.IsOk() {
+		_ = cache.store(ctx, fs, path, result.Unwrap())
+	}
+	return result
+}
+
+// entryPath returns the file holding the cached tree of root.
+func (c *TreeCache) entryPath(root domain.FilePath) string {
+	sum := sha256.Sum256([]byte(root.String()))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:16])+".json")
+}
+
+// load returns the cached tree of root if every directory in it has the
+// modification time it had when cached.
+func (c *TreeCache) load(ctx context.Context, fs domain.FS, root domain.FilePath) (domain.Node, bool) {
+	data, err := c.fs.ReadFile(ctx, c.entryPath(root))
+	if err != nil {
+		return domain.Node{}, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Version != cacheVersion || entry.Root != root.String() {
+		return domain.Node{}, false
+	}
+
+	for rel, mtime := range entry.Dirs {
+		current, ok := dirModTime(ctx, fs, filepath.Join(root.String(), rel))
+		if !ok || current.UnixNano() != mtime {
+			return domain.Node{}, false
+		}
+	}
+	return expandNode(root, entry.Tree), true
+}
+
+// store writes tree to the cache. Trees with a directory changed within
+// racyWindow are not stored, since a further change in the same tick
+// would not be noticed.
+func (c *TreeCache) store(ctx context.Context, fs domain.FS, root domain.FilePath, tree domain.Node) error {
+	entry := cacheEntry{
+		Version: cacheVersion,
+		Root:    root.String(),
+		Dirs:    map[string]int64{},
+		Tree:    reduceNode(tree),
+	}
+
+	cutoff := c.now().Add(-racyWindow)
+	err := Walk(tree, func(node domain.Node) error {
+		if node.Type != domain.NodeDir {
+			return nil
+		}
+		mtime, ok := dirModTime(ctx, fs, node.Path.String())
+		if !ok {
+			return fmt.Errorf("no modification time for %s", node.Path)
+		}
+		if mtime.After(cutoff) {
+			return fmt.Errorf("%s changed too recently to cache", node.Path)
+		}
+		rel, err := filepath.Rel(root.String(), node.Path.String())
+		if err != nil {
+			return err
+		}
+		entry.Dirs[rel] = mtime.UnixNano()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := c.fs.MkdirAll(ctx, c.dir, 0755); err != nil {
+		return err
+	}
+	return c.fs.WriteFile(ctx, c.entryPath(root), data, 0644)
+}
+
+// dirModTime returns the modification time of the directory at path.
+func dirModTime(ctx context.Context, fs domain.FS, path string) (time.Time, bool) {
+	info, err := fs.Stat(ctx, path)
+	if err != nil || !info.IsDir() {
+		return time.Time{}, false
+	}
+	mtime, ok := info.ModTime().(time.Time)
+	return mtime, ok && !mtime.IsZero()
+}
+
+// reduceNode converts node to its cached form.
+func reduceNode(node domain.Node) cachedNode {
+	cached := cachedNode{Name: filepath.Base(node.Path.String()), Type: node.Type}
+	for _, child := range node.Children {
+		cached.Children = append(cached.Children, reduceNode(child))
+	}
+	return cached
+}
+
+// expandNode converts cached back into a Node at path.
+func expandNode(path domain.FilePath, cached cachedNode) domain.Node {
+	node := domain.Node{Path: path, Type: cached.Type}
+	if cached.Type == domain.NodeDir {
+		node.Children = make([]domain.Node, 0, len(cached.Children))
+	}
+	for _, child := range cached.Children {
+		node.Children = append(node.Children, expandNode(path.Join(child.Name), child))
+	}
+	return node
+}
@@ -0,0 +1,110 @@
+package scanner_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/internal/domain"
+	"github.com/jamesainslie/dot/internal/scanner"
+)
+
+// readDirCountingFS counts directory listings, which a cached scan skips.
+type readDirCountingFS struct {
+	*adapters.MemFS
+	readDirs atomic.Int32
+}
+
+func (f *readDirCountingFS) ReadDir(ctx context.Context, name string) ([]domain.DirEntry, error) {
+	f.readDirs.Add(1)
+	return f.MemFS.ReadDir(ctx, name)
+}
+
+// setupCachedPackage creates a package with a nested directory whose
+// modification times are old enough to be cached.
+func setupCachedPackage(t *testing.T) (*readDirCountingFS, domain.FilePath) {
+	t.Helper()
+	ctx := context.Background()
+	fs := &readDirCountingFS{MemFS: adapters.NewMemFS()}
+
+	require.NoError(t, fs.MkdirAll(ctx, "/packages/vim/dot-vim/colors", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/packages/vim/dot-vimrc", []byte("set number"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/packages/vim/dot-vim/colors/dark.vim", []byte("hi"), 0644))
+	backdate(t, fs, "/packages/vim", "/packages/vim/dot-vim", "/packages/vim/dot-vim/colors")
+
+	return fs, domain.MustParsePath("/packages/vim")
+}
+
+// backdate sets the modification time of paths to an hour ago.
+func backdate(t *testing.T, fs *readDirCountingFS, paths ...string) {
+	t.Helper()
+	old := time.Now().Add(-time.Hour)
+	for _, path := range paths {
+		require.NoError(t, fs.Chtimes(context.Background(), path, old, old))
+	}
+}
+
+func TestScanTreeCached_ReusesUnchangedTree(t *testing.T) {
+	ctx := context.Background()
+	fs, root := setupCachedPackage(t)
+	cache := scanner.NewTreeCache(fs, "/cache/scan")
+
+	first := scanner.ScanTreeCached(ctx, fs, root, cache)
+	require.True(t, first.IsOk())
+	scanned := fs.readDirs.Load()
+	require.Positive(t, scanned)
+
+	second := scanner.ScanTreeCached(ctx, fs, root, cache)
+	require.True(t, second.IsOk())
+	assert.Equal(t, scanned, fs.readDirs.Load(), "cached tree should not be walked")
+	assert.Equal(t, first.Unwrap(), second.Unwrap())
+}
+
+func TestScanTreeCached_RescansChangedDirectory(t *testing.T) {
+	ctx := context.Background()
+	fs, root := setupCachedPackage(t)
+	cache := scanner.NewTreeCache(fs, "/cache/scan")
+
+	require.True(t, scanner.ScanTreeCached(ctx, fs, root, cache).IsOk())
+
+	// Adding a file changes the modification time of its directory
+	require.NoError(t, fs.WriteFile(ctx, "/packages/vim/dot-vim/colors/light.vim", []byte("hi"), 0644))
+	now := time.Now()
+	require.NoError(t, fs.Chtimes(ctx, "/packages/vim/dot-vim/colors", now, now))
+
+	result := scanner.ScanTreeCached(ctx, fs, root, cache)
+	require.True(t, result.IsOk())
+	files := scanner.CollectFiles(result.Unwrap())
+	assert.Contains(t, files, domain.MustParsePath("/packages/vim/dot-vim/colors/light.vim"))
+}
+
+func TestScanTreeCached_SkipsRecentlyChangedTree(t *testing.T) {
+	ctx := context.Background()
+	fs, root := setupCachedPackage(t)
+	now := time.Now()
+	require.NoError(t, fs.Chtimes(ctx, "/packages/vim/dot-vim", now, now))
+	cache := scanner.NewTreeCache(fs, "/cache/scan")
+
+	require.True(t, scanner.ScanTreeCached(ctx, fs, root, cache).IsOk())
+	scanned := fs.readDirs.Load()
+	require.True(t, scanner.ScanTreeCached(ctx, fs, root, cache).IsOk())
+
+	assert.Greater(t, fs.readDirs.Load(), scanned, "a tree changed within the racy window should be rescanned")
+}
+
+func TestScanTreeCached_NilCache(t *testing.T) {
+	ctx := context.Background()
+	fs, root := setupCachedPackage(t)
+
+	require.True(t, scanner.ScanTreeCached(ctx, fs, root, nil).IsOk())
+	scanned := fs.readDirs.Load()
+	require.True(t, scanner.ScanTreeCached(ctx, fs, root, nil).IsOk())
+
+	assert.Equal(t, 2*scanned, fs.readDirs.Load())
+	assert.False(t, fs.Exists(ctx, "/cache/scan"))
+}
@@ -1,21 +1,40 @@
 package scanner
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
 
 	"github.com/jamesainslie/dot/internal/domain"
 	"github.com/jamesainslie/dot/internal/ignore"
 )
 
+// IgnoreFileName is the file at the root of a package that lists patterns
+// of package files not to link, one per line. Patterns match paths
+// relative to the package root or base names, like the global ignore
+// patterns. The file itself is never linked.
+const IgnoreFileName = ".dotignore"
+
 // ScanPackage scans a single package directory.
 // Returns a Package containing the package metadata and file tree.
 //
 // The scanner:
 // 1. Verifies package directory exists
 // 2. Scans the directory tree
-// 3. Applies ignore patterns (filtered during tree scan)
+// 3. Applies ignore patterns, including those of the package's .dotignore
 // 4. Returns Package with tree
 func ScanPackage(ctx context.Context, fs domain.FS, path domain.PackagePath, name string, ignoreSet *ignore.IgnoreSet) domain.Result[domain.Package] {
+	return ScanPackageCached(ctx, fs, path, name, ignoreSet, nil)
+}
+
+// ScanPackageCached scans a package like ScanPackage, reusing the tree of
+// the package kept in cache when its directories have not changed. Ignore
+// patterns, including the package's .dotignore, are applied after the
+// cache, so changing them takes effect without a rescan.
+func ScanPackageCached(ctx context.Context, fs domain.FS, path domain.PackagePath, name string, ignoreSet *ignore.IgnoreSet, cache *TreeCache) domain.Result[domain.Package] {
 	// Check if package exists
 	if !fs.Exists(ctx, path.String()) {
 		return domain.Err[domain.Package](domain.ErrPackageNotFound{
@@ -25,15 +44,20 @@ func ScanPackage(ctx context.Context, fs domain.FS, path domain.PackagePath, nam
 
 	// Scan the package directory tree
 	pkgFilePath := domain.NewFilePath(path.String()).Unwrap()
-	treeResult := ScanTree(ctx, fs, pkgFilePath)
+	treeResult := ScanTreeCached(ctx, fs, pkgFilePath, cache)
 	if treeResult.IsErr() {
 		return domain.Err[domain.Package](treeResult.UnwrapErr())
 	}
 
 	tree := treeResult.Unwrap()
 
+	packageIgnore, err := loadPackageIgnore(ctx, fs, tree)
+	if err != nil {
+		return domain.Err[domain.Package](err)
+	}
+
 	// Filter tree based on ignore patterns
-	filtered := filterTree(tree, ignoreSet)
+	filtered := filterTree(tree, ignoreSet, packageIgnore, path.String())
 
 	return domain.Ok(domain.Package{
 		Name: name,
@@ -42,11 +66,46 @@ func ScanPackage(ctx context.Context, fs domain.FS, path domain.PackagePath, nam
 	})
 }
 
+// loadPackageIgnore returns the patterns of the .dotignore file at the root
+// of the package tree, or nil if the package has none.
+func loadPackageIgnore(ctx context.Context, fs domain.FS, tree domain.Node) (*ignore.IgnoreSet, error) {
+	var file string
+	for _, child := range tree.Children {
+		if child.Type == domain.NodeFile && filepath.Base(child.Path.String()) == IgnoreFileName {
+			file = child.Path.String()
+		}
+	}
+	if file == "" {
+		return nil, nil
+	}
+
+	data, err := fs.ReadFile(ctx, file)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", file, err)
+	}
+	set := ignore.NewIgnoreSet()
+	if err := set.Add(IgnoreFileName); err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := set.Add(strings.TrimSuffix(line, "/")); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+	}
+	return set, nil
+}
+
 // filterTree removes ignored files from a tree.
-// Returns a new tree with ignored nodes filtered out.
-func filterTree(node domain.Node, ignoreSet *ignore.IgnoreSet) domain.Node {
+// Returns a new tree with ignored nodes filtered out. Paths are matched
+// against packageIgnore relative to root.
+func filterTree(node domain.Node, ignoreSet, packageIgnore *ignore.IgnoreSet, root string) domain.Node {
 	// Check if this node should be ignored
-	if ignoreSet.ShouldIgnore(node.Path.String()) {
+	if ignoreSet.ShouldIgnore(node.Path.String()) || ignoredByPackage(packageIgnore, root, node.Path.String()) {
 		// Return empty node to be filtered by parent
 		return domain.Node{}
 	}
@@ -55,7 +114,7 @@ func filterTree(node domain.Node, ignoreSet *ignore.IgnoreSet) domain.Node {
 	if node.Type == domain.NodeDir {
 		var filteredChildren []domain.Node
 		for _, child := range node.Children {
-			filtered := filterTree(child, ignoreSet)
+			filtered := filterTree(child, ignoreSet, packageIgnore, root)
 			// Skip empty nodes (ignored)
 			if filtered.Path.String() != "" {
 				filteredChildren = append(filteredChildren, filtered)
@@ -72,3 +131,16 @@ func filterTree(node domain.Node, ignoreSet *ignore.IgnoreSet) domain.Node {
 	// File or symlink - return as-is
 	return node
 }
+
+// ignoredByPackage reports whether path matches the package's own ignore
+// patterns.
+func ignoredByPackage(packageIgnore *ignore.IgnoreSet, root, path string) bool {
+	if packageIgnore == nil {
+		return false
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return false
+	}
+	return packageIgnore.ShouldIgnore(filepath.ToSlash(rel))
+}
@@ -2,8 +2,10 @@ package scanner_test
 
 import (
 	"context"
+	"path/filepath"
 	"testing"
 
+	"github.com/jamesainslie/dot/internal/adapters"
 	"github.com/jamesainslie/dot/internal/domain"
 	"github.com/jamesainslie/dot/internal/ignore"
 	"github.com/jamesainslie/dot/internal/scanner"
@@ -84,3 +86,23 @@ func TestScanPackage_WithIgnorePatterns(t *testing.T) {
 
 	mockFS.AssertExpectations(t)
 }
+
+func TestScanPackage_PackageIgnoreFile(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	require.NoError(t, fs.MkdirAll(ctx, "/packages/dot-zsh/docs", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/packages/dot-zsh/dot-zshrc", []byte("x"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/packages/dot-zsh/README.md", []byte("x"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/packages/dot-zsh/docs/usage.txt", []byte("x"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/packages/dot-zsh/.dotignore", []byte("# package notes\n*.md\n\ndocs/\n"), 0644))
+
+	packagePath := domain.NewPackagePath("/packages/dot-zsh").Unwrap()
+	result := scanner.ScanPackage(ctx, fs, packagePath, "dot-zsh", ignore.NewIgnoreSet())
+	require.True(t, result.IsOk())
+
+	var names []string
+	for _, child := range result.Unwrap().Tree.Children {
+		names = append(names, filepath.Base(child.Path.String()))
+	}
+	assert.Equal(t, []string{"dot-zshrc"}, names)
+}
@@ -20,6 +20,9 @@ import (
 // 3. If directory, recursively scan children
 // 4. If file, return file node
 //
+// Children whose directory entry is a socket, named pipe, device node or
+// other special file become NodeSpecial leaves.
+//
 // This is a pure function - all I/O goes through the FS interface.
 func ScanTree(ctx context.Context, fs domain.FS, path domain.FilePath) domain.Result[domain.Node] {
 	// Check for symlinks first (symlinks are always leaves)
@@ -62,6 +65,14 @@ func ScanTree(ctx context.Context, fs domain.FS, path domain.FilePath) domain.Re
 	for _, entry := range entries {
 		childPath := path.Join(entry.Name())
 
+		if domain.SpecialFileKind(entry.Type()) != "" {
+			children = append(children, domain.Node{
+				Path: childPath,
+				Type: domain.NodeSpecial,
+			})
+			continue
+		}
+
 		childResult := ScanTree(ctx, fs, childPath)
 		if childResult.IsErr() {
 			return domain.Err[domain.Node](childResult.UnwrapErr())
@@ -112,6 +123,20 @@ func CollectFiles(node domain.Node) []domain.FilePath {
 	return files
 }
 
+// CollectSpecial returns all special file paths in a tree.
+func CollectSpecial(node domain.Node) []domain.FilePath {
+	var special []domain.FilePath
+
+	Walk(node, func(n domain.Node) error {
+		if n.Type == domain.NodeSpecial {
+			special = append(special, n.Path)
+		}
+		return nil
+	})
+
+	return special
+}
+
 // CountNodes returns the total number of nodes in a tree.
 func CountNodes(node domain.Node) int {
 	count := 1 // Count this node
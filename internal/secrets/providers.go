@@ -0,0 +1,129 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"unicode"
+)
+
+// Runner runs an external command and returns its standard output.
+type Runner func(ctx context.Context, name string, args ...string) (string, error)
+
+// ExecRunner runs the command with os/exec. A failing command's standard
+// error is included in the returned error.
+func ExecRunner(ctx context.Context, name string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return "", fmt.Errorf("%s is not installed or not on PATH", name)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %w: %s", name, err, msg)
+		}
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return stdout.String(), nil
+}
+
+// EnvProvider reads secrets from environment variables.
+type EnvProvider struct {
+	prefix string
+	env    map[string]string
+}
+
+// NewEnvProvider creates a provider reading variables from env.
+func NewEnvProvider(prefix string, env map[string]string) *EnvProvider {
+	return &EnvProvider{prefix: prefix, env: env}
+}
+
+// EnvName returns the variable holding the named secret: the prefix
+// followed by the name in upper case, with characters other than letters
+// and digits replaced by underscores. "github-token" becomes
+// DOT_SECRET_GITHUB_TOKEN with the default prefix.
+func EnvName(prefix, name string) string {
+	return prefix + strings.Map(func(r rune) rune {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return '_'
+		}
+		return unicode.ToUpper(r)
+	}, name)
+}
+
+// Lookup returns the value of the variable named by EnvName.
+func (p *EnvProvider) Lookup(ctx context.Context, name string) (string, error) {
+	if err := validateName(name); err != nil {
+		return "", err
+	}
+	variable := EnvName(p.prefix, name)
+	value, ok := p.env[variable]
+	if !ok {
+		return "", ErrNotFound{Provider: ProviderEnv, Name: name, Hint: "set " + variable}
+	}
+	return value, nil
+}
+
+// PassProvider reads secrets from the pass password store.
+type PassProvider struct {
+	run Runner
+}
+
+// NewPassProvider creates a provider that runs pass.
+func NewPassProvider(run Runner) *PassProvider {
+	return &PassProvider{run: run}
+}
+
+// Lookup runs "pass show <name>" and returns the first line, which by pass
+// convention holds the password.
+func (p *PassProvider) Lookup(ctx context.Context, name string) (string, error) {
+	if err := validateName(name); err != nil {
+		return "", err
+	}
+	out, err := p.run(ctx, "pass", "show", name)
+	if err != nil {
+		return "", fmt.Errorf("read secret %q from pass: %w", name, err)
+	}
+	first, _, _ := strings.Cut(out, "\n")
+	return first, nil
+}
+
+// OnePasswordProvider reads secrets with the 1Password CLI.
+type OnePasswordProvider struct {
+	vault string
+	run   Runner
+}
+
+// NewOnePasswordProvider creates a provider that runs op. Names that are
+// not op:// references are looked up as items in vault.
+func NewOnePasswordProvider(vault string, run Runner) *OnePasswordProvider {
+	return &OnePasswordProvider{vault: vault, run: run}
+}
+
+// Lookup runs "op read" on the secret reference for name. A name starting
+// with op:// is used as the reference; any other name refers to the
+// password field of that item in the configured vault.
+func (p *OnePasswordProvider) Lookup(ctx context.Context, name string) (string, error) {
+	if err := validateName(name); err != nil {
+		return "", err
+	}
+	ref := name
+	if !strings.HasPrefix(name, "op://") {
+		if p.vault == "" {
+			return "", fmt.Errorf("secret %q is not an op:// reference and no 1Password vault is configured (set secrets.vault)", name)
+		}
+		ref = fmt.Sprintf("op://%s/%s/password", p.vault, name)
+	}
+
+	out, err := p.run(ctx, "op", "read", "--no-newline", ref)
+	if err != nil {
+		return "", fmt.Errorf("read secret %q from 1Password: %w", name, err)
+	}
+	return out, nil
+}
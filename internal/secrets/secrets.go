@@ -0,0 +1,136 @@
+// Package secrets resolves named secrets from sources outside the package
+// repository, so templates can reference credentials without committing
+// them.
+//
+// A Provider looks up a secret by name. The environment provider reads
+// variables such as DOT_SECRET_GITHUB_TOKEN; the pass and op providers run
+// the pass password manager and the 1Password CLI.
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Provider names accepted by New.
+const (
+	ProviderEnv         = "env"
+	ProviderPass        = "pass"
+	ProviderOnePassword = "op"
+)
+
+// DefaultEnvPrefix is prepended to secret names by the environment provider.
+const DefaultEnvPrefix = "DOT_SECRET_"
+
+// Providers lists the provider names accepted by New.
+var Providers = []string{ProviderEnv, ProviderPass, ProviderOnePassword}
+
+// Provider looks up secret values by name.
+type Provider interface {
+	// Lookup returns the value of the named secret.
+	Lookup(ctx context.Context, name string) (string, error)
+}
+
+// ErrNotFound indicates that a provider has no secret with the given name.
+type ErrNotFound struct {
+	Provider string
+	Name     string
+	Hint     string
+}
+
+func (e ErrNotFound) Error() string {
+	msg := fmt.Sprintf("secret %q not found in %s", e.Name, e.Provider)
+	if e.Hint != "" {
+		msg += " (" + e.Hint + ")"
+	}
+	return msg
+}
+
+// Config selects and configures a provider.
+type Config struct {
+	// Provider is one of Providers. If empty, ProviderEnv is used.
+	Provider string
+
+	// EnvPrefix is prepended to variable names by the environment
+	// provider. If empty, DefaultEnvPrefix is used.
+	EnvPrefix string
+
+	// Env holds the variables read by the environment provider.
+	Env map[string]string
+
+	// Vault is the 1Password vault holding secrets referenced by item name.
+	Vault string
+
+	// Run executes provider commands. If nil, ExecRunner is used.
+	Run Runner
+}
+
+// New creates the configured provider. Lookups are cached, so each secret
+// is fetched at most once per provider, which avoids repeated passphrase
+// prompts from pass or op.
+func New(cfg Config) (Provider, error) {
+	run := cfg.Run
+	if run == nil {
+		run = ExecRunner
+	}
+
+	var p Provider
+	switch cfg.Provider {
+	case "", ProviderEnv:
+		prefix := cfg.EnvPrefix
+		if prefix == "" {
+			prefix = DefaultEnvPrefix
+		}
+		p = NewEnvProvider(prefix, cfg.Env)
+	case ProviderPass:
+		p = NewPassProvider(run)
+	case ProviderOnePassword:
+		p = NewOnePasswordProvider(cfg.Vault, run)
+	default:
+		return nil, fmt.Errorf("unknown secrets provider %q (must be one of: %s)", cfg.Provider, strings.Join(Providers, ", "))
+	}
+	return NewCache(p), nil
+}
+
+// validateName rejects names that would be parsed as command options.
+func validateName(name string) error {
+	if name == "" {
+		return fmt.Errorf("secret name cannot be empty")
+	}
+	if strings.HasPrefix(name, "-") {
+		return fmt.Errorf("invalid secret name %q: cannot start with '-'", name)
+	}
+	return nil
+}
+
+// Cache memoizes the lookups of another provider. Failed lookups are not
+// cached. It is safe for concurrent use.
+type Cache struct {
+	provider Provider
+	mu       sync.Mutex
+	values   map[string]string
+}
+
+// NewCache wraps provider with a lookup cache.
+func NewCache(provider Provider) *Cache {
+	return &Cache{provider: provider, values: make(map[string]string)}
+}
+
+// Lookup returns the cached value of the named secret, fetching it on
+// first use.
+func (c *Cache) Lookup(ctx context.Context, name string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if value, ok := c.values[name]; ok {
+		return value, nil
+	}
+	value, err := c.provider.Lookup(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	c.values[name] = value
+	return value, nil
+}
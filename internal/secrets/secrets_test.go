@@ -0,0 +1,118 @@
+package secrets_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/secrets"
+)
+
+// fakeRunner records commands and answers them from outputs keyed by the
+// joined command line.
+type fakeRunner struct {
+	outputs map[string]string
+	calls   []string
+}
+
+func (f *fakeRunner) run(ctx context.Context, name string, args ...string) (string, error) {
+	line := strings.Join(append([]string{name}, args...), " ")
+	f.calls = append(f.calls, line)
+	out, ok := f.outputs[line]
+	if !ok {
+		return "", errors.New("exit status 1")
+	}
+	return out, nil
+}
+
+func TestEnvName(t *testing.T) {
+	assert.Equal(t, "DOT_SECRET_GITHUB_TOKEN", secrets.EnvName(secrets.DefaultEnvPrefix, "github-token"))
+	assert.Equal(t, "DOT_SECRET_AWS_WORK_KEY", secrets.EnvName(secrets.DefaultEnvPrefix, "aws/work.key"))
+}
+
+func TestEnvProvider(t *testing.T) {
+	ctx := context.Background()
+	p := secrets.NewEnvProvider("DOT_SECRET_", map[string]string{"DOT_SECRET_GITHUB_TOKEN": "ghp_123"})
+
+	value, err := p.Lookup(ctx, "github-token")
+	require.NoError(t, err)
+	assert.Equal(t, "ghp_123", value)
+
+	_, err = p.Lookup(ctx, "npm-token")
+	var notFound secrets.ErrNotFound
+	require.ErrorAs(t, err, &notFound)
+	assert.Contains(t, err.Error(), "set DOT_SECRET_NPM_TOKEN")
+}
+
+func TestPassProvider(t *testing.T) {
+	ctx := context.Background()
+	runner := &fakeRunner{outputs: map[string]string{
+		"pass show github-token": "ghp_123\nuser: me\n",
+	}}
+	p := secrets.NewPassProvider(runner.run)
+
+	value, err := p.Lookup(ctx, "github-token")
+	require.NoError(t, err)
+	assert.Equal(t, "ghp_123", value)
+
+	_, err = p.Lookup(ctx, "missing")
+	assert.ErrorContains(t, err, `read secret "missing" from pass`)
+}
+
+func TestOnePasswordProvider(t *testing.T) {
+	ctx := context.Background()
+	runner := &fakeRunner{outputs: map[string]string{
+		"op read --no-newline op://Personal/github-token/password": "ghp_123",
+		"op read --no-newline op://Work/npm/credential":            "npm_456",
+	}}
+
+	p := secrets.NewOnePasswordProvider("Personal", runner.run)
+	value, err := p.Lookup(ctx, "github-token")
+	require.NoError(t, err)
+	assert.Equal(t, "ghp_123", value)
+
+	value, err = p.Lookup(ctx, "op://Work/npm/credential")
+	require.NoError(t, err)
+	assert.Equal(t, "npm_456", value)
+
+	_, err = secrets.NewOnePasswordProvider("", runner.run).Lookup(ctx, "github-token")
+	assert.ErrorContains(t, err, "no 1Password vault is configured")
+}
+
+func TestProviders_RejectOptionNames(t *testing.T) {
+	ctx := context.Background()
+	runner := &fakeRunner{}
+
+	_, err := secrets.NewPassProvider(runner.run).Lookup(ctx, "--help")
+	assert.ErrorContains(t, err, "cannot start with '-'")
+	_, err = secrets.NewPassProvider(runner.run).Lookup(ctx, "")
+	assert.Error(t, err)
+	assert.Empty(t, runner.calls)
+}
+
+func TestNew(t *testing.T) {
+	ctx := context.Background()
+	runner := &fakeRunner{outputs: map[string]string{"pass show github-token": "ghp_123\n"}}
+
+	p, err := secrets.New(secrets.Config{Provider: secrets.ProviderPass, Run: runner.run})
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		value, err := p.Lookup(ctx, "github-token")
+		require.NoError(t, err)
+		assert.Equal(t, "ghp_123", value)
+	}
+	assert.Len(t, runner.calls, 1, "lookups are cached")
+
+	p, err = secrets.New(secrets.Config{Env: map[string]string{"MY_TOKEN": "abc"}, EnvPrefix: "MY_"})
+	require.NoError(t, err)
+	value, err := p.Lookup(ctx, "token")
+	require.NoError(t, err)
+	assert.Equal(t, "abc", value)
+
+	_, err = secrets.New(secrets.Config{Provider: "vault"})
+	assert.ErrorContains(t, err, `unknown secrets provider "vault"`)
+}
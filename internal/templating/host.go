@@ -0,0 +1,52 @@
+package templating
+
+import (
+	"os"
+	"os/user"
+	"runtime"
+	"strings"
+)
+
+// HostInfo describes the machine templates are rendered for.
+type HostInfo struct {
+	Hostname string
+	OS       string
+	Arch     string
+	User     string
+	Home     string
+}
+
+// DetectHost collects host information from the running system.
+// Fields that cannot be determined are left empty.
+func DetectHost() HostInfo {
+	info := HostInfo{
+		OS:   runtime.GOOS,
+		Arch: runtime.GOARCH,
+	}
+
+	if hostname, err := os.Hostname(); err == nil {
+		// Use the short name so values files do not depend on the domain
+		info.Hostname, _, _ = strings.Cut(hostname, ".")
+	}
+
+	if u, err := user.Current(); err == nil {
+		info.User = u.Username
+	}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		info.Home = home
+	}
+
+	return info
+}
+
+// Environ returns the current process environment as a map.
+func Environ() map[string]string {
+	env := make(map[string]string)
+	for _, kv := range os.Environ() {
+		if key, value, ok := strings.Cut(kv, "="); ok {
+			env[key] = value
+		}
+	}
+	return env
+}
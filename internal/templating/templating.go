@@ -0,0 +1,205 @@
+// Package templating renders package files written as Go text/template
+// sources into a cache directory so they can be linked like regular files.
+//
+// A package file whose name ends in ".tmpl" is treated as a template. The
+// rendered output is written below the cache directory at the same relative
+// path (without the suffix) and the target link points at the rendered copy
+// instead of the raw source.
+//
+// Templates can read secrets with {{ secret "name" }}. Output that uses a
+// secret is reported by RenderOutput so it can be written readable by the
+// owner only.
+package templating
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/jamesainslie/dot/internal/domain"
+	"github.com/jamesainslie/dot/internal/secrets"
+)
+
+// Suffix marks a package file as a template.
+const Suffix = ".tmpl"
+
+// Data is the value passed to templates during execution.
+//
+// Templates access fields directly, for example {{ .Host.Hostname }},
+// {{ .Env.EDITOR }} or {{ .Values.email }}.
+type Data struct {
+	Host   HostInfo
+	Env    map[string]string
+	Values map[string]any
+}
+
+// Opts configures a Renderer.
+type Opts struct {
+	// CacheDir is the directory rendered output is written to.
+	CacheDir string
+
+	// PackageDir is the package root. Rendered paths mirror the template
+	// location relative to this directory, and values files are read from it.
+	PackageDir string
+
+	// Host describes the machine templates are rendered for.
+	Host HostInfo
+
+	// Env holds environment variables exposed to templates.
+	Env map[string]string
+
+	// Secrets resolves the secret template function. If nil, templates
+	// that call secret fail to render.
+	Secrets secrets.Provider
+}
+
+// Output is the result of rendering a template.
+type Output struct {
+	Content string
+
+	// Secret reports whether the template read a secret, in which case
+	// the content should not be readable by other users.
+	Secret bool
+}
+
+// Renderer renders package templates.
+type Renderer struct {
+	fs   domain.FS
+	opts Opts
+}
+
+// NewRenderer creates a template renderer.
+func NewRenderer(fs domain.FS, opts Opts) *Renderer {
+	if opts.Env == nil {
+		opts.Env = make(map[string]string)
+	}
+	return &Renderer{
+		fs:   fs,
+		opts: opts,
+	}
+}
+
+// IsTemplate reports whether path names a template file.
+func IsTemplate(path string) bool {
+	base := filepath.Base(path)
+	return strings.HasSuffix(base, Suffix) && base != Suffix
+}
+
+// StripSuffix removes the template suffix from path.
+func StripSuffix(path string) string {
+	return strings.TrimSuffix(path, Suffix)
+}
+
+// CacheDir returns the directory rendered output is written to.
+func (r *Renderer) CacheDir() string {
+	return r.opts.CacheDir
+}
+
+// OutputPath returns the cache location for a template file.
+// The result mirrors the template's path relative to the package directory
+// with the template suffix removed.
+func (r *Renderer) OutputPath(templatePath string) (string, error) {
+	rel, err := filepath.Rel(r.opts.PackageDir, templatePath)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("template %s is not under package directory %s", templatePath, r.opts.PackageDir)
+	}
+	return filepath.Join(r.opts.CacheDir, StripSuffix(rel)), nil
+}
+
+// Data assembles the template data from host info, environment and values files.
+func (r *Renderer) Data(ctx context.Context) (Data, error) {
+	values, err := LoadValues(ctx, r.fs, r.opts.PackageDir, r.opts.Host.Hostname)
+	if err != nil {
+		return Data{}, err
+	}
+	return Data{
+		Host:   r.opts.Host,
+		Env:    r.opts.Env,
+		Values: values,
+	}, nil
+}
+
+// Render reads and executes the template at templatePath.
+// Missing map keys are reported as errors rather than rendered as "<no value>".
+func (r *Renderer) Render(ctx context.Context, templatePath string) (string, error) {
+	out, err := r.RenderOutput(ctx, templatePath)
+	return out.Content, err
+}
+
+// RenderOutput is like Render but also reports whether the output
+// contains secrets.
+func (r *Renderer) RenderOutput(ctx context.Context, templatePath string) (Output, error) {
+	data, err := r.Data(ctx)
+	if err != nil {
+		return Output{}, err
+	}
+	return r.render(ctx, templatePath, data)
+}
+
+// RenderWith executes the template at templatePath using the supplied data.
+func (r *Renderer) RenderWith(ctx context.Context, templatePath string, data Data) (string, error) {
+	out, err := r.render(ctx, templatePath, data)
+	return out.Content, err
+}
+
+// RenderOutputWith is like RenderWith but also reports whether the output
+// contains secrets. Callers rendering several templates use it with the
+// result of a single call to Data.
+func (r *Renderer) RenderOutputWith(ctx context.Context, templatePath string, data Data) (Output, error) {
+	return r.render(ctx, templatePath, data)
+}
+
+func (r *Renderer) render(ctx context.Context, templatePath string, data Data) (Output, error) {
+	if ctx.Err() != nil {
+		return Output{}, ctx.Err()
+	}
+
+	src, err := r.fs.ReadFile(ctx, templatePath)
+	if err != nil {
+		return Output{}, fmt.Errorf("read template %s: %w", templatePath, err)
+	}
+
+	var out Output
+	funcs := template.FuncMap{
+		"secret": func(name string) (string, error) {
+			if r.opts.Secrets == nil {
+				return "", fmt.Errorf("no secrets provider configured")
+			}
+			out.Secret = true
+			return r.opts.Secrets.Lookup(ctx, name)
+		},
+	}
+
+	tmpl, err := template.New(filepath.Base(templatePath)).
+		Option("missingkey=error").
+		Funcs(funcs).
+		Parse(string(src))
+	if err != nil {
+		return Output{}, fmt.Errorf("parse template %s: %w", templatePath, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return Output{}, fmt.Errorf("render template %s: %w", templatePath, err)
+	}
+
+	out.Content = buf.String()
+	return out, nil
+}
+
+// IsStale reports whether the output at renderedPath differs from what the
+// template at templatePath renders to now. Missing output counts as stale.
+func (r *Renderer) IsStale(ctx context.Context, templatePath, renderedPath string) (bool, error) {
+	current, err := r.fs.ReadFile(ctx, renderedPath)
+	if err != nil {
+		return true, nil
+	}
+	expected, err := r.Render(ctx, templatePath)
+	if err != nil {
+		return false, err
+	}
+	return string(current) != expected, nil
+}
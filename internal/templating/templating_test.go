@@ -0,0 +1,136 @@
+package templating_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/internal/secrets"
+	"github.com/jamesainslie/dot/internal/templating"
+)
+
+func newTestRenderer(t *testing.T) (*templating.Renderer, *adapters.MemFS) {
+	t.Helper()
+	fs := adapters.NewMemFS()
+	r := templating.NewRenderer(fs, templating.Opts{
+		CacheDir:   "/cache",
+		PackageDir: "/packages",
+		Host:       templating.HostInfo{Hostname: "laptop", OS: "linux"},
+		Env:        map[string]string{"EDITOR": "vim"},
+	})
+	return r, fs
+}
+
+func TestIsTemplate(t *testing.T) {
+	assert.True(t, templating.IsTemplate("/packages/git/dot-gitconfig.tmpl"))
+	assert.False(t, templating.IsTemplate("/packages/git/dot-gitconfig"))
+	assert.False(t, templating.IsTemplate("/packages/git/.tmpl"))
+}
+
+func TestRenderer_OutputPath(t *testing.T) {
+	r, _ := newTestRenderer(t)
+
+	out, err := r.OutputPath("/packages/git/dot-gitconfig.tmpl")
+	require.NoError(t, err)
+	assert.Equal(t, "/cache/git/dot-gitconfig", out)
+
+	_, err = r.OutputPath("/elsewhere/file.tmpl")
+	assert.Error(t, err)
+}
+
+func TestRenderer_Render(t *testing.T) {
+	ctx := context.Background()
+	r, fs := newTestRenderer(t)
+
+	require.NoError(t, fs.MkdirAll(ctx, "/packages/git", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/packages/.dot-values", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/packages/.dot-values/default.yaml", []byte("email: me@example.com\nname: Me\n"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/packages/.dot-values/laptop.yaml", []byte("email: me@laptop.example.com\n"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/packages/git/dot-gitconfig.tmpl",
+		[]byte("{{ .Values.name }} <{{ .Values.email }}> on {{ .Host.Hostname }}/{{ .Host.OS }} using {{ .Env.EDITOR }}"), 0644))
+
+	out, err := r.Render(ctx, "/packages/git/dot-gitconfig.tmpl")
+	require.NoError(t, err)
+	assert.Equal(t, "Me <me@laptop.example.com> on laptop/linux using vim", out)
+}
+
+func TestRenderer_Render_MissingKey(t *testing.T) {
+	ctx := context.Background()
+	r, fs := newTestRenderer(t)
+
+	require.NoError(t, fs.MkdirAll(ctx, "/packages/git", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/packages/git/cfg.tmpl", []byte("{{ .Values.missing }}"), 0644))
+
+	_, err := r.Render(ctx, "/packages/git/cfg.tmpl")
+	assert.Error(t, err)
+}
+
+func TestRenderer_RenderOutput_Secret(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	r := templating.NewRenderer(fs, templating.Opts{
+		CacheDir:   "/cache",
+		PackageDir: "/packages",
+		Secrets:    secrets.NewEnvProvider("DOT_SECRET_", map[string]string{"DOT_SECRET_GITHUB_TOKEN": "ghp_123"}),
+	})
+
+	require.NoError(t, fs.MkdirAll(ctx, "/packages/gh", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/packages/gh/hosts.yml.tmpl", []byte(`oauth_token: {{ secret "github-token" }}`), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/packages/gh/config.yml.tmpl", []byte("editor: vim"), 0644))
+
+	out, err := r.RenderOutput(ctx, "/packages/gh/hosts.yml.tmpl")
+	require.NoError(t, err)
+	assert.Equal(t, "oauth_token: ghp_123", out.Content)
+	assert.True(t, out.Secret)
+
+	out, err = r.RenderOutput(ctx, "/packages/gh/config.yml.tmpl")
+	require.NoError(t, err)
+	assert.False(t, out.Secret)
+
+	require.NoError(t, fs.WriteFile(ctx, "/packages/gh/npmrc.tmpl", []byte(`{{ secret "npm-token" }}`), 0644))
+	_, err = r.RenderOutput(ctx, "/packages/gh/npmrc.tmpl")
+	assert.ErrorContains(t, err, "DOT_SECRET_NPM_TOKEN")
+}
+
+func TestRenderer_Render_SecretWithoutProvider(t *testing.T) {
+	ctx := context.Background()
+	r, fs := newTestRenderer(t)
+
+	require.NoError(t, fs.MkdirAll(ctx, "/packages/gh", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/packages/gh/hosts.yml.tmpl", []byte(`{{ secret "github-token" }}`), 0644))
+
+	_, err := r.Render(ctx, "/packages/gh/hosts.yml.tmpl")
+	assert.ErrorContains(t, err, "no secrets provider configured")
+}
+
+func TestRenderer_IsStale(t *testing.T) {
+	ctx := context.Background()
+	r, fs := newTestRenderer(t)
+
+	require.NoError(t, fs.MkdirAll(ctx, "/packages/sh", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/cache/sh", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/packages/sh/rc.tmpl", []byte("host={{ .Host.Hostname }}"), 0644))
+
+	stale, err := r.IsStale(ctx, "/packages/sh/rc.tmpl", "/cache/sh/rc")
+	require.NoError(t, err)
+	assert.True(t, stale, "missing output is stale")
+
+	require.NoError(t, fs.WriteFile(ctx, "/cache/sh/rc", []byte("host=laptop"), 0644))
+	stale, err = r.IsStale(ctx, "/packages/sh/rc.tmpl", "/cache/sh/rc")
+	require.NoError(t, err)
+	assert.False(t, stale)
+
+	require.NoError(t, fs.WriteFile(ctx, "/packages/sh/rc.tmpl", []byte("host={{ .Host.Hostname }}!"), 0644))
+	stale, err = r.IsStale(ctx, "/packages/sh/rc.tmpl", "/cache/sh/rc")
+	require.NoError(t, err)
+	assert.True(t, stale)
+}
+
+func TestLoadValues_NoFiles(t *testing.T) {
+	values, err := templating.LoadValues(context.Background(), adapters.NewMemFS(), "/packages", "laptop")
+	require.NoError(t, err)
+	assert.Empty(t, values)
+}
@@ -0,0 +1,63 @@
+package templating
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jamesainslie/dot/internal/domain"
+)
+
+// ValuesDir is the directory inside the package directory holding values files.
+const ValuesDir = ".dot-values"
+
+// defaultValuesFile holds values shared by every host.
+const defaultValuesFile = "default.yaml"
+
+// LoadValues reads template values for a host.
+//
+// Values are read from <packageDir>/.dot-values/default.yaml and then
+// <packageDir>/.dot-values/<hostname>.yaml, with host values overriding
+// defaults key by key. Missing files are not an error.
+func LoadValues(ctx context.Context, fs domain.FS, packageDir, hostname string) (map[string]any, error) {
+	values := make(map[string]any)
+
+	files := []string{defaultValuesFile}
+	if hostname != "" {
+		files = append(files, hostname+".yaml")
+	}
+
+	for _, name := range files {
+		path := filepath.Join(packageDir, ValuesDir, name)
+		layer, err := readValuesFile(ctx, fs, path)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range layer {
+			values[k] = v
+		}
+	}
+
+	return values, nil
+}
+
+// readValuesFile parses a single YAML values file.
+func readValuesFile(ctx context.Context, fs domain.FS, path string) (map[string]any, error) {
+	data, err := fs.ReadFile(ctx, path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read values file %s: %w", path, err)
+	}
+
+	var values map[string]any
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("parse values file %s: %w", path, err)
+	}
+	return values, nil
+}
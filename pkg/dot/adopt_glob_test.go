@@ -0,0 +1,157 @@
+package dot_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+func TestAdopt_GlobPattern(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+
+	packageDir := "/test/packages"
+	targetDir := "/test/target"
+	nvimDir := targetDir + "/.config/nvim"
+
+	require.NoError(t, fs.MkdirAll(ctx, packageDir, 0755))
+	require.NoError(t, fs.MkdirAll(ctx, nvimDir+"/lua/plugins", 0755))
+	require.NoError(t, fs.WriteFile(ctx, nvimDir+"/init.lua", []byte("init"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, nvimDir+"/lua/plugins/lsp.lua", []byte("lsp"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, nvimDir+"/.luarc.json", []byte("{}"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, targetDir+"/.config/starship.toml", []byte("prompt"), 0644))
+	require.NoError(t, fs.Symlink(ctx, "/elsewhere/managed.lua", nvimDir+"/managed.lua"))
+
+	client, err := dot.NewClient(dot.Config{
+		PackageDir: packageDir,
+		TargetDir:  targetDir,
+		FS:         fs,
+		Logger:     adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+
+	plan, err := client.PlanAdopt(ctx, []string{"~/.config/nvim/**"}, "nvim")
+	require.NoError(t, err)
+	var moved []string
+	for _, op := range plan.Operations {
+		if move, ok := op.(dot.FileMove); ok {
+			moved = append(moved, move.Source.String())
+		}
+	}
+	assert.ElementsMatch(t, []string{
+		nvimDir + "/init.lua",
+		nvimDir + "/lua/plugins/lsp.lua",
+		nvimDir + "/.luarc.json",
+	}, moved, "symlinks and files outside the pattern are not adopted")
+
+	require.NoError(t, client.Adopt(ctx, []string{"~/.config/nvim/**"}, "nvim"))
+
+	pkgDir := packageDir + "/nvim"
+	for rel, content := range map[string]string{
+		"init.lua":            "init",
+		"lua/plugins/lsp.lua": "lsp",
+		"dot-luarc.json":      "{}",
+	} {
+		data, err := fs.ReadFile(ctx, pkgDir+"/"+rel)
+		require.NoError(t, err, rel)
+		assert.Equal(t, content, string(data))
+	}
+
+	link, err := fs.ReadLink(ctx, nvimDir+"/lua/plugins/lsp.lua")
+	require.NoError(t, err)
+	assert.Equal(t, pkgDir+"/lua/plugins/lsp.lua", link)
+
+	isLink, _ := fs.IsSymlink(ctx, nvimDir)
+	assert.False(t, isLink, "the directory itself stays in place")
+	assert.True(t, fs.Exists(ctx, targetDir+"/.config/starship.toml"))
+}
+
+func TestAdopt_GlobPatternSkipsDirectories(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+
+	targetDir := "/test/target"
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages", 0755))
+	for _, file := range []string{"app/config.toml", "app/node_modules/dep/package.json", "app/.cache/index", ".cache/tool/settings"} {
+		require.NoError(t, fs.MkdirAll(ctx, filepath.Dir(targetDir+"/"+file), 0755))
+		require.NoError(t, fs.WriteFile(ctx, targetDir+"/"+file, []byte("x"), 0644))
+	}
+
+	client, err := dot.NewClient(dot.Config{
+		PackageDir: "/test/packages",
+		TargetDir:  targetDir,
+		FS:         fs,
+		Logger:     adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+
+	moved := func(pattern string) []string {
+		opts := dot.AdoptOptions{SkipPatterns: dot.DefaultScanSkipPatterns()}
+		plan, err := client.PlanAdoptWithOptions(ctx, opts, []string{pattern}, "app")
+		require.NoError(t, err)
+		var sources []string
+		for _, op := range plan.Operations {
+			if move, ok := op.(dot.FileMove); ok {
+				sources = append(sources, move.Source.String())
+			}
+		}
+		return sources
+	}
+
+	assert.Equal(t, []string{targetDir + "/app/config.toml"}, moved("app/**"))
+	assert.Equal(t, []string{targetDir + "/.cache/tool/settings"}, moved(".cache/tool/**"),
+		"the directory a pattern starts at is not skipped")
+}
+
+func TestAdopt_GlobPatternSingleLevel(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+
+	targetDir := "/test/target"
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, targetDir+"/.config/git", 0755))
+	require.NoError(t, fs.WriteFile(ctx, targetDir+"/.gitconfig", []byte("a"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, targetDir+"/.gitignore", []byte("b"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, targetDir+"/.config/git/.gitmessage", []byte("c"), 0644))
+
+	client, err := dot.NewClient(dot.Config{
+		PackageDir: "/test/packages",
+		TargetDir:  targetDir,
+		FS:         fs,
+		Logger:     adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+
+	plan, err := client.PlanAdopt(ctx, []string{".git*"}, "git")
+	require.NoError(t, err)
+	var moved []string
+	for _, op := range plan.Operations {
+		if move, ok := op.(dot.FileMove); ok {
+			moved = append(moved, move.Dest.String())
+		}
+	}
+	assert.Equal(t, []string{"/test/packages/git/dot-gitconfig", "/test/packages/git/dot-gitignore"}, moved)
+
+	// "FILE/**" matches the file itself, as used by dot adopt --recursive
+	plan, err = client.PlanAdopt(ctx, []string{".gitconfig/**"}, "git")
+	require.NoError(t, err)
+	moved = nil
+	for _, op := range plan.Operations {
+		if move, ok := op.(dot.FileMove); ok {
+			moved = append(moved, move.Dest.String())
+		}
+	}
+	assert.Equal(t, []string{"/test/packages/git/dot-gitconfig"}, moved)
+
+	_, err = client.PlanAdopt(ctx, []string{".config/*.toml"}, "git")
+	assert.ErrorContains(t, err, `no files match ".config/*.toml"`)
+
+	_, err = client.PlanAdopt(ctx, []string{".config/[git"}, "git")
+	assert.ErrorContains(t, err, "invalid pattern")
+}
@@ -0,0 +1,92 @@
+package dot_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// setupHardlinkTest creates a maildir message in the target directory
+// that is hard linked from an archive folder.
+func setupHardlinkTest(t *testing.T) (*adapters.MemFS, *dot.Client) {
+	t.Helper()
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target/.mail/inbox", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/archive", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/target/.mail/inbox/msg", []byte("hello"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/test/target/.mail/inbox/other", []byte("other"), 0644))
+	require.NoError(t, fs.Link(ctx, "/test/target/.mail/inbox/msg", "/test/archive/msg"))
+
+	client, err := dot.NewClient(dot.Config{
+		PackageDir: "/test/packages",
+		TargetDir:  "/test/target",
+		FS:         fs,
+		Logger:     adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+	return fs, client
+}
+
+func TestAdopt_WarnsAboutHardlinks(t *testing.T) {
+	_, client := setupHardlinkTest(t)
+	ctx := context.Background()
+
+	plan, err := client.PlanAdopt(ctx, []string{".mail/**"}, "mail")
+	require.NoError(t, err)
+	require.Len(t, plan.Metadata.Warnings, 1)
+	assert.Contains(t, plan.Metadata.Warnings[0].Message, "/test/target/.mail/inbox/msg has 2 hard links")
+	assert.Equal(t, "2", plan.Metadata.Warnings[0].Context["links"])
+
+	var moves int
+	for _, op := range plan.Operations {
+		if op.Kind() == dot.OpKindFileMove {
+			moves++
+		}
+	}
+	assert.Equal(t, 2, moves, "hard-linked files are still moved by default")
+}
+
+func TestAdopt_CopyHardlinks(t *testing.T) {
+	fs, client := setupHardlinkTest(t)
+	ctx := context.Background()
+	opts := dot.AdoptOptions{CopyHardlinks: true}
+
+	plan, err := client.PlanAdoptWithOptions(ctx, opts, []string{".mail/**"}, "mail")
+	require.NoError(t, err)
+	require.Len(t, plan.Metadata.Warnings, 1)
+	var kinds []dot.OperationKind
+	for _, op := range plan.Operations {
+		kinds = append(kinds, op.Kind())
+	}
+	assert.Contains(t, kinds, dot.OpKindFileBackup)
+	assert.Contains(t, kinds, dot.OpKindFileStash)
+
+	require.NoError(t, client.AdoptWithOptions(ctx, opts, []string{".mail/**"}, "mail"))
+
+	target, err := fs.ReadLink(ctx, "/test/target/.mail/inbox/msg")
+	require.NoError(t, err)
+	assert.Equal(t, "/test/packages/mail/inbox/msg", target)
+	data, err := fs.ReadFile(ctx, "/test/packages/mail/inbox/msg")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+
+	// The archive keeps the original file, no longer shared with the package
+	count, err := fs.LinkCount(ctx, "/test/archive/msg")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), count)
+	count, err = fs.LinkCount(ctx, "/test/packages/mail/inbox/msg")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), count)
+
+	target, err = fs.ReadLink(ctx, "/test/target/.mail/inbox/other")
+	require.NoError(t, err)
+	assert.Equal(t, "/test/packages/mail/inbox/other", target)
+}
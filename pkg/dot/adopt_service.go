@@ -4,21 +4,45 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"sort"
+	"strings"
 
+	"github.com/jamesainslie/dot/internal/domain"
 	"github.com/jamesainslie/dot/internal/executor"
 	"github.com/jamesainslie/dot/internal/manifest"
 	"github.com/jamesainslie/dot/internal/scanner"
 )
 
+// AdoptOptions configures adopt behavior.
+type AdoptOptions struct {
+	// CopyHardlinks copies files that have other hard links into the
+	// package instead of moving them. The adopted name is removed, with a
+	// backup kept in the backup directory, and the file stays in place
+	// under its other names.
+	CopyHardlinks bool
+
+	// SkipPatterns are directories that glob patterns do not descend
+	// into, matched as in ScanConfig.SkipPatterns. The directory a
+	// pattern starts at is never skipped, so "~/.cache/app/**" still
+	// adopts from inside .cache. DefaultScanSkipPatterns lists common
+	// cloud-synced and volatile directories.
+	SkipPatterns []string
+}
+
 // AdoptService handles file adoption operations.
 type AdoptService struct {
-	fs          FS
-	logger      Logger
-	executor    *executor.Executor
-	manifestSvc *ManifestService
-	packageDir  string
-	targetDir   string
-	dryRun      bool
+	fs           FS
+	logger       Logger
+	executor     *executor.Executor
+	manifestSvc  *ManifestService
+	packageDir   string
+	targetDir    string
+	backupDir    string
+	dryRun       bool
+	specialFiles SpecialFilePolicy
+
+	// metrics records the standard metrics of the plans executed.
+	metrics *commandMetrics
 }
 
 // newAdoptService creates a new adopt service.
@@ -29,25 +53,38 @@ func newAdoptService(
 	manifestSvc *ManifestService,
 	packageDir string,
 	targetDir string,
+	backupDir string,
 	dryRun bool,
+	specialFiles SpecialFilePolicy,
 ) *AdoptService {
 	return &AdoptService{
-		fs:          fs,
-		logger:      logger,
-		executor:    exec,
-		manifestSvc: manifestSvc,
-		packageDir:  packageDir,
-		targetDir:   targetDir,
-		dryRun:      dryRun,
+		fs:           fs,
+		logger:       logger,
+		executor:     exec,
+		manifestSvc:  manifestSvc,
+		packageDir:   packageDir,
+		targetDir:    targetDir,
+		backupDir:    backupDir,
+		dryRun:       dryRun,
+		specialFiles: specialFiles,
 	}
 }
 
 // Adopt moves existing files from target into package then creates symlinks.
 func (s *AdoptService) Adopt(ctx context.Context, files []string, pkg string) error {
-	plan, err := s.PlanAdopt(ctx, files, pkg)
+	return s.AdoptWithOptions(ctx, AdoptOptions{}, files, pkg)
+}
+
+// AdoptWithOptions adopts files like Adopt with the given options.
+func (s *AdoptService) AdoptWithOptions(ctx context.Context, opts AdoptOptions, files []string, pkg string) error {
+	plan, err := s.PlanAdoptWithOptions(ctx, opts, files, pkg)
 	if err != nil {
 		return err
 	}
+	s.metrics.recordPlan("adopt", plan)
+	for _, w := range plan.Metadata.Warnings {
+		s.logger.Warn(ctx, "plan_warning", "message", w.Message)
+	}
 	if len(plan.Operations) == 0 {
 		s.logger.Info(ctx, "nothing_to_adopt")
 		return nil
@@ -56,7 +93,7 @@ func (s *AdoptService) Adopt(ctx context.Context, files []string, pkg string) er
 		s.logger.Info(ctx, "dry_run_plan", "operations", len(plan.Operations))
 		return nil
 	}
-	result := s.executor.Execute(ctx, plan)
+	result := s.metrics.execute(ctx, s.executor, "adopt", plan)
 	if !result.IsOk() {
 		return result.UnwrapErr()
 	}
@@ -76,7 +113,32 @@ func (s *AdoptService) Adopt(ctx context.Context, files []string, pkg string) er
 }
 
 // PlanAdopt computes the execution plan for adopting files.
+//
+// Files are paths relative to the target directory; a leading "~/" also
+// refers to the target directory. Entries containing glob metacharacters
+// are expanded to the regular files they match, where "**" matches any
+// number of directories. Like the contents of an adopted directory,
+// matched files keep their path below the pattern's leading literal
+// directories, so ".config/nvim/**" adopts ".config/nvim/lua/init.lua" as
+// "lua/init.lua", but each file is linked individually.
+//
+// Sockets, named pipes, and devices cannot be moved into a package. With
+// SpecialFilesSkip they are left in place with a plan warning, and a
+// directory containing one has its regular files adopted individually
+// instead of being replaced by a link. With SpecialFilesError planning
+// fails with ErrSpecialFile.
 func (s *AdoptService) PlanAdopt(ctx context.Context, files []string, pkg string) (Plan, error) {
+	return s.PlanAdoptWithOptions(ctx, AdoptOptions{}, files, pkg)
+}
+
+// PlanAdoptWithOptions computes the execution plan for adopting files
+// with the given options.
+//
+// Moving a file that has other hard links, such as a message in a
+// maildir, separates the adopted copy from its other names once the
+// package is committed or checked out again. Such files are moved with a
+// plan warning, or copied when opts.CopyHardlinks is set.
+func (s *AdoptService) PlanAdoptWithOptions(ctx context.Context, opts AdoptOptions, files []string, pkg string) (Plan, error) {
 	packagePathResult := NewPackagePath(s.packageDir)
 	if !packagePathResult.IsOk() {
 		return Plan{}, packagePathResult.UnwrapErr()
@@ -100,7 +162,24 @@ func (s *AdoptService) PlanAdopt(ctx context.Context, files []string, pkg string
 		operations = append(operations, NewDirCreate(dirID, pkgPathResult.Unwrap()))
 	}
 
-	for _, file := range files {
+	sources, err := s.expandAdoptPatterns(ctx, files, opts.SkipPatterns)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	var warnings []WarningInfo
+	createdDirs := make(map[string]bool)
+	for _, source := range sources {
+		file := source.path
+		if source.matched {
+			matchOps, err := s.createMatchedFileAdoptOperations(ctx, source, pkgPath, createdDirs)
+			if err != nil {
+				return Plan{}, err
+			}
+			operations = append(operations, matchOps...)
+			continue
+		}
+
 		sourceFile := filepath.Join(s.targetDir, file)
 		if !s.fs.Exists(ctx, sourceFile) {
 			return Plan{}, ErrSourceNotFound{Path: sourceFile}
@@ -113,6 +192,25 @@ func (s *AdoptService) PlanAdopt(ctx context.Context, files []string, pkg string
 		}
 
 		if isDir {
+			special, err := s.findSpecialFiles(ctx, sourceFile)
+			if err != nil {
+				return Plan{}, err
+			}
+			if len(special) > 0 {
+				if s.specialFiles == SpecialFilesError {
+					return Plan{}, special[0]
+				}
+				fileOps, err := s.createRegularFileAdoptOperations(ctx, file, pkgPath, createdDirs)
+				if err != nil {
+					return Plan{}, err
+				}
+				operations = append(operations, fileOps...)
+				for _, sf := range special {
+					warnings = append(warnings, specialFileWarning(pkg, sf))
+				}
+				continue
+			}
+
 			// For directories: move CONTENTS into package root (flat structure)
 			dirOps, err := s.createDirectoryAdoptOperations(ctx, sourceFile, pkgPath, file)
 			if err != nil {
@@ -120,6 +218,19 @@ func (s *AdoptService) PlanAdopt(ctx context.Context, files []string, pkg string
 			}
 			operations = append(operations, dirOps...)
 		} else {
+			info, err := s.fs.Stat(ctx, sourceFile)
+			if err != nil {
+				return Plan{}, fmt.Errorf("failed to stat %s: %w", sourceFile, err)
+			}
+			if kind := SpecialFileKind(info.Mode()); kind != "" {
+				sf := ErrSpecialFile{Path: sourceFile, Kind: kind}
+				if s.specialFiles == SpecialFilesError {
+					return Plan{}, sf
+				}
+				warnings = append(warnings, specialFileWarning(pkg, sf))
+				continue
+			}
+
 			// For files: move file into package directory with translation
 			adoptedName := scanner.UntranslateDotfile(filepath.Base(file))
 			destFile := filepath.Join(pkgPath, adoptedName)
@@ -145,6 +256,12 @@ func (s *AdoptService) PlanAdopt(ctx context.Context, files []string, pkg string
 		}
 	}
 
+	operations, hardlinkWarnings, err := s.applyHardlinkPolicy(ctx, operations, pkg, opts)
+	if err != nil {
+		return Plan{}, err
+	}
+	warnings = append(warnings, hardlinkWarnings...)
+
 	// Build PackageOperations map for manifest tracking
 	packageOps := make(map[string][]OperationID)
 	opIDs := make([]OperationID, 0, len(operations))
@@ -159,10 +276,389 @@ func (s *AdoptService) PlanAdopt(ctx context.Context, files []string, pkg string
 		Metadata: PlanMetadata{
 			PackageCount:   1,
 			OperationCount: len(operations),
+			Warnings:       warnings,
+			Replacements:   s.adoptReplacements(ctx, operations),
 		},
 	}, nil
 }
 
+// adoptReplacements returns the package files the adopted files of
+// operations are moved or copied over.
+func (s *AdoptService) adoptReplacements(ctx context.Context, operations []Operation) []ReplacementInfo {
+	var replacements []ReplacementInfo
+	for _, op := range operations {
+		var source, dest string
+		switch op := op.(type) {
+		case FileMove:
+			source, dest = op.Source.String(), op.Dest.String()
+		case FileBackup:
+			source, dest = op.Source.String(), op.Backup.String()
+		default:
+			continue
+		}
+		if !s.fs.Exists(ctx, dest) {
+			continue
+		}
+		if isDir, err := s.fs.IsDir(ctx, dest); err != nil || isDir {
+			continue
+		}
+		replacements = append(replacements, ReplacementInfo{Path: dest, Replacement: source, Policy: "adopt"})
+	}
+	return replacements
+}
+
+// applyHardlinkPolicy checks the source of every file move for other hard
+// links, recording a warning for each one found. With opts.CopyHardlinks
+// the move is replaced by a copy into the package followed by stashing
+// the adopted name in the backup directory. Filesystems that cannot count
+// hard links leave the operations unchanged.
+func (s *AdoptService) applyHardlinkPolicy(ctx context.Context, operations []Operation, pkg string, opts AdoptOptions) ([]Operation, []WarningInfo, error) {
+	hfs, ok := s.fs.(HardlinkFS)
+	if !ok {
+		return operations, nil, nil
+	}
+
+	var warnings []WarningInfo
+	result := make([]Operation, 0, len(operations))
+	for _, op := range operations {
+		move, ok := op.(FileMove)
+		if !ok {
+			result = append(result, op)
+			continue
+		}
+		source := move.Source.String()
+		count, err := hfs.LinkCount(ctx, source)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to count hard links of %s: %w", source, err)
+		}
+		if count <= 1 {
+			result = append(result, op)
+			continue
+		}
+
+		warning := WarningInfo{
+			Severity: "caution",
+			Context: map[string]string{
+				"package": pkg,
+				"path":    source,
+				"links":   fmt.Sprint(count),
+			},
+		}
+		if !opts.CopyHardlinks {
+			warning.Message = fmt.Sprintf("%s has %d hard links; moving it into the package can break its link with the other names", source, count)
+			warnings = append(warnings, warning)
+			result = append(result, op)
+			continue
+		}
+		warning.Message = fmt.Sprintf("%s has %d hard links; copying it into the package and leaving the other names in place", source, count)
+		warnings = append(warnings, warning)
+
+		data, err := s.fs.ReadFile(ctx, source)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s: %w", source, err)
+		}
+		sourceResult := NewFilePath(source)
+		if !sourceResult.IsOk() {
+			return nil, nil, sourceResult.UnwrapErr()
+		}
+		backupDirResult := NewFilePath(s.backupDir)
+		if !backupDirResult.IsOk() {
+			return nil, nil, backupDirResult.UnwrapErr()
+		}
+		rel, err := filepath.Rel(s.targetDir, source)
+		if err != nil {
+			return nil, nil, err
+		}
+		result = append(result,
+			NewFileBackup(OperationID(fmt.Sprintf("adopt-copy-%s", rel)), sourceResult.Unwrap(), move.Dest),
+			NewFileStash(OperationID(fmt.Sprintf("adopt-stash-%s", rel)), sourceResult.Unwrap(), backupDirResult.Unwrap(), domain.HashContent(data)),
+		)
+	}
+	return result, warnings, nil
+}
+
+// findSpecialFiles returns the sockets, named pipes, and devices below dir.
+func (s *AdoptService) findSpecialFiles(ctx context.Context, dir string) ([]ErrSpecialFile, error) {
+	entries, err := s.fs.ReadDir(ctx, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	var special []ErrSpecialFile
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if entry.IsDir() {
+			found, err := s.findSpecialFiles(ctx, path)
+			if err != nil {
+				return nil, err
+			}
+			special = append(special, found...)
+			continue
+		}
+		if kind := SpecialFileKind(entry.Type()); kind != "" {
+			special = append(special, ErrSpecialFile{Path: path, Kind: kind})
+		}
+	}
+	return special, nil
+}
+
+// createRegularFileAdoptOperations creates operations to adopt each
+// regular file below the directory dir individually, keeping its path
+// relative to dir. The directory itself stays in place.
+func (s *AdoptService) createRegularFileAdoptOperations(ctx context.Context, dir, pkgPath string, createdDirs map[string]bool) ([]Operation, error) {
+	_, matches, err := s.globTarget(ctx, filepath.Join(dir, "**"), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var operations []Operation
+	for _, match := range matches {
+		pkgRel, err := filepath.Rel(dir, match)
+		if err != nil {
+			return nil, err
+		}
+		source := adoptSource{path: match, matched: true, pkgRel: pkgRel}
+		fileOps, err := s.createMatchedFileAdoptOperations(ctx, source, pkgPath, createdDirs)
+		if err != nil {
+			return nil, err
+		}
+		operations = append(operations, fileOps...)
+	}
+	return operations, nil
+}
+
+// specialFileWarning describes a special file left out of a plan.
+func specialFileWarning(pkg string, sf ErrSpecialFile) WarningInfo {
+	return WarningInfo{
+		Message:  fmt.Sprintf("skipped %s %s", sf.Kind, sf.Path),
+		Severity: "caution",
+		Context: map[string]string{
+			"package": pkg,
+			"path":    sf.Path,
+			"kind":    sf.Kind,
+		},
+	}
+}
+
+// adoptSource is a file to adopt, relative to the target directory.
+type adoptSource struct {
+	path string
+	// matched is set for files found by a glob pattern, which are placed
+	// at pkgRel inside the package.
+	matched bool
+	pkgRel  string
+}
+
+// expandAdoptPatterns resolves adopt arguments to sources, expanding glob
+// patterns without descending into directories matching skipPatterns. A
+// pattern that matches nothing is an error.
+func (s *AdoptService) expandAdoptPatterns(ctx context.Context, files []string, skipPatterns []string) ([]adoptSource, error) {
+	sources := make([]adoptSource, 0, len(files))
+	seen := make(map[string]bool)
+	for _, file := range files {
+		file = s.targetRelative(file)
+		if !isAdoptPattern(file) {
+			sources = append(sources, adoptSource{path: file})
+			continue
+		}
+
+		root, matches, err := s.globTarget(ctx, file, skipPatterns)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %q in %s", file, s.targetDir)
+		}
+		for _, match := range matches {
+			if seen[match] {
+				continue
+			}
+			seen[match] = true
+			pkgRel, err := filepath.Rel(root, match)
+			if err != nil || pkgRel == "." {
+				// "FILE/**" matched FILE itself
+				pkgRel = filepath.Base(match)
+			}
+			sources = append(sources, adoptSource{path: match, matched: true, pkgRel: pkgRel})
+		}
+	}
+	return sources, nil
+}
+
+// targetRelative strips a leading "~/" or the target directory from an
+// adopt argument.
+func (s *AdoptService) targetRelative(file string) string {
+	if file == "~" {
+		return "."
+	}
+	if rest, ok := strings.CutPrefix(file, "~/"); ok {
+		return rest
+	}
+	if filepath.IsAbs(file) {
+		if rel, err := filepath.Rel(s.targetDir, file); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+	return file
+}
+
+// isAdoptPattern reports whether an adopt argument is a glob pattern.
+func isAdoptPattern(file string) bool {
+	return strings.ContainsAny(file, "*?[")
+}
+
+// globTarget returns the regular files below the target directory whose
+// relative path matches pattern, in lexical order, and the literal
+// directory the pattern starts with. Symlinks are skipped so that links
+// already managed by dot are never adopted, and so are directories below
+// the starting one that match skipPatterns.
+func (s *AdoptService) globTarget(ctx context.Context, pattern string, skipPatterns []string) (string, []string, error) {
+	parts := splitPath(filepath.Clean(pattern))
+	for _, part := range parts {
+		if part != "**" {
+			if _, err := filepath.Match(part, ""); err != nil {
+				return "", nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+			}
+		}
+	}
+
+	// Start walking at the longest literal prefix of the pattern
+	literal := 0
+	for literal < len(parts) && !isAdoptPattern(parts[literal]) {
+		literal++
+	}
+	root := filepath.Join(parts[:literal]...)
+	rootPath := filepath.Join(s.targetDir, root)
+	if isDir, err := s.fs.IsDir(ctx, rootPath); err != nil || !isDir {
+		// "FILE/**" matches FILE itself
+		isLink, _ := s.fs.IsSymlink(ctx, rootPath)
+		if err == nil && !isLink && s.fs.Exists(ctx, rootPath) && matchPathParts(parts, splitPath(root)) {
+			return root, []string{root}, nil
+		}
+		return root, nil, nil
+	}
+
+	var matches []string
+	var walk func(rel string) error
+	walk = func(rel string) error {
+		entries, err := s.fs.ReadDir(ctx, filepath.Join(s.targetDir, rel))
+		if err != nil {
+			return fmt.Errorf("failed to read directory: %w", err)
+		}
+		for _, entry := range entries {
+			entryRel := filepath.Join(rel, entry.Name())
+			switch {
+			case entry.IsDir():
+				if !matchPathPrefix(parts, splitPath(entryRel)) || matchesSkipPattern(entryRel, skipPatterns) {
+					continue
+				}
+				if err := walk(entryRel); err != nil {
+					return err
+				}
+			case entry.Type().IsRegular():
+				if matchPathParts(parts, splitPath(entryRel)) {
+					matches = append(matches, entryRel)
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk(root); err != nil {
+		return "", nil, err
+	}
+	sort.Strings(matches)
+	return root, matches, nil
+}
+
+// matchPathParts matches path components against pattern components. A
+// "**" component matches zero or more path components.
+func matchPathParts(pattern, path []string) bool {
+	if len(pattern) == 0 {
+		return len(path) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(path); i++ {
+			if matchPathParts(pattern[1:], path[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(path) == 0 {
+		return false
+	}
+	if ok, _ := filepath.Match(pattern[0], path[0]); !ok {
+		return false
+	}
+	return matchPathParts(pattern[1:], path[1:])
+}
+
+// matchPathPrefix reports whether files below a directory with the given
+// path components can match the pattern, so the walk can skip the rest.
+func matchPathPrefix(pattern, dir []string) bool {
+	for i, part := range dir {
+		if i >= len(pattern) {
+			return false
+		}
+		if pattern[i] == "**" {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern[i], part); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// createMatchedFileAdoptOperations creates operations to adopt a file
+// matched by a glob pattern. Package directories already planned are
+// recorded in createdDirs.
+func (s *AdoptService) createMatchedFileAdoptOperations(ctx context.Context, source adoptSource, pkgPath string, createdDirs map[string]bool) ([]Operation, error) {
+	var operations []Operation
+
+	file := source.path
+	translated := translatePathComponents(source.pkgRel)
+	var parts []string
+	if parent := filepath.Dir(translated); parent != "." {
+		parts = splitPath(parent)
+	}
+	for i := range parts {
+		dir := filepath.Join(parts[:i+1]...)
+		dirPath := filepath.Join(pkgPath, dir)
+		if createdDirs[dir] || s.fs.Exists(ctx, dirPath) {
+			continue
+		}
+		createdDirs[dir] = true
+
+		dirResult := NewFilePath(dirPath)
+		if !dirResult.IsOk() {
+			return nil, dirResult.UnwrapErr()
+		}
+		dirID := OperationID(fmt.Sprintf("adopt-create-dir-%s", dir))
+		operations = append(operations, NewDirCreate(dirID, dirResult.Unwrap()))
+	}
+
+	sourceResult := NewTargetPath(filepath.Join(s.targetDir, file))
+	if !sourceResult.IsOk() {
+		return nil, sourceResult.UnwrapErr()
+	}
+	destResult := NewFilePath(filepath.Join(pkgPath, translated))
+	if !destResult.IsOk() {
+		return nil, destResult.UnwrapErr()
+	}
+
+	moveID := OperationID(fmt.Sprintf("adopt-move-%s", file))
+	operations = append(operations, FileMove{
+		OpID:   moveID,
+		Source: sourceResult.Unwrap(),
+		Dest:   destResult.Unwrap(),
+	})
+	linkID := OperationID(fmt.Sprintf("adopt-link-%s", file))
+	operations = append(operations, NewLinkCreate(linkID, destResult.Unwrap(), sourceResult.Unwrap()))
+
+	return operations, nil
+}
+
 // createDirectoryAdoptOperations creates operations to adopt a directory's contents.
 // Moves directory CONTENTS into package root (flat structure), not the directory itself.
 func (s *AdoptService) createDirectoryAdoptOperations(ctx context.Context, sourceDir, pkgPath, originalPath string) ([]Operation, error) {
@@ -90,7 +90,7 @@ func (s *BootstrapService) GenerateBootstrap(ctx context.Context, opts GenerateB
 	// Read manifest to find installed packages
 	installed, err := s.getInstalledPackages(ctx)
 	if err != nil {
-		s.logger.Warn(ctx, "failed to read manifest", "error", err)
+		s.logger.Warn(ctx, "manifest_read_failed", "error", err)
 		// Continue with empty installed list
 		installed = []string{}
 	}
@@ -154,6 +154,49 @@ func (s *BootstrapService) WriteBootstrap(ctx context.Context, data []byte, outp
 	return nil
 }
 
+// BootstrapProblem describes an error in a bootstrap configuration file.
+type BootstrapProblem = bootstrap.Problem
+
+// BootstrapValidation contains the result of checking a bootstrap
+// configuration file.
+type BootstrapValidation struct {
+	// Path is the checked file
+	Path string
+
+	// Problems lists the errors found, in the order of the file
+	Problems []BootstrapProblem
+}
+
+// ValidateBootstrap checks the bootstrap configuration file at path, or
+// the .dotbootstrap.yaml of the package directory if path is empty, for
+// the errors clone would report and for fields clone ignores.
+//
+// Returns an error if:
+//   - File does not exist
+//   - File cannot be read
+func (s *BootstrapService) ValidateBootstrap(ctx context.Context, path string) (BootstrapValidation, error) {
+	if path == "" {
+		path = filepath.Join(s.packageDir, ".dotbootstrap.yaml")
+	}
+	if !s.fs.Exists(ctx, path) {
+		return BootstrapValidation{}, ErrSourceNotFound{Path: path}
+	}
+
+	data, err := s.fs.ReadFile(ctx, path)
+	if err != nil {
+		return BootstrapValidation{}, fmt.Errorf("read file: %w", err)
+	}
+
+	problems := bootstrap.Check(data)
+	s.logger.Debug(ctx, "bootstrap_validated", "path", path, "problems", len(problems))
+	return BootstrapValidation{Path: path, Problems: problems}, nil
+}
+
+// BootstrapSchema returns the JSON Schema of bootstrap configuration files.
+func BootstrapSchema() []byte {
+	return bootstrap.Schema()
+}
+
 // getInstalledPackages retrieves the list of installed packages from manifest.
 func (s *BootstrapService) getInstalledPackages(ctx context.Context) ([]string, error) {
 	// Read manifest file
@@ -333,6 +333,32 @@ func TestBootstrapService_WriteBootstrap_WithForce(t *testing.T) {
 	assert.Contains(t, err.Error(), "already exists")
 }
 
+func TestBootstrapService_ValidateBootstrap(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+	svc := newBootstrapService(fs, adapters.NewNoopLogger(), "/tmp/packages", "/tmp/target")
+	require.NoError(t, fs.MkdirAll(ctx, "/tmp/packages", 0755))
+
+	_, err := svc.ValidateBootstrap(ctx, "")
+	var notFound ErrSourceNotFound
+	require.ErrorAs(t, err, &notFound)
+	assert.Equal(t, "/tmp/packages/.dotbootstrap.yaml", notFound.Path)
+
+	config := "version: \"1.0\"\npackages:\n  - name: vim\nprofiles:\n  full:\n    packages: [vim, zsh]\n"
+	require.NoError(t, fs.WriteFile(ctx, "/tmp/packages/.dotbootstrap.yaml", []byte(config), 0644))
+	result, err := svc.ValidateBootstrap(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/packages/.dotbootstrap.yaml", result.Path)
+	require.Len(t, result.Problems, 1)
+	assert.Equal(t, 6, result.Problems[0].Line)
+	assert.Contains(t, result.Problems[0].Message, "unknown package: zsh")
+
+	require.NoError(t, fs.WriteFile(ctx, "/tmp/other.yaml", []byte("version: \"1.0\"\npackages:\n  - name: vim\n"), 0644))
+	result, err = svc.ValidateBootstrap(ctx, "/tmp/other.yaml")
+	require.NoError(t, err)
+	assert.Empty(t, result.Problems)
+}
+
 func TestBootstrapService_Integration(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping integration test")
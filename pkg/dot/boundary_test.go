@@ -0,0 +1,47 @@
+package dot_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+func TestClient_Boundary(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/vim", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/elsewhere", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/vim/dot-vimrc", []byte("set nocompatible"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/test/target/.bashrc", []byte("export EDITOR=vim"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/elsewhere/.inputrc", []byte("set bell-style none"), 0644))
+
+	client, err := dot.NewClient(dot.Config{
+		PackageDir: "/test/packages",
+		TargetDir:  "/test/target",
+		Boundary:   true,
+		FS:         fs,
+		Logger:     adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+
+	// Managing and adopting within the managed directories work as usual
+	require.NoError(t, client.Manage(ctx, "vim"))
+	assert.True(t, fs.Exists(ctx, "/test/target/.vimrc"))
+	require.NoError(t, client.Adopt(ctx, []string{".bashrc"}, "bash"))
+	assert.True(t, fs.Exists(ctx, "/test/packages/bash/dot-bashrc"))
+	require.NoError(t, client.Unmanage(ctx, "vim"))
+	assert.False(t, fs.Exists(ctx, "/test/target/.vimrc"))
+
+	// A path leading out of them is refused and the file stays in place
+	err = client.Adopt(ctx, []string{"../../elsewhere/.inputrc"}, "readline")
+	var outside dot.ErrOutsideBoundary
+	require.ErrorAs(t, err, &outside)
+	assert.Equal(t, "/elsewhere/.inputrc", outside.Path)
+	assert.True(t, fs.Exists(ctx, "/elsewhere/.inputrc"))
+}
@@ -0,0 +1,465 @@
+package dot
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// bundleFormat is the version of the bundle layout written by Export.
+const bundleFormat = 1
+
+// Names of the entries of a bundle. Package directories, the bootstrap
+// configuration and the repository configuration are stored below
+// bundlePackagesDir as they are laid out in the package directory.
+const (
+	bundleIndexName    = "dot-bundle.json"
+	bundleManifestName = "manifest.json"
+	bundlePackagesDir  = "packages"
+)
+
+// BundleIndex describes the contents of a bundle.
+type BundleIndex struct {
+	// Format is the version of the bundle layout.
+	Format int `json:"format"`
+
+	// CreatedAt is when the bundle was exported.
+	CreatedAt time.Time `json:"created_at"`
+
+	// Packages lists the bundled packages, sorted.
+	Packages []string `json:"packages"`
+
+	// Installed lists the bundled packages that were installed on the
+	// exporting machine, according to its manifest.
+	Installed []string `json:"installed"`
+
+	// Inputs identifies what the bundle was exported from. Bundles written
+	// by older versions have none.
+	Inputs *BundleInputs `json:"inputs,omitempty"`
+}
+
+// BundleInputs records the hashes of the inputs of a bundle, so two
+// bundles can be told to come from the same sources without comparing
+// their contents.
+type BundleInputs struct {
+	// Commit is the commit checked out in the package directory, or
+	// empty if it is not a git repository. Uncommitted changes are not
+	// reflected; see Packages.
+	Commit string `json:"commit,omitempty"`
+
+	// ConfigHash is the SHA-256 of the bundled bootstrap and repository
+	// configuration, or empty if neither is bundled.
+	ConfigHash string `json:"config_hash,omitempty"`
+
+	// Packages maps each bundled package to the SHA-256 of its file paths
+	// and contents.
+	Packages map[string]string `json:"packages"`
+}
+
+// bundleWriter writes the entries of a bundle to a gzip-compressed tar
+// archive. Entries carry no owner, so they belong to whoever extracts them.
+type bundleWriter struct {
+	buf bytes.Buffer
+	gz  *gzip.Writer
+	tw  *tar.Writer
+	mod time.Time
+
+	// normalize replaces the permissions of entries by 0755 for
+	// directories and executable files and 0644 for other files, as git
+	// records them, so the umask of the exporting machine does not show.
+	normalize bool
+}
+
+// newBundleWriter creates a bundle writer whose entries are stamped with
+// mod.
+func newBundleWriter(mod time.Time, normalize bool) *bundleWriter {
+	w := &bundleWriter{mod: mod, normalize: normalize}
+	w.gz = gzip.NewWriter(&w.buf)
+	w.tw = tar.NewWriter(w.gz)
+	return w
+}
+
+// file adds a regular file.
+func (w *bundleWriter) file(name string, data []byte, mode os.FileMode) error {
+	hdr := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     int64(w.perm(mode)),
+		Size:     int64(len(data)),
+		ModTime:  w.mod,
+	}
+	if err := w.tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := w.tw.Write(data)
+	return err
+}
+
+// dir adds a directory.
+func (w *bundleWriter) dir(name string, mode os.FileMode) error {
+	return w.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeDir,
+		Name:     name + "/",
+		Mode:     int64(w.perm(mode | os.ModeDir)),
+		ModTime:  w.mod,
+	})
+}
+
+// symlink adds a symbolic link pointing at target.
+func (w *bundleWriter) symlink(name, target string) error {
+	return w.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeSymlink,
+		Name:     name,
+		Linkname: target,
+		Mode:     0777,
+		ModTime:  w.mod,
+	})
+}
+
+// perm returns the permissions an entry of mode is written with. When
+// normalizing, entries become 0644, or 0755 if directories or executable,
+// and entries unreadable by group and others stay so, as 0600 or 0700.
+func (w *bundleWriter) perm(mode os.FileMode) os.FileMode {
+	if !w.normalize {
+		return mode.Perm()
+	}
+	perm := os.FileMode(0644)
+	if mode.IsDir() || mode.Perm()&0111 != 0 {
+		perm = 0755
+	}
+	if mode.Perm()&0077 == 0 {
+		perm &^= 0077
+	}
+	return perm
+}
+
+// tree adds the directory dir of fs and everything below it under name,
+// skipping git metadata, and returns the number of files added.
+func (w *bundleWriter) tree(ctx context.Context, fs FS, dir, name string) (int, error) {
+	info, err := fs.Stat(ctx, dir)
+	if err != nil {
+		return 0, err
+	}
+	if err := w.dir(name, info.Mode()); err != nil {
+		return 0, err
+	}
+
+	entries, err := fs.ReadDir(ctx, dir)
+	if err != nil {
+		return 0, err
+	}
+	slices.SortFunc(entries, func(a, b DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+
+	files := 0
+	for _, entry := range entries {
+		if entry.Name() == ".git" {
+			continue
+		}
+		src := filepath.Join(dir, entry.Name())
+		dest := path.Join(name, entry.Name())
+
+		isLink, err := fs.IsSymlink(ctx, src)
+		if err != nil {
+			return 0, err
+		}
+		switch {
+		case isLink:
+			target, err := fs.ReadLink(ctx, src)
+			if err != nil {
+				return 0, err
+			}
+			if err := w.symlink(dest, target); err != nil {
+				return 0, err
+			}
+			files++
+		case entry.IsDir():
+			n, err := w.tree(ctx, fs, src, dest)
+			if err != nil {
+				return 0, err
+			}
+			files += n
+		default:
+			data, err := fs.ReadFile(ctx, src)
+			if err != nil {
+				return 0, err
+			}
+			info, err := fs.Stat(ctx, src)
+			if err != nil {
+				return 0, err
+			}
+			if err := w.file(dest, data, info.Mode()); err != nil {
+				return 0, err
+			}
+			files++
+		}
+	}
+	return files, nil
+}
+
+// bytes finishes the archive and returns it.
+func (w *bundleWriter) bytes() ([]byte, error) {
+	if err := w.tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := w.gz.Close(); err != nil {
+		return nil, err
+	}
+	return w.buf.Bytes(), nil
+}
+
+// bundleEntry is an entry read from a bundle.
+type bundleEntry struct {
+	name     string
+	typeflag byte
+	mode     os.FileMode
+	linkname string
+	data     []byte
+}
+
+// readBundle reads the entries of the bundle at bundlePath and its index.
+// Entries whose names are absolute or leave the bundle are rejected, as
+// are bundles that checkBundleEntries rejects.
+func readBundle(ctx context.Context, fs FS, bundlePath string) (BundleIndex, []bundleEntry, error) {
+	raw, err := fs.ReadFile(ctx, bundlePath)
+	if err != nil {
+		return BundleIndex{}, nil, fmt.Errorf("read bundle: %w", err)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return BundleIndex{}, nil, ErrInvalidBundle{Path: bundlePath, Cause: err}
+	}
+	tr := tar.NewReader(gz)
+
+	var entries []bundleEntry
+	var index *BundleIndex
+	seen := make(map[string]bool)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return BundleIndex{}, nil, ErrInvalidBundle{Path: bundlePath, Cause: err}
+		}
+
+		name := strings.TrimSuffix(hdr.Name, "/")
+		if clean := path.Clean(name); path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") || clean != name {
+			return BundleIndex{}, nil, ErrInvalidBundle{Path: bundlePath, Cause: fmt.Errorf("unsafe entry name %q", hdr.Name)}
+		}
+		if seen[name] {
+			return BundleIndex{}, nil, ErrInvalidBundle{Path: bundlePath, Cause: fmt.Errorf("duplicate entry %q", hdr.Name)}
+		}
+		seen[name] = true
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return BundleIndex{}, nil, ErrInvalidBundle{Path: bundlePath, Cause: err}
+		}
+
+		if name == bundleIndexName {
+			index = &BundleIndex{}
+			if err := json.Unmarshal(data, index); err != nil {
+				return BundleIndex{}, nil, ErrInvalidBundle{Path: bundlePath, Cause: fmt.Errorf("parse %s: %w", bundleIndexName, err)}
+			}
+			continue
+		}
+		entries = append(entries, bundleEntry{
+			name:     name,
+			typeflag: hdr.Typeflag,
+			mode:     os.FileMode(hdr.Mode).Perm(),
+			linkname: hdr.Linkname,
+			data:     data,
+		})
+	}
+
+	if index == nil {
+		return BundleIndex{}, nil, ErrInvalidBundle{Path: bundlePath, Cause: fmt.Errorf("%s is missing", bundleIndexName)}
+	}
+	if err := checkBundleEntries(entries); err != nil {
+		return BundleIndex{}, nil, ErrInvalidBundle{Path: bundlePath, Cause: err}
+	}
+	if index.Format > bundleFormat {
+		return BundleIndex{}, nil, ErrInvalidBundle{
+			Path:  bundlePath,
+			Cause: fmt.Errorf("bundle format %d is newer than this version of dot reads (%d)", index.Format, bundleFormat),
+		}
+	}
+	return *index, entries, nil
+}
+
+// checkBundleEntries rejects bundles whose extraction could write outside
+// the package directory: symbolic links that are absolute or point out of
+// the directory holding them below bundlePackagesDir, links resolved
+// through another link of the bundle, and entries below a link of the
+// bundle, which would be written where the link points.
+func checkBundleEntries(entries []bundleEntry) error {
+	links := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.typeflag == tar.TypeSymlink {
+			links[entry.name] = true
+		}
+	}
+
+	for _, entry := range entries {
+		for dir := path.Dir(entry.name); dir != "."; dir = path.Dir(dir) {
+			if links[dir] {
+				return fmt.Errorf("entry %q is below the symbolic link %q", entry.name, dir)
+			}
+		}
+		if entry.typeflag != tar.TypeSymlink {
+			continue
+		}
+		if err := checkBundleLink(entry, links); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkBundleLink resolves the target of the link entry one name at a
+// time, and rejects it if it is absolute, leaves bundlePackagesDir or
+// goes through a link of the bundle, whose target would change where it
+// leads.
+func checkBundleLink(entry bundleEntry, links map[string]bool) error {
+	if entry.linkname == "" || path.IsAbs(entry.linkname) {
+		return fmt.Errorf("symbolic link %q has unsafe target %q", entry.name, entry.linkname)
+	}
+
+	resolved := strings.Split(path.Dir(entry.name), "/")
+	for _, part := range strings.Split(entry.linkname, "/") {
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			if len(resolved) <= 1 {
+				return fmt.Errorf("symbolic link %q has unsafe target %q", entry.name, entry.linkname)
+			}
+			resolved = resolved[:len(resolved)-1]
+		default:
+			resolved = append(resolved, part)
+			if links[path.Join(resolved...)] {
+				return fmt.Errorf("symbolic link %q resolves through the symbolic link %q", entry.name, path.Join(resolved...))
+			}
+		}
+	}
+	if resolved[0] != bundlePackagesDir {
+		return fmt.Errorf("symbolic link %q has unsafe target %q", entry.name, entry.linkname)
+	}
+	return nil
+}
+
+// extractBundle writes the package directory entries of a bundle below
+// packageDir.
+func extractBundle(ctx context.Context, fs FS, entries []bundleEntry, packageDir string) error {
+	prefix := bundlePackagesDir + "/"
+	for _, entry := range entries {
+		rel, ok := strings.CutPrefix(entry.name, prefix)
+		if !ok {
+			continue
+		}
+		dest := filepath.Join(packageDir, filepath.FromSlash(rel))
+
+		switch entry.typeflag {
+		case tar.TypeDir:
+			if err := fs.MkdirAll(ctx, dest, entry.mode|0700); err != nil {
+				return fmt.Errorf("create %s: %w", dest, err)
+			}
+		case tar.TypeReg:
+			if err := fs.MkdirAll(ctx, filepath.Dir(dest), 0755); err != nil {
+				return fmt.Errorf("create %s: %w", filepath.Dir(dest), err)
+			}
+			if err := fs.WriteFile(ctx, dest, entry.data, entry.mode); err != nil {
+				return fmt.Errorf("write %s: %w", dest, err)
+			}
+		case tar.TypeSymlink:
+			if err := fs.MkdirAll(ctx, filepath.Dir(dest), 0755); err != nil {
+				return fmt.Errorf("create %s: %w", filepath.Dir(dest), err)
+			}
+			if err := fs.Symlink(ctx, entry.linkname, dest); err != nil {
+				return fmt.Errorf("create link %s: %w", dest, err)
+			}
+		}
+	}
+	return nil
+}
+
+// CloneBundle installs packages from a bundle written by Export instead of
+// cloning a repository, so machines without network access can be set up.
+//
+// The bundle is extracted into packageDir, which must be empty unless
+// opts.Force is set. Packages are selected as Clone selects them, except
+// that without opts.Packages, opts.Profile or opts.Interactive the
+// packages installed on the exporting machine are installed. Options that
+// only apply to git clones are ignored.
+func (s *CloneService) CloneBundle(ctx context.Context, bundlePath string, opts CloneOptions) (CloneReport, error) {
+	s.logger.Info(ctx, "clone_bundle_started", "bundle", bundlePath, "package_dir", s.packageDir)
+	report := s.newCloneReport(bundlePath)
+	report.Bundle = true
+
+	if err := validatePackageDir(ctx, s.fs, s.packageDir, opts.Force); err != nil {
+		s.logger.Error(ctx, "package_directory_validation_failed", "error", err)
+		return report, err
+	}
+
+	index, entries, err := readBundle(ctx, s.fs, bundlePath)
+	if err != nil {
+		s.logger.Error(ctx, "bundle_read_failed", "error", err)
+		return report, err
+	}
+	s.logger.Info(ctx, "bundle_read", "packages", index.Packages, "created_at", index.CreatedAt)
+
+	defaulted := len(opts.Packages) == 0 && opts.Profile == "" && !opts.Interactive
+	if defaulted {
+		opts.Packages = bundledInstalled(index)
+	}
+	if s.dryRun {
+		s.logger.Info(ctx, "dry_run_mode", "would_extract", index.Packages, "would_install", opts.Packages)
+		report.Selected = slices.Clone(opts.Packages)
+		return report, nil
+	}
+
+	if err := s.fs.MkdirAll(ctx, s.packageDir, 0755); err != nil {
+		return report, fmt.Errorf("create packageDir: %w", err)
+	}
+	if err := extractBundle(ctx, s.fs, entries, s.packageDir); err != nil {
+		s.logger.Error(ctx, "bundle_extract_failed", "error", err)
+		return report, err
+	}
+
+	err = s.installPackages(ctx, opts, &report)
+	if defaulted {
+		for i := range report.Skipped {
+			if report.Skipped[i].Reason == SkipNotRequested {
+				report.Skipped[i].Detail = "not installed on the exporting machine"
+			}
+		}
+	}
+	if err != nil {
+		return report, err
+	}
+
+	s.logger.Info(ctx, "clone_complete", "packages_installed", len(report.Installed))
+	return report, nil
+}
+
+// bundledInstalled returns the packages of index that were installed on
+// the exporting machine and are in the bundle.
+func bundledInstalled(index BundleIndex) []string {
+	installed := make([]string, 0, len(index.Installed))
+	for _, pkg := range index.Installed {
+		if slices.Contains(index.Packages, pkg) {
+			installed = append(installed, pkg)
+		}
+	}
+	return installed
+}
@@ -0,0 +1,183 @@
+package dot
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+// Capability names reported by Config.CheckEnvironment.
+const (
+	CapabilityPackageDirReadable   = "package_dir_readable"
+	CapabilityTargetWritable       = "target_writable"
+	CapabilitySymlinks             = "symlinks_supported"
+	CapabilityManifestDirCreatable = "manifest_dir_creatable"
+)
+
+// CapabilityCheck is the result of probing one capability of the
+// environment.
+type CapabilityCheck struct {
+	Name string `json:"name" yaml:"name"`
+	Path string `json:"path" yaml:"path"`
+	OK   bool   `json:"ok" yaml:"ok"`
+
+	// Message explains a failed check, or how a passing check was
+	// established when that is not obvious.
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+// CapabilityReport holds the capabilities of the environment a
+// configuration needs.
+type CapabilityReport struct {
+	Checks []CapabilityCheck `json:"checks" yaml:"checks"`
+}
+
+// OK reports whether every check passed.
+func (r CapabilityReport) OK() bool {
+	return len(r.Failed()) == 0
+}
+
+// Failed returns the checks that did not pass.
+func (r CapabilityReport) Failed() []CapabilityCheck {
+	failed := []CapabilityCheck{}
+	for _, check := range r.Checks {
+		if !check.OK {
+			failed = append(failed, check)
+		}
+	}
+	return failed
+}
+
+// CheckEnvironment probes the filesystem of the configuration for what
+// managing packages needs: a readable package directory, a writable target
+// directory that supports symbolic links, and a manifest directory that
+// can be created. Probes write and remove a temporary file and link; a
+// directory that does not exist yet is probed at its nearest existing
+// parent, where it would be created.
+//
+// The configuration should be valid; see Validate.
+func (c Config) CheckEnvironment(ctx context.Context) CapabilityReport {
+	cfg := c.WithDefaults()
+	report := CapabilityReport{Checks: []CapabilityCheck{}}
+	if cfg.FS == nil {
+		return report
+	}
+
+	report.Checks = append(report.Checks, probeReadable(ctx, cfg.FS, cfg.PackageDir))
+	target := probeWritable(ctx, cfg.FS, CapabilityTargetWritable, cfg.TargetDir)
+	report.Checks = append(report.Checks, target)
+	report.Checks = append(report.Checks, probeSymlinks(ctx, cfg.FS, cfg.TargetDir, target))
+
+	manifestDir := cfg.ManifestDir
+	if manifestDir == "" {
+		manifestDir = cfg.TargetDir
+	}
+	report.Checks = append(report.Checks, probeWritable(ctx, cfg.FS, CapabilityManifestDirCreatable, manifestDir))
+	return report
+}
+
+// probeReadable checks that dir is a directory whose entries can be listed.
+func probeReadable(ctx context.Context, fs FS, dir string) CapabilityCheck {
+	check := CapabilityCheck{Name: CapabilityPackageDirReadable, Path: dir}
+	if isDir, err := fs.IsDir(ctx, dir); err != nil || !isDir {
+		check.Message = "not a directory"
+		if err != nil {
+			check.Message = err.Error()
+		}
+		return check
+	}
+	if _, err := fs.ReadDir(ctx, dir); err != nil {
+		check.Message = err.Error()
+		return check
+	}
+	check.OK = true
+	return check
+}
+
+// probeWritable checks that a file can be created in dir or, if dir does
+// not exist, in the parent it would be created in.
+func probeWritable(ctx context.Context, fs FS, name, dir string) CapabilityCheck {
+	check := CapabilityCheck{Name: name, Path: dir}
+	existing, err := existingAncestor(ctx, fs, dir)
+	if err != nil {
+		check.Message = err.Error()
+		return check
+	}
+
+	probe := probePath(existing)
+	if err := fs.WriteFile(ctx, probe, nil, 0600); err != nil {
+		check.Message = err.Error()
+		return check
+	}
+	if err := fs.Remove(ctx, probe); err != nil {
+		check.Message = fmt.Sprintf("remove probe %s: %v", probe, err)
+		return check
+	}
+
+	check.OK = true
+	if existing != dir {
+		check.Message = "does not exist; can be created in " + existing
+	}
+	return check
+}
+
+// probeSymlinks checks that a symbolic link can be created and read in
+// dir. It is skipped when the writable check of dir failed.
+func probeSymlinks(ctx context.Context, fs FS, dir string, writable CapabilityCheck) CapabilityCheck {
+	check := CapabilityCheck{Name: CapabilitySymlinks, Path: dir}
+	if !writable.OK {
+		check.Message = "not checked: " + CapabilityTargetWritable + " failed"
+		return check
+	}
+	existing, err := existingAncestor(ctx, fs, dir)
+	if err != nil {
+		check.Message = err.Error()
+		return check
+	}
+
+	link := probePath(existing)
+	target := filepath.Base(link) + "-target"
+	if err := fs.Symlink(ctx, target, link); err != nil {
+		check.Message = err.Error()
+		return check
+	}
+	defer func() { _ = fs.Remove(ctx, link) }()
+
+	read, err := fs.ReadLink(ctx, link)
+	if err != nil {
+		check.Message = err.Error()
+		return check
+	}
+	if read != target {
+		check.Message = fmt.Sprintf("link reads back as %q instead of %q", read, target)
+		return check
+	}
+	check.OK = true
+	return check
+}
+
+// existingAncestor returns dir, or its nearest parent that exists. The
+// path found must be a directory.
+func existingAncestor(ctx context.Context, fs FS, dir string) (string, error) {
+	for !fs.Exists(ctx, dir) {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("no existing parent directory")
+		}
+		dir = parent
+	}
+	isDir, err := fs.IsDir(ctx, dir)
+	if err != nil {
+		return "", err
+	}
+	if !isDir {
+		return "", fmt.Errorf("%s is not a directory", dir)
+	}
+	return dir, nil
+}
+
+// probePath returns a path in dir for a probe no other file uses.
+func probePath(dir string) string {
+	return filepath.Join(dir, fmt.Sprintf(".dot-probe-%d", time.Now().UnixNano()))
+}
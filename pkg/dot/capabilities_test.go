@@ -0,0 +1,101 @@
+package dot_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// noSymlinkFS is a filesystem that cannot create symbolic links.
+type noSymlinkFS struct {
+	*adapters.MemFS
+}
+
+func (noSymlinkFS) Symlink(ctx context.Context, oldname, newname string) error {
+	return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: errors.ErrUnsupported}
+}
+
+func capabilityConfig(t *testing.T, fs dot.FS) dot.Config {
+	t.Helper()
+	return dot.Config{
+		PackageDir:  "/test/packages",
+		TargetDir:   "/test/target",
+		ManifestDir: "/test/state/dot",
+		FS:          fs,
+		Logger:      adapters.NewNoopLogger(),
+	}
+}
+
+func TestConfig_CheckEnvironment(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target", 0755))
+
+	report := capabilityConfig(t, fs).CheckEnvironment(ctx)
+	assert.True(t, report.OK(), "%+v", report.Failed())
+
+	names := []string{}
+	for _, check := range report.Checks {
+		names = append(names, check.Name)
+	}
+	assert.Equal(t, []string{
+		dot.CapabilityPackageDirReadable,
+		dot.CapabilityTargetWritable,
+		dot.CapabilitySymlinks,
+		dot.CapabilityManifestDirCreatable,
+	}, names)
+	assert.Equal(t, "does not exist; can be created in /test", report.Checks[3].Message)
+
+	entries, err := fs.ReadDir(ctx, "/test/target")
+	require.NoError(t, err)
+	assert.Empty(t, entries, "probes are removed")
+	assert.False(t, fs.Exists(ctx, "/test/state"), "missing directories are not created")
+}
+
+func TestConfig_CheckEnvironment_Failures(t *testing.T) {
+	ctx := context.Background()
+	mem := adapters.NewMemFS()
+	require.NoError(t, mem.MkdirAll(ctx, "/test/target", 0755))
+
+	report := capabilityConfig(t, noSymlinkFS{mem}).CheckEnvironment(ctx)
+	assert.False(t, report.OK())
+
+	failed := map[string]string{}
+	for _, check := range report.Failed() {
+		failed[check.Name] = check.Message
+	}
+	assert.Len(t, failed, 2)
+	assert.Contains(t, failed, dot.CapabilityPackageDirReadable)
+	assert.Contains(t, failed[dot.CapabilitySymlinks], "unsupported")
+}
+
+func TestNewClient_RequireCapabilities(t *testing.T) {
+	ctx := context.Background()
+	mem := adapters.NewMemFS()
+	require.NoError(t, mem.MkdirAll(ctx, "/test/packages", 0755))
+	require.NoError(t, mem.MkdirAll(ctx, "/test/target", 0755))
+
+	cfg := capabilityConfig(t, noSymlinkFS{mem})
+	_, err := dot.NewClient(cfg)
+	require.NoError(t, err, "capabilities are only enforced when required")
+
+	cfg.RequireCapabilities = true
+	_, err = dot.NewClient(cfg)
+	var capErr dot.ErrCapabilities
+	require.ErrorAs(t, err, &capErr)
+	require.Len(t, capErr.Failed, 1)
+	assert.Equal(t, dot.CapabilitySymlinks, capErr.Failed[0].Name)
+	assert.Contains(t, err.Error(), "symlinks_supported (/test/target")
+
+	cfg.FS = mem
+	_, err = dot.NewClient(cfg)
+	assert.NoError(t, err)
+}
@@ -4,15 +4,21 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"os"
+	"slices"
 
 	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/internal/audit"
 	"github.com/jamesainslie/dot/internal/cli/selector"
+	"github.com/jamesainslie/dot/internal/domain"
 	"github.com/jamesainslie/dot/internal/executor"
 	"github.com/jamesainslie/dot/internal/ignore"
 	"github.com/jamesainslie/dot/internal/manifest"
 	"github.com/jamesainslie/dot/internal/pipeline"
-	"github.com/jamesainslie/dot/internal/planner"
+	"github.com/jamesainslie/dot/internal/scanner"
+	"github.com/jamesainslie/dot/internal/secrets"
+	"github.com/jamesainslie/dot/internal/templating"
 )
 
 // Client provides the high-level API for dot operations.
@@ -30,7 +36,32 @@ type Client struct {
 	doctorSvc    *DoctorService
 	adoptSvc     *AdoptService
 	cloneSvc     *CloneService
+	syncSvc      *SyncService
+	repoSvc      *RepoService
+	takeoverSvc  *TakeoverService
+	rollbackSvc  *RollbackService
+	undoSvc      *UndoService
 	bootstrapSvc *BootstrapService
+	scaffoldSvc  *ScaffoldService
+	importSvc    *ImportService
+	exportSvc    *ExportService
+	matrixSvc    *PlanMatrixService
+	skelSvc      *SkelService
+	portSvc      *ManifestPortService
+	traceSvc     *TraceService
+
+	// auditLog records executed operations, if configured.
+	auditLog *audit.Log
+
+	// events is the bus the services publish to.
+	events *EventBus
+
+	// targets are the clients of the other directories named in
+	// Config.Targets, keyed by directory.
+	targets map[string]*Client
+
+	// sparse extends sparse checkouts of the package directory.
+	sparse adapters.GitSparseCheckout
 }
 
 // NewClient creates a new Client with the given configuration.
@@ -49,12 +80,53 @@ func NewClient(cfg Config) (*Client, error) {
 	// Apply defaults
 	cfg = cfg.WithDefaults()
 
+	if cfg.RequireCapabilities {
+		if report := cfg.CheckEnvironment(context.Background()); !report.OK() {
+			return nil, ErrCapabilities{Failed: report.Failed()}
+		}
+	}
+
+	// Tag the records of each service with its component name
+	component := func(name string) Logger {
+		return cfg.Logger.With("component", name)
+	}
+
 	// Create default ignore set
 	ignoreSet := ignore.NewDefaultIgnoreSet()
 
-	// Create default resolution policies
-	policies := planner.ResolutionPolicies{
-		OnFileExists: planner.PolicyFail, // Safe default
+	// Create resolution policies from the configured strategies
+	strategies, err := conflictStrategies(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	policies := resolutionPolicies(cfg, strategies)
+
+	// Create template renderer for *.tmpl package files
+	env := templating.Environ()
+	secretsProvider, err := secrets.New(secrets.Config{
+		Provider:  cfg.SecretsProvider,
+		EnvPrefix: cfg.SecretsEnvPrefix,
+		Env:       env,
+		Vault:     cfg.SecretsVault,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	host := templating.DetectHost()
+	if cfg.platform != "" {
+		host.OS = cfg.platform
+	}
+	renderer := templating.NewRenderer(cfg.FS, templating.Opts{
+		CacheDir:   cfg.TemplateCacheDir,
+		PackageDir: cfg.PackageDir,
+		Host:       host,
+		Env:        env,
+		Secrets:    secretsProvider,
+	})
+
+	var scanCache *scanner.TreeCache
+	if cfg.ScanCacheDir != "" {
+		scanCache = scanner.NewTreeCache(cfg.FS, cfg.ScanCacheDir)
 	}
 
 	// Create manage pipeline
@@ -64,14 +136,53 @@ func NewClient(cfg Config) (*Client, error) {
 		Policies:           policies,
 		BackupDir:          cfg.BackupDir,
 		PackageNameMapping: cfg.PackageNameMapping,
+		Renderer:           renderer,
+		SpecialFiles:       cfg.SpecialFiles,
+		ScanConcurrency:    cfg.Concurrency,
+		ScanCache:          scanCache,
 	})
 
-	// Create executor
-	exec := executor.New(executor.Opts{
-		FS:     cfg.FS,
-		Logger: cfg.Logger,
-		Tracer: cfg.Tracer,
-	})
+	var auditLog *audit.Log
+	if cfg.AuditLog != "" {
+		auditLog = audit.NewLog(cfg.FS, cfg.AuditLog, component("audit"))
+	}
+
+	// Services publish to an event bus shared with the clients of other
+	// target directories. Executed operations are recorded when an audit
+	// log is configured.
+	events := cfg.events
+	if events == nil {
+		events = domain.NewEventBus()
+		events.Subscribe(logEvents(component), TopicProgress, TopicWarning)
+		if auditLog != nil {
+			events.SubscribeSink(auditLog)
+		}
+		if cfg.EventSink != nil {
+			events.SubscribeSink(cfg.EventSink)
+		}
+		cfg.events = events
+	}
+
+	// Create executor, persisting checkpoints when a directory is configured
+	// and confining its changes to the managed directories with Boundary
+	execFS := cfg.FS
+	if cfg.Boundary {
+		execFS = adapters.NewBoundaryFS(cfg.FS, cfg.boundaryRoots()...)
+	}
+	var checkpointStore *executor.FSCheckpointStore
+	execOpts := executor.Opts{
+		FS:          execFS,
+		Logger:      component("executor"),
+		Tracer:      cfg.Tracer,
+		Metrics:     cfg.Metrics,
+		Events:      events,
+		MaxParallel: cfg.Concurrency,
+	}
+	if cfg.CheckpointDir != "" {
+		checkpointStore = executor.NewFSCheckpointStore(cfg.FS, cfg.CheckpointDir)
+		execOpts.Checkpoint = checkpointStore
+	}
+	exec := executor.New(execOpts)
 
 	// Create manifest store and service
 	var manifestStore *manifest.FSManifestStore
@@ -80,22 +191,72 @@ func NewClient(cfg Config) (*Client, error) {
 	} else {
 		manifestStore = manifest.NewFSManifestStore(cfg.FS)
 	}
-	manifestSvc := newManifestService(cfg.FS, cfg.Logger, manifestStore)
+	manifestSvc := newManifestService(cfg.FS, component("manifest"), manifestStore)
 
 	// Create specialized services (unmanageSvc first since manageSvc depends on it)
-	unmanageSvc := newUnmanageService(cfg.FS, cfg.Logger, exec, manifestSvc, cfg.PackageDir, cfg.TargetDir, cfg.DryRun)
-	manageSvc := newManageService(cfg.FS, cfg.Logger, managePipe, exec, manifestSvc, unmanageSvc, cfg.PackageDir, cfg.TargetDir, cfg.DryRun)
-	statusSvc := newStatusService(manifestSvc, cfg.TargetDir)
-	doctorSvc := newDoctorService(cfg.FS, cfg.Logger, manifestSvc, cfg.TargetDir)
-	adoptSvc := newAdoptService(cfg.FS, cfg.Logger, exec, manifestSvc, cfg.PackageDir, cfg.TargetDir, cfg.DryRun)
+	unmanageSvc := newUnmanageService(cfg.FS, component("unmanage"), exec, manifestSvc, cfg.PackageDir, cfg.TargetDir, cfg.DryRun)
+	manageSvc := newManageService(cfg.FS, component("manage"), managePipe, exec, manifestSvc, unmanageSvc, renderer, cfg.PackageDir, cfg.TargetDir, cfg.DryRun)
+	statusSvc := newStatusService(cfg.FS, manifestSvc, cfg.PackageDir, cfg.TargetDir)
+	doctorSvc := newDoctorService(cfg.FS, component("doctor"), exec, manifestSvc, manageSvc, renderer, cfg.PackageDir, cfg.TargetDir, cfg.BackupDir, cfg.Concurrency, cfg.DryRun)
+	adoptSvc := newAdoptService(cfg.FS, component("adopt"), exec, manifestSvc, cfg.PackageDir, cfg.TargetDir, cfg.BackupDir, cfg.DryRun, cfg.SpecialFiles)
+	takeoverSvc := newTakeoverService(component("takeover"), manageSvc, manifestSvc, cfg.PackageDir, cfg.TargetDir, cfg.DryRun)
+	rollbackSvc := newRollbackService(component("rollback"), exec, checkpointStore, manifestSvc, cfg.PackageDir, cfg.TargetDir, cfg.DryRun)
+	undoSvc := newUndoService(cfg.FS, component("undo"), exec, auditLog, manifestSvc, rollbackSvc, cfg.PackageDir, cfg.TargetDir, cfg.DryRun)
 
 	// Create git cloner and package selector for clone service
-	gitCloner := adapters.NewGoGitCloner()
-	packageSelector := selector.NewInteractiveSelector(os.Stdin, os.Stdout)
-	cloneSvc := newCloneService(cfg.FS, cfg.Logger, manageSvc, gitCloner, packageSelector, cfg.PackageDir, cfg.TargetDir, cfg.DryRun)
+	gitTransport := adapters.TransportOptions{
+		Timeout:         cfg.GitTimeout,
+		ProxyURL:        cfg.GitProxy,
+		InsecureSkipTLS: cfg.GitInsecureSkipTLS,
+	}
+	gitRepository := adapters.NewGoGitRepositoryWithTransport(gitTransport)
+	var gitCloner adapters.GitCloner
+	var sparse adapters.GitSparseCheckout
+	var cloneRepository adapters.GitRepository
+	if _, onDisk := cfg.FS.(*adapters.OSFilesystem); onDisk {
+		goGitCloner := adapters.NewGoGitClonerWithTransport(gitTransport)
+		gitCloner, sparse, cloneRepository = goGitCloner, goGitCloner, gitRepository
+	} else {
+		// Working copies are only kept on disk: other filesystems, such
+		// as in-memory ones, are given snapshots of the repositories
+		gitCloner = adapters.NewFSCloner(cfg.FS, gitTransport)
+	}
+	packageSelector := selector.NewFuzzySelector(os.Stdin, os.Stdout)
+	cloneSvc := newCloneService(cfg.FS, component("clone"), manageSvc, gitCloner, packageSelector, cfg.PackageDir, cfg.TargetDir, cfg.DryRun, cfg.Offline)
+
+	// Create sync service
+	gitPuller := adapters.NewGoGitPullerWithTransport(gitTransport)
+	syncSvc := newSyncService(cfg.FS, component("sync"), manageSvc, unmanageSvc, manifestSvc, gitPuller, cfg.PackageDir, cfg.TargetDir, cfg.DryRun, cfg.Offline)
+	cloneSvc.repository = cloneRepository
+	cloneSvc.events = events
+	doctorSvc.events = events
+	metrics := newCommandMetrics(cfg.Metrics, cfg.PackageDir)
+	manageSvc.metrics = metrics
+	manageSvc.snapshots = newPackageSnapshots(cfg.FS, component("snapshot"), cfg.SnapshotDir, cfg.SnapshotRetention)
+	unmanageSvc.metrics = metrics
+	adoptSvc.metrics = metrics
+	doctorSvc.metrics = metrics
+	undoSvc.metrics = metrics
+	repoSvc := newRepoService(component("repo"), manifestSvc, gitPuller, gitPuller, gitRepository, cfg.PackageDir, cfg.TargetDir, cfg.DryRun, cfg.Offline)
 
 	// Create bootstrap service
-	bootstrapSvc := newBootstrapService(cfg.FS, cfg.Logger, cfg.PackageDir, cfg.TargetDir)
+	bootstrapSvc := newBootstrapService(cfg.FS, component("bootstrap"), cfg.PackageDir, cfg.TargetDir)
+	scaffoldSvc := newScaffoldService(cfg.FS, component("scaffold"), cfg.PackageDir, cfg.DryRun)
+	importSvc := newImportService(cfg.FS, component("import"), manageSvc, cfg.PackageDir, cfg.TargetDir, cfg.PackageNameMapping, cfg.DryRun)
+	importSvc.tree = gitRepository
+	exportSvc := newExportService(cfg.FS, component("export"), manifestSvc, gitPuller, cfg.PackageDir, cfg.TargetDir, cfg.DryRun)
+	matrixSvc := newPlanMatrixService(cfg.FS, component("plan-matrix"), cfg)
+	skelSvc := newSkelService(cfg.FS, component("skel"), cfg)
+	traceSvc := newTraceService(cfg.FS, component("trace"), cfg.Tracer)
+	portSvc := newManifestPortService(component("manifest-port"), manifestSvc, cfg.PackageDir, cfg.TargetDir, cfg.TemplateCacheDir, cfg.DryRun)
+
+	targets, err := newTargetClients(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	for _, dir := range slices.Sorted(maps.Keys(targets)) {
+		statusSvc.targets = append(statusSvc.targets, targets[dir].statusSvc)
+	}
 
 	return &Client{
 		config:       cfg,
@@ -105,7 +266,23 @@ func NewClient(cfg Config) (*Client, error) {
 		doctorSvc:    doctorSvc,
 		adoptSvc:     adoptSvc,
 		cloneSvc:     cloneSvc,
+		syncSvc:      syncSvc,
+		repoSvc:      repoSvc,
+		takeoverSvc:  takeoverSvc,
+		rollbackSvc:  rollbackSvc,
+		undoSvc:      undoSvc,
 		bootstrapSvc: bootstrapSvc,
+		scaffoldSvc:  scaffoldSvc,
+		importSvc:    importSvc,
+		exportSvc:    exportSvc,
+		matrixSvc:    matrixSvc,
+		skelSvc:      skelSvc,
+		portSvc:      portSvc,
+		traceSvc:     traceSvc,
+		auditLog:     auditLog,
+		events:       events,
+		targets:      targets,
+		sparse:       sparse,
 	}, nil
 }
 
@@ -118,12 +295,19 @@ func (c *Client) Config() Config {
 
 // Manage installs the specified packages by creating symlinks.
 func (c *Client) Manage(ctx context.Context, packages ...string) error {
-	return c.manageSvc.Manage(ctx, packages...)
+	if err := c.checkoutPackages(ctx, packages); err != nil {
+		return err
+	}
+	return c.eachTarget(packages, func(target *Client, packages []string) error {
+		return target.manageSvc.Manage(ctx, packages...)
+	})
 }
 
 // PlanManage computes the execution plan for managing packages without applying changes.
 func (c *Client) PlanManage(ctx context.Context, packages ...string) (Plan, error) {
-	return c.manageSvc.PlanManage(ctx, packages...)
+	return c.planTargets(packages, func(target *Client, packages []string) (Plan, error) {
+		return target.manageSvc.PlanManage(ctx, packages...)
+	})
 }
 
 // === Methods from unmanage.go ===
@@ -131,35 +315,78 @@ func (c *Client) PlanManage(ctx context.Context, packages ...string) (Plan, erro
 // Unmanage removes the specified packages by deleting symlinks.
 // Adopted packages are automatically restored unless disabled.
 func (c *Client) Unmanage(ctx context.Context, packages ...string) error {
-	return c.unmanageSvc.Unmanage(ctx, packages...)
+	return c.eachTarget(packages, func(target *Client, packages []string) error {
+		return target.unmanageSvc.Unmanage(ctx, packages...)
+	})
 }
 
 // UnmanageWithOptions removes packages with specified options.
 func (c *Client) UnmanageWithOptions(ctx context.Context, opts UnmanageOptions, packages ...string) error {
-	return c.unmanageSvc.UnmanageWithOptions(ctx, opts, packages...)
+	return c.eachTarget(packages, func(target *Client, packages []string) error {
+		return target.unmanageSvc.UnmanageWithOptions(ctx, opts, packages...)
+	})
 }
 
 // UnmanageAll removes all installed packages with specified options.
 // Returns the count of packages unmanaged.
 func (c *Client) UnmanageAll(ctx context.Context, opts UnmanageOptions) (int, error) {
-	return c.unmanageSvc.UnmanageAll(ctx, opts)
+	count, err := c.unmanageSvc.UnmanageAll(ctx, opts)
+	if err != nil {
+		return count, err
+	}
+	others, err := c.unmanageAllTargets(ctx, opts)
+	return count + others, err
 }
 
 // PlanUnmanage computes the execution plan for unmanaging packages.
 func (c *Client) PlanUnmanage(ctx context.Context, packages ...string) (Plan, error) {
-	return c.unmanageSvc.PlanUnmanage(ctx, packages...)
+	return c.planTargets(packages, func(target *Client, packages []string) (Plan, error) {
+		return target.unmanageSvc.PlanUnmanage(ctx, packages...)
+	})
+}
+
+// PlanUnmanageWithOptions computes the execution plan for unmanaging packages
+// with specified options.
+func (c *Client) PlanUnmanageWithOptions(ctx context.Context, opts UnmanageOptions, packages ...string) (Plan, error) {
+	return c.planTargets(packages, func(target *Client, packages []string) (Plan, error) {
+		return target.unmanageSvc.PlanUnmanageWithOptions(ctx, opts, packages...)
+	})
 }
 
 // === Methods from remanage.go ===
 
 // Remanage reinstalls packages using incremental hash-based change detection.
 func (c *Client) Remanage(ctx context.Context, packages ...string) error {
-	return c.manageSvc.Remanage(ctx, packages...)
+	if err := c.checkoutPackages(ctx, packages); err != nil {
+		return err
+	}
+	return c.eachTarget(packages, func(target *Client, packages []string) error {
+		return target.manageSvc.Remanage(ctx, packages...)
+	})
+}
+
+// RemanageWithOptions reinstalls packages with specified options.
+func (c *Client) RemanageWithOptions(ctx context.Context, opts RemanageOptions, packages ...string) error {
+	if err := c.checkoutPackages(ctx, packages); err != nil {
+		return err
+	}
+	return c.eachTarget(packages, func(target *Client, packages []string) error {
+		return target.manageSvc.RemanageWithOptions(ctx, opts, packages...)
+	})
 }
 
 // PlanRemanage computes incremental execution plan using hash-based change detection.
 func (c *Client) PlanRemanage(ctx context.Context, packages ...string) (Plan, error) {
-	return c.manageSvc.PlanRemanage(ctx, packages...)
+	return c.planTargets(packages, func(target *Client, packages []string) (Plan, error) {
+		return target.manageSvc.PlanRemanage(ctx, packages...)
+	})
+}
+
+// PlanRemanageWithOptions computes the remanage plan with specified options.
+func (c *Client) PlanRemanageWithOptions(ctx context.Context, opts RemanageOptions, packages ...string) (Plan, error) {
+	return c.planTargets(packages, func(target *Client, packages []string) (Plan, error) {
+		return target.manageSvc.PlanRemanageWithOptions(ctx, opts, packages...)
+	})
 }
 
 // === Methods from adopt.go ===
@@ -169,11 +396,22 @@ func (c *Client) Adopt(ctx context.Context, files []string, pkg string) error {
 	return c.adoptSvc.Adopt(ctx, files, pkg)
 }
 
+// AdoptWithOptions adopts files like Adopt with the given options.
+func (c *Client) AdoptWithOptions(ctx context.Context, opts AdoptOptions, files []string, pkg string) error {
+	return c.adoptSvc.AdoptWithOptions(ctx, opts, files, pkg)
+}
+
 // PlanAdopt computes the execution plan for adopting files.
 func (c *Client) PlanAdopt(ctx context.Context, files []string, pkg string) (Plan, error) {
 	return c.adoptSvc.PlanAdopt(ctx, files, pkg)
 }
 
+// PlanAdoptWithOptions computes the execution plan for adopting files with
+// the given options.
+func (c *Client) PlanAdoptWithOptions(ctx context.Context, opts AdoptOptions, files []string, pkg string) (Plan, error) {
+	return c.adoptSvc.PlanAdoptWithOptions(ctx, opts, files, pkg)
+}
+
 // === Methods from status.go ===
 
 // Status reports the current installation state for packages.
@@ -181,11 +419,37 @@ func (c *Client) Status(ctx context.Context, packages ...string) (Status, error)
 	return c.statusSvc.Status(ctx, packages...)
 }
 
+// StatusWithOptions reports the installation state of the packages
+// selected by opts. With opts.Health, the links of each package are
+// inspected as by Health and summarized in PackageInfo.Health.
+func (c *Client) StatusWithOptions(ctx context.Context, opts StatusOptions) (Status, error) {
+	return c.statusSvc.StatusWithOptions(ctx, opts)
+}
+
+// Health reports the state of each link recorded for the given packages.
+// If no packages are specified, all installed packages are inspected.
+func (c *Client) Health(ctx context.Context, packages ...string) ([]PackageHealth, error) {
+	return c.statusSvc.Health(ctx, packages...)
+}
+
+// LinkStats reports how the managed links of the target directory are
+// laid out: links per directory, the deepest links, links resolved
+// through chains of symbolic links, and files linked more than once.
+func (c *Client) LinkStats(ctx context.Context, opts LinkStatsOptions) (LinkStats, error) {
+	return c.statusSvc.LinkStats(ctx, opts)
+}
+
 // List returns all installed packages from the manifest.
 func (c *Client) List(ctx context.Context) ([]PackageInfo, error) {
 	return c.statusSvc.List(ctx)
 }
 
+// ListWithOptions returns installed and available packages selected by
+// opts.
+func (c *Client) ListWithOptions(ctx context.Context, opts ListOptions) ([]PackageInfo, error) {
+	return c.statusSvc.ListWithOptions(ctx, opts)
+}
+
 // === Methods from doctor.go ===
 
 // Doctor performs health checks with default scan configuration.
@@ -198,6 +462,24 @@ func (c *Client) DoctorWithScan(ctx context.Context, scanCfg ScanConfig) (Diagno
 	return c.doctorSvc.DoctorWithScan(ctx, scanCfg)
 }
 
+// PlanFix computes the operations that repair the issues in report
+// without applying them.
+func (c *Client) PlanFix(ctx context.Context, report DiagnosticReport) (FixPlan, error) {
+	return c.doctorSvc.PlanFix(ctx, report)
+}
+
+// AdoptOrphans adds orphaned links in report that point into a package to
+// the manifest entry of that package.
+func (c *Client) AdoptOrphans(ctx context.Context, report DiagnosticReport) ([]Issue, error) {
+	return c.doctorSvc.AdoptOrphans(ctx, report)
+}
+
+// Fix repairs the issues in report that doctor can fix. In dry-run mode
+// the fix is planned but not applied.
+func (c *Client) Fix(ctx context.Context, report DiagnosticReport) (FixPlan, error) {
+	return c.doctorSvc.Fix(ctx, report)
+}
+
 // Clone clones a dotfiles repository and installs packages.
 //
 // Workflow:
@@ -209,16 +491,169 @@ func (c *Client) DoctorWithScan(ctx context.Context, scanCfg ScanConfig) (Diagno
 //  6. Installs selected packages
 //  7. Updates manifest with repository tracking
 //
+// The report lists the packages installed and those left out, and is
+// returned as far as the clone got when it fails.
+//
 // Returns an error if:
 //   - Package directory is not empty (and Force=false)
 //   - Authentication fails
 //   - Clone operation fails
 //   - Bootstrap config is invalid
 //   - Package installation fails
-func (c *Client) Clone(ctx context.Context, repoURL string, opts CloneOptions) error {
+func (c *Client) Clone(ctx context.Context, repoURL string, opts CloneOptions) (CloneReport, error) {
 	return c.cloneSvc.Clone(ctx, repoURL, opts)
 }
 
+// CloneBundle installs packages from a bundle written by Export without
+// network access. The bundle is extracted into the package directory and
+// packages are selected as Clone selects them, defaulting to the packages
+// installed on the exporting machine.
+//
+// Returns ErrInvalidBundle if bundlePath is not a bundle.
+func (c *Client) CloneBundle(ctx context.Context, bundlePath string, opts CloneOptions) (CloneReport, error) {
+	return c.cloneSvc.CloneBundle(ctx, bundlePath, opts)
+}
+
+// Export writes packages, the bootstrap configuration and a snapshot of
+// the manifest to a bundle that CloneBundle installs on another machine.
+func (c *Client) Export(ctx context.Context, opts ExportOptions) (ExportResult, error) {
+	return c.exportSvc.Export(ctx, opts)
+}
+
+// PlanMatrix computes the plans of manage for the profiles and platforms
+// of the bootstrap configuration selected by opts, each against an empty
+// target directory, without changing anything.
+//
+// Returns ErrInvalidBootstrap if there is no valid bootstrap configuration.
+func (c *Client) PlanMatrix(ctx context.Context, opts PlanMatrixOptions) (PlanMatrix, error) {
+	return c.matrixSvc.PlanMatrix(ctx, opts)
+}
+
+// ExportSkel writes the selected packages into a skeleton directory such
+// as /etc/skel, as plain copies of the files manage would link, so that
+// new accounts start with them without running dot.
+func (c *Client) ExportSkel(ctx context.Context, opts SkelOptions) (SkelResult, error) {
+	return c.skelSvc.ExportSkel(ctx, opts)
+}
+
+// TracePlan runs plan through the executor without changing anything and
+// returns the filesystem changes each operation would make, such as a
+// plan printed by --dry-run --format json.
+func (c *Client) TracePlan(ctx context.Context, plan Plan) (PlanTrace, error) {
+	return c.traceSvc.TracePlan(ctx, plan)
+}
+
+// ExportManifest returns the manifest of the target directory in the
+// portable format, for backup, inspection or migration.
+func (c *Client) ExportManifest(ctx context.Context) (PortableManifest, error) {
+	return c.portSvc.ExportManifest(ctx)
+}
+
+// ImportManifest replaces the manifest of the target directory with a
+// portable manifest written by ExportManifest.
+func (c *Client) ImportManifest(ctx context.Context, p PortableManifest, opts ManifestImportOptions) (ManifestImportResult, error) {
+	return c.portSvc.ImportManifest(ctx, p, opts)
+}
+
+// Takeover registers links that already exist in the target, for example
+// from GNU Stow, as manifest entries for the given packages.
+//
+// The target directory is never modified. The report lists, per package,
+// the links found, the links still missing, and any conflicting paths.
+func (c *Client) Takeover(ctx context.Context, packages ...string) (TakeoverReport, error) {
+	return c.takeoverSvc.Takeover(ctx, packages...)
+}
+
+// Sync pulls the package repository and remanages packages that changed.
+//
+// Workflow:
+//  1. Pulls the package directory from its remote
+//  2. Diffs the commit recorded in the manifest against the new HEAD
+//  3. Remanages installed packages whose files changed
+//  4. Unmanages installed packages that were removed from the repository
+//  5. Records the new commit in the manifest
+//
+// In dry-run mode nothing is pulled and the returned plan covers changes
+// already checked out but not yet applied.
+func (c *Client) Sync(ctx context.Context, opts SyncOptions) (SyncResult, error) {
+	return c.syncSvc.Sync(ctx, opts)
+}
+
+// Unshallow fetches the full history of the package repository when it was
+// cloned shallow, as dot clone does by default. It reports whether the
+// repository was shallow; full history is needed to bisect configuration
+// changes or sync from a commit older than the clone.
+func (c *Client) Unshallow(ctx context.Context) (bool, error) {
+	return c.repoSvc.Unshallow(ctx)
+}
+
+// RepoStatus reports the branch, remote divergence, and uncommitted changes
+// of the package repository, and which packages the changes belong to.
+func (c *Client) RepoStatus(ctx context.Context) (RepoStatus, error) {
+	return c.repoSvc.Status(ctx)
+}
+
+// RepoPull fast-forwards the package repository from its remote without
+// changing the target directory. Sync pulls and applies changes in one step.
+func (c *Client) RepoPull(ctx context.Context) (RepoPullResult, error) {
+	return c.repoSvc.Pull(ctx)
+}
+
+// RepoPush pushes the checked-out branch of the package repository to origin.
+func (c *Client) RepoPush(ctx context.Context, opts RepoPushOptions) error {
+	return c.repoSvc.Push(ctx, opts)
+}
+
+// SetRemoteURL changes the URL of a remote of the package repository.
+func (c *Client) SetRemoteURL(ctx context.Context, remote, url string) error {
+	return c.repoSvc.SetRemoteURL(ctx, remote, url)
+}
+
+// Checkpoints returns the persisted execution checkpoints, newest first.
+// Returns ErrCheckpointsDisabled when Config.CheckpointDir is empty.
+func (c *Client) Checkpoints(ctx context.Context) ([]CheckpointInfo, error) {
+	return c.rollbackSvc.Checkpoints(ctx)
+}
+
+// Rollback undoes the plan recorded by a persisted checkpoint.
+//
+// The id may be a full checkpoint ID or a unique prefix. An empty id selects
+// the most recent checkpoint that has not already been rolled back, which
+// covers both the last completed plan and one interrupted by a crash.
+// Links created by the plan are removed from the manifest.
+func (c *Client) Rollback(ctx context.Context, id string) (RollbackResult, error) {
+	return c.rollbackSvc.Rollback(ctx, id)
+}
+
+// Undo reverts a plan recorded in the audit log by executing its inverse:
+// created links are deleted, deleted links are recreated, and adopted
+// files are moved back. It is refused with ErrConflict errors when the
+// paths involved have changed since the plan ran.
+//
+// The id may be a full plan ID or a unique prefix. An empty id selects the
+// most recent plan that has not been undone.
+// Returns ErrAuditLogDisabled when Config.AuditLog is empty.
+func (c *Client) Undo(ctx context.Context, id string) (UndoResult, error) {
+	return c.undoSvc.Undo(ctx, id)
+}
+
+// Interrupted returns checkpoints of executions that were interrupted before
+// they finished, for example by a crash or power loss, newest first.
+// Returns nil when Config.CheckpointDir is empty.
+func (c *Client) Interrupted(ctx context.Context) ([]CheckpointInfo, error) {
+	return c.rollbackSvc.Interrupted(ctx)
+}
+
+// Resume completes an interrupted execution from the plan journaled in its
+// checkpoint and updates the manifest as the interrupted command would have.
+//
+// The id may be a full checkpoint ID or a unique prefix. An empty id selects
+// the most recent interrupted execution. Use Rollback instead to undo the
+// operations that did run.
+func (c *Client) Resume(ctx context.Context, id string) (ResumeResult, error) {
+	return c.rollbackSvc.Resume(ctx, id)
+}
+
 // GenerateBootstrap creates a bootstrap configuration from current installation.
 //
 // Workflow:
@@ -245,6 +680,51 @@ func (c *Client) WriteBootstrap(ctx context.Context, data []byte, outputPath str
 	return c.bootstrapSvc.WriteBootstrap(ctx, data, outputPath)
 }
 
+// ValidateBootstrap checks a bootstrap configuration file, by default the
+// .dotbootstrap.yaml of the package directory, and returns every problem
+// found with its line.
+//
+// Returns an error if the file does not exist or cannot be read.
+func (c *Client) ValidateBootstrap(ctx context.Context, path string) (BootstrapValidation, error) {
+	return c.bootstrapSvc.ValidateBootstrap(ctx, path)
+}
+
+// NewPackage creates a package with a .dotignore and the files of opts,
+// and lists it in the repository's bootstrap configuration if there is
+// one. Names are given the dot- prefix unless opts.NoPrefix is set.
+//
+// Returns ErrPackageExists if the package directory already exists.
+func (c *Client) NewPackage(ctx context.Context, name string, opts NewPackageOptions) (NewPackageResult, error) {
+	return c.scaffoldSvc.NewPackage(ctx, name, opts)
+}
+
+// ImportStow converts the GNU Stow directory stowDir into packages of the
+// package directory, replaces the Stow links in the target directory with
+// links to the new packages and records them in the manifest.
+//
+// Returns ErrPackageExists if a package it would create already exists.
+func (c *Client) ImportStow(ctx context.Context, stowDir string) (ImportReport, error) {
+	return c.importSvc.ImportStow(ctx, stowDir)
+}
+
+// ImportChezmoi converts the chezmoi source directory sourceDir into
+// packages of the package directory and replaces the files chezmoi wrote
+// to the target directory with links to them.
+//
+// Returns ErrPackageExists if a package it would create already exists.
+func (c *Client) ImportChezmoi(ctx context.Context, sourceDir string) (ImportReport, error) {
+	return c.importSvc.ImportChezmoi(ctx, sourceDir)
+}
+
+// ImportYadm converts the files tracked by the yadm repository repoDir
+// into packages of the package directory and replaces them in the target
+// directory with links.
+//
+// Returns ErrPackageExists if a package it would create already exists.
+func (c *Client) ImportYadm(ctx context.Context, repoDir string) (ImportReport, error) {
+	return c.importSvc.ImportYadm(ctx, repoDir)
+}
+
 // === Methods from helpers.go ===
 
 // isManifestNotFoundError checks if an error represents a missing manifest file.
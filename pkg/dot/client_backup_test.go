@@ -0,0 +1,114 @@
+package dot_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+func setupBackupClient(t *testing.T) (*adapters.MemFS, *dot.Client) {
+	t.Helper()
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/vim", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/vim/dot-vimrc", []byte("set nocompatible"), 0644))
+
+	client, err := dot.NewClient(dot.Config{
+		PackageDir: "/test/packages",
+		TargetDir:  "/test/target",
+		Backup:     true,
+		FS:         fs,
+		Logger:     adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+	return fs, client
+}
+
+type backupIndex struct {
+	Objects map[string]struct {
+		Refs int `json:"refs"`
+	} `json:"objects"`
+	Entries []struct {
+		Path string `json:"path"`
+		Hash string `json:"hash"`
+	} `json:"entries"`
+}
+
+func readBackupIndex(t *testing.T, fs *adapters.MemFS) backupIndex {
+	t.Helper()
+	data, err := fs.ReadFile(context.Background(), "/test/target/.dot-backup/index.json")
+	require.NoError(t, err)
+	var index backupIndex
+	require.NoError(t, json.Unmarshal(data, &index))
+	return index
+}
+
+func TestClient_Manage_BacksUpConflictingFile(t *testing.T) {
+	fs, client := setupBackupClient(t)
+	ctx := context.Background()
+
+	existing := []byte("my local vimrc")
+	require.NoError(t, fs.WriteFile(ctx, "/test/target/.vimrc", existing, 0644))
+
+	require.NoError(t, client.Manage(ctx, "vim"))
+
+	isLink, err := fs.IsSymlink(ctx, "/test/target/.vimrc")
+	require.NoError(t, err)
+	assert.True(t, isLink)
+
+	sum := sha256.Sum256(existing)
+	hash := hex.EncodeToString(sum[:])
+	stored, err := fs.ReadFile(ctx, "/test/target/.dot-backup/objects/"+hash[:2]+"/"+hash)
+	require.NoError(t, err)
+	assert.Equal(t, existing, stored)
+
+	index := readBackupIndex(t, fs)
+	assert.Equal(t, 1, index.Objects[hash].Refs)
+	require.Len(t, index.Entries, 1)
+	assert.Equal(t, "/test/target/.vimrc", index.Entries[0].Path)
+}
+
+func TestClient_Manage_BackupDeduplicatesContent(t *testing.T) {
+	fs, client := setupBackupClient(t)
+	ctx := context.Background()
+
+	existing := []byte("my local vimrc")
+	for i := 0; i < 2; i++ {
+		require.NoError(t, fs.WriteFile(ctx, "/test/target/.vimrc", existing, 0644))
+		require.NoError(t, client.Manage(ctx, "vim"))
+		require.NoError(t, client.Unmanage(ctx, "vim"))
+	}
+
+	sum := sha256.Sum256(existing)
+	hash := hex.EncodeToString(sum[:])
+	objects, err := fs.ReadDir(ctx, "/test/target/.dot-backup/objects/"+hash[:2])
+	require.NoError(t, err)
+	assert.Len(t, objects, 1)
+
+	index := readBackupIndex(t, fs)
+	assert.Len(t, index.Objects, 1)
+	assert.Equal(t, 2, index.Objects[hash].Refs)
+	assert.Len(t, index.Entries, 2)
+}
+
+func TestClient_Manage_WithoutBackupReportsConflict(t *testing.T) {
+	fs, client := setupStowedTree(t)
+	ctx := context.Background()
+
+	require.NoError(t, fs.WriteFile(ctx, "/test/target/.vimrc", []byte("local"), 0644))
+
+	err := client.Manage(ctx, "vim")
+
+	require.Error(t, err)
+	assert.False(t, fs.Exists(ctx, "/test/target/.dot-backup/index.json"))
+}
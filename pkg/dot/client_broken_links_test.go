@@ -88,3 +88,97 @@ func TestClient_Doctor_WrongLinkType(t *testing.T) {
 
 	assert.Equal(t, dot.HealthErrors, report.OverallHealth)
 }
+
+func TestClient_Doctor_ParallelLinkChecksAreDeterministic(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target", 0755))
+	packages := []string{"alpha", "bravo", "charlie", "delta"}
+	for _, pkg := range packages {
+		for _, name := range []string{"dot-a", "dot-b", "dot-c"} {
+			require.NoError(t, fs.MkdirAll(ctx, "/test/packages/"+pkg+"/"+pkg, 0755))
+			require.NoError(t, fs.WriteFile(ctx, "/test/packages/"+pkg+"/"+pkg+"/"+name, []byte("x"), 0644))
+		}
+	}
+
+	cfg := dot.Config{
+		PackageDir:  "/test/packages",
+		TargetDir:   "/test/target",
+		FS:          fs,
+		Logger:      adapters.NewNoopLogger(),
+		Concurrency: 4,
+	}
+	client, err := dot.NewClient(cfg)
+	require.NoError(t, err)
+	require.NoError(t, client.Manage(ctx, packages...))
+
+	// Break every link
+	for _, pkg := range packages {
+		require.NoError(t, fs.RemoveAll(ctx, "/test/packages/"+pkg+"/"+pkg))
+	}
+
+	first, err := client.DoctorWithScan(ctx, dot.ScanConfig{Mode: dot.ScanOff})
+	require.NoError(t, err)
+	require.Len(t, first.Issues, 12)
+	assert.Equal(t, 12, first.Statistics.TotalLinks)
+	assert.Equal(t, 12, first.Statistics.BrokenLinks)
+	assert.Equal(t, "alpha", first.Issues[0].Package)
+	assert.Equal(t, "delta", first.Issues[11].Package)
+
+	for i := 0; i < 5; i++ {
+		report, err := client.DoctorWithScan(ctx, dot.ScanConfig{Mode: dot.ScanOff})
+		require.NoError(t, err)
+		assert.Equal(t, first.Issues, report.Issues)
+	}
+}
+
+func TestClient_Doctor_StatsAndThresholds(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/app", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/app/dot-a", []byte("x"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/app/dot-b", []byte("x"), 0644))
+
+	client, err := dot.NewClient(dot.Config{
+		PackageDir: "/test/packages",
+		TargetDir:  "/test/target",
+		FS:         fs,
+		Logger:     adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+	require.NoError(t, client.Manage(ctx, "app"))
+
+	require.NoError(t, fs.Remove(ctx, "/test/packages/app/dot-b"))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/app/dot-c", []byte("x"), 0644))
+	require.NoError(t, fs.Symlink(ctx, "/test/packages/app/dot-c", "/test/target/.orphan"))
+
+	scanCfg := dot.ScopedScanConfig()
+	report, err := client.DoctorWithScan(ctx, scanCfg)
+	require.NoError(t, err)
+	assert.Equal(t, dot.HealthErrors, report.OverallHealth)
+	assert.Equal(t, map[string]dot.PackageStats{
+		"app": {Links: 2, BrokenLinks: 1, ManagedLinks: 2, Issues: 2, Health: dot.HealthErrors},
+	}, report.Statistics.Packages, "the broken link and the orphan into app")
+	assert.Positive(t, report.Statistics.FilesScanned)
+	assert.Positive(t, report.Statistics.ScanDuration)
+
+	// One broken link is tolerated as a warning
+	scanCfg.Thresholds = dot.HealthThresholds{BrokenLinks: 1}
+	report, err = client.DoctorWithScan(ctx, scanCfg)
+	require.NoError(t, err)
+	assert.Equal(t, dot.HealthWarnings, report.OverallHealth)
+
+	// Orphans within the threshold no longer affect health
+	require.NoError(t, fs.Remove(ctx, "/test/target/.b"))
+	require.NoError(t, fs.Remove(ctx, "/test/target/.dot-manifest.json"))
+	require.NoError(t, client.Manage(ctx, "app"))
+	scanCfg.Thresholds = dot.HealthThresholds{OrphanedLinks: 1}
+	report, err = client.DoctorWithScan(ctx, scanCfg)
+	require.NoError(t, err)
+	assert.Equal(t, dot.HealthOK, report.OverallHealth)
+	require.Len(t, report.Issues, 1, "tolerated orphans are still reported")
+	assert.Equal(t, dot.IssueOrphanedLink, report.Issues[0].Type)
+}
@@ -0,0 +1,196 @@
+package dot_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// keepLocal is a custom strategy that leaves existing files in place.
+type keepLocal struct {
+	resolved []string
+}
+
+func (s *keepLocal) Name() string { return "keep-local" }
+
+func (s *keepLocal) Resolve(req dot.ResolutionRequest) dot.ResolutionOutcome {
+	s.resolved = append(s.resolved, req.Conflict.Path.String())
+	warning := dot.ResolutionWarning{Message: "Keeping local file", Severity: dot.WarnInfo}
+	return dot.ResolutionOutcome{Status: dot.ResolveSkip, Warning: &warning}
+}
+
+func setupConflictClient(t *testing.T, cfg dot.Config) (*adapters.MemFS, *dot.Client) {
+	t.Helper()
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/vim", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/vim/dot-vimrc", []byte("set nocompatible"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/test/target/.vimrc", []byte("my local vimrc"), 0644))
+
+	cfg.PackageDir = "/test/packages"
+	cfg.TargetDir = "/test/target"
+	cfg.FS = fs
+	cfg.Logger = adapters.NewNoopLogger()
+	client, err := dot.NewClient(cfg)
+	require.NoError(t, err)
+	return fs, client
+}
+
+func TestClient_Manage_CustomConflictStrategy(t *testing.T) {
+	strategy := &keepLocal{}
+	fs, client := setupConflictClient(t, dot.Config{
+		OnConflict:         "keep-local",
+		ConflictStrategies: []dot.ResolutionStrategy{strategy},
+	})
+	ctx := context.Background()
+
+	require.NoError(t, client.Manage(ctx, "vim"))
+
+	assert.Equal(t, []string{"/test/target/.vimrc"}, strategy.resolved)
+	data, err := fs.ReadFile(ctx, "/test/target/.vimrc")
+	require.NoError(t, err)
+	assert.Equal(t, "my local vimrc", string(data))
+}
+
+func TestClient_Manage_AdoptConflictStrategy(t *testing.T) {
+	fs, client := setupConflictClient(t, dot.Config{OnConflict: "adopt"})
+	ctx := context.Background()
+
+	require.NoError(t, client.Manage(ctx, "vim"))
+
+	isLink, err := fs.IsSymlink(ctx, "/test/target/.vimrc")
+	require.NoError(t, err)
+	assert.True(t, isLink)
+	data, err := fs.ReadFile(ctx, "/test/packages/vim/dot-vimrc")
+	require.NoError(t, err)
+	assert.Equal(t, "my local vimrc", string(data))
+}
+
+func TestClient_Manage_OverwriteConflictStrategy(t *testing.T) {
+	fs, client := setupConflictClient(t, dot.Config{OnConflict: "overwrite"})
+	ctx := context.Background()
+
+	require.NoError(t, client.Manage(ctx, "vim"))
+
+	link, err := fs.ReadLink(ctx, "/test/target/.vimrc")
+	require.NoError(t, err)
+	assert.Contains(t, link, "packages/vim/dot-vimrc")
+}
+
+func TestClient_PlanManage_Replacements(t *testing.T) {
+	ctx := context.Background()
+
+	_, client := setupConflictClient(t, dot.Config{OnConflict: "overwrite"})
+	plan, err := client.PlanManage(ctx, "vim")
+	require.NoError(t, err)
+	assert.Equal(t, []dot.ReplacementInfo{
+		{Path: "/test/target/.vimrc", Replacement: "/test/packages/vim/dot-vimrc", Policy: "overwrite"},
+	}, plan.Metadata.Replacements)
+
+	_, client = setupConflictClient(t, dot.Config{OnConflict: "adopt"})
+	plan, err = client.PlanManage(ctx, "vim")
+	require.NoError(t, err)
+	assert.Equal(t, []dot.ReplacementInfo{
+		{Path: "/test/packages/vim/dot-vimrc", Replacement: "/test/target/.vimrc", Policy: "adopt"},
+	}, plan.Metadata.Replacements)
+
+	_, client = setupConflictClient(t, dot.Config{OnConflict: "backup"})
+	plan, err = client.PlanManage(ctx, "vim")
+	require.NoError(t, err)
+	assert.Empty(t, plan.Metadata.Replacements, "backed up files are not lost")
+}
+
+func TestClient_PlanAdopt_Replacements(t *testing.T) {
+	fs, client := setupConflictClient(t, dot.Config{})
+	ctx := context.Background()
+	require.NoError(t, fs.WriteFile(ctx, "/test/target/.gvimrc", []byte("set guifont"), 0644))
+
+	plan, err := client.PlanAdopt(ctx, []string{".vimrc", ".gvimrc"}, "vim")
+	require.NoError(t, err)
+	assert.Equal(t, []dot.ReplacementInfo{
+		{Path: "/test/packages/vim/dot-vimrc", Replacement: "/test/target/.vimrc", Policy: "adopt"},
+	}, plan.Metadata.Replacements)
+}
+
+func TestClient_Manage_PromptConflictStrategy(t *testing.T) {
+	var choices []string
+	fs, client := setupConflictClient(t, dot.Config{
+		OnConflict: "prompt",
+		ConflictPrompt: func(c dot.Conflict, options []string) (string, error) {
+			choices = options
+			return "backup", nil
+		},
+		Backup: true,
+	})
+	ctx := context.Background()
+
+	require.NoError(t, client.Manage(ctx, "vim"))
+
+	assert.Contains(t, choices, "backup")
+	assert.NotContains(t, choices, "prompt")
+	assert.True(t, fs.Exists(ctx, "/test/target/.dot-backup/index.json"))
+}
+
+func TestClient_Manage_ConflictPolicies(t *testing.T) {
+	fs, client := setupConflictClient(t, dot.Config{
+		OnConflict: "fail",
+		ConflictPolicies: map[string]string{
+			"~/.config/**":  "fail",
+			"~/.config/*rc": "skip",
+			"~/.vim*":       "overwrite",
+		},
+	})
+	ctx := context.Background()
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/app/dot-config", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/app/dot-config/apprc", []byte("new"), 0644))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target/.config", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/target/.config/apprc", []byte("local"), 0644))
+
+	require.NoError(t, client.Manage(ctx, "vim"))
+	link, err := fs.ReadLink(ctx, "/test/target/.vimrc")
+	require.NoError(t, err)
+	assert.Contains(t, link, "packages/vim/dot-vimrc")
+
+	require.NoError(t, client.Manage(ctx, "app"))
+	data, err := fs.ReadFile(ctx, "/test/target/.config/apprc")
+	require.NoError(t, err)
+	assert.Equal(t, "local", string(data), "the longest matching pattern applies")
+}
+
+func TestConfig_Validate_ConflictStrategies(t *testing.T) {
+	base := dot.Config{
+		PackageDir: "/test/packages",
+		TargetDir:  "/test/target",
+		FS:         adapters.NewMemFS(),
+		Logger:     adapters.NewNoopLogger(),
+	}
+
+	cfg := base
+	cfg.OnConflict = "keep-local"
+	assert.ErrorContains(t, cfg.Validate(), `unknown conflict strategy "keep-local"`)
+
+	cfg.ConflictStrategies = []dot.ResolutionStrategy{&keepLocal{}}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.ConflictPolicies = map[string]string{"~/.ssh/**": "keep-local"}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.ConflictPolicies = map[string]string{"~/.ssh/**": "shred"}
+	assert.ErrorContains(t, cfg.Validate(), `unknown conflict strategy "shred"`)
+
+	cfg.ConflictPolicies = map[string]string{"~/[ssh": "fail"}
+	assert.ErrorContains(t, cfg.Validate(), "invalid pattern")
+	cfg.ConflictPolicies = nil
+
+	cfg.ConflictStrategies = append(cfg.ConflictStrategies, &keepLocal{})
+	assert.ErrorContains(t, cfg.Validate(), "already registered")
+
+	assert.ElementsMatch(t, []string{"adopt", "backup", "fail", "overwrite", "prompt", "skip"}, dot.BuiltinConflictStrategies())
+}
@@ -2,6 +2,9 @@ package dot_test
 
 import (
 	"context"
+	"fmt"
+	"maps"
+	"slices"
 	"testing"
 
 	"github.com/jamesainslie/dot/internal/adapters"
@@ -34,7 +37,7 @@ func TestClient_Doctor_OrphanedLinkDetection(t *testing.T) {
 	require.NoError(t, err)
 
 	// Create orphaned symlink
-	require.NoError(t, fs.Symlink(ctx, "/nowhere", "/test/target/.orphaned"))
+	require.NoError(t, fs.Symlink(ctx, "/test/packages/app/gone", "/test/target/.orphaned"))
 
 	// Test scoped scan (should detect orphan)
 	report, err := client.DoctorWithScan(ctx, dot.ScopedScanConfig())
@@ -114,6 +117,38 @@ func TestClient_Doctor_SkipPatterns(t *testing.T) {
 	assert.NotNil(t, report)
 }
 
+func TestClient_Doctor_SkipsCloudSyncedDirectories(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/app", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/app/dot-config", []byte("x"), 0644))
+	for _, dir := range []string{"OneDrive - Contoso/docs", "Library/CloudStorage/Dropbox", "go/pkg/mod", "src"} {
+		require.NoError(t, fs.MkdirAll(ctx, "/test/target/"+dir, 0755))
+		require.NoError(t, fs.Symlink(ctx, "/test/packages/app/dot-config", "/test/target/"+dir+"/link"))
+	}
+
+	client, err := dot.NewClient(dot.Config{
+		PackageDir: "/test/packages",
+		TargetDir:  "/test/target",
+		FS:         fs,
+		Logger:     adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+	require.NoError(t, client.Manage(ctx, "app"))
+
+	report, err := client.DoctorWithScan(ctx, dot.DeepScanConfig(5))
+	require.NoError(t, err)
+
+	var orphans []string
+	for _, issue := range report.Issues {
+		if issue.Type == dot.IssueOrphanedLink {
+			orphans = append(orphans, issue.Path)
+		}
+	}
+	assert.Equal(t, []string{"src/link"}, orphans, "links in glob and multi-component skip patterns are not scanned")
+}
+
 func TestClient_Doctor_OrphanedLinkBrokenTarget(t *testing.T) {
 	fs := adapters.NewMemFS()
 	ctx := context.Background()
@@ -138,11 +173,11 @@ func TestClient_Doctor_OrphanedLinkBrokenTarget(t *testing.T) {
 	require.NoError(t, err)
 
 	// Create orphaned symlink with broken target
-	require.NoError(t, fs.Symlink(ctx, "/nonexistent", "/test/target/.orphaned-broken"))
+	require.NoError(t, fs.Symlink(ctx, "/test/packages/app/nonexistent", "/test/target/.orphaned-broken"))
 
 	// Create orphaned symlink with valid target
-	require.NoError(t, fs.MkdirAll(ctx, "/test/valid-target", 0755))
-	require.NoError(t, fs.Symlink(ctx, "/test/valid-target", "/test/target/.orphaned-ok"))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/app/valid-target", 0755))
+	require.NoError(t, fs.Symlink(ctx, "/test/packages/app/valid-target", "/test/target/.orphaned-ok"))
 
 	// Test scoped scan
 	report, err := client.DoctorWithScan(ctx, dot.ScopedScanConfig())
@@ -174,6 +209,50 @@ func TestClient_Doctor_OrphanedLinkBrokenTarget(t *testing.T) {
 	assert.Equal(t, 1, orphanedCount, "Expected 1 orphaned link issue")
 }
 
+func TestClient_Doctor_ForeignLinks(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/app", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/app/dot-config", []byte("cfg"), 0644))
+
+	client, err := dot.NewClient(dot.Config{
+		PackageDir: "/test/packages",
+		TargetDir:  "/test/target",
+		FS:         fs,
+		Logger:     adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+	require.NoError(t, client.Manage(ctx, "app"))
+
+	// Links created by other tools
+	require.NoError(t, fs.MkdirAll(ctx, "/nix/store/abc-hm-files", 0755))
+	require.NoError(t, fs.Symlink(ctx, "/nix/store/abc-hm-files", "/test/target/.nix-files"))
+	require.NoError(t, fs.Symlink(ctx, "/opt/tool/missing", "/test/target/.tool"))
+
+	report, err := client.DoctorWithScan(ctx, dot.ScopedScanConfig())
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, report.Statistics.ForeignLinks)
+	assert.Zero(t, report.Statistics.OrphanedLinks)
+	assert.Zero(t, report.Statistics.BrokenLinks)
+	assert.Equal(t, dot.HealthWarnings, report.OverallHealth, "only the broken foreign link is a warning")
+
+	severities := map[string]dot.IssueSeverity{}
+	for _, issue := range report.Issues {
+		assert.Equal(t, dot.IssueForeignLink, issue.Type)
+		severities[issue.Path] = issue.Severity
+		if issue.Path == ".nix-files" {
+			assert.Contains(t, issue.Message, "nix")
+		}
+	}
+	assert.Equal(t, map[string]dot.IssueSeverity{
+		".nix-files": dot.SeverityInfo,
+		".tool":      dot.SeverityWarning,
+	}, severities)
+}
+
 func TestClient_Doctor_DefaultScanMode(t *testing.T) {
 	fs := adapters.NewMemFS()
 	ctx := context.Background()
@@ -198,8 +277,8 @@ func TestClient_Doctor_DefaultScanMode(t *testing.T) {
 	require.NoError(t, err)
 
 	// Create orphaned symlink
-	require.NoError(t, fs.WriteFile(ctx, "/test/target-file", []byte("x"), 0644))
-	require.NoError(t, fs.Symlink(ctx, "/test/target-file", "/test/target/.orphan"))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/app/dot-extra", []byte("x"), 0644))
+	require.NoError(t, fs.Symlink(ctx, "/test/packages/app/dot-extra", "/test/target/.orphan"))
 
 	// Test default doctor (should use scoped scanning)
 	report, err := client.Doctor(ctx)
@@ -208,3 +287,130 @@ func TestClient_Doctor_DefaultScanMode(t *testing.T) {
 	// Should detect orphaned link with default scoped scanning
 	assert.Equal(t, 1, report.Statistics.OrphanedLinks, "Expected default scoped scan to detect orphan")
 }
+
+func TestClient_Doctor_ScopedToPackages(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/vim", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/app/config/app", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/vim/dot-vimrc", []byte("set nu"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/app/config/app/rc", []byte("rc"), 0644))
+
+	client, err := dot.NewClient(dot.Config{
+		PackageDir: "/test/packages",
+		TargetDir:  "/test/target",
+		FS:         fs,
+		Logger:     adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+	require.NoError(t, client.Manage(ctx, "vim", "app"))
+
+	require.NoError(t, fs.Remove(ctx, "/test/target/config/app/rc"))
+	require.NoError(t, fs.Symlink(ctx, "/nowhere", "/test/target/.orphaned"))
+	require.NoError(t, fs.Symlink(ctx, "/nowhere", "/test/target/config/app/old"))
+
+	scanCfg := dot.ScopedScanConfig()
+	scanCfg.Packages = []string{"vim"}
+	report, err := client.DoctorWithScan(ctx, scanCfg)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Statistics.ManagedLinks, "only vim's link is checked")
+	assert.Contains(t, report.Statistics.Packages, "vim")
+	assert.NotContains(t, report.Statistics.Packages, "app")
+	for _, issue := range report.Issues {
+		assert.NotEqual(t, "app", issue.Package)
+	}
+
+	scanCfg.Packages = []string{"app"}
+	report, err = client.DoctorWithScan(ctx, scanCfg)
+	require.NoError(t, err)
+	var paths []string
+	for _, issue := range report.Issues {
+		paths = append(paths, issue.Path)
+	}
+	assert.Contains(t, paths, "config/app/rc", "broken managed link")
+	assert.Contains(t, paths, "config/app/old", "orphan next to the package's links")
+	assert.NotContains(t, paths, ".orphaned", "orphan outside the package's directories")
+
+	scanCfg.Packages = []string{"missing"}
+	_, err = client.DoctorWithScan(ctx, scanCfg)
+	var notFound dot.ErrPackageNotFound
+	assert.ErrorAs(t, err, &notFound)
+
+	// A full report filtered to a package matches its own health
+	full, err := client.DoctorWithScan(ctx, dot.ScopedScanConfig())
+	require.NoError(t, err)
+	assert.Equal(t, dot.HealthOK, full.Statistics.Packages["vim"].Health)
+	assert.Equal(t, dot.HealthErrors, full.Statistics.Packages["app"].Health)
+
+	vim := full.ForPackages("vim")
+	assert.Equal(t, dot.HealthOK, vim.OverallHealth)
+	assert.Empty(t, vim.Issues)
+	assert.Equal(t, 1, vim.Statistics.ManagedLinks)
+	assert.Equal(t, 0, vim.Statistics.OrphanedLinks, "the orphan scan belongs to no package")
+
+	app := full.ForPackages("app", "missing")
+	assert.Equal(t, dot.HealthErrors, app.OverallHealth)
+	require.Len(t, app.Issues, 1)
+	assert.Equal(t, "config/app/rc", app.Issues[0].Path)
+	assert.Equal(t, 1, app.Statistics.BrokenLinks)
+	assert.Equal(t, []string{"app"}, slices.Collect(maps.Keys(app.Statistics.Packages)))
+}
+
+// setupManyLinks manages a package of n files.
+func setupManyLinks(t *testing.T, n int) *dot.Client {
+	t.Helper()
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/many", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target", 0755))
+	for i := 0; i < n; i++ {
+		require.NoError(t, fs.WriteFile(ctx, fmt.Sprintf("/test/packages/many/dot-file%03d", i), []byte("x"), 0644))
+	}
+
+	client, err := dot.NewClient(dot.Config{
+		PackageDir:  "/test/packages",
+		TargetDir:   "/test/target",
+		FS:          fs,
+		Logger:      adapters.NewNoopLogger(),
+		Concurrency: 4,
+	})
+	require.NoError(t, err)
+	require.NoError(t, client.Manage(ctx, "many"))
+	return client
+}
+
+func TestClient_Doctor_PublishesProgress(t *testing.T) {
+	client := setupManyLinks(t, 250)
+
+	var checked []int
+	client.Events().Subscribe(func(ctx context.Context, event dot.Event) {
+		if event.Name == "doctor_links_checked" {
+			assert.Equal(t, 250, event.Fields["total"])
+			checked = append(checked, event.Fields["checked"].(int))
+		}
+	}, dot.TopicProgress)
+
+	report, err := client.DoctorWithScan(context.Background(), dot.ScanConfig{Mode: dot.ScanOff})
+	require.NoError(t, err)
+	assert.Equal(t, 250, report.Statistics.TotalLinks)
+
+	require.NotEmpty(t, checked)
+	assert.LessOrEqual(t, len(checked), 100)
+	assert.True(t, slices.IsSorted(checked), "progress should only advance")
+	assert.Equal(t, 250, checked[len(checked)-1])
+}
+
+func TestClient_Doctor_Cancelled(t *testing.T) {
+	client := setupManyLinks(t, 10)
+
+	// Cancel once the first link is checked
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client.Events().Subscribe(func(context.Context, dot.Event) { cancel() }, dot.TopicProgress)
+
+	_, err := client.DoctorWithScan(ctx, dot.ScopedScanConfig())
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Contains(t, err.Error(), "link checks stopped after")
+}
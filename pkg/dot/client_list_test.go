@@ -0,0 +1,58 @@
+package dot_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+func TestClient_ListWithOptions(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+
+	for _, pkg := range []string{"vim", "vim-plugins", "zsh", ".git"} {
+		require.NoError(t, fs.MkdirAll(ctx, "/test/packages/"+pkg, 0755))
+	}
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/vim/dot-vimrc", []byte("set nu"), 0644))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target", 0755))
+
+	client, err := dot.NewClient(dot.Config{
+		PackageDir: "/test/packages",
+		TargetDir:  "/test/target",
+		FS:         fs,
+		Logger:     adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+	require.NoError(t, client.Manage(ctx, "vim"))
+
+	names := func(packages []dot.PackageInfo) []string {
+		var out []string
+		for _, pkg := range packages {
+			out = append(out, pkg.Name)
+		}
+		return out
+	}
+
+	all, err := client.ListWithOptions(ctx, dot.ListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"vim", "vim-plugins", "zsh"}, names(all))
+	assert.True(t, all[0].Installed)
+	assert.Equal(t, 1, all[0].LinkCount)
+	assert.False(t, all[1].Installed)
+
+	installed, err := client.ListWithOptions(ctx, dot.ListOptions{Installed: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"vim"}, names(installed))
+
+	available, err := client.ListWithOptions(ctx, dot.ListOptions{Available: true, Pattern: "vim*"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"vim-plugins"}, names(available))
+
+	_, err = client.ListWithOptions(ctx, dot.ListOptions{Pattern: "["})
+	assert.Error(t, err)
+}
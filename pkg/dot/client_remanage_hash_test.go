@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/internal/manifest"
 	"github.com/jamesainslie/dot/pkg/dot"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -81,3 +82,39 @@ func TestClient_RemanageNotInstalled(t *testing.T) {
 	require.NoError(t, err)
 	assert.Len(t, status.Packages, 1)
 }
+
+func TestClient_Remanage_FileHashes(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/app", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/app/dot-config", []byte("version1"), 0644))
+
+	client, err := dot.NewClient(dot.Config{
+		PackageDir: "/test/packages",
+		TargetDir:  "/test/target",
+		FS:         fs,
+		Logger:     adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+	require.NoError(t, client.Manage(ctx, "app"))
+
+	result := manifest.NewFSManifestStore(fs).Load(ctx, dot.NewTargetPath("/test/target").Unwrap())
+	require.True(t, result.IsOk())
+	files := result.Unwrap().Packages["app"].Files
+	require.Contains(t, files, "dot-config", "manage records file hashes")
+
+	plan, err := client.PlanRemanage(ctx, "app")
+	require.NoError(t, err)
+	assert.Empty(t, plan.Operations, "unchanged package is skipped")
+
+	plan, err = client.PlanRemanageWithOptions(ctx, dot.RemanageOptions{Force: true}, "app")
+	require.NoError(t, err)
+	assert.NotEmpty(t, plan.Operations, "force plans unchanged packages")
+
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/app/dot-config", []byte("version two"), 0644))
+	plan, err = client.PlanRemanage(ctx, "app")
+	require.NoError(t, err)
+	assert.NotEmpty(t, plan.Operations, "changed package is planned")
+}
@@ -0,0 +1,167 @@
+package dot_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+func setupStowedTree(t *testing.T) (*adapters.MemFS, *dot.Client) {
+	t.Helper()
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/vim", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/vim/dot-vimrc", []byte("set nocompatible"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/vim/dot-gvimrc", []byte("set guifont"), 0644))
+
+	client, err := dot.NewClient(dot.Config{
+		PackageDir: "/test/packages",
+		TargetDir:  "/test/target",
+		FS:         fs,
+		Logger:     adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+	return fs, client
+}
+
+func TestClient_Manage_RegistersExistingLinks(t *testing.T) {
+	fs, client := setupStowedTree(t)
+	ctx := context.Background()
+
+	// Links left behind by GNU Stow, without a manifest
+	require.NoError(t, fs.Symlink(ctx, "../packages/vim/dot-vimrc", "/test/target/.vimrc"))
+	require.NoError(t, fs.Symlink(ctx, "../packages/vim/dot-gvimrc", "/test/target/.gvimrc"))
+
+	plan, err := client.PlanManage(ctx, "vim")
+	require.NoError(t, err)
+	assert.Empty(t, plan.Operations)
+	assert.Len(t, plan.Satisfied, 2)
+
+	require.NoError(t, client.Manage(ctx, "vim"))
+
+	status, err := client.Status(ctx, "vim")
+	require.NoError(t, err)
+	require.Len(t, status.Packages, 1)
+	assert.Equal(t, 2, status.Packages[0].LinkCount)
+
+	// Existing links are left untouched
+	target, err := fs.ReadLink(ctx, "/test/target/.vimrc")
+	require.NoError(t, err)
+	assert.Equal(t, "../packages/vim/dot-vimrc", target)
+}
+
+func TestClient_Manage_PartiallyStowed(t *testing.T) {
+	fs, client := setupStowedTree(t)
+	ctx := context.Background()
+
+	require.NoError(t, fs.Symlink(ctx, "/test/packages/vim/dot-vimrc", "/test/target/.vimrc"))
+
+	require.NoError(t, client.Manage(ctx, "vim"))
+
+	target, err := fs.ReadLink(ctx, "/test/target/.gvimrc")
+	require.NoError(t, err)
+	assert.Equal(t, "/test/packages/vim/dot-gvimrc", target)
+
+	status, err := client.Status(ctx, "vim")
+	require.NoError(t, err)
+	require.Len(t, status.Packages, 1)
+	assert.Equal(t, 2, status.Packages[0].LinkCount)
+}
+
+func TestClient_Manage_ReportsConflicts(t *testing.T) {
+	fs, client := setupStowedTree(t)
+	ctx := context.Background()
+
+	require.NoError(t, fs.WriteFile(ctx, "/test/target/.vimrc", []byte("local"), 0644))
+
+	err := client.Manage(ctx, "vim")
+	require.Error(t, err)
+	var conflict dot.ErrConflict
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, "/test/target/.vimrc", conflict.Path)
+
+	// Nothing is linked when the plan has conflicts
+	assert.False(t, fs.Exists(ctx, "/test/target/.gvimrc"))
+}
+
+func TestClient_Takeover_ReportsAndRegisters(t *testing.T) {
+	fs, client := setupStowedTree(t)
+	ctx := context.Background()
+
+	require.NoError(t, fs.Symlink(ctx, "../packages/vim/dot-vimrc", "/test/target/.vimrc"))
+	require.NoError(t, fs.WriteFile(ctx, "/test/target/.gvimrc", []byte("local"), 0644))
+
+	report, err := client.Takeover(ctx, "vim")
+	require.NoError(t, err)
+	require.Len(t, report.Packages, 1)
+
+	vim := report.Packages[0]
+	assert.Equal(t, "vim", vim.Name)
+	assert.Equal(t, []string{".vimrc"}, vim.Linked)
+	assert.Empty(t, vim.Missing)
+	require.Len(t, vim.Conflicts, 1)
+	assert.Equal(t, ".gvimrc", vim.Conflicts[0].Path)
+	assert.True(t, vim.Registered)
+	assert.False(t, vim.Complete())
+
+	status, err := client.Status(ctx, "vim")
+	require.NoError(t, err)
+	require.Len(t, status.Packages, 1)
+	assert.Equal(t, 1, status.Packages[0].LinkCount)
+
+	// Conflicting file is left untouched
+	data, err := fs.ReadFile(ctx, "/test/target/.gvimrc")
+	require.NoError(t, err)
+	assert.Equal(t, "local", string(data))
+}
+
+func TestClient_Takeover_NothingLinked(t *testing.T) {
+	fs, client := setupStowedTree(t)
+	ctx := context.Background()
+
+	report, err := client.Takeover(ctx, "vim")
+	require.NoError(t, err)
+	require.Len(t, report.Packages, 1)
+	assert.Empty(t, report.Packages[0].Linked)
+	assert.Equal(t, []string{".gvimrc", ".vimrc"}, report.Packages[0].Missing)
+	assert.False(t, report.Packages[0].Registered)
+
+	// No links are created
+	assert.False(t, fs.Exists(ctx, "/test/target/.vimrc"))
+	status, err := client.Status(ctx, "vim")
+	require.NoError(t, err)
+	assert.Empty(t, status.Packages)
+}
+
+func TestClient_Takeover_DryRun(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/vim", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/vim/dot-vimrc", []byte("set nocompatible"), 0644))
+	require.NoError(t, fs.Symlink(ctx, "/test/packages/vim/dot-vimrc", "/test/target/.vimrc"))
+
+	client, err := dot.NewClient(dot.Config{
+		PackageDir: "/test/packages",
+		TargetDir:  "/test/target",
+		FS:         fs,
+		Logger:     adapters.NewNoopLogger(),
+		DryRun:     true,
+	})
+	require.NoError(t, err)
+
+	report, err := client.Takeover(ctx, "vim")
+	require.NoError(t, err)
+	require.Len(t, report.Packages, 1)
+	assert.Equal(t, []string{".vimrc"}, report.Packages[0].Linked)
+	assert.True(t, report.Packages[0].Complete())
+	assert.False(t, report.Packages[0].Registered)
+	assert.False(t, fs.Exists(ctx, "/test/target/.dot-manifest.json"))
+}
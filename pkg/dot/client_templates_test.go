@@ -0,0 +1,110 @@
+package dot_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+func TestClient_ManageRendersTemplates(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/git", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/.dot-values", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/.dot-values/default.yaml", []byte("email: me@example.com\n"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/git/dot-gitconfig.tmpl", []byte("email = {{ .Values.email }}\n"), 0644))
+
+	cfg := dot.Config{
+		PackageDir:       "/test/packages",
+		TargetDir:        "/test/target",
+		TemplateCacheDir: "/test/cache",
+		FS:               fs,
+		Logger:           adapters.NewNoopLogger(),
+	}
+	client, err := dot.NewClient(cfg)
+	require.NoError(t, err)
+
+	require.NoError(t, client.Manage(ctx, "git"))
+
+	// Link uses the name without the template suffix and points at the rendered copy
+	target, err := fs.ReadLink(ctx, "/test/target/.gitconfig")
+	require.NoError(t, err)
+	assert.Equal(t, "/test/cache/git/dot-gitconfig", target)
+
+	data, err := fs.ReadFile(ctx, "/test/cache/git/dot-gitconfig")
+	require.NoError(t, err)
+	assert.Equal(t, "email = me@example.com\n", string(data))
+
+	report, err := client.Doctor(ctx)
+	require.NoError(t, err)
+	for _, issue := range report.Issues {
+		assert.NotEqual(t, dot.IssueStaleRender, issue.Type)
+	}
+
+	// Changing values makes the render stale
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/.dot-values/default.yaml", []byte("email: new@example.com\n"), 0644))
+
+	report, err = client.Doctor(ctx)
+	require.NoError(t, err)
+	var stale bool
+	for _, issue := range report.Issues {
+		if issue.Type == dot.IssueStaleRender {
+			stale = true
+			assert.Equal(t, ".gitconfig", issue.Path)
+		}
+	}
+	assert.True(t, stale, "doctor should report stale render")
+
+	// Remanage re-renders even though the package content is unchanged
+	require.NoError(t, client.Remanage(ctx, "git"))
+	data, err = fs.ReadFile(ctx, "/test/cache/git/dot-gitconfig")
+	require.NoError(t, err)
+	assert.Equal(t, "email = new@example.com\n", string(data))
+}
+
+func TestClient_ManageRendersSecrets(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+	t.Setenv("DOTFILES_GITHUB_TOKEN", "ghp_123")
+
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/gh", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/gh/dot-netrc.tmpl", []byte(`password {{ secret "github-token" }}`), 0644))
+
+	client, err := dot.NewClient(dot.Config{
+		PackageDir:       "/test/packages",
+		TargetDir:        "/test/target",
+		TemplateCacheDir: "/test/cache",
+		SecretsEnvPrefix: "DOTFILES_",
+		FS:               fs,
+		Logger:           adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, client.Manage(ctx, "gh"))
+
+	data, err := fs.ReadFile(ctx, "/test/cache/gh/dot-netrc")
+	require.NoError(t, err)
+	assert.Equal(t, "password ghp_123", string(data))
+	info, err := fs.Stat(ctx, "/test/cache/gh/dot-netrc")
+	require.NoError(t, err)
+	assert.Equal(t, 0600, int(info.Mode().Perm()), "rendered secrets are readable by the owner only")
+}
+
+func TestNewClient_UnknownSecretsProvider(t *testing.T) {
+	_, err := dot.NewClient(dot.Config{
+		PackageDir:      "/test/packages",
+		TargetDir:       "/test/target",
+		SecretsProvider: "vault",
+		FS:              adapters.NewMemFS(),
+		Logger:          adapters.NewNoopLogger(),
+	})
+	assert.ErrorContains(t, err, `unknown secrets provider "vault"`)
+}
@@ -0,0 +1,55 @@
+package dot
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_CloneOnMemFS(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	require.NoError(t, fs.MkdirAll(ctx, "/home/user", 0755))
+
+	client, err := NewClient(Config{
+		PackageDir: "/home/user/dotfiles",
+		TargetDir:  "/home/user",
+		FS:         fs,
+		Logger:     adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+	_, isSnapshot := client.cloneSvc.cloner.(*adapters.FSCloner)
+	assert.True(t, isSnapshot, "clones into an in-memory filesystem are snapshots")
+	assert.Nil(t, client.sparse)
+
+	url := "https://example.com/dotfiles.git"
+	client.cloneSvc.cloner = adapters.NewFixtureCloner(fs, map[string]adapters.GitFixture{
+		url: {
+			Commit: "0123456789abcdef0123456789abcdef01234567",
+			Files: map[string]string{
+				"vim/dot-vimrc": "syntax on",
+				"zsh/dot-zshrc": "export EDITOR=vim",
+			},
+		},
+	})
+
+	report, err := client.Clone(ctx, url, CloneOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "main", report.Branch)
+	assert.Equal(t, "0123456789abcdef0123456789abcdef01234567", report.Commit)
+	assert.ElementsMatch(t, []string{"vim", "zsh"}, report.Installed)
+
+	target, err := fs.ReadLink(ctx, "/home/user/.vimrc")
+	require.NoError(t, err)
+	assert.Contains(t, target, "dotfiles/vim/dot-vimrc")
+
+	require.NoError(t, client.Unmanage(ctx, "zsh"))
+	status, err := client.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, status.Packages, 1)
+	assert.Equal(t, "vim", status.Packages[0].Name)
+	assert.False(t, fs.Exists(ctx, "/home/user/.zshrc"))
+}
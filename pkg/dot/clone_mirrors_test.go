@@ -0,0 +1,196 @@
+package dot
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/internal/domain"
+	"github.com/jamesainslie/dot/internal/manifest"
+)
+
+func newMirrorTestService(t *testing.T, cloneFn func(ctx context.Context, url string, dest string, opts adapters.CloneOptions) error) *CloneService {
+	t.Helper()
+	fs := adapters.NewMemFS()
+	logger := adapters.NewNoopLogger()
+	manageSvc := &ManageService{fs: fs, logger: logger, packageDir: "/packages", targetDir: "/home", dryRun: true}
+	selector := &mockPackageSelector{
+		selectFn: func(ctx context.Context, packages []string) ([]string, error) {
+			return nil, nil
+		},
+	}
+	cloner := &mockGitCloner{cloneFn: func(ctx context.Context, url string, dest string, opts adapters.CloneOptions) error {
+		if err := cloneFn(ctx, url, dest, opts); err != nil {
+			return err
+		}
+		return fs.MkdirAll(ctx, dest+"/dot-vim", 0755)
+	}}
+	return newCloneService(fs, logger, manageSvc, cloner, selector, "/packages", "/home", true, false)
+}
+
+func TestCloneService_Clone_FallsBackToMirror(t *testing.T) {
+	var tried []string
+	svc := newMirrorTestService(t, func(ctx context.Context, url string, dest string, opts adapters.CloneOptions) error {
+		tried = append(tried, url)
+		if url == "https://mirror.example.com/dotfiles" {
+			return nil
+		}
+		return errors.New("connection refused")
+	})
+
+	type attempt struct {
+		url string
+		ok  bool
+	}
+	var attempts []attempt
+	svc.events = domain.NewEventBus()
+	svc.events.Subscribe(func(ctx context.Context, event Event) {
+		switch event.Name {
+		case "clone_attempt_failed", "clone_attempt_succeeded":
+			attempts = append(attempts, attempt{event.Fields["url"].(string), event.Err == nil})
+		}
+	}, TopicProgress, TopicWarning)
+	_, err := svc.Clone(context.Background(), "https://github.com/user/dotfiles", CloneOptions{
+		Mirrors: []string{"https://git.example.com/dotfiles", "https://mirror.example.com/dotfiles", "https://unused.example.com/dotfiles"},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"https://github.com/user/dotfiles",
+		"https://git.example.com/dotfiles",
+		"https://mirror.example.com/dotfiles",
+	}, tried)
+	assert.Equal(t, []attempt{
+		{"https://github.com/user/dotfiles", false},
+		{"https://git.example.com/dotfiles", false},
+		{"https://mirror.example.com/dotfiles", true},
+	}, attempts)
+}
+
+func TestCloneService_Clone_AllMirrorsFail(t *testing.T) {
+	svc := newMirrorTestService(t, func(ctx context.Context, url string, dest string, opts adapters.CloneOptions) error {
+		return errors.New("unreachable " + url)
+	})
+
+	_, err := svc.Clone(context.Background(), "https://github.com/user/dotfiles", CloneOptions{
+		Mirrors: []string{"https://mirror.example.com/dotfiles"},
+	})
+
+	var cloneErr ErrCloneFailed
+	require.ErrorAs(t, err, &cloneErr)
+	assert.Equal(t, "https://github.com/user/dotfiles", cloneErr.URL)
+	assert.Contains(t, err.Error(), "unreachable https://github.com/user/dotfiles")
+	assert.Contains(t, err.Error(), "unreachable https://mirror.example.com/dotfiles")
+}
+
+func TestCloneService_Clone_AttemptTimeout(t *testing.T) {
+	svc := newMirrorTestService(t, func(ctx context.Context, url string, dest string, opts adapters.CloneOptions) error {
+		if url == "https://stalled.example.com/dotfiles" {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		_, hasDeadline := ctx.Deadline()
+		assert.True(t, hasDeadline)
+		return nil
+	})
+
+	_, err := svc.Clone(context.Background(), "https://stalled.example.com/dotfiles", CloneOptions{
+		Mirrors:        []string{"https://mirror.example.com/dotfiles"},
+		AttemptTimeout: 10 * time.Millisecond,
+	})
+
+	require.NoError(t, err)
+}
+
+func TestCloneService_Clone_CancelledStopsMirrors(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var tried int
+	svc := newMirrorTestService(t, func(ctx context.Context, url string, dest string, opts adapters.CloneOptions) error {
+		tried++
+		cancel()
+		return ctx.Err()
+	})
+
+	_, err := svc.Clone(ctx, "https://github.com/user/dotfiles", CloneOptions{
+		Mirrors: []string{"https://mirror.example.com/dotfiles"},
+	})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, tried)
+}
+
+func TestCloneService_Clone_Depth(t *testing.T) {
+	tests := []struct {
+		name        string
+		fullHistory bool
+		want        int
+	}{
+		{name: "shallow by default", want: 1},
+		{name: "full history", fullHistory: true, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var depth int
+			svc := newMirrorTestService(t, func(ctx context.Context, url string, dest string, opts adapters.CloneOptions) error {
+				depth = opts.Depth
+				return nil
+			})
+
+			_, err := svc.Clone(context.Background(), "https://github.com/user/dotfiles", CloneOptions{FullHistory: tt.fullHistory})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, depth)
+		})
+	}
+}
+
+func TestCloneService_Clone_MachineBranch(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	logger := adapters.NewNoopLogger()
+	manageSvc := &ManageService{fs: fs, logger: logger, packageDir: "/packages", targetDir: "/home", dryRun: true}
+
+	var depth int
+	cloner := &mockCheckoutCloner{mockGitCloner: mockGitCloner{cloneFn: func(ctx context.Context, url string, dest string, opts adapters.CloneOptions) error {
+		depth = opts.Depth
+		return fs.MkdirAll(ctx, dest+"/dot-vim", 0755)
+	}}}
+	svc := newCloneService(fs, logger, manageSvc, cloner, &mockPackageSelector{}, "/packages", "/home", true, false)
+
+	_, err := svc.Clone(ctx, "https://github.com/user/dotfiles", CloneOptions{Branch: "main", MachineBranch: "laptop"})
+	require.NoError(t, err)
+	assert.Equal(t, "laptop", cloner.checkedOut)
+	assert.Zero(t, depth, "machine branches need full history to rebase")
+}
+
+func TestCloneService_UpdateManifestRepository_ManifestDir(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	logger := adapters.NewNoopLogger()
+	store := manifest.NewFSManifestStoreWithDir(fs, "/state/manifest")
+	manageSvc := &ManageService{manifestSvc: newManifestService(fs, logger, store)}
+	svc := newCloneService(fs, logger, manageSvc, nil, &mockPackageSelector{}, "/packages", "/home", false, false)
+
+	require.NoError(t, svc.updateManifestRepository(ctx, manifest.RepositoryInfo{Branch: "laptop", BaseBranch: "main"}))
+
+	// Recorded where sync reads it, not in the target directory
+	m := store.Load(ctx, NewTargetPath("/home").Unwrap()).Unwrap()
+	info, ok := m.GetRepository()
+	require.True(t, ok)
+	assert.Equal(t, "main", info.BaseBranch)
+	assert.False(t, fs.Exists(ctx, "/home/.dot-manifest.json"))
+}
+
+func TestCloneService_Clone_MachineBranchUnsupported(t *testing.T) {
+	svc := newMirrorTestService(t, func(ctx context.Context, url string, dest string, opts adapters.CloneOptions) error {
+		return nil
+	})
+
+	_, err := svc.Clone(context.Background(), "https://github.com/user/dotfiles", CloneOptions{Branch: "main", MachineBranch: "laptop"})
+	assert.ErrorContains(t, err, "machine branches are not supported")
+}
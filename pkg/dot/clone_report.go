@@ -0,0 +1,167 @@
+package dot
+
+import (
+	"errors"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/jamesainslie/dot/internal/bootstrap"
+)
+
+// CloneReport summarizes what a clone did, for display and for
+// provisioning logs. A failed clone returns the report as far as it got.
+type CloneReport struct {
+	// Repository is the URL the repository was cloned from, which is a
+	// mirror if the primary URL failed, or the path of the bundle.
+	Repository string `json:"repository"`
+
+	// Bundle is set when the packages came from a bundle.
+	Bundle bool `json:"bundle,omitempty"`
+
+	// Branch is the checked out branch and BaseBranch the branch a machine
+	// branch is based on.
+	Branch     string `json:"branch,omitempty"`
+	BaseBranch string `json:"base_branch,omitempty"`
+
+	// Commit is the SHA of the checked out commit.
+	Commit string `json:"commit,omitempty"`
+
+	PackageDir string `json:"package_dir"`
+	TargetDir  string `json:"target_dir"`
+
+	// Profile is the bootstrap profile packages were selected by, if any.
+	Profile string `json:"profile,omitempty"`
+
+	// Resumed is set when an interrupted clone was resumed.
+	Resumed bool `json:"resumed,omitempty"`
+
+	// DryRun is set when nothing was changed.
+	DryRun bool `json:"dry_run,omitempty"`
+
+	// Selected are the packages chosen for installation, and Installed
+	// those that were installed, which is none in dry-run mode or when
+	// installing failed.
+	Selected  []string         `json:"selected"`
+	Installed []string         `json:"installed"`
+	Skipped   []SkippedPackage `json:"skipped"`
+
+	// Conflicts are the target paths that prevented installing packages.
+	Conflicts []CloneConflict `json:"conflicts"`
+}
+
+// SkipReason is why a package of the repository was not installed.
+type SkipReason string
+
+const (
+	// SkipPlatform marks packages restricted to other platforms.
+	SkipPlatform SkipReason = "platform"
+	// SkipProfile marks packages that are not in the selected profile.
+	SkipProfile SkipReason = "profile"
+	// SkipNotRequested marks packages left out of CloneOptions.Packages.
+	SkipNotRequested SkipReason = "not_requested"
+	// SkipDeselected marks packages not picked in interactive selection.
+	SkipDeselected SkipReason = "deselected"
+)
+
+// SkippedPackage is a package of the repository that was not installed.
+type SkippedPackage struct {
+	Name   string     `json:"name"`
+	Reason SkipReason `json:"reason"`
+
+	// Detail explains the reason, such as "requires darwin".
+	Detail string `json:"detail,omitempty"`
+}
+
+// CloneConflict is a target path that prevented installing packages.
+type CloneConflict struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// newCloneReport creates an empty report of the clone service.
+func (s *CloneService) newCloneReport(repository string) CloneReport {
+	return CloneReport{
+		Repository: repository,
+		PackageDir: s.packageDir,
+		TargetDir:  s.targetDir,
+		DryRun:     s.dryRun,
+		Selected:   []string{},
+		Installed:  []string{},
+		Skipped:    []SkippedPackage{},
+		Conflicts:  []CloneConflict{},
+	}
+}
+
+// selectedProfile returns the bootstrap profile packages are selected by,
+// following the order of selectPackagesWithBootstrap.
+func selectedProfile(config bootstrap.Config, hasBootstrap bool, opts CloneOptions) string {
+	switch {
+	case !hasBootstrap || len(opts.Packages) > 0:
+		return ""
+	case opts.Profile != "":
+		return opts.Profile
+	case opts.Interactive:
+		return ""
+	default:
+		return config.Defaults.Profile
+	}
+}
+
+// skippedPackages lists the packages of the repository that are not in
+// selected, with the reason each was left out. Packages are those of the
+// bootstrap configuration if there is one, and discovered otherwise.
+func skippedPackages(config bootstrap.Config, hasBootstrap bool, discovered, selected []string, opts CloneOptions, profile string) []SkippedPackage {
+	names := discovered
+	platforms := map[string][]string{}
+	onPlatform := map[string]bool{}
+	if hasBootstrap {
+		names = extractPackageNames(config.Packages)
+		for _, spec := range config.Packages {
+			platforms[spec.Name] = spec.Platform
+		}
+		for _, spec := range bootstrap.FilterPackagesByPlatform(config.Packages, runtime.GOOS) {
+			onPlatform[spec.Name] = true
+		}
+	}
+
+	skipped := []SkippedPackage{}
+	for _, name := range names {
+		if slices.Contains(selected, name) {
+			continue
+		}
+		skip := SkippedPackage{Name: name}
+		switch {
+		case hasBootstrap && !onPlatform[name]:
+			skip.Reason = SkipPlatform
+			skip.Detail = "requires " + strings.Join(platforms[name], ", ")
+		case len(opts.Packages) > 0:
+			skip.Reason = SkipNotRequested
+		case profile != "":
+			skip.Reason = SkipProfile
+			skip.Detail = "not in profile " + profile
+		default:
+			skip.Reason = SkipDeselected
+		}
+		skipped = append(skipped, skip)
+	}
+	return skipped
+}
+
+// cloneConflicts returns the conflicts that made err.
+func cloneConflicts(err error) []CloneConflict {
+	errs := []error{err}
+	var multiple ErrMultiple
+	if errors.As(err, &multiple) {
+		errs = multiple.Errors
+	}
+
+	conflicts := []CloneConflict{}
+	for _, e := range errs {
+		var conflict ErrConflict
+		if errors.As(e, &conflict) {
+			conflicts = append(conflicts, CloneConflict{Path: conflict.Path, Reason: conflict.Reason})
+		}
+	}
+	return conflicts
+}
@@ -0,0 +1,135 @@
+package dot
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/internal/bootstrap"
+)
+
+// otherPlatform returns a platform that is not the current one.
+func otherPlatform() string {
+	if runtime.GOOS == "plan9" {
+		return "linux"
+	}
+	return "plan9"
+}
+
+func TestSkippedPackages(t *testing.T) {
+	config := bootstrap.Config{
+		Packages: []bootstrap.PackageSpec{
+			{Name: "vim"},
+			{Name: "zsh"},
+			{Name: "karabiner", Platform: []string{otherPlatform()}},
+		},
+		Profiles: map[string]bootstrap.Profile{"editor": {Packages: []string{"vim", "karabiner"}}},
+	}
+
+	t.Run("profile", func(t *testing.T) {
+		opts := CloneOptions{Profile: "editor"}
+		profile := selectedProfile(config, true, opts)
+		assert.Equal(t, "editor", profile)
+		assert.Equal(t, []SkippedPackage{
+			{Name: "zsh", Reason: SkipProfile, Detail: "not in profile editor"},
+			{Name: "karabiner", Reason: SkipPlatform, Detail: "requires " + otherPlatform()},
+		}, skippedPackages(config, true, nil, []string{"vim"}, opts, profile))
+	})
+
+	t.Run("requested packages", func(t *testing.T) {
+		opts := CloneOptions{Packages: []string{"zsh"}, Profile: "editor"}
+		profile := selectedProfile(config, true, opts)
+		assert.Empty(t, profile)
+		skipped := skippedPackages(config, true, nil, opts.Packages, opts, profile)
+		require.Len(t, skipped, 2)
+		assert.Equal(t, SkippedPackage{Name: "vim", Reason: SkipNotRequested}, skipped[0])
+		assert.Equal(t, SkipPlatform, skipped[1].Reason)
+	})
+
+	t.Run("interactive", func(t *testing.T) {
+		config := config
+		config.Defaults.Profile = "editor"
+		opts := CloneOptions{Interactive: true}
+		profile := selectedProfile(config, true, opts)
+		assert.Empty(t, profile, "interactive selection overrides the default profile")
+		skipped := skippedPackages(config, true, nil, []string{"zsh"}, opts, profile)
+		assert.Equal(t, SkippedPackage{Name: "vim", Reason: SkipDeselected}, skipped[0])
+	})
+
+	t.Run("without bootstrap", func(t *testing.T) {
+		opts := CloneOptions{Profile: "editor"}
+		profile := selectedProfile(bootstrap.Config{}, false, opts)
+		assert.Empty(t, profile)
+		assert.Equal(t, []SkippedPackage{{Name: "tmux", Reason: SkipDeselected}},
+			skippedPackages(bootstrap.Config{}, false, []string{"tmux", "vim"}, []string{"vim"}, opts, profile))
+	})
+}
+
+func TestCloneConflicts(t *testing.T) {
+	single := fmt.Errorf("install packages: %w", ErrConflict{Path: "/home/.vimrc", Reason: "file exists"})
+	assert.Equal(t, []CloneConflict{{Path: "/home/.vimrc", Reason: "file exists"}}, cloneConflicts(single))
+
+	multiple := fmt.Errorf("install packages: %w", ErrMultiple{Errors: []error{
+		ErrConflict{Path: "/home/.vimrc", Reason: "file exists"},
+		ErrConflict{Path: "/home/.zshrc", Reason: "file exists"},
+	}})
+	assert.Len(t, cloneConflicts(multiple), 2)
+
+	assert.Empty(t, cloneConflicts(assert.AnError))
+}
+
+func TestClient_CloneBundle_Report(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	_, err := setupExport(t, fs).Export(ctx, ExportOptions{Output: "/out/bundle.tar.gz"})
+	require.NoError(t, err)
+
+	report, err := newBundleClient(t, fs, false).CloneBundle(ctx, "/out/bundle.tar.gz", CloneOptions{})
+	require.NoError(t, err)
+	assert.True(t, report.Bundle)
+	assert.Equal(t, "/out/bundle.tar.gz", report.Repository)
+	assert.Equal(t, "/dst/packages", report.PackageDir)
+	assert.Equal(t, []string{"vim"}, report.Selected)
+	assert.Equal(t, []string{"vim"}, report.Installed)
+	assert.Equal(t, []SkippedPackage{
+		{Name: "zsh", Reason: SkipNotRequested, Detail: "not installed on the exporting machine"},
+		{Name: "tmux", Reason: SkipNotRequested, Detail: "not installed on the exporting machine"},
+	}, report.Skipped)
+	assert.Empty(t, report.Conflicts)
+}
+
+func TestClient_CloneBundle_ReportConflicts(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	_, err := setupExport(t, fs).Export(ctx, ExportOptions{Output: "/out/bundle.tar.gz"})
+	require.NoError(t, err)
+
+	client := newBundleClient(t, fs, false)
+	require.NoError(t, fs.WriteFile(ctx, "/dst/target/.vimrc", []byte("local"), 0644))
+
+	report, err := client.CloneBundle(ctx, "/out/bundle.tar.gz", CloneOptions{Profile: "editor"})
+	require.Error(t, err)
+	assert.Equal(t, "editor", report.Profile)
+	assert.Equal(t, []string{"vim"}, report.Selected)
+	assert.Empty(t, report.Installed)
+	require.NotEmpty(t, report.Conflicts)
+	assert.Equal(t, "/dst/target/.vimrc", report.Conflicts[0].Path)
+}
+
+func TestClient_CloneBundle_ReportDryRun(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	_, err := setupExport(t, fs).Export(ctx, ExportOptions{Output: "/out/bundle.tar.gz"})
+	require.NoError(t, err)
+
+	report, err := newBundleClient(t, fs, true).CloneBundle(ctx, "/out/bundle.tar.gz", CloneOptions{})
+	require.NoError(t, err)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, []string{"vim"}, report.Selected)
+	assert.Empty(t, report.Installed)
+}
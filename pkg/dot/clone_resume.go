@@ -0,0 +1,92 @@
+package dot
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"github.com/jamesainslie/dot/internal/manifest"
+)
+
+// partialClone describes a package directory left behind by a clone that
+// was interrupted after the repository was cloned but before the clone
+// finished installing packages.
+type partialClone struct {
+	// url is the origin of the repository, or empty when the git backend
+	// cannot report it.
+	url string
+
+	// dirty reports uncommitted changes or local commits, which cleaning
+	// up would lose.
+	dirty bool
+}
+
+// detectPartialClone reports whether the package directory holds a partial
+// clone of repoURL or one of opts.Mirrors: a git repository whose clone
+// was never recorded in the manifest. A repository cloned from another
+// URL is not a partial clone of this one.
+func (s *CloneService) detectPartialClone(ctx context.Context, repoURL string, opts CloneOptions) (partialClone, bool, error) {
+	if !s.fs.Exists(ctx, filepath.Join(s.packageDir, ".git")) {
+		return partialClone{}, false, nil
+	}
+
+	m, err := s.loadManifest(ctx)
+	if err != nil {
+		return partialClone{}, false, err
+	}
+	if _, recorded := m.GetRepository(); recorded {
+		return partialClone{}, false, nil
+	}
+
+	if s.repository == nil {
+		return partialClone{}, true, nil
+	}
+	status, err := s.repository.Status(ctx, s.packageDir)
+	if err != nil {
+		return partialClone{}, false, fmt.Errorf("inspect %s: %w", s.packageDir, err)
+	}
+	if status.RemoteURL != repoURL && !slices.Contains(opts.Mirrors, status.RemoteURL) {
+		return partialClone{}, false, nil
+	}
+	return partialClone{
+		url:   status.RemoteURL,
+		dirty: len(status.Changes) > 0 || status.Ahead > 0,
+	}, true, nil
+}
+
+// cleanupPartialClone removes the contents of the package directory so
+// the repository can be cloned again. The directory itself is kept. A
+// partial clone with uncommitted changes or local commits is not removed.
+func (s *CloneService) cleanupPartialClone(ctx context.Context, partial partialClone) error {
+	if partial.dirty {
+		return ErrPartialClone{Path: s.packageDir, URL: partial.url, Reason: "it has uncommitted changes or local commits"}
+	}
+
+	entries, err := s.fs.ReadDir(ctx, s.packageDir)
+	if err != nil {
+		return fmt.Errorf("read packageDir: %w", err)
+	}
+	s.logger.Info(ctx, "removing_partial_clone", "path", s.packageDir, "entries", len(entries))
+	for _, entry := range entries {
+		if err := s.fs.RemoveAll(ctx, filepath.Join(s.packageDir, entry.Name())); err != nil {
+			return fmt.Errorf("remove partial clone: %w", err)
+		}
+	}
+	return nil
+}
+
+// loadManifest loads the manifest of the target directory from the store
+// the other services read.
+func (s *CloneService) loadManifest(ctx context.Context) (manifest.Manifest, error) {
+	targetPathResult := NewTargetPath(s.targetDir)
+	if !targetPathResult.IsOk() {
+		return manifest.Manifest{}, targetPathResult.UnwrapErr()
+	}
+
+	result := s.manifestStore().Load(ctx, targetPathResult.Unwrap())
+	if !result.IsOk() {
+		return manifest.Manifest{}, fmt.Errorf("load manifest: %w", result.UnwrapErr())
+	}
+	return result.Unwrap(), nil
+}
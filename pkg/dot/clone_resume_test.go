@@ -0,0 +1,116 @@
+package dot
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+)
+
+const partialCloneURL = "https://github.com/user/dotfiles"
+
+// newPartialCloneService returns a clone service whose package directory
+// holds a clone of partialCloneURL that installed no packages.
+func newPartialCloneService(t *testing.T, status adapters.RepoStatus, dryRun bool) (*CloneService, *adapters.MemFS, *int) {
+	t.Helper()
+	ctx := context.Background()
+	client, _ := newSparseTestClient(t, nil)
+	fs := client.config.FS.(*adapters.MemFS)
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/.git", 0755))
+
+	clones := 0
+	cloner := &mockGitCloner{cloneFn: func(ctx context.Context, url string, dest string, opts adapters.CloneOptions) error {
+		clones++
+		require.NoError(t, fs.MkdirAll(ctx, dest+"/.git", 0755))
+		require.NoError(t, fs.MkdirAll(ctx, dest+"/dot-vim", 0755))
+		return fs.WriteFile(ctx, dest+"/dot-vim/gvimrc", []byte("set go="), 0644)
+	}}
+	svc := newCloneService(fs, client.config.Logger, client.manageSvc, cloner, &mockPackageSelector{}, "/test/packages", "/test/target", dryRun, false)
+	svc.repository = &mockGitRepository{status: status}
+	return svc, fs, &clones
+}
+
+func TestCloneService_Clone_PartialCloneReported(t *testing.T) {
+	svc, _, clones := newPartialCloneService(t, adapters.RepoStatus{RemoteURL: partialCloneURL}, false)
+
+	_, err := svc.Clone(context.Background(), partialCloneURL, CloneOptions{})
+	var partial ErrPartialClone
+	require.ErrorAs(t, err, &partial)
+	assert.Equal(t, "/test/packages", partial.Path)
+	assert.Equal(t, partialCloneURL, partial.URL)
+	assert.Empty(t, partial.Reason)
+	assert.Zero(t, *clones)
+}
+
+func TestCloneService_Clone_Resume(t *testing.T) {
+	ctx := context.Background()
+	svc, fs, clones := newPartialCloneService(t, adapters.RepoStatus{RemoteURL: partialCloneURL}, false)
+
+	_, err := svc.Clone(ctx, partialCloneURL, CloneOptions{Resume: true})
+	require.NoError(t, err)
+
+	assert.Zero(t, *clones, "the partial clone is reused")
+	assert.True(t, fs.Exists(ctx, "/test/target/vimrc"))
+	m, err := svc.loadManifest(ctx)
+	require.NoError(t, err)
+	repo, ok := m.GetRepository()
+	require.True(t, ok)
+	assert.Equal(t, partialCloneURL, repo.URL)
+
+	// Once recorded, the clone is complete and no longer resumable
+	_, err = svc.Clone(ctx, partialCloneURL, CloneOptions{Resume: true})
+	assert.IsType(t, ErrPackageDirNotEmpty{}, err)
+}
+
+func TestCloneService_Clone_Cleanup(t *testing.T) {
+	ctx := context.Background()
+	svc, fs, clones := newPartialCloneService(t, adapters.RepoStatus{RemoteURL: partialCloneURL}, false)
+
+	_, err := svc.Clone(ctx, partialCloneURL, CloneOptions{Cleanup: true})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, *clones)
+	assert.False(t, fs.Exists(ctx, "/test/packages/dot-vim/vimrc"), "old contents are removed")
+	assert.True(t, fs.Exists(ctx, "/test/target/gvimrc"))
+}
+
+func TestCloneService_Clone_CleanupKeepsChanges(t *testing.T) {
+	ctx := context.Background()
+	status := adapters.RepoStatus{
+		RemoteURL: partialCloneURL,
+		Changes:   []adapters.FileChange{{Path: "dot-vim/vimrc", Status: "modified"}},
+	}
+	svc, fs, clones := newPartialCloneService(t, status, false)
+
+	_, err := svc.Clone(ctx, partialCloneURL, CloneOptions{Cleanup: true})
+	var partial ErrPartialClone
+	require.ErrorAs(t, err, &partial)
+	assert.NotEmpty(t, partial.Reason)
+	assert.Zero(t, *clones)
+	assert.True(t, fs.Exists(ctx, "/test/packages/dot-vim/vimrc"))
+}
+
+func TestCloneService_Clone_CleanupDryRun(t *testing.T) {
+	ctx := context.Background()
+	svc, fs, clones := newPartialCloneService(t, adapters.RepoStatus{RemoteURL: partialCloneURL}, true)
+
+	_, err := svc.Clone(ctx, partialCloneURL, CloneOptions{Cleanup: true})
+	require.NoError(t, err)
+	assert.Zero(t, *clones)
+	assert.True(t, fs.Exists(ctx, "/test/packages/dot-vim/vimrc"))
+}
+
+func TestCloneService_Clone_OtherRepositoryNotResumed(t *testing.T) {
+	svc, _, _ := newPartialCloneService(t, adapters.RepoStatus{RemoteURL: "https://github.com/other/dotfiles"}, false)
+
+	_, err := svc.Clone(context.Background(), partialCloneURL, CloneOptions{Resume: true})
+	assert.IsType(t, ErrPackageDirNotEmpty{}, err)
+
+	// A mirror of the requested repository is the same repository
+	svc, _, _ = newPartialCloneService(t, adapters.RepoStatus{RemoteURL: "https://gitlab.com/user/dotfiles"}, false)
+	_, err = svc.Clone(context.Background(), partialCloneURL, CloneOptions{Resume: true, Mirrors: []string{"https://gitlab.com/user/dotfiles"}})
+	assert.NoError(t, err)
+}
@@ -2,10 +2,11 @@ package dot
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"time"
 
@@ -26,6 +27,15 @@ type CloneService struct {
 	packageDir string
 	targetDir  string
 	dryRun     bool
+	offline    bool
+
+	// repository, if set, inspects existing clones so interrupted clones
+	// can be resumed or cleaned up.
+	repository adapters.GitRepository
+
+	// events receives the progress of clones and the problems worked
+	// around, if set.
+	events *EventBus
 }
 
 // newCloneService creates a new clone service.
@@ -38,6 +48,7 @@ func newCloneService(
 	packageDir string,
 	targetDir string,
 	dryRun bool,
+	offline bool,
 ) *CloneService {
 	return &CloneService{
 		fs:         fs,
@@ -48,6 +59,7 @@ func newCloneService(
 		packageDir: packageDir,
 		targetDir:  targetDir,
 		dryRun:     dryRun,
+		offline:    offline,
 	}
 }
 
@@ -67,12 +79,58 @@ type CloneOptions struct {
 	// Branch specifies which branch to clone.
 	// If empty, clones default branch.
 	Branch string
+
+	// MachineBranch, if set, is a per-machine branch checked out after
+	// cloning. It tracks the remote branch of that name if one exists and
+	// otherwise starts at the cloned branch. Sync rebases it onto the cloned
+	// branch. Implies FullHistory, since rebasing needs the shared history.
+	MachineBranch string
+
+	// FullHistory clones every commit instead of only the latest one.
+	// Shallow clones are faster but cannot be bisected or diffed against
+	// commits before the clone; see Client.Unshallow.
+	FullHistory bool
+
+	// Mirrors lists fallback repository URLs tried in order when cloning
+	// from the primary URL fails.
+	Mirrors []string
+
+	// AttemptTimeout bounds each clone attempt so a stalled URL does not
+	// block the remaining mirrors. If zero, attempts are not time-limited.
+	AttemptTimeout time.Duration
+
+	// Packages, if set, installs these packages instead of selecting them,
+	// and checks out only their directories, the repository configuration
+	// and the files at the repository root. Managing another package later
+	// extends the checkout.
+	Packages []string
+
+	// Lazy, if set, checks out only the repository configuration and the
+	// files at the repository root. Other packages are known from the git
+	// index and are checked out when first managed, including the
+	// packages this clone installs.
+	Lazy bool
+
+	// Resume continues a clone that was interrupted after the repository
+	// was cloned, selecting and installing packages from the existing
+	// clone instead of cloning again.
+	Resume bool
+
+	// Cleanup removes the partial clone of an interrupted clone and clones
+	// again. A partial clone with uncommitted changes or local commits is
+	// kept.
+	Cleanup bool
 }
 
+// repoConfigDir is the directory of the repository configuration, which
+// sparse clones always check out.
+var repoConfigDir = filepath.Join(".config", "dot")
+
 // Clone clones a repository and installs packages.
 //
 // Workflow:
-//  1. Validate packageDir is empty (unless Force=true)
+//  1. Validate packageDir is empty (unless Force=true), or holds a partial
+//     clone to resume or clean up
 //  2. Resolve authentication from environment
 //  3. Clone repository to packageDir
 //  4. Load bootstrap config if present
@@ -80,43 +138,121 @@ type CloneOptions struct {
 //  6. Filter packages by current platform
 //  7. Install selected packages via ManageService
 //  8. Update manifest with repository information
-func (s *CloneService) Clone(ctx context.Context, repoURL string, opts CloneOptions) error {
-	s.logger.Info(ctx, "clone_operation_started", "url", repoURL, "package_dir", s.packageDir)
+//
+// The returned report describes the clone as far as it got, including
+// when an error is returned.
+func (s *CloneService) Clone(ctx context.Context, repoURL string, opts CloneOptions) (CloneReport, error) {
+	s.progress(ctx, "clone_operation_started", "Cloning "+repoURL, map[string]any{"url": repoURL, "package_dir": s.packageDir})
+	report := s.newCloneReport(repoURL)
+
+	if s.offline {
+		return report, ErrOffline{Operation: "clone"}
+	}
 
 	// Validate package directory
 	s.logger.Debug(ctx, "validating_package_directory", "path", s.packageDir, "force", opts.Force)
+	clonedURL, resumed := repoURL, false
 	if err := validatePackageDir(ctx, s.fs, s.packageDir, opts.Force); err != nil {
-		s.logger.Error(ctx, "package_directory_validation_failed", "error", err)
-		return err
+		partial, found, detectErr := s.detectPartialClone(ctx, repoURL, opts)
+		switch {
+		case detectErr != nil || !found:
+			s.logger.Error(ctx, "package_directory_validation_failed", "error", err)
+			return report, err
+		case opts.Resume || opts.Cleanup && s.dryRun:
+			// A dry run plans from the partial clone instead of removing it
+			s.progress(ctx, "resuming_partial_clone", "Resuming the partial clone in "+s.packageDir, map[string]any{"path": s.packageDir, "url": partial.url, "cleanup": opts.Cleanup})
+			resumed = true
+			if partial.url != "" {
+				clonedURL = partial.url
+			}
+		case opts.Cleanup:
+			if err := s.cleanupPartialClone(ctx, partial); err != nil {
+				return report, err
+			}
+		default:
+			return report, ErrPartialClone{Path: s.packageDir, URL: partial.url}
+		}
 	}
 	s.logger.Debug(ctx, "package_directory_validated")
 
-	// Resolve authentication
-	s.logger.Debug(ctx, "resolving_authentication", "url", repoURL)
-	auth, err := adapters.ResolveAuth(ctx, repoURL)
-	if err != nil {
-		s.logger.Error(ctx, "authentication_resolution_failed", "error", err)
-		return ErrAuthFailed{Cause: err}
+	var err error
+	if !resumed {
+		// Clone repository, falling back to mirrors in order
+		clonedURL, err = s.cloneWithMirrors(ctx, repoURL, opts)
+		if err != nil {
+			return report, err
+		}
+		s.progress(ctx, "repository_cloned_successfully", "Cloned "+clonedURL, map[string]any{"path": s.packageDir, "url": clonedURL})
+	}
+	report.Repository = clonedURL
+	report.Resumed = resumed
+
+	baseBranch := ""
+	onMachineBranch := false
+	if resumed && opts.MachineBranch != "" {
+		// The machine branch may have been checked out before the
+		// interruption; it is based on the requested branch.
+		current, _ := getCurrentBranch(ctx, s.fs, s.packageDir)
+		onMachineBranch = current == opts.MachineBranch
+		baseBranch = opts.Branch
+	}
+	if opts.MachineBranch != "" && !onMachineBranch {
+		baseBranch, err = s.checkoutMachineBranch(ctx, opts)
+		if err != nil {
+			return report, err
+		}
 	}
-	s.logger.Debug(ctx, "authentication_resolved", "method", getAuthMethodName(auth))
 
-	s.logger.Info(ctx, "cloning_repository", "url", repoURL, "destination", s.packageDir)
+	branch := opts.Branch
+	if opts.MachineBranch != "" {
+		branch = opts.MachineBranch
+	}
+	if branch == "" {
+		// Read actual branch from repository HEAD
+		detectedBranch, err := getCurrentBranch(ctx, s.fs, s.packageDir)
+		if err != nil {
+			// If we can't detect the branch (detached HEAD, IO error, etc.),
+			// fall back to "main" as a sensible default
+			s.warn(ctx, "failed_to_detect_branch", "Cannot detect the cloned branch, assuming main", err, map[string]any{"fallback": "main"})
+			branch = "main"
+		} else {
+			s.logger.Debug(ctx, "detected_branch", "branch", detectedBranch)
+			branch = detectedBranch
+		}
+	}
 
-	// Clone repository
-	cloneOpts := adapters.CloneOptions{
-		Auth:   auth,
-		Branch: opts.Branch,
-		Depth:  1, // Shallow clone for faster cloning
+	commitSHA, err := getCommitSHA(ctx, s.fs, s.packageDir)
+	if err != nil {
+		s.logger.Debug(ctx, "failed_to_get_commit_sha", "error", err)
+	} else {
+		s.logger.Debug(ctx, "detected_commit_sha", "sha", commitSHA)
 	}
+	report.Branch, report.BaseBranch, report.Commit = branch, baseBranch, commitSHA
 
-	s.logger.Debug(ctx, "initiating_git_clone", "branch", opts.Branch, "depth", 1)
-	if err := s.cloner.Clone(ctx, repoURL, s.packageDir, cloneOpts); err != nil {
-		s.logger.Error(ctx, "git_clone_failed", "error", err)
-		return ErrCloneFailed{URL: repoURL, Cause: err}
+	if err := s.installPackages(ctx, opts, &report); err != nil || len(report.Installed) == 0 {
+		return report, err
+	}
+
+	// Update manifest with repository information
+	s.logger.Debug(ctx, "updating_manifest_with_repository_info")
+	repoInfo := buildRepositoryInfo(clonedURL, branch, commitSHA)
+	repoInfo.BaseBranch = baseBranch
+
+	if err := s.updateManifestRepository(ctx, repoInfo); err != nil {
+		s.warn(ctx, "failed_to_update_manifest_repository", "Cannot record the repository in the manifest", err, nil)
+	} else {
+		s.logger.Debug(ctx, "manifest_updated_with_repository_info")
 	}
 
-	s.logger.Info(ctx, "repository_cloned_successfully", "path", s.packageDir)
+	s.progress(ctx, "clone_complete", fmt.Sprintf("Installed %d package(s)", len(report.Installed)), map[string]any{"packages_installed": len(report.Installed)})
+	return report, nil
+}
 
+// installPackages selects packages of the package directory by opts and
+// the bootstrap configuration, and installs them. It records the selected
+// and installed packages in report, along with the packages left out and
+// the conflicts that failed installing.
+func (s *CloneService) installPackages(ctx context.Context, opts CloneOptions, report *CloneReport) error {
 	// Load bootstrap configuration if present
 	s.logger.Debug(ctx, "checking_for_bootstrap_config")
 	bootstrapConfig, hasBootstrap, err := loadBootstrapConfig(ctx, s.fs, s.packageDir)
@@ -126,80 +262,186 @@ func (s *CloneService) Clone(ctx context.Context, repoURL string, opts CloneOpti
 	}
 
 	if hasBootstrap {
-		s.logger.Info(ctx, "bootstrap_config_found", "packages", len(bootstrapConfig.Packages), "profiles", len(bootstrapConfig.Profiles))
+		s.logger.Debug(ctx, "bootstrap_config_found", "packages", len(bootstrapConfig.Packages), "profiles", len(bootstrapConfig.Profiles))
 	} else {
 		s.logger.Debug(ctx, "no_bootstrap_config_found")
 	}
 
+	// Without a bootstrap configuration the packages are those discovered
+	// in the package directory
+	var discovered []string
+	if !hasBootstrap {
+		s.logger.Debug(ctx, "discovering_packages", "directory", s.packageDir)
+		discovered, err = s.discoverClonedPackages(ctx)
+		if err != nil && len(opts.Packages) == 0 {
+			s.logger.Error(ctx, "package_discovery_failed", "error", err)
+			return fmt.Errorf("discover packages: %w", err)
+		}
+	}
+
 	// Select packages to install
-	s.logger.Info(ctx, "selecting_packages", "has_bootstrap", hasBootstrap, "profile", opts.Profile, "interactive", opts.Interactive)
+	s.logger.Debug(ctx, "selecting_packages", "has_bootstrap", hasBootstrap, "profile", opts.Profile, "interactive", opts.Interactive)
 	var packagesToInstall []string
-	if hasBootstrap {
+	switch {
+	case len(opts.Packages) > 0:
+		packagesToInstall = opts.Packages
+	case hasBootstrap:
 		packagesToInstall, err = s.selectPackagesWithBootstrap(ctx, bootstrapConfig, opts)
-	} else {
-		packagesToInstall, err = s.selectPackagesWithoutBootstrap(ctx, opts)
+	default:
+		packagesToInstall, err = s.selectPackagesWithoutBootstrap(ctx, discovered, opts)
 	}
 	if err != nil {
 		s.logger.Error(ctx, "package_selection_failed", "error", err)
 		return err
 	}
 
+	report.Profile = selectedProfile(bootstrapConfig, hasBootstrap, opts)
+	report.Skipped = skippedPackages(bootstrapConfig, hasBootstrap, discovered, packagesToInstall, opts, report.Profile)
+
 	if len(packagesToInstall) == 0 {
-		s.logger.Info(ctx, "no_packages_selected")
+		s.progress(ctx, "no_packages_selected", "No packages selected", nil)
 		return nil
 	}
+	report.Selected = slices.Clone(packagesToInstall)
 
-	s.logger.Info(ctx, "packages_selected", "count", len(packagesToInstall), "packages", packagesToInstall)
+	s.progress(ctx, "packages_selected", fmt.Sprintf("Selected %d package(s)", len(packagesToInstall)), map[string]any{"count": len(packagesToInstall), "packages": packagesToInstall})
 
 	// Install packages
 	if s.dryRun {
-		s.logger.Info(ctx, "dry_run_mode", "would_install", packagesToInstall)
+		s.logger.Debug(ctx, "dry_run_mode", "would_install", packagesToInstall)
 		return nil
 	}
 
-	s.logger.Info(ctx, "installing_packages", "count", len(packagesToInstall))
+	s.progress(ctx, "installing_packages", fmt.Sprintf("Installing %d package(s)", len(packagesToInstall)), map[string]any{"count": len(packagesToInstall)})
+	if err := materializePackages(ctx, s.fs, s.logger, s.sparseCheckout(), s.packageDir, packagesToInstall); err != nil {
+		s.logger.Error(ctx, "package_checkout_failed", "error", err)
+		return fmt.Errorf("install packages: %w", err)
+	}
 	if err := s.manageSvc.Manage(ctx, packagesToInstall...); err != nil {
 		s.logger.Error(ctx, "package_installation_failed", "error", err)
+		report.Conflicts = cloneConflicts(err)
 		return fmt.Errorf("install packages: %w", err)
 	}
-	s.logger.Info(ctx, "packages_installed_successfully", "count", len(packagesToInstall))
+	s.progress(ctx, "packages_installed_successfully", fmt.Sprintf("Installed %d package(s)", len(packagesToInstall)), map[string]any{"count": len(packagesToInstall)})
 
-	// Update manifest with repository information
-	s.logger.Debug(ctx, "updating_manifest_with_repository_info")
-	branch := opts.Branch
-	if branch == "" {
-		// Read actual branch from repository HEAD
-		detectedBranch, err := getCurrentBranch(s.packageDir)
+	report.Installed = slices.Clone(packagesToInstall)
+	return nil
+}
+
+// checkoutMachineBranch switches the fresh clone to opts.MachineBranch and
+// returns the cloned branch it is based on.
+func (s *CloneService) checkoutMachineBranch(ctx context.Context, opts CloneOptions) (string, error) {
+	base := opts.Branch
+	if base == "" {
+		detected, err := getCurrentBranch(ctx, s.fs, s.packageDir)
 		if err != nil {
-			// If we can't detect the branch (detached HEAD, IO error, etc.),
-			// fall back to "main" as a sensible default
-			s.logger.Warn(ctx, "failed_to_detect_branch", "error", err, "fallback", "main")
-			branch = "main"
-		} else {
-			s.logger.Debug(ctx, "detected_branch", "branch", detectedBranch)
-			branch = detectedBranch
+			return "", fmt.Errorf("detect cloned branch: %w", err)
+		}
+		base = detected
+	}
+
+	checkout, ok := s.cloner.(adapters.GitCheckout)
+	if !ok {
+		return "", fmt.Errorf("machine branches are not supported by this git backend")
+	}
+
+	s.progress(ctx, "checking_out_machine_branch", "Checking out machine branch "+opts.MachineBranch, map[string]any{"branch": opts.MachineBranch, "base": base})
+	if err := checkout.CheckoutBranch(ctx, s.packageDir, opts.MachineBranch); err != nil {
+		s.logger.Error(ctx, "machine_branch_checkout_failed", "error", err)
+		return "", err
+	}
+	return base, nil
+}
+
+// cloneWithMirrors clones from repoURL and then from each mirror until one
+// succeeds, returning the URL that was cloned.
+//
+// With no mirrors the error from the single attempt is returned unchanged.
+// Otherwise ErrCloneFailed reports the primary URL and the cause of every
+// failed attempt.
+func (s *CloneService) cloneWithMirrors(ctx context.Context, repoURL string, opts CloneOptions) (string, error) {
+	urls := append([]string{repoURL}, opts.Mirrors...)
+
+	var failures []error
+	for i, url := range urls {
+		err := s.cloneAttempt(ctx, url, opts)
+		if err == nil {
+			s.progress(ctx, "clone_attempt_succeeded", "Cloned from "+url, map[string]any{"url": url, "attempt": i + 1, "mirror": i > 0})
+			return url, nil
 		}
+		s.warn(ctx, "clone_attempt_failed", "Clone failed from "+url, err, map[string]any{"url": url, "attempt": i + 1, "remaining": len(urls) - i - 1})
+
+		if len(urls) == 1 {
+			return "", err
+		}
+		if ctx.Err() != nil {
+			return "", ErrCloneFailed{URL: repoURL, Cause: ctx.Err()}
+		}
+
+		failures = append(failures, fmt.Errorf("%s: %w", url, err))
+	}
+
+	return "", ErrCloneFailed{URL: repoURL, Cause: errors.Join(failures...)}
+}
+
+// cloneAttempt resolves authentication for url and clones it into packageDir.
+func (s *CloneService) cloneAttempt(ctx context.Context, url string, opts CloneOptions) error {
+	if opts.AttemptTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.AttemptTimeout)
+		defer cancel()
 	}
 
-	commitSHA, err := getCommitSHA(s.packageDir)
+	// Resolve authentication
+	s.logger.Debug(ctx, "resolving_authentication", "url", url)
+	auth, err := adapters.ResolveAuth(ctx, url)
 	if err != nil {
-		s.logger.Debug(ctx, "failed_to_get_commit_sha", "error", err)
-	} else {
-		s.logger.Debug(ctx, "detected_commit_sha", "sha", commitSHA)
+		s.logger.Error(ctx, "authentication_resolution_failed", "error", err)
+		return ErrAuthFailed{Cause: err}
 	}
+	s.logger.Debug(ctx, "authentication_resolved", "method", getAuthMethodName(auth))
 
-	repoInfo := buildRepositoryInfo(repoURL, branch, commitSHA)
+	s.progress(ctx, "cloning_repository", "Cloning "+url+" into "+s.packageDir, map[string]any{"url": url, "destination": s.packageDir})
 
-	if err := s.updateManifestRepository(ctx, repoInfo); err != nil {
-		s.logger.Warn(ctx, "failed_to_update_manifest_repository", "error", err)
-	} else {
-		s.logger.Debug(ctx, "manifest_updated_with_repository_info")
+	depth := 1 // Shallow clone for faster cloning
+	if opts.FullHistory || opts.MachineBranch != "" {
+		depth = 0
+	}
+	cloneOpts := adapters.CloneOptions{
+		Auth:   auth,
+		Branch: opts.Branch,
+		Depth:  depth,
+	}
+	if len(opts.Packages) > 0 || opts.Lazy {
+		cloneOpts.SparseDirectories = append(slices.Clone(opts.Packages), repoConfigDir)
+	}
+
+	s.logger.Debug(ctx, "initiating_git_clone", "branch", opts.Branch, "depth", depth)
+	if err := s.cloner.Clone(ctx, url, s.packageDir, cloneOpts); err != nil {
+		s.logger.Error(ctx, "git_clone_failed", "error", err)
+		return ErrCloneFailed{URL: url, Cause: err}
 	}
 
-	s.logger.Info(ctx, "clone_complete", "packages_installed", len(packagesToInstall))
 	return nil
 }
 
+// progress publishes a step of a clone to the event bus.
+func (s *CloneService) progress(ctx context.Context, name, message string, fields map[string]any) {
+	s.events.Publish(ctx, Event{Topic: TopicProgress, Source: "clone", Name: name, Message: message, Fields: fields})
+}
+
+// warn publishes a problem a clone worked around to the event bus.
+func (s *CloneService) warn(ctx context.Context, name, message string, err error, fields map[string]any) {
+	s.events.Publish(ctx, Event{Topic: TopicWarning, Source: "clone", Name: name, Message: message, Err: err, Fields: fields})
+}
+
+// sparseCheckout returns the cloner as a sparse checkout, or nil if the git
+// backend does not support one.
+func (s *CloneService) sparseCheckout() adapters.GitSparseCheckout {
+	sparse, _ := s.cloner.(adapters.GitSparseCheckout)
+	return sparse
+}
+
 // selectPackagesWithBootstrap selects packages using bootstrap configuration.
 func (s *CloneService) selectPackagesWithBootstrap(ctx context.Context, config bootstrap.Config, opts CloneOptions) ([]string, error) {
 	// Filter packages by platform
@@ -210,7 +452,7 @@ func (s *CloneService) selectPackagesWithBootstrap(ctx context.Context, config b
 
 	// If profile specified, use it
 	if opts.Profile != "" {
-		s.logger.Info(ctx, "using_specified_profile", "profile", opts.Profile)
+		s.logger.Debug(ctx, "using_specified_profile", "profile", opts.Profile)
 		profilePackages, err := selectPackagesFromProfile(config, opts.Profile)
 		if err != nil {
 			s.logger.Error(ctx, "profile_selection_failed", "profile", opts.Profile, "error", err)
@@ -223,13 +465,13 @@ func (s *CloneService) selectPackagesWithBootstrap(ctx context.Context, config b
 
 	// If interactive flag explicitly set, prompt user
 	if opts.Interactive {
-		s.logger.Info(ctx, "interactive_mode", "available_packages", len(allPackages))
-		return s.selector.Select(ctx, allPackages)
+		s.logger.Debug(ctx, "interactive_mode", "available_packages", len(allPackages))
+		return s.selectSpecs(ctx, filtered)
 	}
 
 	// Use default profile if configured
 	if config.Defaults.Profile != "" {
-		s.logger.Info(ctx, "using_default_profile", "profile", config.Defaults.Profile)
+		s.logger.Debug(ctx, "using_default_profile", "profile", config.Defaults.Profile)
 		profilePackages, err := selectPackagesFromProfile(config, config.Defaults.Profile)
 		if err != nil {
 			s.logger.Error(ctx, "default_profile_selection_failed", "profile", config.Defaults.Profile, "error", err)
@@ -242,39 +484,57 @@ func (s *CloneService) selectPackagesWithBootstrap(ctx context.Context, config b
 
 	// If terminal is interactive (and no default profile), prompt user
 	if terminal.IsInteractive() {
-		s.logger.Info(ctx, "terminal_interactive_detected", "prompting_user", true)
-		return s.selector.Select(ctx, allPackages)
+		s.logger.Debug(ctx, "terminal_interactive_detected", "prompting_user", true)
+		return s.selectSpecs(ctx, filtered)
 	}
 
 	// Install all packages (non-interactive mode with no profile)
-	s.logger.Info(ctx, "non_interactive_mode", "installing_all_packages", len(allPackages))
+	s.logger.Debug(ctx, "non_interactive_mode", "installing_all_packages", len(allPackages))
 	return allPackages, nil
 }
 
-// selectPackagesWithoutBootstrap selects packages when no bootstrap config exists.
-func (s *CloneService) selectPackagesWithoutBootstrap(ctx context.Context, opts CloneOptions) ([]string, error) {
-	// Discover packages in directory
-	s.logger.Debug(ctx, "discovering_packages", "directory", s.packageDir)
-	packages, err := discoverPackages(ctx, s.fs, s.packageDir)
-	if err != nil {
-		s.logger.Error(ctx, "package_discovery_failed", "error", err)
-		return nil, fmt.Errorf("discover packages: %w", err)
+// selectSpecs prompts the user to select among the packages of a bootstrap
+// configuration, showing their descriptions and platforms when the
+// selector supports it.
+func (s *CloneService) selectSpecs(ctx context.Context, specs []bootstrap.PackageSpec) ([]string, error) {
+	optSelector, ok := s.selector.(selector.OptionSelector)
+	if !ok {
+		return s.selector.Select(ctx, extractPackageNames(specs))
+	}
+	options := make([]selector.Option, len(specs))
+	for i, spec := range specs {
+		options[i] = selector.Option{Name: spec.Name, Description: spec.Description, Platform: spec.Platform}
+	}
+	return optSelector.SelectOptions(ctx, options)
+}
+
+// discoverClonedPackages returns the packages of the package directory,
+// including those a sparse checkout left out.
+func (s *CloneService) discoverClonedPackages(ctx context.Context) ([]string, error) {
+	packages, err := indexedPackages(ctx, s.sparseCheckout(), s.packageDir)
+	if err == nil && packages == nil {
+		packages, err = discoverPackages(ctx, s.fs, s.packageDir)
 	}
+	return packages, err
+}
 
+// selectPackagesWithoutBootstrap selects among the discovered packages
+// when no bootstrap config exists.
+func (s *CloneService) selectPackagesWithoutBootstrap(ctx context.Context, packages []string, opts CloneOptions) ([]string, error) {
 	s.logger.Debug(ctx, "packages_discovered", "count", len(packages), "packages", packages)
 	if len(packages) == 0 {
-		s.logger.Warn(ctx, "no_packages_found", "packageDir", s.packageDir)
+		s.warn(ctx, "no_packages_found", "No packages found in "+s.packageDir, nil, map[string]any{"package_dir": s.packageDir})
 		return []string{}, nil
 	}
 
 	// If interactive flag or terminal is interactive, prompt user
 	if opts.Interactive || terminal.IsInteractive() {
-		s.logger.Info(ctx, "interactive_selection", "available_packages", len(packages))
+		s.logger.Debug(ctx, "interactive_selection", "available_packages", len(packages))
 		return s.selector.Select(ctx, packages)
 	}
 
 	// Install all discovered packages
-	s.logger.Info(ctx, "auto_selecting_all_packages", "count", len(packages))
+	s.logger.Debug(ctx, "auto_selecting_all_packages", "count", len(packages))
 	return packages, nil
 }
 
@@ -285,8 +545,9 @@ func (s *CloneService) updateManifestRepository(ctx context.Context, info manife
 		return targetPathResult.UnwrapErr()
 	}
 
+	manifestStore := s.manifestStore()
+
 	// Load existing manifest
-	manifestStore := manifest.NewFSManifestStore(s.fs)
 	manifestResult := manifestStore.Load(ctx, targetPathResult.Unwrap())
 	if !manifestResult.IsOk() {
 		return manifestResult.UnwrapErr()
@@ -300,6 +561,16 @@ func (s *CloneService) updateManifestRepository(ctx context.Context, info manife
 	return manifestStore.Save(ctx, targetPathResult.Unwrap(), m)
 }
 
+// manifestStore returns the manifest location the other services read,
+// which may be a configured manifest directory rather than the target
+// directory.
+func (s *CloneService) manifestStore() manifest.ManifestStore {
+	if s.manageSvc != nil && s.manageSvc.manifestSvc != nil {
+		return s.manageSvc.manifestSvc.store
+	}
+	return manifest.NewFSManifestStore(s.fs)
+}
+
 // validatePackageDir checks if the package directory is suitable for cloning.
 func validatePackageDir(ctx context.Context, fs FS, path string, force bool) error {
 	// Check if directory exists
@@ -356,15 +627,6 @@ func loadBootstrapConfig(ctx context.Context, fs FS, packageDir string) (bootstr
 	return config, true, nil
 }
 
-// selectPackagesFromProfile selects packages from a named profile.
-func selectPackagesFromProfile(config bootstrap.Config, profileName string) ([]string, error) {
-	packages, err := bootstrap.GetProfile(config, profileName)
-	if err != nil {
-		return nil, ErrProfileNotFound{Profile: profileName}
-	}
-	return packages, nil
-}
-
 // discoverPackages discovers package directories in the package directory.
 func discoverPackages(ctx context.Context, fs FS, packageDir string) ([]string, error) {
 	entries, err := fs.ReadDir(ctx, packageDir)
@@ -427,9 +689,9 @@ func buildRepositoryInfo(url, branch, commitSHA string) manifest.RepositoryInfo
 
 // getCurrentBranch reads the current branch name from a git repository.
 // Returns an error if HEAD is detached or cannot be read.
-func getCurrentBranch(repoPath string) (string, error) {
+func getCurrentBranch(ctx context.Context, fs FS, repoPath string) (string, error) {
 	headPath := filepath.Join(repoPath, ".git", "HEAD")
-	headData, err := os.ReadFile(headPath)
+	headData, err := fs.ReadFile(ctx, headPath)
 	if err != nil {
 		return "", fmt.Errorf("read HEAD file: %w", err)
 	}
@@ -454,10 +716,10 @@ func getCurrentBranch(repoPath string) (string, error) {
 
 // getCommitSHA attempts to get the current commit SHA from a git repository.
 // Returns empty string if unable to determine (best effort).
-func getCommitSHA(repoPath string) (string, error) {
+func getCommitSHA(ctx context.Context, fs FS, repoPath string) (string, error) {
 	// Read the HEAD file to get current ref
 	headPath := filepath.Join(repoPath, ".git", "HEAD")
-	headData, err := os.ReadFile(headPath)
+	headData, err := fs.ReadFile(ctx, headPath)
 	if err != nil {
 		return "", err
 	}
@@ -474,7 +736,7 @@ func getCommitSHA(repoPath string) (string, error) {
 
 		// Build full path to ref file
 		fullRefPath := filepath.Join(repoPath, ".git", refPath)
-		shaData, err := os.ReadFile(fullRefPath)
+		shaData, err := fs.ReadFile(ctx, fullRefPath)
 		if err != nil {
 			return "", err
 		}
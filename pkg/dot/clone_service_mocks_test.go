@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/internal/cli/selector"
 )
 
 // mockGitCloner is a test double for GitCloner.
@@ -18,6 +19,17 @@ func (m *mockGitCloner) Clone(ctx context.Context, url string, dest string, opts
 	return nil
 }
 
+// mockCheckoutCloner is a mockGitCloner that can also check out branches.
+type mockCheckoutCloner struct {
+	mockGitCloner
+	checkedOut string
+}
+
+func (m *mockCheckoutCloner) CheckoutBranch(ctx context.Context, path string, branch string) error {
+	m.checkedOut = branch
+	return nil
+}
+
 // mockPackageSelector is a test double for PackageSelector.
 type mockPackageSelector struct {
 	selectFn func(ctx context.Context, packages []string) ([]string, error)
@@ -29,3 +41,70 @@ func (m *mockPackageSelector) Select(ctx context.Context, packages []string) ([]
 	}
 	return packages, nil
 }
+
+// mockOptionSelector is a test double for OptionSelector that records the
+// options it was offered and selects all of them.
+type mockOptionSelector struct {
+	mockPackageSelector
+	options []selector.Option
+}
+
+func (m *mockOptionSelector) SelectOptions(ctx context.Context, options []selector.Option) ([]string, error) {
+	m.options = options
+	names := make([]string, len(options))
+	for i, opt := range options {
+		names[i] = opt.Name
+	}
+	return names, nil
+}
+
+// mockGitPuller is a test double for GitPuller.
+type mockGitPuller struct {
+	pullFn    func(ctx context.Context, path string, opts adapters.PullOptions) error
+	head      string
+	changed   []string
+	changeErr error
+	pulled    bool
+
+	shallow      bool
+	unshallowErr error
+	unshallowed  bool
+
+	rebaseResult adapters.RebaseResult
+	rebaseErr    error
+	rebasedOnto  string
+}
+
+func (m *mockGitPuller) Pull(ctx context.Context, path string, opts adapters.PullOptions) error {
+	m.pulled = true
+	if m.pullFn != nil {
+		return m.pullFn(ctx, path, opts)
+	}
+	return nil
+}
+
+func (m *mockGitPuller) Head(ctx context.Context, path string) (string, error) {
+	return m.head, nil
+}
+
+func (m *mockGitPuller) ChangedFiles(ctx context.Context, path string, from string, to string) ([]string, error) {
+	return m.changed, m.changeErr
+}
+
+func (m *mockGitPuller) IsShallow(ctx context.Context, path string) (bool, error) {
+	return m.shallow, nil
+}
+
+func (m *mockGitPuller) Unshallow(ctx context.Context, path string, opts adapters.PullOptions) error {
+	m.unshallowed = true
+	if m.unshallowErr != nil {
+		return m.unshallowErr
+	}
+	m.shallow = false
+	return nil
+}
+
+func (m *mockGitPuller) Rebase(ctx context.Context, path string, onto string, opts adapters.PullOptions) (adapters.RebaseResult, error) {
+	m.rebasedOnto = onto
+	return m.rebaseResult, m.rebaseErr
+}
@@ -22,7 +22,7 @@ func TestNewCloneService(t *testing.T) {
 	cloner := adapters.NewGoGitCloner()
 	sel := selector.NewInteractiveSelector(os.Stdin, os.Stdout)
 
-	svc := newCloneService(fs, logger, manageSvc, cloner, sel, "/packages", "/home", false)
+	svc := newCloneService(fs, logger, manageSvc, cloner, sel, "/packages", "/home", false, false)
 
 	assert.NotNil(t, svc)
 	assert.Equal(t, "/packages", svc.packageDir)
@@ -318,13 +318,35 @@ func TestCloneService_SelectPackagesWithBootstrap_DefaultProfile(t *testing.T) {
 	output := &strings.Builder{}
 	sel := selector.NewInteractiveSelector(input, output)
 
-	svc := newCloneService(fs, logger, nil, nil, sel, "/packages", "/home", false)
+	svc := newCloneService(fs, logger, nil, nil, sel, "/packages", "/home", false, false)
 
 	packages, err := svc.selectPackagesWithBootstrap(ctx, config, CloneOptions{})
 	require.NoError(t, err)
 	assert.Equal(t, []string{"dot-vim", "dot-zsh"}, packages)
 }
 
+func TestCloneService_SelectPackagesWithBootstrap_InteractiveDetails(t *testing.T) {
+	ctx := context.Background()
+	config := bootstrap.Config{
+		Version: "1.0",
+		Packages: []bootstrap.PackageSpec{
+			{Name: "dot-vim", Description: "Vim setup"},
+			{Name: "dot-zsh", Platform: []string{runtime.GOOS}},
+		},
+	}
+
+	sel := &mockOptionSelector{}
+	svc := newCloneService(adapters.NewMemFS(), adapters.NewNoopLogger(), nil, nil, sel, "/packages", "/home", false, false)
+
+	packages, err := svc.selectPackagesWithBootstrap(ctx, config, CloneOptions{Interactive: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dot-vim", "dot-zsh"}, packages)
+	assert.Equal(t, []selector.Option{
+		{Name: "dot-vim", Description: "Vim setup"},
+		{Name: "dot-zsh", Platform: []string{runtime.GOOS}},
+	}, sel.options)
+}
+
 func TestCloneService_SelectPackagesWithBootstrap_ExplicitProfile(t *testing.T) {
 	ctx := context.Background()
 	fs := adapters.NewMemFS()
@@ -349,7 +371,7 @@ func TestCloneService_SelectPackagesWithBootstrap_ExplicitProfile(t *testing.T)
 	output := &strings.Builder{}
 	sel := selector.NewInteractiveSelector(input, output)
 
-	svc := newCloneService(fs, logger, nil, nil, sel, "/packages", "/home", false)
+	svc := newCloneService(fs, logger, nil, nil, sel, "/packages", "/home", false, false)
 
 	packages, err := svc.selectPackagesWithBootstrap(ctx, config, CloneOptions{Profile: "minimal"})
 	require.NoError(t, err)
@@ -386,7 +408,7 @@ func TestCloneService_SelectPackagesWithBootstrap_ProfileWithPlatformFilter(t *t
 	output := &strings.Builder{}
 	sel := selector.NewInteractiveSelector(input, output)
 
-	svc := newCloneService(fs, logger, nil, nil, sel, "/packages", "/home", false)
+	svc := newCloneService(fs, logger, nil, nil, sel, "/packages", "/home", false, false)
 
 	packages, err := svc.selectPackagesWithBootstrap(ctx, config, CloneOptions{Profile: "all"})
 	require.NoError(t, err)
@@ -426,7 +448,7 @@ func TestCloneService_SelectPackagesWithBootstrap_DefaultProfileWithPlatformFilt
 	output := &strings.Builder{}
 	sel := selector.NewInteractiveSelector(input, output)
 
-	svc := newCloneService(fs, logger, nil, nil, sel, "/packages", "/home", false)
+	svc := newCloneService(fs, logger, nil, nil, sel, "/packages", "/home", false, false)
 
 	packages, err := svc.selectPackagesWithBootstrap(ctx, config, CloneOptions{})
 	require.NoError(t, err)
@@ -473,7 +495,7 @@ func TestCloneService_SelectPackagesWithBootstrap_ProfileNotFoundError(t *testin
 	output := &strings.Builder{}
 	sel := selector.NewInteractiveSelector(input, output)
 
-	svc := newCloneService(fs, logger, nil, nil, sel, "/packages", "/home", false)
+	svc := newCloneService(fs, logger, nil, nil, sel, "/packages", "/home", false, false)
 
 	// Test with explicit non-existent profile
 	_, err := svc.selectPackagesWithBootstrap(ctx, config, CloneOptions{Profile: "nonexistent"})
@@ -517,7 +539,7 @@ func TestCloneService_SelectPackagesWithBootstrap_DefaultProfilePriority(t *test
 	output := &strings.Builder{}
 	sel := selector.NewInteractiveSelector(input, output)
 
-	svc := newCloneService(fs, logger, nil, nil, sel, "/packages", "/home", false)
+	svc := newCloneService(fs, logger, nil, nil, sel, "/packages", "/home", false, false)
 
 	// With Interactive=false and a default profile configured,
 	// the default profile should be used even if terminal is interactive.
@@ -558,7 +580,7 @@ func TestCloneService_SelectPackagesWithBootstrap_ExplicitInteractiveOverridesDe
 	output := &strings.Builder{}
 	sel := selector.NewInteractiveSelector(input, output)
 
-	svc := newCloneService(fs, logger, nil, nil, sel, "/packages", "/home", false)
+	svc := newCloneService(fs, logger, nil, nil, sel, "/packages", "/home", false, false)
 
 	// With Interactive=true, should prompt even if default profile exists
 	packages, err := svc.selectPackagesWithBootstrap(ctx, config, CloneOptions{Interactive: true})
@@ -584,10 +606,12 @@ func TestCloneService_SelectPackagesWithoutBootstrap_AllPackages(t *testing.T) {
 	output := &strings.Builder{}
 	sel := selector.NewInteractiveSelector(input, output)
 
-	svc := newCloneService(fs, logger, nil, nil, sel, "/packages", "/home", false)
+	svc := newCloneService(fs, logger, nil, nil, sel, "/packages", "/home", false, false)
 
 	// Non-interactive should install all
-	packages, err := svc.selectPackagesWithoutBootstrap(ctx, CloneOptions{})
+	discovered, err := svc.discoverClonedPackages(ctx)
+	require.NoError(t, err)
+	packages, err := svc.selectPackagesWithoutBootstrap(ctx, discovered, CloneOptions{})
 	require.NoError(t, err)
 	assert.ElementsMatch(t, []string{"dot-vim", "dot-zsh"}, packages)
 }
@@ -605,9 +629,11 @@ func TestCloneService_SelectPackagesWithoutBootstrap_NoPackages(t *testing.T) {
 	output := &strings.Builder{}
 	sel := selector.NewInteractiveSelector(input, output)
 
-	svc := newCloneService(fs, logger, nil, nil, sel, "/packages", "/home", false)
+	svc := newCloneService(fs, logger, nil, nil, sel, "/packages", "/home", false, false)
 
-	packages, err := svc.selectPackagesWithoutBootstrap(ctx, CloneOptions{})
+	discovered, err := svc.discoverClonedPackages(ctx)
+	require.NoError(t, err)
+	packages, err := svc.selectPackagesWithoutBootstrap(ctx, discovered, CloneOptions{})
 	require.NoError(t, err)
 	assert.Empty(t, packages)
 }
@@ -668,9 +694,9 @@ func TestCloneService_Clone_Success(t *testing.T) {
 		dryRun:     true, // Dry run to avoid actual file operations
 	}
 
-	svc := newCloneService(fs, logger, manageSvc, cloner, selector, "/packages", "/home", true)
+	svc := newCloneService(fs, logger, manageSvc, cloner, selector, "/packages", "/home", true, false)
 
-	err = svc.Clone(ctx, "https://github.com/user/dotfiles", CloneOptions{
+	_, err = svc.Clone(ctx, "https://github.com/user/dotfiles", CloneOptions{
 		Branch: "main",
 	})
 
@@ -692,9 +718,9 @@ func TestCloneService_Clone_PackageDirNotEmpty(t *testing.T) {
 	selector := &mockPackageSelector{}
 	manageSvc := &ManageService{}
 
-	svc := newCloneService(fs, logger, manageSvc, cloner, selector, "/packages", "/home", false)
+	svc := newCloneService(fs, logger, manageSvc, cloner, selector, "/packages", "/home", false, false)
 
-	err = svc.Clone(ctx, "https://github.com/user/dotfiles", CloneOptions{})
+	_, err = svc.Clone(ctx, "https://github.com/user/dotfiles", CloneOptions{})
 
 	assert.Error(t, err)
 	assert.IsType(t, ErrPackageDirNotEmpty{}, err)
@@ -715,9 +741,9 @@ func TestCloneService_Clone_CloneFails(t *testing.T) {
 	selector := &mockPackageSelector{}
 	manageSvc := &ManageService{}
 
-	svc := newCloneService(fs, logger, manageSvc, cloner, selector, "/packages", "/home", false)
+	svc := newCloneService(fs, logger, manageSvc, cloner, selector, "/packages", "/home", false, false)
 
-	err := svc.Clone(ctx, "https://github.com/user/invalid", CloneOptions{})
+	_, err := svc.Clone(ctx, "https://github.com/user/invalid", CloneOptions{})
 
 	assert.Error(t, err)
 	assert.IsType(t, ErrCloneFailed{}, err)
@@ -767,9 +793,9 @@ profiles:
 		dryRun:     true,
 	}
 
-	svc := newCloneService(fs, logger, manageSvc, cloner, selector, "/packages", "/home", true)
+	svc := newCloneService(fs, logger, manageSvc, cloner, selector, "/packages", "/home", true, false)
 
-	err = svc.Clone(ctx, "https://github.com/user/dotfiles", CloneOptions{
+	_, err = svc.Clone(ctx, "https://github.com/user/dotfiles", CloneOptions{
 		Profile: "minimal",
 		Branch:  "main",
 	})
@@ -799,9 +825,9 @@ func TestCloneService_Clone_NoPackagesSelected(t *testing.T) {
 
 	manageSvc := &ManageService{}
 
-	svc := newCloneService(fs, logger, manageSvc, cloner, selector, "/packages", "/home", false)
+	svc := newCloneService(fs, logger, manageSvc, cloner, selector, "/packages", "/home", false, false)
 
-	err := svc.Clone(ctx, "https://github.com/user/dotfiles", CloneOptions{
+	_, err := svc.Clone(ctx, "https://github.com/user/dotfiles", CloneOptions{
 		Interactive: true,
 	})
 
@@ -809,7 +835,34 @@ func TestCloneService_Clone_NoPackagesSelected(t *testing.T) {
 }
 
 func TestCloneService_GetCommitSHA(t *testing.T) {
-	t.Skip("getCommitSHA requires git repository - tested in integration tests")
+	ctx := context.Background()
+	sha := "0123456789abcdef0123456789abcdef01234567"
+
+	t.Run("reads the commit of the branch", func(t *testing.T) {
+		fs := adapters.NewMemFS()
+		require.NoError(t, fs.MkdirAll(ctx, "/repo/.git/refs/heads", 0755))
+		require.NoError(t, fs.WriteFile(ctx, "/repo/.git/HEAD", []byte("ref: refs/heads/main\n"), 0644))
+		require.NoError(t, fs.WriteFile(ctx, "/repo/.git/refs/heads/main", []byte(sha+"\n"), 0644))
+
+		got, err := getCommitSHA(ctx, fs, "/repo")
+		require.NoError(t, err)
+		assert.Equal(t, sha, got)
+	})
+
+	t.Run("reads a detached HEAD", func(t *testing.T) {
+		fs := adapters.NewMemFS()
+		require.NoError(t, fs.MkdirAll(ctx, "/repo/.git", 0755))
+		require.NoError(t, fs.WriteFile(ctx, "/repo/.git/HEAD", []byte(sha+"\n"), 0644))
+
+		got, err := getCommitSHA(ctx, fs, "/repo")
+		require.NoError(t, err)
+		assert.Equal(t, sha, got)
+	})
+
+	t.Run("fails without a repository", func(t *testing.T) {
+		_, err := getCommitSHA(ctx, adapters.NewMemFS(), "/repo")
+		assert.Error(t, err)
+	})
 }
 
 func TestGetCurrentBranch(t *testing.T) {
@@ -822,7 +875,7 @@ func TestGetCurrentBranch(t *testing.T) {
 		headPath := gitDir + "/HEAD"
 		require.NoError(t, os.WriteFile(headPath, []byte(headContent), 0644))
 
-		branch, err := getCurrentBranch(tmpDir)
+		branch, err := getCurrentBranch(context.Background(), adapters.NewOSFilesystem(), tmpDir)
 		require.NoError(t, err)
 		assert.Equal(t, "main", branch)
 	})
@@ -836,7 +889,7 @@ func TestGetCurrentBranch(t *testing.T) {
 		headPath := gitDir + "/HEAD"
 		require.NoError(t, os.WriteFile(headPath, []byte(headContent), 0644))
 
-		branch, err := getCurrentBranch(tmpDir)
+		branch, err := getCurrentBranch(context.Background(), adapters.NewOSFilesystem(), tmpDir)
 		require.NoError(t, err)
 		assert.Equal(t, "master", branch)
 	})
@@ -850,7 +903,7 @@ func TestGetCurrentBranch(t *testing.T) {
 		headPath := gitDir + "/HEAD"
 		require.NoError(t, os.WriteFile(headPath, []byte(headContent), 0644))
 
-		branch, err := getCurrentBranch(tmpDir)
+		branch, err := getCurrentBranch(context.Background(), adapters.NewOSFilesystem(), tmpDir)
 		require.NoError(t, err)
 		assert.Equal(t, "feature-branch-name", branch)
 	})
@@ -864,7 +917,7 @@ func TestGetCurrentBranch(t *testing.T) {
 		headPath := gitDir + "/HEAD"
 		require.NoError(t, os.WriteFile(headPath, []byte(headContent), 0644))
 
-		branch, err := getCurrentBranch(tmpDir)
+		branch, err := getCurrentBranch(context.Background(), adapters.NewOSFilesystem(), tmpDir)
 		require.NoError(t, err)
 		assert.Equal(t, "develop", branch)
 	})
@@ -878,7 +931,7 @@ func TestGetCurrentBranch(t *testing.T) {
 		headPath := gitDir + "/HEAD"
 		require.NoError(t, os.WriteFile(headPath, []byte(headContent), 0644))
 
-		branch, err := getCurrentBranch(tmpDir)
+		branch, err := getCurrentBranch(context.Background(), adapters.NewOSFilesystem(), tmpDir)
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "detached HEAD")
 		assert.Empty(t, branch)
@@ -889,7 +942,7 @@ func TestGetCurrentBranch(t *testing.T) {
 		gitDir := tmpDir + "/.git"
 		require.NoError(t, os.Mkdir(gitDir, 0755))
 
-		branch, err := getCurrentBranch(tmpDir)
+		branch, err := getCurrentBranch(context.Background(), adapters.NewOSFilesystem(), tmpDir)
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "read HEAD file")
 		assert.Empty(t, branch)
@@ -898,7 +951,7 @@ func TestGetCurrentBranch(t *testing.T) {
 	t.Run("returns error when .git directory missing", func(t *testing.T) {
 		tmpDir := t.TempDir()
 
-		branch, err := getCurrentBranch(tmpDir)
+		branch, err := getCurrentBranch(context.Background(), adapters.NewOSFilesystem(), tmpDir)
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "read HEAD file")
 		assert.Empty(t, branch)
@@ -913,7 +966,7 @@ func TestGetCurrentBranch(t *testing.T) {
 		headPath := gitDir + "/HEAD"
 		require.NoError(t, os.WriteFile(headPath, []byte(headContent), 0644))
 
-		branch, err := getCurrentBranch(tmpDir)
+		branch, err := getCurrentBranch(context.Background(), adapters.NewOSFilesystem(), tmpDir)
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "empty branch name")
 		assert.Empty(t, branch)
@@ -928,7 +981,7 @@ func TestGetCurrentBranch(t *testing.T) {
 		headPath := gitDir + "/HEAD"
 		require.NoError(t, os.WriteFile(headPath, []byte(headContent), 0644))
 
-		branch, err := getCurrentBranch(tmpDir)
+		branch, err := getCurrentBranch(context.Background(), adapters.NewOSFilesystem(), tmpDir)
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "detached HEAD or unexpected format")
 		assert.Empty(t, branch)
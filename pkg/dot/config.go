@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"runtime"
+	"time"
 )
 
 // Config holds configuration for the dot Client.
@@ -16,6 +17,12 @@ type Config struct {
 	// Must be an absolute path.
 	TargetDir string
 
+	// Targets maps package names to the directory their links are created
+	// in instead of TargetDir. Each target directory has its own manifest,
+	// and package name mapping does not apply to these packages: the
+	// package root maps to the directory itself. Paths must be absolute.
+	Targets map[string]string
+
 	// LinkMode specifies whether to create relative or absolute symlinks.
 	LinkMode LinkMode
 
@@ -26,6 +33,21 @@ type Config struct {
 	// DryRun enables preview mode without applying changes.
 	DryRun bool
 
+	// Offline makes operations that need network access, such as clone
+	// and pulling during sync, fail immediately with ErrOffline.
+	Offline bool
+
+	// GitTimeout bounds each git network operation (clone, pull).
+	// If zero, there is no limit.
+	GitTimeout time.Duration
+
+	// GitProxy routes git network traffic through the given proxy URL.
+	// If empty, no proxy is used.
+	GitProxy string
+
+	// GitInsecureSkipTLS disables certificate verification for HTTPS remotes.
+	GitInsecureSkipTLS bool
+
 	// Verbosity controls logging detail (0=quiet, 1=info, 2=debug, 3=trace).
 	Verbosity int
 
@@ -33,6 +55,73 @@ type Config struct {
 	// If empty, backups go to <TargetDir>/.dot-backup/
 	BackupDir string
 
+	// Backup stores files that conflict with a new link in BackupDir
+	// before replacing them. Identical content is stored only once.
+	Backup bool
+
+	// OnConflict names the strategy that resolves files and links in the
+	// way of a new link: one of BuiltinConflictStrategies or a strategy of
+	// ConflictStrategies. If empty, such conflicts fail, or the files are
+	// backed up when Backup is set.
+	OnConflict string
+
+	// ConflictPolicies maps glob patterns over target paths to the
+	// strategy resolving files and links in the way of a new link at
+	// matching paths, overriding OnConflict. Patterns starting with "~/"
+	// or relative ones are resolved against TargetDir, and "**" matches
+	// any number of path components. When several patterns match, the
+	// longest applies.
+	ConflictPolicies map[string]string
+
+	// ConflictStrategies are custom conflict resolution strategies that
+	// OnConflict and ConflictPolicies can name. Names must not repeat or shadow built-ins.
+	ConflictStrategies []ResolutionStrategy
+
+	// ConflictPrompt asks how to resolve each conflict when OnConflict is
+	// "prompt". If nil, the prompt strategy fails every conflict.
+	ConflictPrompt ConflictPrompter
+
+	// SecretsProvider selects where {{ secret "name" }} in templates reads
+	// from: "env", "pass" or "op". If empty, environment variables are used.
+	SecretsProvider string
+
+	// SecretsEnvPrefix is prepended to secret names by the env provider.
+	// If empty, defaults to DOT_SECRET_.
+	SecretsEnvPrefix string
+
+	// SecretsVault is the 1Password vault holding secrets referenced by
+	// item name rather than op:// reference.
+	SecretsVault string
+
+	// TemplateCacheDir specifies where rendered *.tmpl package files are written.
+	// If empty, defaults to <TargetDir>/.cache/dot/templates
+	TemplateCacheDir string
+
+	// ScanCacheDir specifies where the scanned trees of packages are kept,
+	// so packages whose directories have not changed are not walked again.
+	// If empty, every package is scanned on every run.
+	ScanCacheDir string
+
+	// CheckpointDir specifies where execution checkpoints are persisted so
+	// that executed plans can be rolled back later.
+	// If empty, checkpoints are kept in memory only and rollback is unavailable.
+	CheckpointDir string
+
+	// AuditLog is the path of the append-only log every executed operation
+	// is recorded in, for Client.History.
+	// If empty, operations are not recorded.
+	AuditLog string
+
+	// SnapshotDir specifies where a remanage that would remove or overwrite
+	// files in the package directory first writes a tar archive of each
+	// package affected, for recovery independent of git.
+	// If empty, packages are not snapshotted.
+	SnapshotDir string
+
+	// SnapshotRetention is the number of snapshots kept per package.
+	// If zero, defaults to 5.
+	SnapshotRetention int
+
 	// ManifestDir specifies where to store the manifest file.
 	// If empty, manifest is stored in TargetDir for backward compatibility.
 	ManifestDir string
@@ -46,11 +135,48 @@ type Config struct {
 	// Default: true (project is pre-1.0, breaking change acceptable)
 	PackageNameMapping bool
 
+	// SpecialFiles controls how sockets, named pipes, and devices in
+	// packages or adopted paths are handled. The zero value skips them
+	// with a plan warning.
+	SpecialFiles SpecialFilePolicy
+
+	// Boundary makes plan execution refuse to change files outside the
+	// package, target, backup, template cache and manifest directories,
+	// failing the operation with ErrOutsideBoundary. It guards against
+	// paths computed wrongly rather than controlling access.
+	Boundary bool
+
 	// Infrastructure dependencies (required)
 	FS      FS
 	Logger  Logger
 	Tracer  Tracer
 	Metrics Metrics
+
+	// RequireCapabilities makes NewClient probe the environment with
+	// CheckEnvironment and fail with ErrCapabilities if a check fails.
+	RequireCapabilities bool
+
+	// EventSink receives an event as each operation of an executed plan
+	// starts, succeeds, fails or is rolled back (optional).
+	EventSink EventSink
+
+	// events is the bus of the client the clients of other target
+	// directories are created for, which they share.
+	events *EventBus
+
+	// platform overrides the operating system templates are rendered
+	// for, for plans computed on behalf of other platforms.
+	platform string
+}
+
+// boundaryRoots returns the directories plan execution may change when
+// Boundary is set.
+func (c Config) boundaryRoots() []string {
+	roots := []string{c.PackageDir, c.TargetDir, c.BackupDir, c.TemplateCacheDir, c.ManifestDir}
+	for _, dir := range c.Targets {
+		roots = append(roots, dir)
+	}
+	return roots
 }
 
 // LinkMode specifies symlink creation strategy.
@@ -79,6 +205,34 @@ func (c Config) Validate() error {
 		return fmt.Errorf("targetDir must be absolute path: %s", c.TargetDir)
 	}
 
+	for pkg, dir := range c.Targets {
+		if !filepath.IsAbs(dir) {
+			return fmt.Errorf("target of package %s must be absolute path: %s", pkg, dir)
+		}
+	}
+
+	if c.CheckpointDir != "" && !filepath.IsAbs(c.CheckpointDir) {
+		return fmt.Errorf("checkpointDir must be absolute path: %s", c.CheckpointDir)
+	}
+	if c.AuditLog != "" && !filepath.IsAbs(c.AuditLog) {
+		return fmt.Errorf("auditLog must be absolute path: %s", c.AuditLog)
+	}
+
+	if c.TemplateCacheDir != "" && !filepath.IsAbs(c.TemplateCacheDir) {
+		return fmt.Errorf("templateCacheDir must be absolute path: %s", c.TemplateCacheDir)
+	}
+
+	if c.ScanCacheDir != "" && !filepath.IsAbs(c.ScanCacheDir) {
+		return fmt.Errorf("scanCacheDir must be absolute path: %s", c.ScanCacheDir)
+	}
+
+	if c.SnapshotDir != "" && !filepath.IsAbs(c.SnapshotDir) {
+		return fmt.Errorf("snapshotDir must be absolute path: %s", c.SnapshotDir)
+	}
+	if c.SnapshotRetention < 0 {
+		return fmt.Errorf("snapshotRetention must not be negative: %d", c.SnapshotRetention)
+	}
+
 	if c.FS == nil {
 		return fmt.Errorf("FS is required")
 	}
@@ -95,6 +249,14 @@ func (c Config) Validate() error {
 		return fmt.Errorf("concurrency cannot be negative")
 	}
 
+	if c.GitTimeout < 0 {
+		return fmt.Errorf("gitTimeout cannot be negative")
+	}
+
+	if _, err := conflictStrategies(c); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -114,6 +276,10 @@ func (c Config) WithDefaults() Config {
 		cfg.BackupDir = filepath.Join(cfg.TargetDir, ".dot-backup")
 	}
 
+	if cfg.TemplateCacheDir == "" {
+		cfg.TemplateCacheDir = filepath.Join(cfg.TargetDir, ".cache", "dot", "templates")
+	}
+
 	if cfg.Concurrency == 0 {
 		cfg.Concurrency = runtime.NumCPU()
 	}
@@ -1,9 +1,170 @@
 package dot
 
-import "github.com/jamesainslie/dot/internal/domain"
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/jamesainslie/dot/internal/domain"
+	"github.com/jamesainslie/dot/internal/planner"
+)
 
 // ConflictInfo represents conflict information in plan metadata.
 type ConflictInfo = domain.ConflictInfo
 
 // WarningInfo represents warning information in plan metadata.
 type WarningInfo = domain.WarningInfo
+
+// ReplacementInfo records an existing file a plan replaces.
+type ReplacementInfo = domain.ReplacementInfo
+
+// Conflict resolution re-exports from internal/planner
+
+// ResolutionStrategy resolves conflicts found while planning. Custom
+// strategies are registered with Config.ConflictStrategies and selected
+// by name with Config.OnConflict.
+type ResolutionStrategy = planner.ResolutionStrategy
+
+// ResolutionRequest describes a conflict for a strategy to resolve.
+type ResolutionRequest = planner.ResolutionRequest
+
+// ResolutionOutcome is how a strategy resolved a conflict.
+type ResolutionOutcome = planner.ResolutionOutcome
+
+// ResolutionStatus indicates the outcome of conflict resolution.
+type ResolutionStatus = planner.ResolutionStatus
+
+// ResolutionStatus constants
+const (
+	ResolveOK       = planner.ResolveOK
+	ResolveConflict = planner.ResolveConflict
+	ResolveWarning  = planner.ResolveWarning
+	ResolveSkip     = planner.ResolveSkip
+)
+
+// ResolutionWarning is a non-fatal issue reported by a strategy.
+type ResolutionWarning = planner.Warning
+
+// WarningSeverity indicates the severity level of a warning.
+type WarningSeverity = planner.WarningSeverity
+
+// WarningSeverity constants
+const (
+	WarnInfo    = planner.WarnInfo
+	WarnCaution = planner.WarnCaution
+	WarnDanger  = planner.WarnDanger
+)
+
+// Conflict is a conflict detected during planning.
+type Conflict = planner.Conflict
+
+// ConflictType categorizes conflicts by their nature.
+type ConflictType = planner.ConflictType
+
+// ConflictType constants
+const (
+	ConflictFileExists   = planner.ConflictFileExists
+	ConflictWrongLink    = planner.ConflictWrongLink
+	ConflictPermission   = planner.ConflictPermission
+	ConflictCircular     = planner.ConflictCircular
+	ConflictDirExpected  = planner.ConflictDirExpected
+	ConflictFileExpected = planner.ConflictFileExpected
+)
+
+// ConflictFileInfo describes the file at a conflicting path.
+type ConflictFileInfo = planner.FileInfo
+
+// ConflictPrompter asks how to resolve a conflict and returns the name of
+// the strategy to apply, one of choices.
+type ConflictPrompter = planner.Prompter
+
+// BuiltinConflictStrategies returns the names of the built-in conflict
+// resolution strategies: adopt, backup, fail, overwrite, prompt and skip.
+func BuiltinConflictStrategies() []string {
+	return planner.BuiltinStrategies()
+}
+
+// conflictStrategies creates the registry of the built-in strategies and
+// those of cfg, and checks that cfg.OnConflict and cfg.ConflictPolicies
+// name them.
+func conflictStrategies(cfg Config) (*planner.StrategyRegistry, error) {
+	registry := planner.NewStrategyRegistry(cfg.ConflictPrompt)
+	for _, strategy := range cfg.ConflictStrategies {
+		if err := registry.Register(strategy); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.OnConflict != "" {
+		if _, ok := registry.Lookup(cfg.OnConflict); !ok {
+			return nil, fmt.Errorf("unknown conflict strategy %q (available: %s)", cfg.OnConflict, strings.Join(registry.Names(), ", "))
+		}
+	}
+	for _, pattern := range slices.Sorted(maps.Keys(cfg.ConflictPolicies)) {
+		if err := planner.ValidatePathPattern(pattern); err != nil {
+			return nil, fmt.Errorf("conflict policy: %w", err)
+		}
+		name := cfg.ConflictPolicies[pattern]
+		if _, ok := registry.Lookup(name); !ok {
+			return nil, fmt.Errorf("conflict policy %q: unknown conflict strategy %q (available: %s)", pattern, name, strings.Join(registry.Names(), ", "))
+		}
+	}
+	return registry, nil
+}
+
+// resolutionPolicies returns the policies of cfg: OnConflict for existing
+// files and wrong links, or backing up files when Backup is set, and
+// failing otherwise, overridden by ConflictPolicies at matching paths.
+func resolutionPolicies(cfg Config, strategies *planner.StrategyRegistry) planner.ResolutionPolicies {
+	policies := planner.DefaultPolicies()
+	policies.Strategies = strategies
+	switch {
+	case cfg.OnConflict != "":
+		policies.OnFileExists = planner.ResolutionPolicy(cfg.OnConflict)
+		policies.OnWrongLink = planner.ResolutionPolicy(cfg.OnConflict)
+	case cfg.Backup:
+		policies.OnFileExists = planner.PolicyBackup
+	}
+	policies.Paths = pathPolicies(cfg)
+	return policies
+}
+
+// pathPolicies converts cfg.ConflictPolicies to planner path policies
+// over absolute paths, longest pattern first.
+func pathPolicies(cfg Config) []planner.PathPolicy {
+	if len(cfg.ConflictPolicies) == 0 {
+		return nil
+	}
+	paths := make([]planner.PathPolicy, 0, len(cfg.ConflictPolicies))
+	for pattern, name := range cfg.ConflictPolicies {
+		policy := planner.ResolutionPolicy(name)
+		paths = append(paths, planner.PathPolicy{
+			Pattern:      targetPattern(cfg.TargetDir, pattern),
+			OnFileExists: policy,
+			OnWrongLink:  policy,
+		})
+	}
+	slices.SortFunc(paths, func(a, b planner.PathPolicy) int {
+		if n := cmp.Compare(len(b.Pattern), len(a.Pattern)); n != 0 {
+			return n
+		}
+		return cmp.Compare(a.Pattern, b.Pattern)
+	})
+	return paths
+}
+
+// targetPattern resolves a conflict policy pattern against targetDir.
+func targetPattern(targetDir, pattern string) string {
+	if pattern == "~" {
+		return targetDir
+	}
+	if rest, ok := strings.CutPrefix(pattern, "~/"); ok {
+		return filepath.Join(targetDir, rest)
+	}
+	if filepath.IsAbs(pattern) {
+		return pattern
+	}
+	return filepath.Join(targetDir, pattern)
+}
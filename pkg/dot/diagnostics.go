@@ -1,7 +1,20 @@
 package dot
 
+import (
+	"slices"
+	"time"
+)
+
+// DiagnosticSchemaVersion is the version of the JSON and YAML schema of
+// DiagnosticReport. It changes only when fields are removed or change
+// meaning; new fields may be added within a version.
+const DiagnosticSchemaVersion = 1
+
 // DiagnosticReport contains health check results.
 type DiagnosticReport struct {
+	// SchemaVersion is the DiagnosticSchemaVersion the report follows.
+	SchemaVersion int `json:"schema_version" yaml:"schema_version"`
+
 	OverallHealth HealthStatus    `json:"overall_health" yaml:"overall_health"`
 	Issues        []Issue         `json:"issues" yaml:"issues"`
 	Statistics    DiagnosticStats `json:"statistics" yaml:"statistics"`
@@ -48,6 +61,7 @@ type Issue struct {
 	Severity   IssueSeverity `json:"severity" yaml:"severity"`
 	Type       IssueType     `json:"type" yaml:"type"`
 	Path       string        `json:"path,omitempty" yaml:"path,omitempty"`
+	Package    string        `json:"package,omitempty" yaml:"package,omitempty"`
 	Message    string        `json:"message" yaml:"message"`
 	Suggestion string        `json:"suggestion,omitempty" yaml:"suggestion,omitempty"`
 }
@@ -94,7 +108,8 @@ type IssueType int
 const (
 	// IssueBrokenLink indicates a symlink pointing to a non-existent target.
 	IssueBrokenLink IssueType = iota
-	// IssueOrphanedLink indicates a symlink not managed by any package.
+	// IssueOrphanedLink indicates a symlink into the package directory not
+	// managed by any package.
 	IssueOrphanedLink
 	// IssueWrongTarget indicates a symlink pointing to an unexpected target.
 	IssueWrongTarget
@@ -104,6 +119,25 @@ const (
 	IssueCircular
 	// IssueManifestInconsistency indicates mismatch between manifest and filesystem.
 	IssueManifestInconsistency
+	// IssueStaleRender indicates rendered template output no longer matches its template.
+	IssueStaleRender
+	// IssueScanTruncated indicates the orphan scan stopped at its budget,
+	// so orphaned links beyond it were not looked for.
+	IssueScanTruncated
+	// IssueForeignLink indicates a symlink outside the package directory,
+	// such as one created by another tool, that dot leaves alone.
+	IssueForeignLink
+	// IssueSourceUnavailable indicates the package directory is missing, so
+	// link sources were not checked.
+	IssueSourceUnavailable
+	// IssueWrongPackage indicates a managed link resolving into the
+	// directory of another package than the one recorded in the manifest,
+	// as after a package is renamed.
+	IssueWrongPackage
+	// IssuePermissionDrift indicates a package file others can change,
+	// that cannot be read, or that belongs to another user, or rendered
+	// output whose mode drifted from its template.
+	IssuePermissionDrift
 )
 
 // String returns the string representation of issue type.
@@ -121,11 +155,56 @@ func (t IssueType) String() string {
 		return "circular"
 	case IssueManifestInconsistency:
 		return "manifest_inconsistency"
+	case IssueStaleRender:
+		return "stale_render"
+	case IssueScanTruncated:
+		return "scan_truncated"
+	case IssueForeignLink:
+		return "foreign_link"
+	case IssueSourceUnavailable:
+		return "source_unavailable"
+	case IssueWrongPackage:
+		return "wrong_package"
+	case IssuePermissionDrift:
+		return "permission_drift"
 	default:
 		return "unknown"
 	}
 }
 
+// Description returns a one-sentence description of the problems of type
+// t, such as for the rules of SARIF reports.
+func (t IssueType) Description() string {
+	switch t {
+	case IssueBrokenLink:
+		return "A symlink points to a path that does not exist."
+	case IssueOrphanedLink:
+		return "A symlink into the package directory is not managed by any package."
+	case IssueWrongTarget:
+		return "A managed symlink points somewhere other than its package file."
+	case IssuePermission:
+		return "A path could not be checked for lack of permissions."
+	case IssueCircular:
+		return "A symlink resolves back to itself."
+	case IssueManifestInconsistency:
+		return "The manifest does not match the filesystem."
+	case IssueStaleRender:
+		return "Rendered template output no longer matches its template."
+	case IssueScanTruncated:
+		return "The orphan scan stopped at its budget before covering the target directory."
+	case IssueForeignLink:
+		return "A symlink points outside the package directory and is left alone."
+	case IssueSourceUnavailable:
+		return "The package directory is missing, so link sources were not checked."
+	case IssueWrongPackage:
+		return "A managed symlink points into another package than the one recorded."
+	case IssuePermissionDrift:
+		return "A package file has unsafe permissions or owner, or rendered output lost the mode of its template."
+	default:
+		return "An unknown problem."
+	}
+}
+
 // MarshalJSON marshals IssueType as a string.
 func (t IssueType) MarshalJSON() ([]byte, error) {
 	return []byte(`"` + t.String() + `"`), nil
@@ -142,6 +221,95 @@ type DiagnosticStats struct {
 	BrokenLinks   int `json:"broken_links" yaml:"broken_links"`
 	OrphanedLinks int `json:"orphaned_links" yaml:"orphaned_links"`
 	ManagedLinks  int `json:"managed_links" yaml:"managed_links"`
+
+	// ForeignLinks counts the unmanaged links pointing outside the package
+	// directory. They are not counted as orphaned.
+	ForeignLinks int `json:"foreign_links" yaml:"foreign_links"`
+
+	// UnknownLinks counts the managed links into an unavailable package
+	// directory, whose sources could not be checked.
+	UnknownLinks int `json:"unknown_links,omitempty" yaml:"unknown_links,omitempty"`
+
+	// FilesScanned counts the directory entries examined by the orphan scan.
+	FilesScanned int `json:"files_scanned" yaml:"files_scanned"`
+
+	// ScanTruncated is set when the orphan scan stopped at the entry or
+	// time budget of its ScanConfig.
+	ScanTruncated bool `json:"scan_truncated,omitempty" yaml:"scan_truncated,omitempty"`
+
+	// ScanDuration is how long the health check took.
+	ScanDuration time.Duration `json:"scan_duration" yaml:"scan_duration"`
+
+	// Packages breaks the managed link counts down by package.
+	Packages map[string]PackageStats `json:"packages,omitempty" yaml:"packages,omitempty"`
+}
+
+// PackageStats contains the statistics of one managed package.
+type PackageStats struct {
+	Links       int `json:"links" yaml:"links"`
+	BrokenLinks int `json:"broken_links" yaml:"broken_links"`
+
+	// UnknownLinks counts the links whose sources could not be checked.
+	UnknownLinks int `json:"unknown_links,omitempty" yaml:"unknown_links,omitempty"`
+
+	// ManagedLinks is the link count the manifest records for the package.
+	ManagedLinks int `json:"managed_links" yaml:"managed_links"`
+
+	// Issues counts the issues reported for the package.
+	Issues int `json:"issues" yaml:"issues"`
+
+	// Health is the health of the package alone, judged like the overall
+	// health of the report.
+	Health HealthStatus `json:"health" yaml:"health"`
+}
+
+// ForPackages returns the part of the report about the named packages:
+// their issues, including orphaned links into them, and their statistics.
+// Issues of no package, such as truncated scans, and the statistics of the
+// orphan scan are left out, and the overall health is the worst health of
+// the named packages. Names the report has no statistics for are ignored.
+func (r DiagnosticReport) ForPackages(names ...string) DiagnosticReport {
+	filtered := DiagnosticReport{
+		SchemaVersion: r.SchemaVersion,
+		OverallHealth: HealthOK,
+		Issues:        []Issue{},
+		Statistics: DiagnosticStats{
+			ScanDuration: r.Statistics.ScanDuration,
+			Packages:     make(map[string]PackageStats),
+		},
+	}
+	for _, issue := range r.Issues {
+		if issue.Package != "" && slices.Contains(names, issue.Package) {
+			filtered.Issues = append(filtered.Issues, issue)
+		}
+	}
+	for _, name := range names {
+		pkg, ok := r.Statistics.Packages[name]
+		if !ok {
+			continue
+		}
+		filtered.Statistics.Packages[name] = pkg
+		filtered.Statistics.TotalLinks += pkg.Links
+		filtered.Statistics.BrokenLinks += pkg.BrokenLinks
+		filtered.Statistics.UnknownLinks += pkg.UnknownLinks
+		filtered.Statistics.ManagedLinks += pkg.ManagedLinks
+		filtered.OverallHealth = max(filtered.OverallHealth, pkg.Health)
+	}
+	return filtered
+}
+
+// HealthThresholds set how many problems are tolerated before they affect
+// the overall health. Tolerated problems are still reported as issues.
+type HealthThresholds struct {
+	// OrphanedLinks is the number of orphaned links tolerated before they
+	// make the overall health a warning.
+	// Default: 0 (any orphaned link is a warning)
+	OrphanedLinks int
+
+	// BrokenLinks is the number of broken links tolerated before they make
+	// the overall health an error. Tolerated broken links are warnings.
+	// Default: 0 (any broken link is an error)
+	BrokenLinks int
 }
 
 // ScanMode controls orphaned link detection behavior.
@@ -185,7 +353,10 @@ type ScanConfig struct {
 	ScopeToDirs []string
 
 	// SkipPatterns are directory names/patterns to skip during scanning.
-	// Default constructors include common large directories unlikely to contain dotfile symlinks.
+	// A pattern matches the trailing components of a directory path, and
+	// each component may be a glob, as in "Library/CloudStorage" or
+	// "OneDrive*".
+	// Default constructors use DefaultScanSkipPatterns.
 	SkipPatterns []string
 
 	// MaxWorkers limits parallel directory scanning goroutines.
@@ -199,11 +370,59 @@ type ScanConfig struct {
 	// Useful for fast health checks without full enumeration.
 	// Default: 0 (unlimited)
 	MaxIssues int
+
+	// MaxEntries limits the directory entries the orphan scan visits.
+	// When reached, the scan stops and reports what it found so far with
+	// an IssueScanTruncated issue.
+	// Values <= 0 mean unlimited.
+	// Default: 0 (unlimited)
+	MaxEntries int
+
+	// MaxDuration limits how long the orphan scan runs. When reached, the
+	// scan stops and reports what it found so far with an
+	// IssueScanTruncated issue.
+	// Values <= 0 mean unlimited.
+	// Default: 0 (unlimited)
+	MaxDuration time.Duration
+
+	// Thresholds tune how issues affect the overall health.
+	// Default: zero (every issue counts)
+	Thresholds HealthThresholds
+
+	// CheckPermissions adds IssuePermissionDrift checks of the modes and
+	// owners of the package files behind managed links.
+	// Default: false
+	CheckPermissions bool
+
+	// Packages restricts checks to the links of the named packages and,
+	// unless ScopeToDirs is set, orphan scanning to the directories
+	// holding them. Empty means every managed package.
+	Packages []string
+
+	// OnIssue, when set, is called with each issue as soon as it is
+	// found, so that long runs show problems before the report is
+	// complete. Every issue of the report is passed once, in the order the
+	// issues are found rather than the order of the report. Calls are not
+	// concurrent, and should return quickly: checks wait for them.
+	// Default: nil
+	OnIssue func(Issue)
+}
+
+// emit passes issues to OnIssue, if set.
+func (c ScanConfig) emit(issues ...Issue) {
+	if c.OnIssue == nil {
+		return
+	}
+	for _, issue := range issues {
+		c.OnIssue(issue)
+	}
 }
 
-// defaultSkipPatterns returns common directories to skip during scanning.
-// These are large directories unlikely to contain dotfile symlinks.
-func defaultSkipPatterns() []string {
+// DefaultScanSkipPatterns returns the directories skipped by default in
+// orphan scans and adoption globs. These are large, cloud-synced, or
+// volatile directories unlikely to contain dotfile symlinks, where
+// scanning is slow and turns up links dot did not create.
+func DefaultScanSkipPatterns() []string {
 	return []string{
 		// Version control
 		".git", ".svn", ".hg",
@@ -213,9 +432,14 @@ func defaultSkipPatterns() []string {
 		".cache", ".npm", ".cargo", ".rustup", ".pyenv", ".rbenv",
 		".gradle", ".m2", "go/pkg",
 		// Application state and data
-		".docker", ".rd", ".local/share", ".kube/cache",
+		".docker", ".rd", ".local/share", ".kube/cache", ".var/app", "snap",
 		// Editor caches
 		".vscode", ".config/Code",
+		// Cloud-synced folders
+		"Library/CloudStorage", "Library/Mobile Documents",
+		"OneDrive", "OneDrive - *", "Dropbox", "Google Drive", "Nextcloud",
+		// Game libraries
+		"Steam", ".steam",
 		// System directories (macOS)
 		"Library", ".Trash",
 	}
@@ -228,7 +452,7 @@ func DefaultScanConfig() ScanConfig {
 		Mode:         ScanScoped,
 		MaxDepth:     3,
 		ScopeToDirs:  nil,
-		SkipPatterns: defaultSkipPatterns(),
+		SkipPatterns: DefaultScanSkipPatterns(),
 		MaxWorkers:   0, // Use NumCPU
 		MaxIssues:    0, // Unlimited
 	}
@@ -240,7 +464,7 @@ func ScopedScanConfig() ScanConfig {
 		Mode:         ScanScoped,
 		MaxDepth:     3,
 		ScopeToDirs:  nil,
-		SkipPatterns: defaultSkipPatterns(),
+		SkipPatterns: DefaultScanSkipPatterns(),
 		MaxWorkers:   0,
 		MaxIssues:    0,
 	}
@@ -255,7 +479,7 @@ func DeepScanConfig(maxDepth int) ScanConfig {
 		Mode:         ScanDeep,
 		MaxDepth:     maxDepth,
 		ScopeToDirs:  nil,
-		SkipPatterns: defaultSkipPatterns(),
+		SkipPatterns: DefaultScanSkipPatterns(),
 		MaxWorkers:   0,
 		MaxIssues:    0,
 	}
@@ -43,6 +43,11 @@ func TestDefaultScanConfig(t *testing.T) {
 	assert.Contains(t, cfg.SkipPatterns, "Library")
 	assert.Contains(t, cfg.SkipPatterns, ".docker")
 	assert.Contains(t, cfg.SkipPatterns, ".pyenv")
+	// Cloud-synced and volatile directories
+	assert.Contains(t, cfg.SkipPatterns, "Library/CloudStorage")
+	assert.Contains(t, cfg.SkipPatterns, "OneDrive")
+	assert.Contains(t, cfg.SkipPatterns, "Steam")
+	assert.Equal(t, dot.DefaultScanSkipPatterns(), cfg.SkipPatterns)
 }
 
 func TestScopedScanConfig(t *testing.T) {
@@ -80,6 +80,9 @@ func TestIssueType_String(t *testing.T) {
 		{dot.IssuePermission, "permission"},
 		{dot.IssueCircular, "circular"},
 		{dot.IssueManifestInconsistency, "manifest_inconsistency"},
+		{dot.IssueStaleRender, "stale_render"},
+		{dot.IssueScanTruncated, "scan_truncated"},
+		{dot.IssueForeignLink, "foreign_link"},
 	}
 
 	for _, tt := range tests {
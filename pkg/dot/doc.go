@@ -106,6 +106,8 @@
 //   - Logger: Logger implementation (required)
 //   - Tracer: Distributed tracing (optional, defaults to noop)
 //   - Metrics: Metrics collection (optional, defaults to noop)
+//   - EventSink: Per-operation progress events (optional); Client.Events
+//     returns the bus carrying these and the progress of other commands
 //   - LinkMode: Relative or absolute symlinks (default: relative)
 //   - Folding: Enable directory folding (default: true)
 //   - DryRun: Preview mode (default: false)
@@ -0,0 +1,80 @@
+package dot
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// scanBudget bounds the entries an orphan scan visits and how long it
+// runs. It is shared by the workers of a parallel scan; a nil budget is
+// unlimited.
+type scanBudget struct {
+	maxEntries int64
+	maxTime    time.Duration
+	deadline   time.Time
+	entries    atomic.Int64
+	stopped    atomic.Bool
+
+	mu     sync.Mutex
+	reason string
+	dir    string
+}
+
+// newScanBudget starts the budget of cfg, or returns nil if it sets none.
+func newScanBudget(cfg ScanConfig) *scanBudget {
+	if cfg.MaxEntries <= 0 && cfg.MaxDuration <= 0 {
+		return nil
+	}
+	b := &scanBudget{maxEntries: int64(max(cfg.MaxEntries, 0)), maxTime: cfg.MaxDuration}
+	if cfg.MaxDuration > 0 {
+		b.deadline = time.Now().Add(cfg.MaxDuration)
+	}
+	return b
+}
+
+// spend counts an entry of dir and reports whether the scan may visit it.
+func (b *scanBudget) spend(dir string) bool {
+	if b == nil {
+		return true
+	}
+	if b.exhausted() {
+		return false
+	}
+	if b.maxEntries > 0 && b.entries.Add(1) > b.maxEntries {
+		b.exhaust(strconv.FormatInt(b.maxEntries, 10)+" entries", dir)
+		return false
+	}
+	if !b.deadline.IsZero() && time.Now().After(b.deadline) {
+		b.exhaust(b.maxTime.String(), dir)
+		return false
+	}
+	return true
+}
+
+// exhaust records why the scan stopped and where, keeping the first.
+func (b *scanBudget) exhaust(reason, dir string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.reason == "" {
+		b.reason, b.dir = reason, dir
+		b.stopped.Store(true)
+	}
+}
+
+// exhausted reports whether the scan has stopped at the budget.
+func (b *scanBudget) exhausted() bool {
+	return b != nil && b.stopped.Load()
+}
+
+// exhaustion returns the limit that stopped the scan and the directory it
+// stopped in, or "" if the scan was not stopped.
+func (b *scanBudget) exhaustion() (reason, dir string) {
+	if b == nil {
+		return "", ""
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.reason, b.dir
+}
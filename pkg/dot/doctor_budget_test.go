@@ -0,0 +1,110 @@
+package dot_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// setupBudgetClient creates a client managing one package, with three
+// target directories of orphaned links.
+func setupBudgetClient(t *testing.T) *dot.Client {
+	t.Helper()
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/app", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/app/dot-config", []byte("cfg"), 0644))
+	for _, dir := range []string{"dir1", "dir2", "dir3"} {
+		require.NoError(t, fs.MkdirAll(ctx, "/test/target/"+dir, 0755))
+		for i := 0; i < 20; i++ {
+			require.NoError(t, fs.Symlink(ctx, "/test/packages/app/dot-config", fmt.Sprintf("/test/target/%s/orphan%d", dir, i)))
+		}
+	}
+
+	client, err := dot.NewClient(dot.Config{
+		PackageDir: "/test/packages",
+		TargetDir:  "/test/target",
+		FS:         fs,
+		Logger:     adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+	require.NoError(t, client.Manage(ctx, "app"))
+	return client
+}
+
+func truncatedIssues(report dot.DiagnosticReport) []dot.Issue {
+	var truncated []dot.Issue
+	for _, issue := range report.Issues {
+		if issue.Type == dot.IssueScanTruncated {
+			truncated = append(truncated, issue)
+		}
+	}
+	return truncated
+}
+
+func TestClient_Doctor_MaxEntries(t *testing.T) {
+	for _, workers := range []int{1, 4} {
+		t.Run(fmt.Sprintf("workers=%d", workers), func(t *testing.T) {
+			client := setupBudgetClient(t)
+
+			scanCfg := dot.DeepScanConfig(5)
+			scanCfg.MaxWorkers = workers
+			scanCfg.ScopeToDirs = []string{"/test/target/dir1", "/test/target/dir2", "/test/target/dir3"}
+			scanCfg.MaxEntries = 25
+
+			report, err := client.DoctorWithScan(context.Background(), scanCfg)
+			require.NoError(t, err)
+
+			assert.True(t, report.Statistics.ScanTruncated)
+			assert.LessOrEqual(t, report.Statistics.FilesScanned, 25)
+			assert.Greater(t, report.Statistics.OrphanedLinks, 0, "partial results are reported")
+
+			truncated := truncatedIssues(report)
+			require.Len(t, truncated, 1)
+			assert.Equal(t, dot.SeverityWarning, truncated[0].Severity)
+			assert.Contains(t, truncated[0].Message, "25 entries")
+			assert.Contains(t, truncated[0].Path, "dir")
+		})
+	}
+}
+
+func TestClient_Doctor_MaxDuration(t *testing.T) {
+	client := setupBudgetClient(t)
+
+	scanCfg := dot.DeepScanConfig(5)
+	scanCfg.MaxWorkers = 1
+	scanCfg.MaxDuration = time.Nanosecond
+
+	report, err := client.DoctorWithScan(context.Background(), scanCfg)
+	require.NoError(t, err)
+
+	assert.True(t, report.Statistics.ScanTruncated)
+	truncated := truncatedIssues(report)
+	require.Len(t, truncated, 1)
+	assert.Contains(t, truncated[0].Message, "1ns")
+	assert.Equal(t, dot.HealthWarnings, report.OverallHealth)
+}
+
+func TestClient_Doctor_BudgetNotReached(t *testing.T) {
+	client := setupBudgetClient(t)
+
+	scanCfg := dot.DeepScanConfig(5)
+	scanCfg.MaxEntries = 1000
+	scanCfg.MaxDuration = time.Minute
+
+	report, err := client.DoctorWithScan(context.Background(), scanCfg)
+	require.NoError(t, err)
+
+	assert.False(t, report.Statistics.ScanTruncated)
+	assert.Empty(t, truncatedIssues(report))
+	assert.Equal(t, 60, report.Statistics.OrphanedLinks)
+}
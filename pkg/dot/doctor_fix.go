@@ -0,0 +1,343 @@
+package dot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/jamesainslie/dot/internal/domain"
+	"github.com/jamesainslie/dot/internal/manifest"
+)
+
+// FixPlan describes how doctor repairs the issues of a diagnostic report.
+type FixPlan struct {
+	// Plan holds the operations that repair the fixed issues. It runs
+	// through the executor like any other plan, so a failed operation
+	// rolls back the ones already applied.
+	Plan Plan
+
+	// Fixed lists the issues the fix repairs.
+	Fixed []Issue
+
+	// Skipped lists the issues that need manual action.
+	Skipped []Issue
+
+	// stale maps packages to manifest links their package no longer
+	// provides; they are dropped from the manifest once the plan succeeds.
+	stale map[string][]string
+}
+
+// fixTargets holds the desired state of one package, keyed by path.
+type fixTargets struct {
+	links   map[string]LinkCreate
+	dirs    map[string]DirCreate
+	renders map[string]FileRender
+}
+
+// fixBuilder accumulates the operations of a fix plan.
+type fixBuilder struct {
+	manifest *manifest.Manifest
+	targets  map[string]fixTargets
+	planned  map[OperationID]bool
+	ops      []Operation
+	fix      FixPlan
+}
+
+func (b *fixBuilder) add(ops ...Operation) {
+	for _, op := range ops {
+		if !b.planned[op.ID()] {
+			b.planned[op.ID()] = true
+			b.ops = append(b.ops, op)
+		}
+	}
+}
+
+// PlanFix computes the operations that repair the issues in report.
+//
+// Missing, broken and replaced links of managed packages are recreated as
+// 'dot manage' would create them; a regular file in the way is moved to
+// the backup directory first, and links into another package than their
+// own are pointed back at it. Managed links whose package no longer
+// provides the file are removed along with their manifest entry, and
+// unmanaged links with a missing target are removed. Other issues,
+// including orphaned links with a valid target and permission problems,
+// are skipped.
+func (s *DoctorService) PlanFix(ctx context.Context, report DiagnosticReport) (FixPlan, error) {
+	targetPath, err := s.getTargetPath()
+	if err != nil {
+		return FixPlan{}, err
+	}
+
+	m := manifest.New()
+	if result := s.manifestSvc.Load(ctx, targetPath); result.IsOk() {
+		m = result.Unwrap()
+	}
+
+	b := &fixBuilder{
+		manifest: &m,
+		targets:  make(map[string]fixTargets),
+		planned:  make(map[OperationID]bool),
+		fix:      FixPlan{stale: make(map[string][]string)},
+	}
+	for _, issue := range report.Issues {
+		if issue.Type == IssueForeignLink && issue.Severity == SeverityInfo {
+			// Working links of other tools need no action
+			continue
+		}
+		fixed, err := s.planIssueFix(ctx, b, issue)
+		if err != nil {
+			return FixPlan{}, err
+		}
+		if fixed {
+			b.fix.Fixed = append(b.fix.Fixed, issue)
+		} else {
+			b.fix.Skipped = append(b.fix.Skipped, issue)
+		}
+	}
+
+	b.fix.Plan = Plan{
+		Operations: b.ops,
+		Metadata: PlanMetadata{
+			OperationCount: len(b.ops),
+			LinkCount:      countOpsByKind(b.ops, OpKindLinkCreate),
+			DirCount:       countOpsByKind(b.ops, OpKindDirCreate),
+		},
+	}
+	return b.fix, nil
+}
+
+// Fix plans and applies the repairs for report. In dry-run mode the plan
+// is returned without being applied.
+func (s *DoctorService) Fix(ctx context.Context, report DiagnosticReport) (FixPlan, error) {
+	fix, err := s.PlanFix(ctx, report)
+	if err != nil {
+		return FixPlan{}, err
+	}
+	s.metrics.recordPlan("doctor", fix.Plan)
+	if s.dryRun || len(fix.Fixed) == 0 {
+		return fix, nil
+	}
+
+	if len(fix.Plan.Operations) > 0 {
+		result := s.metrics.execute(ctx, s.executor, "doctor", fix.Plan)
+		if !result.IsOk() {
+			return fix, result.UnwrapErr()
+		}
+		if execResult := result.Unwrap(); !execResult.Success() {
+			return fix, fmt.Errorf("execution failed: %d operations failed", len(execResult.Failed))
+		}
+	}
+
+	if err := s.pruneManifest(ctx, fix.stale); err != nil {
+		return fix, fmt.Errorf("update manifest: %w", err)
+	}
+	s.logger.Info(ctx, "doctor_fix_applied", "fixed", len(fix.Fixed), "skipped", len(fix.Skipped))
+	return fix, nil
+}
+
+// planIssueFix adds the operations repairing issue and reports whether
+// the issue can be fixed.
+func (s *DoctorService) planIssueFix(ctx context.Context, b *fixBuilder, issue Issue) (bool, error) {
+	fullPath := issue.Path
+	if !filepath.IsAbs(fullPath) {
+		fullPath = filepath.Join(s.targetDir, issue.Path)
+	}
+	target := NewTargetPath(fullPath)
+	if !target.IsOk() {
+		return false, nil
+	}
+	isLink, _ := s.fs.IsSymlink(ctx, fullPath)
+
+	switch {
+	case issue.Type == IssueBrokenLink && issue.Package == "":
+		// Unmanaged link pointing nowhere
+		b.add(NewLinkDelete(fixOpID("unlink", fullPath), target.Unwrap()))
+		return true, nil
+
+	case issue.Type == IssueBrokenLink:
+		targets, err := s.fixTargetsFor(ctx, b, issue.Package)
+		if err != nil {
+			return false, err
+		}
+		link, provided := targets.links[fullPath]
+		if isLink {
+			b.add(NewLinkDelete(fixOpID("unlink", fullPath), target.Unwrap()))
+		} else if s.fs.Exists(ctx, fullPath) {
+			// Something other than a link appeared since the check
+			return false, nil
+		}
+		if !provided {
+			b.fix.stale[issue.Package] = append(b.fix.stale[issue.Package], issue.Path)
+			return true, nil
+		}
+		b.add(s.linkWithParents(targets, link)...)
+		return true, nil
+
+	case issue.Type == IssueWrongPackage:
+		targets, err := s.fixTargetsFor(ctx, b, issue.Package)
+		if err != nil {
+			return false, err
+		}
+		link, provided := targets.links[fullPath]
+		if !provided || !isLink {
+			// A renamed package is fixed by managing it under its new name
+			return false, nil
+		}
+		b.add(NewLinkDelete(fixOpID("unlink", fullPath), target.Unwrap()))
+		b.add(s.linkWithParents(targets, link)...)
+		return true, nil
+
+	case issue.Type == IssueWrongTarget:
+		targets, err := s.fixTargetsFor(ctx, b, issue.Package)
+		if err != nil {
+			return false, err
+		}
+		link, provided := targets.links[fullPath]
+		if !provided || isLink || s.backupDir == "" {
+			return false, nil
+		}
+		info, err := s.fs.Stat(ctx, fullPath)
+		if err != nil || info.IsDir() {
+			return false, nil
+		}
+		data, err := s.fs.ReadFile(ctx, fullPath)
+		if err != nil {
+			return false, nil
+		}
+		backupDir := NewFilePath(s.backupDir)
+		source := NewFilePath(fullPath)
+		if !backupDir.IsOk() || !source.IsOk() {
+			return false, nil
+		}
+		b.add(NewFileStash(fixOpID("stash", fullPath), source.Unwrap(), backupDir.Unwrap(), domain.HashContent(data)))
+		b.add(s.linkWithParents(targets, link)...)
+		return true, nil
+
+	default:
+		return false, nil
+	}
+}
+
+// linkWithParents returns link preceded by the operations it needs: the
+// directories above it that do not exist yet and the template render
+// producing its source.
+func (s *DoctorService) linkWithParents(targets fixTargets, link LinkCreate) []Operation {
+	var ops []Operation
+	for dir := filepath.Dir(link.Target.String()); dir != s.targetDir && dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if op, ok := targets.dirs[dir]; ok {
+			ops = append([]Operation{op}, ops...)
+		}
+	}
+	if render, ok := targets.renders[link.Source.String()]; ok {
+		ops = append(ops, render)
+	}
+	return append(ops, link)
+}
+
+// fixTargetsFor plans the package as 'dot manage' would and indexes the
+// resulting links, including those blocked by a conflict.
+func (s *DoctorService) fixTargetsFor(ctx context.Context, b *fixBuilder, pkg string) (fixTargets, error) {
+	if targets, ok := b.targets[pkg]; ok {
+		return targets, nil
+	}
+
+	targets := fixTargets{
+		links:   make(map[string]LinkCreate),
+		dirs:    make(map[string]DirCreate),
+		renders: make(map[string]FileRender),
+	}
+	plan, err := s.manageSvc.PlanManage(ctx, pkg)
+	var notFound ErrPackageNotFound
+	switch {
+	case errors.As(err, &notFound):
+		// A removed package provides nothing
+		b.targets[pkg] = targets
+		return targets, nil
+	case err != nil:
+		return fixTargets{}, fmt.Errorf("plan package %s: %w", pkg, err)
+	}
+
+	for _, op := range slices.Concat(plan.Operations, plan.Satisfied) {
+		switch op := op.(type) {
+		case LinkCreate:
+			targets.links[op.Target.String()] = op
+		case DirCreate:
+			targets.dirs[op.Path.String()] = op
+		case FileRender:
+			targets.renders[op.Dest.String()] = op
+		}
+	}
+	for _, conflict := range plan.Metadata.Conflicts {
+		source, ok := conflict.Context["source"]
+		if !ok {
+			continue
+		}
+		sourcePath := NewFilePath(source)
+		targetPath := NewTargetPath(conflict.Path)
+		if sourcePath.IsOk() && targetPath.IsOk() {
+			targets.links[conflict.Path] = NewLinkCreate(fixOpID("link", conflict.Path), sourcePath.Unwrap(), targetPath.Unwrap())
+		}
+	}
+
+	// Adopted packages link their target to the package root
+	if info, ok := b.manifest.GetPackage(pkg); ok && info.Source == manifest.SourceAdopted {
+		root := NewFilePath(filepath.Join(s.packageDir, pkg))
+		for _, link := range info.Links {
+			fullPath := filepath.Join(s.targetDir, link)
+			targetPath := NewTargetPath(fullPath)
+			if _, planned := targets.links[fullPath]; planned || !root.IsOk() || !targetPath.IsOk() || !s.fs.Exists(ctx, root.Unwrap().String()) {
+				continue
+			}
+			targets.links[fullPath] = NewLinkCreate(fixOpID("link", fullPath), root.Unwrap(), targetPath.Unwrap())
+		}
+	}
+
+	b.targets[pkg] = targets
+	return targets, nil
+}
+
+// pruneManifest removes links from the manifest entries of their packages.
+func (s *DoctorService) pruneManifest(ctx context.Context, stale map[string][]string) error {
+	if len(stale) == 0 {
+		return nil
+	}
+	targetPath, err := s.getTargetPath()
+	if err != nil {
+		return err
+	}
+	result := s.manifestSvc.Load(ctx, targetPath)
+	if !result.IsOk() {
+		return result.UnwrapErr()
+	}
+	m := result.Unwrap()
+
+	for pkg, links := range stale {
+		info, ok := m.GetPackage(pkg)
+		if !ok {
+			continue
+		}
+		info.Links = slices.DeleteFunc(info.Links, func(link string) bool { return slices.Contains(links, link) })
+		info.Templates = slices.DeleteFunc(info.Templates, func(render manifest.RenderInfo) bool { return slices.Contains(links, render.Link) })
+		info.LinkCount = len(info.Links)
+		m.AddPackage(info)
+	}
+	return s.manifestSvc.Save(ctx, targetPath, m)
+}
+
+// fixOpID returns the operation ID for a fix step on path.
+func fixOpID(step, path string) OperationID {
+	return OperationID("doctor-fix-" + step + "-" + strings.TrimPrefix(path, "/"))
+}
+
+func countOpsByKind(ops []Operation, kind OperationKind) int {
+	n := 0
+	for _, op := range ops {
+		if op.Kind() == kind {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,182 @@
+package dot_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/internal/manifest"
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// setupFixTest manages package app, providing .vimrc and config/app/rc.
+func setupFixTest(t *testing.T, dryRun bool) (*adapters.MemFS, *dot.Client) {
+	t.Helper()
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/app/config/app", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/app/dot-vimrc", []byte("set nu"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/app/config/app/rc", []byte("rc"), 0644))
+
+	cfg := dot.Config{
+		PackageDir: "/test/packages",
+		TargetDir:  "/test/target",
+		FS:         fs,
+		Logger:     adapters.NewNoopLogger(),
+	}
+	client, err := dot.NewClient(cfg)
+	require.NoError(t, err)
+	require.NoError(t, client.Manage(ctx, "app"))
+
+	if dryRun {
+		cfg.DryRun = true
+		client, err = dot.NewClient(cfg)
+		require.NoError(t, err)
+	}
+	return fs, client
+}
+
+func TestClient_Fix_RestoresManagedLinks(t *testing.T) {
+	ctx := context.Background()
+	fs, client := setupFixTest(t, false)
+
+	// A deleted link and a file written over a link
+	require.NoError(t, fs.Remove(ctx, "/test/target/.vimrc"))
+	require.NoError(t, fs.Remove(ctx, "/test/target/config/app/rc"))
+	require.NoError(t, fs.WriteFile(ctx, "/test/target/config/app/rc", []byte("local edit"), 0644))
+
+	report, err := client.DoctorWithScan(ctx, dot.ScanConfig{Mode: dot.ScanOff})
+	require.NoError(t, err)
+	require.Len(t, report.Issues, 2)
+	assert.Equal(t, "app", report.Issues[0].Package)
+
+	fix, err := client.Fix(ctx, report)
+	require.NoError(t, err)
+	assert.Len(t, fix.Fixed, 2)
+	assert.Empty(t, fix.Skipped)
+
+	target, err := fs.ReadLink(ctx, "/test/target/.vimrc")
+	require.NoError(t, err)
+	assert.Equal(t, "/test/packages/app/dot-vimrc", target)
+	target, err = fs.ReadLink(ctx, "/test/target/config/app/rc")
+	require.NoError(t, err)
+	assert.Equal(t, "/test/packages/app/config/app/rc", target)
+	assert.True(t, fs.Exists(ctx, "/test/target/.dot-backup"), "replaced file is backed up")
+
+	report, err = client.DoctorWithScan(ctx, dot.ScanConfig{Mode: dot.ScanOff})
+	require.NoError(t, err)
+	assert.Equal(t, dot.HealthOK, report.OverallHealth)
+}
+
+func TestClient_Fix_RemovesStaleLinks(t *testing.T) {
+	ctx := context.Background()
+	fs, client := setupFixTest(t, false)
+
+	// The package no longer provides .vimrc, leaving a dangling link
+	require.NoError(t, fs.Remove(ctx, "/test/packages/app/dot-vimrc"))
+	// An unmanaged link pointing nowhere, one that still works, and a
+	// broken link of another tool
+	require.NoError(t, fs.Symlink(ctx, "/test/packages/app/gone", "/test/target/config/app/old"))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/app/extra", []byte("x"), 0644))
+	require.NoError(t, fs.Symlink(ctx, "/test/packages/app/extra", "/test/target/config/app/other"))
+	require.NoError(t, fs.Symlink(ctx, "/nix/store/gone", "/test/target/config/app/nix"))
+
+	report, err := client.DoctorWithScan(ctx, dot.ScopedScanConfig())
+	require.NoError(t, err)
+
+	fix, err := client.Fix(ctx, report)
+	require.NoError(t, err)
+	assert.Len(t, fix.Fixed, 2)
+	require.Len(t, fix.Skipped, 2)
+	assert.ElementsMatch(t, []dot.IssueType{dot.IssueOrphanedLink, dot.IssueForeignLink},
+		[]dot.IssueType{fix.Skipped[0].Type, fix.Skipped[1].Type}, "broken links of other tools are left for manual action")
+
+	assert.False(t, fs.Exists(ctx, "/test/target/.vimrc"))
+	assert.False(t, fs.Exists(ctx, "/test/target/config/app/old"))
+	assert.True(t, fs.Exists(ctx, "/test/target/config/app/other"))
+	isLink, err := fs.IsSymlink(ctx, "/test/target/config/app/nix")
+	require.NoError(t, err)
+	assert.True(t, isLink, "links of other tools are left alone")
+
+	result := manifest.NewFSManifestStore(fs).Load(ctx, dot.NewTargetPath("/test/target").Unwrap())
+	require.True(t, result.IsOk())
+	m := result.Unwrap()
+	assert.Equal(t, []string{"config/app/rc"}, m.Packages["app"].Links)
+	assert.Equal(t, 1, m.Packages["app"].LinkCount)
+}
+
+func TestClient_Fix_DryRun(t *testing.T) {
+	ctx := context.Background()
+	fs, client := setupFixTest(t, true)
+	require.NoError(t, fs.Remove(ctx, "/test/target/.vimrc"))
+
+	report, err := client.DoctorWithScan(ctx, dot.ScanConfig{Mode: dot.ScanOff})
+	require.NoError(t, err)
+
+	fix, err := client.Fix(ctx, report)
+	require.NoError(t, err)
+	assert.Len(t, fix.Fixed, 1)
+	require.Len(t, fix.Plan.Operations, 1)
+	assert.Equal(t, dot.OpKindLinkCreate, fix.Plan.Operations[0].Kind())
+	assert.False(t, fs.Exists(ctx, "/test/target/.vimrc"), "dry run leaves the target untouched")
+}
+
+func TestClient_Fix_RelinksLinkIntoOtherPackage(t *testing.T) {
+	ctx := context.Background()
+	fs, client := setupFixTest(t, false)
+
+	// The link of app was pointed at a copy of its file in package other
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/other", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/other/dot-vimrc", []byte("set nu"), 0644))
+	require.NoError(t, fs.Remove(ctx, "/test/target/.vimrc"))
+	require.NoError(t, fs.Symlink(ctx, "/test/packages/other/dot-vimrc", "/test/target/.vimrc"))
+
+	report, err := client.DoctorWithScan(ctx, dot.ScanConfig{Mode: dot.ScanOff})
+	require.NoError(t, err)
+	require.Len(t, report.Issues, 1)
+	issue := report.Issues[0]
+	assert.Equal(t, dot.IssueWrongPackage, issue.Type)
+	assert.Equal(t, "app", issue.Package)
+	assert.Contains(t, issue.Message, "package other")
+	assert.Contains(t, issue.Suggestion, "dot remanage app")
+	assert.Equal(t, dot.HealthWarnings, report.OverallHealth)
+
+	fix, err := client.Fix(ctx, report)
+	require.NoError(t, err)
+	assert.Len(t, fix.Fixed, 1)
+
+	target, err := fs.ReadLink(ctx, "/test/target/.vimrc")
+	require.NoError(t, err)
+	assert.Equal(t, "/test/packages/app/dot-vimrc", target)
+}
+
+func TestClient_Doctor_RenamedPackage(t *testing.T) {
+	ctx := context.Background()
+	fs, client := setupFixTest(t, false)
+
+	// Package app was renamed to editor and its links repointed by hand
+	require.NoError(t, fs.Rename(ctx, "/test/packages/app", "/test/packages/editor"))
+	for _, link := range []string{".vimrc", "config/app/rc"} {
+		require.NoError(t, fs.Remove(ctx, "/test/target/"+link))
+	}
+	require.NoError(t, fs.Symlink(ctx, "/test/packages/editor/dot-vimrc", "/test/target/.vimrc"))
+	require.NoError(t, fs.Symlink(ctx, "/test/packages/editor/config/app/rc", "/test/target/config/app/rc"))
+
+	report, err := client.DoctorWithScan(ctx, dot.ScanConfig{Mode: dot.ScanOff})
+	require.NoError(t, err)
+	require.Len(t, report.Issues, 2)
+	for _, issue := range report.Issues {
+		assert.Equal(t, dot.IssueWrongPackage, issue.Type)
+		assert.Contains(t, issue.Suggestion, "'dot unmanage app' then 'dot manage editor'")
+	}
+
+	fix, err := client.Fix(ctx, report)
+	require.NoError(t, err)
+	assert.Empty(t, fix.Fixed)
+	assert.Len(t, fix.Skipped, 2)
+}
@@ -0,0 +1,60 @@
+package dot
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/jamesainslie/dot/internal/manifest"
+)
+
+// AdoptOrphans records orphaned links from report that point into the
+// package directory as links of the package they point into. The links
+// themselves are left as they are; only the manifest changes, and in
+// dry-run mode it is not written. Returns the issues that were adopted.
+func (s *DoctorService) AdoptOrphans(ctx context.Context, report DiagnosticReport) ([]Issue, error) {
+	targetPath, err := s.getTargetPath()
+	if err != nil {
+		return nil, err
+	}
+
+	m := manifest.New()
+	if result := s.manifestSvc.Load(ctx, targetPath); result.IsOk() {
+		m = result.Unwrap()
+	} else if !isManifestNotFoundError(result.UnwrapErr()) {
+		return nil, result.UnwrapErr()
+	}
+
+	var adopted []Issue
+	for _, issue := range report.Issues {
+		if issue.Type != IssueOrphanedLink || issue.Package == "" || filepath.IsAbs(issue.Path) {
+			continue
+		}
+		link := filepath.ToSlash(issue.Path)
+
+		info, exists := m.GetPackage(issue.Package)
+		if !exists {
+			info = manifest.PackageInfo{
+				Name:        issue.Package,
+				InstalledAt: time.Now(),
+				Source:      manifest.SourceManaged,
+			}
+		}
+		if !slices.Contains(info.Links, link) {
+			info.Links = append(info.Links, link)
+			info.LinkCount = len(info.Links)
+			m.AddPackage(info)
+		}
+		adopted = append(adopted, issue)
+	}
+
+	if len(adopted) == 0 || s.dryRun {
+		return adopted, nil
+	}
+	if err := s.manifestSvc.Save(ctx, targetPath, m); err != nil {
+		return nil, err
+	}
+	s.logger.Info(ctx, "doctor_orphans_adopted", "count", len(adopted))
+	return adopted, nil
+}
@@ -0,0 +1,61 @@
+package dot_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/manifest"
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+func TestClient_AdoptOrphans(t *testing.T) {
+	ctx := context.Background()
+	fs, client := setupFixTest(t, false)
+
+	// A link into the package created outside dot, and one elsewhere
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/app/config/app/extra", []byte("x"), 0644))
+	require.NoError(t, fs.Symlink(ctx, "/test/packages/app/config/app/extra", "/test/target/config/app/extra"))
+	require.NoError(t, fs.WriteFile(ctx, "/test/elsewhere", []byte("x"), 0644))
+	require.NoError(t, fs.Symlink(ctx, "/test/elsewhere", "/test/target/config/app/other"))
+
+	report, err := client.DoctorWithScan(ctx, dot.ScopedScanConfig())
+	require.NoError(t, err)
+	require.Len(t, report.Issues, 2)
+
+	adopted, err := client.AdoptOrphans(ctx, report)
+	require.NoError(t, err)
+	require.Len(t, adopted, 1)
+	assert.Equal(t, "config/app/extra", adopted[0].Path)
+	assert.Equal(t, "app", adopted[0].Package)
+
+	result := manifest.NewFSManifestStore(fs).Load(ctx, dot.NewTargetPath("/test/target").Unwrap())
+	require.True(t, result.IsOk())
+	assert.Contains(t, result.Unwrap().Packages["app"].Links, "config/app/extra")
+	assert.Equal(t, 3, result.Unwrap().Packages["app"].LinkCount)
+
+	report, err = client.DoctorWithScan(ctx, dot.ScopedScanConfig())
+	require.NoError(t, err)
+	require.Len(t, report.Issues, 1)
+	assert.Equal(t, "config/app/other", report.Issues[0].Path)
+	assert.Empty(t, report.Issues[0].Package)
+}
+
+func TestClient_AdoptOrphans_DryRun(t *testing.T) {
+	ctx := context.Background()
+	fs, client := setupFixTest(t, true)
+	require.NoError(t, fs.Symlink(ctx, "/test/packages/app/dot-vimrc", "/test/target/.exrc"))
+
+	report, err := client.DoctorWithScan(ctx, dot.DeepScanConfig(3))
+	require.NoError(t, err)
+
+	adopted, err := client.AdoptOrphans(ctx, report)
+	require.NoError(t, err)
+	require.Len(t, adopted, 1)
+
+	result := manifest.NewFSManifestStore(fs).Load(ctx, dot.NewTargetPath("/test/target").Unwrap())
+	require.True(t, result.IsOk())
+	assert.NotContains(t, result.Unwrap().Packages["app"].Links, ".exrc")
+}
@@ -0,0 +1,154 @@
+package dot
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jamesainslie/dot/internal/manifest"
+)
+
+// sensitivePaths are target paths, relative to the target directory,
+// holding credentials or keys. Permission drift under them is an error
+// rather than a warning.
+var sensitivePaths = []string{
+	".ssh", ".gnupg", ".aws", ".kube", ".docker", ".netrc", ".pgpass",
+	".git-credentials", ".config/gh",
+}
+
+// permissionSeverity returns the severity of permission drift at the
+// link linkPath, relative to the target directory.
+func permissionSeverity(linkPath string) IssueSeverity {
+	rel := filepath.ToSlash(filepath.Clean(linkPath))
+	for _, sensitive := range sensitivePaths {
+		if rel == sensitive || strings.HasPrefix(rel, sensitive+"/") {
+			return SeverityError
+		}
+	}
+	return SeverityWarning
+}
+
+// checkLinkPermissions reports a package file behind a managed link that
+// others can change, that its owner cannot read, or that belongs to
+// another user than the target directory. Behind a link to a directory,
+// every entry of the directory is checked.
+func (s *DoctorService) checkLinkPermissions(ctx context.Context, pkgName, linkPath string, issues *[]Issue) {
+	fullPath := filepath.Join(s.targetDir, linkPath)
+	if isLink, err := s.fs.IsSymlink(ctx, fullPath); err != nil || !isLink {
+		return
+	}
+	target, err := s.fs.ReadLink(ctx, fullPath)
+	if err != nil {
+		return
+	}
+	source := filepath.Clean(absLinkTarget(fullPath, target))
+	if s.packageDir == "" || !isWithin(source, s.packageDir) {
+		// Rendered templates are checked against their template
+		return
+	}
+
+	targetUID, hasOwner := uint32(0), false
+	if info, err := s.fs.Stat(ctx, s.targetDir); err == nil {
+		targetUID, hasOwner = fileOwnerUID(info)
+	}
+	check := func(path string, info FileInfo) {
+		issue := Issue{
+			Severity: permissionSeverity(linkPath),
+			Type:     IssuePermissionDrift,
+			Package:  pkgName,
+			Path:     linkPath,
+		}
+		if rel, err := filepath.Rel(source, path); err == nil && rel != "." {
+			issue.Path = filepath.Join(linkPath, rel)
+		}
+
+		mode := info.Mode().Perm()
+		readable, grant := os.FileMode(0400), "u+r"
+		if info.IsDir() {
+			readable, grant = 0500, "u+rx"
+		}
+		if mode&0002 != 0 {
+			issue.Message = fmt.Sprintf("Package file is world-writable (mode %04o): %s", mode, path)
+			issue.Suggestion = "Run 'chmod o-w " + path + "'"
+			*issues = append(*issues, issue)
+		}
+		if mode&readable != readable {
+			issue.Message = fmt.Sprintf("Package file is not readable by its owner (mode %04o): %s", mode, path)
+			issue.Suggestion = "Run 'chmod " + grant + " " + path + "'"
+			*issues = append(*issues, issue)
+		}
+
+		// Files of root can only be changed by root, who can change anything
+		if uid, ok := fileOwnerUID(info); ok && hasOwner && uid != 0 && uid != targetUID {
+			issue.Message = fmt.Sprintf("Package file is owned by uid %d, not by the owner of the target directory (uid %d): %s",
+				uid, targetUID, path)
+			issue.Suggestion = fmt.Sprintf("Run 'chown %d %s' unless another user is meant to control it", targetUID, path)
+			*issues = append(*issues, issue)
+		}
+	}
+	s.walkSource(ctx, source, check)
+}
+
+// walkSource calls fn for the package file at path and, if it is a
+// directory, for everything below it. Entries that cannot be read are
+// skipped; checkLink reports an inaccessible link source.
+func (s *DoctorService) walkSource(ctx context.Context, path string, fn func(path string, info FileInfo)) {
+	info, err := s.fs.Stat(ctx, path)
+	if err != nil {
+		return
+	}
+	fn(path, info)
+	if !info.IsDir() {
+		return
+	}
+	entries, err := s.fs.ReadDir(ctx, path)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		s.walkSource(ctx, filepath.Join(path, entry.Name()), fn)
+	}
+}
+
+// checkRenderPermissions reports rendered template output, a copy of its
+// template, whose mode drifted from the template: output others may read
+// or change when the template does not allow it, or output that lost the
+// execute permission of its template.
+func (s *DoctorService) checkRenderPermissions(ctx context.Context, pkgName string, render manifest.RenderInfo, issues *[]Issue) {
+	templateInfo, err := s.fs.Stat(ctx, render.Template)
+	if err != nil {
+		return
+	}
+	renderedInfo, err := s.fs.Stat(ctx, render.Rendered)
+	if err != nil {
+		return
+	}
+
+	template, rendered := templateInfo.Mode().Perm(), renderedInfo.Mode().Perm()
+	extra := rendered &^ template
+	lostExec := template & 0111 &^ rendered
+	if extra == 0 && lostExec == 0 {
+		return
+	}
+
+	// Keep what both allow, then grant execute where reading is allowed
+	want := rendered & template
+	want |= lostExec & (want>>2 | 0100)
+
+	message := fmt.Sprintf("Rendered output (mode %04o) grants permissions its template (mode %04o) does not: %s",
+		rendered, template, render.Rendered)
+	if extra == 0 {
+		message = fmt.Sprintf("Rendered output (mode %04o) is not executable like its template (mode %04o): %s",
+			rendered, template, render.Rendered)
+	}
+	*issues = append(*issues, Issue{
+		Severity:   permissionSeverity(render.Link),
+		Type:       IssuePermissionDrift,
+		Package:    pkgName,
+		Path:       render.Link,
+		Message:    message,
+		Suggestion: fmt.Sprintf("Run 'chmod %04o %s'", want, render.Rendered),
+	})
+}
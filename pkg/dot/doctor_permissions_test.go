@@ -0,0 +1,103 @@
+package dot_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// permissionIssues returns the permission drift issues of a doctor run
+// checking permissions.
+func permissionIssues(t *testing.T, client *dot.Client) []dot.Issue {
+	t.Helper()
+	report, err := client.DoctorWithScan(context.Background(), dot.ScanConfig{Mode: dot.ScanOff, CheckPermissions: true})
+	require.NoError(t, err)
+	var issues []dot.Issue
+	for _, issue := range report.Issues {
+		if issue.Type == dot.IssuePermissionDrift {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+func TestClient_Doctor_PermissionDrift(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/dot-config", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/dot-ssh", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/dot-config/app.toml", []byte("app"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/dot-config/tool.toml", []byte("tool"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/dot-ssh/config", []byte("Host *"), 0600))
+
+	client, err := dot.NewClient(dot.Config{
+		PackageDir:         "/test/packages",
+		TargetDir:          "/test/target",
+		PackageNameMapping: true,
+		FS:                 fs,
+		Logger:             adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+	require.NoError(t, client.Manage(ctx, "dot-config", "dot-ssh"))
+	assert.Empty(t, permissionIssues(t, client))
+
+	require.NoError(t, fs.Chmod(ctx, "/test/packages/dot-config/app.toml", 0666))
+	require.NoError(t, fs.Chmod(ctx, "/test/packages/dot-config/tool.toml", 0044))
+	require.NoError(t, fs.Chmod(ctx, "/test/packages/dot-ssh/config", 0602))
+
+	issues := permissionIssues(t, client)
+	require.Len(t, issues, 3)
+	byPath := make(map[string]dot.Issue, len(issues))
+	for _, issue := range issues {
+		byPath[issue.Path] = issue
+	}
+
+	assert.Equal(t, dot.SeverityWarning, byPath[".config/app.toml"].Severity)
+	assert.Contains(t, byPath[".config/app.toml"].Message, "world-writable (mode 0666)")
+	assert.Equal(t, "Run 'chmod o-w /test/packages/dot-config/app.toml'", byPath[".config/app.toml"].Suggestion)
+
+	assert.Contains(t, byPath[".config/tool.toml"].Message, "not readable by its owner")
+
+	assert.Equal(t, dot.SeverityError, byPath[".ssh/config"].Severity, "credentials are sensitive")
+	assert.Equal(t, "dot-ssh", byPath[".ssh/config"].Package)
+
+	// Without the option only links are checked
+	report, err := client.DoctorWithScan(ctx, dot.ScanConfig{Mode: dot.ScanOff})
+	require.NoError(t, err)
+	assert.Equal(t, dot.HealthOK, report.OverallHealth)
+}
+
+func TestClient_Doctor_RenderPermissionDrift(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/bin", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/bin/dot-hello.tmpl", []byte("#!/bin/sh\n"), 0700))
+
+	client, err := dot.NewClient(dot.Config{
+		PackageDir:       "/test/packages",
+		TargetDir:        "/test/target",
+		TemplateCacheDir: "/test/cache",
+		FS:               fs,
+		Logger:           adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+	require.NoError(t, client.Manage(ctx, "bin"))
+
+	issues := permissionIssues(t, client)
+	require.Len(t, issues, 1)
+	assert.Equal(t, ".hello", issues[0].Path)
+	assert.Contains(t, issues[0].Message, "grants permissions its template (mode 0700) does not")
+	assert.Equal(t, "Run 'chmod 0700 /test/cache/bin/dot-hello'", issues[0].Suggestion)
+
+	require.NoError(t, fs.Chmod(ctx, "/test/cache/bin/dot-hello", 0700))
+	assert.Empty(t, permissionIssues(t, client))
+}
@@ -2,20 +2,40 @@ package dot
 
 import (
 	"context"
+	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/jamesainslie/dot/internal/executor"
 	"github.com/jamesainslie/dot/internal/manifest"
+	"github.com/jamesainslie/dot/internal/templating"
 )
 
 // DoctorService handles health check and diagnostic operations.
 type DoctorService struct {
 	fs          FS
 	logger      Logger
+	executor    *executor.Executor
 	manifestSvc *ManifestService
+	manageSvc   *ManageService
+	renderer    *templating.Renderer
+	packageDir  string
 	targetDir   string
+	backupDir   string
+	concurrency int
+	dryRun      bool
+
+	// events receives the progress of link checks.
+	events *EventBus
+
+	// metrics records the standard metrics of the repairs executed.
+	metrics *commandMetrics
 }
 
 // scanResult holds the results from scanning a single directory.
@@ -28,14 +48,28 @@ type scanResult struct {
 func newDoctorService(
 	fs FS,
 	logger Logger,
+	exec *executor.Executor,
 	manifestSvc *ManifestService,
+	manageSvc *ManageService,
+	renderer *templating.Renderer,
+	packageDir string,
 	targetDir string,
+	backupDir string,
+	concurrency int,
+	dryRun bool,
 ) *DoctorService {
 	return &DoctorService{
 		fs:          fs,
 		logger:      logger,
+		executor:    exec,
 		manifestSvc: manifestSvc,
+		manageSvc:   manageSvc,
+		renderer:    renderer,
+		packageDir:  packageDir,
 		targetDir:   targetDir,
+		backupDir:   backupDir,
+		concurrency: concurrency,
+		dryRun:      dryRun,
 	}
 }
 
@@ -46,6 +80,17 @@ func (s *DoctorService) Doctor(ctx context.Context) (DiagnosticReport, error) {
 
 // DoctorWithScan performs health checks with explicit scan configuration.
 func (s *DoctorService) DoctorWithScan(ctx context.Context, scanCfg ScanConfig) (DiagnosticReport, error) {
+	start := time.Now()
+	if onIssue := scanCfg.OnIssue; onIssue != nil {
+		// Link checks and orphan scans find issues concurrently
+		var mu sync.Mutex
+		scanCfg.OnIssue = func(issue Issue) {
+			mu.Lock()
+			defer mu.Unlock()
+			onIssue(issue)
+		}
+	}
+
 	targetPath, err := s.getTargetPath()
 	if err != nil {
 		return DiagnosticReport{}, err
@@ -55,24 +100,62 @@ func (s *DoctorService) DoctorWithScan(ctx context.Context, scanCfg ScanConfig)
 	if err != nil {
 		return DiagnosticReport{}, err
 	}
+	scanCfg.emit(issues...)
 	// If manifest doesn't exist, return early with info issue
 	if m == nil {
+		stats.ScanDuration = time.Since(start)
 		return DiagnosticReport{
+			SchemaVersion: DiagnosticSchemaVersion,
 			OverallHealth: HealthOK,
 			Issues:        issues,
 			Statistics:    stats,
 		}, nil
 	}
 
-	s.checkManagedPackages(ctx, m, &issues, &stats)
+	checked := m
+	if len(scanCfg.Packages) > 0 {
+		checked, err = onlyPackages(m, scanCfg.Packages)
+		if err != nil {
+			return DiagnosticReport{}, err
+		}
+		if len(scanCfg.ScopeToDirs) == 0 {
+			scanCfg.ScopeToDirs = s.packageLinkDirs(checked, scanCfg.Mode)
+			if len(scanCfg.ScopeToDirs) == 0 {
+				// Packages without links leave nothing to scan
+				scanCfg.Mode = ScanOff
+			}
+		}
+	}
 
+	if !s.fs.Exists(ctx, s.packageDir) {
+		issues = append(issues, Issue{
+			Severity:   SeverityInfo,
+			Type:       IssueSourceUnavailable,
+			Path:       s.packageDir,
+			Message:    "Package directory not found; link sources are unknown and were checked from the manifest only",
+			Suggestion: "No action needed when auditing a target without its packages; otherwise check the package directory setting",
+		})
+		scanCfg.emit(issues[len(issues)-1])
+	}
+
+	if err := s.checkManagedPackages(ctx, checked, scanCfg, &issues, &stats); err != nil {
+		return DiagnosticReport{}, err
+	}
+
+	// Orphans are judged against every managed link, in or out of scope
 	if scanCfg.Mode != ScanOff {
 		s.performOrphanScan(ctx, m, scanCfg, &issues, &stats)
+		if err := ctx.Err(); err != nil {
+			return DiagnosticReport{}, fmt.Errorf("orphan scan stopped: %w", err)
+		}
 	}
 
-	health := s.determineOverallHealth(issues)
+	health := s.determineOverallHealth(issues, stats, scanCfg.Thresholds)
+	s.determinePackageHealth(issues, &stats, scanCfg.Thresholds)
+	stats.ScanDuration = time.Since(start)
 
 	return DiagnosticReport{
+		SchemaVersion: DiagnosticSchemaVersion,
 		OverallHealth: health,
 		Issues:        issues,
 		Statistics:    stats,
@@ -112,34 +195,217 @@ func (s *DoctorService) loadManifestOrCreateDefault(ctx context.Context, targetP
 	return &m, issues, stats, nil
 }
 
+// doctorProgressSteps is how many progress events checking the links of
+// a manifest publishes at most, so large manifests do not flood
+// subscribers.
+const doctorProgressSteps = 100
+
+// linkCheck is one manifest entry to validate and the findings for it.
+type linkCheck struct {
+	pkgName string
+	link    string
+	render  *manifest.RenderInfo
+	issues  []Issue
+	stats   DiagnosticStats
+}
+
 // checkManagedPackages validates all packages in the manifest.
-func (s *DoctorService) checkManagedPackages(ctx context.Context, m *manifest.Manifest, issues *[]Issue, stats *DiagnosticStats) {
-	for pkgName, pkgInfo := range m.Packages {
+// Links are checked by a bounded pool of workers, since each check costs
+// several filesystem calls that are slow on network home directories.
+// Findings are aggregated in package and link order, so the report does
+// not depend on scheduling.
+//
+// Progress is published as doctor_links_checked events, at most
+// doctorProgressSteps times per run, and the issues of each link are
+// passed to scanCfg.OnIssue as its check completes. Cancelling ctx stops
+// the checks and returns its error, with no findings added.
+func (s *DoctorService) checkManagedPackages(ctx context.Context, m *manifest.Manifest, scanCfg ScanConfig, issues *[]Issue, stats *DiagnosticStats) error {
+	var checks []linkCheck
+	stats.Packages = make(map[string]PackageStats, len(m.Packages))
+	for _, pkgName := range slices.Sorted(maps.Keys(m.Packages)) {
+		pkgInfo := m.Packages[pkgName]
 		stats.ManagedLinks += pkgInfo.LinkCount
+		stats.Packages[pkgName] = PackageStats{ManagedLinks: pkgInfo.LinkCount}
 		for _, linkPath := range pkgInfo.Links {
-			stats.TotalLinks++
-			s.checkLink(ctx, pkgName, linkPath, issues, stats)
+			checks = append(checks, linkCheck{pkgName: pkgName, link: linkPath})
+		}
+		for i := range pkgInfo.Templates {
+			checks = append(checks, linkCheck{pkgName: pkgName, render: &pkgInfo.Templates[i]})
 		}
 	}
+
+	var mu sync.Mutex
+	done := 0
+	step := max(1, (len(checks)+doctorProgressSteps-1)/doctorProgressSteps)
+	finished := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		done++
+		if done%step == 0 || done == len(checks) {
+			s.events.Publish(ctx, Event{
+				Topic:   TopicProgress,
+				Source:  "doctor",
+				Name:    "doctor_links_checked",
+				Message: fmt.Sprintf("Checked %d of %d links", done, len(checks)),
+				Fields:  map[string]any{"checked": done, "total": len(checks)},
+			})
+		}
+	}
+
+	run := func(c *linkCheck) {
+		if ctx.Err() != nil {
+			return
+		}
+		defer finished()
+		if c.render != nil {
+			s.checkRender(ctx, c.pkgName, *c.render, &c.issues)
+			if scanCfg.CheckPermissions {
+				s.checkRenderPermissions(ctx, c.pkgName, *c.render, &c.issues)
+			}
+		} else {
+			c.stats.TotalLinks++
+			s.checkLink(ctx, c.pkgName, c.link, &c.issues, &c.stats)
+			if scanCfg.CheckPermissions {
+				s.checkLinkPermissions(ctx, c.pkgName, c.link, &c.issues)
+			}
+		}
+		scanCfg.emit(c.issues...)
+	}
+
+	workers := s.concurrency
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(checks) {
+		workers = len(checks)
+	}
+	if workers <= 1 {
+		for i := range checks {
+			run(&checks[i])
+		}
+	} else {
+		next := make(chan int)
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range next {
+					run(&checks[i])
+				}
+			}()
+		}
+		for i := range checks {
+			next <- i
+		}
+		close(next)
+		wg.Wait()
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("link checks stopped after %d of %d: %w", done, len(checks), err)
+	}
+
+	for _, c := range checks {
+		*issues = append(*issues, c.issues...)
+		stats.TotalLinks += c.stats.TotalLinks
+		stats.BrokenLinks += c.stats.BrokenLinks
+		stats.UnknownLinks += c.stats.UnknownLinks
+
+		pkgStats := stats.Packages[c.pkgName]
+		pkgStats.Links += c.stats.TotalLinks
+		pkgStats.BrokenLinks += c.stats.BrokenLinks
+		pkgStats.UnknownLinks += c.stats.UnknownLinks
+		stats.Packages[c.pkgName] = pkgStats
+	}
+	return nil
+}
+
+// checkRender reports rendered template output that no longer matches
+// what its template would produce today.
+func (s *DoctorService) checkRender(ctx context.Context, pkgName string, render manifest.RenderInfo, issues *[]Issue) {
+	if s.renderer == nil || !s.fs.Exists(ctx, render.Rendered) {
+		// Missing output surfaces as a broken link via checkLink
+		return
+	}
+	if s.sourceUnavailable(ctx, render.Template) {
+		return
+	}
+
+	stale, err := s.renderer.IsStale(ctx, render.Template, render.Rendered)
+	if err != nil {
+		*issues = append(*issues, Issue{
+			Severity:   SeverityWarning,
+			Type:       IssueStaleRender,
+			Package:    pkgName,
+			Path:       render.Link,
+			Message:    "Cannot render template: " + err.Error(),
+			Suggestion: "Fix the template then run 'dot remanage " + pkgName + "'",
+		})
+		return
+	}
+
+	if stale {
+		*issues = append(*issues, Issue{
+			Severity:   SeverityWarning,
+			Type:       IssueStaleRender,
+			Package:    pkgName,
+			Path:       render.Link,
+			Message:    "Rendered output is stale: " + render.Template,
+			Suggestion: "Run 'dot remanage " + pkgName + "' to re-render",
+		})
+	}
 }
 
-// determineOverallHealth computes health status from issues.
-func (s *DoctorService) determineOverallHealth(issues []Issue) HealthStatus {
+// determineOverallHealth computes health status from issues, ignoring
+// orphaned links and downgrading broken links while their counts are
+// within thresholds.
+func (s *DoctorService) determineOverallHealth(issues []Issue, stats DiagnosticStats, thresholds HealthThresholds) HealthStatus {
 	health := HealthOK
 	for _, issue := range issues {
-		if issue.Severity == SeverityError {
+		severity := issue.Severity
+		switch {
+		case issue.Type == IssueOrphanedLink && stats.OrphanedLinks <= thresholds.OrphanedLinks:
+			continue
+		case issue.Type == IssueBrokenLink && severity == SeverityError && stats.BrokenLinks <= thresholds.BrokenLinks:
+			severity = SeverityWarning
+		}
+		if severity == SeverityError {
 			return HealthErrors
 		}
-		if issue.Severity == SeverityWarning && health == HealthOK {
+		if severity == SeverityWarning && health == HealthOK {
 			health = HealthWarnings
 		}
 	}
 	return health
 }
 
-// checkLink validates a single link from the manifest.
+// determinePackageHealth counts the issues of each package in stats and
+// computes its health from them, with the thresholds applied to the counts
+// of the whole report, as for the overall health.
+func (s *DoctorService) determinePackageHealth(issues []Issue, stats *DiagnosticStats, thresholds HealthThresholds) {
+	byPackage := make(map[string][]Issue)
+	for _, issue := range issues {
+		if issue.Package != "" {
+			byPackage[issue.Package] = append(byPackage[issue.Package], issue)
+		}
+	}
+	for name, pkg := range stats.Packages {
+		pkg.Issues = len(byPackage[name])
+		pkg.Health = s.determineOverallHealth(byPackage[name], *stats, thresholds)
+		stats.Packages[name] = pkg
+	}
+}
+
+// checkLink validates a single link from the manifest. A link into an
+// unavailable package directory is counted as unknown rather than broken.
 func (s *DoctorService) checkLink(ctx context.Context, pkgName string, linkPath string, issues *[]Issue, stats *DiagnosticStats) {
 	fullPath := filepath.Join(s.targetDir, linkPath)
+	if isLink, err := s.fs.IsSymlink(ctx, fullPath); err == nil && isLink {
+		if target, err := s.fs.ReadLink(ctx, fullPath); err == nil && s.sourceUnavailable(ctx, absLinkTarget(fullPath, target)) {
+			stats.UnknownLinks++
+			return
+		}
+	}
 	_, err := s.fs.Stat(ctx, fullPath)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -147,6 +413,7 @@ func (s *DoctorService) checkLink(ctx context.Context, pkgName string, linkPath
 			*issues = append(*issues, Issue{
 				Severity:   SeverityError,
 				Type:       IssueBrokenLink,
+				Package:    pkgName,
 				Path:       linkPath,
 				Message:    "Link does not exist",
 				Suggestion: "Run 'dot remanage " + pkgName + "' to restore link",
@@ -155,6 +422,7 @@ func (s *DoctorService) checkLink(ctx context.Context, pkgName string, linkPath
 			*issues = append(*issues, Issue{
 				Severity:   SeverityError,
 				Type:       IssuePermission,
+				Package:    pkgName,
 				Path:       linkPath,
 				Message:    "Cannot access link: " + err.Error(),
 				Suggestion: "Check filesystem permissions",
@@ -168,6 +436,7 @@ func (s *DoctorService) checkLink(ctx context.Context, pkgName string, linkPath
 		*issues = append(*issues, Issue{
 			Severity:   SeverityError,
 			Type:       IssuePermission,
+			Package:    pkgName,
 			Path:       linkPath,
 			Message:    "Cannot check if path is symlink: " + err.Error(),
 			Suggestion: "Check filesystem permissions",
@@ -178,6 +447,7 @@ func (s *DoctorService) checkLink(ctx context.Context, pkgName string, linkPath
 		*issues = append(*issues, Issue{
 			Severity:   SeverityError,
 			Type:       IssueWrongTarget,
+			Package:    pkgName,
 			Path:       linkPath,
 			Message:    "Expected symlink but found regular file",
 			Suggestion: "Run 'dot unmanage " + pkgName + "' then 'dot manage " + pkgName + "'",
@@ -190,6 +460,7 @@ func (s *DoctorService) checkLink(ctx context.Context, pkgName string, linkPath
 		*issues = append(*issues, Issue{
 			Severity:   SeverityError,
 			Type:       IssuePermission,
+			Package:    pkgName,
 			Path:       linkPath,
 			Message:    "Cannot read link target: " + err.Error(),
 			Suggestion: "Check filesystem permissions",
@@ -204,6 +475,10 @@ func (s *DoctorService) checkLink(ctx context.Context, pkgName string, linkPath
 		absTarget = filepath.Join(filepath.Dir(fullPath), target)
 	}
 
+	if owner := s.packageOf(filepath.Clean(absTarget)); owner != "" && owner != pkgName {
+		*issues = append(*issues, s.wrongPackageIssue(ctx, pkgName, owner, linkPath, target))
+	}
+
 	_, err = s.fs.Stat(ctx, absTarget)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -211,6 +486,7 @@ func (s *DoctorService) checkLink(ctx context.Context, pkgName string, linkPath
 			*issues = append(*issues, Issue{
 				Severity:   SeverityError,
 				Type:       IssueBrokenLink,
+				Package:    pkgName,
 				Path:       linkPath,
 				Message:    "Link target does not exist: " + target,
 				Suggestion: "Run 'dot remanage " + pkgName + "' to fix broken link",
@@ -221,6 +497,7 @@ func (s *DoctorService) checkLink(ctx context.Context, pkgName string, linkPath
 			*issues = append(*issues, Issue{
 				Severity:   SeverityError,
 				Type:       IssuePermission,
+				Package:    pkgName,
 				Path:       linkPath,
 				Message:    "Cannot access link target: " + err.Error(),
 				Suggestion: "Check file permissions for target path or run with appropriate permissions",
@@ -241,6 +518,8 @@ func (s *DoctorService) performOrphanScan(
 	scanDirs := s.determineScanDirectories(m, scanCfg)
 	rootDirs := s.normalizeAndDeduplicateDirs(scanDirs, scanCfg.Mode)
 	linkSet := buildManagedLinkSet(m)
+	budget := newScanBudget(scanCfg)
+	defer s.reportTruncatedScan(budget, scanCfg, issues, stats)
 
 	// Determine worker count
 	workers := scanCfg.MaxWorkers
@@ -251,10 +530,10 @@ func (s *DoctorService) performOrphanScan(
 	// If only 1 worker or 1 directory, use sequential scan (no overhead)
 	if workers == 1 || len(rootDirs) == 1 {
 		for _, dir := range rootDirs {
-			if s.shouldStopScan(scanCfg, issues) {
+			if s.shouldStopScan(scanCfg, issues) || budget.exhausted() {
 				break
 			}
-			s.scanDirectory(ctx, dir, m, linkSet, scanCfg, issues, stats)
+			s.scanDirectory(ctx, dir, m, linkSet, scanCfg, budget, issues, stats)
 		}
 		return
 	}
@@ -271,7 +550,7 @@ func (s *DoctorService) performOrphanScan(
 	// Start workers
 	for i := 0; i < workers; i++ {
 		wg.Add(1)
-		go s.scanWorker(workerCtx, &wg, dirChan, resultChan, m, linkSet, scanCfg)
+		go s.scanWorker(workerCtx, &wg, dirChan, resultChan, m, linkSet, scanCfg, budget)
 	}
 
 	// Feed directories to workers with cancellation support
@@ -310,16 +589,19 @@ func (s *DoctorService) scanWorker(
 	m *manifest.Manifest,
 	linkSet map[string]bool,
 	scanCfg ScanConfig,
+	budget *scanBudget,
 ) {
 	defer wg.Done()
+	// Issues are passed on as results are collected, within MaxIssues
+	scanCfg.OnIssue = nil
 	for dir := range dirChan {
-		if ctx.Err() != nil {
+		if ctx.Err() != nil || budget.exhausted() {
 			return
 		}
 
 		localIssues := []Issue{}
 		localStats := DiagnosticStats{}
-		s.scanDirectory(ctx, dir, m, linkSet, scanCfg, &localIssues, &localStats)
+		s.scanDirectory(ctx, dir, m, linkSet, scanCfg, budget, &localIssues, &localStats)
 
 		// Only send result if context not cancelled
 		select {
@@ -342,6 +624,9 @@ func (s *DoctorService) collectScanResult(
 	cancelWorkers context.CancelFunc,
 	resultChan chan scanResult,
 ) bool {
+	collected := len(*issues)
+	defer func() { scanCfg.emit((*issues)[collected:]...) }()
+
 	// Respect remaining budget before appending
 	if scanCfg.MaxIssues > 0 {
 		remaining := scanCfg.MaxIssues - len(*issues)
@@ -366,10 +651,35 @@ func (s *DoctorService) collectScanResult(
 	stats.TotalLinks += result.stats.TotalLinks
 	stats.BrokenLinks += result.stats.BrokenLinks
 	stats.OrphanedLinks += result.stats.OrphanedLinks
+	stats.ForeignLinks += result.stats.ForeignLinks
 	stats.ManagedLinks += result.stats.ManagedLinks
+	stats.FilesScanned += result.stats.FilesScanned
 	return false
 }
 
+// reportTruncatedScan adds an IssueScanTruncated issue when the scan
+// stopped at its budget.
+func (s *DoctorService) reportTruncatedScan(budget *scanBudget, scanCfg ScanConfig, issues *[]Issue, stats *DiagnosticStats) {
+	reason, dir := budget.exhaustion()
+	if reason == "" {
+		return
+	}
+	stats.ScanTruncated = true
+
+	path, err := filepath.Rel(s.targetDir, dir)
+	if err != nil {
+		path = dir
+	}
+	*issues = append(*issues, Issue{
+		Severity:   SeverityWarning,
+		Type:       IssueScanTruncated,
+		Path:       path,
+		Message:    "Orphan scan truncated after " + reason + "; links beyond it were not checked",
+		Suggestion: "Narrow the scan with --scan-mode=scoped or raise --scan-max-entries and --scan-timeout",
+	})
+	scanCfg.emit((*issues)[len(*issues)-1])
+}
+
 // shouldStopScan checks if scanning should stop early based on MaxIssues limit.
 func (s *DoctorService) shouldStopScan(scanCfg ScanConfig, issues *[]Issue) bool {
 	return scanCfg.MaxIssues > 0 && len(*issues) >= scanCfg.MaxIssues
@@ -406,10 +716,11 @@ func (s *DoctorService) scanDirectory(
 	m *manifest.Manifest,
 	linkSet map[string]bool,
 	scanCfg ScanConfig,
+	budget *scanBudget,
 	issues *[]Issue,
 	stats *DiagnosticStats,
 ) {
-	err := s.scanForOrphanedLinksWithLimits(ctx, dir, m, linkSet, scanCfg, issues, stats)
+	err := s.scanForOrphanedLinksWithLimits(ctx, dir, m, linkSet, scanCfg, budget, issues, stats)
 	if err != nil {
 		// Log but continue - orphan detection is best-effort
 		s.logger.Warn(ctx, "scan_directory_failed", "dir", dir, "error", err)
@@ -423,6 +734,7 @@ func (s *DoctorService) scanForOrphanedLinksWithLimits(
 	m *manifest.Manifest,
 	linkSet map[string]bool,
 	scanCfg ScanConfig,
+	budget *scanBudget,
 	issues *[]Issue,
 	stats *DiagnosticStats,
 ) error {
@@ -439,7 +751,7 @@ func (s *DoctorService) scanForOrphanedLinksWithLimits(
 		return nil
 	}
 
-	return s.scanForOrphanedLinks(ctx, dir, m, linkSet, scanCfg, issues, stats)
+	return s.scanForOrphanedLinks(ctx, dir, m, linkSet, scanCfg, budget, issues, stats)
 }
 
 // scanForOrphanedLinks recursively scans for symlinks not in the manifest.
@@ -450,6 +762,7 @@ func (s *DoctorService) scanForOrphanedLinks(
 	m *manifest.Manifest,
 	linkSet map[string]bool,
 	scanCfg ScanConfig,
+	budget *scanBudget,
 	issues *[]Issue,
 	stats *DiagnosticStats,
 ) error {
@@ -467,7 +780,12 @@ func (s *DoctorService) scanForOrphanedLinks(
 		if s.shouldStopScan(scanCfg, issues) {
 			return nil
 		}
+		// Check the entry and time budget
+		if !budget.spend(dir) {
+			return nil
+		}
 
+		stats.FilesScanned++
 		fullPath := filepath.Join(dir, entry.Name())
 
 		// Performance optimization: check type from DirEntry (no Lstat syscall)
@@ -475,10 +793,12 @@ func (s *DoctorService) scanForOrphanedLinks(
 
 		if entryType&os.ModeSymlink != 0 {
 			// It's a symlink - check if orphaned
+			found := len(*issues)
 			s.checkForOrphanedLink(ctx, fullPath, linkSet, issues, stats)
+			scanCfg.emit((*issues)[found:]...)
 		} else if entry.IsDir() {
 			// It's a directory - recurse
-			s.scanDirectoryRecursive(ctx, fullPath, m, linkSet, scanCfg, issues, stats)
+			s.scanDirectoryRecursive(ctx, fullPath, m, linkSet, scanCfg, budget, issues, stats)
 		}
 		// Regular files are ignored (no need to check)
 	}
@@ -497,10 +817,11 @@ func (s *DoctorService) scanDirectoryRecursive(
 	m *manifest.Manifest,
 	linkSet map[string]bool,
 	scanCfg ScanConfig,
+	budget *scanBudget,
 	issues *[]Issue,
 	stats *DiagnosticStats,
 ) {
-	err := s.scanForOrphanedLinksWithLimits(ctx, dir, m, linkSet, scanCfg, issues, stats)
+	err := s.scanForOrphanedLinksWithLimits(ctx, dir, m, linkSet, scanCfg, budget, issues, stats)
 	if err != nil {
 		// Continue on error - best effort scanning
 		s.logger.Warn(ctx, "recursive_scan_failed", "dir", dir, "error", err)
@@ -508,6 +829,8 @@ func (s *DoctorService) scanDirectoryRecursive(
 }
 
 // checkForOrphanedLink checks if symlink is orphaned (not in manifest) and validates target.
+// Unmanaged links pointing outside the package directory are reported as
+// foreign links rather than orphans, since dot never created them.
 // Note: This function assumes fullPath is already confirmed to be a symlink by the caller.
 func (s *DoctorService) checkForOrphanedLink(
 	ctx context.Context,
@@ -523,42 +846,15 @@ func (s *DoctorService) checkForOrphanedLink(
 
 	normalizedRel := filepath.ToSlash(relPath)
 	normalizedFull := filepath.ToSlash(fullPath)
-	managed := linkSet[normalizedRel] || linkSet[normalizedFull]
+	if linkSet[normalizedRel] || linkSet[normalizedFull] {
+		return
+	}
+	stats.TotalLinks++
 
-	if !managed {
-		stats.TotalLinks++
+	target, err := s.fs.ReadLink(ctx, fullPath)
+	if err != nil {
+		// The destination is unknown, so it may be a link dot lost track of
 		stats.OrphanedLinks++
-
-		// Check if the orphaned symlink's target exists
-		target, err := s.fs.ReadLink(ctx, fullPath)
-		if err == nil {
-			// Resolve target to absolute path
-			var absTarget string
-			if filepath.IsAbs(target) {
-				absTarget = target
-			} else {
-				absTarget = filepath.Join(filepath.Dir(fullPath), target)
-			}
-
-			// Check if target exists
-			_, err = s.fs.Stat(ctx, absTarget)
-			if err != nil {
-				if os.IsNotExist(err) {
-					// Orphaned and broken
-					stats.BrokenLinks++
-					*issues = append(*issues, Issue{
-						Severity:   SeverityError,
-						Type:       IssueBrokenLink,
-						Path:       relPath,
-						Message:    "Unmanaged symlink with broken target: " + target,
-						Suggestion: "Remove manually or fix target, then use 'dot adopt' to manage",
-					})
-					return
-				}
-			}
-		}
-
-		// Orphaned but target exists (or couldn't check)
 		*issues = append(*issues, Issue{
 			Severity:   SeverityWarning,
 			Type:       IssueOrphanedLink,
@@ -566,7 +862,181 @@ func (s *DoctorService) checkForOrphanedLink(
 			Message:    "Symlink not managed by dot",
 			Suggestion: "Remove manually or use 'dot adopt' to bring under management",
 		})
+		return
 	}
+
+	dest := absLinkTarget(fullPath, target)
+	pkg := s.packageOf(dest)
+	_, statErr := s.fs.Stat(ctx, dest)
+	broken := statErr != nil && os.IsNotExist(statErr) && !s.sourceUnavailable(ctx, dest)
+
+	if pkg == "" {
+		s.reportForeignLink(relPath, target, dest, broken, issues, stats)
+		return
+	}
+
+	stats.OrphanedLinks++
+	if broken {
+		stats.BrokenLinks++
+		*issues = append(*issues, Issue{
+			Severity:   SeverityError,
+			Type:       IssueBrokenLink,
+			Path:       relPath,
+			Message:    "Unmanaged symlink with broken target: " + target,
+			Suggestion: "Remove manually or fix target, then use 'dot adopt' to manage",
+		})
+		return
+	}
+	*issues = append(*issues, Issue{
+		Severity:   SeverityWarning,
+		Type:       IssueOrphanedLink,
+		Path:       relPath,
+		Package:    pkg,
+		Message:    "Symlink into package " + pkg + " not managed by dot",
+		Suggestion: "Run 'dot doctor --adopt-orphans' to add it to the manifest",
+	})
+}
+
+// reportForeignLink reports an unmanaged link at relPath whose target dest
+// is outside the package directory. Such links belong to other tools, so
+// they are informational, and only a warning when broken.
+func (s *DoctorService) reportForeignLink(relPath, target, dest string, broken bool, issues *[]Issue, stats *DiagnosticStats) {
+	stats.ForeignLinks++
+
+	owner := "another tool"
+	if name := foreignLinkOwner(dest); name != "" {
+		owner = name
+	}
+	issue := Issue{
+		Severity:   SeverityInfo,
+		Type:       IssueForeignLink,
+		Path:       relPath,
+		Message:    "Symlink outside the package directory, likely managed by " + owner + ": " + target,
+		Suggestion: "No action needed; dot does not touch links it did not create",
+	}
+	if broken {
+		issue.Severity = SeverityWarning
+		issue.Message = "Symlink outside the package directory with broken target, likely managed by " + owner + ": " + target
+		issue.Suggestion = "Fix or remove it with the tool that created it"
+	}
+	*issues = append(*issues, issue)
+}
+
+// foreignLinkOwners maps path components found in link targets to the
+// tools known to create such links.
+var foreignLinkOwners = []struct {
+	components []string
+	owner      string
+}{
+	{[]string{"nix", "store"}, "nix"},
+	{[]string{".nix-profile"}, "nix"},
+	{[]string{"Cellar"}, "homebrew"},
+	{[]string{"homebrew"}, "homebrew"},
+	{[]string{".linuxbrew"}, "homebrew"},
+	{[]string{".asdf"}, "asdf"},
+	{[]string{"mise", "installs"}, "mise"},
+	{[]string{".sdkman"}, "sdkman"},
+	{[]string{".nvm"}, "nvm"},
+	{[]string{"flatpak", "exports"}, "flatpak"},
+}
+
+// foreignLinkOwner returns the tool that likely created a link to path,
+// or "" if it is not recognized.
+func foreignLinkOwner(path string) string {
+	parts := splitPath(filepath.Clean(path))
+	for _, known := range foreignLinkOwners {
+		for i := 0; i+len(known.components) <= len(parts); i++ {
+			if slices.Equal(parts[i:i+len(known.components)], known.components) {
+				return known.owner
+			}
+		}
+	}
+	return ""
+}
+
+// wrongPackageIssue reports a link of pkgName pointing into the package
+// owner. The fix depends on whether pkgName still exists: if it does its
+// links are recreated, and if it was renamed to owner the links move to
+// owner in the manifest by managing it again.
+func (s *DoctorService) wrongPackageIssue(ctx context.Context, pkgName, owner, linkPath, target string) Issue {
+	suggestion := "Run 'dot remanage " + pkgName + "' to link it into its own package"
+	if !s.fs.Exists(ctx, filepath.Join(s.packageDir, pkgName)) {
+		suggestion = "Run 'dot unmanage " + pkgName + "' then 'dot manage " + owner + "' to record it under " + owner
+	}
+	return Issue{
+		Severity:   SeverityWarning,
+		Type:       IssueWrongPackage,
+		Package:    pkgName,
+		Path:       linkPath,
+		Message:    "Link points into package " + owner + ": " + target,
+		Suggestion: suggestion,
+	}
+}
+
+// sourceUnavailable reports whether path is inside the package directory
+// while that directory does not exist, so whether path exists is unknown.
+func (s *DoctorService) sourceUnavailable(ctx context.Context, path string) bool {
+	return s.packageDir != "" && isWithin(filepath.Clean(path), s.packageDir) && !s.fs.Exists(ctx, s.packageDir)
+}
+
+// packageOf returns the package containing path, or "" if path is not
+// inside the package directory.
+func (s *DoctorService) packageOf(path string) string {
+	return packageOfPath(s.packageDir, path)
+}
+
+// packageOfPath returns the package of packageDir containing path, or ""
+// if path is not inside packageDir.
+func packageOfPath(packageDir, path string) string {
+	if packageDir == "" {
+		return ""
+	}
+	rel, err := filepath.Rel(packageDir, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return ""
+	}
+	return strings.SplitN(filepath.ToSlash(rel), "/", 2)[0]
+}
+
+// absLinkTarget resolves the target of the link at linkPath.
+func absLinkTarget(linkPath, target string) string {
+	if filepath.IsAbs(target) {
+		return target
+	}
+	return filepath.Join(filepath.Dir(linkPath), target)
+}
+
+// onlyPackages returns a copy of m holding only the named packages.
+func onlyPackages(m *manifest.Manifest, names []string) (*manifest.Manifest, error) {
+	scoped := manifest.New()
+	for _, name := range names {
+		info, ok := m.GetPackage(name)
+		if !ok {
+			return nil, ErrPackageNotFound{Package: name}
+		}
+		scoped.AddPackage(info)
+	}
+	return &scoped, nil
+}
+
+// packageLinkDirs returns the directories directly holding the links of
+// m, in the form performOrphanScan expects for mode: relative to the
+// target directory when scoped, absolute otherwise.
+func (s *DoctorService) packageLinkDirs(m *manifest.Manifest, mode ScanMode) []string {
+	dirSet := make(map[string]bool)
+	for _, pkgInfo := range m.Packages {
+		for _, link := range pkgInfo.Links {
+			dirSet[filepath.Dir(link)] = true
+		}
+	}
+	dirs := make([]string, 0, len(dirSet))
+	for _, dir := range slices.Sorted(maps.Keys(dirSet)) {
+		if mode != ScanScoped {
+			dir = filepath.Join(s.targetDir, dir)
+		}
+		dirs = append(dirs, dir)
+	}
+	return dirs
 }
 
 // extractManagedDirectories returns unique directories containing managed links.
@@ -653,14 +1123,24 @@ func calculateDepth(path, targetDir string) int {
 	return depth
 }
 
-// shouldSkipDirectory checks if a directory should be skipped based on patterns.
+// shouldSkipDirectory checks if a directory, or the directory holding it,
+// matches one of the skip patterns.
 func shouldSkipDirectory(path string, skipPatterns []string) bool {
-	base := filepath.Base(path)
-	for _, pattern := range skipPatterns {
-		if base == pattern {
-			return true
+	return matchesSkipPattern(path, skipPatterns) || matchesSkipPattern(filepath.Dir(path), skipPatterns)
+}
+
+// matchesSkipPattern reports whether the trailing components of path match
+// one of the patterns. Each pattern component is a glob, so "OneDrive*"
+// matches "OneDrive - Contoso" and "Library/CloudStorage" matches any
+// CloudStorage directory directly inside a Library directory.
+func matchesSkipPattern(path string, patterns []string) bool {
+	parts := splitPath(filepath.Clean(path))
+	for _, pattern := range patterns {
+		patternParts := splitPath(filepath.Clean(pattern))
+		if len(patternParts) == 0 || len(patternParts) > len(parts) {
+			continue
 		}
-		if filepath.Base(filepath.Dir(path)) == pattern {
+		if matchPathParts(patternParts, parts[len(parts)-len(patternParts):]) {
 			return true
 		}
 	}
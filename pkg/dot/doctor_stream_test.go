@@ -0,0 +1,101 @@
+package dot_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// newStreamClient manages a package with a broken link and leaves
+// orphaned links in three directories.
+func newStreamClient(t *testing.T) (*dot.Client, *adapters.MemFS) {
+	t.Helper()
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/app", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/app/dot-config", []byte("cfg"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/app/dot-profile", []byte("cfg"), 0644))
+	for _, dir := range []string{"dir1", "dir2", "dir3"} {
+		require.NoError(t, fs.MkdirAll(ctx, "/test/target/"+dir, 0755))
+		for i := range 5 {
+			require.NoError(t, fs.Symlink(ctx, "/test/packages/app/gone", fmt.Sprintf("/test/target/%s/orphan%d", dir, i)))
+		}
+	}
+
+	client, err := dot.NewClient(dot.Config{
+		PackageDir: "/test/packages",
+		TargetDir:  "/test/target",
+		FS:         fs,
+		Logger:     adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+	require.NoError(t, client.Manage(ctx, "app"))
+	require.NoError(t, fs.Remove(ctx, "/test/packages/app/dot-profile"))
+	return client, fs
+}
+
+func TestClient_DoctorWithScan_OnIssue(t *testing.T) {
+	for _, workers := range []int{1, 3} {
+		t.Run(fmt.Sprintf("%d workers", workers), func(t *testing.T) {
+			client, _ := newStreamClient(t)
+
+			var streamed []dot.Issue
+			report, err := client.DoctorWithScan(context.Background(), dot.ScanConfig{
+				Mode:        dot.ScanDeep,
+				MaxDepth:    3,
+				MaxWorkers:  workers,
+				ScopeToDirs: []string{"/test/target/dir1", "/test/target/dir2", "/test/target/dir3"},
+				OnIssue:     func(issue dot.Issue) { streamed = append(streamed, issue) },
+			})
+			require.NoError(t, err)
+
+			// 15 orphans and the broken managed link, each passed once
+			require.Len(t, report.Issues, 16)
+			assert.ElementsMatch(t, report.Issues, streamed)
+		})
+	}
+}
+
+func TestClient_DoctorWithScan_OnIssueWithinMaxIssues(t *testing.T) {
+	client, _ := newStreamClient(t)
+
+	var streamed []dot.Issue
+	report, err := client.DoctorWithScan(context.Background(), dot.ScanConfig{
+		Mode:        dot.ScanDeep,
+		MaxDepth:    3,
+		MaxWorkers:  3,
+		MaxIssues:   4,
+		ScopeToDirs: []string{"/test/target/dir1", "/test/target/dir2", "/test/target/dir3"},
+		OnIssue:     func(issue dot.Issue) { streamed = append(streamed, issue) },
+	})
+	require.NoError(t, err)
+
+	// Issues dropped to respect MaxIssues are not streamed either
+	assert.ElementsMatch(t, report.Issues, streamed)
+}
+
+func TestClient_DoctorWithScan_OnIssueInterrupts(t *testing.T) {
+	client, _ := newStreamClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Stopping at the first issue found skips the rest of the run
+	count := 0
+	_, err := client.DoctorWithScan(ctx, dot.ScanConfig{
+		Mode:     dot.ScanDeep,
+		MaxDepth: 3,
+		OnIssue: func(dot.Issue) {
+			count++
+			cancel()
+		},
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, count)
+}
@@ -1,6 +1,10 @@
 package dot
 
-import "github.com/jamesainslie/dot/internal/domain"
+import (
+	"io/fs"
+
+	"github.com/jamesainslie/dot/internal/domain"
+)
 
 // Domain entity re-exports
 
@@ -15,8 +19,24 @@ const (
 	NodeFile    = domain.NodeFile
 	NodeDir     = domain.NodeDir
 	NodeSymlink = domain.NodeSymlink
+	NodeSpecial = domain.NodeSpecial
 )
 
+// SpecialFilePolicy selects how special files are handled.
+type SpecialFilePolicy = domain.SpecialFilePolicy
+
+// SpecialFilePolicy constants
+const (
+	SpecialFilesSkip  = domain.SpecialFilesSkip
+	SpecialFilesError = domain.SpecialFilesError
+)
+
+// SpecialFileKind describes the kind of special file mode stands for, or
+// returns "" for regular files, directories and symbolic links.
+func SpecialFileKind(mode fs.FileMode) string {
+	return domain.SpecialFileKind(mode)
+}
+
 // Node represents a node in a filesystem tree.
 type Node = domain.Node
 
@@ -2,6 +2,7 @@ package dot
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/jamesainslie/dot/internal/domain"
 )
@@ -20,12 +21,23 @@ type ErrConflict = domain.ErrConflict
 // ErrCyclicDependency represents a dependency cycle error.
 type ErrCyclicDependency = domain.ErrCyclicDependency
 
+// ErrInvalidPlan represents a plan that is inconsistent as a whole.
+type ErrInvalidPlan = domain.ErrInvalidPlan
+
+// ErrSpecialFile represents a socket, named pipe or device node found
+// where a regular file was expected.
+type ErrSpecialFile = domain.ErrSpecialFile
+
 // ErrFilesystemOperation represents a filesystem operation error.
 type ErrFilesystemOperation = domain.ErrFilesystemOperation
 
 // ErrPermissionDenied represents a permission denied error.
 type ErrPermissionDenied = domain.ErrPermissionDenied
 
+// ErrOutsideBoundary represents a change to the filesystem refused for
+// being outside the directories of Config.Boundary.
+type ErrOutsideBoundary = domain.ErrOutsideBoundary
+
 // ErrMultiple represents multiple aggregated errors.
 type ErrMultiple = domain.ErrMultiple
 
@@ -66,6 +78,27 @@ func (e ErrPackageDirNotEmpty) Unwrap() error {
 	return e.Cause
 }
 
+// ErrPartialClone indicates the package directory holds a clone that was
+// interrupted before its packages were installed.
+type ErrPartialClone struct {
+	Path string
+	URL  string
+
+	// Reason, if set, explains why the partial clone was kept.
+	Reason string
+}
+
+func (e ErrPartialClone) Error() string {
+	msg := fmt.Sprintf("package directory holds an interrupted clone: %s", e.Path)
+	if e.URL != "" {
+		msg += fmt.Sprintf(" (from %s)", e.URL)
+	}
+	if e.Reason != "" {
+		msg += fmt.Sprintf(": not removed because %s", e.Reason)
+	}
+	return msg
+}
+
 // ErrBootstrapNotFound indicates the bootstrap configuration file was not found.
 type ErrBootstrapNotFound struct {
 	Path string
@@ -92,6 +125,20 @@ func (e ErrInvalidBootstrap) Unwrap() error {
 	return e.Cause
 }
 
+// ErrCapabilities indicates the environment lacks capabilities the
+// configuration needs.
+type ErrCapabilities struct {
+	Failed []CapabilityCheck
+}
+
+func (e ErrCapabilities) Error() string {
+	parts := make([]string, 0, len(e.Failed))
+	for _, check := range e.Failed {
+		parts = append(parts, fmt.Sprintf("%s (%s: %s)", check.Name, check.Path, check.Message))
+	}
+	return "environment check failed: " + strings.Join(parts, ", ")
+}
+
 // ErrAuthFailed indicates authentication failure during git clone.
 type ErrAuthFailed struct {
 	Cause error
@@ -119,13 +166,131 @@ func (e ErrCloneFailed) Unwrap() error {
 	return e.Cause
 }
 
+// ErrCheckpointsDisabled indicates rollback was requested but checkpoints
+// are not persisted because Config.CheckpointDir is empty.
+type ErrCheckpointsDisabled struct{}
+
+func (e ErrCheckpointsDisabled) Error() string {
+	return "checkpoints are not persisted: no checkpoint directory is configured"
+}
+
+// ErrAuditLogDisabled indicates history was requested but operations are
+// not recorded because Config.AuditLog is empty.
+type ErrAuditLogDisabled struct{}
+
+func (e ErrAuditLogDisabled) Error() string {
+	return "operations are not recorded: no audit log is configured"
+}
+
+// ErrPlanNotFound indicates no plan recorded in the audit log matches the
+// requested ID, or, when ID is empty, that no plan is left to undo.
+type ErrPlanNotFound struct {
+	ID string
+}
+
+func (e ErrPlanNotFound) Error() string {
+	if e.ID == "" {
+		return "no plan to undo"
+	}
+	return fmt.Sprintf("plan %s not found in the audit log", e.ID)
+}
+
+// ErrPullFailed indicates pulling repository changes failed.
+type ErrPullFailed struct {
+	Path  string
+	Cause error
+}
+
+func (e ErrPullFailed) Error() string {
+	return fmt.Sprintf("pull failed for %s: %v", e.Path, e.Cause)
+}
+
+func (e ErrPullFailed) Unwrap() error {
+	return e.Cause
+}
+
+// ErrPushFailed indicates pushing repository changes failed.
+type ErrPushFailed struct {
+	Path  string
+	Cause error
+}
+
+func (e ErrPushFailed) Error() string {
+	return fmt.Sprintf("push failed for %s: %v", e.Path, e.Cause)
+}
+
+func (e ErrPushFailed) Unwrap() error {
+	return e.Cause
+}
+
+// ErrRebaseConflict indicates a per-machine branch could not be rebased
+// because it and its base branch changed the same files differently.
+type ErrRebaseConflict struct {
+	Branch   string
+	Onto     string
+	Packages []string
+	Files    []string
+}
+
+func (e ErrRebaseConflict) Error() string {
+	if len(e.Packages) == 0 {
+		return fmt.Sprintf("cannot rebase %s onto %s: conflicting changes to %s", e.Branch, e.Onto, strings.Join(e.Files, ", "))
+	}
+	return fmt.Sprintf("cannot rebase %s onto %s: conflicting changes in package(s) %s", e.Branch, e.Onto, strings.Join(e.Packages, ", "))
+}
+
+// ErrOffline indicates an operation needs network access while offline mode is enabled.
+type ErrOffline struct {
+	Operation string
+}
+
+func (e ErrOffline) Error() string {
+	return fmt.Sprintf("%s requires network access but offline mode is enabled", e.Operation)
+}
+
 // ErrProfileNotFound indicates the requested profile does not exist.
 type ErrProfileNotFound struct {
 	Profile string
+
+	// Kind is the kind the profile name was qualified with, if any.
+	Kind ProfileKind
+
+	// Available lists the profiles that could have been meant, qualified
+	// with their kind.
+	Available []string
 }
 
 func (e ErrProfileNotFound) Error() string {
-	return fmt.Sprintf("profile not found: %s", e.Profile)
+	if len(e.Available) == 0 {
+		return fmt.Sprintf("profile not found: %s", e.Profile)
+	}
+	return fmt.Sprintf("profile not found: %s (available: %s)", e.Profile, strings.Join(e.Available, ", "))
+}
+
+// ErrAmbiguousProfile indicates a profile name defined by several kinds of
+// profile, which must be qualified to tell which is meant.
+type ErrAmbiguousProfile struct {
+	Profile string
+	Kinds   []ProfileKind
+}
+
+func (e ErrAmbiguousProfile) Error() string {
+	qualified := make([]string, 0, len(e.Kinds))
+	for _, kind := range e.Kinds {
+		qualified = append(qualified, ProfileRef{Kind: kind, Name: e.Profile}.String())
+	}
+	return fmt.Sprintf("profile %q is ambiguous: use %s", e.Profile, strings.Join(qualified, " or "))
+}
+
+// ErrProfileKind indicates a profile of another kind than the command
+// takes.
+type ErrProfileKind struct {
+	Profile string
+	Want    ProfileKind
+}
+
+func (e ErrProfileKind) Error() string {
+	return fmt.Sprintf("profile %s is not a %s profile", e.Profile, e.Want)
 }
 
 // ErrBootstrapExists indicates the bootstrap file already exists.
@@ -137,6 +302,41 @@ func (e ErrBootstrapExists) Error() string {
 	return fmt.Sprintf("bootstrap file already exists: %s", e.Path)
 }
 
+// ErrNotLinked indicates a target path that is not a link of any
+// installed package.
+type ErrNotLinked struct {
+	Path string
+}
+
+func (e ErrNotLinked) Error() string {
+	return fmt.Sprintf("%s is not linked from any installed package", e.Path)
+}
+
+// ErrPackageExists indicates a package directory that already exists.
+type ErrPackageExists struct {
+	Package string
+	Path    string
+}
+
+func (e ErrPackageExists) Error() string {
+	return fmt.Sprintf("package %s already exists: %s", e.Package, e.Path)
+}
+
+// ErrInvalidBundle indicates a file that is not a bundle written by
+// dot export.
+type ErrInvalidBundle struct {
+	Path  string
+	Cause error
+}
+
+func (e ErrInvalidBundle) Error() string {
+	return fmt.Sprintf("invalid bundle %s: %v", e.Path, e.Cause)
+}
+
+func (e ErrInvalidBundle) Unwrap() error {
+	return e.Cause
+}
+
 // UserFacingError converts an error into a user-friendly message.
 func UserFacingError(err error) string {
 	return domain.UserFacingError(err)
@@ -0,0 +1,57 @@
+package dot
+
+import (
+	"context"
+	"maps"
+	"slices"
+
+	"github.com/jamesainslie/dot/internal/domain"
+)
+
+// Event is a message published by a service of the client.
+type Event = domain.Event
+
+// EventBus delivers the events of a client to its subscribers.
+type EventBus = domain.EventBus
+
+// EventTopic groups the events published on an EventBus.
+type EventTopic = domain.EventTopic
+
+// EventHandler consumes events delivered by an EventBus.
+type EventHandler = domain.EventHandler
+
+// EventTopic constants
+const (
+	TopicOperation = domain.TopicOperation
+	TopicProgress  = domain.TopicProgress
+	TopicWarning   = domain.TopicWarning
+)
+
+// Events returns the bus the services of the client publish to. Its
+// subscribers receive the operations of executed plans, the progress of
+// long-running commands such as clone, and the problems worked around,
+// for every target directory of the client.
+func (c *Client) Events() *EventBus {
+	return c.events
+}
+
+// logEvents returns a handler logging progress events at info level and
+// warnings at warn level, with the logger of the publishing component.
+func logEvents(component func(name string) Logger) EventHandler {
+	return func(ctx context.Context, event Event) {
+		args := make([]any, 0, 2*len(event.Fields)+2)
+		for _, key := range slices.Sorted(maps.Keys(event.Fields)) {
+			args = append(args, key, event.Fields[key])
+		}
+		if event.Err != nil {
+			args = append(args, "error", event.Err)
+		}
+
+		logger := component(event.Source)
+		if event.Topic == TopicWarning {
+			logger.Warn(ctx, event.Name, args...)
+			return
+		}
+		logger.Info(ctx, event.Name, args...)
+	}
+}
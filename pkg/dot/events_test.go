@@ -0,0 +1,55 @@
+package dot_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+func TestClient_Events(t *testing.T) {
+	ctx := context.Background()
+	_, client := setupTargetsTest(t)
+
+	var targets []string
+	client.Events().Subscribe(func(ctx context.Context, event dot.Event) {
+		require.NotNil(t, event.Execution)
+		if event.Execution.Kind != dot.EventSucceeded {
+			return
+		}
+		if link, ok := event.Execution.Operation.(dot.LinkCreate); ok {
+			targets = append(targets, link.Target.String())
+		}
+	}, dot.TopicOperation)
+
+	require.NoError(t, client.Manage(ctx, "dot-vim", "dot-bin"))
+
+	assert.ElementsMatch(t, []string{"/test/target/.vim/vimrc", "/test/local/bin/tool"}, targets,
+		"operations of every target directory are published on the client's bus")
+}
+
+func TestClient_Events_SinkReceivesEachEventOnce(t *testing.T) {
+	ctx := context.Background()
+	fs, _ := setupTargetsTest(t)
+
+	var kinds []dot.ExecutionEventKind
+	client, err := dot.NewClient(dot.Config{
+		PackageDir:         "/test/packages",
+		TargetDir:          "/test/target",
+		Targets:            map[string]string{"dot-bin": "/test/local/bin"},
+		PackageNameMapping: true,
+		FS:                 fs,
+		Logger:             adapters.NewNoopLogger(),
+		EventSink: dot.EventSinkFunc(func(ctx context.Context, event dot.ExecutionEvent) {
+			kinds = append(kinds, event.Kind)
+		}),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, client.Manage(ctx, "dot-bin"))
+	assert.Equal(t, []dot.ExecutionEventKind{dot.EventStarted, dot.EventSucceeded}, kinds)
+}
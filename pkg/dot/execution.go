@@ -4,3 +4,17 @@ import "github.com/jamesainslie/dot/internal/domain"
 
 // ExecutionResult contains the outcome of plan execution.
 type ExecutionResult = domain.ExecutionResult
+
+// ExecutionEvent reports the progress of a single operation.
+type ExecutionEvent = domain.ExecutionEvent
+
+// ExecutionEventKind identifies what happened to an operation.
+type ExecutionEventKind = domain.ExecutionEventKind
+
+// Execution event kinds
+const (
+	EventStarted    = domain.EventStarted
+	EventSucceeded  = domain.EventSucceeded
+	EventFailed     = domain.EventFailed
+	EventRolledBack = domain.EventRolledBack
+)
@@ -0,0 +1,317 @@
+package dot
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/internal/bootstrap"
+	"github.com/jamesainslie/dot/internal/manifest"
+)
+
+// ExportService writes packages into bundles that clone installs without
+// network access.
+type ExportService struct {
+	fs          FS
+	logger      Logger
+	manifestSvc *ManifestService
+	puller      adapters.GitPuller
+	packageDir  string
+	targetDir   string
+	dryRun      bool
+}
+
+// newExportService creates a new export service.
+func newExportService(
+	fs FS,
+	logger Logger,
+	manifestSvc *ManifestService,
+	puller adapters.GitPuller,
+	packageDir string,
+	targetDir string,
+	dryRun bool,
+) *ExportService {
+	return &ExportService{
+		fs:          fs,
+		logger:      logger,
+		manifestSvc: manifestSvc,
+		puller:      puller,
+		packageDir:  packageDir,
+		targetDir:   targetDir,
+		dryRun:      dryRun,
+	}
+}
+
+// ExportOptions configures an export.
+type ExportOptions struct {
+	// Output is the path of the bundle to write.
+	Output string
+
+	// Packages lists the packages to bundle. If empty, every package of
+	// the package directory is bundled.
+	Packages []string
+
+	// Reproducible makes exports of the same inputs byte-identical: every
+	// entry and the index are stamped with ModTime instead of the current
+	// time, and permissions are normalized to 0755 or 0644. Entries are
+	// always written in sorted order and without owners.
+	Reproducible bool
+
+	// ModTime stamps the entries of a reproducible bundle, such as the
+	// time of the commit it is built from. If zero, the Unix epoch is used.
+	ModTime time.Time
+}
+
+// ExportResult describes a written bundle.
+type ExportResult struct {
+	// Path is the path of the bundle.
+	Path string `json:"path"`
+
+	// Packages lists the bundled packages, sorted.
+	Packages []string `json:"packages"`
+
+	// Files is the number of package files in the bundle.
+	Files int `json:"files"`
+
+	// Bootstrap reports whether the bundle holds a bootstrap
+	// configuration.
+	Bootstrap bool `json:"bootstrap"`
+
+	// Manifest reports whether the bundle holds a snapshot of the
+	// manifest.
+	Manifest bool `json:"manifest"`
+
+	// Size is the size of the bundle in bytes, or zero in dry-run mode.
+	Size int64 `json:"size"`
+
+	// Inputs are the input hashes recorded in the bundle index.
+	Inputs BundleInputs `json:"inputs"`
+}
+
+// Export writes the selected packages, the bootstrap configuration and the
+// repository configuration of the package directory, and a snapshot of the
+// manifest, to a gzip-compressed tar archive at opts.Output.
+//
+// A bootstrap configuration is restricted to the bundled packages, so a
+// bundle of some packages installs with the same profiles. In dry-run mode
+// the bundle is described but not written.
+func (s *ExportService) Export(ctx context.Context, opts ExportOptions) (ExportResult, error) {
+	if opts.Output == "" {
+		return ExportResult{}, ErrInvalidPath{Path: opts.Output, Reason: "bundle path is required"}
+	}
+
+	packages := slices.Clone(opts.Packages)
+	if len(packages) == 0 {
+		var err error
+		if packages, err = discoverPackages(ctx, s.fs, s.packageDir); err != nil {
+			return ExportResult{}, err
+		}
+	}
+	slices.Sort(packages)
+	packages = slices.Compact(packages)
+	for _, pkg := range packages {
+		if isDir, err := s.fs.IsDir(ctx, filepath.Join(s.packageDir, pkg)); err != nil || !isDir {
+			return ExportResult{}, ErrPackageNotFound{Package: pkg}
+		}
+	}
+
+	s.logger.Info(ctx, "export_started", "output", opts.Output, "packages", packages)
+
+	now := time.Now().UTC()
+	if opts.Reproducible {
+		now = time.Unix(opts.ModTime.Unix(), 0).UTC()
+		if opts.ModTime.IsZero() {
+			now = time.Unix(0, 0).UTC()
+		}
+	}
+	w := newBundleWriter(now, opts.Reproducible)
+	result := ExportResult{Path: opts.Output, Packages: packages}
+
+	for _, pkg := range packages {
+		files, err := w.tree(ctx, s.fs, filepath.Join(s.packageDir, pkg), path.Join(bundlePackagesDir, pkg))
+		if err != nil {
+			return ExportResult{}, fmt.Errorf("bundle package %s: %w", pkg, err)
+		}
+		result.Files += files
+	}
+
+	configDir := filepath.Join(s.packageDir, repoConfigDir)
+	if isDir, err := s.fs.IsDir(ctx, configDir); err == nil && isDir {
+		if _, err := w.tree(ctx, s.fs, configDir, path.Join(bundlePackagesDir, filepath.ToSlash(repoConfigDir))); err != nil {
+			return ExportResult{}, fmt.Errorf("bundle repository configuration: %w", err)
+		}
+	}
+
+	bootstrapData, err := s.bundleBootstrap(ctx, packages)
+	if err != nil {
+		return ExportResult{}, err
+	}
+	if bootstrapData != nil {
+		if err := w.file(path.Join(bundlePackagesDir, ".dotbootstrap.yaml"), bootstrapData, 0644); err != nil {
+			return ExportResult{}, err
+		}
+		result.Bootstrap = true
+	}
+
+	snapshot, installed, err := s.manifestSnapshot(ctx, packages)
+	if err != nil {
+		return ExportResult{}, err
+	}
+	if snapshot != nil {
+		if err := w.file(bundleManifestName, snapshot, 0644); err != nil {
+			return ExportResult{}, err
+		}
+		result.Manifest = true
+	}
+
+	inputs, err := s.bundleInputs(ctx, packages, bootstrapData)
+	if err != nil {
+		return ExportResult{}, err
+	}
+	result.Inputs = inputs
+
+	index, err := json.MarshalIndent(BundleIndex{
+		Format:    bundleFormat,
+		CreatedAt: now,
+		Packages:  packages,
+		Installed: installed,
+		Inputs:    &inputs,
+	}, "", "  ")
+	if err != nil {
+		return ExportResult{}, fmt.Errorf("encode %s: %w", bundleIndexName, err)
+	}
+	if err := w.file(bundleIndexName, index, 0644); err != nil {
+		return ExportResult{}, err
+	}
+
+	data, err := w.bytes()
+	if err != nil {
+		return ExportResult{}, fmt.Errorf("write bundle: %w", err)
+	}
+
+	if s.dryRun {
+		s.logger.Info(ctx, "dry_run_mode", "would_write", opts.Output, "files", result.Files)
+		return result, nil
+	}
+
+	if err := s.fs.MkdirAll(ctx, filepath.Dir(opts.Output), 0755); err != nil {
+		return ExportResult{}, fmt.Errorf("create %s: %w", filepath.Dir(opts.Output), err)
+	}
+	if err := s.fs.WriteFile(ctx, opts.Output, data, 0644); err != nil {
+		return ExportResult{}, fmt.Errorf("write bundle: %w", err)
+	}
+	result.Size = int64(len(data))
+
+	s.logger.Info(ctx, "export_complete", "output", opts.Output, "packages", len(packages), "files", result.Files, "bytes", result.Size)
+	return result, nil
+}
+
+// bundleInputs returns the input hashes of a bundle of packages holding
+// the bootstrap configuration bootstrapData.
+func (s *ExportService) bundleInputs(ctx context.Context, packages []string, bootstrapData []byte) (BundleInputs, error) {
+	inputs := BundleInputs{Packages: make(map[string]string, len(packages))}
+	if s.puller != nil {
+		if head, err := s.puller.Head(ctx, s.packageDir); err == nil {
+			inputs.Commit = head
+		}
+	}
+
+	hasher := manifest.NewContentHasher(s.fs)
+	hashTree := func(dir string) (string, error) {
+		pathResult := NewPackagePath(dir)
+		if !pathResult.IsOk() {
+			return "", pathResult.UnwrapErr()
+		}
+		return hasher.HashPackage(ctx, pathResult.Unwrap())
+	}
+	for _, pkg := range packages {
+		hash, err := hashTree(filepath.Join(s.packageDir, pkg))
+		if err != nil {
+			return BundleInputs{}, fmt.Errorf("hash package %s: %w", pkg, err)
+		}
+		inputs.Packages[pkg] = hash
+	}
+
+	configDir := filepath.Join(s.packageDir, repoConfigDir)
+	var configHash string
+	if isDir, err := s.fs.IsDir(ctx, configDir); err == nil && isDir {
+		if configHash, err = hashTree(configDir); err != nil {
+			return BundleInputs{}, fmt.Errorf("hash repository configuration: %w", err)
+		}
+	}
+	if configHash != "" || bootstrapData != nil {
+		sum := sha256.New()
+		sum.Write([]byte(configHash))
+		sum.Write([]byte{0})
+		sum.Write(bootstrapData)
+		inputs.ConfigHash = hex.EncodeToString(sum.Sum(nil))
+	}
+	return inputs, nil
+}
+
+// bundleBootstrap returns the bootstrap configuration of the package
+// directory restricted to packages, or nil if there is none.
+func (s *ExportService) bundleBootstrap(ctx context.Context, packages []string) ([]byte, error) {
+	cfg, found, err := loadBootstrapConfig(ctx, s.fs, s.packageDir)
+	if err != nil || !found {
+		return nil, err
+	}
+
+	subset := bootstrap.Subset(cfg, packages)
+	if len(subset.Packages) == len(cfg.Packages) && len(subset.Profiles) == len(cfg.Profiles) {
+		// Keep the file as written, with its comments
+		return s.fs.ReadFile(ctx, filepath.Join(s.packageDir, ".dotbootstrap.yaml"))
+	}
+	data, err := bootstrap.NewGenerator().MarshalYAML(subset)
+	if err != nil {
+		return nil, fmt.Errorf("bundle bootstrap configuration: %w", err)
+	}
+	return data, nil
+}
+
+// manifestSnapshot returns the manifest restricted to packages and the
+// installed packages among them, or nil data if none of them is installed.
+func (s *ExportService) manifestSnapshot(ctx context.Context, packages []string) ([]byte, []string, error) {
+	targetPathResult := NewTargetPath(s.targetDir)
+	if !targetPathResult.IsOk() {
+		return nil, nil, targetPathResult.UnwrapErr()
+	}
+	manifestResult := s.manifestSvc.Load(ctx, targetPathResult.Unwrap())
+	if !manifestResult.IsOk() {
+		return nil, nil, fmt.Errorf("load manifest: %w", manifestResult.UnwrapErr())
+	}
+
+	m := manifestResult.Unwrap()
+	installed := []string{}
+	snapshot := make(map[string]manifest.PackageInfo)
+	for name, info := range m.Packages {
+		if slices.Contains(packages, name) {
+			installed = append(installed, name)
+			snapshot[name] = info
+		}
+	}
+	slices.Sort(installed)
+	if len(installed) == 0 {
+		return nil, installed, nil
+	}
+
+	hashes := make(map[string]string, len(installed))
+	for _, name := range installed {
+		if hash, ok := m.Hashes[name]; ok {
+			hashes[name] = hash
+		}
+	}
+	m.Packages, m.Hashes = snapshot, hashes
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, nil, fmt.Errorf("encode manifest snapshot: %w", err)
+	}
+	return data, installed, nil
+}
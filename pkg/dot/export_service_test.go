@@ -0,0 +1,407 @@
+package dot
+
+import (
+	"archive/tar"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/internal/bootstrap"
+)
+
+const exportBootstrap = `version: "1.0"
+packages:
+  - name: vim
+  - name: zsh
+  - name: tmux
+profiles:
+  editor:
+    description: Editor only
+    packages: [vim]
+  shell:
+    description: Shell only
+    packages: [tmux]
+defaults:
+  profile: shell
+`
+
+// setupExport creates a package directory at /src/packages with vim
+// installed into /src/target, and returns a client for it.
+func setupExport(t *testing.T, fs *adapters.MemFS) *Client {
+	t.Helper()
+	ctx := context.Background()
+
+	files := map[string]string{
+		"/src/packages/vim/dot-vimrc":            "set nocompatible",
+		"/src/packages/vim/dot-vim/colors/x.vim": "hi Normal",
+		"/src/packages/zsh/dot-zshrc":            "bindkey -v",
+		"/src/packages/tmux/dot-tmux.conf":       "set -g mouse on",
+		"/src/packages/.config/dot/config.yaml":  "symlinks:\n  mode: relative\n",
+		"/src/packages/.git/HEAD":                "ref: refs/heads/main",
+		"/src/packages/.dotbootstrap.yaml":       exportBootstrap,
+	}
+	require.NoError(t, fs.MkdirAll(ctx, "/src/target", 0755))
+	for path, content := range files {
+		require.NoError(t, fs.MkdirAll(ctx, filepath.Dir(path), 0755))
+		require.NoError(t, fs.WriteFile(ctx, path, []byte(content), 0644))
+	}
+	require.NoError(t, fs.WriteFile(ctx, "/src/packages/zsh/dot-zlogin", []byte("exec tmux"), 0700))
+	require.NoError(t, fs.Symlink(ctx, "dot-zshrc", "/src/packages/zsh/dot-zprofile"))
+
+	client, err := NewClient(Config{
+		PackageDir: "/src/packages",
+		TargetDir:  "/src/target",
+		FS:         fs,
+		Logger:     adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+	require.NoError(t, client.Manage(ctx, "vim"))
+	return client
+}
+
+// newBundleClient returns a client for the empty package directory
+// /dst/packages and target directory /dst/target.
+func newBundleClient(t *testing.T, fs *adapters.MemFS, dryRun bool) *Client {
+	t.Helper()
+	require.NoError(t, fs.MkdirAll(context.Background(), "/dst/target", 0755))
+	client, err := NewClient(Config{
+		PackageDir: "/dst/packages",
+		TargetDir:  "/dst/target",
+		DryRun:     dryRun,
+		Offline:    true,
+		FS:         fs,
+		Logger:     adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+	return client
+}
+
+func TestClient_Export(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	client := setupExport(t, fs)
+
+	result, err := client.Export(ctx, ExportOptions{Output: "/out/bundle.tar.gz"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tmux", "vim", "zsh"}, result.Packages)
+	assert.Equal(t, 6, result.Files)
+	assert.True(t, result.Bootstrap)
+	assert.True(t, result.Manifest)
+	assert.Positive(t, result.Size)
+
+	index, entries, err := readBundle(ctx, fs, "/out/bundle.tar.gz")
+	require.NoError(t, err)
+	assert.Equal(t, bundleFormat, index.Format)
+	assert.Equal(t, []string{"tmux", "vim", "zsh"}, index.Packages)
+	assert.Equal(t, []string{"vim"}, index.Installed)
+
+	names := make(map[string]bundleEntry, len(entries))
+	for _, entry := range entries {
+		names[entry.name] = entry
+	}
+	assert.Contains(t, names, "packages/.config/dot/config.yaml")
+	assert.Contains(t, names, bundleManifestName)
+	assert.NotContains(t, names, "packages/.git/HEAD")
+	assert.Equal(t, os.FileMode(0700), names["packages/zsh/dot-zlogin"].mode)
+	assert.Equal(t, byte(tar.TypeSymlink), names["packages/zsh/dot-zprofile"].typeflag)
+	assert.Equal(t, "dot-zshrc", names["packages/zsh/dot-zprofile"].linkname)
+
+	// The bootstrap configuration is bundled as written
+	assert.Equal(t, exportBootstrap, string(names["packages/.dotbootstrap.yaml"].data))
+}
+
+func TestClient_Export_Packages(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	client := setupExport(t, fs)
+
+	result, err := client.Export(ctx, ExportOptions{Output: "/out/bundle.tar.gz", Packages: []string{"zsh", "vim"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"vim", "zsh"}, result.Packages)
+
+	_, entries, err := readBundle(ctx, fs, "/out/bundle.tar.gz")
+	require.NoError(t, err)
+	for _, entry := range entries {
+		assert.NotContains(t, entry.name, "tmux")
+	}
+
+	_, err = client.Export(ctx, ExportOptions{Output: "/out/bundle.tar.gz", Packages: []string{"emacs"}})
+	var notFound ErrPackageNotFound
+	assert.ErrorAs(t, err, &notFound)
+}
+
+func TestClient_Export_DryRun(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	setupExport(t, fs)
+
+	client, err := NewClient(Config{
+		PackageDir: "/src/packages",
+		TargetDir:  "/src/target",
+		DryRun:     true,
+		FS:         fs,
+		Logger:     adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+
+	result, err := client.Export(ctx, ExportOptions{Output: "/out/bundle.tar.gz"})
+	require.NoError(t, err)
+	assert.Len(t, result.Packages, 3)
+	assert.Zero(t, result.Size)
+	assert.False(t, fs.Exists(ctx, "/out/bundle.tar.gz"))
+}
+
+func TestClient_Export_Reproducible(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	client := setupExport(t, fs)
+	opts := ExportOptions{Output: "/out/first.tar.gz", Reproducible: true, ModTime: time.Unix(1700000000, 0)}
+
+	first, err := client.Export(ctx, opts)
+	require.NoError(t, err)
+
+	// Rewriting a file with the same content and another mode changes no input
+	require.NoError(t, fs.WriteFile(ctx, "/src/packages/vim/dot-vimrc", []byte("set nocompatible"), 0664))
+	opts.Output = "/out/second.tar.gz"
+	second, err := client.Export(ctx, opts)
+	require.NoError(t, err)
+
+	a, err := fs.ReadFile(ctx, "/out/first.tar.gz")
+	require.NoError(t, err)
+	b, err := fs.ReadFile(ctx, "/out/second.tar.gz")
+	require.NoError(t, err)
+	assert.Equal(t, a, b, "bundles of the same inputs should be byte-identical")
+	assert.Equal(t, first.Inputs, second.Inputs)
+
+	index, entries, err := readBundle(ctx, fs, "/out/first.tar.gz")
+	require.NoError(t, err)
+	assert.True(t, index.CreatedAt.Equal(time.Unix(1700000000, 0)))
+	require.NotNil(t, index.Inputs)
+	assert.Equal(t, first.Inputs, *index.Inputs)
+	assert.Empty(t, index.Inputs.Commit, "the package directory is not a git repository")
+	assert.NotEmpty(t, index.Inputs.ConfigHash)
+	assert.Len(t, index.Inputs.Packages, 3)
+
+	modes := make(map[string]os.FileMode, len(entries))
+	for _, entry := range entries {
+		modes[entry.name] = entry.mode
+	}
+	assert.Equal(t, os.FileMode(0644), modes["packages/vim/dot-vimrc"])
+	assert.Equal(t, os.FileMode(0700), modes["packages/zsh/dot-zlogin"], "private files stay private")
+	assert.Equal(t, os.FileMode(0755), modes["packages/vim"])
+
+	// Changing a package changes its recorded hash only
+	require.NoError(t, fs.WriteFile(ctx, "/src/packages/zsh/dot-zshrc", []byte("bindkey -e"), 0644))
+	opts.Output = "/out/third.tar.gz"
+	third, err := client.Export(ctx, opts)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.Inputs.Packages["zsh"], third.Inputs.Packages["zsh"])
+	assert.Equal(t, first.Inputs.Packages["vim"], third.Inputs.Packages["vim"])
+	assert.Equal(t, first.Inputs.ConfigHash, third.Inputs.ConfigHash)
+}
+
+func TestClient_CloneBundle(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	_, err := setupExport(t, fs).Export(ctx, ExportOptions{Output: "/out/bundle.tar.gz"})
+	require.NoError(t, err)
+
+	client := newBundleClient(t, fs, false)
+	_, err = client.CloneBundle(ctx, "/out/bundle.tar.gz", CloneOptions{})
+	require.NoError(t, err)
+
+	// Every bundled package is extracted, and the packages installed on
+	// the exporting machine are installed
+	assert.True(t, fs.Exists(ctx, "/dst/packages/tmux/dot-tmux.conf"))
+	assert.True(t, fs.Exists(ctx, "/dst/packages/.config/dot/config.yaml"))
+	info, err := fs.Stat(ctx, "/dst/packages/zsh/dot-zlogin")
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0700), info.Mode().Perm())
+	link, err := fs.ReadLink(ctx, "/dst/packages/zsh/dot-zprofile")
+	require.NoError(t, err)
+	assert.Equal(t, "dot-zshrc", link)
+
+	link, err = fs.ReadLink(ctx, "/dst/target/.vimrc")
+	require.NoError(t, err)
+	assert.Contains(t, link, "packages/vim/dot-vimrc")
+	assert.False(t, fs.Exists(ctx, "/dst/target/.zshrc"))
+}
+
+func TestClient_CloneBundle_Profile(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	_, err := setupExport(t, fs).Export(ctx, ExportOptions{Output: "/out/bundle.tar.gz", Packages: []string{"vim", "zsh"}})
+	require.NoError(t, err)
+
+	client := newBundleClient(t, fs, false)
+	_, err = client.CloneBundle(ctx, "/out/bundle.tar.gz", CloneOptions{Profile: "editor"})
+	require.NoError(t, err)
+	assert.True(t, fs.Exists(ctx, "/dst/target/.vimrc"))
+
+	// The profile of the package left out is dropped with it
+	cfg, found, err := loadBootstrapConfig(ctx, fs, "/dst/packages")
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, []string{"vim", "zsh"}, bootstrap.GetPackageNames(cfg))
+	assert.NotContains(t, cfg.Profiles, "shell")
+	assert.Empty(t, cfg.Defaults.Profile)
+}
+
+func TestClient_CloneBundle_DryRun(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	_, err := setupExport(t, fs).Export(ctx, ExportOptions{Output: "/out/bundle.tar.gz"})
+	require.NoError(t, err)
+
+	client := newBundleClient(t, fs, true)
+	_, err = client.CloneBundle(ctx, "/out/bundle.tar.gz", CloneOptions{})
+	require.NoError(t, err)
+	assert.False(t, fs.Exists(ctx, "/dst/packages"))
+}
+
+func TestClient_CloneBundle_NotEmpty(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	_, err := setupExport(t, fs).Export(ctx, ExportOptions{Output: "/out/bundle.tar.gz"})
+	require.NoError(t, err)
+	require.NoError(t, fs.MkdirAll(ctx, "/dst/packages/old", 0755))
+
+	_, err = newBundleClient(t, fs, false).CloneBundle(ctx, "/out/bundle.tar.gz", CloneOptions{})
+	var notEmpty ErrPackageDirNotEmpty
+	assert.ErrorAs(t, err, &notEmpty)
+}
+
+func TestReadBundle_Invalid(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	require.NoError(t, fs.MkdirAll(ctx, "/out", 0755))
+
+	writeBundle := func(t *testing.T, build func(w *bundleWriter)) {
+		t.Helper()
+		w := newBundleWriter(time.Now(), false)
+		build(w)
+		data, err := w.bytes()
+		require.NoError(t, err)
+		require.NoError(t, fs.WriteFile(ctx, "/out/bundle.tar.gz", data, 0644))
+	}
+
+	t.Run("not an archive", func(t *testing.T) {
+		require.NoError(t, fs.WriteFile(ctx, "/out/bundle.tar.gz", []byte("not a bundle"), 0644))
+		_, _, err := readBundle(ctx, fs, "/out/bundle.tar.gz")
+		var invalid ErrInvalidBundle
+		assert.ErrorAs(t, err, &invalid)
+	})
+
+	t.Run("missing index", func(t *testing.T) {
+		writeBundle(t, func(w *bundleWriter) {
+			require.NoError(t, w.file("packages/vim/dot-vimrc", []byte("x"), 0644))
+		})
+		_, _, err := readBundle(ctx, fs, "/out/bundle.tar.gz")
+		assert.ErrorContains(t, err, "dot-bundle.json is missing")
+	})
+
+	t.Run("unsafe entry", func(t *testing.T) {
+		writeBundle(t, func(w *bundleWriter) {
+			require.NoError(t, w.file("packages/../../etc/passwd", []byte("x"), 0644))
+		})
+		_, _, err := readBundle(ctx, fs, "/out/bundle.tar.gz")
+		assert.ErrorContains(t, err, "unsafe entry")
+	})
+
+	malicious := []struct {
+		name    string
+		build   func(w *bundleWriter)
+		message string
+	}{
+		{
+			name: "absolute link",
+			build: func(w *bundleWriter) {
+				require.NoError(t, w.symlink("packages/vim/dot-vimrc", "/etc/passwd"))
+			},
+			message: `symbolic link "packages/vim/dot-vimrc" has unsafe target "/etc/passwd"`,
+		},
+		{
+			name: "link leaving the package directory",
+			build: func(w *bundleWriter) {
+				require.NoError(t, w.symlink("packages/vim/escape", "../../../etc"))
+			},
+			message: `has unsafe target "../../../etc"`,
+		},
+		{
+			name: "link to the bundle root",
+			build: func(w *bundleWriter) {
+				require.NoError(t, w.symlink("packages/vim/root", "../.."))
+			},
+			message: `has unsafe target "../.."`,
+		},
+		{
+			name: "link through another link",
+			build: func(w *bundleWriter) {
+				require.NoError(t, w.symlink("packages/vim/up", ".."))
+				require.NoError(t, w.symlink("packages/vim/escape", "up/../../etc"))
+			},
+			message: `resolves through the symbolic link "packages/vim/up"`,
+		},
+		{
+			name: "entry written through a link",
+			build: func(w *bundleWriter) {
+				require.NoError(t, w.symlink("packages/vim/dir", "../zsh"))
+				require.NoError(t, w.file("packages/vim/dir/dot-zshrc", []byte("x"), 0644))
+			},
+			message: `entry "packages/vim/dir/dot-zshrc" is below the symbolic link "packages/vim/dir"`,
+		},
+		{
+			name: "entry before the link it is below",
+			build: func(w *bundleWriter) {
+				require.NoError(t, w.file("packages/vim/dir/dot-zshrc", []byte("x"), 0644))
+				require.NoError(t, w.symlink("packages/vim/dir", "../zsh"))
+			},
+			message: "is below the symbolic link",
+		},
+		{
+			name: "duplicate entry",
+			build: func(w *bundleWriter) {
+				require.NoError(t, w.symlink("packages/vim/dot-vimrc", "../zsh/dot-zshrc"))
+				require.NoError(t, w.file("packages/vim/dot-vimrc", []byte("x"), 0644))
+			},
+			message: `duplicate entry "packages/vim/dot-vimrc"`,
+		},
+	}
+	for _, tt := range malicious {
+		t.Run(tt.name, func(t *testing.T) {
+			writeBundle(t, func(w *bundleWriter) {
+				require.NoError(t, w.file(bundleIndexName, []byte(`{"format": 1}`), 0644))
+				tt.build(w)
+			})
+			_, _, err := readBundle(ctx, fs, "/out/bundle.tar.gz")
+			var invalid ErrInvalidBundle
+			assert.ErrorAs(t, err, &invalid)
+			assert.ErrorContains(t, err, tt.message)
+		})
+	}
+
+	t.Run("links inside the package directory", func(t *testing.T) {
+		writeBundle(t, func(w *bundleWriter) {
+			require.NoError(t, w.file(bundleIndexName, []byte(`{"format": 1}`), 0644))
+			require.NoError(t, w.symlink("packages/vim/dot-vimrc", "../zsh/./dot-zshrc"))
+			require.NoError(t, w.symlink("packages/vim/packages", ".."))
+		})
+		_, entries, err := readBundle(ctx, fs, "/out/bundle.tar.gz")
+		require.NoError(t, err)
+		assert.Len(t, entries, 2)
+	})
+
+	t.Run("newer format", func(t *testing.T) {
+		writeBundle(t, func(w *bundleWriter) {
+			require.NoError(t, w.file(bundleIndexName, []byte(`{"format": 99}`), 0644))
+		})
+		_, _, err := readBundle(ctx, fs, "/out/bundle.tar.gz")
+		assert.ErrorContains(t, err, "newer")
+	})
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package dot
+
+// fileOwnerUID reports no owner because this platform's stat data carries
+// no user ID.
+func fileOwnerUID(info FileInfo) (uint32, bool) {
+	return 0, false
+}
@@ -0,0 +1,14 @@
+//go:build unix
+
+package dot
+
+import "syscall"
+
+// fileOwnerUID returns the user owning the file described by info, if the
+// filesystem reports it.
+func fileOwnerUID(info FileInfo) (uint32, bool) {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return st.Uid, true
+	}
+	return 0, false
+}
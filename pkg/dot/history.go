@@ -0,0 +1,35 @@
+package dot
+
+import (
+	"context"
+
+	"github.com/jamesainslie/dot/internal/audit"
+)
+
+// AuditRecord is an operation recorded in the audit log.
+type AuditRecord = audit.Record
+
+// AuditResult is the outcome of an audited operation.
+type AuditResult = audit.Result
+
+// AuditResult constants
+const (
+	AuditSucceeded      = audit.ResultSucceeded
+	AuditFailed         = audit.ResultFailed
+	AuditRolledBack     = audit.ResultRolledBack
+	AuditRollbackFailed = audit.ResultRollbackFailed
+)
+
+// HistoryFilter selects the audit records returned by Client.History.
+type HistoryFilter = audit.Filter
+
+// History returns the operations recorded in the audit log that filter
+// selects, oldest first.
+//
+// Returns ErrAuditLogDisabled when Config.AuditLog is empty.
+func (c *Client) History(ctx context.Context, filter HistoryFilter) ([]AuditRecord, error) {
+	if c.auditLog == nil {
+		return nil, ErrAuditLogDisabled{}
+	}
+	return c.auditLog.Read(ctx, filter)
+}
@@ -0,0 +1,74 @@
+package dot_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+func TestClient_History(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/vim", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/vim/dot-vimrc", []byte("set nocompatible"), 0644))
+
+	var events []dot.ExecutionEvent
+	client, err := dot.NewClient(dot.Config{
+		PackageDir: "/test/packages",
+		TargetDir:  "/test/target",
+		AuditLog:   "/test/state/audit.jsonl",
+		FS:         fs,
+		Logger:     adapters.NewNoopLogger(),
+		EventSink: dot.EventSinkFunc(func(ctx context.Context, event dot.ExecutionEvent) {
+			events = append(events, event)
+		}),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, client.Manage(ctx, "vim"))
+	assert.NotEmpty(t, events, "configured event sink still receives events")
+
+	records, err := client.History(ctx, dot.HistoryFilter{Package: "vim"})
+	require.NoError(t, err)
+	require.NotEmpty(t, records)
+	last := records[len(records)-1]
+	assert.Equal(t, dot.AuditSucceeded, last.Result)
+	assert.Equal(t, "LinkCreate", last.Kind)
+	assert.NotEmpty(t, last.PlanID)
+	assert.NotEmpty(t, last.CheckpointID)
+
+	records, err = client.History(ctx, dot.HistoryFilter{Package: "zsh"})
+	require.NoError(t, err)
+	assert.Empty(t, records)
+}
+
+func TestClient_History_Disabled(t *testing.T) {
+	client, err := dot.NewClient(dot.Config{
+		PackageDir: "/test/packages",
+		TargetDir:  "/test/target",
+		FS:         adapters.NewMemFS(),
+		Logger:     adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+
+	_, err = client.History(context.Background(), dot.HistoryFilter{})
+	assert.True(t, errors.As(err, &dot.ErrAuditLogDisabled{}))
+}
+
+func TestConfig_Validate_AuditLog(t *testing.T) {
+	cfg := dot.Config{
+		PackageDir: "/test/packages",
+		TargetDir:  "/test/target",
+		AuditLog:   "relative/audit.jsonl",
+		FS:         adapters.NewMemFS(),
+		Logger:     adapters.NewNoopLogger(),
+	}
+	assert.ErrorContains(t, cfg.Validate(), "auditLog must be absolute path")
+}
@@ -0,0 +1,350 @@
+package dot
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// chezmoiAttributes are the attributes chezmoi encodes in the name of a
+// source state entry.
+type chezmoiAttributes struct {
+	// name is the name of the entry in the target directory.
+	name string
+
+	// kind is the type prefix of the entry: "create", "modify", "remove",
+	// "run", "symlink" or "external", or empty for a regular file or
+	// directory.
+	kind string
+
+	encrypted  bool
+	private    bool
+	readonly   bool
+	executable bool
+	empty      bool
+	template   bool
+}
+
+// parseChezmoiName parses the attribute prefixes and suffixes of the
+// source state entry name.
+func parseChezmoiName(name string) chezmoiAttributes {
+	var attrs chezmoiAttributes
+	rest := name
+prefixes:
+	for {
+		attr, after, ok := strings.Cut(rest, "_")
+		if !ok {
+			break
+		}
+		switch attr {
+		case "literal":
+			rest = after
+			break prefixes
+		case "dot":
+			rest = "." + after
+			break prefixes
+		case "create", "modify", "remove", "run", "symlink", "external":
+			attrs.kind = attr
+		case "once", "onchange", "before", "after":
+			// When a script runs does not matter; scripts are not imported
+		case "exact":
+			// dot never removes files missing from a package
+		case "encrypted":
+			attrs.encrypted = true
+		case "private":
+			attrs.private = true
+		case "readonly":
+			attrs.readonly = true
+		case "executable":
+			attrs.executable = true
+		case "empty":
+			attrs.empty = true
+		default:
+			break prefixes
+		}
+		rest = after
+	}
+
+	if attrs.encrypted {
+		for _, ext := range []string{".age", ".asc"} {
+			if trimmed, ok := strings.CutSuffix(rest, ext); ok {
+				rest = trimmed
+				break
+			}
+		}
+	}
+	if trimmed, ok := strings.CutSuffix(rest, ".literal"); ok {
+		rest = trimmed
+	} else if trimmed, ok := strings.CutSuffix(rest, ".tmpl"); ok {
+		rest = trimmed
+		attrs.template = true
+	}
+	attrs.name = rest
+	return attrs
+}
+
+// mode returns the permissions of a file with the attributes.
+func (a chezmoiAttributes) mode() os.FileMode {
+	mode := os.FileMode(0644)
+	if a.executable {
+		mode = 0755
+	}
+	if a.private {
+		mode &^= 0077
+	}
+	if a.readonly {
+		mode &^= 0222
+	}
+	return mode
+}
+
+// unsupported returns why a file with the attributes cannot be imported,
+// or an empty string if it can.
+func (a chezmoiAttributes) unsupported() string {
+	switch {
+	case a.kind == "run":
+		return "chezmoi scripts are not imported"
+	case a.kind == "modify":
+		return "modify_ scripts are not imported"
+	case a.kind == "remove":
+		return "remove_ entries are not imported"
+	case a.kind == "symlink":
+		return "symlink_ entries are not imported"
+	case a.encrypted:
+		return "encrypted files are not imported"
+	case a.template:
+		return "chezmoi templates must be converted to dot templates by hand"
+	}
+	return ""
+}
+
+// ImportChezmoi converts the chezmoi source directory sourceDir into
+// packages of the package directory. The attributes in the names of
+// source files are applied: dot_ becomes a leading dot, private_,
+// readonly_ and executable_ set the permissions of the package file, and
+// the other prefixes are dropped. Without package name mapping each
+// top-level entry of the target directory becomes a package named for it
+// without its leading dot, so dot_vimrc goes into vimrc; with package name
+// mapping each top-level directory becomes a package and files at the root
+// of the target directory are skipped. A .chezmoiroot file is followed.
+//
+// Scripts, modify_, remove_ and symlink_ entries, encrypted files,
+// externals and templates are reported and left out, as are files listed
+// in .chezmoiignore.
+//
+// Files chezmoi has written to the target directory are replaced with
+// links when their content matches the source. Files that differ are
+// skipped so changes not yet added to chezmoi are kept. sourceDir is left
+// unchanged. In dry-run mode the report is returned without changing
+// anything.
+func (s *ImportService) ImportChezmoi(ctx context.Context, sourceDir string) (ImportReport, error) {
+	sourceDir, err := s.importDir(ctx, sourceDir)
+	if err != nil {
+		return ImportReport{}, err
+	}
+	s.logger.Info(ctx, "import_started", "from", "chezmoi", "dir", sourceDir)
+
+	root := sourceDir
+	if data, err := s.fs.ReadFile(ctx, filepath.Join(sourceDir, ".chezmoiroot")); err == nil {
+		root = filepath.Join(sourceDir, strings.TrimSpace(string(data)))
+	}
+	ignore, err := s.chezmoiIgnore(ctx, root)
+	if err != nil {
+		return ImportReport{}, err
+	}
+
+	report := newImportReport("chezmoi")
+	files, err := s.chezmoiFiles(ctx, sourceDir, root, ignore, &report)
+	if err != nil {
+		return ImportReport{}, err
+	}
+	files = claimTargets(sourceDir, files, &report)
+
+	kept := make([]importFile, 0, len(files))
+	var replaced []string
+	for _, file := range files {
+		replace, reason, err := s.chezmoiTarget(ctx, file)
+		if err != nil {
+			return ImportReport{}, err
+		}
+		if reason != "" {
+			report.Skipped = append(report.Skipped, ImportSkip{Path: relativeTo(sourceDir, file.source), Reason: reason})
+			continue
+		}
+		if replace {
+			replaced = append(replaced, file.target)
+		}
+		kept = append(kept, file)
+	}
+	return s.importFiles(ctx, kept, replaced, report)
+}
+
+// chezmoiFiles lists the files of the chezmoi source state below root and
+// where they go in the package directory. Files that cannot be imported
+// are added to the report with their path relative to sourceDir.
+func (s *ImportService) chezmoiFiles(ctx context.Context, sourceDir, root string, ignore []string, report *ImportReport) ([]importFile, error) {
+	var files []importFile
+	var walk func(dir, targetRel, from string) error
+	walk = func(dir, targetRel, from string) error {
+		entries, err := s.fs.ReadDir(ctx, dir)
+		if err != nil {
+			return fmt.Errorf("read %s: %w", dir, err)
+		}
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
+			skip := func(reason string) {
+				report.Skipped = append(report.Skipped, ImportSkip{Path: relativeTo(sourceDir, path), Reason: reason})
+			}
+
+			switch name := entry.Name(); {
+			case name == ".chezmoiscripts":
+				skip("chezmoi scripts are not imported")
+				continue
+			case strings.HasPrefix(name, ".chezmoiexternal"):
+				skip("chezmoi externals are not imported")
+				continue
+			case strings.HasPrefix(name, "."):
+				// chezmoi ignores other names with a leading dot, such as
+				// .git and its own configuration
+				continue
+			}
+			if entry.Type()&os.ModeSymlink != 0 {
+				skip("symbolic link")
+				continue
+			}
+
+			attrs := parseChezmoiName(entry.Name())
+			rel := filepath.Join(targetRel, attrs.name)
+			entryFrom := from
+			if entryFrom == "" {
+				entryFrom = entry.Name()
+			}
+			if chezmoiIgnores(ignore, rel) {
+				skip("listed in .chezmoiignore")
+				continue
+			}
+
+			if entry.IsDir() {
+				switch attrs.kind {
+				case "remove":
+					skip("remove_ entries are not imported")
+				case "external":
+					skip("chezmoi externals are not imported")
+				default:
+					if err := walk(path, rel, entryFrom); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+
+			if reason := attrs.unsupported(); reason != "" {
+				skip(reason)
+				continue
+			}
+			if !attrs.empty {
+				info, err := s.fs.Stat(ctx, path)
+				if err != nil {
+					return fmt.Errorf("stat %s: %w", path, err)
+				}
+				if info.Size() == 0 {
+					skip("chezmoi removes empty files without the empty_ attribute")
+					continue
+				}
+			}
+			pkg, pkgPath, ok := s.place(homePackage(rel), rel)
+			if !ok {
+				skip(errRootFile)
+				continue
+			}
+			files = append(files, importFile{
+				source:  path,
+				from:    entryFrom,
+				pkg:     pkg,
+				pkgPath: pkgPath,
+				target:  filepath.Join(s.targetDir, rel),
+				mode:    attrs.mode(),
+			})
+		}
+		return nil
+	}
+	if err := walk(root, "", ""); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// chezmoiTarget reports whether the target of file holds the file as
+// chezmoi wrote it and must be replaced with a link, or the reason the
+// file cannot be imported.
+func (s *ImportService) chezmoiTarget(ctx context.Context, file importFile) (bool, string, error) {
+	if isLink, err := s.fs.IsSymlink(ctx, file.target); err == nil && isLink {
+		return false, "a symbolic link is in the way in the target directory", nil
+	}
+	if !s.fs.Exists(ctx, file.target) {
+		return false, "", nil
+	}
+	if isDir, err := s.fs.IsDir(ctx, file.target); err == nil && isDir {
+		return false, "a directory is in the way in the target directory", nil
+	}
+
+	want, err := s.fs.ReadFile(ctx, file.source)
+	if err != nil {
+		return false, "", fmt.Errorf("read %s: %w", file.source, err)
+	}
+	got, err := s.fs.ReadFile(ctx, file.target)
+	if err != nil {
+		return false, "", fmt.Errorf("read %s: %w", file.target, err)
+	}
+	if !bytes.Equal(want, got) {
+		return false, "differs from the target directory; run chezmoi apply or chezmoi re-add first", nil
+	}
+	return true, "", nil
+}
+
+// chezmoiIgnore reads the patterns of the .chezmoiignore file in root.
+// Lines with template actions depend on chezmoi's data and are left out,
+// as are negated patterns.
+func (s *ImportService) chezmoiIgnore(ctx context.Context, root string) ([]string, error) {
+	path := filepath.Join(root, ".chezmoiignore")
+	if !s.fs.Exists(ctx, path) {
+		return nil, nil
+	}
+	data, err := s.fs.ReadFile(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+
+	var patterns []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") || strings.Contains(line, "{{") {
+			continue
+		}
+		patterns = append(patterns, filepath.FromSlash(line))
+	}
+	return patterns, nil
+}
+
+// chezmoiIgnores reports whether one of patterns matches rel or one of its
+// parent directories. A pattern starting with **/ matches at any depth.
+func chezmoiIgnores(patterns []string, rel string) bool {
+	parts := strings.Split(rel, string(filepath.Separator))
+	for _, pattern := range patterns {
+		anyDepth := strings.TrimPrefix(pattern, "**"+string(filepath.Separator))
+		for end := 1; end <= len(parts); end++ {
+			for start := 0; start < end; start++ {
+				if start > 0 && anyDepth == pattern {
+					break
+				}
+				if ok, _ := filepath.Match(anyDepth, filepath.Join(parts[start:end]...)); ok {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,229 @@
+package dot
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+)
+
+func TestParseChezmoiName(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   chezmoiAttributes
+	}{
+		{name: "dot", source: "dot_vimrc", want: chezmoiAttributes{name: ".vimrc"}},
+		{name: "plain", source: "README.md", want: chezmoiAttributes{name: "README.md"}},
+		{name: "private", source: "private_dot_ssh", want: chezmoiAttributes{name: ".ssh", private: true}},
+		{name: "executable", source: "executable_hello", want: chezmoiAttributes{name: "hello", executable: true}},
+		{name: "underscore in name", source: "my_notes", want: chezmoiAttributes{name: "my_notes"}},
+		{name: "literal", source: "literal_dot_keep", want: chezmoiAttributes{name: "dot_keep"}},
+		{name: "dot stops prefixes", source: "dot_private_x", want: chezmoiAttributes{name: ".private_x"}},
+		{name: "template", source: "dot_gitconfig.tmpl", want: chezmoiAttributes{name: ".gitconfig", template: true}},
+		{name: "literal suffix", source: "notes.tmpl.literal", want: chezmoiAttributes{name: "notes.tmpl"}},
+		{name: "encrypted", source: "encrypted_private_dot_netrc.age", want: chezmoiAttributes{name: ".netrc", encrypted: true, private: true}},
+		{name: "script", source: "run_once_before_install.sh", want: chezmoiAttributes{name: "install.sh", kind: "run"}},
+		{name: "exact", source: "exact_dot_vim", want: chezmoiAttributes{name: ".vim"}},
+		{name: "empty readonly", source: "empty_readonly_dot_hushlogin", want: chezmoiAttributes{name: ".hushlogin", empty: true, readonly: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseChezmoiName(tt.source))
+		})
+	}
+}
+
+func TestChezmoiAttributes_Mode(t *testing.T) {
+	assert.Equal(t, os.FileMode(0644), chezmoiAttributes{}.mode())
+	assert.Equal(t, os.FileMode(0755), chezmoiAttributes{executable: true}.mode())
+	assert.Equal(t, os.FileMode(0600), chezmoiAttributes{private: true}.mode())
+	assert.Equal(t, os.FileMode(0500), chezmoiAttributes{private: true, readonly: true, executable: true}.mode())
+}
+
+// setupChezmoiDir creates a chezmoi source directory at /test/chezmoi that
+// has been applied to /test/target, with .zshrc changed since.
+func setupChezmoiDir(t *testing.T, mapping, dryRun bool) (*adapters.MemFS, *Client) {
+	t.Helper()
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+
+	files := map[string]string{
+		"/test/chezmoi/dot_vimrc":                      "set nocompatible",
+		"/test/chezmoi/dot_zshrc":                      "export EDITOR=vim",
+		"/test/chezmoi/dot_config/nvim/init.lua":       "vim.o.number = true",
+		"/test/chezmoi/private_dot_ssh/private_config": "Host *",
+		"/test/chezmoi/dot_local/bin/executable_hello": "#!/bin/sh",
+		"/test/chezmoi/dot_gitconfig.tmpl":             "[user]\n  email = {{ .email }}",
+		"/test/chezmoi/run_once_install.sh":            "#!/bin/sh",
+		"/test/chezmoi/README.md":                      "my dotfiles",
+		"/test/chezmoi/dot_empty":                      "",
+		"/test/chezmoi/empty_dot_hushlogin":            "",
+		"/test/chezmoi/.chezmoiignore":                 "# notes\nREADME.md\n{{ if ne .chezmoi.os \"darwin\" }}\n{{ end }}\n",
+		"/test/chezmoi/.git/HEAD":                      "ref: refs/heads/main",
+		"/test/target/.vimrc":                          "set nocompatible",
+		"/test/target/.zshrc":                          "export EDITOR=nano",
+	}
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages", 0755))
+	for path, content := range files {
+		require.NoError(t, fs.MkdirAll(ctx, filepath.Dir(path), 0755))
+		require.NoError(t, fs.WriteFile(ctx, path, []byte(content), 0644))
+	}
+
+	client, err := NewClient(Config{
+		PackageDir:         "/test/packages",
+		TargetDir:          "/test/target",
+		PackageNameMapping: mapping,
+		DryRun:             dryRun,
+		FS:                 fs,
+		Logger:             adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+	return fs, client
+}
+
+// skipReasons maps the paths of skipped files to their reasons.
+func skipReasons(report ImportReport) map[string]string {
+	reasons := make(map[string]string, len(report.Skipped))
+	for _, skip := range report.Skipped {
+		reasons[skip.Path] = skip.Reason
+	}
+	return reasons
+}
+
+func TestClient_ImportChezmoi(t *testing.T) {
+	ctx := context.Background()
+	fs, client := setupChezmoiDir(t, false, false)
+
+	report, err := client.ImportChezmoi(ctx, "/test/chezmoi")
+	require.NoError(t, err)
+
+	assert.Equal(t, "chezmoi", report.From)
+	files := make(map[string][]string)
+	for _, pkg := range report.Packages {
+		files[pkg.Name] = pkg.Files
+	}
+	assert.Equal(t, map[string][]string{
+		"config":    {".config/nvim/init.lua"},
+		"hushlogin": {"dot-hushlogin"},
+		"local":     {".local/bin/hello"},
+		"ssh":       {".ssh/config"},
+		"vimrc":     {"dot-vimrc"},
+	}, files)
+	assert.Equal(t, []string{".vimrc"}, report.Replaced)
+
+	reasons := skipReasons(report)
+	assert.Len(t, reasons, 5)
+	assert.Contains(t, reasons["dot_gitconfig.tmpl"], "templates")
+	assert.Contains(t, reasons["run_once_install.sh"], "scripts")
+	assert.Contains(t, reasons["README.md"], ".chezmoiignore")
+	assert.Contains(t, reasons["dot_empty"], "empty_")
+	assert.Contains(t, reasons["dot_zshrc"], "differs")
+
+	// Attributes become permissions
+	info, err := fs.Stat(ctx, "/test/packages/ssh/.ssh/config")
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	info, err = fs.Stat(ctx, "/test/packages/local/.local/bin/hello")
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+
+	// The file chezmoi wrote is replaced with a link; the changed one is kept
+	link, err := fs.ReadLink(ctx, "/test/target/.vimrc")
+	require.NoError(t, err)
+	assert.Contains(t, link, "packages/vimrc/dot-vimrc")
+	isLink, err := fs.IsSymlink(ctx, "/test/target/.zshrc")
+	require.NoError(t, err)
+	assert.False(t, isLink)
+	assert.True(t, fs.Exists(ctx, "/test/chezmoi/dot_vimrc"))
+}
+
+func TestClient_ImportChezmoi_PackageNameMapping(t *testing.T) {
+	ctx := context.Background()
+	_, client := setupChezmoiDir(t, true, true)
+
+	report, err := client.ImportChezmoi(ctx, "/test/chezmoi")
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(report.Packages))
+	for _, pkg := range report.Packages {
+		names = append(names, pkg.Name)
+	}
+	assert.Equal(t, []string{"dot-config", "dot-local", "dot-ssh"}, names)
+	assert.Equal(t, []string{"dot_config"}, report.Packages[0].From)
+	assert.Equal(t, []string{"config"}, report.Packages[2].Files)
+
+	reasons := skipReasons(report)
+	assert.Equal(t, errRootFile, reasons["dot_vimrc"])
+	assert.Equal(t, errRootFile, reasons["empty_dot_hushlogin"])
+	assert.Empty(t, report.Replaced)
+}
+
+func TestClient_ImportChezmoi_Root(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/chezmoi/home", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/chezmoi/.chezmoiroot", []byte("home\n"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/test/chezmoi/install.sh", []byte("#!/bin/sh"), 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/chezmoi/home/dot_bashrc", []byte("set -o vi"), 0644))
+
+	client, err := NewClient(Config{
+		PackageDir: "/test/packages",
+		TargetDir:  "/test/target",
+		DryRun:     true,
+		FS:         fs,
+		Logger:     adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+
+	report, err := client.ImportChezmoi(ctx, "/test/chezmoi")
+	require.NoError(t, err)
+	require.Len(t, report.Packages, 1)
+	assert.Equal(t, "bashrc", report.Packages[0].Name)
+	assert.Equal(t, []string{"dot-bashrc"}, report.Packages[0].Files)
+	assert.Empty(t, report.Skipped)
+}
+
+// failLinkFS is a filesystem that fails to create symbolic links.
+type failLinkFS struct {
+	*adapters.MemFS
+}
+
+func (failLinkFS) Symlink(ctx context.Context, oldname, newname string) error {
+	return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: os.ErrPermission}
+}
+
+func TestClient_ImportChezmoi_ManageFailureRestoresTarget(t *testing.T) {
+	ctx := context.Background()
+	fs, _ := setupChezmoiDir(t, false, false)
+	require.NoError(t, fs.Chmod(ctx, "/test/target/.vimrc", 0640))
+	client, err := NewClient(Config{
+		PackageDir: "/test/packages",
+		TargetDir:  "/test/target",
+		FS:         failLinkFS{MemFS: fs},
+		Logger:     adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+
+	_, err = client.ImportChezmoi(ctx, "/test/chezmoi")
+	require.ErrorIs(t, err, os.ErrPermission)
+
+	// The file chezmoi wrote is back as it was and no package is left
+	data, err := fs.ReadFile(ctx, "/test/target/.vimrc")
+	require.NoError(t, err)
+	assert.Equal(t, "set nocompatible", string(data))
+	info, err := fs.Stat(ctx, "/test/target/.vimrc")
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+	entries, err := fs.ReadDir(ctx, "/test/packages")
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
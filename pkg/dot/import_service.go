@@ -0,0 +1,484 @@
+package dot
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/internal/scanner"
+)
+
+// stowIgnored lists the names GNU Stow leaves out of packages by default.
+var stowIgnored = []string{
+	".stow-local-ignore", ".git", ".gitignore", ".gitmodules", ".hg", ".svn",
+	"CVS", "RCS", "README*", "LICENSE*", "COPYING",
+}
+
+// ImportService converts packages of other dotfile managers into packages
+// of the package directory.
+type ImportService struct {
+	fs                 FS
+	logger             Logger
+	manageSvc          *ManageService
+	packageDir         string
+	targetDir          string
+	packageNameMapping bool
+	dryRun             bool
+
+	// tree lists the files of yadm repositories. Importing from yadm is
+	// not supported when nil.
+	tree adapters.GitTree
+}
+
+// newImportService creates a new import service.
+func newImportService(
+	fs FS,
+	logger Logger,
+	manageSvc *ManageService,
+	packageDir string,
+	targetDir string,
+	packageNameMapping bool,
+	dryRun bool,
+) *ImportService {
+	return &ImportService{
+		fs:                 fs,
+		logger:             logger,
+		manageSvc:          manageSvc,
+		packageDir:         packageDir,
+		targetDir:          targetDir,
+		packageNameMapping: packageNameMapping,
+		dryRun:             dryRun,
+	}
+}
+
+// ImportReport describes the result of an import.
+type ImportReport struct {
+	// From names the previous manager: "stow", "chezmoi" or "yadm".
+	From string `json:"from"`
+
+	// Packages lists the created packages, sorted by name.
+	Packages []ImportedPackage `json:"packages"`
+
+	// Replaced lists the links and files of the previous manager in the
+	// target directory that were replaced with links, relative to the
+	// target directory.
+	Replaced []string `json:"replaced"`
+
+	// Skipped lists files that were not imported.
+	Skipped []ImportSkip `json:"skipped"`
+}
+
+// ImportedPackage describes a package created by an import.
+type ImportedPackage struct {
+	// Name is the package name.
+	Name string `json:"name"`
+
+	// From lists the packages of the previous manager it was built from,
+	// or the top-level entries of the imported directory for managers
+	// without packages.
+	From []string `json:"from"`
+
+	// Files lists the package files, relative to the package directory.
+	Files []string `json:"files"`
+}
+
+// ImportSkip describes a file left out of an import.
+type ImportSkip struct {
+	// Path is the file's path relative to the imported directory.
+	Path string `json:"path"`
+
+	// Reason explains why the file was not imported.
+	Reason string `json:"reason"`
+}
+
+// importFile is a file to copy into a package.
+type importFile struct {
+	source  string
+	from    string
+	pkg     string
+	pkgPath string
+	target  string
+
+	// mode is the permission of the package file, or zero to keep the
+	// permission of source.
+	mode os.FileMode
+}
+
+// errRootFile is the reason a file at the root of the target directory is
+// not imported with package name mapping.
+const errRootFile = "files at the root of the target directory need package_name_mapping disabled"
+
+// ImportStow converts the GNU Stow directory stowDir into packages of the
+// package directory. Each file keeps its place in the target directory:
+// a leading dot of a file name is written as the dot- prefix and, with
+// package name mapping, each top-level directory of a Stow package becomes
+// a package of its own, so vim/.vim/colors becomes dot-vim/colors. Files at
+// the root of a Stow package cannot be placed with package name mapping
+// and are skipped.
+//
+// The files are copied; stowDir is left unchanged. Links in the target
+// directory that resolve into stowDir are removed and the new packages are
+// managed, which links them again and records them in the manifest. In
+// dry-run mode the report is returned without changing anything.
+func (s *ImportService) ImportStow(ctx context.Context, stowDir string) (ImportReport, error) {
+	stowDir, err := s.importDir(ctx, stowDir)
+	if err != nil {
+		return ImportReport{}, err
+	}
+	s.logger.Info(ctx, "import_started", "from", "stow", "dir", stowDir)
+
+	report := newImportReport("stow")
+	entries, err := s.fs.ReadDir(ctx, stowDir)
+	if err != nil {
+		return ImportReport{}, fmt.Errorf("read stow directory: %w", err)
+	}
+
+	var files []importFile
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		found, err := s.stowPackageFiles(ctx, stowDir, entry.Name(), &report)
+		if err != nil {
+			return ImportReport{}, err
+		}
+		files = append(files, found...)
+	}
+	files = claimTargets(stowDir, files, &report)
+
+	links, err := s.stowLinks(ctx, stowDir, files)
+	if err != nil {
+		return ImportReport{}, err
+	}
+	return s.importFiles(ctx, files, links, report)
+}
+
+// importDir checks that dir is an absolute path to a directory and returns
+// it cleaned.
+func (s *ImportService) importDir(ctx context.Context, dir string) (string, error) {
+	if !filepath.IsAbs(dir) {
+		return "", ErrInvalidPath{Path: dir, Reason: "must be an absolute path"}
+	}
+	dir = filepath.Clean(dir)
+	if isDir, err := s.fs.IsDir(ctx, dir); err != nil || !isDir {
+		return "", ErrSourceNotFound{Path: dir}
+	}
+	return dir, nil
+}
+
+// importFiles copies files into their packages, removes the replaced
+// paths of the target directory and manages the new packages. If any step
+// fails, the replaced paths are put back and the new packages deleted. In
+// dry-run mode the report is returned without changing anything.
+func (s *ImportService) importFiles(ctx context.Context, files []importFile, replaced []string, report ImportReport) (ImportReport, error) {
+	packages := make(map[string]*ImportedPackage)
+	for _, file := range files {
+		pkg, ok := packages[file.pkg]
+		if !ok {
+			dir := filepath.Join(s.packageDir, file.pkg)
+			if s.fs.Exists(ctx, dir) {
+				return ImportReport{}, ErrPackageExists{Package: file.pkg, Path: dir}
+			}
+			pkg = &ImportedPackage{Name: file.pkg}
+			packages[file.pkg] = pkg
+		}
+		if len(pkg.From) == 0 || pkg.From[len(pkg.From)-1] != file.from {
+			pkg.From = append(pkg.From, file.from)
+		}
+		pkg.Files = append(pkg.Files, file.pkgPath)
+	}
+	names := make([]string, 0, len(packages))
+	for name := range packages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		report.Packages = append(report.Packages, *packages[name])
+	}
+	for _, path := range replaced {
+		report.Replaced = append(report.Replaced, relativeTo(s.targetDir, path))
+	}
+
+	if len(files) == 0 {
+		s.logger.Info(ctx, "nothing_to_import")
+		return report, nil
+	}
+	if s.dryRun {
+		s.logger.Info(ctx, "dry_run_import", "packages", len(report.Packages), "replaced", len(report.Replaced))
+		return report, nil
+	}
+
+	// Until the packages are managed, a failure puts back what was removed
+	// and deletes the new packages, leaving the previous manager in charge
+	var removed []removedPath
+	for _, file := range files {
+		if err := s.copyFile(ctx, file.source, filepath.Join(s.packageDir, file.pkg, file.pkgPath), file.mode); err != nil {
+			return ImportReport{}, s.abortImport(ctx, names, removed, err)
+		}
+	}
+	for _, path := range replaced {
+		saved, err := s.saveRemoved(ctx, path)
+		if err != nil {
+			return ImportReport{}, s.abortImport(ctx, names, removed, err)
+		}
+		if err := s.fs.Remove(ctx, path); err != nil {
+			return ImportReport{}, s.abortImport(ctx, names, removed, fmt.Errorf("remove %s: %w", path, err))
+		}
+		removed = append(removed, saved)
+	}
+	if err := s.manageSvc.Manage(ctx, names...); err != nil {
+		return ImportReport{}, s.abortImport(ctx, names, removed, fmt.Errorf("manage imported packages: %w", err))
+	}
+
+	s.logger.Info(ctx, "import_completed", "from", report.From, "packages", len(report.Packages), "replaced", len(report.Replaced))
+	return report, nil
+}
+
+// removedPath is a link or file of the target directory removed by an
+// import, saved so that it can be put back.
+type removedPath struct {
+	path string
+
+	// link is the destination of a link, or empty for a file.
+	link string
+
+	data []byte
+	mode os.FileMode
+}
+
+// saveRemoved saves the link or file at path before it is removed.
+func (s *ImportService) saveRemoved(ctx context.Context, path string) (removedPath, error) {
+	if isLink, err := s.fs.IsSymlink(ctx, path); err == nil && isLink {
+		dest, err := s.fs.ReadLink(ctx, path)
+		if err != nil {
+			return removedPath{}, fmt.Errorf("read link %s: %w", path, err)
+		}
+		return removedPath{path: path, link: dest}, nil
+	}
+	info, err := s.fs.Stat(ctx, path)
+	if err != nil {
+		return removedPath{}, fmt.Errorf("stat %s: %w", path, err)
+	}
+	data, err := s.fs.ReadFile(ctx, path)
+	if err != nil {
+		return removedPath{}, fmt.Errorf("read %s: %w", path, err)
+	}
+	return removedPath{path: path, data: data, mode: info.Mode().Perm()}, nil
+}
+
+// abortImport puts back the removed paths of the target directory that
+// are still free and deletes the created packages of names, returning
+// cause along with anything that could not be undone.
+func (s *ImportService) abortImport(ctx context.Context, names []string, removed []removedPath, cause error) error {
+	errs := []error{cause}
+	for i := len(removed) - 1; i >= 0; i-- {
+		r := removed[i]
+		var err error
+		switch {
+		case s.fs.Exists(ctx, r.path):
+			err = fmt.Errorf("restore %s: path is occupied", r.path)
+		case r.link != "":
+			err = s.fs.Symlink(ctx, r.link, r.path)
+		default:
+			err = s.fs.WriteFile(ctx, r.path, r.data, r.mode)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, name := range names {
+		if err := s.fs.RemoveAll(ctx, filepath.Join(s.packageDir, name)); err != nil {
+			errs = append(errs, fmt.Errorf("remove package %s: %w", name, err))
+		}
+	}
+
+	s.logger.Warn(ctx, "import_aborted", "packages", len(names), "removed", len(removed), "error", cause)
+	if len(errs) == 1 {
+		return cause
+	}
+	return ErrMultiple{Errors: errs}
+}
+
+// newImportReport creates an empty report of an import from manager.
+func newImportReport(manager string) ImportReport {
+	return ImportReport{From: manager, Packages: []ImportedPackage{}, Replaced: []string{}, Skipped: []ImportSkip{}}
+}
+
+// claimTargets drops the files whose target is provided by an earlier
+// file, adding them to the report with their path relative to dir.
+func claimTargets(dir string, files []importFile, report *ImportReport) []importFile {
+	claimed := make(map[string]bool)
+	kept := make([]importFile, 0, len(files))
+	for _, file := range files {
+		if claimed[file.target] {
+			report.Skipped = append(report.Skipped, ImportSkip{
+				Path:   relativeTo(dir, file.source),
+				Reason: "provided by another package",
+			})
+			continue
+		}
+		claimed[file.target] = true
+		kept = append(kept, file)
+	}
+	return kept
+}
+
+// place returns the package and package path of the file at rel, relative
+// to the target directory. Without package name mapping the file goes into
+// pkg at its path in the target directory. With package name mapping the
+// top-level directory of rel becomes the package; ok is false for files at
+// the root of the target directory, which cannot be placed.
+func (s *ImportService) place(pkg, rel string) (string, string, bool) {
+	if !s.packageNameMapping {
+		return pkg, scanner.UntranslatePath(rel), true
+	}
+	top, rest, nested := strings.Cut(rel, string(filepath.Separator))
+	if !nested {
+		return "", "", false
+	}
+	return scanner.UntranslateDotfile(top), scanner.UntranslatePath(rest), true
+}
+
+// homePackage returns the package, without package name mapping, of the
+// file at rel in a previous manager that keeps the whole target directory
+// in one tree: its top-level entry without a leading dot, so .vimrc goes
+// into vimrc and .config/nvim/init.lua into config.
+func homePackage(rel string) string {
+	top, _, _ := strings.Cut(rel, string(filepath.Separator))
+	if name := strings.TrimPrefix(top, "."); name != "" {
+		return name
+	}
+	return top
+}
+
+// stowPackageFiles lists the files of the Stow package name and where they
+// go in the package directory. Files that cannot be imported are added to
+// the report.
+func (s *ImportService) stowPackageFiles(ctx context.Context, stowDir, name string, report *ImportReport) ([]importFile, error) {
+	var files []importFile
+	var walk func(dir string) error
+	walk = func(dir string) error {
+		entries, err := s.fs.ReadDir(ctx, dir)
+		if err != nil {
+			return fmt.Errorf("read %s: %w", dir, err)
+		}
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
+			if stowIgnores(entry.Name()) {
+				continue
+			}
+			if entry.Type()&os.ModeSymlink != 0 {
+				report.Skipped = append(report.Skipped, ImportSkip{Path: relativeTo(stowDir, path), Reason: "symbolic link"})
+				continue
+			}
+			if entry.IsDir() {
+				if err := walk(path); err != nil {
+					return err
+				}
+				continue
+			}
+
+			rel := relativeTo(filepath.Join(stowDir, name), path)
+			pkg, pkgPath, ok := s.place(name, rel)
+			if !ok {
+				report.Skipped = append(report.Skipped, ImportSkip{Path: relativeTo(stowDir, path), Reason: errRootFile})
+				continue
+			}
+			files = append(files, importFile{
+				source:  path,
+				from:    name,
+				pkg:     pkg,
+				pkgPath: pkgPath,
+				target:  filepath.Join(s.targetDir, rel),
+			})
+		}
+		return nil
+	}
+	if err := walk(filepath.Join(stowDir, name)); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// stowLinks returns the links in the target directory that resolve into
+// stowDir and provide one of files, either directly or as a folded
+// directory.
+func (s *ImportService) stowLinks(ctx context.Context, stowDir string, files []importFile) ([]string, error) {
+	seen := make(map[string]bool)
+	var links []string
+	for _, file := range files {
+		rel := relativeTo(s.targetDir, file.target)
+		path := s.targetDir
+		for _, part := range strings.Split(rel, string(filepath.Separator)) {
+			path = filepath.Join(path, part)
+			isLink, err := s.fs.IsSymlink(ctx, path)
+			if err != nil || !isLink {
+				if !s.fs.Exists(ctx, path) {
+					break
+				}
+				continue
+			}
+			dest, err := s.fs.ReadLink(ctx, path)
+			if err != nil {
+				return nil, fmt.Errorf("read link %s: %w", path, err)
+			}
+			if !filepath.IsAbs(dest) {
+				dest = filepath.Join(filepath.Dir(path), dest)
+			}
+			if isWithin(filepath.Clean(dest), stowDir) && !seen[path] {
+				seen[path] = true
+				links = append(links, path)
+			}
+			break
+		}
+	}
+	sort.Strings(links)
+	return links, nil
+}
+
+// copyFile copies source to dest with mode, or with the permissions of
+// source when mode is zero, creating the parent directories of dest.
+func (s *ImportService) copyFile(ctx context.Context, source, dest string, mode os.FileMode) error {
+	if mode == 0 {
+		info, err := s.fs.Stat(ctx, source)
+		if err != nil {
+			return fmt.Errorf("stat %s: %w", source, err)
+		}
+		mode = info.Mode().Perm()
+	}
+	data, err := s.fs.ReadFile(ctx, source)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", source, err)
+	}
+	if err := s.fs.MkdirAll(ctx, filepath.Dir(dest), 0755); err != nil {
+		return fmt.Errorf("create %s: %w", filepath.Dir(dest), err)
+	}
+	if err := s.fs.WriteFile(ctx, dest, data, mode); err != nil {
+		return fmt.Errorf("write %s: %w", dest, err)
+	}
+	return nil
+}
+
+// stowIgnores reports whether GNU Stow leaves name out of packages.
+func stowIgnores(name string) bool {
+	for _, pattern := range stowIgnored {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// relativeTo returns path relative to base, or path itself when it is not
+// below base.
+func relativeTo(base, path string) string {
+	rel, err := filepath.Rel(base, path)
+	if err != nil {
+		return path
+	}
+	return rel
+}
@@ -0,0 +1,188 @@
+package dot_test
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// setupStowDir creates a Stow directory at /test/stow whose packages are
+// stowed into /test/target, with the nvim directory folded.
+func setupStowDir(t *testing.T, mapping, dryRun bool) (*adapters.MemFS, *dot.Client) {
+	t.Helper()
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target/.config", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/stow/vim/.vim/colors", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/stow/nvim/.config/nvim", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/stow/vim/.vimrc", []byte("set nocompatible"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/test/stow/vim/.vim/colors/dark.vim", []byte("hi Normal"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/test/stow/vim/README.md", []byte("notes"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/test/stow/nvim/.config/nvim/init.lua", []byte("vim.o.number = true"), 0644))
+
+	require.NoError(t, fs.Symlink(ctx, "../stow/vim/.vimrc", "/test/target/.vimrc"))
+	require.NoError(t, fs.Symlink(ctx, "../stow/vim/.vim", "/test/target/.vim"))
+	require.NoError(t, fs.Symlink(ctx, "../../stow/nvim/.config/nvim", "/test/target/.config/nvim"))
+
+	client, err := dot.NewClient(dot.Config{
+		PackageDir:         "/test/packages",
+		TargetDir:          "/test/target",
+		PackageNameMapping: mapping,
+		DryRun:             dryRun,
+		FS:                 fs,
+		Logger:             adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+	return fs, client
+}
+
+func TestClient_ImportStow(t *testing.T) {
+	ctx := context.Background()
+	fs, client := setupStowDir(t, false, false)
+
+	report, err := client.ImportStow(ctx, "/test/stow")
+	require.NoError(t, err)
+
+	require.Len(t, report.Packages, 2)
+	assert.Equal(t, "nvim", report.Packages[0].Name)
+	assert.Equal(t, []string{".config/nvim/init.lua"}, report.Packages[0].Files)
+	assert.Equal(t, "vim", report.Packages[1].Name)
+	assert.ElementsMatch(t, []string{"dot-vimrc", ".vim/colors/dark.vim"}, report.Packages[1].Files)
+	assert.Equal(t, []string{".config/nvim", ".vim", ".vimrc"}, report.Replaced)
+	assert.Empty(t, report.Skipped)
+
+	data, err := fs.ReadFile(ctx, "/test/packages/vim/dot-vimrc")
+	require.NoError(t, err)
+	assert.Equal(t, "set nocompatible", string(data))
+	assert.False(t, fs.Exists(ctx, "/test/packages/vim/README.md"))
+
+	// Links now resolve into the package directory
+	link, err := fs.ReadLink(ctx, "/test/target/.vimrc")
+	require.NoError(t, err)
+	assert.Contains(t, link, "packages/vim/dot-vimrc")
+	link, err = fs.ReadLink(ctx, "/test/target/.config/nvim/init.lua")
+	require.NoError(t, err)
+	assert.Equal(t, "/test/packages/nvim/.config/nvim/init.lua", link)
+
+	status, err := client.Status(ctx, "vim", "nvim")
+	require.NoError(t, err)
+	assert.Len(t, status.Packages, 2)
+
+	// The Stow directory is left in place
+	assert.True(t, fs.Exists(ctx, "/test/stow/vim/.vimrc"))
+}
+
+func TestClient_ImportStow_PackageNameMapping(t *testing.T) {
+	ctx := context.Background()
+	fs, client := setupStowDir(t, true, false)
+
+	report, err := client.ImportStow(ctx, "/test/stow")
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(report.Packages))
+	for _, pkg := range report.Packages {
+		names = append(names, pkg.Name)
+	}
+	assert.Equal(t, []string{"dot-config", "dot-vim"}, names)
+	assert.Equal(t, []string{"nvim/init.lua"}, report.Packages[0].Files)
+	assert.Equal(t, []string{"nvim"}, report.Packages[0].From)
+	assert.Equal(t, []string{"colors/dark.vim"}, report.Packages[1].Files)
+
+	// Files at the root of a Stow package have no package to go to
+	require.Len(t, report.Skipped, 1)
+	assert.Equal(t, "vim/.vimrc", report.Skipped[0].Path)
+	assert.Equal(t, []string{".config/nvim", ".vim"}, report.Replaced)
+
+	link, err := fs.ReadLink(ctx, "/test/target/.vim/colors/dark.vim")
+	require.NoError(t, err)
+	assert.Equal(t, "/test/packages/dot-vim/colors/dark.vim", link)
+	assert.True(t, fs.Exists(ctx, "/test/packages/dot-config/nvim/init.lua"))
+
+	// The skipped file keeps its Stow link
+	link, err = fs.ReadLink(ctx, "/test/target/.vimrc")
+	require.NoError(t, err)
+	assert.Equal(t, "../stow/vim/.vimrc", link)
+}
+
+func TestClient_ImportStow_DryRun(t *testing.T) {
+	ctx := context.Background()
+	fs, client := setupStowDir(t, false, true)
+
+	report, err := client.ImportStow(ctx, "/test/stow")
+	require.NoError(t, err)
+	assert.Len(t, report.Packages, 2)
+	assert.Len(t, report.Replaced, 3)
+
+	assert.False(t, fs.Exists(ctx, "/test/packages/vim"))
+	link, err := fs.ReadLink(ctx, "/test/target/.vimrc")
+	require.NoError(t, err)
+	assert.Equal(t, "../stow/vim/.vimrc", link)
+}
+
+func TestClient_ImportStow_Errors(t *testing.T) {
+	ctx := context.Background()
+	fs, client := setupStowDir(t, false, false)
+
+	_, err := client.ImportStow(ctx, "stow")
+	var invalid dot.ErrInvalidPath
+	assert.ErrorAs(t, err, &invalid)
+
+	_, err = client.ImportStow(ctx, "/test/missing")
+	var notFound dot.ErrSourceNotFound
+	assert.ErrorAs(t, err, &notFound)
+
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/vim", 0755))
+	_, err = client.ImportStow(ctx, "/test/stow")
+	var exists dot.ErrPackageExists
+	assert.ErrorAs(t, err, &exists)
+	assert.Equal(t, "vim", exists.Package)
+}
+
+// failPackageLinkFS is a filesystem that fails to link package files.
+type failPackageLinkFS struct {
+	*adapters.MemFS
+}
+
+func (f failPackageLinkFS) Symlink(ctx context.Context, oldname, newname string) error {
+	if strings.Contains(oldname, "packages/") {
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: os.ErrPermission}
+	}
+	return f.MemFS.Symlink(ctx, oldname, newname)
+}
+
+func TestClient_ImportStow_ManageFailureRestoresTarget(t *testing.T) {
+	ctx := context.Background()
+	fs, _ := setupStowDir(t, false, false)
+	client, err := dot.NewClient(dot.Config{
+		PackageDir: "/test/packages",
+		TargetDir:  "/test/target",
+		FS:         failPackageLinkFS{MemFS: fs},
+		Logger:     adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+
+	_, err = client.ImportStow(ctx, "/test/stow")
+	require.ErrorContains(t, err, "manage imported packages")
+
+	// The Stow links are back and the copied packages are gone
+	for path, want := range map[string]string{
+		"/test/target/.vimrc":       "../stow/vim/.vimrc",
+		"/test/target/.vim":         "../stow/vim/.vim",
+		"/test/target/.config/nvim": "../../stow/nvim/.config/nvim",
+	} {
+		link, err := fs.ReadLink(ctx, path)
+		require.NoError(t, err, path)
+		assert.Equal(t, want, link)
+	}
+	assert.False(t, fs.Exists(ctx, "/test/packages/vim"))
+	assert.False(t, fs.Exists(ctx, "/test/packages/nvim"))
+}
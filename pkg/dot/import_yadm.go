@@ -0,0 +1,105 @@
+package dot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// yadmOwnDirs are the directories, relative to the home directory, where
+// yadm keeps its configuration, bootstrap program and encrypted archive.
+var yadmOwnDirs = []string{
+	filepath.Join(".config", "yadm"),
+	filepath.Join(".local", "share", "yadm"),
+}
+
+// ImportYadm converts the files tracked by the yadm repository repoDir
+// into packages of the package directory. yadm keeps a bare repository
+// whose work tree is the home directory, so the tracked files are read
+// from the target directory, copied into packages and replaced with links.
+// repoDir is the bare repository or the yadm data directory holding
+// repo.git. Files are placed as by ImportChezmoi.
+//
+// Alternate files, whose names carry a ## condition, and yadm's own
+// configuration are reported and left out, as are tracked files that are
+// missing from the target directory or are symbolic links. The repository
+// is left unchanged. In dry-run mode the report is returned without
+// changing anything.
+func (s *ImportService) ImportYadm(ctx context.Context, repoDir string) (ImportReport, error) {
+	repoDir, err := s.importDir(ctx, repoDir)
+	if err != nil {
+		return ImportReport{}, err
+	}
+	if s.tree == nil {
+		return ImportReport{}, errors.New("importing from yadm needs a git backend")
+	}
+	if bare := filepath.Join(repoDir, "repo.git"); s.fs.Exists(ctx, bare) {
+		repoDir = bare
+	}
+	s.logger.Info(ctx, "import_started", "from", "yadm", "dir", repoDir)
+
+	tracked, err := s.tree.TrackedFiles(ctx, repoDir)
+	if err != nil {
+		return ImportReport{}, fmt.Errorf("read yadm repository: %w", err)
+	}
+
+	report := newImportReport("yadm")
+	var files []importFile
+	for _, name := range tracked {
+		rel := filepath.FromSlash(name)
+		target := filepath.Join(s.targetDir, rel)
+		reason, err := s.yadmSkipReason(ctx, rel, target)
+		if err != nil {
+			return ImportReport{}, err
+		}
+		if reason == "" {
+			if pkg, pkgPath, ok := s.place(homePackage(rel), rel); ok {
+				top, _, _ := strings.Cut(rel, string(filepath.Separator))
+				files = append(files, importFile{source: target, from: top, pkg: pkg, pkgPath: pkgPath, target: target})
+				continue
+			}
+			reason = errRootFile
+		}
+		report.Skipped = append(report.Skipped, ImportSkip{Path: rel, Reason: reason})
+	}
+	files = claimTargets(s.targetDir, files, &report)
+
+	replaced := make([]string, 0, len(files))
+	for _, file := range files {
+		replaced = append(replaced, file.target)
+	}
+	return s.importFiles(ctx, files, replaced, report)
+}
+
+// yadmSkipReason returns why the tracked file at rel, relative to the
+// target directory, cannot be imported, or an empty string if it can.
+func (s *ImportService) yadmSkipReason(ctx context.Context, rel, target string) (string, error) {
+	if strings.Contains(filepath.Base(rel), "##") {
+		return "yadm alternates must be converted to a dot template or a single file by hand", nil
+	}
+	for _, dir := range yadmOwnDirs {
+		if isWithin(rel, dir) {
+			return "yadm configuration is not imported", nil
+		}
+	}
+	if isWithin(target, s.packageDir) {
+		return "inside the package directory", nil
+	}
+
+	if isLink, err := s.fs.IsSymlink(ctx, target); err == nil && isLink {
+		return "symbolic link", nil
+	}
+	if !s.fs.Exists(ctx, target) {
+		return "missing from the target directory", nil
+	}
+	isDir, err := s.fs.IsDir(ctx, target)
+	if err != nil {
+		return "", fmt.Errorf("stat %s: %w", target, err)
+	}
+	if isDir {
+		return "a directory is in the way in the target directory", nil
+	}
+	return "", nil
+}
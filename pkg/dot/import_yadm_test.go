@@ -0,0 +1,82 @@
+package dot
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+)
+
+// mockGitTree lists fixed files for any repository and records the path it
+// was asked about.
+type mockGitTree struct {
+	files []string
+	path  string
+}
+
+func (m *mockGitTree) TrackedFiles(ctx context.Context, path string) ([]string, error) {
+	m.path = path
+	return m.files, nil
+}
+
+func TestClient_ImportYadm(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/yadm/repo.git", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target/.config/nvim", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target/.config/yadm", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/target/.bashrc", []byte("set -o vi"), 0600))
+	require.NoError(t, fs.WriteFile(ctx, "/test/target/.config/nvim/init.lua", []byte("vim.o.number = true"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/test/target/.config/yadm/bootstrap", []byte("#!/bin/sh"), 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/target/.gitconfig##os.Darwin", []byte("[user]"), 0644))
+
+	client, err := NewClient(Config{
+		PackageDir: "/test/packages",
+		TargetDir:  "/test/target",
+		FS:         fs,
+		Logger:     adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+	tree := &mockGitTree{files: []string{
+		".bashrc",
+		".config/nvim/init.lua",
+		".config/yadm/bootstrap",
+		".gitconfig##os.Darwin",
+		".profile",
+	}}
+	client.importSvc.tree = tree
+
+	report, err := client.ImportYadm(ctx, "/test/yadm")
+	require.NoError(t, err)
+	assert.Equal(t, "/test/yadm/repo.git", tree.path)
+
+	assert.Equal(t, "yadm", report.From)
+	require.Len(t, report.Packages, 2)
+	assert.Equal(t, "bashrc", report.Packages[0].Name)
+	assert.Equal(t, []string{"dot-bashrc"}, report.Packages[0].Files)
+	assert.Equal(t, "config", report.Packages[1].Name)
+	assert.Equal(t, []string{".config"}, report.Packages[1].From)
+	assert.Equal(t, []string{".bashrc", ".config/nvim/init.lua"}, report.Replaced)
+
+	reasons := skipReasons(report)
+	assert.Len(t, reasons, 3)
+	assert.Contains(t, reasons[".config/yadm/bootstrap"], "yadm configuration")
+	assert.Contains(t, reasons[".gitconfig##os.Darwin"], "alternates")
+	assert.Contains(t, reasons[".profile"], "missing")
+
+	// The home files move into packages and are linked back
+	data, err := fs.ReadFile(ctx, "/test/packages/bashrc/dot-bashrc")
+	require.NoError(t, err)
+	assert.Equal(t, "set -o vi", string(data))
+	info, err := fs.Stat(ctx, "/test/packages/bashrc/dot-bashrc")
+	require.NoError(t, err)
+	assert.Equal(t, "-rw-------", info.Mode().Perm().String())
+	link, err := fs.ReadLink(ctx, "/test/target/.bashrc")
+	require.NoError(t, err)
+	assert.Contains(t, link, "packages/bashrc/dot-bashrc")
+	assert.True(t, fs.Exists(ctx, "/test/target/.config/yadm/bootstrap"))
+}
@@ -0,0 +1,205 @@
+package dot
+
+import (
+	"cmp"
+	"context"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// defaultLinkStatsTop is the length of the lists of LinkStats when
+// LinkStatsOptions.Top is not set.
+const defaultLinkStatsTop = 10
+
+// maxLinkChain bounds the symbolic links followed from a managed link, as
+// the kernel bounds path resolution.
+const maxLinkChain = 40
+
+// LinkStatsOptions configures LinkStats.
+type LinkStatsOptions struct {
+	// Top is the number of entries kept in each list. Zero keeps 10; a
+	// negative value keeps all.
+	Top int
+}
+
+// LinkStats describes how the managed links of the target directory are
+// laid out.
+type LinkStats struct {
+	// Links is the number of managed links.
+	Links int `json:"links" yaml:"links"`
+
+	// DirectoryCount is the number of directories holding managed links.
+	DirectoryCount int `json:"directory_count" yaml:"directory_count"`
+
+	// Directories counts the links of each directory, most first.
+	Directories []DirectoryLinks `json:"directories" yaml:"directories"`
+
+	// Deepest lists the links nested deepest below the target directory.
+	Deepest []LinkDepth `json:"deepest" yaml:"deepest"`
+
+	// LongestChains lists the links resolved through more than one
+	// symbolic link, longest first.
+	LongestChains []LinkChain `json:"longest_chains" yaml:"longest_chains"`
+
+	// DuplicateSources lists files that more than one managed link
+	// resolves to.
+	DuplicateSources []DuplicateSource `json:"duplicate_sources" yaml:"duplicate_sources"`
+}
+
+// DirectoryLinks is the number of managed links directly in a directory,
+// relative to the target directory.
+type DirectoryLinks struct {
+	Dir   string `json:"dir" yaml:"dir"`
+	Links int    `json:"links" yaml:"links"`
+}
+
+// LinkDepth is the number of path elements of a managed link below the
+// target directory; a link in the target directory itself has depth 1.
+type LinkDepth struct {
+	Path    string `json:"path" yaml:"path"`
+	Package string `json:"package" yaml:"package"`
+	Depth   int    `json:"depth" yaml:"depth"`
+}
+
+// LinkChain is the symbolic links a managed link resolves through.
+type LinkChain struct {
+	Path    string `json:"path" yaml:"path"`
+	Package string `json:"package" yaml:"package"`
+
+	// Targets lists each path reached, the last being the file the link
+	// resolves to.
+	Targets []string `json:"targets" yaml:"targets"`
+}
+
+// Hops returns the number of symbolic links followed.
+func (c LinkChain) Hops() int {
+	return len(c.Targets)
+}
+
+// DuplicateSource is a file several managed links resolve to.
+type DuplicateSource struct {
+	Source string   `json:"source" yaml:"source"`
+	Links  []string `json:"links" yaml:"links"`
+}
+
+// LinkStats reports how the links recorded in the manifest of the target
+// directory are spread over directories, how deep they are nested, which
+// resolve through chains of symbolic links, and which files are linked
+// more than once. Links missing from the target directory count toward
+// the totals but have no chain.
+func (s *StatusService) LinkStats(ctx context.Context, opts LinkStatsOptions) (LinkStats, error) {
+	top := opts.Top
+	if top == 0 {
+		top = defaultLinkStatsTop
+	}
+
+	targetPathResult := NewTargetPath(s.targetDir)
+	if !targetPathResult.IsOk() {
+		return LinkStats{}, targetPathResult.UnwrapErr()
+	}
+	manifestResult := s.manifestSvc.Load(ctx, targetPathResult.Unwrap())
+	if !manifestResult.IsOk() {
+		if isManifestNotFoundError(manifestResult.UnwrapErr()) {
+			return newLinkStats(), nil
+		}
+		return LinkStats{}, manifestResult.UnwrapErr()
+	}
+	m := manifestResult.Unwrap()
+
+	stats := newLinkStats()
+	perDir := make(map[string]int)
+	bySource := make(map[string][]string)
+	for _, info := range m.PackageList() {
+		for _, link := range info.Links {
+			if err := ctx.Err(); err != nil {
+				return LinkStats{}, err
+			}
+			stats.Links++
+			perDir[filepath.Dir(link)]++
+			stats.Deepest = append(stats.Deepest, LinkDepth{
+				Path:    link,
+				Package: info.Name,
+				Depth:   len(strings.Split(filepath.ToSlash(filepath.Clean(link)), "/")),
+			})
+
+			targets := s.linkChain(ctx, filepath.Join(s.targetDir, link))
+			if len(targets) == 0 {
+				continue
+			}
+			if len(targets) > 1 {
+				stats.LongestChains = append(stats.LongestChains, LinkChain{Path: link, Package: info.Name, Targets: targets})
+			}
+			source := targets[len(targets)-1]
+			bySource[source] = append(bySource[source], link)
+		}
+	}
+
+	stats.DirectoryCount = len(perDir)
+	for dir, count := range perDir {
+		stats.Directories = append(stats.Directories, DirectoryLinks{Dir: dir, Links: count})
+	}
+	slices.SortFunc(stats.Directories, func(a, b DirectoryLinks) int {
+		return cmp.Or(cmp.Compare(b.Links, a.Links), cmp.Compare(a.Dir, b.Dir))
+	})
+	slices.SortFunc(stats.Deepest, func(a, b LinkDepth) int {
+		return cmp.Or(cmp.Compare(b.Depth, a.Depth), cmp.Compare(a.Path, b.Path))
+	})
+	slices.SortFunc(stats.LongestChains, func(a, b LinkChain) int {
+		return cmp.Or(cmp.Compare(b.Hops(), a.Hops()), cmp.Compare(a.Path, b.Path))
+	})
+	for source, links := range bySource {
+		if len(links) > 1 {
+			slices.Sort(links)
+			stats.DuplicateSources = append(stats.DuplicateSources, DuplicateSource{Source: source, Links: links})
+		}
+	}
+	slices.SortFunc(stats.DuplicateSources, func(a, b DuplicateSource) int {
+		return cmp.Or(cmp.Compare(len(b.Links), len(a.Links)), cmp.Compare(a.Source, b.Source))
+	})
+
+	if top > 0 {
+		stats.Directories = stats.Directories[:min(top, len(stats.Directories))]
+		stats.Deepest = stats.Deepest[:min(top, len(stats.Deepest))]
+		stats.LongestChains = stats.LongestChains[:min(top, len(stats.LongestChains))]
+		stats.DuplicateSources = stats.DuplicateSources[:min(top, len(stats.DuplicateSources))]
+	}
+	return stats, nil
+}
+
+// newLinkStats returns statistics of no links, with empty lists.
+func newLinkStats() LinkStats {
+	return LinkStats{
+		Directories:      []DirectoryLinks{},
+		Deepest:          []LinkDepth{},
+		LongestChains:    []LinkChain{},
+		DuplicateSources: []DuplicateSource{},
+	}
+}
+
+// linkChain follows the symbolic link at path and any it leads to,
+// returning each path reached. It returns nil when path is not a symbolic
+// link, and stops at a loop or after maxLinkChain links.
+func (s *StatusService) linkChain(ctx context.Context, path string) []string {
+	var targets []string
+	seen := map[string]bool{path: true}
+	for len(targets) < maxLinkChain {
+		if isLink, err := s.fs.IsSymlink(ctx, path); err != nil || !isLink {
+			break
+		}
+		target, err := s.fs.ReadLink(ctx, path)
+		if err != nil {
+			break
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(path), target)
+		}
+		path = filepath.Clean(target)
+		targets = append(targets, path)
+		if seen[path] {
+			break
+		}
+		seen[path] = true
+	}
+	return targets
+}
@@ -0,0 +1,77 @@
+package dot_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+func TestClient_LinkStats(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/vim", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/nvim/dot-config/nvim/lua/plugins", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/shared", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/shared/vimrc", []byte("set nocompatible"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/vim/dot-vimrc", []byte("set nocompatible"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/vim/dot-gvimrc", []byte("set guifont"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/nvim/dot-config/nvim/lua/plugins/init.lua", []byte("return {}"), 0644))
+
+	client, err := dot.NewClient(dot.Config{
+		PackageDir: "/test/packages",
+		TargetDir:  "/test/target",
+		FS:         fs,
+		Logger:     adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+
+	empty, err := client.LinkStats(ctx, dot.LinkStatsOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 0, empty.Links)
+	assert.Empty(t, empty.Directories)
+
+	require.NoError(t, client.Manage(ctx, "vim", "nvim"))
+
+	// Both vim files are replaced by links to one shared file
+	for _, name := range []string{"dot-vimrc", "dot-gvimrc"} {
+		require.NoError(t, fs.Remove(ctx, "/test/packages/vim/"+name))
+		require.NoError(t, fs.Symlink(ctx, "/test/shared/vimrc", "/test/packages/vim/"+name))
+	}
+
+	stats, err := client.LinkStats(ctx, dot.LinkStatsOptions{Top: -1})
+	require.NoError(t, err)
+	assert.Equal(t, 3, stats.Links)
+	assert.Equal(t, 2, stats.DirectoryCount)
+	assert.Equal(t, dot.DirectoryLinks{Dir: ".", Links: 2}, stats.Directories[0])
+
+	require.NotEmpty(t, stats.Deepest)
+	assert.Equal(t, "nvim", stats.Deepest[0].Package)
+	assert.Equal(t, 5, stats.Deepest[0].Depth)
+
+	require.Len(t, stats.LongestChains, 2)
+	assert.Equal(t, dot.LinkChain{
+		Path:    ".gvimrc",
+		Package: "vim",
+		Targets: []string{"/test/packages/vim/dot-gvimrc", "/test/shared/vimrc"},
+	}, stats.LongestChains[0])
+	assert.Equal(t, 2, stats.LongestChains[0].Hops())
+
+	require.Len(t, stats.DuplicateSources, 1)
+	assert.Equal(t, dot.DuplicateSource{
+		Source: "/test/shared/vimrc",
+		Links:  []string{".gvimrc", ".vimrc"},
+	}, stats.DuplicateSources[0])
+
+	limited, err := client.LinkStats(ctx, dot.LinkStatsOptions{Top: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 3, limited.Links)
+	assert.Len(t, limited.Directories, 1)
+	assert.Len(t, limited.Deepest, 1)
+	assert.Len(t, limited.LongestChains, 1)
+}
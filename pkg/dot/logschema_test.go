@@ -0,0 +1,65 @@
+package dot_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+func TestClient_Manage_LogsFollowSchema(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/dot-vim", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/dot-vim/vimrc", []byte("set nu"), 0644))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target", 0755))
+
+	var buf bytes.Buffer
+	client, err := dot.NewClient(dot.Config{
+		PackageDir: "/test/packages",
+		TargetDir:  "/test/target",
+		FS:         fs,
+		Logger:     adapters.NewJSONLogger(&buf, slog.LevelDebug),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, client.Manage(ctx, "dot-vim"))
+
+	var records []map[string]any
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		require.NoError(t, adapters.ValidateLogRecord(line), "record: %s", line)
+		var record map[string]any
+		require.NoError(t, json.Unmarshal(line, &record))
+		records = append(records, record)
+	}
+	require.NotEmpty(t, records)
+
+	var executed bool
+	for _, record := range records {
+		if record[adapters.LogFieldEvent] != "executing_operation" {
+			continue
+		}
+		executed = true
+		assert.Equal(t, "executor", record[adapters.LogFieldComponent])
+		assert.NotEmpty(t, record[adapters.LogFieldPlanID])
+		assert.NotEmpty(t, record[adapters.LogFieldOpID])
+	}
+	assert.True(t, executed, "operations are logged")
+
+	documented := []any{"manage", "unmanage", "doctor", "adopt", "takeover", "rollback", "clone", "sync", "repo", "bootstrap", "scaffold", "import", "export", "manifest", "executor"}
+	for _, record := range records {
+		if component, ok := record[adapters.LogFieldComponent]; ok {
+			assert.Contains(t, documented, component)
+		}
+	}
+}
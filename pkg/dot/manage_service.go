@@ -4,10 +4,12 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"time"
 
 	"github.com/jamesainslie/dot/internal/executor"
 	"github.com/jamesainslie/dot/internal/manifest"
 	"github.com/jamesainslie/dot/internal/pipeline"
+	"github.com/jamesainslie/dot/internal/templating"
 )
 
 // ManageService handles package installation (manage and remanage operations).
@@ -18,9 +20,17 @@ type ManageService struct {
 	executor    *executor.Executor
 	manifestSvc *ManifestService
 	unmanageSvc *UnmanageService
+	renderer    *templating.Renderer
 	packageDir  string
 	targetDir   string
 	dryRun      bool
+
+	// metrics records the standard metrics of the plans executed.
+	metrics *commandMetrics
+
+	// snapshots archives packages before a remanage removes or
+	// overwrites their files. Nil disables snapshots.
+	snapshots *packageSnapshots
 }
 
 // newManageService creates a new manage service.
@@ -31,6 +41,7 @@ func newManageService(
 	exec *executor.Executor,
 	manifestSvc *ManifestService,
 	unmanageSvc *UnmanageService,
+	renderer *templating.Renderer,
 	packageDir string,
 	targetDir string,
 	dryRun bool,
@@ -42,6 +53,7 @@ func newManageService(
 		executor:    exec,
 		manifestSvc: manifestSvc,
 		unmanageSvc: unmanageSvc,
+		renderer:    renderer,
 		packageDir:  packageDir,
 		targetDir:   targetDir,
 		dryRun:      dryRun,
@@ -54,16 +66,30 @@ func (s *ManageService) Manage(ctx context.Context, packages ...string) error {
 	if err != nil {
 		return err
 	}
+	s.metrics.recordPlan("manage", plan)
+	if err := conflictsError(plan); err != nil {
+		return err
+	}
+	for _, w := range plan.Metadata.Warnings {
+		s.logger.Warn(ctx, "plan_warning", "message", w.Message)
+	}
 	if s.dryRun {
 		return nil
 	}
-	result := s.executor.Execute(ctx, plan)
-	if !result.IsOk() {
-		return result.UnwrapErr()
-	}
-	execResult := result.Unwrap()
-	if !execResult.Success() {
-		return fmt.Errorf("execution failed: %d operations failed", len(execResult.Failed))
+	// Links that already exist (for example from GNU Stow) leave nothing to
+	// execute, but they are still registered in the manifest below, as are
+	// packages whose conflicts a strategy skipped with a plan warning
+	if len(plan.Operations) > 0 || (len(plan.Satisfied) == 0 && len(plan.Metadata.Warnings) == 0) {
+		result := s.metrics.execute(ctx, s.executor, "manage", plan)
+		if !result.IsOk() {
+			return result.UnwrapErr()
+		}
+		execResult := result.Unwrap()
+		if !execResult.Success() {
+			return fmt.Errorf("execution failed: %d operations failed", len(execResult.Failed))
+		}
+	} else {
+		s.logger.Info(ctx, "already_linked", "packages", packages, "links", len(plan.Satisfied))
 	}
 	// Update manifest
 	targetPathResult := NewTargetPath(s.targetDir)
@@ -102,12 +128,41 @@ func (s *ManageService) PlanManage(ctx context.Context, packages ...string) (Pla
 	return planResult.Unwrap(), nil
 }
 
+// conflictsError converts unresolved plan conflicts into an error.
+// Returns nil when the plan has no conflicts.
+func conflictsError(plan Plan) error {
+	conflicts := plan.Metadata.Conflicts
+	if len(conflicts) == 0 {
+		return nil
+	}
+	if len(conflicts) == 1 {
+		return ErrConflict{Path: conflicts[0].Path, Reason: conflicts[0].Details}
+	}
+	errs := make([]error, 0, len(conflicts))
+	for _, c := range conflicts {
+		errs = append(errs, ErrConflict{Path: c.Path, Reason: c.Details})
+	}
+	return ErrMultiple{Errors: errs}
+}
+
+// RemanageOptions configures remanage behavior.
+type RemanageOptions struct {
+	// Force reinstalls packages even when their content is unchanged.
+	Force bool
+}
+
 // Remanage reinstalls packages using incremental hash-based change detection.
 func (s *ManageService) Remanage(ctx context.Context, packages ...string) error {
-	plan, err := s.PlanRemanage(ctx, packages...)
+	return s.RemanageWithOptions(ctx, RemanageOptions{}, packages...)
+}
+
+// RemanageWithOptions reinstalls packages with specified options.
+func (s *ManageService) RemanageWithOptions(ctx context.Context, opts RemanageOptions, packages ...string) error {
+	plan, err := s.PlanRemanageWithOptions(ctx, opts, packages...)
 	if err != nil {
 		return err
 	}
+	s.metrics.recordPlan("remanage", plan)
 	if len(plan.Operations) == 0 {
 		s.logger.Info(ctx, "no_changes_detected", "packages", packages)
 		return nil
@@ -116,7 +171,10 @@ func (s *ManageService) Remanage(ctx context.Context, packages ...string) error
 		s.logger.Info(ctx, "dry_run_plan", "operations", len(plan.Operations))
 		return nil
 	}
-	result := s.executor.Execute(ctx, plan)
+	if err := s.snapshotReplaced(ctx, plan); err != nil {
+		return err
+	}
+	result := s.metrics.execute(ctx, s.executor, "remanage", plan)
 	if !result.IsOk() {
 		return result.UnwrapErr()
 	}
@@ -147,8 +205,29 @@ func (s *ManageService) Remanage(ctx context.Context, packages ...string) error
 	return nil
 }
 
+// snapshotReplaced snapshots the packages holding files that plan removes
+// or overwrites, so that nothing is destroyed without an archive of it.
+func (s *ManageService) snapshotReplaced(ctx context.Context, plan Plan) error {
+	if s.snapshots == nil {
+		return nil
+	}
+	now := time.Now()
+	for _, pkg := range packagesReplaced(ctx, s.fs, s.packageDir, plan) {
+		if _, err := s.snapshots.take(ctx, s.packageDir, pkg, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // PlanRemanage computes incremental execution plan using hash-based change detection.
 func (s *ManageService) PlanRemanage(ctx context.Context, packages ...string) (Plan, error) {
+	return s.PlanRemanageWithOptions(ctx, RemanageOptions{}, packages...)
+}
+
+// PlanRemanageWithOptions computes the remanage plan with specified options.
+// With Force, every installed package is planned in full.
+func (s *ManageService) PlanRemanageWithOptions(ctx context.Context, opts RemanageOptions, packages ...string) (Plan, error) {
 	targetPathResult := NewTargetPath(s.targetDir)
 	if !targetPathResult.IsOk() {
 		return Plan{}, fmt.Errorf("invalid target directory: %w", targetPathResult.UnwrapErr())
@@ -168,7 +247,7 @@ func (s *ManageService) PlanRemanage(ctx context.Context, packages ...string) (P
 	packageOps := make(map[string][]OperationID)
 
 	for _, pkg := range packages {
-		ops, pkgOpsMap, err := s.planSinglePackageRemanage(ctx, pkg, &m, hasher)
+		ops, pkgOpsMap, err := s.planSinglePackageRemanage(ctx, pkg, &m, hasher, opts)
 		if err != nil {
 			return Plan{}, err
 		}
@@ -194,24 +273,22 @@ func (s *ManageService) planSinglePackageRemanage(
 	pkg string,
 	m *manifest.Manifest,
 	hasher *manifest.ContentHasher,
+	opts RemanageOptions,
 ) ([]Operation, map[string][]OperationID, error) {
-	_, exists := m.GetPackage(pkg)
+	pkgInfo, exists := m.GetPackage(pkg)
 	if !exists {
 		return s.planNewPackageInstall(ctx, pkg)
 	}
+	if opts.Force {
+		s.logger.Info(ctx, "forced_remanage", "package", pkg)
+		return s.planFullRemanage(ctx, pkg)
+	}
 
 	pkgPath, err := s.getPackagePath(pkg)
 	if err != nil {
 		return nil, nil, err
 	}
-	currentHash, err := hasher.HashPackage(ctx, pkgPath)
-	if err != nil {
-		s.logger.Warn(ctx, "hash_computation_failed", "package", pkg, "error", err)
-		return s.planFullRemanage(ctx, pkg)
-	}
-
-	storedHash, hasHash := m.GetHash(pkg)
-	if !hasHash || storedHash != currentHash {
+	if !s.contentUnchanged(ctx, pkg, pkgInfo, pkgPath, m, hasher) {
 		return s.planFullRemanage(ctx, pkg)
 	}
 
@@ -225,10 +302,65 @@ func (s *ManageService) planSinglePackageRemanage(
 		return s.planFullRemanage(ctx, pkg)
 	}
 
+	// Template output depends on values and environment as well as package
+	// content, so re-render when any recorded output has gone stale
+	if s.rendersStale(ctx, pkg, m) {
+		s.logger.Info(ctx, "stale_renders_detected", "package", pkg)
+		return s.planFullRemanage(ctx, pkg)
+	}
+
 	s.logger.Info(ctx, "package_unchanged", "package", pkg)
 	return []Operation{}, map[string][]OperationID{}, nil
 }
 
+// contentUnchanged reports whether the package content matches what the
+// manifest recorded. The per-file hashes are compared when recorded, so
+// only files whose size or modification time changed are read; entries
+// written before file hashes existed fall back to the package hash.
+func (s *ManageService) contentUnchanged(
+	ctx context.Context,
+	pkg string,
+	pkgInfo manifest.PackageInfo,
+	pkgPath PackagePath,
+	m *manifest.Manifest,
+	hasher *manifest.ContentHasher,
+) bool {
+	if len(pkgInfo.Files) > 0 {
+		files, err := hasher.HashFiles(ctx, pkgPath, pkgInfo.Files)
+		if err != nil {
+			s.logger.Warn(ctx, "hash_computation_failed", "package", pkg, "error", err)
+			return false
+		}
+		return manifest.SameFiles(files, pkgInfo.Files)
+	}
+
+	currentHash, err := hasher.HashPackage(ctx, pkgPath)
+	if err != nil {
+		s.logger.Warn(ctx, "hash_computation_failed", "package", pkg, "error", err)
+		return false
+	}
+	storedHash, hasHash := m.GetHash(pkg)
+	return hasHash && storedHash == currentHash
+}
+
+// rendersStale checks whether any rendered template recorded for the package is out of date.
+func (s *ManageService) rendersStale(ctx context.Context, pkg string, m *manifest.Manifest) bool {
+	if s.renderer == nil {
+		return false
+	}
+	pkgInfo, exists := m.GetPackage(pkg)
+	if !exists {
+		return false
+	}
+	for _, render := range pkgInfo.Templates {
+		stale, err := s.renderer.IsStale(ctx, render.Template, render.Rendered)
+		if err != nil || stale {
+			return true
+		}
+	}
+	return false
+}
+
 // planNewPackageInstall plans installation of a package not yet in manifest.
 func (s *ManageService) planNewPackageInstall(ctx context.Context, pkg string) ([]Operation, map[string][]OperationID, error) {
 	pkgPlan, err := s.PlanManage(ctx, pkg)
@@ -279,9 +411,16 @@ func (s *ManageService) planFullRemanage(ctx context.Context, pkg string) ([]Ope
 		return nil, nil, err
 	}
 
-	// Concatenate operations (unmanage first, then manage)
-	ops := make([]Operation, 0, len(unmanagePlan.Operations)+len(managePlan.Operations))
+	// Concatenate operations (unmanage first, then manage). Links that are
+	// already in place are removed by the unmanage step, so they must be
+	// recreated along with the regular manage operations.
+	ops := make([]Operation, 0, len(unmanagePlan.Operations)+len(managePlan.Operations)+len(managePlan.Satisfied))
 	ops = append(ops, unmanagePlan.Operations...)
+	for _, op := range managePlan.Satisfied {
+		if op.Kind() == OpKindLinkCreate {
+			ops = append(ops, op)
+		}
+	}
 	ops = append(ops, managePlan.Operations...)
 
 	// Merge package operations
@@ -43,7 +43,7 @@ func TestManageService_Manage(t *testing.T) {
 		manifestSvc := newManifestService(fs, adapters.NewNoopLogger(), manifestStore)
 		unmanageSvc := newUnmanageService(fs, adapters.NewNoopLogger(), exec, manifestSvc, packageDir, targetDir, false)
 
-		svc := newManageService(fs, adapters.NewNoopLogger(), managePipe, exec, manifestSvc, unmanageSvc, packageDir, targetDir, false)
+		svc := newManageService(fs, adapters.NewNoopLogger(), managePipe, exec, manifestSvc, unmanageSvc, nil, packageDir, targetDir, false)
 
 		err := svc.Manage(ctx, "test-pkg")
 		require.NoError(t, err)
@@ -78,7 +78,7 @@ func TestManageService_Manage(t *testing.T) {
 		manifestSvc := newManifestService(fs, adapters.NewNoopLogger(), manifestStore)
 		unmanageSvc := newUnmanageService(fs, adapters.NewNoopLogger(), exec, manifestSvc, packageDir, targetDir, true)
 
-		svc := newManageService(fs, adapters.NewNoopLogger(), managePipe, exec, manifestSvc, unmanageSvc, packageDir, targetDir, true)
+		svc := newManageService(fs, adapters.NewNoopLogger(), managePipe, exec, manifestSvc, unmanageSvc, nil, packageDir, targetDir, true)
 
 		err := svc.Manage(ctx, "test-pkg")
 		require.NoError(t, err)
@@ -111,7 +111,7 @@ func TestManageService_PlanManage(t *testing.T) {
 		manifestSvc := newManifestService(fs, adapters.NewNoopLogger(), manifestStore)
 		unmanageSvc := newUnmanageService(fs, adapters.NewNoopLogger(), exec, manifestSvc, packageDir, targetDir, false)
 
-		svc := newManageService(fs, adapters.NewNoopLogger(), managePipe, exec, manifestSvc, unmanageSvc, packageDir, targetDir, false)
+		svc := newManageService(fs, adapters.NewNoopLogger(), managePipe, exec, manifestSvc, unmanageSvc, nil, packageDir, targetDir, false)
 
 		plan, err := svc.PlanManage(ctx, "test-pkg")
 		require.NoError(t, err)
@@ -145,7 +145,7 @@ func TestManageService_Remanage(t *testing.T) {
 		manifestSvc := newManifestService(fs, adapters.NewNoopLogger(), manifestStore)
 		unmanageSvc := newUnmanageService(fs, adapters.NewNoopLogger(), exec, manifestSvc, packageDir, targetDir, false)
 
-		svc := newManageService(fs, adapters.NewNoopLogger(), managePipe, exec, manifestSvc, unmanageSvc, packageDir, targetDir, false)
+		svc := newManageService(fs, adapters.NewNoopLogger(), managePipe, exec, manifestSvc, unmanageSvc, nil, packageDir, targetDir, false)
 
 		// Initial manage
 		err := svc.Manage(ctx, "test-pkg")
@@ -202,7 +202,7 @@ func TestManageService_Remanage(t *testing.T) {
 			Tracer: adapters.NewNoopTracer(),
 		})
 		unmanageSvc := newUnmanageService(fs, adapters.NewNoopLogger(), exec, manifestSvc, packageDir, targetDir, false)
-		svc := newManageService(fs, adapters.NewNoopLogger(), managePipe, exec, manifestSvc, unmanageSvc, packageDir, targetDir, false)
+		svc := newManageService(fs, adapters.NewNoopLogger(), managePipe, exec, manifestSvc, unmanageSvc, nil, packageDir, targetDir, false)
 
 		// Remanage adopted package
 		err = svc.Remanage(ctx, "dot-ssh")
@@ -0,0 +1,85 @@
+package dot
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/jamesainslie/dot/internal/manifest"
+)
+
+// ManifestMergeResult is the outcome of merging diverged manifests.
+type ManifestMergeResult struct {
+	// Merged is the merged manifest document.
+	Merged []byte
+
+	// Resolved describes entries changed on both sides that were combined
+	// automatically.
+	Resolved []string
+
+	// Conflicts describes entries that could not be merged; Merged keeps
+	// the local version of them.
+	Conflicts []string
+
+	// TwoWay reports that no common ancestor was available, so packages
+	// removed on one side are kept.
+	TwoWay bool
+}
+
+// ManifestSchemaVersion is the manifest schema version written by this
+// version of dot.
+const ManifestSchemaVersion = manifest.CurrentSchemaVersion
+
+// SupportedManifestSchemaVersions returns the manifest schema versions this
+// version of dot reads, oldest first.
+func SupportedManifestSchemaVersions() []int {
+	return manifest.SupportedSchemaVersions()
+}
+
+// MergeManifests merges the local and remote versions of a manifest that
+// diverged from base, combining package entries instead of lines. Empty
+// input stands for a manifest that did not exist on that side.
+func MergeManifests(base, ours, theirs []byte) (ManifestMergeResult, error) {
+	var manifests [3]manifest.Manifest
+	for i, side := range []struct {
+		name string
+		data []byte
+	}{{"base", base}, {"local", ours}, {"remote", theirs}} {
+		m := manifest.New()
+		if len(bytes.TrimSpace(side.data)) > 0 {
+			decoded, _, err := manifest.Decode(side.data)
+			if err != nil {
+				return ManifestMergeResult{}, fmt.Errorf("%s manifest: %w", side.name, err)
+			}
+			m = decoded
+		}
+		manifests[i] = m
+	}
+
+	result := manifest.Merge(manifests[0], manifests[1], manifests[2])
+	merged, err := manifest.Encode(result.Manifest)
+	if err != nil {
+		return ManifestMergeResult{}, err
+	}
+	return ManifestMergeResult{
+		Merged:    merged,
+		Resolved:  result.Resolved,
+		Conflicts: result.Conflicts,
+	}, nil
+}
+
+// MergeConflictedManifest merges a manifest left with git conflict markers.
+// The base version is only available when the conflict was written with
+// merge.conflictStyle diff3 or zdiff3; otherwise the merge is two-way.
+func MergeConflictedManifest(data []byte) (ManifestMergeResult, error) {
+	ours, base, theirs, hasBase, err := manifest.SplitConflict(data)
+	if err != nil {
+		return ManifestMergeResult{}, fmt.Errorf("read conflict markers: %w", err)
+	}
+	if !hasBase {
+		base = nil
+	}
+
+	result, err := MergeManifests(base, ours, theirs)
+	result.TwoWay = !hasBase
+	return result, err
+}
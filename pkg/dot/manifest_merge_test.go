@@ -0,0 +1,36 @@
+package dot_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+func TestMergeManifests_EmptySides(t *testing.T) {
+	// Both sides added the manifest
+	local := []byte(`{"schema_version": 1, "packages": {"vim": {"name": "vim", "links": [".vimrc"], "link_count": 1}}, "hashes": {}}`)
+	remote := []byte(`{"schema_version": 1, "packages": {"zsh": {"name": "zsh", "links": [".zshrc"], "link_count": 1}}, "hashes": {}}`)
+
+	result, err := dot.MergeManifests(nil, local, remote)
+	require.NoError(t, err)
+	assert.Empty(t, result.Conflicts)
+	assert.Contains(t, string(result.Merged), `"vim"`)
+	assert.Contains(t, string(result.Merged), `"zsh"`)
+}
+
+func TestMergeManifests_InvalidInput(t *testing.T) {
+	_, err := dot.MergeManifests(nil, []byte("{"), nil)
+	assert.ErrorContains(t, err, "local manifest")
+}
+
+func TestMergeConflictedManifest_TwoWay(t *testing.T) {
+	data := []byte("<<<<<<< HEAD\n{\"packages\": {\"vim\": {\"name\": \"vim\"}}}\n=======\n{\"packages\": {}}\n>>>>>>> theirs\n")
+
+	result, err := dot.MergeConflictedManifest(data)
+	require.NoError(t, err)
+	assert.True(t, result.TwoWay)
+	assert.Contains(t, string(result.Merged), `"vim"`, "without a base, removals are not applied")
+}
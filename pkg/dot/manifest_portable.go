@@ -0,0 +1,336 @@
+package dot
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/jamesainslie/dot/internal/manifest"
+)
+
+// Identifiers of the portable manifest format written by ExportManifest.
+const (
+	// PortableManifestFormat is the value of PortableManifest.Format.
+	PortableManifestFormat = "dot-manifest"
+
+	// PortableManifestVersion is the version of the format. It changes only
+	// when fields are removed or change meaning.
+	PortableManifestVersion = 1
+)
+
+//go:embed manifest_portable.schema.json
+var portableManifestSchema []byte
+
+// PortableManifestSchema returns the JSON Schema of portable manifests, for
+// editors and tools that check documents before they are imported.
+func PortableManifestSchema() []byte {
+	return bytes.Clone(portableManifestSchema)
+}
+
+// PortableManifest is the manifest of a target directory in a documented
+// form independent of the machine: every path is relative to the target
+// directory, the package directory or the template cache, and nothing
+// depends on how the manifest is stored.
+type PortableManifest struct {
+	// Format is always PortableManifestFormat.
+	Format string `json:"format" yaml:"format"`
+
+	// Version is the PortableManifestVersion the document follows.
+	Version int `json:"version" yaml:"version"`
+
+	// Packages lists the installed packages by name.
+	Packages []PortablePackage `json:"packages" yaml:"packages"`
+
+	// Repository describes the repository the packages were cloned from.
+	Repository *PortableRepository `json:"repository,omitempty" yaml:"repository,omitempty"`
+}
+
+// PortablePackage is an installed package of a PortableManifest.
+type PortablePackage struct {
+	Name        string    `json:"name" yaml:"name"`
+	Source      string    `json:"source,omitempty" yaml:"source,omitempty"`
+	InstalledAt time.Time `json:"installed_at" yaml:"installed_at"`
+
+	// Links lists the links of the package, relative to the target directory.
+	Links []string `json:"links" yaml:"links"`
+
+	// Dirs lists the directories created for the links, relative to the
+	// target directory.
+	Dirs []string `json:"dirs,omitempty" yaml:"dirs,omitempty"`
+
+	// Templates lists the links served from rendered templates.
+	Templates []PortableTemplate `json:"templates,omitempty" yaml:"templates,omitempty"`
+
+	// Hash is the content hash of the package.
+	Hash string `json:"hash,omitempty" yaml:"hash,omitempty"`
+
+	// Files maps package files, relative to the package, to their content
+	// hashes.
+	Files map[string]string `json:"files,omitempty" yaml:"files,omitempty"`
+}
+
+// PortableTemplate records that a link of a package points at rendered
+// template output.
+type PortableTemplate struct {
+	// Link is the link, relative to the target directory.
+	Link string `json:"link" yaml:"link"`
+
+	// Template is the template, relative to the package directory.
+	Template string `json:"template" yaml:"template"`
+
+	// Rendered is the rendered output, relative to the template cache.
+	Rendered string `json:"rendered" yaml:"rendered"`
+}
+
+// PortableRepository describes the repository of a PortableManifest.
+type PortableRepository struct {
+	URL        string    `json:"url" yaml:"url"`
+	Branch     string    `json:"branch" yaml:"branch"`
+	BaseBranch string    `json:"base_branch,omitempty" yaml:"base_branch,omitempty"`
+	CommitSHA  string    `json:"commit_sha,omitempty" yaml:"commit_sha,omitempty"`
+	ClonedAt   time.Time `json:"cloned_at" yaml:"cloned_at"`
+}
+
+// ManifestImportOptions configures ImportManifest.
+type ManifestImportOptions struct {
+	// Force replaces a manifest that already records packages.
+	Force bool
+}
+
+// ManifestImportResult describes a manifest written by ImportManifest.
+type ManifestImportResult struct {
+	// Packages lists the imported packages.
+	Packages []string `json:"packages" yaml:"packages"`
+
+	// Replaced lists the packages of the previous manifest.
+	Replaced []string `json:"replaced,omitempty" yaml:"replaced,omitempty"`
+
+	// DryRun reports that nothing was written.
+	DryRun bool `json:"dry_run" yaml:"dry_run"`
+}
+
+// ManifestPortService converts the manifest of the target directory to
+// and from the portable format.
+type ManifestPortService struct {
+	logger      Logger
+	manifestSvc *ManifestService
+	packageDir  string
+	targetDir   string
+	cacheDir    string
+	dryRun      bool
+}
+
+// newManifestPortService creates a new manifest port service. cacheDir is
+// the template cache rendered output is relative to.
+func newManifestPortService(logger Logger, manifestSvc *ManifestService, packageDir, targetDir, cacheDir string, dryRun bool) *ManifestPortService {
+	return &ManifestPortService{
+		logger:      logger,
+		manifestSvc: manifestSvc,
+		packageDir:  packageDir,
+		targetDir:   targetDir,
+		cacheDir:    cacheDir,
+		dryRun:      dryRun,
+	}
+}
+
+// ExportManifest returns the manifest of the target directory in the
+// portable format. Templates outside the package directory, or rendered
+// outside the template cache, have no portable form and are left out;
+// their links are kept. File sizes and modification times are left out,
+// so files are hashed again after an import.
+func (s *ManifestPortService) ExportManifest(ctx context.Context) (PortableManifest, error) {
+	m, err := s.load(ctx)
+	if err != nil {
+		return PortableManifest{}, err
+	}
+
+	portable := PortableManifest{
+		Format:   PortableManifestFormat,
+		Version:  PortableManifestVersion,
+		Packages: []PortablePackage{},
+	}
+	for _, name := range slices.Sorted(maps.Keys(m.Packages)) {
+		info := m.Packages[name]
+		pkg := PortablePackage{
+			Name:        name,
+			Source:      string(info.Source),
+			InstalledAt: info.InstalledAt,
+			Links:       slices.Clone(info.Links),
+			Dirs:        slices.Clone(info.Dirs),
+			Hash:        m.Hashes[name],
+		}
+		if pkg.Links == nil {
+			pkg.Links = []string{}
+		}
+		for _, render := range info.Templates {
+			template, okTemplate := portableRel(s.packageDir, render.Template)
+			rendered, okRendered := portableRel(s.cacheDir, render.Rendered)
+			if !okTemplate || !okRendered {
+				s.logger.Warn(ctx, "manifest_template_not_portable", "package", name, "link", render.Link)
+				continue
+			}
+			pkg.Templates = append(pkg.Templates, PortableTemplate{Link: render.Link, Template: template, Rendered: rendered})
+		}
+		if len(info.Files) > 0 {
+			pkg.Files = make(map[string]string, len(info.Files))
+			for file, hash := range info.Files {
+				pkg.Files[file] = hash.Hash
+			}
+		}
+		portable.Packages = append(portable.Packages, pkg)
+	}
+	if repo := m.Repository; repo != nil {
+		portable.Repository = &PortableRepository{
+			URL:        repo.URL,
+			Branch:     repo.Branch,
+			BaseBranch: repo.BaseBranch,
+			CommitSHA:  repo.CommitSHA,
+			ClonedAt:   repo.ClonedAt,
+		}
+	}
+	return portable, nil
+}
+
+// ImportManifest replaces the manifest of the target directory with the
+// portable manifest p, resolving its paths against the package directory
+// and template cache of this machine. Only the manifest is written; the
+// links it records are expected to exist, which doctor checks.
+//
+// Returns an error when p is not a portable manifest of a supported
+// version, names a path outside its directory, or when the target
+// directory already has a manifest recording packages and opts.Force is
+// not set.
+func (s *ManifestPortService) ImportManifest(ctx context.Context, p PortableManifest, opts ManifestImportOptions) (ManifestImportResult, error) {
+	imported, err := s.fromPortable(p)
+	if err != nil {
+		return ManifestImportResult{}, err
+	}
+
+	current, err := s.load(ctx)
+	if err != nil {
+		return ManifestImportResult{}, err
+	}
+	result := ManifestImportResult{
+		Packages: slices.Sorted(maps.Keys(imported.Packages)),
+		Replaced: slices.Sorted(maps.Keys(current.Packages)),
+		DryRun:   s.dryRun,
+	}
+	if len(result.Replaced) > 0 && !opts.Force {
+		return result, fmt.Errorf("the manifest already records %d package(s); use force to replace it", len(result.Replaced))
+	}
+	if s.dryRun {
+		return result, nil
+	}
+
+	targetPathResult := NewTargetPath(s.targetDir)
+	if !targetPathResult.IsOk() {
+		return ManifestImportResult{}, targetPathResult.UnwrapErr()
+	}
+	if err := s.manifestSvc.Save(ctx, targetPathResult.Unwrap(), imported); err != nil {
+		return ManifestImportResult{}, err
+	}
+	s.logger.Info(ctx, "manifest_imported", "packages", len(result.Packages), "replaced", len(result.Replaced))
+	return result, nil
+}
+
+// fromPortable builds the manifest p describes on this machine.
+func (s *ManifestPortService) fromPortable(p PortableManifest) (manifest.Manifest, error) {
+	if p.Format != PortableManifestFormat {
+		return manifest.Manifest{}, fmt.Errorf("not a portable manifest: format is %q, want %q", p.Format, PortableManifestFormat)
+	}
+	if p.Version < 1 || p.Version > PortableManifestVersion {
+		return manifest.Manifest{}, fmt.Errorf("unsupported portable manifest version %d (this dot reads up to %d)", p.Version, PortableManifestVersion)
+	}
+
+	m := manifest.New()
+	for _, pkg := range p.Packages {
+		if pkg.Name == "" || !filepath.IsLocal(pkg.Name) {
+			return manifest.Manifest{}, fmt.Errorf("invalid package name %q", pkg.Name)
+		}
+		if _, exists := m.Packages[pkg.Name]; exists {
+			return manifest.Manifest{}, fmt.Errorf("package %s is listed twice", pkg.Name)
+		}
+		paths := slices.Concat(pkg.Links, pkg.Dirs, slices.Collect(maps.Keys(pkg.Files)))
+		for _, t := range pkg.Templates {
+			paths = append(paths, t.Link, t.Template, t.Rendered)
+		}
+		for _, path := range paths {
+			if !filepath.IsLocal(filepath.FromSlash(path)) {
+				return manifest.Manifest{}, fmt.Errorf("package %s: path %q is not relative to its directory", pkg.Name, path)
+			}
+		}
+
+		info := manifest.PackageInfo{
+			Name:        pkg.Name,
+			InstalledAt: pkg.InstalledAt,
+			LinkCount:   len(pkg.Links),
+			Links:       slices.Clone(pkg.Links),
+			Source:      manifest.PackageSource(pkg.Source),
+			Dirs:        slices.Clone(pkg.Dirs),
+		}
+		if info.Links == nil {
+			info.Links = []string{}
+		}
+		for _, t := range pkg.Templates {
+			info.Templates = append(info.Templates, manifest.RenderInfo{
+				Link:     t.Link,
+				Template: filepath.Join(s.packageDir, filepath.FromSlash(t.Template)),
+				Rendered: filepath.Join(s.cacheDir, filepath.FromSlash(t.Rendered)),
+			})
+		}
+		if len(pkg.Files) > 0 {
+			info.Files = make(map[string]manifest.FileHash, len(pkg.Files))
+			for file, hash := range pkg.Files {
+				info.Files[file] = manifest.FileHash{Hash: hash}
+			}
+		}
+		m.AddPackage(info)
+		if pkg.Hash != "" {
+			m.SetHash(pkg.Name, pkg.Hash)
+		}
+	}
+	if repo := p.Repository; repo != nil {
+		m.Repository = &manifest.RepositoryInfo{
+			URL:        repo.URL,
+			Branch:     repo.Branch,
+			BaseBranch: repo.BaseBranch,
+			CommitSHA:  repo.CommitSHA,
+			ClonedAt:   repo.ClonedAt,
+		}
+	}
+	return m, nil
+}
+
+// load loads the manifest of the target directory, empty when there is none.
+func (s *ManifestPortService) load(ctx context.Context) (manifest.Manifest, error) {
+	targetPathResult := NewTargetPath(s.targetDir)
+	if !targetPathResult.IsOk() {
+		return manifest.Manifest{}, targetPathResult.UnwrapErr()
+	}
+	result := s.manifestSvc.Load(ctx, targetPathResult.Unwrap())
+	if !result.IsOk() {
+		if isManifestNotFoundError(result.UnwrapErr()) {
+			return manifest.New(), nil
+		}
+		return manifest.Manifest{}, result.UnwrapErr()
+	}
+	return result.Unwrap(), nil
+}
+
+// portableRel returns path relative to dir, slash-separated, if it lies
+// inside dir.
+func portableRel(dir, path string) (string, bool) {
+	if dir == "" {
+		return "", false
+	}
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || !filepath.IsLocal(rel) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
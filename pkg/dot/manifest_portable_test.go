@@ -0,0 +1,165 @@
+package dot_test
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+func newPortClient(t *testing.T, fs *adapters.MemFS, packageDir, targetDir, cacheDir string, dryRun bool) *dot.Client {
+	t.Helper()
+	client, err := dot.NewClient(dot.Config{
+		PackageDir:       packageDir,
+		TargetDir:        targetDir,
+		TemplateCacheDir: cacheDir,
+		DryRun:           dryRun,
+		FS:               fs,
+		Logger:           adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+	return client
+}
+
+func TestClient_ExportImportManifest(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	require.NoError(t, fs.MkdirAll(ctx, "/a/packages/git", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/a/packages/vim/dot-vim", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/a/packages/.dot-values", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/a/target", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/a/packages/.dot-values/default.yaml", []byte("email: me@example.com\n"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/a/packages/git/dot-gitconfig.tmpl", []byte("email = {{ .Values.email }}\n"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/a/packages/vim/dot-vim/vimrc", []byte("set nocompatible"), 0644))
+
+	source := newPortClient(t, fs, "/a/packages", "/a/target", "/a/cache", false)
+	require.NoError(t, source.Manage(ctx, "vim", "git"))
+
+	exported, err := source.ExportManifest(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, dot.PortableManifestFormat, exported.Format)
+	assert.Equal(t, dot.PortableManifestVersion, exported.Version)
+	require.Len(t, exported.Packages, 2)
+	assert.Equal(t, "git", exported.Packages[0].Name)
+	assert.Equal(t, "vim", exported.Packages[1].Name)
+	assert.Equal(t, []dot.PortableTemplate{{
+		Link:     ".gitconfig",
+		Template: "git/dot-gitconfig.tmpl",
+		Rendered: "git/dot-gitconfig",
+	}}, exported.Packages[0].Templates)
+	assert.NotEmpty(t, exported.Packages[1].Hash)
+
+	// No path of this machine is left in the document
+	data, err := json.Marshal(exported)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "/a/")
+
+	// Imported on another layout, paths resolve against its directories
+	dest := newPortClient(t, fs, "/b/packages", "/b/target", "/b/cache", false)
+	require.NoError(t, fs.MkdirAll(ctx, "/b/target", 0755))
+
+	result, err := dest.ImportManifest(ctx, exported, dot.ManifestImportOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"git", "vim"}, result.Packages)
+	assert.Empty(t, result.Replaced)
+
+	reexported, err := dest.ExportManifest(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, exported, reexported)
+
+	data, err = fs.ReadFile(ctx, "/b/target/.dot-manifest.json")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "/b/packages/git/dot-gitconfig.tmpl")
+	assert.Contains(t, string(data), "/b/cache/git/dot-gitconfig")
+
+	// A manifest recording packages is replaced only with force
+	_, err = dest.ImportManifest(ctx, exported, dot.ManifestImportOptions{})
+	assert.ErrorContains(t, err, "already records 2 package(s)")
+
+	result, err = dest.ImportManifest(ctx, exported, dot.ManifestImportOptions{Force: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"git", "vim"}, result.Replaced)
+}
+
+func TestClient_ImportManifest_DryRun(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target", 0755))
+	client := newPortClient(t, fs, "/test/packages", "/test/target", "", true)
+
+	result, err := client.ImportManifest(ctx, dot.PortableManifest{
+		Format:   dot.PortableManifestFormat,
+		Version:  dot.PortableManifestVersion,
+		Packages: []dot.PortablePackage{{Name: "vim", Links: []string{".vimrc"}}},
+	}, dot.ManifestImportOptions{})
+	require.NoError(t, err)
+	assert.True(t, result.DryRun)
+	assert.Equal(t, []string{"vim"}, result.Packages)
+	assert.False(t, fs.Exists(ctx, "/test/target/.dot-manifest.json"))
+}
+
+func TestClient_ImportManifest_Invalid(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target", 0755))
+	client := newPortClient(t, fs, "/test/packages", "/test/target", "", false)
+
+	valid := func(pkgs ...dot.PortablePackage) dot.PortableManifest {
+		return dot.PortableManifest{Format: dot.PortableManifestFormat, Version: dot.PortableManifestVersion, Packages: pkgs}
+	}
+
+	tests := []struct {
+		name     string
+		manifest dot.PortableManifest
+		want     string
+	}{
+		{"wrong format", dot.PortableManifest{Format: "other", Version: 1}, "not a portable manifest"},
+		{"newer version", dot.PortableManifest{Format: dot.PortableManifestFormat, Version: dot.PortableManifestVersion + 1}, "unsupported portable manifest version"},
+		{"absolute link", valid(dot.PortablePackage{Name: "vim", Links: []string{"/home/me/.vimrc"}}), "not relative"},
+		{"escaping template", valid(dot.PortablePackage{Name: "git", Templates: []dot.PortableTemplate{{Link: ".gitconfig", Template: "../x", Rendered: "git/x"}}}), "not relative"},
+		{"duplicate package", valid(dot.PortablePackage{Name: "vim"}, dot.PortablePackage{Name: "vim"}), "listed twice"},
+		{"empty name", valid(dot.PortablePackage{}), "invalid package name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.ImportManifest(ctx, tt.manifest, dot.ManifestImportOptions{})
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+	assert.False(t, fs.Exists(ctx, "/test/target/.dot-manifest.json"))
+}
+
+func TestPortableManifestSchema_MatchesTypes(t *testing.T) {
+	type object struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	var doc struct {
+		object
+		Definitions map[string]object `json:"definitions"`
+	}
+	require.NoError(t, json.Unmarshal(dot.PortableManifestSchema(), &doc))
+
+	// Every field of each type is described, under its JSON name
+	fields := func(v any) []string {
+		data, err := json.Marshal(v)
+		require.NoError(t, err)
+		var m map[string]any
+		require.NoError(t, json.Unmarshal(data, &m))
+		return slices.Sorted(maps.Keys(m))
+	}
+	props := func(o object) []string { return slices.Sorted(maps.Keys(o.Properties)) }
+
+	full := dot.PortableManifest{Repository: &dot.PortableRepository{}}
+	assert.Equal(t, fields(full), props(doc.object))
+	pkg := dot.PortablePackage{Source: "x", Dirs: []string{"d"}, Templates: []dot.PortableTemplate{{}}, Hash: "h", Files: map[string]string{"f": "h"}}
+	assert.Equal(t, fields(pkg), props(doc.Definitions["package"]))
+	assert.Equal(t, fields(dot.PortableTemplate{}), props(doc.Definitions["template"]))
+	assert.Equal(t, fields(dot.PortableRepository{BaseBranch: "b", CommitSHA: "c"}), props(doc.Definitions["repository"]))
+}
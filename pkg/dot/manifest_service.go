@@ -3,6 +3,8 @@ package dot
 import (
 	"context"
 	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/jamesainslie/dot/internal/domain"
@@ -59,20 +61,29 @@ func (s *ManifestService) UpdateWithSource(ctx context.Context, targetPath Targe
 		packagesToUpdate = plan.PackageNames()
 	}
 
+	createdDirs := s.extractDirsFromOperations(plan.Operations, targetPath.String())
+
 	for _, pkg := range packagesToUpdate {
 		// Extract links from package operations
 		ops := plan.OperationsForPackage(pkg)
 		links := s.extractLinksFromOperations(ops, targetPath.String())
 
-		m.AddPackage(manifest.PackageInfo{
+		var previousDirs []string
+		if previous, ok := m.GetPackage(pkg); ok {
+			previousDirs = previous.Dirs
+		}
+
+		info := manifest.PackageInfo{
 			Name:        pkg,
 			InstalledAt: time.Now(),
 			LinkCount:   len(links),
 			Links:       links,
 			Source:      source,
-		})
+			Templates:   s.extractRendersFromOperations(ops, targetPath.String()),
+			Dirs:        ownedDirs(createdDirs, previousDirs, links),
+		}
 
-		// Compute and store package hash
+		// Compute and store package hash and per-file hashes
 		pkgPathStr := filepath.Join(packageDir, pkg)
 		pkgPathResult := NewPackagePath(pkgPathStr)
 		if pkgPathResult.IsOk() {
@@ -83,13 +94,75 @@ func (s *ManifestService) UpdateWithSource(ctx context.Context, targetPath Targe
 			} else {
 				m.SetHash(pkg, hash)
 			}
+			if files, err := hasher.HashFiles(ctx, pkgPath, nil); err == nil {
+				info.Files = files
+			}
 		}
+		m.AddPackage(info)
 	}
 
+	s.reassignUnfoldedLinks(&m, targetPath.String(), packageDir, packagesToUpdate, plan.Operations)
+
 	// Save manifest
 	return s.Save(ctx, targetPath, m)
 }
 
+// reassignUnfoldedLinks updates packages whose directory link the plan
+// unfolded. The directory link is replaced by the links the plan created
+// inside it that point into the package, and the unfolded directories
+// become directories the package owns.
+func (s *ManifestService) reassignUnfoldedLinks(m *manifest.Manifest, targetDir, packageDir string, updated []string, ops []Operation) {
+	created := s.extractDirsFromOperations(ops, targetDir)
+	for name, info := range m.Packages {
+		if slices.Contains(updated, name) {
+			continue
+		}
+		pkgDir := filepath.Join(packageDir, name) + string(filepath.Separator)
+		changed := false
+		var links []string
+		for _, link := range info.Links {
+			linkPath := filepath.Join(targetDir, link)
+			if !unfoldsLink(ops, linkPath) {
+				links = append(links, link)
+				continue
+			}
+			changed = true
+			for _, op := range ops {
+				linkOp, ok := op.(LinkCreate)
+				if !ok || !strings.HasPrefix(linkOp.Target.String(), linkPath+string(filepath.Separator)) ||
+					!strings.HasPrefix(linkOp.Source.String(), pkgDir) {
+					continue
+				}
+				if rel, err := filepath.Rel(targetDir, linkOp.Target.String()); err == nil {
+					links = append(links, rel)
+				}
+			}
+		}
+		if !changed {
+			continue
+		}
+		info.Links = links
+		info.LinkCount = len(links)
+		info.Dirs = ownedDirs(created, info.Dirs, links)
+		m.Packages[name] = info
+	}
+}
+
+// unfoldsLink reports whether ops replace the link at path with a
+// directory.
+func unfoldsLink(ops []Operation, path string) bool {
+	deleted, created := false, false
+	for _, op := range ops {
+		switch op := op.(type) {
+		case LinkDelete:
+			deleted = deleted || op.Target.String() == path
+		case DirCreate:
+			created = created || op.Path.String() == path
+		}
+	}
+	return deleted && created
+}
+
 // RemovePackage removes a package from the manifest.
 func (s *ManifestService) RemovePackage(ctx context.Context, targetPath TargetPath, pkg string) error {
 	manifestResult := s.Load(ctx, targetPath)
@@ -118,3 +191,77 @@ func (s *ManifestService) extractLinksFromOperations(ops []Operation, targetDir
 	}
 	return links
 }
+
+// extractDirsFromOperations extracts the paths of DirCreate operations
+// relative to targetDir. Directories outside targetDir are skipped.
+func (s *ManifestService) extractDirsFromOperations(ops []Operation, targetDir string) []string {
+	var dirs []string
+	for _, op := range ops {
+		dirOp, ok := op.(DirCreate)
+		if !ok {
+			continue
+		}
+		relPath, err := filepath.Rel(targetDir, dirOp.Path.String())
+		if err != nil || relPath == "." || strings.HasPrefix(relPath, "..") {
+			continue
+		}
+		dirs = append(dirs, relPath)
+	}
+	return dirs
+}
+
+// ownedDirs returns the directories a package owns: those it owned before
+// and those created by the plan that hold one of its links. A directory
+// shared by several packages is owned by each of them.
+func ownedDirs(created, previous, links []string) []string {
+	owned := slices.Clone(previous)
+	for _, dir := range created {
+		if slices.Contains(owned, dir) {
+			continue
+		}
+		for _, link := range links {
+			if strings.HasPrefix(link, dir+string(filepath.Separator)) {
+				owned = append(owned, dir)
+				break
+			}
+		}
+	}
+	slices.Sort(owned)
+	return owned
+}
+
+// extractRendersFromOperations pairs FileRender operations with the links
+// that point at their output.
+func (s *ManifestService) extractRendersFromOperations(ops []Operation, targetDir string) []manifest.RenderInfo {
+	templates := make(map[string]string)
+	for _, op := range ops {
+		if renderOp, ok := op.(FileRender); ok {
+			templates[renderOp.Dest.String()] = renderOp.Template.String()
+		}
+	}
+	if len(templates) == 0 {
+		return nil
+	}
+
+	renders := make([]manifest.RenderInfo, 0, len(templates))
+	for _, op := range ops {
+		linkOp, ok := op.(LinkCreate)
+		if !ok {
+			continue
+		}
+		template, rendered := templates[linkOp.Source.String()]
+		if !rendered {
+			continue
+		}
+		relPath, err := filepath.Rel(targetDir, linkOp.Target.String())
+		if err != nil {
+			relPath = linkOp.Target.String()
+		}
+		renders = append(renders, manifest.RenderInfo{
+			Link:     relPath,
+			Template: template,
+			Rendered: linkOp.Source.String(),
+		})
+	}
+	return renders
+}
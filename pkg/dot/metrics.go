@@ -0,0 +1,124 @@
+package dot
+
+import (
+	"context"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jamesainslie/dot/internal/domain"
+	"github.com/jamesainslie/dot/internal/executor"
+)
+
+// Standard metrics recorded by the commands of a client into its Metrics,
+// whatever the backend. Every metric carries the LabelCommand and
+// LabelPackage labels, so services report alike.
+const (
+	// MetricPlanOperations counts planned operations by the package they
+	// belong to. Operations of no package are counted with an empty
+	// package label.
+	MetricPlanOperations = "plan_operations_total"
+
+	// MetricConflicts counts plan conflicts by LabelType and the package
+	// whose file conflicted, when known.
+	MetricConflicts = "conflicts_total"
+
+	// MetricExecutionDuration observes how long executing a plan took, in
+	// seconds, once for each package in the plan.
+	MetricExecutionDuration = "execution_duration_seconds"
+
+	// MetricRollbacks counts executions rolled back after a failure, once
+	// for each package in the plan.
+	MetricRollbacks = "rollback_total"
+)
+
+// Labels of the standard metrics.
+const (
+	LabelCommand = "command"
+	LabelPackage = "package"
+	LabelType    = "type"
+)
+
+// commandMetrics records the standard metrics for the services of a
+// client. A nil commandMetrics records nothing.
+type commandMetrics struct {
+	metrics    Metrics
+	packageDir string
+}
+
+// newCommandMetrics creates a recorder into metrics. packageDir is used to
+// tell which package a conflicting file came from.
+func newCommandMetrics(metrics Metrics, packageDir string) *commandMetrics {
+	return &commandMetrics{metrics: metrics, packageDir: packageDir}
+}
+
+// recordPlan records the size and conflicts of a plan computed by command.
+func (m *commandMetrics) recordPlan(command string, plan Plan) {
+	if m == nil {
+		return
+	}
+
+	owners := make(map[OperationID]string, len(plan.Operations))
+	for pkg, ids := range plan.PackageOperations {
+		for _, id := range ids {
+			owners[id] = pkg
+		}
+	}
+	counts := make(map[string]int)
+	for _, op := range plan.Operations {
+		counts[owners[op.ID()]]++
+	}
+	operations := m.metrics.Counter(MetricPlanOperations, LabelCommand, LabelPackage)
+	for _, pkg := range slices.Sorted(maps.Keys(counts)) {
+		operations.Add(float64(counts[pkg]), command, pkg)
+	}
+
+	conflicts := m.metrics.Counter(MetricConflicts, LabelCommand, LabelPackage, LabelType)
+	for _, c := range plan.Metadata.Conflicts {
+		conflicts.Inc(command, m.conflictPackage(c), c.Type)
+	}
+}
+
+// conflictPackage returns the package of the source a conflict was found
+// for, or "" when the conflict does not name one in the package directory.
+func (m *commandMetrics) conflictPackage(c ConflictInfo) string {
+	source := c.Context["source"]
+	if source == "" || m.packageDir == "" {
+		return ""
+	}
+	rel, err := filepath.Rel(m.packageDir, source)
+	if err != nil || !filepath.IsLocal(rel) {
+		return ""
+	}
+	pkg, _, _ := strings.Cut(filepath.ToSlash(rel), "/")
+	return pkg
+}
+
+// execute executes plan for command with exec, recording its duration and
+// whether it was rolled back.
+func (m *commandMetrics) execute(ctx context.Context, exec *executor.Executor, command string, plan Plan) domain.Result[executor.ExecutionResult] {
+	start := time.Now()
+	result := exec.Execute(ctx, plan)
+	if m == nil {
+		return result
+	}
+
+	duration := time.Since(start).Seconds()
+	rolledBack := len(result.UnwrapOr(executor.ExecutionResult{}).RolledBack) > 0
+	packages := plan.PackageNames()
+	if len(packages) == 0 {
+		packages = []string{""}
+	}
+	slices.Sort(packages)
+	durations := m.metrics.Histogram(MetricExecutionDuration, LabelCommand, LabelPackage)
+	rollbacks := m.metrics.Counter(MetricRollbacks, LabelCommand, LabelPackage)
+	for _, pkg := range packages {
+		durations.Observe(duration, command, pkg)
+		if rolledBack {
+			rollbacks.Inc(command, pkg)
+		}
+	}
+	return result
+}
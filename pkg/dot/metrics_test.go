@@ -0,0 +1,83 @@
+package dot_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// metricSeries returns the series of name in registry, keyed by their
+// joined label values.
+func metricSeries(t *testing.T, registry *adapters.MetricsRegistry, name string) map[string]adapters.MetricSeries {
+	t.Helper()
+	series := make(map[string]adapters.MetricSeries)
+	for _, family := range registry.Snapshot() {
+		if family.Name != name {
+			continue
+		}
+		for _, s := range family.Series {
+			key := ""
+			for i, v := range s.LabelValues {
+				if i > 0 {
+					key += ","
+				}
+				key += v
+			}
+			series[key] = s
+		}
+	}
+	return series
+}
+
+func TestClient_RecordsStandardMetrics(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/vim", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/zsh", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/vim/dot-vimrc", []byte("set nu"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/vim/dot-gvimrc", []byte("set go"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/zsh/dot-zshrc", []byte("autoload"), 0644))
+
+	registry := adapters.NewMetricsRegistry()
+	client, err := dot.NewClient(dot.Config{
+		PackageDir: "/test/packages",
+		TargetDir:  "/test/target",
+		FS:         fs,
+		Logger:     adapters.NewNoopLogger(),
+		Metrics:    registry,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, client.Manage(ctx, "vim", "zsh"))
+
+	operations := metricSeries(t, registry, dot.MetricPlanOperations)
+	assert.Equal(t, 2.0, operations["manage,vim"].Value)
+	assert.Equal(t, 1.0, operations["manage,zsh"].Value)
+
+	durations := metricSeries(t, registry, dot.MetricExecutionDuration)
+	assert.Equal(t, uint64(1), durations["manage,vim"].Count)
+	assert.Equal(t, uint64(1), durations["manage,zsh"].Count)
+	assert.Empty(t, metricSeries(t, registry, dot.MetricRollbacks))
+
+	require.NoError(t, client.Unmanage(ctx, "zsh"))
+	operations = metricSeries(t, registry, dot.MetricPlanOperations)
+	assert.Positive(t, operations["unmanage,zsh"].Value)
+
+	t.Run("conflicts by type and package", func(t *testing.T) {
+		require.NoError(t, fs.WriteFile(ctx, "/test/target/.zshrc", []byte("local"), 0644))
+		require.Error(t, client.Manage(ctx, "zsh"))
+
+		conflicts := metricSeries(t, registry, dot.MetricConflicts)
+		require.Len(t, conflicts, 1)
+		for key, s := range conflicts {
+			assert.Contains(t, key, "manage,zsh,")
+			assert.Equal(t, 1.0, s.Value)
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package dot
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+)
+
+func TestCloneService_OfflineFailsFast(t *testing.T) {
+	fs := adapters.NewMemFS()
+	cloned := false
+	cloner := &mockGitCloner{cloneFn: func(ctx context.Context, url string, dest string, opts adapters.CloneOptions) error {
+		cloned = true
+		return nil
+	}}
+
+	svc := newCloneService(fs, adapters.NewNoopLogger(), nil, cloner, &mockPackageSelector{}, "/packages", "/home", false, true)
+
+	_, err := svc.Clone(context.Background(), "https://example.com/dotfiles.git", CloneOptions{})
+
+	var offline ErrOffline
+	require.True(t, errors.As(err, &offline))
+	assert.Equal(t, "clone", offline.Operation)
+	assert.False(t, cloned)
+	assert.False(t, fs.Exists(context.Background(), "/packages"))
+}
+
+func TestSyncService_OfflineFailsFast(t *testing.T) {
+	puller := &mockGitPuller{head: syncNewCommit}
+	client, _ := setupSyncClient(t, false, puller)
+	client.syncSvc.offline = true
+
+	_, err := client.Sync(context.Background(), SyncOptions{})
+
+	var offline ErrOffline
+	require.True(t, errors.As(err, &offline))
+	assert.Equal(t, "sync", offline.Operation)
+	assert.False(t, puller.pulled)
+}
+
+func TestSyncService_OfflineDryRunPreviews(t *testing.T) {
+	puller := &mockGitPuller{head: syncNewCommit, changed: []string{"vim/dot-vimrc"}}
+	client, _ := setupSyncClient(t, true, puller)
+	client.syncSvc.offline = true
+
+	result, err := client.Sync(context.Background(), SyncOptions{})
+
+	require.NoError(t, err)
+	assert.False(t, puller.pulled)
+	assert.Equal(t, []string{"vim"}, result.Packages)
+}
+
+func TestErrOffline_Error(t *testing.T) {
+	err := ErrOffline{Operation: "clone"}
+
+	assert.Equal(t, "clone requires network access but offline mode is enabled", err.Error())
+}
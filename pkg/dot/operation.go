@@ -17,6 +17,9 @@ const (
 	OpKindFileMove     = domain.OpKindFileMove
 	OpKindFileBackup   = domain.OpKindFileBackup
 	OpKindDirCopy      = domain.OpKindDirCopy
+	OpKindFileRender   = domain.OpKindFileRender
+	OpKindFileStash    = domain.OpKindFileStash
+	OpKindFileRestore  = domain.OpKindFileRestore
 )
 
 // OperationID uniquely identifies an operation.
@@ -49,6 +52,15 @@ type FileBackup = domain.FileBackup
 // DirCopy recursively copies a directory.
 type DirCopy = domain.DirCopy
 
+// FileRender writes rendered template output to a file.
+type FileRender = domain.FileRender
+
+// FileStash moves a file into the content-addressed backup store.
+type FileStash = domain.FileStash
+
+// FileRestore moves a file out of the backup store to its original path.
+type FileRestore = domain.FileRestore
+
 // NewLinkCreate creates a new LinkCreate operation.
 func NewLinkCreate(id OperationID, source FilePath, target TargetPath) LinkCreate {
 	return domain.NewLinkCreate(id, source, target)
@@ -88,3 +100,18 @@ func NewFileBackup(id OperationID, source, backup FilePath) FileBackup {
 func NewDirCopy(id OperationID, source, dest FilePath) DirCopy {
 	return domain.NewDirCopy(id, source, dest)
 }
+
+// NewFileRender creates a new FileRender operation.
+func NewFileRender(id OperationID, template, dest FilePath, content string) FileRender {
+	return domain.NewFileRender(id, template, dest, content)
+}
+
+// NewFileStash creates a new FileStash operation.
+func NewFileStash(id OperationID, source, backupDir FilePath, hash string) FileStash {
+	return domain.NewFileStash(id, source, backupDir, hash)
+}
+
+// NewFileRestore creates a new FileRestore operation.
+func NewFileRestore(id OperationID, path, backupDir FilePath, hash, planID string) FileRestore {
+	return domain.NewFileRestore(id, path, backupDir, hash, planID)
+}
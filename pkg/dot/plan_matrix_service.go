@@ -0,0 +1,335 @@
+package dot
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"slices"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/internal/bootstrap"
+)
+
+// PlanMatrixOptions selects the combinations a plan matrix covers.
+type PlanMatrixOptions struct {
+	// AllProfiles plans every profile of the bootstrap configuration
+	// instead of only the default one.
+	AllProfiles bool
+
+	// AllPlatforms plans every platform named by the bootstrap
+	// configuration instead of only the running one.
+	AllPlatforms bool
+
+	// Profile names the bootstrap profile planned instead of the default
+	// one when AllProfiles is not set. It may be qualified as
+	// bootstrap:NAME.
+	Profile string
+}
+
+// PlanMatrix holds the plans of manage for combinations of bootstrap
+// profiles and platforms, each computed against an empty target.
+type PlanMatrix struct {
+	// Profiles and Platforms are the rows and columns of the matrix. An
+	// empty profile stands for every package of the platform.
+	Profiles  []string `json:"profiles" yaml:"profiles"`
+	Platforms []string `json:"platforms" yaml:"platforms"`
+
+	// Entries holds one entry per combination, by profile then platform.
+	Entries []PlanMatrixEntry `json:"entries" yaml:"entries"`
+}
+
+// Failed returns the entries whose plan could not be computed.
+func (m PlanMatrix) Failed() []PlanMatrixEntry {
+	failed := []PlanMatrixEntry{}
+	for _, entry := range m.Entries {
+		if entry.Error != "" {
+			failed = append(failed, entry)
+		}
+	}
+	return failed
+}
+
+// PlanMatrixEntry is the plan of one profile on one platform.
+type PlanMatrixEntry struct {
+	Profile  string   `json:"profile" yaml:"profile"`
+	Platform string   `json:"platform" yaml:"platform"`
+	Packages []string `json:"packages" yaml:"packages"`
+
+	Operations  int `json:"operations" yaml:"operations"`
+	Links       int `json:"links" yaml:"links"`
+	Directories int `json:"directories" yaml:"directories"`
+
+	// Paths lists the paths the plan creates, relative to the target
+	// directory.
+	Paths []string `json:"paths" yaml:"paths"`
+
+	// Conflicts lists the conflicts between packages of the entry, such
+	// as two packages providing the same path.
+	Conflicts []ConflictInfo `json:"conflicts,omitempty" yaml:"conflicts,omitempty"`
+
+	// Error is set when the plan could not be computed.
+	Error string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// PlanMatrixService computes plans for bootstrap profiles and platforms.
+type PlanMatrixService struct {
+	fs     FS
+	logger Logger
+	config Config
+}
+
+// newPlanMatrixService creates a new plan matrix service. cfg is the
+// configuration sandboxed clients are derived from.
+func newPlanMatrixService(fs FS, logger Logger, cfg Config) *PlanMatrixService {
+	return &PlanMatrixService{fs: fs, logger: logger, config: cfg}
+}
+
+// PlanMatrix computes the plan of managing the packages of each selected
+// profile and platform. The package directory is copied to an in-memory
+// filesystem with an empty target directory, so plans show what a fresh
+// machine would get and nothing on disk is read beyond the packages or
+// changed.
+//
+// Returns ErrInvalidBootstrap if the package directory has no valid
+// bootstrap configuration.
+func (s *PlanMatrixService) PlanMatrix(ctx context.Context, opts PlanMatrixOptions) (PlanMatrix, error) {
+	config, ok, err := loadBootstrapConfig(ctx, s.fs, s.config.PackageDir)
+	if err != nil {
+		return PlanMatrix{}, err
+	}
+	if !ok {
+		return PlanMatrix{}, ErrInvalidBootstrap{Reason: "no .dotbootstrap.yaml in " + s.config.PackageDir}
+	}
+
+	profiles := matrixProfiles(config, opts.AllProfiles)
+	if opts.Profile != "" && !opts.AllProfiles {
+		if _, err := selectPackagesFromProfile(config, opts.Profile); err != nil {
+			return PlanMatrix{}, err
+		}
+		profiles = []string{ParseProfileRef(opts.Profile).Name}
+	}
+
+	matrix := PlanMatrix{
+		Profiles:  profiles,
+		Platforms: matrixPlatforms(config, opts.AllPlatforms),
+		Entries:   []PlanMatrixEntry{},
+	}
+
+	// Packages are read from disk once, and copied in memory for each entry
+	sandbox, err := s.sandbox(ctx, s.fs)
+	if err != nil {
+		return PlanMatrix{}, err
+	}
+
+	for _, profile := range matrix.Profiles {
+		for _, platform := range matrix.Platforms {
+			entry := s.plan(ctx, sandbox, config, profile, platform)
+			s.logger.Debug(ctx, "plan_matrix_entry", "profile", profile, "platform", platform, "operations", entry.Operations, "error", entry.Error)
+			matrix.Entries = append(matrix.Entries, entry)
+		}
+	}
+	return matrix, nil
+}
+
+// plan computes the entry of profile on platform in a fresh copy of
+// sandbox.
+func (s *PlanMatrixService) plan(ctx context.Context, sandbox FS, config bootstrap.Config, profile, platform string) PlanMatrixEntry {
+	entry := PlanMatrixEntry{Profile: profile, Platform: platform, Packages: []string{}, Paths: []string{}}
+
+	packages, err := profilePackages(config, profile, platform)
+	if err != nil {
+		entry.Error = err.Error()
+		return entry
+	}
+	entry.Packages = packages
+	if len(packages) == 0 {
+		return entry
+	}
+
+	fresh, err := s.sandbox(ctx, sandbox)
+	if err != nil {
+		entry.Error = err.Error()
+		return entry
+	}
+	client, err := NewClient(s.sandboxConfig(fresh, platform))
+	if err != nil {
+		entry.Error = err.Error()
+		return entry
+	}
+	plan, err := client.PlanManage(ctx, packages...)
+	if err != nil {
+		entry.Error = err.Error()
+		return entry
+	}
+
+	entry.Operations = len(plan.Operations)
+	entry.Links = plan.Metadata.LinkCount
+	entry.Directories = plan.Metadata.DirCount
+	entry.Conflicts = plan.Metadata.Conflicts
+	for _, op := range plan.Operations {
+		if path := createdPath(op); path != "" {
+			if rel, err := filepath.Rel(s.config.TargetDir, path); err == nil {
+				path = rel
+			}
+			entry.Paths = append(entry.Paths, path)
+		}
+	}
+	slices.Sort(entry.Paths)
+	return entry
+}
+
+// profilePackages returns the packages of profile available on platform,
+// as clone selects them. An empty profile selects every package of the
+// platform.
+func profilePackages(config bootstrap.Config, profile, platform string) ([]string, error) {
+	packages := extractPackageNames(bootstrap.FilterPackagesByPlatform(config.Packages, platform))
+	if profile == "" {
+		return packages, nil
+	}
+	selected, err := selectPackagesFromProfile(config, profile)
+	if err != nil {
+		return nil, err
+	}
+	return intersectPackages(selected, packages), nil
+}
+
+// sandboxConfig returns the configuration of a client planning in fs for
+// platform, with nothing recorded.
+func (s *PlanMatrixService) sandboxConfig(fs FS, platform string) Config {
+	cfg := s.config
+	cfg.FS = fs
+	cfg.Logger = s.logger
+	cfg.DryRun = true
+	cfg.Offline = true
+	cfg.TemplateCacheDir = ""
+	cfg.ScanCacheDir = ""
+	cfg.CheckpointDir = ""
+	cfg.AuditLog = ""
+	cfg.EventSink = nil
+	cfg.events = nil
+	cfg.ConflictPrompt = nil
+	cfg.platform = platform
+	return cfg
+}
+
+// sandbox copies the package directory of from into an in-memory
+// filesystem holding empty target directories.
+func (s *PlanMatrixService) sandbox(ctx context.Context, from FS) (*adapters.MemFS, error) {
+	mem := adapters.NewMemFS()
+	if err := copyTree(ctx, from, mem, s.config.PackageDir); err != nil {
+		return nil, fmt.Errorf("copy package directory: %w", err)
+	}
+	dirs := []string{s.config.TargetDir}
+	for _, dir := range s.config.Targets {
+		dirs = append(dirs, dir)
+	}
+	for _, dir := range dirs {
+		if err := mem.MkdirAll(ctx, dir, 0755); err != nil {
+			return nil, err
+		}
+	}
+	return mem, nil
+}
+
+// copyTree copies the tree at dir from src to the same path in dest,
+// skipping .git directories.
+func copyTree(ctx context.Context, src, dest FS, dir string) error {
+	info, err := src.Stat(ctx, dir)
+	if err != nil {
+		return err
+	}
+	if err := dest.MkdirAll(ctx, dir, info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	entries, err := src.ReadDir(ctx, dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.Name() == ".git" {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+
+		isLink, err := src.IsSymlink(ctx, path)
+		if err != nil {
+			return err
+		}
+		switch {
+		case isLink:
+			target, err := src.ReadLink(ctx, path)
+			if err != nil {
+				return err
+			}
+			if err := dest.Symlink(ctx, target, path); err != nil {
+				return err
+			}
+		case entry.IsDir():
+			if err := copyTree(ctx, src, dest, path); err != nil {
+				return err
+			}
+		default:
+			data, err := src.ReadFile(ctx, path)
+			if err != nil {
+				return err
+			}
+			info, err := src.Stat(ctx, path)
+			if err != nil {
+				return err
+			}
+			if err := dest.WriteFile(ctx, path, data, info.Mode().Perm()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// createdPath returns the path in the target op creates, or "" if it
+// creates none. Rendered templates are created in the template cache and
+// linked like other files.
+func createdPath(op Operation) string {
+	switch op := op.(type) {
+	case LinkCreate:
+		return op.Target.String()
+	case DirCreate:
+		return op.Path.String()
+	default:
+		return ""
+	}
+}
+
+// matrixProfiles returns the profiles of config a matrix covers: every
+// profile with all, and the default profile otherwise. An empty profile
+// stands for every package.
+func matrixProfiles(config bootstrap.Config, all bool) []string {
+	if all && len(config.Profiles) > 0 {
+		profiles := make([]string, 0, len(config.Profiles))
+		for name := range config.Profiles {
+			profiles = append(profiles, name)
+		}
+		slices.Sort(profiles)
+		return profiles
+	}
+	return []string{config.Defaults.Profile}
+}
+
+// matrixPlatforms returns the platforms a matrix covers: the running
+// platform and, with all, every platform packages of config are
+// restricted to.
+func matrixPlatforms(config bootstrap.Config, all bool) []string {
+	platforms := []string{runtime.GOOS}
+	if all {
+		for _, pkg := range config.Packages {
+			for _, platform := range pkg.Platform {
+				if !slices.Contains(platforms, platform) {
+					platforms = append(platforms, platform)
+				}
+			}
+		}
+		slices.Sort(platforms)
+	}
+	return platforms
+}
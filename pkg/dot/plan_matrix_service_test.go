@@ -0,0 +1,128 @@
+package dot_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+const planMatrixBootstrap = `version: "1.0"
+packages:
+  - name: vim
+  - name: zsh
+    platform: [linux, darwin]
+  - name: mac
+    platform: [darwin]
+profiles:
+  minimal:
+    description: Editor only
+    packages: [vim]
+  full:
+    description: Everything
+    packages: [vim, zsh, mac]
+defaults:
+  profile: minimal
+`
+
+func setupPlanMatrixClient(t *testing.T) (*adapters.MemFS, *dot.Client) {
+	t.Helper()
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	for pkg, file := range map[string]string{"vim": "dot-vimrc", "zsh": "dot-zshrc", "mac": "dot-hammerspoon"} {
+		require.NoError(t, fs.MkdirAll(ctx, "/test/packages/"+pkg, 0755))
+		require.NoError(t, fs.WriteFile(ctx, "/test/packages/"+pkg+"/"+file, []byte("x"), 0644))
+	}
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/.dotbootstrap.yaml", []byte(planMatrixBootstrap), 0644))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target", 0755))
+	// An existing file the simulated clean target does not see
+	require.NoError(t, fs.WriteFile(ctx, "/test/target/.vimrc", []byte("local"), 0644))
+
+	client, err := dot.NewClient(dot.Config{
+		PackageDir: "/test/packages",
+		TargetDir:  "/test/target",
+		FS:         fs,
+		Logger:     adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+	return fs, client
+}
+
+func TestClient_PlanMatrix_AllProfilesAllPlatforms(t *testing.T) {
+	ctx := context.Background()
+	fs, client := setupPlanMatrixClient(t)
+
+	matrix, err := client.PlanMatrix(ctx, dot.PlanMatrixOptions{AllProfiles: true, AllPlatforms: true})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"full", "minimal"}, matrix.Profiles)
+	assert.Contains(t, matrix.Platforms, "darwin")
+	assert.Contains(t, matrix.Platforms, "linux")
+	assert.Len(t, matrix.Entries, len(matrix.Profiles)*len(matrix.Platforms))
+	assert.Empty(t, matrix.Failed())
+
+	entries := map[string]dot.PlanMatrixEntry{}
+	for _, entry := range matrix.Entries {
+		entries[entry.Profile+"/"+entry.Platform] = entry
+	}
+	assert.Equal(t, []string{"vim", "zsh", "mac"}, entries["full/darwin"].Packages)
+	assert.Equal(t, []string{".hammerspoon", ".vimrc", ".zshrc"}, entries["full/darwin"].Paths)
+	assert.Equal(t, []string{"vim", "zsh"}, entries["full/linux"].Packages)
+	assert.Equal(t, []string{"vim"}, entries["minimal/linux"].Packages)
+	assert.Equal(t, 1, entries["minimal/linux"].Links)
+	assert.Empty(t, entries["minimal/linux"].Conflicts, "the target is simulated clean")
+
+	data, err := fs.ReadFile(ctx, "/test/target/.vimrc")
+	require.NoError(t, err)
+	assert.Equal(t, "local", string(data), "nothing is changed")
+	assert.False(t, fs.Exists(ctx, "/test/target/.zshrc"))
+}
+
+func TestClient_PlanMatrix_DefaultsAndErrors(t *testing.T) {
+	ctx := context.Background()
+	fs, client := setupPlanMatrixClient(t)
+
+	matrix, err := client.PlanMatrix(ctx, dot.PlanMatrixOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"minimal"}, matrix.Profiles)
+	require.Len(t, matrix.Entries, 1)
+
+	// A profile naming a package missing from the repository fails its entry
+	require.NoError(t, fs.RemoveAll(ctx, "/test/packages/vim"))
+	matrix, err = client.PlanMatrix(ctx, dot.PlanMatrixOptions{})
+	require.NoError(t, err)
+	require.Len(t, matrix.Failed(), 1)
+	assert.Contains(t, matrix.Failed()[0].Error, "vim")
+
+	require.NoError(t, fs.Remove(ctx, "/test/packages/.dotbootstrap.yaml"))
+	_, err = client.PlanMatrix(ctx, dot.PlanMatrixOptions{})
+	assert.True(t, errors.As(err, &dot.ErrInvalidBootstrap{}))
+}
+
+func TestClient_PlanMatrix_Profile(t *testing.T) {
+	ctx := context.Background()
+	_, client := setupPlanMatrixClient(t)
+
+	for _, name := range []string{"full", "bootstrap:full"} {
+		matrix, err := client.PlanMatrix(ctx, dot.PlanMatrixOptions{Profile: name})
+		require.NoError(t, err, name)
+		assert.Equal(t, []string{"full"}, matrix.Profiles, name)
+		require.Len(t, matrix.Entries, 1)
+		assert.Equal(t, "full", matrix.Entries[0].Profile)
+	}
+
+	_, err := client.PlanMatrix(ctx, dot.PlanMatrixOptions{Profile: "work"})
+	var notFound dot.ErrProfileNotFound
+	require.ErrorAs(t, err, &notFound)
+	assert.Equal(t, []string{"bootstrap:full", "bootstrap:minimal"}, notFound.Available)
+	assert.EqualError(t, err, "profile not found: work (available: bootstrap:full, bootstrap:minimal)")
+
+	_, err = client.PlanMatrix(ctx, dot.PlanMatrixOptions{Profile: "machine:full"})
+	require.ErrorAs(t, err, &notFound)
+	assert.Equal(t, dot.ProfileMachine, notFound.Kind)
+}
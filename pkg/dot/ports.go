@@ -1,12 +1,24 @@
 package dot
 
-import "github.com/jamesainslie/dot/internal/domain"
+import (
+	"context"
+
+	"github.com/jamesainslie/dot/internal/domain"
+)
 
 // Port interfaces re-exported from internal/domain
 
 // FS defines the filesystem abstraction interface.
 type FS = domain.FS
 
+// MetadataFS is implemented by filesystems that can change file modes
+// and times; backups use it to preserve the metadata of the original.
+type MetadataFS = domain.MetadataFS
+
+// HardlinkFS is implemented by filesystems that can create hard links and
+// count them; adopt uses it to warn about hard-linked files.
+type HardlinkFS = domain.HardlinkFS
+
 // FileInfo provides information about a file.
 type FileInfo = domain.FileInfo
 
@@ -16,6 +28,16 @@ type DirEntry = domain.DirEntry
 // Logger provides structured logging.
 type Logger = domain.Logger
 
+// LogFields correlate log records with the plan, package and operation
+// they were made for.
+type LogFields = domain.LogFields
+
+// LogFieldsFromContext returns the log fields of a context passed to a
+// Logger, for loggers that add them to their records.
+func LogFieldsFromContext(ctx context.Context) LogFields {
+	return domain.LogFieldsFromContext(ctx)
+}
+
 // Tracer provides distributed tracing support.
 type Tracer = domain.Tracer
 
@@ -31,6 +53,12 @@ type Attribute = domain.Attribute
 // Metrics provides metrics collection.
 type Metrics = domain.Metrics
 
+// EventSink receives per-operation progress events during execution.
+type EventSink = domain.EventSink
+
+// EventSinkFunc adapts a function to the EventSink interface.
+type EventSinkFunc = domain.EventSinkFunc
+
 // Counter represents a monotonically increasing counter.
 type Counter = domain.Counter
 
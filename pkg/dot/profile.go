@@ -0,0 +1,108 @@
+package dot
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/jamesainslie/dot/internal/bootstrap"
+)
+
+// ProfileKind is the kind of profile a profile name refers to.
+type ProfileKind string
+
+const (
+	// ProfileBootstrap is a named set of packages in the profiles of
+	// .dotbootstrap.yaml.
+	ProfileBootstrap ProfileKind = "bootstrap"
+
+	// ProfileMachine is the settings of one machine. The kind is reserved
+	// so that names can be qualified before machine profiles are defined;
+	// none are yet, so machine profiles are never found.
+	ProfileMachine ProfileKind = "machine"
+)
+
+// profileKinds lists the kinds of profile in resolution order.
+var profileKinds = []ProfileKind{ProfileBootstrap, ProfileMachine}
+
+// ProfileRef is a profile name given by a user, optionally qualified with
+// its kind as "bootstrap:NAME" or "machine:NAME".
+type ProfileRef struct {
+	// Kind is the kind the name was qualified with, or empty for a name
+	// to be looked up among every kind.
+	Kind ProfileKind
+
+	// Name is the name of the profile.
+	Name string
+}
+
+// ParseProfileRef parses a profile name, qualified with a kind or not.
+// Only the kinds of ProfileKind are read as qualifiers, so other names
+// containing a colon are taken whole.
+func ParseProfileRef(s string) ProfileRef {
+	if kind, name, ok := strings.Cut(s, ":"); ok && slices.Contains(profileKinds, ProfileKind(kind)) {
+		return ProfileRef{Kind: ProfileKind(kind), Name: name}
+	}
+	return ProfileRef{Name: s}
+}
+
+// String returns the profile name as it is written, with its qualifier
+// if it has one.
+func (r ProfileRef) String() string {
+	if r.Kind == "" {
+		return r.Name
+	}
+	return string(r.Kind) + ":" + r.Name
+}
+
+// resolveProfile returns the profile ref refers to among the profile names
+// defined for each kind.
+//
+// A qualified name resolves to its kind only. An unqualified name resolves
+// to the one kind defining it; a name defined by several kinds is
+// ambiguous and must be qualified. Returns ErrProfileNotFound, listing the
+// profiles that are defined, when no kind defines the name.
+func resolveProfile(ref ProfileRef, defined map[ProfileKind][]string) (ProfileRef, error) {
+	kinds := profileKinds
+	if ref.Kind != "" {
+		kinds = []ProfileKind{ref.Kind}
+	}
+
+	var found []ProfileKind
+	for _, kind := range kinds {
+		if slices.Contains(defined[kind], ref.Name) {
+			found = append(found, kind)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		notFound := ErrProfileNotFound{Profile: ref.String(), Kind: ref.Kind}
+		for _, kind := range kinds {
+			for _, name := range slices.Sorted(slices.Values(defined[kind])) {
+				notFound.Available = append(notFound.Available, ProfileRef{Kind: kind, Name: name}.String())
+			}
+		}
+		return ProfileRef{}, notFound
+	case 1:
+		return ProfileRef{Kind: found[0], Name: ref.Name}, nil
+	default:
+		return ProfileRef{}, ErrAmbiguousProfile{Profile: ref.Name, Kinds: found}
+	}
+}
+
+// selectPackagesFromProfile selects the packages of the bootstrap profile
+// named profileName, which may be qualified with its kind. A name that
+// resolves to a machine profile selects no packages and is an error.
+func selectPackagesFromProfile(config bootstrap.Config, profileName string) ([]string, error) {
+	resolved, err := resolveProfile(ParseProfileRef(profileName), map[ProfileKind][]string{
+		ProfileBootstrap: slices.Collect(maps.Keys(config.Profiles)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resolved.Kind != ProfileBootstrap {
+		return nil, ErrProfileKind{Profile: resolved.String(), Want: ProfileBootstrap}
+	}
+	return config.Profiles[resolved.Name].Packages, nil
+}
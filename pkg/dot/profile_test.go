@@ -0,0 +1,83 @@
+package dot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/bootstrap"
+)
+
+func TestParseProfileRef(t *testing.T) {
+	tests := []struct {
+		in   string
+		want ProfileRef
+	}{
+		{"work", ProfileRef{Name: "work"}},
+		{"bootstrap:work", ProfileRef{Kind: ProfileBootstrap, Name: "work"}},
+		{"machine:laptop", ProfileRef{Kind: ProfileMachine, Name: "laptop"}},
+		{"team:work", ProfileRef{Name: "team:work"}},
+	}
+	for _, tt := range tests {
+		ref := ParseProfileRef(tt.in)
+		assert.Equal(t, tt.want, ref, tt.in)
+		assert.Equal(t, tt.in, ref.String())
+	}
+}
+
+func TestResolveProfile(t *testing.T) {
+	defined := map[ProfileKind][]string{
+		ProfileBootstrap: {"work", "minimal"},
+		ProfileMachine:   {"work", "laptop"},
+	}
+
+	t.Run("unique names resolve to their kind", func(t *testing.T) {
+		ref, err := resolveProfile(ParseProfileRef("minimal"), defined)
+		require.NoError(t, err)
+		assert.Equal(t, ProfileRef{Kind: ProfileBootstrap, Name: "minimal"}, ref)
+
+		ref, err = resolveProfile(ParseProfileRef("laptop"), defined)
+		require.NoError(t, err)
+		assert.Equal(t, ProfileRef{Kind: ProfileMachine, Name: "laptop"}, ref)
+	})
+
+	t.Run("names of several kinds are ambiguous", func(t *testing.T) {
+		_, err := resolveProfile(ParseProfileRef("work"), defined)
+		var ambiguous ErrAmbiguousProfile
+		require.ErrorAs(t, err, &ambiguous)
+		assert.Equal(t, []ProfileKind{ProfileBootstrap, ProfileMachine}, ambiguous.Kinds)
+		assert.EqualError(t, err, `profile "work" is ambiguous: use bootstrap:work or machine:work`)
+	})
+
+	t.Run("qualified names resolve to their kind only", func(t *testing.T) {
+		ref, err := resolveProfile(ParseProfileRef("machine:work"), defined)
+		require.NoError(t, err)
+		assert.Equal(t, ProfileRef{Kind: ProfileMachine, Name: "work"}, ref)
+
+		_, err = resolveProfile(ParseProfileRef("bootstrap:laptop"), defined)
+		assert.EqualError(t, err, "profile not found: bootstrap:laptop (available: bootstrap:minimal, bootstrap:work)")
+	})
+
+	t.Run("missing names list every profile", func(t *testing.T) {
+		_, err := resolveProfile(ParseProfileRef("desktop"), defined)
+		var notFound ErrProfileNotFound
+		require.ErrorAs(t, err, &notFound)
+		assert.Equal(t, []string{"bootstrap:minimal", "bootstrap:work", "machine:laptop", "machine:work"}, notFound.Available)
+	})
+}
+
+func TestSelectPackagesFromProfile_Qualified(t *testing.T) {
+	config := bootstrap.Config{
+		Profiles: map[string]bootstrap.Profile{
+			"minimal": {Packages: []string{"vim"}},
+		},
+	}
+
+	packages, err := selectPackagesFromProfile(config, "bootstrap:minimal")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"vim"}, packages)
+
+	_, err = selectPackagesFromProfile(config, "machine:minimal")
+	assert.IsType(t, ErrProfileNotFound{}, err)
+}
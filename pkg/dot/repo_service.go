@@ -0,0 +1,402 @@
+package dot
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/internal/manifest"
+)
+
+// defaultRemote is the remote that clone creates and pull and push use.
+const defaultRemote = "origin"
+
+// RepoService manages the git repository checked out in the package directory.
+type RepoService struct {
+	logger      Logger
+	manifestSvc *ManifestService
+	puller      adapters.GitPuller
+	history     adapters.GitHistory
+	repository  adapters.GitRepository
+	packageDir  string
+	targetDir   string
+	dryRun      bool
+	offline     bool
+}
+
+// newRepoService creates a new repository service.
+func newRepoService(
+	logger Logger,
+	manifestSvc *ManifestService,
+	puller adapters.GitPuller,
+	history adapters.GitHistory,
+	repository adapters.GitRepository,
+	packageDir string,
+	targetDir string,
+	dryRun bool,
+	offline bool,
+) *RepoService {
+	return &RepoService{
+		logger:      logger,
+		manifestSvc: manifestSvc,
+		puller:      puller,
+		history:     history,
+		repository:  repository,
+		packageDir:  packageDir,
+		targetDir:   targetDir,
+		dryRun:      dryRun,
+		offline:     offline,
+	}
+}
+
+// RepoStatus describes the package repository checkout.
+type RepoStatus struct {
+	// Path is the package directory.
+	Path string
+
+	// Branch is the checked-out branch, or empty if HEAD is detached.
+	Branch string
+
+	// Head is the commit checked out.
+	Head string
+
+	// SyncedCommit is the commit last applied by clone or sync, as recorded
+	// in the manifest. Empty if no commit was recorded.
+	SyncedCommit string
+
+	// RemoteURL is the URL of the origin remote.
+	RemoteURL string
+
+	// Upstream names the remote-tracking branch, such as "origin/main".
+	// Empty if the branch has none.
+	Upstream string
+
+	// Ahead is the number of local commits not yet pushed to Upstream.
+	Ahead int
+
+	// Behind is the number of fetched Upstream commits not yet pulled.
+	Behind int
+
+	// Changes lists uncommitted changes, sorted by path.
+	Changes []RepoChange
+
+	// Packages lists the packages with uncommitted changes.
+	Packages []string
+}
+
+// RepoChange describes an uncommitted change in the package repository.
+type RepoChange struct {
+	// Path is the path relative to the package directory.
+	Path string
+
+	// Status is one of "modified", "added", "deleted", "renamed",
+	// or "untracked".
+	Status string
+}
+
+// Unsynced reports whether the checkout has moved past the commit last
+// applied to the target directory. Sync applies the difference.
+func (s RepoStatus) Unsynced() bool {
+	return s.SyncedCommit != "" && s.SyncedCommit != s.Head
+}
+
+// RepoPullResult describes the outcome of pulling the package repository.
+type RepoPullResult struct {
+	// FromCommit is the commit checked out before pulling.
+	FromCommit string
+
+	// ToCommit is the commit checked out after pulling. In dry-run mode
+	// nothing is pulled and it equals FromCommit.
+	ToCommit string
+
+	// Changed lists the files changed by the pull.
+	Changed []string
+
+	// Rebased is the number of per-machine commits replayed onto the base
+	// branch. Always zero for branches that are pulled directly.
+	Rebased int
+}
+
+// UpToDate reports whether the pull brought in no new commits.
+func (r RepoPullResult) UpToDate() bool {
+	return r.FromCommit == r.ToCommit
+}
+
+// Status reports the state of the package repository.
+func (s *RepoService) Status(ctx context.Context) (RepoStatus, error) {
+	repoStatus, err := s.repository.Status(ctx, s.packageDir)
+	if err != nil {
+		return RepoStatus{}, fmt.Errorf("inspect repository %s: %w", s.packageDir, err)
+	}
+
+	status := RepoStatus{
+		Path:         s.packageDir,
+		Branch:       repoStatus.Branch,
+		Head:         repoStatus.Head,
+		SyncedCommit: s.repositoryInfo(ctx).CommitSHA,
+		RemoteURL:    repoStatus.RemoteURL,
+		Upstream:     repoStatus.Upstream,
+		Ahead:        repoStatus.Ahead,
+		Behind:       repoStatus.Behind,
+		Changes:      make([]RepoChange, 0, len(repoStatus.Changes)),
+	}
+
+	files := make([]string, 0, len(repoStatus.Changes))
+	for _, change := range repoStatus.Changes {
+		status.Changes = append(status.Changes, RepoChange(change))
+		files = append(files, change.Path)
+	}
+	status.Packages = packagesOf(files)
+
+	return status, nil
+}
+
+// Pull fetches and fast-forwards the package repository, or rebases a
+// per-machine branch onto its base branch, without touching the target
+// directory. Sync applies the pulled changes to installed
+// packages. In dry-run mode nothing is pulled.
+func (s *RepoService) Pull(ctx context.Context) (RepoPullResult, error) {
+	from, err := s.puller.Head(ctx, s.packageDir)
+	if err != nil {
+		return RepoPullResult{}, ErrPullFailed{Path: s.packageDir, Cause: err}
+	}
+	result := RepoPullResult{FromCommit: from, ToCommit: from}
+
+	if s.dryRun {
+		s.logger.Info(ctx, "dry_run_repo_pull", "path", s.packageDir)
+		return result, nil
+	}
+	if s.offline {
+		return result, ErrOffline{Operation: "repo pull"}
+	}
+
+	rebased, err := updateCheckout(ctx, s.logger, s.puller, s.packageDir, s.repositoryInfo(ctx))
+	if err != nil {
+		return result, err
+	}
+	result.Rebased = rebased
+
+	to, err := s.puller.Head(ctx, s.packageDir)
+	if err != nil {
+		return result, ErrPullFailed{Path: s.packageDir, Cause: err}
+	}
+	result.ToCommit = to
+
+	if !result.UpToDate() {
+		changed, err := s.puller.ChangedFiles(ctx, s.packageDir, from, to)
+		if err != nil {
+			s.logger.Warn(ctx, "diff_failed", "from", from, "to", to, "error", err)
+		}
+		result.Changed = changed
+	}
+
+	return result, nil
+}
+
+// RepoPushOptions configures pushing the package repository.
+type RepoPushOptions struct {
+	// Force overwrites the remote branch even if the push is not a
+	// fast-forward, as needed after sync rebases a per-machine branch.
+	Force bool
+}
+
+// Push pushes the checked-out branch of the package repository to origin.
+// In dry-run mode nothing is pushed.
+func (s *RepoService) Push(ctx context.Context, opts RepoPushOptions) error {
+	if s.dryRun {
+		s.logger.Info(ctx, "dry_run_repo_push", "path", s.packageDir)
+		return nil
+	}
+	if s.offline {
+		return ErrOffline{Operation: "repo push"}
+	}
+
+	auth, err := resolveRepoAuth(ctx, s.repositoryInfo(ctx).URL)
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info(ctx, "pushing_repository", "path", s.packageDir, "force", opts.Force)
+	if err := s.repository.Push(ctx, s.packageDir, adapters.PushOptions{Auth: auth, Force: opts.Force}); err != nil {
+		s.logger.Error(ctx, "git_push_failed", "error", err)
+		return ErrPushFailed{Path: s.packageDir, Cause: err}
+	}
+
+	return nil
+}
+
+// SetRemoteURL changes the URL of a remote of the package repository.
+// Changing origin also updates the repository URL recorded in the manifest,
+// which selects credentials for later pulls and pushes. In dry-run mode
+// nothing is changed.
+func (s *RepoService) SetRemoteURL(ctx context.Context, remote, url string) error {
+	if s.dryRun {
+		s.logger.Info(ctx, "dry_run_set_remote_url", "remote", remote, "url", url)
+		return nil
+	}
+
+	s.logger.Info(ctx, "setting_remote_url", "path", s.packageDir, "remote", remote, "url", url)
+	if err := s.repository.SetRemoteURL(ctx, s.packageDir, remote, url); err != nil {
+		return fmt.Errorf("set remote url: %w", err)
+	}
+
+	if remote != defaultRemote {
+		return nil
+	}
+	if err := s.recordURL(ctx, url); err != nil {
+		s.logger.Warn(ctx, "failed_to_record_repository_url", "error", err)
+	}
+	return nil
+}
+
+// recordURL updates the repository URL in the manifest, if one is recorded.
+func (s *RepoService) recordURL(ctx context.Context, url string) error {
+	targetPathResult := NewTargetPath(s.targetDir)
+	if !targetPathResult.IsOk() {
+		return targetPathResult.UnwrapErr()
+	}
+	targetPath := targetPathResult.Unwrap()
+
+	manifestResult := s.manifestSvc.Load(ctx, targetPath)
+	if !manifestResult.IsOk() {
+		return manifestResult.UnwrapErr()
+	}
+	m := manifestResult.Unwrap()
+
+	info, ok := m.GetRepository()
+	if !ok {
+		return nil
+	}
+	info.URL = url
+	m.SetRepository(info)
+
+	return s.manifestSvc.Save(ctx, targetPath, m)
+}
+
+// Unshallow fetches the full history of a shallow package repository.
+// It reports whether the repository was shallow; a repository that already
+// has full history is left untouched. In dry-run mode nothing is fetched.
+func (s *RepoService) Unshallow(ctx context.Context) (bool, error) {
+	shallow, err := s.history.IsShallow(ctx, s.packageDir)
+	if err != nil {
+		return false, fmt.Errorf("inspect repository %s: %w", s.packageDir, err)
+	}
+	if !shallow {
+		s.logger.Info(ctx, "repository_has_full_history", "path", s.packageDir)
+		return false, nil
+	}
+
+	if s.dryRun {
+		s.logger.Info(ctx, "dry_run_unshallow", "path", s.packageDir)
+		return true, nil
+	}
+	if s.offline {
+		return true, ErrOffline{Operation: "repo unshallow"}
+	}
+
+	auth, err := resolveRepoAuth(ctx, s.repositoryInfo(ctx).URL)
+	if err != nil {
+		return true, err
+	}
+
+	s.logger.Info(ctx, "fetching_full_history", "path", s.packageDir)
+	if err := s.history.Unshallow(ctx, s.packageDir, adapters.PullOptions{Auth: auth}); err != nil {
+		s.logger.Error(ctx, "git_unshallow_failed", "error", err)
+		return true, ErrPullFailed{Path: s.packageDir, Cause: err}
+	}
+
+	return true, nil
+}
+
+// repositoryInfo returns the repository information recorded in the
+// manifest, or empty information if none was recorded.
+func (s *RepoService) repositoryInfo(ctx context.Context) manifest.RepositoryInfo {
+	targetPathResult := NewTargetPath(s.targetDir)
+	if !targetPathResult.IsOk() {
+		return manifest.RepositoryInfo{}
+	}
+
+	manifestResult := s.manifestSvc.Load(ctx, targetPathResult.Unwrap())
+	if !manifestResult.IsOk() {
+		return manifest.RepositoryInfo{}
+	}
+
+	m := manifestResult.Unwrap()
+	info, _ := m.GetRepository()
+	return info
+}
+
+// updateCheckout brings the package directory up to date with origin. A
+// per-machine branch, recorded with a base branch, is rebased onto the base
+// branch; any other branch is pulled. It returns the number of per-machine
+// commits replayed.
+func updateCheckout(ctx context.Context, logger Logger, puller adapters.GitPuller, packageDir string, info manifest.RepositoryInfo) (int, error) {
+	auth, err := resolveRepoAuth(ctx, info.URL)
+	if err != nil {
+		return 0, err
+	}
+
+	if info.BaseBranch == "" {
+		logger.Info(ctx, "pulling_repository", "path", packageDir, "branch", info.Branch)
+		if err := puller.Pull(ctx, packageDir, adapters.PullOptions{Auth: auth, Branch: info.Branch}); err != nil {
+			logger.Error(ctx, "git_pull_failed", "error", err)
+			return 0, ErrPullFailed{Path: packageDir, Cause: err}
+		}
+		return 0, nil
+	}
+
+	rebaser, ok := puller.(adapters.GitRebaser)
+	if !ok {
+		return 0, ErrPullFailed{Path: packageDir, Cause: fmt.Errorf("machine branches are not supported by this git backend")}
+	}
+
+	logger.Info(ctx, "rebasing_machine_branch", "path", packageDir, "branch", info.Branch, "onto", info.BaseBranch)
+	result, err := rebaser.Rebase(ctx, packageDir, info.BaseBranch, adapters.PullOptions{Auth: auth})
+	if err != nil {
+		logger.Error(ctx, "git_rebase_failed", "error", err)
+		return 0, ErrPullFailed{Path: packageDir, Cause: err}
+	}
+	if len(result.Conflicts) > 0 {
+		logger.Warn(ctx, "machine_branch_conflicts", "branch", info.Branch, "onto", info.BaseBranch, "files", result.Conflicts)
+		return 0, ErrRebaseConflict{
+			Branch:   info.Branch,
+			Onto:     info.BaseBranch,
+			Packages: packagesOf(result.Conflicts),
+			Files:    result.Conflicts,
+		}
+	}
+	return result.Replayed, nil
+}
+
+// packagesOf returns the sorted packages containing the given
+// repository-relative files. Files at the repository root and in hidden
+// directories belong to no package.
+func packagesOf(files []string) []string {
+	seen := make(map[string]bool)
+	var packages []string
+	for _, file := range files {
+		top, rest, found := strings.Cut(file, "/")
+		if !found || rest == "" || strings.HasPrefix(top, ".") || seen[top] {
+			continue
+		}
+		seen[top] = true
+		packages = append(packages, top)
+	}
+	sort.Strings(packages)
+	return packages
+}
+
+// resolveRepoAuth resolves authentication for a repository URL.
+// An empty URL resolves to no authentication.
+func resolveRepoAuth(ctx context.Context, url string) (adapters.AuthMethod, error) {
+	if url == "" {
+		return nil, nil
+	}
+	auth, err := adapters.ResolveAuth(ctx, url)
+	if err != nil {
+		return nil, ErrAuthFailed{Cause: err}
+	}
+	return auth, nil
+}
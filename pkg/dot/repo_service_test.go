@@ -0,0 +1,260 @@
+package dot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/internal/manifest"
+)
+
+func newRepoTestService(t *testing.T, history *mockGitPuller, dryRun, offline bool) *RepoService {
+	t.Helper()
+	svc, _ := newRepoTestServiceWithRepository(t, history, &mockGitRepository{}, dryRun, offline)
+	return svc
+}
+
+func newRepoTestServiceWithRepository(t *testing.T, puller *mockGitPuller, repository *mockGitRepository, dryRun, offline bool) (*RepoService, *ManifestService) {
+	t.Helper()
+	fs := adapters.NewMemFS()
+	require.NoError(t, fs.MkdirAll(context.Background(), "/home", 0755))
+	manifestSvc := newManifestService(fs, adapters.NewNoopLogger(), manifest.NewFSManifestStore(fs))
+	svc := newRepoService(adapters.NewNoopLogger(), manifestSvc, puller, puller, repository, "/packages", "/home", dryRun, offline)
+	return svc, manifestSvc
+}
+
+// mockGitRepository records repository maintenance calls.
+type mockGitRepository struct {
+	status  adapters.RepoStatus
+	pushErr error
+	pushed  bool
+	forced  bool
+	remotes map[string]string
+}
+
+func (m *mockGitRepository) Status(ctx context.Context, path string) (adapters.RepoStatus, error) {
+	return m.status, nil
+}
+
+func (m *mockGitRepository) Push(ctx context.Context, path string, opts adapters.PushOptions) error {
+	m.pushed = true
+	m.forced = opts.Force
+	return m.pushErr
+}
+
+func (m *mockGitRepository) SetRemoteURL(ctx context.Context, path string, remote string, url string) error {
+	if _, ok := m.remotes[remote]; !ok {
+		return fmt.Errorf("remote %q not found", remote)
+	}
+	m.remotes[remote] = url
+	return nil
+}
+
+// recordRepository stores repository information in the test manifest.
+func recordRepository(t *testing.T, manifestSvc *ManifestService, info manifest.RepositoryInfo) {
+	t.Helper()
+	ctx := context.Background()
+	targetPath := NewTargetPath("/home").Unwrap()
+	m := manifest.New()
+	m.SetRepository(info)
+	require.NoError(t, manifestSvc.Save(ctx, targetPath, m))
+}
+
+func TestRepoService_Unshallow(t *testing.T) {
+	history := &mockGitPuller{shallow: true}
+	svc := newRepoTestService(t, history, false, false)
+
+	wasShallow, err := svc.Unshallow(context.Background())
+	require.NoError(t, err)
+	assert.True(t, wasShallow)
+	assert.True(t, history.unshallowed)
+	assert.False(t, history.shallow)
+}
+
+func TestRepoService_Unshallow_FullHistory(t *testing.T) {
+	history := &mockGitPuller{}
+	svc := newRepoTestService(t, history, false, false)
+
+	wasShallow, err := svc.Unshallow(context.Background())
+	require.NoError(t, err)
+	assert.False(t, wasShallow)
+	assert.False(t, history.unshallowed)
+}
+
+func TestRepoService_Unshallow_DryRun(t *testing.T) {
+	history := &mockGitPuller{shallow: true}
+	svc := newRepoTestService(t, history, true, true)
+
+	wasShallow, err := svc.Unshallow(context.Background())
+	require.NoError(t, err)
+	assert.True(t, wasShallow)
+	assert.False(t, history.unshallowed)
+}
+
+func TestRepoService_Unshallow_Offline(t *testing.T) {
+	history := &mockGitPuller{shallow: true}
+	svc := newRepoTestService(t, history, false, true)
+
+	_, err := svc.Unshallow(context.Background())
+	var offline ErrOffline
+	require.ErrorAs(t, err, &offline)
+	assert.False(t, history.unshallowed)
+}
+
+func TestRepoService_Unshallow_FetchFailure(t *testing.T) {
+	history := &mockGitPuller{shallow: true, unshallowErr: errors.New("connection refused")}
+	svc := newRepoTestService(t, history, false, false)
+
+	_, err := svc.Unshallow(context.Background())
+	var pullErr ErrPullFailed
+	require.ErrorAs(t, err, &pullErr)
+	assert.Equal(t, "/packages", pullErr.Path)
+}
+
+func TestRepoService_Status(t *testing.T) {
+	repository := &mockGitRepository{status: adapters.RepoStatus{
+		Branch:   "main",
+		Head:     "def456",
+		Upstream: "origin/main",
+		Ahead:    1,
+		Changes: []adapters.FileChange{
+			{Path: ".gitignore", Status: "modified"},
+			{Path: "vim/dot-vimrc", Status: "modified"},
+			{Path: "zsh/dot-zshrc", Status: "untracked"},
+			{Path: "zsh/dot-zprofile", Status: "deleted"},
+		},
+	}}
+	svc, manifestSvc := newRepoTestServiceWithRepository(t, &mockGitPuller{}, repository, false, false)
+	recordRepository(t, manifestSvc, manifest.RepositoryInfo{URL: "https://example.com/dotfiles.git", CommitSHA: "abc123"})
+
+	status, err := svc.Status(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, "/packages", status.Path)
+	assert.Equal(t, "main", status.Branch)
+	assert.Equal(t, "abc123", status.SyncedCommit)
+	assert.True(t, status.Unsynced())
+	assert.Equal(t, 1, status.Ahead)
+	assert.Len(t, status.Changes, 4)
+	assert.Equal(t, []string{"vim", "zsh"}, status.Packages)
+}
+
+func TestRepoService_Pull(t *testing.T) {
+	puller := &mockGitPuller{head: "abc123", changed: []string{"vim/dot-vimrc"}}
+	puller.pullFn = func(ctx context.Context, path string, opts adapters.PullOptions) error {
+		assert.Equal(t, "main", opts.Branch)
+		puller.head = "def456"
+		return nil
+	}
+	svc, manifestSvc := newRepoTestServiceWithRepository(t, puller, &mockGitRepository{}, false, false)
+	recordRepository(t, manifestSvc, manifest.RepositoryInfo{Branch: "main", CommitSHA: "abc123"})
+
+	result, err := svc.Pull(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "abc123", result.FromCommit)
+	assert.Equal(t, "def456", result.ToCommit)
+	assert.Equal(t, []string{"vim/dot-vimrc"}, result.Changed)
+	assert.False(t, result.UpToDate())
+
+	// Pulling must not mark the changes as applied
+	status, err := svc.Status(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "abc123", status.SyncedCommit)
+}
+
+func TestRepoService_Pull_DryRunAndOffline(t *testing.T) {
+	puller := &mockGitPuller{head: "abc123"}
+	svc, _ := newRepoTestServiceWithRepository(t, puller, &mockGitRepository{}, true, true)
+
+	result, err := svc.Pull(context.Background())
+	require.NoError(t, err)
+	assert.True(t, result.UpToDate())
+	assert.False(t, puller.pulled)
+
+	svc, _ = newRepoTestServiceWithRepository(t, puller, &mockGitRepository{}, false, true)
+	_, err = svc.Pull(context.Background())
+	var offline ErrOffline
+	require.ErrorAs(t, err, &offline)
+	assert.False(t, puller.pulled)
+}
+
+func TestRepoService_Push(t *testing.T) {
+	repository := &mockGitRepository{}
+	svc, _ := newRepoTestServiceWithRepository(t, &mockGitPuller{}, repository, false, false)
+
+	require.NoError(t, svc.Push(context.Background(), RepoPushOptions{}))
+	assert.True(t, repository.pushed)
+}
+
+func TestRepoService_Push_Force(t *testing.T) {
+	repository := &mockGitRepository{}
+	svc, _ := newRepoTestServiceWithRepository(t, &mockGitPuller{}, repository, false, false)
+
+	require.NoError(t, svc.Push(context.Background(), RepoPushOptions{Force: true}))
+	assert.True(t, repository.forced)
+}
+
+func TestRepoService_Pull_MachineBranch(t *testing.T) {
+	puller := &mockGitPuller{head: "abc123", rebaseResult: adapters.RebaseResult{Replayed: 1}}
+	svc, manifestSvc := newRepoTestServiceWithRepository(t, puller, &mockGitRepository{}, false, false)
+	recordRepository(t, manifestSvc, manifest.RepositoryInfo{Branch: "laptop", BaseBranch: "main", CommitSHA: "abc123"})
+
+	result, err := svc.Pull(context.Background())
+	require.NoError(t, err)
+	assert.False(t, puller.pulled)
+	assert.Equal(t, "main", puller.rebasedOnto)
+	assert.Equal(t, 1, result.Rebased)
+}
+
+func TestRepoService_Push_Failure(t *testing.T) {
+	repository := &mockGitRepository{pushErr: errors.New("non-fast-forward update")}
+	svc, _ := newRepoTestServiceWithRepository(t, &mockGitPuller{}, repository, false, false)
+
+	err := svc.Push(context.Background(), RepoPushOptions{})
+	var pushErr ErrPushFailed
+	require.ErrorAs(t, err, &pushErr)
+	assert.Equal(t, "/packages", pushErr.Path)
+}
+
+func TestRepoService_Push_DryRunAndOffline(t *testing.T) {
+	repository := &mockGitRepository{}
+	svc, _ := newRepoTestServiceWithRepository(t, &mockGitPuller{}, repository, true, false)
+	require.NoError(t, svc.Push(context.Background(), RepoPushOptions{}))
+	assert.False(t, repository.pushed)
+
+	svc, _ = newRepoTestServiceWithRepository(t, &mockGitPuller{}, repository, false, true)
+	var offline ErrOffline
+	require.ErrorAs(t, svc.Push(context.Background(), RepoPushOptions{}), &offline)
+	assert.False(t, repository.pushed)
+}
+
+func TestRepoService_SetRemoteURL(t *testing.T) {
+	ctx := context.Background()
+	repository := &mockGitRepository{remotes: map[string]string{"origin": "https://old.example.com/dotfiles.git"}}
+	svc, manifestSvc := newRepoTestServiceWithRepository(t, &mockGitPuller{}, repository, false, false)
+	recordRepository(t, manifestSvc, manifest.RepositoryInfo{URL: "https://old.example.com/dotfiles.git", Branch: "main"})
+
+	require.NoError(t, svc.SetRemoteURL(ctx, "origin", "git@example.com:user/dotfiles.git"))
+	assert.Equal(t, "git@example.com:user/dotfiles.git", repository.remotes["origin"])
+
+	m := manifestSvc.Load(ctx, NewTargetPath("/home").Unwrap()).Unwrap()
+	info, ok := m.GetRepository()
+	require.True(t, ok)
+	assert.Equal(t, "git@example.com:user/dotfiles.git", info.URL)
+	assert.Equal(t, "main", info.Branch)
+
+	assert.ErrorContains(t, svc.SetRemoteURL(ctx, "upstream", "https://example.com"), "not found")
+}
+
+func TestRepoService_SetRemoteURL_DryRun(t *testing.T) {
+	repository := &mockGitRepository{remotes: map[string]string{"origin": "https://old.example.com/dotfiles.git"}}
+	svc, _ := newRepoTestServiceWithRepository(t, &mockGitPuller{}, repository, true, false)
+
+	require.NoError(t, svc.SetRemoteURL(context.Background(), "origin", "https://new.example.com/dotfiles.git"))
+	assert.Equal(t, "https://old.example.com/dotfiles.git", repository.remotes["origin"])
+}
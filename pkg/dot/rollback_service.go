@@ -0,0 +1,491 @@
+package dot
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jamesainslie/dot/internal/executor"
+	"github.com/jamesainslie/dot/internal/manifest"
+)
+
+// CheckpointInfo describes a stored execution checkpoint.
+type CheckpointInfo struct {
+	// ID uniquely identifies the checkpoint.
+	ID string `json:"id"`
+
+	// CreatedAt is when execution of the plan started.
+	CreatedAt time.Time `json:"created_at"`
+
+	// Status is "pending" for an execution that was interrupted, "completed"
+	// for one that finished, or "rolled_back" for one that was undone.
+	Status string `json:"status"`
+
+	// Operations lists the executed operations in execution order.
+	Operations []string `json:"operations"`
+
+	// Planned lists every operation of the journaled plan in plan order.
+	// For a pending checkpoint, the operations not in Operations are the
+	// ones a resume would run.
+	Planned []string `json:"planned,omitempty"`
+}
+
+// ResumeResult describes the outcome of resuming an interrupted execution.
+type ResumeResult struct {
+	// Checkpoint is the checkpoint that was resumed.
+	Checkpoint CheckpointInfo `json:"checkpoint"`
+
+	// Remaining lists the operations that had not run when execution was
+	// interrupted. Links found already in place are skipped, so fewer
+	// operations than this may have been executed.
+	Remaining []string `json:"remaining"`
+
+	// Executed is the number of operations run by the resume.
+	Executed int `json:"executed"`
+
+	// DryRun reports that nothing was changed.
+	DryRun bool `json:"dry_run"`
+}
+
+// RollbackResult describes the outcome of rolling back a checkpoint.
+type RollbackResult struct {
+	// Checkpoint is the checkpoint that was rolled back.
+	Checkpoint CheckpointInfo `json:"checkpoint"`
+
+	// RolledBack is the number of operations undone.
+	RolledBack int `json:"rolled_back"`
+
+	// DryRun reports that nothing was changed.
+	DryRun bool `json:"dry_run"`
+}
+
+// RollbackService undoes executed plans and resumes interrupted ones using
+// persisted checkpoints.
+type RollbackService struct {
+	logger      Logger
+	executor    *executor.Executor
+	store       *executor.FSCheckpointStore
+	manifestSvc *ManifestService
+	packageDir  string
+	targetDir   string
+	dryRun      bool
+}
+
+// newRollbackService creates a new rollback service.
+// A nil store means checkpoints are not persisted.
+func newRollbackService(
+	logger Logger,
+	exec *executor.Executor,
+	store *executor.FSCheckpointStore,
+	manifestSvc *ManifestService,
+	packageDir string,
+	targetDir string,
+	dryRun bool,
+) *RollbackService {
+	return &RollbackService{
+		logger:      logger,
+		executor:    exec,
+		store:       store,
+		manifestSvc: manifestSvc,
+		packageDir:  packageDir,
+		targetDir:   targetDir,
+		dryRun:      dryRun,
+	}
+}
+
+// Checkpoints returns stored checkpoints, newest first.
+func (s *RollbackService) Checkpoints(ctx context.Context) ([]CheckpointInfo, error) {
+	if s.store == nil {
+		return nil, ErrCheckpointsDisabled{}
+	}
+
+	checkpoints, err := s.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]CheckpointInfo, 0, len(checkpoints))
+	for _, checkpoint := range checkpoints {
+		infos = append(infos, newCheckpointInfo(checkpoint))
+	}
+	return infos, nil
+}
+
+// Rollback undoes the plan recorded by the checkpoint with the given ID or
+// unique ID prefix. An empty ID selects the most recent checkpoint that has
+// not been rolled back.
+//
+// Only the newest checkpoint that has not been rolled back can be rolled
+// back, since the plans of later checkpoints may have changed the same
+// paths. Links created by the rolled-back plan are removed from the
+// manifest, and links it deleted are added back to their packages.
+func (s *RollbackService) Rollback(ctx context.Context, id string) (RollbackResult, error) {
+	if s.store == nil {
+		return RollbackResult{}, ErrCheckpointsDisabled{}
+	}
+
+	checkpoint, err := s.resolve(ctx, id, func(c *executor.Checkpoint) bool {
+		return c.Status != executor.CheckpointRolledBack
+	})
+	if err != nil {
+		return RollbackResult{}, err
+	}
+	if err := s.checkNewest(ctx, checkpoint); err != nil {
+		return RollbackResult{}, err
+	}
+
+	result := RollbackResult{
+		Checkpoint: newCheckpointInfo(checkpoint),
+		DryRun:     s.dryRun,
+	}
+	if s.dryRun {
+		s.logger.Info(ctx, "dry_run_rollback", "checkpoint_id", checkpoint.ID, "operations", checkpoint.Len())
+		return result, nil
+	}
+
+	s.logger.Info(ctx, "rolling_back_checkpoint", "checkpoint_id", checkpoint.ID, "operations", checkpoint.Len())
+	execResult := s.executor.Rollback(ctx, checkpoint.ID)
+	if !execResult.IsOk() {
+		return result, execResult.UnwrapErr()
+	}
+	result.RolledBack = len(execResult.Unwrap().RolledBack)
+	result.Checkpoint.Status = string(executor.CheckpointRolledBack)
+
+	created := linkTargets(checkpoint.ListOperations(), OpKindLinkCreate)
+	if err := s.pruneManifest(ctx, created); err != nil {
+		s.logger.Warn(ctx, "manifest_prune_failed", "checkpoint_id", checkpoint.ID, "error", err)
+	}
+	if err := s.restoreManifest(ctx, s.restoredLinks(checkpoint)); err != nil {
+		s.logger.Warn(ctx, "manifest_restore_failed", "checkpoint_id", checkpoint.ID, "error", err)
+	}
+
+	return result, nil
+}
+
+// checkNewest returns an error if a checkpoint newer than checkpoint has
+// not been rolled back.
+func (s *RollbackService) checkNewest(ctx context.Context, checkpoint *executor.Checkpoint) error {
+	checkpoints, err := s.store.List(ctx)
+	if err != nil {
+		return err
+	}
+	for _, newer := range checkpoints {
+		if newer.ID == checkpoint.ID {
+			return nil
+		}
+		if newer.Status != executor.CheckpointRolledBack {
+			return fmt.Errorf("checkpoint %s is followed by checkpoint %s, which has not been rolled back: roll back %s first", checkpoint.ID, newer.ID, newer.ID)
+		}
+	}
+	return nil
+}
+
+// restoredLinks returns the plan of links recreated by rolling back the
+// link deletions of checkpoint, attributed to the packages they belong to.
+// Links outside the package directory are left out.
+func (s *RollbackService) restoredLinks(checkpoint *executor.Checkpoint) Plan {
+	plan := Plan{PackageOperations: make(map[string][]OperationID)}
+	for _, op := range checkpoint.ListOperations() {
+		deleted, ok := op.(LinkDelete)
+		if !ok || deleted.Previous == "" {
+			continue
+		}
+		source := absLinkTarget(deleted.Target.String(), deleted.Previous)
+		pkg := checkpoint.Package(deleted.ID())
+		if pkg == "" {
+			pkg = packageOfPath(s.packageDir, source)
+		}
+		sourcePathResult := NewFilePath(source)
+		if pkg == "" || !sourcePathResult.IsOk() {
+			continue
+		}
+
+		created := NewLinkCreate(deleted.ID(), sourcePathResult.Unwrap(), deleted.Target)
+		plan.Operations = append(plan.Operations, created)
+		plan.PackageOperations[pkg] = append(plan.PackageOperations[pkg], created.ID())
+	}
+	return plan
+}
+
+// restoreManifest records the links created by plan in the manifest.
+// Packages still in the manifest keep their record and gain the links;
+// the others are recorded afresh as if just managed.
+func (s *RollbackService) restoreManifest(ctx context.Context, plan Plan) error {
+	var restored []string
+	for _, pkg := range plan.PackageNames() {
+		if len(linkTargets(plan.OperationsForPackage(pkg), OpKindLinkCreate)) > 0 {
+			restored = append(restored, pkg)
+		}
+	}
+	if len(restored) == 0 {
+		return nil
+	}
+
+	targetPathResult := NewTargetPath(s.targetDir)
+	if !targetPathResult.IsOk() {
+		return targetPathResult.UnwrapErr()
+	}
+	targetPath := targetPathResult.Unwrap()
+
+	manifestResult := s.manifestSvc.Load(ctx, targetPath)
+	if !manifestResult.IsOk() {
+		return manifestResult.UnwrapErr()
+	}
+	m := manifestResult.Unwrap()
+
+	var added []string
+	for _, pkg := range restored {
+		info, ok := m.GetPackage(pkg)
+		if !ok {
+			added = append(added, pkg)
+			continue
+		}
+		for _, link := range s.manifestSvc.extractLinksFromOperations(plan.OperationsForPackage(pkg), s.targetDir) {
+			if !slices.Contains(info.Links, link) {
+				info.Links = append(info.Links, link)
+			}
+		}
+		info.LinkCount = len(info.Links)
+		m.AddPackage(info)
+	}
+	if err := s.manifestSvc.Save(ctx, targetPath, m); err != nil {
+		return err
+	}
+	if len(added) == 0 {
+		return nil
+	}
+	return s.manifestSvc.UpdateWithSource(ctx, targetPath, s.packageDir, added, plan, manifest.SourceManaged)
+}
+
+// Interrupted returns checkpoints of executions that never finished, newest
+// first. It returns nil when checkpoints are not persisted.
+func (s *RollbackService) Interrupted(ctx context.Context) ([]CheckpointInfo, error) {
+	if s.store == nil {
+		return nil, nil
+	}
+
+	checkpoints, err := s.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var infos []CheckpointInfo
+	for _, checkpoint := range checkpoints {
+		if checkpoint.Status == executor.CheckpointPending {
+			infos = append(infos, newCheckpointInfo(checkpoint))
+		}
+	}
+	return infos, nil
+}
+
+// Resume completes the interrupted execution recorded by the checkpoint with
+// the given ID or unique ID prefix. An empty ID selects the most recent
+// interrupted execution.
+//
+// Once the remaining operations have run, the manifest is updated as the
+// interrupted command would have done.
+func (s *RollbackService) Resume(ctx context.Context, id string) (ResumeResult, error) {
+	if s.store == nil {
+		return ResumeResult{}, ErrCheckpointsDisabled{}
+	}
+
+	checkpoint, err := s.resolve(ctx, id, func(c *executor.Checkpoint) bool {
+		return c.Status == executor.CheckpointPending
+	})
+	if err != nil {
+		return ResumeResult{}, err
+	}
+
+	remaining := checkpoint.Remaining()
+	result := ResumeResult{
+		Checkpoint: newCheckpointInfo(checkpoint),
+		Remaining:  operationDescriptions(remaining),
+		DryRun:     s.dryRun,
+	}
+	if s.dryRun {
+		s.logger.Info(ctx, "dry_run_resume", "checkpoint_id", checkpoint.ID, "remaining", len(remaining))
+		return result, nil
+	}
+
+	s.logger.Info(ctx, "resuming_checkpoint", "checkpoint_id", checkpoint.ID, "remaining", len(remaining))
+	execResult := s.executor.Resume(ctx, checkpoint.ID)
+	if !execResult.IsOk() {
+		return result, execResult.UnwrapErr()
+	}
+	result.Executed = len(execResult.Unwrap().Executed)
+	result.Checkpoint.Status = string(executor.CheckpointCompleted)
+
+	plan, _ := checkpoint.Plan()
+	if err := s.completeManifest(ctx, plan); err != nil {
+		s.logger.Warn(ctx, "manifest_update_failed", "checkpoint_id", checkpoint.ID, "error", err)
+	}
+
+	return result, nil
+}
+
+// completeManifest applies the manifest changes of a resumed plan. Plans
+// that attribute operations to packages come from manage, remanage, or
+// adopt and register those packages; other plans come from unmanage and
+// have their deleted links removed.
+func (s *RollbackService) completeManifest(ctx context.Context, plan Plan) error {
+	if len(plan.PackageOperations) == 0 {
+		return s.pruneManifest(ctx, linkTargets(plan.Operations, OpKindLinkDelete))
+	}
+
+	targetPathResult := NewTargetPath(s.targetDir)
+	if !targetPathResult.IsOk() {
+		return targetPathResult.UnwrapErr()
+	}
+
+	source := manifest.SourceManaged
+	for _, op := range plan.Operations {
+		if op.Kind() == OpKindFileMove {
+			source = manifest.SourceAdopted
+			break
+		}
+	}
+	return s.manifestSvc.UpdateWithSource(ctx, targetPathResult.Unwrap(), s.packageDir, plan.PackageNames(), plan, source)
+}
+
+// resolve finds a checkpoint by ID or unique ID prefix. An empty ID selects
+// the newest checkpoint accepted by latest.
+func (s *RollbackService) resolve(ctx context.Context, id string, latest func(*executor.Checkpoint) bool) (*executor.Checkpoint, error) {
+	checkpoints, err := s.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if id == "" {
+		for _, checkpoint := range checkpoints {
+			if latest(checkpoint) {
+				return checkpoint, nil
+			}
+		}
+		return nil, ErrCheckpointNotFound{ID: id}
+	}
+
+	var matches []*executor.Checkpoint
+	for _, checkpoint := range checkpoints {
+		if string(checkpoint.ID) == id {
+			return checkpoint, nil
+		}
+		if strings.HasPrefix(string(checkpoint.ID), id) {
+			matches = append(matches, checkpoint)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, ErrCheckpointNotFound{ID: id}
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, fmt.Errorf("checkpoint prefix %q is ambiguous: matches %d checkpoints", id, len(matches))
+	}
+}
+
+// linkTargets returns the targets of link operations of the given kind.
+func linkTargets(ops []Operation, kind OperationKind) []string {
+	var targets []string
+	for _, op := range ops {
+		switch typed := op.(type) {
+		case LinkCreate:
+			if kind == OpKindLinkCreate {
+				targets = append(targets, typed.Target.String())
+			}
+		case LinkDelete:
+			if kind == OpKindLinkDelete {
+				targets = append(targets, typed.Target.String())
+			}
+		}
+	}
+	return targets
+}
+
+// pruneManifest removes the given link targets from the manifest, dropping
+// packages left without links.
+func (s *RollbackService) pruneManifest(ctx context.Context, targets []string) error {
+	if len(targets) == 0 {
+		return nil
+	}
+	removed := make(map[string]bool, len(targets))
+	for _, target := range targets {
+		rel, err := filepath.Rel(s.targetDir, target)
+		if err != nil {
+			rel = target
+		}
+		removed[rel] = true
+	}
+
+	targetPathResult := NewTargetPath(s.targetDir)
+	if !targetPathResult.IsOk() {
+		return targetPathResult.UnwrapErr()
+	}
+	targetPath := targetPathResult.Unwrap()
+
+	manifestResult := s.manifestSvc.Load(ctx, targetPath)
+	if !manifestResult.IsOk() {
+		return manifestResult.UnwrapErr()
+	}
+	m := manifestResult.Unwrap()
+
+	changed := false
+	for _, pkg := range m.PackageList() {
+		links := make([]string, 0, len(pkg.Links))
+		for _, link := range pkg.Links {
+			if !removed[link] {
+				links = append(links, link)
+			}
+		}
+		if len(links) == len(pkg.Links) {
+			continue
+		}
+
+		changed = true
+		if len(links) == 0 {
+			m.RemovePackage(pkg.Name)
+			continue
+		}
+		templates := pkg.Templates[:0:0]
+		for _, render := range pkg.Templates {
+			if !removed[render.Link] {
+				templates = append(templates, render)
+			}
+		}
+		pkg.Links = links
+		pkg.LinkCount = len(links)
+		pkg.Templates = templates
+		m.AddPackage(pkg)
+	}
+
+	if !changed {
+		return nil
+	}
+	return s.manifestSvc.Save(ctx, targetPath, m)
+}
+
+// newCheckpointInfo converts an executor checkpoint to its public form.
+func newCheckpointInfo(checkpoint *executor.Checkpoint) CheckpointInfo {
+	info := CheckpointInfo{
+		ID:         string(checkpoint.ID),
+		CreatedAt:  checkpoint.CreatedAt,
+		Status:     string(checkpoint.Status),
+		Operations: operationDescriptions(checkpoint.ListOperations()),
+	}
+	if plan, ok := checkpoint.Plan(); ok {
+		info.Planned = operationDescriptions(plan.Operations)
+	}
+	return info
+}
+
+// operationDescriptions describes each operation for display.
+func operationDescriptions(ops []Operation) []string {
+	descriptions := make([]string, 0, len(ops))
+	for _, op := range ops {
+		descriptions = append(descriptions, op.String())
+	}
+	return descriptions
+}
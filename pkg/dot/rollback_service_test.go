@@ -0,0 +1,280 @@
+package dot_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/internal/executor"
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+func setupRollbackClient(t *testing.T, fs *adapters.MemFS, dryRun bool) *dot.Client {
+	t.Helper()
+	client, err := dot.NewClient(dot.Config{
+		PackageDir:    "/test/packages",
+		TargetDir:     "/test/target",
+		CheckpointDir: "/test/state/checkpoints",
+		DryRun:        dryRun,
+		FS:            fs,
+		Logger:        adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+	return client
+}
+
+func setupRollbackFS(t *testing.T) *adapters.MemFS {
+	t.Helper()
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/vim", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/zsh", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/vim/dot-vimrc", []byte("set nocompatible"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/zsh/dot-zshrc", []byte("export EDITOR=vim"), 0644))
+	return fs
+}
+
+func TestClient_Rollback_UndoesLatestManage(t *testing.T) {
+	ctx := context.Background()
+	fs := setupRollbackFS(t)
+	client := setupRollbackClient(t, fs, false)
+
+	require.NoError(t, client.Manage(ctx, "zsh"))
+	require.NoError(t, client.Manage(ctx, "vim"))
+	require.True(t, fs.Exists(ctx, "/test/target/.vimrc"))
+
+	result, err := client.Rollback(ctx, "")
+	require.NoError(t, err)
+	assert.False(t, result.DryRun)
+	assert.Equal(t, 1, result.RolledBack)
+	assert.Equal(t, "rolled_back", result.Checkpoint.Status)
+
+	assert.False(t, fs.Exists(ctx, "/test/target/.vimrc"))
+	assert.True(t, fs.Exists(ctx, "/test/target/.zshrc"), "earlier manage is untouched")
+
+	packages, err := client.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, packages, 1)
+	assert.Equal(t, "zsh", packages[0].Name)
+
+	checkpoints, err := client.Checkpoints(ctx)
+	require.NoError(t, err)
+	require.Len(t, checkpoints, 2)
+	assert.Equal(t, "rolled_back", checkpoints[0].Status)
+	assert.Equal(t, "completed", checkpoints[1].Status)
+
+	// The next rollback skips the rolled-back checkpoint
+	_, err = client.Rollback(ctx, "")
+	require.NoError(t, err)
+	assert.False(t, fs.Exists(ctx, "/test/target/.zshrc"))
+}
+
+func TestClient_Rollback_ByIDPrefix(t *testing.T) {
+	ctx := context.Background()
+	fs := setupRollbackFS(t)
+	client := setupRollbackClient(t, fs, false)
+
+	require.NoError(t, client.Manage(ctx, "vim"))
+	checkpoints, err := client.Checkpoints(ctx)
+	require.NoError(t, err)
+	require.Len(t, checkpoints, 1)
+
+	result, err := client.Rollback(ctx, checkpoints[0].ID[:8])
+	require.NoError(t, err)
+	assert.Equal(t, checkpoints[0].ID, result.Checkpoint.ID)
+	assert.False(t, fs.Exists(ctx, "/test/target/.vimrc"))
+
+	// Rolling back the same checkpoint again fails
+	_, err = client.Rollback(ctx, checkpoints[0].ID)
+	require.Error(t, err)
+}
+
+func TestClient_Rollback_NotFound(t *testing.T) {
+	ctx := context.Background()
+	client := setupRollbackClient(t, setupRollbackFS(t), false)
+
+	_, err := client.Rollback(ctx, "")
+	var notFound dot.ErrCheckpointNotFound
+	require.ErrorAs(t, err, &notFound)
+
+	_, err = client.Rollback(ctx, "deadbeef")
+	require.ErrorAs(t, err, &notFound)
+	assert.Equal(t, "deadbeef", notFound.ID)
+}
+
+func TestClient_Rollback_DryRun(t *testing.T) {
+	ctx := context.Background()
+	fs := setupRollbackFS(t)
+	require.NoError(t, setupRollbackClient(t, fs, false).Manage(ctx, "vim"))
+
+	result, err := setupRollbackClient(t, fs, true).Rollback(ctx, "")
+	require.NoError(t, err)
+	assert.True(t, result.DryRun)
+	assert.Equal(t, 0, result.RolledBack)
+	assert.Len(t, result.Checkpoint.Operations, 1)
+	assert.Equal(t, "completed", result.Checkpoint.Status)
+
+	assert.True(t, fs.Exists(ctx, "/test/target/.vimrc"))
+}
+
+func TestClient_Rollback_CheckpointsDisabled(t *testing.T) {
+	ctx := context.Background()
+	client, err := dot.NewClient(dot.Config{
+		PackageDir: "/test/packages",
+		TargetDir:  "/test/target",
+		FS:         setupRollbackFS(t),
+		Logger:     adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+
+	_, err = client.Rollback(ctx, "")
+	require.ErrorIs(t, err, dot.ErrCheckpointsDisabled{})
+
+	_, err = client.Checkpoints(ctx)
+	require.ErrorIs(t, err, dot.ErrCheckpointsDisabled{})
+
+	_, err = client.Resume(ctx, "")
+	require.ErrorIs(t, err, dot.ErrCheckpointsDisabled{})
+
+	interrupted, err := client.Interrupted(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, interrupted)
+}
+
+// interruptManage journals the plan for managing pkg and applies only its
+// first operation, as if power was lost part way through dot manage.
+func interruptManage(t *testing.T, fs *adapters.MemFS, client *dot.Client, pkg string) string {
+	t.Helper()
+	ctx := context.Background()
+
+	plan, err := client.PlanManage(ctx, pkg)
+	require.NoError(t, err)
+	require.NotEmpty(t, plan.Operations)
+
+	checkpoint := executor.NewFSCheckpointStore(fs, "/test/state/checkpoints").Create(ctx)
+	checkpoint.SetPlan(plan)
+	first := plan.Operations[0]
+	require.NoError(t, first.Execute(ctx, fs))
+	checkpoint.Record(first.ID(), first)
+	return string(checkpoint.ID)
+}
+
+func TestClient_Resume_CompletesInterruptedManage(t *testing.T) {
+	ctx := context.Background()
+	fs := setupRollbackFS(t)
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/nvim/dot-config/nvim", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/nvim/dot-config/nvim/init.lua", []byte("-- nvim"), 0644))
+	client := setupRollbackClient(t, fs, false)
+
+	id := interruptManage(t, fs, client, "nvim")
+
+	interrupted, err := client.Interrupted(ctx)
+	require.NoError(t, err)
+	require.Len(t, interrupted, 1)
+	assert.Equal(t, id, interrupted[0].ID)
+	assert.Less(t, len(interrupted[0].Operations), len(interrupted[0].Planned))
+
+	result, err := client.Resume(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, "completed", result.Checkpoint.Status)
+	assert.NotEmpty(t, result.Remaining)
+
+	packages, err := client.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, packages, 1)
+	assert.Equal(t, "nvim", packages[0].Name)
+	for _, link := range packages[0].Links {
+		_, err := fs.ReadLink(ctx, "/test/target/"+link)
+		assert.NoError(t, err, "link %s should exist", link)
+	}
+
+	interrupted, err = client.Interrupted(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, interrupted)
+
+	// Nothing is left to resume
+	_, err = client.Resume(ctx, "")
+	var notFound dot.ErrCheckpointNotFound
+	require.ErrorAs(t, err, &notFound)
+}
+
+func TestClient_Resume_DryRun(t *testing.T) {
+	ctx := context.Background()
+	fs := setupRollbackFS(t)
+	id := interruptManage(t, fs, setupRollbackClient(t, fs, false), "vim")
+
+	result, err := setupRollbackClient(t, fs, true).Resume(ctx, id[:8])
+	require.NoError(t, err)
+	assert.True(t, result.DryRun)
+	assert.Equal(t, 0, result.Executed)
+	assert.Equal(t, "pending", result.Checkpoint.Status)
+}
+
+func TestClient_Rollback_InterruptedManage(t *testing.T) {
+	ctx := context.Background()
+	fs := setupRollbackFS(t)
+	client := setupRollbackClient(t, fs, false)
+	interruptManage(t, fs, client, "vim")
+	require.True(t, fs.Exists(ctx, "/test/target/.vimrc"))
+
+	_, err := client.Rollback(ctx, "")
+	require.NoError(t, err)
+	assert.False(t, fs.Exists(ctx, "/test/target/.vimrc"))
+
+	interrupted, err := client.Interrupted(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, interrupted)
+}
+
+func TestClient_Rollback_RefusesOlderCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	fs := setupRollbackFS(t)
+	client := setupRollbackClient(t, fs, false)
+
+	require.NoError(t, client.Manage(ctx, "vim"))
+	require.NoError(t, client.Unmanage(ctx, "vim"))
+	checkpoints, err := client.Checkpoints(ctx)
+	require.NoError(t, err)
+	require.Len(t, checkpoints, 2)
+
+	// The unmanage has since changed the links the manage created
+	_, err = client.Rollback(ctx, checkpoints[1].ID)
+	assert.ErrorContains(t, err, "roll back "+checkpoints[0].ID+" first")
+
+	_, err = client.Rollback(ctx, checkpoints[0].ID)
+	require.NoError(t, err)
+	_, err = client.Rollback(ctx, checkpoints[1].ID)
+	require.NoError(t, err)
+	assert.False(t, fs.Exists(ctx, "/test/target/.vimrc"))
+}
+
+func TestClient_Rollback_UnmanageRestoresManifest(t *testing.T) {
+	ctx := context.Background()
+	fs := setupRollbackFS(t)
+	client := setupRollbackClient(t, fs, false)
+
+	require.NoError(t, client.Manage(ctx, "vim", "zsh"))
+	require.NoError(t, client.Unmanage(ctx, "vim"))
+	packages, err := client.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, packages, 1)
+
+	_, err = client.Rollback(ctx, "")
+	require.NoError(t, err)
+	link, err := fs.ReadLink(ctx, "/test/target/.vimrc")
+	require.NoError(t, err)
+	assert.Contains(t, link, "dot-vimrc")
+
+	packages, err = client.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, packages, 2)
+	for _, pkg := range packages {
+		if pkg.Name == "vim" {
+			assert.Equal(t, []string{".vimrc"}, pkg.Links)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package dot
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/jamesainslie/dot/internal/bootstrap"
+	"github.com/jamesainslie/dot/internal/scanner"
+	"github.com/jamesainslie/dot/internal/templating"
+)
+
+// ScaffoldService creates new packages in the package directory.
+type ScaffoldService struct {
+	fs         FS
+	logger     Logger
+	packageDir string
+	dryRun     bool
+}
+
+// newScaffoldService creates a new scaffold service.
+func newScaffoldService(fs FS, logger Logger, packageDir string, dryRun bool) *ScaffoldService {
+	return &ScaffoldService{
+		fs:         fs,
+		logger:     logger,
+		packageDir: packageDir,
+		dryRun:     dryRun,
+	}
+}
+
+// NewPackageOptions configures package creation.
+type NewPackageOptions struct {
+	// Files lists files to create empty in the package, as named in the
+	// package's target directory. A leading dot of a file name is written
+	// as the dot- prefix, so .zshrc becomes dot-zshrc.
+	Files []string
+
+	// Template creates Files as templates, with the template suffix.
+	Template bool
+
+	// Ignore lists patterns written to the package's .dotignore.
+	Ignore []string
+
+	// NoPrefix keeps the package name as given instead of adding the
+	// dot- prefix.
+	NoPrefix bool
+}
+
+// NewPackageResult describes a created package.
+type NewPackageResult struct {
+	// Package is the name of the created package.
+	Package string
+
+	// Path is the absolute path of the package directory.
+	Path string
+
+	// Created lists the created files, relative to the package directory.
+	Created []string
+
+	// Registered reports whether the package was added to the bootstrap
+	// configuration.
+	Registered bool
+}
+
+// newPackageName returns the package for a target directory called name. A
+// leading dot is replaced with the dot- prefix, which is also added to
+// other names unless noPrefix is set.
+func newPackageName(name string, noPrefix bool) string {
+	name = strings.TrimPrefix(name, ".")
+	if noPrefix || strings.HasPrefix(name, "dot-") {
+		return name
+	}
+	return "dot-" + name
+}
+
+// NewPackage creates the package called name with a .dotignore and the
+// files of opts, and lists it in the repository's bootstrap configuration
+// if there is one. Existing packages are not modified.
+func (s *ScaffoldService) NewPackage(ctx context.Context, name string, opts NewPackageOptions) (NewPackageResult, error) {
+	pkg := newPackageName(name, opts.NoPrefix)
+	if pkg == "" || pkg == "dot-" || strings.ContainsAny(pkg, `/\`) || pkg == ".." {
+		return NewPackageResult{}, ErrInvalidPath{Path: name, Reason: "package name must be a single path element"}
+	}
+	dir := filepath.Join(s.packageDir, pkg)
+	if s.fs.Exists(ctx, dir) {
+		return NewPackageResult{}, ErrPackageExists{Package: pkg, Path: dir}
+	}
+
+	files := map[string][]byte{scanner.IgnoreFileName: ignoreFileContent(opts.Ignore)}
+	created := []string{scanner.IgnoreFileName}
+	for _, file := range opts.Files {
+		rel, err := packageFilePath(file)
+		if err != nil {
+			return NewPackageResult{}, err
+		}
+		if opts.Template && !templating.IsTemplate(rel) {
+			rel += templating.Suffix
+		}
+		if _, dup := files[rel]; !dup {
+			files[rel] = nil
+			created = append(created, rel)
+		}
+	}
+
+	result := NewPackageResult{Package: pkg, Path: dir, Created: created}
+	bootstrapPath := filepath.Join(s.packageDir, ".dotbootstrap.yaml")
+	var bootstrapData []byte
+	if s.fs.Exists(ctx, bootstrapPath) {
+		data, err := s.fs.ReadFile(ctx, bootstrapPath)
+		if err != nil {
+			return NewPackageResult{}, fmt.Errorf("read bootstrap config: %w", err)
+		}
+		bootstrapData, result.Registered, err = bootstrap.AddPackage(data, bootstrap.PackageSpec{Name: pkg})
+		if err != nil {
+			return NewPackageResult{}, err
+		}
+	}
+
+	if s.dryRun {
+		s.logger.Info(ctx, "dry_run_new_package", "package", pkg, "files", created)
+		return result, nil
+	}
+
+	for _, rel := range created {
+		path := filepath.Join(dir, rel)
+		if err := s.fs.MkdirAll(ctx, filepath.Dir(path), 0755); err != nil {
+			return NewPackageResult{}, fmt.Errorf("create package %s: %w", pkg, err)
+		}
+		if err := s.fs.WriteFile(ctx, path, files[rel], 0644); err != nil {
+			return NewPackageResult{}, fmt.Errorf("create package %s: %w", pkg, err)
+		}
+	}
+	if result.Registered {
+		if err := s.fs.WriteFile(ctx, bootstrapPath, bootstrapData, 0644); err != nil {
+			return NewPackageResult{}, fmt.Errorf("write bootstrap config: %w", err)
+		}
+	}
+	s.logger.Info(ctx, "package_created", "package", pkg, "files", len(created), "registered", result.Registered)
+	return result, nil
+}
+
+// packageFilePath converts a path as named in the target directory to its
+// path in a package.
+func packageFilePath(file string) (string, error) {
+	clean := filepath.Clean(file)
+	if filepath.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", ErrInvalidPath{Path: file, Reason: "must be relative to the target directory"}
+	}
+	return scanner.UntranslatePath(clean), nil
+}
+
+// ignoreFileContent returns the .dotignore of a new package.
+func ignoreFileContent(patterns []string) []byte {
+	var b strings.Builder
+	b.WriteString("# Package files matching these patterns are not linked.\n")
+	b.WriteString("# Patterns match paths relative to the package or file names.\n")
+	for _, pattern := range patterns {
+		b.WriteString(pattern + "\n")
+	}
+	return []byte(b.String())
+}
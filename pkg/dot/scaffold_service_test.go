@@ -0,0 +1,130 @@
+package dot_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+func newScaffoldTestClient(t *testing.T, dryRun bool) (*adapters.MemFS, *dot.Client) {
+	t.Helper()
+	fs := adapters.NewMemFS()
+	require.NoError(t, fs.MkdirAll(context.Background(), "/test/packages", 0755))
+	require.NoError(t, fs.MkdirAll(context.Background(), "/test/target", 0755))
+	client, err := dot.NewClient(dot.Config{
+		PackageDir:         "/test/packages",
+		TargetDir:          "/test/target",
+		PackageNameMapping: true,
+		DryRun:             dryRun,
+		FS:                 fs,
+		Logger:             adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+	return fs, client
+}
+
+func TestClient_NewPackage(t *testing.T) {
+	ctx := context.Background()
+	fs, client := newScaffoldTestClient(t, false)
+
+	result, err := client.NewPackage(ctx, "zsh", dot.NewPackageOptions{
+		Files:  []string{".zshrc", "functions/prompt.zsh"},
+		Ignore: []string{"*.md"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "dot-zsh", result.Package)
+	assert.Equal(t, "/test/packages/dot-zsh", result.Path)
+	assert.Equal(t, []string{".dotignore", "dot-zshrc", "functions/prompt.zsh"}, result.Created)
+	assert.False(t, result.Registered)
+	assert.True(t, fs.Exists(ctx, "/test/packages/dot-zsh/dot-zshrc"))
+	assert.True(t, fs.Exists(ctx, "/test/packages/dot-zsh/functions/prompt.zsh"))
+	ignore, err := fs.ReadFile(ctx, "/test/packages/dot-zsh/.dotignore")
+	require.NoError(t, err)
+	assert.Contains(t, string(ignore), "\n*.md\n")
+
+	// The new package links its files, but not its .dotignore
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/dot-zsh/README.md", []byte("notes"), 0644))
+	require.NoError(t, client.Manage(ctx, "dot-zsh"))
+	assert.True(t, fs.Exists(ctx, "/test/target/.zsh/.zshrc"))
+	assert.False(t, fs.Exists(ctx, "/test/target/.zsh/.dotignore"))
+	assert.False(t, fs.Exists(ctx, "/test/target/.zsh/README.md"))
+}
+
+func TestClient_NewPackage_Naming(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     dot.NewPackageOptions
+		expected string
+	}{
+		{name: "vim", expected: "dot-vim"},
+		{name: ".gnupg", expected: "dot-gnupg"},
+		{name: "dot-config", expected: "dot-config"},
+		{name: "bin", opts: dot.NewPackageOptions{NoPrefix: true}, expected: "bin"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, client := newScaffoldTestClient(t, false)
+			result, err := client.NewPackage(context.Background(), tt.name, tt.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result.Package)
+		})
+	}
+}
+
+func TestClient_NewPackage_Template(t *testing.T) {
+	ctx := context.Background()
+	fs, client := newScaffoldTestClient(t, false)
+
+	result, err := client.NewPackage(ctx, "git", dot.NewPackageOptions{Files: []string{".gitconfig"}, Template: true})
+	require.NoError(t, err)
+	assert.Contains(t, result.Created, "dot-gitconfig.tmpl")
+	assert.True(t, fs.Exists(ctx, "/test/packages/dot-git/dot-gitconfig.tmpl"))
+}
+
+func TestClient_NewPackage_RegistersInBootstrap(t *testing.T) {
+	ctx := context.Background()
+	fs, client := newScaffoldTestClient(t, false)
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/.dotbootstrap.yaml", []byte("version: \"1.0\"\n# Packages\npackages:\n  - name: dot-vim\n    required: true\n"), 0644))
+
+	result, err := client.NewPackage(ctx, "zsh", dot.NewPackageOptions{})
+	require.NoError(t, err)
+	assert.True(t, result.Registered)
+
+	data, err := fs.ReadFile(ctx, "/test/packages/.dotbootstrap.yaml")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "# Packages")
+	assert.Contains(t, string(data), "name: dot-zsh")
+}
+
+func TestClient_NewPackage_Errors(t *testing.T) {
+	ctx := context.Background()
+	fs, client := newScaffoldTestClient(t, false)
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/dot-vim", 0755))
+
+	_, err := client.NewPackage(ctx, "vim", dot.NewPackageOptions{})
+	assert.ErrorAs(t, err, &dot.ErrPackageExists{})
+
+	_, err = client.NewPackage(ctx, "a/b", dot.NewPackageOptions{})
+	assert.ErrorAs(t, err, &dot.ErrInvalidPath{})
+
+	_, err = client.NewPackage(ctx, "zsh", dot.NewPackageOptions{Files: []string{"../escape"}})
+	assert.ErrorAs(t, err, &dot.ErrInvalidPath{})
+	assert.False(t, fs.Exists(ctx, "/test/packages/dot-zsh"), "nothing is created on error")
+}
+
+func TestClient_NewPackage_DryRun(t *testing.T) {
+	ctx := context.Background()
+	fs, client := newScaffoldTestClient(t, true)
+
+	result, err := client.NewPackage(ctx, "zsh", dot.NewPackageOptions{Files: []string{".zshrc"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{".dotignore", "dot-zshrc"}, result.Created)
+	assert.False(t, fs.Exists(ctx, "/test/packages/dot-zsh"))
+}
@@ -0,0 +1,265 @@
+package dot
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+
+	"github.com/jamesainslie/dot/internal/domain"
+)
+
+// SkelOptions selects what ExportSkel copies and where.
+type SkelOptions struct {
+	// Output is the directory to write, such as /etc/skel.
+	Output string
+
+	// Packages lists the packages to copy. Empty selects the packages of
+	// Profile in the bootstrap configuration.
+	Packages []string
+
+	// Profile names the bootstrap profile whose packages are copied when
+	// Packages is empty. Empty uses the default profile, or every package
+	// when there is none.
+	Profile string
+
+	// AllowSecrets writes rendered templates that hold secrets, readable
+	// only by their owner. By default they are left out, since every
+	// account created from the skeleton would get a copy.
+	AllowSecrets bool
+}
+
+// SkelResult describes a skeleton directory written by ExportSkel.
+type SkelResult struct {
+	// Path is the skeleton directory.
+	Path string `json:"path" yaml:"path"`
+
+	// Packages lists the copied packages.
+	Packages []string `json:"packages" yaml:"packages"`
+
+	// Files lists the files written, relative to Path.
+	Files []string `json:"files" yaml:"files"`
+
+	// Replaced lists the files of Files that already existed in Path.
+	Replaced []string `json:"replaced,omitempty" yaml:"replaced,omitempty"`
+
+	// Skipped lists the paths of packages linked outside the target
+	// directory, which have no place in the skeleton.
+	Skipped []string `json:"skipped,omitempty" yaml:"skipped,omitempty"`
+
+	// SkippedSecrets lists the files, relative to Path, of templates left
+	// out because they hold secrets and AllowSecrets is not set.
+	SkippedSecrets []string `json:"skipped_secrets,omitempty" yaml:"skipped_secrets,omitempty"`
+}
+
+// SkelService writes packages into skeleton directories.
+type SkelService struct {
+	fs        FS
+	logger    Logger
+	config    Config
+	sandboxes *PlanMatrixService
+}
+
+// newSkelService creates a new skel service. cfg is the configuration
+// sandboxed clients are derived from.
+func newSkelService(fs FS, logger Logger, cfg Config) *SkelService {
+	return &SkelService{fs: fs, logger: logger, config: cfg, sandboxes: newPlanMatrixService(fs, logger, cfg)}
+}
+
+// ExportSkel writes the files that managing the selected packages would
+// link into an empty target directory into opts.Output, as copies, in the
+// layout of /etc/skel. Templates are rendered for the running platform.
+// No manifest is written, so accounts created from the skeleton own plain
+// files and dot plays no part in them.
+//
+// Templates that hold secrets are left out unless opts.AllowSecrets is
+// set. Files already in the output directory are replaced. In dry-run mode
+// the result lists what would be written and nothing is changed.
+//
+// Returns ErrInvalidBootstrap when no packages are given and the package
+// directory has no bootstrap configuration to select them from.
+func (s *SkelService) ExportSkel(ctx context.Context, opts SkelOptions) (SkelResult, error) {
+	if opts.Output == "" || !filepath.IsAbs(opts.Output) {
+		return SkelResult{}, fmt.Errorf("skeleton directory must be an absolute path: %q", opts.Output)
+	}
+	result := SkelResult{Path: filepath.Clean(opts.Output), Files: []string{}}
+
+	packages, err := s.skelPackages(ctx, opts)
+	if err != nil {
+		return SkelResult{}, err
+	}
+	result.Packages = packages
+	if len(packages) == 0 {
+		return result, nil
+	}
+
+	// Plan against an empty target in memory, as for a fresh account
+	sandbox, err := s.sandboxes.sandbox(ctx, s.fs)
+	if err != nil {
+		return SkelResult{}, err
+	}
+	cfg := s.sandboxes.sandboxConfig(sandbox, runtime.GOOS)
+	client, err := NewClient(cfg)
+	if err != nil {
+		return SkelResult{}, err
+	}
+	plan, err := client.PlanManage(ctx, packages...)
+	if err != nil {
+		return SkelResult{}, err
+	}
+
+	renders := make(map[string]FileRender)
+	for _, op := range plan.Operations {
+		if render, ok := op.(FileRender); ok {
+			renders[render.Dest.String()] = render
+		}
+	}
+
+	w := &skelWriter{svc: s, sandbox: sandbox, result: &result}
+	for _, op := range plan.Operations {
+		link, ok := op.(LinkCreate)
+		if !ok {
+			continue
+		}
+		rel, err := filepath.Rel(s.config.TargetDir, link.Target.String())
+		if err != nil || !filepath.IsLocal(rel) {
+			result.Skipped = append(result.Skipped, link.Target.String())
+			continue
+		}
+		dest := filepath.Join(result.Path, rel)
+		if render, ok := renders[link.Source.String()]; ok {
+			if render.Secret && !opts.AllowSecrets {
+				result.SkippedSecrets = append(result.SkippedSecrets, rel)
+				continue
+			}
+			err = w.writeRender(ctx, render, dest)
+		} else {
+			err = w.copy(ctx, link.Source.String(), dest)
+		}
+		if err != nil {
+			return SkelResult{}, err
+		}
+	}
+
+	slices.Sort(result.Files)
+	slices.Sort(result.Replaced)
+	slices.Sort(result.SkippedSecrets)
+	s.logger.Info(ctx, "skel_exported", "path", result.Path, "packages", len(result.Packages), "files", len(result.Files), "dry_run", s.config.DryRun)
+	return result, nil
+}
+
+// skelPackages returns the packages opts selects.
+func (s *SkelService) skelPackages(ctx context.Context, opts SkelOptions) ([]string, error) {
+	if len(opts.Packages) > 0 {
+		return opts.Packages, nil
+	}
+	config, ok, err := loadBootstrapConfig(ctx, s.fs, s.config.PackageDir)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidBootstrap{Reason: "no .dotbootstrap.yaml in " + s.config.PackageDir + " to select packages from"}
+	}
+	profile := opts.Profile
+	if profile == "" {
+		profile = config.Defaults.Profile
+	}
+	return profilePackages(config, profile, runtime.GOOS)
+}
+
+// skelWriter copies package files from the sandbox into the skeleton.
+type skelWriter struct {
+	svc     *SkelService
+	sandbox FS
+	result  *SkelResult
+}
+
+// copy copies the package file or directory at source to dest, following
+// links inside the package.
+func (w *skelWriter) copy(ctx context.Context, source, dest string) error {
+	info, err := w.sandbox.Stat(ctx, source)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", source, err)
+	}
+	if !info.IsDir() {
+		data, err := w.sandbox.ReadFile(ctx, source)
+		if err != nil {
+			return fmt.Errorf("read %s: %w", source, err)
+		}
+		return w.write(ctx, dest, data, info.Mode().Perm())
+	}
+
+	if err := w.mkdir(ctx, dest, info.Mode().Perm()); err != nil {
+		return err
+	}
+	entries, err := w.sandbox.ReadDir(ctx, source)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", source, err)
+	}
+	for _, entry := range entries {
+		if err := w.copy(ctx, filepath.Join(source, entry.Name()), filepath.Join(dest, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeRender writes the rendered content of render to dest, with the
+// mode of its template, or only readable by the owner when it holds
+// secrets.
+func (w *skelWriter) writeRender(ctx context.Context, render FileRender, dest string) error {
+	mode := os.FileMode(domain.SecureFilePerms)
+	if !render.Secret {
+		mode = domain.DefaultFilePerms
+		if info, err := w.sandbox.Stat(ctx, render.Template.String()); err == nil {
+			mode = info.Mode().Perm()
+		}
+	}
+	return w.write(ctx, dest, []byte(render.Content), mode)
+}
+
+// write writes a file of the skeleton, replacing a file already there.
+func (w *skelWriter) write(ctx context.Context, dest string, data []byte, mode os.FileMode) error {
+	fs := w.svc.fs
+	rel, _ := filepath.Rel(w.result.Path, dest)
+	w.result.Files = append(w.result.Files, rel)
+
+	isLink, _ := fs.IsSymlink(ctx, dest)
+	exists := isLink || fs.Exists(ctx, dest)
+	if exists {
+		if isDir, _ := fs.IsDir(ctx, dest); isDir && !isLink {
+			return fmt.Errorf("%s is a directory in the skeleton where a file belongs", dest)
+		}
+		w.result.Replaced = append(w.result.Replaced, rel)
+	}
+	if w.svc.config.DryRun {
+		return nil
+	}
+
+	if err := w.mkdir(ctx, filepath.Dir(dest), domain.DefaultDirPerms); err != nil {
+		return err
+	}
+	if exists {
+		// Writing would follow a link, or keep the mode of the file
+		if err := fs.Remove(ctx, dest); err != nil {
+			return fmt.Errorf("replace %s: %w", dest, err)
+		}
+	}
+	if err := fs.WriteFile(ctx, dest, data, mode); err != nil {
+		return fmt.Errorf("write %s: %w", dest, err)
+	}
+	return nil
+}
+
+// mkdir creates a directory of the skeleton.
+func (w *skelWriter) mkdir(ctx context.Context, dir string, mode os.FileMode) error {
+	if w.svc.config.DryRun {
+		return nil
+	}
+	if err := w.svc.fs.MkdirAll(ctx, dir, mode); err != nil {
+		return fmt.Errorf("create %s: %w", dir, err)
+	}
+	return nil
+}
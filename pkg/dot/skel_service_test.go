@@ -0,0 +1,152 @@
+package dot_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+const skelBootstrap = `version: "1.0"
+packages:
+  - name: shell
+  - name: git
+  - name: extra
+profiles:
+  base:
+    description: Organization defaults
+    packages: [shell, git]
+defaults:
+  profile: base
+`
+
+func setupSkelClient(t *testing.T, dryRun bool) (*adapters.MemFS, *dot.Client) {
+	t.Helper()
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/shell", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/git", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/extra", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/shell/dot-bashrc", []byte("export EDITOR=vim\n"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/shell/dot-aliases", []byte("alias ll='ls -l'\n"), 0600))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/git/dot-gitconfig.tmpl", []byte("[init]\n\tdefaultBranch = {{ .Values.branch }}\n"), 0644))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/.dot-values", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/.dot-values/default.yaml", []byte("branch: main\n"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/extra/dot-extra", []byte("x"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/.dotbootstrap.yaml", []byte(skelBootstrap), 0644))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target", 0755))
+
+	// A distribution default the skeleton replaces
+	require.NoError(t, fs.MkdirAll(ctx, "/etc/skel", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/etc/skel/.bashrc", []byte("# distro\n"), 0644))
+
+	client, err := dot.NewClient(dot.Config{
+		PackageDir: "/test/packages",
+		TargetDir:  "/test/target",
+		DryRun:     dryRun,
+		FS:         fs,
+		Logger:     adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+	return fs, client
+}
+
+func TestClient_ExportSkel(t *testing.T) {
+	ctx := context.Background()
+	fs, client := setupSkelClient(t, false)
+
+	result, err := client.ExportSkel(ctx, dot.SkelOptions{Output: "/etc/skel"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"shell", "git"}, result.Packages)
+	assert.Equal(t, []string{".aliases", ".bashrc", ".gitconfig"}, result.Files)
+	assert.Equal(t, []string{".bashrc"}, result.Replaced)
+
+	// Files are plain copies, with templates rendered
+	isLink, err := fs.IsSymlink(ctx, "/etc/skel/.bashrc")
+	require.NoError(t, err)
+	assert.False(t, isLink)
+	data, err := fs.ReadFile(ctx, "/etc/skel/.bashrc")
+	require.NoError(t, err)
+	assert.Equal(t, "export EDITOR=vim\n", string(data))
+	data, err = fs.ReadFile(ctx, "/etc/skel/.gitconfig")
+	require.NoError(t, err)
+	assert.Equal(t, "[init]\n\tdefaultBranch = main\n", string(data))
+
+	info, err := fs.Stat(ctx, "/etc/skel/.aliases")
+	require.NoError(t, err)
+	assert.Equal(t, "-rw-------", info.Mode().Perm().String(), "modes are kept")
+
+	// Nothing is managed in the target
+	assert.False(t, fs.Exists(ctx, "/etc/skel/.dot-manifest.json"))
+	assert.False(t, fs.Exists(ctx, "/test/target/.dot-manifest.json"))
+	assert.False(t, fs.Exists(ctx, "/test/target/.bashrc"))
+}
+
+func TestClient_ExportSkel_PackagesAndDryRun(t *testing.T) {
+	ctx := context.Background()
+	fs, client := setupSkelClient(t, true)
+
+	result, err := client.ExportSkel(ctx, dot.SkelOptions{Output: "/etc/skel", Packages: []string{"extra"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{".extra"}, result.Files)
+	assert.False(t, fs.Exists(ctx, "/etc/skel/.extra"))
+}
+
+func TestClient_ExportSkel_Errors(t *testing.T) {
+	ctx := context.Background()
+	fs, client := setupSkelClient(t, false)
+
+	_, err := client.ExportSkel(ctx, dot.SkelOptions{Output: "skel"})
+	assert.ErrorContains(t, err, "absolute path")
+
+	_, err = client.ExportSkel(ctx, dot.SkelOptions{Output: "/etc/skel", Profile: "missing"})
+	var notFound dot.ErrProfileNotFound
+	assert.True(t, errors.As(err, &notFound))
+
+	require.NoError(t, fs.Remove(ctx, "/test/packages/.dotbootstrap.yaml"))
+	_, err = client.ExportSkel(ctx, dot.SkelOptions{Output: "/etc/skel"})
+	var invalid dot.ErrInvalidBootstrap
+	assert.True(t, errors.As(err, &invalid))
+}
+
+func TestClient_ExportSkel_Secrets(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("DOTFILES_GITHUB_TOKEN", "ghp_123")
+	fs := adapters.NewMemFS()
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/gh", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/gh/dot-netrc.tmpl", []byte(`password {{ secret "github-token" }}`), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/gh/dot-ghrc", []byte("editor: vim\n"), 0644))
+
+	client, err := dot.NewClient(dot.Config{
+		PackageDir:       "/test/packages",
+		TargetDir:        "/test/target",
+		SecretsEnvPrefix: "DOTFILES_",
+		FS:               fs,
+		Logger:           adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+
+	result, err := client.ExportSkel(ctx, dot.SkelOptions{Output: "/etc/skel", Packages: []string{"gh"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{".ghrc"}, result.Files)
+	assert.Equal(t, []string{".netrc"}, result.SkippedSecrets)
+	assert.False(t, fs.Exists(ctx, "/etc/skel/.netrc"), "secrets are not copied to every new account")
+
+	result, err = client.ExportSkel(ctx, dot.SkelOptions{Output: "/etc/skel", Packages: []string{"gh"}, AllowSecrets: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{".ghrc", ".netrc"}, result.Files)
+	assert.Empty(t, result.SkippedSecrets)
+	data, err := fs.ReadFile(ctx, "/etc/skel/.netrc")
+	require.NoError(t, err)
+	assert.Equal(t, "password ghp_123", string(data))
+	info, err := fs.Stat(ctx, "/etc/skel/.netrc")
+	require.NoError(t, err)
+	assert.Equal(t, 0600, int(info.Mode().Perm()))
+}
@@ -0,0 +1,144 @@
+package dot
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// defaultSnapshotRetention is the number of snapshots kept per package
+// when Config.SnapshotRetention is zero.
+const defaultSnapshotRetention = 5
+
+// snapshotSuffix ends the names of snapshots, which start with the time
+// they were taken so that they sort from oldest to newest.
+const snapshotSuffix = ".tar.gz"
+
+// snapshotTimeFormat formats the times in the names of snapshots.
+const snapshotTimeFormat = "20060102T150405.000000000Z"
+
+// packageSnapshots writes gzip-compressed tar archives of packages before
+// a remanage removes or overwrites files in them: a last resort to
+// recover them that does not depend on git. The archives of a package are
+// kept in a directory named after it, the newest keep of them.
+type packageSnapshots struct {
+	fs     FS
+	logger Logger
+	dir    string
+	keep   int
+}
+
+// newPackageSnapshots creates snapshots kept in dir, or returns nil when
+// dir is empty.
+func newPackageSnapshots(fs FS, logger Logger, dir string, keep int) *packageSnapshots {
+	if dir == "" {
+		return nil
+	}
+	if keep <= 0 {
+		keep = defaultSnapshotRetention
+	}
+	return &packageSnapshots{fs: fs, logger: logger, dir: dir, keep: keep}
+}
+
+// take writes a snapshot of the package pkg of packageDir and prunes the
+// oldest snapshots of the package. It returns the path of the snapshot.
+func (p *packageSnapshots) take(ctx context.Context, packageDir, pkg string, now time.Time) (string, error) {
+	w := newBundleWriter(now, false)
+	files, err := w.tree(ctx, p.fs, filepath.Join(packageDir, pkg), pkg)
+	if err != nil {
+		return "", fmt.Errorf("snapshot package %s: %w", pkg, err)
+	}
+	data, err := w.bytes()
+	if err != nil {
+		return "", fmt.Errorf("snapshot package %s: %w", pkg, err)
+	}
+
+	dir := filepath.Join(p.dir, pkg)
+	if err := p.fs.MkdirAll(ctx, dir, 0700); err != nil {
+		return "", fmt.Errorf("snapshot package %s: %w", pkg, err)
+	}
+	// Packages may hold private files, so the archive is only readable
+	// by its owner
+	name := filepath.Join(dir, now.UTC().Format(snapshotTimeFormat)+snapshotSuffix)
+	if err := p.fs.WriteFile(ctx, name, data, 0600); err != nil {
+		return "", fmt.Errorf("snapshot package %s: %w", pkg, err)
+	}
+	p.logger.Info(ctx, "package_snapshot", "package", pkg, "path", name, "files", files)
+
+	if err := p.prune(ctx, dir); err != nil {
+		p.logger.Warn(ctx, "snapshot_prune_failed", "package", pkg, "error", err)
+	}
+	return name, nil
+}
+
+// prune removes the oldest snapshots of dir beyond the number kept.
+func (p *packageSnapshots) prune(ctx context.Context, dir string) error {
+	entries, err := p.fs.ReadDir(ctx, dir)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), snapshotSuffix) {
+			names = append(names, entry.Name())
+		}
+	}
+	slices.Sort(names)
+	for len(names) > p.keep {
+		if err := p.fs.Remove(ctx, filepath.Join(dir, names[0])); err != nil {
+			return err
+		}
+		names = names[1:]
+	}
+	return nil
+}
+
+// replacedPaths returns the existing paths op removes or may overwrite.
+func replacedPaths(op Operation) []string {
+	switch op := op.(type) {
+	case LinkDelete:
+		return []string{op.Target.String()}
+	case DirDelete:
+		return []string{op.Path.String()}
+	case DirRemoveAll:
+		return []string{op.Path.String()}
+	case FileMove:
+		return []string{op.Source.String(), op.Dest.String()}
+	case FileStash:
+		return []string{op.Source.String()}
+	case FileRestore:
+		return []string{op.Path.String()}
+	case FileRender:
+		return []string{op.Dest.String()}
+	case DirCopy:
+		return []string{op.Dest.String()}
+	default:
+		return nil
+	}
+}
+
+// packagesReplaced returns the packages of packageDir holding existing
+// files that the operations of plan remove or overwrite, sorted.
+func packagesReplaced(ctx context.Context, fs FS, packageDir string, plan Plan) []string {
+	var packages []string
+	for _, op := range plan.Operations {
+		for _, path := range replacedPaths(op) {
+			rel, err := filepath.Rel(packageDir, path)
+			if err != nil || rel == "." || !filepath.IsLocal(rel) {
+				continue
+			}
+			pkg, _, _ := strings.Cut(filepath.ToSlash(rel), "/")
+			if slices.Contains(packages, pkg) {
+				continue
+			}
+			if isLink, err := fs.IsSymlink(ctx, path); err == nil && (isLink || fs.Exists(ctx, path)) {
+				packages = append(packages, pkg)
+			}
+		}
+	}
+	slices.Sort(packages)
+	return packages
+}
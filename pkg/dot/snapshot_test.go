@@ -0,0 +1,133 @@
+package dot
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+)
+
+// readSnapshot returns the contents of the files and links of a snapshot
+// by name, a link as "-> target".
+func readSnapshot(t *testing.T, fs FS, name string) map[string]string {
+	t.Helper()
+	raw, err := fs.ReadFile(context.Background(), name)
+	require.NoError(t, err)
+	gz, err := gzip.NewReader(bytes.NewReader(raw))
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+
+	files := make(map[string]string)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return files
+		}
+		require.NoError(t, err)
+		switch hdr.Typeflag {
+		case tar.TypeReg:
+			data, err := io.ReadAll(tr)
+			require.NoError(t, err)
+			files[hdr.Name] = string(data)
+		case tar.TypeSymlink:
+			files[hdr.Name] = "-> " + hdr.Linkname
+		}
+	}
+}
+
+func TestPackageSnapshots_Take(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	require.NoError(t, fs.MkdirAll(ctx, "/packages/vim/dot-vim/colors", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/packages/vim/dot-vimrc", []byte("set nu"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/packages/vim/dot-vim/colors/x.vim", []byte("hi Normal"), 0644))
+	require.NoError(t, fs.Symlink(ctx, "dot-vimrc", "/packages/vim/dot-gvimrc"))
+
+	snapshots := newPackageSnapshots(fs, adapters.NewNoopLogger(), "/cache/snapshots", 2)
+	now := time.Date(2024, 5, 1, 12, 30, 15, 0, time.UTC)
+	name, err := snapshots.take(ctx, "/packages", "vim", now)
+	require.NoError(t, err)
+	assert.Equal(t, "/cache/snapshots/vim/20240501T123015.000000000Z.tar.gz", name)
+	assert.Equal(t, map[string]string{
+		"vim/dot-vimrc":            "set nu",
+		"vim/dot-vim/colors/x.vim": "hi Normal",
+		"vim/dot-gvimrc":           "-> dot-vimrc",
+	}, readSnapshot(t, fs, name))
+	info, err := fs.Stat(ctx, name)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// Only the newest snapshots are kept
+	for i := 1; i <= 3; i++ {
+		_, err := snapshots.take(ctx, "/packages", "vim", now.Add(time.Duration(i)*time.Hour))
+		require.NoError(t, err)
+	}
+	entries, err := fs.ReadDir(ctx, "/cache/snapshots/vim")
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.ElementsMatch(t, []string{"20240501T143015.000000000Z.tar.gz", "20240501T153015.000000000Z.tar.gz"}, names)
+
+	_, err = snapshots.take(ctx, "/packages", "emacs", now)
+	assert.ErrorContains(t, err, "snapshot package emacs")
+
+	assert.Nil(t, newPackageSnapshots(fs, adapters.NewNoopLogger(), "", 0))
+	assert.Equal(t, defaultSnapshotRetention, newPackageSnapshots(fs, adapters.NewNoopLogger(), "/cache", 0).keep)
+}
+
+func TestPackagesReplaced(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	require.NoError(t, fs.MkdirAll(ctx, "/packages/vim", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/packages/zsh", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/packages/vim/dot-vimrc", []byte("set nu"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/packages/zsh/dot-zshrc", []byte("bindkey -v"), 0644))
+
+	plan := Plan{Operations: []Operation{
+		// Moving a file over one in the package overwrites it
+		NewFileMove("move", NewTargetPath("/home/.vimrc").Unwrap(), NewFilePath("/packages/vim/dot-vimrc").Unwrap()),
+		// Files that do not exist yet are not worth a snapshot
+		NewFileMove("move-new", NewTargetPath("/home/.emacs").Unwrap(), NewFilePath("/packages/emacs/dot-emacs").Unwrap()),
+		// Links are only deleted from the target directory
+		NewLinkDelete("unlink", NewTargetPath("/home/.zshrc").Unwrap()),
+		NewDirCreate("mkdir", NewFilePath("/packages/zsh/dot-config").Unwrap()),
+	}}
+	assert.Equal(t, []string{"vim"}, packagesReplaced(ctx, fs, "/packages", plan))
+
+	plan.Operations = append(plan.Operations, NewDirRemoveAll("rm", NewFilePath("/packages/zsh").Unwrap()))
+	assert.Equal(t, []string{"vim", "zsh"}, packagesReplaced(ctx, fs, "/packages", plan))
+}
+
+func TestManageService_SnapshotReplaced(t *testing.T) {
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	require.NoError(t, fs.MkdirAll(ctx, "/packages/vim", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/packages/vim/dot-vimrc", []byte("set nu"), 0644))
+	plan := Plan{Operations: []Operation{
+		NewFileMove("move", NewTargetPath("/home/.vimrc").Unwrap(), NewFilePath("/packages/vim/dot-vimrc").Unwrap()),
+	}}
+
+	s := &ManageService{fs: fs, packageDir: "/packages"}
+	require.NoError(t, s.snapshotReplaced(ctx, plan))
+	assert.False(t, fs.Exists(ctx, "/cache"))
+
+	s.snapshots = newPackageSnapshots(fs, adapters.NewNoopLogger(), "/cache", 0)
+	require.NoError(t, s.snapshotReplaced(ctx, plan))
+	entries, err := fs.ReadDir(ctx, "/cache/vim")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, map[string]string{"vim/dot-vimrc": "set nu"}, readSnapshot(t, fs, filepath.Join("/cache/vim", entries[0].Name())))
+}
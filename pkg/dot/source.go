@@ -0,0 +1,125 @@
+package dot
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/jamesainslie/dot/internal/manifest"
+	"github.com/jamesainslie/dot/internal/templating"
+)
+
+// SourceFile identifies the package file behind a managed path.
+type SourceFile struct {
+	// Package is the name of the package holding the file.
+	Package string
+
+	// Path is the absolute path of the file in the package directory.
+	Path string
+
+	// Template reports whether the file is a template whose rendered
+	// output the target links to.
+	Template bool
+
+	// Managed reports whether the package is installed.
+	Managed bool
+}
+
+// ResolveSource returns the package file behind path. An absolute path is
+// looked up among the links of installed packages, including paths inside
+// folded directories and links to rendered templates. A relative path is
+// taken as relative to the package directory, starting with the package
+// name; the file itself need not exist yet.
+func (c *Client) ResolveSource(ctx context.Context, path string) (SourceFile, error) {
+	if filepath.IsAbs(path) {
+		path = filepath.Clean(path)
+		for _, target := range c.targetClients() {
+			source, ok, err := target.linkedSource(ctx, path)
+			if err != nil {
+				return SourceFile{}, err
+			}
+			if ok {
+				return source, nil
+			}
+		}
+		return SourceFile{}, ErrNotLinked{Path: path}
+	}
+
+	rel := filepath.Clean(path)
+	pkg, _, _ := strings.Cut(filepath.ToSlash(rel), "/")
+	if pkg == "." || pkg == ".." || pkg == "" {
+		return SourceFile{}, ErrInvalidPath{Path: path, Reason: "must start with a package name"}
+	}
+	if isDir, err := c.config.FS.IsDir(ctx, filepath.Join(c.config.PackageDir, pkg)); err != nil || !isDir {
+		return SourceFile{}, ErrPackageNotFound{Package: pkg}
+	}
+
+	source := SourceFile{
+		Package:  pkg,
+		Path:     filepath.Join(c.config.PackageDir, rel),
+		Template: templating.IsTemplate(rel),
+	}
+	target := c.byTarget([]string{pkg})[0].client
+	m, err := target.loadManifest(ctx)
+	if err != nil {
+		return SourceFile{}, err
+	}
+	_, source.Managed = m.GetPackage(pkg)
+	return source, nil
+}
+
+// linkedSource looks up path among the links recorded in the manifest of
+// the client's target directory.
+func (c *Client) linkedSource(ctx context.Context, path string) (SourceFile, bool, error) {
+	rel, err := filepath.Rel(c.config.TargetDir, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return SourceFile{}, false, nil
+	}
+	m, err := c.loadManifest(ctx)
+	if err != nil {
+		return SourceFile{}, false, err
+	}
+
+	packages := m.PackageList()
+	slices.SortFunc(packages, func(a, b manifest.PackageInfo) int { return cmp.Compare(a.Name, b.Name) })
+	for _, pkg := range packages {
+		for _, render := range pkg.Templates {
+			if filepath.Clean(render.Link) == rel {
+				return SourceFile{Package: pkg.Name, Path: render.Template, Template: true, Managed: true}, true, nil
+			}
+		}
+		for _, link := range pkg.Links {
+			link = filepath.Clean(link)
+			if link != rel && !strings.HasPrefix(rel, link+string(filepath.Separator)) {
+				continue
+			}
+			linkPath := filepath.Join(c.config.TargetDir, link)
+			dest, err := c.config.FS.ReadLink(ctx, linkPath)
+			if err != nil {
+				continue
+			}
+			if !filepath.IsAbs(dest) {
+				dest = filepath.Join(filepath.Dir(linkPath), dest)
+			}
+			source := filepath.Join(dest, strings.TrimPrefix(rel, link))
+			return SourceFile{Package: pkg.Name, Path: source, Template: templating.IsTemplate(source), Managed: true}, true, nil
+		}
+	}
+	return SourceFile{}, false, nil
+}
+
+// loadManifest loads the manifest of the client's target directory.
+func (c *Client) loadManifest(ctx context.Context) (manifest.Manifest, error) {
+	targetPath := NewTargetPath(c.config.TargetDir)
+	if !targetPath.IsOk() {
+		return manifest.Manifest{}, targetPath.UnwrapErr()
+	}
+	result := c.manageSvc.manifestSvc.Load(ctx, targetPath.Unwrap())
+	if !result.IsOk() {
+		return manifest.Manifest{}, fmt.Errorf("load manifest: %w", result.UnwrapErr())
+	}
+	return result.Unwrap(), nil
+}
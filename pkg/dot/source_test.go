@@ -0,0 +1,96 @@
+package dot_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// setupSourceTest creates package dot-vim with vimrc and package dot-git
+// with a gitconfig template, both installed into /test/target.
+func setupSourceTest(t *testing.T) *dot.Client {
+	t.Helper()
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/dot-vim/colors", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/dot-vim/vimrc", []byte("set nu"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/dot-vim/colors/dark.vim", []byte("hi"), 0644))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/dot-git", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/dot-git/dot-gitconfig.tmpl", []byte("[user]"), 0644))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/dot-zsh", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target", 0755))
+
+	client, err := dot.NewClient(dot.Config{
+		PackageDir:         "/test/packages",
+		TargetDir:          "/test/target",
+		TemplateCacheDir:   "/test/cache",
+		PackageNameMapping: true,
+		FS:                 fs,
+		Logger:             adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+	require.NoError(t, client.Manage(ctx, "dot-vim", "dot-git"))
+	return client
+}
+
+func TestClient_ResolveSource_Link(t *testing.T) {
+	client := setupSourceTest(t)
+
+	source, err := client.ResolveSource(context.Background(), "/test/target/.vim/vimrc")
+	require.NoError(t, err)
+	assert.Equal(t, dot.SourceFile{Package: "dot-vim", Path: "/test/packages/dot-vim/vimrc", Managed: true}, source)
+}
+
+func TestClient_ResolveSource_FoldedDirectory(t *testing.T) {
+	client := setupSourceTest(t)
+
+	source, err := client.ResolveSource(context.Background(), "/test/target/.vim/colors/dark.vim")
+	require.NoError(t, err)
+	assert.Equal(t, "dot-vim", source.Package)
+	assert.Equal(t, "/test/packages/dot-vim/colors/dark.vim", source.Path)
+}
+
+func TestClient_ResolveSource_Template(t *testing.T) {
+	client := setupSourceTest(t)
+
+	source, err := client.ResolveSource(context.Background(), "/test/target/.git/.gitconfig")
+	require.NoError(t, err)
+	assert.Equal(t, dot.SourceFile{Package: "dot-git", Path: "/test/packages/dot-git/dot-gitconfig.tmpl", Template: true, Managed: true}, source)
+}
+
+func TestClient_ResolveSource_PackageRelative(t *testing.T) {
+	client := setupSourceTest(t)
+	ctx := context.Background()
+
+	source, err := client.ResolveSource(ctx, "dot-git/dot-gitconfig.tmpl")
+	require.NoError(t, err)
+	assert.Equal(t, dot.SourceFile{Package: "dot-git", Path: "/test/packages/dot-git/dot-gitconfig.tmpl", Template: true, Managed: true}, source)
+
+	source, err = client.ResolveSource(ctx, "dot-zsh/dot-zshrc")
+	require.NoError(t, err)
+	assert.Equal(t, "/test/packages/dot-zsh/dot-zshrc", source.Path, "new files can be created")
+	assert.False(t, source.Managed)
+}
+
+func TestClient_ResolveSource_Errors(t *testing.T) {
+	client := setupSourceTest(t)
+	ctx := context.Background()
+
+	_, err := client.ResolveSource(ctx, "/test/target/.bashrc")
+	assert.ErrorAs(t, err, &dot.ErrNotLinked{})
+
+	_, err = client.ResolveSource(ctx, "/elsewhere/.vim/vimrc")
+	assert.ErrorAs(t, err, &dot.ErrNotLinked{})
+
+	_, err = client.ResolveSource(ctx, "dot-emacs/init.el")
+	assert.ErrorAs(t, err, &dot.ErrPackageNotFound{})
+
+	_, err = client.ResolveSource(ctx, "../outside")
+	assert.ErrorAs(t, err, &dot.ErrInvalidPath{})
+}
@@ -0,0 +1,75 @@
+package dot
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+)
+
+// checkoutPackages extends a sparse checkout of the package directory with
+// the packages that are not checked out yet. Nothing is checked out in
+// dry-run mode or when the package directory is not a sparse checkout;
+// missing packages are then reported while planning.
+func (c *Client) checkoutPackages(ctx context.Context, packages []string) error {
+	if c.config.DryRun {
+		return nil
+	}
+	return materializePackages(ctx, c.config.FS, c.config.Logger, c.sparse, c.config.PackageDir, packages)
+}
+
+// materializePackages checks out the directories of packages missing from
+// the sparse checkout at packageDir. Packages already on disk, and package
+// directories that are fully checked out, are left alone.
+func materializePackages(ctx context.Context, fs FS, logger Logger, sparse adapters.GitSparseCheckout, packageDir string, packages []string) error {
+	if sparse == nil {
+		return nil
+	}
+
+	var missing []string
+	for _, pkg := range packages {
+		if !fs.Exists(ctx, filepath.Join(packageDir, pkg)) {
+			missing = append(missing, pkg)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	dirs, err := sparse.SparseDirectories(ctx, packageDir)
+	if err != nil || dirs == nil {
+		return nil
+	}
+
+	logger.Info(ctx, "extending_sparse_checkout", "packages", missing)
+	if err := sparse.AddSparseDirectories(ctx, packageDir, missing); err != nil {
+		return fmt.Errorf("check out packages: %w", err)
+	}
+	return nil
+}
+
+// indexedPackages returns the packages tracked in the sparse checkout at
+// packageDir, including those not checked out yet. It returns nil when
+// the package directory is fully checked out.
+func indexedPackages(ctx context.Context, sparse adapters.GitSparseCheckout, packageDir string) ([]string, error) {
+	if sparse == nil {
+		return nil, nil
+	}
+	dirs, err := sparse.SparseDirectories(ctx, packageDir)
+	if err != nil || dirs == nil {
+		return nil, err
+	}
+
+	tracked, err := sparse.RepositoryDirectories(ctx, packageDir)
+	if err != nil {
+		return nil, fmt.Errorf("read package index: %w", err)
+	}
+	packages := make([]string, 0, len(tracked))
+	for _, dir := range tracked {
+		if !isHiddenFile(dir) {
+			packages = append(packages, dir)
+		}
+	}
+	return packages, nil
+}
@@ -0,0 +1,147 @@
+package dot
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+)
+
+// mockSparseCheckout records sparse checkouts in a MemFS by creating the
+// added package directories.
+type mockSparseCheckout struct {
+	fs      *adapters.MemFS
+	dirs    []string
+	tracked []string
+	added   []string
+}
+
+func (m *mockSparseCheckout) SparseDirectories(ctx context.Context, path string) ([]string, error) {
+	return m.dirs, nil
+}
+
+func (m *mockSparseCheckout) AddSparseDirectories(ctx context.Context, path string, dirs []string) error {
+	m.added = append(m.added, dirs...)
+	for _, dir := range dirs {
+		if err := m.fs.MkdirAll(ctx, filepath.Join(path, dir), 0755); err != nil {
+			return err
+		}
+		if err := m.fs.WriteFile(ctx, filepath.Join(path, dir, "rc"), []byte("x"), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *mockSparseCheckout) RepositoryDirectories(ctx context.Context, path string) ([]string, error) {
+	return m.tracked, nil
+}
+
+// mockSparseCloner is a mockGitCloner with a sparse checkout.
+type mockSparseCloner struct {
+	mockGitCloner
+	*mockSparseCheckout
+}
+
+func TestCloneService_Clone_Packages(t *testing.T) {
+	var sparse []string
+	svc := newMirrorTestService(t, func(ctx context.Context, url string, dest string, opts adapters.CloneOptions) error {
+		sparse = opts.SparseDirectories
+		return nil
+	})
+	svc.selector = &mockPackageSelector{
+		selectFn: func(ctx context.Context, packages []string) ([]string, error) {
+			return nil, errors.New("selector must not be called")
+		},
+	}
+
+	_, err := svc.Clone(context.Background(), "https://github.com/user/dotfiles", CloneOptions{Packages: []string{"dot-vim"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dot-vim", filepath.Join(".config", "dot")}, sparse)
+}
+
+func TestCloneService_Clone_FullCheckoutWithoutPackages(t *testing.T) {
+	sparse := []string{"unset"}
+	svc := newMirrorTestService(t, func(ctx context.Context, url string, dest string, opts adapters.CloneOptions) error {
+		sparse = opts.SparseDirectories
+		return nil
+	})
+
+	_, err := svc.Clone(context.Background(), "https://github.com/user/dotfiles", CloneOptions{})
+	require.NoError(t, err)
+	assert.Nil(t, sparse)
+}
+
+func newSparseTestClient(t *testing.T, dirs []string) (*Client, *mockSparseCheckout) {
+	t.Helper()
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/dot-vim", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/dot-vim/vimrc", []byte("set nu"), 0644))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target", 0755))
+
+	client, err := NewClient(Config{
+		PackageDir: "/test/packages",
+		TargetDir:  "/test/target",
+		FS:         fs,
+		Logger:     adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+	sparse := &mockSparseCheckout{fs: fs, dirs: dirs}
+	client.sparse = sparse
+	return client, sparse
+}
+
+func TestClient_Manage_ExtendsSparseCheckout(t *testing.T) {
+	ctx := context.Background()
+	client, sparse := newSparseTestClient(t, []string{"dot-vim", ".config/dot"})
+
+	require.NoError(t, client.Manage(ctx, "dot-vim", "dot-zsh"))
+
+	assert.Equal(t, []string{"dot-zsh"}, sparse.added)
+	target, err := client.config.FS.ReadLink(ctx, "/test/target/rc")
+	require.NoError(t, err)
+	assert.Contains(t, target, "packages/dot-zsh/rc")
+}
+
+func TestClient_Manage_FullCheckoutIsNotExtended(t *testing.T) {
+	ctx := context.Background()
+	client, sparse := newSparseTestClient(t, nil)
+
+	err := client.Manage(ctx, "dot-zsh")
+	assert.Error(t, err, "missing package is reported")
+	assert.Empty(t, sparse.added)
+}
+
+func TestCloneService_Clone_Lazy(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newSparseTestClient(t, nil)
+	fs := client.config.FS.(*adapters.MemFS)
+	require.NoError(t, fs.RemoveAll(ctx, "/test/packages"))
+
+	var sparse []string
+	cloner := &mockSparseCloner{
+		mockGitCloner: mockGitCloner{cloneFn: func(ctx context.Context, url string, dest string, opts adapters.CloneOptions) error {
+			sparse = opts.SparseDirectories
+			return fs.MkdirAll(ctx, filepath.Join(dest, ".config", "dot"), 0755)
+		}},
+		mockSparseCheckout: &mockSparseCheckout{
+			fs:      fs,
+			dirs:    []string{".config/dot"},
+			tracked: []string{".config", "dot-vim", "dot-zsh"},
+		},
+	}
+	svc := newCloneService(fs, client.config.Logger, client.manageSvc, cloner, &mockPackageSelector{}, "/test/packages", "/test/target", false, false)
+
+	_, err := svc.Clone(ctx, "https://github.com/user/dotfiles", CloneOptions{Lazy: true})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{filepath.Join(".config", "dot")}, sparse, "no package is checked out by the clone")
+	assert.Equal(t, []string{"dot-vim", "dot-zsh"}, cloner.added, "installed packages are checked out from the index")
+	assert.True(t, fs.Exists(ctx, "/test/target/rc"))
+}
@@ -0,0 +1,117 @@
+package dot_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// setupSpecialFilesTest creates a target directory holding .app with a
+// config file and a named pipe.
+func setupSpecialFilesTest(t *testing.T, policy dot.SpecialFilePolicy) (*adapters.MemFS, *dot.Client) {
+	t.Helper()
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target/.app", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/target/.app/config", []byte("cfg"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/test/target/.app/control", nil, os.ModeNamedPipe|0600))
+
+	client, err := dot.NewClient(dot.Config{
+		PackageDir:   "/test/packages",
+		TargetDir:    "/test/target",
+		SpecialFiles: policy,
+		FS:           fs,
+		Logger:       adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+	return fs, client
+}
+
+func TestManage_SkipsSpecialFiles(t *testing.T) {
+	fs, client := setupSpecialFilesTest(t, dot.SpecialFilesSkip)
+	ctx := context.Background()
+
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/app", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/app/dot-apprc", []byte("rc"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/app/agent.sock", nil, os.ModeSocket|0600))
+
+	plan, err := client.PlanManage(ctx, "app")
+	require.NoError(t, err)
+	for _, op := range plan.Operations {
+		if link, ok := op.(dot.LinkCreate); ok {
+			assert.NotEqual(t, "/test/target/agent.sock", link.Target.String())
+		}
+	}
+	require.Len(t, plan.Metadata.Warnings, 1)
+	assert.Equal(t, "skipped socket /test/packages/app/agent.sock", plan.Metadata.Warnings[0].Message)
+	assert.Equal(t, "app", plan.Metadata.Warnings[0].Context["package"])
+
+	require.NoError(t, client.Manage(ctx, "app"))
+	assert.True(t, fs.Exists(ctx, "/test/target/.apprc"))
+	assert.False(t, fs.Exists(ctx, "/test/target/agent.sock"))
+}
+
+func TestManage_SpecialFilesError(t *testing.T) {
+	fs, client := setupSpecialFilesTest(t, dot.SpecialFilesError)
+	ctx := context.Background()
+
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/app", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/app/agent.sock", nil, os.ModeSocket|0600))
+
+	_, err := client.PlanManage(ctx, "app")
+	var special dot.ErrSpecialFile
+	require.ErrorAs(t, err, &special)
+	assert.Equal(t, "/test/packages/app/agent.sock", special.Path)
+	assert.Equal(t, "socket", special.Kind)
+}
+
+func TestAdopt_DirectoryWithSpecialFile(t *testing.T) {
+	fs, client := setupSpecialFilesTest(t, dot.SpecialFilesSkip)
+	ctx := context.Background()
+
+	plan, err := client.PlanAdopt(ctx, []string{".app"}, "app")
+	require.NoError(t, err)
+	require.Len(t, plan.Metadata.Warnings, 1)
+	assert.Equal(t, "skipped named pipe /test/target/.app/control", plan.Metadata.Warnings[0].Message)
+
+	require.NoError(t, client.Adopt(ctx, []string{".app"}, "app"))
+
+	// The directory stays in place with the pipe; its config is adopted
+	isDir, err := fs.IsDir(ctx, "/test/target/.app")
+	require.NoError(t, err)
+	assert.True(t, isDir)
+	assert.True(t, fs.Exists(ctx, "/test/target/.app/control"))
+	target, err := fs.ReadLink(ctx, "/test/target/.app/config")
+	require.NoError(t, err)
+	assert.Equal(t, "/test/packages/app/config", target)
+	data, err := fs.ReadFile(ctx, "/test/packages/app/config")
+	require.NoError(t, err)
+	assert.Equal(t, "cfg", string(data))
+	assert.False(t, fs.Exists(ctx, "/test/packages/app/control"))
+}
+
+func TestAdopt_SingleSpecialFile(t *testing.T) {
+	ctx := context.Background()
+
+	_, client := setupSpecialFilesTest(t, dot.SpecialFilesSkip)
+	plan, err := client.PlanAdopt(ctx, []string{".app/control"}, "app")
+	require.NoError(t, err)
+	for _, op := range plan.Operations {
+		assert.NotEqual(t, dot.OpKindFileMove, op.Kind())
+	}
+	require.Len(t, plan.Metadata.Warnings, 1)
+
+	_, client = setupSpecialFilesTest(t, dot.SpecialFilesError)
+	_, err = client.PlanAdopt(ctx, []string{".app"}, "app")
+	var special dot.ErrSpecialFile
+	require.ErrorAs(t, err, &special)
+	assert.Equal(t, "named pipe", special.Kind)
+}
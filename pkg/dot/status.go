@@ -1,6 +1,10 @@
 package dot
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
 // Status represents the installation state of packages.
 type Status struct {
@@ -14,4 +18,109 @@ type PackageInfo struct {
 	InstalledAt time.Time `json:"installed_at" yaml:"installed_at"`
 	LinkCount   int       `json:"link_count" yaml:"link_count"`
 	Links       []string  `json:"links" yaml:"links"`
+	Installed   bool      `json:"installed" yaml:"installed"`
+
+	// SourceUnknown is set when the package directory is unavailable, so
+	// the package is reported from the manifest alone and whether its
+	// source files still exist cannot be told.
+	SourceUnknown bool `json:"source_unknown,omitempty" yaml:"source_unknown,omitempty"`
+
+	// Health summarizes the state of the links of an installed package.
+	// It is only set when requested with StatusOptions.Health.
+	Health *LinkHealthSummary `json:"health,omitempty" yaml:"health,omitempty"`
+}
+
+// StatusOptions selects what StatusWithOptions reports.
+type StatusOptions struct {
+	// Packages restricts the status to the named packages; empty reports
+	// every installed package.
+	Packages []string
+
+	// Health inspects the links of each package and sets
+	// PackageInfo.Health. Without it status reads the manifest only.
+	Health bool
+}
+
+// LinkHealthSummary counts the links of a package by state, as
+// classified by Health.
+type LinkHealthSummary struct {
+	OK      int `json:"ok" yaml:"ok"`
+	Broken  int `json:"broken" yaml:"broken"`
+	Drifted int `json:"drifted" yaml:"drifted"`
+	Unknown int `json:"unknown,omitempty" yaml:"unknown,omitempty"`
+
+	// VerifiedAt is when the links were inspected.
+	VerifiedAt time.Time `json:"verified_at" yaml:"verified_at"`
+}
+
+// Healthy reports whether no link is broken or drifted.
+func (h LinkHealthSummary) Healthy() bool {
+	return h.Broken == 0 && h.Drifted == 0
+}
+
+// String returns "ok" for a healthy package, or the counts of broken,
+// drifted and unknown links, as in "1 broken, 2 drifted".
+func (h LinkHealthSummary) String() string {
+	var parts []string
+	for _, c := range []struct {
+		count int
+		state LinkState
+	}{{h.Broken, LinkStateBroken}, {h.Drifted, LinkStateDrifted}, {h.Unknown, LinkStateUnknown}} {
+		if c.count > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", c.count, c.state))
+		}
+	}
+	if len(parts) == 0 {
+		return string(LinkStateOK)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// LinkState describes the on-disk state of a managed link.
+type LinkState string
+
+const (
+	// LinkStateOK indicates the link points into its package.
+	LinkStateOK LinkState = "ok"
+	// LinkStateBroken indicates the link or its target is missing.
+	LinkStateBroken LinkState = "broken"
+	// LinkStateDrifted indicates the path was replaced or now points elsewhere.
+	LinkStateDrifted LinkState = "drifted"
+	// LinkStateUnknown indicates the link points into a package directory
+	// that is unavailable, so whether its source exists cannot be told.
+	LinkStateUnknown LinkState = "unknown"
+)
+
+// LinkStatus reports the state of a single managed link.
+type LinkStatus struct {
+	Path  string    `json:"path" yaml:"path"`
+	State LinkState `json:"state" yaml:"state"`
+}
+
+// PackageHealth reports the state of every link recorded for a package.
+type PackageHealth struct {
+	Name  string       `json:"name" yaml:"name"`
+	Links []LinkStatus `json:"links" yaml:"links"`
+}
+
+// Summary counts the links of h by state, as verified at verifiedAt.
+func (h PackageHealth) Summary(verifiedAt time.Time) LinkHealthSummary {
+	return LinkHealthSummary{
+		OK:         h.Count(LinkStateOK),
+		Broken:     h.Count(LinkStateBroken),
+		Drifted:    h.Count(LinkStateDrifted),
+		Unknown:    h.Count(LinkStateUnknown),
+		VerifiedAt: verifiedAt,
+	}
+}
+
+// Count returns the number of links in the given state.
+func (h PackageHealth) Count(state LinkState) int {
+	count := 0
+	for _, link := range h.Links {
+		if link.State == state {
+			count++
+		}
+	}
+	return count
 }
@@ -0,0 +1,146 @@
+package dot_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+func TestClient_Health_ClassifiesLinks(t *testing.T) {
+	fs, client := setupStowedTree(t)
+	ctx := context.Background()
+
+	require.NoError(t, client.Manage(ctx, "vim"))
+
+	health, err := client.Health(ctx)
+	require.NoError(t, err)
+	require.Len(t, health, 1)
+	assert.Equal(t, "vim", health[0].Name)
+	assert.Equal(t, 2, health[0].Count(dot.LinkStateOK))
+
+	// Replace one link with a regular file and break the other
+	require.NoError(t, fs.Remove(ctx, "/test/target/.vimrc"))
+	require.NoError(t, fs.WriteFile(ctx, "/test/target/.vimrc", []byte("local"), 0644))
+	require.NoError(t, fs.Remove(ctx, "/test/packages/vim/dot-gvimrc"))
+
+	health, err = client.Health(ctx, "vim")
+	require.NoError(t, err)
+	require.Len(t, health, 1)
+	assert.Equal(t, 0, health[0].Count(dot.LinkStateOK))
+	assert.Equal(t, 1, health[0].Count(dot.LinkStateDrifted))
+	assert.Equal(t, 1, health[0].Count(dot.LinkStateBroken))
+}
+
+func TestClient_Health_DetectsLinkOutsidePackage(t *testing.T) {
+	fs, client := setupStowedTree(t)
+	ctx := context.Background()
+
+	require.NoError(t, client.Manage(ctx, "vim"))
+	require.NoError(t, fs.WriteFile(ctx, "/test/elsewhere", []byte("x"), 0644))
+	require.NoError(t, fs.Remove(ctx, "/test/target/.vimrc"))
+	require.NoError(t, fs.Symlink(ctx, "/test/elsewhere", "/test/target/.vimrc"))
+
+	health, err := client.Health(ctx, "vim")
+	require.NoError(t, err)
+	require.Len(t, health, 1)
+	for _, link := range health[0].Links {
+		if link.Path == ".vimrc" {
+			assert.Equal(t, dot.LinkStateDrifted, link.State)
+		}
+	}
+}
+
+func TestClient_Health_NoManifest(t *testing.T) {
+	_, client := setupStowedTree(t)
+
+	health, err := client.Health(context.Background())
+
+	require.NoError(t, err)
+	assert.Empty(t, health)
+}
+
+func TestClient_StatusAndDoctor_WithoutPackageDir(t *testing.T) {
+	fs, client := setupStowedTree(t)
+	ctx := context.Background()
+
+	require.NoError(t, client.Manage(ctx, "vim"))
+	// Audit the target as if its packages were on another machine
+	require.NoError(t, fs.RemoveAll(ctx, "/test/packages"))
+
+	status, err := client.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, status.Packages, 1)
+	assert.True(t, status.Packages[0].SourceUnknown)
+	assert.Equal(t, 2, status.Packages[0].LinkCount)
+
+	health, err := client.Health(ctx)
+	require.NoError(t, err)
+	require.Len(t, health, 1)
+	assert.Equal(t, 2, health[0].Count(dot.LinkStateUnknown))
+	assert.Equal(t, 0, health[0].Count(dot.LinkStateBroken))
+
+	report, err := client.Doctor(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, dot.HealthOK, report.OverallHealth)
+	assert.Equal(t, 0, report.Statistics.BrokenLinks)
+	assert.Equal(t, 2, report.Statistics.UnknownLinks)
+	assert.Equal(t, 2, report.Statistics.Packages["vim"].UnknownLinks)
+	require.Len(t, report.Issues, 1)
+	assert.Equal(t, dot.IssueSourceUnavailable, report.Issues[0].Type)
+	assert.Equal(t, dot.SeverityInfo, report.Issues[0].Severity)
+}
+
+func TestClient_Doctor_MissingLinkWithoutPackageDir(t *testing.T) {
+	fs, client := setupStowedTree(t)
+	ctx := context.Background()
+
+	require.NoError(t, client.Manage(ctx, "vim"))
+	require.NoError(t, fs.RemoveAll(ctx, "/test/packages"))
+	require.NoError(t, fs.Remove(ctx, "/test/target/.vimrc"))
+
+	report, err := client.Doctor(ctx)
+	require.NoError(t, err)
+
+	// A link missing from the target is broken whatever its source
+	assert.Equal(t, 1, report.Statistics.BrokenLinks)
+	assert.Equal(t, 1, report.Statistics.UnknownLinks)
+}
+
+func TestClient_StatusWithOptions_Health(t *testing.T) {
+	fs, client := setupStowedTree(t)
+	ctx := context.Background()
+
+	require.NoError(t, client.Manage(ctx, "vim"))
+
+	// Status alone reads the manifest only
+	status, err := client.Status(ctx, "vim")
+	require.NoError(t, err)
+	require.Len(t, status.Packages, 1)
+	assert.Nil(t, status.Packages[0].Health)
+
+	require.NoError(t, fs.Remove(ctx, "/test/packages/vim/dot-gvimrc"))
+	before := time.Now()
+
+	status, err = client.StatusWithOptions(ctx, dot.StatusOptions{Packages: []string{"vim"}, Health: true})
+	require.NoError(t, err)
+	require.Len(t, status.Packages, 1)
+	health := status.Packages[0].Health
+	require.NotNil(t, health)
+	assert.Equal(t, 1, health.OK)
+	assert.Equal(t, 1, health.Broken)
+	assert.Equal(t, 0, health.Drifted)
+	assert.False(t, health.Healthy())
+	assert.Equal(t, "1 broken", health.String())
+	assert.False(t, health.VerifiedAt.Before(before))
+}
+
+func TestLinkHealthSummary_String(t *testing.T) {
+	assert.Equal(t, "ok", dot.LinkHealthSummary{OK: 3}.String())
+	assert.Equal(t, "1 broken, 2 drifted, 1 unknown", dot.LinkHealthSummary{Broken: 1, Drifted: 2, Unknown: 1}.String())
+	assert.True(t, dot.LinkHealthSummary{OK: 1, Unknown: 1}.Healthy())
+}
@@ -2,24 +2,63 @@ package dot
 
 import (
 	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jamesainslie/dot/internal/manifest"
 )
 
 // StatusService handles status and listing operations.
 type StatusService struct {
+	fs          FS
 	manifestSvc *ManifestService
+	packageDir  string
 	targetDir   string
+
+	// targets are the status services of the directories packages are
+	// linked into besides targetDir; see Config.Targets.
+	targets []*StatusService
 }
 
 // newStatusService creates a new status service.
-func newStatusService(manifestSvc *ManifestService, targetDir string) *StatusService {
+func newStatusService(fs FS, manifestSvc *ManifestService, packageDir, targetDir string) *StatusService {
 	return &StatusService{
+		fs:          fs,
 		manifestSvc: manifestSvc,
+		packageDir:  packageDir,
 		targetDir:   targetDir,
 	}
 }
 
 // Status reports the current installation state for packages.
 func (s *StatusService) Status(ctx context.Context, packages ...string) (Status, error) {
+	return s.StatusWithOptions(ctx, StatusOptions{Packages: packages})
+}
+
+// StatusWithOptions reports the installation state of the packages
+// selected by opts, with the health of their links if requested.
+func (s *StatusService) StatusWithOptions(ctx context.Context, opts StatusOptions) (Status, error) {
+	status, err := s.targetStatus(ctx, opts)
+	if err != nil {
+		return Status{}, err
+	}
+	for _, target := range s.targets {
+		other, err := target.StatusWithOptions(ctx, opts)
+		if err != nil {
+			return Status{}, err
+		}
+		status.Packages = append(status.Packages, other.Packages...)
+	}
+	return status, nil
+}
+
+// targetStatus reports the installation state for packages recorded in
+// the manifest of targetDir.
+func (s *StatusService) targetStatus(ctx context.Context, opts StatusOptions) (Status, error) {
 	targetPathResult := NewTargetPath(s.targetDir)
 	if !targetPathResult.IsOk() {
 		return Status{}, targetPathResult.UnwrapErr()
@@ -39,38 +78,107 @@ func (s *StatusService) Status(ctx context.Context, packages ...string) (Status,
 
 	m := manifestResult.Unwrap()
 
+	// Without the package directory the manifest is all there is to go on
+	sourceUnknown := !s.fs.Exists(ctx, s.packageDir)
+
 	// Filter to requested packages if specified
-	pkgInfos := make([]PackageInfo, 0)
-	if len(packages) == 0 {
-		// Return all packages
+	var infos []manifest.PackageInfo
+	if len(opts.Packages) == 0 {
 		for _, info := range m.Packages {
-			pkgInfos = append(pkgInfos, PackageInfo{
-				Name:        info.Name,
-				Source:      string(info.Source),
-				InstalledAt: info.InstalledAt,
-				LinkCount:   info.LinkCount,
-				Links:       info.Links,
-			})
+			infos = append(infos, info)
 		}
 	} else {
-		// Return only specified packages
-		for _, pkg := range packages {
+		for _, pkg := range opts.Packages {
 			if info, exists := m.GetPackage(pkg); exists {
-				pkgInfos = append(pkgInfos, PackageInfo{
-					Name:        info.Name,
-					Source:      string(info.Source),
-					InstalledAt: info.InstalledAt,
-					LinkCount:   info.LinkCount,
-					Links:       info.Links,
-				})
+				infos = append(infos, info)
 			}
 		}
 	}
+
+	verifiedAt := time.Now()
+	pkgInfos := make([]PackageInfo, 0, len(infos))
+	for _, info := range infos {
+		pkgInfo := PackageInfo{
+			Name:          info.Name,
+			Source:        string(info.Source),
+			InstalledAt:   info.InstalledAt,
+			LinkCount:     info.LinkCount,
+			Links:         info.Links,
+			Installed:     true,
+			SourceUnknown: sourceUnknown,
+		}
+		if opts.Health {
+			summary := s.packageHealth(ctx, info).Summary(verifiedAt)
+			pkgInfo.Health = &summary
+		}
+		pkgInfos = append(pkgInfos, pkgInfo)
+	}
 	return Status{
 		Packages: pkgInfos,
 	}, nil
 }
 
+// ListOptions selects the packages returned by ListWithOptions.
+type ListOptions struct {
+	// Installed includes packages recorded in the manifest.
+	Installed bool
+
+	// Available includes packages in the package directory that are not
+	// installed. With neither Installed nor Available set, both are listed.
+	Available bool
+
+	// Pattern restricts the list to package names matching the glob.
+	Pattern string
+}
+
+// ListWithOptions returns installed and available packages selected by
+// opts, sorted by name. Available packages have Installed unset and no
+// links; a missing package directory lists none.
+func (s *StatusService) ListWithOptions(ctx context.Context, opts ListOptions) ([]PackageInfo, error) {
+	if !opts.Installed && !opts.Available {
+		opts.Installed, opts.Available = true, true
+	}
+	if opts.Pattern != "" {
+		if _, err := filepath.Match(opts.Pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", opts.Pattern, err)
+		}
+	}
+
+	installed, err := s.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(installed))
+	var packages []PackageInfo
+	for _, pkg := range installed {
+		seen[pkg.Name] = true
+		if opts.Installed {
+			packages = append(packages, pkg)
+		}
+	}
+
+	if opts.Available && s.fs.Exists(ctx, s.packageDir) {
+		names, err := discoverPackages(ctx, s.fs, s.packageDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			if !seen[name] {
+				packages = append(packages, PackageInfo{Name: name})
+			}
+		}
+	}
+
+	if opts.Pattern != "" {
+		packages = slices.DeleteFunc(packages, func(pkg PackageInfo) bool {
+			matched, _ := filepath.Match(opts.Pattern, pkg.Name)
+			return !matched
+		})
+	}
+	sort.Slice(packages, func(i, j int) bool { return packages[i].Name < packages[j].Name })
+	return packages, nil
+}
+
 // List returns all installed packages from the manifest.
 func (s *StatusService) List(ctx context.Context) ([]PackageInfo, error) {
 	status, err := s.Status(ctx)
@@ -79,3 +187,124 @@ func (s *StatusService) List(ctx context.Context) ([]PackageInfo, error) {
 	}
 	return status.Packages, nil
 }
+
+// Health inspects every link recorded for the given packages.
+// If no packages are specified, all installed packages are inspected.
+// Results are sorted by package name.
+func (s *StatusService) Health(ctx context.Context, packages ...string) ([]PackageHealth, error) {
+	health, err := s.targetHealth(ctx, packages)
+	if err != nil || len(s.targets) == 0 {
+		return health, err
+	}
+	for _, target := range s.targets {
+		other, err := target.Health(ctx, packages...)
+		if err != nil {
+			return nil, err
+		}
+		health = append(health, other...)
+	}
+	sort.Slice(health, func(i, j int) bool { return health[i].Name < health[j].Name })
+	return health, nil
+}
+
+// targetHealth inspects the links recorded for packages in the manifest
+// of targetDir.
+func (s *StatusService) targetHealth(ctx context.Context, packages []string) ([]PackageHealth, error) {
+	targetPathResult := NewTargetPath(s.targetDir)
+	if !targetPathResult.IsOk() {
+		return nil, targetPathResult.UnwrapErr()
+	}
+
+	manifestResult := s.manifestSvc.Load(ctx, targetPathResult.Unwrap())
+	if !manifestResult.IsOk() {
+		err := manifestResult.UnwrapErr()
+		if isManifestNotFoundError(err) {
+			return []PackageHealth{}, nil
+		}
+		return nil, err
+	}
+	m := manifestResult.Unwrap()
+
+	if len(packages) == 0 {
+		for name := range m.Packages {
+			packages = append(packages, name)
+		}
+	}
+	sort.Strings(packages)
+
+	health := make([]PackageHealth, 0, len(packages))
+	for _, pkg := range packages {
+		info, exists := m.GetPackage(pkg)
+		if !exists {
+			continue
+		}
+		health = append(health, s.packageHealth(ctx, info))
+	}
+	return health, nil
+}
+
+// packageHealth classifies each link of an installed package.
+func (s *StatusService) packageHealth(ctx context.Context, info manifest.PackageInfo) PackageHealth {
+	rendered := make(map[string]string, len(info.Templates))
+	for _, render := range info.Templates {
+		rendered[render.Link] = render.Rendered
+	}
+
+	links := make([]LinkStatus, 0, len(info.Links))
+	for _, link := range info.Links {
+		links = append(links, LinkStatus{
+			Path:  link,
+			State: s.linkState(ctx, info.Name, link, rendered[link]),
+		})
+	}
+	return PackageHealth{Name: info.Name, Links: links}
+}
+
+// linkState determines whether a link is intact, broken, or has drifted
+// away from its package. Rendered template links are expected to point at
+// their rendered output instead of the package directory. A link into a
+// package directory that does not exist, such as one on another machine,
+// is unknown rather than broken.
+func (s *StatusService) linkState(ctx context.Context, pkg, link, rendered string) LinkState {
+	fullPath := filepath.Join(s.targetDir, link)
+
+	isLink, err := s.fs.IsSymlink(ctx, fullPath)
+	if err != nil {
+		return LinkStateBroken
+	}
+	if !isLink {
+		return LinkStateDrifted
+	}
+
+	target, err := s.fs.ReadLink(ctx, fullPath)
+	if err != nil {
+		return LinkStateBroken
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(fullPath), target)
+	}
+	target = filepath.Clean(target)
+
+	switch {
+	case rendered != "":
+		if target != filepath.Clean(rendered) {
+			return LinkStateDrifted
+		}
+	case !isWithin(target, filepath.Join(s.packageDir, pkg)):
+		return LinkStateDrifted
+	}
+
+	if !s.fs.Exists(ctx, target) {
+		if rendered == "" && !s.fs.Exists(ctx, s.packageDir) {
+			return LinkStateUnknown
+		}
+		return LinkStateBroken
+	}
+	return LinkStateOK
+}
+
+// isWithin reports whether path is dir or a descendant of dir.
+func isWithin(path, dir string) bool {
+	dir = filepath.Clean(dir)
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
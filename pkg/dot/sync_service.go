@@ -0,0 +1,292 @@
+package dot
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/internal/manifest"
+	"github.com/jamesainslie/dot/internal/templating"
+)
+
+// SyncService pulls repository changes and remanages affected packages.
+type SyncService struct {
+	fs          FS
+	logger      Logger
+	manageSvc   *ManageService
+	unmanageSvc *UnmanageService
+	manifestSvc *ManifestService
+	puller      adapters.GitPuller
+	packageDir  string
+	targetDir   string
+	dryRun      bool
+	offline     bool
+}
+
+// newSyncService creates a new sync service.
+func newSyncService(
+	fs FS,
+	logger Logger,
+	manageSvc *ManageService,
+	unmanageSvc *UnmanageService,
+	manifestSvc *ManifestService,
+	puller adapters.GitPuller,
+	packageDir string,
+	targetDir string,
+	dryRun bool,
+	offline bool,
+) *SyncService {
+	return &SyncService{
+		fs:          fs,
+		logger:      logger,
+		manageSvc:   manageSvc,
+		unmanageSvc: unmanageSvc,
+		manifestSvc: manifestSvc,
+		puller:      puller,
+		packageDir:  packageDir,
+		targetDir:   targetDir,
+		dryRun:      dryRun,
+		offline:     offline,
+	}
+}
+
+// SyncOptions configures sync behavior.
+type SyncOptions struct {
+	// Branch specifies which remote branch to pull, or for a per-machine
+	// branch which branch to rebase onto.
+	// If empty, the branch recorded in the manifest (or tracked by HEAD) is used.
+	Branch string
+}
+
+// SyncResult describes the outcome of a sync.
+type SyncResult struct {
+	// FromCommit is the commit recorded in the manifest before syncing.
+	// Empty if no commit was recorded.
+	FromCommit string
+
+	// ToCommit is the commit checked out after pulling.
+	ToCommit string
+
+	// Packages lists installed packages that changed and were remanaged.
+	Packages []string
+
+	// Removed lists installed packages whose directory no longer exists
+	// and which were unmanaged.
+	Removed []string
+
+	// Plan is the incremental remanage plan for the changed packages.
+	Plan Plan
+
+	// Rebased is the number of per-machine commits replayed onto the base
+	// branch. The remote copy of the machine branch, if any, must be force
+	// pushed afterwards.
+	Rebased int
+
+	// ShallowHistory reports that FromCommit is not in the shallow clone's
+	// history, so changes could not be diffed and every installed package
+	// was remanaged. Client.Unshallow fetches the missing history.
+	ShallowHistory bool
+}
+
+// UpToDate reports whether the sync found nothing to apply.
+func (r SyncResult) UpToDate() bool {
+	return len(r.Packages) == 0 && len(r.Removed) == 0
+}
+
+// Sync pulls the package repository and remanages changed packages.
+//
+// Workflow:
+//  1. Pull the package directory, or rebase a per-machine branch onto its
+//     base branch (skipped in dry-run mode)
+//  2. Diff the recorded manifest commit against the new HEAD
+//  3. Map changed files to installed packages
+//  4. Remanage changed packages and unmanage removed ones
+//  5. Record the new commit in the manifest
+//
+// In dry-run mode the repository is not pulled; the plan covers changes
+// already present in the checkout but not yet applied.
+func (s *SyncService) Sync(ctx context.Context, opts SyncOptions) (SyncResult, error) {
+	s.logger.Info(ctx, "sync_started", "package_dir", s.packageDir)
+
+	// Dry-run never pulls, so it still previews local changes while offline
+	if s.offline && !s.dryRun {
+		return SyncResult{}, ErrOffline{Operation: "sync"}
+	}
+
+	targetPathResult := NewTargetPath(s.targetDir)
+	if !targetPathResult.IsOk() {
+		return SyncResult{}, targetPathResult.UnwrapErr()
+	}
+	targetPath := targetPathResult.Unwrap()
+
+	manifestResult := s.manifestSvc.Load(ctx, targetPath)
+	if !manifestResult.IsOk() {
+		return SyncResult{}, manifestResult.UnwrapErr()
+	}
+	m := manifestResult.Unwrap()
+
+	repoInfo, hasRepo := m.GetRepository()
+	result := SyncResult{FromCommit: repoInfo.CommitSHA}
+
+	if !s.dryRun {
+		rebased, err := s.pull(ctx, repoInfo, opts)
+		if err != nil {
+			return SyncResult{}, err
+		}
+		result.Rebased = rebased
+	}
+
+	head, err := s.puller.Head(ctx, s.packageDir)
+	if err != nil {
+		return SyncResult{}, ErrPullFailed{Path: s.packageDir, Cause: err}
+	}
+	result.ToCommit = head
+
+	changed, removed, diffFailed := s.changedPackages(ctx, m, result.FromCommit, head)
+	result.Packages = changed
+	result.Removed = removed
+	if diffFailed && s.isShallow(ctx) {
+		s.logger.Warn(ctx, "sync_history_incomplete", "from", result.FromCommit, "package_dir", s.packageDir)
+		result.ShallowHistory = true
+	}
+	s.logger.Info(ctx, "sync_changes_detected", "from", result.FromCommit, "to", head, "changed", changed, "removed", removed)
+
+	if len(changed) > 0 {
+		plan, err := s.manageSvc.PlanRemanage(ctx, changed...)
+		if err != nil {
+			return SyncResult{}, err
+		}
+		result.Plan = plan
+	}
+
+	if s.dryRun {
+		s.logger.Info(ctx, "dry_run_sync", "operations", len(result.Plan.Operations))
+		return result, nil
+	}
+
+	if len(changed) > 0 {
+		if err := s.manageSvc.Remanage(ctx, changed...); err != nil {
+			return SyncResult{}, fmt.Errorf("remanage packages: %w", err)
+		}
+	}
+	if len(removed) > 0 {
+		if err := s.unmanageSvc.Unmanage(ctx, removed...); err != nil {
+			return SyncResult{}, fmt.Errorf("unmanage removed packages: %w", err)
+		}
+	}
+
+	repoInfo.CommitSHA = head
+	if !hasRepo && repoInfo.Branch == "" {
+		if branch, err := getCurrentBranch(ctx, s.fs, s.packageDir); err == nil {
+			repoInfo.Branch = branch
+		}
+	}
+	if err := s.recordCommit(ctx, targetPath, repoInfo); err != nil {
+		s.logger.Warn(ctx, "failed_to_record_commit", "error", err)
+	}
+
+	s.logger.Info(ctx, "sync_complete", "commit", head, "remanaged", len(changed), "removed", len(removed))
+	return result, nil
+}
+
+// pull updates the package directory from its remote, returning the number
+// of per-machine commits rebased. opts.Branch overrides the pulled branch,
+// or for a per-machine branch the branch it is rebased onto.
+func (s *SyncService) pull(ctx context.Context, repoInfo manifest.RepositoryInfo, opts SyncOptions) (int, error) {
+	if opts.Branch != "" {
+		if repoInfo.BaseBranch != "" {
+			repoInfo.BaseBranch = opts.Branch
+		} else {
+			repoInfo.Branch = opts.Branch
+		}
+	}
+	return updateCheckout(ctx, s.logger, s.puller, s.packageDir, repoInfo)
+}
+
+// isShallow reports whether the package directory is a shallow clone.
+// Pullers that cannot inspect history are treated as having full history.
+func (s *SyncService) isShallow(ctx context.Context) bool {
+	history, ok := s.puller.(adapters.GitHistory)
+	if !ok {
+		return false
+	}
+	shallow, err := history.IsShallow(ctx, s.packageDir)
+	return err == nil && shallow
+}
+
+// changedPackages maps files changed between two commits to installed packages.
+// Falls back to every installed package when the change set cannot be computed,
+// which is reported by the final return value.
+func (s *SyncService) changedPackages(ctx context.Context, m manifest.Manifest, from, to string) ([]string, []string, bool) {
+	installed := make(map[string]manifest.PackageInfo, len(m.Packages))
+	for name, info := range m.Packages {
+		installed[name] = info
+	}
+
+	affected := make(map[string]bool)
+	diffFailed := false
+	switch {
+	case from == to:
+		// Nothing new since the last sync
+	case from == "":
+		s.logger.Info(ctx, "no_recorded_commit", "remanaging", "all")
+		for name := range installed {
+			affected[name] = true
+		}
+	default:
+		files, err := s.puller.ChangedFiles(ctx, s.packageDir, from, to)
+		if err != nil {
+			s.logger.Warn(ctx, "diff_failed_remanaging_all", "error", err)
+			diffFailed = true
+			for name := range installed {
+				affected[name] = true
+			}
+			break
+		}
+		for _, file := range files {
+			top := strings.SplitN(file, "/", 2)[0]
+			if top == templating.ValuesDir {
+				// Template values affect every package with rendered files
+				for name, info := range installed {
+					if len(info.Templates) > 0 {
+						affected[name] = true
+					}
+				}
+				continue
+			}
+			if _, ok := installed[top]; ok {
+				affected[top] = true
+			}
+		}
+	}
+
+	changed := make([]string, 0, len(affected))
+	removed := make([]string, 0)
+	for name := range affected {
+		if s.fs.Exists(ctx, filepath.Join(s.packageDir, name)) {
+			changed = append(changed, name)
+		} else {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(changed)
+	sort.Strings(removed)
+
+	return changed, removed, diffFailed
+}
+
+// recordCommit stores the synced commit in the manifest repository information.
+func (s *SyncService) recordCommit(ctx context.Context, targetPath TargetPath, info manifest.RepositoryInfo) error {
+	manifestResult := s.manifestSvc.Load(ctx, targetPath)
+	if !manifestResult.IsOk() {
+		return manifestResult.UnwrapErr()
+	}
+
+	m := manifestResult.Unwrap()
+	m.SetRepository(info)
+
+	return s.manifestSvc.Save(ctx, targetPath, m)
+}
@@ -0,0 +1,254 @@
+package dot
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/internal/manifest"
+)
+
+const (
+	syncOldCommit = "1111111111111111111111111111111111111111"
+	syncNewCommit = "2222222222222222222222222222222222222222"
+)
+
+// setupSyncClient creates a client with vim and zsh installed at syncOldCommit.
+func setupSyncClient(t *testing.T, dryRun bool, puller *mockGitPuller) (*Client, FS) {
+	t.Helper()
+	ctx := context.Background()
+	fs := adapters.NewMemFS()
+
+	require.NoError(t, fs.MkdirAll(ctx, "/packages/vim", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/packages/zsh", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/home", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/packages/vim/dot-vimrc", []byte("set nocompatible"), 0644))
+	require.NoError(t, fs.WriteFile(ctx, "/packages/zsh/dot-zshrc", []byte("export EDITOR=vim"), 0644))
+
+	setup, err := NewClient(Config{
+		PackageDir: "/packages",
+		TargetDir:  "/home",
+		FS:         fs,
+		Logger:     adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+	require.NoError(t, setup.Manage(ctx, "vim", "zsh"))
+
+	targetPath := NewTargetPath("/home").Unwrap()
+	m := setup.manageSvc.manifestSvc.Load(ctx, targetPath).Unwrap()
+	m.SetRepository(manifest.RepositoryInfo{URL: "https://example.com/dotfiles.git", Branch: "main", CommitSHA: syncOldCommit})
+	require.NoError(t, setup.manageSvc.manifestSvc.Save(ctx, targetPath, m))
+
+	client, err := NewClient(Config{
+		PackageDir: "/packages",
+		TargetDir:  "/home",
+		FS:         fs,
+		Logger:     adapters.NewNoopLogger(),
+		DryRun:     dryRun,
+	})
+	require.NoError(t, err)
+	client.syncSvc.puller = puller
+	return client, fs
+}
+
+func TestSyncService_RemanagesChangedPackages(t *testing.T) {
+	ctx := context.Background()
+	puller := &mockGitPuller{head: syncNewCommit, changed: []string{"vim/dot-gvimrc", "README.md"}}
+	client, fs := setupSyncClient(t, false, puller)
+
+	puller.pullFn = func(ctx context.Context, path string, opts adapters.PullOptions) error {
+		assert.Equal(t, "main", opts.Branch)
+		return fs.WriteFile(ctx, "/packages/vim/dot-gvimrc", []byte("set guifont"), 0644)
+	}
+
+	result, err := client.Sync(ctx, SyncOptions{})
+	require.NoError(t, err)
+
+	assert.True(t, puller.pulled)
+	assert.Equal(t, syncOldCommit, result.FromCommit)
+	assert.Equal(t, syncNewCommit, result.ToCommit)
+	assert.Equal(t, []string{"vim"}, result.Packages)
+	assert.False(t, result.UpToDate())
+
+	target, err := fs.ReadLink(ctx, "/home/.gvimrc")
+	require.NoError(t, err)
+	assert.Equal(t, "/packages/vim/dot-gvimrc", target)
+
+	m := client.manageSvc.manifestSvc.Load(ctx, NewTargetPath("/home").Unwrap()).Unwrap()
+	repo, ok := m.GetRepository()
+	require.True(t, ok)
+	assert.Equal(t, syncNewCommit, repo.CommitSHA)
+	assert.Equal(t, "https://example.com/dotfiles.git", repo.URL)
+}
+
+func TestSyncService_UpToDate(t *testing.T) {
+	ctx := context.Background()
+	puller := &mockGitPuller{head: syncOldCommit}
+	client, _ := setupSyncClient(t, false, puller)
+
+	result, err := client.Sync(ctx, SyncOptions{})
+	require.NoError(t, err)
+	assert.True(t, result.UpToDate())
+	assert.Empty(t, result.Plan.Operations)
+}
+
+func TestSyncService_DryRunDoesNotPull(t *testing.T) {
+	ctx := context.Background()
+	puller := &mockGitPuller{head: syncNewCommit, changed: []string{"zsh/dot-zprofile"}}
+	client, fs := setupSyncClient(t, true, puller)
+
+	// Change already checked out but not yet applied
+	require.NoError(t, fs.WriteFile(ctx, "/packages/zsh/dot-zprofile", []byte("path=()"), 0644))
+
+	result, err := client.Sync(ctx, SyncOptions{})
+	require.NoError(t, err)
+
+	assert.False(t, puller.pulled)
+	assert.Equal(t, []string{"zsh"}, result.Packages)
+	assert.NotEmpty(t, result.Plan.Operations)
+	assert.False(t, fs.Exists(ctx, "/home/.zprofile"))
+
+	m := client.manageSvc.manifestSvc.Load(ctx, NewTargetPath("/home").Unwrap()).Unwrap()
+	repo, _ := m.GetRepository()
+	assert.Equal(t, syncOldCommit, repo.CommitSHA)
+}
+
+func TestSyncService_UnmanagesRemovedPackages(t *testing.T) {
+	ctx := context.Background()
+	puller := &mockGitPuller{head: syncNewCommit, changed: []string{"zsh/dot-zshrc"}}
+	client, fs := setupSyncClient(t, false, puller)
+
+	puller.pullFn = func(ctx context.Context, path string, opts adapters.PullOptions) error {
+		return fs.RemoveAll(ctx, "/packages/zsh")
+	}
+
+	result, err := client.Sync(ctx, SyncOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, result.Packages)
+	assert.Equal(t, []string{"zsh"}, result.Removed)
+	assert.False(t, fs.Exists(ctx, "/home/.zshrc"))
+}
+
+func TestSyncService_DiffFailureRemanagesAll(t *testing.T) {
+	ctx := context.Background()
+	puller := &mockGitPuller{head: syncNewCommit, changeErr: errors.New("object not found")}
+	client, _ := setupSyncClient(t, true, puller)
+
+	result, err := client.Sync(ctx, SyncOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"vim", "zsh"}, result.Packages)
+	assert.False(t, result.ShallowHistory)
+}
+
+func TestSyncService_DiffFailureInShallowClone(t *testing.T) {
+	ctx := context.Background()
+	puller := &mockGitPuller{head: syncNewCommit, changeErr: errors.New("object not found"), shallow: true}
+	client, _ := setupSyncClient(t, true, puller)
+
+	result, err := client.Sync(ctx, SyncOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"vim", "zsh"}, result.Packages)
+	assert.True(t, result.ShallowHistory)
+}
+
+func TestSyncService_ShallowCloneWithDiff(t *testing.T) {
+	ctx := context.Background()
+	puller := &mockGitPuller{head: syncNewCommit, changed: []string{"vim/dot-vimrc"}, shallow: true}
+	client, _ := setupSyncClient(t, true, puller)
+
+	result, err := client.Sync(ctx, SyncOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"vim"}, result.Packages)
+	assert.False(t, result.ShallowHistory, "history is only reported missing when the diff fails")
+}
+
+func TestSyncService_PullFailure(t *testing.T) {
+	ctx := context.Background()
+	puller := &mockGitPuller{
+		head: syncNewCommit,
+		pullFn: func(ctx context.Context, path string, opts adapters.PullOptions) error {
+			return errors.New("non-fast-forward update")
+		},
+	}
+	client, _ := setupSyncClient(t, false, puller)
+
+	_, err := client.Sync(ctx, SyncOptions{})
+	require.Error(t, err)
+	var pullErr ErrPullFailed
+	assert.ErrorAs(t, err, &pullErr)
+}
+
+// setMachineBranch records the synced repository as the per-machine branch
+// laptop based on main.
+func setMachineBranch(t *testing.T, client *Client) {
+	t.Helper()
+	ctx := context.Background()
+	targetPath := NewTargetPath("/home").Unwrap()
+	m := client.manageSvc.manifestSvc.Load(ctx, targetPath).Unwrap()
+	info, _ := m.GetRepository()
+	info.Branch = "laptop"
+	info.BaseBranch = "main"
+	m.SetRepository(info)
+	require.NoError(t, client.manageSvc.manifestSvc.Save(ctx, targetPath, m))
+}
+
+func TestSyncService_MachineBranchRebases(t *testing.T) {
+	ctx := context.Background()
+	puller := &mockGitPuller{head: syncNewCommit, changed: []string{"zsh/dot-zshrc"}, rebaseResult: adapters.RebaseResult{Replayed: 2}}
+	client, _ := setupSyncClient(t, false, puller)
+	setMachineBranch(t, client)
+
+	result, err := client.Sync(ctx, SyncOptions{})
+	require.NoError(t, err)
+
+	assert.False(t, puller.pulled)
+	assert.Equal(t, "main", puller.rebasedOnto)
+	assert.Equal(t, 2, result.Rebased)
+	assert.Equal(t, []string{"zsh"}, result.Packages)
+
+	m := client.manageSvc.manifestSvc.Load(ctx, NewTargetPath("/home").Unwrap()).Unwrap()
+	repo, ok := m.GetRepository()
+	require.True(t, ok)
+	assert.Equal(t, "laptop", repo.Branch)
+	assert.Equal(t, "main", repo.BaseBranch)
+	assert.Equal(t, syncNewCommit, repo.CommitSHA)
+}
+
+func TestSyncService_MachineBranchBranchOverride(t *testing.T) {
+	ctx := context.Background()
+	puller := &mockGitPuller{head: syncOldCommit}
+	client, _ := setupSyncClient(t, false, puller)
+	setMachineBranch(t, client)
+
+	_, err := client.Sync(ctx, SyncOptions{Branch: "develop"})
+	require.NoError(t, err)
+	assert.Equal(t, "develop", puller.rebasedOnto)
+
+	// The override is not recorded
+	m := client.manageSvc.manifestSvc.Load(ctx, NewTargetPath("/home").Unwrap()).Unwrap()
+	repo, _ := m.GetRepository()
+	assert.Equal(t, "main", repo.BaseBranch)
+}
+
+func TestSyncService_MachineBranchConflict(t *testing.T) {
+	ctx := context.Background()
+	puller := &mockGitPuller{
+		head:         syncOldCommit,
+		rebaseResult: adapters.RebaseResult{Conflicts: []string{".gitignore", "vim/dot-vimrc", "zsh/dot-zshrc", "zsh/dot-zprofile"}},
+	}
+	client, _ := setupSyncClient(t, false, puller)
+	setMachineBranch(t, client)
+
+	_, err := client.Sync(ctx, SyncOptions{})
+	var conflict ErrRebaseConflict
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, "laptop", conflict.Branch)
+	assert.Equal(t, "main", conflict.Onto)
+	assert.Equal(t, []string{"vim", "zsh"}, conflict.Packages)
+	assert.Len(t, conflict.Files, 4)
+	assert.Contains(t, err.Error(), "conflicting changes in package(s) vim, zsh")
+}
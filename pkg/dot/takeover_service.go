@@ -0,0 +1,156 @@
+package dot
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+)
+
+// TakeoverService reconstructs manifest entries from links already present in the target.
+type TakeoverService struct {
+	logger      Logger
+	manageSvc   *ManageService
+	manifestSvc *ManifestService
+	packageDir  string
+	targetDir   string
+	dryRun      bool
+}
+
+// newTakeoverService creates a new takeover service.
+func newTakeoverService(
+	logger Logger,
+	manageSvc *ManageService,
+	manifestSvc *ManifestService,
+	packageDir string,
+	targetDir string,
+	dryRun bool,
+) *TakeoverService {
+	return &TakeoverService{
+		logger:      logger,
+		manageSvc:   manageSvc,
+		manifestSvc: manifestSvc,
+		packageDir:  packageDir,
+		targetDir:   targetDir,
+		dryRun:      dryRun,
+	}
+}
+
+// TakeoverReport describes which links were taken over for each package.
+type TakeoverReport struct {
+	Packages []PackageTakeover `json:"packages"`
+}
+
+// PackageTakeover describes the takeover result for a single package.
+// All paths are relative to the target directory.
+type PackageTakeover struct {
+	// Name is the package name.
+	Name string `json:"name"`
+
+	// Linked lists existing links that resolve into the package.
+	Linked []string `json:"linked"`
+
+	// Missing lists links the package would create that do not exist yet.
+	Missing []string `json:"missing"`
+
+	// Conflicts lists paths occupied by files or links pointing elsewhere.
+	Conflicts []ConflictInfo `json:"conflicts"`
+
+	// Registered reports whether the package was written to the manifest.
+	Registered bool `json:"registered"`
+}
+
+// Complete reports whether every link the package needs already exists.
+func (p PackageTakeover) Complete() bool {
+	return len(p.Missing) == 0 && len(p.Conflicts) == 0
+}
+
+// Takeover registers existing links for the given packages in the manifest
+// without modifying the target directory. Packages with no existing links
+// are reported but not registered. In dry-run mode the manifest is not written.
+func (s *TakeoverService) Takeover(ctx context.Context, packages ...string) (TakeoverReport, error) {
+	s.logger.Info(ctx, "takeover_started", "packages", packages)
+
+	targetPathResult := NewTargetPath(s.targetDir)
+	if !targetPathResult.IsOk() {
+		return TakeoverReport{}, targetPathResult.UnwrapErr()
+	}
+	targetPath := targetPathResult.Unwrap()
+
+	report := TakeoverReport{Packages: make([]PackageTakeover, 0, len(packages))}
+	for _, pkg := range packages {
+		plan, err := s.manageSvc.PlanManage(ctx, pkg)
+		if err != nil {
+			return TakeoverReport{}, fmt.Errorf("plan package %s: %w", pkg, err)
+		}
+
+		result := s.inspectPlan(pkg, plan)
+		if len(result.Linked) > 0 && !s.dryRun {
+			if err := s.manifestSvc.Update(ctx, targetPath, s.packageDir, []string{pkg}, satisfiedPlan(pkg, plan)); err != nil {
+				return TakeoverReport{}, fmt.Errorf("update manifest for %s: %w", pkg, err)
+			}
+			result.Registered = true
+		}
+
+		s.logger.Info(ctx, "package_taken_over",
+			"package", pkg,
+			"linked", len(result.Linked),
+			"missing", len(result.Missing),
+			"conflicts", len(result.Conflicts),
+			"registered", result.Registered)
+		report.Packages = append(report.Packages, result)
+	}
+
+	return report, nil
+}
+
+// inspectPlan classifies the links of a manage plan for a single package.
+func (s *TakeoverService) inspectPlan(pkg string, plan Plan) PackageTakeover {
+	result := PackageTakeover{
+		Name:      pkg,
+		Linked:    []string{},
+		Missing:   []string{},
+		Conflicts: []ConflictInfo{},
+	}
+
+	for _, op := range plan.Satisfied {
+		if link, ok := op.(LinkCreate); ok {
+			result.Linked = append(result.Linked, s.relative(link.Target.String()))
+		}
+	}
+	for _, op := range plan.Operations {
+		if link, ok := op.(LinkCreate); ok {
+			result.Missing = append(result.Missing, s.relative(link.Target.String()))
+		}
+	}
+	for _, conflict := range plan.Metadata.Conflicts {
+		conflict.Path = s.relative(conflict.Path)
+		result.Conflicts = append(result.Conflicts, conflict)
+	}
+
+	sort.Strings(result.Linked)
+	sort.Strings(result.Missing)
+	return result
+}
+
+// relative converts an absolute target path to one relative to the target directory.
+func (s *TakeoverService) relative(path string) string {
+	rel, err := filepath.Rel(s.targetDir, path)
+	if err != nil {
+		return path
+	}
+	return rel
+}
+
+// satisfiedPlan builds a plan containing only the existing links of a package,
+// suitable for recording in the manifest.
+func satisfiedPlan(pkg string, plan Plan) Plan {
+	ids := make([]OperationID, 0, len(plan.Satisfied))
+	for _, op := range plan.Satisfied {
+		ids = append(ids, op.ID())
+	}
+	return Plan{
+		Satisfied:         plan.Satisfied,
+		PackageOperations: map[string][]OperationID{pkg: ids},
+	}
+}
@@ -0,0 +1,158 @@
+package dot
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/url"
+	"path/filepath"
+	"slices"
+)
+
+// newTargetClients creates a client for each directory of cfg.Targets
+// other than cfg.TargetDir, keyed by directory. The clients share cfg
+// apart from the target directory and its manifest location.
+func newTargetClients(cfg Config) (map[string]*Client, error) {
+	clients := make(map[string]*Client)
+	for _, dir := range cfg.Targets {
+		dir = filepath.Clean(dir)
+		if dir == filepath.Clean(cfg.TargetDir) || clients[dir] != nil {
+			continue
+		}
+
+		targetCfg := cfg
+		targetCfg.TargetDir = dir
+		targetCfg.Targets = nil
+		targetCfg.PackageNameMapping = false
+		if cfg.ManifestDir != "" {
+			targetCfg.ManifestDir = filepath.Join(cfg.ManifestDir, "targets", url.PathEscape(dir))
+		}
+
+		client, err := NewClient(targetCfg)
+		if err != nil {
+			return nil, fmt.Errorf("target %s: %w", dir, err)
+		}
+		clients[dir] = client
+	}
+	return clients, nil
+}
+
+// targetGroup holds packages linked into the same target directory.
+type targetGroup struct {
+	client   *Client
+	packages []string
+}
+
+// byTarget groups packages by the client of their target directory. The
+// default target comes first, followed by the others in directory order.
+// Without packages the default target alone is returned.
+func (c *Client) byTarget(packages []string) []targetGroup {
+	if len(c.targets) == 0 {
+		return []targetGroup{{client: c, packages: packages}}
+	}
+
+	grouped := make(map[string][]string)
+	var defaults []string
+	for _, pkg := range packages {
+		dir, ok := c.config.Targets[pkg]
+		if !ok || c.targets[filepath.Clean(dir)] == nil {
+			defaults = append(defaults, pkg)
+			continue
+		}
+		dir = filepath.Clean(dir)
+		grouped[dir] = append(grouped[dir], pkg)
+	}
+
+	var groups []targetGroup
+	if len(defaults) > 0 || len(grouped) == 0 {
+		groups = append(groups, targetGroup{client: c, packages: defaults})
+	}
+	for _, dir := range slices.Sorted(maps.Keys(grouped)) {
+		groups = append(groups, targetGroup{client: c.targets[dir], packages: grouped[dir]})
+	}
+	return groups
+}
+
+// eachTarget runs fn for every group of packages, stopping at the first
+// error.
+func (c *Client) eachTarget(packages []string, fn func(target *Client, packages []string) error) error {
+	for _, group := range c.byTarget(packages) {
+		if err := fn(group.client, group.packages); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// planTargets computes a plan for every group of packages with plan and
+// merges the results.
+func (c *Client) planTargets(packages []string, plan func(target *Client, packages []string) (Plan, error)) (Plan, error) {
+	groups := c.byTarget(packages)
+	if len(groups) == 1 {
+		return plan(groups[0].client, groups[0].packages)
+	}
+
+	var merged Plan
+	for _, group := range groups {
+		p, err := plan(group.client, group.packages)
+		if err != nil {
+			return Plan{}, err
+		}
+		merged = mergePlans(merged, p)
+	}
+	return merged, nil
+}
+
+// mergePlans combines the plans of two target directories. The plans do
+// not share paths, so their operations stay independent; parallel batches
+// are not carried over.
+func mergePlans(a, b Plan) Plan {
+	merged := Plan{
+		Operations: append(slices.Clone(a.Operations), b.Operations...),
+		Satisfied:  append(slices.Clone(a.Satisfied), b.Satisfied...),
+		Metadata: PlanMetadata{
+			PackageCount:   a.Metadata.PackageCount + b.Metadata.PackageCount,
+			OperationCount: a.Metadata.OperationCount + b.Metadata.OperationCount,
+			LinkCount:      a.Metadata.LinkCount + b.Metadata.LinkCount,
+			DirCount:       a.Metadata.DirCount + b.Metadata.DirCount,
+			Conflicts:      append(slices.Clone(a.Metadata.Conflicts), b.Metadata.Conflicts...),
+			Warnings:       append(slices.Clone(a.Metadata.Warnings), b.Metadata.Warnings...),
+			Replacements:   append(slices.Clone(a.Metadata.Replacements), b.Metadata.Replacements...),
+		},
+	}
+	if a.PackageOperations != nil || b.PackageOperations != nil {
+		merged.PackageOperations = make(map[string][]OperationID, len(a.PackageOperations)+len(b.PackageOperations))
+		maps.Copy(merged.PackageOperations, a.PackageOperations)
+		maps.Copy(merged.PackageOperations, b.PackageOperations)
+	}
+	if a.Dependencies != nil || b.Dependencies != nil {
+		merged.Dependencies = make(map[OperationID][]OperationID, len(a.Dependencies)+len(b.Dependencies))
+		maps.Copy(merged.Dependencies, a.Dependencies)
+		maps.Copy(merged.Dependencies, b.Dependencies)
+	}
+	return merged
+}
+
+// targetClients returns the client of the default target directory
+// followed by the clients of the other target directories.
+func (c *Client) targetClients() []*Client {
+	clients := []*Client{c}
+	for _, dir := range slices.Sorted(maps.Keys(c.targets)) {
+		clients = append(clients, c.targets[dir])
+	}
+	return clients
+}
+
+// unmanageAllTargets unmanages every installed package of each target
+// directory besides the default one and returns how many there were.
+func (c *Client) unmanageAllTargets(ctx context.Context, opts UnmanageOptions) (int, error) {
+	total := 0
+	for _, dir := range slices.Sorted(maps.Keys(c.targets)) {
+		count, err := c.targets[dir].unmanageSvc.UnmanageAll(ctx, opts)
+		if err != nil {
+			return total, err
+		}
+		total += count
+	}
+	return total, nil
+}
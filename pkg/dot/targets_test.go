@@ -0,0 +1,101 @@
+package dot_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/internal/manifest"
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// setupTargetsTest creates package dot-vim for the default target and
+// package dot-bin linked into /test/local/bin.
+func setupTargetsTest(t *testing.T) (*adapters.MemFS, *dot.Client) {
+	t.Helper()
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/dot-vim", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/dot-vim/vimrc", []byte("set nu"), 0644))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/dot-bin", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/dot-bin/tool", []byte("#!/bin/sh"), 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/local/bin", 0755))
+
+	client, err := dot.NewClient(dot.Config{
+		PackageDir:         "/test/packages",
+		TargetDir:          "/test/target",
+		Targets:            map[string]string{"dot-bin": "/test/local/bin"},
+		PackageNameMapping: true,
+		FS:                 fs,
+		Logger:             adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+	return fs, client
+}
+
+func TestClient_Manage_MultipleTargets(t *testing.T) {
+	ctx := context.Background()
+	fs, client := setupTargetsTest(t)
+
+	plan, err := client.PlanManage(ctx, "dot-vim", "dot-bin")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"dot-bin", "dot-vim"}, plan.PackageNames())
+
+	require.NoError(t, client.Manage(ctx, "dot-vim", "dot-bin"))
+
+	target, err := fs.ReadLink(ctx, "/test/target/.vim/vimrc")
+	require.NoError(t, err)
+	assert.Contains(t, target, "packages/dot-vim/vimrc")
+	target, err = fs.ReadLink(ctx, "/test/local/bin/tool")
+	require.NoError(t, err)
+	assert.Contains(t, target, "packages/dot-bin/tool")
+	assert.False(t, fs.Exists(ctx, "/test/target/.bin"), "package name mapping does not apply")
+
+	store := manifest.NewFSManifestStore(fs)
+	home := store.Load(ctx, dot.NewTargetPath("/test/target").Unwrap()).Unwrap()
+	assert.Contains(t, home.Packages, "dot-vim")
+	assert.NotContains(t, home.Packages, "dot-bin")
+	bin := store.Load(ctx, dot.NewTargetPath("/test/local/bin").Unwrap()).Unwrap()
+	assert.Equal(t, []string{"tool"}, bin.Packages["dot-bin"].Links)
+
+	status, err := client.Status(ctx)
+	require.NoError(t, err)
+	var names []string
+	for _, pkg := range status.Packages {
+		names = append(names, pkg.Name)
+	}
+	assert.ElementsMatch(t, []string{"dot-vim", "dot-bin"}, names)
+}
+
+func TestClient_Unmanage_MultipleTargets(t *testing.T) {
+	ctx := context.Background()
+	fs, client := setupTargetsTest(t)
+	require.NoError(t, client.Manage(ctx, "dot-vim", "dot-bin"))
+
+	require.NoError(t, client.Unmanage(ctx, "dot-bin"))
+	assert.False(t, fs.Exists(ctx, "/test/local/bin/tool"))
+	assert.True(t, fs.Exists(ctx, "/test/target/.vim/vimrc"))
+
+	count, err := client.UnmanageAll(ctx, dot.UnmanageOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.False(t, fs.Exists(ctx, "/test/target/.vim/vimrc"))
+}
+
+func TestConfig_Validate_RelativeTarget(t *testing.T) {
+	cfg := dot.Config{
+		PackageDir: "/test/packages",
+		TargetDir:  "/test/target",
+		Targets:    map[string]string{"bin": "local/bin"},
+		FS:         adapters.NewMemFS(),
+		Logger:     adapters.NewNoopLogger(),
+	}
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "target of package bin")
+}
@@ -0,0 +1,112 @@
+package dot
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/internal/domain"
+	"github.com/jamesainslie/dot/internal/executor"
+)
+
+// FSCall is a filesystem change an operation would make, as recorded by
+// TracePlan.
+type FSCall = adapters.FSCall
+
+// OperationTrace is the filesystem changes of one operation of a traced
+// plan.
+type OperationTrace struct {
+	ID          OperationID `json:"id" yaml:"id"`
+	Kind        string      `json:"kind" yaml:"kind"`
+	Description string      `json:"description" yaml:"description"`
+	Package     string      `json:"package,omitempty" yaml:"package,omitempty"`
+	Calls       []FSCall    `json:"calls" yaml:"calls"`
+}
+
+// PlanTrace is the operations of a plan in the order they were executed,
+// with the filesystem changes each would make.
+type PlanTrace struct {
+	Operations []OperationTrace `json:"operations" yaml:"operations"`
+}
+
+// Calls returns the filesystem changes of every operation, in order.
+func (t PlanTrace) Calls() []FSCall {
+	var calls []FSCall
+	for _, op := range t.Operations {
+		calls = append(calls, op.Calls...)
+	}
+	return calls
+}
+
+// TraceService runs plans through the executor against a recording
+// filesystem.
+type TraceService struct {
+	fs     FS
+	logger Logger
+	tracer Tracer
+}
+
+// newTraceService creates a new trace service reading from fs.
+func newTraceService(fs FS, logger Logger, tracer Tracer) *TraceService {
+	return &TraceService{fs: fs, logger: logger, tracer: tracer}
+}
+
+// TracePlan executes plan with the executor used by every command, on a
+// filesystem that records changes instead of making them, and returns the
+// changes of each operation. Operations run one at a time in plan order,
+// so the trace is the same on every run. Operations read the filesystem
+// as it is, without the changes recorded before them.
+//
+// Returns the trace so far together with the error when the plan is
+// invalid or an operation fails; changes recorded while rolling back are
+// not part of the trace.
+func (s *TraceService) TracePlan(ctx context.Context, plan Plan) (PlanTrace, error) {
+	recorder := adapters.NewRecordingFS(s.fs)
+
+	var (
+		mu    sync.Mutex
+		trace = PlanTrace{Operations: []OperationTrace{}}
+	)
+	sink := domain.EventSinkFunc(func(ctx context.Context, event domain.ExecutionEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch event.Kind {
+		case domain.EventStarted:
+			recorder.Reset()
+			trace.Operations = append(trace.Operations, OperationTrace{
+				ID:          event.Operation.ID(),
+				Kind:        event.Operation.Kind().String(),
+				Description: event.Operation.String(),
+				Package:     event.Package,
+			})
+		case domain.EventSucceeded, domain.EventFailed:
+			if n := len(trace.Operations); n > 0 {
+				trace.Operations[n-1].Calls = recorder.Calls()
+			}
+		}
+	})
+
+	exec := executor.New(executor.Opts{
+		FS:          recorder,
+		Logger:      s.logger,
+		Tracer:      s.tracer,
+		Events:      sink,
+		MaxParallel: 1,
+	})
+
+	// Without batches the executor runs operations one at a time in order
+	plan.Batches = nil
+	result := exec.Execute(ctx, plan)
+	mu.Lock()
+	defer mu.Unlock()
+	for i := range trace.Operations {
+		if trace.Operations[i].Calls == nil {
+			trace.Operations[i].Calls = []FSCall{}
+		}
+	}
+	if !result.IsOk() {
+		return trace, result.UnwrapErr()
+	}
+	s.logger.Debug(ctx, "plan_traced", "operations", len(trace.Operations), "calls", len(trace.Calls()))
+	return trace, nil
+}
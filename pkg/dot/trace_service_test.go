@@ -0,0 +1,55 @@
+package dot_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+func TestClient_TracePlan(t *testing.T) {
+	fs, client := setupStowedTree(t)
+	ctx := context.Background()
+
+	plan, err := client.PlanManage(ctx, "vim")
+	require.NoError(t, err)
+
+	trace, err := client.TracePlan(ctx, plan)
+	require.NoError(t, err)
+	require.Len(t, trace.Operations, len(plan.Operations))
+
+	var links []string
+	for _, call := range trace.Calls() {
+		if call.Method == "Symlink" {
+			links = append(links, call.Path)
+		}
+	}
+	assert.ElementsMatch(t, []string{"/test/target/.vimrc", "/test/target/.gvimrc"}, links)
+	for _, op := range trace.Operations {
+		assert.Equal(t, "vim", op.Package)
+		assert.NotEmpty(t, op.Calls, op.Description)
+	}
+
+	// Nothing was changed
+	assert.False(t, fs.Exists(ctx, "/test/target/.vimrc"))
+	status, err := client.Status(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, status.Packages)
+}
+
+func TestClient_TracePlan_Failure(t *testing.T) {
+	_, client := setupStowedTree(t)
+	ctx := context.Background()
+
+	// The executor rejects a link to a missing source before running anything
+	source := dot.NewFilePath("/test/packages/vim/missing").Unwrap()
+	target := dot.NewTargetPath("/test/target/.missing").Unwrap()
+	plan := dot.Plan{Operations: []dot.Operation{dot.NewLinkCreate("link", source, target)}}
+
+	trace, err := client.TracePlan(ctx, plan)
+	assert.Error(t, err)
+	assert.Empty(t, trace.Operations)
+}
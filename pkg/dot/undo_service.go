@@ -0,0 +1,408 @@
+package dot
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/jamesainslie/dot/internal/audit"
+	"github.com/jamesainslie/dot/internal/domain"
+	"github.com/jamesainslie/dot/internal/executor"
+)
+
+// UndoResult describes the outcome of undoing a plan.
+type UndoResult struct {
+	// PlanID is the ID of the plan that was undone.
+	PlanID string `json:"plan_id"`
+
+	// CheckpointID is the checkpoint the undone plan was executed under.
+	CheckpointID string `json:"checkpoint_id,omitempty"`
+
+	// Packages are the packages the undone plan changed.
+	Packages []string `json:"packages"`
+
+	// Operations describe the operations of the inverse plan, in order.
+	Operations []string `json:"operations"`
+
+	// Skipped describe operations of the undone plan that needed no
+	// inverse, such as links already removed or rendered templates.
+	Skipped []string `json:"skipped,omitempty"`
+
+	// DryRun reports that nothing was changed.
+	DryRun bool `json:"dry_run"`
+}
+
+// UndoService undoes executed plans using the operations recorded in the
+// audit log. Unlike a rollback, which replays a checkpoint, an undo
+// synthesizes an inverse plan and checks it against the current state of
+// the target directory, so it is refused when the paths it would touch
+// have changed since.
+type UndoService struct {
+	fs          FS
+	logger      Logger
+	executor    *executor.Executor
+	audit       *audit.Log
+	manifestSvc *ManifestService
+	rollbackSvc *RollbackService
+	packageDir  string
+	targetDir   string
+	dryRun      bool
+
+	// metrics records the standard metrics of the plans executed.
+	metrics *commandMetrics
+}
+
+// newUndoService creates a new undo service.
+// A nil audit log means no plan can be undone.
+func newUndoService(
+	fs FS,
+	logger Logger,
+	exec *executor.Executor,
+	auditLog *audit.Log,
+	manifestSvc *ManifestService,
+	rollbackSvc *RollbackService,
+	packageDir string,
+	targetDir string,
+	dryRun bool,
+) *UndoService {
+	return &UndoService{
+		fs:          fs,
+		logger:      logger,
+		executor:    exec,
+		audit:       auditLog,
+		manifestSvc: manifestSvc,
+		rollbackSvc: rollbackSvc,
+		packageDir:  packageDir,
+		targetDir:   targetDir,
+		dryRun:      dryRun,
+	}
+}
+
+// Undo reverts the plan with the given ID or unique ID prefix. An empty ID
+// selects the most recent plan that changed something and has not been
+// undone. Links the plan created are deleted, links it deleted are
+// recreated, files it adopted are moved back, and files it backed up are
+// restored from the backups tagged with its ID.
+//
+// Returns ErrAuditLogDisabled when there is no audit log, and an
+// ErrMultiple of ErrConflict when the current state no longer matches
+// what the plan left behind.
+func (s *UndoService) Undo(ctx context.Context, id string) (UndoResult, error) {
+	if s.audit == nil {
+		return UndoResult{}, ErrAuditLogDisabled{}
+	}
+
+	records, err := s.audit.Read(ctx, audit.Filter{})
+	if err != nil {
+		return UndoResult{}, err
+	}
+	plan, err := resolvePlan(audit.Plans(records), id)
+	if err != nil {
+		return UndoResult{}, err
+	}
+
+	inverse, skipped, err := s.invert(ctx, plan)
+	if err != nil {
+		return UndoResult{}, err
+	}
+
+	result := UndoResult{
+		PlanID:       plan.ID,
+		CheckpointID: plan.CheckpointID,
+		Packages:     plan.Packages(),
+		Operations:   operationDescriptions(inverse.Operations),
+		Skipped:      skipped,
+		DryRun:       s.dryRun,
+	}
+	if result.Packages == nil {
+		result.Packages = []string{}
+	}
+	s.metrics.recordPlan("undo", inverse)
+	if len(inverse.Operations) == 0 {
+		return result, nil
+	}
+	if s.dryRun {
+		s.logger.Info(ctx, "dry_run_undo", "plan_id", plan.ID, "operations", len(inverse.Operations))
+		return result, nil
+	}
+
+	s.logger.Info(ctx, "undoing_plan", "plan_id", plan.ID, "operations", len(inverse.Operations))
+	execResult := s.metrics.execute(ctx, s.executor, "undo", inverse)
+	if !execResult.IsOk() {
+		return result, execResult.UnwrapErr()
+	}
+
+	if err := s.updateManifest(ctx, inverse); err != nil {
+		s.logger.Warn(ctx, "manifest_update_failed", "plan_id", plan.ID, "error", err)
+	}
+	return result, nil
+}
+
+// resolvePlan finds a plan by ID or unique ID prefix. An empty ID selects
+// the newest plan that can be undone.
+func resolvePlan(plans []audit.Plan, id string) (audit.Plan, error) {
+	if id == "" {
+		for i := len(plans) - 1; i >= 0; i-- {
+			plan := plans[i]
+			if plan.Undoes == "" && plan.UndoneBy == "" && len(plan.Applied) > 0 {
+				return plan, nil
+			}
+		}
+		return audit.Plan{}, ErrPlanNotFound{}
+	}
+
+	var matches []audit.Plan
+	for _, plan := range plans {
+		if plan.ID == id {
+			matches = []audit.Plan{plan}
+			break
+		}
+		if strings.HasPrefix(plan.ID, id) {
+			matches = append(matches, plan)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return audit.Plan{}, ErrPlanNotFound{ID: id}
+	case 1:
+	default:
+		return audit.Plan{}, fmt.Errorf("plan prefix %q is ambiguous: matches %d plans", id, len(matches))
+	}
+
+	plan := matches[0]
+	switch {
+	case plan.UndoneBy != "":
+		return audit.Plan{}, fmt.Errorf("plan %s was already undone by plan %s", plan.ID, plan.UndoneBy)
+	case len(plan.Applied) == 0:
+		return audit.Plan{}, fmt.Errorf("plan %s has no applied operations to undo", plan.ID)
+	}
+	return plan, nil
+}
+
+// invert builds the plan undoing the applied operations of plan, last
+// first. Operations whose effect is already gone are skipped; operations
+// that conflict with the current state are reported together.
+func (s *UndoService) invert(ctx context.Context, plan audit.Plan) (Plan, []string, error) {
+	inverse := Plan{
+		ID:                audit.UndoPlanID(plan.ID),
+		PackageOperations: make(map[string][]OperationID),
+	}
+	state := undoState{fs: s.fs, paths: make(map[string]bool)}
+	var skipped []string
+	var conflicts []error
+
+	for i := len(plan.Applied) - 1; i >= 0; i-- {
+		r := plan.Applied[i]
+		op, skip, err := s.inverseOf(ctx, &state, r)
+		switch {
+		case err != nil:
+			conflicts = append(conflicts, err)
+		case skip != "":
+			skipped = append(skipped, skip)
+		default:
+			inverse.Operations = append(inverse.Operations, op)
+			if pkg := s.packageOf(r); pkg != "" {
+				inverse.PackageOperations[pkg] = append(inverse.PackageOperations[pkg], op.ID())
+			}
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return Plan{}, nil, ErrMultiple{Errors: conflicts}
+	}
+	return inverse, skipped, nil
+}
+
+// inverseOf returns the operation undoing r, or why none is needed.
+func (s *UndoService) inverseOf(ctx context.Context, state *undoState, r audit.Record) (Operation, string, error) {
+	id := "undo-" + r.OperationID
+	switch r.Kind {
+	case OpKindLinkCreate.String():
+		dest, isLink := state.link(ctx, r.Target)
+		switch {
+		case !state.occupied(ctx, r.Target):
+			return nil, "link already removed: " + r.Target, nil
+		case !isLink || absLinkTarget(r.Target, dest) != r.Source:
+			return nil, "", ErrConflict{Path: r.Target, Reason: "no longer a link to " + r.Source}
+		}
+		state.free(r.Target)
+		return inverseRecord(id, OpKindLinkDelete, "", "", r.Target)
+
+	case OpKindLinkDelete.String():
+		if r.Source == "" {
+			return nil, "", ErrConflict{Path: r.Path, Reason: "previous link target was not recorded"}
+		}
+		source := absLinkTarget(r.Path, r.Source)
+		if state.occupied(ctx, r.Path) {
+			if dest, isLink := state.link(ctx, r.Path); isLink && absLinkTarget(r.Path, dest) == source {
+				return nil, "link already restored: " + r.Path, nil
+			}
+			return nil, "", ErrConflict{Path: r.Path, Reason: "path is occupied"}
+		}
+		if !state.occupied(ctx, source) {
+			return nil, "", ErrConflict{Path: r.Path, Reason: "link source no longer exists: " + source}
+		}
+		state.fill(r.Path)
+		return inverseRecord(id, OpKindLinkCreate, source, r.Path, "")
+
+	case OpKindFileMove.String():
+		if _, isLink := state.link(ctx, r.Target); isLink || !state.occupied(ctx, r.Target) {
+			return nil, "", ErrConflict{Path: r.Target, Reason: "moved file no longer exists"}
+		}
+		if state.occupied(ctx, r.Source) {
+			return nil, "", ErrConflict{Path: r.Source, Reason: "path is occupied"}
+		}
+		state.free(r.Target)
+		state.fill(r.Source)
+		return inverseRecord(id, OpKindFileMove, r.Target, r.Source, "")
+
+	case OpKindDirCreate.String():
+		if !state.occupied(ctx, r.Path) {
+			return nil, "directory already removed: " + r.Path, nil
+		}
+		if !state.emptyDir(ctx, r.Path) {
+			return nil, "", ErrConflict{Path: r.Path, Reason: "directory is not empty"}
+		}
+		state.free(r.Path)
+		return inverseRecord(id, OpKindDirDelete, "", "", r.Path)
+
+	case OpKindDirDelete.String():
+		if state.occupied(ctx, r.Path) {
+			if isDir, err := s.fs.IsDir(ctx, r.Path); err == nil && isDir {
+				return nil, "directory already exists: " + r.Path, nil
+			}
+			return nil, "", ErrConflict{Path: r.Path, Reason: "path is occupied"}
+		}
+		state.fill(r.Path)
+		return inverseRecord(id, OpKindDirCreate, "", "", r.Path)
+
+	case OpKindFileStash.String():
+		// The backup index tells which backup the plan took of the file
+		index, err := domain.LoadBackupIndex(ctx, s.fs, r.Path)
+		if err != nil {
+			return nil, "", err
+		}
+		entry, ok := index.Entry(r.PlanID, r.Source)
+		if !ok {
+			return nil, "", ErrConflict{Path: r.Source, Reason: "backup taken by plan " + r.PlanID + " no longer exists"}
+		}
+		if state.occupied(ctx, r.Source) {
+			return nil, "", ErrConflict{Path: r.Source, Reason: "path is occupied"}
+		}
+		state.fill(r.Source)
+		op, err := domain.OperationRecord{
+			ID:     OperationID(id),
+			Kind:   OpKindFileRestore.String(),
+			Target: r.Source,
+			Path:   r.Path,
+			Hash:   entry.Hash,
+			PlanID: r.PlanID,
+		}.Operation()
+		return op, "", err
+
+	case OpKindFileRender.String():
+		return nil, "rendered template left in place: " + r.Target, nil
+
+	default:
+		return nil, "", fmt.Errorf("cannot undo %s operation %s; roll back checkpoint %s instead", r.Kind, r.OperationID, r.CheckpointID)
+	}
+}
+
+// inverseRecord builds an inverse operation from its paths.
+func inverseRecord(id string, kind OperationKind, source, target, path string) (Operation, string, error) {
+	op, err := domain.OperationRecord{
+		ID:     OperationID(id),
+		Kind:   kind.String(),
+		Source: source,
+		Target: target,
+		Path:   path,
+	}.Operation()
+	return op, "", err
+}
+
+// packageOf returns the package of the operation of r, falling back to
+// the package its link source lives in when the record names none.
+func (s *UndoService) packageOf(r audit.Record) string {
+	if r.Package != "" {
+		return r.Package
+	}
+	switch r.Kind {
+	case OpKindLinkCreate.String():
+		return packageOfPath(s.packageDir, r.Source)
+	case OpKindLinkDelete.String():
+		return packageOfPath(s.packageDir, absLinkTarget(r.Path, r.Source))
+	case OpKindFileMove.String():
+		return packageOfPath(s.packageDir, r.Target)
+	default:
+		return ""
+	}
+}
+
+// updateManifest records the effect of the executed inverse plan: links
+// it deleted are pruned and links it recreated are added back to their
+// packages.
+func (s *UndoService) updateManifest(ctx context.Context, inverse Plan) error {
+	if err := s.rollbackSvc.pruneManifest(ctx, linkTargets(inverse.Operations, OpKindLinkDelete)); err != nil {
+		return err
+	}
+
+	return s.rollbackSvc.restoreManifest(ctx, inverse)
+}
+
+// undoState is the state of the filesystem as the inverse plan leaves it
+// so far, so that each inverse operation is checked against the effects
+// of those before it.
+type undoState struct {
+	fs FS
+
+	// paths maps paths the inverse plan empties to false and paths it
+	// fills to true.
+	paths map[string]bool
+}
+
+// occupied reports whether anything, including a broken link, is at path.
+func (st *undoState) occupied(ctx context.Context, path string) bool {
+	if present, ok := st.paths[path]; ok {
+		return present
+	}
+	if st.fs.Exists(ctx, path) {
+		return true
+	}
+	isLink, err := st.fs.IsSymlink(ctx, path)
+	return err == nil && isLink
+}
+
+// link returns the target of the link at path, if path is a link that the
+// inverse plan has not touched.
+func (st *undoState) link(ctx context.Context, path string) (string, bool) {
+	if _, ok := st.paths[path]; ok {
+		return "", false
+	}
+	dest, err := st.fs.ReadLink(ctx, path)
+	return dest, err == nil
+}
+
+// emptyDir reports whether the directory at path holds nothing once the
+// inverse plan so far has run.
+func (st *undoState) emptyDir(ctx context.Context, path string) bool {
+	entries, err := st.fs.ReadDir(ctx, path)
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		if st.occupied(ctx, filepath.Join(path, entry.Name())) {
+			return false
+		}
+	}
+	for p, present := range st.paths {
+		if present && filepath.Dir(p) == path {
+			return false
+		}
+	}
+	return true
+}
+
+func (st *undoState) free(path string) { st.paths[path] = false }
+func (st *undoState) fill(path string) { st.paths[path] = true }
@@ -0,0 +1,213 @@
+package dot_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/internal/domain"
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+func setupUndoClient(t *testing.T, fs *adapters.MemFS, dryRun bool) *dot.Client {
+	t.Helper()
+	client, err := dot.NewClient(dot.Config{
+		PackageDir: "/test/packages",
+		TargetDir:  "/test/target",
+		AuditLog:   "/test/state/audit.jsonl",
+		DryRun:     dryRun,
+		FS:         fs,
+		Logger:     adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+	return client
+}
+
+func installedPackages(t *testing.T, client *dot.Client) []string {
+	t.Helper()
+	packages, err := client.List(context.Background())
+	require.NoError(t, err)
+	names := []string{}
+	for _, pkg := range packages {
+		names = append(names, pkg.Name)
+	}
+	return names
+}
+
+func TestClient_Undo_Manage(t *testing.T) {
+	ctx := context.Background()
+	fs := setupRollbackFS(t)
+	client := setupUndoClient(t, fs, false)
+
+	require.NoError(t, client.Manage(ctx, "vim"))
+	require.NoError(t, client.Manage(ctx, "zsh"))
+
+	result, err := client.Undo(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"zsh"}, result.Packages)
+	assert.Len(t, result.Operations, 1)
+	assert.False(t, fs.Exists(ctx, "/test/target/.zshrc"))
+	assert.True(t, fs.Exists(ctx, "/test/target/.vimrc"))
+	assert.Equal(t, []string{"vim"}, installedPackages(t, client))
+
+	// The next undo skips the undone plan
+	result, err = client.Undo(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"vim"}, result.Packages)
+	assert.False(t, fs.Exists(ctx, "/test/target/.vimrc"))
+	assert.Empty(t, installedPackages(t, client))
+
+	_, err = client.Undo(ctx, "")
+	assert.True(t, errors.As(err, &dot.ErrPlanNotFound{}))
+}
+
+func TestClient_Undo_Unmanage(t *testing.T) {
+	ctx := context.Background()
+	fs := setupRollbackFS(t)
+	client := setupUndoClient(t, fs, false)
+
+	require.NoError(t, client.Manage(ctx, "vim"))
+	require.NoError(t, client.Unmanage(ctx, "vim"))
+	require.False(t, fs.Exists(ctx, "/test/target/.vimrc"))
+
+	_, err := client.Undo(ctx, "")
+	require.NoError(t, err)
+
+	link, err := fs.ReadLink(ctx, "/test/target/.vimrc")
+	require.NoError(t, err)
+	assert.Contains(t, link, "packages/vim/dot-vimrc")
+	assert.Equal(t, []string{"vim"}, installedPackages(t, client))
+}
+
+func TestClient_Undo_Adopt(t *testing.T) {
+	ctx := context.Background()
+	fs := setupRollbackFS(t)
+	require.NoError(t, fs.WriteFile(ctx, "/test/target/.gitconfig", []byte("[user]"), 0644))
+	client := setupUndoClient(t, fs, false)
+
+	require.NoError(t, client.Adopt(ctx, []string{".gitconfig"}, "git"))
+	isLink, err := fs.IsSymlink(ctx, "/test/target/.gitconfig")
+	require.NoError(t, err)
+	require.True(t, isLink)
+
+	_, err = client.Undo(ctx, "")
+	require.NoError(t, err)
+
+	isLink, err = fs.IsSymlink(ctx, "/test/target/.gitconfig")
+	require.NoError(t, err)
+	assert.False(t, isLink)
+	data, err := fs.ReadFile(ctx, "/test/target/.gitconfig")
+	require.NoError(t, err)
+	assert.Equal(t, "[user]", string(data))
+	assert.False(t, fs.Exists(ctx, "/test/packages/git/dot-gitconfig"))
+	assert.Empty(t, installedPackages(t, client))
+}
+
+func TestClient_Undo_RestoresBackups(t *testing.T) {
+	ctx := context.Background()
+	fs := setupRollbackFS(t)
+	require.NoError(t, fs.WriteFile(ctx, "/test/target/.vimrc", []byte("my local vimrc"), 0644))
+	client, err := dot.NewClient(dot.Config{
+		PackageDir: "/test/packages",
+		TargetDir:  "/test/target",
+		AuditLog:   "/test/state/audit.jsonl",
+		OnConflict: "backup",
+		Backup:     true,
+		FS:         fs,
+		Logger:     adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, client.Manage(ctx, "vim"))
+	isLink, err := fs.IsSymlink(ctx, "/test/target/.vimrc")
+	require.NoError(t, err)
+	require.True(t, isLink)
+
+	index, err := domain.LoadBackupIndex(ctx, fs, "/test/target/.dot-backup")
+	require.NoError(t, err)
+	require.Len(t, index.Entries, 1)
+	assert.NotEmpty(t, index.Entries[0].PlanID, "backups are tagged with the plan that took them")
+	assert.Equal(t, "/test/target/.vimrc", index.Entries[0].Path)
+
+	result, err := client.Undo(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, index.Entries[0].PlanID, result.PlanID)
+
+	isLink, err = fs.IsSymlink(ctx, "/test/target/.vimrc")
+	require.NoError(t, err)
+	assert.False(t, isLink)
+	data, err := fs.ReadFile(ctx, "/test/target/.vimrc")
+	require.NoError(t, err)
+	assert.Equal(t, "my local vimrc", string(data))
+
+	index, err = domain.LoadBackupIndex(ctx, fs, "/test/target/.dot-backup")
+	require.NoError(t, err)
+	assert.Empty(t, index.Entries, "the restored backup is released")
+}
+
+func TestClient_Undo_ConflictWithCurrentState(t *testing.T) {
+	ctx := context.Background()
+	fs := setupRollbackFS(t)
+	client := setupUndoClient(t, fs, false)
+
+	require.NoError(t, client.Manage(ctx, "vim"))
+	require.NoError(t, fs.Remove(ctx, "/test/target/.vimrc"))
+	require.NoError(t, fs.WriteFile(ctx, "/test/target/.vimrc", []byte("edited"), 0644))
+
+	_, err := client.Undo(ctx, "")
+	var conflict dot.ErrConflict
+	require.True(t, errors.As(err, &conflict))
+	assert.Equal(t, "/test/target/.vimrc", conflict.Path)
+
+	data, err := fs.ReadFile(ctx, "/test/target/.vimrc")
+	require.NoError(t, err)
+	assert.Equal(t, "edited", string(data), "nothing is changed on conflict")
+}
+
+func TestClient_Undo_ByIDAndDryRun(t *testing.T) {
+	ctx := context.Background()
+	fs := setupRollbackFS(t)
+	client := setupUndoClient(t, fs, false)
+
+	require.NoError(t, client.Manage(ctx, "vim"))
+	require.NoError(t, client.Manage(ctx, "zsh"))
+	records, err := client.History(ctx, dot.HistoryFilter{Package: "vim"})
+	require.NoError(t, err)
+	require.NotEmpty(t, records)
+	vimPlan := records[0].PlanID
+
+	result, err := setupUndoClient(t, fs, true).Undo(ctx, vimPlan[:8])
+	require.NoError(t, err)
+	assert.True(t, result.DryRun)
+	assert.Equal(t, vimPlan, result.PlanID)
+	assert.NotEmpty(t, result.Operations)
+	assert.True(t, fs.Exists(ctx, "/test/target/.vimrc"), "dry run changes nothing")
+
+	_, err = client.Undo(ctx, vimPlan)
+	require.NoError(t, err)
+	assert.False(t, fs.Exists(ctx, "/test/target/.vimrc"))
+	assert.True(t, fs.Exists(ctx, "/test/target/.zshrc"))
+
+	_, err = client.Undo(ctx, vimPlan)
+	assert.ErrorContains(t, err, "already undone")
+
+	_, err = client.Undo(ctx, "nonexistent")
+	assert.True(t, errors.As(err, &dot.ErrPlanNotFound{}))
+}
+
+func TestClient_Undo_Disabled(t *testing.T) {
+	client, err := dot.NewClient(dot.Config{
+		PackageDir: "/test/packages",
+		TargetDir:  "/test/target",
+		FS:         adapters.NewMemFS(),
+		Logger:     adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+
+	_, err = client.Undo(context.Background(), "")
+	assert.True(t, errors.As(err, &dot.ErrAuditLogDisabled{}))
+}
@@ -0,0 +1,86 @@
+package dot_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/internal/manifest"
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+// setupFoldedTest links /test/target/.config to the .config directory of
+// package a, as adopting the directory does, and returns a client for
+// packages a and b that both provide files below .config.
+func setupFoldedTest(t *testing.T) (*adapters.MemFS, *dot.Client) {
+	t.Helper()
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/a/.config/git", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/a/.config/git/config", []byte("git"), 0644))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/b/.config/nvim", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/b/.config/nvim/init.lua", []byte("nvim"), 0644))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target", 0755))
+	require.NoError(t, fs.Symlink(ctx, "/test/packages/a/.config", "/test/target/.config"))
+
+	m := manifest.New()
+	m.AddPackage(manifest.PackageInfo{Name: "a", Links: []string{".config"}, LinkCount: 1, Source: manifest.SourceAdopted})
+	require.NoError(t, manifest.NewFSManifestStore(fs).Save(ctx, dot.NewTargetPath("/test/target").Unwrap(), m))
+
+	client, err := dot.NewClient(dot.Config{
+		PackageDir: "/test/packages",
+		TargetDir:  "/test/target",
+		FS:         fs,
+		Logger:     adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+	return fs, client
+}
+
+func TestClient_Manage_FoldedDirectorySatisfiesOwnPackage(t *testing.T) {
+	ctx := context.Background()
+	_, client := setupFoldedTest(t)
+
+	plan, err := client.PlanManage(ctx, "a")
+	require.NoError(t, err)
+	assert.Empty(t, plan.Operations)
+	assert.NotEmpty(t, plan.Satisfied)
+}
+
+func TestClient_Manage_UnfoldsFoldedDirectory(t *testing.T) {
+	ctx := context.Background()
+	fs, client := setupFoldedTest(t)
+
+	plan, err := client.PlanManage(ctx, "b")
+	require.NoError(t, err)
+	assert.Empty(t, plan.Metadata.Conflicts)
+	require.Len(t, plan.Metadata.Warnings, 1)
+	assert.Contains(t, plan.Metadata.Warnings[0].Message, "Unfolding directory link")
+
+	require.NoError(t, client.Manage(ctx, "b"))
+
+	isLink, err := fs.IsSymlink(ctx, "/test/target/.config")
+	require.NoError(t, err)
+	assert.False(t, isLink, ".config is a directory once unfolded")
+	target, err := fs.ReadLink(ctx, "/test/target/.config/git/config")
+	require.NoError(t, err)
+	assert.Equal(t, "/test/packages/a/.config/git/config", target)
+	target, err = fs.ReadLink(ctx, "/test/target/.config/nvim/init.lua")
+	require.NoError(t, err)
+	assert.Equal(t, "/test/packages/b/.config/nvim/init.lua", target)
+
+	result := manifest.NewFSManifestStore(fs).Load(ctx, dot.NewTargetPath("/test/target").Unwrap())
+	require.True(t, result.IsOk())
+	m := result.Unwrap()
+	assert.Equal(t, []string{".config/git/config"}, m.Packages["a"].Links)
+	assert.Equal(t, 1, m.Packages["a"].LinkCount)
+	assert.Equal(t, []string{".config/nvim/init.lua"}, m.Packages["b"].Links)
+
+	report, err := client.DoctorWithScan(ctx, dot.ScanConfig{Mode: dot.ScanOff})
+	require.NoError(t, err)
+	assert.Equal(t, dot.HealthOK, report.OverallHealth)
+}
@@ -0,0 +1,79 @@
+package dot_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jamesainslie/dot/internal/adapters"
+	"github.com/jamesainslie/dot/internal/manifest"
+	"github.com/jamesainslie/dot/pkg/dot"
+)
+
+func TestUnmanage_PruneDirs(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/app/config/app", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target/config", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/app/config/app/rc", []byte("rc"), 0644))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/app/local/share/app", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/app/local/share/app/data", []byte("data"), 0644))
+
+	client, err := dot.NewClient(dot.Config{
+		PackageDir: "/test/packages",
+		TargetDir:  "/test/target",
+		FS:         fs,
+		Logger:     adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+	require.NoError(t, client.Manage(ctx, "app"))
+
+	result := manifest.NewFSManifestStore(fs).Load(ctx, dot.NewTargetPath("/test/target").Unwrap())
+	require.True(t, result.IsOk())
+	assert.Equal(t, []string{"config/app", "local", "local/share", "local/share/app"}, result.Unwrap().Packages["app"].Dirs,
+		"pre-existing config is not owned")
+
+	// A file of the user's keeps local/share alive
+	require.NoError(t, fs.WriteFile(ctx, "/test/target/local/share/notes", []byte("mine"), 0644))
+
+	plan, err := client.PlanUnmanageWithOptions(ctx, dot.UnmanageOptions{PruneDirs: true}, "app")
+	require.NoError(t, err)
+	var pruned []string
+	for _, op := range plan.Operations {
+		if op, ok := op.(dot.DirDelete); ok {
+			pruned = append(pruned, op.Path.String())
+		}
+	}
+	assert.Equal(t, []string{"/test/target/local/share/app", "/test/target/config/app"}, pruned)
+
+	require.NoError(t, client.UnmanageWithOptions(ctx, dot.UnmanageOptions{PruneDirs: true}, "app"))
+	assert.False(t, fs.Exists(ctx, "/test/target/config/app"))
+	assert.True(t, fs.Exists(ctx, "/test/target/config"))
+	assert.False(t, fs.Exists(ctx, "/test/target/local/share/app"))
+	assert.True(t, fs.Exists(ctx, "/test/target/local/share/notes"))
+}
+
+func TestUnmanage_WithoutPruneDirsKeepsDirectories(t *testing.T) {
+	fs := adapters.NewMemFS()
+	ctx := context.Background()
+
+	require.NoError(t, fs.MkdirAll(ctx, "/test/packages/app/config/app", 0755))
+	require.NoError(t, fs.MkdirAll(ctx, "/test/target", 0755))
+	require.NoError(t, fs.WriteFile(ctx, "/test/packages/app/config/app/rc", []byte("rc"), 0644))
+
+	client, err := dot.NewClient(dot.Config{
+		PackageDir: "/test/packages",
+		TargetDir:  "/test/target",
+		FS:         fs,
+		Logger:     adapters.NewNoopLogger(),
+	})
+	require.NoError(t, err)
+	require.NoError(t, client.Manage(ctx, "app"))
+	require.NoError(t, client.Unmanage(ctx, "app"))
+
+	assert.False(t, fs.Exists(ctx, "/test/target/config/app/rc"))
+	assert.True(t, fs.Exists(ctx, "/test/target/config/app"))
+}
@@ -1,9 +1,12 @@
 package dot
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/jamesainslie/dot/internal/executor"
 	"github.com/jamesainslie/dot/internal/manifest"
@@ -18,6 +21,9 @@ type UnmanageOptions struct {
 	Restore bool
 	// Cleanup removes orphaned manifest entries (packages with no links or missing directories)
 	Cleanup bool
+	// PruneDirs deletes directories dot created for the packages' links
+	// that are left empty once the links are removed
+	PruneDirs bool
 }
 
 // DefaultUnmanageOptions returns default unmanage options.
@@ -38,6 +44,9 @@ type UnmanageService struct {
 	packageDir  string
 	targetDir   string
 	dryRun      bool
+
+	// metrics records the standard metrics of the plans executed.
+	metrics *commandMetrics
 }
 
 // newUnmanageService creates a new UnmanageService instance.
@@ -96,6 +105,7 @@ func (s *UnmanageService) UnmanageWithOptions(ctx context.Context, opts Unmanage
 		s.logger.Error(ctx, "plan_failed", "error", err)
 		return err
 	}
+	s.metrics.recordPlan("unmanage", plan)
 
 	// In cleanup mode, empty operations are expected for orphaned packages
 	// Skip early return to allow manifest cleanup
@@ -114,7 +124,7 @@ func (s *UnmanageService) UnmanageWithOptions(ctx context.Context, opts Unmanage
 		}
 
 		s.logger.Debug(ctx, "executing_plan", "operation_count", len(plan.Operations))
-		result := s.executor.Execute(ctx, plan)
+		result := s.metrics.execute(ctx, s.executor, "unmanage", plan)
 		if !result.IsOk() {
 			s.logger.Error(ctx, "execution_error", "error", result.UnwrapErr())
 			return result.UnwrapErr()
@@ -210,6 +220,12 @@ func (s *UnmanageService) filterOrphanedPackages(ctx context.Context, m manifest
 
 // PlanUnmanage computes the execution plan for unmanaging packages.
 func (s *UnmanageService) PlanUnmanage(ctx context.Context, packages ...string) (Plan, error) {
+	return s.PlanUnmanageWithOptions(ctx, DefaultUnmanageOptions(), packages...)
+}
+
+// PlanUnmanageWithOptions computes the execution plan for unmanaging packages
+// with the given restore, purge, and cleanup options.
+func (s *UnmanageService) PlanUnmanageWithOptions(ctx context.Context, opts UnmanageOptions, packages ...string) (Plan, error) {
 	s.logger.Debug(ctx, "plan_unmanage_started", "packages", packages)
 
 	targetPathResult := NewTargetPath(s.targetDir)
@@ -235,7 +251,7 @@ func (s *UnmanageService) PlanUnmanage(ctx context.Context, packages ...string)
 	}
 
 	m := manifestResult.Unwrap()
-	return s.planUnmanageWithOptions(ctx, m, packages, DefaultUnmanageOptions())
+	return s.planUnmanageWithOptions(ctx, m, packages, opts)
 }
 
 // planUnmanageWithOptions creates an unmanage plan with restoration/purge/cleanup logic.
@@ -244,6 +260,9 @@ func (s *UnmanageService) planUnmanageWithOptions(ctx context.Context, m manifes
 
 	// Build operations for each package
 	var operations []Operation
+	packageOps := make(map[string][]OperationID)
+	removed := make(map[string]bool)
+	var ownedDirs []string
 	for _, pkg := range packages {
 		pkgInfo, exists := m.GetPackage(pkg)
 		if !exists {
@@ -268,8 +287,17 @@ func (s *UnmanageService) planUnmanageWithOptions(ctx context.Context, m manifes
 			}
 		}
 
+		first := len(operations)
+
+		// Links of restored packages are replaced by the restored files
+		restores := pkgInfo.Source == manifest.SourceAdopted && opts.Restore && !opts.Purge
+		ownedDirs = append(ownedDirs, pkgInfo.Dirs...)
+
 		// Delete symlinks
 		for _, link := range pkgInfo.Links {
+			if !restores {
+				removed[filepath.Join(s.targetDir, link)] = true
+			}
 			targetFilePath := s.targetDir + "/" + link
 			targetPathResult := NewTargetPath(targetFilePath)
 			if !targetPathResult.IsOk() {
@@ -280,7 +308,7 @@ func (s *UnmanageService) planUnmanageWithOptions(ctx context.Context, m manifes
 		}
 
 		// Handle adopted packages
-		if pkgInfo.Source == manifest.SourceAdopted && opts.Restore && !opts.Purge {
+		if restores {
 			// Restore files from package back to target
 			s.logger.Debug(ctx, "adding_restore_operations", "package", pkg)
 			restoreOps, err := s.createRestoreOperations(ctx, pkg, pkgInfo.Links)
@@ -300,6 +328,14 @@ func (s *UnmanageService) planUnmanageWithOptions(ctx context.Context, m manifes
 			id := OperationID(fmt.Sprintf("unmanage-purge-%s", pkg))
 			operations = append(operations, NewDirRemoveAll(id, pkgPathResult.Unwrap()))
 		}
+
+		for _, op := range operations[first:] {
+			packageOps[pkg] = append(packageOps[pkg], op.ID())
+		}
+	}
+
+	if opts.PruneDirs {
+		operations = append(operations, s.planPruneDirs(ctx, ownedDirs, removed)...)
 	}
 
 	s.logger.Debug(ctx, "plan_unmanage_completed", "operations", len(operations))
@@ -310,9 +346,56 @@ func (s *UnmanageService) planUnmanageWithOptions(ctx context.Context, m manifes
 			PackageCount:   len(packages),
 			OperationCount: len(operations),
 		},
+		PackageOperations: packageOps,
 	}, nil
 }
 
+// planPruneDirs returns DirDelete operations for the owned directories,
+// relative to the target directory, that hold nothing but paths in removed
+// once the plan has run. Directories are deleted deepest first, so that a
+// parent emptied by pruning its children is pruned too.
+func (s *UnmanageService) planPruneDirs(ctx context.Context, owned []string, removed map[string]bool) []Operation {
+	dirs := make([]string, 0, len(owned))
+	for _, dir := range owned {
+		dirs = append(dirs, filepath.Join(s.targetDir, dir))
+	}
+	slices.Sort(dirs)
+	dirs = slices.Compact(dirs)
+	slices.SortFunc(dirs, func(a, b string) int {
+		return cmp.Compare(strings.Count(b, string(filepath.Separator)), strings.Count(a, string(filepath.Separator)))
+	})
+
+	var operations []Operation
+	for _, dir := range dirs {
+		if isDir, err := s.fs.IsDir(ctx, dir); err != nil || !isDir {
+			continue
+		}
+		if isLink, _ := s.fs.IsSymlink(ctx, dir); isLink {
+			continue
+		}
+		entries, err := s.fs.ReadDir(ctx, dir)
+		if err != nil {
+			s.logger.Warn(ctx, "failed_to_read_directory", "path", dir, "error", err)
+			continue
+		}
+		emptied := true
+		for _, entry := range entries {
+			if !removed[filepath.Join(dir, entry.Name())] {
+				emptied = false
+				break
+			}
+		}
+		dirPath := NewFilePath(dir)
+		if !emptied || !dirPath.IsOk() {
+			continue
+		}
+		removed[dir] = true
+		id := OperationID(fmt.Sprintf("unmanage-prune-%s", strings.TrimPrefix(dir, s.targetDir+string(filepath.Separator))))
+		operations = append(operations, NewDirDelete(id, dirPath.Unwrap()))
+	}
+	return operations
+}
+
 // isPackageOrphaned checks if a package is orphaned (has no valid links or missing package directory).
 func (s *UnmanageService) isPackageOrphaned(ctx context.Context, pkg string, pkgInfo manifest.PackageInfo) bool {
 	// Check if package directory exists
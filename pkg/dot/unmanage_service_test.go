@@ -41,7 +41,7 @@ func TestUnmanageService_Unmanage(t *testing.T) {
 		manifestStore := manifest.NewFSManifestStore(fs)
 		manifestSvc := newManifestService(fs, adapters.NewNoopLogger(), manifestStore)
 		unmanageSvc := newUnmanageService(fs, adapters.NewNoopLogger(), exec, manifestSvc, packageDir, targetDir, false)
-		manageSvc := newManageService(fs, adapters.NewNoopLogger(), managePipe, exec, manifestSvc, unmanageSvc, packageDir, targetDir, false)
+		manageSvc := newManageService(fs, adapters.NewNoopLogger(), managePipe, exec, manifestSvc, unmanageSvc, nil, packageDir, targetDir, false)
 
 		err := manageSvc.Manage(ctx, "test-pkg")
 		require.NoError(t, err)
@@ -105,7 +105,7 @@ func TestUnmanageService_PlanUnmanage(t *testing.T) {
 		manifestStore := manifest.NewFSManifestStore(fs)
 		manifestSvc := newManifestService(fs, adapters.NewNoopLogger(), manifestStore)
 		unmanageSvc := newUnmanageService(fs, adapters.NewNoopLogger(), exec, manifestSvc, packageDir, targetDir, false)
-		manageSvc := newManageService(fs, adapters.NewNoopLogger(), managePipe, exec, manifestSvc, unmanageSvc, packageDir, targetDir, false)
+		manageSvc := newManageService(fs, adapters.NewNoopLogger(), managePipe, exec, manifestSvc, unmanageSvc, nil, packageDir, targetDir, false)
 
 		err := manageSvc.Manage(ctx, "test-pkg")
 		require.NoError(t, err)
@@ -143,7 +143,7 @@ func TestUnmanageService_PlanUnmanage(t *testing.T) {
 		manifestStore := manifest.NewFSManifestStore(fs)
 		manifestSvc := newManifestService(fs, adapters.NewNoopLogger(), manifestStore)
 		unmanageSvc := newUnmanageService(fs, adapters.NewNoopLogger(), exec, manifestSvc, packageDir, targetDir, false)
-		manageSvc := newManageService(fs, adapters.NewNoopLogger(), managePipe, exec, manifestSvc, unmanageSvc, packageDir, targetDir, false)
+		manageSvc := newManageService(fs, adapters.NewNoopLogger(), managePipe, exec, manifestSvc, unmanageSvc, nil, packageDir, targetDir, false)
 
 		// Manage both
 		require.NoError(t, manageSvc.Manage(ctx, "pkg1", "pkg2"))
@@ -206,7 +206,7 @@ func TestUnmanageService_UnmanageAll(t *testing.T) {
 		manifestStore := manifest.NewFSManifestStore(fs)
 		manifestSvc := newManifestService(fs, adapters.NewNoopLogger(), manifestStore)
 		unmanageSvc := newUnmanageService(fs, adapters.NewNoopLogger(), exec, manifestSvc, packageDir, targetDir, false)
-		manageSvc := newManageService(fs, adapters.NewNoopLogger(), managePipe, exec, manifestSvc, unmanageSvc, packageDir, targetDir, false)
+		manageSvc := newManageService(fs, adapters.NewNoopLogger(), managePipe, exec, manifestSvc, unmanageSvc, nil, packageDir, targetDir, false)
 
 		// Manage both packages
 		require.NoError(t, manageSvc.Manage(ctx, "pkg1", "pkg2"))
@@ -273,7 +273,7 @@ func TestUnmanageService_UnmanageAll(t *testing.T) {
 		manifestStore := manifest.NewFSManifestStore(fs)
 		manifestSvc := newManifestService(fs, adapters.NewNoopLogger(), manifestStore)
 		unmanageSvc := newUnmanageService(fs, adapters.NewNoopLogger(), exec, manifestSvc, packageDir, targetDir, true) // dry-run=true
-		manageSvc := newManageService(fs, adapters.NewNoopLogger(), managePipe, exec, manifestSvc, unmanageSvc, packageDir, targetDir, false)
+		manageSvc := newManageService(fs, adapters.NewNoopLogger(), managePipe, exec, manifestSvc, unmanageSvc, nil, packageDir, targetDir, false)
 
 		// Manage package
 		require.NoError(t, manageSvc.Manage(ctx, "test-pkg"))
@@ -314,7 +314,7 @@ func TestUnmanageService_UnmanageAll(t *testing.T) {
 		manifestStore := manifest.NewFSManifestStore(fs)
 		manifestSvc := newManifestService(fs, adapters.NewNoopLogger(), manifestStore)
 		unmanageSvc := newUnmanageService(fs, adapters.NewNoopLogger(), exec, manifestSvc, packageDir, targetDir, false)
-		manageSvc := newManageService(fs, adapters.NewNoopLogger(), managePipe, exec, manifestSvc, unmanageSvc, packageDir, targetDir, false)
+		manageSvc := newManageService(fs, adapters.NewNoopLogger(), managePipe, exec, manifestSvc, unmanageSvc, nil, packageDir, targetDir, false)
 
 		// Manage package first
 		require.NoError(t, manageSvc.Manage(ctx, "test-pkg"))